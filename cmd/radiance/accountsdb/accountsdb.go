@@ -0,0 +1,19 @@
+package accountsdb
+
+import (
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/radiance/accountsdb/get"
+	"go.firedancer.io/radiance/cmd/radiance/accountsdb/scan"
+)
+
+var Cmd = cobra.Command{
+	Use:   "accountsdb",
+	Short: "Query the accounts of a snapshot",
+}
+
+func init() {
+	Cmd.AddCommand(
+		&get.Cmd,
+		&scan.Cmd,
+	)
+}
@@ -0,0 +1,67 @@
+package get
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/radiance/accountsdb/util"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "get <pubkey>...",
+	Short: "Print accounts of a snapshot",
+	Args:  cobra.MinimumNArgs(1),
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagSnapshot = flags.String("snapshot", "", "Path to a full snapshot archive (snapshot-<slot>-<hash>.tar.zst)")
+	flagData     = flags.String("data", "none", "Encoding to print account data in ("+strings.Join(util.DataEncodings, ", ")+")")
+)
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(_ *cobra.Command, args []string) {
+	if *flagSnapshot == "" {
+		klog.Exit("No snapshot given")
+	}
+	pubkeys := make(map[[32]byte]struct{}, len(args))
+	for _, arg := range args {
+		pubkey, err := base58.DecodeFromString(arg)
+		if err != nil {
+			klog.Exitf("Invalid pubkey %s: %s", arg, err)
+		}
+		pubkeys[pubkey] = struct{}{}
+	}
+
+	manifest, accts, err := util.LoadSnapshot(*flagSnapshot, func(pubkey [32]byte, _ *accounts.Account) bool {
+		_, ok := pubkeys[pubkey]
+		return ok
+	})
+	if err != nil {
+		klog.Exitf("Failed to load snapshot: %s", err)
+	}
+	klog.Infof("Loaded snapshot of slot %d", manifest.Bank.Slot)
+
+	for i, arg := range args {
+		pubkey, _ := base58.DecodeFromString(arg)
+		acct, ok := accts[pubkey]
+		if !ok {
+			klog.Errorf("Account %s does not exist", arg)
+			continue
+		}
+		if i != 0 {
+			os.Stdout.WriteString("\n")
+		}
+		if err := util.PrintAccount(os.Stdout, pubkey, acct, *flagData); err != nil {
+			klog.Exit(err)
+		}
+	}
+}
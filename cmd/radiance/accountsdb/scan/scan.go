@@ -0,0 +1,64 @@
+package scan
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/radiance/accountsdb/util"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "scan",
+	Short: "Print the accounts of a snapshot owned by a program",
+	Args:  cobra.NoArgs,
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagSnapshot = flags.String("snapshot", "", "Path to a full snapshot archive (snapshot-<slot>-<hash>.tar.zst)")
+	flagOwner    = flags.String("owner", "", "Owner of the accounts to print")
+	flagData     = flags.String("data", "none", "Encoding to print account data in ("+strings.Join(util.DataEncodings, ", ")+")")
+	flagLimit    = flags.Int("limit", 0, "Print at most N accounts (0 for all)")
+)
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(_ *cobra.Command, _ []string) {
+	if *flagSnapshot == "" {
+		klog.Exit("No snapshot given")
+	}
+	if *flagOwner == "" {
+		klog.Exit("No owner given")
+	}
+	owner, err := base58.DecodeFromString(*flagOwner)
+	if err != nil {
+		klog.Exitf("Invalid owner %s: %s", *flagOwner, err)
+	}
+
+	manifest, accts, err := util.LoadSnapshot(*flagSnapshot, func(_ [32]byte, acct *accounts.Account) bool {
+		return acct.Owner == owner
+	})
+	if err != nil {
+		klog.Exitf("Failed to load snapshot: %s", err)
+	}
+	klog.Infof("Loaded snapshot of slot %d: %d accounts owned by %s", manifest.Bank.Slot, len(accts), *flagOwner)
+
+	for i, pubkey := range util.SortedPubkeys(accts) {
+		if *flagLimit != 0 && i >= *flagLimit {
+			break
+		}
+		if i != 0 {
+			os.Stdout.WriteString("\n")
+		}
+		if err := util.PrintAccount(os.Stdout, pubkey, accts[pubkey], *flagData); err != nil {
+			klog.Exit(err)
+		}
+	}
+}
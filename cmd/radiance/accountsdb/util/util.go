@@ -0,0 +1,80 @@
+// Package util loads and prints the accounts of snapshots for the
+// accountsdb commands.
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+// DataEncodings are the encodings the data of accounts may be printed in.
+var DataEncodings = []string{"none", "hex", "base64"}
+
+// filterAccounts keeps the accounts of a snapshot that match a filter, so
+// that a query does not hold all accounts in memory.
+type filterAccounts struct {
+	accounts.MemAccounts
+	keep func(pubkey [32]byte, acct *accounts.Account) bool
+}
+
+func (f filterAccounts) SetAccount(pubkey *[32]byte, acct *accounts.Account) error {
+	// an account is set once per newer version, which may no longer match
+	if acct.Lamports != 0 && f.keep(*pubkey, acct) {
+		f.Map[*pubkey] = acct
+	} else {
+		delete(f.Map, *pubkey)
+	}
+	return nil
+}
+
+// LoadSnapshot reads a full snapshot archive, and returns its manifest and
+// those of its accounts for which keep returns true.
+func LoadSnapshot(fpath string, keep func(pubkey [32]byte, acct *accounts.Account) bool) (*snapshot.Manifest, map[[32]byte]*accounts.Account, error) {
+	accts := filterAccounts{MemAccounts: accounts.NewMemAccounts(), keep: keep}
+	manifest, err := snapshot.LoadArchiveFromFile(fpath, accts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, accts.Map, nil
+}
+
+// SortedPubkeys returns the pubkeys of the given accounts in order.
+func SortedPubkeys(accts map[[32]byte]*accounts.Account) [][32]byte {
+	pubkeys := make([][32]byte, 0, len(accts))
+	for pubkey := range accts {
+		pubkeys = append(pubkeys, pubkey)
+	}
+	sort.Slice(pubkeys, func(i, j int) bool {
+		return bytes.Compare(pubkeys[i][:], pubkeys[j][:]) < 0
+	})
+	return pubkeys
+}
+
+// PrintAccount prints an account, with its data in the given encoding, one
+// of DataEncodings.
+func PrintAccount(w io.Writer, pubkey [32]byte, acct *accounts.Account, dataEncoding string) error {
+	fmt.Fprintf(w, "pubkey:     %s\n", base58.Encode(pubkey[:]))
+	fmt.Fprintf(w, "lamports:   %d\n", acct.Lamports)
+	fmt.Fprintf(w, "owner:      %s\n", base58.Encode(acct.Owner[:]))
+	fmt.Fprintf(w, "executable: %t\n", acct.Executable)
+	fmt.Fprintf(w, "rent_epoch: %d\n", acct.RentEpoch)
+	fmt.Fprintf(w, "data_len:   %d\n", len(acct.Data))
+	switch dataEncoding {
+	case "", "none":
+	case "hex":
+		fmt.Fprintf(w, "data:       %s\n", hex.EncodeToString(acct.Data))
+	case "base64":
+		fmt.Fprintf(w, "data:       %s\n", base64.StdEncoding.EncodeToString(acct.Data))
+	default:
+		return fmt.Errorf("unknown data encoding %q", dataEncoding)
+	}
+	return nil
+}
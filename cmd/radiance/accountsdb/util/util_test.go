@@ -0,0 +1,41 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func TestFilterAccounts(t *testing.T) {
+	owner := [32]byte{9}
+	accts := filterAccounts{
+		MemAccounts: accounts.NewMemAccounts(),
+		keep: func(_ [32]byte, acct *accounts.Account) bool {
+			return acct.Owner == owner
+		},
+	}
+	require.NoError(t, accts.SetAccount(&[32]byte{1}, &accounts.Account{Lamports: 1, Owner: owner}))
+	require.NoError(t, accts.SetAccount(&[32]byte{2}, &accounts.Account{Lamports: 1}))
+	require.NoError(t, accts.SetAccount(&[32]byte{3}, &accounts.Account{Lamports: 1, Owner: owner}))
+	// newer versions that no longer match, or are deleted, are dropped
+	require.NoError(t, accts.SetAccount(&[32]byte{3}, &accounts.Account{}))
+	assert.Equal(t, [][32]byte{{1}}, SortedPubkeys(accts.Map))
+}
+
+func TestPrintAccount(t *testing.T) {
+	var buf bytes.Buffer
+	acct := &accounts.Account{Lamports: 10, Data: []byte{0xab, 0xcd}, RentEpoch: 3}
+	require.NoError(t, PrintAccount(&buf, [32]byte{}, acct, "hex"))
+	assert.Equal(t, "pubkey:     11111111111111111111111111111111\n"+
+		"lamports:   10\n"+
+		"owner:      11111111111111111111111111111111\n"+
+		"executable: false\n"+
+		"rent_epoch: 3\n"+
+		"data_len:   2\n"+
+		"data:       abcd\n", buf.String())
+
+	assert.Error(t, PrintAccount(&buf, [32]byte{}, acct, "yaml"))
+}
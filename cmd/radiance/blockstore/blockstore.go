@@ -7,8 +7,14 @@ import (
 	"go.firedancer.io/radiance/cmd/radiance/blockstore/compact"
 	"go.firedancer.io/radiance/cmd/radiance/blockstore/dumpbatches"
 	"go.firedancer.io/radiance/cmd/radiance/blockstore/dumpshreds"
+	"go.firedancer.io/radiance/cmd/radiance/blockstore/exportcar"
+	"go.firedancer.io/radiance/cmd/radiance/blockstore/exportparquet"
+	"go.firedancer.io/radiance/cmd/radiance/blockstore/exportslice"
+	"go.firedancer.io/radiance/cmd/radiance/blockstore/indexaddrsigs"
+	"go.firedancer.io/radiance/cmd/radiance/blockstore/purge"
 	"go.firedancer.io/radiance/cmd/radiance/blockstore/statdatarate"
 	"go.firedancer.io/radiance/cmd/radiance/blockstore/statentries"
+	"go.firedancer.io/radiance/cmd/radiance/blockstore/stats"
 	"go.firedancer.io/radiance/cmd/radiance/blockstore/verifydata"
 	"go.firedancer.io/radiance/cmd/radiance/blockstore/yaml"
 )
@@ -23,8 +29,14 @@ func init() {
 		&compact.Cmd,
 		&dumpshreds.Cmd,
 		&dumpbatches.Cmd,
+		&exportcar.Cmd,
+		&exportparquet.Cmd,
+		&exportslice.Cmd,
+		&indexaddrsigs.Cmd,
+		&purge.Cmd,
 		&statdatarate.Cmd,
 		&statentries.Cmd,
+		&stats.Cmd,
 		&verifydata.Cmd,
 		&yaml.Cmd,
 	)
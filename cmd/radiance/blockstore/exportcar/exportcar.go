@@ -0,0 +1,111 @@
+//go:build !lite
+
+package exportcar
+
+import (
+	"bufio"
+	"os"
+	"time"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/car"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "export-car <rocksdb> <out.car>",
+	Short: "Export the blocks of an epoch to a CAR file",
+	Long: "Writes the rooted blocks of an epoch to an IPLD CAR file in the old-faithful format,\n" +
+		"with the epoch as the root, followed by subsets of blocks, each block\n" +
+		"following its entries and transactions.\n" +
+		"\n" +
+		"Transaction status metas, rewards and block times are left empty.",
+	Args: cobra.ExactArgs(2),
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagEpoch         = flags.Uint64("epoch", 0, "Epoch to export")
+	flagSlotsPerEpoch = flags.Uint64("slots-per-epoch", 432000, "Slots per epoch")
+	flagSubsetSize    = flags.Int("subset-size", 10, "Blocks per subset")
+	flagStatIvl       = flags.Duration("stat-interval", 5*time.Second, "Stats interval")
+)
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(_ *cobra.Command, args []string) {
+	start := time.Now()
+
+	db, err := blockstore.OpenReadOnly(args[0])
+	if err != nil {
+		klog.Exitf("Failed to open blockstore: %s", err)
+	}
+	defer db.Close()
+
+	f, err := os.Create(args[1])
+	if err != nil {
+		klog.Exit(err)
+	}
+	defer f.Close()
+	out := bufio.NewWriterSize(f, 1<<20)
+
+	// the root is filled in once the epoch is written
+	w, err := car.NewWriter(out, car.CID{})
+	if err != nil {
+		klog.Exit(err)
+	}
+	epoch := car.NewEpochWriter(w, *flagEpoch, *flagSubsetSize)
+
+	firstSlot := *flagEpoch * *flagSlotsPerEpoch
+	stopSlot := firstSlot + *flagSlotsPerEpoch
+	klog.Infof("Exporting epoch %d, slots [%d:%d)", *flagEpoch, firstSlot, stopSlot)
+
+	iter := db.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), db.CfRoot)
+	defer iter.Close()
+	key := blockstore.MakeSlotKey(firstSlot)
+	var numBlocks int
+	lastStats := time.Now()
+	for iter.Seek(key[:]); iter.Valid(); iter.Next() {
+		slot, ok := blockstore.ParseSlotKey(iter.Key().Data())
+		if !ok {
+			klog.Exitf("Invalid root key: %x", iter.Key().Data())
+		}
+		if slot >= stopSlot {
+			break
+		}
+		block, err := db.GetBlock(slot)
+		if err != nil {
+			klog.Exitf("Cannot read block %d: %s", slot, err)
+		}
+		if err := epoch.WriteBlock(block, &car.BlockMeta{ParentSlot: block.ParentSlot}); err != nil {
+			klog.Exitf("Cannot write block %d: %s", slot, err)
+		}
+		numBlocks++
+		if *flagStatIvl > 0 && time.Since(lastStats) >= *flagStatIvl {
+			klog.Infof("[stats] slot=%d blocks=%d bytes=%d", slot, numBlocks, w.Written())
+			lastStats = time.Now()
+		}
+	}
+
+	root, err := epoch.Finish()
+	if err != nil {
+		klog.Exit(err)
+	}
+	if err := out.Flush(); err != nil {
+		klog.Exit(err)
+	}
+	if _, err := f.WriteAt(car.EncodeHeader(root), 0); err != nil {
+		klog.Exit(err)
+	}
+	if err := f.Close(); err != nil {
+		klog.Exit(err)
+	}
+
+	klog.Infof("Wrote %d blocks (%d bytes) with root %s", numBlocks, w.Written(), root)
+	klog.Infof("Time taken: %s", time.Since(start))
+}
@@ -0,0 +1,233 @@
+//go:build !lite
+
+package exportparquet
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/parquet"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "export-parquet <rocksdb> <out-dir>",
+	Short: "Export transactions as Parquet datasets",
+	Long: "Exports the executed transactions of the full slots in [start, end], their\n" +
+		"instructions and lamport balance changes, as the Parquet datasets\n" +
+		"<out-dir>/{transactions,instructions,balance_changes}, of which each file\n" +
+		"holds a range of --partition-size slots, named <first>-<last>.parquet.\n" +
+		"Transactions without a status are skipped.",
+	Args: cobra.ExactArgs(2),
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagStart         = flags.Uint64("start", 0, "First slot to export")
+	flagEnd           = flags.Uint64("end", 0, "Last slot to export (required)")
+	flagPartitionSize = flags.Uint64("partition-size", 100000, "Number of slots of each file")
+	flagCompression   = flags.String("compression", "zstd", "Page compression (zstd, none)")
+	flagStatIvl       = flags.Duration("stat-interval", 5*time.Second, "Stats interval")
+)
+
+func init() {
+	Cmd.MarkFlagRequired("end")
+	Cmd.Run = run
+}
+
+func run(_ *cobra.Command, args []string) {
+	if *flagEnd < *flagStart {
+		klog.Exitf("Invalid slot range [%d, %d]", *flagStart, *flagEnd)
+	}
+	if *flagPartitionSize == 0 {
+		klog.Exit("Partition size must be positive")
+	}
+	var codec parquet.Codec
+	switch *flagCompression {
+	case "zstd":
+		codec = parquet.Zstd
+	case "none":
+		codec = parquet.Uncompressed
+	default:
+		klog.Exitf("Unsupported compression %q", *flagCompression)
+	}
+
+	db, err := blockstore.OpenReadOnly(args[0])
+	if err != nil {
+		klog.Exitf("Failed to open blockstore: %s", err)
+	}
+	defer db.Close()
+
+	outDir := args[1]
+	for _, name := range datasets {
+		if err := os.MkdirAll(filepath.Join(outDir, name), 0o755); err != nil {
+			klog.Exit(err)
+		}
+	}
+
+	iter := db.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), db.CfMeta)
+	defer iter.Close()
+
+	var part *partition
+	var numSlots, numTxs int
+	lastStat := time.Now()
+	start := blockstore.MakeSlotKey(*flagStart)
+	for iter.Seek(start[:]); iter.Valid(); iter.Next() {
+		slot, ok := blockstore.ParseSlotKey(iter.Key().Data())
+		if !ok {
+			continue
+		}
+		if slot > *flagEnd {
+			break
+		}
+		meta, err := blockstore.ParseBincode[blockstore.SlotMeta](iter.Value().Data())
+		if err != nil {
+			klog.Warningf("slot %d: invalid meta: %s", slot, err)
+			continue
+		}
+		if !meta.IsFull() {
+			continue
+		}
+
+		if part == nil || slot > part.end {
+			if part != nil {
+				if err := part.close(); err != nil {
+					klog.Exitf("Failed to write partition: %s", err)
+				}
+			}
+			partStart, partEnd := partitionRange(slot, *flagPartitionSize)
+			if part, err = createPartition(outDir, partStart, partEnd, codec); err != nil {
+				klog.Exitf("Failed to create partition: %s", err)
+			}
+		}
+
+		n, err := exportSlot(db, part, slot)
+		if err != nil {
+			klog.Exitf("Failed to export slot %d: %s", slot, err)
+		}
+		numSlots++
+		numTxs += n
+		if *flagStatIvl > 0 && time.Since(lastStat) >= *flagStatIvl {
+			klog.Infof("[stats] slot=%d slots=%d txs=%d", slot, numSlots, numTxs)
+			lastStat = time.Now()
+		}
+	}
+	if err := iter.Err(); err != nil {
+		klog.Exitf("Failed to iterate slots: %s", err)
+	}
+	if part != nil {
+		if err := part.close(); err != nil {
+			klog.Exitf("Failed to write partition: %s", err)
+		}
+	}
+
+	klog.Infof("Exported %d transactions of %d slots", numTxs, numSlots)
+}
+
+// exportSlot appends the rows of the transactions of a full slot to a
+// partition, and returns the number of transactions exported.
+func exportSlot(db *blockstore.DB, part *partition, slot uint64) (int, error) {
+	block, err := db.GetBlock(slot)
+	if err != nil {
+		return 0, err
+	}
+	blockTime, err := db.GetBlockTime(slot)
+	if err != nil && !errors.Is(err, blockstore.ErrNotFound) {
+		return 0, err
+	}
+
+	var numTxs int
+	for i, tx := range block.Transactions() {
+		if len(tx.Signatures) == 0 {
+			continue
+		}
+		meta, err := db.GetTransactionStatusInSlot(tx.Signatures[0], slot)
+		if errors.Is(err, blockstore.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		tx := tx
+		r := &txRecord{slot: slot, blockTime: blockTime, index: i, tx: &tx, meta: meta}
+		if err := part.writers[datasetTransactions].Append(r.transactionRow()...); err != nil {
+			return 0, err
+		}
+		for _, row := range r.instructionRows() {
+			if err := part.writers[datasetInstructions].Append(row...); err != nil {
+				return 0, err
+			}
+		}
+		for _, row := range r.balanceChangeRows() {
+			if err := part.writers[datasetBalanceChanges].Append(row...); err != nil {
+				return 0, err
+			}
+		}
+		numTxs++
+	}
+	return numTxs, nil
+}
+
+// partition is the files of each dataset holding a slot range.
+type partition struct {
+	start, end uint64
+	files      map[string]*os.File
+	bufs       map[string]*bufio.Writer
+	writers    map[string]*parquet.Writer
+}
+
+func createPartition(outDir string, start, end uint64, codec parquet.Codec) (*partition, error) {
+	p := &partition{
+		start:   start,
+		end:     end,
+		files:   make(map[string]*os.File),
+		bufs:    make(map[string]*bufio.Writer),
+		writers: make(map[string]*parquet.Writer),
+	}
+	for _, name := range datasets {
+		path := filepath.Join(outDir, name, fmt.Sprintf("%d-%d.parquet", start, end))
+		f, err := os.Create(path)
+		if err != nil {
+			p.abort()
+			return nil, err
+		}
+		p.files[name] = f
+		p.bufs[name] = bufio.NewWriter(f)
+		if p.writers[name], err = parquet.NewWriter(p.bufs[name], schemas[name], codec); err != nil {
+			p.abort()
+			return nil, err
+		}
+	}
+	klog.Infof("Writing slots [%d, %d]", start, end)
+	return p, nil
+}
+
+func (p *partition) abort() {
+	for _, f := range p.files {
+		f.Close()
+	}
+}
+
+// close writes the footers of the files of the partition, and closes them.
+func (p *partition) close() error {
+	defer p.abort()
+	for _, name := range datasets {
+		if err := p.writers[name].Close(); err != nil {
+			return err
+		}
+		if err := p.bufs[name].Flush(); err != nil {
+			return err
+		}
+		if err := p.files[name].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package exportparquet
+
+import (
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/parquet"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// Datasets exported, each to a directory of Parquet files.
+const (
+	datasetTransactions   = "transactions"
+	datasetInstructions   = "instructions"
+	datasetBalanceChanges = "balance_changes"
+)
+
+var datasets = []string{datasetTransactions, datasetInstructions, datasetBalanceChanges}
+
+var schemas = map[string][]parquet.Column{
+	datasetTransactions: {
+		{Name: "slot", Type: parquet.Uint64},
+		{Name: "block_time", Type: parquet.Int64},
+		{Name: "index", Type: parquet.Int32},
+		{Name: "signature", Type: parquet.String},
+		{Name: "fee_payer", Type: parquet.String},
+		{Name: "fee", Type: parquet.Uint64},
+		{Name: "success", Type: parquet.Boolean},
+		{Name: "err", Type: parquet.String},
+		{Name: "compute_units", Type: parquet.Uint64},
+		{Name: "num_signatures", Type: parquet.Int32},
+		{Name: "num_instructions", Type: parquet.Int32},
+		{Name: "num_accounts", Type: parquet.Int32},
+	},
+	datasetInstructions: {
+		{Name: "slot", Type: parquet.Uint64},
+		{Name: "tx_index", Type: parquet.Int32},
+		{Name: "signature", Type: parquet.String},
+		{Name: "instruction_index", Type: parquet.Int32},
+		{Name: "inner_index", Type: parquet.Int32},
+		{Name: "stack_height", Type: parquet.Int32},
+		{Name: "program_id", Type: parquet.String},
+		{Name: "accounts", Type: parquet.String},
+		{Name: "data", Type: parquet.Bytes},
+	},
+	datasetBalanceChanges: {
+		{Name: "slot", Type: parquet.Uint64},
+		{Name: "tx_index", Type: parquet.Int32},
+		{Name: "signature", Type: parquet.String},
+		{Name: "account", Type: parquet.String},
+		{Name: "pre_balance", Type: parquet.Uint64},
+		{Name: "post_balance", Type: parquet.Uint64},
+		{Name: "delta", Type: parquet.Int64},
+	},
+}
+
+// txRecord is an executed transaction of a block.
+type txRecord struct {
+	slot      uint64
+	blockTime int64
+	index     int
+	tx        *solana.Transaction
+	meta      *sealevel.TransactionStatusMeta
+}
+
+// accountKeys returns the account keys of the transaction, followed by the
+// addresses it loaded from lookup tables, in the order in which
+// instructions and balances index them.
+func (r *txRecord) accountKeys() []solana.PublicKey {
+	keys := make([]solana.PublicKey, 0, len(r.tx.Message.AccountKeys)+
+		len(r.meta.LoadedAddresses.Writable)+len(r.meta.LoadedAddresses.Readonly))
+	keys = append(keys, r.tx.Message.AccountKeys...)
+	keys = append(keys, r.meta.LoadedAddresses.Writable...)
+	keys = append(keys, r.meta.LoadedAddresses.Readonly...)
+	return keys
+}
+
+func (r *txRecord) signature() string {
+	if len(r.tx.Signatures) == 0 {
+		return ""
+	}
+	return r.tx.Signatures[0].String()
+}
+
+// transactionRow returns the row of the transactions dataset.
+func (r *txRecord) transactionRow() []interface{} {
+	var feePayer, errStr string
+	if len(r.tx.Message.AccountKeys) > 0 {
+		feePayer = r.tx.Message.AccountKeys[0].String()
+	}
+	if r.meta.Err != nil {
+		errStr = r.meta.Err.Error()
+	}
+	return []interface{}{
+		r.slot,
+		r.blockTime,
+		int32(r.index),
+		r.signature(),
+		feePayer,
+		r.meta.Fee,
+		r.meta.Err == nil,
+		errStr,
+		r.meta.ComputeUnitsConsumed,
+		int32(len(r.tx.Signatures)),
+		int32(len(r.tx.Message.Instructions)),
+		int32(len(r.accountKeys())),
+	}
+}
+
+// instructionRows returns the rows of the instructions dataset: each
+// top-level instruction, with an inner index of -1, followed by the inner
+// instructions it invoked.
+func (r *txRecord) instructionRows() [][]interface{} {
+	keys := r.accountKeys()
+	sig := r.signature()
+	inner := make(map[int][]sealevel.InnerInstruction)
+	for _, ii := range r.meta.InnerInstructions {
+		inner[int(ii.Index)] = append(inner[int(ii.Index)], ii.Instructions...)
+	}
+
+	var rows [][]interface{}
+	row := func(index, innerIndex int, stackHeight uint32, instr *solana.CompiledInstruction) []interface{} {
+		accounts := make([]string, len(instr.Accounts))
+		for i, idx := range instr.Accounts {
+			accounts[i] = keyAt(keys, idx)
+		}
+		return []interface{}{
+			r.slot,
+			int32(r.index),
+			sig,
+			int32(index),
+			int32(innerIndex),
+			int32(stackHeight),
+			keyAt(keys, instr.ProgramIDIndex),
+			strings.Join(accounts, ","),
+			[]byte(instr.Data),
+		}
+	}
+	for i := range r.tx.Message.Instructions {
+		rows = append(rows, row(i, -1, 1, &r.tx.Message.Instructions[i]))
+		for j := range inner[i] {
+			rows = append(rows, row(i, j, inner[i][j].StackHeight, &inner[i][j].Instruction))
+		}
+	}
+	return rows
+}
+
+// balanceChangeRows returns the rows of the balance changes dataset, one
+// for each account of which the transaction changed the lamport balance.
+func (r *txRecord) balanceChangeRows() [][]interface{} {
+	keys := r.accountKeys()
+	sig := r.signature()
+	var rows [][]interface{}
+	for i := 0; i < len(r.meta.PreBalances) && i < len(r.meta.PostBalances); i++ {
+		pre, post := r.meta.PreBalances[i], r.meta.PostBalances[i]
+		if pre == post {
+			continue
+		}
+		rows = append(rows, []interface{}{
+			r.slot,
+			int32(r.index),
+			sig,
+			keyAt(keys, uint16(i)),
+			pre,
+			post,
+			int64(post - pre),
+		})
+	}
+	return rows
+}
+
+// keyAt returns the base58 account key at an index, or an empty string if
+// the index is out of range.
+func keyAt(keys []solana.PublicKey, idx uint16) string {
+	if int(idx) >= len(keys) {
+		return ""
+	}
+	return keys[idx].String()
+}
+
+// partitionRange returns the slot range [start, end] of the partition of
+// the given size containing a slot.
+func partitionRange(slot, size uint64) (start, end uint64) {
+	start = slot - slot%size
+	end = start + size - 1
+	if end < start {
+		end = ^uint64(0)
+	}
+	return start, end
+}
@@ -0,0 +1,99 @@
+package exportparquet
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func testRecord() *txRecord {
+	payer := solana.PublicKey{1}
+	dest := solana.PublicKey{2}
+	program := solana.PublicKey{3}
+	loaded := solana.PublicKey{4}
+	tx := &solana.Transaction{
+		Signatures: []solana.Signature{{9}},
+		Message: solana.Message{
+			AccountKeys: []solana.PublicKey{payer, dest, program},
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 2, Accounts: []uint16{0, 3}, Data: []byte{7}},
+				{ProgramIDIndex: 2, Accounts: []uint16{1}},
+			},
+		},
+	}
+	meta := &sealevel.TransactionStatusMeta{
+		Err:          errors.New("custom program error: 0x1"),
+		Fee:          5000,
+		PreBalances:  []uint64{100000, 10, 1, 0},
+		PostBalances: []uint64{90000, 5010, 1, 0},
+		InnerInstructions: []sealevel.InnerInstructions{{
+			Index: 0,
+			Instructions: []sealevel.InnerInstruction{
+				{Instruction: solana.CompiledInstruction{ProgramIDIndex: 2, Accounts: []uint16{3}}, StackHeight: 2},
+			},
+		}},
+		LoadedAddresses:      sealevel.LoadedAddresses{Writable: []solana.PublicKey{loaded}},
+		ComputeUnitsConsumed: 300,
+	}
+	return &txRecord{slot: 42, blockTime: 1700000000, index: 3, tx: tx, meta: meta}
+}
+
+func TestTransactionRow(t *testing.T) {
+	r := testRecord()
+	row := r.transactionRow()
+	assert.Len(t, row, len(schemas[datasetTransactions]))
+	assert.Equal(t, []interface{}{
+		uint64(42),
+		int64(1700000000),
+		int32(3),
+		solana.Signature{9}.String(),
+		solana.PublicKey{1}.String(),
+		uint64(5000),
+		false,
+		"custom program error: 0x1",
+		uint64(300),
+		int32(1),
+		int32(2),
+		int32(4),
+	}, row)
+}
+
+func TestInstructionRows(t *testing.T) {
+	r := testRecord()
+	rows := r.instructionRows()
+	assert.Len(t, rows, 3)
+	for _, row := range rows {
+		assert.Len(t, row, len(schemas[datasetInstructions]))
+	}
+	program := solana.PublicKey{3}.String()
+	accounts := solana.PublicKey{1}.String() + "," + solana.PublicKey{4}.String()
+	assert.Equal(t, []interface{}{uint64(42), int32(3), solana.Signature{9}.String(), int32(0), int32(-1), int32(1), program, accounts, []byte{7}}, rows[0])
+	assert.Equal(t, int32(0), rows[1][3])
+	assert.Equal(t, int32(0), rows[1][4])
+	assert.Equal(t, int32(2), rows[1][5])
+	assert.Equal(t, solana.PublicKey{4}.String(), rows[1][7])
+	assert.Equal(t, int32(1), rows[2][3])
+	assert.Equal(t, int32(-1), rows[2][4])
+}
+
+func TestBalanceChangeRows(t *testing.T) {
+	r := testRecord()
+	rows := r.balanceChangeRows()
+	assert.Len(t, rows, 2)
+	assert.Equal(t, []interface{}{uint64(42), int32(3), solana.Signature{9}.String(), solana.PublicKey{1}.String(), uint64(100000), uint64(90000), int64(-10000)}, rows[0])
+	assert.Equal(t, solana.PublicKey{2}.String(), rows[1][3])
+	assert.Equal(t, int64(5000), rows[1][6])
+}
+
+func TestPartitionRange(t *testing.T) {
+	start, end := partitionRange(123456, 100000)
+	assert.Equal(t, uint64(100000), start)
+	assert.Equal(t, uint64(199999), end)
+	start, end = partitionRange(math.MaxUint64, 100000)
+	assert.Equal(t, uint64(math.MaxUint64-math.MaxUint64%100000), start)
+	assert.Equal(t, uint64(math.MaxUint64), end)
+}
@@ -0,0 +1,62 @@
+//go:build !lite
+
+package exportslice
+
+import (
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "export-slice <rocksdb> <out-rocksdb>",
+	Short: "Copy a slot range into a new blockstore",
+	Long: "Copies the data of the slots in [start, end] from every column family\n" +
+		"into a newly created blockstore, e.g. to share a ledger reproducing a bug.",
+	Args: cobra.ExactArgs(2),
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagStart   = flags.Uint64("start", 0, "First slot to copy")
+	flagEnd     = flags.Uint64("end", 0, "Last slot to copy (required)")
+	flagCompact = flags.Bool("compact", true, "Compact the new blockstore")
+)
+
+func init() {
+	Cmd.MarkFlagRequired("end")
+	Cmd.Run = run
+}
+
+func run(_ *cobra.Command, args []string) {
+	if *flagEnd < *flagStart {
+		klog.Exitf("Invalid slot range [%d, %d]", *flagStart, *flagEnd)
+	}
+
+	src, err := blockstore.OpenReadOnly(args[0])
+	if err != nil {
+		klog.Exitf("Failed to open blockstore: %s", err)
+	}
+	defer src.Close()
+
+	dst, err := blockstore.Create(args[1])
+	if err != nil {
+		klog.Exitf("Failed to create blockstore: %s", err)
+	}
+	defer dst.Close()
+
+	klog.Infof("Copying slots [%d, %d]", *flagStart, *flagEnd)
+	if err := src.CopySlots(dst, *flagStart, *flagEnd); err != nil {
+		klog.Exitf("Failed to copy slots: %s", err)
+	}
+	klog.Infof("Copied slots [%d, %d]", *flagStart, *flagEnd)
+
+	if *flagCompact {
+		klog.Infof("Compacting")
+		dst.CompactBefore(*flagEnd + 1)
+		klog.Infof("Compacted")
+	}
+
+	klog.Infof("Done")
+}
@@ -0,0 +1,83 @@
+//go:build !lite
+
+package indexaddrsigs
+
+import (
+	"math"
+	"time"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "index-address-sigs <rocksdb>",
+	Short: "Build the address signatures index from transaction statuses",
+	Long: "Indexes the transactions with a status of each full slot by their account keys,\n" +
+		"building or repairing the address_signatures column family that\n" +
+		"getSignaturesForAddress queries, e.g. of ledgers copied without it.",
+	Args: cobra.ExactArgs(1),
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagStart   = flags.Uint64("start-slot", 0, "First slot to index")
+	flagEnd     = flags.Uint64("end-slot", math.MaxUint64, "Last slot to index")
+	flagStatIvl = flags.Duration("stat-interval", 5*time.Second, "Stats interval")
+)
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(_ *cobra.Command, args []string) {
+	db, err := blockstore.OpenReadWrite(args[0])
+	if err != nil {
+		klog.Exitf("Failed to open blockstore: %s", err)
+	}
+	defer db.Close()
+
+	iter := db.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), db.CfMeta)
+	defer iter.Close()
+
+	var numSlots, numTxs, numFailed int
+	lastStat := time.Now()
+	start := blockstore.MakeSlotKey(*flagStart)
+	for iter.Seek(start[:]); iter.Valid(); iter.Next() {
+		slot, ok := blockstore.ParseSlotKey(iter.Key().Data())
+		if !ok {
+			continue
+		}
+		if slot > *flagEnd {
+			break
+		}
+		meta, err := blockstore.ParseBincode[blockstore.SlotMeta](iter.Value().Data())
+		if err != nil {
+			klog.Warningf("slot %d: invalid meta: %s", slot, err)
+			continue
+		}
+		if !meta.IsFull() {
+			continue
+		}
+		n, err := db.IndexAddressSignatures(slot)
+		if err != nil {
+			klog.Warningf("slot %d: %s", slot, err)
+			numFailed++
+			continue
+		}
+		numSlots++
+		numTxs += n
+		if *flagStatIvl > 0 && time.Since(lastStat) >= *flagStatIvl {
+			klog.Infof("[stats] slot=%d slots=%d txs=%d failed=%d", slot, numSlots, numTxs, numFailed)
+			lastStat = time.Now()
+		}
+	}
+	if err := iter.Err(); err != nil {
+		klog.Exitf("Failed to iterate slots: %s", err)
+	}
+
+	klog.Infof("Indexed %d transactions of %d slots (%d failed)", numTxs, numSlots, numFailed)
+}
@@ -0,0 +1,51 @@
+//go:build !lite
+
+package purge
+
+import (
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "purge <rocksdb>",
+	Short: "Delete blockstore data below a slot",
+	Long: "Deletes the data of all slots below the given slot from every column family,\n" +
+		"then compacts the deleted ranges to reclaim disk space.",
+	Args: cobra.ExactArgs(1),
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagBefore  = flags.Uint64("before", 0, "Delete slots below this slot (required)")
+	flagCompact = flags.Bool("compact", true, "Compact after deleting")
+)
+
+func init() {
+	Cmd.MarkFlagRequired("before")
+	Cmd.Run = run
+}
+
+func run(_ *cobra.Command, args []string) {
+	db, err := blockstore.OpenReadWrite(args[0])
+	if err != nil {
+		klog.Exitf("Failed to open blockstore: %s", err)
+	}
+	defer db.Close()
+
+	klog.Infof("Purging slots below %d", *flagBefore)
+	if err := db.PurgeBefore(*flagBefore); err != nil {
+		klog.Exitf("Failed to purge: %s", err)
+	}
+	klog.Infof("Purged slots below %d", *flagBefore)
+
+	if *flagCompact {
+		klog.Infof("Compacting")
+		db.CompactBefore(*flagBefore)
+		klog.Infof("Compacted")
+	}
+
+	klog.Infof("Done")
+}
@@ -0,0 +1,37 @@
+package stats
+
+import "sort"
+
+// distribution summarizes a list of counts.
+type distribution struct {
+	Count int     `yaml:"count"`
+	Min   uint64  `yaml:"min"`
+	P50   uint64  `yaml:"p50"`
+	P90   uint64  `yaml:"p90"`
+	P99   uint64  `yaml:"p99"`
+	Max   uint64  `yaml:"max"`
+	Mean  float64 `yaml:"mean"`
+}
+
+// summarize returns the distribution of counts, which it sorts.
+func summarize(counts []uint64) (d distribution) {
+	d.Count = len(counts)
+	if len(counts) == 0 {
+		return
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i] < counts[j] })
+	percentile := func(p int) uint64 {
+		return counts[(len(counts)-1)*p/100]
+	}
+	var sum uint64
+	for _, c := range counts {
+		sum += c
+	}
+	d.Min = counts[0]
+	d.P50 = percentile(50)
+	d.P90 = percentile(90)
+	d.P99 = percentile(99)
+	d.Max = counts[len(counts)-1]
+	d.Mean = float64(sum) / float64(len(counts))
+	return
+}
@@ -0,0 +1,25 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	assert.Equal(t, distribution{}, summarize(nil))
+
+	counts := make([]uint64, 100)
+	for i := range counts {
+		counts[i] = uint64(100 - i)
+	}
+	assert.Equal(t, distribution{
+		Count: 100,
+		Min:   1,
+		P50:   50,
+		P90:   90,
+		P99:   99,
+		Max:   100,
+		Mean:  50.5,
+	}, summarize(counts))
+}
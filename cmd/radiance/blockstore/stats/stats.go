@@ -0,0 +1,216 @@
+//go:build !lite
+
+package stats
+
+import (
+	"os"
+	"sort"
+
+	"github.com/linxGnu/grocksdb"
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "stats <rocksdb>",
+	Short: "Report blockstore statistics",
+	Long: "Reports the estimated sizes of each column family, the range of slots,\n" +
+		"the distribution of shreds per slot, dead slots and the coverage of roots,\n" +
+		"as YAML.",
+	Args: cobra.ExactArgs(1),
+}
+
+var flags = Cmd.Flags()
+
+var flagShreds = flags.Bool("shreds", true, "Count shreds per slot (iterates all shreds)")
+
+func init() {
+	Cmd.Run = run
+}
+
+type report struct {
+	ColumnFamilies    []cfStats     `yaml:"column_families"`
+	Slots             slotStats     `yaml:"slots"`
+	DataShredsPerSlot *distribution `yaml:"data_shreds_per_slot,omitempty"`
+	CodeShredsPerSlot *distribution `yaml:"code_shreds_per_slot,omitempty"`
+	DeadSlots         rangeStats    `yaml:"dead_slots"`
+	Roots             rootStats     `yaml:"roots"`
+}
+
+type cfStats struct {
+	Name          string `yaml:"name"`
+	EstimatedKeys uint64 `yaml:"estimated_keys"`
+	LiveDataSize  uint64 `yaml:"live_data_size"`
+	SSTFilesSize  uint64 `yaml:"sst_files_size"`
+}
+
+type slotStats struct {
+	rangeStats `yaml:",inline"`
+	NumFull    int `yaml:"num_full"`
+	NumSkipped int `yaml:"num_skipped"`
+}
+
+type rangeStats struct {
+	Count int    `yaml:"count"`
+	First uint64 `yaml:"first"`
+	Last  uint64 `yaml:"last"`
+}
+
+func (r *rangeStats) add(slot uint64) {
+	if r.Count == 0 {
+		r.First = slot
+	}
+	r.Last = slot
+	r.Count++
+}
+
+type rootStats struct {
+	rangeStats `yaml:",inline"`
+	// NumRootedSlots is the number of slots with metadata that are roots.
+	NumRootedSlots int     `yaml:"num_rooted_slots"`
+	Coverage       float64 `yaml:"coverage"`
+}
+
+func run(_ *cobra.Command, args []string) {
+	db, err := blockstore.OpenReadOnly(args[0])
+	if err != nil {
+		klog.Exitf("Failed to open blockstore: %s", err)
+	}
+	defer db.Close()
+
+	var r report
+	r.ColumnFamilies = columnFamilyStats(db)
+
+	slots := metaStats(db, &r.Slots)
+	r.Roots = rootCoverage(db, slots)
+	if dead := db.ColumnFamilyHandles()[blockstore.CfDeadSlots]; dead != nil {
+		r.DeadSlots = slotKeyStats(db, dead)
+	}
+	if *flagShreds {
+		data := summarize(shredsPerSlot(db, db.CfDataShred))
+		code := summarize(shredsPerSlot(db, db.CfCodeShred))
+		r.DataShredsPerSlot, r.CodeShredsPerSlot = &data, &code
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	defer enc.Close()
+	if err := enc.Encode(&r); err != nil {
+		klog.Exitf("Failed to encode report: %s", err)
+	}
+}
+
+// columnFamilyStats returns the sizes of each column family, as estimated
+// by RocksDB.
+func columnFamilyStats(db *blockstore.DB) []cfStats {
+	handles := db.ColumnFamilyHandles()
+	names := make([]string, 0, len(handles))
+	for name := range handles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	stats := make([]cfStats, len(names))
+	for i, name := range names {
+		cf := handles[name]
+		stats[i].Name = name
+		stats[i].EstimatedKeys, _ = db.DB.GetIntPropertyCF("rocksdb.estimate-num-keys", cf)
+		stats[i].LiveDataSize, _ = db.DB.GetIntPropertyCF("rocksdb.estimate-live-data-size", cf)
+		stats[i].SSTFilesSize, _ = db.DB.GetIntPropertyCF("rocksdb.total-sst-files-size", cf)
+	}
+	return stats
+}
+
+func newScanOptions() *grocksdb.ReadOptions {
+	opts := grocksdb.NewDefaultReadOptions()
+	opts.SetFillCache(false)
+	return opts
+}
+
+// metaStats scans the slot metadata, and returns the slots with metadata
+// in ascending order.
+func metaStats(db *blockstore.DB, stats *slotStats) []uint64 {
+	iter := db.DB.NewIteratorCF(newScanOptions(), db.CfMeta)
+	defer iter.Close()
+
+	var slots []uint64
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		slot, ok := blockstore.ParseSlotKey(iter.Key().Data())
+		if !ok {
+			klog.Warningf("Invalid slot key: %x", iter.Key().Data())
+			continue
+		}
+		stats.add(slot)
+		slots = append(slots, slot)
+		meta, err := blockstore.ParseBincode[blockstore.SlotMeta](iter.Value().Data())
+		if err != nil {
+			klog.Warningf("Invalid meta of slot %d: %s", slot, err)
+			continue
+		}
+		if meta.IsFull() {
+			stats.NumFull++
+		}
+	}
+	if stats.Count > 0 {
+		stats.NumSkipped = int(stats.Last-stats.First+1) - stats.Count
+	}
+	return slots
+}
+
+// slotKeyStats returns the range of the slot keys of a column family.
+func slotKeyStats(db *blockstore.DB, cf *grocksdb.ColumnFamilyHandle) (stats rangeStats) {
+	iter := db.DB.NewIteratorCF(newScanOptions(), cf)
+	defer iter.Close()
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		if slot, ok := blockstore.ParseSlotKey(iter.Key().Data()); ok {
+			stats.add(slot)
+		}
+	}
+	return
+}
+
+// rootCoverage returns the range of roots, and the share of the given
+// slots with metadata that are roots.
+func rootCoverage(db *blockstore.DB, slots []uint64) (stats rootStats) {
+	iter := db.DB.NewIteratorCF(newScanOptions(), db.CfRoot)
+	defer iter.Close()
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		slot, ok := blockstore.ParseSlotKey(iter.Key().Data())
+		if !ok {
+			continue
+		}
+		stats.add(slot)
+		i := sort.Search(len(slots), func(i int) bool { return slots[i] >= slot })
+		if i < len(slots) && slots[i] == slot {
+			stats.NumRootedSlots++
+		}
+	}
+	if len(slots) > 0 {
+		stats.Coverage = float64(stats.NumRootedSlots) / float64(len(slots))
+	}
+	return
+}
+
+// shredsPerSlot returns the number of shreds of each slot in a shred
+// column family, by iterating its keys.
+func shredsPerSlot(db *blockstore.DB, cf *grocksdb.ColumnFamilyHandle) []uint64 {
+	iter := db.DB.NewIteratorCF(newScanOptions(), cf)
+	defer iter.Close()
+
+	var counts []uint64
+	var current uint64
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		slot, _, ok := blockstore.ParseShredKey(iter.Key().Data())
+		if !ok {
+			continue
+		}
+		if len(counts) == 0 || slot != current {
+			counts = append(counts, 0)
+			current = slot
+		}
+		counts[len(counts)-1]++
+	}
+	return counts
+}
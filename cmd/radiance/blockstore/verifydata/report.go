@@ -0,0 +1,120 @@
+package verifydata
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Classes of verification failures.
+const (
+	failInvalidKey      = "invalid_key"
+	failInvalidMeta     = "invalid_meta"
+	failMissingShreds   = "missing_shreds"
+	failInvalidShreds   = "invalid_shreds"
+	failInvalidEntries  = "invalid_entries"
+	failPoh             = "poh"
+	failChainedRoot     = "chained_merkle_root"
+	failDuplicateShreds = "duplicate_shreds"
+	failMetaMismatch    = "meta_mismatch"
+	failShredGap        = "shred_gap"
+)
+
+// failure is a record of a verification failure.
+type failure struct {
+	Slot       uint64  `json:"slot"`
+	ShredIndex *uint64 `json:"shred_index,omitempty"`
+	Class      string  `json:"class"`
+	Key        string  `json:"key,omitempty"`
+	Error      string  `json:"error"`
+}
+
+// failureReport collects the failures of all workers. A nil report
+// discards them.
+type failureReport struct {
+	mu       sync.Mutex
+	failures []failure
+}
+
+func (r *failureReport) add(class string, slot uint64, shredIndex *uint64, key []byte, err error) {
+	if r == nil {
+		return
+	}
+	f := failure{
+		Slot:       slot,
+		ShredIndex: shredIndex,
+		Class:      class,
+		Key:        hex.EncodeToString(key),
+		Error:      err.Error(),
+	}
+	r.mu.Lock()
+	r.failures = append(r.failures, f)
+	r.mu.Unlock()
+}
+
+// sorted returns the failures ordered by slot and shred index.
+func (r *failureReport) sorted() []failure {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failures := append([]failure(nil), r.failures...)
+	sort.SliceStable(failures, func(i, j int) bool {
+		a, b := &failures[i], &failures[j]
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		return a.ShredIndex != nil && (b.ShredIndex == nil || *a.ShredIndex < *b.ShredIndex)
+	})
+	return failures
+}
+
+func (r *failureReport) writeJSON(w io.Writer) error {
+	failures := r.sorted()
+	if failures == nil {
+		failures = []failure{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(failures)
+}
+
+func (r *failureReport) writeCSV(w io.Writer) error {
+	wr := csv.NewWriter(w)
+	wr.Write([]string{"slot", "shred_index", "class", "key", "error"})
+	for _, f := range r.sorted() {
+		var index string
+		if f.ShredIndex != nil {
+			index = strconv.FormatUint(*f.ShredIndex, 10)
+		}
+		wr.Write([]string{strconv.FormatUint(f.Slot, 10), index, f.Class, f.Key, f.Error})
+	}
+	wr.Flush()
+	return wr.Error()
+}
+
+// writeFile writes the report to a file, as CSV if its extension is .csv,
+// and as JSON otherwise.
+func (r *failureReport) writeFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(path) == ".csv" {
+		err = r.writeCSV(f)
+	} else {
+		err = r.writeJSON(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
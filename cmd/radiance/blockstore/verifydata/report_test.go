@@ -0,0 +1,36 @@
+package verifydata
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureReport(t *testing.T) {
+	var nilReport *failureReport
+	nilReport.add(failPoh, 1, nil, nil, errors.New("ignored"))
+
+	r := new(failureReport)
+	index := uint64(3)
+	r.add(failPoh, 9, nil, nil, errors.New("invalid hash"))
+	r.add(failInvalidShreds, 7, &index, []byte{0xab}, errors.New("missing shred 3"))
+	r.add(failInvalidMeta, 7, nil, []byte{0x01}, errors.New("eof"))
+
+	var buf bytes.Buffer
+	require.NoError(t, r.writeCSV(&buf))
+	assert.Equal(t, "slot,shred_index,class,key,error\n"+
+		"7,3,invalid_shreds,ab,missing shred 3\n"+
+		"7,,invalid_meta,01,eof\n"+
+		"9,,poh,,invalid hash\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, r.writeJSON(&buf))
+	assert.JSONEq(t, `[
+		{"slot": 7, "shred_index": 3, "class": "invalid_shreds", "key": "ab", "error": "missing shred 3"},
+		{"slot": 7, "class": "invalid_meta", "key": "01", "error": "eof"},
+		{"slot": 9, "class": "poh", "error": "invalid hash"}
+	]`, buf.String())
+}
@@ -0,0 +1,18 @@
+package verifydata
+
+import "math"
+
+// sampled reports whether a slot is part of a sample of slots at the given
+// rate in [0, 1]. The sample is deterministic, so that verifications of the
+// same ledger at the same rate check the same slots.
+func sampled(slot uint64, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	// splitmix64 finalizer
+	x := slot + 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x) < rate*math.MaxUint64
+}
@@ -0,0 +1,19 @@
+package verifydata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampled(t *testing.T) {
+	var n int
+	for slot := uint64(0); slot < 100_000; slot++ {
+		assert.True(t, sampled(slot, 1))
+		assert.False(t, sampled(slot, 0))
+		if sampled(slot, 0.1) {
+			n++
+		}
+	}
+	assert.InDelta(t, 10_000, n, 500)
+}
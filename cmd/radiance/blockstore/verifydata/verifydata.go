@@ -38,6 +38,15 @@ var (
 	flagMaxErrs  = flags.Uint32("max-errors", 100, "Abort after N errors")
 	flagStatIvl  = flags.Duration("stat-interval", 5*time.Second, "Stats interval")
 	flagDumpSigs = flags.Bool("dump-sigs", false, "Print first signature of each transaction")
+	flagPoh      = flags.Bool("verify-poh", true, "Verify the PoH hashes of the entries of each slot")
+	flagRecover  = flags.Bool("recover", false, "Recover missing data shreds from coding shreds")
+	flagChained  = flags.Bool("verify-chained-roots", true, "Verify that the erasure sets of each slot chain to the merkle roots of their predecessors")
+	flagDups     = flags.Bool("duplicates", false, "Report conflicting shreds of each slot")
+	flagStart    = flags.Uint64("start-slot", 0, "First slot to verify")
+	flagEnd      = flags.Uint64("end-slot", 0, "Last slot to verify (default last slot)")
+	flagSample   = flags.Float64("sample-rate", 1, "Share of slots to verify, sampled deterministically")
+	flagMeta     = flags.Bool("verify-meta", true, "Verify that the meta of each slot agrees with the data shreds at hand")
+	flagReport   = flags.String("report", "", "Write failures to a report file (CSV if it ends with .csv, JSON otherwise)")
 )
 
 // TODO add a progress bar :3
@@ -69,6 +78,18 @@ func run(c *cobra.Command, args []string) {
 	if slotLo > slotHi {
 		panic("wtf: slotLo > slotHi")
 	}
+	if *flagStart > slotLo {
+		slotLo = *flagStart
+	}
+	if flags.Changed("end-slot") && *flagEnd < slotHi-1 {
+		slotHi = *flagEnd + 1
+	}
+	if slotLo >= slotHi {
+		klog.Exitf("No slots to verify in [%d, %d)", slotLo, slotHi)
+	}
+	if *flagSample <= 0 || *flagSample > 1 {
+		klog.Exitf("Invalid sample rate %g", *flagSample)
+	}
 	total := slotHi - slotLo
 	klog.Infof("Verifying %d slots", total)
 
@@ -86,6 +107,13 @@ func run(c *cobra.Command, args []string) {
 	var numFailure atomic.Uint32
 	var numBytes atomic.Uint64
 	var numTxns atomic.Uint64
+	var numRecovered atomic.Uint64
+	var numDuplicates atomic.Uint64
+	var numMetaMismatches atomic.Uint64
+	var report *failureReport
+	if *flagReport != "" {
+		report = new(failureReport)
+	}
 
 	// application lifetime
 	rootCtx := c.Context()
@@ -107,8 +135,9 @@ func run(c *cobra.Command, args []string) {
 		lastStatsUpdate = now
 	}
 	stats := func() {
-		klog.Infof("[stats] good=%d skipped=%d bad=%d tps=%.0f",
-			numSuccess.Load(), numSkipped.Load(), numFailure.Load(), txRate.Value())
+		klog.Infof("[stats] good=%d skipped=%d bad=%d recovered=%d duplicates=%d meta_mismatches=%d tps=%.0f",
+			numSuccess.Load(), numSkipped.Load(), numFailure.Load(), numRecovered.Load(),
+			numDuplicates.Load(), numMetaMismatches.Load(), txRate.Value())
 	}
 
 	var barOutput io.Writer
@@ -171,15 +200,23 @@ func run(c *cobra.Command, args []string) {
 
 		klog.Infof("[worker %d]: range=[%d:%d]", i, wLo, wHi)
 		w := &worker{
-			id:          i,
-			bar:         bar,
-			stop:        wHi,
-			numSuccess:  &numSuccess,
-			numSkipped:  &numSkipped,
-			numFailures: &numFailure,
-			maxFailures: *flagMaxErrs,
-			numBytes:    &numBytes,
-			numTxns:     &numTxns,
+			id:            i,
+			bar:           bar,
+			stop:          wHi,
+			numSuccess:    &numSuccess,
+			numSkipped:    &numSkipped,
+			numFailures:   &numFailure,
+			maxFailures:   *flagMaxErrs,
+			numBytes:      &numBytes,
+			numTxns:       &numTxns,
+			recoverShreds: *flagRecover,
+			numRecovered:  &numRecovered,
+			duplicates:    *flagDups,
+			numDuplicates: &numDuplicates,
+			sampleRate:    *flagSample,
+			report:        report,
+			verifyMeta:    *flagMeta,
+			numMetaErrs:   &numMetaMismatches,
 		}
 		w.init(db, wLo)
 		group.Go(func() error {
@@ -206,6 +243,15 @@ func run(c *cobra.Command, args []string) {
 		exitCode = 1
 	}
 
+	if report != nil {
+		if err := report.writeFile(*flagReport); err != nil {
+			klog.Errorf("%s", err)
+			exitCode = 1
+		} else {
+			klog.Infof("Wrote report to %s", *flagReport)
+		}
+	}
+
 	stats()
 	timeTaken := time.Since(start)
 	klog.Infof("Time taken: %s", timeTaken)
@@ -4,6 +4,8 @@ package verifydata
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"sync/atomic"
 	"time"
@@ -11,12 +13,15 @@ import (
 	"github.com/linxGnu/grocksdb"
 	"github.com/vbauerster/mpb/v8"
 	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/poh"
+	"go.firedancer.io/radiance/pkg/shred"
 	"k8s.io/klog/v2"
 )
 
 // worker does a single pass over blockstore.CfMeta and blockstore.CfDataShred concurrently.
 type worker struct {
 	id    uint
+	db    *blockstore.DB
 	meta  *grocksdb.Iterator
 	shred *grocksdb.Iterator
 	// Slot range
@@ -31,9 +36,23 @@ type worker struct {
 	maxFailures uint32
 	numTxns     *atomic.Uint64
 	numBytes    *atomic.Uint64
+	// recover missing data shreds from coding shreds
+	recoverShreds bool
+	numRecovered  *atomic.Uint64
+	// report conflicting shreds
+	duplicates    bool
+	numDuplicates *atomic.Uint64
+	// share of slots to verify
+	sampleRate float64
+	// failure records, or nil
+	report *failureReport
+	// cross-check slot metas against data shreds
+	verifyMeta  bool
+	numMetaErrs *atomic.Uint64
 }
 
 func (w *worker) init(db *blockstore.DB, start uint64) {
+	w.db = db
 	w.current = start
 	w.meta = db.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), db.CfMeta)
 	w.shred = db.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), db.CfDataShred)
@@ -93,6 +112,7 @@ func (w *worker) readSlot() (shouldContinue bool) {
 	metaSlot, ok = blockstore.ParseSlotKey(w.meta.Key().Data())
 	if !ok {
 		klog.Warningf("Skipping invalid slot key: %x", w.meta.Key().Data())
+		w.report.add(failInvalidKey, 0, nil, w.meta.Key().Data(), fmt.Errorf("invalid slot key"))
 		return
 	}
 	if metaSlot >= w.stop {
@@ -121,6 +141,11 @@ func (w *worker) readSlot() (shouldContinue bool) {
 	if step > 1 {
 		w.numSkipped.Add(step - 1)
 	}
+	if !sampled(metaSlot, w.sampleRate) {
+		w.numSkipped.Add(1)
+		success = true
+		return
+	}
 
 	// Shred iterator should follow meta iter
 	shredSlot, _, ok := blockstore.ParseShredKey(w.shred.Key().Data())
@@ -131,6 +156,7 @@ func (w *worker) readSlot() (shouldContinue bool) {
 		klog.V(4).Infof("slot %d: not all shreds consumed", metaSlot)
 	} else if shredSlot > metaSlot {
 		klog.Warningf("slot %d: missing shreds", metaSlot)
+		w.report.add(failMissingShreds, metaSlot, nil, w.meta.Key().Data(), fmt.Errorf("missing shreds"))
 		return
 	}
 
@@ -144,6 +170,7 @@ func (w *worker) readSlot() (shouldContinue bool) {
 		if !ok {
 			// Double failure, just go to next slot
 			klog.Warningf("slot %d: invalid shred key after sync: %x", metaSlot, w.shred.Key().Data())
+			w.report.add(failInvalidKey, metaSlot, nil, w.shred.Key().Data(), fmt.Errorf("invalid shred key"))
 			return
 		}
 	}
@@ -157,8 +184,15 @@ func (w *worker) readSlot() (shouldContinue bool) {
 	meta, err := blockstore.ParseBincode[blockstore.SlotMeta](w.meta.Value().Data())
 	if err != nil {
 		klog.Warningf("slot %d: invalid meta: %s", metaSlot, err)
+		w.report.add(failInvalidMeta, metaSlot, nil, w.meta.Key().Data(), err)
 		return
 	}
+	if w.duplicates {
+		w.reportDuplicates(metaSlot)
+	}
+	if w.verifyMeta {
+		w.verifySlotMeta(meta)
+	}
 	if isFull = meta.IsFull(); !isFull {
 		w.numSkipped.Add(1)
 		success = true
@@ -167,8 +201,15 @@ func (w *worker) readSlot() (shouldContinue bool) {
 
 	// Read data shreds.
 	shreds, err := blockstore.GetDataShredsFromIter(w.shred, metaSlot, 0, uint32(meta.Received), 2)
+	if err != nil && w.recoverShreds {
+		klog.V(3).Infof("slot %d: recovering data shreds: %s", metaSlot, err)
+		var numRecovered int
+		shreds, numRecovered, err = w.db.GetDataShredsWithRecovery(metaSlot, 0, uint32(meta.Received), 2)
+		w.numRecovered.Add(uint64(numRecovered))
+	}
 	if err != nil {
 		klog.Warningf("slot %d: invalid data shreds: %s", metaSlot, err)
+		w.reportShredFailure(failInvalidShreds, metaSlot, err)
 		return
 	}
 
@@ -178,11 +219,14 @@ func (w *worker) readSlot() (shouldContinue bool) {
 	entries, err := blockstore.DataShredsToEntries(meta, shreds)
 	if err != nil {
 		klog.Warningf("slot %d: cannot decode entries: %s", metaSlot, err)
+		w.report.add(failInvalidEntries, metaSlot, nil, w.meta.Key().Data(), err)
 		return
 	}
 
 	var numTxns uint64
+	var slotEntries []shred.Entry
 	for _, outer := range entries {
+		slotEntries = append(slotEntries, outer.Entries...)
 		for _, e := range outer.Entries {
 			numTxns += uint64(len(e.Txns))
 			if *flagDumpSigs {
@@ -196,6 +240,34 @@ func (w *worker) readSlot() (shouldContinue bool) {
 	}
 	w.numTxns.Add(numTxns)
 
+	// The first entry follows the last entry of the parent slot, which is
+	// not at hand, so verification starts with the second entry.
+	if *flagPoh && len(slotEntries) > 1 {
+		if err := poh.VerifyEntries(poh.State(slotEntries[0].Hash), slotEntries[1:], 1); err != nil {
+			klog.Warningf("slot %d: %s", metaSlot, err)
+			w.report.add(failPoh, metaSlot, nil, w.meta.Key().Data(), err)
+			return
+		}
+	}
+
+	if *flagChained {
+		violations, err := w.db.VerifyChainedMerkleRoots(meta)
+		if err != nil {
+			klog.Warningf("slot %d: cannot verify chained merkle roots: %s", metaSlot, err)
+			w.report.add(failChainedRoot, metaSlot, nil, w.meta.Key().Data(), err)
+			return
+		}
+		for _, violation := range violations {
+			klog.Warningf("slot %d: %s", metaSlot, violation)
+			index := uint64(violation.FECSetIndex)
+			key := blockstore.MakeShredKey(metaSlot, index)
+			w.report.add(failChainedRoot, metaSlot, &index, key[:], violation)
+		}
+		if len(violations) != 0 {
+			return
+		}
+	}
+
 	// TODO Sigverify / sanitize txs
 
 	success = true
@@ -203,6 +275,98 @@ func (w *worker) readSlot() (shouldContinue bool) {
 	return
 }
 
+// reportDuplicates logs the proof of conflicting shreds of a slot stored by
+// the validator, and the conflicts among the shreds at hand.
+func (w *worker) reportDuplicates(slot uint64) {
+	var proofs []*shred.DuplicateSlotProof
+	stored, err := w.db.GetDuplicateSlotProof(slot)
+	if err == nil {
+		proofs = append(proofs, stored)
+	} else if !errors.Is(err, blockstore.ErrNotFound) {
+		klog.Warningf("slot %d: invalid duplicate slot proof: %s", slot, err)
+	}
+	found, err := w.db.FindDuplicateShreds(slot)
+	if err != nil {
+		klog.Warningf("slot %d: cannot find duplicate shreds: %s", slot, err)
+		return
+	}
+	proofs = append(proofs, found...)
+	for _, proof := range proofs {
+		klog.Warningf("slot %d: duplicate shreds: %s", slot, formatDuplicateSlotProof(proof))
+		var index *uint64
+		var key []byte
+		if len(proof.Shred1) >= shred.CommonHeaderSize {
+			i := uint64(binary.LittleEndian.Uint32(proof.Shred1[0x49:0x4d]))
+			shredKey := blockstore.MakeShredKey(slot, i)
+			index, key = &i, shredKey[:]
+		}
+		w.report.add(failDuplicateShreds, slot, index, key,
+			fmt.Errorf("duplicate shreds: %s", formatDuplicateSlotProof(proof)))
+	}
+	if len(proofs) != 0 {
+		w.numDuplicates.Add(1)
+	}
+}
+
+// verifySlotMeta reports the fields of the meta of a slot that disagree
+// with its data shreds at hand, and the gaps in the data shreds of slots
+// that are full as per their meta. These are counted separately from the
+// failures to read a slot.
+func (w *worker) verifySlotMeta(meta *blockstore.SlotMeta) {
+	errs, gaps, err := w.db.CheckSlotMeta(meta)
+	if err != nil {
+		klog.Warningf("slot %d: cannot check meta: %s", meta.Slot, err)
+		return
+	}
+	metaKey := blockstore.MakeSlotKey(meta.Slot)
+	for _, e := range errs {
+		klog.Warningf("slot %d: meta mismatch: %s", meta.Slot, e)
+		w.report.add(failMetaMismatch, meta.Slot, nil, metaKey[:], e)
+	}
+	if meta.IsFull() {
+		for _, gap := range gaps {
+			klog.Warningf("slot %d: missing data shreds %s", meta.Slot, gap)
+			index := gap.Start
+			key := blockstore.MakeShredKey(meta.Slot, index)
+			w.report.add(failShredGap, meta.Slot, &index, key[:], fmt.Errorf("missing data shreds %s", gap))
+		}
+	}
+	if len(errs) != 0 {
+		w.numMetaErrs.Add(1)
+	}
+}
+
+// reportShredFailure records a failure to read the data shreds of a slot,
+// at the shred the iterator stopped at, if it is of the slot.
+func (w *worker) reportShredFailure(class string, slot uint64, err error) {
+	if w.report == nil {
+		return
+	}
+	if w.shred.Valid() {
+		key := w.shred.Key().Data()
+		if shredSlot, index, ok := blockstore.ParseShredKey(key); ok && shredSlot == slot {
+			w.report.add(class, slot, &index, key, err)
+			return
+		}
+	}
+	w.report.add(class, slot, nil, w.meta.Key().Data(), err)
+}
+
+func formatDuplicateSlotProof(proof *shred.DuplicateSlotProof) string {
+	describe := func(raw []byte) string {
+		if len(raw) < shred.CommonHeaderSize {
+			return "invalid"
+		}
+		kind := "code"
+		if (&shred.CommonHeader{Variant: raw[0x40]}).IsData() {
+			kind = "data"
+		}
+		return fmt.Sprintf("%s index=%d fec_set=%d",
+			kind, binary.LittleEndian.Uint32(raw[0x49:0x4d]), binary.LittleEndian.Uint32(raw[0x4f:0x53]))
+	}
+	return describe(proof.Shred1) + " vs " + describe(proof.Shred2)
+}
+
 func (w *worker) shouldAbort(numFailures uint32) bool {
 	return w.maxFailures > 0 && numFailures >= w.maxFailures
 }
@@ -0,0 +1,26 @@
+package yaml
+
+import "go.firedancer.io/radiance/pkg/storageproto"
+
+// reward is a YAML-friendly version of storageproto.Reward.
+type reward struct {
+	Pubkey      string `yaml:"pubkey"`
+	Lamports    int64  `yaml:"lamports"`
+	PostBalance uint64 `yaml:"post_balance"`
+	RewardType  string `yaml:"reward_type"`
+	Commission  *uint8 `yaml:"commission,omitempty"`
+}
+
+func makeRewards(rewards *storageproto.Rewards) []reward {
+	rs := make([]reward, len(rewards.Rewards))
+	for i, r := range rewards.Rewards {
+		rs[i] = reward{
+			Pubkey:      r.Pubkey.String(),
+			Lamports:    r.Lamports,
+			PostBalance: r.PostBalance,
+			RewardType:  r.RewardType.String(),
+			Commission:  r.Commission,
+		}
+	}
+	return rs
+}
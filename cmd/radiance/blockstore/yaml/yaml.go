@@ -3,6 +3,7 @@
 package yaml
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -30,6 +31,7 @@ var (
 	flagShreds        = flags.Bool("shreds", false, "Also dump shreds")
 	flagTxns          = flags.Bool("txs", false, "Also dump transactions")
 	flagRoots         = flags.Bool("roots", false, "Dump roots table")
+	flagRewards       = flags.Bool("rewards", false, "Also dump block rewards")
 	flagShredRevision = flags.Int("shred-revision", 2, "Shred revision (1, 2)")
 )
 
@@ -179,6 +181,10 @@ func dumpSlot(db *blockstore.DB, slot uint64) {
 
 	fmt.Printf("  %d:\n", slot)
 	printSlotMeta(slotMeta)
+	printBlockTime(db, slot)
+	if *flagRewards {
+		dumpRewards(db, slot)
+	}
 	if *flagShreds {
 		dumpDataShreds(db, slot)
 	}
@@ -195,6 +201,42 @@ func printSlotMeta(slotMeta *blockstore.SlotMeta) {
 	}
 }
 
+func printBlockTime(db *blockstore.DB, slot uint64) {
+	blockTime, err := db.GetBlockTime(slot)
+	if err == nil {
+		fmt.Println("    block_time:", blockTime)
+	} else if !errors.Is(err, blockstore.ErrNotFound) {
+		klog.Errorf("Failed to get block time of slot %d: %s", slot, err)
+	}
+	blockHeight, err := db.GetBlockHeight(slot)
+	if err == nil {
+		fmt.Println("    block_height:", blockHeight)
+	} else if !errors.Is(err, blockstore.ErrNotFound) {
+		klog.Errorf("Failed to get block height of slot %d: %s", slot, err)
+	}
+}
+
+func dumpRewards(db *blockstore.DB, slot uint64) {
+	rewards, err := db.GetRewards(slot)
+	if errors.Is(err, blockstore.ErrNotFound) {
+		return
+	} else if err != nil {
+		klog.Errorf("Failed to get rewards of slot %d: %s", slot, err)
+		return
+	}
+
+	if rewards.NumPartitions != nil {
+		fmt.Println("    num_reward_partitions:", *rewards.NumPartitions)
+	}
+	fmt.Println("    rewards:")
+
+	enc := newYAMLPrinter(3)
+	defer enc.Close()
+	if err := enc.Encode(makeRewards(rewards)); err != nil {
+		panic(err.Error())
+	}
+}
+
 func dumpDataShreds(db *blockstore.DB, slot uint64) {
 	shreds, err := db.GetAllDataShreds(slot, *flagShredRevision)
 	if err != nil {
@@ -4,6 +4,8 @@ import (
 	"github.com/spf13/cobra"
 	"go.firedancer.io/radiance/cmd/radiance/gossip/ping"
 	"go.firedancer.io/radiance/cmd/radiance/gossip/pull"
+	"go.firedancer.io/radiance/cmd/radiance/gossip/spy"
+	"go.firedancer.io/radiance/cmd/radiance/gossip/votes"
 )
 
 var Cmd = cobra.Command{
@@ -15,5 +17,7 @@ func init() {
 	Cmd.AddCommand(
 		&ping.Cmd,
 		&pull.Cmd,
+		&spy.Cmd,
+		&votes.Cmd,
 	)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/ed25519"
 	"crypto/rand"
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/netip"
 	"time"
@@ -55,11 +57,14 @@ func run(c *cobra.Command, _ []string) {
 
 	pingClient := gossip.NewPingClient(identity, conn)
 	pingServer := gossip.NewPingServer(identity, conn)
-	pullClient := gossip.NewPullClient(identity, conn)
+	table := gossip.NewCrdsTable()
+	self := &gossip.ContactInfoV2{Outset: uint64(time.Now().UnixMicro())}
+	pullClient := gossip.NewPullClient(identity, conn, table, self)
 	handler := &gossip.Handler{
 		PingClient: pingClient,
 		PingServer: pingServer,
 		PullClient: pullClient,
+		PushServer: gossip.NewPushServer(table),
 	}
 	client := gossip.NewDriver(handler, conn)
 
@@ -96,4 +101,9 @@ func run(c *cobra.Command, _ []string) {
 	})
 	_ = group.Wait()
 	_ = conn.Close()
+
+	for _, entry := range table.Entries() {
+		jsonBuf, _ := json.MarshalIndent(entry.Value, "", "\t")
+		fmt.Println(string(jsonBuf))
+	}
 }
@@ -0,0 +1,190 @@
+package spy
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/gossip"
+)
+
+// report is a view of the cluster built from the CRDS table.
+type report struct {
+	Nodes     []nodeInfo     `json:"nodes"`
+	Votes     []voteInfo     `json:"votes"`
+	Snapshots []snapshotInfo `json:"snapshots"`
+}
+
+type nodeInfo struct {
+	Pubkey       solana.PublicKey `json:"pubkey"`
+	Gossip       string           `json:"gossip,omitempty"`
+	TPU          string           `json:"tpu,omitempty"`
+	TPUQUIC      string           `json:"tpuQuic,omitempty"`
+	TVU          string           `json:"tvu,omitempty"`
+	RPC          string           `json:"rpc,omitempty"`
+	ShredVersion uint16           `json:"shredVersion"`
+	Version      string           `json:"version,omitempty"`
+	Wallclock    uint64           `json:"wallclock"`
+}
+
+type voteInfo struct {
+	Node        solana.PublicKey `json:"node"`
+	VoteAccount solana.PublicKey `json:"voteAccount"`
+	Index       uint16           `json:"index"`
+	Slot        *uint64          `json:"slot,omitempty"`
+	Wallclock   uint64           `json:"wallclock"`
+}
+
+type snapshotInfo struct {
+	Node        solana.PublicKey `json:"node"`
+	Full        gossip.SlotHash  `json:"full"`
+	Incremental *gossip.SlotHash `json:"incremental,omitempty"`
+	Wallclock   uint64           `json:"wallclock"`
+}
+
+// buildReport collects the nodes, votes and snapshot hashes of a table.
+// The contact info of a node takes precedence over its legacy one.
+func buildReport(table *gossip.CrdsTable) *report {
+	r := new(report)
+
+	nodes := make(map[gossip.Pubkey]nodeInfo)
+	for _, entry := range table.Entries(gossip.CrdsKindContactInfo) {
+		info := entry.Value.Data.(*gossip.CrdsData__ContactInfo).Value
+		nodes[info.Id] = nodeInfo{
+			Pubkey:       solana.PublicKey(info.Id),
+			Gossip:       formatSocket(info.Gossip.AddrPort),
+			TPU:          formatSocket(info.Tpu.AddrPort),
+			TVU:          formatSocket(info.Tvu.AddrPort),
+			RPC:          formatSocket(info.Rpc.AddrPort),
+			ShredVersion: info.ShredVersion,
+			Wallclock:    info.Wallclock,
+		}
+	}
+	for _, entry := range table.Entries(gossip.CrdsKindContactInfoV2) {
+		info := entry.Value.Data.(*gossip.CrdsData__ContactInfoV2).Value
+		socket := func(key uint8) string {
+			sock, _ := info.Socket(key)
+			return formatSocket(sock)
+		}
+		v := info.Version
+		nodes[info.Pubkey] = nodeInfo{
+			Pubkey:       solana.PublicKey(info.Pubkey),
+			Gossip:       socket(gossip.SocketGossip),
+			TPU:          socket(gossip.SocketTPU),
+			TPUQUIC:      socket(gossip.SocketTPUQUIC),
+			TVU:          socket(gossip.SocketTVU),
+			RPC:          socket(gossip.SocketRPC),
+			ShredVersion: info.ShredVersion,
+			Version:      fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch),
+			Wallclock:    info.Wallclock,
+		}
+	}
+	for _, node := range nodes {
+		r.Nodes = append(r.Nodes, node)
+	}
+	sort.Slice(r.Nodes, func(i, j int) bool {
+		return r.Nodes[i].Pubkey.String() < r.Nodes[j].Pubkey.String()
+	})
+
+	for _, entry := range table.Entries(gossip.CrdsKindVote) {
+		vote := entry.Value.Data.(*gossip.CrdsData__Vote)
+		voteAccount, ok := voteAccountOf(&vote.Field1.Transaction)
+		if !ok {
+			continue
+		}
+		r.Votes = append(r.Votes, voteInfo{
+			Node:        solana.PublicKey(vote.Field1.From),
+			VoteAccount: voteAccount,
+			Index:       uint16(vote.Field0),
+			Slot:        vote.Field1.Slot,
+			Wallclock:   vote.Field1.Wallclock,
+		})
+	}
+	sort.Slice(r.Votes, func(i, j int) bool {
+		a, b := r.Votes[i].VoteAccount.String(), r.Votes[j].VoteAccount.String()
+		if a != b {
+			return a < b
+		}
+		return r.Votes[i].Index < r.Votes[j].Index
+	})
+
+	for _, entry := range table.Entries(gossip.CrdsKindIncrementalSnapshotHashes) {
+		hashes := entry.Value.Data.(*gossip.CrdsData__IncrementalSnapshotHashes).Value
+		info := snapshotInfo{
+			Node:      solana.PublicKey(hashes.From),
+			Full:      hashes.Base,
+			Wallclock: hashes.Wallclock,
+		}
+		if n := len(hashes.Hashes); n > 0 {
+			info.Incremental = &hashes.Hashes[n-1]
+		}
+		r.Snapshots = append(r.Snapshots, info)
+	}
+	sort.Slice(r.Snapshots, func(i, j int) bool {
+		return r.Snapshots[i].Full.Slot > r.Snapshots[j].Full.Slot
+	})
+	return r
+}
+
+// voteAccountOf returns the vote account of a vote transaction, the first
+// account of its first instruction.
+func voteAccountOf(tx *gossip.Transaction) (solana.PublicKey, bool) {
+	msg := &tx.Message
+	if len(msg.Instructions) == 0 || len(msg.Instructions[0].Accounts) == 0 {
+		return solana.PublicKey{}, false
+	}
+	index := int(msg.Instructions[0].Accounts[0])
+	if index >= len(msg.AccountKeys) {
+		return solana.PublicKey{}, false
+	}
+	return msg.AccountKeys[index], true
+}
+
+func formatSocket(sock netip.AddrPort) string {
+	if !sock.Addr().IsValid() || sock.Addr().IsUnspecified() {
+		return ""
+	}
+	return sock.String()
+}
+
+// print writes the report as tables.
+func (r *report) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "PUBKEY\tGOSSIP\tTPU\tTVU\tRPC\tSHRED VERSION\tVERSION\n")
+	for _, n := range r.Nodes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			n.Pubkey, dash(n.Gossip), dash(n.TPU), dash(n.TVU), dash(n.RPC), n.ShredVersion, dash(n.Version))
+	}
+	fmt.Fprintf(tw, "\nVOTE ACCOUNT\tNODE\tINDEX\tSLOT\n")
+	for _, v := range r.Votes {
+		slot := "-"
+		if v.Slot != nil {
+			slot = fmt.Sprint(*v.Slot)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", v.VoteAccount, v.Node, v.Index, slot)
+	}
+	fmt.Fprintf(tw, "\nNODE\tFULL SNAPSHOT\tINCREMENTAL SNAPSHOT\n")
+	for _, s := range r.Snapshots {
+		incremental := "-"
+		if s.Incremental != nil {
+			incremental = formatSlotHash(*s.Incremental)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Node, formatSlotHash(s.Full), incremental)
+	}
+	fmt.Fprintf(tw, "\n%d nodes, %d votes, %d snapshot hashes\n", len(r.Nodes), len(r.Votes), len(r.Snapshots))
+	tw.Flush()
+}
+
+func formatSlotHash(h gossip.SlotHash) string {
+	return fmt.Sprintf("%d:%s", h.Slot, solana.Hash(h.Hash))
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package spy
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/gossip"
+)
+
+func TestBuildReport(t *testing.T) {
+	table := gossip.NewCrdsTable()
+	insert := func(data gossip.CrdsData) {
+		_, err := table.Insert(gossip.CrdsValue{Data: data}, time.Now())
+		require.NoError(t, err)
+	}
+
+	// the contact info of node 1 replaces its legacy one
+	insert(&gossip.CrdsData__ContactInfo{Value: gossip.ContactInfo{Id: gossip.Pubkey{1}, ShredVersion: 1}})
+	info := gossip.ContactInfoV2{
+		Pubkey:       gossip.Pubkey{1},
+		ShredVersion: 2,
+		Version:      gossip.ClientVersion{Major: 2, Minor: 1, Patch: 7},
+	}
+	info.SetSockets(map[uint8]netip.AddrPort{
+		gossip.SocketGossip: netip.MustParseAddrPort("10.0.0.1:8001"),
+		gossip.SocketTPU:    netip.MustParseAddrPort("10.0.0.1:8003"),
+	})
+	insert(&gossip.CrdsData__ContactInfoV2{Value: info})
+	insert(&gossip.CrdsData__ContactInfo{Value: gossip.ContactInfo{
+		Id:     gossip.Pubkey{2},
+		Gossip: gossip.SocketAddr{AddrPort: netip.MustParseAddrPort("10.0.0.2:8001")},
+	}})
+
+	slot := uint64(100)
+	insert(&gossip.CrdsData__Vote{Field0: 3, Field1: gossip.Vote{
+		From: gossip.Pubkey{1},
+		Transaction: gossip.Transaction{Message: solana.Message{
+			AccountKeys:  []solana.PublicKey{{1}, {5}, {6}},
+			Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 2, Accounts: []uint16{1, 0}}},
+		}},
+		Slot: &slot,
+	}})
+	insert(&gossip.CrdsData__IncrementalSnapshotHashes{Value: gossip.IncrementalSnapshotHashes{
+		From:   gossip.Pubkey{2},
+		Base:   gossip.SlotHash{Slot: 1000, Hash: gossip.Hash{1}},
+		Hashes: []gossip.SlotHash{{Slot: 1100}, {Slot: 1200, Hash: gossip.Hash{2}}},
+	}})
+
+	r := buildReport(table)
+	assert.Equal(t, []nodeInfo{
+		{
+			Pubkey:       solana.PublicKey{1},
+			Gossip:       "10.0.0.1:8001",
+			TPU:          "10.0.0.1:8003",
+			ShredVersion: 2,
+			Version:      "2.1.7",
+		},
+		{
+			Pubkey: solana.PublicKey{2},
+			Gossip: "10.0.0.2:8001",
+		},
+	}, r.Nodes)
+	assert.Equal(t, []voteInfo{{
+		Node:        solana.PublicKey{1},
+		VoteAccount: solana.PublicKey{5},
+		Index:       3,
+		Slot:        &slot,
+	}}, r.Votes)
+	assert.Equal(t, []snapshotInfo{{
+		Node:        solana.PublicKey{2},
+		Full:        gossip.SlotHash{Slot: 1000, Hash: gossip.Hash{1}},
+		Incremental: &gossip.SlotHash{Slot: 1200, Hash: gossip.Hash{2}},
+	}}, r.Snapshots)
+
+	var buf bytes.Buffer
+	r.print(&buf)
+	assert.Contains(t, buf.String(), "2 nodes, 1 votes, 1 snapshot hashes")
+}
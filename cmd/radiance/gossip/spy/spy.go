@@ -0,0 +1,125 @@
+package spy
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/gossip"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "spy",
+	Short: "Join gossip as a spy and watch the cluster",
+	Long: "Joins gossip without advertising any sockets, pulls CRDS values from the\n" +
+		"entrypoints and the discovered nodes, and periodically prints the node table,\n" +
+		"vote accounts and snapshot hashes. With --http, serves them as JSON at\n" +
+		"/nodes, /votes, /snapshots and / (all).",
+	Args: cobra.NoArgs,
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagEntrypoints   = flags.StringSlice("entrypoint", nil, "Gossip entrypoint (<host>:<port>), repeatable")
+	flagShredVersion  = flags.Uint16("shred-version", 0, "Shred version to advertise (0 for any)")
+	flagPullInterval  = flags.Duration("pull-interval", 2*time.Second, "Delay between pull requests")
+	flagPrintInterval = flags.Duration("print-interval", 10*time.Second, "Delay between prints of the tables, 0 to disable")
+	flagTimeout       = flags.Duration("timeout", 10*time.Minute, "Drop values received longer ago than this duration")
+	flagHTTP          = flags.String("http", "", "Listen address of the JSON HTTP server, disabled if empty")
+)
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(c *cobra.Command, _ []string) {
+	if len(*flagEntrypoints) == 0 {
+		klog.Exit("No entrypoint specified")
+	}
+	entrypoints, err := gossip.ResolveEntrypoints(*flagEntrypoints)
+	if err != nil {
+		klog.Exit(err)
+	}
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		klog.Exit(err)
+	}
+	node, err := gossip.NewNode(conn, entrypoints, *flagShredVersion)
+	if err != nil {
+		klog.Exit(err)
+	}
+	klog.Infof("Spying as %s", solana.PublicKey(node.Pubkey))
+	table := node.Table
+	handler := node.Handler
+
+	group, ctx := errgroup.WithContext(c.Context())
+	group.Go(func() error {
+		return node.Run(ctx, *flagPullInterval, func() error {
+			node.Prune(*flagTimeout)
+			return nil
+		})
+	})
+	if *flagPrintInterval > 0 {
+		group.Go(func() error {
+			ticker := time.NewTicker(*flagPrintInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+				buildReport(table).print(os.Stdout)
+				klog.Infof("[stats] values=%d inserted=%d stale=%d invalid=%d prunes=%d",
+					table.Len(),
+					handler.PullClient.NumValues.Load()+handler.PushServer.NumValues.Load(),
+					handler.PullClient.NumStale.Load()+handler.PushServer.NumStale.Load(),
+					handler.PullClient.NumInvalid.Load()+handler.PushServer.NumInvalid.Load(),
+					handler.PushServer.NumPrunes.Load())
+			}
+		})
+	}
+	if *flagHTTP != "" {
+		server := &http.Server{Addr: *flagHTTP, Handler: newHTTPHandler(table)}
+		group.Go(func() error {
+			klog.Infof("Serving JSON on %s", *flagHTTP)
+			if err := server.ListenAndServe(); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})
+		group.Go(func() error {
+			<-ctx.Done()
+			return server.Close()
+		})
+	}
+
+	if err := group.Wait(); err != nil && err != context.Canceled {
+		klog.Exit(err)
+	}
+}
+
+func newHTTPHandler(table *gossip.CrdsTable) http.Handler {
+	mux := http.NewServeMux()
+	serve := func(path string, view func(r *report) any) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(view(buildReport(table))); err != nil {
+				klog.Warningf("Failed to write response: %s", err)
+			}
+		})
+	}
+	serve("/", func(r *report) any { return r })
+	serve("/nodes", func(r *report) any { return r.Nodes })
+	serve("/votes", func(r *report) any { return r.Votes })
+	serve("/snapshots", func(r *report) any { return r.Snapshots })
+	return mux
+}
@@ -0,0 +1,135 @@
+package votes
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/gossip"
+	"go.firedancer.io/radiance/pkg/snapshot"
+	"go.firedancer.io/radiance/pkg/votelistener"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "votes",
+	Short: "Follow the votes of a cluster and its optimistic confirmations",
+	Long: "Joins gossip as a spy and streams the vote transactions propagated by the\n" +
+		"nodes. With --snapshot, votes are checked against the authorized voters of\n" +
+		"the epoch stakes of the snapshot, and weighted by their stake to print the\n" +
+		"slots optimistically confirmed, as they reach 2/3 of the stake.",
+	Args: cobra.NoArgs,
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagEntrypoints  = flags.StringSlice("entrypoint", nil, "Gossip entrypoint (<host>:<port>), repeatable")
+	flagShredVersion = flags.Uint16("shred-version", 0, "Shred version to advertise (0 for any)")
+	flagSnapshot     = flags.String("snapshot", "", "Snapshot archive to read the epoch stakes from")
+	flagPrintVotes   = flags.Bool("print-votes", false, "Print every vote, always on without --snapshot")
+	flagPullInterval = flags.Duration("pull-interval", 2*time.Second, "Delay between pull requests")
+	flagPollInterval = flags.Duration("poll-interval", 200*time.Millisecond, "Delay between polls of new votes")
+	flagTimeout      = flags.Duration("timeout", 10*time.Minute, "Drop values received longer ago than this duration")
+)
+
+// maxTrackedSlots is the number of slots below the highest confirmed slot
+// of which votes are still tallied.
+const maxTrackedSlots = 512
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(c *cobra.Command, _ []string) {
+	if len(*flagEntrypoints) == 0 {
+		klog.Exit("No entrypoint specified")
+	}
+	entrypoints, err := gossip.ResolveEntrypoints(*flagEntrypoints)
+	if err != nil {
+		klog.Exit(err)
+	}
+
+	var stakes *votelistener.Stakes
+	if *flagSnapshot != "" {
+		manifest, err := snapshot.LoadArchiveFromFile(*flagSnapshot, discardAccounts{})
+		if err != nil {
+			klog.Exitf("Failed to load snapshot: %s", err)
+		}
+		stakes = votelistener.StakesFromManifest(manifest)
+		klog.Infof("Loaded epoch stakes of snapshot of slot %d", manifest.Bank.Slot)
+	}
+
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		klog.Exit(err)
+	}
+	node, err := gossip.NewNode(conn, entrypoints, *flagShredVersion)
+	if err != nil {
+		klog.Exit(err)
+	}
+	listener := votelistener.NewListener(node.Table, stakes)
+	votes := make(chan votelistener.Vote, 1024)
+
+	group, ctx := errgroup.WithContext(c.Context())
+	group.Go(func() error {
+		return node.Run(ctx, *flagPullInterval, func() error {
+			node.Prune(*flagTimeout)
+			klog.V(2).Infof("[stats] values=%d votes=%d invalid_votes=%d",
+				node.Table.Len(), listener.NumVotes.Load(), listener.NumInvalid.Load())
+			return nil
+		})
+	})
+	group.Go(func() error {
+		defer close(votes)
+		if err := listener.Run(ctx, *flagPollInterval, votes); err != ctx.Err() {
+			return err
+		}
+		return nil
+	})
+	group.Go(func() error {
+		var tracker *votelistener.Tracker
+		if stakes != nil {
+			tracker = votelistener.NewTracker(stakes)
+		}
+		for vote := range votes {
+			if *flagPrintVotes || tracker == nil {
+				fmt.Printf("vote slot=%d hash=%s vote_account=%s from=%s\n",
+					vote.Slot, solana.Hash(vote.Hash), vote.VoteAccount, solana.PublicKey(vote.From))
+			}
+			if tracker == nil {
+				continue
+			}
+			tally, ok := tracker.Add(vote)
+			if !ok {
+				continue
+			}
+			fmt.Printf("confirmed slot=%d hash=%s stake=%.1f%% votes=%d\n",
+				tally.Slot, solana.Hash(tally.Hash), 100*float64(tally.Stake)/float64(tally.TotalStake), tally.NumVotes)
+			if tally.Slot > maxTrackedSlots {
+				tracker.SetRoot(tally.Slot - maxTrackedSlots)
+			}
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		klog.Exit(err)
+	}
+}
+
+// discardAccounts drops the accounts of the snapshot, only the epoch
+// stakes of its manifest are used.
+type discardAccounts struct{}
+
+func (discardAccounts) GetAccount(*[32]byte) (*accounts.Account, error) {
+	return nil, errors.New("accounts of the snapshot are not loaded")
+}
+
+func (discardAccounts) SetAccount(*[32]byte, *accounts.Account) error {
+	return nil
+}
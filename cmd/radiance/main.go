@@ -10,9 +10,13 @@ import (
 	"go.firedancer.io/radiance/cmd/radiance/tpu_udp"
 
 	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/radiance/accountsdb"
 	"go.firedancer.io/radiance/cmd/radiance/blockstore"
 	"go.firedancer.io/radiance/cmd/radiance/gossip"
+	"go.firedancer.io/radiance/cmd/radiance/node"
 	"go.firedancer.io/radiance/cmd/radiance/replay"
+	"go.firedancer.io/radiance/cmd/radiance/rpc"
+	"go.firedancer.io/radiance/cmd/radiance/snapshot"
 	"k8s.io/klog/v2"
 
 	// Load in instruction pretty-printing
@@ -31,9 +35,13 @@ func init() {
 	cmd.PersistentFlags().AddGoFlagSet(klogFlags)
 
 	cmd.AddCommand(
+		&accountsdb.Cmd,
 		&blockstore.Cmd,
 		&gossip.Cmd,
+		&node.Cmd,
 		&replay.Cmd,
+		&rpc.Cmd,
+		&snapshot.Cmd,
 		&tpu_udp.Cmd,
 		&tpu_quic.Cmd,
 	)
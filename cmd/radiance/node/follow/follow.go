@@ -0,0 +1,294 @@
+//go:build !lite
+
+package follow
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/gossip"
+	"go.firedancer.io/radiance/pkg/leaderschedule"
+	"go.firedancer.io/radiance/pkg/snapshot"
+	"go.firedancer.io/radiance/pkg/tvu"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "follow",
+	Short: "Follow a live cluster as a non-voting verifier",
+	Long: "Joins gossip advertising a TVU socket, receives the shreds of new blocks from\n" +
+		"Turbine, verifies them against the leader schedule, inserts them into the\n" +
+		"blockstore, recovers missing shreds from erasure codes, and replays completed\n" +
+		"slots from the bank of the snapshot.\n\n" +
+		"Leaders are only known for the epochs of the epoch stakes of the snapshot,\n" +
+		"so the snapshot should be recent.",
+	Args: cobra.NoArgs,
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagSnapshot      = flags.String("snapshot", "", "Path to full snapshot archive to start from")
+	flagDB            = flags.String("db", "", "Path to RocksDB to insert shreds into")
+	flagEntrypoints   = flags.StringSlice("entrypoint", nil, "Gossip entrypoint (<host>:<port>), repeatable")
+	flagShredVersion  = flags.Uint16("shred-version", 0, "Shred version of the cluster")
+	flagPublicIP      = flags.String("public-ip", "", "IP address advertised to the cluster")
+	flagGossipPort    = flags.Uint16("gossip-port", 8001, "Port of the gossip socket")
+	flagTVUPort       = flags.Uint16("tvu-port", 8002, "Port of the TVU socket, which receives shreds")
+	flagPullInterval  = flags.Duration("pull-interval", 2*time.Second, "Delay between pull requests")
+	flagStatsInterval = flags.Duration("stats-interval", 10*time.Second, "Delay between logs of the pipeline stats, 0 to disable")
+	flagSkipSigverify = flags.Bool("skip-sigverify", false, "Skip verification of transaction signatures")
+)
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(c *cobra.Command, _ []string) {
+	if *flagSnapshot == "" {
+		klog.Exit("No snapshot given")
+	}
+	if *flagDB == "" {
+		klog.Exit("No database given")
+	}
+	if len(*flagEntrypoints) == 0 {
+		klog.Exit("No entrypoint specified")
+	}
+	if *flagShredVersion == 0 {
+		klog.Exit("No shred version given")
+	}
+	publicIP, err := netip.ParseAddr(*flagPublicIP)
+	if err != nil {
+		klog.Exitf("Invalid public IP: %s", err)
+	}
+	entrypoints, err := gossip.ResolveEntrypoints(*flagEntrypoints)
+	if err != nil {
+		klog.Exit(err)
+	}
+
+	// Replay continues from the frozen bank of the snapshot slot.
+	accts := accounts.NewMemAccounts()
+	manifest, err := snapshot.LoadArchiveFromFile(*flagSnapshot, accts)
+	if err != nil {
+		klog.Exitf("Failed to load snapshot: %s", err)
+	}
+	rootBank := manifest.NewBank(accts, bank.LoadFeatures(accts, manifest.Bank.Slot))
+	rootHash, _ := rootBank.Hash()
+	klog.Infof("Loaded snapshot of slot %d: bank_hash=%s", rootBank.Slot, solana.Hash(rootHash))
+	leaders := newLeaderSchedules(manifest)
+
+	db, err := blockstore.OpenReadWrite(*flagDB)
+	if err != nil {
+		klog.Exitf("Failed to open blockstore: %s", err)
+	}
+	defer db.Close()
+
+	gossipConn, err := net.ListenUDP("udp", net.UDPAddrFromAddrPort(netip.AddrPortFrom(netip.IPv4Unspecified(), *flagGossipPort)))
+	if err != nil {
+		klog.Exit(err)
+	}
+	tvuConn, err := net.ListenUDP("udp", net.UDPAddrFromAddrPort(netip.AddrPortFrom(netip.IPv4Unspecified(), *flagTVUPort)))
+	if err != nil {
+		klog.Exit(err)
+	}
+
+	node, err := gossip.NewNode(gossipConn, entrypoints, *flagShredVersion)
+	if err != nil {
+		klog.Exit(err)
+	}
+	klog.Infof("Following as %s", solana.PublicKey(node.Pubkey))
+
+	// The contact info advertises the TVU socket, so that the node joins
+	// the Turbine trees of the cluster.
+	table := node.Table
+	node.Self.SetSockets(map[uint8]netip.AddrPort{
+		gossip.SocketGossip: netip.AddrPortFrom(publicIP, *flagGossipPort),
+		gossip.SocketTVU:    netip.AddrPortFrom(publicIP, *flagTVUPort),
+	})
+	stakes := nodeStakes(manifest, rootBank.Epoch)
+	pushClient := gossip.NewPushClient(node.Identity, gossipConn, table, *flagShredVersion)
+	pushClient.SetStakes(stakes)
+	node.Handler.PullServer = gossip.NewPullServer(node.Identity, gossipConn, table)
+	node.Handler.PushClient = pushClient
+
+	filter := tvu.NewShredFilter(*flagShredVersion, 0)
+	filter.SetRoot(rootBank.Slot)
+	fetcher := tvu.NewShredFetcher(tvuConn, filter)
+	verifier := tvu.NewSigVerifier(leaders.leader)
+	replayer := newReplayer(db, rootBank, filter)
+
+	fetched := make(chan [][]byte, 16)
+	verified := make(chan [][]byte, 16)
+	completed := make(chan uint64, 1024)
+
+	group, ctx := errgroup.WithContext(c.Context())
+	group.Go(func() error {
+		return node.Run(ctx, *flagPullInterval, func() error {
+			// the values of staked nodes outlive those of unstaked ones,
+			// and the contact info of this node is kept fresh
+			table.PurgeTimeouts(time.Now(), func(origin gossip.Pubkey) time.Duration {
+				if stakes[origin] != 0 {
+					return stakedCrdsTimeout
+				}
+				return gossip.CrdsTimeout
+			})
+			table.Trim(gossip.CrdsUniquePubkeyCapacity, stakes, node.Pubkey)
+			if err := insertSelf(table, node.Identity, node.Self); err != nil {
+				return err
+			}
+			if err := node.Handler.PushServer.SendPrunes(node.Identity, gossipConn, stakes); err != nil {
+				klog.Warningf("Failed to send prunes: %s", err)
+			}
+			pushClient.RotateActiveSet()
+			return nil
+		})
+	})
+	group.Go(func() error {
+		ticker := time.NewTicker(pushInterval)
+		defer ticker.Stop()
+		for {
+			if err := pushClient.PushNewValues(); err != nil {
+				klog.Warningf("Failed to push values: %s", err)
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+	group.Go(func() error {
+		return fetcher.Run(ctx, fetched)
+	})
+	group.Go(func() error {
+		verifier.Run(ctx, fetched, verified)
+		return nil
+	})
+	group.Go(func() error {
+		defer close(completed)
+		return tvu.InsertShreds(ctx, db, verified, func(stats *blockstore.InsertStats) {
+			replayer.NumRecovered.Add(uint64(stats.NumRecovered))
+			for _, slot := range stats.CompletedSlots {
+				select {
+				case completed <- slot:
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	})
+	group.Go(func() error {
+		for slot := range completed {
+			replayer.onCompleted(slot)
+		}
+		return nil
+	})
+	if *flagStatsInterval > 0 {
+		group.Go(func() error {
+			ticker := time.NewTicker(*flagStatsInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+				klog.Infof("[stats] gossip_values=%d pushed=%d pruned=%d packets=%d shreds_ok=%d shreds_dropped=%d "+
+					"sigverify_ok=%d sigverify_invalid=%d no_leader=%d recovered=%d replayed=%d root=%d",
+					table.Len(), pushClient.NumPushed.Load(), pushClient.NumPrunes.Load(), fetcher.NumPackets.Load(), filter.NumOK.Load(),
+					filter.NumInvalid.Load()+filter.NumVersion.Load()+filter.NumSlotWindow.Load()+
+						filter.NumDuplicate.Load()+filter.NumIndexBounds.Load(),
+					verifier.NumOK.Load(), verifier.NumInvalid.Load(), verifier.NumNoLeader.Load(),
+					replayer.NumRecovered.Load(), replayer.NumReplayed.Load(), filter.Root())
+			}
+		})
+	}
+
+	if err := group.Wait(); err != nil && err != context.Canceled {
+		klog.Exit(err)
+	}
+}
+
+// Intervals of gossip.
+const (
+	// pushInterval is the delay between pushes of new values.
+	//
+	// Based on solana_gossip::cluster_info::GOSSIP_SLEEP_MILLIS.
+	pushInterval = 100 * time.Millisecond
+	// stakedCrdsTimeout is how long the values of a staked node are kept
+	// without updates.
+	stakedCrdsTimeout = time.Hour
+)
+
+// insertSelf inserts the contact info of this node into the table, with
+// the current wallclock, such that it is pushed to peers.
+func insertSelf(table *gossip.CrdsTable, identity ed25519.PrivateKey, self *gossip.ContactInfoV2) error {
+	info := *self
+	info.Wallclock = gossip.Wallclock()
+	value := gossip.CrdsValue{Data: &gossip.CrdsData__ContactInfoV2{Value: info}}
+	if err := value.Sign(identity); err != nil {
+		return err
+	}
+	_, err := table.Insert(value, time.Now())
+	return err
+}
+
+// nodeStakes returns the stakes of the nodes in the epoch stakes of an
+// epoch of a snapshot.
+func nodeStakes(manifest *snapshot.Manifest, epoch uint64) map[gossip.Pubkey]uint64 {
+	stakes := make(map[gossip.Pubkey]uint64)
+	for _, epochStakes := range [][]snapshot.EpochStakes{manifest.Bank.EpochStakes, manifest.VersionedEpochStakes} {
+		for i := range epochStakes {
+			if epochStakes[i].Epoch != epoch {
+				continue
+			}
+			for _, node := range epochStakes[i].NodeIdToVoteAccounts {
+				stakes[node.NodeId] = node.TotalStake
+			}
+		}
+	}
+	return stakes
+}
+
+// leaderSchedules are the leader schedules of the epochs of the epoch
+// stakes of a snapshot.
+type leaderSchedules struct {
+	manifest  *snapshot.Manifest
+	schedules map[uint64]*leaderschedule.Schedule
+}
+
+func newLeaderSchedules(manifest *snapshot.Manifest) *leaderSchedules {
+	s := &leaderSchedules{
+		manifest:  manifest,
+		schedules: make(map[uint64]*leaderschedule.Schedule),
+	}
+	epochSchedule := &manifest.Bank.EpochSchedule
+	add := func(stakes []snapshot.EpochStakes) {
+		for i := range stakes {
+			es := &stakes[i]
+			s.schedules[es.Epoch] = leaderschedule.FromEpochStakes(es,
+				epochSchedule.GetFirstSlotInEpoch(es.Epoch), epochSchedule.GetSlotsInEpoch(es.Epoch))
+			klog.V(2).Infof("Computed leader schedule of epoch %d", es.Epoch)
+		}
+	}
+	add(manifest.Bank.EpochStakes)
+	add(manifest.VersionedEpochStakes)
+	return s
+}
+
+func (s *leaderSchedules) leader(slot uint64) ([32]byte, bool) {
+	schedule, ok := s.schedules[s.manifest.Bank.EpochSchedule.GetEpoch(slot)]
+	if !ok {
+		return [32]byte{}, false
+	}
+	return schedule.Leader(slot)
+}
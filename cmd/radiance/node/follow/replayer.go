@@ -0,0 +1,180 @@
+//go:build !lite
+
+package follow
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/poh"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/shred"
+	"go.firedancer.io/radiance/pkg/tvu"
+	"k8s.io/klog/v2"
+)
+
+// maxReplayedBanks is the number of recent banks kept by the replayer, the
+// descendants of which can be replayed.
+const maxReplayedBanks = 512
+
+// replayer replays the slots completed by the insertion of shreds, on top
+// of the banks of their parents. Slots completed before their parent are
+// replayed once their parent is.
+type replayer struct {
+	db     *blockstore.DB
+	filter *tvu.ShredFilter
+	root   uint64
+
+	banks map[uint64]*bank.Bank
+	// completed slots waiting for their parent, by parent
+	pending map[uint64][]uint64
+
+	NumReplayed  atomic.Uint64 // slots replayed
+	NumRecovered atomic.Uint64 // data shreds recovered by the insertion
+}
+
+func newReplayer(db *blockstore.DB, rootBank *bank.Bank, filter *tvu.ShredFilter) *replayer {
+	return &replayer{
+		db:      db,
+		filter:  filter,
+		root:    rootBank.Slot,
+		banks:   map[uint64]*bank.Bank{rootBank.Slot: rootBank},
+		pending: make(map[uint64][]uint64),
+	}
+}
+
+// onCompleted replays a completed slot, and the completed slots pending on
+// it, if its parent was replayed.
+func (r *replayer) onCompleted(slot uint64) {
+	if slot <= r.root {
+		return
+	}
+	meta, err := r.db.GetSlotMeta(slot)
+	if err != nil {
+		klog.Errorf("Slot %d: failed to get slot meta: %s", slot, err)
+		return
+	}
+	if meta.ParentSlot < r.root {
+		klog.V(2).Infof("Slot %d: parent slot %d precedes root %d", slot, meta.ParentSlot, r.root)
+		return
+	}
+	if _, ok := r.banks[meta.ParentSlot]; !ok {
+		r.pending[meta.ParentSlot] = append(r.pending[meta.ParentSlot], slot)
+		return
+	}
+
+	queue := []*blockstore.SlotMeta{meta}
+	for len(queue) != 0 {
+		meta := queue[0]
+		queue = queue[1:]
+		if err := r.replay(meta); err != nil {
+			klog.Errorf("Slot %d: %s", meta.Slot, err)
+			continue
+		}
+		children := r.pending[meta.Slot]
+		delete(r.pending, meta.Slot)
+		for _, child := range children {
+			childMeta, err := r.db.GetSlotMeta(child)
+			if err != nil {
+				klog.Errorf("Slot %d: failed to get slot meta: %s", child, err)
+				continue
+			}
+			queue = append(queue, childMeta)
+		}
+	}
+}
+
+// replay replays the entries of a full slot on a bank derived from the
+// bank of its parent, which is kept for the children of the slot.
+func (r *replayer) replay(meta *blockstore.SlotMeta) error {
+	parentBank := r.banks[meta.ParentSlot]
+	entries, err := r.db.GetEntries(meta, shred.RevisionV2)
+	if err != nil {
+		return fmt.Errorf("failed to get entries: %w", err)
+	}
+	var slotEntries []shred.Entry
+	for _, batch := range entries {
+		slotEntries = append(slotEntries, batch.Entries...)
+	}
+	for _, entry := range slotEntries {
+		if len(entry.Txns) == 0 || *flagSkipSigverify {
+			continue
+		}
+		txs := make([]*solana.Transaction, len(entry.Txns))
+		for k := range entry.Txns {
+			txs[k] = &entry.Txns[k]
+		}
+		for k, err := range sealevel.VerifyTransactionsSignatures(txs) {
+			if err != nil {
+				return fmt.Errorf("invalid tx %s: %w", txs[k].Signatures[0], err)
+			}
+		}
+	}
+	if err := poh.VerifyEntries(poh.State(parentBank.LastBlockhash()), slotEntries, runtime.NumCPU()); err != nil {
+		return err
+	}
+
+	slotBank, err := bank.NewBankFromParent(parentBank, meta.Slot)
+	if err != nil {
+		return err
+	}
+	var numTxs, numFailedTxs int
+	for _, entry := range slotEntries {
+		txs := make([]*solana.Transaction, len(entry.Txns))
+		for k := range entry.Txns {
+			txs[k] = &entry.Txns[k]
+		}
+		txMetas, err := slotBank.ProcessTransactions(txs)
+		for k, txMeta := range txMetas {
+			numTxs++
+			if txMeta.Err != nil {
+				numFailedTxs++
+				klog.V(3).Infof("Slot %d: tx %s failed: %s", meta.Slot, txs[k].Signatures[0], txMeta.Err)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("tx %s was not committed: %w", txs[len(txMetas)].Signatures[0], err)
+		}
+	}
+	if len(slotEntries) != 0 {
+		if err := slotBank.RegisterBlockhash(slotEntries[len(slotEntries)-1].Hash); err != nil {
+			return err
+		}
+	}
+	slotBank.Freeze()
+	bankHash, _ := slotBank.Hash()
+	klog.Infof("Slot %d: parent=%d entries=%d txs=%d failed=%d bank_hash=%s",
+		meta.Slot, meta.ParentSlot, len(slotEntries), numTxs, numFailedTxs, solana.Hash(bankHash))
+
+	r.banks[meta.Slot] = slotBank
+	r.NumReplayed.Add(1)
+	if meta.Slot >= r.root+maxReplayedBanks {
+		r.setRoot(slotBank, meta.Slot-maxReplayedBanks)
+	}
+	return nil
+}
+
+// setRoot treats the slots up to the given slot as rooted: their banks are
+// dropped, and their shreds are no longer accepted.
+func (r *replayer) setRoot(slotBank *bank.Bank, root uint64) {
+	if _, ok := r.banks[root]; ok {
+		slotBank.StatusCache.AddRoot(root)
+	}
+	for slot := range r.banks {
+		if slot < root {
+			delete(r.banks, slot)
+		}
+	}
+	for parent := range r.pending {
+		if parent < root {
+			delete(r.pending, parent)
+		}
+	}
+	slotBank.ProgramCache.Prune(root)
+	r.root = root
+	r.filter.SetRoot(root)
+}
@@ -0,0 +1,17 @@
+package node
+
+import (
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/radiance/node/follow"
+)
+
+var Cmd = cobra.Command{
+	Use:   "node",
+	Short: "Run a node on a live cluster",
+}
+
+func init() {
+	Cmd.AddCommand(
+		&follow.Cmd,
+	)
+}
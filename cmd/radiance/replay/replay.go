@@ -3,16 +3,28 @@
 package replay
 
 import (
-	"bytes"
 	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/spf13/cobra"
 	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/bigtable"
 	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/features"
 	"go.firedancer.io/radiance/pkg/genesis"
-	"go.firedancer.io/radiance/pkg/merkletree"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"go.firedancer.io/radiance/pkg/geyser/yellowstone"
 	"go.firedancer.io/radiance/pkg/poh"
+	"go.firedancer.io/radiance/pkg/rpcserver"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/shred"
+	"go.firedancer.io/radiance/pkg/snapshot"
 	"k8s.io/klog/v2"
 )
 
@@ -26,34 +38,118 @@ var Cmd = cobra.Command{
 var flags = Cmd.Flags()
 
 var (
-	flagGenesis string
-	flagDB      string
+	flagGenesis        string
+	flagSnapshot       string
+	flagSnapshotOut    string
+	flagDB             string
+	flagSkipSigverify  bool
+	flagVerifyBankHash bool
+	flagStartSlot      uint64
+	flagEndSlot        uint64
+	flagDiffRPC        string
+	flagGeyserLog      bool
+	flagGeyserGRPC     string
+	flagBigtable       string
+	flagRPC            string
+	flagHardForks      []uint
 )
 
 func init() {
-	flags.StringVar(&flagGenesis, "genesis", "", "Path to genesis")
+	flags.StringVar(&flagGenesis, "genesis", "", "Path to genesis archive, genesis.bin or ledger directory")
+	flags.StringVar(&flagSnapshot, "snapshot", "", "Path to full snapshot archive to start from, instead of genesis")
+	flags.StringVar(&flagSnapshotOut, "snapshot-out", "", "Directory to write a full snapshot archive of the last replayed slot to (requires --snapshot)")
 	flags.StringVar(&flagDB, "db", "", "Path to RocksDB")
+	flags.UintSliceVar(&flagHardForks, "hard-fork", nil, "Add a hard fork at this slot, in addition to those of the snapshot")
+	flags.BoolVar(&flagSkipSigverify, "skip-sigverify", false, "Skip verification of transaction signatures")
+	flags.BoolVar(&flagVerifyBankHash, "verify-bank-hash", false, "Assert bank hashes against the votes of later slots")
+	flags.Uint64Var(&flagStartSlot, "start-slot", 0, "First slot to print a summary of")
+	flags.Uint64Var(&flagEndSlot, "end-slot", 0, "Last slot to replay (0 for all)")
+	flags.StringVar(&flagDiffRPC, "diff-rpc", "", "Diff transaction results against getBlock of this RPC endpoint")
+	flags.BoolVar(&flagGeyserLog, "geyser-log", false, "Log the account writes, transactions and slot updates of replay")
+	flags.StringVar(&flagGeyserGRPC, "geyser-grpc", "", "Serve the updates of replay on this address as Yellowstone gRPC geyser")
+	flags.StringVar(&flagBigtable, "bigtable-upload", "", "Upload rooted blocks to the ledger warehouse of this Bigtable instance (projects/{project}/instances/{instance}), "+
+		"authenticated with the access token of $BIGTABLE_ACCESS_TOKEN")
+	flags.StringVar(&flagRPC, "rpc", "", "Serve the replayed accounts, simulating transactions on the last replayed bank, and the blocks of the database, as JSON-RPC on this address until replay ends")
 }
 
 func run(c *cobra.Command, _ []string) {
-	if flagGenesis == "" {
-		klog.Exit("No genesis given")
+	if flagGenesis == "" && flagSnapshot == "" {
+		klog.Exit("No genesis or snapshot given")
 	}
 	if flagDB == "" {
 		klog.Exit("No database given")
 	}
-
-	// Read genesis, containing the initial set of accounts.
-	genesisConfig, genesisHash, err := genesis.ReadGenesisFromFile(flagGenesis)
-	if err != nil {
-		klog.Exitf("Failed to read genesis: %s", err)
+	if flagSnapshotOut != "" && flagSnapshot == "" {
+		klog.Exit("--snapshot-out requires --snapshot")
 	}
-	klog.V(2).Infof("Genesis hash: %s", hex.EncodeToString(genesisHash[:]))
 
 	// Load initial accounts into memory.
 	// Obviously, an in-memory database won't cut it for later stages of replay.
 	accounts := accounts.NewMemAccounts()
-	genesisConfig.FillAccounts(accounts)
+
+	// PoH delay function (SHA-256 hash chain).
+	var chain poh.State
+
+	var rootBank *bank.Bank
+	var manifest *snapshot.Manifest
+	if flagSnapshot != "" {
+		// Replay continues from the frozen bank of the snapshot slot.
+		var err error
+		manifest, err = snapshot.LoadArchiveFromFile(flagSnapshot, accounts)
+		if err != nil {
+			klog.Exitf("Failed to load snapshot: %s", err)
+		}
+		rootBank = manifest.NewBank(accounts, bank.LoadFeatures(accounts, manifest.Bank.Slot))
+		registerHardForks(rootBank)
+		chain = rootBank.LastBlockhash()
+		rootHash, _ := rootBank.Hash()
+		klog.Infof("Loaded snapshot of slot %d: bank_hash=%s", rootBank.Slot, solana.Hash(rootHash))
+	} else {
+		rootBank, chain = newGenesisBank(accounts)
+	}
+
+	// Replay updates are streamed to the geyser plugins, starting with the
+	// initial accounts.
+	var notifier *geyser.Notifier
+	if flagGeyserLog || flagGeyserGRPC != "" || flagBigtable != "" || flagRPC != "" {
+		notifier = geyser.NewNotifier()
+	}
+	if flagGeyserLog {
+		notifier.AddPlugin(geyser.LogPlugin{})
+	}
+	if flagGeyserGRPC != "" {
+		server := yellowstone.NewServer(yellowstone.DefaultBufferSize)
+		notifier.AddPlugin(server)
+		go func() {
+			if err := server.ListenAndServe(c.Context(), flagGeyserGRPC); err != nil {
+				klog.Exitf("Failed to serve geyser gRPC: %s", err)
+			}
+		}()
+		klog.Infof("Serving geyser gRPC on %s", flagGeyserGRPC)
+	}
+	var uploader *bigtable.Uploader
+	if flagBigtable != "" {
+		client := bigtable.NewClient(flagBigtable, os.Getenv("BIGTABLE_ACCESS_TOKEN"))
+		uploader = bigtable.NewUploader(c.Context(), client, 64)
+		notifier.AddPlugin(uploader)
+	}
+	var accountsDB *rpcserver.AccountsDB
+	if flagRPC != "" {
+		// filled with the initial accounts at startup
+		accountsDB = rpcserver.NewAccountsDB(rootBank.Slot, nil)
+		notifier.AddPlugin(accountsDB)
+		// the bank of a genesis is set once its slot is replayed
+		if rootBank.IsFrozen() {
+			accountsDB.SetBank(rootBank)
+		}
+	}
+	if notifier != nil {
+		for pubkey, acct := range accounts.Map {
+			notifier.UpdateAccount(rootBank.Slot, pubkey, acct, nil, true)
+		}
+		notifier.NotifyEndOfStartup()
+		rootBank.Geyser = notifier
+	}
 
 	// Open blockstore database.
 	db, err := blockstore.OpenReadOnly(flagDB)
@@ -61,6 +157,16 @@ func run(c *cobra.Command, _ []string) {
 		klog.Exitf("Failed to open blockstore: %s", err)
 	}
 
+	if flagRPC != "" {
+		server := rpcserver.NewServer(db, accountsDB)
+		go func() {
+			if err := server.ListenAndServe(c.Context(), flagRPC); err != nil {
+				klog.Exitf("Failed to serve JSON-RPC: %s", err)
+			}
+		}()
+		klog.Infof("Serving JSON-RPC on %s", flagRPC)
+	}
+
 	// Open block iterator.
 	walker, err := blockstore.NewBlockWalk([]blockstore.WalkHandle{{DB: db}}, 2)
 	if err != nil {
@@ -68,58 +174,302 @@ func run(c *cobra.Command, _ []string) {
 	}
 	defer walker.Close()
 
-	// PoH delay function (SHA-256 hash chain).
-	var chain poh.State
-	chain = *genesisHash
+	banks := make(map[uint64]*bank.Bank)
+	if rootBank.IsFrozen() {
+		banks[rootBank.Slot] = rootBank
+	}
+
+	var rpcClient *rpc.Client
+	if flagDiffRPC != "" {
+		rpcClient = rpc.New(flagDiffRPC)
+	}
 
+	// Blocks are replayed from genesis or from the snapshot slot. Only the
+	// slots from the start slot are summarized.
+	diverged := false
+	lastBank := rootBank
 replay:
-	for slot := uint64(0); true; slot++ {
-		klog.V(2).Infof("Slot %d: %x", slot, chain)
+	for {
 		meta, ok := walker.Next()
 		if !ok {
 			break
 		}
+		if flagEndSlot != 0 && meta.Slot > flagEndSlot {
+			break
+		}
+		if rootBank.IsFrozen() && meta.Slot <= rootBank.Slot {
+			continue
+		}
+		klog.V(2).Infof("Slot %d: %x", meta.Slot, chain)
 		entries, err := walker.Entries(meta)
 		if err != nil {
-			klog.Errorf("Failed to get entries of block %d: %s", slot, err)
+			klog.Errorf("Failed to get entries of block %d: %s", meta.Slot, err)
+			diverged = true
 			break
 		}
-		cum := uint64(0) // Cumulative hash count between mixins
+		var slotEntries []shred.Entry
 		for i, batch := range entries {
 			for j, entry := range batch {
-				cum += entry.NumHashes
-				klog.V(7).Infof("Replay slot=%d entry=%02d/%02d hash=%s hash_cnt=%d cum=%d txs=%d",
-					slot, i, j, hex.EncodeToString(entry.Hash[:]), entry.NumHashes, cum, len(entry.Txns))
-				if len(entry.Txns) != 0 {
-					numHashes := entry.NumHashes
-					if numHashes > 1 {
-						chain.Hash(uint(entry.NumHashes - 1))
+				klog.V(7).Infof("Replay slot=%d entry=%02d/%02d hash=%s hash_cnt=%d txs=%d",
+					meta.Slot, i, j, hex.EncodeToString(entry.Hash[:]), entry.NumHashes, len(entry.Txns))
+				for _, tx := range entry.Txns {
+					if len(tx.Signatures) == 0 {
+						klog.Errorf("Invalid tx: Zero signatures")
+						diverged = true
+						break replay
 					}
+				}
 
-					var txSigs [][]byte
-					for _, tx := range entry.Txns {
-						if len(tx.Signatures) == 0 {
-							klog.Errorf("Invalid tx: Zero signatures")
+				if len(entry.Txns) != 0 && !flagSkipSigverify {
+					txs := make([]*solana.Transaction, len(entry.Txns))
+					for k := range entry.Txns {
+						txs[k] = &entry.Txns[k]
+					}
+					for k, err := range sealevel.VerifyTransactionsSignatures(txs) {
+						if err != nil {
+							klog.Errorf("Invalid tx %s: %s", txs[k].Signatures[0], err)
+							diverged = true
 							break replay
 						}
-						for i := range tx.Signatures {
-							txSigs = append(txSigs, tx.Signatures[i][:])
-						}
 					}
-
-					sigTree := merkletree.HashNodes(txSigs)
-					klog.V(7).Infof("Mixin: %x", sigTree.GetRoot()[:])
-					chain.Record(sigTree.GetRoot())
-					cum = 0
-				} else {
-					chain.Hash(uint(entry.NumHashes))
 				}
-				if !bytes.Equal(entry.Hash[:], chain[:]) {
-					klog.Errorf("PoH mismatch! expected %s, actual %s",
-						entry.Hash, solana.Hash(chain))
+			}
+			slotEntries = append(slotEntries, batch...)
+		}
+
+		if err := poh.VerifyEntries(chain, slotEntries, runtime.NumCPU()); err != nil {
+			klog.Errorf("Slot %d: %s", meta.Slot, err)
+			diverged = true
+			break replay
+		}
+		if len(slotEntries) != 0 {
+			chain = poh.State(slotEntries[len(slotEntries)-1].Hash)
+		}
+
+		slotBank := rootBank
+		if rootBank.IsFrozen() || meta.Slot != rootBank.Slot {
+			parentBank, ok := banks[meta.ParentSlot]
+			if !ok {
+				klog.Errorf("Slot %d: parent slot %d was not replayed", meta.Slot, meta.ParentSlot)
+				diverged = true
+				break replay
+			}
+			slotBank, err = bank.NewBankFromParent(parentBank, meta.Slot)
+			if err != nil {
+				klog.Errorf("Slot %d: %s", meta.Slot, err)
+				diverged = true
+				break replay
+			}
+			if notifier != nil {
+				notifier.UpdateSlotStatus(meta.Slot, meta.ParentSlot, geyser.SlotStatusCreatedBank)
+			}
+		}
+
+		var replayed []replayedTx
+		var numFailedTxs int
+		for _, entry := range slotEntries {
+			txs := make([]*solana.Transaction, len(entry.Txns))
+			for k := range entry.Txns {
+				txs[k] = &entry.Txns[k]
+				if flagVerifyBankHash && !verifyVotedBankHashes(txs[k], banks) {
+					diverged = true
 					break replay
 				}
 			}
+			txMetas, err := slotBank.ProcessTransactions(txs)
+			for k, txMeta := range txMetas {
+				replayed = append(replayed, replayedTx{Signature: txs[k].Signatures[0], Meta: txMeta})
+				if txMeta.Err != nil {
+					numFailedTxs++
+					klog.V(3).Infof("Slot %d: tx %s failed: %s", meta.Slot, txs[k].Signatures[0], txMeta.Err)
+				}
+			}
+			if err != nil {
+				klog.Errorf("Slot %d: tx %s was not committed: %s", meta.Slot, txs[len(txMetas)].Signatures[0], err)
+				diverged = true
+				break replay
+			}
+		}
+		if len(slotEntries) != 0 {
+			if err := slotBank.RegisterBlockhash(slotEntries[len(slotEntries)-1].Hash); err != nil {
+				klog.Fatal(err)
+			}
+		}
+		slotBank.Freeze()
+		bankHash, _ := slotBank.Hash()
+		if accountsDB != nil {
+			accountsDB.SetBank(slotBank)
+		}
+		if notifier != nil {
+			var parentBlockhash [32]byte
+			if parentBank, ok := banks[meta.ParentSlot]; ok {
+				parentBlockhash = parentBank.LastBlockhash()
+			}
+			notifier.NotifyBlockMetadata(&geyser.BlockMetadata{
+				Slot:                     meta.Slot,
+				ParentSlot:               meta.ParentSlot,
+				Blockhash:                slotBank.LastBlockhash(),
+				ParentBlockhash:          parentBlockhash,
+				BlockHeight:              slotBank.BlockHeight,
+				ExecutedTransactionCount: uint64(len(replayed)),
+				EntryCount:               uint64(len(slotEntries)),
+			})
+			notifier.UpdateSlotStatus(meta.Slot, meta.ParentSlot, geyser.SlotStatusProcessed)
+		}
+		if meta.Slot >= flagStartSlot {
+			cacheStats := slotBank.ProgramCache.Stats()
+			klog.Infof("Slot %d: parent=%d entries=%d txs=%d failed=%d sigs=%d bank_hash=%s "+
+				"programs=%d program_cache_hits=%d program_cache_misses=%d program_cache_evictions=%d",
+				meta.Slot, meta.ParentSlot, len(slotEntries), len(replayed), numFailedTxs,
+				slotBank.SignatureCount, solana.Hash(bankHash),
+				slotBank.ProgramCache.Len(), cacheStats.Hits, cacheStats.Misses, cacheStats.Evictions)
+		}
+
+		if rpcClient != nil && meta.Slot >= flagStartSlot {
+			block, err := fetchBlock(c.Context(), rpcClient, meta.Slot)
+			if err != nil {
+				klog.Exitf("Failed to fetch block %d from RPC: %s", meta.Slot, err)
+			}
+			diff, err := diffBlock(meta.Slot, replayed, block)
+			if err != nil {
+				klog.Exitf("Failed to diff block %d: %s", meta.Slot, err)
+			}
+			if diff != nil {
+				klog.Error(diff.String())
+				diverged = true
+				break replay
+			}
+		}
+
+		banks[meta.Slot] = slotBank
+		lastBank = slotBank
+		if meta.Slot >= maxReplayedBanks {
+			// banks older than the replayed ones are treated as rooted, so
+			// programs they can no longer observe are dropped from the cache,
+			// and their transactions are visible from all forks
+			rootSlot := meta.Slot - maxReplayedBanks
+			// rootSlot may have been skipped, so every bank up to it is
+			// rooted and dropped, in slot order
+			var rooted []uint64
+			for slot := range banks {
+				if slot <= rootSlot {
+					rooted = append(rooted, slot)
+				}
+			}
+			sort.Slice(rooted, func(i, j int) bool { return rooted[i] < rooted[j] })
+			for _, slot := range rooted {
+				slotBank.StatusCache.AddRoot(slot)
+				if notifier != nil {
+					notifier.UpdateSlotStatus(slot, banks[slot].ParentSlot, geyser.SlotStatusRooted)
+				}
+				delete(banks, slot)
+			}
+			slotBank.ProgramCache.Prune(rootSlot)
+		}
+	}
+
+	if uploader != nil {
+		if err := uploader.Close(); err != nil {
+			klog.Errorf("Failed to upload blocks to Bigtable: %s", err)
+		}
+		klog.Infof("Uploaded %d blocks to Bigtable", uploader.NumUploaded())
+	}
+
+	if diverged {
+		klog.Exit("Replay diverged")
+	}
+
+	if flagSnapshotOut != "" {
+		if err := writeSnapshot(flagSnapshotOut, lastBank, manifest, accounts); err != nil {
+			klog.Exitf("Failed to write snapshot: %s", err)
+		}
+	}
+}
+
+// writeSnapshot writes a full snapshot of the last replayed bank to the
+// given directory, named after its snapshot hash. Its accounts are the
+// replayed ones, and it descends from the bank of the snapshot replay
+// started from.
+func writeSnapshot(dir string, lastBank *bank.Bank, base *snapshot.Manifest, accts accounts.MemAccounts) error {
+	storages, capitalization, err := snapshot.PackAccounts(lastBank.Slot, accts.Map, snapshot.DefaultStorageSize)
+	if err != nil {
+		return err
+	}
+	manifest, err := snapshot.NewManifest(lastBank, base, capitalization)
+	if err != nil {
+		return err
+	}
+	fpath := filepath.Join(dir, snapshot.ArchiveName(lastBank.Slot, manifest.ComputeHash(accts.Map)))
+	if err := snapshot.WriteArchiveToFile(fpath, manifest, storages); err != nil {
+		return err
+	}
+	klog.Infof("Wrote snapshot of slot %d to %s: %d storages, capitalization=%d",
+		lastBank.Slot, fpath, len(storages), capitalization)
+	return nil
+}
+
+// newGenesisBank returns the bank of slot 0, with the accounts of genesis,
+// and the genesis hash as its only blockhash, which starts the PoH chain.
+func newGenesisBank(accts accounts.MemAccounts) (*bank.Bank, poh.State) {
+	// Read genesis, containing the initial set of accounts.
+	genesisConfig, genesisHash, err := genesis.ReadGenesisFromFile(flagGenesis)
+	if err != nil {
+		klog.Exitf("Failed to read genesis: %s", err)
+	}
+	klog.V(2).Infof("Genesis hash: %s", hex.EncodeToString(genesisHash[:]))
+	rootBank, err := bank.NewGenesisBank(genesisConfig, *genesisHash, accts, features.NewFeaturesDefault())
+	if err != nil {
+		klog.Fatal(err)
+	}
+	registerHardForks(rootBank)
+	klog.Infof("Shred version: %d", rootBank.HardForks.ShredVersion(*genesisHash))
+	return rootBank, poh.State(*genesisHash)
+}
+
+// registerHardForks registers the hard forks given by flags with the root
+// bank, which its descendants share, unless it has them already.
+func registerHardForks(rootBank *bank.Bank) {
+	registered := make(map[uint64]bool)
+	for _, fork := range rootBank.HardForks.Forks() {
+		registered[fork.Slot] = true
+	}
+	for _, flagSlot := range flagHardForks {
+		slot := uint64(flagSlot)
+		if registered[slot] {
+			continue
+		}
+		if !rootBank.RegisterHardFork(slot) {
+			klog.Warningf("Hard fork at slot %d ignored, slot %d is already rooted", slot, rootBank.Slot)
+		}
+	}
+}
+
+// maxReplayedBanks is the number of recent banks kept by replay, whose bank
+// hashes votes are checked against.
+const maxReplayedBanks = 512
+
+// verifyVotedBankHashes checks the bank hashes voted on by the vote
+// instructions of a transaction against the replayed banks, and returns
+// false on a mismatch. Votes for slots not replayed are ignored.
+func verifyVotedBankHashes(tx *solana.Transaction, banks map[uint64]*bank.Bank) bool {
+	for _, instr := range tx.Message.Instructions {
+		if int(instr.ProgramIDIndex) >= len(tx.Message.AccountKeys) ||
+			tx.Message.AccountKeys[instr.ProgramIDIndex] != sealevel.VoteProgramAddr {
+			continue
+		}
+		votedSlot, votedHash, ok := sealevel.VotedBankHash(instr.Data)
+		if !ok {
+			continue
+		}
+		votedBank, ok := banks[votedSlot]
+		if !ok {
+			continue
+		}
+		if bankHash, _ := votedBank.Hash(); bankHash != votedHash {
+			klog.Errorf("Bank hash mismatch in slot %d! voted %s, actual %s",
+				votedSlot, solana.Hash(votedHash), solana.Hash(bankHash))
+			return false
 		}
 	}
+	return true
 }
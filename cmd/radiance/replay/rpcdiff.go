@@ -0,0 +1,154 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"go.firedancer.io/radiance/pkg/rpcserver"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// replayedTx is a transaction replayed in a slot, and its status meta as
+// computed by replay.
+type replayedTx struct {
+	Signature solana.Signature
+	Meta      *sealevel.TransactionStatusMeta
+}
+
+// fieldDiff is a field of a transaction status that differs between the
+// expected status, as returned by RPC, and the replayed one.
+type fieldDiff struct {
+	Field    string
+	Expected interface{}
+	Actual   interface{}
+}
+
+// txDiff describes how a replayed transaction differs from the transaction
+// at the same index of the block returned by RPC.
+type txDiff struct {
+	Slot      uint64
+	Index     int
+	Signature solana.Signature
+	Fields    []fieldDiff
+}
+
+func (d *txDiff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "slot %d tx %d (%s) differs from RPC:", d.Slot, d.Index, d.Signature)
+	for _, field := range d.Fields {
+		fmt.Fprintf(&b, "\n  %s: expected %v, actual %v", field.Field, field.Expected, field.Actual)
+	}
+	return b.String()
+}
+
+// fetchBlock fetches the transactions of a confirmed block, with their
+// status meta, from an RPC endpoint.
+func fetchBlock(ctx context.Context, client *rpc.Client, slot uint64) (*rpc.GetBlockResult, error) {
+	maxVersion := uint64(0)
+	rewards := false
+	return client.GetBlockWithOpts(ctx, slot, &rpc.GetBlockOpts{
+		Encoding:                       solana.EncodingBase64,
+		TransactionDetails:             rpc.TransactionDetailsFull,
+		Rewards:                        &rewards,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxVersion,
+	})
+}
+
+// diffBlock compares the replayed transactions of a slot with the block
+// returned by RPC, and returns the first transaction that differs, or nil.
+func diffBlock(slot uint64, replayed []replayedTx, block *rpc.GetBlockResult) (*txDiff, error) {
+	for i := range replayed {
+		if i >= len(block.Transactions) {
+			return &txDiff{Slot: slot, Index: i, Signature: replayed[i].Signature, Fields: []fieldDiff{
+				{Field: "transaction", Expected: "none", Actual: replayed[i].Signature},
+			}}, nil
+		}
+		expected := &block.Transactions[i]
+		tx, err := expected.GetTransaction()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tx %d of slot %d: %w", i, slot, err)
+		}
+		if expected.Meta == nil {
+			return nil, fmt.Errorf("tx %d of slot %d has no status meta", i, slot)
+		}
+		if diff := diffTx(slot, i, tx, expected.Meta, &replayed[i]); diff != nil {
+			return diff, nil
+		}
+	}
+	if len(block.Transactions) > len(replayed) {
+		extra := &block.Transactions[len(replayed)]
+		var sig solana.Signature
+		if tx, err := extra.GetTransaction(); err == nil && len(tx.Signatures) != 0 {
+			sig = tx.Signatures[0]
+		}
+		return &txDiff{Slot: slot, Index: len(replayed), Signature: sig, Fields: []fieldDiff{
+			{Field: "transaction", Expected: sig, Actual: "none"},
+		}}, nil
+	}
+	return nil, nil
+}
+
+// diffTx compares a replayed transaction with the expected one.
+func diffTx(slot uint64, index int, tx *solana.Transaction, expected *rpc.TransactionMeta, actual *replayedTx) *txDiff {
+	diff := &txDiff{Slot: slot, Index: index, Signature: actual.Signature}
+
+	if len(tx.Signatures) == 0 || tx.Signatures[0] != actual.Signature {
+		var expectedSig solana.Signature
+		if len(tx.Signatures) != 0 {
+			expectedSig = tx.Signatures[0]
+		}
+		diff.Fields = append(diff.Fields, fieldDiff{Field: "signature", Expected: expectedSig, Actual: actual.Signature})
+		return diff
+	}
+
+	expectedErr, _ := json.Marshal(expected.Err)
+	actualErr, _ := json.Marshal(rpcserver.TransactionError(actual.Meta.Err))
+	if string(expectedErr) != string(actualErr) {
+		diff.Fields = append(diff.Fields, fieldDiff{Field: "err", Expected: string(expectedErr), Actual: string(actualErr)})
+	}
+	if expected.Fee != actual.Meta.Fee {
+		diff.Fields = append(diff.Fields, fieldDiff{Field: "fee", Expected: expected.Fee, Actual: actual.Meta.Fee})
+	}
+	diff.Fields = append(diff.Fields, diffBalances("preBalances", expected.PreBalances, actual.Meta.PreBalances)...)
+	diff.Fields = append(diff.Fields, diffBalances("postBalances", expected.PostBalances, actual.Meta.PostBalances)...)
+	diff.Fields = append(diff.Fields, diffLogs(expected.LogMessages, actual.Meta.LogMessages)...)
+
+	if len(diff.Fields) == 0 {
+		return nil
+	}
+	return diff
+}
+
+// diffBalances returns the first balance that differs.
+func diffBalances(field string, expected, actual []uint64) []fieldDiff {
+	if len(expected) != len(actual) {
+		return []fieldDiff{{Field: field + ".len", Expected: len(expected), Actual: len(actual)}}
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			return []fieldDiff{{Field: fmt.Sprintf("%s[%d]", field, i), Expected: expected[i], Actual: actual[i]}}
+		}
+	}
+	return nil
+}
+
+// diffLogs returns the first log message that differs.
+func diffLogs(expected, actual []string) []fieldDiff {
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		if i >= len(expected) {
+			return []fieldDiff{{Field: fmt.Sprintf("logMessages[%d]", i), Expected: "none", Actual: actual[i]}}
+		}
+		if i >= len(actual) {
+			return []fieldDiff{{Field: fmt.Sprintf("logMessages[%d]", i), Expected: expected[i], Actual: "none"}}
+		}
+		if expected[i] != actual[i] {
+			return []fieldDiff{{Field: fmt.Sprintf("logMessages[%d]", i), Expected: expected[i], Actual: actual[i]}}
+		}
+	}
+	return nil
+}
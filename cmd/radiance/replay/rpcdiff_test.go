@@ -0,0 +1,52 @@
+package replay
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func TestDiffTx(t *testing.T) {
+	tx := &solana.Transaction{Signatures: []solana.Signature{{1}}}
+	var expectedErr interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"InstructionError":[0,{"Custom":1}]}`), &expectedErr))
+	expected := &rpc.TransactionMeta{
+		Err:          expectedErr,
+		Fee:          5000,
+		PreBalances:  []uint64{10, 20},
+		PostBalances: []uint64{5, 20},
+		LogMessages:  []string{"Program 11111111111111111111111111111111 invoke [1]"},
+	}
+	actual := &replayedTx{Signature: solana.Signature{1}, Meta: &sealevel.TransactionStatusMeta{
+		Err:          sealevel.TxErrInstructionError{Index: 0, Err: sealevel.InstrErrCustom{Code: 1}},
+		Fee:          5000,
+		PreBalances:  []uint64{10, 20},
+		PostBalances: []uint64{5, 20},
+		LogMessages:  []string{"Program 11111111111111111111111111111111 invoke [1]"},
+	}}
+	assert.Nil(t, diffTx(7, 0, tx, expected, actual))
+
+	// only the first differing balance and log message are reported
+	actual.Meta.Err = nil
+	actual.Meta.PostBalances = []uint64{4, 21}
+	actual.Meta.LogMessages = nil
+	diff := diffTx(7, 0, tx, expected, actual)
+	require.NotNil(t, diff)
+	assert.Equal(t, []fieldDiff{
+		{Field: "err", Expected: `{"InstructionError":[0,{"Custom":1}]}`, Actual: `null`},
+		{Field: "postBalances[0]", Expected: uint64(5), Actual: uint64(4)},
+		{Field: "logMessages[0]", Expected: "Program 11111111111111111111111111111111 invoke [1]", Actual: "none"},
+	}, diff.Fields)
+
+	// transactions out of order differ by signature only
+	actual.Signature = solana.Signature{2}
+	diff = diffTx(7, 0, tx, expected, actual)
+	require.NotNil(t, diff)
+	assert.Equal(t, "signature", diff.Fields[0].Field)
+	assert.Len(t, diff.Fields, 1)
+}
@@ -0,0 +1,19 @@
+//go:build !lite
+
+package rpc
+
+import (
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/radiance/rpc/serve"
+)
+
+var Cmd = cobra.Command{
+	Use:   "rpc",
+	Short: "Serve the JSON-RPC API",
+}
+
+func init() {
+	Cmd.AddCommand(
+		&serve.Cmd,
+	)
+}
@@ -0,0 +1,7 @@
+//go:build lite
+
+package rpc
+
+import "github.com/spf13/cobra"
+
+var Cmd cobra.Command
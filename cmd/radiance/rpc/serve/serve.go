@@ -0,0 +1,69 @@
+//go:build !lite
+
+package serve
+
+import (
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/radiance/accountsdb/util"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/rpcserver"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "serve [rocksdb]",
+	Short: "Serve the JSON-RPC API from a blockstore and a snapshot",
+	Long: "Serves getBlock, getTransaction and getSignaturesForAddress from the full slots of a blockstore,\n" +
+		"whether or not they were rooted, and getAccountInfo and getMultipleAccounts from the accounts\n" +
+		"of a snapshot, on the bank of which simulateTransaction executes transactions.",
+	Args: cobra.MaximumNArgs(1),
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagListen   = flags.String("listen", ":8899", "Address to serve JSON-RPC on")
+	flagSnapshot = flags.String("snapshot", "", "Path to a full snapshot archive to serve the accounts of, and to simulate transactions on")
+)
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(c *cobra.Command, args []string) {
+	if len(args) == 0 && *flagSnapshot == "" {
+		klog.Exit("No blockstore or snapshot given")
+	}
+
+	var store rpcserver.Blockstore
+	if len(args) != 0 {
+		db, err := blockstore.OpenReadOnly(args[0])
+		if err != nil {
+			klog.Exitf("Failed to open blockstore: %s", err)
+		}
+		defer db.Close()
+		store = db
+	}
+
+	var accountsDB *rpcserver.AccountsDB
+	if *flagSnapshot != "" {
+		manifest, accts, err := util.LoadSnapshot(*flagSnapshot, func([32]byte, *accounts.Account) bool {
+			return true
+		})
+		if err != nil {
+			klog.Exitf("Failed to load snapshot: %s", err)
+		}
+		klog.Infof("Loaded %d accounts of snapshot of slot %d", len(accts), manifest.Bank.Slot)
+		accountsDB = rpcserver.NewAccountsDB(manifest.Bank.Slot, accts)
+		memAccts := accounts.MemAccounts{Map: accts}
+		accountsDB.SetBank(manifest.NewBank(memAccts, bank.LoadFeatures(memAccts, manifest.Bank.Slot)))
+	}
+
+	server := rpcserver.NewServer(store, accountsDB)
+	klog.Infof("Serving JSON-RPC on %s", *flagListen)
+	if err := server.ListenAndServe(c.Context(), *flagListen); err != nil {
+		klog.Exit(err)
+	}
+}
@@ -0,0 +1,172 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	mrand "math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/gossip"
+	"go.firedancer.io/radiance/pkg/snapshot"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "download",
+	Short: "Download the latest snapshots of a cluster",
+	Long: "Joins gossip as a spy, collects the snapshot hashes advertised by the nodes,\n" +
+		"picks the latest full and incremental snapshot, trusting only the hashes of\n" +
+		"the known validators if any are given, and downloads them over HTTP from the\n" +
+		"RPC port of a node serving them. Interrupted downloads are resumed.",
+	Args: cobra.NoArgs,
+}
+
+var flags = Cmd.Flags()
+
+var (
+	flagEntrypoints     = flags.StringSlice("entrypoint", nil, "Gossip entrypoint (<host>:<port>), repeatable")
+	flagShredVersion    = flags.Uint16("shred-version", 0, "Shred version of the cluster (0 for any)")
+	flagKnownValidators = flags.StringSlice("known-validator", nil, "Pubkey of a validator whose snapshot hashes are trusted, repeatable")
+	flagOut             = flags.String("out", ".", "Directory to download snapshot archives to")
+	flagNoIncremental   = flags.Bool("no-incremental", false, "Only download the full snapshot")
+	flagDiscovery       = flags.Duration("discovery", 15*time.Second, "How long to collect snapshot hashes before picking one")
+	flagTimeout         = flags.Duration("timeout", 2*time.Minute, "How long to wait for a node serving snapshots")
+	flagRetries         = flags.Int("retries", 5, "Attempts to download from a node before trying the next one")
+)
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(c *cobra.Command, _ []string) {
+	if len(*flagEntrypoints) == 0 {
+		klog.Exit("No entrypoint specified")
+	}
+	entrypoints, err := gossip.ResolveEntrypoints(*flagEntrypoints)
+	if err != nil {
+		klog.Exit(err)
+	}
+	knownValidators := make(map[gossip.Pubkey]struct{})
+	for _, s := range *flagKnownValidators {
+		pubkey, err := solana.PublicKeyFromBase58(s)
+		if err != nil {
+			klog.Exitf("Invalid known validator %s: %s", s, err)
+		}
+		knownValidators[gossip.Pubkey(pubkey)] = struct{}{}
+	}
+
+	sources, err := discover(c.Context(), entrypoints, knownValidators)
+	if err != nil {
+		klog.Exit(err)
+	}
+	// nodes advertising other hashes for the same slots are not tried
+	best := sources[0]
+	var matching []gossip.SnapshotSource
+	for _, source := range sources {
+		if source.Full == best.Full && (best.Incremental == nil || *source.Incremental == *best.Incremental) {
+			matching = append(matching, source)
+		}
+	}
+	sources = matching
+	klog.Infof("Found %d nodes serving full snapshot of slot %d", len(sources), best.Full.Slot)
+
+	full := snapshot.ArchiveName(best.Full.Slot, best.Full.Hash)
+	if err := downloadFromSources(c.Context(), sources, full); err != nil {
+		klog.Exitf("Failed to download full snapshot: %s", err)
+	}
+	if *flagNoIncremental || best.Incremental == nil {
+		return
+	}
+	incremental := snapshot.IncrementalArchiveName(best.Full.Slot, best.Incremental.Slot, best.Incremental.Hash)
+	if err := downloadFromSources(c.Context(), sources, incremental); err != nil {
+		klog.Exitf("Failed to download incremental snapshot: %s", err)
+	}
+}
+
+// discover pulls from gossip until the discovery time passed and nodes
+// serving snapshots were found, and returns them in random order.
+func discover(ctx context.Context, entrypoints []netip.AddrPort, knownValidators map[gossip.Pubkey]struct{}) ([]gossip.SnapshotSource, error) {
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	node, err := gossip.NewNode(conn, entrypoints, *flagShredVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []gossip.SnapshotSource
+	discoverCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	start := time.Now()
+	err = node.Run(discoverCtx, 2*time.Second, func() error {
+		sources = gossip.SelectSnapshotSources(gossip.SnapshotSources(node.Table, *flagShredVersion), knownValidators)
+		elapsed := time.Since(start)
+		if len(sources) > 0 && elapsed >= *flagDiscovery {
+			cancel()
+			return nil
+		}
+		if elapsed >= *flagTimeout {
+			return fmt.Errorf("no node serving snapshots found in %s", *flagTimeout)
+		}
+		klog.V(2).Infof("Discovered %d values, %d snapshot sources", node.Table.Len(), len(sources))
+		return nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	mrand.Shuffle(len(sources), func(i, j int) {
+		sources[i], sources[j] = sources[j], sources[i]
+	})
+	return sources, nil
+}
+
+// downloadFromSources downloads a snapshot archive into the output
+// directory, unless it is there already, trying the sources in order.
+func downloadFromSources(ctx context.Context, sources []gossip.SnapshotSource, name string) error {
+	fpath := filepath.Join(*flagOut, name)
+	if _, err := os.Stat(fpath); err == nil {
+		klog.Infof("%s exists, skipping download", fpath)
+		return nil
+	}
+	client := &http.Client{}
+	var lastErr error
+	for _, source := range sources {
+		url := fmt.Sprintf("http://%s/%s", source.RPC, name)
+		for attempt := 0; attempt < *flagRetries; attempt++ {
+			klog.Infof("Downloading %s from %s", url, solana.PublicKey(source.Node))
+			var lastLog time.Time
+			err := snapshot.Download(ctx, client, url, fpath, func(written, total int64) {
+				if time.Since(lastLog) < 5*time.Second {
+					return
+				}
+				lastLog = time.Now()
+				if total > 0 {
+					klog.Infof("%s: %d/%d MiB (%.1f%%)", name, written>>20, total>>20, 100*float64(written)/float64(total))
+				} else {
+					klog.Infof("%s: %d MiB", name, written>>20)
+				}
+			})
+			if err == nil {
+				klog.Infof("Downloaded %s", fpath)
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			klog.Warningf("Failed to download %s: %s", url, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
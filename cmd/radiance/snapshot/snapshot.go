@@ -0,0 +1,19 @@
+package snapshot
+
+import (
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/radiance/snapshot/download"
+	"go.firedancer.io/radiance/cmd/radiance/snapshot/verify"
+)
+
+var Cmd = cobra.Command{
+	Use:   "snapshot",
+	Short: "Download and inspect snapshot archives",
+}
+
+func init() {
+	Cmd.AddCommand(
+		&download.Cmd,
+		&verify.Cmd,
+	)
+}
@@ -0,0 +1,49 @@
+package verify
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/snapshot"
+	"k8s.io/klog/v2"
+)
+
+var Cmd = cobra.Command{
+	Use:   "verify <snapshot-<slot>-<hash>.tar.zst>",
+	Short: "Verify the hash of a snapshot archive",
+	Long: "Loads the accounts of a full snapshot archive and checks its\n" +
+		"snapshot hash against the one in the file name.",
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	Cmd.Run = run
+}
+
+func run(_ *cobra.Command, args []string) {
+	fpath := args[0]
+	slot, expected, err := snapshot.ParseArchiveName(fpath)
+	if err != nil {
+		klog.Exit(err)
+	}
+
+	start := time.Now()
+	accts := accounts.NewMemAccounts()
+	manifest, err := snapshot.LoadArchiveFromFile(fpath, accts)
+	if err != nil {
+		klog.Exitf("failed to load snapshot: %s", err)
+	}
+	klog.Infof("Loaded %d accounts of slot %d in %s", len(accts.Map), manifest.Bank.Slot, time.Since(start))
+	if manifest.Bank.Slot != slot {
+		klog.Exitf("snapshot is of slot %d, but named after slot %d", manifest.Bank.Slot, slot)
+	}
+
+	hash := manifest.ComputeHash(accts.Map)
+	if hash != expected {
+		klog.Exitf("snapshot hash mismatch: computed %s, expected %s",
+			base58.Encode(hash[:]), base58.Encode(expected[:]))
+	}
+	klog.Infof("Snapshot hash %s of slot %d verified", base58.Encode(hash[:]), slot)
+}
@@ -13,15 +13,25 @@ import (
 )
 
 func CodeShreds(t testing.TB, network string, slot uint64) [][]byte {
-	return shreds(t, network, slot, 'c')
+	return shreds(t, 'c', network, strconv.FormatUint(slot, 10))
 }
 
 func DataShreds(t testing.TB, network string, slot uint64) [][]byte {
-	return shreds(t, network, slot, 'd')
+	return shreds(t, 'd', network, strconv.FormatUint(slot, 10))
 }
 
-func shreds(t testing.TB, network string, slot uint64, shredType rune) [][]byte {
-	dir := Path(t, "shreds", network, strconv.FormatUint(slot, 10))
+// MerkleCodeShreds and MerkleDataShreds return the merkle shreds of a
+// localnet slot, of which each data shred is its own erasure set.
+func MerkleCodeShreds(t testing.TB) [][]byte {
+	return shreds(t, 'c', "localnet", "merkle")
+}
+
+func MerkleDataShreds(t testing.TB) [][]byte {
+	return shreds(t, 'd', "localnet", "merkle")
+}
+
+func shreds(t testing.TB, shredType rune, dirs ...string) [][]byte {
+	dir := Path(t, append([]string{"shreds"}, dirs...)...)
 	entries, err := os.ReadDir(dir)
 	require.NoError(t, err, "cannot open shreds")
 	sort.Slice(entries, func(i, j int) bool {
@@ -0,0 +1,114 @@
+package accounts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+
+	"github.com/zeebo/blake3"
+)
+
+// MerkleFanout is the number of children of each node of the Merkle tree
+// over account hashes.
+const MerkleFanout = 16
+
+// Hash returns the hash of the account stored at the given address.
+// Accounts without lamports do not exist, and hash to zero.
+//
+// Based on solana_accounts_db::accounts_db::AccountsDb::hash_account.
+func (a *Account) Hash(pubkey [32]byte) [32]byte {
+	if a.Lamports == 0 {
+		return [32]byte{}
+	}
+
+	var buf [8]byte
+	hasher := blake3.New()
+	binary.LittleEndian.PutUint64(buf[:], a.Lamports)
+	hasher.Write(buf[:])
+	binary.LittleEndian.PutUint64(buf[:], a.RentEpoch)
+	hasher.Write(buf[:])
+	hasher.Write(a.Data)
+	if a.Executable {
+		hasher.Write([]byte{1})
+	} else {
+		hasher.Write([]byte{0})
+	}
+	hasher.Write(a.Owner[:])
+	hasher.Write(pubkey[:])
+
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	return hash
+}
+
+// PubkeyHash is the hash of the account stored at an address.
+type PubkeyHash struct {
+	Pubkey [32]byte
+	Hash   [32]byte
+}
+
+// DeltaHash returns the Merkle root of the given account hashes, ordered by
+// address. The accounts delta hash of a slot is over the hashes of the
+// accounts written in the slot.
+//
+// Based on solana_accounts_db::accounts_hash::AccountsHasher::accumulate_account_hashes.
+func DeltaHash(hashes []PubkeyHash) [32]byte {
+	sorted := make([]PubkeyHash, len(hashes))
+	copy(sorted, hashes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Pubkey[:], sorted[j].Pubkey[:]) < 0
+	})
+
+	level := make([][32]byte, len(sorted))
+	for i := range sorted {
+		level[i] = sorted[i].Hash
+	}
+	return merkleRoot(level)
+}
+
+// AccountsHash returns the hash of all accounts, as committed to by full
+// snapshots: the Merkle root of the hashes of the accounts with lamports,
+// ordered by address.
+//
+// Based on solana_accounts_db::accounts_db::AccountsDb::calculate_accounts_hash_from_storages.
+func AccountsHash(accts map[[32]byte]*Account) [32]byte {
+	hashes := make([]PubkeyHash, 0, len(accts))
+	for pubkey, acct := range accts {
+		if acct != nil && acct.Lamports != 0 {
+			hashes = append(hashes, PubkeyHash{Pubkey: pubkey, Hash: acct.Hash(pubkey)})
+		}
+	}
+	return DeltaHash(hashes)
+}
+
+// merkleRoot returns the root of the Merkle tree with MerkleFanout children
+// per node over the given hashes. Every level, including the first, hashes
+// chunks of MerkleFanout nodes, so a single hash is still hashed once.
+//
+// Based on solana_accounts_db::accounts_hash::AccountsHasher::compute_merkle_root_loop.
+func merkleRoot(level [][32]byte) [32]byte {
+	if len(level) == 0 {
+		return sha256.Sum256(nil)
+	}
+	for {
+		next := make([][32]byte, 0, (len(level)+MerkleFanout-1)/MerkleFanout)
+		for start := 0; start < len(level); start += MerkleFanout {
+			end := start + MerkleFanout
+			if end > len(level) {
+				end = len(level)
+			}
+			hasher := sha256.New()
+			for _, hash := range level[start:end] {
+				hasher.Write(hash[:])
+			}
+			var node [32]byte
+			copy(node[:], hasher.Sum(nil))
+			next = append(next, node)
+		}
+		if len(next) == 1 {
+			return next[0]
+		}
+		level = next
+	}
+}
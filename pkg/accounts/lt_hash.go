@@ -0,0 +1,91 @@
+package accounts
+
+import (
+	"encoding/binary"
+
+	"github.com/zeebo/blake3"
+)
+
+// LtHashNumElements is the number of 16-bit elements of a lattice hash.
+const LtHashNumElements = 1024
+
+// LtHash is a lattice hash, a homomorphic hash over a set of accounts.
+// Accounts are mixed in and out of the hash by element-wise wrapping
+// addition and subtraction of their own lattice hashes, so the hash of all
+// accounts is updated incrementally as accounts change.
+//
+// Based on solana_lattice_hash::lt_hash::LtHash, as specified by SIMD-0215.
+type LtHash [LtHashNumElements]uint16
+
+// LtHash returns the lattice hash of the account stored at the given
+// address. Accounts without lamports do not exist, and hash to the identity.
+//
+// Based on solana_accounts_db::accounts_db::AccountsDb::lt_hash_account.
+func (a *Account) LtHash(pubkey [32]byte) *LtHash {
+	var ltHash LtHash
+	if a.Lamports == 0 {
+		return &ltHash
+	}
+
+	var lamports [8]byte
+	binary.LittleEndian.PutUint64(lamports[:], a.Lamports)
+	hasher := blake3.New()
+	hasher.Write(lamports[:])
+	hasher.Write(a.Data)
+	if a.Executable {
+		hasher.Write([]byte{1})
+	} else {
+		hasher.Write([]byte{0})
+	}
+	hasher.Write(a.Owner[:])
+	hasher.Write(pubkey[:])
+
+	var buf [LtHashNumElements * 2]byte
+	hasher.Digest().Read(buf[:])
+	for i := range ltHash {
+		ltHash[i] = binary.LittleEndian.Uint16(buf[2*i:])
+	}
+	return &ltHash
+}
+
+// AccountsLtHash returns the lattice hash of all accounts.
+//
+// Based on solana_accounts_db::accounts_db::AccountsDb::calculate_accounts_lt_hash_at_startup_from_storages.
+func AccountsLtHash(accts map[[32]byte]*Account) LtHash {
+	var ltHash LtHash
+	for pubkey, acct := range accts {
+		if acct != nil {
+			ltHash.MixIn(acct.LtHash(pubkey))
+		}
+	}
+	return ltHash
+}
+
+// MixIn adds the other hash to the hash.
+func (h *LtHash) MixIn(other *LtHash) {
+	for i := range h {
+		h[i] += other[i]
+	}
+}
+
+// MixOut removes the other hash from the hash.
+func (h *LtHash) MixOut(other *LtHash) {
+	for i := range h {
+		h[i] -= other[i]
+	}
+}
+
+// Bytes returns the elements of the hash in little-endian order.
+func (h *LtHash) Bytes() [LtHashNumElements * 2]byte {
+	var buf [LtHashNumElements * 2]byte
+	for i, element := range h {
+		binary.LittleEndian.PutUint16(buf[2*i:], element)
+	}
+	return buf
+}
+
+// Checksum returns a 32-byte digest of the hash.
+func (h *LtHash) Checksum() [32]byte {
+	buf := h.Bytes()
+	return blake3.Sum256(buf[:])
+}
@@ -0,0 +1,255 @@
+package accountsdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// MaxDiskEntries is the number of versions of an account that the disk tier
+// of the index holds per pubkey.
+const MaxDiskEntries = 4
+
+// diskRecordSize is the size of a record of the disk index: a state byte, a
+// count byte, padding, the pubkey and the entries.
+const diskRecordSize = 8 + 32 + MaxDiskEntries*24
+
+// States of a record of the disk index.
+const (
+	diskRecordEmpty   = 0
+	diskRecordUsed    = 1
+	diskRecordDeleted = 2
+)
+
+// DiskIndex is the disk tier of the accounts index: an open addressing hash
+// table in a file, mapping pubkeys to up to MaxDiskEntries versions. The
+// file grows as pubkeys are added.
+//
+// Based on solana_bucket_map::bucket_map::BucketMap.
+type DiskIndex struct {
+	mu       sync.Mutex
+	f        *os.File
+	path     string
+	capacity uint64
+	len      uint64
+	deleted  uint64
+}
+
+// CreateDiskIndex creates an empty disk index at the given path, replacing
+// any existing file, with room for capacity pubkeys before it grows.
+func CreateDiskIndex(path string, capacity uint64) (*DiskIndex, error) {
+	if capacity == 0 {
+		capacity = 1
+	}
+	f, err := createDiskIndexFile(path, capacity)
+	if err != nil {
+		return nil, err
+	}
+	return &DiskIndex{f: f, path: path, capacity: capacity}, nil
+}
+
+func createDiskIndexFile(path string, capacity uint64) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(int64(capacity * diskRecordSize)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Close closes the file of the disk index. The file is left in place.
+func (d *DiskIndex) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.f.Close()
+}
+
+// Len returns the number of pubkeys in the disk index.
+func (d *DiskIndex) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return int(d.len)
+}
+
+// Get returns the versions of an account, or false if the pubkey is not in
+// the disk index.
+func (d *DiskIndex) Get(pubkey [32]byte) ([]IndexEntry, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pos, found, err := d.find(pubkey)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	var record [diskRecordSize]byte
+	if err := d.readRecord(pos, &record); err != nil {
+		return nil, false, err
+	}
+	return decodeDiskEntries(&record), true, nil
+}
+
+// Put sets the versions of an account, of which there must be at most
+// MaxDiskEntries.
+func (d *DiskIndex) Put(pubkey [32]byte, entries []IndexEntry) error {
+	if len(entries) > MaxDiskEntries {
+		return fmt.Errorf("%d index entries exceed the %d of the disk index", len(entries), MaxDiskEntries)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	pos, found, err := d.find(pubkey)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if (d.len+d.deleted+1)*4 > d.capacity*3 {
+			if err := d.grow(); err != nil {
+				return err
+			}
+			if pos, _, err = d.find(pubkey); err != nil {
+				return err
+			}
+		}
+		var record [diskRecordSize]byte
+		if err := d.readRecord(pos, &record); err != nil {
+			return err
+		}
+		if record[0] == diskRecordDeleted {
+			d.deleted--
+		}
+		d.len++
+	}
+	return d.writeRecord(pos, pubkey, entries)
+}
+
+// Delete removes a pubkey from the disk index.
+func (d *DiskIndex) Delete(pubkey [32]byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pos, found, err := d.find(pubkey)
+	if err != nil || !found {
+		return err
+	}
+	if _, err := d.f.WriteAt([]byte{diskRecordDeleted}, int64(pos*diskRecordSize)); err != nil {
+		return err
+	}
+	d.len--
+	d.deleted++
+	return nil
+}
+
+// find returns the position of the record of a pubkey, and whether it was
+// found. If it was not found, the position is where it is to be inserted.
+func (d *DiskIndex) find(pubkey [32]byte) (uint64, bool, error) {
+	var record [diskRecordSize]byte
+	insertPos := int64(-1)
+	pos := xxhash.Sum64(pubkey[:]) % d.capacity
+	for probes := uint64(0); probes < d.capacity; probes++ {
+		if err := d.readRecord(pos, &record); err != nil {
+			return 0, false, err
+		}
+		switch record[0] {
+		case diskRecordEmpty:
+			if insertPos < 0 {
+				insertPos = int64(pos)
+			}
+			return uint64(insertPos), false, nil
+		case diskRecordDeleted:
+			if insertPos < 0 {
+				insertPos = int64(pos)
+			}
+		case diskRecordUsed:
+			if bytes.Equal(record[8:40], pubkey[:]) {
+				return pos, true, nil
+			}
+		}
+		pos = (pos + 1) % d.capacity
+	}
+	if insertPos < 0 {
+		return 0, false, fmt.Errorf("disk index is full")
+	}
+	return uint64(insertPos), false, nil
+}
+
+// grow doubles the capacity of the disk index, rehashing its pubkeys into
+// a new file, which replaces the old one.
+func (d *DiskIndex) grow() error {
+	newPath := d.path + ".tmp"
+	newIndex := &DiskIndex{path: newPath, capacity: d.capacity * 2}
+	f, err := createDiskIndexFile(newPath, newIndex.capacity)
+	if err != nil {
+		return err
+	}
+	newIndex.f = f
+
+	var record [diskRecordSize]byte
+	for pos := uint64(0); pos < d.capacity; pos++ {
+		if err := d.readRecord(pos, &record); err != nil {
+			f.Close()
+			return err
+		}
+		if record[0] != diskRecordUsed {
+			continue
+		}
+		var pubkey [32]byte
+		copy(pubkey[:], record[8:40])
+		newPos, _, err := newIndex.find(pubkey)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if err := newIndex.writeRecord(newPos, pubkey, decodeDiskEntries(&record)); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := os.Rename(newPath, d.path); err != nil {
+		f.Close()
+		return err
+	}
+	d.f.Close()
+	d.f = f
+	d.capacity = newIndex.capacity
+	d.deleted = 0
+	return nil
+}
+
+func (d *DiskIndex) readRecord(pos uint64, record *[diskRecordSize]byte) error {
+	_, err := d.f.ReadAt(record[:], int64(pos*diskRecordSize))
+	return err
+}
+
+func (d *DiskIndex) writeRecord(pos uint64, pubkey [32]byte, entries []IndexEntry) error {
+	var record [diskRecordSize]byte
+	record[0] = diskRecordUsed
+	record[1] = uint8(len(entries))
+	copy(record[8:40], pubkey[:])
+	for i, entry := range entries {
+		buf := record[40+i*24:]
+		binary.LittleEndian.PutUint64(buf[0:8], entry.Slot)
+		binary.LittleEndian.PutUint64(buf[8:16], entry.StorageId)
+		binary.LittleEndian.PutUint64(buf[16:24], entry.Offset)
+	}
+	_, err := d.f.WriteAt(record[:], int64(pos*diskRecordSize))
+	return err
+}
+
+func decodeDiskEntries(record *[diskRecordSize]byte) []IndexEntry {
+	entries := make([]IndexEntry, record[1])
+	for i := range entries {
+		buf := record[40+i*24:]
+		entries[i] = IndexEntry{
+			Slot:      binary.LittleEndian.Uint64(buf[0:8]),
+			StorageId: binary.LittleEndian.Uint64(buf[8:16]),
+			Offset:    binary.LittleEndian.Uint64(buf[16:24]),
+		}
+	}
+	return entries
+}
@@ -0,0 +1,232 @@
+// Package accountsdb indexes the accounts stored in append vecs.
+package accountsdb
+
+import (
+	"sort"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// IndexEntry locates a version of an account: the slot it was stored in,
+// and its offset in the append vec of the slot with the given id.
+type IndexEntry struct {
+	Slot      uint64
+	StorageId uint64
+	Offset    uint64
+}
+
+// Index maps pubkeys to the versions of the account stored in each slot,
+// and tracks which slots are rooted. A version is visible from a fork if it
+// is rooted, or if its slot is an ancestor of the fork.
+//
+// Entries are held in memory. Given a disk tier, the index keeps at most
+// maxMemKeys pubkeys in memory, and moves others to disk, from where they
+// are read back on access.
+//
+// Based on solana_accounts_db::accounts_index::AccountsIndex.
+type Index struct {
+	mu         sync.RWMutex
+	mem        map[[32]byte][]IndexEntry
+	roots      map[uint64]struct{}
+	maxRoot    uint64
+	disk       *DiskIndex
+	maxMemKeys int
+}
+
+// NewIndex returns an empty in-memory index.
+func NewIndex() *Index {
+	return &Index{
+		mem:   make(map[[32]byte][]IndexEntry),
+		roots: make(map[uint64]struct{}),
+	}
+}
+
+// NewIndexWithDisk returns an empty index, which keeps up to maxMemKeys
+// pubkeys in memory, and the others in the given disk tier.
+func NewIndexWithDisk(disk *DiskIndex, maxMemKeys int) *Index {
+	index := NewIndex()
+	index.disk = disk
+	index.maxMemKeys = maxMemKeys
+	return index
+}
+
+// Insert adds a version of an account. A version in the same slot as an
+// existing one replaces it.
+func (index *Index) Insert(pubkey [32]byte, entry IndexEntry) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	entries, err := index.load(pubkey)
+	if err != nil {
+		return err
+	}
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Slot >= entry.Slot })
+	if i < len(entries) && entries[i].Slot == entry.Slot {
+		entries[i] = entry
+	} else {
+		entries = append(entries, IndexEntry{})
+		copy(entries[i+1:], entries[i:])
+		entries[i] = entry
+	}
+	index.mem[pubkey] = entries
+	return index.evict()
+}
+
+// Latest returns the newest version of an account at or before maxSlot that
+// is visible from a fork with the given ancestors, or false if there is
+// none. With nil ancestors, only rooted versions are visible.
+//
+// Based on solana_accounts_db::accounts_index::AccountsIndex::latest_slot.
+func (index *Index) Latest(pubkey [32]byte, maxSlot uint64, ancestors map[uint64]struct{}) (IndexEntry, bool, error) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	entries, err := index.get(pubkey)
+	if err != nil {
+		return IndexEntry{}, false, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		slot := entries[i].Slot
+		if slot > maxSlot {
+			continue
+		}
+		if _, ok := ancestors[slot]; ok {
+			return entries[i], true, nil
+		}
+		if _, ok := index.roots[slot]; ok {
+			return entries[i], true, nil
+		}
+	}
+	return IndexEntry{}, false, nil
+}
+
+// Entries returns the versions of an account, oldest first.
+func (index *Index) Entries(pubkey [32]byte) ([]IndexEntry, error) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	entries, err := index.get(pubkey)
+	return append([]IndexEntry(nil), entries...), err
+}
+
+// get returns the entries of a pubkey from memory or from the disk tier,
+// without moving them to memory.
+func (index *Index) get(pubkey [32]byte) ([]IndexEntry, error) {
+	if entries, ok := index.mem[pubkey]; ok {
+		return entries, nil
+	}
+	if index.disk == nil {
+		return nil, nil
+	}
+	entries, _, err := index.disk.Get(pubkey)
+	return entries, err
+}
+
+// AddRoot marks a slot as rooted.
+func (index *Index) AddRoot(slot uint64) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.roots[slot] = struct{}{}
+	if slot > index.maxRoot {
+		index.maxRoot = slot
+	}
+}
+
+// IsRoot returns whether a slot is rooted.
+func (index *Index) IsRoot(slot uint64) bool {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	_, ok := index.roots[slot]
+	return ok
+}
+
+// MaxRoot returns the newest rooted slot.
+func (index *Index) MaxRoot() uint64 {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	return index.maxRoot
+}
+
+// Len returns the number of pubkeys in the index.
+func (index *Index) Len() int {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	if index.disk == nil {
+		return len(index.mem)
+	}
+	return len(index.mem) + index.disk.Len()
+}
+
+// Clean removes the rooted versions of an account that are older than its
+// newest rooted version, as they are no longer visible from any fork, and
+// returns them so that their storage may be reclaimed.
+//
+// Based on solana_accounts_db::accounts_index::AccountsIndex::clean_rooted_entries.
+func (index *Index) Clean(pubkey [32]byte) ([]IndexEntry, error) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	entries, err := index.load(pubkey)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	newestRoot := -1
+	for i := range entries {
+		if _, ok := index.roots[entries[i].Slot]; ok {
+			newestRoot = i
+		}
+	}
+	var reclaimed []IndexEntry
+	kept := entries[:0]
+	for i, entry := range entries {
+		if _, ok := index.roots[entry.Slot]; ok && i < newestRoot {
+			reclaimed = append(reclaimed, entry)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	index.mem[pubkey] = kept
+	return reclaimed, index.evict()
+}
+
+// load moves the entries of a pubkey from the disk tier to memory, if
+// needed, and returns them.
+func (index *Index) load(pubkey [32]byte) ([]IndexEntry, error) {
+	if entries, ok := index.mem[pubkey]; ok {
+		return entries, nil
+	}
+	if index.disk == nil {
+		return nil, nil
+	}
+	entries, ok, err := index.disk.Get(pubkey)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if err := index.disk.Delete(pubkey); err != nil {
+		return nil, err
+	}
+	index.mem[pubkey] = entries
+	return entries, nil
+}
+
+// evict moves pubkeys from memory to the disk tier until at most
+// maxMemKeys are left in memory. Pubkeys with more versions than the disk
+// tier holds stay in memory.
+func (index *Index) evict() error {
+	if index.disk == nil || len(index.mem) <= index.maxMemKeys {
+		return nil
+	}
+	for pubkey, entries := range index.mem {
+		if len(index.mem) <= index.maxMemKeys {
+			break
+		}
+		if len(entries) > MaxDiskEntries {
+			continue
+		}
+		if err := index.disk.Put(pubkey, entries); err != nil {
+			return err
+		}
+		delete(index.mem, pubkey)
+	}
+	klog.V(5).Infof("Accounts index: %d pubkeys in memory, %d on disk", len(index.mem), index.disk.Len())
+	return nil
+}
@@ -0,0 +1,88 @@
+package accountsdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexLatest(t *testing.T) {
+	index := NewIndex()
+	pubkey := [32]byte{1}
+	require.NoError(t, index.Insert(pubkey, IndexEntry{Slot: 5, StorageId: 1, Offset: 0}))
+	require.NoError(t, index.Insert(pubkey, IndexEntry{Slot: 10, StorageId: 2, Offset: 8}))
+	require.NoError(t, index.Insert(pubkey, IndexEntry{Slot: 7, StorageId: 3, Offset: 16}))
+	require.NoError(t, index.Insert(pubkey, IndexEntry{Slot: 10, StorageId: 2, Offset: 24}))
+	entries, err := index.Entries(pubkey)
+	require.NoError(t, err)
+	assert.Equal(t, []IndexEntry{{5, 1, 0}, {7, 3, 16}, {10, 2, 24}}, entries)
+
+	// no version is visible before slots are rooted
+	_, ok, err := index.Latest(pubkey, 10, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	index.AddRoot(5)
+	index.AddRoot(7)
+	assert.True(t, index.IsRoot(7))
+	assert.Equal(t, uint64(7), index.MaxRoot())
+
+	entry, ok, err := index.Latest(pubkey, 10, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(7), entry.Slot)
+
+	entry, _, err = index.Latest(pubkey, 6, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), entry.Slot)
+
+	// slot 10 is only visible from forks descending from it
+	entry, _, err = index.Latest(pubkey, 11, map[uint64]struct{}{10: {}, 11: {}})
+	require.NoError(t, err)
+	assert.Equal(t, IndexEntry{10, 2, 24}, entry)
+
+	reclaimed, err := index.Clean(pubkey)
+	require.NoError(t, err)
+	assert.Equal(t, []IndexEntry{{5, 1, 0}}, reclaimed)
+	entries, err = index.Entries(pubkey)
+	require.NoError(t, err)
+	assert.Equal(t, []IndexEntry{{7, 3, 16}, {10, 2, 24}}, entries)
+}
+
+func TestIndexDiskTier(t *testing.T) {
+	disk, err := CreateDiskIndex(filepath.Join(t.TempDir(), "index"), 2)
+	require.NoError(t, err)
+	defer disk.Close()
+
+	index := NewIndexWithDisk(disk, 4)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, index.Insert([32]byte{byte(i)}, IndexEntry{Slot: 1, Offset: uint64(i) * 8}))
+	}
+	index.AddRoot(1)
+	assert.Equal(t, 100, index.Len())
+	assert.Equal(t, 96, disk.Len())
+
+	for i := 0; i < 100; i++ {
+		entry, ok, err := index.Latest([32]byte{byte(i)}, 1, nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, uint64(i)*8, entry.Offset)
+	}
+
+	// updating a pubkey on disk moves it back to memory
+	require.NoError(t, index.Insert([32]byte{0}, IndexEntry{Slot: 2, Offset: 1}))
+	entries, err := index.Entries([32]byte{0})
+	require.NoError(t, err)
+	assert.Equal(t, []IndexEntry{{Slot: 1}, {Slot: 2, Offset: 1}}, entries)
+	assert.Equal(t, 100, index.Len())
+
+	_, ok, err := disk.Get([32]byte{200})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, disk.Delete([32]byte{5}))
+	_, ok, err = disk.Get([32]byte{5})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
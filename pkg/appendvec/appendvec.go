@@ -0,0 +1,193 @@
+// Package appendvec reads and writes append vecs, the account storage files
+// of the accounts database, as found in snapshot archives.
+package appendvec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+// HeaderSize is the size of the header of a stored account: the stored
+// meta, the account meta, and the account hash.
+const HeaderSize = 48 + 56 + 32
+
+// Alignment is the alignment of stored accounts in an append vec.
+const Alignment = 8
+
+// ErrFull is returned when appending an account to an append vec that has
+// no room left for it.
+var ErrFull = errors.New("append vec is full")
+
+// StoredAccountMeta is an account stored in an append vec.
+//
+// Based on solana_accounts_db::account_storage::meta::StoredAccountMeta.
+type StoredAccountMeta struct {
+	// Offset is the offset of the account in the append vec.
+	Offset uint64
+
+	WriteVersion uint64
+	Pubkey       [32]byte
+	Lamports     uint64
+	RentEpoch    uint64
+	Owner        [32]byte
+	Executable   bool
+	Hash         [32]byte
+	Data         []byte
+}
+
+// Account returns a copy of the stored account.
+func (m *StoredAccountMeta) Account() *accounts.Account {
+	return &accounts.Account{
+		Lamports:   m.Lamports,
+		Data:       append([]byte{}, m.Data...),
+		Owner:      m.Owner,
+		Executable: m.Executable,
+		RentEpoch:  m.RentEpoch,
+	}
+}
+
+// StoredSize returns the size the account takes in an append vec,
+// including its alignment padding.
+func (m *StoredAccountMeta) StoredSize() uint64 {
+	return alignUp(HeaderSize + uint64(len(m.Data)))
+}
+
+// AppendVec is an account storage, holding accounts appended one after the
+// other. Only the first Len bytes of it are valid.
+//
+// Based on solana_accounts_db::append_vec::AppendVec.
+type AppendVec struct {
+	data []byte
+	len  uint64
+}
+
+// New returns an empty append vec, which holds up to capacity bytes.
+func New(capacity uint64) *AppendVec {
+	return &AppendVec{data: make([]byte, capacity)}
+}
+
+// FromBytes returns the append vec of the given file contents, of which the
+// first currentLen bytes are valid. The append vec references data, which
+// must not be modified.
+func FromBytes(data []byte, currentLen uint64) (*AppendVec, error) {
+	if currentLen > uint64(len(data)) {
+		return nil, fmt.Errorf("append vec of %d bytes is shorter than its length %d", len(data), currentLen)
+	}
+	return &AppendVec{data: data, len: currentLen}, nil
+}
+
+// Len returns the number of bytes used in the append vec.
+func (v *AppendVec) Len() uint64 {
+	return v.len
+}
+
+// Capacity returns the size of the append vec.
+func (v *AppendVec) Capacity() uint64 {
+	return uint64(len(v.data))
+}
+
+// Bytes returns the used part of the append vec, as written to a snapshot.
+func (v *AppendVec) Bytes() []byte {
+	return v.data[:v.len]
+}
+
+// Get returns the account stored at the given offset, and the offset of the
+// next account. The data of the account references the append vec.
+//
+// Based on solana_accounts_db::append_vec::AppendVec::get_stored_account_meta.
+func (v *AppendVec) Get(offset uint64) (*StoredAccountMeta, uint64, error) {
+	if offset%Alignment != 0 {
+		return nil, 0, fmt.Errorf("unaligned offset %d", offset)
+	}
+	if offset > v.len || v.len-offset < HeaderSize {
+		return nil, 0, fmt.Errorf("truncated account header at offset %d", offset)
+	}
+	header := v.data[offset : offset+HeaderSize]
+	dataLen := binary.LittleEndian.Uint64(header[8:16])
+	if dataLen > v.len-offset-HeaderSize {
+		return nil, 0, fmt.Errorf("truncated account data at offset %d", offset)
+	}
+
+	meta := &StoredAccountMeta{
+		Offset:       offset,
+		WriteVersion: binary.LittleEndian.Uint64(header[0:8]),
+		Lamports:     binary.LittleEndian.Uint64(header[48:56]),
+		RentEpoch:    binary.LittleEndian.Uint64(header[56:64]),
+		Executable:   header[96] != 0,
+	}
+	copy(meta.Pubkey[:], header[16:48])
+	copy(meta.Owner[:], header[64:96])
+	copy(meta.Hash[:], header[104:136])
+	dataStart := offset + HeaderSize
+	meta.Data = v.data[dataStart : dataStart+dataLen : dataStart+dataLen]
+	return meta, alignUp(dataStart + dataLen), nil
+}
+
+// ForEach calls fn with each account of the append vec, in storage order,
+// until fn returns an error.
+func (v *AppendVec) ForEach(fn func(meta *StoredAccountMeta) error) error {
+	for offset := uint64(0); offset < v.len; {
+		meta, next, err := v.Get(offset)
+		if err != nil {
+			return err
+		}
+		if err := fn(meta); err != nil {
+			return err
+		}
+		offset = next
+	}
+	return nil
+}
+
+// Accounts returns all accounts of the append vec, in storage order.
+func (v *AppendVec) Accounts() ([]*StoredAccountMeta, error) {
+	var metas []*StoredAccountMeta
+	err := v.ForEach(func(meta *StoredAccountMeta) error {
+		metas = append(metas, meta)
+		return nil
+	})
+	return metas, err
+}
+
+// Append stores an account at the end of the append vec, and returns its
+// offset, or ErrFull if there is no room left for it. The Offset field of
+// meta is ignored.
+//
+// Based on solana_accounts_db::append_vec::AppendVec::append_accounts.
+func (v *AppendVec) Append(meta *StoredAccountMeta) (uint64, error) {
+	offset := v.len
+	size := meta.StoredSize()
+	if size > v.Capacity()-offset {
+		return 0, ErrFull
+	}
+
+	header := v.data[offset : offset+HeaderSize]
+	binary.LittleEndian.PutUint64(header[0:8], meta.WriteVersion)
+	binary.LittleEndian.PutUint64(header[8:16], uint64(len(meta.Data)))
+	copy(header[16:48], meta.Pubkey[:])
+	binary.LittleEndian.PutUint64(header[48:56], meta.Lamports)
+	binary.LittleEndian.PutUint64(header[56:64], meta.RentEpoch)
+	copy(header[64:96], meta.Owner[:])
+	header[96] = 0
+	if meta.Executable {
+		header[96] = 1
+	}
+	for i := 97; i < 104; i++ {
+		header[i] = 0
+	}
+	copy(header[104:136], meta.Hash[:])
+	end := offset + HeaderSize + uint64(copy(v.data[offset+HeaderSize:], meta.Data))
+	for i := end; i < offset+size; i++ {
+		v.data[i] = 0
+	}
+
+	v.len = offset + size
+	return offset, nil
+}
+
+func alignUp(n uint64) uint64 {
+	return (n + Alignment - 1) &^ (Alignment - 1)
+}
@@ -0,0 +1,57 @@
+package appendvec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func TestAppendVec(t *testing.T) {
+	metas := []*StoredAccountMeta{
+		{WriteVersion: 1, Pubkey: [32]byte{1}, Lamports: 10, Owner: [32]byte{9}, Hash: [32]byte{5}, Data: []byte{1, 2, 3}},
+		{WriteVersion: 2, Pubkey: [32]byte{2}, Lamports: 20, RentEpoch: 7, Executable: true, Data: []byte{}},
+		{WriteVersion: 3, Pubkey: [32]byte{3}, Lamports: 30, Data: make([]byte, 16)},
+	}
+
+	vec := New(1024)
+	var offsets []uint64
+	for _, meta := range metas {
+		offset, err := vec.Append(meta)
+		require.NoError(t, err)
+		offsets = append(offsets, offset)
+	}
+	assert.Equal(t, []uint64{0, 144, 280}, offsets)
+	assert.Equal(t, uint64(432), vec.Len())
+
+	_, err := vec.Append(&StoredAccountMeta{Data: make([]byte, 1024)})
+	assert.Equal(t, ErrFull, err)
+
+	// reopen the used part, as written to a snapshot
+	reopened, err := FromBytes(append(vec.Bytes(), make([]byte, 64)...), vec.Len())
+	require.NoError(t, err)
+	stored, err := reopened.Accounts()
+	require.NoError(t, err)
+	require.Len(t, stored, 3)
+	for i, meta := range stored {
+		assert.Equal(t, offsets[i], meta.Offset)
+		meta.Offset = 0
+		assert.Equal(t, metas[i], meta)
+	}
+
+	meta, next, err := reopened.Get(offsets[1])
+	require.NoError(t, err)
+	assert.Equal(t, offsets[2], next)
+	assert.Equal(t, &accounts.Account{Lamports: 20, Data: []byte{}, Executable: true, RentEpoch: 7}, meta.Account())
+
+	_, _, err = reopened.Get(4)
+	assert.Error(t, err)
+	_, _, err = reopened.Get(vec.Len())
+	assert.Error(t, err)
+
+	truncated, err := FromBytes(vec.Bytes(), offsets[2]+HeaderSize+8)
+	require.NoError(t, err)
+	_, err = truncated.Accounts()
+	assert.Error(t, err)
+}
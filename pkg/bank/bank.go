@@ -1,6 +1,421 @@
+// Package bank holds the state of the chain for one slot.
 package bank
 
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"go.firedancer.io/radiance/pkg/global"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"k8s.io/klog/v2"
+)
+
+// Bank is the state of the chain for one slot: the accounts, the sysvars
+// and features that transactions of the slot execute against, the recent
+// blockhashes they may reference, and the fee rate they pay. A bank is
+// created from its parent, executes the transactions of its slot, and is
+// then frozen, after which it is immutable and has a hash.
+//
+// Based on solana_runtime::bank::Bank.
 type Bank struct {
 	Slot         uint64
+	ParentSlot   uint64
+	ParentHash   [32]byte
 	TicksPerSlot uint64
+
+	// Epoch is the epoch of the slot, and SlotIndex the index of the slot
+	// in the epoch, as per EpochSchedule.
+	Epoch         uint64
+	SlotIndex     uint64
+	EpochSchedule sealevel.SysvarEpochSchedule
+
+	Accounts             accounts.Accounts
+	SysvarCache          sealevel.SysvarCache
+	Features             *features.Features
+	BlockhashQueue       *BlockhashQueue
+	LamportsPerSignature uint64
+	SlotsPerYear         float64
+
+	// ProgramCache holds the programs loaded by transactions, and is shared
+	// by a bank with its descendants.
+	ProgramCache *sealevel.ProgramCache
+
+	// StatusCache holds the statuses of recent transactions, and is shared
+	// by a bank with its descendants.
+	StatusCache *StatusCache
+
+	// HardForks are the hard forks of the cluster, and are shared by a
+	// bank with its descendants.
+	HardForks *HardForks
+
+	// Stakes holds the vote accounts and stake delegations of the cluster,
+	// and is shared by a bank with its descendants.
+	Stakes *StakesCache
+
+	// CostTracker accumulates the costs of the transactions of the slot,
+	// which must fit in the cost limits of the block.
+	CostTracker *sealevel.CostTracker
+
+	// Geyser, if set, is notified of the accounts and transactions
+	// committed by the bank, and is inherited by its descendants.
+	Geyser *geyser.Notifier
+
+	// CollectorId is the leader of the slot, which is paid the part of the
+	// transaction fees that is not burned, as per FeeBurnPercent.
+	CollectorId         [32]byte
+	CollectorFeeDetails sealevel.FeeDetails
+	FeeBurnPercent      uint8
+
+	// SignatureCount is the number of transaction signatures processed in
+	// the slot.
+	SignatureCount uint64
+
+	// BlockHeight is the number of ancestors of the bank, and
+	// TransactionCount the number of transactions committed by the bank and
+	// its ancestors.
+	BlockHeight      uint64
+	TransactionCount uint64
+
+	// EpochAccountsHash is the epoch accounts hash of the epoch, which the
+	// bank hash commits to at the end of its calculation window.
+	EpochAccountsHash *[32]byte
+
+	// AccountsLtHash is the lattice hash of all accounts, maintained once
+	// accounts_lt_hash is active.
+	AccountsLtHash accounts.LtHash
+
+	// written holds the accounts stored in the slot, as of the start of the
+	// slot, nil for accounts that did not exist.
+	written map[[32]byte]*accounts.Account
+
+	// accountsMu guards Accounts while the transactions of an entry
+	// execute in parallel with the commits of earlier ones.
+	accountsMu sync.RWMutex
+
+	// numTransactions is the number of transactions committed in the slot.
+	numTransactions uint64
+
+	// ancestors holds the slot of the bank and of its recent ancestors, of
+	// which transactions are visible in the status cache.
+	ancestors map[uint64]struct{}
+
+	frozen            bool
+	accountsDeltaHash [32]byte
+	hash              [32]byte
+}
+
+// NewBank returns a root bank for the given slot, reading its sysvars from
+// the given accounts.
+func NewBank(slot uint64, accts accounts.Accounts, f *features.Features, epochSchedule sealevel.SysvarEpochSchedule) *Bank {
+	bank := &Bank{
+		Slot:           slot,
+		EpochSchedule:  epochSchedule,
+		Accounts:       accts,
+		Features:       f,
+		BlockhashQueue: NewBlockhashQueue(DefaultMaxBlockhashQueueAge),
+		FeeBurnPercent: sealevel.DefaultBurnPercent,
+		ProgramCache:   sealevel.NewProgramCache(sealevel.DefaultProgramCacheCapacity),
+		StatusCache:    NewStatusCache(),
+		HardForks:      NewHardForks(),
+		CostTracker:    sealevel.NewCostTracker(),
+		written:        make(map[[32]byte]*accounts.Account),
+		ancestors:      map[uint64]struct{}{slot: {}},
+	}
+	bank.Epoch, bank.SlotIndex = epochSchedule.GetEpochAndSlotIndex(slot)
+	bank.SysvarCache.FillFromAccounts(accts)
+	var stakeHistory sealevel.SysvarStakeHistory
+	if current, err := bank.SysvarCache.StakeHistory(); err == nil {
+		stakeHistory = *current
+	}
+	bank.Stakes = NewStakesCache(bank.Epoch, stakeHistory)
+	return bank
+}
+
+// NewBankFromParent returns a bank for the given slot, which descends from
+// the frozen parent bank. The child inherits the state of its parent, and
+// stores the sysvars advanced to its slot. At an epoch boundary, it also
+// activates pending features and records the stake history of the epoch.
+//
+// Based on solana_runtime::bank::Bank::new_from_parent.
+func NewBankFromParent(parent *Bank, slot uint64) (*Bank, error) {
+	if !parent.IsFrozen() {
+		return nil, fmt.Errorf("parent bank of slot %d is not frozen", parent.Slot)
+	}
+	if slot <= parent.Slot {
+		return nil, fmt.Errorf("slot %d does not descend from parent slot %d", slot, parent.Slot)
+	}
+
+	bank := &Bank{
+		Slot:                 slot,
+		ParentSlot:           parent.Slot,
+		ParentHash:           parent.hash,
+		TicksPerSlot:         parent.TicksPerSlot,
+		EpochSchedule:        parent.EpochSchedule,
+		Accounts:             parent.Accounts,
+		SysvarCache:          parent.SysvarCache.Clone(),
+		Features:             parent.Features,
+		BlockhashQueue:       parent.BlockhashQueue.Clone(),
+		LamportsPerSignature: parent.LamportsPerSignature,
+		SlotsPerYear:         parent.SlotsPerYear,
+		ProgramCache:         parent.ProgramCache,
+		StatusCache:          parent.StatusCache,
+		HardForks:            parent.HardForks,
+		Stakes:               parent.Stakes,
+		CostTracker:          sealevel.NewCostTracker(),
+		Geyser:               parent.Geyser,
+		FeeBurnPercent:       parent.FeeBurnPercent,
+		EpochAccountsHash:    parent.EpochAccountsHash,
+		AccountsLtHash:       parent.AccountsLtHash,
+		BlockHeight:          parent.BlockHeight + 1,
+		TransactionCount:     parent.TransactionCount,
+		written:              make(map[[32]byte]*accounts.Account),
+		ancestors:            map[uint64]struct{}{slot: {}},
+	}
+	bank.Epoch, bank.SlotIndex = bank.EpochSchedule.GetEpochAndSlotIndex(slot)
+	// transactions of older ancestors reference blockhashes too old to be
+	// processed again
+	for ancestor := range parent.ancestors {
+		if ancestor+MaxCacheEntries > slot {
+			bank.ancestors[ancestor] = struct{}{}
+		}
+	}
+	if bank.Epoch > parent.Epoch {
+		if err := bank.applyFeatureActivations(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := bank.updateSlotHashes(); err != nil {
+		return nil, err
+	}
+	if err := bank.updateStakeHistory(parent.Epoch); err != nil {
+		return nil, err
+	}
+	bank.Stakes.updateEpochStakes(bank.EpochSchedule.GetLeaderScheduleEpoch(slot), bank.NewWarmupCooldownRateEpoch())
+	if err := bank.updateClock(parent.Epoch); err != nil {
+		return nil, err
+	}
+	if err := bank.updateLastRestartSlot(); err != nil {
+		return nil, err
+	}
+
+	return bank, nil
+}
+
+// Ancestors returns the slot of the bank and of its recent ancestors.
+func (bank *Bank) Ancestors() map[uint64]struct{} {
+	ancestors := make(map[uint64]struct{}, len(bank.ancestors))
+	for slot := range bank.ancestors {
+		ancestors[slot] = struct{}{}
+	}
+	return ancestors
+}
+
+// IsFrozen returns whether the bank is frozen.
+func (bank *Bank) IsFrozen() bool {
+	return bank.frozen
+}
+
+// Hash returns the hash of the bank, or false if it is not frozen yet.
+func (bank *Bank) Hash() ([32]byte, bool) {
+	return bank.hash, bank.frozen
+}
+
+// AccountsDeltaHash returns the hash of the accounts stored in the slot, or
+// false if the bank is not frozen yet.
+func (bank *Bank) AccountsDeltaHash() ([32]byte, bool) {
+	return bank.accountsDeltaHash, bank.frozen
+}
+
+// StoreAccount stores an account in the slot of the bank.
+func (bank *Bank) StoreAccount(pubkey [32]byte, acct *accounts.Account) error {
+	return bank.storeAccount(pubkey, acct, nil)
+}
+
+// storeAccount stores an account written by the transaction with the given
+// signature, if any.
+func (bank *Bank) storeAccount(pubkey [32]byte, acct *accounts.Account, txSignature *solana.Signature) error {
+	if bank.frozen {
+		return fmt.Errorf("bank of slot %d is frozen", bank.Slot)
+	}
+	if _, ok := bank.written[pubkey]; !ok {
+		var initial *accounts.Account
+		if prev, err := bank.Accounts.GetAccount(&pubkey); err == nil && prev != nil {
+			initial = copyAccount(prev)
+		}
+		bank.written[pubkey] = initial
+	}
+	if err := bank.Accounts.SetAccount(&pubkey, acct); err != nil {
+		return err
+	}
+	bank.Stakes.CheckAndStore(pubkey, acct)
+	if bank.Geyser != nil {
+		bank.Geyser.UpdateAccount(bank.Slot, pubkey, acct, txSignature, false)
+	}
+	return nil
+}
+
+// RegisterHardFork registers a hard fork at a slot, which must descend
+// from the slot of the bank, and returns false otherwise.
+//
+// Based on solana_runtime::bank::Bank::register_hard_fork.
+func (bank *Bank) RegisterHardFork(slot uint64) bool {
+	if slot <= bank.Slot {
+		return false
+	}
+	bank.HardForks.Register(slot)
+	return true
+}
+
+// LastBlockhash returns the most recent blockhash of the bank.
+func (bank *Bank) LastBlockhash() [32]byte {
+	hash, _ := bank.BlockhashQueue.LastHash()
+	return hash
+}
+
+// RegisterBlockhash registers the blockhash of the last tick of the slot,
+// which later transactions may reference, at the fee rate of the bank.
+func (bank *Bank) RegisterBlockhash(hash [32]byte) error {
+	if bank.frozen {
+		return fmt.Errorf("bank of slot %d is frozen", bank.Slot)
+	}
+	bank.BlockhashQueue.Register(hash, bank.LamportsPerSignature)
+	return bank.updateRecentBlockhashes()
+}
+
+// Freeze ends the slot of the bank, and computes its hash. The bank must not
+// be modified anymore. Freezing a frozen bank does nothing.
+//
+// Based on solana_runtime::bank::Bank::freeze.
+func (bank *Bank) Freeze() {
+	if bank.frozen {
+		return
+	}
+	bank.distributeTransactionFees()
+	bank.accountsDeltaHash = bank.calculateAccountsDeltaHash()
+	if bank.Features.IsActive(features.AccountsLtHash) {
+		bank.updateAccountsLtHash()
+	}
+	if bank.shouldCalculateEpochAccountsHash() {
+		bank.calculateEpochAccountsHash()
+	}
+	bank.hash = bank.hashInternalState()
+	bank.frozen = true
+}
+
+// FreezeWithHash freezes a bank restored from a snapshot with the hashes
+// stored in the snapshot, as the accounts stored in its slot are unknown.
+func (bank *Bank) FreezeWithHash(hash, accountsDeltaHash [32]byte) {
+	bank.accountsDeltaHash = accountsDeltaHash
+	bank.hash = hash
+	bank.frozen = true
+}
+
+// calculateAccountsDeltaHash returns the hash of the accounts stored in
+// the slot, in their state at the end of the slot.
+//
+// Based on solana_accounts_db::accounts_db::AccountsDb::calculate_accounts_delta_hash.
+func (bank *Bank) calculateAccountsDeltaHash() [32]byte {
+	hashes := make([]accounts.PubkeyHash, 0, len(bank.written))
+	for pubkey := range bank.written {
+		pubkey := pubkey
+		var hash [32]byte
+		if acct, err := bank.Accounts.GetAccount(&pubkey); err == nil && acct != nil {
+			hash = acct.Hash(pubkey)
+		}
+		hashes = append(hashes, accounts.PubkeyHash{Pubkey: pubkey, Hash: hash})
+	}
+	return accounts.DeltaHash(hashes)
+}
+
+// updateAccountsLtHash replaces the accounts stored in the slot in the
+// lattice hash of all accounts, mixing out their state at the start of the
+// slot, and mixing in their state at the end.
+//
+// Based on solana_runtime::bank::Bank::update_accounts_lt_hash.
+func (bank *Bank) updateAccountsLtHash() {
+	for pubkey, initial := range bank.written {
+		pubkey := pubkey
+		if initial != nil {
+			bank.AccountsLtHash.MixOut(initial.LtHash(pubkey))
+		}
+		if acct, err := bank.Accounts.GetAccount(&pubkey); err == nil && acct != nil {
+			bank.AccountsLtHash.MixIn(acct.LtHash(pubkey))
+		}
+	}
+}
+
+// hashInternalState returns the hash of the bank, which commits to the
+// parent bank, the accounts stored in the slot, the signatures processed in
+// the slot and the last blockhash, to the epoch accounts hash once per
+// epoch, to the hard forks since the parent slot, and to the lattice hash
+// of all accounts once accounts_lt_hash is active.
+//
+// Based on solana_runtime::bank::Bank::hash_internal_state.
+func (bank *Bank) hashInternalState() [32]byte {
+	var signatureCount [8]byte
+	binary.LittleEndian.PutUint64(signatureCount[:], bank.SignatureCount)
+	lastBlockhash := bank.LastBlockhash()
+
+	hasher := sha256.New()
+	hasher.Write(bank.ParentHash[:])
+	hasher.Write(bank.accountsDeltaHash[:])
+	hasher.Write(signatureCount[:])
+	hasher.Write(lastBlockhash[:])
+
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+
+	if bank.shouldIncludeEpochAccountsHash() {
+		if bank.EpochAccountsHash != nil {
+			hasher.Reset()
+			hasher.Write(hash[:])
+			hasher.Write(bank.EpochAccountsHash[:])
+			copy(hash[:], hasher.Sum(nil))
+		} else {
+			// replay started past the start of the calculation, from a
+			// snapshot without the epoch accounts hash
+			klog.Warningf("Epoch accounts hash of slot %d is unknown, bank hash will diverge", bank.Slot)
+		}
+	}
+
+	if buf, ok := bank.HardForks.HashData(bank.Slot, bank.ParentSlot); ok {
+		hasher.Reset()
+		hasher.Write(hash[:])
+		hasher.Write(buf[:])
+		copy(hash[:], hasher.Sum(nil))
+	}
+
+	if bank.Features.IsActive(features.AccountsLtHash) {
+		// the whole lattice hash, not its checksum
+		ltHash := bank.AccountsLtHash.Bytes()
+		hasher.Reset()
+		hasher.Write(hash[:])
+		hasher.Write(ltHash[:])
+		copy(hash[:], hasher.Sum(nil))
+	}
+	return hash
+}
+
+// NewExecutionCtx returns an execution context for a transaction of the
+// slot of the bank.
+func (bank *Bank) NewExecutionCtx(txCtx *sealevel.TransactionCtx) *sealevel.ExecutionCtx {
+	return &sealevel.ExecutionCtx{
+		Log:                  sealevel.NewLogCollector(sealevel.LogCollectorDefaultBytesLimit),
+		Accounts:             bank.Accounts,
+		TransactionContext:   txCtx,
+		GlobalCtx:            global.GlobalCtx{Features: *bank.Features},
+		ComputeMeter:         cu.NewComputeMeter(sealevel.CUMaxComputeUnitLimit),
+		SysvarCache:          bank.SysvarCache,
+		Blockhash:            bank.LastBlockhash(),
+		LamportsPerSignature: bank.LamportsPerSignature,
+		ProgramCache:         bank.ProgramCache,
+	}
 }
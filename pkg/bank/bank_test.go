@@ -0,0 +1,446 @@
+package bank
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func TestBankLifecycle(t *testing.T) {
+	epochSchedule := sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32}
+	root := NewBank(0, accounts.NewMemAccounts(), features.NewFeaturesDefault(), epochSchedule)
+	root.LamportsPerSignature = 5000
+	root.SysvarCache.SetClock(sealevel.SysvarClock{UnixTimestamp: 1})
+
+	// only frozen banks have a hash and may have children
+	_, err := NewBankFromParent(root, 1)
+	assert.Error(t, err)
+	_, ok := root.Hash()
+	assert.False(t, ok)
+
+	require.NoError(t, root.RegisterBlockhash([32]byte{1}))
+	root.Freeze()
+	rootHash, ok := root.Hash()
+	require.True(t, ok)
+	assert.Error(t, root.RegisterBlockhash([32]byte{2}))
+
+	_, err = NewBankFromParent(root, 0)
+	assert.Error(t, err)
+
+	child, err := NewBankFromParent(root, 33)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), child.ParentSlot)
+	assert.Equal(t, rootHash, child.ParentHash)
+	assert.Equal(t, uint64(1), child.Epoch)
+	assert.Equal(t, uint64(1), child.SlotIndex)
+	assert.Equal(t, uint64(1), child.BlockHeight)
+	assert.Equal(t, [32]byte{1}, child.LastBlockhash())
+
+	clock, err := child.SysvarCache.Clock()
+	require.NoError(t, err)
+	assert.Equal(t, sealevel.SysvarClock{Slot: 33, EpochStartTimestamp: 1, Epoch: 1, LeaderScheduleEpoch: 1, UnixTimestamp: 1}, *clock)
+
+	// the updated sysvars are stored in the slot of the child
+	clockAcct, err := child.Accounts.GetAccount(&sealevel.SysvarClockAddr)
+	require.NoError(t, err)
+	assert.Equal(t, sealevel.SysvarOwnerAddr, clockAcct.Owner)
+	var storedClock sealevel.SysvarClock
+	require.NoError(t, storedClock.UnmarshalWithDecoder(bin.NewBinDecoder(clockAcct.Data)))
+	assert.Equal(t, *clock, storedClock)
+	slotHashes, err := child.SysvarCache.SlotHashes()
+	require.NoError(t, err)
+	assert.Equal(t, sealevel.SysvarSlotHashes{{Slot: 0, Hash: rootHash}}, *slotHashes)
+	slotHashesAcct, err := child.Accounts.GetAccount(&sealevel.SysvarSlotHashesAddr)
+	require.NoError(t, err)
+	assert.Len(t, slotHashesAcct.Data, sealevel.SysvarSlotHashesStructLen)
+	var storedSlotHashes sealevel.SysvarSlotHashes
+	require.NoError(t, storedSlotHashes.UnmarshalWithDecoder(bin.NewBinDecoder(slotHashesAcct.Data)))
+	assert.Equal(t, *slotHashes, storedSlotHashes)
+	assert.Contains(t, child.written, sealevel.SysvarStakeHistoryAddr)
+
+	clock, err = root.SysvarCache.Clock()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), clock.Slot)
+
+	// the child registers blockhashes of its own
+	require.NoError(t, child.RegisterBlockhash([32]byte{2}))
+	assert.Equal(t, [32]byte{1}, root.LastBlockhash())
+	child.SignatureCount = 1
+	child.Freeze()
+	childHash, ok := child.Hash()
+	require.True(t, ok)
+	assert.NotEqual(t, rootHash, childHash)
+
+	execCtx := child.NewExecutionCtx(&sealevel.TransactionCtx{})
+	assert.Equal(t, [32]byte{2}, execCtx.Blockhash)
+	assert.Equal(t, uint64(5000), execCtx.LamportsPerSignature)
+
+	// loaded programs are cached across slots
+	assert.Same(t, root.ProgramCache, child.ProgramCache)
+	assert.Same(t, root.ProgramCache, execCtx.ProgramCache)
+}
+
+func TestBlockhashQueue(t *testing.T) {
+	q := NewBlockhashQueue(2)
+	_, ok := q.LastHash()
+	assert.False(t, ok)
+
+	q.Register([32]byte{1}, 10)
+	q.Register([32]byte{2}, 20)
+	q.Register([32]byte{3}, 30)
+	assert.True(t, q.IsHashValidForAge([32]byte{1}, 2))
+	assert.False(t, q.IsHashValidForAge([32]byte{1}, 1))
+	lamports, ok := q.LamportsPerSignature([32]byte{2})
+	assert.True(t, ok)
+	assert.Equal(t, uint64(20), lamports)
+
+	// hashes older than the max age are evicted
+	q.Register([32]byte{4}, 40)
+	_, ok = q.LamportsPerSignature([32]byte{1})
+	assert.False(t, ok)
+	last, ok := q.LastHash()
+	assert.True(t, ok)
+	assert.Equal(t, [32]byte{4}, last)
+
+	recent := q.RecentBlockhashes()
+	require.Len(t, recent, 3)
+	assert.Equal(t, [32]byte{4}, recent[0].Blockhash)
+	assert.Equal(t, [32]byte{2}, recent[2].Blockhash)
+	assert.Equal(t, uint64(20), recent[2].FeeCalculator.LamportsPerSignature)
+
+	// a queue restored from its contents, as stored in a snapshot
+	restored := RestoreBlockhashQueue(&last, q.LastHashIndex(), q.Hashes(), q.MaxAge())
+	assert.Equal(t, q, restored)
+}
+
+func TestStatusCache(t *testing.T) {
+	c := NewStatusCache()
+	blockhash := [32]byte{1}
+	key := [32]byte{2, 3, 4}
+	c.Insert(blockhash, key[:], 10, nil)
+	c.Insert(blockhash, key[:], 11, sealevel.TxErrAccountInUse)
+
+	// statuses are visible from the forks of their slots
+	_, _, ok := c.GetStatus(key[:], blockhash, map[uint64]struct{}{12: {}})
+	assert.False(t, ok)
+	slot, txErr, ok := c.GetStatus(key[:], blockhash, map[uint64]struct{}{11: {}, 12: {}})
+	assert.True(t, ok)
+	assert.Equal(t, uint64(11), slot)
+	assert.Equal(t, sealevel.TxErrAccountInUse, txErr)
+	_, _, ok = c.GetStatus(key[:], [32]byte{9}, map[uint64]struct{}{11: {}})
+	assert.False(t, ok)
+
+	// and from all forks once rooted
+	c.AddRoot(10)
+	slot, txErr, ok = c.GetStatus(key[:], blockhash, nil)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(10), slot)
+	assert.NoError(t, txErr)
+
+	deltas := c.SlotDeltas(map[uint64]struct{}{11: {}})
+	require.Len(t, deltas, 2)
+	assert.Equal(t, uint64(10), deltas[0].Slot)
+	assert.Len(t, deltas[1].Statuses[blockhash].Statuses, 1)
+
+	// a cache restored from its slot deltas, as stored in a snapshot
+	restored := NewStatusCache()
+	restored.AppendSlotDeltas(deltas)
+	slot, _, ok = restored.GetStatus(key[:], blockhash, nil)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(10), slot)
+
+	// the oldest root is purged past MaxCacheEntries roots
+	for root := uint64(11); root <= 10+MaxCacheEntries; root++ {
+		c.AddRoot(root)
+	}
+	slot, _, ok = c.GetStatus(key[:], blockhash, nil)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(11), slot)
+	c.AddRoot(11 + MaxCacheEntries)
+	_, _, ok = c.GetStatus(key[:], blockhash, nil)
+	assert.False(t, ok)
+
+	// the slice may be taken at the last offset, up to the whole key
+	short := make([]byte, CachedKeySize)
+	c.Insert([32]byte{3}, short, 20, nil)
+	_, _, ok = c.GetStatus(short, [32]byte{3}, map[uint64]struct{}{20: {}})
+	assert.True(t, ok)
+
+	// keys too short for a key index restored from a snapshot are skipped
+	restored.AppendSlotDeltas([]SlotDelta{{
+		Slot:     30,
+		Statuses: map[[32]byte]*BlockhashStatuses{{4}: {KeyIndex: 40, Statuses: []KeyStatus{{}}}},
+	}})
+	restored.Insert([32]byte{4}, key[:], 31, nil)
+	_, _, ok = restored.GetStatus(key[:], [32]byte{4}, map[uint64]struct{}{31: {}})
+	assert.False(t, ok)
+}
+
+func TestBankHash(t *testing.T) {
+	epochSchedule := sealevel.SysvarEpochSchedule{SlotsPerEpoch: 432000}
+	root := NewBank(0, accounts.NewMemAccounts(), features.NewFeaturesDefault(), epochSchedule)
+
+	// the accounts delta hash of a slot without stores is the empty hash
+	root.Freeze()
+	deltaHash, ok := root.AccountsDeltaHash()
+	require.True(t, ok)
+	assert.Equal(t, sha256.Sum256(nil), deltaHash)
+
+	child, err := NewBankFromParent(root, 1)
+	require.NoError(t, err)
+	acct := &accounts.Account{Lamports: 1, Data: []byte{1, 2, 3}, Owner: [32]byte{9}}
+	require.NoError(t, child.StoreAccount([32]byte{1}, acct))
+	require.NoError(t, child.StoreAccount([32]byte{2}, &accounts.Account{}))
+	require.NoError(t, child.RegisterBlockhash([32]byte{7}))
+	child.SignatureCount = 2
+	child.Freeze()
+	assert.Error(t, child.StoreAccount([32]byte{3}, acct))
+
+	// accounts without lamports hash to zero, and the slot also stores the
+	// SlotHashes and RecentBlockhashes sysvars
+	hashes := []accounts.PubkeyHash{{Pubkey: [32]byte{1}, Hash: acct.Hash([32]byte{1})}, {Pubkey: [32]byte{2}}}
+	for _, addr := range [][32]byte{sealevel.SysvarSlotHashesAddr, sealevel.SysvarRecentBlockHashesAddr} {
+		addr := addr
+		sysvar, err := child.Accounts.GetAccount(&addr)
+		require.NoError(t, err)
+		hashes = append(hashes, accounts.PubkeyHash{Pubkey: addr, Hash: sysvar.Hash(addr)})
+	}
+	expectedDelta := accounts.DeltaHash(hashes)
+	deltaHash, _ = child.AccountsDeltaHash()
+	assert.Equal(t, expectedDelta, deltaHash)
+
+	parentHash, _ := root.Hash()
+	var preimage []byte
+	preimage = append(preimage, parentHash[:]...)
+	preimage = append(preimage, expectedDelta[:]...)
+	preimage = append(preimage, 2, 0, 0, 0, 0, 0, 0, 0)
+	preimage = append(preimage, make([]byte, 32)...)
+	preimage[len(preimage)-32] = 7
+	bankHash, _ := child.Hash()
+	assert.Equal(t, sha256.Sum256(preimage), bankHash)
+
+	// the first bank at or past the stop slot mixes in the epoch accounts hash
+	stopSlot := EpochAccountsHashStopSlot(&epochSchedule, 0)
+	assert.Equal(t, uint64(324000), stopSlot)
+	eah := [32]byte{0xee}
+	child.EpochAccountsHash = &eah
+	before, err := NewBankFromParent(child, stopSlot-1)
+	require.NoError(t, err)
+	assert.False(t, before.shouldIncludeEpochAccountsHash())
+	before.Freeze()
+	at, err := NewBankFromParent(before, stopSlot)
+	require.NoError(t, err)
+	assert.True(t, at.shouldIncludeEpochAccountsHash())
+	at.Freeze()
+	after, err := NewBankFromParent(at, stopSlot+1)
+	require.NoError(t, err)
+	assert.False(t, after.shouldIncludeEpochAccountsHash())
+}
+
+func TestEpochAccountsHash(t *testing.T) {
+	epochSchedule := sealevel.SysvarEpochSchedule{SlotsPerEpoch: 1000}
+	accts := accounts.NewMemAccounts()
+	root := NewBank(0, accts, features.NewFeaturesDefault(), epochSchedule)
+	require.NoError(t, root.StoreAccount([32]byte{1}, &accounts.Account{Lamports: 1}))
+	root.Freeze()
+	assert.Equal(t, uint64(250), EpochAccountsHashStartSlot(&epochSchedule, 0))
+	assert.Equal(t, uint64(750), EpochAccountsHashStopSlot(&epochSchedule, 0))
+
+	before, err := NewBankFromParent(root, 249)
+	require.NoError(t, err)
+	before.Freeze()
+	assert.Nil(t, before.EpochAccountsHash)
+
+	// the first bank at or past the start slot calculates the hash, which
+	// its descendants inherit
+	start, err := NewBankFromParent(before, 251)
+	require.NoError(t, err)
+	require.NoError(t, start.StoreAccount([32]byte{2}, &accounts.Account{Lamports: 2}))
+	start.Freeze()
+	require.NotNil(t, start.EpochAccountsHash)
+	eah := accounts.AccountsHash(accts.Map)
+	assert.Equal(t, eah, *start.EpochAccountsHash)
+	assert.Equal(t, &eah, start.EpochAccountsHashToSerialize())
+
+	child, err := NewBankFromParent(start, 252)
+	require.NoError(t, err)
+	require.NoError(t, child.StoreAccount([32]byte{3}, &accounts.Account{Lamports: 3}))
+	child.Freeze()
+	assert.Equal(t, &eah, child.EpochAccountsHash)
+
+	// snapshots past the stop slot do not hold it
+	stop, err := NewBankFromParent(child, 750)
+	require.NoError(t, err)
+	assert.True(t, stop.shouldIncludeEpochAccountsHash())
+	assert.Nil(t, stop.EpochAccountsHashToSerialize())
+
+	// the hash is no longer calculated once snapshots commit to the
+	// accounts lattice hash
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.SnapshotsLtHash, 0)
+	ltRoot := NewBank(0, accounts.NewMemAccounts(), f, epochSchedule)
+	ltRoot.Freeze()
+	ltStart, err := NewBankFromParent(ltRoot, 250)
+	require.NoError(t, err)
+	assert.False(t, ltStart.shouldCalculateEpochAccountsHash())
+	ltStart.Freeze()
+	assert.Nil(t, ltStart.EpochAccountsHash)
+}
+
+func TestFeatureActivations(t *testing.T) {
+	epochSchedule := sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32}
+	accts := accounts.NewMemAccounts()
+	feature := func(data ...byte) *accounts.Account {
+		return &accounts.Account{Lamports: 1, Owner: sealevel.FeatureProgramAddr, Data: data}
+	}
+	require.NoError(t, accts.SetAccount(&features.AccountsLtHash.Address, feature(1, 3, 0, 0, 0, 0, 0, 0, 0)))
+	require.NoError(t, accts.SetAccount(&features.SnapshotsLtHash.Address, feature(1, 40, 0, 0, 0, 0, 0, 0, 0)))
+	require.NoError(t, accts.SetAccount(&features.LastRestartSlotSysvar.Address, feature(0, 0, 0, 0, 0, 0, 0, 0, 0)))
+	require.NoError(t, accts.SetAccount(&features.TimelyVoteCredits.Address, &accounts.Account{Lamports: 1, Data: []byte{1, 0, 0, 0, 0, 0, 0, 0, 0}}))
+
+	// features activated up to the slot are active, pending ones are not
+	f := LoadFeatures(accts, 10)
+	slot, ok := f.ActivationSlot(features.AccountsLtHash)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), slot)
+	assert.False(t, f.IsActive(features.SnapshotsLtHash))
+	assert.False(t, f.IsActive(features.LastRestartSlotSysvar))
+	assert.False(t, f.IsActive(features.TimelyVoteCredits))
+
+	root := NewBank(10, accts, f, epochSchedule)
+	root.Freeze()
+	child, err := NewBankFromParent(root, 20)
+	require.NoError(t, err)
+	assert.Same(t, f, child.Features)
+	child.Freeze()
+
+	// pending features are activated at the first slot of an epoch, which
+	// is recorded in their accounts
+	next, err := NewBankFromParent(child, 33)
+	require.NoError(t, err)
+	slot, ok = next.Features.ActivationSlot(features.LastRestartSlotSysvar)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(33), slot)
+	assert.False(t, next.Features.IsActive(features.SnapshotsLtHash))
+	assert.False(t, f.IsActive(features.LastRestartSlotSysvar))
+	acct, err := accts.GetAccount(&features.LastRestartSlotSysvar.Address)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 33, 0, 0, 0, 0, 0, 0, 0}, acct.Data)
+	assert.Contains(t, next.written, features.LastRestartSlotSysvar.Address)
+	next.Freeze()
+
+	later, err := NewBankFromParent(next, 64)
+	require.NoError(t, err)
+	assert.True(t, later.Features.IsActive(features.SnapshotsLtHash))
+}
+
+func TestStakeHistoryUpdate(t *testing.T) {
+	epochSchedule := sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32}
+	accts := accounts.NewMemAccounts()
+	root := NewBank(0, accts, features.NewFeaturesDefault(), epochSchedule)
+	state := sealevel.StakeStateV2{
+		Status: sealevel.StakeStateV2StatusStake,
+		Stake: sealevel.StakeStateV2Stake{Stake: sealevel.Stake{Delegation: sealevel.Delegation{
+			VoterPubkey:       [32]byte{7},
+			StakeLamports:     1000,
+			ActivationEpoch:   math.MaxUint64,
+			DeactivationEpoch: math.MaxUint64,
+		}}},
+	}
+	var data bytes.Buffer
+	require.NoError(t, state.MarshalWithEncoder(bin.NewBinEncoder(&data)))
+	require.NoError(t, root.StoreAccount([32]byte{1}, &accounts.Account{Lamports: 1000, Owner: sealevel.StakeProgramAddr, Data: data.Bytes()}))
+	assert.Equal(t, map[[32]byte]sealevel.Stake{{1}: state.Stake.Stake}, root.Stakes.StakeDelegations())
+	root.Freeze()
+
+	child, err := NewBankFromParent(root, 1)
+	require.NoError(t, err)
+	assert.NotContains(t, child.written, sealevel.SysvarStakeHistoryAddr)
+	child.Freeze()
+
+	// the stake of the epoch is recorded at the first slot of the next
+	next, err := NewBankFromParent(child, 32)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), next.Stakes.Epoch())
+	expected := sealevel.SysvarStakeHistory{{Epoch: 0, Entry: sealevel.StakeHistoryEntry{Effective: 1000}}}
+	stakeHistory, err := next.SysvarCache.StakeHistory()
+	require.NoError(t, err)
+	assert.Equal(t, expected, *stakeHistory)
+	assert.Equal(t, expected, sealevel.ReadStakeHistorySysvar(&next.Accounts))
+
+	// closing the stake account removes its delegation
+	require.NoError(t, next.StoreAccount([32]byte{1}, &accounts.Account{Owner: sealevel.StakeProgramAddr}))
+	assert.Empty(t, next.Stakes.StakeDelegations())
+}
+
+func TestBankAccountsLtHash(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.AccountsLtHash, 0)
+	epochSchedule := sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32}
+	accts := accounts.NewMemAccounts()
+	root := NewBank(0, accts, f, epochSchedule)
+
+	pubkey := [32]byte{1}
+	first := &accounts.Account{Lamports: 1, Data: []byte{1}}
+	require.NoError(t, root.StoreAccount(pubkey, first))
+	require.NoError(t, root.StoreAccount(pubkey, &accounts.Account{Lamports: 2, Data: []byte{2}}))
+	root.Freeze()
+
+	// only the state at the end of the slot is mixed in
+	second := &accounts.Account{Lamports: 2, Data: []byte{2}}
+	assert.Equal(t, *second.LtHash(pubkey), root.AccountsLtHash)
+
+	// and mixed out again once the account changes, along with the
+	// SlotHashes sysvar the child stores
+	child, err := NewBankFromParent(root, 1)
+	require.NoError(t, err)
+	third := &accounts.Account{Lamports: 3, Owner: [32]byte{9}}
+	require.NoError(t, child.StoreAccount(pubkey, third))
+	child.Freeze()
+	slotHashes, err := accts.GetAccount(&sealevel.SysvarSlotHashesAddr)
+	require.NoError(t, err)
+	expected := *third.LtHash(pubkey)
+	expected.MixIn(slotHashes.LtHash(sealevel.SysvarSlotHashesAddr))
+	assert.Equal(t, expected, child.AccountsLtHash)
+	assert.Equal(t, *second.LtHash(pubkey), root.AccountsLtHash)
+
+	// closing the account mixes it out entirely
+	grandchild, err := NewBankFromParent(child, 2)
+	require.NoError(t, err)
+	require.NoError(t, grandchild.StoreAccount(pubkey, &accounts.Account{}))
+	grandchild.Freeze()
+	slotHashes, err = accts.GetAccount(&sealevel.SysvarSlotHashesAddr)
+	require.NoError(t, err)
+	assert.Equal(t, *slotHashes.LtHash(sealevel.SysvarSlotHashesAddr), grandchild.AccountsLtHash)
+
+	// the bank hash mixes in the whole lattice hash, of which the identity
+	// is all zeros
+	empty := NewBank(0, accounts.NewMemAccounts(), f, epochSchedule)
+	empty.Freeze()
+	emptyHash, _ := empty.Hash()
+	assert.Equal(t, "22861bcbdfe31d04534eec0d460c9681d98d96c90761cf7b424be8df6c8d2f0f", hex.EncodeToString(emptyHash[:]))
+
+	withoutLtHash := NewBank(0, accounts.NewMemAccounts(), features.NewFeaturesDefault(), epochSchedule)
+	withLtHash := NewBank(0, accounts.NewMemAccounts(), f, epochSchedule)
+	for _, b := range []*Bank{withoutLtHash, withLtHash} {
+		require.NoError(t, b.StoreAccount(pubkey, second))
+		b.Freeze()
+	}
+	hashWithout, _ := withoutLtHash.Hash()
+	hashWith, _ := withLtHash.Hash()
+	preimage := append([]byte{}, hashWithout[:]...)
+	for _, element := range second.LtHash(pubkey) {
+		preimage = binary.LittleEndian.AppendUint16(preimage, element)
+	}
+	assert.Equal(t, sha256.Sum256(preimage), hashWith)
+}
@@ -0,0 +1,134 @@
+package bank
+
+import (
+	"sort"
+
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// MaxRecentBlockhashes is the number of blockhashes transactions may
+// reference, and the number of entries of the RecentBlockhashes sysvar.
+const MaxRecentBlockhashes = 150
+
+// MaxProcessingAge is the age in blockhashes past which a transaction
+// referencing a blockhash is no longer processed.
+const MaxProcessingAge = MaxRecentBlockhashes
+
+// DefaultMaxBlockhashQueueAge is the number of blockhashes kept by the
+// blockhash queue of a bank.
+const DefaultMaxBlockhashQueueAge = 300
+
+// HashInfo is a blockhash registered in the blockhash queue, with the fee
+// rate at the time.
+type HashInfo struct {
+	LamportsPerSignature uint64
+	HashIndex            uint64
+}
+
+// BlockhashQueue holds the recent blockhashes of a bank, which transactions
+// may reference.
+//
+// Based on solana_runtime::blockhash_queue::BlockhashQueue.
+type BlockhashQueue struct {
+	lastHash  *[32]byte
+	lastIndex uint64
+	hashes    map[[32]byte]HashInfo
+	maxAge    uint64
+}
+
+// NewBlockhashQueue returns an empty queue that keeps up to maxAge
+// blockhashes.
+func NewBlockhashQueue(maxAge uint64) *BlockhashQueue {
+	return &BlockhashQueue{hashes: make(map[[32]byte]HashInfo), maxAge: maxAge}
+}
+
+// RestoreBlockhashQueue returns a queue holding the given blockhashes, as
+// restored from a snapshot, of which lastHash was registered last, at
+// lastIndex.
+func RestoreBlockhashQueue(lastHash *[32]byte, lastIndex uint64, hashes map[[32]byte]HashInfo, maxAge uint64) *BlockhashQueue {
+	return &BlockhashQueue{lastHash: lastHash, lastIndex: lastIndex, hashes: hashes, maxAge: maxAge}
+}
+
+// Clone returns a copy of the queue, which may be updated without affecting
+// the original.
+func (q *BlockhashQueue) Clone() *BlockhashQueue {
+	clone := *q
+	clone.hashes = make(map[[32]byte]HashInfo, len(q.hashes))
+	for hash, info := range q.hashes {
+		clone.hashes[hash] = info
+	}
+	return &clone
+}
+
+// LastHash returns the most recently registered blockhash, or false if the
+// queue is empty.
+func (q *BlockhashQueue) LastHash() ([32]byte, bool) {
+	if q.lastHash == nil {
+		return [32]byte{}, false
+	}
+	return *q.lastHash, true
+}
+
+// LastHashIndex returns the index of the most recently registered
+// blockhash.
+func (q *BlockhashQueue) LastHashIndex() uint64 {
+	return q.lastIndex
+}
+
+// MaxAge returns the number of blockhashes kept by the queue.
+func (q *BlockhashQueue) MaxAge() uint64 {
+	return q.maxAge
+}
+
+// Hashes returns a copy of the blockhashes in the queue.
+func (q *BlockhashQueue) Hashes() map[[32]byte]HashInfo {
+	return q.Clone().hashes
+}
+
+// LamportsPerSignature returns the fee rate at the time the given blockhash
+// was registered, or false if it is not in the queue.
+func (q *BlockhashQueue) LamportsPerSignature(hash [32]byte) (uint64, bool) {
+	info, ok := q.hashes[hash]
+	return info.LamportsPerSignature, ok
+}
+
+// IsHashValidForAge returns whether the given blockhash is at most maxAge
+// blockhashes old.
+func (q *BlockhashQueue) IsHashValidForAge(hash [32]byte, maxAge uint64) bool {
+	info, ok := q.hashes[hash]
+	return ok && q.lastIndex-info.HashIndex <= maxAge
+}
+
+// Register adds a blockhash to the queue, evicting the blockhashes that
+// became too old.
+func (q *BlockhashQueue) Register(hash [32]byte, lamportsPerSignature uint64) {
+	q.lastIndex++
+	for h, info := range q.hashes {
+		if q.lastIndex-info.HashIndex > q.maxAge {
+			delete(q.hashes, h)
+		}
+	}
+	q.hashes[hash] = HashInfo{LamportsPerSignature: lamportsPerSignature, HashIndex: q.lastIndex}
+	q.lastHash = &hash
+}
+
+// RecentBlockhashes returns the most recent blockhashes, newest first, as
+// held by the RecentBlockhashes sysvar.
+func (q *BlockhashQueue) RecentBlockhashes() sealevel.SysvarRecentBlockhashes {
+	recent := make(sealevel.SysvarRecentBlockhashes, 0, len(q.hashes))
+	indexes := make(map[[32]byte]uint64, len(q.hashes))
+	for hash, info := range q.hashes {
+		recent = append(recent, sealevel.RecentBlockHashesEntry{
+			Blockhash:     hash,
+			FeeCalculator: sealevel.FeeCalculator{LamportsPerSignature: info.LamportsPerSignature},
+		})
+		indexes[hash] = info.HashIndex
+	}
+	sort.Slice(recent, func(i, j int) bool {
+		return indexes[recent[i].Blockhash] > indexes[recent[j].Blockhash]
+	})
+	if len(recent) > MaxRecentBlockhashes {
+		recent = recent[:MaxRecentBlockhashes]
+	}
+	return recent
+}
@@ -0,0 +1,110 @@
+package bank
+
+import (
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"k8s.io/klog/v2"
+)
+
+// MinimumEpochAccountsHashInterval is the fewest slots between the start
+// and the stop of the epoch accounts hash calculation for it to be enabled
+// in an epoch: the max lockout history, plus a buffer.
+const MinimumEpochAccountsHashInterval = 31 + 150
+
+// EpochAccountsHashStartSlot returns the slot at which the epoch accounts
+// hash calculation of the given epoch starts, a quarter into the epoch. The
+// epoch accounts hash is the accounts hash of the first bank at or past
+// this slot.
+//
+// Based on solana_runtime::epoch_accounts_hash::calculation_start.
+func EpochAccountsHashStartSlot(epochSchedule *sealevel.SysvarEpochSchedule, epoch uint64) uint64 {
+	slotsPerEpoch := epochSchedule.GetSlotsInEpoch(epoch)
+	return epochSchedule.GetFirstSlotInEpoch(epoch) + slotsPerEpoch/4
+}
+
+// EpochAccountsHashStopSlot returns the slot at which the epoch accounts
+// hash calculation of the given epoch stops, three quarters into the epoch.
+// The first bank at or past this slot commits to the epoch accounts hash.
+//
+// Based on solana_runtime::epoch_accounts_hash::calculation_stop.
+func EpochAccountsHashStopSlot(epochSchedule *sealevel.SysvarEpochSchedule, epoch uint64) uint64 {
+	slotsPerEpoch := epochSchedule.GetSlotsInEpoch(epoch)
+	return epochSchedule.GetFirstSlotInEpoch(epoch) + slotsPerEpoch*3/4
+}
+
+// isEpochAccountsHashEnabled returns whether the epoch accounts hash is
+// calculated in the epoch of the bank: its calculation window must be long
+// enough for the start bank to be rooted before the stop, and snapshots
+// must not commit to the accounts lattice hash instead.
+//
+// Based on solana_runtime::epoch_accounts_hash::is_enabled_this_epoch.
+func (bank *Bank) isEpochAccountsHashEnabled() bool {
+	if bank.Features.IsActive(features.SnapshotsLtHash) {
+		return false
+	}
+	slotsPerEpoch := bank.EpochSchedule.GetSlotsInEpoch(bank.Epoch)
+	return slotsPerEpoch*3/4-slotsPerEpoch/4 >= MinimumEpochAccountsHashInterval
+}
+
+// shouldCalculateEpochAccountsHash returns whether the bank is the first of
+// its epoch at or past the start slot of the epoch accounts hash
+// calculation.
+//
+// Based on solana_runtime::bank_forks::BankForks::set_root.
+func (bank *Bank) shouldCalculateEpochAccountsHash() bool {
+	if !bank.isEpochAccountsHashEnabled() {
+		return false
+	}
+	startSlot := EpochAccountsHashStartSlot(&bank.EpochSchedule, bank.Epoch)
+	return bank.ParentSlot < startSlot && bank.Slot >= startSlot
+}
+
+// shouldIncludeEpochAccountsHash returns whether the bank is the first of
+// its epoch at or past the stop slot of the epoch accounts hash calculation.
+//
+// Based on solana_runtime::bank::Bank::should_include_epoch_accounts_hash.
+func (bank *Bank) shouldIncludeEpochAccountsHash() bool {
+	if !bank.isEpochAccountsHashEnabled() {
+		return false
+	}
+	stopSlot := EpochAccountsHashStopSlot(&bank.EpochSchedule, bank.Epoch)
+	return bank.ParentSlot < stopSlot && bank.Slot >= stopSlot
+}
+
+// calculateEpochAccountsHash sets the epoch accounts hash of the bank, and
+// of its descendants, to the accounts hash of all accounts at the end of
+// its slot. Agave calculates it in the background once the bank is rooted;
+// replay follows a single fork, so the accounts of the bank are final.
+//
+// Based on solana_core::accounts_hash_verifier::AccountsHashVerifier::calculate_and_verify_accounts_hash.
+func (bank *Bank) calculateEpochAccountsHash() {
+	mem, ok := bank.Accounts.(accounts.MemAccounts)
+	if !ok {
+		klog.Warningf("Cannot calculate epoch accounts hash of slot %d from %T", bank.Slot, bank.Accounts)
+		bank.EpochAccountsHash = nil
+		return
+	}
+	eah := accounts.AccountsHash(mem.Map)
+	bank.EpochAccountsHash = &eah
+	klog.Infof("Epoch accounts hash of epoch %d calculated at slot %d: %s",
+		bank.Epoch, bank.Slot, base58.Encode(eah[:]))
+}
+
+// EpochAccountsHashToSerialize returns the epoch accounts hash that
+// snapshots of the bank hold, and commit to, or nil outside of the
+// calculation window, exclusive of its start and stop slots.
+//
+// Based on solana_runtime::bank::Bank::get_epoch_accounts_hash_to_serialize.
+func (bank *Bank) EpochAccountsHashToSerialize() *[32]byte {
+	if !bank.isEpochAccountsHashEnabled() {
+		return nil
+	}
+	startSlot := EpochAccountsHashStartSlot(&bank.EpochSchedule, bank.Epoch)
+	stopSlot := EpochAccountsHashStopSlot(&bank.EpochSchedule, bank.Epoch)
+	if bank.Slot <= startSlot || bank.Slot >= stopSlot {
+		return nil
+	}
+	return bank.EpochAccountsHash
+}
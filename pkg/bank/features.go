@@ -0,0 +1,74 @@
+package bank
+
+import (
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"k8s.io/klog/v2"
+)
+
+// LoadFeatures returns the features that the feature accounts record as
+// activated at or before the given slot.
+func LoadFeatures(accts accounts.Accounts, slot uint64) *features.Features {
+	active, _ := computeActiveFeatureSet(accts, slot, false)
+	return active
+}
+
+// computeActiveFeatureSet returns the features that the feature accounts
+// record as activated at or before the given slot and, if includePending,
+// the features pending activation, which are activated at the slot.
+//
+// Based on solana_runtime::bank::Bank::compute_active_feature_set.
+func computeActiveFeatureSet(accts accounts.Accounts, slot uint64, includePending bool) (*features.Features, []features.FeatureGate) {
+	active := features.NewFeaturesDefault()
+	var pending []features.FeatureGate
+	for _, gate := range features.AllFeatureGates {
+		address := gate.Address
+		acct, err := accts.GetAccount(&address)
+		if err != nil || acct == nil || acct.Owner != sealevel.FeatureProgramAddr {
+			continue
+		}
+		activatedAt, activated, ok := features.ActivatedAt(acct.Data)
+		switch {
+		case !ok:
+		case !activated && includePending:
+			pending = append(pending, gate)
+			active.EnableFeature(gate, slot)
+		case activated && activatedAt <= slot:
+			active.EnableFeature(gate, activatedAt)
+		}
+	}
+	return active, pending
+}
+
+// applyFeatureActivations activates the features pending activation at the
+// slot of the bank, recording the slot in their feature accounts. The
+// features of the bank are copied, as they are shared with its parent.
+//
+// Based on solana_runtime::bank::Bank::apply_feature_activations.
+func (bank *Bank) applyFeatureActivations() error {
+	active, pending := computeActiveFeatureSet(bank.Accounts, bank.Slot, true)
+	f := bank.Features.Clone()
+	for gate, info := range *active {
+		if !f.IsActive(gate) {
+			f.EnableFeature(gate, info.ActivationSlot)
+		}
+	}
+	bank.Features = f
+
+	for _, gate := range pending {
+		address := gate.Address
+		acct, err := bank.Accounts.GetAccount(&address)
+		if err != nil {
+			return err
+		}
+		acct = copyAccount(acct)
+		if features.SetActivatedAt(acct.Data, bank.Slot) {
+			if err := bank.StoreAccount(address, acct); err != nil {
+				return err
+			}
+		}
+		klog.Infof("feature %s activated at slot %d", gate.Name, bank.Slot)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package bank
+
+import (
+	"fmt"
+
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/safemath"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"k8s.io/klog/v2"
+)
+
+// distributeTransactionFees pays the leader of the slot its reward from the
+// transaction fees collected in the slot. The rest of the fees, and the
+// reward if it cannot be deposited, are burned.
+//
+// Based on solana_runtime::bank::fee_distribution::Bank::distribute_transaction_fee_details.
+func (bank *Bank) distributeTransactionFees() {
+	reward, _ := bank.CollectorFeeDetails.RewardAndBurn(bank.FeeBurnPercent, bank.Features)
+	if reward == 0 {
+		return
+	}
+	if err := bank.depositFees(bank.CollectorId, reward); err != nil {
+		klog.V(3).Infof("Slot %d: burned fee reward of %d lamports: %s", bank.Slot, reward, err)
+	}
+}
+
+// depositFees deposits fees to the given system account, which must not be
+// left rent paying unless it already was.
+//
+// Based on solana_runtime::bank::fee_distribution::Bank::deposit_fees.
+func (bank *Bank) depositFees(pubkey [32]byte, fees uint64) error {
+	acct := &accounts.Account{}
+	if existing, err := bank.Accounts.GetAccount(&pubkey); err == nil && existing != nil {
+		acct = copyAccount(existing)
+	}
+	if acct.Owner != sealevel.SystemProgramAddr {
+		return fmt.Errorf("fee collector is not a system account")
+	}
+
+	var rent sealevel.SysvarRent
+	if r, err := bank.SysvarCache.Rent(); err == nil {
+		rent = *r
+	}
+	pre := sealevel.NewRentState(acct, &rent)
+	lamports, err := safemath.CheckedAddU64(acct.Lamports, fees)
+	if err != nil {
+		return fmt.Errorf("fee collector lamports overflow")
+	}
+	acct.Lamports = lamports
+	if !sealevel.NewRentState(acct, &rent).TransitionAllowedFrom(pre) {
+		return fmt.Errorf("fee collector would be left rent paying")
+	}
+	return bank.StoreAccount(pubkey, acct)
+}
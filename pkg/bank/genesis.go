@@ -0,0 +1,71 @@
+package bank
+
+import (
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/genesis"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// NewGenesisBank returns the bank of slot 0, with the accounts of genesis
+// stored into accts, and the genesis hash as its only blockhash, which
+// starts the PoH chain. Sysvars that genesis has no account for are
+// derived from its config, and the features that genesis records as
+// activated are enabled in f.
+//
+// Based on solana_runtime::bank::Bank::new_with_paths.
+func NewGenesisBank(g *genesis.Genesis, genesisHash [32]byte, accts accounts.Accounts, f *features.Features) (*Bank, error) {
+	g.FillAccounts(accts)
+	for gate, info := range *LoadFeatures(accts, 0) {
+		f.EnableFeature(gate, info.ActivationSlot)
+	}
+
+	epochSchedule := sealevel.SysvarEpochSchedule{
+		SlotsPerEpoch:            g.EpochSchedule.SlotPerEpoch,
+		LeaderScheduleSlotOffset: g.EpochSchedule.LeaderScheduleSlotOffset,
+		Warmup:                   g.EpochSchedule.Warmup,
+		FirstNormalEpoch:         g.EpochSchedule.FirstNormalEpoch,
+		FirstNormalSlot:          g.EpochSchedule.FirstNormalSlot,
+	}
+	bank := NewBank(0, accts, f, epochSchedule)
+	for i := range g.Accounts {
+		bank.Stakes.CheckAndStore(g.Accounts[i].Pubkey, &g.Accounts[i].Account)
+	}
+	for epoch := uint64(0); epoch <= epochSchedule.GetLeaderScheduleEpoch(0); epoch++ {
+		bank.Stakes.updateEpochStakes(epoch, bank.NewWarmupCooldownRateEpoch())
+	}
+	bank.TicksPerSlot = g.TicksPerSlot
+	bank.LamportsPerSignature = g.Fees.TargetLamportsPerSig
+	bank.FeeBurnPercent = g.Fees.BurnPercent
+	bank.SlotsPerYear = g.SlotsPerYear()
+
+	if _, err := bank.SysvarCache.Clock(); err != nil {
+		timestamp := g.CreationTime.Unix()
+		bank.SysvarCache.SetClock(sealevel.SysvarClock{
+			EpochStartTimestamp: timestamp,
+			LeaderScheduleEpoch: epochSchedule.GetLeaderScheduleEpoch(0),
+			UnixTimestamp:       timestamp,
+		})
+	}
+	if _, err := bank.SysvarCache.EpochSchedule(); err != nil {
+		bank.SysvarCache.SetEpochSchedule(epochSchedule)
+	}
+	if _, err := bank.SysvarCache.Rent(); err != nil {
+		bank.SysvarCache.SetRent(sealevel.SysvarRent{
+			LamportsPerUint8Year: g.Rent.LamportsPerByteYear,
+			ExemptionThreshold:   g.Rent.ExemptionThreshold,
+			BurnPercent:          g.Rent.BurnPercent,
+		})
+	}
+	if _, err := bank.SysvarCache.Fees(); err != nil {
+		bank.SysvarCache.SetFees(sealevel.SysvarFees{
+			FeeCalculator: sealevel.FeeCalculator{LamportsPerSignature: bank.LamportsPerSignature},
+		})
+	}
+
+	if err := bank.RegisterBlockhash(genesisHash); err != nil {
+		return nil, err
+	}
+	bank.SysvarCache.SetRecentBlockHashes(bank.BlockhashQueue.RecentBlockhashes())
+	return bank, nil
+}
@@ -0,0 +1,52 @@
+package bank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/genesis"
+)
+
+func TestNewGenesisBank(t *testing.T) {
+	f := fixtures.Open(t, "genesis", "mainnet.tar.bz2")
+	defer f.Close()
+	g, hash, err := genesis.ReadGenesisFromArchive(f)
+	require.NoError(t, err)
+
+	accts := accounts.NewMemAccounts()
+	bank, err := NewGenesisBank(g, *hash, accts, features.NewFeaturesDefault())
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(0), bank.Slot)
+	assert.Equal(t, uint64(0), bank.Epoch)
+	assert.Equal(t, uint64(64), bank.TicksPerSlot)
+	assert.Equal(t, uint64(10000), bank.LamportsPerSignature)
+	assert.Equal(t, *hash, bank.LastBlockhash())
+	assert.Equal(t, uint64(432000), bank.EpochSchedule.SlotsPerEpoch)
+
+	clock, err := bank.SysvarCache.Clock()
+	require.NoError(t, err)
+	assert.Equal(t, g.CreationTime.Unix(), clock.UnixTimestamp)
+	assert.Equal(t, uint64(1), clock.LeaderScheduleEpoch)
+	rent, err := bank.SysvarCache.Rent()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3480), rent.LamportsPerUint8Year)
+	recent, err := bank.SysvarCache.RecentBlockHashes()
+	require.NoError(t, err)
+	assert.Len(t, *recent, 1)
+
+	system := g.Builtins[2]
+	acct, err := accts.GetAccount(&system.Pubkey)
+	require.NoError(t, err)
+	assert.True(t, acct.Executable)
+
+	// replay continues from the frozen genesis bank
+	bank.Freeze()
+	child, err := NewBankFromParent(bank, 1)
+	require.NoError(t, err)
+	assert.Equal(t, *hash, child.LastBlockhash())
+}
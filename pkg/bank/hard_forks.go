@@ -0,0 +1,108 @@
+package bank
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// HardFork is a slot at which the cluster restarted, and the number of
+// times it restarted at the slot.
+type HardFork struct {
+	Slot  uint64
+	Count uint64
+}
+
+// HardForks are the hard forks of a cluster, which are shared by a bank
+// with its descendants. A bank that descends from a hard fork has a
+// different hash, and its shreds a different version, than the banks of
+// the same slot replayed without it.
+//
+// Based on solana_sdk::hard_forks::HardForks.
+type HardForks struct {
+	mu    sync.RWMutex
+	forks []HardFork
+}
+
+// NewHardForks returns hard forks with the given entries.
+func NewHardForks(forks ...HardFork) *HardForks {
+	h := &HardForks{forks: append([]HardFork(nil), forks...)}
+	sort.Slice(h.forks, func(i, j int) bool { return h.forks[i].Slot < h.forks[j].Slot })
+	return h
+}
+
+// Register registers a hard fork at a slot.
+//
+// Based on solana_sdk::hard_forks::HardForks::register.
+func (h *HardForks) Register(slot uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := sort.Search(len(h.forks), func(i int) bool { return h.forks[i].Slot >= slot })
+	if i < len(h.forks) && h.forks[i].Slot == slot {
+		h.forks[i].Count++
+		return
+	}
+	h.forks = append(h.forks, HardFork{})
+	copy(h.forks[i+1:], h.forks[i:])
+	h.forks[i] = HardFork{Slot: slot, Count: 1}
+}
+
+// Forks returns the hard forks in slot order.
+func (h *HardForks) Forks() []HardFork {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]HardFork(nil), h.forks...)
+}
+
+// HashData returns the data mixed into the hash of the bank of a slot, the
+// number of hard forks after its parent slot up to the slot, or false if
+// there are none.
+//
+// Based on solana_sdk::hard_forks::HardForks::get_hash_data.
+func (h *HardForks) HashData(slot, parentSlot uint64) ([8]byte, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var count uint64
+	for _, fork := range h.forks {
+		if parentSlot < fork.Slot && fork.Slot <= slot {
+			count += fork.Count
+		}
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], count)
+	return buf, count > 0
+}
+
+// LastRestartSlot returns the slot of the last hard fork up to a slot, or
+// 0 if there is none.
+func (h *HardForks) LastRestartSlot(slot uint64) uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for i := len(h.forks) - 1; i >= 0; i-- {
+		if h.forks[i].Slot <= slot {
+			return h.forks[i].Slot
+		}
+	}
+	return 0
+}
+
+// ShredVersion returns the version of the shreds of a cluster with the
+// given genesis hash and hard forks.
+//
+// Based on solana_sdk::shred_version::compute_shred_version.
+func (h *HardForks) ShredVersion(genesisHash [32]byte) uint16 {
+	hash := genesisHash
+	var buf [16]byte
+	for _, fork := range h.Forks() {
+		binary.LittleEndian.PutUint64(buf[0:8], fork.Slot)
+		binary.LittleEndian.PutUint64(buf[8:16], fork.Count)
+		hasher := sha256.New()
+		hasher.Write(hash[:])
+		hasher.Write(buf[:])
+		copy(hash[:], hasher.Sum(nil))
+	}
+	return shred.VersionFromHash(hash)
+}
@@ -0,0 +1,93 @@
+package bank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func TestHardForks(t *testing.T) {
+	h := NewHardForks()
+	h.Register(20)
+	h.Register(10)
+	assert.Equal(t, []HardFork{{Slot: 10, Count: 1}, {Slot: 20, Count: 1}}, h.Forks())
+
+	_, ok := h.HashData(9, 0)
+	assert.False(t, ok)
+	buf, ok := h.HashData(10, 0)
+	assert.True(t, ok)
+	assert.Equal(t, [8]byte{1}, buf)
+	buf, _ = h.HashData(20, 0)
+	assert.Equal(t, [8]byte{2}, buf)
+	buf, _ = h.HashData(20, 10)
+	assert.Equal(t, [8]byte{1}, buf)
+	_, ok = h.HashData(21, 20)
+	assert.False(t, ok)
+
+	h.Register(10)
+	assert.Equal(t, []HardFork{{Slot: 10, Count: 2}, {Slot: 20, Count: 1}}, h.Forks())
+	buf, _ = h.HashData(19, 9)
+	assert.Equal(t, [8]byte{2}, buf)
+
+	assert.Equal(t, uint64(0), h.LastRestartSlot(9))
+	assert.Equal(t, uint64(10), h.LastRestartSlot(19))
+	assert.Equal(t, uint64(20), h.LastRestartSlot(100))
+}
+
+func TestHardForks_ShredVersion(t *testing.T) {
+	h := NewHardForks()
+	assert.Equal(t, uint16(1), h.ShredVersion([32]byte{}))
+	h.Register(1)
+	assert.Equal(t, uint16(55551), h.ShredVersion([32]byte{}))
+	h.Register(1)
+	assert.Equal(t, uint16(46353), h.ShredVersion([32]byte{}))
+}
+
+func TestBank_HardFork(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.LastRestartSlotSysvar, 0)
+	epochSchedule := sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32}
+	newRoot := func() *Bank {
+		root := NewBank(0, accounts.NewMemAccounts(), f, epochSchedule)
+		require.NoError(t, root.RegisterBlockhash([32]byte{1}))
+		root.Freeze()
+		return root
+	}
+
+	forked := newRoot()
+	assert.False(t, forked.RegisterHardFork(0))
+	assert.True(t, forked.RegisterHardFork(2))
+	unforked := newRoot()
+
+	// the hash of a bank commits to the hard forks since its parent
+	for slot := uint64(1); slot <= 3; slot++ {
+		var err error
+		forked, err = NewBankFromParent(forked, slot)
+		require.NoError(t, err)
+		require.NoError(t, forked.RegisterBlockhash([32]byte{byte(slot)}))
+		forked.Freeze()
+		unforked, err = NewBankFromParent(unforked, slot)
+		require.NoError(t, err)
+		require.NoError(t, unforked.RegisterBlockhash([32]byte{byte(slot)}))
+		unforked.Freeze()
+
+		forkedHash, _ := forked.Hash()
+		unforkedHash, _ := unforked.Hash()
+		if slot < 2 {
+			assert.Equal(t, forkedHash, unforkedHash)
+		} else {
+			assert.NotEqual(t, forkedHash, unforkedHash)
+		}
+		lastRestartSlot, err := forked.SysvarCache.LastRestartSlot()
+		require.NoError(t, err)
+		if slot < 2 {
+			assert.Equal(t, uint64(0), lastRestartSlot.LastRestartSlot)
+		} else {
+			assert.Equal(t, uint64(2), lastRestartSlot.LastRestartSlot)
+		}
+	}
+}
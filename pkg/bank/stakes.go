@@ -0,0 +1,241 @@
+package bank
+
+import (
+	"sync"
+
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// Offsets of the prior voters of the current and 1.14.11 vote states, up to
+// which an initialized vote account is non-zero.
+const (
+	voteStatePriorVotersOffset        = 114
+	voteState1_14_11PriorVotersOffset = 82
+)
+
+// StakesCache holds the vote accounts and the stake delegations of the
+// cluster as of the accounts last stored, and the stake history of the
+// epochs before Epoch, so the stake of the cluster is known without
+// scanning all accounts. It is shared by a bank with its descendants.
+//
+// Based on solana_runtime::stakes::StakesCache.
+type StakesCache struct {
+	mu           sync.RWMutex
+	voteAccounts map[[32]byte]*accounts.Account
+	delegations  map[[32]byte]sealevel.Stake
+	epoch        uint64
+	history      sealevel.SysvarStakeHistory
+	epochStakes  map[uint64]*EpochStakes
+}
+
+// EpochStakes is the stake of the cluster as of the first slot of the
+// epoch before a leader schedule epoch, from which the leader schedule and
+// the vote tallies of that epoch are computed.
+//
+// Based on solana_runtime::epoch_stakes::EpochStakes.
+type EpochStakes struct {
+	// Epoch is the epoch of the stakes, before the leader schedule epoch.
+	Epoch             uint64
+	VoteAccounts      map[[32]byte]*accounts.Account
+	VoteAccountStakes map[[32]byte]uint64
+	StakeDelegations  map[[32]byte]sealevel.Stake
+	History           sealevel.SysvarStakeHistory
+}
+
+// MaxLeaderScheduleStakes is the number of epochs before the current leader
+// schedule epoch of which the epoch stakes are retained.
+//
+// Based on solana_runtime::bank::MAX_LEADER_SCHEDULE_STAKES.
+const MaxLeaderScheduleStakes = 5
+
+// NewStakesCache returns an empty cache for the given epoch, with the stake
+// history of the previous epochs.
+func NewStakesCache(epoch uint64, history sealevel.SysvarStakeHistory) *StakesCache {
+	return &StakesCache{
+		voteAccounts: make(map[[32]byte]*accounts.Account),
+		delegations:  make(map[[32]byte]sealevel.Stake),
+		epoch:        epoch,
+		history:      append(sealevel.SysvarStakeHistory{}, history...),
+		epochStakes:  make(map[uint64]*EpochStakes),
+	}
+}
+
+// CheckAndStore updates the cache with an account being stored, if it is
+// owned by the vote or the stake program. Closed and uninitialized accounts
+// are removed.
+//
+// Based on solana_runtime::stakes::StakesCache::check_and_store.
+func (s *StakesCache) CheckAndStore(pubkey [32]byte, acct *accounts.Account) {
+	switch acct.Owner {
+	case sealevel.VoteProgramAddr:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if acct.Lamports == 0 || !isVoteAccountInitialized(acct.Data) {
+			delete(s.voteAccounts, pubkey)
+			return
+		}
+		s.voteAccounts[pubkey] = copyAccount(acct)
+	case sealevel.StakeProgramAddr:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		var state sealevel.StakeStateV2
+		if acct.Lamports == 0 || state.UnmarshalWithDecoder(bin.NewBinDecoder(acct.Data)) != nil ||
+			state.Status != sealevel.StakeStateV2StatusStake {
+			delete(s.delegations, pubkey)
+			return
+		}
+		s.delegations[pubkey] = state.Stake.Stake
+	}
+}
+
+// isVoteAccountInitialized returns whether the data of a vote account is
+// an initialized vote state, of the current or 1.14.11 version.
+//
+// Based on solana_vote_interface::state::VoteStateVersions::is_correct_size_and_initialized.
+func isVoteAccountInitialized(data []byte) bool {
+	var priorVotersOffset int
+	switch len(data) {
+	case sealevel.VoteStateV3Size:
+		priorVotersOffset = voteStatePriorVotersOffset
+	case sealevel.VoteStateV2Size:
+		priorVotersOffset = voteState1_14_11PriorVotersOffset
+	default:
+		return false
+	}
+	// the version is followed by the node pubkey and authorities
+	for _, b := range data[4 : 4+priorVotersOffset] {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Epoch returns the epoch of the cache.
+func (s *StakesCache) Epoch() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.epoch
+}
+
+// History returns the stake history of the epochs before that of the
+// cache.
+func (s *StakesCache) History() sealevel.SysvarStakeHistory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append(sealevel.SysvarStakeHistory{}, s.history...)
+}
+
+// VoteAccounts returns the vote accounts of the cluster.
+func (s *StakesCache) VoteAccounts() map[[32]byte]*accounts.Account {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	voteAccounts := make(map[[32]byte]*accounts.Account, len(s.voteAccounts))
+	for pubkey, acct := range s.voteAccounts {
+		voteAccounts[pubkey] = acct
+	}
+	return voteAccounts
+}
+
+// StakeDelegations returns the stakes of the stake accounts of the
+// cluster, by stake account.
+func (s *StakesCache) StakeDelegations() map[[32]byte]sealevel.Stake {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	delegations := make(map[[32]byte]sealevel.Stake, len(s.delegations))
+	for pubkey, stake := range s.delegations {
+		delegations[pubkey] = stake
+	}
+	return delegations
+}
+
+// VoteAccountStakes returns the effective stake delegated to each vote
+// account in the epoch of the cache.
+//
+// Based on solana_runtime::stakes::Stakes::refresh_vote_accounts.
+func (s *StakesCache) VoteAccountStakes(newRateActivationEpoch *uint64) map[[32]byte]uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.voteAccountStakes(newRateActivationEpoch)
+}
+
+func (s *StakesCache) voteAccountStakes(newRateActivationEpoch *uint64) map[[32]byte]uint64 {
+	stakes := make(map[[32]byte]uint64, len(s.voteAccounts))
+	for pubkey := range s.voteAccounts {
+		stakes[pubkey] = 0
+	}
+	for _, stake := range s.delegations {
+		voter := [32]byte(stake.Delegation.VoterPubkey)
+		if _, ok := stakes[voter]; !ok {
+			continue
+		}
+		stakes[voter] += stake.Delegation.Stake(s.epoch, s.history, newRateActivationEpoch)
+	}
+	return stakes
+}
+
+// EpochStakes returns the epoch stakes captured by the cache, by leader
+// schedule epoch.
+func (s *StakesCache) EpochStakes() map[uint64]*EpochStakes {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	epochStakes := make(map[uint64]*EpochStakes, len(s.epochStakes))
+	for epoch, stakes := range s.epochStakes {
+		epochStakes[epoch] = stakes
+	}
+	return epochStakes
+}
+
+// updateEpochStakes captures the stakes of the cache for the given leader
+// schedule epoch, unless they already were, and drops those of the epochs
+// more than MaxLeaderScheduleStakes before it.
+//
+// Based on solana_runtime::bank::Bank::update_epoch_stakes.
+func (s *StakesCache) updateEpochStakes(leaderScheduleEpoch uint64, newRateActivationEpoch *uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.epochStakes[leaderScheduleEpoch]; ok {
+		return
+	}
+	epochStakes := &EpochStakes{
+		Epoch:             s.epoch,
+		VoteAccounts:      make(map[[32]byte]*accounts.Account, len(s.voteAccounts)),
+		VoteAccountStakes: s.voteAccountStakes(newRateActivationEpoch),
+		StakeDelegations:  make(map[[32]byte]sealevel.Stake, len(s.delegations)),
+		History:           append(sealevel.SysvarStakeHistory{}, s.history...),
+	}
+	for pubkey, acct := range s.voteAccounts {
+		epochStakes.VoteAccounts[pubkey] = acct
+	}
+	for pubkey, stake := range s.delegations {
+		epochStakes.StakeDelegations[pubkey] = stake
+	}
+	s.epochStakes[leaderScheduleEpoch] = epochStakes
+	for epoch := range s.epochStakes {
+		if epoch+MaxLeaderScheduleStakes < leaderScheduleEpoch {
+			delete(s.epochStakes, epoch)
+		}
+	}
+}
+
+// activateEpoch records in the stake history the stake activation of the
+// epoch of the cache, which has ended, and moves the cache to the next
+// epoch. Activating an epoch again, as sibling banks do, does nothing.
+//
+// Based on solana_runtime::stakes::Stakes::activate_epoch.
+func (s *StakesCache) activateEpoch(nextEpoch uint64, newRateActivationEpoch *uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nextEpoch <= s.epoch {
+		return
+	}
+	delegations := make([]sealevel.Delegation, 0, len(s.delegations))
+	for _, stake := range s.delegations {
+		delegations = append(delegations, stake.Delegation)
+	}
+	entry := sealevel.StakeHistoryEntryForEpoch(s.epoch, delegations, s.history, newRateActivationEpoch)
+	s.history.Add(s.epoch, entry)
+	s.epoch = nextEpoch
+}
@@ -0,0 +1,238 @@
+package bank
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// MaxCacheEntries is the number of rooted slots of which the status cache
+// keeps the statuses. Older transactions reference blockhashes that are no
+// longer in the blockhash queue, and would fail the age check anyway.
+const MaxCacheEntries = DefaultMaxBlockhashQueueAge
+
+// CachedKeySize is the size of the slice of a key, a message hash or a
+// signature, that the status cache stores.
+const CachedKeySize = 20
+
+// KeyStatus is the status of a transaction, of which Key is the slice of
+// the key that the status cache stores. Err is nil for a successful
+// transaction.
+type KeyStatus struct {
+	Key [CachedKeySize]byte
+	Err error
+}
+
+// BlockhashStatuses are the statuses of the transactions of a slot that
+// reference a blockhash. The keys of the transactions are sliced at
+// KeyIndex.
+type BlockhashStatuses struct {
+	KeyIndex int
+	Statuses []KeyStatus
+}
+
+// SlotDelta holds the statuses of the transactions of a slot, by the
+// blockhash they reference, as serialized in snapshots.
+//
+// Based on solana_runtime::status_cache::SlotDelta.
+type SlotDelta struct {
+	Slot     uint64
+	IsRoot   bool
+	Statuses map[[32]byte]*BlockhashStatuses
+}
+
+type slotStatus struct {
+	slot uint64
+	err  error
+}
+
+type blockhashEntry struct {
+	maxSlot  uint64
+	keyIndex int
+	keys     map[[CachedKeySize]byte][]slotStatus
+}
+
+// StatusCache holds the statuses of recently committed transactions, so
+// that a transaction is processed at most once on a fork. It is shared by
+// a bank with its descendants.
+//
+// Based on solana_runtime::status_cache::StatusCache.
+type StatusCache struct {
+	mu         sync.RWMutex
+	cache      map[[32]byte]*blockhashEntry
+	roots      map[uint64]struct{}
+	slotDeltas map[uint64]map[[32]byte]*BlockhashStatuses
+}
+
+// NewStatusCache returns an empty status cache.
+func NewStatusCache() *StatusCache {
+	return &StatusCache{
+		cache:      make(map[[32]byte]*blockhashEntry),
+		roots:      make(map[uint64]struct{}),
+		slotDeltas: make(map[uint64]map[[32]byte]*BlockhashStatuses),
+	}
+}
+
+// GetStatus returns the status of a transaction that references the given
+// blockhash, if it was committed in one of the given ancestor slots or in a
+// rooted slot.
+//
+// Based on solana_runtime::status_cache::StatusCache::get_status.
+func (c *StatusCache) GetStatus(key []byte, blockhash [32]byte, ancestors map[uint64]struct{}) (slot uint64, txErr error, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.cache[blockhash]
+	if !ok || entry.keyIndex+CachedKeySize > len(key) {
+		return 0, nil, false
+	}
+	var keySlice [CachedKeySize]byte
+	copy(keySlice[:], key[entry.keyIndex:])
+	for _, status := range entry.keys[keySlice] {
+		if _, ok := ancestors[status.slot]; ok {
+			return status.slot, status.err, true
+		}
+		if _, ok := c.roots[status.slot]; ok {
+			return status.slot, status.err, true
+		}
+	}
+	return 0, nil, false
+}
+
+// Insert records the status of a transaction that references the given
+// blockhash, committed in the given slot.
+//
+// Based on solana_runtime::status_cache::StatusCache::insert.
+func (c *StatusCache) Insert(blockhash [32]byte, key []byte, slot uint64, txErr error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[blockhash]
+	if !ok {
+		// the slice is taken at a random offset, which is the same for all
+		// keys referencing the blockhash, up to the last whole slice
+		entry = &blockhashEntry{
+			keyIndex: rand.Intn(len(key) - CachedKeySize + 1),
+			keys:     make(map[[CachedKeySize]byte][]slotStatus),
+		}
+		c.cache[blockhash] = entry
+	} else if entry.keyIndex+CachedKeySize > len(key) {
+		// the key index was restored from a snapshot for longer keys;
+		// GetStatus could never find this key
+		return
+	}
+	var keySlice [CachedKeySize]byte
+	copy(keySlice[:], key[entry.keyIndex:])
+	c.insertWithKeyIndex(blockhash, entry.keyIndex, keySlice, slot, txErr)
+}
+
+func (c *StatusCache) insertWithKeyIndex(blockhash [32]byte, keyIndex int, keySlice [CachedKeySize]byte, slot uint64, txErr error) {
+	entry, ok := c.cache[blockhash]
+	if !ok {
+		entry = &blockhashEntry{keyIndex: keyIndex, keys: make(map[[CachedKeySize]byte][]slotStatus)}
+		c.cache[blockhash] = entry
+	}
+	if slot > entry.maxSlot {
+		entry.maxSlot = slot
+	}
+	entry.keys[keySlice] = append(entry.keys[keySlice], slotStatus{slot: slot, err: txErr})
+
+	deltas, ok := c.slotDeltas[slot]
+	if !ok {
+		deltas = make(map[[32]byte]*BlockhashStatuses)
+		c.slotDeltas[slot] = deltas
+	}
+	statuses, ok := deltas[blockhash]
+	if !ok {
+		statuses = &BlockhashStatuses{KeyIndex: entry.keyIndex}
+		deltas[blockhash] = statuses
+	}
+	statuses.Statuses = append(statuses.Statuses, KeyStatus{Key: keySlice, Err: txErr})
+}
+
+// AddRoot marks a slot as rooted. Once more than MaxCacheEntries slots are
+// rooted, the statuses of the oldest root are purged.
+//
+// Based on solana_runtime::status_cache::StatusCache::add_root.
+func (c *StatusCache) AddRoot(slot uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roots[slot] = struct{}{}
+	c.purgeRoots()
+}
+
+// purgeRoots drops the oldest root, and the statuses of no newer slot, once
+// there are more than MaxCacheEntries roots.
+func (c *StatusCache) purgeRoots() {
+	if len(c.roots) <= MaxCacheEntries {
+		return
+	}
+	minRoot := uint64(0)
+	first := true
+	for root := range c.roots {
+		if first || root < minRoot {
+			minRoot = root
+			first = false
+		}
+	}
+	delete(c.roots, minRoot)
+	for blockhash, entry := range c.cache {
+		if entry.maxSlot <= minRoot {
+			delete(c.cache, blockhash)
+		}
+	}
+	for slot := range c.slotDeltas {
+		if slot <= minRoot {
+			delete(c.slotDeltas, slot)
+		}
+	}
+}
+
+// SlotDeltas returns the statuses of the rooted slots and of the given
+// ancestor slots, oldest first, as written to snapshots. The ancestors are
+// rooted by taking a snapshot of their descendant, so all slots are marked
+// as rooted.
+//
+// Based on solana_runtime::status_cache::StatusCache::root_slot_deltas.
+func (c *StatusCache) SlotDeltas(ancestors map[uint64]struct{}) []SlotDelta {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	slots := make(map[uint64]struct{}, len(c.roots)+len(ancestors))
+	for root := range c.roots {
+		slots[root] = struct{}{}
+	}
+	for slot := range ancestors {
+		slots[slot] = struct{}{}
+	}
+	deltas := make([]SlotDelta, 0, len(slots))
+	for slot := range slots {
+		statuses := make(map[[32]byte]*BlockhashStatuses)
+		for blockhash, s := range c.slotDeltas[slot] {
+			statuses[blockhash] = &BlockhashStatuses{
+				KeyIndex: s.KeyIndex,
+				Statuses: append([]KeyStatus(nil), s.Statuses...),
+			}
+		}
+		deltas = append(deltas, SlotDelta{Slot: slot, IsRoot: true, Statuses: statuses})
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Slot < deltas[j].Slot })
+	return deltas
+}
+
+// AppendSlotDeltas adds the statuses of the given slots, as restored from
+// a snapshot. Rooted slots are marked as such.
+//
+// Based on solana_runtime::status_cache::StatusCache::append.
+func (c *StatusCache) AppendSlotDeltas(deltas []SlotDelta) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, delta := range deltas {
+		for blockhash, statuses := range delta.Statuses {
+			for _, status := range statuses.Statuses {
+				c.insertWithKeyIndex(blockhash, statuses.KeyIndex, status.Key, delta.Slot, status.Err)
+			}
+		}
+		if delta.IsRoot {
+			c.roots[delta.Slot] = struct{}{}
+			c.purgeRoots()
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package bank
+
+import (
+	"encoding/binary"
+
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// updateSysvarAccount stores the account of a sysvar with the given data.
+// The account keeps the balance of the previous one, topped up to rent
+// exemption.
+//
+// Based on solana_runtime::bank::Bank::update_sysvar_account.
+func (bank *Bank) updateSysvarAccount(addr [32]byte, data []byte) error {
+	acct := &accounts.Account{Lamports: 1, Owner: sealevel.SysvarOwnerAddr, Data: data}
+	if prev, err := bank.Accounts.GetAccount(&addr); err == nil && prev != nil {
+		acct.Lamports = prev.Lamports
+		acct.RentEpoch = prev.RentEpoch
+	}
+	if rent, err := bank.SysvarCache.Rent(); err == nil {
+		if minBalance := rent.MinimumBalance(uint64(len(data))); acct.Lamports < minBalance {
+			acct.Lamports = minBalance
+		}
+	}
+	return bank.StoreAccount(addr, acct)
+}
+
+// updateClock advances the Clock sysvar to the slot of the bank. The
+// timestamp is carried over from the parent, and becomes the start of the
+// epoch at an epoch boundary.
+//
+// Based on solana_runtime::bank::Bank::update_clock.
+func (bank *Bank) updateClock(parentEpoch uint64) error {
+	clock, err := bank.SysvarCache.Clock()
+	if err != nil {
+		return nil
+	}
+	newClock := *clock
+	newClock.Slot = bank.Slot
+	newClock.Epoch = bank.Epoch
+	newClock.LeaderScheduleEpoch = bank.EpochSchedule.GetLeaderScheduleEpoch(bank.Slot)
+	if parentEpoch != bank.Epoch {
+		newClock.EpochStartTimestamp = newClock.UnixTimestamp
+	}
+	bank.SysvarCache.SetClock(newClock)
+
+	data := make([]byte, 0, sealevel.SysvarClockStructLen)
+	data = binary.LittleEndian.AppendUint64(data, newClock.Slot)
+	data = binary.LittleEndian.AppendUint64(data, uint64(newClock.EpochStartTimestamp))
+	data = binary.LittleEndian.AppendUint64(data, newClock.Epoch)
+	data = binary.LittleEndian.AppendUint64(data, newClock.LeaderScheduleEpoch)
+	data = binary.LittleEndian.AppendUint64(data, uint64(newClock.UnixTimestamp))
+	bank.SysvarCache.SetSysvarData(sealevel.SysvarClockAddr, data)
+	return bank.updateSysvarAccount(sealevel.SysvarClockAddr, data)
+}
+
+// updateSlotHashes records the hash of the parent bank in the SlotHashes
+// sysvar.
+//
+// Based on solana_runtime::bank::Bank::update_slot_hashes.
+func (bank *Bank) updateSlotHashes() error {
+	var slotHashes sealevel.SysvarSlotHashes
+	if current, err := bank.SysvarCache.SlotHashes(); err == nil {
+		slotHashes = append(slotHashes, *current...)
+	}
+	slotHashes.Add(bank.ParentSlot, bank.ParentHash)
+	bank.SysvarCache.SetSlotHashes(slotHashes)
+
+	data := make([]byte, sealevel.SysvarSlotHashesStructLen)
+	binary.LittleEndian.PutUint64(data, uint64(len(slotHashes)))
+	for i, slotHash := range slotHashes {
+		entry := data[8+i*40:]
+		binary.LittleEndian.PutUint64(entry, slotHash.Slot)
+		copy(entry[8:40], slotHash.Hash[:])
+	}
+	bank.SysvarCache.SetSysvarData(sealevel.SysvarSlotHashesAddr, data)
+	return bank.updateSysvarAccount(sealevel.SysvarSlotHashesAddr, data)
+}
+
+// updateRecentBlockhashes stores the most recent blockhashes of the queue
+// in the RecentBlockhashes sysvar.
+//
+// Based on solana_runtime::bank::Bank::update_recent_blockhashes_locked.
+func (bank *Bank) updateRecentBlockhashes() error {
+	recentBlockhashes := bank.BlockhashQueue.RecentBlockhashes()
+	bank.SysvarCache.SetRecentBlockHashes(recentBlockhashes)
+
+	data := make([]byte, sealevel.SysvarRecentBlockhashesStructLen)
+	binary.LittleEndian.PutUint64(data, uint64(len(recentBlockhashes)))
+	for i, entry := range recentBlockhashes {
+		buf := data[8+i*40:]
+		copy(buf[:32], entry.Blockhash[:])
+		binary.LittleEndian.PutUint64(buf[32:], entry.FeeCalculator.LamportsPerSignature)
+	}
+	return bank.updateSysvarAccount(sealevel.SysvarRecentBlockHashesAddr, data)
+}
+
+// updateLastRestartSlot sets the LastRestartSlot sysvar to the slot of the
+// last hard fork up to the slot of the bank, once last_restart_slot_sysvar
+// is active.
+//
+// Based on solana_runtime::bank::Bank::update_last_restart_slot.
+func (bank *Bank) updateLastRestartSlot() error {
+	if !bank.Features.IsActive(features.LastRestartSlotSysvar) {
+		return nil
+	}
+	lastRestartSlot := bank.HardForks.LastRestartSlot(bank.Slot)
+	if current, err := bank.SysvarCache.LastRestartSlot(); err == nil && current.LastRestartSlot == lastRestartSlot {
+		return nil
+	}
+	bank.SysvarCache.SetLastRestartSlot(sealevel.SysvarLastRestartSlot{LastRestartSlot: lastRestartSlot})
+
+	data := binary.LittleEndian.AppendUint64(nil, lastRestartSlot)
+	bank.SysvarCache.SetSysvarData(sealevel.SysvarLastRestartSlotAddr, data)
+	return bank.updateSysvarAccount(sealevel.SysvarLastRestartSlotAddr, data)
+}
+
+// updateStakeHistory records the stake activation of the epoch that has
+// ended in the StakeHistory sysvar, at the first slot of the next epoch.
+//
+// Based on solana_runtime::bank::Bank::update_stake_history.
+func (bank *Bank) updateStakeHistory(parentEpoch uint64) error {
+	if parentEpoch == bank.Epoch {
+		return nil
+	}
+	bank.Stakes.activateEpoch(bank.Epoch, bank.NewWarmupCooldownRateEpoch())
+	stakeHistory := bank.Stakes.History()
+	bank.SysvarCache.SetStakeHistory(stakeHistory)
+
+	data := make([]byte, sealevel.SysvarStakeHistoryStructLen)
+	binary.LittleEndian.PutUint64(data, uint64(len(stakeHistory)))
+	for i, pair := range stakeHistory {
+		entry := data[8+i*32:]
+		binary.LittleEndian.PutUint64(entry, pair.Epoch)
+		binary.LittleEndian.PutUint64(entry[8:], pair.Entry.Effective)
+		binary.LittleEndian.PutUint64(entry[16:], pair.Entry.Activating)
+		binary.LittleEndian.PutUint64(entry[24:], pair.Entry.Deactivating)
+	}
+	bank.SysvarCache.SetSysvarData(sealevel.SysvarStakeHistoryAddr, data)
+	return bank.updateSysvarAccount(sealevel.SysvarStakeHistoryAddr, data)
+}
+
+// NewWarmupCooldownRateEpoch returns the epoch from which stake warms up
+// and cools down at the reduced rate, or nil if reduce_stake_warmup_cooldown
+// is not active.
+//
+// Based on solana_feature_set::FeatureSet::new_warmup_cooldown_rate_epoch.
+func (bank *Bank) NewWarmupCooldownRateEpoch() *uint64 {
+	slot, ok := bank.Features.ActivationSlot(features.ReduceStakeWarmupCooldown)
+	if !ok {
+		return nil
+	}
+	epoch := bank.EpochSchedule.GetEpoch(slot)
+	return &epoch
+}
@@ -0,0 +1,402 @@
+package bank
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// bankAccounts is the view of the accounts of a bank that transactions
+// commit through, so that the bank tracks the accounts they store.
+type bankAccounts struct {
+	bank        *Bank
+	txSignature *solana.Signature
+}
+
+func (a bankAccounts) GetAccount(pubkey *[32]byte) (*accounts.Account, error) {
+	return a.bank.Accounts.GetAccount(pubkey)
+}
+
+func (a bankAccounts) SetAccount(pubkey *[32]byte, acct *accounts.Account) error {
+	return a.bank.storeAccount(*pubkey, acct, a.txSignature)
+}
+
+// syncAccounts is the view of the accounts of a bank that transactions of
+// an entry execute against, while earlier transactions commit.
+type syncAccounts struct {
+	bank *Bank
+}
+
+func (a syncAccounts) GetAccount(pubkey *[32]byte) (*accounts.Account, error) {
+	a.bank.accountsMu.RLock()
+	defer a.bank.accountsMu.RUnlock()
+	return a.bank.Accounts.GetAccount(pubkey)
+}
+
+func (a syncAccounts) SetAccount(pubkey *[32]byte, acct *accounts.Account) error {
+	a.bank.accountsMu.Lock()
+	defer a.bank.accountsMu.Unlock()
+	return a.bank.Accounts.SetAccount(pubkey, acct)
+}
+
+// ProcessTransaction executes a transaction in the slot of the bank, and
+// commits it. A transaction that fails once its fee payer has been charged
+// is still committed, paying its fee and advancing its durable nonce, and
+// its status meta records the error. A transaction that cannot be charged
+// its fee, that was already committed on the fork of the bank, or that
+// would exceed the cost limits of the block, is not committed, and its
+// error is returned instead, which makes the block invalid.
+//
+// Based on solana_runtime::bank::Bank::load_execute_and_commit_transactions.
+func (bank *Bank) ProcessTransaction(tx *solana.Transaction) (*sealevel.TransactionStatusMeta, error) {
+	metas, err := bank.ProcessTransactions([]*solana.Transaction{tx})
+	if err != nil {
+		return nil, err
+	}
+	return metas[0], nil
+}
+
+// ProcessTransactions executes the transactions of an entry in the slot of
+// the bank, and commits them in order, as ProcessTransaction does.
+// Transactions that do not conflict on their account locks execute in
+// parallel. Each transaction is charged the cost of its execution against
+// the cost limits of the block before it is committed. The status metas of the committed transactions are returned. If a
+// transaction cannot be committed, the transactions after it are not
+// committed either, and its error is returned.
+//
+// Based on solana_ledger::blockstore_processor::execute_batch.
+func (bank *Bank) ProcessTransactions(txs []*solana.Transaction) ([]*sealevel.TransactionStatusMeta, error) {
+	if bank.frozen {
+		return nil, fmt.Errorf("bank of slot %d is frozen", bank.Slot)
+	}
+
+	var batchErr error
+	resolved := make([]*sealevel.ResolvedTransaction, 0, len(txs))
+	txLocks := make([]sealevel.TransactionAccountLocks, 0, len(txs))
+	for _, tx := range txs {
+		r, err := bank.resolveTransaction(tx, bank.Accounts)
+		if err != nil {
+			batchErr = err
+			break
+		}
+		resolved = append(resolved, r)
+		txLocks = append(txLocks, sealevel.NewTransactionAccountLocks(r.AccountKeys, r.IsWritable))
+	}
+
+	var commitErr error
+	executed := make([]*executedTransaction, len(resolved))
+	metas := make([]*sealevel.TransactionStatusMeta, 0, len(resolved))
+	sealevel.NewScheduler(runtime.NumCPU()).Execute(txLocks, func(idx int) error {
+		var err error
+		executed[idx], err = bank.executeTransaction(txs[idx], resolved[idx], syncAccounts{bank})
+		return err
+	}, func(idx int, err error) {
+		if commitErr != nil {
+			return
+		}
+		if err != nil {
+			commitErr = err
+			return
+		}
+		txCost := executedTransactionCost(txs[idx], executed[idx], bank.Features)
+		if err := bank.CostTracker.TryAdd(&txCost); err != nil {
+			commitErr = err
+			return
+		}
+		meta, err := bank.commitTransaction(txs[idx], executed[idx], txCost.IsSimpleVote)
+		if err != nil {
+			commitErr = err
+			return
+		}
+		metas = append(metas, meta)
+	})
+	if commitErr != nil {
+		return metas, commitErr
+	}
+	return metas, batchErr
+}
+
+// commitTransaction commits an executed transaction in the slot of the
+// bank, and returns its status meta.
+//
+// Based on solana_runtime::bank::Bank::commit_transactions.
+func (bank *Bank) commitTransaction(tx *solana.Transaction, executed *executedTransaction, isVote bool) (*sealevel.TransactionStatusMeta, error) {
+	bank.accountsMu.Lock()
+	defer bank.accountsMu.Unlock()
+
+	resolved := executed.resolved
+	if executed.txErr == nil && bank.ProgramCache != nil {
+		bank.ProgramCache.Merge(executed.modifiedPrograms)
+	}
+
+	// the loaded accounts are copies, so the accounts of the bank are still
+	// as they were before the transaction
+	pre := sealevel.CollectTransactionBalances(bank.Accounts, resolved.AccountKeys, resolved.Instructions)
+
+	err := sealevel.CommitTransactionAccounts(bankAccounts{bank, &tx.Signatures[0]}, resolved.AccountKeys, resolved.IsWritable, executed.txAccts, &executed.rollback, executed.txErr)
+	if err != nil {
+		return nil, err
+	}
+	bank.StatusCache.Insert(tx.Message.RecentBlockhash, executed.messageHash[:], bank.Slot, executed.txErr)
+	bank.StatusCache.Insert(tx.Message.RecentBlockhash, tx.Signatures[0][:], bank.Slot, executed.txErr)
+	bank.SignatureCount += uint64(len(tx.Signatures))
+	bank.TransactionCount++
+	bank.CollectorFeeDetails.TransactionFee += executed.feeDetails.TransactionFee
+	bank.CollectorFeeDetails.PrioritizationFee += executed.feeDetails.PrioritizationFee
+
+	post := sealevel.CollectTransactionBalances(bank.Accounts, resolved.AccountKeys, resolved.Instructions)
+	meta := sealevel.NewTransactionStatusMeta(executed.txErr, executed.feeDetails.TotalFee(), pre, post, executed.txCtx, resolved.LoadedAddresses)
+	meta.LogMessages = executed.logs
+	meta.ComputeUnitsConsumed = executed.computeUnitsConsumed
+
+	if bank.Geyser != nil {
+		bank.Geyser.NotifyTransaction(&geyser.TransactionUpdate{
+			Slot:        bank.Slot,
+			Index:       bank.numTransactions,
+			Signature:   tx.Signatures[0],
+			IsVote:      isVote,
+			Transaction: tx,
+			Meta:        meta,
+		})
+	}
+	bank.numTransactions++
+	return meta, nil
+}
+
+// executedTransaction is a transaction loaded and executed in the slot of a
+// bank, of which nothing is committed yet.
+type executedTransaction struct {
+	resolved    *sealevel.ResolvedTransaction
+	messageHash [32]byte
+	feeDetails  sealevel.FeeDetails
+	rollback    sealevel.RollbackAccounts
+
+	// txAccts are the accounts of the transaction after its execution, nil
+	// if they failed to load.
+	txAccts []*accounts.Account
+	// txCtx is nil if the accounts of the transaction failed to load.
+	txCtx                  *sealevel.TransactionCtx
+	txErr                  error
+	logs                   []string
+	computeUnitsConsumed   uint64
+	loadedAccountsDataSize uint32
+	modifiedPrograms       sealevel.ProgramsModifiedByTx
+}
+
+// loadAndExecuteTransaction resolves and executes a transaction in the slot
+// of the bank, reading accounts from accts.
+func (bank *Bank) loadAndExecuteTransaction(tx *solana.Transaction, accts accounts.Accounts) (*executedTransaction, error) {
+	resolved, err := bank.resolveTransaction(tx, accts)
+	if err != nil {
+		return nil, err
+	}
+	return bank.executeTransaction(tx, resolved, accts)
+}
+
+// resolveTransaction resolves the account keys and instructions of a
+// transaction in the slot of the bank, reading address lookup tables from
+// accts, and validates its account locks.
+func (bank *Bank) resolveTransaction(tx *solana.Transaction, accts accounts.Accounts) (*sealevel.ResolvedTransaction, error) {
+	var slotHashes sealevel.SysvarSlotHashes
+	if hashes, err := bank.SysvarCache.SlotHashes(); err == nil {
+		slotHashes = *hashes
+	}
+	resolved, err := sealevel.ResolveTransaction(tx, accts, bank.Slot, slotHashes, sealevel.NewReservedAccountKeys(bank.Features))
+	if err != nil {
+		return nil, err
+	}
+	locks := sealevel.NewTransactionAccountLocks(resolved.AccountKeys, resolved.IsWritable)
+	if err := locks.Validate(); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// executeTransaction executes a resolved transaction in the slot of the
+// bank, reading accounts from accts. The error of a transaction that cannot
+// be charged its fee, or that was already committed on the fork of the
+// bank, is returned, while that of a transaction that failed once charged
+// is recorded in the executed transaction.
+//
+// Based on solana_runtime::bank::Bank::load_and_execute_transactions.
+func (bank *Bank) executeTransaction(tx *solana.Transaction, resolved *sealevel.ResolvedTransaction, accts accounts.Accounts) (*executedTransaction, error) {
+	limits, err := sealevel.ProcessComputeBudgetInstructions(resolved.Instructions)
+	if err != nil {
+		return nil, err
+	}
+	nonce, lamportsPerSignature, err := sealevel.CheckTransactionAge(accts, resolved.Instructions,
+		tx.Message.RecentBlockhash, bank.BlockhashQueue.RecentBlockhashes(), bank.LastBlockhash(), bank.LamportsPerSignature)
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := sealevel.MessageHash(&tx.Message)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, ok := bank.StatusCache.GetStatus(messageHash[:], tx.Message.RecentBlockhash, bank.ancestors); ok {
+		return nil, sealevel.TxErrAlreadyProcessed
+	}
+	feeDetails := sealevel.CalculateFeeDetails(uint64(len(tx.Signatures)), resolved.Instructions, lamportsPerSignature, limits, bank.Features)
+	fee := feeDetails.TotalFee()
+
+	rent, err := bank.SysvarCache.Rent()
+	if err != nil {
+		return nil, err
+	}
+	rentCollector := &sealevel.RentCollector{
+		Epoch:         bank.Epoch,
+		EpochSchedule: bank.EpochSchedule,
+		SlotsPerYear:  bank.SlotsPerYear,
+		Rent:          *rent,
+	}
+
+	executed := &executedTransaction{resolved: resolved, messageHash: messageHash, feeDetails: feeDetails}
+	var feePayer *accounts.Account
+	loaded, err := sealevel.LoadTransactionAccounts(accts, resolved.AccountKeys, resolved.IsWritable,
+		resolved.Instructions, fee, rentCollector, limits, bank.Features)
+	if err != nil {
+		// the fee payer may still be charged for a transaction whose other
+		// accounts fail to load
+		if !bank.Features.IsActive(features.EnableTransactionLoadingFailureFees) {
+			return nil, err
+		}
+		executed.txErr = err
+		feePayer, err = loadAndChargeFeePayer(accts, resolved.AccountKeys[0], fee, rent)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		feePayer = loaded.Accounts[0]
+		executed.txAccts = loaded.Accounts
+		executed.loadedAccountsDataSize = loaded.LoadedAccountsDataSize
+	}
+	executed.rollback = sealevel.NewRollbackAccounts(nonce, resolved.AccountKeys[0], copyAccount(feePayer))
+	if executed.txErr != nil {
+		return executed, nil
+	}
+
+	txCtx := &sealevel.TransactionCtx{
+		InstructionTrace:         []sealevel.InstructionCtx{{}},
+		AccountKeys:              resolved.AccountKeys,
+		Accounts:                 sealevel.TransactionAccounts{Accounts: loaded.Accounts, Touched: make([]bool, len(loaded.Accounts))},
+		InstructionTraceCapacity: sealevel.CUMaxInstructionTraceLength,
+		Rent:                     *rent,
+	}
+	logCollector := sealevel.NewLogCollector(sealevel.LogCollectorDefaultBytesLimit)
+	execCtx := bank.NewExecutionCtx(txCtx)
+	execCtx.Accounts = accts
+	execCtx.Log = logCollector
+	execCtx.ApplyComputeBudgetLimits(limits)
+
+	preStates := sealevel.TransactionRentStates(txCtx, resolved.IsWritable, rent)
+	executed.txErr = execCtx.ProcessMessage(resolved.Instructions, loaded.ProgramIndices)
+	if executed.txErr == nil {
+		postStates := sealevel.TransactionRentStates(txCtx, resolved.IsWritable, rent)
+		executed.txErr = sealevel.VerifyRentStateChanges(preStates, postStates, txCtx)
+	}
+
+	executed.txCtx = txCtx
+	executed.logs = logCollector.Messages
+	executed.computeUnitsConsumed = uint64(limits.ComputeUnitLimit) - execCtx.ComputeMeter.Remaining()
+	executed.modifiedPrograms = execCtx.ModifiedPrograms
+	return executed, nil
+}
+
+// SimulationResult is the outcome of a transaction executed without being
+// committed.
+//
+// Based on solana_runtime::bank::TransactionSimulationResult.
+type SimulationResult struct {
+	Err  error
+	Logs []string
+
+	// AccountKeys are the account keys of the transaction, including its
+	// loaded addresses, and PostAccounts are its accounts after its
+	// execution, in the same order. Both are nil if the transaction was
+	// not executed.
+	AccountKeys  []solana.PublicKey
+	PostAccounts []*accounts.Account
+
+	UnitsConsumed          uint64
+	LoadedAccountsDataSize uint32
+
+	// ReturnData is the data last set by a program of the transaction with
+	// sol_set_return_data, nil if there is none.
+	ReturnData          []byte
+	ReturnDataProgramId solana.PublicKey
+
+	InnerInstructions []sealevel.InnerInstructions
+}
+
+// SimulateTransaction executes a transaction in the slot of the bank,
+// reading accounts from accts, and returns its outcome without committing
+// anything. The bank may be frozen. accts is a view of the accounts of the
+// bank, such as bank.Accounts, or one that is safe to read while the
+// descendants of the bank are being replayed.
+//
+// Based on solana_runtime::bank::Bank::simulate_transaction_unchecked.
+func (bank *Bank) SimulateTransaction(tx *solana.Transaction, accts accounts.Accounts) *SimulationResult {
+	executed, err := bank.loadAndExecuteTransaction(tx, accts)
+	if err != nil {
+		return &SimulationResult{Err: err}
+	}
+	result := &SimulationResult{
+		Err:                    executed.txErr,
+		Logs:                   executed.logs,
+		UnitsConsumed:          executed.computeUnitsConsumed,
+		LoadedAccountsDataSize: executed.loadedAccountsDataSize,
+	}
+	if executed.txCtx != nil {
+		result.AccountKeys = executed.resolved.AccountKeys
+		result.PostAccounts = executed.txAccts
+		result.InnerInstructions = sealevel.InnerInstructionsFromTrace(executed.txCtx)
+		programId, data := executed.txCtx.ReturnData()
+		if len(data) != 0 {
+			result.ReturnData = data
+			result.ReturnDataProgramId = programId
+		}
+	}
+	return result
+}
+
+// executedTransactionCost returns the cost of an executed transaction,
+// which is charged against the cost limits of the block.
+func executedTransactionCost(tx *solana.Transaction, executed *executedTransaction, f *features.Features) sealevel.TransactionCost {
+	resolved := executed.resolved
+	numSignatures := uint64(len(tx.Signatures))
+	isSimpleVote := sealevel.IsSimpleVoteTransaction(numSignatures, !tx.Message.IsVersioned(), resolved.Instructions)
+	var writableAccounts []solana.PublicKey
+	for i, key := range resolved.AccountKeys {
+		if resolved.IsWritable[i] {
+			writableAccounts = append(writableAccounts, key)
+		}
+	}
+	return sealevel.CalculateExecutedTransactionCost(numSignatures, isSimpleVote, writableAccounts, resolved.Instructions,
+		executed.computeUnitsConsumed, executed.loadedAccountsDataSize, f)
+}
+
+// loadAndChargeFeePayer loads the fee payer of a transaction, and charges
+// it the fee.
+func loadAndChargeFeePayer(accts accounts.Accounts, pubkey solana.PublicKey, fee uint64, rent *sealevel.SysvarRent) (*accounts.Account, error) {
+	acct, err := accts.GetAccount((*[32]byte)(&pubkey))
+	if err != nil || acct == nil {
+		return nil, sealevel.TxErrAccountNotFound
+	}
+	feePayer := copyAccount(acct)
+	err = sealevel.ValidateFeePayer(pubkey, feePayer, 0, fee, rent)
+	if err != nil {
+		return nil, err
+	}
+	return feePayer, nil
+}
+
+func copyAccount(acct *accounts.Account) *accounts.Account {
+	acctCopy := *acct
+	acctCopy.Data = append([]byte{}, acct.Data...)
+	return &acctCopy
+}
@@ -0,0 +1,298 @@
+package bank
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func newTransferTx(from, to solana.PublicKey, lamports uint64, recentBlockhash solana.Hash) *solana.Transaction {
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint32(data, 2)
+	binary.LittleEndian.PutUint64(data[4:], lamports)
+	return &solana.Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			Header:          solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+			AccountKeys:     []solana.PublicKey{from, to, sealevel.SystemProgramAddr},
+			RecentBlockhash: recentBlockhash,
+			Instructions:    []solana.CompiledInstruction{{ProgramIDIndex: 2, Accounts: []uint16{0, 1}, Data: data}},
+		},
+	}
+}
+
+func TestBankProcessTransaction(t *testing.T) {
+	rent := sealevel.SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}
+	payer, recipient, collector := solana.PublicKey{1}, solana.PublicKey{2}, [32]byte{3}
+
+	accts := accounts.NewMemAccounts()
+	systemProgram := &accounts.Account{Lamports: 1, Owner: sealevel.NativeLoaderAddr, Executable: true, Data: []byte("system_program")}
+	require.NoError(t, accts.SetAccount((*[32]byte)(&sealevel.SystemProgramAddr), systemProgram))
+	require.NoError(t, accts.SetAccount((*[32]byte)(&payer), &accounts.Account{Lamports: 1_000_000_000, Owner: sealevel.SystemProgramAddr}))
+	require.NoError(t, accts.SetAccount(&collector, &accounts.Account{Lamports: rent.MinimumBalance(0), Owner: sealevel.SystemProgramAddr}))
+
+	bank := NewBank(0, accts, features.NewFeaturesDefault(), sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32})
+	bank.SysvarCache.SetRent(rent)
+	bank.LamportsPerSignature = 5000
+	bank.CollectorId = collector
+	require.NoError(t, bank.RegisterBlockhash([32]byte{1}))
+	updates := geyser.NewChannel(context.Background(), 16)
+	bank.Geyser = geyser.NewNotifier(updates)
+
+	meta, err := bank.ProcessTransaction(newTransferTx(payer, recipient, 100_000_000, solana.Hash{1}))
+	require.NoError(t, err)
+	// the writes of the transaction are streamed before the transaction
+	for _, pubkey := range []solana.PublicKey{payer, recipient} {
+		event := <-updates.Events()
+		require.NotNil(t, event.Account)
+		assert.Equal(t, [32]byte(pubkey), event.Account.Pubkey)
+		assert.Equal(t, solana.Signature{1}, *event.Account.TxSignature)
+	}
+	event := <-updates.Events()
+	require.NotNil(t, event.Transaction)
+	assert.Equal(t, uint64(0), event.Transaction.Index)
+	assert.Equal(t, meta, event.Transaction.Meta)
+	assert.False(t, event.Transaction.IsVote)
+	assert.NoError(t, meta.Err)
+	assert.Equal(t, uint64(5000), meta.Fee)
+	assert.Equal(t, []uint64{1_000_000_000, 0, 1}, meta.PreBalances)
+	assert.Equal(t, []uint64{899_995_000, 100_000_000, 1}, meta.PostBalances)
+	assert.NotZero(t, meta.ComputeUnitsConsumed)
+
+	// a transaction is processed at most once
+	_, err = bank.ProcessTransaction(newTransferTx(payer, recipient, 100_000_000, solana.Hash{1}))
+	assert.Equal(t, sealevel.TxErrAlreadyProcessed, err)
+
+	// a failing transaction still pays its fee
+	meta, err = bank.ProcessTransaction(newTransferTx(payer, recipient, 1_000_000_000, solana.Hash{1}))
+	require.NoError(t, err)
+	var instrErr sealevel.TxErrInstructionError
+	require.True(t, errors.As(meta.Err, &instrErr))
+	assert.Equal(t, uint8(0), instrErr.Index)
+	assert.Equal(t, []uint64{899_990_000, 100_000_000, 1}, meta.PostBalances)
+
+	// a transaction with an unknown blockhash is not committed
+	_, err = bank.ProcessTransaction(newTransferTx(payer, recipient, 1, solana.Hash{2}))
+	assert.Equal(t, sealevel.TxErrBlockhashNotFound, err)
+	assert.Equal(t, uint64(2), bank.SignatureCount)
+	assert.Equal(t, uint64(2), bank.TransactionCount)
+
+	// the leader is paid half of the fees at the end of the slot
+	bank.Freeze()
+	collectorAcct, err := accts.GetAccount(&collector)
+	require.NoError(t, err)
+	assert.Equal(t, rent.MinimumBalance(0)+5000, collectorAcct.Lamports)
+	_, err = bank.ProcessTransaction(newTransferTx(payer, recipient, 1, solana.Hash{1}))
+	assert.Error(t, err)
+}
+
+func TestBankProcessTransaction_CostLimits(t *testing.T) {
+	rent := sealevel.SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}
+	payer, recipient := solana.PublicKey{1}, solana.PublicKey{2}
+
+	accts := accounts.NewMemAccounts()
+	systemProgram := &accounts.Account{Lamports: 1, Owner: sealevel.NativeLoaderAddr, Executable: true, Data: []byte("system_program")}
+	require.NoError(t, accts.SetAccount((*[32]byte)(&sealevel.SystemProgramAddr), systemProgram))
+	require.NoError(t, accts.SetAccount((*[32]byte)(&payer), &accounts.Account{Lamports: 1_000_000_000, Owner: sealevel.SystemProgramAddr}))
+
+	bank := NewBank(0, accts, features.NewFeaturesDefault(), sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32})
+	bank.SysvarCache.SetRent(rent)
+	bank.LamportsPerSignature = 5000
+	require.NoError(t, bank.RegisterBlockhash([32]byte{1}))
+
+	// once the block is full, a transaction exceeding its limit is not
+	// committed
+	_, err := bank.ProcessTransaction(newTransferTx(payer, recipient, 100_000_000, solana.Hash{1}))
+	require.NoError(t, err)
+	assert.NotZero(t, bank.CostTracker.BlockCost())
+	bank.CostTracker.BlockCostLimit = bank.CostTracker.BlockCost()
+
+	_, err = bank.ProcessTransaction(newTransferTx(payer, recipient, 1, solana.Hash{1}))
+	assert.Equal(t, sealevel.TxErrWouldExceedMaxBlockCostLimit, err)
+	assert.Equal(t, uint64(1), bank.TransactionCount)
+	payerAcct, err := accts.GetAccount((*[32]byte)(&payer))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(899_995_000), payerAcct.Lamports)
+
+	// each bank has its own limits
+	bank.Freeze()
+	child, err := NewBankFromParent(bank, 1)
+	require.NoError(t, err)
+	assert.Zero(t, child.CostTracker.BlockCost())
+}
+
+func TestBankProcessTransaction_ExecutedCost(t *testing.T) {
+	rent := sealevel.SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}
+	payer, programKey, programDataKey := solana.PublicKey{1}, solana.PublicKey{7}, solana.PublicKey{8}
+
+	accts := accounts.NewMemAccounts()
+	for _, builtin := range []struct {
+		key  solana.PublicKey
+		name string
+	}{
+		{sealevel.BpfLoaderUpgradeableAddr, "solana_bpf_loader_upgradeable_program"},
+		{sealevel.ComputeBudgetProgramAddr, "compute_budget_program"},
+	} {
+		acct := &accounts.Account{Lamports: 1, Owner: sealevel.NativeLoaderAddr, Executable: true, Data: []byte(builtin.name)}
+		require.NoError(t, accts.SetAccount((*[32]byte)(&builtin.key), acct))
+	}
+	require.NoError(t, accts.SetAccount((*[32]byte)(&payer), &accounts.Account{Lamports: 1_000_000_000, Owner: sealevel.SystemProgramAddr}))
+
+	programData := new(bytes.Buffer)
+	require.NoError(t, (&sealevel.UpgradeableLoaderState{
+		Type:    sealevel.UpgradeableLoaderStateTypeProgram,
+		Program: sealevel.UpgradeableLoaderStateProgram{ProgramDataAddress: programDataKey},
+	}).MarshalWithEncoder(bin.NewBinEncoder(programData)))
+	program := &accounts.Account{Lamports: 1_000_000_000, Owner: sealevel.BpfLoaderUpgradeableAddr, Executable: true, Data: programData.Bytes()}
+	require.NoError(t, accts.SetAccount((*[32]byte)(&programKey), program))
+	elf := fixtures.Load(t, "sbpf", "multiple_file.so")
+	data := make([]byte, 45+len(elf))
+	copy(data[45:], elf)
+	var state bytes.Buffer
+	require.NoError(t, (&sealevel.UpgradeableLoaderState{Type: sealevel.UpgradeableLoaderStateTypeProgramData}).MarshalWithEncoder(bin.NewBinEncoder(&state)))
+	copy(data, state.Bytes())
+	require.NoError(t, accts.SetAccount((*[32]byte)(&programDataKey), &accounts.Account{Lamports: 1_000_000_000, Owner: sealevel.BpfLoaderUpgradeableAddr, Data: data}))
+
+	bank := NewBank(1, accts, features.NewFeaturesDefault(), sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32})
+	bank.SysvarCache.SetRent(rent)
+	bank.SysvarCache.SetClock(sealevel.SysvarClock{Slot: 1})
+	bank.LamportsPerSignature = 5000
+	require.NoError(t, bank.RegisterBlockhash([32]byte{1}))
+
+	// the transaction requests far more compute units than it consumes,
+	// and only fits in the block by the units it consumed
+	limitData := make([]byte, 5)
+	limitData[0] = sealevel.ComputeBudgetInstrTypeSetComputeUnitLimit
+	binary.LittleEndian.PutUint32(limitData[1:], 1_400_000)
+	tx := &solana.Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			Header:          solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 2},
+			AccountKeys:     []solana.PublicKey{payer, programKey, sealevel.ComputeBudgetProgramAddr},
+			RecentBlockhash: solana.Hash{1},
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 2, Data: limitData},
+				{ProgramIDIndex: 1},
+			},
+		},
+	}
+	bank.CostTracker.BlockCostLimit = 100_000
+	meta, err := bank.ProcessTransaction(tx)
+	require.NoError(t, err)
+	require.NoError(t, meta.Err)
+	assert.Less(t, meta.ComputeUnitsConsumed, uint64(50_000))
+	assert.Less(t, bank.CostTracker.BlockCost(), uint64(100_000))
+}
+
+func TestBankProcessTransactions(t *testing.T) {
+	rent := sealevel.SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}
+
+	accts := accounts.NewMemAccounts()
+	systemProgram := &accounts.Account{Lamports: 1, Owner: sealevel.NativeLoaderAddr, Executable: true, Data: []byte("system_program")}
+	require.NoError(t, accts.SetAccount((*[32]byte)(&sealevel.SystemProgramAddr), systemProgram))
+	var txs []*solana.Transaction
+	for i := byte(1); i <= 8; i++ {
+		payer, recipient := solana.PublicKey{i}, solana.PublicKey{i, 1}
+		require.NoError(t, accts.SetAccount((*[32]byte)(&payer), &accounts.Account{Lamports: 1_000_000_000, Owner: sealevel.SystemProgramAddr}))
+		tx := newTransferTx(payer, recipient, 100_000_000, solana.Hash{1})
+		tx.Signatures[0] = solana.Signature{i}
+		txs = append(txs, tx)
+	}
+	// the last transaction spends what the first one received
+	tx := newTransferTx(solana.PublicKey{1, 1}, solana.PublicKey{2, 1}, 50_000_000, solana.Hash{1})
+	tx.Signatures[0] = solana.Signature{9}
+	txs = append(txs, tx)
+
+	bank := NewBank(0, accts, features.NewFeaturesDefault(), sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32})
+	bank.SysvarCache.SetRent(rent)
+	bank.LamportsPerSignature = 5000
+	require.NoError(t, bank.RegisterBlockhash([32]byte{1}))
+	updates := geyser.NewChannel(context.Background(), 64)
+	bank.Geyser = geyser.NewNotifier(updates)
+
+	metas, err := bank.ProcessTransactions(txs)
+	require.NoError(t, err)
+	require.Len(t, metas, len(txs))
+	for _, meta := range metas {
+		assert.NoError(t, meta.Err)
+	}
+	assert.Equal(t, []uint64{100_000_000, 100_000_000, 1}, metas[8].PreBalances)
+	assert.Equal(t, []uint64{49_995_000, 150_000_000, 1}, metas[8].PostBalances)
+
+	// transactions are committed in order
+	var index uint64
+	for event := range updates.Events() {
+		if event.Transaction == nil {
+			continue
+		}
+		assert.Equal(t, index, event.Transaction.Index)
+		assert.Equal(t, txs[index].Signatures[0], event.Transaction.Signature)
+		if index++; index == uint64(len(txs)) {
+			break
+		}
+	}
+
+	// the transactions after one exceeding the cost limits are not committed
+	txs = txs[:0]
+	for i := byte(1); i <= 3; i++ {
+		tx := newTransferTx(solana.PublicKey{i}, solana.PublicKey{i, 1}, 1, solana.Hash{1})
+		tx.Signatures[0] = solana.Signature{i, 1}
+		txs = append(txs, tx)
+	}
+	// the transfers all cost the same
+	bank.CostTracker.BlockCostLimit = bank.CostTracker.BlockCost() + bank.CostTracker.BlockCost()/bank.CostTracker.TransactionCount()
+	metas, err = bank.ProcessTransactions(txs)
+	assert.Equal(t, sealevel.TxErrWouldExceedMaxBlockCostLimit, err)
+	assert.Len(t, metas, 1)
+	assert.Equal(t, uint64(10), bank.TransactionCount)
+}
+
+func TestBankSimulateTransaction(t *testing.T) {
+	rent := sealevel.SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}
+	payer, recipient := solana.PublicKey{1}, solana.PublicKey{2}
+
+	accts := accounts.NewMemAccounts()
+	systemProgram := &accounts.Account{Lamports: 1, Owner: sealevel.NativeLoaderAddr, Executable: true, Data: []byte("system_program")}
+	require.NoError(t, accts.SetAccount((*[32]byte)(&sealevel.SystemProgramAddr), systemProgram))
+	require.NoError(t, accts.SetAccount((*[32]byte)(&payer), &accounts.Account{Lamports: 1_000_000_000, Owner: sealevel.SystemProgramAddr}))
+
+	bank := NewBank(0, accts, features.NewFeaturesDefault(), sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32})
+	bank.SysvarCache.SetRent(rent)
+	bank.LamportsPerSignature = 5000
+	require.NoError(t, bank.RegisterBlockhash([32]byte{1}))
+	bank.Freeze()
+
+	// a frozen bank simulates transactions, of which nothing is committed
+	for i := 0; i < 2; i++ {
+		result := bank.SimulateTransaction(newTransferTx(payer, recipient, 100_000_000, solana.Hash{1}), accts)
+		require.NoError(t, result.Err)
+		assert.NotZero(t, result.UnitsConsumed)
+		assert.Nil(t, result.ReturnData)
+		require.Equal(t, []solana.PublicKey{payer, recipient, sealevel.SystemProgramAddr}, result.AccountKeys)
+		assert.Equal(t, uint64(899_995_000), result.PostAccounts[0].Lamports)
+		assert.Equal(t, uint64(100_000_000), result.PostAccounts[1].Lamports)
+	}
+	payerAcct, err := accts.GetAccount((*[32]byte)(&payer))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1_000_000_000), payerAcct.Lamports)
+	assert.Zero(t, bank.TransactionCount)
+
+	result := bank.SimulateTransaction(newTransferTx(payer, recipient, 1_000_000_000, solana.Hash{1}), accts)
+	var instrErr sealevel.TxErrInstructionError
+	assert.True(t, errors.As(result.Err, &instrErr))
+	result = bank.SimulateTransaction(newTransferTx(payer, recipient, 1, solana.Hash{2}), accts)
+	assert.Equal(t, sealevel.TxErrBlockhashNotFound, result.Err)
+	assert.Nil(t, result.PostAccounts)
+}
@@ -0,0 +1,123 @@
+// Package bigtable uploads confirmed blocks and their transaction statuses
+// to Google Bigtable, in the schema of the ledger warehouse of Agave, so
+// that they may be served by the RPC nodes reading from it.
+//
+// Based on solana_storage_bigtable.
+package bigtable
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Tables of the ledger warehouse.
+const (
+	TableBlocks   = "blocks"
+	TableTx       = "tx"
+	TableTxByAddr = "tx-by-addr"
+)
+
+// FamilyName is the column family holding all cells.
+const FamilyName = "x"
+
+// Qualifiers of the cells, naming their encoding.
+const (
+	QualifierProto   = "proto"
+	QualifierBincode = "bin"
+)
+
+// BlocksKey returns the row key of a block in the blocks table.
+//
+// Based on solana_storage_bigtable::slot_to_blocks_key.
+func BlocksKey(slot uint64) string {
+	return fmt.Sprintf("%016x", slot)
+}
+
+// TxByAddrKey returns the row key of the transactions of an address in a
+// slot in the tx-by-addr table. Slots are inverted, so that the most
+// recent come first.
+//
+// Based on solana_storage_bigtable::slot_to_tx_by_addr_key.
+func TxByAddrKey(address string, slot uint64) string {
+	return address + "/" + BlocksKey(^slot)
+}
+
+// Cell is a cell of a row, in the column family of all cells.
+type Cell struct {
+	Qualifier string
+	Value     []byte
+}
+
+// Row is a row of a table, of which the cells are set.
+type Row struct {
+	Key   string
+	Cells []Cell
+}
+
+// RowWriter sets the cells of rows of the tables of the warehouse.
+type RowWriter interface {
+	WriteRows(ctx context.Context, table string, rows []Row) error
+}
+
+// CompressionMethod is the compression of the value of a cell, which
+// prefixes the compressed data as a bincode enum.
+//
+// Based on solana_storage_bigtable::compression::CompressionMethod.
+type CompressionMethod uint32
+
+const (
+	NoCompression CompressionMethod = iota
+	Bzip2
+	Gzip
+	Zstd
+)
+
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+// Compress returns the value of a cell holding data, compressed with zstd.
+func Compress(data []byte) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, uint32(Zstd))
+	return zstdEncoder.EncodeAll(data, out)
+}
+
+// Decompress returns the data held by the value of a cell, compressed with
+// any of the compression methods.
+//
+// Based on solana_storage_bigtable::compression::decompress.
+func Decompress(value []byte) ([]byte, error) {
+	if len(value) < 4 {
+		return nil, fmt.Errorf("compressed value truncated")
+	}
+	method := CompressionMethod(binary.LittleEndian.Uint32(value))
+	data := value[4:]
+	var r io.Reader
+	switch method {
+	case NoCompression:
+		return data, nil
+	case Bzip2:
+		r = bzip2.NewReader(bytes.NewReader(data))
+	case Gzip:
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		r = gr
+	case Zstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return nil, fmt.Errorf("unknown compression method %d", method)
+	}
+	return io.ReadAll(r)
+}
@@ -0,0 +1,259 @@
+package bigtable
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestKeys(t *testing.T) {
+	assert.Equal(t, "0000000000000064", BlocksKey(100))
+	assert.Equal(t, "addr/ffffffffffffff9b", TxByAddrKey("addr", 100))
+}
+
+func TestCompress(t *testing.T) {
+	data := []byte("hello hello hello hello")
+	compressed := Compress(data)
+	assert.Equal(t, uint32(Zstd), binary.LittleEndian.Uint32(compressed))
+	decompressed, err := Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+
+	raw, err := Decompress(append([]byte{0, 0, 0, 0}, data...))
+	require.NoError(t, err)
+	assert.Equal(t, data, raw)
+
+	_, err = Decompress([]byte{9, 0, 0, 0})
+	assert.Error(t, err)
+}
+
+func TestEncodeTransactionInfo(t *testing.T) {
+	memo := "[2] hi"
+	info, err := EncodeTransactionInfo(7, 3, sealevel.TxErrBlockhashNotFound, &memo)
+	require.NoError(t, err)
+	expected := binary.LittleEndian.AppendUint64(nil, 7)
+	expected = binary.LittleEndian.AppendUint32(expected, 3)
+	expected = append(expected, 1, 7, 0, 0, 0)
+	expected = append(expected, 1)
+	expected = binary.LittleEndian.AppendUint64(expected, uint64(len(memo)))
+	expected = append(expected, memo...)
+	assert.Equal(t, expected, info)
+
+	info, err = EncodeTransactionInfo(7, 3, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected[:12], info[:12])
+	assert.Equal(t, []byte{0, 0}, info[12:])
+}
+
+// fields returns the fields of an encoded message, by number.
+func fields(t *testing.T, data []byte) map[protowire.Number][]interface{} {
+	out := make(map[protowire.Number][]interface{})
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		require.GreaterOrEqual(t, n, 0)
+		data = data[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			require.GreaterOrEqual(t, n, 0)
+			out[num] = append(out[num], v)
+			data = data[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			require.GreaterOrEqual(t, n, 0)
+			out[num] = append(out[num], v)
+			data = data[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+	}
+	return out
+}
+
+func TestTxByAddrError(t *testing.T) {
+	b, err := appendTxByAddrError(nil, sealevel.TxErrInstructionError{
+		Index: 2,
+		Err:   sealevel.InstrErrCustom{Code: 6001},
+	})
+	require.NoError(t, err)
+	f := fields(t, b)
+	assert.Equal(t, []interface{}{uint64(8)}, f[1])
+	instrErr := fields(t, f[2][0].([]byte))
+	assert.Equal(t, []interface{}{uint64(2)}, instrErr[1])
+	assert.Equal(t, []interface{}{uint64(25)}, instrErr[2])
+	assert.Equal(t, []interface{}{uint64(6001)}, fields(t, instrErr[3][0].([]byte))[1])
+
+	b, err = appendTxByAddrError(nil, sealevel.TxErrInsufficientFundsForRent{AccountIndex: 4})
+	require.NoError(t, err)
+	f = fields(t, b)
+	assert.Equal(t, []interface{}{uint64(31)}, f[1])
+	assert.Equal(t, []interface{}{uint64(4)}, fields(t, f[3][0].([]byte))[1])
+
+	// the first variant is the default value, which is omitted
+	b, err = appendTxByAddrError(nil, sealevel.TxErrAccountInUse)
+	require.NoError(t, err)
+	assert.Empty(t, b)
+}
+
+func TestExtractMemos(t *testing.T) {
+	tx := &solana.Transaction{Message: solana.Message{
+		AccountKeys: []solana.PublicKey{solana.NewWallet().PublicKey(), memoProgramIDs[1], memoProgramIDs[0]},
+		Instructions: []solana.CompiledInstruction{
+			{ProgramIDIndex: 1, Data: []byte("hello")},
+			{ProgramIDIndex: 0, Data: []byte("not a memo")},
+			{ProgramIDIndex: 2, Data: []byte{0xff}},
+		},
+	}}
+	memo := ExtractMemos(tx)
+	require.NotNil(t, memo)
+	assert.Equal(t, "[5] hello; [1] (unparseable)", *memo)
+
+	tx.Message.Instructions = tx.Message.Instructions[1:2]
+	assert.Nil(t, ExtractMemos(tx))
+}
+
+func testBlock(slot uint64) *Block {
+	payer := solana.NewWallet().PublicKey()
+	tx := &solana.Transaction{
+		Signatures: []solana.Signature{{byte(slot), 1}},
+		Message: solana.Message{
+			AccountKeys: []solana.PublicKey{payer, solana.PublicKey(sealevel.SysvarClockAddr), sealevel.SystemProgramAddr},
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 2, Accounts: []uint16{0, 1}},
+			},
+		},
+	}
+	height := slot - 1
+	return &Block{
+		Slot:        slot,
+		ParentSlot:  slot - 1,
+		Blockhash:   [32]byte{byte(slot)},
+		BlockHeight: &height,
+		Transactions: []Transaction{{
+			Transaction: tx,
+			Meta: &sealevel.TransactionStatusMeta{
+				Err:          sealevel.TxErrInsufficientFundsForFee,
+				Fee:          5000,
+				PreBalances:  []uint64{10, 1, 1},
+				PostBalances: []uint64{5, 1, 1},
+			},
+		}},
+	}
+}
+
+func TestBlockRows(t *testing.T) {
+	block := testBlock(100)
+	blocks, txs, txByAddrs, err := block.Rows()
+	require.NoError(t, err)
+
+	require.Len(t, blocks, 1)
+	assert.Equal(t, BlocksKey(100), blocks[0].Key)
+	data, err := Decompress(blocks[0].Cells[0].Value)
+	require.NoError(t, err)
+	f := fields(t, data)
+	assert.Equal(t, []interface{}{uint64(99)}, f[3])
+	require.Len(t, f[4], 1)
+	assert.Equal(t, []interface{}{uint64(99)}, fields(t, f[7][0].([]byte))[1])
+	assert.Empty(t, f[6])
+
+	require.Len(t, txs, 1)
+	sig := block.Transactions[0].Transaction.Signatures[0]
+	assert.Equal(t, sig.String(), txs[0].Key)
+	assert.Equal(t, QualifierBincode, txs[0].Cells[0].Qualifier)
+
+	// the clock sysvar is not indexed
+	require.Len(t, txByAddrs, 2)
+	keys := []string{txByAddrs[0].Key, txByAddrs[1].Key}
+	assert.Contains(t, keys, TxByAddrKey(block.Transactions[0].Transaction.Message.AccountKeys[0].String(), 100))
+	assert.Contains(t, keys, TxByAddrKey(solana.PublicKey(sealevel.SystemProgramAddr).String(), 100))
+	data, err = Decompress(txByAddrs[0].Cells[0].Value)
+	require.NoError(t, err)
+	info := fields(t, fields(t, data)[1][0].([]byte))
+	assert.Equal(t, []interface{}{sig[:]}, info[1])
+	assert.Len(t, info[2], 1)
+}
+
+func TestClient_WriteRows(t *testing.T) {
+	var req mutateRowsRequest
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &req))
+		fmt.Fprint(w, `[{"entries":[{"status":{}},{"index":"1","status":{"code":3,"message":"bad row"}}]}]`)
+	}))
+	defer server.Close()
+
+	client := NewClient("projects/p/instances/i", "token")
+	client.Endpoint = server.URL
+	err := client.WriteRows(context.Background(), TableTx, []Row{
+		{Key: "a", Cells: []Cell{{Qualifier: "bin", Value: []byte{1}}}},
+		{Key: "b", Cells: []Cell{{Qualifier: "bin", Value: []byte{2}}}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"b"`)
+	assert.Equal(t, "/v2/projects/p/instances/i/tables/tx:mutateRows", path)
+	assert.Equal(t, "Bearer token", auth)
+	require.Len(t, req.Entries, 2)
+	assert.Equal(t, []byte("a"), req.Entries[0].RowKey)
+	assert.Equal(t, FamilyName, req.Entries[0].Mutations[0].SetCell.FamilyName)
+	assert.Equal(t, []byte{2}, req.Entries[1].Mutations[0].SetCell.Value)
+}
+
+type memWriter struct {
+	mu     sync.Mutex
+	tables map[string][]string
+}
+
+func (m *memWriter) WriteRows(_ context.Context, table string, rows []Row) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, row := range rows {
+		m.tables[table] = append(m.tables[table], row.Key)
+	}
+	return nil
+}
+
+func TestUploader(t *testing.T) {
+	w := &memWriter{tables: make(map[string][]string)}
+	u := NewUploader(context.Background(), w, 4)
+
+	for _, slot := range []uint64{10, 11} {
+		block := testBlock(slot)
+		tx := block.Transactions[0]
+		require.NoError(t, u.NotifyTransaction(&geyser.TransactionUpdate{
+			Slot:        slot,
+			Signature:   tx.Transaction.Signatures[0],
+			Transaction: tx.Transaction,
+			Meta:        tx.Meta,
+		}))
+		require.NoError(t, u.NotifyBlockMetadata(&geyser.BlockMetadata{
+			Slot:                     slot,
+			ParentSlot:               slot - 1,
+			ExecutedTransactionCount: 1,
+		}))
+	}
+	require.NoError(t, u.UpdateSlotStatus(&geyser.SlotUpdate{Slot: 10, Status: geyser.SlotStatusProcessed}))
+	require.NoError(t, u.UpdateSlotStatus(&geyser.SlotUpdate{Slot: 10, Status: geyser.SlotStatusRooted}))
+	assert.Error(t, u.UpdateSlotStatus(&geyser.SlotUpdate{Slot: 9, Status: geyser.SlotStatusRooted}))
+	require.NoError(t, u.Close())
+
+	// slot 11 was never rooted
+	assert.Equal(t, 1, u.NumUploaded())
+	assert.Equal(t, []string{BlocksKey(10)}, w.tables[TableBlocks])
+	assert.Len(t, w.tables[TableTx], 1)
+	assert.Len(t, w.tables[TableTxByAddr], 2)
+}
@@ -0,0 +1,315 @@
+package bigtable
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/storageproto"
+)
+
+// Block is a confirmed block, with the status metas of its transactions.
+// Unknown block heights and times are nil.
+//
+// Based on solana_transaction_status::VersionedConfirmedBlock.
+type Block struct {
+	Slot              uint64
+	ParentSlot        uint64
+	Blockhash         [32]byte
+	PreviousBlockhash [32]byte
+	BlockHeight       *uint64
+	BlockTime         *int64
+	Transactions      []Transaction
+}
+
+// Transaction is a transaction of a block, with its status meta.
+type Transaction struct {
+	Transaction *solana.Transaction
+	Meta        *sealevel.TransactionStatusMeta
+}
+
+// AccountKeys returns the static account keys of the transaction, followed
+// by the addresses it loaded from lookup tables.
+func (tx *Transaction) AccountKeys() []solana.PublicKey {
+	keys := append([]solana.PublicKey(nil), tx.Transaction.Message.AccountKeys...)
+	keys = append(keys, tx.Meta.LoadedAddresses.Writable...)
+	return append(keys, tx.Meta.LoadedAddresses.Readonly...)
+}
+
+// EncodeConfirmedBlock returns the solana.storage.ConfirmedBlock.ConfirmedBlock
+// of a block. Its rewards are not recorded.
+func EncodeConfirmedBlock(block *Block) ([]byte, error) {
+	var b []byte
+	b = storageproto.AppendString(b, 1, base58.Encode(block.PreviousBlockhash[:]))
+	b = storageproto.AppendString(b, 2, base58.Encode(block.Blockhash[:]))
+	b = storageproto.AppendUint64(b, 3, block.ParentSlot)
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		meta, err := storageproto.AppendTransactionStatusMeta(nil, tx.Meta)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		b = storageproto.AppendMessage(b, 4, func(b []byte) []byte {
+			b = storageproto.AppendMessage(b, 1, func(b []byte) []byte {
+				return storageproto.AppendTransaction(b, tx.Transaction)
+			})
+			return storageproto.AppendOptionalBytes(b, 2, meta)
+		})
+	}
+	if block.BlockTime != nil {
+		b = storageproto.AppendMessage(b, 6, func(b []byte) []byte {
+			return storageproto.AppendUint64(b, 1, uint64(*block.BlockTime))
+		})
+	}
+	if block.BlockHeight != nil {
+		b = storageproto.AppendMessage(b, 7, func(b []byte) []byte {
+			return storageproto.AppendUint64(b, 1, *block.BlockHeight)
+		})
+	}
+	return b, nil
+}
+
+// EncodeTransactionInfo returns the bincode encoding of the location and
+// status of a transaction, a cell of the tx table.
+//
+// Based on solana_storage_bigtable::TransactionInfo.
+func EncodeTransactionInfo(slot uint64, index uint32, txErr error, memo *string) ([]byte, error) {
+	var b []byte
+	b = binary.LittleEndian.AppendUint64(b, slot)
+	b = binary.LittleEndian.AppendUint32(b, index)
+	if txErr != nil {
+		b = append(b, 1)
+		var err error
+		if b, err = sealevel.AppendTransactionError(b, txErr); err != nil {
+			return nil, err
+		}
+	} else {
+		b = append(b, 0)
+	}
+	if memo != nil {
+		b = append(b, 1)
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(*memo)))
+		b = append(b, *memo...)
+	} else {
+		b = append(b, 0)
+	}
+	return b, nil
+}
+
+// TransactionByAddrInfo is a transaction referencing an address.
+//
+// Based on solana_storage_bigtable::TransactionByAddrInfo.
+type TransactionByAddrInfo struct {
+	Signature solana.Signature
+	Err       error
+	Index     uint32
+	Memo      *string
+	BlockTime *int64
+}
+
+// EncodeTransactionByAddr returns the
+// solana.storage.TransactionByAddr.TransactionByAddr of the transactions
+// referencing an address in a slot, a cell of the tx-by-addr table.
+func EncodeTransactionByAddr(infos []TransactionByAddrInfo) ([]byte, error) {
+	var b []byte
+	for i := range infos {
+		info := &infos[i]
+		var txErr []byte
+		if info.Err != nil {
+			var err error
+			if txErr, err = appendTxByAddrError(nil, info.Err); err != nil {
+				return nil, err
+			}
+		}
+		b = storageproto.AppendMessage(b, 1, func(b []byte) []byte {
+			b = storageproto.AppendBytes(b, 1, info.Signature[:])
+			if info.Err != nil {
+				b = storageproto.AppendOptionalBytes(b, 2, txErr)
+			}
+			b = storageproto.AppendUint64(b, 3, uint64(info.Index))
+			if info.Memo != nil {
+				b = storageproto.AppendMessage(b, 4, func(b []byte) []byte {
+					return storageproto.AppendString(b, 1, *info.Memo)
+				})
+			}
+			if info.BlockTime != nil {
+				b = storageproto.AppendMessage(b, 5, func(b []byte) []byte {
+					return storageproto.AppendUint64(b, 1, uint64(*info.BlockTime))
+				})
+			}
+			return b
+		})
+	}
+	return b, nil
+}
+
+// Variants of solana_sdk::transaction::TransactionError with fields.
+const (
+	txErrVariantInstructionError                      = 8
+	txErrVariantDuplicateInstruction                  = 30
+	txErrVariantInsufficientFundsForRent              = 31
+	txErrVariantProgramExecutionTemporarilyRestricted = 35
+
+	instrErrVariantCustom = 25
+)
+
+// appendTxByAddrError appends the fields of a
+// solana.storage.TransactionByAddr.TransactionError, which, unlike the
+// errors of confirmed blocks, is structured. The variants of its enums
+// are the ones of the bincode encoding of the error.
+//
+// Based on solana_storage_proto::convert::tx_by_addr.
+func appendTxByAddrError(b []byte, txErr error) ([]byte, error) {
+	encoded, err := sealevel.AppendTransactionError(nil, txErr)
+	if err != nil {
+		return nil, err
+	}
+	variant := binary.LittleEndian.Uint32(encoded)
+	b = storageproto.AppendUint64(b, 1, uint64(variant))
+	switch variant {
+	case txErrVariantInstructionError:
+		index := encoded[4]
+		instrVariant := binary.LittleEndian.Uint32(encoded[5:])
+		b = storageproto.AppendMessage(b, 2, func(b []byte) []byte {
+			b = storageproto.AppendUint64(b, 1, uint64(index))
+			b = storageproto.AppendUint64(b, 2, uint64(instrVariant))
+			if instrVariant == instrErrVariantCustom {
+				code := binary.LittleEndian.Uint32(encoded[9:])
+				b = storageproto.AppendMessage(b, 3, func(b []byte) []byte {
+					return storageproto.AppendUint64(b, 1, uint64(code))
+				})
+			}
+			return b
+		})
+	case txErrVariantDuplicateInstruction, txErrVariantInsufficientFundsForRent,
+		txErrVariantProgramExecutionTemporarilyRestricted:
+		index := encoded[4]
+		b = storageproto.AppendMessage(b, 3, func(b []byte) []byte {
+			return storageproto.AppendUint64(b, 1, uint64(index))
+		})
+	}
+	return b, nil
+}
+
+var memoProgramIDs = []solana.PublicKey{
+	solana.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo"),
+	solana.MustPublicKeyFromBase58("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr"),
+}
+
+// ExtractMemos returns the memos of the top-level SPL memo instructions of
+// a transaction, each prefixed with its length, or nil if it has none.
+//
+// Based on solana_transaction_status::extract_memos::extract_and_fmt_memos.
+func ExtractMemos(tx *solana.Transaction) *string {
+	var memos []string
+	keys := tx.Message.AccountKeys
+	for _, instr := range tx.Message.Instructions {
+		if int(instr.ProgramIDIndex) >= len(keys) || !isMemoProgram(keys[instr.ProgramIDIndex]) {
+			continue
+		}
+		memo := "(unparseable)"
+		if utf8.Valid(instr.Data) {
+			memo = string(instr.Data)
+		}
+		memos = append(memos, fmt.Sprintf("[%d] %s", len(instr.Data), memo))
+	}
+	if len(memos) == 0 {
+		return nil
+	}
+	joined := strings.Join(memos, "; ")
+	return &joined
+}
+
+func isMemoProgram(key solana.PublicKey) bool {
+	for _, id := range memoProgramIDs {
+		if key == id {
+			return true
+		}
+	}
+	return false
+}
+
+// isSysvar reports whether an address is a sysvar, which are not indexed
+// in the tx-by-addr table.
+//
+// Based on solana_sdk::sysvar::is_sysvar_id.
+func isSysvar(key solana.PublicKey) bool {
+	switch [32]byte(key) {
+	case sealevel.SysvarClockAddr, sealevel.SysvarEpochRewardsAddr,
+		sealevel.SysvarEpochScheduleAddr, sealevel.SysvarFeesAddr,
+		sealevel.SysvarInstructionsAddr, sealevel.SysvarLastRestartSlotAddr,
+		sealevel.SysvarRecentBlockHashesAddr, sealevel.SysvarRentAddr,
+		sealevel.SysvarRewardsAddr, sealevel.SysvarSlotHashesAddr,
+		sealevel.SysvarSlotHistoryAddr, sealevel.SysvarStakeHistoryAddr:
+		return true
+	}
+	return false
+}
+
+// Rows returns the rows of a block in each table of the warehouse.
+//
+// Based on solana_storage_bigtable::LedgerStorage::upload_confirmed_block.
+func (block *Block) Rows() (blocks, txs, txByAddrs []Row, err error) {
+	byAddr := make(map[solana.PublicKey][]TransactionByAddrInfo)
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if len(tx.Transaction.Signatures) == 0 {
+			return nil, nil, nil, fmt.Errorf("transaction %d of slot %d has no signature", i, block.Slot)
+		}
+		sig := tx.Transaction.Signatures[0]
+		memo := ExtractMemos(tx.Transaction)
+		for _, key := range tx.AccountKeys() {
+			if isSysvar(key) {
+				continue
+			}
+			byAddr[key] = append(byAddr[key], TransactionByAddrInfo{
+				Signature: sig,
+				Err:       tx.Meta.Err,
+				Index:     uint32(i),
+				Memo:      memo,
+				BlockTime: block.BlockTime,
+			})
+		}
+		info, err := EncodeTransactionInfo(block.Slot, uint32(i), tx.Meta.Err, memo)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("transaction %s: %w", sig, err)
+		}
+		txs = append(txs, Row{
+			Key:   sig.String(),
+			Cells: []Cell{{Qualifier: QualifierBincode, Value: Compress(info)}},
+		})
+	}
+
+	addrs := make([]solana.PublicKey, 0, len(byAddr))
+	for addr := range byAddr {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return string(addrs[i][:]) < string(addrs[j][:])
+	})
+	for _, addr := range addrs {
+		data, err := EncodeTransactionByAddr(byAddr[addr])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		txByAddrs = append(txByAddrs, Row{
+			Key:   TxByAddrKey(addr.String(), block.Slot),
+			Cells: []Cell{{Qualifier: QualifierProto, Value: Compress(data)}},
+		})
+	}
+
+	data, err := EncodeConfirmedBlock(block)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("slot %d: %w", block.Slot, err)
+	}
+	blocks = []Row{{
+		Key:   BlocksKey(block.Slot),
+		Cells: []Cell{{Qualifier: QualifierProto, Value: Compress(data)}},
+	}}
+	return blocks, txs, txByAddrs, nil
+}
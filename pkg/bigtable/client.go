@@ -0,0 +1,136 @@
+package bigtable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultEndpoint is the endpoint of the Bigtable Data API.
+const DefaultEndpoint = "https://bigtable.googleapis.com"
+
+// Client writes rows to the tables of a Bigtable instance through the
+// REST transport of the Bigtable Data API, authenticated with an OAuth 2.0
+// access token.
+type Client struct {
+	Endpoint string
+	Instance string // projects/{project}/instances/{instance}
+	Token    string
+	HTTP     *http.Client
+}
+
+// NewClient returns a client of the given instance, of the form
+// projects/{project}/instances/{instance}.
+func NewClient(instance, token string) *Client {
+	return &Client{
+		Endpoint: DefaultEndpoint,
+		Instance: instance,
+		Token:    token,
+		HTTP:     http.DefaultClient,
+	}
+}
+
+// The messages of the REST transport. Bytes fields are base64 encoded, as
+// encoding/json does for byte slices.
+
+type setCell struct {
+	FamilyName      string `json:"familyName"`
+	ColumnQualifier []byte `json:"columnQualifier"`
+	TimestampMicros string `json:"timestampMicros"`
+	Value           []byte `json:"value"`
+}
+
+type mutation struct {
+	SetCell setCell `json:"setCell"`
+}
+
+type mutateRowsEntry struct {
+	RowKey    []byte     `json:"rowKey"`
+	Mutations []mutation `json:"mutations"`
+}
+
+type mutateRowsRequest struct {
+	Entries []mutateRowsEntry `json:"entries"`
+}
+
+// mutateRowsResponse is a message of the response stream, which the REST
+// transport returns as a JSON array.
+type mutateRowsResponse struct {
+	Entries []struct {
+		Index  int64 `json:"index,string"`
+		Status struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"status"`
+	} `json:"entries"`
+}
+
+// WriteRows sets the cells of rows of a table, with the server time as
+// their timestamp.
+//
+// Based on solana_storage_bigtable::bigtable::BigTable::put_row_data.
+func (c *Client) WriteRows(ctx context.Context, table string, rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	req := mutateRowsRequest{Entries: make([]mutateRowsEntry, len(rows))}
+	for i, row := range rows {
+		entry := mutateRowsEntry{RowKey: []byte(row.Key)}
+		for _, cell := range row.Cells {
+			entry.Mutations = append(entry.Mutations, mutation{SetCell: setCell{
+				FamilyName:      FamilyName,
+				ColumnQualifier: []byte(cell.Qualifier),
+				TimestampMicros: "-1",
+				Value:           cell.Value,
+			}})
+		}
+		req.Entries[i] = entry
+	}
+	body, err := json.Marshal(&req)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/tables/%s:mutateRows", c.Endpoint, c.Instance, table)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	res, err := c.HTTP.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("mutateRows of table %s: %s: %s", table, res.Status, bytes.TrimSpace(resBody))
+	}
+
+	var responses []mutateRowsResponse
+	if err := json.Unmarshal(resBody, &responses); err != nil {
+		return fmt.Errorf("invalid mutateRows response: %w", err)
+	}
+	for _, response := range responses {
+		for _, entry := range response.Entries {
+			if entry.Status.Code == 0 {
+				continue
+			}
+			if entry.Index < 0 || entry.Index >= int64(len(rows)) {
+				return fmt.Errorf("mutateRows of table %s failed: %s", table, entry.Status.Message)
+			}
+			return fmt.Errorf("mutateRows of row %q of table %s failed: %s",
+				rows[entry.Index].Key, table, entry.Status.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package bigtable
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.firedancer.io/radiance/pkg/geyser"
+	"k8s.io/klog/v2"
+)
+
+// Uploader is a geyser plugin uploading the blocks of replay once they are
+// rooted. Blocks are uploaded in the background, in the order they are
+// rooted.
+type Uploader struct {
+	w   RowWriter
+	ctx context.Context
+
+	mu      sync.Mutex
+	pending map[uint64]*Block
+	uploads chan *Block
+	done    chan struct{}
+	err     error
+
+	numUploaded int
+}
+
+// NewUploader returns a plugin uploading rooted blocks with w, which
+// buffers up to bufferSize rooted blocks not uploaded yet.
+func NewUploader(ctx context.Context, w RowWriter, bufferSize int) *Uploader {
+	u := &Uploader{
+		w:       w,
+		ctx:     ctx,
+		pending: make(map[uint64]*Block),
+		uploads: make(chan *Block, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go u.run()
+	return u
+}
+
+func (u *Uploader) run() {
+	defer close(u.done)
+	for block := range u.uploads {
+		if err := UploadBlock(u.ctx, u.w, block); err != nil {
+			klog.Errorf("Failed to upload block %d to Bigtable: %s", block.Slot, err)
+			u.mu.Lock()
+			if u.err == nil {
+				u.err = err
+			}
+			u.mu.Unlock()
+			continue
+		}
+		u.mu.Lock()
+		u.numUploaded++
+		u.mu.Unlock()
+		klog.V(3).Infof("Uploaded block %d to Bigtable", block.Slot)
+	}
+}
+
+// Close waits for the rooted blocks to be uploaded, and returns the first
+// error of an upload. Blocks that are not rooted are not uploaded.
+func (u *Uploader) Close() error {
+	close(u.uploads)
+	<-u.done
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.err
+}
+
+// NumUploaded returns the number of blocks uploaded so far.
+func (u *Uploader) NumUploaded() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.numUploaded
+}
+
+func (u *Uploader) block(slot uint64) *Block {
+	block, ok := u.pending[slot]
+	if !ok {
+		block = &Block{Slot: slot}
+		u.pending[slot] = block
+	}
+	return block
+}
+
+func (u *Uploader) UpdateAccount(*geyser.AccountUpdate) error {
+	return nil
+}
+
+func (u *Uploader) NotifyEndOfStartup() error {
+	return nil
+}
+
+func (u *Uploader) NotifyTransaction(update *geyser.TransactionUpdate) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	block := u.block(update.Slot)
+	for uint64(len(block.Transactions)) <= update.Index {
+		block.Transactions = append(block.Transactions, Transaction{})
+	}
+	block.Transactions[update.Index] = Transaction{
+		Transaction: update.Transaction,
+		Meta:        update.Meta,
+	}
+	return nil
+}
+
+func (u *Uploader) NotifyBlockMetadata(meta *geyser.BlockMetadata) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	block := u.block(meta.Slot)
+	block.ParentSlot = meta.ParentSlot
+	block.Blockhash = meta.Blockhash
+	block.PreviousBlockhash = meta.ParentBlockhash
+	height := meta.BlockHeight
+	block.BlockHeight = &height
+	if uint64(len(block.Transactions)) != meta.ExecutedTransactionCount {
+		return fmt.Errorf("block %d has %d transactions, but %d were notified",
+			meta.Slot, meta.ExecutedTransactionCount, len(block.Transactions))
+	}
+	for i, tx := range block.Transactions {
+		if tx.Transaction == nil {
+			return fmt.Errorf("transaction %d of block %d was not notified", i, meta.Slot)
+		}
+	}
+	return nil
+}
+
+// UpdateSlotStatus uploads the block of a rooted slot, and drops the
+// blocks of older slots, which can no longer be rooted.
+func (u *Uploader) UpdateSlotStatus(update *geyser.SlotUpdate) error {
+	if update.Status != geyser.SlotStatusRooted {
+		return nil
+	}
+	u.mu.Lock()
+	block, ok := u.pending[update.Slot]
+	for slot := range u.pending {
+		if slot <= update.Slot {
+			delete(u.pending, slot)
+		}
+	}
+	u.mu.Unlock()
+	if !ok || block.BlockHeight == nil {
+		return fmt.Errorf("rooted slot %d was not replayed", update.Slot)
+	}
+	u.uploads <- block
+	return nil
+}
+
+// UploadBlock writes the rows of a block to the tables of the warehouse.
+// The block is written last, so that its transactions are indexed once it
+// is visible.
+func UploadBlock(ctx context.Context, w RowWriter, block *Block) error {
+	blocks, txs, txByAddrs, err := block.Rows()
+	if err != nil {
+		return err
+	}
+	if err := w.WriteRows(ctx, TableTxByAddr, txByAddrs); err != nil {
+		return err
+	}
+	if err := w.WriteRows(ctx, TableTx, txs); err != nil {
+		return err
+	}
+	return w.WriteRows(ctx, TableBlocks, blocks)
+}
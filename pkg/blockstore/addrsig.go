@@ -0,0 +1,67 @@
+package blockstore
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// TransactionAccountKeys splits the account keys of a transaction into
+// writable and readonly keys, followed by the addresses it loaded from
+// lookup tables, as recorded in its status meta. Programs invoked by the
+// transaction are readonly, unless the upgradeable loader is an account of
+// the transaction.
+//
+// Based on solana_sdk::message::SanitizedMessage::is_writable, without the
+// demotion of reserved account keys.
+func TransactionAccountKeys(tx *solana.Transaction, loaded *sealevel.LoadedAddresses) (writable, readonly []solana.PublicKey) {
+	msg := &tx.Message
+	keys := msg.AccountKeys
+	numSigned := int(msg.Header.NumRequiredSignatures)
+	numWritableSigned := numSigned - int(msg.Header.NumReadonlySignedAccounts)
+	numWritableUnsigned := len(keys) - int(msg.Header.NumReadonlyUnsignedAccounts)
+
+	programs := make(map[int]bool)
+	for _, instr := range msg.Instructions {
+		programs[int(instr.ProgramIDIndex)] = true
+	}
+	upgradeableLoader := false
+	for _, key := range keys {
+		if key == solana.BPFLoaderUpgradeableProgramID {
+			upgradeableLoader = true
+		}
+	}
+
+	for i, key := range keys {
+		isWritable := i < numWritableSigned || (i >= numSigned && i < numWritableUnsigned)
+		if programs[i] && !upgradeableLoader {
+			isWritable = false
+		}
+		if isWritable {
+			writable = append(writable, key)
+		} else {
+			readonly = append(readonly, key)
+		}
+	}
+	if loaded != nil {
+		writable = append(writable, loaded.Writable...)
+		readonly = append(readonly, loaded.Readonly...)
+	}
+	return writable, readonly
+}
+
+// addressSignatureCells returns the cells of CfAddressSig indexing the
+// signature of a transaction by each of its account keys.
+// AddressSignatureMeta values are a bincode bool of whether the account is
+// writable.
+func addressSignatureCells(slot uint64, index uint32, sig solana.Signature, writable, readonly []solana.PublicKey) []cell {
+	cells := make([]cell, 0, len(writable)+len(readonly))
+	for _, addr := range writable {
+		key := MakeAddressSigKey(addr, slot, index, sig)
+		cells = append(cells, cell{key: key[:], value: []byte{1}})
+	}
+	for _, addr := range readonly {
+		key := MakeAddressSigKey(addr, slot, index, sig)
+		cells = append(cells, cell{key: key[:], value: []byte{0}})
+	}
+	return cells
+}
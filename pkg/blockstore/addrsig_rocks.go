@@ -0,0 +1,55 @@
+//go:build !lite
+
+package blockstore
+
+import (
+	"errors"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+// IndexAddressSignatures indexes the signatures of the transactions of a
+// full slot by their account keys in CfAddressSig, which is created if it
+// is missing. Transactions without a status in CfTxStatus are skipped.
+// Existing cells are overwritten, so that the index of a slot can be
+// repaired. Returns the number of transactions indexed.
+//
+// The DB must be opened with OpenReadWrite.
+func (d *DB) IndexAddressSignatures(slot uint64) (int, error) {
+	if d.CfTxStatus == nil {
+		return 0, errors.New("missing column family " + CfTxStatus)
+	}
+	cf, err := d.columnFamily(CfAddressSig)
+	if err != nil {
+		return 0, err
+	}
+	block, err := d.GetBlock(slot)
+	if err != nil {
+		return 0, err
+	}
+
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	var numIndexed int
+	for i, tx := range block.Transactions() {
+		if len(tx.Signatures) == 0 {
+			continue
+		}
+		sig := tx.Signatures[0]
+		meta, err := d.GetTransactionStatusInSlot(sig, slot)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		writable, readonly := TransactionAccountKeys(&tx, &meta.LoadedAddresses)
+		for _, c := range addressSignatureCells(slot, uint32(i), sig, writable, readonly) {
+			batch.PutCF(cf, c.key, c.value)
+		}
+		numIndexed++
+	}
+	if err := d.DB.Write(grocksdb.NewDefaultWriteOptions(), batch); err != nil {
+		return 0, err
+	}
+	return numIndexed, nil
+}
@@ -0,0 +1,34 @@
+package blockstore
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func TestTransactionAccountKeys(t *testing.T) {
+	keys := []solana.PublicKey{{1}, {2}, {3}, {4}, {5}}
+	tx := &solana.Transaction{Message: solana.Message{
+		Header: solana.MessageHeader{
+			NumRequiredSignatures:       2,
+			NumReadonlySignedAccounts:   1,
+			NumReadonlyUnsignedAccounts: 1,
+		},
+		AccountKeys:  keys,
+		Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 3}},
+	}}
+	loaded := &sealevel.LoadedAddresses{
+		Writable: []solana.PublicKey{{6}},
+		Readonly: []solana.PublicKey{{7}},
+	}
+	writable, readonly := TransactionAccountKeys(tx, loaded)
+	assert.Equal(t, []solana.PublicKey{{1}, {3}, {6}}, writable)
+	assert.Equal(t, []solana.PublicKey{{2}, {4}, {5}, {7}}, readonly)
+
+	// invoked programs stay writable with the upgradeable loader
+	tx.Message.AccountKeys = append(keys[:4:4], solana.BPFLoaderUpgradeableProgramID)
+	writable, _ = TransactionAccountKeys(tx, nil)
+	assert.Equal(t, []solana.PublicKey{{1}, {3}, {4}}, writable)
+}
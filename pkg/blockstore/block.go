@@ -0,0 +1,76 @@
+package blockstore
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// Block is a full slot, reassembled from its data shreds.
+type Block struct {
+	Slot       uint64
+	ParentSlot uint64
+	// Batches holds the entries of the block, in the batches that were
+	// shredded together. Each batch ends with a data shred marking the end
+	// of a batch, which are the entry end indexes of the slot meta.
+	Batches []Entries
+}
+
+// NewBlock reassembles a full slot from its data shreds, which must be
+// ordered by index, starting with the first.
+func NewBlock(meta *SlotMeta, shreds []shred.Shred) (*Block, error) {
+	if !meta.IsFull() {
+		return nil, fmt.Errorf("slot %d is not full", meta.Slot)
+	}
+	if uint64(len(shreds)) != meta.LastIndex+1 {
+		return nil, fmt.Errorf("slot %d has %d data shreds, expected %d",
+			meta.Slot, len(shreds), meta.LastIndex+1)
+	}
+	for i, s := range shreds {
+		if s.Slot != meta.Slot || s.Index != uint32(i) {
+			return nil, fmt.Errorf("unexpected shred %d/%d at index %d of slot %d",
+				s.Slot, s.Index, i, meta.Slot)
+		}
+	}
+	batches, err := DataShredsToEntries(meta, shreds)
+	if err != nil {
+		return nil, err
+	}
+	return &Block{
+		Slot:       meta.Slot,
+		ParentSlot: meta.ParentSlot,
+		Batches:    batches,
+	}, nil
+}
+
+// Entries returns the entries of the block, across batches.
+func (b *Block) Entries() []shred.Entry {
+	var entries []shred.Entry
+	for _, batch := range b.Batches {
+		entries = append(entries, batch.Entries...)
+	}
+	return entries
+}
+
+// Transactions returns the transactions of the block, in order.
+func (b *Block) Transactions() []solana.Transaction {
+	var txns []solana.Transaction
+	for _, batch := range b.Batches {
+		for _, entry := range batch.Entries {
+			txns = append(txns, entry.Txns...)
+		}
+	}
+	return txns
+}
+
+// Blockhash returns the hash of the last entry of the block, which is the
+// blockhash of the slot.
+func (b *Block) Blockhash() (hash solana.Hash, ok bool) {
+	for i := len(b.Batches) - 1; i >= 0; i-- {
+		if entries := b.Batches[i].Entries; len(entries) != 0 {
+			return entries[len(entries)-1].Hash, true
+		}
+	}
+	return hash, false
+}
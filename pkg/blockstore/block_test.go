@@ -0,0 +1,46 @@
+package blockstore
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+)
+
+func TestNewBlock_Mainnet_Recent(t *testing.T) {
+	shreds := parseShreds(t, fixtures.DataShreds(t, "mainnet", 102815960), 2)
+	meta := &SlotMeta{
+		Slot:               102815960,
+		Consumed:           1427,
+		Received:           1427,
+		LastIndex:          1426,
+		ParentSlot:         102815959,
+		NumEntryEndIndexes: 574,
+		EntryEndIndexes:    mainnet_102815960_EntryEndIndexes,
+	}
+	block, err := NewBlock(meta, shreds)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(102815960), block.Slot)
+	assert.Equal(t, uint64(102815959), block.ParentSlot)
+	require.Len(t, block.Batches, 574)
+	assert.Equal(t, uint32(1426), block.Batches[573].Shreds[len(block.Batches[573].Shreds)-1].Index)
+
+	txns := block.Transactions()
+	require.Len(t, txns, 3177)
+	assert.Equal(t,
+		solana.MustSignatureFromBase58("5ruUBRn3CNNcFneJtEaJBaUjhtHtEb6Q9Hu2ygTSA9TjLr6FtE2WGGBkt7nUAHc1fnBaL8DGMS6maeCYHpRzuY6K"),
+		txns[0].Signatures[0])
+
+	entries := block.Entries()
+	blockhash, ok := block.Blockhash()
+	require.True(t, ok)
+	assert.Equal(t, entries[len(entries)-1].Hash, blockhash)
+
+	_, err = NewBlock(meta, shreds[:1426])
+	assert.Error(t, err)
+	meta.LastIndex = 1500
+	_, err = NewBlock(meta, shreds)
+	assert.Error(t, err)
+}
@@ -1,4 +1,4 @@
-// Package blockstore is a read-only client for the Solana blockstore database.
+// Package blockstore is a client for the Solana blockstore database.
 //
 // For the reference implementation in Rust, see here:
 // https://docs.rs/solana-ledger/latest/solana_ledger/blockstore/struct.Blockstore.html
@@ -43,6 +43,15 @@ const (
 	// CfDeadSlots contains slots that have been marked as dead
 	CfDeadSlots = "dead_slots"
 
+	// CfDuplicateSlots contains proofs of slots with conflicting shreds
+	CfDuplicateSlots = "duplicate_slots"
+
+	// CfOrphans contains slots of which the parent is missing
+	CfOrphans = "orphans"
+
+	// CfIndex contains the indexes of received shreds of each slot
+	CfIndex = "index"
+
 	CfBlockHeight = "block_height"
 
 	CfBankHash = "bank_hashes"
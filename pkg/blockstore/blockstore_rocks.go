@@ -13,14 +13,49 @@ import (
 type DB struct {
 	DB *grocksdb.DB
 
-	CfDefault   *grocksdb.ColumnFamilyHandle
-	CfMeta      *grocksdb.ColumnFamilyHandle
-	CfRoot      *grocksdb.ColumnFamilyHandle
-	CfDataShred *grocksdb.ColumnFamilyHandle
-	CfCodeShred *grocksdb.ColumnFamilyHandle
-	CfTxStatus  *grocksdb.ColumnFamilyHandle
+	CfDefault        *grocksdb.ColumnFamilyHandle
+	CfMeta           *grocksdb.ColumnFamilyHandle
+	CfRoot           *grocksdb.ColumnFamilyHandle
+	CfDataShred      *grocksdb.ColumnFamilyHandle
+	CfCodeShred      *grocksdb.ColumnFamilyHandle
+	CfTxStatus       *grocksdb.ColumnFamilyHandle
+	CfAddressSig     *grocksdb.ColumnFamilyHandle
+	CfRewards        *grocksdb.ColumnFamilyHandle
+	CfBlockTime      *grocksdb.ColumnFamilyHandle
+	CfBlockHeight    *grocksdb.ColumnFamilyHandle
+	CfDuplicateSlots *grocksdb.ColumnFamilyHandle
+
+	// handles of all column families by name, including unknown ones
+	handles map[string]*grocksdb.ColumnFamilyHandle
 }
 
+// Create creates an empty blockstore at path, with the column families of
+// slot metadata, roots, shreds, duplicate slot proofs, transaction
+// statuses, rewards, block times and block heights, and opens it for
+// writing.
+func Create(path string) (*DB, error) {
+	opts := grocksdb.NewDefaultOptions()
+	opts.SetCreateIfMissing(true)
+	opts.SetCreateIfMissingColumnFamilies(true)
+	opts.SetErrorIfExists(true)
+	cfNames := []string{CfDefault, CfMeta, CfRoot, CfDataShred, CfCodeShred, CfDuplicateSlots, CfTxStatus, CfAddressSig,
+		CfRewards, CfBlockTime, CfBlockHeight}
+	cfOpts := make([]*grocksdb.Options, len(cfNames))
+	for i := range cfOpts {
+		cfOpts[i] = grocksdb.NewDefaultOptions()
+	}
+	db, cfHandles, err := grocksdb.OpenDbColumnFamilies(opts, path, cfNames, cfOpts)
+	if err != nil {
+		return nil, err
+	}
+	for _, handle := range cfHandles {
+		handle.Destroy()
+	}
+	db.Close()
+	return OpenReadWrite(path)
+}
+
+// OpenReadWrite opens a blockstore for reading and writing.
 func OpenReadWrite(path string) (*DB, error) {
 	return open(path, "", true)
 }
@@ -110,8 +145,10 @@ func open(path string, secondaryPath string, write bool) (*DB, error) {
 	}
 
 	// Write handles into DB object
+	db.handles = make(map[string]*grocksdb.ColumnFamilyHandle, len(cfHandles))
 	for i, slot := range handleSlots {
 		*slot = cfHandles[i]
+		db.handles[cfNames[i]] = cfHandles[i]
 	}
 
 	if db.CfMeta == nil {
@@ -143,11 +180,29 @@ func getCfOpts(db *DB, name string) (**grocksdb.ColumnFamilyHandle, *grocksdb.Op
 		return &db.CfDataShred, grocksdb.NewDefaultOptions()
 	case CfCodeShred:
 		return &db.CfCodeShred, grocksdb.NewDefaultOptions()
+	case CfTxStatus:
+		return &db.CfTxStatus, grocksdb.NewDefaultOptions()
+	case CfAddressSig:
+		return &db.CfAddressSig, grocksdb.NewDefaultOptions()
+	case CfRewards:
+		return &db.CfRewards, grocksdb.NewDefaultOptions()
+	case CfBlockTime:
+		return &db.CfBlockTime, grocksdb.NewDefaultOptions()
+	case CfBlockHeight:
+		return &db.CfBlockHeight, grocksdb.NewDefaultOptions()
+	case CfDuplicateSlots:
+		return &db.CfDuplicateSlots, grocksdb.NewDefaultOptions()
 	default:
 		return &handle, grocksdb.NewDefaultOptions()
 	}
 }
 
+// ColumnFamilyHandles returns the handles of all column families of the
+// blockstore by name, including those without a field in DB.
+func (d *DB) ColumnFamilyHandles() map[string]*grocksdb.ColumnFamilyHandle {
+	return d.handles
+}
+
 func (d *DB) Close() {
 	d.DB.Close()
 }
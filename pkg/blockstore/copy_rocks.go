@@ -0,0 +1,97 @@
+//go:build !lite
+
+package blockstore
+
+import (
+	"github.com/linxGnu/grocksdb"
+)
+
+// copyBatchSize is the number of cells per write when copying slots.
+const copyBatchSize = 4096
+
+// CopySlots copies all data of the slots [start, end] into another
+// blockstore, creating the column families missing from it.
+//
+// dst must be opened with OpenReadWrite.
+func (d *DB) CopySlots(dst *DB, start, end uint64) error {
+	for _, name := range slotColumnFamilies {
+		if cf := d.handles[name]; cf != nil {
+			if err := d.copyRange(dst, name, cf, true, start, end); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range signatureColumnFamilies {
+		if cf := d.handles[name]; cf != nil {
+			if err := d.copyRange(dst, name, cf, false, start, end); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyRange copies the cells of the slots [start, end] in a column family.
+// Column families keyed by slot are only scanned within the slot range,
+// others entirely.
+func (d *DB) copyRange(dst *DB, name string, cf *grocksdb.ColumnFamilyHandle, bySlot bool, start, end uint64) error {
+	dstCf, err := dst.columnFamily(name)
+	if err != nil {
+		return err
+	}
+
+	opts := grocksdb.NewDefaultReadOptions()
+	opts.SetFillCache(false)
+	iter := d.DB.NewIteratorCF(opts, cf)
+	defer iter.Close()
+
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	if bySlot {
+		startKey := MakeSlotKey(start)
+		iter.Seek(startKey[:])
+	} else {
+		iter.SeekToFirst()
+	}
+	for ; iter.Valid(); iter.Next() {
+		key := iter.Key()
+		slot, ok := keySlot(name, key.Data())
+		if ok && bySlot && slot > end {
+			key.Free()
+			break
+		}
+		if ok && slot >= start && slot <= end {
+			value := iter.Value()
+			batch.PutCF(dstCf, key.Data(), value.Data())
+			value.Free()
+		}
+		key.Free()
+		if batch.Count() >= copyBatchSize {
+			if err := dst.DB.Write(grocksdb.NewDefaultWriteOptions(), batch); err != nil {
+				return err
+			}
+			batch.Clear()
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return dst.DB.Write(grocksdb.NewDefaultWriteOptions(), batch)
+}
+
+// columnFamily returns the handle of a column family, creating it if it is
+// missing.
+func (d *DB) columnFamily(name string) (*grocksdb.ColumnFamilyHandle, error) {
+	if cf := d.handles[name]; cf != nil {
+		return cf, nil
+	}
+	cf, err := d.DB.CreateColumnFamily(grocksdb.NewDefaultOptions(), name)
+	if err != nil {
+		return nil, err
+	}
+	if slot, _ := getCfOpts(d, name); slot != nil {
+		*slot = cf
+	}
+	d.handles[name] = cf
+	return cf, nil
+}
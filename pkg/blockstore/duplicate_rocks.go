@@ -0,0 +1,44 @@
+//go:build !lite
+
+package blockstore
+
+import (
+	"github.com/linxGnu/grocksdb"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// GetDuplicateSlotProof returns the proof of conflicting shreds of a slot
+// stored by the validator.
+//
+// Based on solana_ledger::blockstore::Blockstore::get_duplicate_slot.
+func (d *DB) GetDuplicateSlotProof(slot uint64) (*shred.DuplicateSlotProof, error) {
+	res, err := d.getSlotValue(d.CfDuplicateSlots, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Free()
+	return shred.ParseDuplicateSlotProof(res.Data())
+}
+
+// FindDuplicateShreds returns proofs of the conflicts among the data and
+// coding shreds of a slot at hand, which the validator has not necessarily
+// detected: erasure sets with different merkle roots, and data shreds
+// following the last data shred of the slot.
+func (d *DB) FindDuplicateShreds(slot uint64) ([]*shred.DuplicateSlotProof, error) {
+	prefix := MakeSlotKey(slot)
+	var shreds [][]byte
+	for _, cf := range []*grocksdb.ColumnFamilyHandle{d.CfDataShred, d.CfCodeShred} {
+		iter := d.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), cf)
+		for iter.Seek(prefix[:]); iter.ValidForPrefix(prefix[:]); iter.Next() {
+			value := iter.Value()
+			shreds = append(shreds, append([]byte(nil), value.Data()...))
+			value.Free()
+		}
+		err := iter.Err()
+		iter.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shred.FindDuplicates(shreds), nil
+}
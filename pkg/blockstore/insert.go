@@ -0,0 +1,490 @@
+package blockstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// NewSlotMeta returns the metadata of a slot without shreds. The parent of
+// orphan slots, of which only children were received, is math.MaxUint64.
+//
+// Based on solana_ledger::blockstore_meta::SlotMeta::new.
+func NewSlotMeta(slot, parentSlot uint64) *SlotMeta {
+	return &SlotMeta{
+		Slot:        slot,
+		LastIndex:   math.MaxUint64,
+		ParentSlot:  parentSlot,
+		IsConnected: slot == 0,
+	}
+}
+
+// IsOrphan returns whether the parent of the slot is unknown.
+func (s *SlotMeta) IsOrphan() bool {
+	return s.ParentSlot == math.MaxUint64
+}
+
+// MarshalBincode returns the bincode encoding of the slot meta, as stored
+// in CfMeta.
+func (s *SlotMeta) MarshalBincode() []byte {
+	b := make([]byte, 0, 8*8+1+8*len(s.NextSlots)+4*len(s.EntryEndIndexes))
+	b = binary.LittleEndian.AppendUint64(b, s.Slot)
+	b = binary.LittleEndian.AppendUint64(b, s.Consumed)
+	b = binary.LittleEndian.AppendUint64(b, s.Received)
+	b = binary.LittleEndian.AppendUint64(b, s.FirstShredTimestamp)
+	b = binary.LittleEndian.AppendUint64(b, s.LastIndex)
+	b = binary.LittleEndian.AppendUint64(b, s.ParentSlot)
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(s.NextSlots)))
+	for _, slot := range s.NextSlots {
+		b = binary.LittleEndian.AppendUint64(b, slot)
+	}
+	if s.IsConnected {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(s.EntryEndIndexes)))
+	for _, index := range s.EntryEndIndexes {
+		b = binary.LittleEndian.AppendUint32(b, index)
+	}
+	return b
+}
+
+// shredStore is the blockstore that shreds are inserted into, as read by
+// an insertion. Missing shreds are nil.
+type shredStore interface {
+	getSlotMeta(slot uint64) (*SlotMeta, error)
+	getDataShred(slot, index uint64) ([]byte, error)
+	getCodeShred(slot, index uint64) ([]byte, error)
+	hasDuplicateSlot(slot uint64) (bool, error)
+}
+
+// InsertStats counts the shreds of an insertion.
+type InsertStats struct {
+	NumData      int
+	NumCode      int
+	NumDuplicate int
+	NumInvalid   int
+	// NumRecovered counts the data shreds recovered from coding shreds,
+	// which are also counted by NumData.
+	NumRecovered int
+	// CompletedSlots are the slots that became full, in order of
+	// completion.
+	CompletedSlots []uint64
+	// DuplicateSlots are the slots of which conflicting shreds were
+	// received for the first time, in order of detection.
+	DuplicateSlots []uint64
+}
+
+// shredInsertion holds the writes of an insertion of shreds, which are
+// committed at once.
+type shredInsertion struct {
+	stats InsertStats
+	db    shredStore
+
+	metas      map[uint64]*SlotMeta
+	data       map[[16]byte][]byte
+	code       map[[16]byte][]byte
+	duplicates map[uint64]*shred.DuplicateSlotProof
+	// a coding shred of each erasure set touched by the insertion
+	erasureSets map[erasureSetKey][]byte
+	now         uint64
+}
+
+// erasureSetKey identifies the erasure set of a slot.
+type erasureSetKey struct {
+	slot        uint64
+	fecSetIndex uint32
+}
+
+func newShredInsertion(db shredStore) *shredInsertion {
+	return &shredInsertion{
+		db:          db,
+		metas:       make(map[uint64]*SlotMeta),
+		data:        make(map[[16]byte][]byte),
+		code:        make(map[[16]byte][]byte),
+		duplicates:  make(map[uint64]*shred.DuplicateSlotProof),
+		erasureSets: make(map[erasureSetKey][]byte),
+		now:         uint64(time.Now().UnixMilli()),
+	}
+}
+
+// slotMeta returns the metadata of a slot, as updated by the insertion, or
+// nil if the slot has none.
+func (ins *shredInsertion) slotMeta(slot uint64) (*SlotMeta, error) {
+	if meta, ok := ins.metas[slot]; ok {
+		return meta, nil
+	}
+	meta, err := ins.db.getSlotMeta(slot)
+	if err == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	meta.Slot = slot
+	ins.metas[slot] = meta
+	return meta, nil
+}
+
+func (ins *shredInsertion) dataShred(slot, index uint64) ([]byte, error) {
+	if raw, ok := ins.data[MakeShredKey(slot, index)]; ok {
+		return raw, nil
+	}
+	return ins.db.getDataShred(slot, index)
+}
+
+func (ins *shredInsertion) codeShred(slot, index uint64) ([]byte, error) {
+	if raw, ok := ins.code[MakeShredKey(slot, index)]; ok {
+		return raw, nil
+	}
+	return ins.db.getCodeShred(slot, index)
+}
+
+// checkDuplicate records the proof of a slot if a shred conflicts with a
+// shred of the slot at hand, unless the slot already has a proof.
+//
+// Based on solana_ledger::blockstore::Blockstore::store_duplicate_slot.
+func (ins *shredInsertion) checkDuplicate(slot uint64, existing, raw []byte) error {
+	if existing == nil {
+		return nil
+	}
+	if _, ok := ins.duplicates[slot]; ok {
+		return nil
+	}
+	proof, err := shred.CheckDuplicate(existing, raw)
+	if err != nil {
+		return nil
+	}
+	exists, err := ins.db.hasDuplicateSlot(slot)
+	if err != nil || exists {
+		return err
+	}
+	ins.duplicates[slot] = proof
+	ins.stats.DuplicateSlots = append(ins.stats.DuplicateSlots, slot)
+	return nil
+}
+
+// insert inserts a serialized data or coding shred. Duplicate and invalid
+// shreds are counted, and dropped.
+func (ins *shredInsertion) insert(raw []byte) error {
+	if len(raw) <= 0x40 {
+		ins.stats.NumInvalid++
+		return nil
+	}
+	variant := raw[0x40]
+	common := shred.CommonHeader{Variant: variant}
+	switch {
+	case common.IsData():
+		return ins.insertDataShred(raw)
+	case common.IsCode():
+		return ins.insertCodeShred(raw)
+	default:
+		ins.stats.NumInvalid++
+		return nil
+	}
+}
+
+// insertCodeShred inserts a coding shred, which leaves the slot meta as is.
+func (ins *shredInsertion) insertCodeShred(raw []byte) error {
+	common, _, ok := shred.ParseCodeHeader(raw)
+	if !ok {
+		ins.stats.NumInvalid++
+		return nil
+	}
+	existing, err := ins.codeShred(common.Slot, uint64(common.Index))
+	if err != nil {
+		return err
+	} else if existing != nil {
+		ins.stats.NumDuplicate++
+		return ins.checkDuplicate(common.Slot, existing, raw)
+	}
+	ins.code[MakeShredKey(common.Slot, uint64(common.Index))] = raw
+	ins.stats.NumCode++
+	ins.erasureSets[erasureSetKey{common.Slot, common.FECSetIndex}] = raw
+	return nil
+}
+
+// recoverErasureSets recovers the data shreds missing from the erasure
+// sets of the coding shreds inserted, if enough shreds of a set are at
+// hand, and inserts them.
+//
+// Based on solana_ledger::blockstore::Blockstore::try_shred_recovery.
+func (ins *shredInsertion) recoverErasureSets() error {
+	keys := make([]erasureSetKey, 0, len(ins.erasureSets))
+	for key := range ins.erasureSets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].slot != keys[j].slot {
+			return keys[i].slot < keys[j].slot
+		}
+		return keys[i].fecSetIndex < keys[j].fecSetIndex
+	})
+	for _, key := range keys {
+		if err := ins.recoverErasureSet(key, ins.erasureSets[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ins *shredInsertion) recoverErasureSet(key erasureSetKey, codeShred []byte) error {
+	common, header, ok := shred.ParseCodeHeader(codeShred)
+	if !ok || uint32(header.Position) > common.Index {
+		return nil
+	}
+	numData, numCode := int(header.NumDataShreds), int(header.NumCodeShreds)
+	firstCodeIndex := uint64(common.Index) - uint64(header.Position)
+
+	var data, code [][]byte
+	for i := 0; i < numData; i++ {
+		raw, err := ins.dataShred(key.slot, uint64(key.fecSetIndex)+uint64(i))
+		if err != nil {
+			return err
+		} else if raw != nil {
+			data = append(data, raw)
+		}
+	}
+	if len(data) == numData {
+		return nil
+	}
+	for i := 0; i < numCode; i++ {
+		raw, err := ins.codeShred(key.slot, firstCodeIndex+uint64(i))
+		if err != nil {
+			return err
+		} else if raw != nil {
+			code = append(code, raw)
+		}
+	}
+	if len(data)+len(code) < numData {
+		return nil
+	}
+
+	// shreds failing recovery are left as is, like invalid shreds
+	recovered, err := shred.RecoverDataShreds(data, code)
+	if err != nil {
+		return nil
+	}
+	for _, raw := range recovered {
+		numData := ins.stats.NumData
+		if err := ins.insertDataShred(raw); err != nil {
+			return err
+		}
+		if ins.stats.NumData > numData {
+			ins.stats.NumRecovered++
+		}
+	}
+	return nil
+}
+
+// insertDataShred inserts a data shred, and updates the meta of its slot,
+// and the chaining of the slot to its parent.
+//
+// Based on solana_ledger::blockstore::Blockstore::check_insert_data_shred.
+func (ins *shredInsertion) insertDataShred(raw []byte) error {
+	s := shred.NewShredFromSerialized(raw, shred.RevisionV2)
+	if !s.IsData() || s.Payload == nil ||
+		uint64(s.ParentOffset) > s.Slot || (s.ParentOffset == 0 && s.Slot != 0) {
+		ins.stats.NumInvalid++
+		return nil
+	}
+	slot, index := s.Slot, uint64(s.Index)
+	parentSlot := s.Slot - uint64(s.ParentOffset)
+
+	existing, err := ins.dataShred(slot, index)
+	if err != nil {
+		return err
+	} else if existing != nil {
+		ins.stats.NumDuplicate++
+		return ins.checkDuplicate(slot, existing, raw)
+	}
+
+	meta, err := ins.slotMeta(slot)
+	if err != nil {
+		return err
+	}
+	isNew := meta == nil
+	if isNew {
+		meta = NewSlotMeta(slot, parentSlot)
+		ins.metas[slot] = meta
+	}
+	wasOrphan := meta.IsOrphan()
+	if !ins.shouldInsertDataShred(meta, &s, parentSlot) {
+		ins.stats.NumInvalid++
+		if isNew {
+			delete(ins.metas, slot)
+		}
+		if meta.LastIndex != math.MaxUint64 && index > meta.LastIndex {
+			// a shred beyond the last one of the slot
+			last, err := ins.dataShred(slot, meta.LastIndex)
+			if err != nil {
+				return err
+			}
+			return ins.checkDuplicate(slot, last, raw)
+		}
+		return nil
+	}
+	ins.data[MakeShredKey(slot, index)] = raw
+	ins.stats.NumData++
+
+	wasFull := meta.IsFull()
+	if err := ins.updateSlotMeta(meta, &s); err != nil {
+		return err
+	}
+	if isNew || wasOrphan {
+		meta.ParentSlot = parentSlot
+		if err := ins.chainToParent(meta); err != nil {
+			return err
+		}
+	}
+	if !wasFull && meta.IsFull() {
+		ins.stats.CompletedSlots = append(ins.stats.CompletedSlots, slot)
+		if meta.IsConnected {
+			return ins.connectChildren(meta)
+		}
+	}
+	return nil
+}
+
+// shouldInsertDataShred reports whether a data shred is consistent with
+// the meta of its slot.
+//
+// Based on solana_ledger::blockstore::Blockstore::should_insert_data_shred.
+func (ins *shredInsertion) shouldInsertDataShred(meta *SlotMeta, s *shred.Shred, parentSlot uint64) bool {
+	index := uint64(s.Index)
+	if !meta.IsOrphan() && meta.ParentSlot != parentSlot {
+		return false
+	}
+	if meta.LastIndex != math.MaxUint64 && index > meta.LastIndex {
+		return false
+	}
+	if isLastInSlot(s) && (meta.LastIndex != math.MaxUint64 || index+1 < meta.Received) {
+		// shreds were received beyond the last one of the slot
+		return false
+	}
+	return true
+}
+
+// isLastInSlot reports whether a data shred is the last of its slot, which
+// also ends a batch of entries.
+func isLastInSlot(s *shred.Shred) bool {
+	return s.Flags&shred.FlagDataEndOfBlock == shred.FlagDataEndOfBlock
+}
+
+// updateSlotMeta updates the meta of a slot with a data shred inserted.
+//
+// Based on solana_ledger::blockstore::update_slot_meta.
+func (ins *shredInsertion) updateSlotMeta(meta *SlotMeta, s *shred.Shred) error {
+	index := uint64(s.Index)
+	if meta.FirstShredTimestamp == 0 {
+		meta.FirstShredTimestamp = ins.now
+	}
+	if isLastInSlot(s) {
+		meta.LastIndex = index
+	}
+	if index+1 > meta.Received {
+		meta.Received = index + 1
+	}
+	if meta.Consumed == index {
+		for {
+			meta.Consumed++
+			raw, err := ins.dataShred(meta.Slot, meta.Consumed)
+			if err != nil {
+				return err
+			}
+			if raw == nil {
+				break
+			}
+		}
+	}
+	if s.Flags&shred.FlagDataEndOfBatch != 0 {
+		i := sort.Search(len(meta.EntryEndIndexes), func(i int) bool {
+			return meta.EntryEndIndexes[i] >= s.Index
+		})
+		if i == len(meta.EntryEndIndexes) || meta.EntryEndIndexes[i] != s.Index {
+			meta.EntryEndIndexes = append(meta.EntryEndIndexes, 0)
+			copy(meta.EntryEndIndexes[i+1:], meta.EntryEndIndexes[i:])
+			meta.EntryEndIndexes[i] = s.Index
+		}
+		meta.NumEntryEndIndexes = uint64(len(meta.EntryEndIndexes))
+	}
+	return nil
+}
+
+// chainToParent adds a slot to the next slots of its parent, which is
+// created as an orphan if it has no meta, and connects the slot if its
+// parent is connected and full.
+//
+// Based on solana_ledger::blockstore::Blockstore::handle_chaining_for_slot.
+func (ins *shredInsertion) chainToParent(meta *SlotMeta) error {
+	if meta.Slot == 0 {
+		return nil
+	}
+	parent, err := ins.slotMeta(meta.ParentSlot)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		parent = NewSlotMeta(meta.ParentSlot, math.MaxUint64)
+		ins.metas[meta.ParentSlot] = parent
+	}
+	found := false
+	for _, next := range parent.NextSlots {
+		if next == meta.Slot {
+			found = true
+			break
+		}
+	}
+	if !found {
+		parent.NextSlots = append(parent.NextSlots, meta.Slot)
+		parent.NumNextSlots = uint64(len(parent.NextSlots))
+	}
+	if parent.IsConnected && parent.IsFull() {
+		meta.IsConnected = true
+	}
+	return nil
+}
+
+// connectChildren connects the descendants of a connected slot that became
+// full, through the children that are full themselves.
+func (ins *shredInsertion) connectChildren(meta *SlotMeta) error {
+	queue := []*SlotMeta{meta}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for _, slot := range parent.NextSlots {
+			child, err := ins.slotMeta(slot)
+			if err != nil {
+				return err
+			}
+			if child == nil || child.IsConnected {
+				continue
+			}
+			child.IsConnected = true
+			if child.IsFull() {
+				queue = append(queue, child)
+			}
+		}
+	}
+	return nil
+}
+
+// insertShreds inserts serialized data and coding shreds into a store,
+// recovers the data shreds missing from their erasure sets, and returns
+// the writes of the insertion, which the caller commits.
+func insertShreds(db shredStore, shreds [][]byte) (*shredInsertion, error) {
+	ins := newShredInsertion(db)
+	for _, raw := range shreds {
+		if err := ins.insert(raw); err != nil {
+			return nil, fmt.Errorf("failed to insert shred: %w", err)
+		}
+	}
+	if err := ins.recoverErasureSets(); err != nil {
+		return nil, fmt.Errorf("failed to recover shreds: %w", err)
+	}
+	return ins, nil
+}
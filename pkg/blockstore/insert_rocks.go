@@ -0,0 +1,91 @@
+//go:build !lite
+
+package blockstore
+
+import (
+	"errors"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+// InsertShreds inserts serialized data and coding shreds, and updates the
+// metadata of their slots: the shreds consumed and received, the last
+// shred and parent of the slot, and the chaining of slots. Duplicate and
+// invalid shreds are dropped, and the first proof of conflicting shreds of
+// a slot is stored. The data shreds missing from the erasure sets of the
+// coding shreds are recovered if possible. All writes are committed
+// atomically.
+//
+// The DB must be opened with OpenReadWrite.
+//
+// Based on solana_ledger::blockstore::Blockstore::insert_shreds.
+func (d *DB) InsertShreds(shreds [][]byte) (*InsertStats, error) {
+	ins, err := insertShreds(d, shreds)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	for key, raw := range ins.data {
+		key := key
+		batch.PutCF(d.CfDataShred, key[:], raw)
+	}
+	for key, raw := range ins.code {
+		key := key
+		batch.PutCF(d.CfCodeShred, key[:], raw)
+	}
+	for slot, meta := range ins.metas {
+		key := MakeSlotKey(slot)
+		batch.PutCF(d.CfMeta, key[:], meta.MarshalBincode())
+	}
+	if len(ins.duplicates) != 0 && d.CfDuplicateSlots == nil {
+		return nil, errors.New("missing column family " + CfDuplicateSlots)
+	}
+	for slot, proof := range ins.duplicates {
+		key := MakeSlotKey(slot)
+		batch.PutCF(d.CfDuplicateSlots, key[:], proof.MarshalBincode())
+	}
+	opts := grocksdb.NewDefaultWriteOptions()
+	if err := d.DB.Write(opts, batch); err != nil {
+		return nil, err
+	}
+	return &ins.stats, nil
+}
+
+func (d *DB) getSlotMeta(slot uint64) (*SlotMeta, error) {
+	return d.GetSlotMeta(slot)
+}
+
+func (d *DB) getDataShred(slot, index uint64) ([]byte, error) {
+	return d.getShredCopy(d.CfDataShred, slot, index)
+}
+
+func (d *DB) getCodeShred(slot, index uint64) ([]byte, error) {
+	return d.getShredCopy(d.CfCodeShred, slot, index)
+}
+
+func (d *DB) getShredCopy(cf *grocksdb.ColumnFamilyHandle, slot, index uint64) ([]byte, error) {
+	res, err := d.getRawShred(cf, slot, index)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Free()
+	if !res.Exists() {
+		return nil, nil
+	}
+	return append([]byte(nil), res.Data()...), nil
+}
+
+func (d *DB) hasDuplicateSlot(slot uint64) (bool, error) {
+	if d.CfDuplicateSlots == nil {
+		return false, nil
+	}
+	key := MakeSlotKey(slot)
+	res, err := d.DB.GetCF(grocksdb.NewDefaultReadOptions(), d.CfDuplicateSlots, key[:])
+	if err != nil {
+		return false, err
+	}
+	defer res.Free()
+	return res.Exists(), nil
+}
@@ -0,0 +1,274 @@
+package blockstore
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// memStore is an in-memory blockstore, to which insertions are committed.
+type memStore struct {
+	metas      map[uint64][]byte
+	data       map[[16]byte][]byte
+	code       map[[16]byte][]byte
+	duplicates map[uint64][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		metas:      make(map[uint64][]byte),
+		data:       make(map[[16]byte][]byte),
+		code:       make(map[[16]byte][]byte),
+		duplicates: make(map[uint64][]byte),
+	}
+}
+
+func (m *memStore) getSlotMeta(slot uint64) (*SlotMeta, error) {
+	raw, ok := m.metas[slot]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return ParseBincode[SlotMeta](raw)
+}
+
+func (m *memStore) getDataShred(slot, index uint64) ([]byte, error) {
+	return m.data[MakeShredKey(slot, index)], nil
+}
+
+func (m *memStore) getCodeShred(slot, index uint64) ([]byte, error) {
+	return m.code[MakeShredKey(slot, index)], nil
+}
+
+func (m *memStore) hasDuplicateSlot(slot uint64) (bool, error) {
+	_, ok := m.duplicates[slot]
+	return ok, nil
+}
+
+func (m *memStore) insert(t *testing.T, shreds ...[]byte) *InsertStats {
+	ins, err := insertShreds(m, shreds)
+	require.NoError(t, err)
+	for key, raw := range ins.data {
+		m.data[key] = raw
+	}
+	for key, raw := range ins.code {
+		m.code[key] = raw
+	}
+	for slot, meta := range ins.metas {
+		m.metas[slot] = meta.MarshalBincode()
+	}
+	for slot, proof := range ins.duplicates {
+		m.duplicates[slot] = proof.MarshalBincode()
+	}
+	return &ins.stats
+}
+
+func (m *memStore) slotMeta(t *testing.T, slot uint64) *SlotMeta {
+	meta, err := m.getSlotMeta(slot)
+	require.NoError(t, err)
+	return meta
+}
+
+// makeDataShred returns a legacy data shred.
+func makeDataShred(slot uint64, index uint32, parentOffset uint16, flags uint8) []byte {
+	raw := make([]byte, legacyDataShredSize)
+	raw[0x40] = shred.LegacyDataID
+	binary.LittleEndian.PutUint64(raw[0x41:], slot)
+	binary.LittleEndian.PutUint32(raw[0x49:], index)
+	binary.LittleEndian.PutUint16(raw[0x53:], parentOffset)
+	raw[0x55] = flags
+	binary.LittleEndian.PutUint16(raw[0x56:], shred.LegacyDataV2HeaderSize+1)
+	return raw
+}
+
+// legacyDataShredSize is the size of the legacy data shreds of tests.
+const legacyDataShredSize = 1228
+
+func TestInsertShreds_Mainnet(t *testing.T) {
+	const slot = 102815960
+	raw := fixtures.DataShreds(t, "mainnet", slot)
+	require.Len(t, raw, 1427)
+	store := newMemStore()
+
+	// insert in two batches, the second one completing the slot
+	reversed := make([][]byte, len(raw))
+	for i := range raw {
+		reversed[len(raw)-1-i] = raw[i]
+	}
+	stats := store.insert(t, reversed[:1000]...)
+	assert.Equal(t, 1000, stats.NumData)
+	assert.Empty(t, stats.CompletedSlots)
+	meta := store.slotMeta(t, slot)
+	assert.Equal(t, uint64(0), meta.Consumed)
+	assert.Equal(t, uint64(1427), meta.Received)
+	assert.Equal(t, uint64(1426), meta.LastIndex)
+	assert.False(t, meta.IsFull())
+
+	stats = store.insert(t, reversed[1000:]...)
+	assert.Equal(t, 427, stats.NumData)
+	assert.Equal(t, []uint64{slot}, stats.CompletedSlots)
+	meta = store.slotMeta(t, slot)
+	assert.True(t, meta.IsFull())
+	assert.Equal(t, uint64(1427), meta.Consumed)
+	assert.Equal(t, uint64(slot-1), meta.ParentSlot)
+	assert.Equal(t, mainnet_102815960_EntryEndIndexes, meta.EntryEndIndexes)
+	assert.NotZero(t, meta.FirstShredTimestamp)
+	assert.False(t, meta.IsConnected)
+
+	parent := store.slotMeta(t, slot-1)
+	assert.True(t, parent.IsOrphan())
+	assert.Equal(t, []uint64{slot}, parent.NextSlots)
+
+	// the slot reassembles from the metadata of the insertion
+	block, err := NewBlock(meta, parseShreds(t, raw, 2))
+	require.NoError(t, err)
+	assert.Len(t, block.Batches, len(mainnet_102815960_EntryEndIndexes))
+
+	stats = store.insert(t, raw[:10]...)
+	assert.Equal(t, 10, stats.NumDuplicate)
+	assert.Zero(t, stats.NumData)
+}
+
+func TestInsertShreds_Chaining(t *testing.T) {
+	store := newMemStore()
+
+	// slot 2 is received before its parent
+	stats := store.insert(t,
+		makeDataShred(2, 0, 1, shred.FlagDataEndOfBatch),
+		makeDataShred(2, 1, 1, shred.FlagDataEndOfBlock),
+		makeDataShred(3, 0, 1, shred.FlagDataEndOfBlock),
+	)
+	assert.Equal(t, []uint64{2, 3}, stats.CompletedSlots)
+	assert.Equal(t, []uint32{0, 1}, store.slotMeta(t, 2).EntryEndIndexes)
+	assert.False(t, store.slotMeta(t, 2).IsConnected)
+	orphan := store.slotMeta(t, 1)
+	assert.Equal(t, uint64(math.MaxUint64), orphan.ParentSlot)
+	assert.Equal(t, []uint64{2}, orphan.NextSlots)
+
+	stats = store.insert(t, makeDataShred(0, 0, 0, shred.FlagDataEndOfBlock))
+	assert.Equal(t, []uint64{0}, stats.CompletedSlots)
+	assert.True(t, store.slotMeta(t, 0).IsConnected)
+	assert.False(t, store.slotMeta(t, 1).IsConnected)
+
+	stats = store.insert(t,
+		makeDataShred(1, 0, 1, 0),
+		makeDataShred(1, 1, 1, shred.FlagDataEndOfBlock),
+	)
+	assert.Equal(t, []uint64{1}, stats.CompletedSlots)
+	meta := store.slotMeta(t, 1)
+	assert.Equal(t, uint64(0), meta.ParentSlot)
+	assert.Equal(t, []uint64{2}, meta.NextSlots)
+	assert.Equal(t, []uint64{1}, store.slotMeta(t, 0).NextSlots)
+	// slot 1 connects its children, and theirs, once full
+	for _, slot := range []uint64{1, 2, 3} {
+		assert.True(t, store.slotMeta(t, slot).IsConnected, "slot %d", slot)
+	}
+}
+
+func TestInsertShreds_Invalid(t *testing.T) {
+	store := newMemStore()
+	store.insert(t,
+		makeDataShred(5, 0, 1, 0),
+		makeDataShred(5, 2, 1, shred.FlagDataEndOfBlock),
+	)
+	stats := store.insert(t,
+		// beyond the last shred of the slot
+		makeDataShred(5, 3, 1, 0),
+		// a second last shred
+		makeDataShred(5, 1, 1, shred.FlagDataEndOfBlock),
+		// a different parent
+		makeDataShred(5, 1, 2, 0),
+		// a parent after the slot
+		makeDataShred(5, 1, 6, 0),
+		[]byte{0x00},
+	)
+	assert.Equal(t, 5, stats.NumInvalid)
+	assert.Zero(t, stats.NumData)
+	assert.Equal(t, []uint64{5}, stats.DuplicateSlots)
+	meta := store.slotMeta(t, 5)
+	assert.Equal(t, uint64(1), meta.Consumed)
+	assert.Equal(t, uint64(3), meta.Received)
+
+	stats = store.insert(t, makeDataShred(5, 1, 1, 0))
+	assert.Equal(t, []uint64{5}, stats.CompletedSlots)
+	assert.Equal(t, uint64(3), store.slotMeta(t, 5).Consumed)
+}
+
+func TestInsertShreds_Duplicate(t *testing.T) {
+	store := newMemStore()
+	original := makeDataShred(7, 0, 1, 0)
+	store.insert(t, original)
+
+	stats := store.insert(t, original)
+	assert.Equal(t, 1, stats.NumDuplicate)
+	assert.Empty(t, stats.DuplicateSlots)
+
+	conflicting := makeDataShred(7, 0, 1, 0)
+	conflicting[shred.LegacyDataV2HeaderSize] = 1
+	stats = store.insert(t, conflicting, conflicting)
+	assert.Equal(t, 2, stats.NumDuplicate)
+	assert.Equal(t, []uint64{7}, stats.DuplicateSlots)
+	proof, err := shred.ParseDuplicateSlotProof(store.duplicates[7])
+	require.NoError(t, err)
+	assert.Equal(t, &shred.DuplicateSlotProof{Shred1: original, Shred2: conflicting}, proof)
+
+	// the slot already has a proof
+	stats = store.insert(t, conflicting)
+	assert.Empty(t, stats.DuplicateSlots)
+}
+
+func TestInsertShreds_Code(t *testing.T) {
+	store := newMemStore()
+	code := fixtures.MerkleCodeShreds(t)
+	stats := store.insert(t, code...)
+	assert.Equal(t, len(code), stats.NumCode)
+	stats = store.insert(t, code[0])
+	assert.Equal(t, 1, stats.NumDuplicate)
+}
+
+func TestInsertShreds_Recovery(t *testing.T) {
+	data := fixtures.MerkleDataShreds(t)
+	code := fixtures.MerkleCodeShreds(t)
+
+	// the data shreds missing are recovered from the coding shreds
+	store := newMemStore()
+	stats := store.insert(t, data[:len(data)/2]...)
+	assert.Zero(t, stats.NumRecovered)
+	stats = store.insert(t, code...)
+	assert.Equal(t, len(code), stats.NumCode)
+	assert.Equal(t, len(data)-len(data)/2, stats.NumRecovered)
+	for _, raw := range data {
+		h := shred.NewShredFromSerialized(raw, shred.RevisionV2)
+		recovered, err := store.getDataShred(h.Slot, uint64(h.Index))
+		require.NoError(t, err)
+		assert.Equal(t, raw, recovered)
+	}
+
+	// complete erasure sets are left as is
+	stats = store.insert(t, data...)
+	assert.Equal(t, len(data), stats.NumDuplicate)
+	assert.Zero(t, stats.NumRecovered)
+}
+
+func TestSlotMeta_MarshalBincode(t *testing.T) {
+	meta := &SlotMeta{
+		Slot:                7,
+		Consumed:            3,
+		Received:            4,
+		FirstShredTimestamp: 1700000000000,
+		LastIndex:           math.MaxUint64,
+		ParentSlot:          6,
+		NumNextSlots:        2,
+		NextSlots:           []uint64{8, 9},
+		IsConnected:         true,
+		NumEntryEndIndexes:  2,
+		EntryEndIndexes:     []uint32{1, 2},
+	}
+	decoded, err := ParseBincode[SlotMeta](meta.MarshalBincode())
+	require.NoError(t, err)
+	assert.Equal(t, meta, decoded)
+}
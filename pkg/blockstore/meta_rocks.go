@@ -3,6 +3,7 @@
 package blockstore
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/linxGnu/grocksdb"
@@ -24,6 +25,20 @@ func (d *DB) MaxRoot() (uint64, error) {
 	return slot, nil
 }
 
+// IsRoot returns whether a slot was rooted.
+//
+// Based on solana_ledger::blockstore::Blockstore::is_root.
+func (d *DB) IsRoot(slot uint64) (bool, error) {
+	res, err := d.getSlotValue(d.CfRoot, slot)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	res.Free()
+	return true, nil
+}
+
 // GetSlotMeta returns the shredding metadata of a given slot.
 func (d *DB) GetSlotMeta(slot uint64) (*SlotMeta, error) {
 	key := MakeSlotKey(slot)
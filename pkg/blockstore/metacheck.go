@@ -0,0 +1,83 @@
+package blockstore
+
+import (
+	"fmt"
+	"math"
+)
+
+// SlotMetaError is a field of the meta of a slot that disagrees with the
+// data shreds of the slot at hand.
+type SlotMetaError struct {
+	Slot   uint64
+	Field  string // consumed, received, last_index or full
+	Stored uint64
+	Actual uint64
+}
+
+func (e *SlotMetaError) Error() string {
+	if e.Field == "full" {
+		if e.Stored != 0 {
+			return fmt.Sprintf("slot %d is full as per its meta, but its data shreds are incomplete", e.Slot)
+		}
+		return fmt.Sprintf("slot %d has all data shreds, but is not full as per its meta", e.Slot)
+	}
+	return fmt.Sprintf("slot %d has %s %s as per its meta, but %s as per its data shreds",
+		e.Slot, e.Field, formatOptionalIndex(e.Stored), formatOptionalIndex(e.Actual))
+}
+
+// formatOptionalIndex formats a shred index, which is none if it is
+// math.MaxUint64.
+func formatOptionalIndex(index uint64) string {
+	if index == math.MaxUint64 {
+		return "none"
+	}
+	return fmt.Sprint(index)
+}
+
+// ShredRange is a range [Start, End) of shred indexes.
+type ShredRange struct {
+	Start, End uint64
+}
+
+func (r ShredRange) String() string {
+	if r.End == r.Start+1 {
+		return fmt.Sprint(r.Start)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End-1)
+}
+
+// CheckSlotMeta compares the meta of a slot with the indexes of its data
+// shreds at hand in ascending order, and the index of the data shred
+// flagged as the last of the slot, or math.MaxUint64 if there is none. It
+// returns the fields of the meta that disagree, and the ranges of data
+// shreds missing before the last one at hand.
+func CheckSlotMeta(meta *SlotMeta, indexes []uint64, lastIndex uint64) (errs []*SlotMetaError, gaps []ShredRange) {
+	var consumed, received uint64
+	for _, index := range indexes {
+		if index > received {
+			gaps = append(gaps, ShredRange{Start: received, End: index})
+		}
+		if index == consumed {
+			consumed++
+		}
+		received = index + 1
+	}
+	full := lastIndex != math.MaxUint64 && consumed == lastIndex+1
+
+	check := func(field string, stored, actual uint64) {
+		if stored != actual {
+			errs = append(errs, &SlotMetaError{Slot: meta.Slot, Field: field, Stored: stored, Actual: actual})
+		}
+	}
+	check("consumed", meta.Consumed, consumed)
+	check("received", meta.Received, received)
+	check("last_index", meta.LastIndex, lastIndex)
+	if meta.IsFull() != full {
+		stored := uint64(0)
+		if meta.IsFull() {
+			stored = 1
+		}
+		errs = append(errs, &SlotMetaError{Slot: meta.Slot, Field: "full", Stored: stored, Actual: 1 - stored})
+	}
+	return errs, gaps
+}
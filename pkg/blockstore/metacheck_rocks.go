@@ -0,0 +1,50 @@
+//go:build !lite
+
+package blockstore
+
+import (
+	"math"
+
+	"github.com/linxGnu/grocksdb"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// GetDataShredIndexes returns the indexes of the data shreds of a slot at
+// hand in ascending order, and the index of the data shred flagged as the
+// last of the slot, or math.MaxUint64 if there is none.
+func (d *DB) GetDataShredIndexes(slot uint64) (indexes []uint64, lastIndex uint64, err error) {
+	opts := grocksdb.NewDefaultReadOptions()
+	opts.SetFillCache(false)
+	iter := d.DB.NewIteratorCF(opts, d.CfDataShred)
+	defer iter.Close()
+
+	lastIndex = math.MaxUint64
+	prefix := MakeSlotKey(slot)
+	for iter.Seek(prefix[:]); iter.ValidForPrefix(prefix[:]); iter.Next() {
+		key := iter.Key()
+		_, index, ok := ParseShredKey(key.Data())
+		key.Free()
+		if !ok {
+			continue
+		}
+		indexes = append(indexes, index)
+		value := iter.Value()
+		raw := value.Data()
+		if lastIndex == math.MaxUint64 && len(raw) > 0x55 && raw[0x55]&shred.FlagDataEndOfBlock == shred.FlagDataEndOfBlock {
+			lastIndex = index
+		}
+		value.Free()
+	}
+	return indexes, lastIndex, iter.Err()
+}
+
+// CheckSlotMeta compares the meta of a slot with its data shreds at hand,
+// see CheckSlotMeta.
+func (d *DB) CheckSlotMeta(meta *SlotMeta) ([]*SlotMetaError, []ShredRange, error) {
+	indexes, lastIndex, err := d.GetDataShredIndexes(meta.Slot)
+	if err != nil {
+		return nil, nil, err
+	}
+	errs, gaps := CheckSlotMeta(meta, indexes, lastIndex)
+	return errs, gaps, nil
+}
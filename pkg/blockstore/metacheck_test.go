@@ -0,0 +1,38 @@
+package blockstore
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSlotMeta(t *testing.T) {
+	meta := &SlotMeta{Slot: 9, Consumed: 4, Received: 4, LastIndex: 3}
+	errs, gaps := CheckSlotMeta(meta, []uint64{0, 1, 2, 3}, 3)
+	assert.Empty(t, errs)
+	assert.Empty(t, gaps)
+
+	// a slot marked full missing shreds
+	errs, gaps = CheckSlotMeta(meta, []uint64{0, 2, 5, 6}, math.MaxUint64)
+	assert.Equal(t, []*SlotMetaError{
+		{Slot: 9, Field: "consumed", Stored: 4, Actual: 1},
+		{Slot: 9, Field: "received", Stored: 4, Actual: 7},
+		{Slot: 9, Field: "last_index", Stored: 3, Actual: math.MaxUint64},
+		{Slot: 9, Field: "full", Stored: 1, Actual: 0},
+	}, errs)
+	assert.Equal(t, []ShredRange{{1, 2}, {3, 5}}, gaps)
+	assert.Equal(t, "1", gaps[0].String())
+	assert.Equal(t, "3-4", gaps[1].String())
+	assert.Equal(t, "slot 9 is full as per its meta, but its data shreds are incomplete", errs[3].Error())
+	assert.Equal(t, "slot 9 has last_index 3 as per its meta, but none as per its data shreds", errs[2].Error())
+
+	// a complete slot not marked full
+	meta = &SlotMeta{Slot: 9, Consumed: 1, Received: 2, LastIndex: math.MaxUint64}
+	errs, _ = CheckSlotMeta(meta, []uint64{0, 1}, 1)
+	assert.Equal(t, []*SlotMetaError{
+		{Slot: 9, Field: "consumed", Stored: 1, Actual: 2},
+		{Slot: 9, Field: "last_index", Stored: math.MaxUint64, Actual: 1},
+		{Slot: 9, Field: "full", Stored: 0, Actual: 1},
+	}, errs)
+}
@@ -0,0 +1,54 @@
+package blockstore
+
+import "encoding/binary"
+
+// slotColumnFamilies are the column families keyed by slot, or by slot and
+// index, which are purged by deleting a range of keys.
+//
+// Based on solana_ledger::blockstore::blockstore_purge::Blockstore::purge_range.
+var slotColumnFamilies = []string{
+	CfMeta,
+	CfErasureMeta,
+	CfRoot,
+	CfDataShred,
+	CfCodeShred,
+	CfDeadSlots,
+	CfDuplicateSlots,
+	CfOrphans,
+	CfIndex,
+	CfBankHash,
+	CfRewards,
+	CfBlockTime,
+	CfBlockHeight,
+	CfPerfSamples,
+	CfOptimisticSlots,
+}
+
+// signatureColumnFamilies are the column families keyed by signature or
+// address, with the slot in the middle of the key, which are purged by
+// scanning all keys.
+var signatureColumnFamilies = []string{
+	CfTxStatus,
+	CfAddressSig,
+	CfTxMemos,
+}
+
+// keySlot returns the slot of a key in one of slotColumnFamilies or
+// signatureColumnFamilies. Keys without a slot, such as memos of
+// blockstores written before they were keyed by slot, are never purged or
+// copied.
+func keySlot(cf string, key []byte) (slot uint64, ok bool) {
+	switch cf {
+	case CfTxStatus, CfTxMemos:
+		_, slot, ok = ParseTxStatusKey(key)
+	case CfAddressSig:
+		var as AddressSignature
+		as, ok = ParseAddressSigKey(key)
+		slot = as.Slot
+	default:
+		if ok = len(key) >= 8; ok {
+			slot = binary.BigEndian.Uint64(key[:8])
+		}
+	}
+	return
+}
@@ -0,0 +1,89 @@
+//go:build !lite
+
+package blockstore
+
+import (
+	"github.com/linxGnu/grocksdb"
+)
+
+// purgeBatchSize is the number of deletions of scanned keys per write.
+const purgeBatchSize = 4096
+
+// PurgeBefore deletes all data of slots below the given slot from every
+// column family of the blockstore. The disk space is only reclaimed by
+// compaction, see CompactBefore.
+//
+// The DB must be opened with OpenReadWrite.
+//
+// Based on solana_ledger::blockstore::blockstore_purge::Blockstore::purge_slots.
+func (d *DB) PurgeBefore(slot uint64) error {
+	end := MakeSlotKey(slot)
+	wo := grocksdb.NewDefaultWriteOptions()
+	defer wo.Destroy()
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	for _, name := range slotColumnFamilies {
+		if cf := d.handles[name]; cf != nil {
+			batch.DeleteRangeCF(cf, nil, end[:])
+		}
+	}
+	if err := d.DB.Write(wo, batch); err != nil {
+		return err
+	}
+	for _, name := range signatureColumnFamilies {
+		if cf := d.handles[name]; cf != nil {
+			if err := d.purgeScan(wo, name, cf, slot); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// purgeScan deletes the keys of slots below the given slot from a column
+// family in signatureColumnFamilies.
+func (d *DB) purgeScan(wo *grocksdb.WriteOptions, name string, cf *grocksdb.ColumnFamilyHandle, slot uint64) error {
+	opts := grocksdb.NewDefaultReadOptions()
+	defer opts.Destroy()
+	opts.SetFillCache(false)
+	iter := d.DB.NewIteratorCF(opts, cf)
+	defer iter.Close()
+
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		key := iter.Key()
+		if keySlot, ok := keySlot(name, key.Data()); ok && keySlot < slot {
+			batch.DeleteCF(cf, key.Data())
+		}
+		key.Free()
+		if batch.Count() >= purgeBatchSize {
+			if err := d.DB.Write(wo, batch); err != nil {
+				return err
+			}
+			batch.Clear()
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return d.DB.Write(wo, batch)
+}
+
+// CompactBefore compacts the key ranges deleted by PurgeBefore, reclaiming
+// their disk space.
+//
+// Based on solana_ledger::blockstore::blockstore_purge::Blockstore::compact_storage.
+func (d *DB) CompactBefore(slot uint64) {
+	end := MakeSlotKey(slot)
+	for _, name := range slotColumnFamilies {
+		if cf := d.handles[name]; cf != nil {
+			d.DB.CompactRangeCF(cf, grocksdb.Range{Limit: end[:]})
+		}
+	}
+	for _, name := range signatureColumnFamilies {
+		if cf := d.handles[name]; cf != nil {
+			d.DB.CompactRangeCF(cf, grocksdb.Range{})
+		}
+	}
+}
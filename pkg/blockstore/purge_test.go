@@ -0,0 +1,36 @@
+package blockstore
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySlot(t *testing.T) {
+	sig := solana.Signature{1}
+	txKey := MakeTxStatusKey(sig, 42)
+	addrKey := MakeAddressSigKey(solana.PublicKey{2}, 43, 1, sig)
+
+	slot, ok := keySlot(CfTxStatus, txKey[:])
+	assert.True(t, ok)
+	assert.Equal(t, uint64(42), slot)
+
+	slot, ok = keySlot(CfTxMemos, txKey[:])
+	assert.True(t, ok)
+	assert.Equal(t, uint64(42), slot)
+
+	slot, ok = keySlot(CfAddressSig, addrKey[:])
+	assert.True(t, ok)
+	assert.Equal(t, uint64(43), slot)
+
+	shredKey := MakeShredKey(44, 3)
+	slot, ok = keySlot(CfDataShred, shredKey[:])
+	assert.True(t, ok)
+	assert.Equal(t, uint64(44), slot)
+
+	_, ok = keySlot(CfTxMemos, sig[:])
+	assert.False(t, ok)
+	_, ok = keySlot(CfMeta, []byte{1})
+	assert.False(t, ok)
+}
@@ -0,0 +1,101 @@
+package blockstore
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/storageproto"
+)
+
+// ParseRewards decodes the values of CfRewards, which are protobuf encoded
+// solana.storage.ConfirmedBlock.Rewards messages, or bincode encoded
+// rewards in blockstores written before.
+//
+// Based on solana_ledger::blockstore_db::LedgerColumn::get_protobuf_or_bincode.
+func ParseRewards(data []byte) (*storageproto.Rewards, error) {
+	rewards, err := storageproto.ParseRewards(data)
+	if err == nil {
+		return rewards, nil
+	}
+	rewards, bincodeErr := parseBincodeRewards(data)
+	if bincodeErr != nil {
+		return nil, fmt.Errorf("invalid rewards: %w", err)
+	}
+	return rewards, nil
+}
+
+// parseBincodeRewards decodes a bincode encoded Vec<StoredExtendedReward>,
+// of which the reward types, unlike the protobuf ones, have no unspecified
+// variant.
+func parseBincodeRewards(data []byte) (*storageproto.Rewards, error) {
+	dec := bin.NewBinDecoder(data)
+	n, err := dec.ReadUint64(bin.LE)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(dec.Remaining()) {
+		return nil, fmt.Errorf("not enough bytes to read %d rewards", n)
+	}
+	rewards := &storageproto.Rewards{Rewards: make([]storageproto.Reward, n)}
+	for i := range rewards.Rewards {
+		reward := &rewards.Rewards[i]
+		pubkey, err := dec.ReadRustString()
+		if err != nil {
+			return nil, err
+		}
+		if reward.Pubkey, err = solana.PublicKeyFromBase58(pubkey); err != nil {
+			return nil, err
+		}
+		if reward.Lamports, err = dec.ReadInt64(bin.LE); err != nil {
+			return nil, err
+		}
+		if reward.PostBalance, err = dec.ReadUint64(bin.LE); err != nil {
+			return nil, err
+		}
+		hasType, err := dec.ReadBool()
+		if err != nil {
+			return nil, err
+		}
+		if hasType {
+			rewardType, err := dec.ReadUint32(bin.LE)
+			if err != nil {
+				return nil, err
+			}
+			reward.RewardType = storageproto.RewardType(rewardType + 1)
+		}
+		hasCommission, err := dec.ReadBool()
+		if err != nil {
+			return nil, err
+		}
+		if hasCommission {
+			commission, err := dec.ReadUint8()
+			if err != nil {
+				return nil, err
+			}
+			reward.Commission = &commission
+		}
+	}
+	if dec.Remaining() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes", dec.Remaining())
+	}
+	return rewards, nil
+}
+
+// ParseBlockTime decodes the values of CfBlockTime, the Unix timestamp of
+// a block.
+func ParseBlockTime(data []byte) (int64, error) {
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid block time of %d bytes", len(data))
+	}
+	return int64(binary.LittleEndian.Uint64(data)), nil
+}
+
+// ParseBlockHeight decodes the values of CfBlockHeight.
+func ParseBlockHeight(data []byte) (uint64, error) {
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid block height of %d bytes", len(data))
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
@@ -0,0 +1,102 @@
+//go:build !lite
+
+package blockstore
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/linxGnu/grocksdb"
+	"go.firedancer.io/radiance/pkg/storageproto"
+)
+
+// getSlotValue returns the value of a slot in a column family that might
+// be missing from older blockstores.
+func (d *DB) getSlotValue(cf *grocksdb.ColumnFamilyHandle, slot uint64) (*grocksdb.Slice, error) {
+	if cf == nil {
+		return nil, ErrNotFound
+	}
+	key := MakeSlotKey(slot)
+	res, err := d.DB.GetCF(grocksdb.NewDefaultReadOptions(), cf, key[:])
+	if err != nil {
+		return nil, err
+	}
+	if !res.Exists() {
+		res.Free()
+		return nil, ErrNotFound
+	}
+	return res, nil
+}
+
+func (d *DB) putSlotValue(cf *grocksdb.ColumnFamilyHandle, name string, slot uint64, value []byte) error {
+	if cf == nil {
+		return errors.New("missing column family " + name)
+	}
+	key := MakeSlotKey(slot)
+	return d.DB.PutCF(grocksdb.NewDefaultWriteOptions(), cf, key[:], value)
+}
+
+// GetRewards returns the rewards of a block.
+//
+// Based on solana_ledger::blockstore::Blockstore::read_rewards.
+func (d *DB) GetRewards(slot uint64) (*storageproto.Rewards, error) {
+	res, err := d.getSlotValue(d.CfRewards, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Free()
+	return ParseRewards(res.Data())
+}
+
+// GetBlockTime returns the Unix timestamp of a block.
+//
+// Based on solana_ledger::blockstore::Blockstore::get_block_time.
+func (d *DB) GetBlockTime(slot uint64) (int64, error) {
+	res, err := d.getSlotValue(d.CfBlockTime, slot)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Free()
+	return ParseBlockTime(res.Data())
+}
+
+// GetBlockHeight returns the number of blocks preceding a block.
+//
+// Based on solana_ledger::blockstore::Blockstore::get_block_height.
+func (d *DB) GetBlockHeight(slot uint64) (uint64, error) {
+	res, err := d.getSlotValue(d.CfBlockHeight, slot)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Free()
+	return ParseBlockHeight(res.Data())
+}
+
+// WriteRewards writes the rewards of a block.
+//
+// The DB must be opened with OpenReadWrite.
+//
+// Based on solana_ledger::blockstore::Blockstore::write_rewards.
+func (d *DB) WriteRewards(slot uint64, rewards *storageproto.Rewards) error {
+	return d.putSlotValue(d.CfRewards, CfRewards, slot, storageproto.AppendRewards(nil, rewards))
+}
+
+// WriteBlockTime writes the Unix timestamp of a block.
+//
+// The DB must be opened with OpenReadWrite.
+//
+// Based on solana_ledger::blockstore::Blockstore::cache_block_time.
+func (d *DB) WriteBlockTime(slot uint64, timestamp int64) error {
+	value := binary.LittleEndian.AppendUint64(nil, uint64(timestamp))
+	return d.putSlotValue(d.CfBlockTime, CfBlockTime, slot, value)
+}
+
+// WriteBlockHeight writes the number of blocks preceding a block.
+//
+// The DB must be opened with OpenReadWrite.
+//
+// Based on solana_ledger::blockstore::Blockstore::cache_block_height.
+func (d *DB) WriteBlockHeight(slot uint64, height uint64) error {
+	value := binary.LittleEndian.AppendUint64(nil, height)
+	return d.putSlotValue(d.CfBlockHeight, CfBlockHeight, slot, value)
+}
@@ -0,0 +1,56 @@
+package blockstore
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/storageproto"
+)
+
+func TestParseRewards(t *testing.T) {
+	commission := uint8(7)
+	rewards := &storageproto.Rewards{
+		Rewards: []storageproto.Reward{{
+			Pubkey:      solana.SysVarClockPubkey,
+			Lamports:    -3,
+			PostBalance: 10,
+			RewardType:  storageproto.RewardTypeStaking,
+			Commission:  &commission,
+		}},
+	}
+	parsed, err := ParseRewards(storageproto.AppendRewards(nil, rewards))
+	require.NoError(t, err)
+	assert.Equal(t, rewards, parsed)
+
+	// Vec<StoredExtendedReward>
+	pubkey := solana.SysVarClockPubkey.String()
+	data := binary.LittleEndian.AppendUint64(nil, 1)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(pubkey)))
+	data = append(data, pubkey...)
+	data = binary.LittleEndian.AppendUint64(data, ^uint64(2))
+	data = binary.LittleEndian.AppendUint64(data, 10)
+	data = append(data, 1, 2, 0, 0, 0)
+	data = append(data, 1, 7)
+	parsed, err = ParseRewards(data)
+	require.NoError(t, err)
+	assert.Equal(t, rewards, parsed)
+
+	_, err = ParseRewards(data[:len(data)-1])
+	assert.Error(t, err)
+}
+
+func TestParseBlockTime(t *testing.T) {
+	blockTime, err := ParseBlockTime(binary.LittleEndian.AppendUint64(nil, 1669852800))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1669852800), blockTime)
+
+	height, err := ParseBlockHeight(binary.LittleEndian.AppendUint64(nil, 150))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(150), height)
+
+	_, err = ParseBlockTime([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
@@ -27,6 +27,53 @@ func ParseShredKey(key []byte) (slot uint64, index uint64, ok bool) {
 	return
 }
 
+// RecoverDataShreds recovers the data shreds in [startIdx, endIdx) that are
+// missing from data, which maps the indexes of the data shreds of a slot at
+// hand to their serialized form, from the serialized coding shreds of the
+// slot. The recovered shreds are added to data. Erasure sets without
+// coding shreds are left as is.
+func RecoverDataShreds(data map[uint32][]byte, code [][]byte, startIdx, endIdx uint32) (numRecovered int, err error) {
+	codeSets := make(map[uint32][][]byte)
+	var fecSetIndexes []uint32
+	for _, raw := range code {
+		common, _, ok := shred.ParseCodeHeader(raw)
+		if !ok {
+			return numRecovered, fmt.Errorf("invalid coding shred")
+		}
+		if _, ok := codeSets[common.FECSetIndex]; !ok {
+			fecSetIndexes = append(fecSetIndexes, common.FECSetIndex)
+		}
+		codeSets[common.FECSetIndex] = append(codeSets[common.FECSetIndex], raw)
+	}
+
+	for _, fecSetIndex := range fecSetIndexes {
+		code := codeSets[fecSetIndex]
+		_, header, _ := shred.ParseCodeHeader(code[0])
+		setEnd := fecSetIndex + uint32(header.NumDataShreds)
+		var present [][]byte
+		missing := false
+		for i := fecSetIndex; i < setEnd; i++ {
+			if raw, ok := data[i]; ok {
+				present = append(present, raw)
+			} else if i >= startIdx && i < endIdx {
+				missing = true
+			}
+		}
+		if !missing {
+			continue
+		}
+		recovered, err := shred.RecoverDataShreds(present, code)
+		if err != nil {
+			return numRecovered, err
+		}
+		for _, raw := range recovered {
+			data[binary.LittleEndian.Uint32(raw[0x49:0x4d])] = raw
+		}
+		numRecovered += len(recovered)
+	}
+	return numRecovered, nil
+}
+
 type entryRange struct {
 	startIdx, endIdx uint32
 }
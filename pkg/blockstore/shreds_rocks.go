@@ -5,6 +5,7 @@ package blockstore
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
 
 	"github.com/linxGnu/grocksdb"
 	"go.firedancer.io/radiance/pkg/shred"
@@ -18,6 +19,22 @@ func (d *DB) GetEntries(meta *SlotMeta, shredRevision int) ([]Entries, error) {
 	return DataShredsToEntries(meta, shreds)
 }
 
+// GetBlock reassembles a full slot from its data shreds.
+func (d *DB) GetBlock(slot uint64) (*Block, error) {
+	meta, err := d.GetSlotMeta(slot)
+	if err != nil {
+		return nil, err
+	}
+	if !meta.IsFull() {
+		return nil, fmt.Errorf("slot %d is not full", slot)
+	}
+	shreds, err := d.GetDataShreds(slot, 0, uint32(meta.LastIndex+1), shred.RevisionV2)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlock(meta, shreds)
+}
+
 func (d *DB) GetAllDataShreds(slot uint64, revision int) ([]shred.Shred, error) {
 	return d.getAllShreds(d.CfDataShred, slot, revision)
 }
@@ -66,6 +83,102 @@ func GetDataShredsFromIter(
 	return shreds, nil
 }
 
+// GetDataShredsWithRecovery is like GetDataShreds, but recovers missing
+// data shreds from the coding shreds of the slot.
+func (d *DB) GetDataShredsWithRecovery(
+	slot uint64,
+	startIdx, endIdx uint32,
+	revision int,
+) (shreds []shred.Shred, numRecovered int, err error) {
+	data, err := d.getAllRawShreds(d.CfDataShred, slot)
+	if err != nil {
+		return nil, 0, err
+	}
+	missing := false
+	for i := startIdx; i < endIdx; i++ {
+		if _, ok := data[i]; !ok {
+			missing = true
+			break
+		}
+	}
+	if missing {
+		code, err := d.getAllRawShreds(d.CfCodeShred, slot)
+		if err != nil {
+			return nil, 0, err
+		}
+		codeShreds := make([][]byte, 0, len(code))
+		for _, raw := range code {
+			codeShreds = append(codeShreds, raw)
+		}
+		numRecovered, err = RecoverDataShreds(data, codeShreds, startIdx, endIdx)
+		if err != nil {
+			return nil, numRecovered, fmt.Errorf("cannot recover shreds of slot %d: %w", slot, err)
+		}
+	}
+
+	shreds = make([]shred.Shred, 0, endIdx-startIdx)
+	for i := startIdx; i < endIdx; i++ {
+		raw, ok := data[i]
+		if !ok {
+			return nil, numRecovered, fmt.Errorf("missing shred %d for slot %d", i, slot)
+		}
+		s := shred.NewShredFromSerialized(raw, revision)
+		if !s.Ok() {
+			return nil, numRecovered, fmt.Errorf("failed to deserialize shred %d/%d", slot, i)
+		}
+		shreds = append(shreds, s)
+	}
+	return shreds, numRecovered, nil
+}
+
+// VerifyChainedMerkleRoots checks that each erasure set of a slot chains to
+// the merkle root of the previous erasure set, starting with the last
+// erasure set of the parent slot, if at hand.
+func (d *DB) VerifyChainedMerkleRoots(meta *SlotMeta) ([]*shred.ChainedMerkleRootError, error) {
+	sets, err := d.getErasureSetShreds(meta.Slot)
+	if err != nil {
+		return nil, err
+	}
+	var prev []byte
+	if meta.ParentSlot != math.MaxUint64 {
+		parent, err := d.GetSlotMeta(meta.ParentSlot)
+		if err == nil && parent.LastIndex != math.MaxUint64 {
+			value, err := d.GetRawDataShred(meta.ParentSlot, parent.LastIndex)
+			if err == nil {
+				if value.Exists() {
+					prev = append([]byte(nil), value.Data()...)
+				}
+				value.Free()
+			}
+		}
+	}
+	return shred.VerifyChainedMerkleRoots(prev, sets)
+}
+
+// getErasureSetShreds returns the first data shred of each erasure set of a
+// slot at hand, ordered by erasure set.
+func (d *DB) getErasureSetShreds(slot uint64) ([][]byte, error) {
+	iter := d.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), d.CfDataShred)
+	defer iter.Close()
+	prefix := MakeSlotKey(slot)
+	iter.Seek(prefix[:])
+	var sets [][]byte
+	var fecSetIndex uint32
+	for ; iter.ValidForPrefix(prefix[:]); iter.Next() {
+		raw := iter.Value().Data()
+		if len(raw) < shred.CommonHeaderSize {
+			return nil, fmt.Errorf("invalid shred %x", iter.Key().Data())
+		}
+		index := binary.LittleEndian.Uint32(raw[0x4f:0x53])
+		if len(sets) != 0 && index == fecSetIndex {
+			continue
+		}
+		fecSetIndex = index
+		sets = append(sets, append([]byte(nil), raw...))
+	}
+	return sets, nil
+}
+
 func (d *DB) GetDataShred(slot, index uint64, revision int) shred.Shred {
 	return d.getShred(d.CfDataShred, slot, index, revision)
 }
@@ -75,7 +188,7 @@ func (d *DB) GetRawDataShred(slot, index uint64) (*grocksdb.Slice, error) {
 }
 
 func (d *DB) GetAllCodeShreds(slot uint64) ([]shred.Shred, error) {
-	return d.getAllShreds(d.CfDataShred, slot, shred.RevisionV2)
+	return d.getAllShreds(d.CfCodeShred, slot, shred.RevisionV2)
 }
 
 func (d *DB) GetCodeShred(slot, index uint64) shred.Shred {
@@ -128,3 +241,25 @@ func (d *DB) getAllShreds(
 	}
 	return shreds, nil
 }
+
+// getAllRawShreds returns copies of the serialized shreds of a slot, by
+// index.
+func (d *DB) getAllRawShreds(
+	cf *grocksdb.ColumnFamilyHandle,
+	slot uint64,
+) (map[uint32][]byte, error) {
+	iter := d.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), cf)
+	defer iter.Close()
+	prefix := MakeSlotKey(slot)
+	iter.Seek(prefix[:])
+	shreds := make(map[uint32][]byte)
+	for iter.ValidForPrefix(prefix[:]) {
+		_, index, ok := ParseShredKey(iter.Key().Data())
+		if !ok || index > math.MaxUint32 {
+			return nil, fmt.Errorf("invalid shred key %x", iter.Key().Data())
+		}
+		shreds[uint32(index)] = append([]byte(nil), iter.Value().Data()...)
+		iter.Next()
+	}
+	return shreds, nil
+}
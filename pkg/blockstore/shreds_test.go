@@ -444,6 +444,30 @@ func TestDataShredsToEntries_Mainnet_Recent(t *testing.T) {
 	}
 }
 
+func TestRecoverDataShreds_Mainnet(t *testing.T) {
+	rawShreds := fixtures.DataShreds(t, "mainnet", 102815960)
+	data := make(map[uint32][]byte, len(rawShreds))
+	for i, raw := range rawShreds {
+		data[uint32(i)] = raw
+	}
+	// erasure set 800 has 32 data shreds and 16 coding shreds at hand
+	for i := uint32(800); i < 810; i++ {
+		delete(data, i)
+	}
+	code := fixtures.CodeShreds(t, "mainnet", 102815960)
+
+	numRecovered, err := RecoverDataShreds(data, code, 0, 700)
+	require.NoError(t, err)
+	assert.Zero(t, numRecovered)
+
+	numRecovered, err = RecoverDataShreds(data, code, 0, uint32(len(rawShreds)))
+	require.NoError(t, err)
+	assert.Equal(t, 10, numRecovered)
+	for i, raw := range rawShreds {
+		assert.Equal(t, raw, data[uint32(i)], "shred %d", i)
+	}
+}
+
 func parseShreds(t testing.TB, raw [][]byte, version int) (shreds []shred.Shred) {
 	shreds = make([]shred.Shred, len(raw))
 	for i, buf := range raw {
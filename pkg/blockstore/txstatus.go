@@ -0,0 +1,178 @@
+package blockstore
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/storageproto"
+)
+
+// Sizes of the keys of CfTxStatus and CfAddressSig. Blockstores written
+// before the removal of the primary index prefix every key with the index,
+// a big-endian uint64 of 0 or 1.
+const (
+	TxStatusKeySize         = 64 + 8
+	LegacyTxStatusKeySize   = 8 + TxStatusKeySize
+	AddressSigKeySize       = 32 + 8 + 4 + 64
+	LegacyAddressSigKeySize = 8 + 32 + 8 + 64
+)
+
+const (
+	legacyPrimaryIndexCount  = 2
+	legacyPrimaryIndexLength = 8
+)
+
+// MakeTxStatusKey creates the RocksDB key for CfTxStatus.
+func MakeTxStatusKey(sig solana.Signature, slot uint64) (key [TxStatusKeySize]byte) {
+	copy(key[:64], sig[:])
+	binary.BigEndian.PutUint64(key[64:], slot)
+	return
+}
+
+// ParseTxStatusKey decodes the RocksDB keys in CfTxStatus, with or without
+// the primary index.
+func ParseTxStatusKey(key []byte) (sig solana.Signature, slot uint64, ok bool) {
+	switch len(key) {
+	case LegacyTxStatusKeySize:
+		key = key[legacyPrimaryIndexLength:]
+	case TxStatusKeySize:
+	default:
+		return sig, 0, false
+	}
+	copy(sig[:], key[:64])
+	return sig, binary.BigEndian.Uint64(key[64:]), true
+}
+
+// AddressSignature is a transaction referencing an address, as indexed in
+// CfAddressSig. The index of the transaction in its block is zero in
+// blockstores written before it was indexed.
+type AddressSignature struct {
+	Address   solana.PublicKey
+	Slot      uint64
+	Index     uint32
+	Signature solana.Signature
+	Writable  bool
+}
+
+// MakeAddressSigKey creates the RocksDB key for CfAddressSig.
+func MakeAddressSigKey(addr solana.PublicKey, slot uint64, index uint32, sig solana.Signature) (key [AddressSigKeySize]byte) {
+	copy(key[:32], addr[:])
+	binary.BigEndian.PutUint64(key[32:40], slot)
+	binary.BigEndian.PutUint32(key[40:44], index)
+	copy(key[44:], sig[:])
+	return
+}
+
+// ParseAddressSigKey decodes the RocksDB keys in CfAddressSig, with or
+// without the primary index.
+func ParseAddressSigKey(key []byte) (as AddressSignature, ok bool) {
+	switch len(key) {
+	case LegacyAddressSigKeySize:
+		key = key[legacyPrimaryIndexLength:]
+		copy(as.Address[:], key[:32])
+		as.Slot = binary.BigEndian.Uint64(key[32:40])
+		copy(as.Signature[:], key[40:])
+	case AddressSigKeySize:
+		copy(as.Address[:], key[:32])
+		as.Slot = binary.BigEndian.Uint64(key[32:40])
+		as.Index = binary.BigEndian.Uint32(key[40:44])
+		copy(as.Signature[:], key[44:])
+	default:
+		return as, false
+	}
+	return as, true
+}
+
+// legacyKeyPrefixes returns the prefixes of the keys starting with the
+// given bytes, with and without each primary index.
+func legacyKeyPrefixes(prefix []byte) [][]byte {
+	prefixes := [][]byte{prefix}
+	for i := uint64(0); i < legacyPrimaryIndexCount; i++ {
+		legacy := binary.BigEndian.AppendUint64(nil, i)
+		prefixes = append(prefixes, append(legacy, prefix...))
+	}
+	return prefixes
+}
+
+// Position returns the position of the transaction in the ledger.
+func (as *AddressSignature) Position() TxPosition {
+	return TxPosition{Slot: as.Slot, Index: as.Index}
+}
+
+// TxPosition is the position of a transaction in the ledger: its slot, and
+// its index in the block.
+type TxPosition struct {
+	Slot  uint64
+	Index uint32
+}
+
+// Less returns whether the transaction at p precedes the one at o.
+func (p TxPosition) Less(o TxPosition) bool {
+	if p.Slot != o.Slot {
+		return p.Slot < o.Slot
+	}
+	return p.Index < o.Index
+}
+
+// sortAddressSignatures orders the transactions of an address by slot and
+// index in the block.
+func sortAddressSignatures(sigs []AddressSignature) {
+	sort.Slice(sigs, func(i, j int) bool {
+		return sigs[i].Position().Less(sigs[j].Position())
+	})
+}
+
+// sortAddressSignaturesRev orders the transactions of an address newest
+// first.
+func sortAddressSignaturesRev(sigs []AddressSignature) {
+	sort.Slice(sigs, func(i, j int) bool {
+		return sigs[j].Position().Less(sigs[i].Position())
+	})
+}
+
+// TransactionStatus is the status meta of a transaction in a slot, as
+// stored in CfTxStatus.
+type TransactionStatus struct {
+	Slot uint64
+	Meta *sealevel.TransactionStatusMeta
+}
+
+func sortTransactionStatuses(statuses []TransactionStatus) {
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Slot < statuses[j].Slot
+	})
+}
+
+// ParseTransactionStatusMeta decodes the values of CfTxStatus, which are
+// protobuf encoded solana.storage.ConfirmedBlock.TransactionStatusMeta
+// messages.
+func ParseTransactionStatusMeta(data []byte) (*sealevel.TransactionStatusMeta, error) {
+	return storageproto.ParseTransactionStatusMeta(data)
+}
+
+type cell struct {
+	key, value []byte
+}
+
+// transactionStatusCells returns the cells of CfTxStatus and CfAddressSig
+// written for a transaction: its status meta, and its signature indexed by
+// each of its account keys.
+//
+// Based on solana_ledger::blockstore::Blockstore::write_transaction_status.
+func transactionStatusCells(
+	slot uint64,
+	index uint32,
+	sig solana.Signature,
+	writable, readonly []solana.PublicKey,
+	meta *sealevel.TransactionStatusMeta,
+) (status cell, addressSigs []cell, err error) {
+	value, err := storageproto.AppendTransactionStatusMeta(nil, meta)
+	if err != nil {
+		return status, nil, err
+	}
+	statusKey := MakeTxStatusKey(sig, slot)
+	status = cell{key: statusKey[:], value: value}
+	return status, addressSignatureCells(slot, index, sig, writable, readonly), nil
+}
@@ -0,0 +1,220 @@
+//go:build !lite
+
+package blockstore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/linxGnu/grocksdb"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func (d *DB) txStatusColumnFamilies() error {
+	if d.CfTxStatus == nil {
+		return errors.New("missing column family " + CfTxStatus)
+	}
+	if d.CfAddressSig == nil {
+		return errors.New("missing column family " + CfAddressSig)
+	}
+	return nil
+}
+
+// WriteTransactionStatus writes the status meta of the transaction at the
+// given index of a slot, and indexes its signature by its writable and
+// readonly account keys, including the addresses loaded from lookup
+// tables.
+//
+// The DB must be opened with OpenReadWrite.
+//
+// Based on solana_ledger::blockstore::Blockstore::write_transaction_status.
+func (d *DB) WriteTransactionStatus(
+	slot uint64,
+	index uint32,
+	sig solana.Signature,
+	writable, readonly []solana.PublicKey,
+	meta *sealevel.TransactionStatusMeta,
+) error {
+	if err := d.txStatusColumnFamilies(); err != nil {
+		return err
+	}
+	status, addressSigs, err := transactionStatusCells(slot, index, sig, writable, readonly, meta)
+	if err != nil {
+		return err
+	}
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	batch.PutCF(d.CfTxStatus, status.key, status.value)
+	for _, c := range addressSigs {
+		batch.PutCF(d.CfAddressSig, c.key, c.value)
+	}
+	return d.DB.Write(grocksdb.NewDefaultWriteOptions(), batch)
+}
+
+// GetTransactionStatuses returns the status metas of a transaction in each
+// slot it was recorded in, ordered by slot.
+func (d *DB) GetTransactionStatuses(sig solana.Signature) ([]TransactionStatus, error) {
+	if err := d.txStatusColumnFamilies(); err != nil {
+		return nil, err
+	}
+	iter := d.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), d.CfTxStatus)
+	defer iter.Close()
+
+	var statuses []TransactionStatus
+	for _, prefix := range legacyKeyPrefixes(sig[:]) {
+		for iter.Seek(prefix); iter.ValidForPrefix(prefix); iter.Next() {
+			key := iter.Key()
+			keySig, slot, ok := ParseTxStatusKey(key.Data())
+			key.Free()
+			if !ok || keySig != sig {
+				continue
+			}
+			value := iter.Value()
+			meta, err := ParseTransactionStatusMeta(value.Data())
+			value.Free()
+			if err != nil {
+				return nil, fmt.Errorf("invalid status of transaction %s in slot %d: %w", sig, slot, err)
+			}
+			statuses = append(statuses, TransactionStatus{Slot: slot, Meta: meta})
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sortTransactionStatuses(statuses)
+	return statuses, nil
+}
+
+// GetTransactionStatus returns the status meta of a transaction in the
+// first slot it was recorded in.
+func (d *DB) GetTransactionStatus(sig solana.Signature) (*TransactionStatus, error) {
+	statuses, err := d.GetTransactionStatuses(sig)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return nil, ErrNotFound
+	}
+	return &statuses[0], nil
+}
+
+// GetTransactionStatusInSlot returns the status meta of a transaction in
+// a slot, or ErrNotFound if it has none.
+func (d *DB) GetTransactionStatusInSlot(sig solana.Signature, slot uint64) (*sealevel.TransactionStatusMeta, error) {
+	if d.CfTxStatus == nil {
+		return nil, errors.New("missing column family " + CfTxStatus)
+	}
+	slotKey := MakeSlotKey(slot)
+	opts := grocksdb.NewDefaultReadOptions()
+	for _, prefix := range legacyKeyPrefixes(sig[:]) {
+		key := append(append([]byte(nil), prefix...), slotKey[:]...)
+		res, err := d.DB.GetCF(opts, d.CfTxStatus, key)
+		if err != nil {
+			return nil, err
+		}
+		if !res.Exists() {
+			res.Free()
+			continue
+		}
+		meta, err := ParseTransactionStatusMeta(res.Data())
+		res.Free()
+		if err != nil {
+			return nil, fmt.Errorf("invalid status of transaction %s in slot %d: %w", sig, slot, err)
+		}
+		return meta, nil
+	}
+	return nil, ErrNotFound
+}
+
+// GetAddressSignatures returns the transactions referencing an address in
+// the slots [startSlot, endSlot], ordered by slot and index in the block.
+//
+// Based on solana_ledger::blockstore::Blockstore::find_address_signatures.
+func (d *DB) GetAddressSignatures(addr solana.PublicKey, startSlot, endSlot uint64) ([]AddressSignature, error) {
+	if err := d.txStatusColumnFamilies(); err != nil {
+		return nil, err
+	}
+	iter := d.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), d.CfAddressSig)
+	defer iter.Close()
+
+	start := MakeSlotKey(startSlot)
+	var sigs []AddressSignature
+	for _, prefix := range legacyKeyPrefixes(addr[:]) {
+		for iter.Seek(append(append([]byte{}, prefix...), start[:]...)); iter.ValidForPrefix(prefix); iter.Next() {
+			key := iter.Key()
+			as, ok := ParseAddressSigKey(key.Data())
+			key.Free()
+			if !ok || as.Address != addr {
+				continue
+			}
+			if as.Slot > endSlot {
+				break
+			}
+			value := iter.Value()
+			as.Writable = len(value.Data()) != 0 && value.Data()[0] != 0
+			value.Free()
+			sigs = append(sigs, as)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sortAddressSignatures(sigs)
+	return sigs, nil
+}
+
+// GetAddressSignaturesRev returns up to limit transactions referencing an
+// address, newest first, that precede the position before and follow the
+// position until. Either bound may be nil.
+//
+// Based on solana_ledger::blockstore::Blockstore::get_confirmed_signatures_for_address2.
+func (d *DB) GetAddressSignaturesRev(addr solana.PublicKey, before, until *TxPosition, limit int) ([]AddressSignature, error) {
+	if err := d.txStatusColumnFamilies(); err != nil {
+		return nil, err
+	}
+	iter := d.DB.NewIteratorCF(grocksdb.NewDefaultReadOptions(), d.CfAddressSig)
+	defer iter.Close()
+
+	var sigs []AddressSignature
+	for _, prefix := range legacyKeyPrefixes(addr[:]) {
+		// seek to the last key of the address in the slot of before, in
+		// either key layout
+		end := append([]byte{}, prefix...)
+		if before != nil {
+			slotKey := MakeSlotKey(before.Slot)
+			end = append(end, slotKey[:]...)
+		}
+		for len(end) < len(prefix)+AddressSigKeySize-32 {
+			end = append(end, 0xff)
+		}
+		var numFound int
+		for iter.SeekForPrev(end); iter.ValidForPrefix(prefix) && numFound < limit; iter.Prev() {
+			key := iter.Key()
+			as, ok := ParseAddressSigKey(key.Data())
+			key.Free()
+			if !ok || as.Address != addr {
+				continue
+			}
+			if before != nil && !as.Position().Less(*before) {
+				continue
+			}
+			if until != nil && !until.Less(as.Position()) {
+				break
+			}
+			value := iter.Value()
+			as.Writable = len(value.Data()) != 0 && value.Data()[0] != 0
+			value.Free()
+			sigs = append(sigs, as)
+			numFound++
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sortAddressSignaturesRev(sigs)
+	if len(sigs) > limit {
+		sigs = sigs[:limit]
+	}
+	return sigs, nil
+}
@@ -0,0 +1,107 @@
+package blockstore
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func TestTxStatusKey(t *testing.T) {
+	sig := solana.Signature{1, 2, 3}
+	key := MakeTxStatusKey(sig, 0x0102)
+	parsedSig, slot, ok := ParseTxStatusKey(key[:])
+	require.True(t, ok)
+	assert.Equal(t, sig, parsedSig)
+	assert.Equal(t, uint64(0x0102), slot)
+
+	legacy := append(binary.BigEndian.AppendUint64(nil, 1), key[:]...)
+	parsedSig, slot, ok = ParseTxStatusKey(legacy)
+	require.True(t, ok)
+	assert.Equal(t, sig, parsedSig)
+	assert.Equal(t, uint64(0x0102), slot)
+
+	_, _, ok = ParseTxStatusKey(key[1:])
+	assert.False(t, ok)
+}
+
+func TestAddressSigKey(t *testing.T) {
+	addr := solana.PublicKey{9}
+	sig := solana.Signature{1, 2, 3}
+	key := MakeAddressSigKey(addr, 7, 3, sig)
+	as, ok := ParseAddressSigKey(key[:])
+	require.True(t, ok)
+	assert.Equal(t, AddressSignature{Address: addr, Slot: 7, Index: 3, Signature: sig}, as)
+
+	legacy := binary.BigEndian.AppendUint64(nil, 0)
+	legacy = append(legacy, addr[:]...)
+	legacy = binary.BigEndian.AppendUint64(legacy, 7)
+	legacy = append(legacy, sig[:]...)
+	as, ok = ParseAddressSigKey(legacy)
+	require.True(t, ok)
+	assert.Equal(t, AddressSignature{Address: addr, Slot: 7, Signature: sig}, as)
+
+	assert.Equal(t, [][]byte{
+		addr[:],
+		append(make([]byte, 8), addr[:]...),
+		append([]byte{0, 0, 0, 0, 0, 0, 0, 1}, addr[:]...),
+	}, legacyKeyPrefixes(addr[:]))
+}
+
+func TestTransactionStatusCells(t *testing.T) {
+	sig := solana.Signature{1}
+	writable := []solana.PublicKey{{2}, {3}}
+	readonly := []solana.PublicKey{{4}}
+	meta := &sealevel.TransactionStatusMeta{
+		Err:          sealevel.TxErrInsufficientFundsForRent{AccountIndex: 1},
+		Fee:          5000,
+		PreBalances:  []uint64{10, 0, 1},
+		PostBalances: []uint64{5, 0, 1},
+		LogMessages:  []string{},
+	}
+	status, addressSigs, err := transactionStatusCells(9, 2, sig, writable, readonly, meta)
+	require.NoError(t, err)
+
+	_, slot, ok := ParseTxStatusKey(status.key)
+	require.True(t, ok)
+	assert.Equal(t, uint64(9), slot)
+	parsed, err := ParseTransactionStatusMeta(status.value)
+	require.NoError(t, err)
+	assert.Equal(t, meta, parsed)
+
+	require.Len(t, addressSigs, 3)
+	var sigs []AddressSignature
+	for _, c := range addressSigs {
+		as, ok := ParseAddressSigKey(c.key)
+		require.True(t, ok)
+		as.Writable = c.value[0] != 0
+		sigs = append(sigs, as)
+	}
+	assert.Equal(t, []AddressSignature{
+		{Address: writable[0], Slot: 9, Index: 2, Signature: sig, Writable: true},
+		{Address: writable[1], Slot: 9, Index: 2, Signature: sig, Writable: true},
+		{Address: readonly[0], Slot: 9, Index: 2, Signature: sig},
+	}, sigs)
+}
+
+func TestSortAddressSignaturesRev(t *testing.T) {
+	sigs := []AddressSignature{
+		{Slot: 2, Index: 1},
+		{Slot: 3, Index: 0},
+		{Slot: 2, Index: 4},
+		{Slot: 1, Index: 9},
+	}
+	sortAddressSignaturesRev(sigs)
+	assert.Equal(t, []AddressSignature{
+		{Slot: 3, Index: 0},
+		{Slot: 2, Index: 4},
+		{Slot: 2, Index: 1},
+		{Slot: 1, Index: 9},
+	}, sigs)
+
+	assert.True(t, TxPosition{Slot: 2, Index: 4}.Less(TxPosition{Slot: 3}))
+	assert.False(t, TxPosition{Slot: 2, Index: 4}.Less(TxPosition{Slot: 2, Index: 4}))
+}
@@ -0,0 +1,95 @@
+// Package car writes content addressable archives (CARv1) of DAG-CBOR
+// encoded IPLD nodes, as used by the old-faithful ledger archives.
+package car
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"io"
+)
+
+// Multicodec codes of the CIDs written.
+const (
+	CodecDagCBOR    = 0x71
+	MultihashSHA256 = 0x12
+)
+
+// CIDSize is the size of a binary CIDv1 of a DAG-CBOR node.
+const CIDSize = 4 + sha256.Size
+
+// CID is a binary CIDv1 of a DAG-CBOR node, addressed by its SHA-256 hash.
+type CID [CIDSize]byte
+
+// NewCID returns the CID of a DAG-CBOR encoded node.
+func NewCID(data []byte) (c CID) {
+	c[0] = 1 // version
+	c[1] = CodecDagCBOR
+	c[2] = MultihashSHA256
+	c[3] = sha256.Size
+	digest := sha256.Sum256(data)
+	copy(c[4:], digest[:])
+	return
+}
+
+var base32Lower = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// String returns the multibase base32 representation of the CID.
+func (c CID) String() string {
+	return "b" + base32Lower.EncodeToString(c[:])
+}
+
+// HeaderSize is the size of the header of an archive with a single root.
+var HeaderSize = len(EncodeHeader(CID{}))
+
+// EncodeHeader returns the header of an archive with a single root, which
+// is of constant size, so that the root may be filled in last.
+func EncodeHeader(root CID) []byte {
+	var header []byte
+	header = appendMapHeader(header, 2)
+	header = appendString(header, "roots")
+	header = appendArrayHeader(header, 1)
+	header = appendLink(header, root)
+	header = appendString(header, "version")
+	header = appendUint(header, 1)
+
+	out := binary.AppendUvarint(nil, uint64(len(header)))
+	return append(out, header...)
+}
+
+// Writer writes the nodes of an archive.
+type Writer struct {
+	w       io.Writer
+	written int64
+}
+
+// NewWriter starts an archive of the given root.
+func NewWriter(w io.Writer, root CID) (*Writer, error) {
+	cw := &Writer{w: w}
+	if err := cw.write(EncodeHeader(root)); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Put writes a DAG-CBOR encoded node, and returns its CID.
+func (w *Writer) Put(node []byte) (CID, error) {
+	c := NewCID(node)
+	section := binary.AppendUvarint(nil, uint64(CIDSize+len(node)))
+	section = append(section, c[:]...)
+	if err := w.write(section); err != nil {
+		return c, err
+	}
+	return c, w.write(node)
+}
+
+// Written returns the number of bytes written so far.
+func (w *Writer) Written() int64 {
+	return w.written
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.written += int64(n)
+	return err
+}
@@ -0,0 +1,112 @@
+package car
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// readSections returns the header and the nodes of an archive, checking
+// the CID of each node.
+func readSections(t *testing.T, data []byte) (header []byte, nodes [][]byte, cids []CID) {
+	r := bytes.NewReader(data)
+	first := true
+	for r.Len() != 0 {
+		size, err := binary.ReadUvarint(r)
+		require.NoError(t, err)
+		section := make([]byte, size)
+		_, err = r.Read(section)
+		require.NoError(t, err)
+		if first {
+			header, first = section, false
+			continue
+		}
+		var c CID
+		copy(c[:], section)
+		require.Equal(t, NewCID(section[CIDSize:]), c)
+		nodes = append(nodes, section[CIDSize:])
+		cids = append(cids, c)
+	}
+	return
+}
+
+func TestCID(t *testing.T) {
+	assert.Equal(t, "bafyreigbtj4x7ip5legnfznufuopl4sg4knzc2cof6duas4b3q2fy6swua",
+		NewCID(appendMapHeader(nil, 0)).String())
+}
+
+func TestCBOR(t *testing.T) {
+	assert.Equal(t, []byte{0x17}, appendUint(nil, 23))
+	assert.Equal(t, []byte{0x18, 0x18}, appendUint(nil, 24))
+	assert.Equal(t, []byte{0x19, 0x01, 0x00}, appendUint(nil, 256))
+	assert.Equal(t, []byte{0x1a, 0x00, 0x01, 0x00, 0x00}, appendUint(nil, 1<<16))
+	assert.Equal(t, []byte{0x1b, 0, 0, 0, 1, 0, 0, 0, 0}, appendUint(nil, 1<<32))
+	assert.Equal(t, []byte{0x20}, appendInt(nil, -1))
+	assert.Equal(t, []byte{0x38, 0x63}, appendInt(nil, -100))
+	assert.Equal(t, []byte{0x43, 1, 2, 3}, appendBytes(nil, []byte{1, 2, 3}))
+	assert.Equal(t, []byte{0x61, 'a'}, appendString(nil, "a"))
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	root := NewCID([]byte{0x80})
+	w, err := NewWriter(&buf, root)
+	require.NoError(t, err)
+	assert.Equal(t, int64(HeaderSize), w.Written())
+	c, err := w.Put([]byte{0x80})
+	require.NoError(t, err)
+	assert.Equal(t, root, c)
+
+	header, nodes, _ := readSections(t, buf.Bytes())
+	expected := []byte{0xa2, 0x65, 'r', 'o', 'o', 't', 's', 0x81, 0xd8, 0x2a, 0x58, 0x25, 0x00}
+	expected = append(expected, root[:]...)
+	expected = append(expected, 0x67, 'v', 'e', 'r', 's', 'i', 'o', 'n', 0x01)
+	assert.Equal(t, expected, header)
+	assert.Equal(t, [][]byte{{0x80}}, nodes)
+	assert.Equal(t, int64(buf.Len()), w.Written())
+}
+
+func TestEpochWriter(t *testing.T) {
+	rawShreds := fixtures.DataShreds(t, "mainnet", 0)
+	shreds := make([]shred.Shred, len(rawShreds))
+	for i, raw := range rawShreds {
+		shreds[i] = shred.NewShredFromSerialized(raw, shred.RevisionV1)
+	}
+	block, err := blockstore.NewBlock(&blockstore.SlotMeta{
+		Consumed:           3,
+		Received:           3,
+		LastIndex:          2,
+		NumEntryEndIndexes: 1,
+		EntryEndIndexes:    []uint32{2},
+	}, shreds)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, CID{})
+	require.NoError(t, err)
+	ew := NewEpochWriter(w, 0, 1)
+	require.NoError(t, ew.WriteBlock(block, &BlockMeta{}))
+	assert.Error(t, ew.WriteBlock(block, &BlockMeta{}))
+	root, err := ew.Finish()
+	require.NoError(t, err)
+
+	_, nodes, cids := readSections(t, buf.Bytes())
+	numEntries := len(block.Entries())
+	// entries, rewards, block, subset and epoch
+	require.Len(t, nodes, numEntries+4)
+	assert.Equal(t, root, cids[len(cids)-1])
+	assert.Equal(t, EncodeEpoch(0, cids[len(cids)-2:len(cids)-1]), nodes[len(nodes)-1])
+	assert.Equal(t, EncodeSubset(0, 0, cids[len(cids)-3:len(cids)-2]), nodes[len(nodes)-2])
+	blockNode := EncodeBlock(0,
+		[]Shredding{{EntryEndIdx: numEntries - 1, ShredEndIdx: 2}},
+		cids[:numEntries], &BlockMeta{}, cids[numEntries])
+	assert.Equal(t, blockNode, nodes[numEntries+1])
+	assert.Equal(t, byte(0x86), blockNode[0])
+	assert.Equal(t, byte(KindBlock), blockNode[1])
+}
@@ -0,0 +1,85 @@
+package car
+
+// The subset of DAG-CBOR needed to encode the nodes of the archives.
+
+// CBOR major types.
+const (
+	majorUint   = 0
+	majorNegint = 1
+	majorBytes  = 2
+	majorString = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+)
+
+// tagLink is the CBOR tag of IPLD links.
+const tagLink = 42
+
+const cborNull = 0xf6
+
+func appendHead(b []byte, major byte, v uint64) []byte {
+	major <<= 5
+	switch {
+	case v < 24:
+		return append(b, major|byte(v))
+	case v <= 0xff:
+		return append(b, major|24, byte(v))
+	case v <= 0xffff:
+		return append(b, major|25, byte(v>>8), byte(v))
+	case v <= 0xffff_ffff:
+		return append(b, major|26, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	default:
+		return append(b, major|27,
+			byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32),
+			byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+}
+
+func appendUint(b []byte, v uint64) []byte {
+	return appendHead(b, majorUint, v)
+}
+
+func appendInt(b []byte, v int64) []byte {
+	if v < 0 {
+		return appendHead(b, majorNegint, uint64(-1-v))
+	}
+	return appendHead(b, majorUint, uint64(v))
+}
+
+func appendBytes(b []byte, v []byte) []byte {
+	return append(appendHead(b, majorBytes, uint64(len(v))), v...)
+}
+
+func appendString(b []byte, v string) []byte {
+	return append(appendHead(b, majorString, uint64(len(v))), v...)
+}
+
+func appendArrayHeader(b []byte, n int) []byte {
+	return appendHead(b, majorArray, uint64(n))
+}
+
+func appendMapHeader(b []byte, n int) []byte {
+	return appendHead(b, majorMap, uint64(n))
+}
+
+func appendNull(b []byte) []byte {
+	return append(b, cborNull)
+}
+
+// appendLink appends a link to a node, which is the binary CID with the
+// identity multibase prefix.
+func appendLink(b []byte, c CID) []byte {
+	b = appendHead(b, majorTag, tagLink)
+	b = appendHead(b, majorBytes, CIDSize+1)
+	b = append(b, 0x00)
+	return append(b, c[:]...)
+}
+
+func appendLinks(b []byte, links []CID) []byte {
+	b = appendArrayHeader(b, len(links))
+	for _, c := range links {
+		b = appendLink(b, c)
+	}
+	return b
+}
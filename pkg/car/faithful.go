@@ -0,0 +1,233 @@
+package car
+
+import (
+	"fmt"
+
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// Kinds of the nodes of old-faithful archives, the first field of each
+// node.
+const (
+	KindTransaction = 0
+	KindEntry       = 1
+	KindBlock       = 2
+	KindSubset      = 3
+	KindEpoch       = 4
+	KindRewards     = 5
+	KindDataFrame   = 6
+)
+
+// The nodes of old-faithful archives are encoded as tuples, in which
+// absent optional fields are null.
+
+// appendDataFrame appends a data frame holding all of the data.
+func appendDataFrame(b []byte, data []byte) []byte {
+	b = appendArrayHeader(b, 6)
+	b = appendUint(b, KindDataFrame)
+	b = appendNull(b) // hash
+	b = appendNull(b) // index
+	b = appendNull(b) // total
+	b = appendBytes(b, data)
+	return appendNull(b) // next
+}
+
+// EncodeTransaction returns the node of a serialized transaction, with
+// its serialized status meta if known, and its index in its block.
+func EncodeTransaction(data, metadata []byte, slot uint64, index int) []byte {
+	var b []byte
+	b = appendArrayHeader(b, 5)
+	b = appendUint(b, KindTransaction)
+	b = appendDataFrame(b, data)
+	b = appendDataFrame(b, metadata)
+	b = appendUint(b, slot)
+	return appendInt(b, int64(index))
+}
+
+// EncodeEntry returns the node of an entry.
+func EncodeEntry(entry *shred.Entry, txns []CID) []byte {
+	var b []byte
+	b = appendArrayHeader(b, 4)
+	b = appendUint(b, KindEntry)
+	b = appendUint(b, entry.NumHashes)
+	b = appendBytes(b, entry.Hash[:])
+	return appendLinks(b, txns)
+}
+
+// Shredding marks the end of a batch of entries of a block: the index of
+// the last entry of the batch in the block, and of its last data shred.
+type Shredding struct {
+	EntryEndIdx int
+	ShredEndIdx int
+}
+
+// BlockMeta is the metadata of a block. Unknown block times are zero.
+type BlockMeta struct {
+	ParentSlot  uint64
+	BlockTime   int64
+	BlockHeight *uint64
+}
+
+// EncodeBlock returns the node of a block.
+func EncodeBlock(slot uint64, shredding []Shredding, entries []CID, meta *BlockMeta, rewards CID) []byte {
+	var b []byte
+	b = appendArrayHeader(b, 6)
+	b = appendUint(b, KindBlock)
+	b = appendUint(b, slot)
+	b = appendArrayHeader(b, len(shredding))
+	for _, s := range shredding {
+		b = appendArrayHeader(b, 2)
+		b = appendInt(b, int64(s.EntryEndIdx))
+		b = appendInt(b, int64(s.ShredEndIdx))
+	}
+	b = appendLinks(b, entries)
+	b = appendArrayHeader(b, 3)
+	b = appendUint(b, meta.ParentSlot)
+	b = appendInt(b, meta.BlockTime)
+	if meta.BlockHeight != nil {
+		b = appendUint(b, *meta.BlockHeight)
+	} else {
+		b = appendNull(b)
+	}
+	return appendLink(b, rewards)
+}
+
+// EncodeRewards returns the node of the serialized rewards of a block.
+func EncodeRewards(slot uint64, data []byte) []byte {
+	var b []byte
+	b = appendArrayHeader(b, 3)
+	b = appendUint(b, KindRewards)
+	b = appendUint(b, slot)
+	return appendDataFrame(b, data)
+}
+
+// EncodeSubset returns the node of a range of blocks.
+func EncodeSubset(first, last uint64, blocks []CID) []byte {
+	var b []byte
+	b = appendArrayHeader(b, 4)
+	b = appendUint(b, KindSubset)
+	b = appendUint(b, first)
+	b = appendUint(b, last)
+	return appendLinks(b, blocks)
+}
+
+// EncodeEpoch returns the node of an epoch, the root of its archive.
+func EncodeEpoch(epoch uint64, subsets []CID) []byte {
+	var b []byte
+	b = appendArrayHeader(b, 3)
+	b = appendUint(b, KindEpoch)
+	b = appendUint(b, epoch)
+	return appendLinks(b, subsets)
+}
+
+// EpochWriter writes the blocks of an epoch to an old-faithful archive:
+// the nodes of each block follow the nodes they link to, subsets of
+// blocks follow their blocks, and the epoch comes last.
+type EpochWriter struct {
+	car        *Writer
+	epoch      uint64
+	subsetSize int
+
+	numBlocks   int
+	blocks      []CID
+	first, last uint64
+	subsets     []CID
+}
+
+// NewEpochWriter returns a writer of the blocks of an epoch, in subsets
+// of up to subsetSize blocks.
+func NewEpochWriter(car *Writer, epoch uint64, subsetSize int) *EpochWriter {
+	if subsetSize <= 0 {
+		subsetSize = 1
+	}
+	return &EpochWriter{
+		car:        car,
+		epoch:      epoch,
+		subsetSize: subsetSize,
+	}
+}
+
+// WriteBlock writes a block and its transactions and entries. Blocks must
+// be written in ascending order. The status metas of the transactions and
+// the rewards of the block are left empty.
+func (e *EpochWriter) WriteBlock(block *blockstore.Block, meta *BlockMeta) error {
+	if e.numBlocks != 0 && block.Slot <= e.last {
+		return fmt.Errorf("block %d written after block %d", block.Slot, e.last)
+	}
+
+	var entries []CID
+	var shredding []Shredding
+	var txIndex int
+	for _, batch := range block.Batches {
+		for i := range batch.Entries {
+			entry := &batch.Entries[i]
+			txns := make([]CID, len(entry.Txns))
+			for j := range entry.Txns {
+				data, err := entry.Txns[j].MarshalBinary()
+				if err != nil {
+					return fmt.Errorf("cannot serialize transaction %d of block %d: %w", txIndex, block.Slot, err)
+				}
+				c, err := e.car.Put(EncodeTransaction(data, nil, block.Slot, txIndex))
+				if err != nil {
+					return err
+				}
+				txns[j] = c
+				txIndex++
+			}
+			c, err := e.car.Put(EncodeEntry(entry, txns))
+			if err != nil {
+				return err
+			}
+			entries = append(entries, c)
+		}
+		if len(batch.Shreds) != 0 {
+			shredding = append(shredding, Shredding{
+				EntryEndIdx: len(entries) - 1,
+				ShredEndIdx: int(batch.Shreds[len(batch.Shreds)-1].Index),
+			})
+		}
+	}
+
+	rewards, err := e.car.Put(EncodeRewards(block.Slot, nil))
+	if err != nil {
+		return err
+	}
+	c, err := e.car.Put(EncodeBlock(block.Slot, shredding, entries, meta, rewards))
+	if err != nil {
+		return err
+	}
+
+	if len(e.blocks) == 0 {
+		e.first = block.Slot
+	}
+	e.last = block.Slot
+	e.numBlocks++
+	e.blocks = append(e.blocks, c)
+	if len(e.blocks) >= e.subsetSize {
+		return e.flushSubset()
+	}
+	return nil
+}
+
+func (e *EpochWriter) flushSubset() error {
+	if len(e.blocks) == 0 {
+		return nil
+	}
+	c, err := e.car.Put(EncodeSubset(e.first, e.last, e.blocks))
+	if err != nil {
+		return err
+	}
+	e.subsets = append(e.subsets, c)
+	e.blocks = e.blocks[:0]
+	return nil
+}
+
+// Finish writes the last subset and the epoch, and returns the CID of the
+// epoch, which is the root of the archive.
+func (e *EpochWriter) Finish() (CID, error) {
+	if err := e.flushSubset(); err != nil {
+		return CID{}, err
+	}
+	return e.car.Put(EncodeEpoch(e.epoch, e.subsets))
+}
@@ -0,0 +1,42 @@
+package features
+
+import "encoding/binary"
+
+// AccountSize is the size of the data of a feature account.
+const AccountSize = 9
+
+// ActivatedAt decodes the data of a feature account, which holds the slot
+// at which the feature was activated, if it was. ok is false if the data is
+// malformed.
+//
+// Based on solana_feature_gate_interface::from_account.
+func ActivatedAt(data []byte) (slot uint64, activated bool, ok bool) {
+	// Option<u64> activated_at
+	if len(data) < 1 {
+		return 0, false, false
+	}
+	switch data[0] {
+	case 0:
+		return 0, false, true
+	case 1:
+		if len(data) < AccountSize {
+			return 0, false, false
+		}
+		return binary.LittleEndian.Uint64(data[1:AccountSize]), true, true
+	default:
+		return 0, false, false
+	}
+}
+
+// SetActivatedAt records in the data of a feature account the slot at which
+// the feature was activated.
+//
+// Based on solana_feature_gate_interface::to_account.
+func SetActivatedAt(data []byte, slot uint64) bool {
+	if len(data) < AccountSize {
+		return false
+	}
+	data[0] = 1
+	binary.LittleEndian.PutUint64(data[1:AccountSize], slot)
+	return true
+}
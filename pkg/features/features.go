@@ -1,6 +1,10 @@
 package features
 
-import "fmt"
+import (
+	"fmt"
+
+	"go.firedancer.io/radiance/pkg/base58"
+)
 
 type FeatureGate struct {
 	Name    string
@@ -15,7 +19,8 @@ type FeatureActivationInfo struct {
 type Features map[FeatureGate]FeatureActivationInfo
 
 func NewFeaturesDefault() *Features {
-	return new(Features)
+	f := make(Features)
+	return &f
 }
 
 func (f *Features) EnableFeature(gate FeatureGate, activationSlot uint64) {
@@ -45,8 +50,16 @@ func (f *Features) AllEnabled() []string {
 	enabledFeatureStrs := make([]string, 0)
 	for feat, enabled := range *f {
 		if enabled.Enabled {
-			enabledFeatureStrs = append(enabledFeatureStrs, fmt.Sprintf("feature %s (%s) enabled", feat.Name, feat.Address))
+			enabledFeatureStrs = append(enabledFeatureStrs, fmt.Sprintf("feature %s (%s) enabled", feat.Name, base58.Encode(feat.Address[:])))
 		}
 	}
 	return enabledFeatureStrs
 }
+
+func (f *Features) Clone() *Features {
+	clone := make(Features, len(*f))
+	for gate, info := range *f {
+		clone[gate] = info
+	}
+	return &clone
+}
@@ -21,3 +21,70 @@ var StakeRaiseMinimumDelegationTo1Sol = FeatureGate{Name: "StakeRaiseMinimumDele
 var StakeRedelegateInstruction = FeatureGate{Name: "StakeRedelegateInstruction", Address: base58.MustDecodeFromString("2KKG3C6RBnxQo9jVVrbzsoSh41TDXLK7gBc9gduyxSzW")}
 var RequireRentExemptSplitDestination = FeatureGate{Name: "RequireRentExemptSplitDestination", Address: base58.MustDecodeFromString("D2aip4BBr8NPWtU9vLrwrBvbuaQ8w1zV38zFLxx4pfBV")}
 var DeprecateExecutableMetaUpdateInBpfLoader = FeatureGate{Name: "DeprecateExecutableMetaUpdateInBpfLoader", Address: base58.MustDecodeFromString("k6uR1J9VtKJnTukBV2Eo15BEy434MBg8bT6hHQgmU8v")}
+var DisableBpfLoaderInstructions = FeatureGate{Name: "DisableBpfLoaderInstructions", Address: base58.MustDecodeFromString("7WeS1vfPRgeeoXArLh7879YcB9mgE9ktjPDtajXeWfXn")}
+var BpfAccountDataDirectMapping = FeatureGate{Name: "BpfAccountDataDirectMapping", Address: base58.MustDecodeFromString("EenyoWx9UMXYKpR8mW5Jmfmy2fRjzUtM7NduYMY8bx33")}
+var DisableDeployOfAllocFreeSyscall = FeatureGate{Name: "DisableDeployOfAllocFreeSyscall", Address: base58.MustDecodeFromString("79HWsX9rpnnJBPcdNURVqygpMAfxdrAirzAGAVmf92im")}
+var Blake3SyscallEnabled = FeatureGate{Name: "Blake3SyscallEnabled", Address: base58.MustDecodeFromString("HTW2pSyErTj4BV6KBM9NZ9VBUJVxt7sacNWcf76wtzb3")}
+var EnableAltBn128Syscall = FeatureGate{Name: "EnableAltBn128Syscall", Address: base58.MustDecodeFromString("A16q37opZdQMCbe5qJ6xpBB9usykfv8jZaMkxvZQi4GJ")}
+var EnableAltBn128CompressionSyscall = FeatureGate{Name: "EnableAltBn128CompressionSyscall", Address: base58.MustDecodeFromString("EJJewYSddEEtSZHiqugnvhQHiWyZKjkFDQASd7oKSagn")}
+var SimplifyAltBn128SyscallErrorCodes = FeatureGate{Name: "SimplifyAltBn128SyscallErrorCodes", Address: base58.MustDecodeFromString("JDn5q3GBeqzvUa7z67BFmVHVJE3kHbeBh3kGG5nHo4wo")}
+var EnablePoseidonSyscall = FeatureGate{Name: "EnablePoseidonSyscall", Address: base58.MustDecodeFromString("FL9RsQA6TVUoh5xJQ9d936RHSebA1NLQqe3Zv9sXZRpr")}
+var EnableBigModExpSyscall = FeatureGate{Name: "EnableBigModExpSyscall", Address: base58.MustDecodeFromString("EBq48m8irRKuE7ZnMTLvLg2UuGSqhe8s8oMqnmja1fJw")}
+var CheckPhysicalOverlapping = FeatureGate{Name: "CheckPhysicalOverlapping", Address: base58.MustDecodeFromString("nWBqjr3gpETbiaVj3CBJ3HFC5TMdnJDGt21hnvSTvVZ")}
+var DisableFeesSysvar = FeatureGate{Name: "DisableFeesSysvar", Address: base58.MustDecodeFromString("JAN1trEUEtZjgXYzNBYHU9DYd7GnThhXfFP7SzPXkPsG")}
+var GetSysvarSyscallEnabled = FeatureGate{Name: "GetSysvarSyscallEnabled", Address: base58.MustDecodeFromString("CLCoTADvV64PSrnR6QXty6Fwrt9Xc6EdxSJE4wLRePjq")}
+var RemainingComputeUnitsSyscallEnabled = FeatureGate{Name: "RemainingComputeUnitsSyscallEnabled", Address: base58.MustDecodeFromString("5TuppMutoyzhUSfuYdhgzD47F92GL1g89KpCZQKqedxP")}
+var EnableGetEpochStakeSyscall = FeatureGate{Name: "EnableGetEpochStakeSyscall", Address: base58.MustDecodeFromString("7mScTYkJXsbdrcwTQRs7oeCSXoJm4WjzBsRyf8bCU3Np")}
+var EnableSecp256r1Precompile = FeatureGate{Name: "EnableSecp256r1Precompile", Address: base58.MustDecodeFromString("sr11RdZWgbHTHxSroPALe6zgaT5A1K9LcE4nfsZS4gi")}
+var MigrateConfigProgramToCoreBpf = FeatureGate{Name: "MigrateConfigProgramToCoreBpf", Address: base58.MustDecodeFromString("2Fr57nzzkLYXW695UdDxDeR5fhnZWSttZeZYemrnpGFV")}
+var MigrateStakeProgramToCoreBpf = FeatureGate{Name: "MigrateStakeProgramToCoreBpf", Address: base58.MustDecodeFromString("6M4oQ6eXneVhtLoiAr4yRYQY43eVLjrKbiDZDJc892yk")}
+var RewardFullPriorityFee = FeatureGate{Name: "RewardFullPriorityFee", Address: base58.MustDecodeFromString("3opE3EzAKnUftUDURkzMgwpNgimBAypW1mNDYH4x4Zg7")}
+var DisableRentFeesCollection = FeatureGate{Name: "DisableRentFeesCollection", Address: base58.MustDecodeFromString("CJzY83ggJHqPGDq8VisV3U91jDJLuEaALZooBrXtnnLU")}
+var AddNewReservedAccountKeys = FeatureGate{Name: "AddNewReservedAccountKeys", Address: base58.MustDecodeFromString("8U4skmMVnF6k2kMvrWbQuRUT3qQSiTYpSjqmhmgfthZu")}
+var AccountsLtHash = FeatureGate{Name: "AccountsLtHash", Address: base58.MustDecodeFromString("LTHasHQX6661DaDD4S6A2TFi6QBuiwXKv66fB1obfHq")}
+var SnapshotsLtHash = FeatureGate{Name: "SnapshotsLtHash", Address: base58.MustDecodeFromString("LTsNAP8h1voEVVToMNBNqoiNQex4aqfUrbFhRH3mSQ2")}
+var EnableTransactionLoadingFailureFees = FeatureGate{Name: "EnableTransactionLoadingFailureFees", Address: base58.MustDecodeFromString("PaymEPK2oqwT9TXAVfadjztH2H6KfLEB9Hhd5Q5frvP")}
+
+// AllFeatureGates lists the feature gates known to radiance.
+var AllFeatureGates = []FeatureGate{
+	StopTruncatingStringsInSyscalls,
+	EnablePartitionedEpochReward,
+	LastRestartSlotSysvar,
+	Libsecp256k1FailOnBadCount,
+	Libsecp256k1FailOnBadCount2,
+	EnableBpfLoaderSetAuthorityCheckedIx,
+	LoosenCpiSizeRestriction,
+	IncreaseTxAccountLockLimit,
+	VoteStateAddVoteLatency,
+	AllowCommissionDecreaseAtAnyTime,
+	CommissionUpdatesOnlyAllowedInFirstHalfOfEpoch,
+	TimelyVoteCredits,
+	ReduceStakeWarmupCooldown,
+	StakeRaiseMinimumDelegationTo1Sol,
+	StakeRedelegateInstruction,
+	RequireRentExemptSplitDestination,
+	DeprecateExecutableMetaUpdateInBpfLoader,
+	DisableBpfLoaderInstructions,
+	BpfAccountDataDirectMapping,
+	DisableDeployOfAllocFreeSyscall,
+	Blake3SyscallEnabled,
+	EnableAltBn128Syscall,
+	EnableAltBn128CompressionSyscall,
+	SimplifyAltBn128SyscallErrorCodes,
+	EnablePoseidonSyscall,
+	EnableBigModExpSyscall,
+	CheckPhysicalOverlapping,
+	DisableFeesSysvar,
+	GetSysvarSyscallEnabled,
+	RemainingComputeUnitsSyscallEnabled,
+	EnableGetEpochStakeSyscall,
+	EnableSecp256r1Precompile,
+	MigrateConfigProgramToCoreBpf,
+	MigrateStakeProgramToCoreBpf,
+	RewardFullPriorityFee,
+	DisableRentFeesCollection,
+	AddNewReservedAccountKeys,
+	AccountsLtHash,
+	SnapshotsLtHash,
+	EnableTransactionLoadingFailureFees,
+}
@@ -6,18 +6,26 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	bin "github.com/gagliardetto/binary"
 	"go.firedancer.io/radiance/pkg/archiveutil"
 )
 
-// ReadGenesisFromFile is a convenience wrapper for ReadGenesisFromArchive.
+// ReadGenesisFromFile reads genesis from a `genesis.tar.bz2` archive, from
+// a bare `genesis.bin` file, or from the genesis.bin of a ledger directory.
 func ReadGenesisFromFile(fpath string) (genesis *Genesis, hash *[32]byte, err error) {
+	if info, statErr := os.Stat(fpath); statErr == nil && info.IsDir() {
+		fpath = filepath.Join(fpath, "genesis.bin")
+	}
 	f, err := os.Open(fpath)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer f.Close()
+	if filepath.Ext(fpath) == ".bin" {
+		return ReadGenesis(f)
+	}
 	return ReadGenesisFromArchive(f)
 }
 
@@ -37,11 +45,14 @@ func ReadGenesisFromArchive(archive io.Reader) (genesis *Genesis, hash *[32]byte
 		err = fmt.Errorf("first file is not genesis.bin")
 		return
 	}
+	return ReadGenesis(files)
+}
 
-	// Read and hash first file
+// ReadGenesis reads a `genesis.bin` file, and returns the genesis config
+// and its hash, which is the first PoH hash of the ledger.
+func ReadGenesis(rd io.Reader) (genesis *Genesis, hash *[32]byte, err error) {
+	// Read and hash file
 	const maxSize = 10_000_001
-	var rd io.Reader
-	rd = files
 	hasher := sha256.New()
 	rd = io.TeeReader(rd, hasher)
 	rd = io.LimitReader(rd, maxSize)
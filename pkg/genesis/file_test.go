@@ -1,6 +1,10 @@
 package genesis
 
 import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -8,6 +12,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/archiveutil"
 	"go.firedancer.io/radiance/pkg/runtime"
 )
 
@@ -62,3 +68,54 @@ func TestReadGenesisFromArchive(t *testing.T) {
 	}, genesis.EpochSchedule)
 	assert.Equal(t, uint32(1), genesis.ClusterID)
 }
+
+func TestReadGenesis(t *testing.T) {
+	f := fixtures.Open(t, "genesis", "mainnet.tar.bz2")
+	defer f.Close()
+	files, err := archiveutil.OpenTar(f)
+	require.NoError(t, err)
+	_, err = files.Next()
+	require.NoError(t, err)
+	genesisBin, err := io.ReadAll(files)
+	require.NoError(t, err)
+
+	genesis, hash, err := ReadGenesis(bytes.NewReader(genesisBin))
+	require.NoError(t, err)
+	archived, archivedHash, err := ReadGenesisFromFile(fixtures.Path(t, "genesis", "mainnet.tar.bz2"))
+	require.NoError(t, err)
+	assert.Equal(t, archived, genesis)
+	assert.Equal(t, archivedHash, hash)
+
+	// ledger directories hold a bare genesis.bin
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "genesis.bin"), genesisBin, 0o644))
+	_, dirHash, err := ReadGenesisFromFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, hash, dirHash)
+
+	_, _, err = ReadGenesis(bytes.NewReader(genesisBin[:len(genesisBin)-1]))
+	assert.Error(t, err)
+}
+
+func TestGenesis_FillAccounts(t *testing.T) {
+	f := fixtures.Open(t, "genesis", "mainnet.tar.bz2")
+	defer f.Close()
+	genesis, _, err := ReadGenesisFromArchive(f)
+	require.NoError(t, err)
+
+	accts := accounts.NewMemAccounts()
+	genesis.FillAccounts(accts)
+	for _, acc := range genesis.Accounts {
+		stored, err := accts.GetAccount(&acc.Pubkey)
+		require.NoError(t, err)
+		assert.Equal(t, acc.Lamports, stored.Lamports)
+	}
+	stake := genesis.Builtins[1]
+	stored, err := accts.GetAccount(&stake.Pubkey)
+	require.NoError(t, err)
+	assert.Equal(t, stake.Account(), stored)
+	assert.Equal(t, []byte("solana_stake_program"), stored.Data)
+	assert.True(t, stored.Executable)
+
+	assert.InDelta(t, 78892314.98, genesis.SlotsPerYear(), 0.01)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
 	"go.firedancer.io/radiance/pkg/runtime"
 )
 
@@ -32,8 +33,48 @@ type BuiltinProgram struct {
 	Pubkey [32]byte
 }
 
+// FillAccounts stores the accounts and reward pools of genesis, and an
+// account for each of its builtin programs that has none.
+//
+// Based on solana_runtime::bank::Bank::process_genesis_config.
 func (g *Genesis) FillAccounts(state accounts.Accounts) {
-	for _, acc := range g.Accounts {
-		state.SetAccount(&acc.Pubkey, &acc.Account)
+	for i := range g.Accounts {
+		state.SetAccount(&g.Accounts[i].Pubkey, &g.Accounts[i].Account)
 	}
+	for i := range g.RewardPools {
+		state.SetAccount(&g.RewardPools[i].Pubkey, &g.RewardPools[i].Account)
+	}
+	for _, builtin := range g.Builtins {
+		builtin := builtin
+		if acct, err := state.GetAccount(&builtin.Pubkey); err == nil && acct != nil && acct.Lamports != 0 {
+			continue
+		}
+		state.SetAccount(&builtin.Pubkey, builtin.Account())
+	}
+}
+
+// Account returns the account of a builtin program, which is owned by
+// the native loader and holds the name of the program.
+//
+// Based on solana_sdk::native_loader::create_loadable_account_with_fields.
+func (b *BuiltinProgram) Account() *accounts.Account {
+	return &accounts.Account{
+		Lamports:   1,
+		Data:       []byte(b.Key),
+		Owner:      base58.MustDecodeFromString(accounts.NativeLoaderAddrStr),
+		Executable: true,
+	}
+}
+
+// SlotsPerYear returns the number of slots in a year, given the duration
+// of a tick and the number of ticks per slot.
+//
+// Based on solana_sdk::genesis_config::GenesisConfig::slots_per_year.
+func (g *Genesis) SlotsPerYear() float64 {
+	if g.PohParams.TickDuration == 0 || g.TicksPerSlot == 0 {
+		return 0
+	}
+	const secondsPerYear = 365.242_199 * 24.0 * 60.0 * 60.0
+	ticksPerYear := secondsPerYear * float64(time.Second) / float64(g.PohParams.TickDuration)
+	return ticksPerYear / float64(g.TicksPerSlot)
 }
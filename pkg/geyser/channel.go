@@ -0,0 +1,60 @@
+package geyser
+
+import "context"
+
+// Event is an update of replay, of which exactly one field is set.
+type Event struct {
+	Account      *AccountUpdate
+	EndOfStartup bool
+	Slot         *SlotUpdate
+	Transaction  *TransactionUpdate
+	Block        *BlockMetadata
+}
+
+// Channel is a plugin that sends updates as events on a channel. Sends
+// block until the event is received, or the context is done, so replay
+// does not run ahead of a slow consumer.
+type Channel struct {
+	ctx    context.Context
+	events chan Event
+}
+
+// NewChannel returns a plugin sending events on a channel with the given
+// buffer size. Once ctx is done, events are dropped.
+func NewChannel(ctx context.Context, size int) *Channel {
+	return &Channel{ctx: ctx, events: make(chan Event, size)}
+}
+
+// Events returns the channel the events are sent on.
+func (c *Channel) Events() <-chan Event {
+	return c.events
+}
+
+func (c *Channel) send(event Event) error {
+	select {
+	case c.events <- event:
+		return nil
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+}
+
+func (c *Channel) UpdateAccount(update *AccountUpdate) error {
+	return c.send(Event{Account: update})
+}
+
+func (c *Channel) NotifyEndOfStartup() error {
+	return c.send(Event{EndOfStartup: true})
+}
+
+func (c *Channel) UpdateSlotStatus(update *SlotUpdate) error {
+	return c.send(Event{Slot: update})
+}
+
+func (c *Channel) NotifyTransaction(update *TransactionUpdate) error {
+	return c.send(Event{Transaction: update})
+}
+
+func (c *Channel) NotifyBlockMetadata(meta *BlockMetadata) error {
+	return c.send(Event{Block: meta})
+}
@@ -0,0 +1,175 @@
+// Package geyser streams the account writes, transactions and slot status
+// changes of replay to embedded consumers, such as indexers.
+package geyser
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"k8s.io/klog/v2"
+)
+
+// AccountUpdate is a write of an account in a slot. WriteVersion orders
+// the writes of the same account within a slot. TxSignature is the
+// signature of the transaction that wrote the account, if any. Writes of
+// the accounts loaded at startup, from a snapshot, have IsStartup set.
+//
+// Based on agave_geyser_plugin_interface::geyser_plugin_interface::ReplicaAccountInfoV3.
+type AccountUpdate struct {
+	Slot         uint64
+	Pubkey       [32]byte
+	Account      *accounts.Account
+	WriteVersion uint64
+	TxSignature  *solana.Signature
+	IsStartup    bool
+}
+
+// SlotStatus is the status of a slot in replay.
+//
+// Based on agave_geyser_plugin_interface::geyser_plugin_interface::SlotStatus.
+type SlotStatus int
+
+const (
+	SlotStatusProcessed SlotStatus = iota
+	SlotStatusRooted
+	SlotStatusConfirmed
+	SlotStatusFirstShredReceived
+	SlotStatusCompleted
+	SlotStatusCreatedBank
+	SlotStatusDead
+)
+
+func (s SlotStatus) String() string {
+	switch s {
+	case SlotStatusProcessed:
+		return "processed"
+	case SlotStatusRooted:
+		return "rooted"
+	case SlotStatusConfirmed:
+		return "confirmed"
+	case SlotStatusFirstShredReceived:
+		return "first_shred_received"
+	case SlotStatusCompleted:
+		return "completed"
+	case SlotStatusCreatedBank:
+		return "created_bank"
+	case SlotStatusDead:
+		return "dead"
+	default:
+		return "unknown"
+	}
+}
+
+// SlotUpdate is a change of the status of a slot.
+type SlotUpdate struct {
+	Slot   uint64
+	Parent uint64
+	Status SlotStatus
+}
+
+// TransactionUpdate is a transaction committed in a slot, at the given
+// index of the block.
+//
+// Based on agave_geyser_plugin_interface::geyser_plugin_interface::ReplicaTransactionInfoV2.
+type TransactionUpdate struct {
+	Slot        uint64
+	Index       uint64
+	Signature   solana.Signature
+	IsVote      bool
+	Transaction *solana.Transaction
+	Meta        *sealevel.TransactionStatusMeta
+}
+
+// BlockMetadata describes a replayed block.
+//
+// Based on agave_geyser_plugin_interface::geyser_plugin_interface::ReplicaBlockInfoV4.
+type BlockMetadata struct {
+	Slot                     uint64
+	ParentSlot               uint64
+	Blockhash                [32]byte
+	ParentBlockhash          [32]byte
+	BlockHeight              uint64
+	ExecutedTransactionCount uint64
+	EntryCount               uint64
+}
+
+// Plugin consumes the updates of replay. Its methods are called from the
+// replay goroutine, in order, and should not block for long.
+//
+// Based on agave_geyser_plugin_interface::geyser_plugin_interface::GeyserPlugin.
+type Plugin interface {
+	UpdateAccount(update *AccountUpdate) error
+	NotifyEndOfStartup() error
+	UpdateSlotStatus(update *SlotUpdate) error
+	NotifyTransaction(update *TransactionUpdate) error
+	NotifyBlockMetadata(meta *BlockMetadata) error
+}
+
+// Notifier dispatches the updates of replay to plugins, and assigns the
+// write versions of account writes. Errors of plugins are logged.
+type Notifier struct {
+	mu           sync.RWMutex
+	plugins      []Plugin
+	writeVersion atomic.Uint64
+}
+
+// NewNotifier returns a notifier that dispatches updates to the given
+// plugins.
+func NewNotifier(plugins ...Plugin) *Notifier {
+	return &Notifier{plugins: plugins}
+}
+
+// AddPlugin adds a plugin, which receives the updates from then on.
+func (n *Notifier) AddPlugin(plugin Plugin) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.plugins = append(n.plugins, plugin)
+}
+
+func (n *Notifier) each(what string, fn func(Plugin) error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for _, plugin := range n.plugins {
+		if err := fn(plugin); err != nil {
+			klog.Errorf("Geyser plugin failed to handle %s: %s", what, err)
+		}
+	}
+}
+
+// UpdateAccount notifies plugins of a write of an account.
+func (n *Notifier) UpdateAccount(slot uint64, pubkey [32]byte, acct *accounts.Account, txSignature *solana.Signature, isStartup bool) {
+	update := &AccountUpdate{
+		Slot:         slot,
+		Pubkey:       pubkey,
+		Account:      acct,
+		WriteVersion: n.writeVersion.Add(1),
+		TxSignature:  txSignature,
+		IsStartup:    isStartup,
+	}
+	n.each("account update", func(p Plugin) error { return p.UpdateAccount(update) })
+}
+
+// NotifyEndOfStartup notifies plugins that all accounts loaded at startup
+// have been notified.
+func (n *Notifier) NotifyEndOfStartup() {
+	n.each("end of startup", func(p Plugin) error { return p.NotifyEndOfStartup() })
+}
+
+// UpdateSlotStatus notifies plugins of a change of the status of a slot.
+func (n *Notifier) UpdateSlotStatus(slot, parent uint64, status SlotStatus) {
+	update := &SlotUpdate{Slot: slot, Parent: parent, Status: status}
+	n.each("slot update", func(p Plugin) error { return p.UpdateSlotStatus(update) })
+}
+
+// NotifyTransaction notifies plugins of a committed transaction.
+func (n *Notifier) NotifyTransaction(update *TransactionUpdate) {
+	n.each("transaction", func(p Plugin) error { return p.NotifyTransaction(update) })
+}
+
+// NotifyBlockMetadata notifies plugins of a replayed block.
+func (n *Notifier) NotifyBlockMetadata(meta *BlockMetadata) {
+	n.each("block metadata", func(p Plugin) error { return p.NotifyBlockMetadata(meta) })
+}
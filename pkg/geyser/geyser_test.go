@@ -0,0 +1,51 @@
+package geyser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+type failingPlugin struct {
+	LogPlugin
+}
+
+func (failingPlugin) UpdateAccount(*AccountUpdate) error {
+	return errors.New("failed")
+}
+
+func TestNotifier(t *testing.T) {
+	ch := NewChannel(context.Background(), 8)
+	n := NewNotifier(failingPlugin{})
+	n.AddPlugin(ch)
+
+	acct := &accounts.Account{Lamports: 1}
+	n.UpdateAccount(5, [32]byte{1}, acct, nil, true)
+	n.NotifyEndOfStartup()
+	n.UpdateAccount(6, [32]byte{1}, acct, nil, false)
+	n.UpdateSlotStatus(6, 5, SlotStatusProcessed)
+
+	events := ch.Events()
+	event := <-events
+	require.NotNil(t, event.Account)
+	assert.Equal(t, uint64(1), event.Account.WriteVersion)
+	assert.True(t, event.Account.IsStartup)
+	assert.True(t, (<-events).EndOfStartup)
+	event = <-events
+	require.NotNil(t, event.Account)
+	assert.Equal(t, uint64(2), event.Account.WriteVersion)
+	assert.Equal(t, uint64(6), event.Account.Slot)
+	assert.Equal(t, &SlotUpdate{Slot: 6, Parent: 5, Status: SlotStatusProcessed}, (<-events).Slot)
+	assert.Equal(t, "processed", SlotStatusProcessed.String())
+}
+
+func TestChannelCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := NewChannel(ctx, 0)
+	cancel()
+	assert.ErrorIs(t, ch.NotifyEndOfStartup(), context.Canceled)
+}
@@ -0,0 +1,44 @@
+package geyser
+
+import (
+	"go.firedancer.io/radiance/pkg/base58"
+	"k8s.io/klog/v2"
+)
+
+// LogPlugin is a plugin that logs updates, for debugging.
+type LogPlugin struct{}
+
+func (LogPlugin) UpdateAccount(update *AccountUpdate) error {
+	if update.IsStartup {
+		klog.V(4).Infof("Geyser: startup account %s lamports=%d",
+			base58.Encode(update.Pubkey[:]), update.Account.Lamports)
+		return nil
+	}
+	klog.Infof("Geyser: slot %d account %s lamports=%d owner=%s data_len=%d write_version=%d",
+		update.Slot, base58.Encode(update.Pubkey[:]), update.Account.Lamports,
+		base58.Encode(update.Account.Owner[:]), len(update.Account.Data), update.WriteVersion)
+	return nil
+}
+
+func (LogPlugin) NotifyEndOfStartup() error {
+	klog.Info("Geyser: end of startup")
+	return nil
+}
+
+func (LogPlugin) UpdateSlotStatus(update *SlotUpdate) error {
+	klog.Infof("Geyser: slot %d (parent %d) %s", update.Slot, update.Parent, update.Status)
+	return nil
+}
+
+func (LogPlugin) NotifyTransaction(update *TransactionUpdate) error {
+	klog.Infof("Geyser: slot %d tx %d %s vote=%t err=%v",
+		update.Slot, update.Index, update.Signature, update.IsVote, update.Meta.Err)
+	return nil
+}
+
+func (LogPlugin) NotifyBlockMetadata(meta *BlockMetadata) error {
+	klog.Infof("Geyser: block %d blockhash=%s block_height=%d txs=%d entries=%d",
+		meta.Slot, base58.Encode(meta.Blockhash[:]), meta.BlockHeight,
+		meta.ExecutedTransactionCount, meta.EntryCount)
+	return nil
+}
@@ -0,0 +1,452 @@
+package yellowstone
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Commitment levels of geyser.proto.
+const (
+	CommitmentProcessed = 0
+	CommitmentConfirmed = 1
+	CommitmentFinalized = 2
+)
+
+// tokenAccountSize is the size of an SPL token account, and
+// tokenAccountStateOffset the offset of its state.
+const (
+	tokenAccountSize        = 165
+	tokenAccountStateOffset = 108
+	tokenAccountTypeAccount = 2
+)
+
+// subscribeRequest is a decoded geyser.SubscribeRequest: the filters of a
+// subscription, by name.
+type subscribeRequest struct {
+	accounts           map[string]*accountsFilter
+	slots              map[string]*slotsFilter
+	transactions       map[string]*transactionsFilter
+	transactionsStatus map[string]*transactionsFilter
+	blocksMeta         map[string]struct{}
+	commitment         uint64
+	dataSlices         []dataSlice
+	ping               *int32
+}
+
+type accountsFilter struct {
+	accounts             map[[32]byte]struct{}
+	owners               map[[32]byte]struct{}
+	memcmps              []memcmpFilter
+	dataSize             *uint64
+	tokenAccountState    bool
+	lamports             []lamportsFilter
+	nonemptyTxnSignature *bool
+}
+
+type memcmpFilter struct {
+	offset uint64
+	data   []byte
+}
+
+// lamportsFilter compares the lamports of an account with a value.
+type lamportsFilter struct {
+	cmp   protowire.Number // 1 eq, 2 ne, 3 lt, 4 gt
+	value uint64
+}
+
+type slotsFilter struct {
+	filterByCommitment bool
+	interslotUpdates   bool
+}
+
+type transactionsFilter struct {
+	vote            *bool
+	failed          *bool
+	signature       *[64]byte
+	accountInclude  map[[32]byte]struct{}
+	accountExclude  map[[32]byte]struct{}
+	accountRequired map[[32]byte]struct{}
+}
+
+type dataSlice struct {
+	offset uint64
+	length uint64
+}
+
+// decodeSubscribeRequest decodes a geyser.SubscribeRequest.
+func decodeSubscribeRequest(data []byte) (*subscribeRequest, error) {
+	req := &subscribeRequest{
+		accounts:           make(map[string]*accountsFilter),
+		slots:              make(map[string]*slotsFilter),
+		transactions:       make(map[string]*transactionsFilter),
+		transactionsStatus: make(map[string]*transactionsFilter),
+		blocksMeta:         make(map[string]struct{}),
+	}
+	err := forEachField(data, func(num protowire.Number, v interface{}) error {
+		if num == 6 {
+			commitment, err := fieldUint64(num, v)
+			if err != nil {
+				return err
+			}
+			req.commitment = commitment
+			return nil
+		}
+		b, err := fieldBytes(num, v)
+		if err != nil {
+			return err
+		}
+		switch num {
+		case 1:
+			return forEachMapEntry(b, func(key string, value []byte) (err error) {
+				req.accounts[key], err = decodeAccountsFilter(value)
+				return
+			})
+		case 2:
+			return forEachMapEntry(b, func(key string, value []byte) (err error) {
+				req.slots[key], err = decodeSlotsFilter(value)
+				return
+			})
+		case 3, 10:
+			return forEachMapEntry(b, func(key string, value []byte) error {
+				filter, err := decodeTransactionsFilter(value)
+				if num == 3 {
+					req.transactions[key] = filter
+				} else {
+					req.transactionsStatus[key] = filter
+				}
+				return err
+			})
+		case 4:
+			return fmt.Errorf("blocks subscriptions are not supported")
+		case 5:
+			return forEachMapEntry(b, func(key string, _ []byte) error {
+				req.blocksMeta[key] = struct{}{}
+				return nil
+			})
+		case 7:
+			var slice dataSlice
+			err := forEachField(b, func(num protowire.Number, v interface{}) error {
+				u, err := fieldUint64(num, v)
+				switch num {
+				case 1:
+					slice.offset = u
+				case 2:
+					slice.length = u
+				}
+				return err
+			})
+			req.dataSlices = append(req.dataSlices, slice)
+			return err
+		case 8:
+			return fmt.Errorf("entry subscriptions are not supported")
+		case 9:
+			var id int32
+			err := forEachField(b, func(num protowire.Number, v interface{}) error {
+				u, err := fieldUint64(num, v)
+				if num == 1 {
+					id = int32(u)
+				}
+				return err
+			})
+			req.ping = &id
+			return err
+		case 11:
+			return fmt.Errorf("from_slot is not supported")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.commitment != CommitmentProcessed && req.commitment != CommitmentFinalized {
+		return nil, fmt.Errorf("commitment %d is not supported", req.commitment)
+	}
+	return req, nil
+}
+
+func decodePubkeys(b []byte, set *map[[32]byte]struct{}) error {
+	key, err := base58.Decode(string(b))
+	if err != nil || len(key) != 32 {
+		return fmt.Errorf("invalid pubkey %q", b)
+	}
+	var pubkey [32]byte
+	copy(pubkey[:], key)
+	if *set == nil {
+		*set = make(map[[32]byte]struct{})
+	}
+	(*set)[pubkey] = struct{}{}
+	return nil
+}
+
+func decodeAccountsFilter(data []byte) (*accountsFilter, error) {
+	filter := &accountsFilter{}
+	err := forEachField(data, func(num protowire.Number, v interface{}) error {
+		switch num {
+		case 2, 3:
+			b, err := fieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			if num == 2 {
+				return decodePubkeys(b, &filter.accounts)
+			}
+			return decodePubkeys(b, &filter.owners)
+		case 4:
+			b, err := fieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			return forEachField(b, func(num protowire.Number, v interface{}) error {
+				return decodeAccountsFilterItem(filter, num, v)
+			})
+		case 5:
+			u, err := fieldUint64(num, v)
+			nonempty := u != 0
+			filter.nonemptyTxnSignature = &nonempty
+			return err
+		}
+		return nil
+	})
+	return filter, err
+}
+
+// decodeAccountsFilterItem decodes the oneof of a
+// geyser.SubscribeRequestFilterAccountsFilter.
+func decodeAccountsFilterItem(filter *accountsFilter, num protowire.Number, v interface{}) error {
+	switch num {
+	case 1:
+		b, err := fieldBytes(num, v)
+		if err != nil {
+			return err
+		}
+		var memcmp memcmpFilter
+		err = forEachField(b, func(num protowire.Number, v interface{}) error {
+			if num == 1 {
+				u, err := fieldUint64(num, v)
+				memcmp.offset = u
+				return err
+			}
+			data, err := fieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			switch num {
+			case 2:
+				memcmp.data = data
+			case 3:
+				memcmp.data, err = base58.Decode(string(data))
+			case 4:
+				memcmp.data, err = base64.StdEncoding.DecodeString(string(data))
+			}
+			return err
+		})
+		filter.memcmps = append(filter.memcmps, memcmp)
+		return err
+	case 2:
+		u, err := fieldUint64(num, v)
+		filter.dataSize = &u
+		return err
+	case 3:
+		u, err := fieldUint64(num, v)
+		filter.tokenAccountState = u != 0
+		return err
+	case 4:
+		b, err := fieldBytes(num, v)
+		if err != nil {
+			return err
+		}
+		return forEachField(b, func(num protowire.Number, v interface{}) error {
+			u, err := fieldUint64(num, v)
+			filter.lamports = append(filter.lamports, lamportsFilter{cmp: num, value: u})
+			return err
+		})
+	}
+	return nil
+}
+
+func decodeSlotsFilter(data []byte) (*slotsFilter, error) {
+	filter := &slotsFilter{}
+	err := forEachField(data, func(num protowire.Number, v interface{}) error {
+		u, err := fieldUint64(num, v)
+		switch num {
+		case 1:
+			filter.filterByCommitment = u != 0
+		case 2:
+			filter.interslotUpdates = u != 0
+		}
+		return err
+	})
+	return filter, err
+}
+
+func decodeTransactionsFilter(data []byte) (*transactionsFilter, error) {
+	filter := &transactionsFilter{}
+	err := forEachField(data, func(num protowire.Number, v interface{}) error {
+		switch num {
+		case 1, 2:
+			u, err := fieldUint64(num, v)
+			set := u != 0
+			if num == 1 {
+				filter.vote = &set
+			} else {
+				filter.failed = &set
+			}
+			return err
+		}
+		b, err := fieldBytes(num, v)
+		if err != nil {
+			return err
+		}
+		switch num {
+		case 3:
+			return decodePubkeys(b, &filter.accountInclude)
+		case 4:
+			return decodePubkeys(b, &filter.accountExclude)
+		case 5:
+			sig, err := base58.Decode(string(b))
+			if err != nil || len(sig) != 64 {
+				return fmt.Errorf("invalid signature %q", b)
+			}
+			filter.signature = new([64]byte)
+			copy(filter.signature[:], sig)
+		case 6:
+			return decodePubkeys(b, &filter.accountRequired)
+		}
+		return nil
+	})
+	return filter, err
+}
+
+// matches returns whether an account update passes the filter.
+func (f *accountsFilter) matches(update *geyser.AccountUpdate) bool {
+	acct := update.Account
+	if len(f.accounts) != 0 {
+		if _, ok := f.accounts[update.Pubkey]; !ok {
+			return false
+		}
+	}
+	if len(f.owners) != 0 {
+		if _, ok := f.owners[acct.Owner]; !ok {
+			return false
+		}
+	}
+	for _, memcmp := range f.memcmps {
+		if memcmp.offset > uint64(len(acct.Data)) ||
+			!bytes.HasPrefix(acct.Data[memcmp.offset:], memcmp.data) {
+			return false
+		}
+	}
+	if f.dataSize != nil && uint64(len(acct.Data)) != *f.dataSize {
+		return false
+	}
+	if f.tokenAccountState && !isTokenAccount(acct.Data) {
+		return false
+	}
+	for _, cmp := range f.lamports {
+		var ok bool
+		switch cmp.cmp {
+		case 1:
+			ok = acct.Lamports == cmp.value
+		case 2:
+			ok = acct.Lamports != cmp.value
+		case 3:
+			ok = acct.Lamports < cmp.value
+		case 4:
+			ok = acct.Lamports > cmp.value
+		}
+		if !ok {
+			return false
+		}
+	}
+	if f.nonemptyTxnSignature != nil && *f.nonemptyTxnSignature != (update.TxSignature != nil) {
+		return false
+	}
+	return true
+}
+
+// isTokenAccount returns whether the data is that of an initialized SPL
+// token account.
+//
+// Based on spl_token_2022::generic_token_account::GenericTokenAccount::valid_account_data.
+func isTokenAccount(data []byte) bool {
+	if len(data) < tokenAccountSize || data[tokenAccountStateOffset] == 0 {
+		return false
+	}
+	return len(data) == tokenAccountSize || data[tokenAccountSize] == tokenAccountTypeAccount
+}
+
+// matches returns whether a slot update passes the filter of a subscription
+// of the given commitment.
+func (f *slotsFilter) matches(update *geyser.SlotUpdate, commitment uint64) bool {
+	switch update.Status {
+	case geyser.SlotStatusProcessed:
+		return !f.filterByCommitment || commitment == CommitmentProcessed
+	case geyser.SlotStatusConfirmed:
+		return !f.filterByCommitment || commitment == CommitmentConfirmed
+	case geyser.SlotStatusRooted:
+		return !f.filterByCommitment || commitment == CommitmentFinalized
+	default:
+		return f.interslotUpdates
+	}
+}
+
+// matches returns whether a transaction passes the filter.
+func (f *transactionsFilter) matches(update *geyser.TransactionUpdate) bool {
+	if f.vote != nil && *f.vote != update.IsVote {
+		return false
+	}
+	if f.failed != nil && *f.failed != (update.Meta.Err != nil) {
+		return false
+	}
+	if f.signature != nil && *f.signature != update.Signature {
+		return false
+	}
+	if len(f.accountInclude) == 0 && len(f.accountExclude) == 0 && len(f.accountRequired) == 0 {
+		return true
+	}
+
+	keys := make(map[[32]byte]struct{})
+	for _, key := range update.Transaction.Message.AccountKeys {
+		keys[key] = struct{}{}
+	}
+	for _, key := range update.Meta.LoadedAddresses.Writable {
+		keys[key] = struct{}{}
+	}
+	for _, key := range update.Meta.LoadedAddresses.Readonly {
+		keys[key] = struct{}{}
+	}
+	if len(f.accountInclude) != 0 {
+		included := false
+		for key := range f.accountInclude {
+			if _, ok := keys[key]; ok {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for key := range f.accountExclude {
+		if _, ok := keys[key]; ok {
+			return false
+		}
+	}
+	for key := range f.accountRequired {
+		if _, ok := keys[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// empty returns whether the request holds no filters.
+func (r *subscribeRequest) empty() bool {
+	return len(r.accounts) == 0 && len(r.slots) == 0 && len(r.transactions) == 0 &&
+		len(r.transactionsStatus) == 0 && len(r.blocksMeta) == 0 && len(r.dataSlices) == 0
+}
@@ -0,0 +1,51 @@
+package yellowstone
+
+import (
+	"go.firedancer.io/radiance/pkg/storageproto"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of geyser.proto are encoded and decoded with protowire,
+// field by field, as no generated code is vendored, and embed the messages
+// of solana-storage.proto encoded by storageproto.
+
+var (
+	appendUint64                = storageproto.AppendUint64
+	appendOptionalUint64        = storageproto.AppendOptionalUint64
+	appendBool                  = storageproto.AppendBool
+	appendBytes                 = storageproto.AppendBytes
+	appendOptionalBytes         = storageproto.AppendOptionalBytes
+	appendString                = storageproto.AppendString
+	appendMessage               = storageproto.AppendMessage
+	appendTransaction           = storageproto.AppendTransaction
+	appendTransactionError      = storageproto.AppendTransactionError
+	appendTransactionStatusMeta = storageproto.AppendTransactionStatusMeta
+
+	forEachField = storageproto.ForEachField
+	fieldUint64  = storageproto.FieldUint64
+	fieldBytes   = storageproto.FieldBytes
+)
+
+// forEachMapEntry calls fn with the key and the encoded value of a map
+// entry of string keys and message values.
+func forEachMapEntry(entry []byte, fn func(key string, value []byte) error) error {
+	var key string
+	var value []byte
+	err := forEachField(entry, func(num protowire.Number, v interface{}) error {
+		b, err := fieldBytes(num, v)
+		if err != nil {
+			return err
+		}
+		switch num {
+		case 1:
+			key = string(b)
+		case 2:
+			value = b
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return fn(key, value)
+}
@@ -0,0 +1,466 @@
+// Package yellowstone serves the updates of replay over gRPC as the Geyser
+// service of Yellowstone gRPC, so that existing dragon's-mouth clients can
+// subscribe to accounts, transactions, slots and blocks of a replayer.
+//
+// gRPC is spoken directly over cleartext HTTP/2, with the messages encoded
+// by protowire.
+package yellowstone
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/encoding/protowire"
+	"k8s.io/klog/v2"
+)
+
+// gRPC status codes.
+const (
+	codeOK                = 0
+	codeInvalidArgument   = 3
+	codeResourceExhausted = 8
+	codeUnimplemented     = 12
+	codeInternal          = 13
+	codeUnavailable       = 14
+)
+
+// maxMessageSize is the size limit of received messages, the default of
+// gRPC servers.
+const maxMessageSize = 4 << 20
+
+// pingInterval is the interval of the pings sent on Subscribe streams, which
+// keep them alive through load balancers.
+const pingInterval = 15 * time.Second
+
+// DefaultBufferSize is the default number of events buffered for each
+// subscriber.
+const DefaultBufferSize = 65536
+
+// statusError is an error returned to the client as a gRPC status.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("gRPC status %d: %s", e.code, e.msg)
+}
+
+// Server is a geyser plugin serving the Geyser service of Yellowstone gRPC.
+// Each subscriber buffers events, and is disconnected once it falls
+// behind by more than its buffer, so that it cannot stall replay.
+type Server struct {
+	bufferSize int
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	// blocks processed and not rooted yet, by slot
+	blocks    map[uint64]*geyser.BlockMetadata
+	processed *geyser.BlockMetadata
+	finalized *geyser.BlockMetadata
+}
+
+type subscriber struct {
+	events chan geyser.Event
+	// closed once the subscriber is dropped for lagging behind
+	lagged chan struct{}
+}
+
+// NewServer returns a server buffering up to bufferSize events for each
+// subscriber.
+func NewServer(bufferSize int) *Server {
+	return &Server{
+		bufferSize:  bufferSize,
+		subscribers: make(map[*subscriber]struct{}),
+		blocks:      make(map[uint64]*geyser.BlockMetadata),
+	}
+}
+
+// ListenAndServe serves the Geyser service on addr, over cleartext HTTP/2,
+// until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: h2c.NewHandler(s, &http2.Server{}),
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) addSubscriber() *subscriber {
+	sub := &subscriber{
+		events: make(chan geyser.Event, s.bufferSize),
+		lagged: make(chan struct{}),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (s *Server) removeSubscriber(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers, sub)
+}
+
+func (s *Server) hasSubscribers() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers) != 0
+}
+
+func (s *Server) broadcast(event geyser.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			klog.Warning("Dropping lagging geyser gRPC subscriber")
+			delete(s.subscribers, sub)
+			close(sub.lagged)
+		}
+	}
+}
+
+func (s *Server) UpdateAccount(update *geyser.AccountUpdate) error {
+	if update.IsStartup || !s.hasSubscribers() {
+		return nil
+	}
+	// the account may be modified by later transactions before it is sent
+	acct := *update.Account
+	acct.Data = append([]byte(nil), acct.Data...)
+	copied := *update
+	copied.Account = &acct
+	s.broadcast(geyser.Event{Account: &copied})
+	return nil
+}
+
+func (s *Server) NotifyEndOfStartup() error {
+	return nil
+}
+
+func (s *Server) UpdateSlotStatus(update *geyser.SlotUpdate) error {
+	if update.Status == geyser.SlotStatusRooted {
+		s.mu.Lock()
+		if meta, ok := s.blocks[update.Slot]; ok {
+			s.finalized = meta
+		}
+		for slot := range s.blocks {
+			if slot <= update.Slot {
+				delete(s.blocks, slot)
+			}
+		}
+		s.mu.Unlock()
+	}
+	s.broadcast(geyser.Event{Slot: update})
+	return nil
+}
+
+func (s *Server) NotifyTransaction(update *geyser.TransactionUpdate) error {
+	s.broadcast(geyser.Event{Transaction: update})
+	return nil
+}
+
+func (s *Server) NotifyBlockMetadata(meta *geyser.BlockMetadata) error {
+	s.mu.Lock()
+	s.blocks[meta.Slot] = meta
+	s.processed = meta
+	s.mu.Unlock()
+	s.broadcast(geyser.Event{Block: meta})
+	return nil
+}
+
+// ServeHTTP serves the gRPC methods of the Geyser service.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "only gRPC is supported", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	var err error
+	switch r.URL.Path {
+	case "/geyser.Geyser/Subscribe":
+		err = s.subscribe(w, r)
+	case "/geyser.Geyser/Ping":
+		err = unary(w, r, s.ping)
+	case "/geyser.Geyser/GetLatestBlockhash":
+		err = unary(w, r, s.getLatestBlockhash)
+	case "/geyser.Geyser/GetBlockHeight":
+		err = unary(w, r, s.getBlockHeight)
+	case "/geyser.Geyser/GetSlot":
+		err = unary(w, r, s.getSlot)
+	case "/geyser.Geyser/GetVersion":
+		err = unary(w, r, s.getVersion)
+	default:
+		err = &statusError{codeUnimplemented, fmt.Sprintf("method %s is not implemented", r.URL.Path)}
+	}
+	writeStatus(w, err)
+}
+
+// writeStatus sets the gRPC status trailers of a response.
+func writeStatus(w http.ResponseWriter, err error) {
+	code, msg := codeOK, ""
+	if err != nil {
+		var st *statusError
+		if errors.As(err, &st) {
+			code, msg = st.code, st.msg
+		} else {
+			code, msg = codeInternal, err.Error()
+		}
+	}
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if msg != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", encodeStatusMessage(msg))
+	}
+}
+
+// encodeStatusMessage percent-encodes a status message, as required of the
+// grpc-message header.
+func encodeStatusMessage(msg string) string {
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// readMessage reads a length-prefixed gRPC message.
+func readMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	if prefix[0] != 0 {
+		return nil, &statusError{codeUnimplemented, "compressed messages are not supported"}
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxMessageSize {
+		return nil, &statusError{codeResourceExhausted, fmt.Sprintf("message of %d bytes is too large", size)}
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// writeMessage writes a length-prefixed gRPC message, and flushes it.
+func writeMessage(w http.ResponseWriter, msg []byte) error {
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(msg)))
+	if _, err := w.Write(append(prefix[:], msg...)); err != nil {
+		return err
+	}
+	w.(http.Flusher).Flush()
+	return nil
+}
+
+// unary serves a unary method, of which fn returns the response to a
+// request.
+func unary(w http.ResponseWriter, r *http.Request, fn func(req []byte) ([]byte, error)) error {
+	req, err := readMessage(r.Body)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return &statusError{codeInvalidArgument, "missing request"}
+		}
+		return err
+	}
+	resp, err := fn(req)
+	if err != nil {
+		return err
+	}
+	return writeMessage(w, resp)
+}
+
+// commitment returns the commitment field of a request.
+func commitment(req []byte, num protowire.Number) (uint64, error) {
+	var level uint64
+	err := forEachField(req, func(n protowire.Number, v interface{}) (err error) {
+		if n == num {
+			level, err = fieldUint64(n, v)
+		}
+		return
+	})
+	if err != nil {
+		return 0, &statusError{codeInvalidArgument, err.Error()}
+	}
+	return level, nil
+}
+
+// block returns the latest block of the given commitment.
+func (s *Server) block(level uint64) (*geyser.BlockMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var meta *geyser.BlockMetadata
+	switch level {
+	case CommitmentProcessed:
+		meta = s.processed
+	case CommitmentFinalized:
+		meta = s.finalized
+	default:
+		return nil, &statusError{codeInvalidArgument, fmt.Sprintf("commitment %d is not supported", level)}
+	}
+	if meta == nil {
+		return nil, &statusError{codeUnavailable, "no block replayed yet"}
+	}
+	return meta, nil
+}
+
+func (s *Server) ping(req []byte) ([]byte, error) {
+	var count uint64
+	err := forEachField(req, func(num protowire.Number, v interface{}) (err error) {
+		if num == 1 {
+			count, err = fieldUint64(num, v)
+		}
+		return
+	})
+	if err != nil {
+		return nil, &statusError{codeInvalidArgument, err.Error()}
+	}
+	return appendUint64(nil, 1, count), nil
+}
+
+func (s *Server) getLatestBlockhash(req []byte) ([]byte, error) {
+	level, err := commitment(req, 1)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := s.block(level)
+	if err != nil {
+		return nil, err
+	}
+	b := appendUint64(nil, 1, meta.Slot)
+	b = appendString(b, 2, base58.Encode(meta.Blockhash[:]))
+	return appendUint64(b, 3, meta.BlockHeight+bank.MaxProcessingAge), nil
+}
+
+func (s *Server) getBlockHeight(req []byte) ([]byte, error) {
+	level, err := commitment(req, 1)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := s.block(level)
+	if err != nil {
+		return nil, err
+	}
+	return appendUint64(nil, 1, meta.BlockHeight), nil
+}
+
+func (s *Server) getSlot(req []byte) ([]byte, error) {
+	level, err := commitment(req, 1)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := s.block(level)
+	if err != nil {
+		return nil, err
+	}
+	return appendUint64(nil, 1, meta.Slot), nil
+}
+
+func (s *Server) getVersion([]byte) ([]byte, error) {
+	return appendString(nil, 1, `{"package":"radiance"}`), nil
+}
+
+// subscribe serves a Subscribe stream. Each request received on the stream
+// replaces the filters of the subscription, except for requests holding
+// only a ping, which are answered with a pong.
+func (s *Server) subscribe(w http.ResponseWriter, r *http.Request) error {
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	ctx := r.Context()
+	requests := make(chan *subscribeRequest)
+	readErrs := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := readMessage(r.Body)
+			if err != nil {
+				readErrs <- err
+				return
+			}
+			req, err := decodeSubscribeRequest(msg)
+			if err != nil {
+				readErrs <- &statusError{codeInvalidArgument, err.Error()}
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	sub := s.addSubscriber()
+	defer s.removeSubscriber(sub)
+	subscription := newSubscription(&subscribeRequest{})
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		var updates [][]byte
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErrs:
+			if errors.Is(err, io.EOF) {
+				// the client is done sending requests, but may still
+				// receive updates
+				readErrs = nil
+				continue
+			}
+			return err
+		case req := <-requests:
+			if req.ping != nil {
+				updates = append(updates, appendUpdate(nil, nil, time.Now(), 9, func(b []byte) []byte {
+					return appendUint64(b, 1, uint64(int64(*req.ping)))
+				}))
+			}
+			if req.ping == nil || !req.empty() {
+				subscription = newSubscription(req)
+			}
+		case event := <-sub.events:
+			var err error
+			updates, err = subscription.update(&event, time.Now())
+			if err != nil {
+				return err
+			}
+		case <-sub.lagged:
+			return &statusError{codeResourceExhausted, "subscriber lagged behind replay"}
+		case now := <-ticker.C:
+			updates = append(updates, appendUpdate(nil, nil, now, 6, func(b []byte) []byte { return b }))
+		}
+		for _, update := range updates {
+			if err := writeMessage(w, update); err != nil {
+				return err
+			}
+		}
+	}
+}
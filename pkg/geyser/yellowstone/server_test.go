@@ -0,0 +1,211 @@
+package yellowstone
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func appendMapEntry(b []byte, num protowire.Number, key string, fn func(b []byte) []byte) []byte {
+	return appendMessage(b, num, func(b []byte) []byte {
+		b = appendOptionalBytes(b, 1, []byte(key))
+		return appendMessage(b, 2, fn)
+	})
+}
+
+// fields returns the fields of an encoded message, by number.
+func fields(t *testing.T, data []byte) map[protowire.Number][]interface{} {
+	out := make(map[protowire.Number][]interface{})
+	require.NoError(t, forEachField(data, func(num protowire.Number, v interface{}) error {
+		out[num] = append(out[num], v)
+		return nil
+	}))
+	return out
+}
+
+func TestDecodeSubscribeRequest(t *testing.T) {
+	owner := [32]byte{1}
+	req := appendMapEntry(nil, 1, "owned", func(b []byte) []byte {
+		b = appendString(b, 3, base58.Encode(owner[:]))
+		return appendMessage(b, 4, func(b []byte) []byte {
+			return appendMessage(b, 4, func(b []byte) []byte {
+				return appendUint64(b, 4, 100) // gt
+			})
+		})
+	})
+	req = appendMapEntry(req, 3, "votes", func(b []byte) []byte {
+		return appendBool(b, 1, true)
+	})
+	req = appendMessage(req, 7, func(b []byte) []byte {
+		b = appendUint64(b, 1, 1)
+		return appendUint64(b, 2, 2)
+	})
+	decoded, err := decodeSubscribeRequest(req)
+	require.NoError(t, err)
+
+	require.Contains(t, decoded.accounts, "owned")
+	filter := decoded.accounts["owned"]
+	assert.True(t, filter.matches(&geyser.AccountUpdate{Account: &accounts.Account{Owner: owner, Lamports: 101}}))
+	assert.False(t, filter.matches(&geyser.AccountUpdate{Account: &accounts.Account{Owner: owner, Lamports: 100}}))
+	assert.False(t, filter.matches(&geyser.AccountUpdate{Account: &accounts.Account{Lamports: 101}}))
+
+	require.Contains(t, decoded.transactions, "votes")
+	tx := &geyser.TransactionUpdate{Transaction: &solana.Transaction{}, Meta: &sealevel.TransactionStatusMeta{}}
+	assert.False(t, decoded.transactions["votes"].matches(tx))
+	tx.IsVote = true
+	assert.True(t, decoded.transactions["votes"].matches(tx))
+
+	assert.Equal(t, []byte{2, 3}, sliceData([]byte{1, 2, 3, 4}, decoded.dataSlices))
+
+	_, err = decodeSubscribeRequest(appendUint64(nil, 6, CommitmentConfirmed))
+	assert.Error(t, err)
+}
+
+func TestIsTokenAccount(t *testing.T) {
+	data := make([]byte, tokenAccountSize)
+	assert.False(t, isTokenAccount(data))
+	data[tokenAccountStateOffset] = 1
+	assert.True(t, isTokenAccount(data))
+	assert.False(t, isTokenAccount(append(data, 1)))
+	assert.True(t, isTokenAccount(append(data, tokenAccountTypeAccount)))
+}
+
+func TestSubscriptionFinalized(t *testing.T) {
+	sub := newSubscription(&subscribeRequest{
+		commitment: CommitmentFinalized,
+		accounts:   map[string]*accountsFilter{"all": {}},
+	})
+	update := func(event geyser.Event) int {
+		updates, err := sub.update(&event, time.Time{})
+		require.NoError(t, err)
+		return len(updates)
+	}
+	acct := &accounts.Account{}
+	assert.Equal(t, 0, update(geyser.Event{Account: &geyser.AccountUpdate{Slot: 1, Account: acct}}))
+	assert.Equal(t, 0, update(geyser.Event{Account: &geyser.AccountUpdate{Slot: 2, Account: acct}}))
+	assert.Equal(t, 0, update(geyser.Event{Account: &geyser.AccountUpdate{Slot: 3, Account: acct}}))
+	// slot 1 was skipped
+	assert.Equal(t, 1, update(geyser.Event{Slot: &geyser.SlotUpdate{Slot: 2, Status: geyser.SlotStatusRooted}}))
+	assert.Equal(t, 1, update(geyser.Event{Slot: &geyser.SlotUpdate{Slot: 3, Status: geyser.SlotStatusRooted}}))
+	assert.Empty(t, sub.pending)
+}
+
+func TestServerSubscribe(t *testing.T) {
+	srv := NewServer(16)
+	ts := httptest.NewServer(h2c.NewHandler(srv, &http2.Server{}))
+	defer ts.Close()
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+
+	body, reqWriter := io.Pipe()
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/geyser.Geyser/Subscribe", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc")
+	go func() {
+		owner := [32]byte{1}
+		msg := appendMapEntry(nil, 1, "owned", func(b []byte) []byte {
+			return appendString(b, 3, base58.Encode(owner[:]))
+		})
+		msg = appendMapEntry(msg, 2, "slots", func(b []byte) []byte { return b })
+		msg = appendMessage(msg, 9, func(b []byte) []byte { return appendUint64(b, 1, 7) })
+		var prefix [5]byte
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(msg)))
+		reqWriter.Write(append(prefix[:], msg...))
+	}()
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the pong is sent once the filters are applied
+	msg, err := readMessage(resp.Body)
+	require.NoError(t, err)
+	pong := fields(t, msg)[9]
+	require.Len(t, pong, 1)
+	assert.Equal(t, []interface{}{uint64(7)}, fields(t, pong[0].([]byte))[1])
+
+	require.NoError(t, srv.UpdateAccount(&geyser.AccountUpdate{Slot: 5, Pubkey: [32]byte{2}, Account: &accounts.Account{Lamports: 3}}))
+	require.NoError(t, srv.UpdateAccount(&geyser.AccountUpdate{Slot: 5, Pubkey: [32]byte{3}, Account: &accounts.Account{Lamports: 4, Owner: [32]byte{1}}}))
+	require.NoError(t, srv.UpdateSlotStatus(&geyser.SlotUpdate{Slot: 5, Parent: 4, Status: geyser.SlotStatusCreatedBank}))
+	require.NoError(t, srv.UpdateSlotStatus(&geyser.SlotUpdate{Slot: 5, Parent: 4, Status: geyser.SlotStatusProcessed}))
+
+	msg, err = readMessage(resp.Body)
+	require.NoError(t, err)
+	update := fields(t, msg)
+	assert.Equal(t, []interface{}{[]byte("owned")}, update[1])
+	require.Len(t, update[2], 1)
+	account := fields(t, update[2][0].([]byte))
+	assert.Equal(t, []interface{}{uint64(5)}, account[2])
+	info := fields(t, account[1][0].([]byte))
+	pubkey := [32]byte{3}
+	assert.Equal(t, []interface{}{pubkey[:]}, info[1])
+	assert.Equal(t, []interface{}{uint64(4)}, info[2])
+
+	msg, err = readMessage(resp.Body)
+	require.NoError(t, err)
+	update = fields(t, msg)
+	assert.Equal(t, []interface{}{[]byte("slots")}, update[1])
+	slot := fields(t, update[3][0].([]byte))
+	assert.Equal(t, []interface{}{uint64(5)}, slot[1])
+	assert.Equal(t, []interface{}{uint64(4)}, slot[2])
+	assert.Nil(t, slot[3]) // processed
+}
+
+func TestServerUnary(t *testing.T) {
+	srv := NewServer(16)
+	call := func(method string, req []byte) (*httptest.ResponseRecorder, []byte) {
+		var prefix [5]byte
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(req)))
+		r := httptest.NewRequest(http.MethodPost, "/geyser.Geyser/"+method, bytes.NewReader(append(prefix[:], req...)))
+		r.Header.Set("Content-Type", "application/grpc")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		msg, err := readMessage(w.Body)
+		if err != nil {
+			return w, nil
+		}
+		return w, msg
+	}
+
+	w, msg := call("Ping", appendUint64(nil, 1, 3))
+	assert.Equal(t, "0", w.Header().Get(http.TrailerPrefix+"Grpc-Status"))
+	assert.Equal(t, []interface{}{uint64(3)}, fields(t, msg)[1])
+
+	w, _ = call("GetSlot", nil)
+	assert.Equal(t, "14", w.Header().Get(http.TrailerPrefix+"Grpc-Status"))
+
+	require.NoError(t, srv.NotifyBlockMetadata(&geyser.BlockMetadata{Slot: 10, BlockHeight: 8}))
+	_, msg = call("GetSlot", nil)
+	assert.Equal(t, []interface{}{uint64(10)}, fields(t, msg)[1])
+	w, _ = call("GetSlot", appendUint64(nil, 1, CommitmentFinalized))
+	assert.Equal(t, "14", w.Header().Get(http.TrailerPrefix+"Grpc-Status"))
+	require.NoError(t, srv.UpdateSlotStatus(&geyser.SlotUpdate{Slot: 10, Status: geyser.SlotStatusRooted}))
+	_, msg = call("GetBlockHeight", appendUint64(nil, 1, CommitmentFinalized))
+	assert.Equal(t, []interface{}{uint64(8)}, fields(t, msg)[1])
+
+	w, _ = call("SubscribeReplayInfo", nil)
+	assert.Equal(t, "12", w.Header().Get(http.TrailerPrefix+"Grpc-Status"))
+}
@@ -0,0 +1,132 @@
+package yellowstone
+
+import (
+	"sort"
+	"time"
+
+	"go.firedancer.io/radiance/pkg/geyser"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// subscription filters the events of replay for a Subscribe stream, and
+// encodes the updates that matched. Updates of finalized subscriptions are
+// held back until their slot is rooted.
+type subscription struct {
+	req *subscribeRequest
+	// updates held back by slot, for finalized subscriptions
+	pending map[uint64][][]byte
+}
+
+func newSubscription(req *subscribeRequest) *subscription {
+	return &subscription{req: req, pending: make(map[uint64][][]byte)}
+}
+
+// update returns the encoded updates to send for an event.
+func (s *subscription) update(event *geyser.Event, now time.Time) ([][]byte, error) {
+	req := s.req
+	var out [][]byte
+	switch {
+	case event.Account != nil:
+		if event.Account.IsStartup {
+			return nil, nil
+		}
+		var filters []string
+		for name, filter := range req.accounts {
+			if filter.matches(event.Account) {
+				filters = append(filters, name)
+			}
+		}
+		if len(filters) != 0 {
+			out = append(out, appendUpdate(nil, sortedNames(filters), now, 2, func(b []byte) []byte {
+				return appendAccountUpdate(b, event.Account, req.dataSlices)
+			}))
+		}
+		return s.hold(event.Account.Slot, out), nil
+	case event.Slot != nil:
+		var filters []string
+		for name, filter := range req.slots {
+			if filter.matches(event.Slot, req.commitment) {
+				filters = append(filters, name)
+			}
+		}
+		if event.Slot.Status == geyser.SlotStatusRooted && req.commitment == CommitmentFinalized {
+			out = s.release(event.Slot.Slot)
+		}
+		if len(filters) != 0 {
+			out = append(out, appendUpdate(nil, sortedNames(filters), now, 3, func(b []byte) []byte {
+				return appendSlotUpdate(b, event.Slot)
+			}))
+		}
+		return out, nil
+	case event.Transaction != nil:
+		for _, field := range []struct {
+			filters map[string]*transactionsFilter
+			num     protowire.Number
+			encode  func([]byte, *geyser.TransactionUpdate) ([]byte, error)
+		}{
+			{req.transactions, 4, appendTransactionUpdate},
+			{req.transactionsStatus, 10, appendTransactionStatusUpdate},
+		} {
+			var filters []string
+			for name, filter := range field.filters {
+				if filter.matches(event.Transaction) {
+					filters = append(filters, name)
+				}
+			}
+			if len(filters) == 0 {
+				continue
+			}
+			var err error
+			update := appendUpdate(nil, sortedNames(filters), now, field.num, func(b []byte) []byte {
+				var msg []byte
+				msg, err = field.encode(b, event.Transaction)
+				return msg
+			})
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, update)
+		}
+		return s.hold(event.Transaction.Slot, out), nil
+	case event.Block != nil:
+		if len(req.blocksMeta) != 0 {
+			filters := make([]string, 0, len(req.blocksMeta))
+			for name := range req.blocksMeta {
+				filters = append(filters, name)
+			}
+			out = append(out, appendUpdate(nil, sortedNames(filters), now, 7, func(b []byte) []byte {
+				return appendBlockMetaUpdate(b, event.Block)
+			}))
+		}
+		return s.hold(event.Block.Slot, out), nil
+	}
+	return nil, nil
+}
+
+// hold returns the updates of a slot to send now: all of them for processed
+// subscriptions, none for finalized ones, which send them once the slot is
+// rooted.
+func (s *subscription) hold(slot uint64, updates [][]byte) [][]byte {
+	if s.req.commitment != CommitmentFinalized || len(updates) == 0 {
+		return updates
+	}
+	s.pending[slot] = append(s.pending[slot], updates...)
+	return nil
+}
+
+// release returns the updates held back for a rooted slot, and drops those
+// of older slots, which were not rooted.
+func (s *subscription) release(root uint64) [][]byte {
+	updates := s.pending[root]
+	for slot := range s.pending {
+		if slot <= root {
+			delete(s.pending, slot)
+		}
+	}
+	return updates
+}
+
+func sortedNames(names []string) []string {
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,149 @@
+package yellowstone
+
+import (
+	"time"
+
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Slot statuses of geyser.proto.
+const (
+	slotStatusProcessed          = 0
+	slotStatusConfirmed          = 1
+	slotStatusFinalized          = 2
+	slotStatusFirstShredReceived = 3
+	slotStatusCompleted          = 4
+	slotStatusCreatedBank        = 5
+	slotStatusDead               = 6
+)
+
+func slotStatus(status geyser.SlotStatus) uint64 {
+	switch status {
+	case geyser.SlotStatusConfirmed:
+		return slotStatusConfirmed
+	case geyser.SlotStatusRooted:
+		return slotStatusFinalized
+	case geyser.SlotStatusFirstShredReceived:
+		return slotStatusFirstShredReceived
+	case geyser.SlotStatusCompleted:
+		return slotStatusCompleted
+	case geyser.SlotStatusCreatedBank:
+		return slotStatusCreatedBank
+	case geyser.SlotStatusDead:
+		return slotStatusDead
+	default:
+		return slotStatusProcessed
+	}
+}
+
+// appendUpdate appends a geyser.SubscribeUpdate, of which the filters are
+// the names of the filters that matched, and the update is the field num
+// appended by fn.
+func appendUpdate(b []byte, filters []string, now time.Time, num protowire.Number, fn func(b []byte) []byte) []byte {
+	for _, name := range filters {
+		b = appendOptionalBytes(b, 1, []byte(name))
+	}
+	b = appendMessage(b, num, fn)
+	return appendMessage(b, 11, func(b []byte) []byte {
+		b = appendUint64(b, 1, uint64(now.Unix()))
+		return appendUint64(b, 2, uint64(now.Nanosecond()))
+	})
+}
+
+// appendAccountUpdate appends a geyser.SubscribeUpdateAccount. If slices
+// are given, only these slices of the data are sent, concatenated.
+func appendAccountUpdate(b []byte, update *geyser.AccountUpdate, slices []dataSlice) []byte {
+	acct := update.Account
+	b = appendMessage(b, 1, func(b []byte) []byte {
+		b = appendBytes(b, 1, update.Pubkey[:])
+		b = appendUint64(b, 2, acct.Lamports)
+		b = appendBytes(b, 3, acct.Owner[:])
+		b = appendBool(b, 4, acct.Executable)
+		b = appendUint64(b, 5, acct.RentEpoch)
+		b = appendBytes(b, 6, sliceData(acct.Data, slices))
+		b = appendUint64(b, 7, update.WriteVersion)
+		if update.TxSignature != nil {
+			b = appendOptionalBytes(b, 8, update.TxSignature[:])
+		}
+		return b
+	})
+	b = appendUint64(b, 2, update.Slot)
+	return appendBool(b, 3, update.IsStartup)
+}
+
+// sliceData returns the concatenation of the given slices of data, which
+// are truncated to its length.
+func sliceData(data []byte, slices []dataSlice) []byte {
+	if len(slices) == 0 {
+		return data
+	}
+	var out []byte
+	for _, slice := range slices {
+		if slice.offset >= uint64(len(data)) {
+			continue
+		}
+		end := uint64(len(data))
+		if slice.length < end-slice.offset {
+			end = slice.offset + slice.length
+		}
+		out = append(out, data[slice.offset:end]...)
+	}
+	return out
+}
+
+// appendSlotUpdate appends a geyser.SubscribeUpdateSlot.
+func appendSlotUpdate(b []byte, update *geyser.SlotUpdate) []byte {
+	b = appendUint64(b, 1, update.Slot)
+	b = appendOptionalUint64(b, 2, update.Parent)
+	return appendUint64(b, 3, slotStatus(update.Status))
+}
+
+// appendTransactionUpdate appends a geyser.SubscribeUpdateTransaction.
+func appendTransactionUpdate(b []byte, update *geyser.TransactionUpdate) ([]byte, error) {
+	var err error
+	b = appendMessage(b, 1, func(b []byte) []byte {
+		b = appendBytes(b, 1, update.Signature[:])
+		b = appendBool(b, 2, update.IsVote)
+		b = appendMessage(b, 3, func(b []byte) []byte {
+			return appendTransaction(b, update.Transaction)
+		})
+		b = appendMessage(b, 4, func(b []byte) []byte {
+			var meta []byte
+			meta, err = appendTransactionStatusMeta(b, update.Meta)
+			return meta
+		})
+		return appendUint64(b, 5, update.Index)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appendUint64(b, 2, update.Slot), nil
+}
+
+// appendTransactionStatusUpdate appends a
+// geyser.SubscribeUpdateTransactionStatus.
+func appendTransactionStatusUpdate(b []byte, update *geyser.TransactionUpdate) ([]byte, error) {
+	b = appendUint64(b, 1, update.Slot)
+	b = appendBytes(b, 2, update.Signature[:])
+	b = appendBool(b, 3, update.IsVote)
+	b = appendUint64(b, 4, update.Index)
+	if update.Meta.Err != nil {
+		return appendTransactionError(b, 5, update.Meta.Err)
+	}
+	return b, nil
+}
+
+// appendBlockMetaUpdate appends a geyser.SubscribeUpdateBlockMeta.
+func appendBlockMetaUpdate(b []byte, meta *geyser.BlockMetadata) []byte {
+	b = appendUint64(b, 1, meta.Slot)
+	b = appendString(b, 2, base58.Encode(meta.Blockhash[:]))
+	b = appendMessage(b, 5, func(b []byte) []byte {
+		return appendUint64(b, 1, meta.BlockHeight)
+	})
+	b = appendUint64(b, 6, meta.ParentSlot)
+	b = appendString(b, 7, base58.Encode(meta.ParentBlockhash[:]))
+	b = appendUint64(b, 8, meta.ExecutedTransactionCount)
+	return appendUint64(b, 9, meta.EntryCount)
+}
@@ -2,7 +2,6 @@ package global
 
 import (
 	"go.firedancer.io/radiance/pkg/accounts"
-	"go.firedancer.io/radiance/pkg/bank"
 	"go.firedancer.io/radiance/pkg/features"
 )
 
@@ -12,8 +11,6 @@ type GlobalCtx struct {
 	Accounts *accounts.Accounts
 	Leader   [32]byte
 	Features features.Features
-	Bank     *bank.Bank
-	// TODO: Bank
 }
 
 func NewGlobalCtxDefault() *GlobalCtx {
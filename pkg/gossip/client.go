@@ -59,6 +59,9 @@ func (c *Driver) Run(ctx context.Context) error {
 // Handler is a network-agnostic multiplexer for incoming gossip messages.
 type Handler struct {
 	*PullClient
+	*PullServer
+	*PushServer
+	*PushClient
 	*PingClient
 	*PingServer
 
@@ -74,6 +77,21 @@ func (h *Handler) HandlePacket(packet []byte, from netip.AddrPort) {
 		return
 	}
 	switch x := msg.(type) {
+	case *Message__PullRequest:
+		if h.PullServer != nil {
+			h.PullServer.HandlePullRequest(x, from)
+			return
+		}
+	case *Message__PushMessage:
+		if h.PushServer != nil {
+			h.PushServer.HandlePushMessage(x, from)
+			return
+		}
+	case *Message__PruneMessage:
+		if h.PushClient != nil {
+			h.PushClient.HandlePruneMessage(x, from)
+			return
+		}
 	case *Message__PullResponse:
 		if h.PullClient != nil {
 			h.PullClient.HandlePullResponse(x, from)
@@ -95,7 +113,9 @@ func (h *Handler) HandlePacket(packet []byte, from netip.AddrPort) {
 
 // Close destroys all handlers.
 func (h *Handler) Close() {
-	h.PingClient.Close()
+	if h.PingClient != nil {
+		h.PingClient.Close()
+	}
 }
 
 type udpSender interface {
@@ -0,0 +1,314 @@
+package gossip
+
+import (
+	"errors"
+	"net/netip"
+	"sort"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/serde"
+)
+
+// Socket keys of ContactInfoV2.
+//
+// Based on solana_gossip::contact_info.
+const (
+	SocketGossip          = uint8(0)
+	SocketRepair          = uint8(1)
+	SocketRPC             = uint8(2)
+	SocketRPCPubsub       = uint8(3)
+	SocketServeRepair     = uint8(4)
+	SocketTPU             = uint8(5)
+	SocketTPUForwards     = uint8(6)
+	SocketTPUForwardsQUIC = uint8(7)
+	SocketTPUQUIC         = uint8(8)
+	SocketTPUVote         = uint8(9)
+	SocketTVU             = uint8(10)
+	SocketTVUQUIC         = uint8(11)
+)
+
+// ContactInfoV2 is the contact info of a node, which replaces the legacy
+// ContactInfo, with a variable number of sockets.
+//
+// Based on solana_gossip::contact_info::ContactInfo.
+type ContactInfoV2 struct {
+	Pubkey       Pubkey
+	Wallclock    uint64
+	Outset       uint64 // when the node instance was first created (µs)
+	ShredVersion uint16
+	Version      ClientVersion
+	Addrs        []netip.Addr
+	Sockets      []SocketEntry
+}
+
+// ClientVersion is the software version of a node.
+//
+// Based on solana_version::Version.
+type ClientVersion struct {
+	Major      uint16
+	Minor      uint16
+	Patch      uint16
+	Commit     uint32
+	FeatureSet uint32
+	Client     uint16
+}
+
+// SocketEntry locates a socket of a ContactInfoV2. The port of an entry
+// is the sum of the offsets of it and the entries preceding it.
+type SocketEntry struct {
+	Key    uint8
+	Index  uint8 // index into Addrs
+	Offset uint16
+}
+
+// Socket returns the socket of the given key, if any.
+func (c *ContactInfoV2) Socket(key uint8) (netip.AddrPort, bool) {
+	var port uint16
+	for _, entry := range c.Sockets {
+		port += entry.Offset
+		if entry.Key != key {
+			continue
+		}
+		if int(entry.Index) >= len(c.Addrs) {
+			return netip.AddrPort{}, false
+		}
+		return netip.AddrPortFrom(c.Addrs[entry.Index], port), true
+	}
+	return netip.AddrPort{}, false
+}
+
+// SetSockets replaces the sockets of the contact info.
+func (c *ContactInfoV2) SetSockets(sockets map[uint8]netip.AddrPort) {
+	keys := make([]uint8, 0, len(sockets))
+	for key := range sockets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := sockets[keys[i]].Port(), sockets[keys[j]].Port()
+		if pi != pj {
+			return pi < pj
+		}
+		return keys[i] < keys[j]
+	})
+
+	c.Addrs = c.Addrs[:0]
+	c.Sockets = c.Sockets[:0]
+	var port uint16
+	for _, key := range keys {
+		sock := sockets[key]
+		index := -1
+		for i, addr := range c.Addrs {
+			if addr == sock.Addr() {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			index = len(c.Addrs)
+			c.Addrs = append(c.Addrs, sock.Addr())
+		}
+		c.Sockets = append(c.Sockets, SocketEntry{
+			Key:    key,
+			Index:  uint8(index),
+			Offset: sock.Port() - port,
+		})
+		port = sock.Port()
+	}
+}
+
+func DeserializeContactInfoV2(deserializer serde.Deserializer) (obj ContactInfoV2, err error) {
+	if obj.Pubkey, err = DeserializePubkey(deserializer); err != nil {
+		return
+	}
+	if obj.Wallclock, err = deserializeVarint(deserializer, 64); err != nil {
+		return
+	}
+	if obj.Outset, err = deserializer.DeserializeU64(); err != nil {
+		return
+	}
+	if obj.ShredVersion, err = deserializer.DeserializeU16(); err != nil {
+		return
+	}
+	if obj.Version, err = deserializeClientVersion(deserializer); err != nil {
+		return
+	}
+
+	numAddrs, err := deserializeShortVecLen(deserializer)
+	if err != nil {
+		return
+	}
+	for i := 0; i < numAddrs; i++ {
+		var raw RawAddr
+		if raw, err = DeserializeRawAddr(deserializer); err != nil {
+			return
+		}
+		switch x := raw.(type) {
+		case *RawAddr__V4:
+			obj.Addrs = append(obj.Addrs, netip.AddrFrom4(*x))
+		case *RawAddr__V6:
+			obj.Addrs = append(obj.Addrs, netip.AddrFrom16(*x))
+		}
+	}
+
+	numSockets, err := deserializeShortVecLen(deserializer)
+	if err != nil {
+		return
+	}
+	for i := 0; i < numSockets; i++ {
+		var entry SocketEntry
+		if entry.Key, err = deserializer.DeserializeU8(); err != nil {
+			return
+		}
+		if entry.Index, err = deserializer.DeserializeU8(); err != nil {
+			return
+		}
+		var offset uint64
+		if offset, err = deserializeVarint(deserializer, 16); err != nil {
+			return
+		}
+		entry.Offset = uint16(offset)
+		obj.Sockets = append(obj.Sockets, entry)
+	}
+
+	numExtensions, err := deserializeShortVecLen(deserializer)
+	if err != nil {
+		return
+	}
+	if numExtensions != 0 {
+		err = errors.New("unsupported contact info extension")
+	}
+	return
+}
+
+func (obj *ContactInfoV2) Serialize(serializer serde.Serializer) error {
+	if err := obj.Pubkey.Serialize(serializer); err != nil {
+		return err
+	}
+	if err := serializeVarint(serializer, obj.Wallclock); err != nil {
+		return err
+	}
+	if err := serializer.SerializeU64(obj.Outset); err != nil {
+		return err
+	}
+	if err := serializer.SerializeU16(obj.ShredVersion); err != nil {
+		return err
+	}
+	if err := obj.Version.serialize(serializer); err != nil {
+		return err
+	}
+
+	if err := serializeVarint(serializer, uint64(len(obj.Addrs))); err != nil {
+		return err
+	}
+	for _, addr := range obj.Addrs {
+		var raw RawAddr
+		if addr.Is4() {
+			v4 := RawAddr__V4(addr.As4())
+			raw = &v4
+		} else {
+			v6 := RawAddr__V6(addr.As16())
+			raw = &v6
+		}
+		if err := raw.Serialize(serializer); err != nil {
+			return err
+		}
+	}
+
+	if err := serializeVarint(serializer, uint64(len(obj.Sockets))); err != nil {
+		return err
+	}
+	for _, entry := range obj.Sockets {
+		if err := serializer.SerializeU8(entry.Key); err != nil {
+			return err
+		}
+		if err := serializer.SerializeU8(entry.Index); err != nil {
+			return err
+		}
+		if err := serializeVarint(serializer, uint64(entry.Offset)); err != nil {
+			return err
+		}
+	}
+
+	// no extensions
+	return serializeVarint(serializer, 0)
+}
+
+func deserializeClientVersion(deserializer serde.Deserializer) (v ClientVersion, err error) {
+	var x uint64
+	if x, err = deserializeVarint(deserializer, 16); err != nil {
+		return
+	}
+	v.Major = uint16(x)
+	if x, err = deserializeVarint(deserializer, 16); err != nil {
+		return
+	}
+	v.Minor = uint16(x)
+	if x, err = deserializeVarint(deserializer, 16); err != nil {
+		return
+	}
+	v.Patch = uint16(x)
+	if v.Commit, err = deserializer.DeserializeU32(); err != nil {
+		return
+	}
+	if v.FeatureSet, err = deserializer.DeserializeU32(); err != nil {
+		return
+	}
+	if x, err = deserializeVarint(deserializer, 16); err != nil {
+		return
+	}
+	v.Client = uint16(x)
+	return
+}
+
+func (v *ClientVersion) serialize(serializer serde.Serializer) error {
+	for _, x := range []uint16{v.Major, v.Minor, v.Patch} {
+		if err := serializeVarint(serializer, uint64(x)); err != nil {
+			return err
+		}
+	}
+	if err := serializer.SerializeU32(v.Commit); err != nil {
+		return err
+	}
+	if err := serializer.SerializeU32(v.FeatureSet); err != nil {
+		return err
+	}
+	return serializeVarint(serializer, uint64(v.Client))
+}
+
+var errVarint = errors.New("invalid varint")
+
+// deserializeVarint reads a LEB128 integer of the given bit size.
+//
+// Based on solana_serde_varint.
+func deserializeVarint(deserializer serde.Deserializer, bits uint) (uint64, error) {
+	var x uint64
+	for shift := uint(0); shift < bits; shift += 7 {
+		b, err := deserializer.DeserializeU8()
+		if err != nil {
+			return 0, err
+		}
+		x |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			if (shift > 0 && b == 0) || (bits < 64 && x>>bits != 0) {
+				return 0, errVarint
+			}
+			return x, nil
+		}
+	}
+	return 0, errVarint
+}
+
+func serializeVarint(serializer serde.Serializer, x uint64) error {
+	for x >= 0x80 {
+		if err := serializer.SerializeU8(byte(x) | 0x80); err != nil {
+			return err
+		}
+		x >>= 7
+	}
+	return serializer.SerializeU8(byte(x))
+}
+
+// deserializeShortVecLen reads the compact-u16 length of a short_vec.
+func deserializeShortVecLen(deserializer serde.Deserializer) (int, error) {
+	n, err := deserializeVarint(deserializer, 16)
+	return int(n), err
+}
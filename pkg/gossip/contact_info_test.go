@@ -0,0 +1,85 @@
+package gossip
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContactInfoV2(t *testing.T) {
+	info := ContactInfoV2{
+		Pubkey:       Pubkey{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		Wallclock:    300,
+		Outset:       7,
+		ShredVersion: 0x1234,
+		Version:      ClientVersion{Major: 2, Minor: 1, Patch: 300, Commit: 5, FeatureSet: 6, Client: 3},
+	}
+	info.SetSockets(map[uint8]netip.AddrPort{
+		SocketTVU:    netip.MustParseAddrPort("10.0.0.1:8002"),
+		SocketGossip: netip.MustParseAddrPort("10.0.0.1:8001"),
+	})
+
+	expected := append(bytes.Repeat([]byte{1}, 32),
+		0xac, 0x02, // wallclock
+		7, 0, 0, 0, 0, 0, 0, 0, // outset
+		0x34, 0x12, // shred version
+		2, 1, 0xac, 0x02, 5, 0, 0, 0, 6, 0, 0, 0, 3, // version
+		1, 0, 0, 0, 0, 10, 0, 0, 1, // addrs
+		2, 0, 0, 0xc1, 0x3e, 10, 0, 1, // sockets
+		0, // extensions
+	)
+	serializer := bincode.NewSerializer()
+	require.NoError(t, info.Serialize(serializer))
+	assert.Equal(t, expected, serializer.GetBytes())
+
+	decoded, err := DeserializeContactInfoV2(bincode.NewDeserializer(expected))
+	require.NoError(t, err)
+	assert.Equal(t, info, decoded)
+
+	gossip, ok := decoded.Socket(SocketGossip)
+	assert.True(t, ok)
+	assert.Equal(t, netip.MustParseAddrPort("10.0.0.1:8001"), gossip)
+	tvu, ok := decoded.Socket(SocketTVU)
+	assert.True(t, ok)
+	assert.Equal(t, netip.MustParseAddrPort("10.0.0.1:8002"), tvu)
+	_, ok = decoded.Socket(SocketRPC)
+	assert.False(t, ok)
+
+	// extensions are not supported
+	expected[len(expected)-1] = 1
+	_, err = DeserializeContactInfoV2(bincode.NewDeserializer(expected))
+	assert.Error(t, err)
+}
+
+func TestDeserializeVarint(t *testing.T) {
+	cases := []struct {
+		buf  []byte
+		bits uint
+		x    uint64
+		ok   bool
+	}{
+		{[]byte{0}, 16, 0, true},
+		{[]byte{0x7f}, 16, 0x7f, true},
+		{[]byte{0xff, 0xff, 0x03}, 16, 0xffff, true},
+		{[]byte{0xff, 0xff, 0x04}, 16, 0, false},
+		{[]byte{0x80, 0x00}, 16, 0, false},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 64, 1<<64 - 1, true},
+	}
+	for _, tc := range cases {
+		x, err := deserializeVarint(bincode.NewDeserializer(tc.buf), tc.bits)
+		if !tc.ok {
+			assert.Error(t, err, "%x", tc.buf)
+			continue
+		}
+		require.NoError(t, err, "%x", tc.buf)
+		assert.Equal(t, tc.x, x)
+
+		serializer := bincode.NewSerializer()
+		require.NoError(t, serializeVarint(serializer, x))
+		assert.Equal(t, tc.buf, serializer.GetBytes())
+	}
+}
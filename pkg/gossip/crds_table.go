@@ -0,0 +1,374 @@
+package gossip
+
+import (
+	"crypto/sha256"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kinds of CRDS values, the variant indexes of CrdsData.
+const (
+	CrdsKindContactInfo               = uint32(0)
+	CrdsKindVote                      = uint32(1)
+	CrdsKindLowestSlot                = uint32(2)
+	CrdsKindSnapshotHashes            = uint32(3)
+	CrdsKindAccountsHashes            = uint32(4)
+	CrdsKindEpochSlots                = uint32(5)
+	CrdsKindLegacyVersion             = uint32(6)
+	CrdsKindVersion                   = uint32(7)
+	CrdsKindNodeInstance              = uint32(8)
+	CrdsKindDuplicateShred            = uint32(9)
+	CrdsKindIncrementalSnapshotHashes = uint32(10)
+	CrdsKindContactInfoV2             = uint32(11)
+)
+
+// CrdsValueLabel identifies the slot of a value in the CRDS table, such
+// that a newer value from the same origin replaces the older one.
+//
+// Based on solana_gossip::crds_value::CrdsValueLabel.
+type CrdsValueLabel struct {
+	Kind   uint32 // variant index of CrdsData
+	Pubkey Pubkey
+	Index  uint16
+}
+
+// Label returns the label of a CRDS value.
+func (c *CrdsValue) Label() CrdsValueLabel {
+	label := CrdsValueLabel{Pubkey: *c.Data.Pubkey()}
+	switch x := c.Data.(type) {
+	case *CrdsData__ContactInfo:
+		label.Kind = CrdsKindContactInfo
+	case *CrdsData__Vote:
+		label.Kind = CrdsKindVote
+		label.Index = uint16(x.Field0)
+	case *CrdsData__LowestSlot:
+		label.Kind = CrdsKindLowestSlot
+	case *CrdsData__SnapshotHashes:
+		label.Kind = CrdsKindSnapshotHashes
+	case *CrdsData__AccountsHashes:
+		label.Kind = CrdsKindAccountsHashes
+	case *CrdsData__EpochSlots:
+		label.Kind = CrdsKindEpochSlots
+		label.Index = uint16(x.Field0)
+	case *CrdsData__LegacyVersion:
+		label.Kind = CrdsKindLegacyVersion
+	case *CrdsData__Version:
+		label.Kind = CrdsKindVersion
+	case *CrdsData__NodeInstance:
+		label.Kind = CrdsKindNodeInstance
+	case *CrdsData__DuplicateShred:
+		label.Kind = CrdsKindDuplicateShred
+		label.Index = x.Field0
+	case *CrdsData__IncrementalSnapshotHashes:
+		label.Kind = CrdsKindIncrementalSnapshotHashes
+	case *CrdsData__ContactInfoV2:
+		label.Kind = CrdsKindContactInfoV2
+	}
+	return label
+}
+
+// Wallclock returns the time in milliseconds at which the origin created
+// the value.
+func (c *CrdsValue) Wallclock() uint64 {
+	switch x := c.Data.(type) {
+	case *CrdsData__ContactInfo:
+		return x.Value.Wallclock
+	case *CrdsData__Vote:
+		return x.Field1.Wallclock
+	case *CrdsData__LowestSlot:
+		return x.Field1.Wallclock
+	case *CrdsData__SnapshotHashes:
+		return x.Value.Wallclock
+	case *CrdsData__AccountsHashes:
+		return x.Value.Wallclock
+	case *CrdsData__EpochSlots:
+		return x.Field1.Wallclock
+	case *CrdsData__LegacyVersion:
+		return x.Wallclock
+	case *CrdsData__Version:
+		return x.Wallclock
+	case *CrdsData__NodeInstance:
+		return x.Wallclock
+	case *CrdsData__DuplicateShred:
+		return x.Field1.Wallclock
+	case *CrdsData__IncrementalSnapshotHashes:
+		return x.Value.Wallclock
+	case *CrdsData__ContactInfoV2:
+		return x.Value.Wallclock
+	default:
+		return 0
+	}
+}
+
+// Hash returns the hash of a serialized CRDS value, which pull requests
+// use to filter out the values a node already has.
+func (c *CrdsValue) Hash() (Hash, error) {
+	buf, err := c.BincodeSerialize()
+	if err != nil {
+		return Hash{}, err
+	}
+	return sha256.Sum256(buf), nil
+}
+
+// Wallclock returns the current time in milliseconds, as used by values.
+func Wallclock() uint64 {
+	return uint64(time.Now().UnixMilli())
+}
+
+// Limits of the CRDS table.
+//
+// Based on solana_gossip::cluster_info::CRDS_UNIQUE_PUBKEY_CAPACITY and
+// solana_gossip::crds_gossip_pull::CRDS_GOSSIP_PULL_CRDS_TIMEOUT_MS.
+const (
+	// CrdsUniquePubkeyCapacity is the number of origins kept by Trim.
+	CrdsUniquePubkeyCapacity = 8192
+	// CrdsTimeout is how long the values of an unstaked origin are kept
+	// without updates.
+	CrdsTimeout = 15 * time.Second
+)
+
+// CrdsEntry is a value of the CRDS table.
+type CrdsEntry struct {
+	Value    CrdsValue
+	Hash     Hash
+	Inserted time.Time // local time of insertion
+	// Ordinal orders the entries by insertion, starting at 1.
+	Ordinal uint64
+
+	numPushDups int // times the value was pushed again
+}
+
+// CrdsTable holds the latest value of each label received via gossip.
+//
+// Based on solana_gossip::crds::Crds.
+type CrdsTable struct {
+	mu      sync.RWMutex
+	entries map[CrdsValueLabel]*CrdsEntry
+	ordinal uint64 // ordinal of the last entry inserted
+}
+
+func NewCrdsTable() *CrdsTable {
+	return &CrdsTable{entries: make(map[CrdsValueLabel]*CrdsEntry)}
+}
+
+// Insert inserts a value, unless the table already holds a value of the
+// same label that is newer, or equally new with a greater or equal hash.
+// Returns whether the value was inserted.
+//
+// Does not verify the signature of the value.
+func (t *CrdsTable) Insert(value CrdsValue, now time.Time) (bool, error) {
+	inserted, _, err := t.insert(value, now, false)
+	return inserted, err
+}
+
+// InsertPush inserts a value received in a push message, like Insert.
+// Unless the value is inserted, returns the number of times the value was
+// pushed before, or math.MaxInt if the table holds a newer value.
+//
+// Based on solana_gossip::crds::Crds::insert.
+func (t *CrdsTable) InsertPush(value CrdsValue, now time.Time) (inserted bool, numDups int, err error) {
+	return t.insert(value, now, true)
+}
+
+func (t *CrdsTable) insert(value CrdsValue, now time.Time, push bool) (bool, int, error) {
+	hash, err := value.Hash()
+	if err != nil {
+		return false, 0, err
+	}
+	label := value.Label()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if prev, ok := t.entries[label]; ok && !overrides(&value, &hash, prev) {
+		if prev.Hash != hash {
+			return false, math.MaxInt, nil
+		}
+		if push {
+			prev.numPushDups++
+		}
+		return false, prev.numPushDups, nil
+	}
+	t.ordinal++
+	t.entries[label] = &CrdsEntry{Value: value, Hash: hash, Inserted: now, Ordinal: t.ordinal}
+	return true, 0, nil
+}
+
+// overrides returns whether a value replaces an entry of the same label.
+//
+// Based on solana_gossip::crds::overrides.
+func overrides(value *CrdsValue, hash *Hash, prev *CrdsEntry) bool {
+	wallclock, prevWallclock := value.Wallclock(), prev.Value.Wallclock()
+	if wallclock != prevWallclock {
+		return wallclock > prevWallclock
+	}
+	for i := range hash {
+		if hash[i] != prev.Hash[i] {
+			return hash[i] > prev.Hash[i]
+		}
+	}
+	return false
+}
+
+// Get returns the entry of a label.
+func (t *CrdsTable) Get(label CrdsValueLabel) (CrdsEntry, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entry, ok := t.entries[label]
+	if !ok {
+		return CrdsEntry{}, false
+	}
+	return *entry, true
+}
+
+// Len returns the number of entries.
+func (t *CrdsTable) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.entries)
+}
+
+// Entries returns a copy of the entries of the given kinds, or of all kinds
+// if none are given.
+func (t *CrdsTable) Entries(kinds ...uint32) []CrdsEntry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entries := make([]CrdsEntry, 0, len(t.entries))
+	for label, entry := range t.entries {
+		if len(kinds) == 0 {
+			entries = append(entries, *entry)
+			continue
+		}
+		for _, kind := range kinds {
+			if label.Kind == kind {
+				entries = append(entries, *entry)
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// Hashes returns the hashes of all entries.
+func (t *CrdsTable) Hashes() []Hash {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	hashes := make([]Hash, 0, len(t.entries))
+	for _, entry := range t.entries {
+		hashes = append(hashes, entry.Hash)
+	}
+	return hashes
+}
+
+// Purge removes the entries inserted before the given time, and returns
+// the number removed.
+func (t *CrdsTable) Purge(before time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var n int
+	for label, entry := range t.entries {
+		if entry.Inserted.Before(before) {
+			delete(t.entries, label)
+			n++
+		}
+	}
+	return n
+}
+
+// EntriesSince returns a copy of the entries inserted after the given
+// ordinal, in order of insertion, and the ordinal of the last entry, to
+// resume from.
+func (t *CrdsTable) EntriesSince(ordinal uint64) ([]CrdsEntry, uint64) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var entries []CrdsEntry
+	for _, entry := range t.entries {
+		if entry.Ordinal > ordinal {
+			entries = append(entries, *entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Ordinal < entries[j].Ordinal
+	})
+	return entries, t.ordinal
+}
+
+// PurgeTimeouts removes the values of the origins of which no value was
+// inserted within the timeout of the origin, and returns the number
+// removed. Values of origins with a zero timeout are kept.
+//
+// Based on solana_gossip::crds::Crds::find_old_labels.
+func (t *CrdsTable) PurgeTimeouts(now time.Time, timeout func(origin Pubkey) time.Duration) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	expired := make(map[Pubkey]struct{})
+	for origin, lastUpdate := range t.lastUpdates() {
+		if d := timeout(origin); d != 0 && lastUpdate.Before(now.Add(-d)) {
+			expired[origin] = struct{}{}
+		}
+	}
+	return t.removeOrigins(expired)
+}
+
+// Trim evicts the values of the origins with the least stake, and the
+// least recently updated among those, until the values of at most capacity
+// origins remain. Returns the number of values removed. The values of keep
+// are never evicted.
+//
+// Based on solana_gossip::crds::Crds::trim.
+func (t *CrdsTable) Trim(capacity int, stakes map[Pubkey]uint64, keep Pubkey) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lastUpdate := t.lastUpdates()
+	if len(lastUpdate) <= capacity {
+		return 0
+	}
+	origins := make([]Pubkey, 0, len(lastUpdate))
+	for origin := range lastUpdate {
+		if origin != keep {
+			origins = append(origins, origin)
+		}
+	}
+	sort.Slice(origins, func(i, j int) bool {
+		si, sj := stakes[origins[i]], stakes[origins[j]]
+		if si != sj {
+			return si < sj
+		}
+		return lastUpdate[origins[i]].Before(lastUpdate[origins[j]])
+	})
+	numEvicted := len(lastUpdate) - capacity
+	if numEvicted > len(origins) {
+		numEvicted = len(origins)
+	}
+	evicted := make(map[Pubkey]struct{}, numEvicted)
+	for _, origin := range origins[:numEvicted] {
+		evicted[origin] = struct{}{}
+	}
+	return t.removeOrigins(evicted)
+}
+
+// lastUpdates returns the time of the last insertion of a value of each
+// origin.
+func (t *CrdsTable) lastUpdates() map[Pubkey]time.Time {
+	lastUpdate := make(map[Pubkey]time.Time)
+	for label, entry := range t.entries {
+		if prev, ok := lastUpdate[label.Pubkey]; !ok || entry.Inserted.After(prev) {
+			lastUpdate[label.Pubkey] = entry.Inserted
+		}
+	}
+	return lastUpdate
+}
+
+// removeOrigins removes the values of the given origins, and returns the
+// number removed.
+func (t *CrdsTable) removeOrigins(origins map[Pubkey]struct{}) int {
+	var n int
+	for label := range t.entries {
+		if _, ok := origins[label.Pubkey]; ok {
+			delete(t.entries, label)
+			n++
+		}
+	}
+	return n
+}
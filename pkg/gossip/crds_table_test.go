@@ -0,0 +1,176 @@
+package gossip
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSignedNodeInstance(t *testing.T, identity ed25519.PrivateKey, wallclock, token uint64) CrdsValue {
+	value := CrdsValue{Data: &CrdsData__NodeInstance{Wallclock: wallclock, Token: token}}
+	require.NoError(t, value.Sign(identity))
+	return value
+}
+
+func TestCrdsTable_Insert(t *testing.T) {
+	_, identity, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	table := NewCrdsTable()
+	now := time.Now()
+
+	v1 := newSignedNodeInstance(t, identity, 100, 1)
+	inserted, err := table.Insert(v1, now)
+	require.NoError(t, err)
+	assert.True(t, inserted)
+	inserted, err = table.Insert(v1, now)
+	require.NoError(t, err)
+	assert.False(t, inserted)
+
+	// older values are rejected, newer ones replace
+	inserted, _ = table.Insert(newSignedNodeInstance(t, identity, 99, 2), now)
+	assert.False(t, inserted)
+	v2 := newSignedNodeInstance(t, identity, 101, 3)
+	inserted, _ = table.Insert(v2, now.Add(time.Second))
+	assert.True(t, inserted)
+	assert.Equal(t, 1, table.Len())
+
+	entry, ok := table.Get(v2.Label())
+	require.True(t, ok)
+	assert.Equal(t, uint64(101), entry.Value.Wallclock())
+	hash, err := v2.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, []Hash{hash}, table.Hashes())
+
+	// values of other kinds have other labels
+	contactInfo := CrdsValue{Data: &CrdsData__ContactInfoV2{Value: ContactInfoV2{Wallclock: 1}}}
+	require.NoError(t, contactInfo.Sign(identity))
+	inserted, _ = table.Insert(contactInfo, now)
+	assert.True(t, inserted)
+	assert.Len(t, table.Entries(), 2)
+	assert.Len(t, table.Entries(CrdsKindContactInfoV2), 1)
+
+	assert.Equal(t, 1, table.Purge(now.Add(time.Millisecond)))
+	assert.Len(t, table.Entries(CrdsKindNodeInstance), 1)
+}
+
+func TestCrdsTable_Eviction(t *testing.T) {
+	table := NewCrdsTable()
+	now := time.Now()
+	var origins []Pubkey
+	for i := 0; i < 4; i++ {
+		_, identity, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		value := newSignedNodeInstance(t, identity, 1, 1)
+		_, err = table.Insert(value, now.Add(time.Duration(i)*time.Second))
+		require.NoError(t, err)
+		origins = append(origins, *value.Data.Pubkey())
+	}
+
+	entries, cursor := table.EntriesSince(2)
+	require.Len(t, entries, 2)
+	assert.Equal(t, origins[2], *entries[0].Value.Data.Pubkey())
+	assert.Equal(t, uint64(4), cursor)
+	entries, _ = table.EntriesSince(cursor)
+	assert.Empty(t, entries)
+
+	// unstaked origins are evicted first, oldest first, except the kept one
+	stakes := map[Pubkey]uint64{origins[0]: 10}
+	assert.Equal(t, 2, table.Trim(2, stakes, origins[1]))
+	assert.Equal(t, 2, table.Len())
+	for _, entry := range table.Entries() {
+		assert.Contains(t, origins[:2], *entry.Value.Data.Pubkey())
+	}
+
+	// staked origins time out later
+	n := table.PurgeTimeouts(now.Add(time.Minute), func(origin Pubkey) time.Duration {
+		if stakes[origin] != 0 {
+			return time.Hour
+		}
+		return time.Second
+	})
+	assert.Equal(t, 1, n)
+	_, ok := table.Get(CrdsValueLabel{Kind: CrdsKindNodeInstance, Pubkey: origins[0]})
+	assert.True(t, ok)
+}
+
+// loopback delivers packets directly to a handler.
+type loopback struct {
+	handler *Handler
+	from    netip.AddrPort
+}
+
+func (l *loopback) WriteToUDPAddrPort(b []byte, _ netip.AddrPort) (int, error) {
+	l.handler.HandlePacket(b, l.from)
+	return len(b), nil
+}
+
+func TestPull(t *testing.T) {
+	_, serverIdentity, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, clientIdentity, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	serverAddr := netip.MustParseAddrPort("127.0.0.1:8001")
+	clientAddr := netip.MustParseAddrPort("127.0.0.1:8002")
+
+	serverTable := NewCrdsTable()
+	var values []CrdsValue
+	for i := 0; i < 8; i++ {
+		_, identity, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		value := newSignedNodeInstance(t, identity, Wallclock(), uint64(i))
+		values = append(values, value)
+		_, err = serverTable.Insert(value, time.Now())
+		require.NoError(t, err)
+	}
+
+	clientTable := NewCrdsTable()
+	clientHandler := &Handler{}
+	serverHandler := &Handler{}
+	toClient := &loopback{handler: clientHandler, from: serverAddr}
+	toServer := &loopback{handler: serverHandler, from: clientAddr}
+	self := &ContactInfoV2{}
+	self.SetSockets(map[uint8]netip.AddrPort{SocketGossip: clientAddr})
+	clientHandler.PullClient = NewPullClient(clientIdentity, toServer, clientTable, self)
+	serverHandler.PullServer = NewPullServer(serverIdentity, toClient, serverTable)
+
+	require.NoError(t, clientHandler.PullClient.Pull(serverAddr))
+	for _, value := range values {
+		_, ok := clientTable.Get(value.Label())
+		assert.True(t, ok)
+	}
+	assert.Equal(t, uint64(len(values)), clientHandler.PullClient.NumValues.Load())
+	assert.Zero(t, clientHandler.PullClient.NumInvalid.Load())
+
+	// the server learned about the client
+	entries := serverTable.Entries(CrdsKindContactInfoV2)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ed25519.PublicKey(entries[0].Value.Data.Pubkey()[:]), clientIdentity.Public())
+
+	// values the client has are not sent again
+	numValues := clientHandler.PullClient.NumValues.Load()
+	require.NoError(t, clientHandler.PullClient.Pull(serverAddr))
+	assert.Zero(t, clientHandler.PullClient.NumStale.Load())
+	assert.Equal(t, numValues, clientHandler.PullClient.NumValues.Load())
+}
+
+func TestPushServer(t *testing.T) {
+	_, identity, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	table := NewCrdsTable()
+	handler := &Handler{PushServer: NewPushServer(table)}
+
+	valid := newSignedNodeInstance(t, identity, 1, 1)
+	invalid := newSignedNodeInstance(t, identity, 1, 2)
+	invalid.Signature[0] ^= 1
+	so := &loopback{handler: handler}
+	require.NoError(t, Push(identity, so, netip.AddrPort{}, []CrdsValue{valid, invalid}))
+
+	assert.Equal(t, uint64(1), handler.PushServer.NumValues.Load())
+	assert.Equal(t, uint64(1), handler.PushServer.NumInvalid.Load())
+	assert.Equal(t, 1, table.Len())
+}
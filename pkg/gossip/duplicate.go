@@ -0,0 +1,103 @@
+package gossip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+const (
+	// DuplicateShredHeaderSize is the size of a serialized DuplicateShred
+	// without its chunk.
+	DuplicateShredHeaderSize = 63
+	// DuplicateShredMaxPayloadSize is the maximum size of a serialized
+	// DuplicateShred, which fits into a push message.
+	DuplicateShredMaxPayloadSize = PacketSize - 115
+)
+
+// NewDuplicateShreds splits a duplicate slot proof into chunks of which
+// the serialized DuplicateShred values are at most maxSize bytes.
+//
+// Based on solana_gossip::duplicate_shred::from_shred.
+func NewDuplicateShreds(from Pubkey, wallclock uint64, proof *shred.DuplicateSlotProof, maxSize int) ([]DuplicateShred, error) {
+	if len(proof.Shred1) < shred.CommonHeaderSize {
+		return nil, fmt.Errorf("invalid shred")
+	}
+	if maxSize <= DuplicateShredHeaderSize {
+		return nil, fmt.Errorf("invalid size limit %d", maxSize)
+	}
+	data := proof.MarshalBincode()
+	chunkSize := maxSize - DuplicateShredHeaderSize
+	numChunks := (len(data) + chunkSize - 1) / chunkSize
+	if numChunks > 0xFF {
+		return nil, fmt.Errorf("too many chunks: %d", numChunks)
+	}
+	shredType := shred.LegacyCodeID
+	variant := proof.Shred1[0x40]
+	if (&shred.CommonHeader{Variant: variant}).IsData() {
+		shredType = shred.LegacyDataID
+	}
+	chunks := make([]DuplicateShred, numChunks)
+	for i := range chunks {
+		end := (i + 1) * chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunks[i] = DuplicateShred{
+			From:       from,
+			Wallclock:  wallclock,
+			Slot:       binary.LittleEndian.Uint64(proof.Shred1[0x41:0x49]),
+			ShredIndex: binary.LittleEndian.Uint32(proof.Shred1[0x49:0x4d]),
+			ShredType:  shredType,
+			NumChunks:  uint8(numChunks),
+			ChunkIndex: uint8(i),
+			Chunk:      data[i*chunkSize : end],
+		}
+	}
+	return chunks, nil
+}
+
+// ErrMissingChunks reports an incomplete set of DuplicateShred chunks.
+var ErrMissingChunks = errors.New("missing duplicate shred chunks")
+
+// ReassembleDuplicateShreds reassembles the duplicate slot proof of the
+// chunks of a node, in any order, and checks that its shreds conflict.
+//
+// Based on solana_gossip::duplicate_shred::into_shreds.
+func ReassembleDuplicateShreds(chunks []DuplicateShred) (*shred.DuplicateSlotProof, error) {
+	if len(chunks) == 0 {
+		return nil, ErrMissingChunks
+	}
+	first := &chunks[0]
+	parts := make([][]byte, first.NumChunks)
+	for i := range chunks {
+		c := &chunks[i]
+		if c.From != first.From || c.Slot != first.Slot || c.NumChunks != first.NumChunks {
+			return nil, fmt.Errorf("inconsistent duplicate shred chunks")
+		}
+		if int(c.ChunkIndex) >= len(parts) {
+			return nil, fmt.Errorf("invalid chunk index %d of %d", c.ChunkIndex, c.NumChunks)
+		}
+		parts[c.ChunkIndex] = c.Chunk
+	}
+	var data []byte
+	for _, part := range parts {
+		if part == nil {
+			return nil, ErrMissingChunks
+		}
+		data = append(data, part...)
+	}
+	proof, err := shred.ParseDuplicateSlotProof(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := shred.CheckDuplicate(proof.Shred1, proof.Shred2); err != nil {
+		return nil, err
+	}
+	if slot := binary.LittleEndian.Uint64(proof.Shred1[0x41:0x49]); slot != first.Slot {
+		return nil, fmt.Errorf("proof of slot %d in chunks of slot %d", slot, first.Slot)
+	}
+	return proof, nil
+}
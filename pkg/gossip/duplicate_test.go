@@ -0,0 +1,51 @@
+package gossip
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+func makeDataShred(slot uint64, index uint32, fill byte) []byte {
+	raw := make([]byte, shred.LegacyDataSize)
+	raw[0x40] = shred.LegacyDataID
+	binary.LittleEndian.PutUint64(raw[0x41:0x49], slot)
+	binary.LittleEndian.PutUint32(raw[0x49:0x4d], index)
+	for i := shred.DataHeaderSize; i < len(raw); i++ {
+		raw[i] = fill
+	}
+	return raw
+}
+
+func TestDuplicateShreds(t *testing.T) {
+	proof := &shred.DuplicateSlotProof{
+		Shred1: makeDataShred(7, 3, 1),
+		Shred2: makeDataShred(7, 3, 2),
+	}
+	chunks, err := NewDuplicateShreds(Pubkey{1}, 1234, proof, DuplicateShredMaxPayloadSize)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+	for i, c := range chunks {
+		assert.Equal(t, uint64(7), c.Slot)
+		assert.Equal(t, uint32(3), c.ShredIndex)
+		assert.Equal(t, shred.LegacyDataID, c.ShredType)
+		assert.Equal(t, uint8(i), c.ChunkIndex)
+		b, err := c.BincodeSerialize()
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(b), DuplicateShredMaxPayloadSize)
+	}
+
+	reversed := []DuplicateShred{chunks[2], chunks[1], chunks[0]}
+	parsed, err := ReassembleDuplicateShreds(reversed)
+	require.NoError(t, err)
+	assert.Equal(t, proof, parsed)
+
+	_, err = ReassembleDuplicateShreds(chunks[:2])
+	assert.ErrorIs(t, err, ErrMissingChunks)
+
+	_, err = NewDuplicateShreds(Pubkey{1}, 1234, proof, DuplicateShredHeaderSize)
+	assert.Error(t, err)
+}
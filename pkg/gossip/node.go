@@ -0,0 +1,143 @@
+package gossip
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	mrand "math/rand"
+	"net"
+	"net/netip"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"k8s.io/klog/v2"
+)
+
+// Node is a gossip node with a new random identity, which pulls the values
+// of a cluster from its entrypoints and from the nodes it discovers.
+//
+// Its handler answers pings and inserts pulled and pushed values into the
+// table. Callers may add servers and clients to the handler before Run.
+type Node struct {
+	Identity ed25519.PrivateKey
+	Pubkey   Pubkey
+	Conn     *net.UDPConn
+	Table    *CrdsTable
+	Self     *ContactInfoV2 // sockets may be set before Run
+	Handler  *Handler
+
+	entrypoints []netip.AddrPort
+}
+
+// ResolveEntrypoints resolves gossip entrypoints given as <host>:<port>.
+func ResolveEntrypoints(entrypoints []string) ([]netip.AddrPort, error) {
+	addrs := make([]netip.AddrPort, 0, len(entrypoints))
+	for _, entrypoint := range entrypoints {
+		udpAddr, err := net.ResolveUDPAddr("udp", entrypoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entrypoint address: %w", err)
+		}
+		addrs = append(addrs, udpAddr.AddrPort())
+	}
+	return addrs, nil
+}
+
+// NewNode creates a node on the given socket, which advertises the given
+// shred version.
+func NewNode(conn *net.UDPConn, entrypoints []netip.AddrPort, shredVersion uint16) (*Node, error) {
+	_, identity, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{
+		Identity:    identity,
+		Conn:        conn,
+		Table:       NewCrdsTable(),
+		entrypoints: entrypoints,
+	}
+	copy(n.Pubkey[:], identity.Public().(ed25519.PublicKey))
+	n.Self = &ContactInfoV2{
+		Outset:       uint64(time.Now().UnixMicro()),
+		ShredVersion: shredVersion,
+	}
+	n.Handler = &Handler{
+		PingServer: NewPingServer(identity, conn),
+		PullClient: NewPullClient(identity, conn, n.Table, n.Self),
+		PushServer: NewPushServer(n.Table),
+	}
+	return n, nil
+}
+
+// Run processes packets and, every interval, pulls from the entrypoints and
+// a random node of the table, then calls tick, until the context is
+// cancelled. Returns nil if the context closed, or the first error of the
+// network or of tick.
+//
+// Closes the socket after returning.
+func (n *Node) Run(ctx context.Context, interval time.Duration, tick func() error) error {
+	group, ctx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		return NewDriver(n.Handler, n.Conn).Run(ctx)
+	})
+	group.Go(func() error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			targets := n.entrypoints
+			if peer, ok := randomPeer(n.Table); ok {
+				targets = append(targets[:len(targets):len(targets)], peer)
+			}
+			for _, target := range targets {
+				if err := n.Handler.PullClient.Pull(target); err != nil {
+					klog.Warningf("Failed to pull from %s: %s", target, err)
+				}
+			}
+			if tick != nil {
+				if err := tick(); err != nil {
+					return err
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+	return group.Wait()
+}
+
+// Prune drops the values received longer ago than the timeout, evicts the
+// values of the nodes beyond the table capacity, and prunes redundant
+// relayers, so that pushes do not flood the node. All nodes are treated
+// as unstaked.
+func (n *Node) Prune(timeout time.Duration) {
+	if num := n.Table.Purge(time.Now().Add(-timeout)); num > 0 {
+		klog.V(3).Infof("Purged %d values", num)
+	}
+	if num := n.Table.Trim(CrdsUniquePubkeyCapacity, nil, Pubkey{}); num > 0 {
+		klog.V(3).Infof("Evicted %d values", num)
+	}
+	if err := n.Handler.PushServer.SendPrunes(n.Identity, n.Conn, nil); err != nil {
+		klog.Warningf("Failed to send prunes: %s", err)
+	}
+}
+
+// randomPeer returns the gossip socket of a random node of the table.
+func randomPeer(table *CrdsTable) (netip.AddrPort, bool) {
+	var peers []netip.AddrPort
+	for _, entry := range table.Entries(CrdsKindContactInfoV2) {
+		info, ok := entry.Value.Data.(*CrdsData__ContactInfoV2)
+		if !ok {
+			continue
+		}
+		if peer, ok := info.Value.Socket(SocketGossip); ok && peer.Addr().IsGlobalUnicast() {
+			peers = append(peers, peer)
+		}
+	}
+	if len(peers) == 0 {
+		return netip.AddrPort{}, false
+	}
+	return peers[mrand.Intn(len(peers))], true
+}
@@ -0,0 +1,62 @@
+package gossip
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLocalNode(t *testing.T, entrypoints ...string) *Node {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	addrs, err := ResolveEntrypoints(entrypoints)
+	require.NoError(t, err)
+	node, err := NewNode(conn, addrs, 0)
+	require.NoError(t, err)
+	return node
+}
+
+func TestNode_Run(t *testing.T) {
+	server := newLocalNode(t)
+	server.Handler.PullServer = NewPullServer(server.Identity, server.Conn, server.Table)
+	value := newSignedNodeInstance(t, server.Identity, Wallclock(), 1)
+	_, err := server.Table.Insert(value, time.Now())
+	require.NoError(t, err)
+
+	client := newLocalNode(t, server.Conn.LocalAddr().String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go server.Run(ctx, time.Hour, nil)
+
+	// the client pulls from its entrypoint until it has the value
+	clientCtx, stop := context.WithCancel(ctx)
+	err = client.Run(clientCtx, 10*time.Millisecond, func() error {
+		if _, ok := client.Table.Get(value.Label()); ok {
+			stop()
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	_, ok := client.Table.Get(value.Label())
+	assert.True(t, ok)
+
+	// the server learned about the client
+	entries := server.Table.Entries(CrdsKindContactInfoV2)
+	require.Len(t, entries, 1)
+	assert.Equal(t, client.Pubkey, *entries[0].Value.Data.Pubkey())
+}
+
+func TestResolveEntrypoints(t *testing.T) {
+	addrs, err := ResolveEntrypoints([]string{"127.0.0.1:8001"})
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+	assert.Equal(t, uint16(8001), addrs[0].Port())
+
+	_, err = ResolveEntrypoints([]string{"127.0.0.1"})
+	assert.Error(t, err)
+}
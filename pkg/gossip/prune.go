@@ -0,0 +1,185 @@
+package gossip
+
+import (
+	"crypto/ed25519"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+)
+
+// pruneDataPrefix prefixes the signed data of prune messages, such that
+// their signatures cannot be replayed as other messages.
+//
+// Based on solana_gossip::protocol::PRUNE_DATA_PREFIX.
+const pruneDataPrefix = "\xffSOLANA_PRUNE_DATA"
+
+// PruneMessageTimeout is how long a prune message is valid after it was
+// signed.
+//
+// Based on solana_gossip::crds_gossip_push::CRDS_GOSSIP_PRUNE_MSG_TIMEOUT_MS.
+const PruneMessageTimeout = 5 * time.Second
+
+// Limits of the pruning of relayers.
+//
+// Based on solana_gossip::crds_gossip_push::{CRDS_GOSSIP_PRUNE_STAKE_THRESHOLD_PCT,
+// CRDS_GOSSIP_PRUNE_MIN_INGRESS_NODES} and solana_gossip::received_cache.
+const (
+	pruneStakeThresholdPct  = 0.15
+	pruneMinIngressNodes    = 2
+	pruneMinNumUpserts      = 20
+	receivedCacheNumDupsMax = 2
+	// maxPrunesPerMessage keeps prune messages within a packet.
+	maxPrunesPerMessage = 32
+)
+
+// signableData returns the data signed by a prune message, with or without
+// the prefix.
+//
+// Based on solana_gossip::protocol::PruneData::signable_data_with_prefix.
+func (p *PruneData) signableData(prefix bool) ([]byte, error) {
+	serializer := bincode.NewSerializer()
+	if prefix {
+		if err := serializer.SerializeBytes([]byte(pruneDataPrefix)); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.Pubkey.Serialize(serializer); err != nil {
+		return nil, err
+	}
+	if err := serialize_vector_Pubkey(p.Prunes, serializer); err != nil {
+		return nil, err
+	}
+	if err := p.Destination.Serialize(serializer); err != nil {
+		return nil, err
+	}
+	if err := serializer.SerializeU64(p.Wallclock); err != nil {
+		return nil, err
+	}
+	return serializer.GetBytes(), nil
+}
+
+// Sign fills in the pubkey and signature of a prune message.
+func (p *PruneData) Sign(identity ed25519.PrivateKey) error {
+	copy(p.Pubkey[:], identity.Public().(ed25519.PublicKey))
+	msg, err := p.signableData(true)
+	if err != nil {
+		return err
+	}
+	copy(p.Signature[:], ed25519.Sign(identity, msg))
+	return nil
+}
+
+// VerifySignature checks the signature of a prune message, with or
+// without the prefix, as signed by older nodes.
+func (p *PruneData) VerifySignature() bool {
+	for _, prefix := range []bool{true, false} {
+		msg, err := p.signableData(prefix)
+		if err != nil {
+			return false
+		}
+		if ed25519.Verify(p.Pubkey[:], msg, p.Signature[:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// receivedCache scores the nodes relaying the values of each origin by how
+// often they are first to deliver them, such that redundant relayers can
+// be pruned.
+//
+// Based on solana_gossip::received_cache::ReceivedCache.
+type receivedCache struct {
+	mu       sync.Mutex
+	origins  map[Pubkey]*receivedCacheEntry
+	relayers map[Pubkey]netip.AddrPort // addresses of the relayers
+}
+
+type receivedCacheEntry struct {
+	scores     map[Pubkey]int // by relayer
+	numUpserts int
+}
+
+func newReceivedCache() *receivedCache {
+	return &receivedCache{
+		origins:  make(map[Pubkey]*receivedCacheEntry),
+		relayers: make(map[Pubkey]netip.AddrPort),
+	}
+}
+
+// record records a value of an origin delivered by a relayer, after the
+// value was received numDups times before.
+func (c *receivedCache) record(origin, relayer Pubkey, addr netip.AddrPort, numDups int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.relayers[relayer] = addr
+	entry, ok := c.origins[origin]
+	if !ok {
+		entry = &receivedCacheEntry{scores: make(map[Pubkey]int)}
+		c.origins[origin] = entry
+	}
+	if numDups == 0 {
+		entry.numUpserts++
+	}
+	if numDups < receivedCacheNumDupsMax {
+		entry.scores[relayer]++
+	} else if _, ok := entry.scores[relayer]; !ok {
+		entry.scores[relayer] = 0
+	}
+}
+
+// prune returns the origins to prune by relayer, and the addresses of the
+// relayers, which are forgotten until they deliver values again.
+//
+// The relayers delivering the values of an origin first are kept, until
+// they are at least pruneMinIngressNodes and hold a fraction of the stake
+// of the origin or of this node. Origins with too few values received are
+// left as is.
+//
+// Based on solana_gossip::received_cache::ReceivedCache::prune.
+func (c *receivedCache) prune(self Pubkey, stakes map[Pubkey]uint64) (map[Pubkey][]Pubkey, map[Pubkey]netip.AddrPort) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addrs := c.relayers
+	c.relayers = make(map[Pubkey]netip.AddrPort)
+	prunes := make(map[Pubkey][]Pubkey)
+	for origin, entry := range c.origins {
+		if entry.numUpserts < pruneMinNumUpserts {
+			continue
+		}
+		delete(c.origins, origin)
+
+		relayers := make([]Pubkey, 0, len(entry.scores))
+		for relayer := range entry.scores {
+			relayers = append(relayers, relayer)
+		}
+		sort.Slice(relayers, func(i, j int) bool {
+			si, sj := entry.scores[relayers[i]], entry.scores[relayers[j]]
+			if si != sj {
+				return si > sj
+			}
+			return stakes[relayers[i]] > stakes[relayers[j]]
+		})
+		minStake := stakes[self]
+		if stakes[origin] < minStake {
+			minStake = stakes[origin]
+		}
+		threshold := uint64(float64(minStake) * pruneStakeThresholdPct)
+		var kept int
+		var keptStake uint64
+		for _, relayer := range relayers {
+			if kept < pruneMinIngressNodes || keptStake < threshold {
+				kept++
+				keptStake += stakes[relayer]
+				continue
+			}
+			if relayer != origin {
+				prunes[relayer] = append(prunes[relayer], origin)
+			}
+		}
+	}
+	return prunes, addrs
+}
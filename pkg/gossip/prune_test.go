@@ -0,0 +1,121 @@
+package gossip
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdentity(t *testing.T) (ed25519.PrivateKey, Pubkey) {
+	pub, identity, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	var pubkey Pubkey
+	copy(pubkey[:], pub)
+	return identity, pubkey
+}
+
+func TestPruneData_Sign(t *testing.T) {
+	identity, pubkey := newIdentity(t)
+	data := PruneData{Prunes: []Pubkey{{1}, {2}}, Destination: Pubkey{3}, Wallclock: 4}
+	require.NoError(t, data.Sign(identity))
+	assert.Equal(t, pubkey, data.Pubkey)
+	assert.True(t, data.VerifySignature())
+
+	// older nodes sign without the prefix
+	msg, err := data.signableData(false)
+	require.NoError(t, err)
+	copy(data.Signature[:], ed25519.Sign(identity, msg))
+	assert.True(t, data.VerifySignature())
+
+	data.Wallclock++
+	assert.False(t, data.VerifySignature())
+}
+
+func TestReceivedCache_Prune(t *testing.T) {
+	self, origin := Pubkey{1}, Pubkey{2}
+	first, second, redundant, staked := Pubkey{3}, Pubkey{4}, Pubkey{5}, Pubkey{6}
+	cache := newReceivedCache()
+	for i := 0; i < pruneMinNumUpserts-1; i++ {
+		cache.record(origin, first, netip.AddrPort{}, 0)
+		cache.record(origin, second, netip.AddrPort{}, 1)
+		cache.record(origin, redundant, netip.AddrPort{}, 2)
+		cache.record(origin, staked, netip.AddrPort{}, 2)
+	}
+	prunes, addrs := cache.prune(self, nil)
+	assert.Empty(t, prunes)
+	assert.Len(t, addrs, 4)
+
+	cache.record(origin, first, netip.AddrPort{}, 0)
+	stakes := map[Pubkey]uint64{self: 100, origin: 100, staked: 20}
+	prunes, _ = cache.prune(self, stakes)
+	assert.Equal(t, map[Pubkey][]Pubkey{redundant: {origin}}, prunes)
+}
+
+func TestPushClient(t *testing.T) {
+	identity, self := newIdentity(t)
+	table := NewCrdsTable()
+
+	// peers of the table, one of another shred version
+	var peers []Pubkey
+	var peerIdentities []ed25519.PrivateKey
+	for i := 0; i < 3; i++ {
+		peerIdentity, peer := newIdentity(t)
+		info := ContactInfoV2{Wallclock: Wallclock(), ShredVersion: 1}
+		if i == 2 {
+			info.ShredVersion = 2
+		}
+		info.SetSockets(map[uint8]netip.AddrPort{
+			SocketGossip: netip.MustParseAddrPort("127.0.0.1:8001"),
+		})
+		value := CrdsValue{Data: &CrdsData__ContactInfoV2{Value: info}}
+		require.NoError(t, value.Sign(peerIdentity))
+		_, err := table.Insert(value, time.Now())
+		require.NoError(t, err)
+		peers = append(peers, peer)
+		peerIdentities = append(peerIdentities, peerIdentity)
+	}
+	origin := newSignedNodeInstance(t, peerIdentities[2], Wallclock(), 1)
+	_, err := table.Insert(origin, time.Now())
+	require.NoError(t, err)
+
+	received := NewCrdsTable()
+	so := &loopback{handler: &Handler{PushServer: NewPushServer(received)}}
+	client := NewPushClient(identity, so, table, 1)
+	client.RotateActiveSet()
+	require.Len(t, client.active, 2)
+
+	// values are pushed once to each peer, except to their origin
+	require.NoError(t, client.PushNewValues())
+	assert.Equal(t, uint64(2+2+2), client.NumPushed.Load())
+	require.NoError(t, client.PushNewValues())
+	assert.Equal(t, uint64(6), client.NumPushed.Load())
+
+	// pruned origins are no longer pushed to the peer
+	prune := &Message__PruneMessage{
+		Pubkey: peers[0],
+		Data:   PruneData{Prunes: []Pubkey{*origin.Data.Pubkey(), self}, Destination: self, Wallclock: Wallclock()},
+	}
+	require.NoError(t, prune.Data.Sign(peerIdentities[0]))
+	packet, err := prune.BincodeSerialize()
+	require.NoError(t, err)
+	handler := &Handler{PushClient: client}
+	handler.HandlePacket(packet, netip.AddrPort{})
+	assert.Equal(t, uint64(1), client.NumPrunes.Load())
+
+	prune.Data.Destination = Pubkey{}
+	packet, err = prune.BincodeSerialize()
+	require.NoError(t, err)
+	handler.HandlePacket(packet, netip.AddrPort{})
+	assert.Equal(t, uint64(1), client.NumInvalid.Load())
+
+	origin = newSignedNodeInstance(t, peerIdentities[2], Wallclock()+1, 2)
+	_, err = table.Insert(origin, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, client.PushNewValues())
+	assert.Equal(t, uint64(7), client.NumPushed.Load())
+}
@@ -2,63 +2,198 @@ package gossip
 
 import (
 	"crypto/ed25519"
-	"encoding/json"
-	"fmt"
 	"net/netip"
+	"sync/atomic"
 	"time"
+
+	"k8s.io/klog/v2"
 )
 
 const PacketSize = 1232
 
+// minPullItems is the lower bound of the number of items that pull
+// filters are sized for, such that a new node splits its requests.
+const minPullItems = 65536
+
 // PullClient implements the stateful client (initiator) side of the gossip pull protocol.
+//
+// Values received in pull responses are verified and inserted into the table.
 type PullClient struct {
 	identity ed25519.PrivateKey
 	so       udpSender
+	table    *CrdsTable
+	self     *ContactInfoV2
+
+	NumValues   atomic.Uint64 // values inserted into the table
+	NumStale    atomic.Uint64 // values older than the ones in the table
+	NumInvalid  atomic.Uint64 // values with an invalid signature
+	NumSendFail atomic.Uint64 // socket refused to send (tx buffer full)
 }
 
-func NewPullClient(identity ed25519.PrivateKey, so udpSender) *PullClient {
+// NewPullClient creates a pull client, which identifies itself with the
+// given contact info. The pubkey and wallclock of the contact info are
+// filled in when sending requests.
+func NewPullClient(identity ed25519.PrivateKey, so udpSender, table *CrdsTable, self *ContactInfoV2) *PullClient {
 	return &PullClient{
 		identity: identity,
 		so:       so,
+		table:    table,
+		self:     self,
 	}
 }
 
+// Pull sends pull requests for the values missing from the table.
+//
+// Based on solana_gossip::crds_gossip_pull::CrdsGossipPull::new_pull_request.
 func (p *PullClient) Pull(target netip.AddrPort) error {
-	filters := NewCrdsFilterSet(65536, MaxBloomSize)
+	hashes := p.table.Hashes()
+	numItems := uint64(len(hashes))
+	if numItems < minPullItems {
+		numItems = minPullItems
+	}
+	filters := NewCrdsFilterSet(numItems, MaxBloomSize)
+	for _, h := range hashes {
+		filters.Add(h)
+	}
+
+	self := *p.self
+	self.Wallclock = Wallclock()
+	value := CrdsValue{Data: &CrdsData__ContactInfoV2{Value: self}}
+	if err := value.Sign(p.identity); err != nil {
+		panic("failed to sign pull request: " + err.Error())
+	}
 	for _, filter := range filters {
-		if err := p.sendPullRequest(target, filter); err != nil {
+		if err := p.sendPullRequest(target, filter, value); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (p *PullClient) sendPullRequest(target netip.AddrPort, filter CrdsFilter) error {
+func (p *PullClient) sendPullRequest(target netip.AddrPort, filter CrdsFilter, value CrdsValue) error {
 	msg := &Message__PullRequest{
 		Filter: filter,
-		Value: CrdsValue{
-			Data: &CrdsData__ContactInfo{
-				Value: ContactInfo{
-					Wallclock: uint64(time.Now().UnixMilli()),
-				},
-			},
-		},
-	}
-	err := msg.Value.Sign(p.identity)
-	if err != nil {
-		panic("failed to sign pull request: " + err.Error())
+		Value:  value,
 	}
-
 	packet, err := msg.BincodeSerialize()
 	if err != nil {
 		panic("failed to serialize packet: " + err.Error())
 	}
 
-	_, err = p.so.WriteToUDPAddrPort(packet, target)
+	if _, err = p.so.WriteToUDPAddrPort(packet, target); err != nil {
+		p.NumSendFail.Add(1)
+	}
 	return err
 }
 
 func (p *PullClient) HandlePullResponse(msg *Message__PullResponse, _ netip.AddrPort) {
-	jsonBuf, _ := json.MarshalIndent(msg, "", "\t")
-	fmt.Println(string(jsonBuf))
+	now := time.Now()
+	for _, value := range msg.Values {
+		if !value.VerifySignature() {
+			p.NumInvalid.Add(1)
+			continue
+		}
+		inserted, err := p.table.Insert(value, now)
+		if err != nil {
+			klog.Warningf("Failed to insert CRDS value: %s", err)
+			continue
+		}
+		if inserted {
+			p.NumValues.Add(1)
+		} else {
+			p.NumStale.Add(1)
+		}
+	}
+}
+
+// PullServer implements the server (responder) side of the gossip pull protocol.
+//
+// Requests are answered with a single packet of values from the table.
+type PullServer struct {
+	identity ed25519.PrivateKey
+	so       udpSender
+	table    *CrdsTable
+
+	NumRequests atomic.Uint64 // valid pull requests
+	NumInvalid  atomic.Uint64 // requests with an invalid caller
+	NumSendFail atomic.Uint64 // socket refused to send (tx buffer full)
+}
+
+func NewPullServer(identity ed25519.PrivateKey, so udpSender, table *CrdsTable) *PullServer {
+	return &PullServer{
+		identity: identity,
+		so:       so,
+		table:    table,
+	}
+}
+
+// HandlePullRequest inserts the contact info of the caller into the table
+// and responds with the values of the table that are missing from the
+// filter of the request.
+//
+// Based on solana_gossip::crds_gossip_pull::CrdsGossipPull::generate_pull_responses.
+func (p *PullServer) HandlePullRequest(msg *Message__PullRequest, from netip.AddrPort) {
+	switch msg.Value.Data.(type) {
+	case *CrdsData__ContactInfo, *CrdsData__ContactInfoV2:
+	default:
+		p.NumInvalid.Add(1)
+		return
+	}
+	if !msg.Value.VerifySignature() {
+		p.NumInvalid.Add(1)
+		return
+	}
+	p.NumRequests.Add(1)
+	if _, err := p.table.Insert(msg.Value, time.Now()); err != nil {
+		klog.Warningf("Failed to insert CRDS value: %s", err)
+	}
+
+	// Respond with the values missing from the filter, except the caller's own
+	caller := *msg.Value.Data.Pubkey()
+	var values []CrdsValue
+	for _, entry := range p.table.Entries() {
+		if *entry.Value.Data.Pubkey() == caller {
+			continue
+		}
+		if msg.Filter.TestMask(&entry.Hash) && !msg.Filter.Filter.Contains(&entry.Hash) {
+			values = append(values, entry.Value)
+		}
+	}
+	values, _ = packValues(values)
+	if len(values) == 0 {
+		return
+	}
+
+	resp := &Message__PullResponse{Values: values}
+	copy(resp.Pubkey[:], p.identity.Public().(ed25519.PublicKey))
+	packet, err := resp.BincodeSerialize()
+	if err != nil {
+		klog.Errorf("Failed to serialize pull response: %s", err)
+		return
+	}
+	if _, err := p.so.WriteToUDPAddrPort(packet, from); err != nil {
+		p.NumSendFail.Add(1)
+	}
+}
+
+// valuesMessageOverhead is the size of the push or pull response message
+// holding no values: its variant index, pubkey, and length of values.
+const valuesMessageOverhead = 4 + 32 + 8
+
+// packValues splits off the leading values that fit into the packet of a
+// push or pull response message.
+func packValues(values []CrdsValue) (packed []CrdsValue, rest []CrdsValue) {
+	size := valuesMessageOverhead
+	for i := range values {
+		buf, err := values[i].BincodeSerialize()
+		if err != nil {
+			continue
+		}
+		if size+len(buf) > PacketSize {
+			return packed, values[i:]
+		}
+		size += len(buf)
+		packed = append(packed, values[i])
+	}
+	return packed, nil
 }
@@ -0,0 +1,332 @@
+package gossip
+
+import (
+	"crypto/ed25519"
+	"math/bits"
+	"math/rand"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Limits of the push protocol.
+//
+// Based on solana_gossip::crds_gossip_push::{CRDS_GOSSIP_PUSH_FANOUT,
+// CRDS_GOSSIP_PUSH_MSG_TIMEOUT_MS} and solana_gossip::push_active_set.
+const (
+	PushFanout         = 6
+	PushMessageTimeout = 30 * time.Second
+	pushActiveSetSize  = 12
+	numStakeBuckets    = 25
+)
+
+// PushServer implements the receiving side of the gossip push protocol.
+//
+// Values received in push messages are verified and inserted into the table.
+// The relayers delivering values first are tracked, such that redundant
+// relayers can be sent prune messages.
+type PushServer struct {
+	table    *CrdsTable
+	received *receivedCache
+
+	NumValues  atomic.Uint64 // values inserted into the table
+	NumStale   atomic.Uint64 // values older than the ones in the table
+	NumInvalid atomic.Uint64 // values with an invalid signature
+	NumPrunes  atomic.Uint64 // prune messages sent
+}
+
+func NewPushServer(table *CrdsTable) *PushServer {
+	return &PushServer{table: table, received: newReceivedCache()}
+}
+
+// HandlePushMessage inserts the values of a push message into the table.
+//
+// Based on solana_gossip::crds_gossip_push::CrdsGossipPush::process_push_message.
+func (p *PushServer) HandlePushMessage(msg *Message__PushMessage, from netip.AddrPort) {
+	now := time.Now()
+	for _, value := range msg.Values {
+		if !value.VerifySignature() {
+			p.NumInvalid.Add(1)
+			continue
+		}
+		inserted, numDups, err := p.table.InsertPush(value, now)
+		if err != nil {
+			klog.Warningf("Failed to insert CRDS value: %s", err)
+			continue
+		}
+		if inserted {
+			p.NumValues.Add(1)
+		} else {
+			p.NumStale.Add(1)
+		}
+		p.received.record(*value.Data.Pubkey(), msg.Pubkey, from, numDups)
+	}
+}
+
+// SendPrunes sends prune messages to the relayers of origins whose values
+// are delivered first by other relayers, such that they stop pushing the
+// values of those origins to this node.
+//
+// Based on solana_gossip::crds_gossip_push::CrdsGossipPush::prune_received_cache.
+func (p *PushServer) SendPrunes(identity ed25519.PrivateKey, so udpSender, stakes map[Pubkey]uint64) error {
+	var self Pubkey
+	copy(self[:], identity.Public().(ed25519.PublicKey))
+	prunes, addrs := p.received.prune(self, stakes)
+	for relayer, origins := range prunes {
+		addr, ok := addrs[relayer]
+		if !ok {
+			continue
+		}
+		for len(origins) > 0 {
+			n := len(origins)
+			if n > maxPrunesPerMessage {
+				n = maxPrunesPerMessage
+			}
+			msg := &Message__PruneMessage{
+				Pubkey: self,
+				Data: PruneData{
+					Prunes:      origins[:n],
+					Destination: relayer,
+					Wallclock:   Wallclock(),
+				},
+			}
+			origins = origins[n:]
+			if err := msg.Data.Sign(identity); err != nil {
+				return err
+			}
+			packet, err := msg.BincodeSerialize()
+			if err != nil {
+				return err
+			}
+			if _, err := so.WriteToUDPAddrPort(packet, addr); err != nil {
+				return err
+			}
+			p.NumPrunes.Add(1)
+		}
+	}
+	return nil
+}
+
+// Push sends signed values to a node as push messages.
+func Push(identity ed25519.PrivateKey, so udpSender, target netip.AddrPort, values []CrdsValue) error {
+	for len(values) > 0 {
+		var packed []CrdsValue
+		packed, values = packValues(values)
+		if len(packed) == 0 {
+			break
+		}
+		msg := &Message__PushMessage{Values: packed}
+		copy(msg.Pubkey[:], identity.Public().(ed25519.PublicKey))
+		packet, err := msg.BincodeSerialize()
+		if err != nil {
+			return err
+		}
+		if _, err := so.WriteToUDPAddrPort(packet, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PushClient implements the sending side of the gossip push protocol.
+//
+// New values of the table are pushed to a few peers of an active set,
+// which is sampled by stake, and rotated over time. Peers prune the origins
+// they receive from other peers first, which are no longer pushed to them.
+//
+// Based on solana_gossip::crds_gossip_push::CrdsGossipPush.
+type PushClient struct {
+	identity     ed25519.PrivateKey
+	so           udpSender
+	table        *CrdsTable
+	self         Pubkey
+	shredVersion uint16
+
+	mu     sync.Mutex
+	stakes map[Pubkey]uint64
+	active []*pushPeer
+	cursor uint64 // ordinal of the last value pushed
+
+	NumPushed   atomic.Uint64 // values pushed to peers
+	NumPrunes   atomic.Uint64 // origins pruned by peers
+	NumInvalid  atomic.Uint64 // invalid prune messages
+	NumSendFail atomic.Uint64 // socket refused to send (tx buffer full)
+}
+
+// pushPeer is a peer of the active set.
+type pushPeer struct {
+	pubkey Pubkey
+	addr   netip.AddrPort
+	pruned map[Pubkey]struct{} // origins not pushed to the peer
+}
+
+// NewPushClient creates a push client, which pushes to the peers of the
+// given shred version, or to any peer if zero.
+func NewPushClient(identity ed25519.PrivateKey, so udpSender, table *CrdsTable, shredVersion uint16) *PushClient {
+	p := &PushClient{
+		identity:     identity,
+		so:           so,
+		table:        table,
+		shredVersion: shredVersion,
+	}
+	copy(p.self[:], identity.Public().(ed25519.PublicKey))
+	return p
+}
+
+// SetStakes sets the stakes of the nodes, by which peers are sampled.
+func (p *PushClient) SetStakes(stakes map[Pubkey]uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stakes = stakes
+}
+
+// stakeBucket returns the bucket of a stake, by the log2 of the stake in
+// SOL.
+//
+// Based on solana_gossip::push_active_set::get_stake_bucket.
+func stakeBucket(stake uint64) int {
+	bucket := bits.Len64(stake / 1_000_000_000)
+	if bucket >= numStakeBuckets {
+		bucket = numStakeBuckets - 1
+	}
+	return bucket
+}
+
+// RotateActiveSet drops the peers of the active set gone from the table,
+// and fills it with peers sampled by stake. A full active set has its
+// oldest peer replaced.
+//
+// Based on solana_gossip::push_active_set::PushActiveSetEntry::rotate.
+func (p *PushClient) RotateActiveSet() {
+	peers := make(map[Pubkey]netip.AddrPort)
+	for _, entry := range p.table.Entries(CrdsKindContactInfoV2) {
+		info, ok := entry.Value.Data.(*CrdsData__ContactInfoV2)
+		if !ok || info.Value.Pubkey == p.self ||
+			(p.shredVersion != 0 && info.Value.ShredVersion != p.shredVersion) {
+			continue
+		}
+		if addr, ok := info.Value.Socket(SocketGossip); ok && addr.Addr().IsValid() && addr.Port() != 0 {
+			peers[info.Value.Pubkey] = addr
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	active := p.active[:0]
+	for _, peer := range p.active {
+		if addr, ok := peers[peer.pubkey]; ok {
+			peer.addr = addr
+			active = append(active, peer)
+			delete(peers, peer.pubkey)
+		}
+	}
+	p.active = active
+
+	// weighted sampling without replacement, with weights growing with the
+	// square of the stake bucket
+	candidates := make([]Pubkey, 0, len(peers))
+	weights := make([]uint64, 0, len(peers))
+	var total uint64
+	for pubkey := range peers {
+		bucket := uint64(stakeBucket(p.stakes[pubkey]))
+		candidates = append(candidates, pubkey)
+		weights = append(weights, (bucket+1)*(bucket+1))
+		total += (bucket + 1) * (bucket + 1)
+	}
+	numNew := pushActiveSetSize - len(p.active)
+	if numNew <= 0 && len(candidates) > 0 {
+		p.active = p.active[1:]
+		numNew = 1
+	}
+	for ; numNew > 0 && total > 0; numNew-- {
+		x := uint64(rand.Int63n(int64(total)))
+		i := 0
+		for ; x >= weights[i]; i++ {
+			x -= weights[i]
+		}
+		p.active = append(p.active, &pushPeer{
+			pubkey: candidates[i],
+			addr:   peers[candidates[i]],
+			pruned: make(map[Pubkey]struct{}),
+		})
+		total -= weights[i]
+		weights[i] = 0
+	}
+}
+
+// PushNewValues pushes the values inserted into the table since the last
+// push to up to PushFanout peers of the active set each, skipping the peers
+// which pruned the origin of a value. Values older than PushMessageTimeout
+// are not pushed.
+//
+// Based on solana_gossip::crds_gossip_push::CrdsGossipPush::new_push_messages.
+func (p *PushClient) PushNewValues() error {
+	p.mu.Lock()
+	entries, cursor := p.table.EntriesSince(p.cursor)
+	p.cursor = cursor
+	minWallclock := uint64(time.Now().Add(-PushMessageTimeout).UnixMilli())
+	messages := make(map[*pushPeer][]CrdsValue)
+	for _, entry := range entries {
+		if entry.Value.Wallclock() < minWallclock {
+			continue
+		}
+		origin := *entry.Value.Data.Pubkey()
+		var fanout int
+		for _, peer := range p.active {
+			if fanout >= PushFanout {
+				break
+			}
+			if peer.pubkey == origin {
+				continue
+			}
+			if _, ok := peer.pruned[origin]; ok {
+				continue
+			}
+			messages[peer] = append(messages[peer], entry.Value)
+			fanout++
+		}
+	}
+	p.mu.Unlock()
+
+	for peer, values := range messages {
+		if err := Push(p.identity, p.so, peer.addr, values); err != nil {
+			p.NumSendFail.Add(1)
+			continue
+		}
+		p.NumPushed.Add(uint64(len(values)))
+	}
+	return nil
+}
+
+// HandlePruneMessage stops pushing the pruned origins to the sender of a
+// prune message. The values of this node are always pushed.
+//
+// Based on solana_gossip::crds_gossip_push::CrdsGossipPush::process_prune_msg.
+func (p *PushClient) HandlePruneMessage(msg *Message__PruneMessage, _ netip.AddrPort) {
+	data := &msg.Data
+	now := time.Now()
+	signed := time.UnixMilli(int64(data.Wallclock))
+	if msg.Pubkey != data.Pubkey || data.Destination != p.self ||
+		signed.Before(now.Add(-PruneMessageTimeout)) || signed.After(now.Add(PruneMessageTimeout)) ||
+		!data.VerifySignature() {
+		p.NumInvalid.Add(1)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, peer := range p.active {
+		if peer.pubkey != data.Pubkey {
+			continue
+		}
+		for _, origin := range data.Prunes {
+			if origin != p.self {
+				peer.pruned[origin] = struct{}{}
+				p.NumPrunes.Add(1)
+			}
+		}
+	}
+}
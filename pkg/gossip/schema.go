@@ -692,6 +692,13 @@ func DeserializeCrdsData(deserializer serde.Deserializer) (CrdsData, error) {
 			return nil, err
 		}
 
+	case 11:
+		if val, err := load_CrdsData__ContactInfoV2(deserializer); err == nil {
+			return &val, nil
+		} else {
+			return nil, err
+		}
+
 	default:
 		return nil, fmt.Errorf("Unknown variant index for CrdsData: %d", index)
 	}
@@ -1389,6 +1396,53 @@ func load_CrdsData__IncrementalSnapshotHashes(deserializer serde.Deserializer) (
 	return obj, nil
 }
 
+type CrdsData__ContactInfoV2 struct {
+	Value ContactInfoV2
+}
+
+func (*CrdsData__ContactInfoV2) isCrdsData() {}
+
+func (obj *CrdsData__ContactInfoV2) Serialize(serializer serde.Serializer) error {
+	if err := serializer.IncreaseContainerDepth(); err != nil {
+		return err
+	}
+	serializer.SerializeVariantIndex(11)
+	if err := obj.Value.Serialize(serializer); err != nil {
+		return err
+	}
+	serializer.DecreaseContainerDepth()
+	return nil
+}
+
+func (obj *CrdsData__ContactInfoV2) BincodeSerialize() ([]byte, error) {
+	if obj == nil {
+		return nil, fmt.Errorf("Cannot serialize null object")
+	}
+	serializer := bincode.NewSerializer()
+	if err := obj.Serialize(serializer); err != nil {
+		return nil, err
+	}
+	return serializer.GetBytes(), nil
+}
+
+func (obj *CrdsData__ContactInfoV2) Pubkey() *Pubkey {
+	return &obj.Value.Pubkey
+}
+
+func load_CrdsData__ContactInfoV2(deserializer serde.Deserializer) (CrdsData__ContactInfoV2, error) {
+	var obj CrdsData__ContactInfoV2
+	if err := deserializer.IncreaseContainerDepth(); err != nil {
+		return obj, err
+	}
+	if val, err := DeserializeContactInfoV2(deserializer); err == nil {
+		obj.Value = val
+	} else {
+		return obj, err
+	}
+	deserializer.DecreaseContainerDepth()
+	return obj, nil
+}
+
 type CrdsFilter struct {
 	Filter   Bloom
 	Mask     uint64
@@ -0,0 +1,109 @@
+package gossip
+
+import "net/netip"
+
+// SnapshotSource is a node advertising the snapshots it serves on its RPC
+// socket.
+type SnapshotSource struct {
+	Node        Pubkey
+	RPC         netip.AddrPort
+	Full        SlotHash
+	Incremental *SlotHash // latest incremental snapshot, if any
+}
+
+// SnapshotSources returns the nodes of the table advertising snapshot
+// hashes and an RPC socket, of the given shred version, or of any if zero.
+func SnapshotSources(table *CrdsTable, shredVersion uint16) []SnapshotSource {
+	rpc := make(map[Pubkey]netip.AddrPort)
+	for _, entry := range table.Entries(CrdsKindContactInfoV2) {
+		info := entry.Value.Data.(*CrdsData__ContactInfoV2).Value
+		if shredVersion != 0 && info.ShredVersion != shredVersion {
+			continue
+		}
+		if addr, ok := info.Socket(SocketRPC); ok && addr.Addr().IsValid() && addr.Port() != 0 {
+			rpc[info.Pubkey] = addr
+		}
+	}
+
+	var sources []SnapshotSource
+	for _, entry := range table.Entries(CrdsKindIncrementalSnapshotHashes) {
+		hashes := entry.Value.Data.(*CrdsData__IncrementalSnapshotHashes).Value
+		addr, ok := rpc[hashes.From]
+		if !ok {
+			continue
+		}
+		source := SnapshotSource{Node: hashes.From, RPC: addr, Full: hashes.Base}
+		for i := range hashes.Hashes {
+			if inc := &hashes.Hashes[i]; inc.Slot > hashes.Base.Slot &&
+				(source.Incremental == nil || inc.Slot > source.Incremental.Slot) {
+				source.Incremental = inc
+			}
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// SelectSnapshotSources returns the sources of the latest snapshot: of the
+// full snapshot of the highest slot, and of the incremental snapshot of the
+// highest slot on top of it, if any.
+//
+// With known validators, only the snapshots advertised by known validators
+// are trusted, and the sources of other snapshots are dropped.
+//
+// Based on solana_validator::bootstrap::get_eligible_peer_snapshot_hashes.
+func SelectSnapshotSources(sources []SnapshotSource, knownValidators map[Pubkey]struct{}) []SnapshotSource {
+	if len(knownValidators) > 0 {
+		known := make(map[SlotHash]map[SlotHash]struct{})
+		for _, source := range sources {
+			if _, ok := knownValidators[source.Node]; !ok {
+				continue
+			}
+			incrementals, ok := known[source.Full]
+			if !ok {
+				incrementals = make(map[SlotHash]struct{})
+				known[source.Full] = incrementals
+			}
+			if source.Incremental != nil {
+				incrementals[*source.Incremental] = struct{}{}
+			}
+		}
+		var trusted []SnapshotSource
+		for _, source := range sources {
+			incrementals, ok := known[source.Full]
+			if !ok {
+				continue
+			}
+			if source.Incremental != nil {
+				if _, ok := incrementals[*source.Incremental]; !ok {
+					continue
+				}
+			}
+			trusted = append(trusted, source)
+		}
+		sources = trusted
+	}
+
+	var fullSlot, incrementalSlot uint64
+	for _, source := range sources {
+		if source.Full.Slot > fullSlot {
+			fullSlot = source.Full.Slot
+		}
+	}
+	for _, source := range sources {
+		if source.Full.Slot == fullSlot && source.Incremental != nil && source.Incremental.Slot > incrementalSlot {
+			incrementalSlot = source.Incremental.Slot
+		}
+	}
+	var selected []SnapshotSource
+	for _, source := range sources {
+		if source.Full.Slot != fullSlot {
+			continue
+		}
+		if incrementalSlot != 0 && (source.Incremental == nil || source.Incremental.Slot != incrementalSlot) {
+			continue
+		}
+		selected = append(selected, source)
+	}
+	return selected
+}
@@ -0,0 +1,75 @@
+package gossip
+
+import (
+	"crypto/ed25519"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotSources(t *testing.T) {
+	table := NewCrdsTable()
+	insert := func(t *testing.T, data CrdsData, identity ed25519.PrivateKey) {
+		value := CrdsValue{Data: data}
+		require.NoError(t, value.Sign(identity))
+		_, err := table.Insert(value, time.Now())
+		require.NoError(t, err)
+	}
+	var nodes []Pubkey
+	for i := 0; i < 3; i++ {
+		identity, pubkey := newIdentity(t)
+		nodes = append(nodes, pubkey)
+		info := ContactInfoV2{Wallclock: 1, ShredVersion: 1}
+		if i == 2 {
+			info.ShredVersion = 2
+		}
+		info.SetSockets(map[uint8]netip.AddrPort{
+			SocketGossip: netip.MustParseAddrPort("10.0.0.1:8001"),
+			SocketRPC:    netip.MustParseAddrPort("10.0.0.1:8899"),
+		})
+		insert(t, &CrdsData__ContactInfoV2{Value: info}, identity)
+		insert(t, &CrdsData__IncrementalSnapshotHashes{Value: IncrementalSnapshotHashes{
+			Base:   SlotHash{Slot: 100},
+			Hashes: []SlotHash{{Slot: 120}, {Slot: 110 + uint64(i)}},
+		}}, identity)
+	}
+
+	sources := SnapshotSources(table, 1)
+	require.Len(t, sources, 2)
+	for _, source := range sources {
+		assert.Contains(t, nodes[:2], source.Node)
+		assert.Equal(t, netip.MustParseAddrPort("10.0.0.1:8899"), source.RPC)
+		assert.Equal(t, SlotHash{Slot: 120}, *source.Incremental)
+	}
+	assert.Len(t, SnapshotSources(table, 0), 3)
+}
+
+func TestSelectSnapshotSources(t *testing.T) {
+	known, peer, other, stale := Pubkey{1}, Pubkey{2}, Pubkey{3}, Pubkey{4}
+	full := SlotHash{Slot: 100, Hash: Hash{1}}
+	sources := []SnapshotSource{
+		{Node: known, Full: full, Incremental: &SlotHash{Slot: 150, Hash: Hash{2}}},
+		{Node: peer, Full: full, Incremental: &SlotHash{Slot: 150, Hash: Hash{2}}},
+		{Node: other, Full: SlotHash{Slot: 200, Hash: Hash{3}}},
+		{Node: stale, Full: full},
+	}
+
+	// the highest slots win, regardless of their hashes
+	selected := SelectSnapshotSources(sources, nil)
+	require.Len(t, selected, 1)
+	assert.Equal(t, other, selected[0].Node)
+
+	// known validators only trust their hashes, and incrementals are preferred
+	selected = SelectSnapshotSources(sources, map[Pubkey]struct{}{known: {}})
+	require.Len(t, selected, 2)
+	assert.Equal(t, known, selected[0].Node)
+	assert.Equal(t, peer, selected[1].Node)
+
+	sources[1].Incremental = &SlotHash{Slot: 150, Hash: Hash{9}}
+	selected = SelectSnapshotSources(sources, map[Pubkey]struct{}{known: {}})
+	require.Len(t, selected, 1)
+	assert.Equal(t, known, selected[0].Node)
+}
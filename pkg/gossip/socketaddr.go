@@ -55,7 +55,10 @@ func DeserializeAddr(deserializer serde.Deserializer) (sa Addr, err error) {
 
 func (a Addr) Serialize(serializer serde.Serializer) error {
 	var raw RawAddr
-	if a.Is4() {
+	if !a.IsValid() {
+		// Placeholder for unset addresses
+		raw = &RawAddr__V4{}
+	} else if a.Is4() {
 		v4 := RawAddr__V4(a.As4())
 		raw = &v4
 	} else {
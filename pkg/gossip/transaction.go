@@ -7,15 +7,17 @@ import (
 
 // TODO write codegen for this
 
+// Transaction is a legacy transaction, as found in votes.
+// Lengths are encoded as short_vec (compact-u16).
 type Transaction solana.Transaction
 
 func DeserializeTransaction(deserializer serde.Deserializer) (Transaction, error) {
 	var obj Transaction
-	numSigs, err := deserializer.DeserializeU8()
+	numSigs, err := deserializeShortVecLen(deserializer)
 	if err != nil {
 		return obj, err
 	}
-	for i := uint8(0); i < numSigs; i++ {
+	for i := 0; i < numSigs; i++ {
 		sig, err := DeserializeSignature(deserializer)
 		if err != nil {
 			return obj, err
@@ -30,7 +32,16 @@ func DeserializeTransaction(deserializer serde.Deserializer) (Transaction, error
 }
 
 func (obj *Transaction) Serialize(serializer serde.Serializer) error {
-	panic("not implemented")
+	if err := serializeVarint(serializer, uint64(len(obj.Signatures))); err != nil {
+		return err
+	}
+	for _, sig := range obj.Signatures {
+		sig := Signature(sig)
+		if err := sig.Serialize(serializer); err != nil {
+			return err
+		}
+	}
+	return serializeTxMessage(&obj.Message, serializer)
 }
 
 func DeserializeTxMessage(deserializer serde.Deserializer) (solana.Message, error) {
@@ -50,11 +61,11 @@ func DeserializeTxMessage(deserializer serde.Deserializer) (solana.Message, erro
 		return obj, err
 	}
 	obj.Header.NumReadonlyUnsignedAccounts = numReadonlyUnsignedAccs
-	numAccountKeys, err := deserializer.DeserializeU8()
+	numAccountKeys, err := deserializeShortVecLen(deserializer)
 	if err != nil {
 		return obj, err
 	}
-	for i := uint8(0); i < numAccountKeys; i++ {
+	for i := 0; i < numAccountKeys; i++ {
 		address, err := DeserializePubkey(deserializer)
 		if err != nil {
 			return obj, err
@@ -66,11 +77,11 @@ func DeserializeTxMessage(deserializer serde.Deserializer) (solana.Message, erro
 		return obj, err
 	}
 	obj.RecentBlockhash = solana.Hash(recentBlockHash)
-	numInsns, err := deserializer.DeserializeU8()
+	numInsns, err := deserializeShortVecLen(deserializer)
 	if err != nil {
 		return obj, err
 	}
-	for i := uint8(0); i < numInsns; i++ {
+	for i := 0; i < numInsns; i++ {
 		insn, err := DeserializeInstruction(deserializer)
 		if err != nil {
 			return obj, err
@@ -80,6 +91,41 @@ func DeserializeTxMessage(deserializer serde.Deserializer) (solana.Message, erro
 	return obj, nil
 }
 
+func serializeTxMessage(obj *solana.Message, serializer serde.Serializer) error {
+	header := []uint8{
+		obj.Header.NumRequiredSignatures,
+		obj.Header.NumReadonlySignedAccounts,
+		obj.Header.NumReadonlyUnsignedAccounts,
+	}
+	for _, x := range header {
+		if err := serializer.SerializeU8(x); err != nil {
+			return err
+		}
+	}
+	if err := serializeVarint(serializer, uint64(len(obj.AccountKeys))); err != nil {
+		return err
+	}
+	for _, address := range obj.AccountKeys {
+		address := Pubkey(address)
+		if err := address.Serialize(serializer); err != nil {
+			return err
+		}
+	}
+	recentBlockHash := Hash(obj.RecentBlockhash)
+	if err := recentBlockHash.Serialize(serializer); err != nil {
+		return err
+	}
+	if err := serializeVarint(serializer, uint64(len(obj.Instructions))); err != nil {
+		return err
+	}
+	for i := range obj.Instructions {
+		if err := serializeInstruction(&obj.Instructions[i], serializer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func DeserializeInstruction(deserializer serde.Deserializer) (solana.CompiledInstruction, error) {
 	var obj solana.CompiledInstruction
 	programIdIdx, err := deserializer.DeserializeU8()
@@ -87,24 +133,23 @@ func DeserializeInstruction(deserializer serde.Deserializer) (solana.CompiledIns
 		return obj, err
 	}
 	obj.ProgramIDIndex = uint16(programIdIdx)
-	numAccs, err := deserializer.DeserializeU8()
+	numAccs, err := deserializeShortVecLen(deserializer)
 	if err != nil {
 		return obj, err
 	}
-	for i := uint8(0); i < numAccs; i++ {
+	for i := 0; i < numAccs; i++ {
 		idx, err := deserializer.DeserializeU8()
 		if err != nil {
 			return obj, err
 		}
 		obj.Accounts = append(obj.Accounts, uint16(idx))
 	}
-	// This is brain-dead
-	dataLen, err := deserializer.DeserializeU8()
+	dataLen, err := deserializeShortVecLen(deserializer)
 	if err != nil {
 		return obj, err
 	}
 	obj.Data = make([]byte, dataLen)
-	for i := uint8(0); i < dataLen; i++ {
+	for i := 0; i < dataLen; i++ {
 		_byte, err := deserializer.DeserializeU8()
 		if err != nil {
 			return obj, err
@@ -113,3 +158,26 @@ func DeserializeInstruction(deserializer serde.Deserializer) (solana.CompiledIns
 	}
 	return obj, nil
 }
+
+func serializeInstruction(obj *solana.CompiledInstruction, serializer serde.Serializer) error {
+	if err := serializer.SerializeU8(uint8(obj.ProgramIDIndex)); err != nil {
+		return err
+	}
+	if err := serializeVarint(serializer, uint64(len(obj.Accounts))); err != nil {
+		return err
+	}
+	for _, idx := range obj.Accounts {
+		if err := serializer.SerializeU8(uint8(idx)); err != nil {
+			return err
+		}
+	}
+	if err := serializeVarint(serializer, uint64(len(obj.Data))); err != nil {
+		return err
+	}
+	for _, b := range obj.Data {
+		if err := serializer.SerializeU8(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
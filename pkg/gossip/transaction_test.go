@@ -0,0 +1,39 @@
+package gossip
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/novifinancial/serde-reflection/serde-generate/runtime/golang/bincode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction(t *testing.T) {
+	data := make([]byte, 200) // length takes two bytes
+	for i := range data {
+		data[i] = byte(i)
+	}
+	tx := Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			Header:          solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+			AccountKeys:     []solana.PublicKey{{2}, {3}, {4}},
+			RecentBlockhash: solana.Hash{5},
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 2, Accounts: []uint16{1, 0}, Data: data},
+			},
+		},
+	}
+
+	serializer := bincode.NewSerializer()
+	require.NoError(t, tx.Serialize(serializer))
+	buf := serializer.GetBytes()
+	expected, err := (*solana.Transaction)(&tx).MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, expected, buf)
+
+	decoded, err := DeserializeTransaction(bincode.NewDeserializer(buf))
+	require.NoError(t, err)
+	assert.Equal(t, tx, decoded)
+}
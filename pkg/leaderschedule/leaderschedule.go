@@ -0,0 +1,92 @@
+// Package leaderschedule computes which node is the leader of each slot.
+package leaderschedule
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+// NumConsecutiveLeaderSlots is the number of consecutive slots of a
+// leader.
+//
+// Based on solana_sdk::clock::NUM_CONSECUTIVE_LEADER_SLOTS.
+const NumConsecutiveLeaderSlots = 4
+
+// Schedule is the leader schedule of an epoch.
+type Schedule struct {
+	Epoch     uint64
+	FirstSlot uint64
+	leaders   [][32]byte
+}
+
+// NodeStake is the stake delegated to the vote accounts of a node.
+type NodeStake struct {
+	Node  [32]byte
+	Stake uint64
+}
+
+// New computes the leader schedule of an epoch from the stakes of the
+// nodes, as of the epoch stakes of the epoch. Nodes without stake are
+// never leaders.
+//
+// Based on solana_ledger::leader_schedule_utils::leader_schedule.
+func New(epoch, firstSlot, slotsInEpoch uint64, stakes []NodeStake) *Schedule {
+	s := &Schedule{Epoch: epoch, FirstSlot: firstSlot}
+
+	// by descending stake, then descending pubkey
+	nodes := make([]NodeStake, 0, len(stakes))
+	for _, stake := range stakes {
+		if stake.Stake != 0 {
+			nodes = append(nodes, stake)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Stake != nodes[j].Stake {
+			return nodes[i].Stake > nodes[j].Stake
+		}
+		return bytes.Compare(nodes[i].Node[:], nodes[j].Node[:]) > 0
+	})
+	if len(nodes) == 0 {
+		return s
+	}
+
+	weights := make([]uint64, len(nodes))
+	for i, node := range nodes {
+		weights[i] = node.Stake
+	}
+	dist := newWeightedIndex(weights)
+	var seed [32]byte
+	binary.LittleEndian.PutUint64(seed[:8], epoch)
+	rng := newChachaRng(seed)
+
+	s.leaders = make([][32]byte, slotsInEpoch)
+	var leader [32]byte
+	for i := range s.leaders {
+		if i%NumConsecutiveLeaderSlots == 0 {
+			leader = nodes[dist.sample(rng)].Node
+		}
+		s.leaders[i] = leader
+	}
+	return s
+}
+
+// FromEpochStakes computes the leader schedule of the epoch of the given
+// epoch stakes.
+func FromEpochStakes(es *snapshot.EpochStakes, firstSlot, slotsInEpoch uint64) *Schedule {
+	stakes := make([]NodeStake, len(es.NodeIdToVoteAccounts))
+	for i, node := range es.NodeIdToVoteAccounts {
+		stakes[i] = NodeStake{Node: node.NodeId, Stake: node.TotalStake}
+	}
+	return New(es.Epoch, firstSlot, slotsInEpoch, stakes)
+}
+
+// Leader returns the leader of a slot of the epoch.
+func (s *Schedule) Leader(slot uint64) ([32]byte, bool) {
+	if slot < s.FirstSlot || slot-s.FirstSlot >= uint64(len(s.leaders)) {
+		return [32]byte{}, false
+	}
+	return s.leaders[slot-s.FirstSlot], true
+}
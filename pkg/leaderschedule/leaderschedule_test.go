@@ -0,0 +1,67 @@
+package leaderschedule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChachaRng(t *testing.T) {
+	// RFC 7539 keystream of the zero key and nonce
+	r := newChachaRng([32]byte{})
+	assert.Equal(t, uint64(0x903df1a0ade0b876), r.nextU64())
+	assert.Equal(t, uint64(0x28bd8653e56a5d40), r.nextU64())
+	for i := 0; i < 64; i++ {
+		r.nextU64()
+	}
+}
+
+func TestWeightedIndex(t *testing.T) {
+	w := newWeightedIndex([]uint64{1, 0, 3})
+	assert.Equal(t, []uint64{1, 1}, w.cumulative)
+	assert.Equal(t, uint64(4), w.total)
+
+	r := newChachaRng([32]byte{1})
+	counts := make([]int, 3)
+	for i := 0; i < 4000; i++ {
+		counts[w.sample(r)]++
+	}
+	assert.Zero(t, counts[1])
+	assert.InDelta(t, 1000, counts[0], 150)
+	assert.InDelta(t, 3000, counts[2], 150)
+}
+
+func TestSchedule(t *testing.T) {
+	stakes := []NodeStake{
+		{Node: [32]byte{1}, Stake: 10},
+		{Node: [32]byte{2}, Stake: 30},
+		{Node: [32]byte{3}, Stake: 0},
+	}
+	s := New(5, 1000, 400, stakes)
+	reordered := New(5, 1000, 400, []NodeStake{stakes[2], stakes[1], stakes[0]})
+	assert.Equal(t, s, reordered)
+	assert.NotEqual(t, s.leaders, New(6, 1000, 400, stakes).leaders)
+
+	_, ok := s.Leader(999)
+	assert.False(t, ok)
+	_, ok = s.Leader(1400)
+	assert.False(t, ok)
+
+	counts := make(map[[32]byte]int)
+	for slot := uint64(1000); slot < 1400; slot += NumConsecutiveLeaderSlots {
+		leader, ok := s.Leader(slot)
+		require.True(t, ok)
+		for i := uint64(1); i < NumConsecutiveLeaderSlots; i++ {
+			next, _ := s.Leader(slot + i)
+			assert.Equal(t, leader, next)
+		}
+		counts[leader]++
+	}
+	assert.Zero(t, counts[[32]byte{3}])
+	assert.Greater(t, counts[[32]byte{2}], counts[[32]byte{1}])
+
+	empty := New(5, 0, 4, nil)
+	_, ok = empty.Leader(0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,89 @@
+package leaderschedule
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// chachaRng generates random numbers from the ChaCha20 keystream of a
+// seed, with a zero nonce.
+//
+// Based on rand_chacha::ChaCha20Rng.
+type chachaRng struct {
+	cipher *chacha20.Cipher
+	buf    [256]byte
+	pos    int
+}
+
+func newChachaRng(seed [32]byte) *chachaRng {
+	cipher, err := chacha20.NewUnauthenticatedCipher(seed[:], make([]byte, chacha20.NonceSize))
+	if err != nil {
+		panic(err.Error())
+	}
+	return &chachaRng{cipher: cipher, pos: len(chachaRng{}.buf)}
+}
+
+func (r *chachaRng) nextU64() uint64 {
+	if r.pos == len(r.buf) {
+		for i := range r.buf {
+			r.buf[i] = 0
+		}
+		r.cipher.XORKeyStream(r.buf[:], r.buf[:])
+		r.pos = 0
+	}
+	x := binary.LittleEndian.Uint64(r.buf[r.pos:])
+	r.pos += 8
+	return x
+}
+
+// weightedIndex samples indexes with a probability proportional to their
+// weights.
+//
+// Based on rand::distributions::WeightedIndex.
+type weightedIndex struct {
+	// cumulative weights, without the total
+	cumulative []uint64
+	total      uint64
+	// largest sample accepted by uniform rejection sampling
+	zone uint64
+}
+
+func newWeightedIndex(weights []uint64) *weightedIndex {
+	w := &weightedIndex{}
+	for i, weight := range weights {
+		if i > 0 {
+			w.cumulative = append(w.cumulative, w.total)
+		}
+		w.total += weight
+	}
+	if w.total != 0 {
+		w.zone = ^uint64(0) - (^uint64(0)-w.total+1)%w.total
+	}
+	return w
+}
+
+func (w *weightedIndex) sample(r *chachaRng) int {
+	// uniform in [0, total)
+	// Based on rand::distributions::uniform::UniformInt::sample.
+	var chosen uint64
+	for {
+		hi, lo := bits.Mul64(r.nextU64(), w.total)
+		if lo <= w.zone {
+			chosen = hi
+			break
+		}
+	}
+	// number of cumulative weights not above the chosen one
+	lo, hi := 0, len(w.cumulative)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if w.cumulative[mid] <= chosen {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
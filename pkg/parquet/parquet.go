@@ -0,0 +1,319 @@
+// Package parquet writes Apache Parquet files of flat records.
+//
+// Columns are required, PLAIN encoded, and written as a single data page
+// per row group.
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic is the first and last four bytes of a Parquet file.
+const Magic = "PAR1"
+
+// DefaultRowGroupSize is the number of buffered bytes after which Append
+// writes a row group.
+const DefaultRowGroupSize = 64 << 20
+
+// Type is the type of the values of a column.
+type Type int
+
+const (
+	Boolean Type = iota
+	Int32
+	Int64
+	Uint64
+	String
+	Bytes
+)
+
+// physical returns the Parquet physical type.
+func (t Type) physical() int32 {
+	switch t {
+	case Boolean:
+		return 0
+	case Int32:
+		return 1
+	case Int64, Uint64:
+		return 2
+	default:
+		return 6 // BYTE_ARRAY
+	}
+}
+
+// converted returns the Parquet converted type, or -1 if there is none.
+func (t Type) converted() int32 {
+	switch t {
+	case String:
+		return 0 // UTF8
+	case Uint64:
+		return 14 // UINT_64
+	default:
+		return -1
+	}
+}
+
+// Column describes a column of a file.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Codec is the compression codec of the pages of a file.
+type Codec int32
+
+const (
+	Uncompressed Codec = 0
+	Zstd         Codec = 6
+)
+
+type columnChunk struct {
+	offset       int64
+	uncompressed int64
+	compressed   int64
+}
+
+type rowGroup struct {
+	columns []columnChunk
+	numRows int64
+	size    int64
+}
+
+// Writer writes rows to a Parquet file.
+type Writer struct {
+	// RowGroupSize is the number of buffered bytes after which Append
+	// writes a row group.
+	RowGroupSize int
+
+	w       io.Writer
+	off     int64
+	columns []Column
+	codec   Codec
+	zstd    *zstd.Encoder
+
+	buffers   [][]byte
+	numRows   int
+	totalRows int64
+	rowGroups []rowGroup
+}
+
+// NewWriter writes the header of a file with the given columns to w.
+func NewWriter(w io.Writer, columns []Column, codec Codec) (*Writer, error) {
+	pw := &Writer{
+		RowGroupSize: DefaultRowGroupSize,
+		w:            w,
+		columns:      columns,
+		codec:        codec,
+		buffers:      make([][]byte, len(columns)),
+	}
+	switch codec {
+	case Uncompressed:
+	case Zstd:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		pw.zstd = enc
+	default:
+		return nil, fmt.Errorf("unsupported codec %d", codec)
+	}
+	if err := pw.write([]byte(Magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.off += int64(n)
+	return err
+}
+
+// NumRows returns the number of rows appended.
+func (w *Writer) NumRows() int64 {
+	return w.totalRows + int64(w.numRows)
+}
+
+// Append buffers a row, of which the values are of the Go types of the
+// column types: bool, int32, int64, uint64, string and []byte.
+func (w *Writer) Append(values ...interface{}) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("row of %d values, expected %d", len(values), len(w.columns))
+	}
+	for i, v := range values {
+		if !w.columns[i].Type.accepts(v) {
+			return fmt.Errorf("invalid value of type %T for column %s", v, w.columns[i].Name)
+		}
+	}
+	var size int
+	for i, v := range values {
+		w.buffers[i] = w.appendValue(w.buffers[i], v)
+		size += len(w.buffers[i])
+	}
+	w.numRows++
+	if size >= w.RowGroupSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+func (t Type) accepts(v interface{}) bool {
+	switch v.(type) {
+	case bool:
+		return t == Boolean
+	case int32:
+		return t == Int32
+	case int64:
+		return t == Int64
+	case uint64:
+		return t == Uint64
+	case string:
+		return t == String
+	case []byte:
+		return t == Bytes
+	default:
+		return false
+	}
+}
+
+// appendValue appends the PLAIN encoding of a value.
+func (w *Writer) appendValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case bool:
+		if w.numRows%8 == 0 {
+			b = append(b, 0)
+		}
+		if v {
+			b[len(b)-1] |= 1 << (w.numRows % 8)
+		}
+	case int32:
+		b = binary.LittleEndian.AppendUint32(b, uint32(v))
+	case int64:
+		b = binary.LittleEndian.AppendUint64(b, uint64(v))
+	case uint64:
+		b = binary.LittleEndian.AppendUint64(b, v)
+	case string:
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(v)))
+		b = append(b, v...)
+	case []byte:
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(v)))
+		b = append(b, v...)
+	}
+	return b
+}
+
+// Flush writes the buffered rows as a row group.
+func (w *Writer) Flush() error {
+	if w.numRows == 0 {
+		return nil
+	}
+	group := rowGroup{
+		columns: make([]columnChunk, len(w.columns)),
+		numRows: int64(w.numRows),
+	}
+	for i, data := range w.buffers {
+		page := data
+		if w.zstd != nil {
+			page = w.zstd.EncodeAll(data, nil)
+		}
+		var t thriftWriter
+		t.beginStruct()
+		t.i32(1, 0) // DATA_PAGE
+		t.i32(2, int32(len(data)))
+		t.i32(3, int32(len(page)))
+		t.structField(5)
+		t.i32(1, int32(w.numRows))
+		t.i32(2, 0) // PLAIN
+		t.i32(3, 3) // RLE
+		t.i32(4, 3) // RLE
+		t.endStruct()
+		t.endStruct()
+
+		group.columns[i] = columnChunk{
+			offset:       w.off,
+			uncompressed: int64(len(t.b) + len(data)),
+			compressed:   int64(len(t.b) + len(page)),
+		}
+		group.size += group.columns[i].uncompressed
+		if err := w.write(t.b); err != nil {
+			return err
+		}
+		if err := w.write(page); err != nil {
+			return err
+		}
+		w.buffers[i] = data[:0]
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.totalRows += int64(w.numRows)
+	w.numRows = 0
+	return nil
+}
+
+// Close writes the buffered rows and the footer of the file. It does not
+// close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if w.zstd != nil {
+		w.zstd.Close()
+	}
+	footer := w.appendFileMetaData()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, Magic...)
+	return w.write(footer)
+}
+
+func (w *Writer) appendFileMetaData() []byte {
+	var t thriftWriter
+	t.beginStruct()
+	t.i32(1, 1)
+	t.list(2, thriftStruct, len(w.columns)+1)
+	t.beginStruct()
+	t.string(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.endStruct()
+	for _, col := range w.columns {
+		t.beginStruct()
+		t.i32(1, col.Type.physical())
+		t.i32(3, 0) // REQUIRED
+		t.string(4, col.Name)
+		if conv := col.Type.converted(); conv >= 0 {
+			t.i32(6, conv)
+		}
+		t.endStruct()
+	}
+	t.i64(3, w.totalRows)
+	t.list(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		t.beginStruct()
+		t.list(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			t.beginStruct()
+			t.i64(2, chunk.offset)
+			t.structField(3)
+			t.i32(1, w.columns[i].Type.physical())
+			t.list(2, thriftI32, 1)
+			t.i32Elem(0) // PLAIN
+			t.list(3, thriftBinary, 1)
+			t.stringElem(w.columns[i].Name)
+			t.i32(4, int32(w.codec))
+			t.i64(5, group.numRows)
+			t.i64(6, chunk.uncompressed)
+			t.i64(7, chunk.compressed)
+			t.i64(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.numRows)
+		t.endStruct()
+	}
+	t.string(6, "radiance")
+	t.endStruct()
+	return t.b
+}
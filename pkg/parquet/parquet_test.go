@@ -0,0 +1,207 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader decodes Thrift compact structs into maps of field ids to
+// int64, []byte, []interface{} or map[int16]interface{} values.
+type thriftReader struct {
+	b []byte
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := r.varint()
+		v := r.b[:n]
+		r.b = r.b[n:]
+		return v
+	case thriftList:
+		h := r.b[0]
+		r.b = r.b[1:]
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.varint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(h & 0xf)
+		}
+		return list
+	case thriftStruct:
+		s := make(map[int16]interface{})
+		var last int16
+		for {
+			h := r.b[0]
+			r.b = r.b[1:]
+			if h == 0 {
+				return s
+			}
+			if delta := int16(h >> 4); delta != 0 {
+				last += delta
+			} else {
+				last = int16(r.zigzag())
+			}
+			s[last] = r.value(h & 0xf)
+		}
+	default:
+		panic(fmt.Sprintf("unexpected type %d", typ))
+	}
+}
+
+func readStruct(b []byte) (map[int16]interface{}, int) {
+	r := thriftReader{b: b}
+	s := r.value(thriftStruct).(map[int16]interface{})
+	return s, len(b) - len(r.b)
+}
+
+func TestThriftWriter(t *testing.T) {
+	var w thriftWriter
+	w.beginStruct()
+	w.i32(1, -1)
+	w.i64(20, 300)
+	w.list(21, thriftI32, 16)
+	for i := 0; i < 16; i++ {
+		w.i32Elem(int32(i))
+	}
+	w.structField(22)
+	w.string(1, "a")
+	w.endStruct()
+	w.endStruct()
+
+	assert.Equal(t, []byte{
+		0x15, 0x01, // field 1, i32 -1
+		0x06, 0x28, 0xd8, 0x04, // field 20, i64 300
+		0x19, 0xf5, 0x10, // field 21, list of 16 i32
+		0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30,
+		0x1c, 0x18, 0x01, 'a', 0x00, // field 22, struct
+		0x00,
+	}, w.b)
+
+	s, n := readStruct(w.b)
+	assert.Equal(t, len(w.b), n)
+	assert.Equal(t, int64(-1), s[1])
+	assert.Equal(t, int64(300), s[20])
+	assert.Len(t, s[21], 16)
+	assert.Equal(t, []byte("a"), s[22].(map[int16]interface{})[1])
+}
+
+func TestWriter(t *testing.T) {
+	for _, codec := range []Codec{Uncompressed, Zstd} {
+		t.Run(fmt.Sprint(codec), func(t *testing.T) {
+			testWriter(t, codec)
+		})
+	}
+}
+
+func testWriter(t *testing.T, codec Codec) {
+	columns := []Column{
+		{Name: "ok", Type: Boolean},
+		{Name: "index", Type: Int32},
+		{Name: "delta", Type: Int64},
+		{Name: "slot", Type: Uint64},
+		{Name: "name", Type: String},
+		{Name: "data", Type: Bytes},
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns, codec)
+	require.NoError(t, err)
+	w.RowGroupSize = 100
+
+	const numRows = 20
+	for i := 0; i < numRows; i++ {
+		require.NoError(t, w.Append(i%3 == 0, int32(i), int64(-i), uint64(i)<<40, fmt.Sprint(i), []byte{byte(i)}))
+	}
+	assert.Error(t, w.Append(true))
+	assert.Error(t, w.Append(1, int32(0), int64(0), uint64(0), "", []byte(nil)))
+	assert.Equal(t, int64(numRows), w.NumRows())
+	require.NoError(t, w.Close())
+
+	file := buf.Bytes()
+	require.True(t, bytes.HasPrefix(file, []byte(Magic)))
+	require.True(t, bytes.HasSuffix(file, []byte(Magic)))
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := file[len(file)-8-footerLen : len(file)-8]
+	meta, n := readStruct(footer)
+	require.Equal(t, footerLen, n)
+
+	assert.Equal(t, int64(numRows), meta[3])
+	schema := meta[2].([]interface{})
+	require.Len(t, schema, len(columns)+1)
+	assert.Equal(t, int64(len(columns)), schema[0].(map[int16]interface{})[5])
+	for i, col := range columns {
+		assert.Equal(t, []byte(col.Name), schema[i+1].(map[int16]interface{})[4])
+	}
+
+	groups := meta[4].([]interface{})
+	require.Greater(t, len(groups), 1)
+	var row int
+	for _, g := range groups {
+		group := g.(map[int16]interface{})
+		groupRows := int(group[3].(int64))
+		for i, c := range group[1].([]interface{}) {
+			colMeta := c.(map[int16]interface{})[3].(map[int16]interface{})
+			assert.Equal(t, int64(codec), colMeta[4])
+			assert.Equal(t, int64(groupRows), colMeta[5])
+
+			off := colMeta[9].(int64)
+			header, n := readStruct(file[off:])
+			assert.Equal(t, colMeta[7], int64(n)+header[3].(int64))
+			page := file[off+int64(n) : off+int64(n)+header[3].(int64)]
+			if codec == Zstd {
+				dec, err := zstd.NewReader(nil)
+				require.NoError(t, err)
+				page, err = dec.DecodeAll(page, nil)
+				require.NoError(t, err)
+			}
+			require.Equal(t, header[2], int64(len(page)))
+			assert.Equal(t, int64(groupRows), header[5].(map[int16]interface{})[1])
+
+			for j := 0; j < groupRows; j++ {
+				k := row + j
+				switch columns[i].Type {
+				case Boolean:
+					assert.Equal(t, k%3 == 0, page[j/8]&(1<<(j%8)) != 0)
+				case Int32:
+					assert.Equal(t, uint32(k), binary.LittleEndian.Uint32(page[4*j:]))
+				case Int64:
+					assert.Equal(t, int64(-k), int64(binary.LittleEndian.Uint64(page[8*j:])))
+				case Uint64:
+					assert.Equal(t, uint64(k)<<40, binary.LittleEndian.Uint64(page[8*j:]))
+				case String, Bytes:
+					l := binary.LittleEndian.Uint32(page)
+					v := page[4 : 4+l]
+					page = page[4+l:]
+					if columns[i].Type == String {
+						assert.Equal(t, fmt.Sprint(k), string(v))
+					} else {
+						assert.Equal(t, []byte{byte(k)}, v)
+					}
+				}
+			}
+		}
+		row += groupRows
+	}
+	assert.Equal(t, numRows, row)
+}
@@ -0,0 +1,92 @@
+package poh
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/merkletree"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// TransactionsMixin returns the value an entry mixes into the PoH chain for
+// its transactions, the Merkle root of their signatures.
+//
+// Port of solana_entry::entry::hash_transactions
+func TransactionsMixin(txns []solana.Transaction) [32]byte {
+	var sigs [][]byte
+	for i := range txns {
+		for j := range txns[i].Signatures {
+			sigs = append(sigs, txns[i].Signatures[j][:])
+		}
+	}
+	tree := merkletree.HashNodes(sigs)
+	root := tree.GetRoot()
+	if root == nil {
+		return [32]byte{}
+	}
+	return *root
+}
+
+// NextHash returns the PoH hash of an entry following the given state. An
+// entry without transactions is a tick, which only hashes; an entry with
+// transactions mixes them in with its last hash.
+//
+// Port of solana_entry::entry::next_hash
+func NextHash(start State, numHashes uint64, txns []solana.Transaction) State {
+	if numHashes == 0 && len(txns) == 0 {
+		return start
+	}
+	s := start
+	if len(txns) == 0 {
+		s.Hash(uint(numHashes))
+		return s
+	}
+	if numHashes > 1 {
+		s.Hash(uint(numHashes - 1))
+	}
+	mixin := TransactionsMixin(txns)
+	s.Record(&mixin)
+	return s
+}
+
+// VerifyEntries checks that each entry's hash follows from the previous one,
+// starting with the given state, i.e. the hash of the last entry of the
+// parent slot. As every entry records its resulting hash, entries are
+// verified independently, by up to the given number of goroutines. The
+// error describes the first entry that does not verify.
+func VerifyEntries(start State, entries []shred.Entry, workers int) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	actual := make([]State, len(entries))
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				prev := start
+				if i > 0 {
+					prev = State(entries[i-1].Hash)
+				}
+				actual[i] = NextHash(prev, entries[i].NumHashes, entries[i].Txns)
+			}
+		}()
+	}
+	for i := range entries {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i := range entries {
+		if actual[i] != State(entries[i].Hash) {
+			return fmt.Errorf("PoH mismatch at entry %d: expected %s, actual %s",
+				i, entries[i].Hash, solana.Hash(actual[i]))
+		}
+	}
+	return nil
+}
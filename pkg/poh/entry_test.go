@@ -0,0 +1,43 @@
+package poh
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/merkletree"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+func TestVerifyEntries(t *testing.T) {
+	txns := []solana.Transaction{
+		{Signatures: []solana.Signature{{1}, {2}}},
+		{Signatures: []solana.Signature{{3}}},
+	}
+	tree := merkletree.HashNodes([][]byte{txns[0].Signatures[0][:], txns[0].Signatures[1][:], txns[1].Signatures[0][:]})
+	assert.Equal(t, *tree.GetRoot(), TransactionsMixin(txns))
+
+	start := State{0xab}
+	tick := start
+	tick.Hash(3)
+	record := tick
+	record.Hash(1)
+	mixin := TransactionsMixin(txns)
+	record.Record(&mixin)
+
+	entries := []shred.Entry{
+		{NumHashes: 3, Hash: solana.Hash(tick)},
+		{NumHashes: 2, Hash: solana.Hash(record), Txns: txns},
+		{NumHashes: 0, Hash: solana.Hash(record)},
+	}
+	assert.NoError(t, VerifyEntries(start, entries, 2))
+	assert.NoError(t, VerifyEntries(start, nil, 2))
+
+	// a wrong start state fails the first entry
+	assert.EqualError(t, VerifyEntries(State{}, entries, 2),
+		"PoH mismatch at entry 0: expected "+solana.Hash(tick).String()+", actual "+solana.Hash(NextHash(State{}, 3, nil)).String())
+
+	// so do transactions that were not mixed in
+	entries[1].Txns = txns[:1]
+	assert.Error(t, VerifyEntries(start, entries, 2))
+}
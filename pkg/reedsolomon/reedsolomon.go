@@ -0,0 +1,291 @@
+// Package reedsolomon implements the systematic Reed-Solomon erasure code
+// over GF(2^8) that shreds are coded with.
+//
+// Based on the reed-solomon-erasure crate, with the galois_8 field.
+package reedsolomon
+
+import (
+	"errors"
+	"fmt"
+)
+
+// generatorPolynomial is the irreducible polynomial of the field,
+// x^8 + x^4 + x^3 + x^2 + 1.
+const generatorPolynomial = 0x11d
+
+var (
+	expTable [510]byte
+	logTable [256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = byte(x)
+		expTable[i+255] = byte(x)
+		logTable[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= generatorPolynomial
+		}
+	}
+}
+
+func galMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+func galDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+255-int(logTable[b])]
+}
+
+// galExp returns a to the power of n.
+func galExp(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])*n%255]
+}
+
+// ErrTooFewShards is returned when fewer shards than data shards are
+// present, so that the missing ones cannot be reconstructed.
+var ErrTooFewShards = errors.New("too few shards")
+
+// Encoder codes data shards into parity shards, and reconstructs missing
+// shards.
+type Encoder struct {
+	dataShards   int
+	parityShards int
+	// matrix is the encoding matrix, of which the first dataShards rows
+	// are the identity.
+	matrix matrix
+}
+
+// New returns an encoder of the given numbers of data and parity shards.
+func New(dataShards, parityShards int) (*Encoder, error) {
+	if dataShards <= 0 || parityShards <= 0 {
+		return nil, fmt.Errorf("invalid shard counts %d/%d", dataShards, parityShards)
+	}
+	if dataShards+parityShards > 256 {
+		return nil, fmt.Errorf("too many shards: %d", dataShards+parityShards)
+	}
+	vandermonde := newVandermonde(dataShards+parityShards, dataShards)
+	top, err := vandermonde.subMatrix(0, dataShards).invert()
+	if err != nil {
+		return nil, err
+	}
+	return &Encoder{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		matrix:       vandermonde.multiply(top),
+	}, nil
+}
+
+func (e *Encoder) checkShards(shards [][]byte, allowMissing bool) (size int, err error) {
+	if len(shards) != e.dataShards+e.parityShards {
+		return 0, fmt.Errorf("expected %d shards, got %d", e.dataShards+e.parityShards, len(shards))
+	}
+	for _, shard := range shards {
+		if shard == nil {
+			if !allowMissing {
+				return 0, ErrTooFewShards
+			}
+			continue
+		}
+		if size != 0 && len(shard) != size {
+			return 0, errors.New("shards differ in size")
+		}
+		size = len(shard)
+	}
+	if size == 0 {
+		return 0, errors.New("empty shards")
+	}
+	return size, nil
+}
+
+// Encode computes the parity shards from the data shards. All shards must
+// be allocated, and of the same size.
+func (e *Encoder) Encode(shards [][]byte) error {
+	if _, err := e.checkShards(shards, false); err != nil {
+		return err
+	}
+	e.codeShards(e.matrix[e.dataShards:], shards[:e.dataShards], shards[e.dataShards:])
+	return nil
+}
+
+// codeShards sets each output to the product of the corresponding row of
+// rows with the inputs.
+func (e *Encoder) codeShards(rows matrix, inputs, outputs [][]byte) {
+	for i, out := range outputs {
+		for j := range out {
+			out[j] = 0
+		}
+		for k, in := range inputs {
+			coef := rows[i][k]
+			if coef == 0 {
+				continue
+			}
+			for j, b := range in {
+				out[j] ^= galMul(coef, b)
+			}
+		}
+	}
+}
+
+// Reconstruct reconstructs the missing shards, which are nil, in place.
+// At least as many shards as data shards must be present.
+//
+// Based on reed_solomon_erasure::ReedSolomon::reconstruct.
+func (e *Encoder) Reconstruct(shards [][]byte) error {
+	size, err := e.checkShards(shards, true)
+	if err != nil {
+		return err
+	}
+
+	// the rows of the encoding matrix of the first data-shards many
+	// shards present form an invertible matrix, which recovers the data
+	// shards from these shards
+	var present [][]byte
+	var subMatrix matrix
+	var missingData, missingParity []int
+	for i, shard := range shards {
+		if shard == nil {
+			if i < e.dataShards {
+				missingData = append(missingData, i)
+			} else {
+				missingParity = append(missingParity, i)
+			}
+			continue
+		}
+		if len(present) < e.dataShards {
+			present = append(present, shard)
+			subMatrix = append(subMatrix, e.matrix[i])
+		}
+	}
+	if len(present) < e.dataShards {
+		return ErrTooFewShards
+	}
+
+	if len(missingData) != 0 {
+		decode, err := subMatrix.invert()
+		if err != nil {
+			return err
+		}
+		rows := make(matrix, len(missingData))
+		outputs := make([][]byte, len(missingData))
+		for i, idx := range missingData {
+			rows[i] = decode[idx]
+			outputs[i] = make([]byte, size)
+			shards[idx] = outputs[i]
+		}
+		e.codeShards(rows, present, outputs)
+	}
+
+	if len(missingParity) != 0 {
+		rows := make(matrix, len(missingParity))
+		outputs := make([][]byte, len(missingParity))
+		for i, idx := range missingParity {
+			rows[i] = e.matrix[idx]
+			outputs[i] = make([]byte, size)
+			shards[idx] = outputs[i]
+		}
+		e.codeShards(rows, shards[:e.dataShards], outputs)
+	}
+	return nil
+}
+
+// matrix is a matrix over GF(2^8), by rows.
+type matrix [][]byte
+
+func newMatrix(rows, cols int) matrix {
+	m := make(matrix, rows)
+	for i := range m {
+		m[i] = make([]byte, cols)
+	}
+	return m
+}
+
+// newVandermonde returns the Vandermonde matrix of which the element (r, c)
+// is r to the power of c.
+func newVandermonde(rows, cols int) matrix {
+	m := newMatrix(rows, cols)
+	for r := range m {
+		for c := range m[r] {
+			m[r][c] = galExp(byte(r), c)
+		}
+	}
+	return m
+}
+
+// subMatrix returns the rows [start, end) of the matrix.
+func (m matrix) subMatrix(start, end int) matrix {
+	sub := newMatrix(end-start, len(m[0]))
+	for i := range sub {
+		copy(sub[i], m[start+i])
+	}
+	return sub
+}
+
+func (m matrix) multiply(other matrix) matrix {
+	out := newMatrix(len(m), len(other[0]))
+	for r := range out {
+		for c := range out[r] {
+			var v byte
+			for k := range m[r] {
+				v ^= galMul(m[r][k], other[k][c])
+			}
+			out[r][c] = v
+		}
+	}
+	return out
+}
+
+// invert returns the inverse of a square matrix, by Gauss-Jordan
+// elimination.
+func (m matrix) invert() (matrix, error) {
+	n := len(m)
+	// work is m augmented with the identity
+	work := newMatrix(n, 2*n)
+	for i := range m {
+		copy(work[i], m[i])
+		work[i][n+i] = 1
+	}
+	for c := 0; c < n; c++ {
+		pivot := c
+		for pivot < n && work[pivot][c] == 0 {
+			pivot++
+		}
+		if pivot == n {
+			return nil, errors.New("singular matrix")
+		}
+		work[c], work[pivot] = work[pivot], work[c]
+		if scale := work[c][c]; scale != 1 {
+			for k := range work[c] {
+				work[c][k] = galDiv(work[c][k], scale)
+			}
+		}
+		for r := 0; r < n; r++ {
+			if r == c || work[r][c] == 0 {
+				continue
+			}
+			factor := work[r][c]
+			for k := range work[r] {
+				work[r][k] ^= galMul(factor, work[c][k])
+			}
+		}
+	}
+	inverse := newMatrix(n, n)
+	for i := range inverse {
+		copy(inverse[i], work[i][n:])
+	}
+	return inverse, nil
+}
@@ -0,0 +1,47 @@
+package reedsolomon
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGalois(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			assert.Equal(t, byte(a), galDiv(galMul(byte(a), byte(b)), byte(b)))
+		}
+	}
+	assert.Equal(t, byte(0), galMul(0, 3))
+	assert.Equal(t, galMul(galMul(3, 3), 3), galExp(3, 3))
+}
+
+func TestReconstruct(t *testing.T) {
+	enc, err := New(32, 32)
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(1))
+	shards := make([][]byte, 64)
+	for i := range shards {
+		shards[i] = make([]byte, 100)
+		if i < 32 {
+			rng.Read(shards[i])
+		}
+	}
+	require.NoError(t, enc.Encode(shards))
+
+	// drop any 32 shards
+	damaged := append([][]byte(nil), shards...)
+	for _, i := range rng.Perm(64)[:32] {
+		damaged[i] = nil
+	}
+	require.NoError(t, enc.Reconstruct(damaged))
+	assert.Equal(t, shards, damaged)
+
+	for i := range damaged[:33] {
+		damaged[i] = nil
+	}
+	assert.ErrorIs(t, enc.Reconstruct(damaged), ErrTooFewShards)
+}
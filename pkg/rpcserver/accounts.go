@@ -0,0 +1,401 @@
+package rpcserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/klauspost/compress/zstd"
+	"github.com/mr-tron/base58"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/geyser"
+)
+
+// Encodings of account data.
+//
+// Based on solana_account_decoder::UiAccountEncoding.
+const (
+	// EncodingBinary is the deprecated encoding of account data as a bare
+	// base58 string.
+	EncodingBinary     = "binary"
+	EncodingBase64Zstd = "base64+zstd"
+)
+
+// maxBase58Bytes bounds the size of account data encoded in base58.
+//
+// Based on solana_rpc::rpc::MAX_BASE58_BYTES.
+const maxBase58Bytes = 128
+
+// maxMultipleAccounts bounds the number of accounts of
+// getMultipleAccounts.
+//
+// Based on solana_rpc_client_api::request::MAX_MULTIPLE_ACCOUNTS.
+const maxMultipleAccounts = 100
+
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+// AccountsDB holds the accounts served by the server as of a slot, such as
+// those of a snapshot. As a geyser plugin, it follows the account writes
+// of replay, which are applied once their slot is processed, so that the
+// accounts of a slot are served all at once. Transactions are simulated on
+// the bank of the slot, if it was given one.
+type AccountsDB struct {
+	mu      sync.RWMutex
+	slot    uint64
+	accts   map[[32]byte]*accounts.Account
+	pending map[uint64]map[[32]byte]*accounts.Account
+	bank    *bank.Bank
+	// pendingBanks are the banks of slots not yet processed.
+	pendingBanks map[uint64]*bank.Bank
+}
+
+// NewAccountsDB returns an AccountsDB of the accounts of a slot. Accounts
+// without lamports do not exist, and are dropped.
+func NewAccountsDB(slot uint64, accts map[[32]byte]*accounts.Account) *AccountsDB {
+	db := &AccountsDB{
+		slot:    slot,
+		accts:   make(map[[32]byte]*accounts.Account, len(accts)),
+		pending: make(map[uint64]map[[32]byte]*accounts.Account),
+
+		pendingBanks: make(map[uint64]*bank.Bank),
+	}
+	for pubkey, acct := range accts {
+		if acct.Lamports != 0 {
+			db.accts[pubkey] = acct
+		}
+	}
+	return db
+}
+
+// Slot returns the slot the accounts are as of.
+func (db *AccountsDB) Slot() uint64 {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.slot
+}
+
+// GetAccounts returns the accounts of the given pubkeys, nil for those
+// that do not exist, and the slot they are as of.
+func (db *AccountsDB) GetAccounts(pubkeys [][32]byte) ([]*accounts.Account, uint64) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	accts := make([]*accounts.Account, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		accts[i] = db.accts[pubkey]
+	}
+	return accts, db.slot
+}
+
+// SetBank sets the frozen bank that transactions are simulated on, which
+// is used once the accounts of its slot are. The accounts of the bank are
+// not read, as those of the AccountsDB are read instead.
+func (db *AccountsDB) SetBank(b *bank.Bank) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if b.Slot == db.slot {
+		db.bank = b
+	} else {
+		db.pendingBanks[b.Slot] = b
+	}
+}
+
+// errReadOnly is returned on writes to the accounts of an AccountsDB.
+var errReadOnly = errors.New("accounts are read-only")
+
+// lockedAccounts is the view of the accounts of an AccountsDB of which the
+// lock is held. Accounts are never modified once stored, so they may be
+// read after the lock is released.
+type lockedAccounts struct {
+	db *AccountsDB
+}
+
+func (a lockedAccounts) GetAccount(pubkey *[32]byte) (*accounts.Account, error) {
+	acct, ok := a.db.accts[*pubkey]
+	if !ok {
+		return nil, fmt.Errorf("no such account %s found", base58.Encode(pubkey[:]))
+	}
+	return acct, nil
+}
+
+func (a lockedAccounts) SetAccount(*[32]byte, *accounts.Account) error {
+	return errReadOnly
+}
+
+// withBank calls f with the bank of the accounts, nil if there is none,
+// and a view of the accounts, as of the same slot, which are not updated
+// until f returns.
+func (db *AccountsDB) withBank(f func(b *bank.Bank, accts accounts.Accounts, slot uint64)) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	f(db.bank, lockedAccounts{db}, db.slot)
+}
+
+func (db *AccountsDB) UpdateAccount(update *geyser.AccountUpdate) error {
+	// the account may be modified by later transactions
+	acct := *update.Account
+	acct.Data = append([]byte(nil), acct.Data...)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if update.IsStartup {
+		db.slot = update.Slot
+		storeAccount(db.accts, update.Pubkey, &acct)
+		return nil
+	}
+	writes, ok := db.pending[update.Slot]
+	if !ok {
+		writes = make(map[[32]byte]*accounts.Account)
+		db.pending[update.Slot] = writes
+	}
+	writes[update.Pubkey] = &acct
+	return nil
+}
+
+// storeAccount writes an account, which is deleted if it has no lamports.
+func storeAccount(accts map[[32]byte]*accounts.Account, pubkey [32]byte, acct *accounts.Account) {
+	if acct.Lamports == 0 {
+		delete(accts, pubkey)
+	} else {
+		accts[pubkey] = acct
+	}
+}
+
+func (db *AccountsDB) NotifyEndOfStartup() error {
+	return nil
+}
+
+func (db *AccountsDB) UpdateSlotStatus(update *geyser.SlotUpdate) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	switch update.Status {
+	case geyser.SlotStatusProcessed:
+		for pubkey, acct := range db.pending[update.Slot] {
+			storeAccount(db.accts, pubkey, acct)
+		}
+		delete(db.pending, update.Slot)
+		db.slot = update.Slot
+		if b, ok := db.pendingBanks[update.Slot]; ok {
+			db.bank = b
+			delete(db.pendingBanks, update.Slot)
+		}
+	case geyser.SlotStatusDead:
+		delete(db.pending, update.Slot)
+		delete(db.pendingBanks, update.Slot)
+	}
+	return nil
+}
+
+func (db *AccountsDB) NotifyTransaction(*geyser.TransactionUpdate) error {
+	return nil
+}
+
+func (db *AccountsDB) NotifyBlockMetadata(*geyser.BlockMetadata) error {
+	return nil
+}
+
+// accountConfig is the config of getAccountInfo and getMultipleAccounts.
+//
+// Based on solana_rpc_client_api::config::RpcAccountInfoConfig.
+type accountConfig struct {
+	Encoding       string     `json:"encoding"`
+	DataSlice      *dataSlice `json:"dataSlice"`
+	Commitment     string     `json:"commitment"`
+	MinContextSlot *uint64    `json:"minContextSlot"`
+}
+
+// dataSlice selects a range of account data.
+//
+// Based on solana_account_decoder::UiDataSliceConfig.
+type dataSlice struct {
+	Offset uint64 `json:"offset"`
+	Length uint64 `json:"length"`
+}
+
+// slice returns the selected range of data, truncated to the data.
+func (s *dataSlice) slice(data []byte) []byte {
+	if s == nil {
+		return data
+	}
+	if s.Offset >= uint64(len(data)) {
+		return []byte{}
+	}
+	data = data[s.Offset:]
+	if s.Length < uint64(len(data)) {
+		data = data[:s.Length]
+	}
+	return data
+}
+
+type uiContext struct {
+	Slot uint64 `json:"slot"`
+}
+
+type uiResponse struct {
+	Context uiContext   `json:"context"`
+	Value   interface{} `json:"value"`
+}
+
+// uiAccount is an account, with its data in the requested encoding.
+//
+// Based on solana_account_decoder::UiAccount.
+type uiAccount struct {
+	Lamports   uint64           `json:"lamports"`
+	Data       interface{}      `json:"data"`
+	Owner      solana.PublicKey `json:"owner"`
+	Executable bool             `json:"executable"`
+	RentEpoch  uint64           `json:"rentEpoch"`
+	Space      uint64           `json:"space"`
+}
+
+// checkAccountConfig validates the config of an account query, defaulting
+// to the binary encoding. Accounts are served as of the last slot of the
+// AccountsDB, whatever the commitment.
+func checkAccountConfig(config *accountConfig) error {
+	if err := checkAccountsCommitment(config.Commitment); err != nil {
+		return err
+	}
+	switch config.Encoding {
+	case "":
+		config.Encoding = EncodingBinary
+	case EncodingBinary, EncodingBase58, EncodingBase64, EncodingBase64Zstd:
+	case EncodingJSONParsed:
+		return invalidParams("Invalid params: encoding %s is not supported", EncodingJSONParsed)
+	default:
+		return invalidParams("Invalid params: unknown variant `%s`, expected one of `binary`, `base58`, `base64`, `jsonParsed`, `base64+zstd`", config.Encoding)
+	}
+	return nil
+}
+
+// checkAccountsCommitment validates a commitment, any of which is served
+// by the last slot of the AccountsDB.
+func checkAccountsCommitment(commitment string) error {
+	switch commitment {
+	case "", "processed", "confirmed", "finalized":
+		return nil
+	default:
+		return invalidParams("Invalid params: unknown commitment %q", commitment)
+	}
+}
+
+// checkMinContextSlot fails if the accounts are older than the minimum
+// context slot.
+func checkMinContextSlot(minContextSlot *uint64, slot uint64) error {
+	if minContextSlot != nil && slot < *minContextSlot {
+		return &Error{
+			Code:    ErrCodeMinContextSlotNotReached,
+			Message: "Minimum context slot has not been reached",
+			Data:    map[string]uint64{"contextSlot": slot},
+		}
+	}
+	return nil
+}
+
+// encodeAccount returns an account with its data in the given encoding, or
+// nil if it does not exist.
+//
+// Based on solana_rpc::rpc::encode_account.
+func encodeAccount(acct *accounts.Account, encoding string, slice *dataSlice) (*uiAccount, error) {
+	if acct == nil {
+		return nil, nil
+	}
+	data := slice.slice(acct.Data)
+	ui := &uiAccount{
+		Lamports:   acct.Lamports,
+		Owner:      acct.Owner,
+		Executable: acct.Executable,
+		RentEpoch:  acct.RentEpoch,
+		Space:      uint64(len(acct.Data)),
+	}
+	switch encoding {
+	case EncodingBinary, EncodingBase58:
+		if len(data) > maxBase58Bytes {
+			return nil, invalidParams("Encoded binary (base 58) data should be less than %d bytes, please use Base64 encoding.", maxBase58Bytes)
+		}
+		if encoding == EncodingBinary {
+			ui.Data = base58.Encode(data)
+		} else {
+			ui.Data = []string{base58.Encode(data), encoding}
+		}
+	case EncodingBase64:
+		ui.Data = []string{base64.StdEncoding.EncodeToString(data), encoding}
+	case EncodingBase64Zstd:
+		ui.Data = []string{base64.StdEncoding.EncodeToString(zstdEncoder.EncodeAll(data, nil)), encoding}
+	}
+	return ui, nil
+}
+
+// parsePubkey decodes a pubkey param.
+func parsePubkey(s string) ([32]byte, error) {
+	pubkey, err := solana.PublicKeyFromBase58(s)
+	if err != nil {
+		return pubkey, invalidParams("Invalid param: %s", err)
+	}
+	return pubkey, nil
+}
+
+// getAccountInfo returns an account, or a null value if it does not exist.
+//
+// Based on solana_rpc::rpc::JsonRpcRequestProcessor::get_account_info.
+func (s *Server) getAccountInfo(params json.RawMessage) (interface{}, error) {
+	var pubkeyStr string
+	var config accountConfig
+	if err := parseParams(params, 1, &pubkeyStr, &config); err != nil {
+		return nil, err
+	}
+	pubkey, err := parsePubkey(pubkeyStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountConfig(&config); err != nil {
+		return nil, err
+	}
+	accts, slot := s.accountsDB.GetAccounts([][32]byte{pubkey})
+	if err := checkMinContextSlot(config.MinContextSlot, slot); err != nil {
+		return nil, err
+	}
+	ui, err := encodeAccount(accts[0], config.Encoding, config.DataSlice)
+	if err != nil {
+		return nil, err
+	}
+	return &uiResponse{Context: uiContext{Slot: slot}, Value: ui}, nil
+}
+
+// getMultipleAccounts returns accounts, null for those that do not exist,
+// all as of the same slot.
+//
+// Based on solana_rpc::rpc::JsonRpcRequestProcessor::get_multiple_accounts.
+func (s *Server) getMultipleAccounts(params json.RawMessage) (interface{}, error) {
+	var pubkeyStrs []string
+	var config accountConfig
+	if err := parseParams(params, 1, &pubkeyStrs, &config); err != nil {
+		return nil, err
+	}
+	if len(pubkeyStrs) > maxMultipleAccounts {
+		return nil, invalidParams("Too many inputs provided; max %d", maxMultipleAccounts)
+	}
+	pubkeys := make([][32]byte, len(pubkeyStrs))
+	for i, pubkeyStr := range pubkeyStrs {
+		var err error
+		if pubkeys[i], err = parsePubkey(pubkeyStr); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkAccountConfig(&config); err != nil {
+		return nil, err
+	}
+	accts, slot := s.accountsDB.GetAccounts(pubkeys)
+	if err := checkMinContextSlot(config.MinContextSlot, slot); err != nil {
+		return nil, err
+	}
+	uis := make([]*uiAccount, len(accts))
+	for i, acct := range accts {
+		var err error
+		if uis[i], err = encodeAccount(acct, config.Encoding, config.DataSlice); err != nil {
+			return nil, err
+		}
+	}
+	return &uiResponse{Context: uiContext{Slot: slot}, Value: uis}, nil
+}
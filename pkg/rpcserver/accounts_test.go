@@ -0,0 +1,140 @@
+package rpcserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/geyser"
+)
+
+func TestGetAccountInfo(t *testing.T) {
+	db := NewAccountsDB(10, map[[32]byte]*accounts.Account{
+		{1}: {Lamports: 5, Data: []byte{1, 2, 3, 4}, Owner: [32]byte{9}, RentEpoch: 7},
+		{2}: {Lamports: 1, Data: bytes.Repeat([]byte{1}, 200)},
+		{3}: {Lamports: 0, Data: []byte{1}},
+	})
+	s := NewServer(nil, db)
+	pubkey := solana.PublicKey{1}.String()
+
+	result, rpcErr := call(t, s, "getAccountInfo", `["`+pubkey+`"]`)
+	require.Nil(t, rpcErr)
+	assert.JSONEq(t, `{
+		"context":{"slot":10},
+		"value":{
+			"lamports":5,
+			"data":"2VfUX",
+			"owner":"`+solana.PublicKey{9}.String()+`",
+			"executable":false,
+			"rentEpoch":7,
+			"space":4
+		}
+	}`, string(result))
+
+	for _, tc := range []struct {
+		config   string
+		expected string
+	}{
+		{`{"encoding":"base58"}`, `["2VfUX","base58"]`},
+		{`{"encoding":"base64"}`, `["AQIDBA==","base64"]`},
+		{`{"encoding":"base64","dataSlice":{"offset":1,"length":2}}`, `["AgM=","base64"]`},
+		{`{"encoding":"base64","dataSlice":{"offset":3,"length":9}}`, `["BA==","base64"]`},
+		{`{"encoding":"base64","dataSlice":{"offset":9,"length":1}}`, `["","base64"]`},
+	} {
+		result, rpcErr = call(t, s, "getAccountInfo", `["`+pubkey+`", `+tc.config+`]`)
+		require.Nil(t, rpcErr, tc.config)
+		var res struct {
+			Value struct {
+				Data json.RawMessage `json:"data"`
+			} `json:"value"`
+		}
+		require.NoError(t, json.Unmarshal(result, &res))
+		assert.JSONEq(t, tc.expected, string(res.Value.Data), tc.config)
+	}
+
+	result, rpcErr = call(t, s, "getAccountInfo", `["`+pubkey+`", {"encoding":"base64+zstd"}]`)
+	require.Nil(t, rpcErr)
+	var res struct {
+		Value struct {
+			Data []string `json:"data"`
+		} `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal(result, &res))
+	assert.Equal(t, "base64+zstd", res.Value.Data[1])
+	compressed, err := base64.StdEncoding.DecodeString(res.Value.Data[0])
+	require.NoError(t, err)
+	decoder, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	data, err := decoder.DecodeAll(compressed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4}, data)
+
+	for _, pubkey := range []solana.PublicKey{{3}, {4}} {
+		result, rpcErr = call(t, s, "getAccountInfo", `["`+pubkey.String()+`"]`)
+		require.Nil(t, rpcErr)
+		assert.JSONEq(t, `{"context":{"slot":10},"value":null}`, string(result))
+	}
+
+	_, rpcErr = call(t, s, "getAccountInfo", `["`+solana.PublicKey{2}.String()+`"]`)
+	assert.Equal(t, ErrCodeInvalidParams, rpcErr.Code)
+	_, rpcErr = call(t, s, "getAccountInfo", `["`+solana.PublicKey{2}.String()+`", {"dataSlice":{"offset":0,"length":128}}]`)
+	assert.Nil(t, rpcErr)
+	_, rpcErr = call(t, s, "getAccountInfo", `["`+pubkey+`", {"minContextSlot":11}]`)
+	assert.Equal(t, &Error{
+		Code:    ErrCodeMinContextSlotNotReached,
+		Message: "Minimum context slot has not been reached",
+		Data:    map[string]interface{}{"contextSlot": float64(10)},
+	}, rpcErr)
+	_, rpcErr = call(t, s, "getAccountInfo", `["`+pubkey+`", {"encoding":"jsonParsed"}]`)
+	assert.Equal(t, ErrCodeInvalidParams, rpcErr.Code)
+}
+
+func TestGetMultipleAccounts(t *testing.T) {
+	db := NewAccountsDB(10, map[[32]byte]*accounts.Account{
+		{1}: {Lamports: 5, Data: []byte{1}},
+		{2}: {Lamports: 6},
+	})
+	s := NewServer(nil, db)
+
+	// writes of replay are applied once their slot is processed
+	require.NoError(t, db.UpdateAccount(&geyser.AccountUpdate{Slot: 11, Pubkey: [32]byte{1}, Account: &accounts.Account{}}))
+	require.NoError(t, db.UpdateAccount(&geyser.AccountUpdate{Slot: 11, Pubkey: [32]byte{3}, Account: &accounts.Account{Lamports: 7}}))
+	require.NoError(t, db.UpdateAccount(&geyser.AccountUpdate{Slot: 12, Pubkey: [32]byte{2}, Account: &accounts.Account{Lamports: 8}}))
+	params := `[["` + solana.PublicKey{1}.String() + `","` + solana.PublicKey{2}.String() + `","` + solana.PublicKey{3}.String() + `"], {"encoding":"base64"}]`
+	result, rpcErr := call(t, s, "getMultipleAccounts", params)
+	require.Nil(t, rpcErr)
+	owner := solana.PublicKey{}.String()
+	assert.JSONEq(t, `{"context":{"slot":10},"value":[
+		{"lamports":5,"data":["AQ==","base64"],"owner":"`+owner+`","executable":false,"rentEpoch":0,"space":1},
+		{"lamports":6,"data":["","base64"],"owner":"`+owner+`","executable":false,"rentEpoch":0,"space":0},
+		null
+	]}`, string(result))
+
+	require.NoError(t, db.UpdateSlotStatus(&geyser.SlotUpdate{Slot: 11, Parent: 10, Status: geyser.SlotStatusProcessed}))
+	require.NoError(t, db.UpdateSlotStatus(&geyser.SlotUpdate{Slot: 12, Parent: 11, Status: geyser.SlotStatusDead}))
+	result, rpcErr = call(t, s, "getMultipleAccounts", params)
+	require.Nil(t, rpcErr)
+	assert.JSONEq(t, `{"context":{"slot":11},"value":[
+		null,
+		{"lamports":6,"data":["","base64"],"owner":"`+owner+`","executable":false,"rentEpoch":0,"space":0},
+		{"lamports":7,"data":["","base64"],"owner":"`+owner+`","executable":false,"rentEpoch":0,"space":0}
+	]}`, string(result))
+	assert.Equal(t, uint64(11), db.Slot())
+
+	many := make([]string, maxMultipleAccounts+1)
+	for i := range many {
+		many[i] = solana.PublicKey{1}.String()
+	}
+	manyJSON, err := json.Marshal(many)
+	require.NoError(t, err)
+	_, rpcErr = call(t, s, "getMultipleAccounts", `[`+string(manyJSON)+`]`)
+	assert.Equal(t, ErrCodeInvalidParams, rpcErr.Code)
+	_, rpcErr = call(t, s, "getMultipleAccounts", `[["abc"]]`)
+	assert.Equal(t, ErrCodeInvalidParams, rpcErr.Code)
+}
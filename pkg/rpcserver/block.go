@@ -0,0 +1,180 @@
+package rpcserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/storageproto"
+)
+
+// Blockstore is the ledger blocks are served from, such as a
+// *blockstore.DB. Missing values are reported as blockstore.ErrNotFound.
+type Blockstore interface {
+	GetSlotMeta(slot uint64) (*blockstore.SlotMeta, error)
+	GetBlock(slot uint64) (*blockstore.Block, error)
+	GetTransactionStatusInSlot(sig solana.Signature, slot uint64) (*sealevel.TransactionStatusMeta, error)
+	GetRewards(slot uint64) (*storageproto.Rewards, error)
+	GetBlockTime(slot uint64) (int64, error)
+	GetBlockHeight(slot uint64) (uint64, error)
+	GetTransactionStatuses(sig solana.Signature) ([]blockstore.TransactionStatus, error)
+	GetAddressSignaturesRev(addr solana.PublicKey, before, until *blockstore.TxPosition, limit int) ([]blockstore.AddressSignature, error)
+	IsRoot(slot uint64) (bool, error)
+}
+
+// blockConfig is the config of getBlock.
+//
+// Based on solana_rpc_client_api::config::RpcBlockConfig.
+type blockConfig struct {
+	encodingConfig
+	TransactionDetails string `json:"transactionDetails"`
+	Rewards            *bool  `json:"rewards"`
+}
+
+// UnmarshalJSON accepts the config, or the encoding alone, as deprecated.
+func (c *blockConfig) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		return json.Unmarshal(data, &c.Encoding)
+	}
+	type config blockConfig
+	return json.Unmarshal(data, (*config)(c))
+}
+
+type uiBlock struct {
+	PreviousBlockhash   solana.Hash         `json:"previousBlockhash"`
+	Blockhash           solana.Hash         `json:"blockhash"`
+	ParentSlot          uint64              `json:"parentSlot"`
+	Transactions        *[]interface{}      `json:"transactions,omitempty"`
+	Signatures          *[]solana.Signature `json:"signatures,omitempty"`
+	Rewards             *[]uiReward         `json:"rewards,omitempty"`
+	NumRewardPartitions *uint64             `json:"numRewardPartitions,omitempty"`
+	BlockTime           *int64              `json:"blockTime"`
+	BlockHeight         *uint64             `json:"blockHeight"`
+}
+
+// getBlock returns a full slot of the blockstore, whether or not it was
+// rooted, with its transactions in the requested encoding and details.
+// Transactions without a status in the blockstore have a null meta.
+//
+// Based on solana_rpc::rpc::JsonRpcRequestProcessor::get_block.
+func (s *Server) getBlock(params json.RawMessage) (interface{}, error) {
+	var slot uint64
+	var config blockConfig
+	if err := parseParams(params, 1, &slot, &config); err != nil {
+		return nil, err
+	}
+	if err := checkCommitment(config.Commitment); err != nil {
+		return nil, err
+	}
+	if err := checkEncoding(&config.Encoding); err != nil {
+		return nil, err
+	}
+	switch config.TransactionDetails {
+	case "":
+		config.TransactionDetails = TransactionDetailsFull
+	case TransactionDetailsFull, TransactionDetailsSignatures, TransactionDetailsNone, TransactionDetailsAccounts:
+	default:
+		return nil, invalidParams("Invalid params: unknown variant `%s`, expected one of `full`, `signatures`, `none`, `accounts`", config.TransactionDetails)
+	}
+	showRewards := config.Rewards == nil || *config.Rewards
+
+	block, ok, err := s.fullBlock(slot)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, &Error{Code: ErrCodeBlockNotAvailable, Message: fmt.Sprintf("Block not available for slot %d", slot)}
+	}
+	ui := &uiBlock{ParentSlot: block.ParentSlot}
+	ui.Blockhash, _ = block.Blockhash()
+	// the previous blockhash of blocks of which the parent is gone is zero
+	if parent, err := s.blockstore.GetBlock(block.ParentSlot); err == nil && block.ParentSlot != block.Slot {
+		ui.PreviousBlockhash, _ = parent.Blockhash()
+	}
+
+	txs := block.Transactions()
+	switch config.TransactionDetails {
+	case TransactionDetailsSignatures:
+		sigs := make([]solana.Signature, len(txs))
+		for i := range txs {
+			if len(txs[i].Signatures) != 0 {
+				sigs[i] = txs[i].Signatures[0]
+			}
+		}
+		ui.Signatures = &sigs
+	case TransactionDetailsFull, TransactionDetailsAccounts:
+		uiTxs := make([]interface{}, len(txs))
+		for i := range txs {
+			tx := &txs[i]
+			version, err := transactionVersion(tx, config.MaxSupportedTransactionVersion)
+			if err != nil {
+				return nil, err
+			}
+			var meta *sealevel.TransactionStatusMeta
+			if len(tx.Signatures) != 0 {
+				meta, err = s.blockstore.GetTransactionStatusInSlot(tx.Signatures[0], slot)
+				if err != nil && !errors.Is(err, blockstore.ErrNotFound) {
+					return nil, err
+				}
+			}
+			uiTx := &uiTransactionWithMeta{Version: version}
+			if config.TransactionDetails == TransactionDetailsAccounts {
+				uiTx.Transaction = encodeAccounts(tx, meta)
+				if meta != nil {
+					uiTx.Meta = encodeSimpleMeta(meta)
+					if showRewards {
+						uiTx.Meta.Rewards = &[]uiReward{}
+					}
+				}
+			} else {
+				uiTx.Transaction, err = encodeTransaction(tx, config.Encoding)
+				if err != nil {
+					return nil, err
+				}
+				uiTx.Meta = encodeMeta(meta)
+			}
+			uiTxs[i] = uiTx
+		}
+		ui.Transactions = &uiTxs
+	}
+
+	if showRewards {
+		rewards, err := s.blockstore.GetRewards(slot)
+		if err != nil && !errors.Is(err, blockstore.ErrNotFound) {
+			return nil, err
+		}
+		uiRewards := []uiReward{}
+		if rewards != nil {
+			uiRewards = encodeRewards(rewards.Rewards)
+			ui.NumRewardPartitions = rewards.NumPartitions
+		}
+		ui.Rewards = &uiRewards
+	}
+	if blockTime, err := s.blockstore.GetBlockTime(slot); err == nil {
+		ui.BlockTime = &blockTime
+	} else if !errors.Is(err, blockstore.ErrNotFound) {
+		return nil, err
+	}
+	if blockHeight, err := s.blockstore.GetBlockHeight(slot); err == nil {
+		ui.BlockHeight = &blockHeight
+	} else if !errors.Is(err, blockstore.ErrNotFound) {
+		return nil, err
+	}
+	return ui, nil
+}
+
+// fullBlock returns the block of a slot, or false if the slot is missing or
+// not full.
+func (s *Server) fullBlock(slot uint64) (*blockstore.Block, bool, error) {
+	block, err := s.blockstore.GetBlock(slot)
+	if err != nil {
+		meta, metaErr := s.blockstore.GetSlotMeta(slot)
+		if errors.Is(metaErr, blockstore.ErrNotFound) || (metaErr == nil && !meta.IsFull()) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return block, true, nil
+}
@@ -0,0 +1,416 @@
+package rpcserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/mr-tron/base58"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/storageproto"
+)
+
+// Encodings of transactions.
+//
+// Based on solana_transaction_status::UiTransactionEncoding.
+const (
+	EncodingJSON   = "json"
+	EncodingBase58 = "base58"
+	EncodingBase64 = "base64"
+	// EncodingJSONParsed, of which the instructions of known programs are
+	// parsed, is not supported.
+	EncodingJSONParsed = "jsonParsed"
+)
+
+// Levels of transaction details of blocks.
+//
+// Based on solana_transaction_status::TransactionDetails.
+const (
+	TransactionDetailsFull       = "full"
+	TransactionDetailsSignatures = "signatures"
+	TransactionDetailsNone       = "none"
+	TransactionDetailsAccounts   = "accounts"
+)
+
+// encodingConfig is the config of the encoding of transactions.
+type encodingConfig struct {
+	Encoding                       string  `json:"encoding"`
+	MaxSupportedTransactionVersion *uint8  `json:"maxSupportedTransactionVersion"`
+	Commitment                     string  `json:"commitment"`
+	MinContextSlot                 *uint64 `json:"minContextSlot"`
+}
+
+// checkEncoding validates the encoding of transactions, defaulting to
+// json.
+func checkEncoding(encoding *string) error {
+	switch *encoding {
+	case "":
+		*encoding = EncodingJSON
+	case EncodingJSON, EncodingBase58, EncodingBase64:
+	case EncodingJSONParsed:
+		return invalidParams("Invalid params: encoding %s is not supported", EncodingJSONParsed)
+	default:
+		return invalidParams("Invalid params: unknown variant `%s`, expected one of `binary`, `base64`, `base58`, `json`, `jsonParsed`", *encoding)
+	}
+	return nil
+}
+
+// transactionVersion returns the version of a transaction to report, nil
+// if the client supports no versions, or an error if it does not support
+// the version of the transaction.
+//
+// Based on solana_transaction_status::VersionedTransactionWithStatusMeta::validate_version.
+func transactionVersion(tx *solana.Transaction, maxSupportedVersion *uint8) (interface{}, error) {
+	if tx.Message.GetVersion() != solana.MessageVersionV0 {
+		if maxSupportedVersion == nil {
+			return nil, nil
+		}
+		return "legacy", nil
+	}
+	if maxSupportedVersion == nil {
+		return nil, &Error{
+			Code: ErrCodeUnsupportedTransactionVersion,
+			Message: "Transaction version (0) is not supported by the requesting client. " +
+				"Please try the request again with the following configuration parameter: " +
+				"\"maxSupportedTransactionVersion\": 0",
+		}
+	}
+	return 0, nil
+}
+
+type uiTransactionWithMeta struct {
+	Transaction interface{}              `json:"transaction"`
+	Meta        *uiTransactionStatusMeta `json:"meta"`
+	Version     interface{}              `json:"version,omitempty"`
+}
+
+type uiTransaction struct {
+	Signatures []solana.Signature `json:"signatures"`
+	Message    uiMessage          `json:"message"`
+}
+
+type uiMessage struct {
+	Header              uiMessageHeader         `json:"header"`
+	AccountKeys         []solana.PublicKey      `json:"accountKeys"`
+	RecentBlockhash     solana.Hash             `json:"recentBlockhash"`
+	Instructions        []uiCompiledInstruction `json:"instructions"`
+	AddressTableLookups *[]uiAddressTableLookup `json:"addressTableLookups,omitempty"`
+}
+
+type uiMessageHeader struct {
+	NumRequiredSignatures       uint8 `json:"numRequiredSignatures"`
+	NumReadonlySignedAccounts   uint8 `json:"numReadonlySignedAccounts"`
+	NumReadonlyUnsignedAccounts uint8 `json:"numReadonlyUnsignedAccounts"`
+}
+
+type uiCompiledInstruction struct {
+	ProgramIDIndex uint16   `json:"programIdIndex"`
+	Accounts       []uint16 `json:"accounts"`
+	Data           string   `json:"data"`
+	StackHeight    *uint32  `json:"stackHeight,omitempty"`
+}
+
+type uiAddressTableLookup struct {
+	AccountKey      solana.PublicKey `json:"accountKey"`
+	WritableIndexes []uint16         `json:"writableIndexes"`
+	ReadonlyIndexes []uint16         `json:"readonlyIndexes"`
+}
+
+// encodeTransaction returns a transaction in the given encoding: as a
+// JSON object, or as a pair of the serialized transaction and the
+// encoding.
+//
+// Based on solana_transaction_status::EncodableWithMeta::encode_with_meta.
+func encodeTransaction(tx *solana.Transaction, encoding string) (interface{}, error) {
+	switch encoding {
+	case EncodingBase58, EncodingBase64:
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		if encoding == EncodingBase58 {
+			return []string{base58.Encode(data), encoding}, nil
+		}
+		return []string{base64.StdEncoding.EncodeToString(data), encoding}, nil
+	}
+
+	msg := &tx.Message
+	ui := &uiTransaction{
+		Signatures: tx.Signatures,
+		Message: uiMessage{
+			Header: uiMessageHeader{
+				NumRequiredSignatures:       msg.Header.NumRequiredSignatures,
+				NumReadonlySignedAccounts:   msg.Header.NumReadonlySignedAccounts,
+				NumReadonlyUnsignedAccounts: msg.Header.NumReadonlyUnsignedAccounts,
+			},
+			AccountKeys:     msg.AccountKeys,
+			RecentBlockhash: msg.RecentBlockhash,
+			Instructions:    make([]uiCompiledInstruction, len(msg.Instructions)),
+		},
+	}
+	for i := range msg.Instructions {
+		ui.Message.Instructions[i] = encodeInstruction(&msg.Instructions[i], nil)
+	}
+	if msg.GetVersion() == solana.MessageVersionV0 {
+		lookups := msg.GetAddressTableLookups()
+		uiLookups := make([]uiAddressTableLookup, len(lookups))
+		for i, lookup := range lookups {
+			uiLookups[i] = uiAddressTableLookup{
+				AccountKey:      lookup.AccountKey,
+				WritableIndexes: indexes(lookup.WritableIndexes),
+				ReadonlyIndexes: indexes(lookup.ReadonlyIndexes),
+			}
+		}
+		ui.Message.AddressTableLookups = &uiLookups
+	}
+	return ui, nil
+}
+
+func encodeInstruction(instr *solana.CompiledInstruction, stackHeight *uint32) uiCompiledInstruction {
+	accounts := instr.Accounts
+	if accounts == nil {
+		accounts = []uint16{}
+	}
+	return uiCompiledInstruction{
+		ProgramIDIndex: instr.ProgramIDIndex,
+		Accounts:       accounts,
+		Data:           base58.Encode(instr.Data),
+		StackHeight:    stackHeight,
+	}
+}
+
+// indexes widens indexes, which would be encoded as a base64 string as
+// bytes.
+func indexes(b []uint8) []uint16 {
+	out := make([]uint16, len(b))
+	for i, v := range b {
+		out[i] = uint16(v)
+	}
+	return out
+}
+
+type uiTransactionStatusMeta struct {
+	Err                  interface{}            `json:"err"`
+	Status               map[string]interface{} `json:"status"`
+	Fee                  uint64                 `json:"fee"`
+	PreBalances          []uint64               `json:"preBalances"`
+	PostBalances         []uint64               `json:"postBalances"`
+	InnerInstructions    *[]uiInnerInstructions `json:"innerInstructions,omitempty"`
+	LogMessages          *[]string              `json:"logMessages,omitempty"`
+	PreTokenBalances     []uiTokenBalance       `json:"preTokenBalances"`
+	PostTokenBalances    []uiTokenBalance       `json:"postTokenBalances"`
+	Rewards              *[]uiReward            `json:"rewards,omitempty"`
+	LoadedAddresses      *uiLoadedAddresses     `json:"loadedAddresses,omitempty"`
+	ReturnData           *json.RawMessage       `json:"returnData,omitempty"`
+	ComputeUnitsConsumed *uint64                `json:"computeUnitsConsumed,omitempty"`
+}
+
+type uiInnerInstructions struct {
+	Index        uint8                   `json:"index"`
+	Instructions []uiCompiledInstruction `json:"instructions"`
+}
+
+type uiTokenBalance struct {
+	AccountIndex  uint8            `json:"accountIndex"`
+	Mint          solana.PublicKey `json:"mint"`
+	UiTokenAmount uiTokenAmount    `json:"uiTokenAmount"`
+	Owner         string           `json:"owner,omitempty"`
+	ProgramId     string           `json:"programId,omitempty"`
+}
+
+type uiTokenAmount struct {
+	UiAmount       *float64 `json:"uiAmount"`
+	Decimals       uint8    `json:"decimals"`
+	Amount         string   `json:"amount"`
+	UiAmountString string   `json:"uiAmountString"`
+}
+
+type uiLoadedAddresses struct {
+	Writable []solana.PublicKey `json:"writable"`
+	Readonly []solana.PublicKey `json:"readonly"`
+}
+
+type uiReward struct {
+	Pubkey      solana.PublicKey `json:"pubkey"`
+	Lamports    int64            `json:"lamports"`
+	PostBalance uint64           `json:"postBalance"`
+	RewardType  *string          `json:"rewardType"`
+	Commission  *uint8           `json:"commission"`
+}
+
+var nullJSON = json.RawMessage("null")
+
+// encodeMeta returns the status meta of a transaction in its RPC form. The
+// transactions of blocks carry no rewards.
+//
+// Based on solana_transaction_status::UiTransactionStatusMeta::from.
+func encodeMeta(meta *sealevel.TransactionStatusMeta) *uiTransactionStatusMeta {
+	if meta == nil {
+		return nil
+	}
+	inner := encodeInnerInstructions(meta.InnerInstructions)
+	logs := meta.LogMessages
+	if logs == nil {
+		logs = []string{}
+	}
+	rewards := []uiReward{}
+	returnData := nullJSON
+	computeUnits := meta.ComputeUnitsConsumed
+	ui := encodeSimpleMeta(meta)
+	ui.InnerInstructions = &inner
+	ui.LogMessages = &logs
+	ui.Rewards = &rewards
+	ui.LoadedAddresses = &uiLoadedAddresses{
+		Writable: nonNilKeys(meta.LoadedAddresses.Writable),
+		Readonly: nonNilKeys(meta.LoadedAddresses.Readonly),
+	}
+	ui.ReturnData = &returnData
+	ui.ComputeUnitsConsumed = &computeUnits
+	return ui
+}
+
+// encodeInnerInstructions returns the instructions invoked by the
+// instructions of a transaction in their RPC form.
+func encodeInnerInstructions(innerInstrs []sealevel.InnerInstructions) []uiInnerInstructions {
+	inner := make([]uiInnerInstructions, len(innerInstrs))
+	for i, instrs := range innerInstrs {
+		inner[i] = uiInnerInstructions{
+			Index:        instrs.Index,
+			Instructions: make([]uiCompiledInstruction, len(instrs.Instructions)),
+		}
+		for k := range instrs.Instructions {
+			stackHeight := instrs.Instructions[k].StackHeight
+			inner[i].Instructions[k] = encodeInstruction(&instrs.Instructions[k].Instruction, &stackHeight)
+		}
+	}
+	return inner
+}
+
+// encodeSimpleMeta returns the fields of the status meta of a transaction
+// kept in blocks with the accounts of the transactions only.
+//
+// Based on solana_transaction_status::UiTransactionStatusMeta::build_simple_ui_transaction_status_meta.
+func encodeSimpleMeta(meta *sealevel.TransactionStatusMeta) *uiTransactionStatusMeta {
+	return &uiTransactionStatusMeta{
+		Err:               TransactionError(meta.Err),
+		Status:            transactionStatus(meta.Err),
+		Fee:               meta.Fee,
+		PreBalances:       nonNilBalances(meta.PreBalances),
+		PostBalances:      nonNilBalances(meta.PostBalances),
+		PreTokenBalances:  encodeTokenBalances(meta.PreTokenBalances),
+		PostTokenBalances: encodeTokenBalances(meta.PostTokenBalances),
+	}
+}
+
+func encodeTokenBalances(balances []sealevel.TokenBalance) []uiTokenBalance {
+	ui := make([]uiTokenBalance, len(balances))
+	for i, balance := range balances {
+		amount := balance.UiTokenAmount
+		ui[i] = uiTokenBalance{
+			AccountIndex: balance.AccountIndex,
+			Mint:         balance.Mint,
+			UiTokenAmount: uiTokenAmount{
+				Decimals:       amount.Decimals,
+				Amount:         amount.Amount,
+				UiAmountString: amount.UiAmountString,
+			},
+		}
+		// zero amounts have no decimal amount, as decoded from protobuf
+		if uiAmount := amount.UiAmount; uiAmount != 0 {
+			ui[i].UiTokenAmount.UiAmount = &uiAmount
+		}
+		if !balance.Owner.IsZero() {
+			ui[i].Owner = balance.Owner.String()
+		}
+		if !balance.ProgramId.IsZero() {
+			ui[i].ProgramId = balance.ProgramId.String()
+		}
+	}
+	return ui
+}
+
+// encodeRewards returns rewards in their RPC form.
+func encodeRewards(rewards []storageproto.Reward) []uiReward {
+	ui := make([]uiReward, len(rewards))
+	for i, reward := range rewards {
+		ui[i] = uiReward{
+			Pubkey:      reward.Pubkey,
+			Lamports:    reward.Lamports,
+			PostBalance: reward.PostBalance,
+			Commission:  reward.Commission,
+		}
+		if reward.RewardType != storageproto.RewardTypeUnspecified {
+			// capitalized, unlike RewardType.String
+			rewardType := reward.RewardType.String()
+			rewardType = string(rewardType[0]-'a'+'A') + rewardType[1:]
+			ui[i].RewardType = &rewardType
+		}
+	}
+	return ui
+}
+
+type uiAccountsTransaction struct {
+	Signatures  []solana.Signature `json:"signatures"`
+	AccountKeys []uiParsedAccount  `json:"accountKeys"`
+}
+
+type uiParsedAccount struct {
+	Pubkey   solana.PublicKey `json:"pubkey"`
+	Writable bool             `json:"writable"`
+	Signer   bool             `json:"signer"`
+	Source   string           `json:"source"`
+}
+
+// encodeAccounts returns the signatures and accounts of a transaction,
+// with the addresses loaded from lookup tables recorded in its meta.
+//
+// Based on solana_transaction_status::parse_accounts::{parse_legacy_message_accounts, parse_v0_message_accounts}.
+func encodeAccounts(tx *solana.Transaction, meta *sealevel.TransactionStatusMeta) *uiAccountsTransaction {
+	var loaded *sealevel.LoadedAddresses
+	if meta != nil {
+		loaded = &meta.LoadedAddresses
+	}
+	writable, _ := blockstore.TransactionAccountKeys(tx, loaded)
+	isWritable := make(map[solana.PublicKey]bool, len(writable))
+	for _, key := range writable {
+		isWritable[key] = true
+	}
+
+	ui := &uiAccountsTransaction{Signatures: tx.Signatures}
+	numSigned := int(tx.Message.Header.NumRequiredSignatures)
+	for i, key := range tx.Message.AccountKeys {
+		ui.AccountKeys = append(ui.AccountKeys, uiParsedAccount{
+			Pubkey:   key,
+			Writable: isWritable[key],
+			Signer:   i < numSigned,
+			Source:   "transaction",
+		})
+	}
+	if loaded != nil {
+		for _, keys := range [][]solana.PublicKey{loaded.Writable, loaded.Readonly} {
+			for _, key := range keys {
+				ui.AccountKeys = append(ui.AccountKeys, uiParsedAccount{
+					Pubkey:   key,
+					Writable: isWritable[key],
+					Source:   "lookupTable",
+				})
+			}
+		}
+	}
+	return ui
+}
+
+func nonNilBalances(balances []uint64) []uint64 {
+	if balances == nil {
+		return []uint64{}
+	}
+	return balances
+}
+
+func nonNilKeys(keys []solana.PublicKey) []solana.PublicKey {
+	if keys == nil {
+		return []solana.PublicKey{}
+	}
+	return keys
+}
@@ -0,0 +1,240 @@
+// Package rpcserver serves the Solana JSON-RPC API from local data, such as
+// a blockstore and the accounts of a snapshot, on which transactions are
+// also simulated.
+package rpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"k8s.io/klog/v2"
+)
+
+// maxRequestSize bounds the size of the body of a request.
+//
+// Based on solana_rpc::rpc_service::MAX_REQUEST_BODY_SIZE.
+const maxRequestSize = 50 * (1 << 10)
+
+// Error codes of JSON-RPC, and of the Solana methods.
+//
+// Based on jsonrpc_core::types::error::ErrorCode and
+// solana_rpc_client_api::custom_error.
+const (
+	ErrCodeParse                                   = -32700
+	ErrCodeInvalidRequest                          = -32600
+	ErrCodeMethodNotFound                          = -32601
+	ErrCodeInvalidParams                           = -32602
+	ErrCodeInternal                                = -32603
+	ErrCodeTransactionSignatureVerificationFailure = -32003
+	ErrCodeBlockNotAvailable                       = -32004
+	ErrCodeUnsupportedTransactionVersion           = -32015
+	ErrCodeMinContextSlotNotReached                = -32016
+)
+
+// Error is the error of a JSON-RPC call.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+func invalidParams(format string, args ...interface{}) *Error {
+	return &Error{Code: ErrCodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// method handles the params of a call, and returns its result, or an
+// error, which is an *Error unless the server failed.
+type method func(params json.RawMessage) (interface{}, error)
+
+// Server serves JSON-RPC calls over HTTP POST requests, one call or a
+// batch of calls per request. Only the methods of the data it was given
+// are served.
+type Server struct {
+	blockstore Blockstore
+	accountsDB *AccountsDB
+	methods    map[string]method
+}
+
+// NewServer returns a server of the blocks and transactions of a
+// blockstore, and of the accounts of an AccountsDB, either of which may be
+// nil.
+func NewServer(blockstore Blockstore, accountsDB *AccountsDB) *Server {
+	s := &Server{
+		blockstore: blockstore,
+		accountsDB: accountsDB,
+		methods:    make(map[string]method),
+	}
+	if blockstore != nil {
+		s.methods["getBlock"] = s.getBlock
+		s.methods["getSignaturesForAddress"] = s.getSignaturesForAddress
+		s.methods["getTransaction"] = s.getTransaction
+	}
+	if accountsDB != nil {
+		s.methods["getAccountInfo"] = s.getAccountInfo
+		s.methods["getMultipleAccounts"] = s.getMultipleAccounts
+		s.methods["simulateTransaction"] = s.simulateTransaction
+	}
+	return s
+}
+
+// ListenAndServe serves calls on addr until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: s,
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Used HTTP Method is not allowed. POST is required", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+	if err != nil {
+		return
+	}
+	if len(body) > maxRequestSize {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.handle(body)); err != nil {
+		klog.V(2).Infof("Failed to write response: %s", err)
+	}
+}
+
+// handle returns the response to a call, or to each call of a batch.
+func (s *Server) handle(body []byte) interface{} {
+	body = bytes.TrimSpace(body)
+	if len(body) != 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return errorResponse(nil, &Error{Code: ErrCodeParse, Message: "Parse error"})
+		}
+		if len(batch) == 0 {
+			return errorResponse(nil, &Error{Code: ErrCodeInvalidRequest, Message: "Invalid request"})
+		}
+		responses := make([]*response, len(batch))
+		for i, call := range batch {
+			responses[i] = s.call(call)
+		}
+		return responses
+	}
+	return s.call(body)
+}
+
+// call handles a single call.
+func (s *Server) call(body []byte) *response {
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return errorResponse(nil, &Error{Code: ErrCodeParse, Message: "Parse error"})
+		}
+		return errorResponse(nil, &Error{Code: ErrCodeInvalidRequest, Message: "Invalid request"})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, &Error{Code: ErrCodeInvalidRequest, Message: "Invalid request"})
+	}
+	m, ok := s.methods[req.Method]
+	if !ok {
+		return errorResponse(req.ID, &Error{Code: ErrCodeMethodNotFound, Message: "Method not found"})
+	}
+	result, err := m(req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			klog.Warningf("%s: %s", req.Method, err)
+			rpcErr = &Error{Code: ErrCodeInternal, Message: "Internal error"}
+		}
+		return errorResponse(req.ID, rpcErr)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		klog.Warningf("%s: failed to encode result: %s", req.Method, err)
+		return errorResponse(req.ID, &Error{Code: ErrCodeInternal, Message: "Internal error"})
+	}
+	return &response{JSONRPC: "2.0", Result: data, ID: nullID(req.ID)}
+}
+
+func errorResponse(id json.RawMessage, err *Error) *response {
+	return &response{JSONRPC: "2.0", Error: err, ID: nullID(id)}
+}
+
+func nullID(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// parseParams decodes the positional params of a call into args, of which
+// the first numRequired must be given.
+func parseParams(params json.RawMessage, numRequired int, args ...interface{}) error {
+	var raw []json.RawMessage
+	if len(params) != 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &raw); err != nil {
+			return invalidParams("Invalid params: %s", err)
+		}
+	}
+	if len(raw) < numRequired {
+		return invalidParams("`params` should have at least %d argument(s)", numRequired)
+	}
+	if len(raw) > len(args) {
+		return invalidParams("Invalid params: invalid length %d, expected at most %d", len(raw), len(args))
+	}
+	for i, arg := range raw {
+		if string(arg) == "null" {
+			continue
+		}
+		if err := json.Unmarshal(arg, args[i]); err != nil {
+			return invalidParams("Invalid params: %s", err)
+		}
+	}
+	return nil
+}
+
+// checkCommitment rejects commitments below confirmed, of which the
+// blockstore has no notion.
+func checkCommitment(commitment string) error {
+	switch commitment {
+	case "", "confirmed", "finalized":
+		return nil
+	case "processed":
+		return invalidParams("Method does not support commitment below `confirmed`")
+	default:
+		return invalidParams("Invalid params: unknown commitment %q", commitment)
+	}
+}
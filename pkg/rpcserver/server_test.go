@@ -0,0 +1,349 @@
+package rpcserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/shred"
+	"go.firedancer.io/radiance/pkg/storageproto"
+)
+
+type txKey struct {
+	sig  solana.Signature
+	slot uint64
+}
+
+// memBlockstore is a Blockstore of full blocks.
+type memBlockstore struct {
+	blocks   map[uint64]*blockstore.Block
+	statuses map[txKey]*sealevel.TransactionStatusMeta
+	rewards  map[uint64]*storageproto.Rewards
+	times    map[uint64]int64
+	roots    map[uint64]bool
+}
+
+func newMemBlockstore() *memBlockstore {
+	return &memBlockstore{
+		blocks:   make(map[uint64]*blockstore.Block),
+		statuses: make(map[txKey]*sealevel.TransactionStatusMeta),
+		rewards:  make(map[uint64]*storageproto.Rewards),
+		times:    make(map[uint64]int64),
+		roots:    make(map[uint64]bool),
+	}
+}
+
+// addBlock adds a block of one entry holding the transactions.
+func (m *memBlockstore) addBlock(slot, parent uint64, hash solana.Hash, txs ...solana.Transaction) {
+	m.blocks[slot] = &blockstore.Block{
+		Slot:       slot,
+		ParentSlot: parent,
+		Batches:    []blockstore.Entries{{Entries: []shred.Entry{{Hash: hash, Txns: txs}}}},
+	}
+}
+
+func (m *memBlockstore) GetSlotMeta(slot uint64) (*blockstore.SlotMeta, error) {
+	block, ok := m.blocks[slot]
+	if !ok {
+		return nil, blockstore.ErrNotFound
+	}
+	return &blockstore.SlotMeta{Slot: slot, ParentSlot: block.ParentSlot, Consumed: 1, LastIndex: 0}, nil
+}
+
+func (m *memBlockstore) GetBlock(slot uint64) (*blockstore.Block, error) {
+	block, ok := m.blocks[slot]
+	if !ok {
+		return nil, blockstore.ErrNotFound
+	}
+	return block, nil
+}
+
+func (m *memBlockstore) GetTransactionStatusInSlot(sig solana.Signature, slot uint64) (*sealevel.TransactionStatusMeta, error) {
+	meta, ok := m.statuses[txKey{sig, slot}]
+	if !ok {
+		return nil, blockstore.ErrNotFound
+	}
+	return meta, nil
+}
+
+func (m *memBlockstore) GetRewards(slot uint64) (*storageproto.Rewards, error) {
+	rewards, ok := m.rewards[slot]
+	if !ok {
+		return nil, blockstore.ErrNotFound
+	}
+	return rewards, nil
+}
+
+func (m *memBlockstore) GetBlockTime(slot uint64) (int64, error) {
+	blockTime, ok := m.times[slot]
+	if !ok {
+		return 0, blockstore.ErrNotFound
+	}
+	return blockTime, nil
+}
+
+func (m *memBlockstore) GetBlockHeight(slot uint64) (uint64, error) {
+	if _, ok := m.blocks[slot]; !ok {
+		return 0, blockstore.ErrNotFound
+	}
+	return slot - 1, nil
+}
+
+func (m *memBlockstore) GetTransactionStatuses(sig solana.Signature) ([]blockstore.TransactionStatus, error) {
+	var statuses []blockstore.TransactionStatus
+	for key, meta := range m.statuses {
+		if key.sig == sig {
+			statuses = append(statuses, blockstore.TransactionStatus{Slot: key.slot, Meta: meta})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Slot < statuses[j].Slot
+	})
+	return statuses, nil
+}
+
+// GetAddressSignaturesRev indexes the transactions of the blocks with a
+// status like (*blockstore.DB).IndexAddressSignatures.
+func (m *memBlockstore) GetAddressSignaturesRev(addr solana.PublicKey, before, until *blockstore.TxPosition, limit int) ([]blockstore.AddressSignature, error) {
+	var sigs []blockstore.AddressSignature
+	for slot, block := range m.blocks {
+		for i, tx := range block.Transactions() {
+			meta, ok := m.statuses[txKey{tx.Signatures[0], slot}]
+			if !ok {
+				continue
+			}
+			as := blockstore.AddressSignature{Address: addr, Slot: slot, Index: uint32(i), Signature: tx.Signatures[0]}
+			if (before != nil && !as.Position().Less(*before)) || (until != nil && !until.Less(as.Position())) {
+				continue
+			}
+			writable, readonly := blockstore.TransactionAccountKeys(&tx, &meta.LoadedAddresses)
+			for _, key := range append(writable, readonly...) {
+				if key == addr {
+					sigs = append(sigs, as)
+				}
+			}
+		}
+	}
+	sort.Slice(sigs, func(i, j int) bool {
+		return sigs[j].Position().Less(sigs[i].Position())
+	})
+	if len(sigs) > limit {
+		sigs = sigs[:limit]
+	}
+	return sigs, nil
+}
+
+func (m *memBlockstore) IsRoot(slot uint64) (bool, error) {
+	return m.roots[slot], nil
+}
+
+// post sends a request to the server and returns the response body.
+func post(t *testing.T, s *Server, body string) string {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+// call calls a method and returns its result, or error.
+func call(t *testing.T, s *Server, method, params string) (json.RawMessage, *Error) {
+	var res response
+	body := post(t, s, `{"jsonrpc":"2.0","id":1,"method":"`+method+`","params":`+params+`}`)
+	require.NoError(t, json.Unmarshal([]byte(body), &res))
+	assert.JSONEq(t, `1`, string(res.ID))
+	return res.Result, res.Error
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer(newMemBlockstore(), nil)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+		post(t, s, `{"jsonrpc":`))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":2}`,
+		post(t, s, `{"jsonrpc":"2.0","id":2,"method":"getFoo"}`))
+	assert.JSONEq(t, `[
+		{"jsonrpc":"2.0","error":{"code":-32602,"message":"`+"`params`"+` should have at least 1 argument(s)"},"id":1},
+		{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid request"},"id":2}
+	]`, post(t, s, `[{"jsonrpc":"2.0","id":1,"method":"getBlock"},{"jsonrpc":"1.0","id":2,"method":"getBlock"}]`))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	assert.Empty(t, NewServer(nil, nil).methods)
+}
+
+func TestGetBlock(t *testing.T) {
+	db := newMemBlockstore()
+	tx := solana.Transaction{
+		Signatures: []solana.Signature{{1}},
+		Message: solana.Message{
+			Header:          solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+			AccountKeys:     []solana.PublicKey{{2}, {3}, {4}},
+			RecentBlockhash: solana.Hash{5},
+			Instructions:    []solana.CompiledInstruction{{ProgramIDIndex: 2, Accounts: []uint16{0, 1}, Data: []byte{1, 2}}},
+		},
+	}
+	unknown := tx
+	unknown.Signatures = []solana.Signature{{6}}
+	db.addBlock(9, 8, solana.Hash{7})
+	db.addBlock(10, 9, solana.Hash{8}, tx, unknown)
+	db.statuses[txKey{tx.Signatures[0], 10}] = &sealevel.TransactionStatusMeta{
+		Err:          sealevel.TxErrInstructionError{Index: 0, Err: sealevel.InstrErrCustom{Code: 1}},
+		Fee:          5000,
+		PreBalances:  []uint64{10, 0, 1},
+		PostBalances: []uint64{5, 0, 1},
+		InnerInstructions: []sealevel.InnerInstructions{{Index: 0, Instructions: []sealevel.InnerInstruction{
+			{Instruction: solana.CompiledInstruction{ProgramIDIndex: 2, Accounts: []uint16{1}, Data: []byte{3}}, StackHeight: 2},
+		}}},
+		LogMessages:          []string{"log"},
+		ComputeUnitsConsumed: 150,
+	}
+	commission := uint8(10)
+	db.rewards[10] = &storageproto.Rewards{Rewards: []storageproto.Reward{
+		{Pubkey: solana.PublicKey{2}, Lamports: 2500, PostBalance: 7500, RewardType: storageproto.RewardTypeFee},
+		{Pubkey: solana.PublicKey{3}, Lamports: 1, PostBalance: 2, RewardType: storageproto.RewardTypeVoting, Commission: &commission},
+	}}
+	db.times[10] = 1700000000
+	s := NewServer(db, nil)
+
+	result, rpcErr := call(t, s, "getBlock", `[10]`)
+	require.Nil(t, rpcErr)
+	expectedTx := `{
+		"signatures":["` + solana.Signature{1}.String() + `"],
+		"message":{
+			"header":{"numRequiredSignatures":1,"numReadonlySignedAccounts":0,"numReadonlyUnsignedAccounts":1},
+			"accountKeys":["` + solana.PublicKey{2}.String() + `","` + solana.PublicKey{3}.String() + `","` + solana.PublicKey{4}.String() + `"],
+			"recentBlockhash":"` + solana.Hash{5}.String() + `",
+			"instructions":[{"programIdIndex":2,"accounts":[0,1],"data":"5T"}]
+		}
+	}`
+	unknownTx := strings.Replace(expectedTx, solana.Signature{1}.String(), solana.Signature{6}.String(), 1)
+	assert.JSONEq(t, `{
+		"previousBlockhash":"`+solana.Hash{7}.String()+`",
+		"blockhash":"`+solana.Hash{8}.String()+`",
+		"parentSlot":9,
+		"transactions":[
+			{
+				"transaction":`+expectedTx+`,
+				"meta":{
+					"err":{"InstructionError":[0,{"Custom":1}]},
+					"status":{"Err":{"InstructionError":[0,{"Custom":1}]}},
+					"fee":5000,
+					"preBalances":[10,0,1],
+					"postBalances":[5,0,1],
+					"innerInstructions":[{"index":0,"instructions":[{"programIdIndex":2,"accounts":[1],"data":"4","stackHeight":2}]}],
+					"logMessages":["log"],
+					"preTokenBalances":[],
+					"postTokenBalances":[],
+					"rewards":[],
+					"loadedAddresses":{"writable":[],"readonly":[]},
+					"returnData":null,
+					"computeUnitsConsumed":150
+				}
+			},
+			{"transaction":`+unknownTx+`,"meta":null}
+		],
+		"rewards":[
+			{"pubkey":"`+solana.PublicKey{2}.String()+`","lamports":2500,"postBalance":7500,"rewardType":"Fee","commission":null},
+			{"pubkey":"`+solana.PublicKey{3}.String()+`","lamports":1,"postBalance":2,"rewardType":"Voting","commission":10}
+		],
+		"blockTime":1700000000,
+		"blockHeight":9
+	}`, string(result))
+
+	result, rpcErr = call(t, s, "getBlock", `[10, {"transactionDetails":"signatures","rewards":false}]`)
+	require.Nil(t, rpcErr)
+	assert.JSONEq(t, `{
+		"previousBlockhash":"`+solana.Hash{7}.String()+`",
+		"blockhash":"`+solana.Hash{8}.String()+`",
+		"parentSlot":9,
+		"signatures":["`+solana.Signature{1}.String()+`","`+solana.Signature{6}.String()+`"],
+		"blockTime":1700000000,
+		"blockHeight":9
+	}`, string(result))
+
+	result, rpcErr = call(t, s, "getBlock", `[10, {"transactionDetails":"accounts","rewards":false,"maxSupportedTransactionVersion":0}]`)
+	require.Nil(t, rpcErr)
+	var block struct {
+		Transactions []json.RawMessage `json:"transactions"`
+	}
+	require.NoError(t, json.Unmarshal(result, &block))
+	require.Len(t, block.Transactions, 2)
+	assert.JSONEq(t, `{
+		"transaction":{
+			"signatures":["`+solana.Signature{1}.String()+`"],
+			"accountKeys":[
+				{"pubkey":"`+solana.PublicKey{2}.String()+`","writable":true,"signer":true,"source":"transaction"},
+				{"pubkey":"`+solana.PublicKey{3}.String()+`","writable":true,"signer":false,"source":"transaction"},
+				{"pubkey":"`+solana.PublicKey{4}.String()+`","writable":false,"signer":false,"source":"transaction"}
+			]
+		},
+		"meta":{
+			"err":{"InstructionError":[0,{"Custom":1}]},
+			"status":{"Err":{"InstructionError":[0,{"Custom":1}]}},
+			"fee":5000,
+			"preBalances":[10,0,1],
+			"postBalances":[5,0,1],
+			"preTokenBalances":[],
+			"postTokenBalances":[]
+		},
+		"version":"legacy"
+	}`, string(block.Transactions[0]))
+
+	result, rpcErr = call(t, s, "getBlock", `[10, "base64"]`)
+	require.Nil(t, rpcErr)
+	var encoded struct {
+		Transactions []struct {
+			Transaction []string `json:"transaction"`
+		} `json:"transactions"`
+	}
+	require.NoError(t, json.Unmarshal(result, &encoded))
+	require.Len(t, encoded.Transactions, 2)
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []string{base64.StdEncoding.EncodeToString(raw), "base64"}, encoded.Transactions[0].Transaction)
+
+	_, rpcErr = call(t, s, "getBlock", `[11]`)
+	assert.Equal(t, &Error{Code: ErrCodeBlockNotAvailable, Message: "Block not available for slot 11"}, rpcErr)
+	_, rpcErr = call(t, s, "getBlock", `[10, {"commitment":"processed"}]`)
+	assert.Equal(t, ErrCodeInvalidParams, rpcErr.Code)
+	_, rpcErr = call(t, s, "getBlock", `[10, {"encoding":"jsonParsed"}]`)
+	assert.Equal(t, ErrCodeInvalidParams, rpcErr.Code)
+
+	v0 := tx
+	v0.Message.SetVersion(solana.MessageVersionV0)
+	db.addBlock(12, 10, solana.Hash{9}, v0)
+	_, rpcErr = call(t, s, "getBlock", `[12]`)
+	assert.Equal(t, ErrCodeUnsupportedTransactionVersion, rpcErr.Code)
+	result, rpcErr = call(t, s, "getBlock", `[12, {"maxSupportedTransactionVersion":0,"rewards":false}]`)
+	require.Nil(t, rpcErr)
+	assert.Contains(t, string(result), `"addressTableLookups":[]`)
+	assert.Contains(t, string(result), `"version":0`)
+}
+
+func TestTransactionError(t *testing.T) {
+	for _, tc := range []struct {
+		err      error
+		expected string
+	}{
+		{nil, `null`},
+		{sealevel.TxErrBlockhashNotFound, `"BlockhashNotFound"`},
+		{sealevel.TxErrInsufficientFundsForRent{AccountIndex: 2}, `{"InsufficientFundsForRent":{"account_index":2}}`},
+		{sealevel.TxErrInstructionError{Index: 1, Err: sealevel.InstrErrCustom{Code: 6}}, `{"InstructionError":[1,{"Custom":6}]}`},
+		{sealevel.TxErrInstructionError{Index: 0, Err: sealevel.InstrErrMissingAccount}, `{"InstructionError":[0,"MissingAccount"]}`},
+		{sealevel.TxErrDuplicateInstruction, `{"DuplicateInstruction":0}`},
+	} {
+		actual, err := json.Marshal(TransactionError(tc.err))
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, string(actual))
+	}
+}
@@ -0,0 +1,246 @@
+package rpcserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/mr-tron/base58"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// Bounds on the size of a transaction to simulate, as encoded and as
+// decoded.
+//
+// Based on solana_rpc::rpc::MAX_BASE58_SIZE, MAX_BASE64_SIZE and
+// solana_packet::PACKET_DATA_SIZE.
+const (
+	maxBase58TransactionSize = 1683
+	maxBase64TransactionSize = 1644
+	maxTransactionSize       = 1232
+)
+
+// simulateTransactionConfig is the config of simulateTransaction.
+//
+// Based on solana_rpc_client_api::config::RpcSimulateTransactionConfig.
+type simulateTransactionConfig struct {
+	SigVerify              bool                               `json:"sigVerify"`
+	ReplaceRecentBlockhash bool                               `json:"replaceRecentBlockhash"`
+	Commitment             string                             `json:"commitment"`
+	Encoding               string                             `json:"encoding"`
+	Accounts               *simulateTransactionAccountsConfig `json:"accounts"`
+	MinContextSlot         *uint64                            `json:"minContextSlot"`
+	InnerInstructions      bool                               `json:"innerInstructions"`
+}
+
+// simulateTransactionAccountsConfig selects the accounts of which the
+// state after the simulation is returned.
+//
+// Based on solana_rpc_client_api::config::RpcSimulateTransactionAccountsConfig.
+type simulateTransactionAccountsConfig struct {
+	Encoding  string   `json:"encoding"`
+	Addresses []string `json:"addresses"`
+}
+
+// uiSimulateTransactionResult is the outcome of a simulated transaction.
+//
+// Based on solana_rpc_client_api::response::RpcSimulateTransactionResult.
+type uiSimulateTransactionResult struct {
+	Err                    interface{}            `json:"err"`
+	Logs                   []string               `json:"logs"`
+	Accounts               []*uiAccount           `json:"accounts"`
+	UnitsConsumed          uint64                 `json:"unitsConsumed"`
+	LoadedAccountsDataSize uint32                 `json:"loadedAccountsDataSize"`
+	ReturnData             *uiReturnData          `json:"returnData"`
+	InnerInstructions      *[]uiInnerInstructions `json:"innerInstructions"`
+	ReplacementBlockhash   *uiBlockhash           `json:"replacementBlockhash"`
+}
+
+// uiReturnData is the data returned by a program of a transaction.
+//
+// Based on solana_transaction_status::UiTransactionReturnData.
+type uiReturnData struct {
+	ProgramId solana.PublicKey `json:"programId"`
+	Data      [2]string        `json:"data"`
+}
+
+// uiBlockhash is a blockhash, and the last block height at which
+// transactions referencing it are processed.
+//
+// Based on solana_rpc_client_api::response::RpcBlockhash.
+type uiBlockhash struct {
+	Blockhash            solana.Hash `json:"blockhash"`
+	LastValidBlockHeight uint64      `json:"lastValidBlockHeight"`
+}
+
+// decodeTransaction decodes a transaction encoded in base58 or base64.
+//
+// Based on solana_rpc::rpc::decode_and_deserialize.
+func decodeTransaction(encoded string, encoding string) (*solana.Transaction, error) {
+	var raw []byte
+	var err error
+	switch encoding {
+	case EncodingBase58:
+		if len(encoded) > maxBase58TransactionSize {
+			return nil, invalidParams("base58 encoded solana_transaction::versioned::VersionedTransaction too large: %d bytes (max: encoded/raw %d/%d)",
+				len(encoded), maxBase58TransactionSize, maxTransactionSize)
+		}
+		raw, err = base58.Decode(encoded)
+	case EncodingBase64:
+		if len(encoded) > maxBase64TransactionSize {
+			return nil, invalidParams("base64 encoded solana_transaction::versioned::VersionedTransaction too large: %d bytes (max: encoded/raw %d/%d)",
+				len(encoded), maxBase64TransactionSize, maxTransactionSize)
+		}
+		raw, err = base64.StdEncoding.DecodeString(encoded)
+	default:
+		return nil, invalidParams("unsupported encoding: %s. Supported encodings: base58, base64", encoding)
+	}
+	if err != nil {
+		return nil, invalidParams("invalid %s encoding: %s", encoding, err)
+	}
+	if len(raw) > maxTransactionSize {
+		return nil, invalidParams("decoded solana_transaction::versioned::VersionedTransaction too large: %d bytes (max: %d bytes)",
+			len(raw), maxTransactionSize)
+	}
+	tx, err := solana.TransactionFromDecoder(bin.NewBinDecoder(raw))
+	if err != nil {
+		return nil, invalidParams("failed to deserialize solana_transaction::versioned::VersionedTransaction: %s", err)
+	}
+	return tx, nil
+}
+
+// simulateTransaction executes a transaction on the bank of the accounts,
+// without committing it, and returns its logs, the compute units it
+// consumed, its return data, and the requested accounts as it left them.
+// Inner instructions are returned in their compiled form.
+//
+// Based on solana_rpc::rpc::rpc_full::FullImpl::simulate_transaction.
+func (s *Server) simulateTransaction(params json.RawMessage) (interface{}, error) {
+	var encoded string
+	var config simulateTransactionConfig
+	if err := parseParams(params, 1, &encoded, &config); err != nil {
+		return nil, err
+	}
+	if config.Encoding == "" {
+		config.Encoding = EncodingBase58
+	}
+	tx, err := decodeTransaction(encoded, config.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountsCommitment(config.Commitment); err != nil {
+		return nil, err
+	}
+	if config.SigVerify && config.ReplaceRecentBlockhash {
+		return nil, invalidParams("sigVerify may not be used with replaceRecentBlockhash")
+	}
+
+	var addrs []solana.PublicKey
+	accountsEncoding := EncodingBase64
+	if config.Accounts != nil {
+		switch config.Accounts.Encoding {
+		case "":
+		case EncodingBinary, EncodingBase58:
+			return nil, invalidParams("base58 encoding not supported")
+		case EncodingBase64, EncodingBase64Zstd:
+			accountsEncoding = config.Accounts.Encoding
+		case EncodingJSONParsed:
+			return nil, invalidParams("Invalid params: encoding %s is not supported", EncodingJSONParsed)
+		default:
+			return nil, invalidParams("Invalid params: unknown variant `%s`, expected one of `binary`, `base58`, `base64`, `jsonParsed`, `base64+zstd`", config.Accounts.Encoding)
+		}
+		if len(config.Accounts.Addresses) > len(tx.Message.AccountKeys) {
+			return nil, invalidParams("Too many accounts provided; max %d", len(tx.Message.AccountKeys))
+		}
+		addrs = make([]solana.PublicKey, len(config.Accounts.Addresses))
+		for i, addr := range config.Accounts.Addresses {
+			if addrs[i], err = parsePubkey(addr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var ui *uiSimulateTransactionResult
+	var slot uint64
+	s.accountsDB.withBank(func(b *bank.Bank, accts accounts.Accounts, accountsSlot uint64) {
+		slot = accountsSlot
+		if err = checkMinContextSlot(config.MinContextSlot, slot); err != nil {
+			return
+		}
+		if b == nil {
+			err = &Error{Code: ErrCodeInternal, Message: "Internal error: no bank to simulate transactions on"}
+			return
+		}
+		ui = &uiSimulateTransactionResult{}
+		if config.ReplaceRecentBlockhash {
+			tx.Message.RecentBlockhash = b.LastBlockhash()
+			ui.ReplacementBlockhash = &uiBlockhash{
+				Blockhash:            tx.Message.RecentBlockhash,
+				LastValidBlockHeight: b.BlockHeight + bank.MaxProcessingAge,
+			}
+		}
+		if _, sanitizeErr := sealevel.SanitizeTransaction(tx); sanitizeErr != nil {
+			err = invalidParams("invalid transaction: %s", sanitizeErr)
+			return
+		}
+		if config.SigVerify {
+			if verifyErr := sealevel.VerifyTransactionSignatures(tx); verifyErr != nil {
+				err = &Error{Code: ErrCodeTransactionSignatureVerificationFailure, Message: "Transaction signature verification failure"}
+				return
+			}
+		}
+		result := b.SimulateTransaction(tx, accts)
+		err = encodeSimulationResult(ui, result, addrs, accountsEncoding, config.InnerInstructions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &uiResponse{Context: uiContext{Slot: slot}, Value: ui}, nil
+}
+
+// encodeSimulationResult fills in the RPC form of the outcome of a
+// simulated transaction, with the state after the simulation of the given
+// accounts, nil for those not in the transaction, or all nil if it
+// failed.
+func encodeSimulationResult(ui *uiSimulateTransactionResult, result *bank.SimulationResult, addrs []solana.PublicKey, encoding string, innerInstructions bool) error {
+	ui.Err = TransactionError(result.Err)
+	ui.Logs = result.Logs
+	if ui.Logs == nil {
+		ui.Logs = []string{}
+	}
+	ui.UnitsConsumed = result.UnitsConsumed
+	ui.LoadedAccountsDataSize = result.LoadedAccountsDataSize
+	if result.ReturnData != nil {
+		ui.ReturnData = &uiReturnData{
+			ProgramId: result.ReturnDataProgramId,
+			Data:      [2]string{base64.StdEncoding.EncodeToString(result.ReturnData), EncodingBase64},
+		}
+	}
+	if innerInstructions {
+		inner := encodeInnerInstructions(result.InnerInstructions)
+		ui.InnerInstructions = &inner
+	}
+	if addrs == nil {
+		return nil
+	}
+	ui.Accounts = make([]*uiAccount, len(addrs))
+	if result.Err != nil {
+		return nil
+	}
+	for i, addr := range addrs {
+		for k, key := range result.AccountKeys {
+			if key != addr {
+				continue
+			}
+			var err error
+			if ui.Accounts[i], err = encodeAccount(result.PostAccounts[k], encoding, nil); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package rpcserver
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/geyser"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// newTransferParams returns the params of simulateTransaction of a
+// transfer from {1} to {2}, encoded in base64.
+func newTransferParams(t *testing.T, lamports uint64, recentBlockhash solana.Hash, config string) string {
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint32(data, 2)
+	binary.LittleEndian.PutUint64(data[4:], lamports)
+	tx := &solana.Transaction{
+		Signatures: []solana.Signature{{2}},
+		Message: solana.Message{
+			Header:          solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+			AccountKeys:     []solana.PublicKey{{1}, {2}, sealevel.SystemProgramAddr},
+			RecentBlockhash: recentBlockhash,
+			Instructions:    []solana.CompiledInstruction{{ProgramIDIndex: 2, Accounts: []uint16{0, 1}, Data: data}},
+		},
+	}
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	return `["` + base64.StdEncoding.EncodeToString(raw) + `", ` + config + `]`
+}
+
+func TestSimulateTransaction(t *testing.T) {
+	accts := accounts.NewMemAccounts()
+	systemProgram := &accounts.Account{Lamports: 1, Owner: sealevel.NativeLoaderAddr, Executable: true, Data: []byte("system_program")}
+	require.NoError(t, accts.SetAccount((*[32]byte)(&sealevel.SystemProgramAddr), systemProgram))
+	require.NoError(t, accts.SetAccount(&[32]byte{1}, &accounts.Account{Lamports: 1_000_000_000, Owner: sealevel.SystemProgramAddr}))
+	db := NewAccountsDB(10, accts.Map)
+	s := NewServer(nil, db)
+
+	params := newTransferParams(t, 100_000_000, solana.Hash{1}, `{"encoding":"base64"}`)
+	_, rpcErr := call(t, s, "simulateTransaction", params)
+	assert.Equal(t, ErrCodeInternal, rpcErr.Code)
+
+	b := bank.NewBank(10, accts, features.NewFeaturesDefault(), sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32})
+	b.SysvarCache.SetRent(sealevel.SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50})
+	b.LamportsPerSignature = 5000
+	require.NoError(t, b.RegisterBlockhash([32]byte{1}))
+	b.Freeze()
+	db.SetBank(b)
+
+	addrs := `"accounts":{"addresses":["` + solana.PublicKey{1}.String() + `","` + solana.PublicKey{2}.String() + `","` + solana.PublicKey{9}.String() + `"]}`
+	result, rpcErr := call(t, s, "simulateTransaction", newTransferParams(t, 100_000_000, solana.Hash{1}, `{"encoding":"base64",`+addrs+`}`))
+	require.Nil(t, rpcErr)
+	owner := solana.PublicKey(sealevel.SystemProgramAddr).String()
+	var res struct {
+		Context uiContext `json:"context"`
+		Value   struct {
+			uiSimulateTransactionResult
+			Accounts json.RawMessage `json:"accounts"`
+		} `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal(result, &res))
+	assert.Equal(t, uint64(10), res.Context.Slot)
+	assert.Nil(t, res.Value.Err)
+	assert.NotZero(t, res.Value.UnitsConsumed)
+	assert.Nil(t, res.Value.ReturnData)
+	assert.Nil(t, res.Value.InnerInstructions)
+	assert.Nil(t, res.Value.ReplacementBlockhash)
+	assert.JSONEq(t, `[
+		{"lamports":899995000,"data":["","base64"],"owner":"`+owner+`","executable":false,"rentEpoch":18446744073709551615,"space":0},
+		{"lamports":100000000,"data":["","base64"],"owner":"`+solana.PublicKey{}.String()+`","executable":false,"rentEpoch":0,"space":0},
+		null
+	]`, string(res.Value.Accounts))
+
+	// nothing is committed
+	served, _ := db.GetAccounts([][32]byte{{1}, {2}})
+	assert.Equal(t, uint64(1_000_000_000), served[0].Lamports)
+	assert.Nil(t, served[1])
+
+	// the accounts a failed transaction left are not returned
+	result, rpcErr = call(t, s, "simulateTransaction", newTransferParams(t, 1_000_000_000, solana.Hash{1}, `{"encoding":"base64",`+addrs+`,"innerInstructions":true}`))
+	require.Nil(t, rpcErr)
+	var failed struct {
+		Value struct {
+			Err               map[string]json.RawMessage `json:"err"`
+			Accounts          []*uiAccount               `json:"accounts"`
+			InnerInstructions []uiInnerInstructions      `json:"innerInstructions"`
+		} `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal(result, &failed))
+	assert.Contains(t, failed.Value.Err, "InstructionError")
+	assert.Equal(t, []*uiAccount{nil, nil, nil}, failed.Value.Accounts)
+	assert.Equal(t, []uiInnerInstructions{}, failed.Value.InnerInstructions)
+
+	result, rpcErr = call(t, s, "simulateTransaction", newTransferParams(t, 1_000_000, solana.Hash{2}, `{"encoding":"base64"}`))
+	require.Nil(t, rpcErr)
+	require.NoError(t, json.Unmarshal(result, &res))
+	assert.Equal(t, "BlockhashNotFound", res.Value.Err)
+	result, rpcErr = call(t, s, "simulateTransaction", newTransferParams(t, 1_000_000, solana.Hash{2}, `{"encoding":"base64","replaceRecentBlockhash":true}`))
+	require.Nil(t, rpcErr)
+	require.NoError(t, json.Unmarshal(result, &res))
+	assert.Nil(t, res.Value.Err)
+	assert.Equal(t, &uiBlockhash{Blockhash: solana.Hash{1}, LastValidBlockHeight: bank.MaxProcessingAge}, res.Value.ReplacementBlockhash)
+
+	_, rpcErr = call(t, s, "simulateTransaction", newTransferParams(t, 1_000_000, solana.Hash{1}, `{"encoding":"base64","sigVerify":true}`))
+	assert.Equal(t, ErrCodeTransactionSignatureVerificationFailure, rpcErr.Code)
+	_, rpcErr = call(t, s, "simulateTransaction", newTransferParams(t, 1_000_000, solana.Hash{1}, `{"encoding":"base64","minContextSlot":11}`))
+	assert.Equal(t, ErrCodeMinContextSlotNotReached, rpcErr.Code)
+	for _, params := range []string{
+		newTransferParams(t, 1_000_000, solana.Hash{1}, `{}`),
+		newTransferParams(t, 1_000_000, solana.Hash{1}, `{"encoding":"json"}`),
+		newTransferParams(t, 1_000_000, solana.Hash{1}, `{"encoding":"base64","sigVerify":true,"replaceRecentBlockhash":true}`),
+		newTransferParams(t, 1_000_000, solana.Hash{1}, `{"encoding":"base64","accounts":{"addresses":[],"encoding":"base58"}}`),
+		`["AQ==", {"encoding":"base64"}]`,
+	} {
+		_, rpcErr = call(t, s, "simulateTransaction", params)
+		assert.Equal(t, ErrCodeInvalidParams, rpcErr.Code, params)
+	}
+
+	// the bank of a slot is used once its accounts are
+	child, err := bank.NewBankFromParent(b, 11)
+	require.NoError(t, err)
+	child.Freeze()
+	db.SetBank(child)
+	result, rpcErr = call(t, s, "simulateTransaction", newTransferParams(t, 1_000_000, solana.Hash{1}, `{"encoding":"base64"}`))
+	require.Nil(t, rpcErr)
+	require.NoError(t, json.Unmarshal(result, &res))
+	assert.Equal(t, uint64(10), res.Context.Slot)
+	require.NoError(t, db.UpdateSlotStatus(&geyser.SlotUpdate{Slot: 11, Parent: 10, Status: geyser.SlotStatusProcessed}))
+	result, rpcErr = call(t, s, "simulateTransaction", newTransferParams(t, 1_000_000, solana.Hash{1}, `{"encoding":"base64","replaceRecentBlockhash":true}`))
+	require.Nil(t, rpcErr)
+	require.NoError(t, json.Unmarshal(result, &res))
+	assert.Equal(t, uint64(11), res.Context.Slot)
+	assert.Equal(t, child.LastBlockhash(), [32]byte(res.Value.ReplacementBlockhash.Blockhash))
+	assert.Equal(t, uint64(bank.MaxProcessingAge+1), res.Value.ReplacementBlockhash.LastValidBlockHeight)
+}
@@ -0,0 +1,247 @@
+package rpcserver
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// maxSignaturesForAddressLimit bounds the number of signatures returned by
+// getSignaturesForAddress.
+//
+// Based on solana_rpc_client_api::request::MAX_GET_CONFIRMED_SIGNATURES_FOR_ADDRESS2_LIMIT.
+const maxSignaturesForAddressLimit = 1000
+
+// transactionConfig is the config of getTransaction.
+//
+// Based on solana_rpc_client_api::config::RpcTransactionConfig.
+type transactionConfig struct {
+	encodingConfig
+}
+
+// UnmarshalJSON accepts the config, or the encoding alone, as deprecated.
+func (c *transactionConfig) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		return json.Unmarshal(data, &c.Encoding)
+	}
+	return json.Unmarshal(data, &c.encodingConfig)
+}
+
+// signaturesForAddressConfig is the config of getSignaturesForAddress.
+//
+// Based on solana_rpc_client_api::config::RpcSignaturesForAddressConfig.
+type signaturesForAddressConfig struct {
+	Before         string  `json:"before"`
+	Until          string  `json:"until"`
+	Limit          *int    `json:"limit"`
+	Commitment     string  `json:"commitment"`
+	MinContextSlot *uint64 `json:"minContextSlot"`
+}
+
+type uiConfirmedTransaction struct {
+	Slot uint64 `json:"slot"`
+	*uiTransactionWithMeta
+	BlockTime *int64 `json:"blockTime"`
+}
+
+// uiSignatureInfo is a transaction referencing an address. Memos are not
+// indexed by the blockstore, so they are always null.
+//
+// Based on solana_rpc_client_api::response::RpcConfirmedTransactionStatusWithSignature.
+type uiSignatureInfo struct {
+	Signature          solana.Signature `json:"signature"`
+	Slot               uint64           `json:"slot"`
+	Err                interface{}      `json:"err"`
+	Memo               *string          `json:"memo"`
+	BlockTime          *int64           `json:"blockTime"`
+	ConfirmationStatus string           `json:"confirmationStatus"`
+}
+
+// confirmedTransaction is a transaction found in a block.
+type confirmedTransaction struct {
+	slot  uint64
+	index int
+	tx    *solana.Transaction
+	meta  *sealevel.TransactionStatusMeta
+}
+
+// findTransaction returns a transaction from the first rooted slot it was
+// recorded in, or else from the first slot of which the block is available,
+// or nil if there is none.
+func (s *Server) findTransaction(sig solana.Signature) (*confirmedTransaction, error) {
+	statuses, err := s.blockstore.GetTransactionStatuses(sig)
+	if err != nil {
+		return nil, err
+	}
+	var found *confirmedTransaction
+	for _, status := range statuses {
+		block, ok, err := s.fullBlock(status.Slot)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		txs := block.Transactions()
+		for i := range txs {
+			if len(txs[i].Signatures) == 0 || txs[i].Signatures[0] != sig {
+				continue
+			}
+			rooted, err := s.blockstore.IsRoot(status.Slot)
+			if err != nil {
+				return nil, err
+			}
+			if found == nil || rooted {
+				found = &confirmedTransaction{slot: status.Slot, index: i, tx: &txs[i], meta: status.Meta}
+			}
+			if rooted {
+				return found, nil
+			}
+			break
+		}
+	}
+	return found, nil
+}
+
+// getTransaction returns a transaction with its status meta, or null if it
+// is not in the blockstore.
+//
+// Based on solana_rpc::rpc::JsonRpcRequestProcessor::get_transaction.
+func (s *Server) getTransaction(params json.RawMessage) (interface{}, error) {
+	var sigStr string
+	var config transactionConfig
+	if err := parseParams(params, 1, &sigStr, &config); err != nil {
+		return nil, err
+	}
+	sig, err := solana.SignatureFromBase58(sigStr)
+	if err != nil {
+		return nil, invalidParams("Invalid param: %s", err)
+	}
+	if err := checkCommitment(config.Commitment); err != nil {
+		return nil, err
+	}
+	if err := checkEncoding(&config.Encoding); err != nil {
+		return nil, err
+	}
+
+	found, err := s.findTransaction(sig)
+	if err != nil || found == nil {
+		return nil, err
+	}
+	version, err := transactionVersion(found.tx, config.MaxSupportedTransactionVersion)
+	if err != nil {
+		return nil, err
+	}
+	ui := &uiConfirmedTransaction{
+		Slot:                  found.slot,
+		uiTransactionWithMeta: &uiTransactionWithMeta{Version: version, Meta: encodeMeta(found.meta)},
+	}
+	ui.Transaction, err = encodeTransaction(found.tx, config.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	if blockTime, err := s.blockstore.GetBlockTime(found.slot); err == nil {
+		ui.BlockTime = &blockTime
+	} else if !errors.Is(err, blockstore.ErrNotFound) {
+		return nil, err
+	}
+	return ui, nil
+}
+
+// getSignaturesForAddress returns the transactions referencing an address,
+// newest first, starting before the transaction before and back to the
+// transaction until, if given.
+//
+// Based on solana_rpc::rpc::JsonRpcRequestProcessor::get_signatures_for_address.
+func (s *Server) getSignaturesForAddress(params json.RawMessage) (interface{}, error) {
+	var addrStr string
+	var config signaturesForAddressConfig
+	if err := parseParams(params, 1, &addrStr, &config); err != nil {
+		return nil, err
+	}
+	addr, err := solana.PublicKeyFromBase58(addrStr)
+	if err != nil {
+		return nil, invalidParams("Invalid param: %s", err)
+	}
+	if err := checkCommitment(config.Commitment); err != nil {
+		return nil, err
+	}
+	limit := maxSignaturesForAddressLimit
+	if config.Limit != nil {
+		limit = *config.Limit
+	}
+	if limit <= 0 || limit > maxSignaturesForAddressLimit {
+		return nil, invalidParams("Invalid limit; max %d", maxSignaturesForAddressLimit)
+	}
+
+	infos := []uiSignatureInfo{}
+	var before, until *blockstore.TxPosition
+	if config.Before != "" {
+		before, err = s.transactionPosition(config.Before)
+		if err != nil {
+			return nil, err
+		}
+		// nothing precedes an unknown transaction
+		if before == nil {
+			return infos, nil
+		}
+	}
+	if config.Until != "" {
+		until, err = s.transactionPosition(config.Until)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sigs, err := s.blockstore.GetAddressSignaturesRev(addr, before, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	blockTimes := make(map[uint64]*int64)
+	roots := make(map[uint64]bool)
+	for _, as := range sigs {
+		info := uiSignatureInfo{Signature: as.Signature, Slot: as.Slot}
+		meta, err := s.blockstore.GetTransactionStatusInSlot(as.Signature, as.Slot)
+		if err == nil {
+			info.Err = TransactionError(meta.Err)
+		} else if !errors.Is(err, blockstore.ErrNotFound) {
+			return nil, err
+		}
+
+		blockTime, ok := blockTimes[as.Slot]
+		if !ok {
+			if t, err := s.blockstore.GetBlockTime(as.Slot); err == nil {
+				blockTime = &t
+			} else if !errors.Is(err, blockstore.ErrNotFound) {
+				return nil, err
+			}
+			blockTimes[as.Slot] = blockTime
+			if roots[as.Slot], err = s.blockstore.IsRoot(as.Slot); err != nil {
+				return nil, err
+			}
+		}
+		info.BlockTime = blockTime
+		info.ConfirmationStatus = "confirmed"
+		if roots[as.Slot] {
+			info.ConfirmationStatus = "finalized"
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// transactionPosition returns the position of a transaction given by
+// signature, or nil if it is not in the blockstore.
+func (s *Server) transactionPosition(sigStr string) (*blockstore.TxPosition, error) {
+	sig, err := solana.SignatureFromBase58(sigStr)
+	if err != nil {
+		return nil, invalidParams("Invalid param: %s", err)
+	}
+	found, err := s.findTransaction(sig)
+	if err != nil || found == nil {
+		return nil, err
+	}
+	return &blockstore.TxPosition{Slot: found.slot, Index: uint32(found.index)}, nil
+}
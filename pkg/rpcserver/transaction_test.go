@@ -0,0 +1,170 @@
+package rpcserver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// newTestTransaction returns a transfer of the fee payer {2} with the given
+// signature.
+func newTestTransaction(sig byte) solana.Transaction {
+	return solana.Transaction{
+		Signatures: []solana.Signature{{sig}},
+		Message: solana.Message{
+			Header:          solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+			AccountKeys:     []solana.PublicKey{{2}, {3}, {4}},
+			RecentBlockhash: solana.Hash{5},
+			Instructions:    []solana.CompiledInstruction{{ProgramIDIndex: 2, Accounts: []uint16{0, 1}}},
+		},
+	}
+}
+
+// newTestLedger returns a blockstore of the rooted slots 5 and 6, and of
+// the unrooted slots 4 and 7, where the transaction 3 was recorded in both
+// slot 4 and 6, and the transaction 4 has no status.
+func newTestLedger() *memBlockstore {
+	db := newMemBlockstore()
+	txs := make(map[byte]solana.Transaction)
+	for sig := byte(1); sig <= 5; sig++ {
+		txs[sig] = newTestTransaction(sig)
+	}
+	db.addBlock(4, 3, solana.Hash{4}, txs[3])
+	db.addBlock(5, 4, solana.Hash{5}, txs[1], txs[2])
+	db.addBlock(6, 5, solana.Hash{6}, txs[3], txs[4])
+	db.addBlock(7, 6, solana.Hash{7}, txs[5])
+	for key, fee := range map[txKey]uint64{
+		{solana.Signature{3}, 4}: 1,
+		{solana.Signature{1}, 5}: 2,
+		{solana.Signature{2}, 5}: 3,
+		{solana.Signature{3}, 6}: 4,
+		{solana.Signature{5}, 7}: 5,
+	} {
+		db.statuses[key] = &sealevel.TransactionStatusMeta{Fee: fee, PreBalances: []uint64{10, 0, 1}, PostBalances: []uint64{10 - fee, 0, 1}}
+	}
+	db.statuses[txKey{solana.Signature{2}, 5}].Err = sealevel.TxErrAccountInUse
+	db.roots[5] = true
+	db.roots[6] = true
+	db.times[5] = 100
+	db.times[6] = 200
+	return db
+}
+
+func TestGetTransaction(t *testing.T) {
+	s := NewServer(newTestLedger(), nil)
+
+	result, rpcErr := call(t, s, "getTransaction", `["`+solana.Signature{3}.String()+`", {"maxSupportedTransactionVersion":0}]`)
+	require.Nil(t, rpcErr)
+	assert.JSONEq(t, `{
+		"slot":6,
+		"transaction":{
+			"signatures":["`+solana.Signature{3}.String()+`"],
+			"message":{
+				"header":{"numRequiredSignatures":1,"numReadonlySignedAccounts":0,"numReadonlyUnsignedAccounts":1},
+				"accountKeys":["`+solana.PublicKey{2}.String()+`","`+solana.PublicKey{3}.String()+`","`+solana.PublicKey{4}.String()+`"],
+				"recentBlockhash":"`+solana.Hash{5}.String()+`",
+				"instructions":[{"programIdIndex":2,"accounts":[0,1],"data":""}]
+			}
+		},
+		"meta":{
+			"err":null,
+			"status":{"Ok":null},
+			"fee":4,
+			"preBalances":[10,0,1],
+			"postBalances":[6,0,1],
+			"innerInstructions":[],
+			"logMessages":[],
+			"preTokenBalances":[],
+			"postTokenBalances":[],
+			"rewards":[],
+			"loadedAddresses":{"writable":[],"readonly":[]},
+			"returnData":null,
+			"computeUnitsConsumed":0
+		},
+		"version":"legacy",
+		"blockTime":200
+	}`, string(result))
+
+	result, rpcErr = call(t, s, "getTransaction", `["`+solana.Signature{5}.String()+`", "base64"]`)
+	require.Nil(t, rpcErr)
+	var tx struct {
+		Slot        uint64          `json:"slot"`
+		Transaction []string        `json:"transaction"`
+		Meta        json.RawMessage `json:"meta"`
+		Version     json.RawMessage `json:"version"`
+		BlockTime   *int64          `json:"blockTime"`
+	}
+	require.NoError(t, json.Unmarshal(result, &tx))
+	assert.Equal(t, uint64(7), tx.Slot)
+	assert.Equal(t, "base64", tx.Transaction[1])
+	assert.Nil(t, tx.Version)
+	assert.Nil(t, tx.BlockTime)
+
+	result, rpcErr = call(t, s, "getTransaction", `["`+solana.Signature{4}.String()+`"]`)
+	require.Nil(t, rpcErr)
+	assert.Equal(t, "null", string(result))
+	_, rpcErr = call(t, s, "getTransaction", `["abc"]`)
+	assert.Equal(t, ErrCodeInvalidParams, rpcErr.Code)
+}
+
+func TestGetSignaturesForAddress(t *testing.T) {
+	s := NewServer(newTestLedger(), nil)
+	addr := solana.PublicKey{2}.String()
+	sigs := func(config string) []string {
+		result, rpcErr := call(t, s, "getSignaturesForAddress", `["`+addr+`", `+config+`]`)
+		require.Nil(t, rpcErr)
+		var infos []struct {
+			Signature solana.Signature `json:"signature"`
+			Slot      uint64           `json:"slot"`
+		}
+		require.NoError(t, json.Unmarshal(result, &infos))
+		var out []string
+		for _, info := range infos {
+			out = append(out, string(rune('0'+info.Signature[0]))+"@"+string(rune('0'+info.Slot)))
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"5@7", "3@6", "2@5", "1@5", "3@4"}, sigs(`{}`))
+	assert.Equal(t, []string{"5@7", "3@6"}, sigs(`{"limit":2}`))
+	assert.Equal(t, []string{"2@5", "1@5", "3@4"}, sigs(`{"before":"`+solana.Signature{3}.String()+`"}`))
+	assert.Equal(t, []string{"5@7", "3@6", "2@5"}, sigs(`{"until":"`+solana.Signature{1}.String()+`"}`))
+	assert.Equal(t, []string{"2@5"}, sigs(`{"before":"`+solana.Signature{3}.String()+`","until":"`+solana.Signature{1}.String()+`"}`))
+	assert.Empty(t, sigs(`{"before":"`+solana.Signature{9}.String()+`"}`))
+	assert.Equal(t, []string{"5@7", "3@6", "2@5", "1@5", "3@4"}, sigs(`{"until":"`+solana.Signature{9}.String()+`"}`))
+
+	result, rpcErr := call(t, s, "getSignaturesForAddress", `["`+addr+`", {"before":"`+solana.Signature{3}.String()+`","limit":1}]`)
+	require.Nil(t, rpcErr)
+	assert.JSONEq(t, `[{
+		"signature":"`+solana.Signature{2}.String()+`",
+		"slot":5,
+		"err":"AccountInUse",
+		"memo":null,
+		"blockTime":100,
+		"confirmationStatus":"finalized"
+	}]`, string(result))
+	result, rpcErr = call(t, s, "getSignaturesForAddress", `["`+addr+`", {"limit":1}]`)
+	require.Nil(t, rpcErr)
+	assert.JSONEq(t, `[{
+		"signature":"`+solana.Signature{5}.String()+`",
+		"slot":7,
+		"err":null,
+		"memo":null,
+		"blockTime":null,
+		"confirmationStatus":"confirmed"
+	}]`, string(result))
+
+	for _, params := range []string{
+		`["` + addr + `", {"limit":0}]`,
+		`["` + addr + `", {"limit":1001}]`,
+		`["abc"]`,
+		`["` + addr + `", {"before":"abc"}]`,
+	} {
+		_, rpcErr = call(t, s, "getSignaturesForAddress", params)
+		assert.Equal(t, ErrCodeInvalidParams, rpcErr.Code, params)
+	}
+}
@@ -0,0 +1,55 @@
+package rpcserver
+
+import (
+	"errors"
+	"strings"
+
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// TransactionError returns a transaction error in its RPC JSON form,
+// e.g. {"InstructionError":[0,{"Custom":1}]}, or nil for success.
+func TransactionError(err error) interface{} {
+	if err == nil {
+		return nil
+	}
+	var instrErr sealevel.TxErrInstructionError
+	if errors.As(err, &instrErr) {
+		return map[string]interface{}{"InstructionError": []interface{}{instrErr.Index, instructionError(instrErr.Err)}}
+	}
+	var rentErr sealevel.TxErrInsufficientFundsForRent
+	if errors.As(err, &rentErr) {
+		return map[string]interface{}{"InsufficientFundsForRent": map[string]interface{}{"account_index": rentErr.AccountIndex}}
+	}
+	var restrictedErr sealevel.TxErrProgramExecutionTemporarilyRestricted
+	if errors.As(err, &restrictedErr) {
+		return map[string]interface{}{"ProgramExecutionTemporarilyRestricted": map[string]interface{}{"account_index": restrictedErr.AccountIndex}}
+	}
+	if err == sealevel.TxErrDuplicateInstruction {
+		// the index of the duplicate instruction is not tracked
+		return map[string]interface{}{"DuplicateInstruction": 0}
+	}
+	return strings.TrimPrefix(err.Error(), "TxErr")
+}
+
+// instructionError returns an instruction error in its RPC JSON form.
+func instructionError(err error) interface{} {
+	var custom sealevel.InstrErrCustom
+	if errors.As(err, &custom) {
+		return map[string]interface{}{"Custom": custom.Code}
+	}
+	if err == sealevel.InstrErrBorshIoError {
+		// the message of the error is not tracked
+		return map[string]interface{}{"BorshIoError": ""}
+	}
+	return strings.TrimPrefix(err.Error(), "InstrErr")
+}
+
+// transactionStatus returns the status of a transaction in its RPC JSON
+// form, {"Ok":null} or {"Err":...}.
+func transactionStatus(err error) map[string]interface{} {
+	if err == nil {
+		return map[string]interface{}{"Ok": nil}
+	}
+	return map[string]interface{}{"Err": TransactionError(err)}
+}
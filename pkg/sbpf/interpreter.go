@@ -1,10 +1,10 @@
 package sbpf
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math"
 	"math/bits"
-	"unsafe"
 
 	"go.firedancer.io/radiance/pkg/cu"
 	"go.firedancer.io/radiance/pkg/global"
@@ -12,15 +12,14 @@ import (
 
 // Interpreter implements the SBF core in pure Go.
 type Interpreter struct {
-	textVA uint64
-	text   []byte
-	ro     []byte
-	stack  Stack
-	heap   []byte
-	input  []byte
+	textVA  uint64
+	text    []byte
+	version SBPFVersion
+	stack   Stack
+	mem     *MemoryMapping
 
 	entry uint64
-	cuMax int
+	meter *cu.ComputeMeter
 
 	syscalls  map[uint32]Syscall
 	funcs     map[uint32]int64
@@ -38,15 +37,34 @@ type TraceSink interface {
 // The caller must create a new interpreter object for every new execution.
 // In other words, Run may only be called once per interpreter.
 func NewInterpreter(globalCtx *global.GlobalCtx, p *Program, opts *VMOpts) *Interpreter {
+	meter := opts.ComputeMeter
+	if meter == nil {
+		m := cu.NewComputeMeter(uint64(opts.MaxCU))
+		meter = &m
+	}
+	stack := NewStack(p.Version.DynamicStackFrames())
+	regions := []MemoryRegion{
+		{VAddr: VaddrProgram, Data: p.RO},
+		stack.region(),
+		{VAddr: VaddrHeap, Data: make([]byte, opts.HeapSize), Writable: true},
+	}
+	if opts.InputRegions != nil {
+		regions = append(regions, opts.InputRegions...)
+	} else {
+		regions = append(regions, MemoryRegion{VAddr: VaddrInput, Data: opts.Input, Writable: true})
+	}
+	mem, err := NewMemoryMapping(regions...)
+	if err != nil {
+		panic(err) // input regions are well-formed by construction
+	}
 	return &Interpreter{
 		textVA:    p.TextVA,
 		text:      p.Text,
-		ro:        p.RO,
-		stack:     NewStack(),
-		heap:      make([]byte, opts.HeapSize),
-		input:     opts.Input,
+		version:   p.Version,
+		stack:     stack,
+		mem:       mem,
 		entry:     p.Entrypoint,
-		cuMax:     opts.MaxCU,
+		meter:     meter,
 		syscalls:  opts.Syscalls,
 		funcs:     p.Funcs,
 		vmContext: opts.Context,
@@ -55,16 +73,23 @@ func NewInterpreter(globalCtx *global.GlobalCtx, p *Program, opts *VMOpts) *Inte
 	}
 }
 
-// Run executes the program.
+// Run executes the program and returns the value of r0 on exit.
 //
 // This function may panic given code that doesn't pass the static verifier.
-func (ip *Interpreter) Run() (err error) {
+func (ip *Interpreter) Run() (ret uint64, err error) {
 	var r [11]uint64
 	r[1] = VaddrInput
 	r[10] = ip.stack.GetFramePtr()
-	// TODO frame pointer
-	pc := int64(ip.entry)
+	if _, _, err = ip.run(&r, int64(ip.entry), -1); err != nil {
+		return 0, err
+	}
+	return r[0], nil
+}
 
+// run executes the program from pc with the given registers, for at most
+// steps instructions unless steps is negative. Returns the pc of the next
+// instruction, or whether the program exited.
+func (ip *Interpreter) run(r *[11]uint64, pc int64, steps int) (next int64, exited bool, err error) {
 	// Design notes
 	// - The interpreter is deliberately implemented in a single big loop,
 	//   to give the compiler more creative liberties, and avoid escaping hot data to the heap.
@@ -72,8 +97,12 @@ func (ip *Interpreter) Run() (err error) {
 	// - The static verifier imposes invariants on the bytecode.
 	//   The interpreter may panic when it notices these invariants are violated (e.g. invalid opcode)
 
-mainLoop:
-	for i := 0; true; i++ {
+	for i := 0; steps < 0 || i < steps; i++ {
+		// Meter
+		if ip.meter.Consume(1) != nil {
+			return pc, false, &Exception{PC: pc, Detail: ExcOutOfCU}
+		}
+
 		// Fetch
 		ins := ip.getSlot(pc)
 		if ip.trace != nil {
@@ -129,7 +158,12 @@ mainLoop:
 		case OpAdd32Reg:
 			r[ins.Dst()] = uint64(int32(r[ins.Dst()]) + int32(r[ins.Src()]))
 		case OpAdd64Imm:
-			r[ins.Dst()] += uint64(ins.Imm())
+			if ins.Dst() == 10 && ip.version.DynamicStackFrames() {
+				// r10 is read-only; this moves the stack pointer instead
+				ip.stack.AdjustStackPtr(int64(ins.Imm()))
+			} else {
+				r[ins.Dst()] += uint64(ins.Imm())
+			}
 		case OpAdd64Reg:
 			r[ins.Dst()] += r[ins.Src()]
 		case OpSub32Imm:
@@ -154,7 +188,7 @@ mainLoop:
 			if src := uint32(r[ins.Src()]); src != 0 {
 				r[ins.Dst()] = uint64(uint32(r[ins.Dst()]) / src)
 			} else {
-				return ExcDivideByZero
+				err = ExcDivideByZero
 			}
 		case OpDiv64Imm:
 			r[ins.Dst()] /= uint64(ins.Imm())
@@ -388,31 +422,35 @@ mainLoop:
 			if sc, ok := ip.syscalls[ins.Uimm()]; ok {
 				r[0], err = sc.Invoke(ip, r[1], r[2], r[3], r[4], r[5])
 			} else if target, ok := ip.funcs[ins.Uimm()]; ok {
-				r[10], ok = ip.stack.Push((*[4]uint64)(r[6:10]), pc+1)
-				if !ok {
+				if r[10], ok = ip.stack.Push((*[4]uint64)(r[6:10]), r[10], pc+1); ok {
+					pc = target - 1
+				} else {
 					err = ExcCallDepth
 				}
-				pc = target - 1
 			} else {
 				err = ExcCallDest{ins.Uimm()}
 			}
 		case OpCallx:
-			target := r[ins.Uimm()]
+			var target uint64
+			if ip.version.CallxUsesSrcReg() {
+				target = r[ins.Src()]
+			} else {
+				target = r[ins.Uimm()]
+			}
 			target &= ^(uint64(0x7))
 			var ok bool
-			r[10], ok = ip.stack.Push((*[4]uint64)(r[6:10]), pc+1)
-			if !ok {
+			if r[10], ok = ip.stack.Push((*[4]uint64)(r[6:10]), r[10], pc+1); !ok {
 				err = ExcCallDepth
-			}
-			if target < ip.textVA || target >= VaddrStack || target >= ip.textVA+uint64(len(ip.text)) {
+			} else if target < ip.textVA || target >= VaddrStack || target >= ip.textVA+uint64(len(ip.text)) {
 				err = NewExcBadAccess(target, 8, false, "jump out-of-bounds")
+			} else {
+				pc = int64((target-ip.textVA)/8) - 1
 			}
-			pc = int64((target-ip.textVA)/8) - 1
 		case OpExit:
 			var ok bool
 			r[10], pc, ok = ip.stack.Pop((*[4]uint64)(r[6:10]))
 			if !ok {
-				break mainLoop
+				return pc, true, nil
 			}
 			pc--
 		default:
@@ -432,12 +470,11 @@ mainLoop:
 			if IsLongIns(ins.Op()) {
 				exc.PC-- // fix reported PC
 			}
-			return exc
+			return pc, false, exc
 		}
 		pc++
 	}
-
-	return nil
+	return pc, false, nil
 }
 
 func (ip *Interpreter) getSlot(pc int64) Slot {
@@ -452,137 +489,98 @@ func (ip *Interpreter) GlobalCtx() *global.GlobalCtx {
 	return ip.globalCtx
 }
 
-func (ip *Interpreter) translateInternal(addr uint64, size uint64, write bool) (unsafe.Pointer, error) {
-	// TODO exhaustive testing against rbpf
-	// TODO review generated asm for performance
-
-	hi, lo := addr>>32, addr&math.MaxUint32
-	switch hi {
-	case VaddrProgram >> 32:
-		if write {
-			return nil, NewExcBadAccess(addr, size, write, "write to program")
-		}
-		if lo+uint64(size) >= uint64(len(ip.ro)) {
-			return nil, NewExcBadAccess(addr, size, write, "out-of-bounds program read")
-		}
-		return unsafe.Pointer(&ip.ro[lo]), nil
-	case VaddrStack >> 32:
-		mem := ip.stack.GetFrame(uint32(addr))
-		if uint64(len(mem)) < size {
-			return nil, NewExcBadAccess(addr, size, write, "out-of-bounds stack access")
-		}
-		return unsafe.Pointer(&mem[0]), nil
-	case VaddrHeap >> 32:
-		if lo+uint64(size) >= uint64(len(ip.heap)) {
-			return nil, NewExcBadAccess(addr, size, write, "out-of-bounds heap access")
-		}
-		return unsafe.Pointer(&ip.heap[lo]), nil
-	case VaddrInput >> 32:
-		if lo+uint64(size) >= uint64(len(ip.input)) {
-			return nil, NewExcBadAccess(addr, size, write, "out-of-bounds input access")
-		}
-		return unsafe.Pointer(&ip.input[lo]), nil
-	default:
-		return nil, NewExcBadAccess(addr, size, write, "unmapped region")
-	}
-}
-
+// Translate returns the host memory backing a VM address range.
 func (ip *Interpreter) Translate(addr uint64, size uint64, write bool) ([]byte, error) {
-	ptr, err := ip.translateInternal(addr, size, write)
-	if err != nil {
-		return nil, err
-	}
+	return ip.mem.Translate(addr, size, write)
+}
 
-	mem := unsafe.Slice((*uint8)(ptr), size)
-	return mem, nil
+// Region returns the memory region containing a VM address.
+func (ip *Interpreter) Region(addr uint64) (*MemoryRegion, error) {
+	return ip.mem.Region(addr)
 }
 
 func (ip *Interpreter) Read(addr uint64, p []byte) error {
-	ptr, err := ip.translateInternal(addr, uint64(len(p)), false)
+	mem, err := ip.mem.Translate(addr, uint64(len(p)), false)
 	if err != nil {
 		return err
 	}
-	mem := unsafe.Slice((*uint8)(ptr), len(p))
 	copy(p, mem)
 	return nil
 }
 
 func (ip *Interpreter) Read8(addr uint64) (uint8, error) {
-	ptr, err := ip.translateInternal(addr, 1, false)
+	mem, err := ip.mem.Translate(addr, 1, false)
 	if err != nil {
 		return 0, err
 	}
-	return *(*uint8)(ptr), nil
+	return mem[0], nil
 }
 
-// TODO is it safe and portable to deref unaligned integer types?
-
 func (ip *Interpreter) Read16(addr uint64) (uint16, error) {
-	ptr, err := ip.translateInternal(addr, 2, false)
+	mem, err := ip.mem.Translate(addr, 2, false)
 	if err != nil {
 		return 0, err
 	}
-	return *(*uint16)(ptr), nil
+	return binary.LittleEndian.Uint16(mem), nil
 }
 
 func (ip *Interpreter) Read32(addr uint64) (uint32, error) {
-	ptr, err := ip.translateInternal(addr, 4, false)
+	mem, err := ip.mem.Translate(addr, 4, false)
 	if err != nil {
 		return 0, err
 	}
-	return *(*uint32)(ptr), nil
+	return binary.LittleEndian.Uint32(mem), nil
 }
 
 func (ip *Interpreter) Read64(addr uint64) (uint64, error) {
-	ptr, err := ip.translateInternal(addr, 8, false)
+	mem, err := ip.mem.Translate(addr, 8, false)
 	if err != nil {
 		return 0, err
 	}
-	return *(*uint64)(ptr), nil
+	return binary.LittleEndian.Uint64(mem), nil
 }
 
 func (ip *Interpreter) Write(addr uint64, p []byte) error {
-	ptr, err := ip.translateInternal(addr, uint64(len(p)), true)
+	mem, err := ip.mem.Translate(addr, uint64(len(p)), true)
 	if err != nil {
 		return err
 	}
-	mem := unsafe.Slice((*uint8)(ptr), len(p))
 	copy(mem, p)
 	return nil
 }
 
 func (ip *Interpreter) Write8(addr uint64, x uint8) error {
-	ptr, err := ip.translateInternal(addr, 1, true)
+	mem, err := ip.mem.Translate(addr, 1, true)
 	if err != nil {
 		return err
 	}
-	*(*uint8)(ptr) = x
+	mem[0] = x
 	return nil
 }
 
 func (ip *Interpreter) Write16(addr uint64, x uint16) error {
-	ptr, err := ip.translateInternal(addr, 2, true)
+	mem, err := ip.mem.Translate(addr, 2, true)
 	if err != nil {
 		return err
 	}
-	*(*uint16)(ptr) = x
+	binary.LittleEndian.PutUint16(mem, x)
 	return nil
 }
 
 func (ip *Interpreter) Write32(addr uint64, x uint32) error {
-	ptr, err := ip.translateInternal(addr, 4, true)
+	mem, err := ip.mem.Translate(addr, 4, true)
 	if err != nil {
 		return err
 	}
-	*(*uint32)(ptr) = x
+	binary.LittleEndian.PutUint32(mem, x)
 	return nil
 }
 
 func (ip *Interpreter) Write64(addr uint64, x uint64) error {
-	ptr, err := ip.translateInternal(addr, 8, false)
+	mem, err := ip.mem.Translate(addr, 8, true)
 	if err != nil {
 		return err
 	}
-	*(*uint64)(ptr) = x
+	binary.LittleEndian.PutUint64(mem, x)
 	return nil
 }
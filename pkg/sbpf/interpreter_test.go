@@ -0,0 +1,65 @@
+package sbpf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runProgram(t *testing.T, version SBPFVersion, funcs map[uint32]int64, slots ...Slot) (uint64, error) {
+	program := &Program{
+		Text:    makeText(slots...),
+		TextVA:  VaddrProgram,
+		Funcs:   funcs,
+		Version: version,
+	}
+	return NewInterpreter(nil, program, &VMOpts{MaxCU: 10_000}).Run()
+}
+
+func TestInterpreter_CallFixedFrames(t *testing.T) {
+	ret, err := runProgram(t, SBPFVersionV1, map[uint32]int64{1: 3},
+		makeSlot(OpMov64Reg, 6, 10, 0, 0),
+		makeSlot(OpCall, 0, 0, 0, 1),
+		makeSlot(OpExit, 0, 0, 0, 0),
+		// callee: r0 = own frame pointer - caller's frame pointer
+		makeSlot(OpMov64Reg, 0, 10, 0, 0),
+		makeSlot(OpSub64Reg, 0, 6, 0, 0),
+		makeSlot(OpExit, 0, 0, 0, 0),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2*StackFrameSize), ret)
+}
+
+func TestInterpreter_CallDynamicFrames(t *testing.T) {
+	ret, err := runProgram(t, SBPFVersionV2, map[uint32]int64{1: 6},
+		// allocate 64 bytes; moves the stack pointer but not r10
+		makeSlot(OpAdd64Imm, 10, 0, 0, -64),
+		makeSlot(OpMov64Reg, 6, 10, 0, 0),
+		makeSlot(OpCall, 0, 0, 0, 1),
+		// r10 is restored on return
+		makeSlot(OpAdd64Reg, 0, 10, 0, 0),
+		makeSlot(OpSub64Reg, 0, 6, 0, 0),
+		makeSlot(OpExit, 0, 0, 0, 0),
+		// callee: r0 = caller's frame pointer - own frame pointer
+		makeSlot(OpMov64Reg, 0, 6, 0, 0),
+		makeSlot(OpSub64Reg, 0, 10, 0, 0),
+		makeSlot(OpExit, 0, 0, 0, 0),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(64), ret)
+}
+
+func TestInterpreter_CallDepthExceeded(t *testing.T) {
+	// infinite recursion
+	_, err := runProgram(t, SBPFVersionV1, map[uint32]int64{1: 0},
+		makeSlot(OpCall, 0, 0, 0, 1),
+		makeSlot(OpExit, 0, 0, 0, 0),
+	)
+	assert.True(t, errors.Is(err, ExcCallDepth), "expected ExcCallDepth, got %v", err)
+
+	var exc *Exception
+	require.True(t, errors.As(err, &exc))
+	assert.Equal(t, int64(0), exc.PC)
+}
@@ -0,0 +1,760 @@
+//go:build sbpfjit
+
+package sbpf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"unsafe"
+)
+
+// x86-64 general purpose registers.
+const (
+	rax = uint8(iota)
+	rcx
+	rdx
+	rbx
+	rsp
+	rbp
+	rsi
+	rdi
+	r8
+	r9
+	r10
+	r11
+	r12
+	r13
+	r14
+	r15
+)
+
+// jitRegs assigns a host register to each SBF register. RAX, RCX and RDX
+// are scratch registers, and RBP holds the jitContext. jitCall loads them
+// in this order.
+var jitRegs = [11]uint8{rsi, rdi, r8, r9, r10, r11, rbx, r12, r13, r14, r15}
+
+// x86-64 condition codes.
+const (
+	ccB  = 0x2
+	ccAE = 0x3
+	ccE  = 0x4
+	ccNE = 0x5
+	ccBE = 0x6
+	ccA  = 0x7
+	ccL  = 0xc
+	ccGE = 0xd
+	ccLE = 0xe
+	ccG  = 0xf
+)
+
+// Offsets of the jitContext fields addressed by native code.
+var (
+	jitOffPC     = int32(unsafe.Offsetof(jitContext{}.pc))
+	jitOffBudget = int32(unsafe.Offsetof(jitContext{}.budget))
+	jitOffAreas  = int32(unsafe.Offsetof(jitContext{}.areas))
+)
+
+// Flags of a jitArea.
+const (
+	jitAreaWritable = 1 << iota
+	jitAreaGapped
+)
+
+// x86Asm assembles x86-64 machine code.
+type x86Asm struct {
+	buf    []byte
+	labels []int // offset of each label, or -1 if unbound
+	fixups []x86Fixup
+}
+
+// x86Fixup is a rel32 operand to patch with the offset of a label.
+type x86Fixup struct {
+	pos   int
+	label int
+}
+
+func (a *x86Asm) newLabel() int {
+	a.labels = append(a.labels, -1)
+	return len(a.labels) - 1
+}
+
+func (a *x86Asm) bind(label int) {
+	a.labels[label] = len(a.buf)
+}
+
+// link patches the rel32 operands of jumps and calls.
+func (a *x86Asm) link() error {
+	for _, f := range a.fixups {
+		target := a.labels[f.label]
+		if target < 0 {
+			return fmt.Errorf("unbound label %d", f.label)
+		}
+		binary.LittleEndian.PutUint32(a.buf[f.pos:], uint32(int32(target-(f.pos+4))))
+	}
+	return nil
+}
+
+func (a *x86Asm) emit(b ...byte) {
+	a.buf = append(a.buf, b...)
+}
+
+func (a *x86Asm) emit16(x uint16) {
+	a.buf = binary.LittleEndian.AppendUint16(a.buf, x)
+}
+
+func (a *x86Asm) emit32(x uint32) {
+	a.buf = binary.LittleEndian.AppendUint32(a.buf, x)
+}
+
+func (a *x86Asm) emit64(x uint64) {
+	a.buf = binary.LittleEndian.AppendUint64(a.buf, x)
+}
+
+func (a *x86Asm) rel32(label int) {
+	a.fixups = append(a.fixups, x86Fixup{pos: len(a.buf), label: label})
+	a.emit32(0)
+}
+
+// rex emits a REX prefix, if one is needed. Byte operands of SPL, BPL,
+// SIL and DIL always need one.
+func (a *x86Asm) rex(w bool, reg, base uint8, byteOp bool) {
+	rex := byte(0x40)
+	if w {
+		rex |= 0x08
+	}
+	rex |= (reg >> 3) << 2
+	rex |= base >> 3
+	if rex != 0x40 || (byteOp && reg >= 4) {
+		a.emit(rex)
+	}
+}
+
+// opReg emits an instruction with a register operand in ModRM.rm.
+func (a *x86Asm) opReg(w bool, op []byte, reg, rm uint8) {
+	a.rex(w, reg, rm, false)
+	a.emit(op...)
+	a.emit(0xc0 | (reg&7)<<3 | rm&7)
+}
+
+// opMem emits an instruction with a [base+disp32] operand. base must not
+// be RSP or R12.
+func (a *x86Asm) opMem(w bool, byteOp bool, op []byte, reg, base uint8, disp int32) {
+	a.rex(w, reg, base, byteOp)
+	a.emit(op...)
+	a.emit(0x80 | (reg&7)<<3 | base&7)
+	a.emit32(uint32(disp))
+}
+
+func (a *x86Asm) movRR(w bool, dst, src uint8) { a.opReg(w, []byte{0x89}, src, dst) }
+
+// aluRR emits an ALU operation with a register source, given its
+// r/m, reg opcode: ADD 0x01, OR 0x09, AND 0x21, SUB 0x29, XOR 0x31, CMP
+// 0x39 or TEST 0x85.
+func (a *x86Asm) aluRR(w bool, op byte, dst, src uint8) { a.opReg(w, []byte{op}, src, dst) }
+
+// aluRI emits an ALU operation with an immediate, given its opcode
+// extension: ADD 0, OR 1, AND 4, SUB 5, XOR 6 or CMP 7.
+func (a *x86Asm) aluRI(w bool, ext uint8, dst uint8, imm int32) {
+	a.opReg(w, []byte{0x81}, ext, dst)
+	a.emit32(uint32(imm))
+}
+
+// aluMI emits an ALU operation on a qword in memory with an immediate.
+func (a *x86Asm) aluMI(ext uint8, base uint8, disp int32, imm int32) {
+	a.opMem(true, false, []byte{0x81}, ext, base, disp)
+	a.emit32(uint32(imm))
+}
+
+func (a *x86Asm) testRI(w bool, dst uint8, imm int32) {
+	a.opReg(w, []byte{0xf7}, 0, dst)
+	a.emit32(uint32(imm))
+}
+
+// movRI32 loads an immediate zero-extended to 64 bits.
+func (a *x86Asm) movRI32(dst uint8, imm uint32) {
+	a.rex(false, 0, dst, false)
+	a.emit(0xb8 + dst&7)
+	a.emit32(imm)
+}
+
+// movRI64 loads a sign-extended 32-bit immediate.
+func (a *x86Asm) movRI64(dst uint8, imm int32) {
+	a.opReg(true, []byte{0xc7}, 0, dst)
+	a.emit32(uint32(imm))
+}
+
+// movabs loads a 64-bit immediate.
+func (a *x86Asm) movabs(dst uint8, imm uint64) {
+	a.rex(true, 0, dst, false)
+	a.emit(0xb8 + dst&7)
+	a.emit64(imm)
+}
+
+func (a *x86Asm) imulRR(w bool, dst, src uint8) { a.opReg(w, []byte{0x0f, 0xaf}, dst, src) }
+
+func (a *x86Asm) imulRRI(w bool, dst, src uint8, imm int32) {
+	a.opReg(w, []byte{0x69}, dst, src)
+	a.emit32(uint32(imm))
+}
+
+// unary emits a group 3 operation, given its opcode extension: NEG 3 or
+// DIV 6.
+func (a *x86Asm) unary(w bool, ext uint8, rm uint8) { a.opReg(w, []byte{0xf7}, ext, rm) }
+
+// shiftCL and shiftRI emit a shift, given its opcode extension: ROL 0,
+// SHL 4, SHR 5 or SAR 7.
+func (a *x86Asm) shiftCL(w bool, ext uint8, rm uint8) { a.opReg(w, []byte{0xd3}, ext, rm) }
+
+func (a *x86Asm) shiftRI(w bool, ext uint8, rm uint8, imm uint8) {
+	a.opReg(w, []byte{0xc1}, ext, rm)
+	a.emit(imm)
+}
+
+// movsxd sign-extends the low 32 bits of src into dst.
+func (a *x86Asm) movsxd(dst, src uint8) { a.opReg(true, []byte{0x63}, dst, src) }
+
+// movzx16 zero-extends the low 16 bits of src into dst.
+func (a *x86Asm) movzx16(dst, src uint8) { a.opReg(false, []byte{0x0f, 0xb7}, dst, src) }
+
+func (a *x86Asm) rol16(rm uint8) {
+	a.emit(0x66)
+	a.shiftRI(false, 0, rm, 8)
+}
+
+func (a *x86Asm) bswap(w bool, r uint8) {
+	a.rex(w, 0, r, false)
+	a.emit(0x0f, 0xc8+r&7)
+}
+
+// load zero-extends size bytes at [base+disp] into dst.
+func (a *x86Asm) load(size int, dst, base uint8, disp int32) {
+	switch size {
+	case 1:
+		a.opMem(false, false, []byte{0x0f, 0xb6}, dst, base, disp)
+	case 2:
+		a.opMem(false, false, []byte{0x0f, 0xb7}, dst, base, disp)
+	case 4:
+		a.opMem(false, false, []byte{0x8b}, dst, base, disp)
+	case 8:
+		a.opMem(true, false, []byte{0x8b}, dst, base, disp)
+	}
+}
+
+// store stores the low size bytes of src at [base+disp].
+func (a *x86Asm) store(size int, src, base uint8, disp int32) {
+	switch size {
+	case 1:
+		a.opMem(false, true, []byte{0x88}, src, base, disp)
+	case 2:
+		a.emit(0x66)
+		a.opMem(false, false, []byte{0x89}, src, base, disp)
+	case 4:
+		a.opMem(false, false, []byte{0x89}, src, base, disp)
+	case 8:
+		a.opMem(true, false, []byte{0x89}, src, base, disp)
+	}
+}
+
+// storeImm stores an immediate of size bytes at [base+disp]; a qword is
+// sign-extended from 32 bits.
+func (a *x86Asm) storeImm(size int, base uint8, disp int32, imm uint32) {
+	switch size {
+	case 1:
+		a.opMem(false, false, []byte{0xc6}, 0, base, disp)
+		a.emit(byte(imm))
+	case 2:
+		a.emit(0x66)
+		a.opMem(false, false, []byte{0xc7}, 0, base, disp)
+		a.emit16(uint16(imm))
+	case 4:
+		a.opMem(false, false, []byte{0xc7}, 0, base, disp)
+		a.emit32(imm)
+	case 8:
+		a.opMem(true, false, []byte{0xc7}, 0, base, disp)
+		a.emit32(imm)
+	}
+}
+
+func (a *x86Asm) jcc(cc uint8, label int) {
+	a.emit(0x0f, 0x80+cc)
+	a.rel32(label)
+}
+
+func (a *x86Asm) jmp(label int) {
+	a.emit(0xe9)
+	a.rel32(label)
+}
+
+func (a *x86Asm) call(label int) {
+	a.emit(0xe8)
+	a.rel32(label)
+}
+
+func (a *x86Asm) ret() { a.emit(0xc3) }
+
+// jitStub is an exit to the interpreter at pc, refunding the compute
+// units prepaid for the instructions of the block from pc on.
+type jitStub struct {
+	label  int
+	pc     int64
+	refund int32
+}
+
+// jitCompiler translates a program to x86-64 machine code.
+//
+// The text is split into basic blocks at jump targets, function entries
+// and after control transfers. Each block checks the compute budget once
+// and prepays the compute units of all its instructions. Instructions
+// that may not complete natively (syscalls, calls, exits, faulting memory
+// accesses and divisions) exit to the interpreter, which executes them
+// one at a time until execution reaches the start of a block again. If
+// the budget does not cover a block, it is interpreted as well, so the
+// compute units consumed and the exceptions raised match the
+// interpreter's.
+type jitCompiler struct {
+	a    x86Asm
+	text []byte
+
+	leaders []bool
+	labels  []int // label of each leader
+	entries []int32
+	stubs   []jitStub
+
+	exit      int
+	translate [4][2]int // by log2 size and write
+}
+
+// compileJIT compiles a verified program.
+func compileJIT(p *Program) (code []byte, entries []int32, err error) {
+	c := &jitCompiler{text: p.Text}
+	numSlots := int64(len(p.Text) / SlotSize)
+	c.leaders = make([]bool, numSlots+1)
+	c.labels = make([]int, numSlots+1)
+	c.entries = make([]int32, numSlots)
+	c.exit = c.a.newLabel()
+	for i := range c.translate {
+		c.translate[i] = [2]int{c.a.newLabel(), c.a.newLabel()}
+	}
+	c.findLeaders(p, numSlots)
+	for pc := range c.labels {
+		c.labels[pc] = c.a.newLabel()
+	}
+
+	for pc := int64(0); pc < numSlots; pc++ {
+		c.entries[pc] = -1
+	}
+	for pc := int64(0); pc < numSlots; {
+		pc = c.compileBlock(pc, numSlots)
+	}
+	// falling off the end of the text is left to the interpreter
+	c.a.bind(c.labels[numSlots])
+	c.exitTo(numSlots)
+
+	for _, stub := range c.stubs {
+		c.a.bind(stub.label)
+		if stub.refund != 0 {
+			c.a.aluMI(0, rbp, jitOffBudget, stub.refund)
+		}
+		c.exitTo(stub.pc)
+	}
+	c.emitExit()
+	for log2Size := range c.translate {
+		for write := range c.translate[log2Size] {
+			c.emitTranslate(1<<log2Size, write == 1)
+		}
+	}
+	if err := c.a.link(); err != nil {
+		return nil, nil, err
+	}
+	return c.a.buf, c.entries, nil
+}
+
+// jitNative returns whether an instruction is compiled to native code,
+// rather than executed by the interpreter.
+func (c *jitCompiler) jitNative(ins Slot) bool {
+	switch ins.Op() {
+	case OpCall, OpCallx, OpExit,
+		OpSdiv32Imm, OpSdiv32Reg, OpSdiv64Imm, OpSdiv64Reg:
+		return false
+	case OpAdd64Imm:
+		// adjusts the stack pointer with dynamic stack frames
+		return ins.Dst() != 10
+	}
+	return jitOpNative[ins.Op()]
+}
+
+// isJump returns whether an instruction is a jump.
+func isJump(op uint8) bool {
+	return op&0x07 == ClassJmp && op != OpCall && op != OpCallx && op != OpExit
+}
+
+// findLeaders marks the first instruction of each basic block.
+func (c *jitCompiler) findLeaders(p *Program, numSlots int64) {
+	mark := func(pc int64) {
+		if pc >= 0 && pc <= numSlots {
+			c.leaders[pc] = true
+		}
+	}
+	mark(int64(p.Entrypoint))
+	for _, target := range p.Funcs {
+		mark(target)
+	}
+	for pc := int64(0); pc < numSlots; pc++ {
+		ins := GetSlot(c.text[pc*SlotSize:])
+		switch {
+		case IsLongIns(ins.Op()):
+			pc++
+		case isJump(ins.Op()):
+			mark(pc + int64(ins.Off()) + 1)
+			mark(pc + 1)
+		case !c.jitNative(ins):
+			mark(pc)
+			mark(pc + 1)
+		}
+	}
+}
+
+// compileBlock compiles the basic block starting at pc, and returns the
+// pc following it.
+func (c *jitCompiler) compileBlock(pc int64, numSlots int64) int64 {
+	c.a.bind(c.labels[pc])
+	if ins := GetSlot(c.text[pc*SlotSize:]); !c.jitNative(ins) {
+		c.exitTo(pc)
+		return pc + 1
+	}
+
+	// count the instructions of the block, the lddw ones being charged once
+	var count int32
+	end := pc
+	for end < numSlots && (end == pc || !c.leaders[end]) {
+		ins := GetSlot(c.text[end*SlotSize:])
+		count++
+		end++
+		if IsLongIns(ins.Op()) {
+			end++
+		}
+		if isJump(ins.Op()) {
+			break
+		}
+	}
+
+	if c.leaders[pc] {
+		c.entries[pc] = int32(len(c.a.buf))
+	}
+	c.a.aluMI(7, rbp, jitOffBudget, count)
+	c.a.jcc(ccB, c.stub(pc, 0))
+	c.a.aluMI(5, rbp, jitOffBudget, count)
+
+	for i := int32(0); pc < end; i++ {
+		ins := GetSlot(c.text[pc*SlotSize:])
+		c.compileIns(pc, ins, count-i)
+		pc++
+		if IsLongIns(ins.Op()) {
+			pc++
+		}
+	}
+	return end
+}
+
+// stub returns the label of an exit to the interpreter at pc.
+func (c *jitCompiler) stub(pc int64, refund int32) int {
+	stub := jitStub{label: c.a.newLabel(), pc: pc, refund: refund}
+	c.stubs = append(c.stubs, stub)
+	return stub.label
+}
+
+// exitTo stores pc in the context and exits.
+func (c *jitCompiler) exitTo(pc int64) {
+	c.a.opMem(true, false, []byte{0xc7}, 0, rbp, jitOffPC)
+	c.a.emit32(uint32(pc))
+	c.a.jmp(c.exit)
+}
+
+// emitExit emits the return to jitCall, which stores the registers.
+func (c *jitCompiler) emitExit() {
+	c.a.bind(c.exit)
+	for i, reg := range jitRegs {
+		c.a.store(8, reg, rbp, int32(8*i))
+	}
+	c.a.ret()
+}
+
+// emitTranslate emits the translation of the VM address in RAX to the
+// host address of an access of size bytes, or to zero if the access is not
+// within a single region of its area or does not fit in a stack frame.
+// Clobbers RCX and RDX.
+func (c *jitCompiler) emitTranslate(size int, write bool) {
+	a := &c.a
+	fail := a.newLabel()
+	flat := a.newLabel()
+	a.bind(c.translateLabel(size, write))
+
+	// the upper 32 bits of the address select the area
+	a.movRR(true, rdx, rax)
+	a.shiftRI(true, 5, rdx, 32)
+	a.aluRI(true, 7, rdx, int32(len(jitContext{}.areas)-1))
+	a.jcc(ccA, fail)
+	a.shiftRI(true, 4, rdx, 5) // * sizeof(jitArea)
+	a.aluRR(true, 0x01, rdx, rbp)
+	if write {
+		a.opMem(false, false, []byte{0xf7}, 0, rdx, jitOffAreas+16)
+		a.emit32(jitAreaWritable)
+		a.jcc(ccE, fail)
+	}
+	a.movRR(false, rcx, rax)
+	a.opMem(false, false, []byte{0xf7}, 0, rdx, jitOffAreas+16)
+	a.emit32(jitAreaGapped)
+	a.jcc(ccE, flat)
+
+	// skip the gaps between stack frames
+	a.testRI(false, rcx, StackFrameSize)
+	a.jcc(ccNE, fail)
+	a.movRR(false, rax, rcx)
+	a.aluRI(false, 4, rax, StackFrameSize-1)
+	a.aluRI(false, 0, rax, int32(size))
+	a.aluRI(false, 7, rax, StackFrameSize)
+	a.jcc(ccA, fail)
+	a.movRR(false, rax, rcx)
+	a.shiftRI(false, 5, rax, 1)
+	a.aluRI(false, 4, rax, ^int32(StackFrameSize-1))
+	a.aluRI(false, 4, rcx, StackFrameSize-1)
+	a.aluRR(false, 0x09, rcx, rax)
+
+	a.bind(flat)
+	// lea rax, [rcx+size]
+	a.emit(0x48, 0x8d, 0x81)
+	a.emit32(uint32(size))
+	a.opMem(true, false, []byte{0x3b}, rax, rdx, jitOffAreas+8)
+	a.jcc(ccA, fail)
+	a.opMem(true, false, []byte{0x8b}, rax, rdx, jitOffAreas)
+	a.aluRR(true, 0x01, rax, rcx)
+	a.ret()
+
+	a.bind(fail)
+	a.aluRR(false, 0x31, rax, rax)
+	a.ret()
+}
+
+// translateLabel returns the label of the translation of accesses of size
+// bytes.
+func (c *jitCompiler) translateLabel(size int, write bool) int {
+	w := 0
+	if write {
+		w = 1
+	}
+	return c.translate[bits.TrailingZeros(uint(size))][w]
+}
+
+// memAccess emits the translation of the VM address base+off to a host
+// address in RAX, exiting to the interpreter if it faults.
+func (c *jitCompiler) memAccess(pc int64, refund int32, base uint8, off int16, size int, write bool) {
+	c.a.movRR(true, rax, base)
+	c.a.aluRI(true, 0, rax, int32(off))
+	c.a.call(c.translateLabel(size, write))
+	c.a.aluRR(true, 0x85, rax, rax)
+	c.a.jcc(ccE, c.stub(pc, refund))
+}
+
+// divide emits an unsigned division of dst by RCX, keeping the quotient,
+// or the remainder if mod, exiting to the interpreter on division by zero.
+func (c *jitCompiler) divide(pc int64, refund int32, w bool, dst uint8, mod bool, checkZero bool) {
+	a := &c.a
+	if checkZero {
+		a.aluRR(w, 0x85, rcx, rcx)
+		a.jcc(ccE, c.stub(pc, refund))
+	}
+	a.movRR(w, rax, dst)
+	a.aluRR(false, 0x31, rdx, rdx)
+	a.unary(w, 6, rcx)
+	if mod {
+		a.movRR(w, dst, rdx)
+	} else {
+		a.movRR(w, dst, rax)
+	}
+}
+
+// jitOpNative marks the opcodes compileIns supports.
+var jitOpNative = func() (native [0x100]bool) {
+	for _, op := range []uint8{
+		OpLdxb, OpLdxh, OpLdxw, OpLdxdw,
+		OpStb, OpSth, OpStw, OpStdw, OpStxb, OpStxh, OpStxw, OpStxdw,
+		OpAdd32Imm, OpAdd32Reg, OpAdd64Imm, OpAdd64Reg,
+		OpSub32Imm, OpSub32Reg, OpSub64Imm, OpSub64Reg,
+		OpMul32Imm, OpMul32Reg, OpMul64Imm, OpMul64Reg,
+		OpDiv32Imm, OpDiv32Reg, OpDiv64Imm, OpDiv64Reg,
+		OpMod32Imm, OpMod32Reg, OpMod64Imm, OpMod64Reg,
+		OpOr32Imm, OpOr32Reg, OpOr64Imm, OpOr64Reg,
+		OpAnd32Imm, OpAnd32Reg, OpAnd64Imm, OpAnd64Reg,
+		OpXor32Imm, OpXor32Reg, OpXor64Imm, OpXor64Reg,
+		OpLsh32Imm, OpLsh32Reg, OpLsh64Imm, OpLsh64Reg,
+		OpRsh32Imm, OpRsh32Reg, OpRsh64Imm, OpRsh64Reg,
+		OpArsh32Imm, OpArsh32Reg, OpArsh64Imm, OpArsh64Reg,
+		OpNeg32, OpNeg64,
+		OpMov32Imm, OpMov32Reg, OpMov64Imm, OpMov64Reg,
+		OpLe, OpBe, OpLddw,
+		OpJa,
+		OpJeqImm, OpJeqReg, OpJgtImm, OpJgtReg, OpJgeImm, OpJgeReg,
+		OpJltImm, OpJltReg, OpJleImm, OpJleReg, OpJsetImm, OpJsetReg,
+		OpJneImm, OpJneReg, OpJsgtImm, OpJsgtReg, OpJsgeImm, OpJsgeReg,
+		OpJsltImm, OpJsltReg, OpJsleImm, OpJsleReg,
+	} {
+		native[op] = true
+	}
+	return
+}()
+
+// jitAccessSizes maps the size modes to the size of memory accesses.
+var jitAccessSizes = map[uint8]int{SizeB: 1, SizeH: 2, SizeW: 4, SizeDw: 8}
+
+// jitAluOps maps the bitwise and additive SBF operations to the ALU
+// opcodes of their register and immediate forms.
+var jitAluOps = map[uint8]struct{ rr, ext uint8 }{
+	AluAdd: {0x01, 0},
+	AluSub: {0x29, 5},
+	AluOr:  {0x09, 1},
+	AluAnd: {0x21, 4},
+	AluXor: {0x31, 6},
+}
+
+// jitJumpCCs maps the conditional jumps to the condition codes of a
+// comparison of dst with src.
+var jitJumpCCs = map[uint8]uint8{
+	JumpEq:  ccE,
+	JumpGt:  ccA,
+	JumpGe:  ccAE,
+	JumpLt:  ccB,
+	JumpLe:  ccBE,
+	JumpNe:  ccNE,
+	JumpSgt: ccG,
+	JumpSge: ccGE,
+	JumpSlt: ccL,
+	JumpSle: ccLE,
+	JumpSet: ccNE,
+}
+
+// compileIns compiles an instruction of a block, with the compute units
+// prepaid for it and the following instructions of the block.
+func (c *jitCompiler) compileIns(pc int64, ins Slot, refund int32) {
+	a := &c.a
+	op := ins.Op()
+	dst := jitRegs[ins.Dst()]
+	src := jitRegs[ins.Src()]
+	class := op & 0x07
+	w := class == ClassAlu64
+
+	switch op {
+	case OpLdxb, OpLdxh, OpLdxw, OpLdxdw:
+		size := jitAccessSizes[op&0x18]
+		c.memAccess(pc, refund, src, ins.Off(), size, false)
+		a.load(size, dst, rax, 0)
+		return
+	case OpStb, OpSth, OpStw, OpStdw:
+		size := jitAccessSizes[op&0x18]
+		c.memAccess(pc, refund, dst, ins.Off(), size, true)
+		a.storeImm(size, rax, 0, ins.Uimm())
+		return
+	case OpStxb, OpStxh, OpStxw, OpStxdw:
+		size := jitAccessSizes[op&0x18]
+		c.memAccess(pc, refund, dst, ins.Off(), size, true)
+		a.store(size, src, rax, 0)
+		return
+	case OpLddw:
+		next := GetSlot(c.text[(pc+1)*SlotSize:])
+		a.movabs(dst, uint64(ins.Uimm())|uint64(next.Uimm())<<32)
+		return
+	case OpJa:
+		a.jmp(c.labels[pc+int64(ins.Off())+1])
+		return
+	}
+
+	if class == ClassJmp {
+		if op&0x08 == SrcX {
+			if op&0xf0 == JumpSet {
+				a.aluRR(true, 0x85, dst, src)
+			} else {
+				a.aluRR(true, 0x39, dst, src)
+			}
+		} else if op&0xf0 == JumpSet {
+			a.testRI(true, dst, ins.Imm())
+		} else {
+			a.aluRI(true, 7, dst, ins.Imm())
+		}
+		a.jcc(jitJumpCCs[op&0xf0], c.labels[pc+int64(ins.Off())+1])
+		return
+	}
+
+	// ALU operations; those of 32 bits zero-extend their result, unless
+	// noted otherwise
+	imm := op&0x08 == SrcK
+	signExtend := false
+	switch aluOp := op & 0xf0; aluOp {
+	case AluAdd, AluSub, AluOr, AluAnd, AluXor:
+		ops := jitAluOps[aluOp]
+		if imm {
+			a.aluRI(w, ops.ext, dst, ins.Imm())
+		} else {
+			a.aluRR(w, ops.rr, dst, src)
+		}
+		signExtend = aluOp == AluAdd || aluOp == AluSub
+	case AluMul:
+		if imm {
+			a.imulRRI(w, dst, dst, ins.Imm())
+		} else {
+			a.imulRR(w, dst, src)
+		}
+		signExtend = true
+	case AluDiv, AluMod:
+		if imm {
+			if w {
+				a.movRI64(rcx, ins.Imm())
+			} else {
+				a.movRI32(rcx, ins.Uimm())
+			}
+		} else {
+			a.movRR(w, rcx, src)
+		}
+		c.divide(pc, refund, w, dst, aluOp == AluMod, !imm)
+	case AluLsh, AluRsh, AluArsh:
+		ext := map[uint8]uint8{AluLsh: 4, AluRsh: 5, AluArsh: 7}[aluOp]
+		if imm {
+			a.shiftRI(w, ext, dst, uint8(ins.Uimm()))
+		} else {
+			a.movRR(false, rcx, src)
+			a.shiftCL(w, ext, dst)
+		}
+		signExtend = aluOp == AluArsh
+	case AluNeg:
+		a.unary(w, 3, dst)
+		signExtend = true
+	case AluMov:
+		switch {
+		case imm && w:
+			a.movRI64(dst, ins.Imm())
+		case imm:
+			a.movRI32(dst, ins.Uimm())
+		default:
+			a.movRR(w, dst, src)
+		}
+	case AluEnd:
+		switch {
+		case op == OpLe && ins.Uimm() == 16:
+			a.movzx16(dst, dst)
+		case op == OpLe && ins.Uimm() == 32:
+			a.movRR(false, dst, dst)
+		case op == OpLe:
+		case ins.Uimm() == 16:
+			a.rol16(dst)
+			a.movzx16(dst, dst)
+		case ins.Uimm() == 32:
+			a.bswap(false, dst)
+		default:
+			a.bswap(true, dst)
+		}
+	default:
+		panic(fmt.Sprintf("unimplemented opcode %#02x", op))
+	}
+	if !w && signExtend {
+		a.movsxd(dst, dst)
+	}
+}
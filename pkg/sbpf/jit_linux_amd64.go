@@ -0,0 +1,167 @@
+//go:build sbpfjit
+
+package sbpf
+
+import (
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"go.firedancer.io/radiance/pkg/global"
+)
+
+// jitCache holds the native code of a program, compiled on its first
+// execution.
+type jitCache struct {
+	once sync.Once
+	code *jitCode
+}
+
+// jitCode is a program compiled to x86-64 machine code.
+type jitCode struct {
+	mem     []byte  // executable mapping
+	entries []int32 // offset of the native code of each block, by pc, or -1
+}
+
+// jitContext is the state shared with native code, addressed by RBP.
+type jitContext struct {
+	regs   [11]uint64 // must be first, see jitCall
+	pc     int64      // pc at which the native code exited
+	budget uint64     // compute units left
+	areas  [5]jitArea // by VM address >> 32
+}
+
+// jitArea describes an area of the VM address space to native code. Areas
+// of several regions, or with gaps other than between stack frames, are
+// left empty, so that native code leaves their accesses to the
+// interpreter.
+type jitArea struct {
+	host  uintptr // start of the host memory of the area
+	len   uint64  // length of the host memory
+	flags uint64  // jitAreaWritable, jitAreaGapped
+	_     uint64
+}
+
+// jitCall runs native code until it exits; see jit_linux_amd64.s.
+func jitCall(ctx *jitContext, target uintptr)
+
+// JIT executes programs compiled to x86-64 machine code. Instructions
+// without native code, and those that fault, are executed by an
+// interpreter over the same registers and memory, which also runs the
+// programs that could not be compiled.
+//
+// Based on solana_rbpf::jit::JitCompiler.
+type JIT struct {
+	*Interpreter
+	code *jitCode
+	ctx  jitContext
+}
+
+// NewExecutor creates an executor for a program execution, which runs the
+// program as native code. The caller must create a new executor for every
+// execution.
+func NewExecutor(globalCtx *global.GlobalCtx, p *Program, opts *VMOpts) Executor {
+	ip := NewInterpreter(globalCtx, p, opts)
+	if opts.Tracer != nil {
+		return ip
+	}
+	code := p.jit.compile(p)
+	if code == nil {
+		return ip
+	}
+	return &JIT{Interpreter: ip, code: code}
+}
+
+// compile returns the native code of a program, or nil if it cannot be
+// compiled.
+func (c *jitCache) compile(p *Program) *jitCode {
+	c.once.Do(func() {
+		buf, entries, err := compileJIT(p)
+		if err != nil {
+			return
+		}
+		mem, err := syscall.Mmap(-1, 0, len(buf), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+		if err != nil {
+			return
+		}
+		copy(mem, buf)
+		if err := syscall.Mprotect(mem, syscall.PROT_READ|syscall.PROT_EXEC); err != nil {
+			_ = syscall.Munmap(mem)
+			return
+		}
+		c.code = &jitCode{mem: mem, entries: entries}
+		runtime.SetFinalizer(c.code, func(code *jitCode) {
+			_ = syscall.Munmap(code.mem)
+		})
+	})
+	return c.code
+}
+
+// Run executes the program and returns the value of r0 on exit.
+func (j *JIT) Run() (uint64, error) {
+	var r [11]uint64
+	r[1] = VaddrInput
+	r[10] = j.stack.GetFramePtr()
+
+	pc := int64(j.entry)
+	for {
+		if pc >= 0 && pc < int64(len(j.code.entries)) && j.code.entries[pc] >= 0 {
+			pc = j.runNative(&r, pc)
+		}
+		next, exited, err := j.run(&r, pc, 1)
+		if err != nil {
+			return 0, err
+		}
+		if exited {
+			return r[0], nil
+		}
+		pc = next
+	}
+}
+
+// runNative runs the native code of the block at pc, and returns the pc
+// at which it exited.
+func (j *JIT) runNative(r *[11]uint64, pc int64) int64 {
+	j.mapAreas()
+	j.ctx.regs = *r
+	remaining := j.meter.Remaining()
+	j.ctx.budget = remaining
+	jitCall(&j.ctx, uintptr(unsafe.Pointer(&j.code.mem[0]))+uintptr(j.code.entries[pc]))
+	runtime.KeepAlive(j)
+	*r = j.ctx.regs
+	// the native code never overdraws the budget
+	_ = j.meter.Consume(remaining - j.ctx.budget)
+	return j.ctx.pc
+}
+
+// mapAreas describes the memory mapping to native code. Syscalls may
+// replace the data of regions, so it is refreshed before native code runs.
+func (j *JIT) mapAreas() {
+	for i, area := range j.mem.areas {
+		j.ctx.areas[i] = jitArea{}
+		if len(area) != 1 || len(area[0].Data) == 0 {
+			continue
+		}
+		region := area[0]
+		var flags uint64
+		if region.Writable {
+			flags |= jitAreaWritable
+		}
+		switch region.GapSize {
+		case 0:
+		case StackFrameSize:
+			if len(region.Data)%StackFrameSize != 0 {
+				continue
+			}
+			flags |= jitAreaGapped
+		default:
+			continue
+		}
+		j.ctx.areas[i] = jitArea{
+			host:  uintptr(unsafe.Pointer(&region.Data[0])),
+			len:   uint64(len(region.Data)),
+			flags: flags,
+		}
+	}
+}
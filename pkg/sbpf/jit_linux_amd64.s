@@ -0,0 +1,31 @@
+//go:build sbpfjit
+
+#include "textflag.h"
+
+// func jitCall(ctx *jitContext, target uintptr)
+//
+// Loads the SBF registers from ctx.regs into the host registers assigned
+// by jitRegs, with ctx in BP, and calls the native code at target. The
+// native code stores the registers back into ctx.regs before returning.
+// R14 and R15 are reserved by the Go ABI and restored afterwards; BP is
+// restored by the epilogue.
+TEXT ·jitCall(SB), NOSPLIT, $16-16
+	MOVQ R14, 0(SP)
+	MOVQ R15, 8(SP)
+	MOVQ target+8(FP), AX
+	MOVQ ctx+0(FP), BP
+	MOVQ 0(BP), SI
+	MOVQ 8(BP), DI
+	MOVQ 16(BP), R8
+	MOVQ 24(BP), R9
+	MOVQ 32(BP), R10
+	MOVQ 40(BP), R11
+	MOVQ 48(BP), BX
+	MOVQ 56(BP), R12
+	MOVQ 64(BP), R13
+	MOVQ 72(BP), R14
+	MOVQ 80(BP), R15
+	CALL AX
+	MOVQ 0(SP), R14
+	MOVQ 8(SP), R15
+	RET
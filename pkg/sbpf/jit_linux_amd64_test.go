@@ -0,0 +1,153 @@
+//go:build sbpfjit
+
+package sbpf
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.firedancer.io/radiance/pkg/cu"
+)
+
+// jitTestOps are the opcodes of the random programs of TestJIT_Differential.
+var jitTestOps = func() (ops []uint8) {
+	for op, native := range jitOpNative {
+		if native && uint8(op) != OpLddw {
+			ops = append(ops, uint8(op))
+		}
+	}
+	return append(ops, OpSdiv64Reg, OpCall, OpExit)
+}()
+
+// randomJITProgram generates a program that passes the verifier, with
+// r2 to r4 pointing into the heap, the stack and the read-only segment.
+func randomJITProgram(rng *rand.Rand) *Program {
+	for {
+		slots := []Slot{
+			makeSlot(OpLddw, 2, 0, 0, 0), makeSlot(0, 0, 0, 0, int32(VaddrHeap>>32)),
+			makeSlot(OpMov64Reg, 3, 10, 0, 0),
+			makeSlot(OpLddw, 4, 0, 0, 0), makeSlot(0, 0, 0, 0, int32(VaddrProgram>>32)),
+		}
+		n := 8 + rng.Intn(48)
+		for i := 0; i < n; i++ {
+			op := jitTestOps[rng.Intn(len(jitTestOps))]
+			dst := uint8(rng.Intn(11))
+			src := uint8(rng.Intn(11))
+			off := int16(rng.Intn(160) - 80)
+			imm := int32(rng.Intn(64))
+			switch {
+			case op&0x07 == ClassJmp && op != OpCall:
+				off = int16(rng.Intn(n+4) - n/2)
+			case rng.Intn(4) == 0:
+				imm = int32(rng.Uint32())
+			}
+			if op == OpCall {
+				imm = 1
+			}
+			slots = append(slots, makeSlot(op, dst, src, off, imm))
+		}
+		slots = append(slots, makeSlot(OpExit, 0, 0, 0, 0))
+		p := &Program{
+			Text:    makeText(slots...),
+			TextVA:  VaddrProgram,
+			Funcs:   map[uint32]int64{1: int64(5 + rng.Intn(n))},
+			Version: SBPFVersionV1,
+		}
+		p.RO = p.Text
+		if p.Verify() == nil {
+			return p
+		}
+	}
+}
+
+type jitTestResult struct {
+	ret    uint64
+	err    string
+	remain uint64
+	input  []byte
+	stack  []byte
+}
+
+func runJITTest(t *testing.T, p *Program, maxCU uint64, jit bool) jitTestResult {
+	meter := cu.NewComputeMeter(maxCU)
+	opts := &VMOpts{
+		HeapSize:     256,
+		Input:        make([]byte, 64),
+		ComputeMeter: &meter,
+	}
+	var exe Executor
+	var stack *Stack
+	if jit {
+		exe = NewExecutor(nil, p, opts)
+		j, ok := exe.(*JIT)
+		require.True(t, ok, "program was not compiled")
+		stack = &j.stack
+	} else {
+		ip := NewInterpreter(nil, p, opts)
+		exe, stack = ip, &ip.stack
+	}
+	ret, err := exe.Run()
+	res := jitTestResult{ret: ret, remain: meter.Remaining(), input: opts.Input, stack: stack.mem}
+	if err != nil {
+		res.err = err.Error()
+	}
+	return res
+}
+
+func TestJIT_Differential(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		p := randomJITProgram(rng)
+		maxCU := uint64(1 + rng.Intn(200))
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			want := runJITTest(t, p, maxCU, false)
+			got := runJITTest(t, p, maxCU, true)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestJIT_StackGaps(t *testing.T) {
+	p := &Program{
+		Text: makeText(
+			makeSlot(OpStdw, 10, 0, -8, 7),
+			makeSlot(OpLdxdw, 0, 10, -8, 0),
+			// the frame below r10 is followed by a gap
+			makeSlot(OpStxdw, 10, 0, 0, 0),
+			makeSlot(OpExit, 0, 0, 0, 0),
+		),
+		TextVA: VaddrProgram,
+	}
+	require.NoError(t, p.Verify())
+	want := runJITTest(t, p, 100, false)
+	got := runJITTest(t, p, 100, true)
+	assert.Equal(t, want, got)
+	assert.Contains(t, got.err, "frame gap")
+}
+
+func TestJIT_CallFrames(t *testing.T) {
+	p := &Program{
+		Text: makeText(
+			makeSlot(OpStdw, 10, 0, -8, 1),
+			makeSlot(OpCall, 0, 0, 0, 1),
+			makeSlot(OpLdxdw, 1, 10, -8, 0),
+			makeSlot(OpAdd64Reg, 0, 1, 0, 0),
+			makeSlot(OpExit, 0, 0, 0, 0),
+			// callee: stores into its own frame
+			makeSlot(OpStdw, 10, 0, -16, 2),
+			makeSlot(OpLdxdw, 0, 10, -16, 0),
+			makeSlot(OpExit, 0, 0, 0, 0),
+		),
+		TextVA: VaddrProgram,
+		Funcs:  map[uint32]int64{1: 5},
+	}
+	require.NoError(t, p.Verify())
+	want := runJITTest(t, p, 100, false)
+	got := runJITTest(t, p, 100, true)
+	assert.Equal(t, want, got)
+	assert.Equal(t, uint64(3), got.ret)
+}
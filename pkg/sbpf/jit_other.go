@@ -0,0 +1,16 @@
+//go:build !sbpfjit || !linux || !amd64
+
+package sbpf
+
+import "go.firedancer.io/radiance/pkg/global"
+
+// jitCache holds the native code of a program, which is only compiled by
+// builds with the sbpfjit tag on linux/amd64.
+type jitCache struct{}
+
+// NewExecutor creates an executor for a program execution. Without the
+// sbpfjit build tag, or on platforms other than linux/amd64, programs are
+// interpreted.
+func NewExecutor(globalCtx *global.GlobalCtx, p *Program, opts *VMOpts) Executor {
+	return NewInterpreter(globalCtx, p, opts)
+}
@@ -117,5 +117,13 @@ func (l *Loader) getProgram() *sbpf.Program {
 		TextVA:     sbpf.VaddrProgram + l.textRange.min,
 		Entrypoint: l.entrypoint,
 		Funcs:      l.funcs,
+		Version:    l.sbpfVersion(),
 	}
 }
+
+func (l *Loader) sbpfVersion() sbpf.SBPFVersion {
+	if l.eh.Flags == EF_SBF_V2 {
+		return sbpf.SBPFVersionV2
+	}
+	return sbpf.SBPFVersionV1
+}
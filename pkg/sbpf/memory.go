@@ -0,0 +1,163 @@
+package sbpf
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// MemoryRegion maps a host memory buffer into the VM address space.
+type MemoryRegion struct {
+	VAddr    uint64 // start of region in VM address space
+	Data     []byte
+	Writable bool
+
+	// GapSize, if non-zero, splits the region into chunks of GapSize bytes,
+	// each followed by an unmapped gap of GapSize bytes in the VM address
+	// space. Data is contiguous in host memory. Used for the stack.
+	GapSize uint64
+}
+
+// vmSize returns the size of the region in VM address space.
+func (r *MemoryRegion) vmSize() uint64 {
+	if r.GapSize == 0 {
+		return uint64(len(r.Data))
+	}
+	return 2 * uint64(len(r.Data))
+}
+
+// MemoryMapping is the VM address space.
+//
+// Each area is aligned to a 4 GiB boundary (see VaddrProgram et al.),
+// so the upper 32 bits of an address select the area. An area consists
+// of one or more contiguous regions, e.g. the input area is split into
+// per-account regions when account data is mapped directly.
+//
+// Based on solana_rbpf::memory_region::AlignedMemoryMapping and
+// UnalignedMemoryMapping.
+type MemoryMapping struct {
+	areas [5][]*MemoryRegion // indexed by VM address >> 32, sorted by VAddr
+}
+
+// NewMemoryMapping creates an address space from the given regions.
+//
+// Regions within an area must be given in ascending order,
+// the first starting at the area's base address and each
+// following region starting where the previous one ends.
+func NewMemoryMapping(regions ...MemoryRegion) (*MemoryMapping, error) {
+	m := new(MemoryMapping)
+	for i := range regions {
+		region := &regions[i]
+		idx := region.VAddr >> 32
+		if idx == 0 || idx >= uint64(len(m.areas)) {
+			return nil, fmt.Errorf("invalid memory region at %#x", region.VAddr)
+		}
+		area := m.areas[idx]
+		if len(area) == 0 {
+			if region.VAddr&math.MaxUint32 != 0 {
+				return nil, fmt.Errorf("invalid memory region at %#x", region.VAddr)
+			}
+		} else if last := area[len(area)-1]; region.VAddr != last.VAddr+last.vmSize() || region.VAddr == last.VAddr {
+			return nil, fmt.Errorf("overlapping or non-contiguous memory region at %#x", region.VAddr)
+		}
+		if region.VAddr-idx<<32+region.vmSize() > math.MaxUint32+1 {
+			return nil, fmt.Errorf("memory region at %#x too large", region.VAddr)
+		}
+		m.areas[idx] = append(area, region)
+	}
+	return m, nil
+}
+
+// Region returns the region containing the given VM address.
+func (m *MemoryMapping) Region(addr uint64) (*MemoryRegion, error) {
+	region := m.find(addr, 1)
+	if region == nil {
+		return nil, NewExcBadAccess(addr, 0, false, "access violation in "+regionName(addr>>32)+" section")
+	}
+	return region, nil
+}
+
+// find returns the region in which an access of the given size
+// at addr starts, or nil. An empty access may end a region.
+func (m *MemoryMapping) find(addr uint64, size uint64) *MemoryRegion {
+	idx := addr >> 32
+	if idx >= uint64(len(m.areas)) {
+		return nil
+	}
+	area := m.areas[idx]
+	i := sort.Search(len(area), func(i int) bool {
+		return area[i].VAddr+area[i].vmSize() > addr
+	})
+	if i == len(area) {
+		if size == 0 && i > 0 && area[i-1].VAddr+area[i-1].vmSize() == addr {
+			return area[i-1]
+		}
+		return nil
+	}
+	if addr < area[i].VAddr {
+		return nil
+	}
+	return area[i]
+}
+
+// Translate returns the host memory backing the VM address range
+// [addr, addr+size). Returns ExcBadAccess if the range is not fully
+// contained in one region, or if a write targets a read-only region.
+func (m *MemoryMapping) Translate(addr uint64, size uint64, write bool) ([]byte, error) {
+	region := m.find(addr, size)
+	if region == nil {
+		return nil, NewExcBadAccess(addr, size, write, "access violation in "+regionName(addr>>32)+" section")
+	}
+	name := regionName(addr >> 32)
+
+	if write && !region.Writable {
+		return nil, NewExcBadAccess(addr, size, write, "access violation in "+name+" section")
+	}
+	off := addr - region.VAddr
+	end, carry := bits.Add64(off, size, 0)
+	if carry != 0 || end > region.vmSize() {
+		return nil, NewExcBadAccess(addr, size, write, "access violation in "+name+" section")
+	}
+
+	if region.GapSize != 0 {
+		chunk, chunkOff := off/region.GapSize, off%region.GapSize
+		if chunk%2 == 1 {
+			return nil, NewExcBadAccess(addr, size, write, "access violation in "+name+" frame gap")
+		}
+		if chunkOff+size > region.GapSize {
+			return nil, NewExcBadAccess(addr, size, write, "access violation in "+name+" section")
+		}
+		off = (chunk/2)*region.GapSize + chunkOff
+		end = off + size
+	}
+	return region.Data[off:end:end], nil
+}
+
+func regionName(idx uint64) string {
+	switch idx {
+	case VaddrProgram >> 32:
+		return "program"
+	case VaddrStack >> 32:
+		return "stack"
+	case VaddrHeap >> 32:
+		return "heap"
+	case VaddrInput >> 32:
+		return "input"
+	default:
+		return "unknown"
+	}
+}
+
+// TranslateSlice translates an array of count elements of elemSize bytes
+// each. Empty arrays are never translated, so their address may be invalid.
+func TranslateSlice(vm VM, addr uint64, count uint64, elemSize uint64, write bool) ([]byte, error) {
+	hi, size := bits.Mul64(count, elemSize)
+	if hi != 0 {
+		return nil, NewExcBadAccess(addr, math.MaxUint64, write, "slice length overflow")
+	}
+	if size == 0 {
+		return []byte{}, nil
+	}
+	return vm.Translate(addr, size, write)
+}
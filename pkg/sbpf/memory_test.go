@@ -0,0 +1,106 @@
+package sbpf
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryMapping_Translate(t *testing.T) {
+	program := []byte{1, 2, 3, 4}
+	stack := make([]byte, 2*StackFrameSize)
+	heap := make([]byte, 16)
+	mem, err := NewMemoryMapping(
+		MemoryRegion{VAddr: VaddrProgram, Data: program},
+		MemoryRegion{VAddr: VaddrStack, Data: stack, Writable: true, GapSize: StackFrameSize},
+		MemoryRegion{VAddr: VaddrHeap, Data: heap, Writable: true},
+	)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		addr  uint64
+		size  uint64
+		write bool
+		ok    bool
+	}{
+		{"ProgramRead", VaddrProgram, 4, false, true},
+		{"ProgramReadEnd", VaddrProgram + 4, 0, false, true},
+		{"ProgramReadOutOfBounds", VaddrProgram + 1, 4, false, false},
+		{"ProgramWrite", VaddrProgram, 1, true, false},
+		{"HeapWrite", VaddrHeap + 8, 8, true, true},
+		{"HeapOverflow", VaddrHeap + 8, math.MaxUint64, false, false},
+		{"StackFrame0", VaddrStack, StackFrameSize, true, true},
+		{"StackGap", VaddrStack + StackFrameSize, 1, false, false},
+		{"StackFrame1", VaddrStack + 2*StackFrameSize, 8, true, true},
+		{"StackCrossesGap", VaddrStack + StackFrameSize - 4, 8, false, false},
+		{"StackOutOfBounds", VaddrStack + 4*StackFrameSize, 1, false, false},
+		{"Input", VaddrInput, 1, false, false},
+		{"Null", 0, 1, false, false},
+		{"Unknown", 0x10_0000_0000, 1, false, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf, err := mem.Translate(tc.addr, tc.size, tc.write)
+			if tc.ok {
+				require.NoError(t, err)
+				assert.Len(t, buf, int(tc.size))
+			} else {
+				var exc ExcBadAccess
+				assert.True(t, errors.As(err, &exc), "expected ExcBadAccess, got %v", err)
+			}
+		})
+	}
+
+	buf, err := mem.Translate(VaddrStack+2*StackFrameSize+4, 1, true)
+	require.NoError(t, err)
+	buf[0] = 0x42
+	assert.Equal(t, byte(0x42), stack[StackFrameSize+4])
+}
+
+func TestNewMemoryMapping_Invalid(t *testing.T) {
+	_, err := NewMemoryMapping(MemoryRegion{VAddr: VaddrHeap + 8})
+	assert.Error(t, err)
+
+	_, err = NewMemoryMapping(MemoryRegion{VAddr: VaddrHeap}, MemoryRegion{VAddr: VaddrHeap})
+	assert.Error(t, err)
+}
+
+func TestMemoryMapping_MultipleRegions(t *testing.T) {
+	header := make([]byte, 8)
+	data := []byte{1, 2, 3, 4}
+	mem, err := NewMemoryMapping(
+		MemoryRegion{VAddr: VaddrInput, Data: header, Writable: true},
+		MemoryRegion{VAddr: VaddrInput + 8, Data: data},
+		MemoryRegion{VAddr: VaddrInput + 12, Data: make([]byte, 8), Writable: true},
+	)
+	require.NoError(t, err)
+
+	buf, err := mem.Translate(VaddrInput+9, 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2, 3}, buf)
+
+	// accesses may not span regions
+	_, err = mem.Translate(VaddrInput+6, 4, false)
+	assert.Error(t, err)
+
+	// each region keeps its own permissions
+	_, err = mem.Translate(VaddrInput+8, 1, true)
+	assert.Error(t, err)
+	_, err = mem.Translate(VaddrInput+12, 8, true)
+	assert.NoError(t, err)
+
+	region, err := mem.Region(VaddrInput + 10)
+	require.NoError(t, err)
+	assert.Equal(t, VaddrInput+8, region.VAddr)
+
+	// regions of an area must be contiguous
+	_, err = NewMemoryMapping(
+		MemoryRegion{VAddr: VaddrInput, Data: header},
+		MemoryRegion{VAddr: VaddrInput + 16, Data: data},
+	)
+	assert.Error(t, err)
+}
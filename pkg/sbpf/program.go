@@ -7,9 +7,49 @@ type Program struct {
 	TextVA     uint64
 	Entrypoint uint64 // PC
 	Funcs      map[uint32]int64
+	Version    SBPFVersion
+
+	jit jitCache // native code, compiled on first execution
 }
 
 // Verify runs the static bytecode verifier.
 func (p *Program) Verify() error {
 	return NewVerifier(p).Verify()
 }
+
+// SBPFVersion is the revision of the SBF instruction set a program targets.
+type SBPFVersion uint8
+
+const (
+	// SBPFVersionV1 is the original SBF instruction set.
+	SBPFVersionV1 = SBPFVersion(iota)
+	// SBPFVersionV2 is the instruction set of ELFs flagged with EF_SBF_V2.
+	SBPFVersionV2
+)
+
+// DisableLddw returns whether the lddw instruction is unavailable.
+func (v SBPFVersion) DisableLddw() bool {
+	return v != SBPFVersionV1
+}
+
+// DisableNeg returns whether the neg instructions are unavailable.
+func (v SBPFVersion) DisableNeg() bool {
+	return v != SBPFVersionV1
+}
+
+// DisableLe returns whether the le instruction is unavailable.
+func (v SBPFVersion) DisableLe() bool {
+	return v != SBPFVersionV1
+}
+
+// DynamicStackFrames returns whether programs may adjust the frame
+// pointer (r10) using add64 immediate.
+func (v SBPFVersion) DynamicStackFrames() bool {
+	return v != SBPFVersionV1
+}
+
+// CallxUsesSrcReg returns whether callx takes its target register from
+// the src field rather than the immediate.
+func (v SBPFVersion) CallxUsesSrcReg() bool {
+	return v != SBPFVersionV1
+}
@@ -6,32 +6,40 @@ package sbpf
 //
 // The memory stack resides in addressable memory at VaddrStack.
 //
-// It is split into statically sized stack frames (StackFrameSize).
+// With fixed frames (SBFv1), it is split into statically sized stack frames (StackFrameSize).
 // Each frame stores spilled function arguments and local variables.
 // The frame pointer (r10) points to the highest address in the current frame.
 //
 // New frames get allocated upwards.
 // Each frame is followed by a gap of size StackFrameSize.
 //
-//	[0x1_0000_0000]: Frame
-//	[0x1_0000_1000]: Gap
-//	[0x1_0000_2000]: Frame
-//	[0x1_0000_3000]: Gap
+//	[0x2_0000_0000]: Frame
+//	[0x2_0000_1000]: Gap
+//	[0x2_0000_2000]: Frame
+//	[0x2_0000_3000]: Gap
 //	...
 //
+// With dynamic frames (SBFv2), the stack is one contiguous region without gaps.
+// The stack pointer starts at the highest stack address,
+// and programs allocate frames downwards by adding to r10.
+// A call passes the current stack pointer as the callee's frame pointer.
+//
 // # Shadow stack
 //
 // The shadow stack is not directly accessible from SBF.
 // It stores return addresses and caller-preserved registers.
+//
+// Based on solana_rbpf::interpreter::Interpreter::push_frame.
 type Stack struct {
-	mem    []byte
-	sp     uint64
-	shadow []Frame
+	mem     []byte
+	sp      uint64
+	dynamic bool
+	shadow  []Frame
 }
 
 // Frame is an entry on the shadow stack.
 type Frame struct {
-	FramePtr uint64
+	FramePtr uint64 // caller's frame pointer
 	NVRegs   [4]uint64
 	RetAddr  int64
 }
@@ -41,77 +49,89 @@ type Frame struct {
 // Note that this constant cannot be changed trivially.
 const StackFrameSize = 0x1000
 
-// StackDepth is the max frame count of the stack.
+// StackDepth is the max frame count of the stack,
+// including the entrypoint's frame.
 const StackDepth = 64
 
-func NewStack() Stack {
+// NewStack creates a stack with fixed or dynamic frames.
+func NewStack(dynamic bool) Stack {
 	s := Stack{
-		mem:    make([]byte, StackDepth*StackFrameSize),
-		sp:     VaddrStack,
-		shadow: make([]Frame, 1, StackDepth),
+		mem:     make([]byte, StackDepth*StackFrameSize),
+		dynamic: dynamic,
+		shadow:  make([]Frame, 0, StackDepth-1),
 	}
-	s.shadow[0] = Frame{
-		FramePtr: VaddrStack + StackFrameSize,
+	if dynamic {
+		s.sp = VaddrStack + uint64(len(s.mem))
+	} else {
+		s.sp = VaddrStack + StackFrameSize
 	}
 	return s
 }
 
-// GetFramePtr returns the current frame pointer.
+// GetFramePtr returns the stack pointer, i.e. the frame pointer
+// that the next call frame receives.
 func (s *Stack) GetFramePtr() uint64 {
-	return s.shadow[len(s.shadow)-1].FramePtr
+	return s.sp
 }
 
-// GetFrame returns the stack frame memory slice containing the frame pointer.
-//
-// The returned slice starts at the location within the frame as indicated by the address.
-// To get the full frame, align the provided address by StackFrameSize.
-//
-// Returns nil if the program tries to address a gap or out-of-bounds memory.
-func (s *Stack) GetFrame(addr uint32) []byte {
-	hi, lo := addr/StackFrameSize, addr%StackFrameSize
-	if hi > StackDepth || hi%2 == 1 {
-		return nil
+// region returns the memory region backing the stack.
+func (s *Stack) region() MemoryRegion {
+	region := MemoryRegion{VAddr: VaddrStack, Data: s.mem, Writable: true}
+	if !s.dynamic {
+		region.GapSize = StackFrameSize
 	}
-	pos := hi / 2
-	off := pos * StackFrameSize
-	return s.mem[off+lo : off+StackFrameSize]
+	return region
+}
+
+// AdjustStackPtr moves the stack pointer by the given offset.
+// Only valid with dynamic frames.
+//
+// The stack pointer may wrap around; accesses through
+// the resulting frame pointer fault in the memory mapping.
+func (s *Stack) AdjustStackPtr(off int64) {
+	s.sp += uint64(off)
 }
 
 // Push allocates a new call frame.
 //
-// Saves the given nonvolatile regs and return address.
+// Saves the given nonvolatile regs, the caller's frame pointer, and return address.
 // Returns the new frame pointer.
-func (s *Stack) Push(nvRegs *[4]uint64, ret int64) (fp uint64, ok bool) {
+// Sets `ok` to false if the max call depth is exceeded.
+func (s *Stack) Push(nvRegs *[4]uint64, fp uint64, ret int64) (newFp uint64, ok bool) {
 	if ok = len(s.shadow) < cap(s.shadow); !ok {
 		return
 	}
 
-	fp = s.GetFramePtr() + 2*StackFrameSize
-	s.shadow = s.shadow[:len(s.shadow)+1]
-	s.shadow[len(s.shadow)-1] = Frame{
+	s.shadow = append(s.shadow, Frame{
 		FramePtr: fp,
 		NVRegs:   *nvRegs,
 		RetAddr:  ret,
+	})
+	if !s.dynamic {
+		s.sp += 2 * StackFrameSize
 	}
-	s.sp = fp - StackFrameSize
+	newFp = s.sp
 	return
 }
 
 // Pop exits the last call frame.
 //
 // Writes saved nonvolatile regs into provided slice.
-// Returns saved return address, new frame pointer.
+// Returns saved return address, caller's frame pointer.
 // Sets `ok` to false if no call frames are left.
 func (s *Stack) Pop(nvRegs *[4]uint64) (fp uint64, ret int64, ok bool) {
-	if len(s.shadow) <= 1 {
+	if len(s.shadow) == 0 {
 		ok = false
 		return
 	}
 
 	var frame Frame
 	frame, s.shadow = s.shadow[len(s.shadow)-1], s.shadow[:len(s.shadow)-1]
+	if !s.dynamic {
+		s.sp -= 2 * StackFrameSize
+	}
 
-	fp = s.GetFramePtr()
+	fp = frame.FramePtr
 	*nvRegs = frame.NVRegs
 	ret = frame.RetAddr
 	ok = true
@@ -2,6 +2,9 @@ package sbpf
 
 import "fmt"
 
+// Verifier is the static bytecode verifier.
+//
+// Based on solana_rbpf::verifier::RequisiteVerifier.
 type Verifier struct {
 	Program *Program
 }
@@ -12,22 +15,28 @@ func NewVerifier(p *Program) *Verifier {
 
 func (v *Verifier) Verify() error {
 	text := v.Program.Text
+	version := v.Program.Version
 	if len(text)%SlotSize != 0 {
 		return fmt.Errorf("odd .text size")
 	}
 	if len(text) == 0 {
 		return fmt.Errorf("empty text")
 	}
+	numSlots := int64(len(text) / SlotSize)
 
-	for pc := uint64(0); (pc+1)*SlotSize <= uint64(len(text)); pc++ {
+	for pc := int64(0); pc < numSlots; pc++ {
 		insBytes := text[pc*SlotSize:]
 		ins := GetSlot(insBytes)
 
-		if ins.Src() > 10 {
-			return fmt.Errorf("invalid src register")
-		}
+		// Stores only read their "destination" register,
+		// so they may address memory relative to the frame pointer.
+		store := false
+
 		switch ins.Op() {
 		case OpLdxb, OpLdxh, OpLdxw, OpLdxdw:
+		case OpStb, OpSth, OpStw, OpStdw,
+			OpStxb, OpStxh, OpStxw, OpStxdw:
+			store = true
 		case OpAdd32Imm, OpAdd32Reg, OpAdd64Imm, OpAdd64Reg:
 		case OpSub32Imm, OpSub32Reg, OpSub64Imm, OpSub64Reg:
 		case OpMul32Imm, OpMul32Reg, OpMul64Imm, OpMul64Reg:
@@ -35,37 +44,37 @@ func (v *Verifier) Verify() error {
 		case OpAnd32Imm, OpAnd32Reg, OpAnd64Imm, OpAnd64Reg:
 		case OpLsh32Reg, OpLsh64Reg:
 		case OpRsh32Reg, OpRsh64Reg:
-		case OpNeg32, OpNeg64:
+		case OpArsh32Reg, OpArsh64Reg:
 		case OpXor32Imm, OpXor32Reg, OpXor64Imm, OpXor64Reg:
 		case OpMov32Imm, OpMov32Reg, OpMov64Imm, OpMov64Reg:
 		case OpDiv32Reg, OpDiv64Reg:
 		case OpMod32Reg, OpMod64Reg:
-		case OpSdiv32Reg, OpSdiv64Reg:
-		case OpCall, OpExit:
-			// nothing
-		case OpStb, OpSth, OpStw, OpStdw,
-			OpStxb, OpStxh, OpStxw, OpStxdw:
-			if ins.Dst() > 10 {
-				return fmt.Errorf("invalid dst register")
+		case OpExit:
+		case OpCall:
+			// Call targets are resolved by the loader, whose relocations
+			// only produce function hashes registered in v.Program.Funcs.
+		case OpNeg32, OpNeg64:
+			if version.DisableNeg() {
+				return fmt.Errorf("unknown opcode %#02x at pc %d", ins.Op(), pc)
 			}
-			continue
 		case OpLsh32Imm, OpRsh32Imm, OpArsh32Imm:
 			if ins.Uimm() > 31 {
-				return fmt.Errorf("32-bit shift out of bounds")
+				return fmt.Errorf("32-bit shift out of bounds at pc %d", pc)
 			}
 		case OpLsh64Imm, OpRsh64Imm, OpArsh64Imm:
 			if ins.Uimm() > 63 {
-				return fmt.Errorf("64-bit shift out of bounds")
+				return fmt.Errorf("64-bit shift out of bounds at pc %d", pc)
 			}
 		case OpLe, OpBe:
+			if ins.Op() == OpLe && version.DisableLe() {
+				return fmt.Errorf("unknown opcode %#02x at pc %d", ins.Op(), pc)
+			}
 			switch ins.Uimm() {
 			case 16, 32, 64:
 				// ok
 			default:
-				return fmt.Errorf("invalid bit size for endianness conversion")
+				return fmt.Errorf("invalid bit size for endianness conversion at pc %d", pc)
 			}
-		case OpSdiv32Imm, OpSdiv64Imm:
-			fallthrough
 		case OpDiv32Imm, OpDiv64Imm, OpMod32Imm, OpMod64Imm:
 			if ins.Imm() == 0 {
 				return ExcDivideByZero
@@ -82,34 +91,85 @@ func (v *Verifier) Verify() error {
 			OpJsgeImm, OpJsgeReg,
 			OpJsltImm, OpJsltReg,
 			OpJsleImm, OpJsleReg:
-			dst := int64(pc) + int64(ins.Off()) + 1
-			if dst < 0 || (dst*SlotSize) >= int64(len(text)) {
-				return fmt.Errorf("jump out of code")
+			dst := pc + int64(ins.Off()) + 1
+			if dst < 0 || dst >= numSlots {
+				return fmt.Errorf("jump out of code at pc %d", pc)
 			}
 			dstIns := GetSlot(text[dst*SlotSize:])
 			if dstIns.Op() == 0 {
-				return fmt.Errorf("jump into middle of instruction")
+				return fmt.Errorf("jump into middle of lddw at pc %d", pc)
 			}
 		case OpCallx:
-			if uimm := ins.Uimm(); uimm >= 10 {
-				return fmt.Errorf("invalid callx register")
+			reg := ins.Uimm()
+			if version.CallxUsesSrcReg() {
+				reg = uint32(ins.Src())
+			}
+			if reg >= 10 {
+				return fmt.Errorf("invalid callx register at pc %d", pc)
 			}
 		case OpLddw:
-			if len(insBytes) < 2*SlotSize {
-				return fmt.Errorf("incomplete lddw instruction")
+			if version.DisableLddw() {
+				return fmt.Errorf("unknown opcode %#02x at pc %d", ins.Op(), pc)
 			}
-			if insBytes[8] != 0 {
-				return fmt.Errorf("malformed lddw instruction")
+			if pc+1 >= numSlots {
+				return fmt.Errorf("lddw cannot be last instruction")
+			}
+			if insBytes[SlotSize] != 0 {
+				return fmt.Errorf("incomplete lddw instruction at pc %d", pc)
 			}
 			pc++
 		default:
-			return fmt.Errorf("unknown opcode %#02x", ins.Op())
+			return fmt.Errorf("unknown opcode %#02x at pc %d", ins.Op(), pc)
 		}
 
-		if ins.Dst() > 9 {
-			return fmt.Errorf("invalid dst register")
+		if err := checkRegisters(ins, store, version); err != nil {
+			return fmt.Errorf("%w at pc %d", err, pc)
 		}
 	}
 
+	return v.verifyFuncs()
+}
+
+// checkRegisters validates register operands. The frame pointer (r10) is
+// read-only, except for stack frame adjustments on dynamic stack frames.
+func checkRegisters(ins Slot, store bool, version SBPFVersion) error {
+	if ins.Src() > 10 {
+		return fmt.Errorf("invalid src register")
+	}
+	switch dst := ins.Dst(); {
+	case dst <= 9:
+		return nil
+	case dst == 10 && store:
+		return nil
+	case dst == 10 && version.DynamicStackFrames() && ins.Op() == OpAdd64Imm:
+		return nil
+	case dst == 10:
+		return fmt.Errorf("cannot write r10")
+	default:
+		return fmt.Errorf("invalid dst register")
+	}
+}
+
+// verifyFuncs checks that the entrypoint and all call destinations point
+// to the start of an instruction within the text section.
+func (v *Verifier) verifyFuncs() error {
+	text := v.Program.Text
+	numSlots := int64(len(text) / SlotSize)
+
+	isTarget := func(pc int64) bool {
+		if pc < 0 || pc >= numSlots {
+			return false
+		}
+		return GetSlot(text[pc*SlotSize:]).Op() != 0
+	}
+
+	if !isTarget(int64(v.Program.Entrypoint)) {
+		return fmt.Errorf("invalid entrypoint %d", v.Program.Entrypoint)
+	}
+	for hash, target := range v.Program.Funcs {
+		if !isTarget(target) {
+			return fmt.Errorf("invalid call destination %d for function %#08x", target, hash)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,82 @@
+package sbpf
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeSlot(op uint8, dst uint8, src uint8, off int16, imm int32) Slot {
+	return Slot(uint64(op) |
+		uint64(dst&0xF)<<8 |
+		uint64(src&0xF)<<12 |
+		uint64(uint16(off))<<16 |
+		uint64(uint32(imm))<<32)
+}
+
+func makeText(slots ...Slot) []byte {
+	text := make([]byte, len(slots)*SlotSize)
+	for i, slot := range slots {
+		binary.LittleEndian.PutUint64(text[i*SlotSize:], uint64(slot))
+	}
+	return text
+}
+
+func TestVerifier(t *testing.T) {
+	exit := makeSlot(OpExit, 0, 0, 0, 0)
+
+	cases := []struct {
+		name    string
+		version SBPFVersion
+		text    []byte
+		funcs   map[uint32]int64
+		ok      bool
+	}{
+		{"Exit", SBPFVersionV1, makeText(exit), nil, true},
+		{"Empty", SBPFVersionV1, nil, nil, false},
+		{"OddSize", SBPFVersionV1, make([]byte, 12), nil, false},
+		{"UnknownOpcode", SBPFVersionV1, makeText(makeSlot(0x06, 0, 0, 0, 0), exit), nil, false},
+		{"Sdiv", SBPFVersionV1, makeText(makeSlot(OpSdiv64Imm, 0, 0, 0, 1), exit), nil, false},
+		{"Arsh64Reg", SBPFVersionV1, makeText(makeSlot(OpArsh64Reg, 1, 2, 0, 0), exit), nil, true},
+		{"DivByZero", SBPFVersionV1, makeText(makeSlot(OpDiv64Imm, 0, 0, 0, 0), exit), nil, false},
+		{"ShiftOverflow", SBPFVersionV1, makeText(makeSlot(OpLsh32Imm, 0, 0, 0, 32), exit), nil, false},
+		{"BadEndian", SBPFVersionV1, makeText(makeSlot(OpBe, 0, 0, 0, 8), exit), nil, false},
+		{"InvalidSrc", SBPFVersionV1, makeText(makeSlot(OpMov64Reg, 0, 11, 0, 0), exit), nil, false},
+		{"InvalidDst", SBPFVersionV1, makeText(makeSlot(OpMov64Imm, 11, 0, 0, 0), exit), nil, false},
+		{"WriteR10", SBPFVersionV1, makeText(makeSlot(OpMov64Imm, 10, 0, 0, 0), exit), nil, false},
+		{"StoreR10", SBPFVersionV1, makeText(makeSlot(OpStxdw, 10, 1, -8, 0), exit), nil, true},
+		{"AddR10V1", SBPFVersionV1, makeText(makeSlot(OpAdd64Imm, 10, 0, 0, -64), exit), nil, false},
+		{"AddR10V2", SBPFVersionV2, makeText(makeSlot(OpAdd64Imm, 10, 0, 0, -64), exit), nil, true},
+		{"JumpForward", SBPFVersionV1, makeText(makeSlot(OpJa, 0, 0, 0, 0), exit), nil, true},
+		{"JumpOutOfCode", SBPFVersionV1, makeText(makeSlot(OpJa, 0, 0, 1, 0), exit), nil, false},
+		{"JumpNegative", SBPFVersionV1, makeText(makeSlot(OpJa, 0, 0, -2, 0), exit), nil, false},
+		{"JumpIntoLddw", SBPFVersionV1, makeText(makeSlot(OpJa, 0, 0, 1, 0), makeSlot(OpLddw, 0, 0, 0, 1), makeSlot(0, 0, 0, 0, 0), exit), nil, false},
+		{"Lddw", SBPFVersionV1, makeText(makeSlot(OpLddw, 0, 0, 0, 1), makeSlot(0, 0, 0, 0, 0), exit), nil, true},
+		{"LddwV2", SBPFVersionV2, makeText(makeSlot(OpLddw, 0, 0, 0, 1), makeSlot(0, 0, 0, 0, 0), exit), nil, false},
+		{"LddwLast", SBPFVersionV1, makeText(exit, makeSlot(OpLddw, 0, 0, 0, 1)), nil, false},
+		{"LddwIncomplete", SBPFVersionV1, makeText(makeSlot(OpLddw, 0, 0, 0, 1), exit), nil, false},
+		{"NegV2", SBPFVersionV2, makeText(makeSlot(OpNeg64, 0, 0, 0, 0), exit), nil, false},
+		{"CallxImm", SBPFVersionV1, makeText(makeSlot(OpCallx, 0, 0, 0, 2), exit), nil, true},
+		{"CallxR10", SBPFVersionV1, makeText(makeSlot(OpCallx, 0, 0, 0, 10), exit), nil, false},
+		{"CallxSrcV2", SBPFVersionV2, makeText(makeSlot(OpCallx, 0, 2, 0, 10), exit), nil, true},
+		{"CallDest", SBPFVersionV1, makeText(makeSlot(OpCall, 0, 0, 0, 1234), exit), map[uint32]int64{1234: 1}, true},
+		{"CallDestOutOfCode", SBPFVersionV1, makeText(makeSlot(OpCall, 0, 0, 0, 1234), exit), map[uint32]int64{1234: 2}, false},
+		{"CallDestIntoLddw", SBPFVersionV1, makeText(makeSlot(OpLddw, 0, 0, 0, 1), makeSlot(0, 0, 0, 0, 0), exit), map[uint32]int64{1234: 1}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			program := &Program{
+				Text:    tc.text,
+				Funcs:   tc.funcs,
+				Version: tc.version,
+			}
+			err := program.Verify()
+			if tc.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"go.firedancer.io/radiance/pkg/cu"
 	"go.firedancer.io/radiance/pkg/global"
 )
 
@@ -13,6 +14,7 @@ type VM interface {
 	GlobalCtx() *global.GlobalCtx
 
 	Translate(addr uint64, size uint64, write bool) ([]byte, error)
+	Region(addr uint64) (*MemoryRegion, error)
 
 	Read(addr uint64, p []byte) error
 	Read8(addr uint64) (uint8, error)
@@ -27,6 +29,14 @@ type VM interface {
 	Write64(addr uint64, x uint64) error
 }
 
+// Executor runs a program on the virtual machine.
+type Executor interface {
+	VM
+
+	// Run executes the program and returns the value of r0 on exit.
+	Run() (uint64, error)
+}
+
 // VMOpts specifies virtual machine parameters.
 type VMOpts struct {
 	// Machine parameters
@@ -38,6 +48,15 @@ type VMOpts struct {
 	Context any // passed to syscalls
 	MaxCU   int
 	Input   []byte // mapped at VaddrInput
+
+	// InputRegions, if set, are mapped at VaddrInput instead of Input.
+	// Used to map account data directly, without copying.
+	InputRegions []MemoryRegion
+
+	// ComputeMeter is charged one compute unit per executed instruction.
+	// It is typically shared with syscalls, which charge their own costs.
+	// If nil, a meter with a budget of MaxCU is used.
+	ComputeMeter *cu.ComputeMeter
 }
 
 type Exception struct {
@@ -0,0 +1,108 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"k8s.io/klog/v2"
+)
+
+// MaxTxAccountLocks is the maximum number of accounts a transaction may lock.
+const MaxTxAccountLocks = 128
+
+// TransactionAccountLocks are the accounts locked by a transaction while it
+// executes: its writable accounts are locked exclusively, and its readonly
+// accounts are shared with other readers.
+type TransactionAccountLocks struct {
+	Writable []solana.PublicKey
+	Readonly []solana.PublicKey
+}
+
+// NewTransactionAccountLocks returns the account locks of a transaction
+// with the given account keys and writability.
+func NewTransactionAccountLocks(accountKeys []solana.PublicKey, isWritable []bool) TransactionAccountLocks {
+	var locks TransactionAccountLocks
+	for idx, key := range accountKeys {
+		if idx < len(isWritable) && isWritable[idx] {
+			locks.Writable = append(locks.Writable, key)
+		} else {
+			locks.Readonly = append(locks.Readonly, key)
+		}
+	}
+	return locks
+}
+
+// Validate checks that a transaction locks at most MaxTxAccountLocks
+// accounts, and locks each of them once.
+//
+// Based on solana_sdk::transaction::sanitized::SanitizedTransaction::validate_account_locks.
+func (locks *TransactionAccountLocks) Validate() error {
+	if len(locks.Writable)+len(locks.Readonly) > MaxTxAccountLocks {
+		return TxErrTooManyAccountLocks
+	}
+
+	seen := make(map[solana.PublicKey]bool, len(locks.Writable)+len(locks.Readonly))
+	for _, keys := range [][]solana.PublicKey{locks.Writable, locks.Readonly} {
+		for _, key := range keys {
+			if seen[key] {
+				klog.Infof("account %s loaded twice", key)
+				return TxErrAccountLoadedTwice
+			}
+			seen[key] = true
+		}
+	}
+	return nil
+}
+
+// AccountLocks is a table of the accounts locked by executing transactions.
+// An account is either write locked by a single transaction, or read locked
+// by any number of transactions. AccountLocks is not safe for concurrent
+// use; callers synchronize access to it.
+//
+// Based on solana_accounts_db::accounts::AccountLocks.
+type AccountLocks struct {
+	writeLocks    map[solana.PublicKey]bool
+	readonlyLocks map[solana.PublicKey]uint64
+}
+
+func NewAccountLocks() *AccountLocks {
+	return &AccountLocks{
+		writeLocks:    make(map[solana.PublicKey]bool),
+		readonlyLocks: make(map[solana.PublicKey]uint64),
+	}
+}
+
+// TryLock locks the accounts of a transaction, or returns TxErrAccountInUse,
+// locking none of them, if any of them conflicts with a held lock.
+func (al *AccountLocks) TryLock(locks *TransactionAccountLocks) error {
+	for _, key := range locks.Writable {
+		if al.writeLocks[key] || al.readonlyLocks[key] != 0 {
+			return TxErrAccountInUse
+		}
+	}
+	for _, key := range locks.Readonly {
+		if al.writeLocks[key] {
+			return TxErrAccountInUse
+		}
+	}
+
+	for _, key := range locks.Writable {
+		al.writeLocks[key] = true
+	}
+	for _, key := range locks.Readonly {
+		al.readonlyLocks[key]++
+	}
+	return nil
+}
+
+// Unlock releases the locks taken by TryLock for a transaction.
+func (al *AccountLocks) Unlock(locks *TransactionAccountLocks) {
+	for _, key := range locks.Writable {
+		delete(al.writeLocks, key)
+	}
+	for _, key := range locks.Readonly {
+		if count := al.readonlyLocks[key]; count <= 1 {
+			delete(al.readonlyLocks, key)
+		} else {
+			al.readonlyLocks[key] = count - 1
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package sealevel
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// LookupTableMetaSize is the size of the serialized ProgramState of an
+// address lookup table account, including padding. The addresses of the
+// table follow it.
+const LookupTableMetaSize = 56
+
+// address lookup table program states
+const (
+	LookupTableProgramStateUninitialized = iota
+	LookupTableProgramStateLookupTable
+)
+
+var (
+	errLookupTableUninitialized   = errors.New("address lookup table is uninitialized")
+	errLookupTableInvalidData     = errors.New("invalid address lookup table data")
+	errLookupTableNotActive       = errors.New("address lookup table is not active")
+	errLookupTableIndexOutOfRange = errors.New("address lookup table index out of range")
+)
+
+// LookupTableMeta is the metadata of an address lookup table.
+type LookupTableMeta struct {
+	// DeactivationSlot is the slot the table was deactivated in, or
+	// math.MaxUint64 if it is active.
+	DeactivationSlot uint64
+
+	// LastExtendedSlot is the last slot addresses were appended in, and
+	// LastExtendedSlotStartIndex the number of addresses before then.
+	// Addresses appended in the current slot cannot be looked up yet.
+	LastExtendedSlot           uint64
+	LastExtendedSlotStartIndex uint8
+
+	Authority *solana.PublicKey
+}
+
+// AddressLookupTable is the state of an address lookup table account.
+//
+// Based on solana_program::address_lookup_table::state::AddressLookupTable.
+type AddressLookupTable struct {
+	Meta      LookupTableMeta
+	Addresses []solana.PublicKey
+}
+
+func (meta *LookupTableMeta) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
+	meta.DeactivationSlot, err = decoder.ReadUint64(bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to read DeactivationSlot when decoding LookupTableMeta: %w", err)
+	}
+	meta.LastExtendedSlot, err = decoder.ReadUint64(bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to read LastExtendedSlot when decoding LookupTableMeta: %w", err)
+	}
+	meta.LastExtendedSlotStartIndex, err = decoder.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("failed to read LastExtendedSlotStartIndex when decoding LookupTableMeta: %w", err)
+	}
+	hasAuthority, err := decoder.ReadBool()
+	if err != nil {
+		return fmt.Errorf("failed to read Authority when decoding LookupTableMeta: %w", err)
+	}
+	if hasAuthority {
+		pk, err := decoder.ReadBytes(solana.PublicKeyLength)
+		if err != nil {
+			return fmt.Errorf("failed to read Authority when decoding LookupTableMeta: %w", err)
+		}
+		authority := solana.PublicKeyFromBytes(pk)
+		meta.Authority = &authority
+	}
+	return nil
+}
+
+// UnmarshalAddressLookupTable decodes the data of an address lookup table
+// account.
+func UnmarshalAddressLookupTable(data []byte) (*AddressLookupTable, error) {
+	if len(data) < LookupTableMetaSize {
+		return nil, errLookupTableInvalidData
+	}
+
+	decoder := bin.NewBinDecoder(data[:LookupTableMetaSize])
+	state, err := decoder.ReadUint32(bin.LE)
+	if err != nil {
+		return nil, errLookupTableInvalidData
+	}
+	switch state {
+	case LookupTableProgramStateUninitialized:
+		return nil, errLookupTableUninitialized
+	case LookupTableProgramStateLookupTable:
+	default:
+		return nil, errLookupTableInvalidData
+	}
+
+	var table AddressLookupTable
+	err = table.Meta.UnmarshalWithDecoder(decoder)
+	if err != nil {
+		return nil, errLookupTableInvalidData
+	}
+
+	rawAddresses := data[LookupTableMetaSize:]
+	if len(rawAddresses)%solana.PublicKeyLength != 0 {
+		return nil, errLookupTableInvalidData
+	}
+	table.Addresses = make([]solana.PublicKey, len(rawAddresses)/solana.PublicKeyLength)
+	for i := range table.Addresses {
+		copy(table.Addresses[i][:], rawAddresses[i*solana.PublicKeyLength:])
+	}
+	return &table, nil
+}
+
+// IsActive returns whether addresses may be looked up in the table. A
+// deactivated table remains usable while its deactivation slot is among
+// the recent slot hashes, so that it cannot be closed and recreated with
+// different addresses while transactions referencing it may still land.
+//
+// Based on solana_program::address_lookup_table::state::LookupTableMeta::status.
+func (meta *LookupTableMeta) IsActive(currentSlot uint64, slotHashes SysvarSlotHashes) bool {
+	if meta.DeactivationSlot == math.MaxUint64 || meta.DeactivationSlot == currentSlot {
+		return true
+	}
+	for _, slotHash := range slotHashes {
+		if slotHash.Slot == meta.DeactivationSlot {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the addresses at the given indexes of the table, which
+// must be active. Addresses appended in the current slot are not yet
+// visible.
+//
+// Based on solana_program::address_lookup_table::state::AddressLookupTable::lookup.
+func (table *AddressLookupTable) Lookup(currentSlot uint64, indexes []uint8, slotHashes SysvarSlotHashes) ([]solana.PublicKey, error) {
+	if !table.Meta.IsActive(currentSlot, slotHashes) {
+		return nil, errLookupTableNotActive
+	}
+
+	activeLen := uint64(len(table.Addresses))
+	if currentSlot <= table.Meta.LastExtendedSlot {
+		activeLen = uint64(table.Meta.LastExtendedSlotStartIndex)
+	}
+
+	addresses := make([]solana.PublicKey, 0, len(indexes))
+	for _, idx := range indexes {
+		if uint64(idx) >= activeLen {
+			return nil, errLookupTableIndexOutOfRange
+		}
+		addresses = append(addresses, table.Addresses[idx])
+	}
+	return addresses, nil
+}
@@ -0,0 +1,411 @@
+package sealevel
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// alt_bn128 (BN254) operations, using the big-endian point encoding of
+// EIP-196 and EIP-197. The point at infinity is encoded as all zeros.
+//
+// Based on solana_program::alt_bn128.
+const (
+	AltBn128AdditionInputLen        = 128
+	AltBn128MultiplicationInputLen  = 128
+	AltBn128PairingElementLen       = 192
+	AltBn128AdditionOutputLen       = 64
+	AltBn128MultiplicationOutputLen = 64
+	AltBn128PairingOutputLen        = 32
+
+	AltBn128G1Size           = 64
+	AltBn128G2Size           = 128
+	AltBn128G1CompressedSize = 32
+	AltBn128G2CompressedSize = 64
+)
+
+var (
+	errAltBn128InvalidInputData      = errors.New("alt_bn128: invalid input data")
+	errAltBn128InvalidInputSize      = errors.New("alt_bn128: invalid input size")
+	errAltBn128G1DecompressionFailed = errors.New("alt_bn128: G1 decompression failed")
+	errAltBn128G2DecompressionFailed = errors.New("alt_bn128: G2 decompression failed")
+)
+
+// altBn128Addition adds the two G1 points in the input, which is
+// zero-padded to AltBn128AdditionInputLen.
+func altBn128Addition(input []byte) ([]byte, error) {
+	if len(input) > AltBn128AdditionInputLen {
+		return nil, errAltBn128InvalidInputData
+	}
+	buf := make([]byte, AltBn128AdditionInputLen)
+	copy(buf, input)
+
+	p, err := unmarshalAltBn128G1(buf[:64])
+	if err != nil {
+		return nil, err
+	}
+	q, err := unmarshalAltBn128G1(buf[64:128])
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).Add(p, q).Marshal(), nil
+}
+
+// altBn128Multiplication multiplies the G1 point in the input by the
+// 32 byte scalar following it. The input is zero-padded to
+// AltBn128MultiplicationInputLen.
+func altBn128Multiplication(input []byte) ([]byte, error) {
+	if len(input) > AltBn128MultiplicationInputLen {
+		return nil, errAltBn128InvalidInputData
+	}
+	buf := make([]byte, AltBn128MultiplicationInputLen)
+	copy(buf, input)
+
+	p, err := unmarshalAltBn128G1(buf[:64])
+	if err != nil {
+		return nil, err
+	}
+	scalar := new(big.Int).SetBytes(buf[64:96])
+	return new(bn256.G1).ScalarMult(p, scalar).Marshal(), nil
+}
+
+// altBn128Pairing checks whether the product of the pairings of the
+// (G1, G2) pairs in the input is one. Returns 1 if so, 0 otherwise, as a
+// 32 byte big-endian integer. Trailing bytes short of a pair are ignored.
+func altBn128Pairing(input []byte) ([]byte, error) {
+	n := len(input) / AltBn128PairingElementLen
+	g1s := make([]*bn256.G1, 0, n)
+	g2s := make([]*bn256.G2, 0, n)
+	for i := 0; i < n; i++ {
+		elem := input[i*AltBn128PairingElementLen : (i+1)*AltBn128PairingElementLen]
+		g1, err := unmarshalAltBn128G1(elem[:64])
+		if err != nil {
+			return nil, err
+		}
+		g2, err := unmarshalAltBn128G2(elem[64:])
+		if err != nil {
+			return nil, err
+		}
+		g1s = append(g1s, g1)
+		g2s = append(g2s, g2)
+	}
+
+	result := make([]byte, AltBn128PairingOutputLen)
+	if bn256.PairingCheck(g1s, g2s) {
+		result[AltBn128PairingOutputLen-1] = 1
+	}
+	return result, nil
+}
+
+func unmarshalAltBn128G1(b []byte) (*bn256.G1, error) {
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(b); err != nil {
+		return nil, errAltBn128InvalidInputData
+	}
+	return p, nil
+}
+
+// unmarshalAltBn128G2 decodes a G2 point, which unlike G1 points must also
+// be checked to be in the prime order subgroup.
+func unmarshalAltBn128G2(b []byte) (*bn256.G2, error) {
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(b); err != nil {
+		return nil, errAltBn128InvalidInputData
+	}
+	if !isZero(new(bn256.G2).ScalarMult(p, bn256.Order).Marshal()) {
+		return nil, errAltBn128InvalidInputData
+	}
+	return p, nil
+}
+
+// Point compression uses the arkworks encoding: the x coordinate, with the
+// two most significant bits of the first byte holding flags.
+const (
+	altBn128FlagNegative = 1 << 7 // y is the greater of the two roots
+	altBn128FlagInfinity = 1 << 6 // point at infinity
+)
+
+// altBn128G1Compress compresses a G1 point. The point is not validated.
+func altBn128G1Compress(input []byte) ([]byte, error) {
+	if len(input) != AltBn128G1Size {
+		return nil, errAltBn128InvalidInputSize
+	}
+	out := make([]byte, AltBn128G1CompressedSize)
+	if isZero(input) {
+		return out, nil
+	}
+
+	x, ok := parseFp(input[:32])
+	if !ok {
+		return nil, errAltBn128G1DecompressionFailed
+	}
+	y, flags, ok := parseFlaggedFp(input[32:64])
+	if !ok {
+		return nil, errAltBn128G1DecompressionFailed
+	}
+	if flags&altBn128FlagInfinity != 0 {
+		out[0] = altBn128FlagInfinity
+		return out, nil
+	}
+
+	x.FillBytes(out)
+	if fpIsNegative(y) {
+		out[0] |= altBn128FlagNegative
+	}
+	return out, nil
+}
+
+// altBn128G1Decompress recovers a G1 point from its x coordinate and the
+// sign of its y coordinate.
+func altBn128G1Decompress(input []byte) ([]byte, error) {
+	if len(input) != AltBn128G1CompressedSize {
+		return nil, errAltBn128InvalidInputSize
+	}
+	out := make([]byte, AltBn128G1Size)
+	if isZero(input) {
+		return out, nil
+	}
+
+	x, flags, ok := parseFlaggedFp(input)
+	if !ok {
+		return nil, errAltBn128G1DecompressionFailed
+	}
+	if flags&altBn128FlagInfinity != 0 {
+		return out, nil
+	}
+
+	// y² = x³ + 3
+	y2 := fpMul(fpMul(x, x), x)
+	y2 = fpAdd(y2, big.NewInt(3))
+	y, ok := fpSqrt(y2)
+	if !ok {
+		return nil, errAltBn128G1DecompressionFailed
+	}
+	if fpIsNegative(y) != (flags&altBn128FlagNegative != 0) {
+		y = fpNeg(y)
+	}
+
+	x.FillBytes(out[:32])
+	y.FillBytes(out[32:])
+	return out, nil
+}
+
+// altBn128G2Compress compresses a G2 point. The point is not validated.
+func altBn128G2Compress(input []byte) ([]byte, error) {
+	if len(input) != AltBn128G2Size {
+		return nil, errAltBn128InvalidInputSize
+	}
+	out := make([]byte, AltBn128G2CompressedSize)
+	if isZero(input) {
+		return out, nil
+	}
+
+	x, ok := parseFp2(input[:64])
+	if !ok {
+		return nil, errAltBn128G2DecompressionFailed
+	}
+	y, flags, ok := parseFlaggedFp2(input[64:128])
+	if !ok {
+		return nil, errAltBn128G2DecompressionFailed
+	}
+	if flags&altBn128FlagInfinity != 0 {
+		out[0] = altBn128FlagInfinity
+		return out, nil
+	}
+
+	x.fillBytes(out)
+	if y.isNegative() {
+		out[0] |= altBn128FlagNegative
+	}
+	return out, nil
+}
+
+// altBn128G2Decompress recovers a G2 point from its x coordinate and the
+// sign of its y coordinate.
+func altBn128G2Decompress(input []byte) ([]byte, error) {
+	if len(input) != AltBn128G2CompressedSize {
+		return nil, errAltBn128InvalidInputSize
+	}
+	out := make([]byte, AltBn128G2Size)
+	if isZero(input) {
+		return out, nil
+	}
+
+	x, flags, ok := parseFlaggedFp2(input)
+	if !ok {
+		return nil, errAltBn128G2DecompressionFailed
+	}
+	if flags&altBn128FlagInfinity != 0 {
+		return out, nil
+	}
+
+	// y² = x³ + b, with b = 3/(9+i) on the twist
+	y2 := x.mul(x).mul(x).add(altBn128TwistB)
+	y, ok := y2.sqrt()
+	if !ok {
+		return nil, errAltBn128G2DecompressionFailed
+	}
+	if y.isNegative() != (flags&altBn128FlagNegative != 0) {
+		y = y.neg()
+	}
+
+	x.fillBytes(out[:64])
+	y.fillBytes(out[64:])
+	return out, nil
+}
+
+func isZero(b []byte) bool {
+	return len(bytes.TrimLeft(b, "\x00")) == 0
+}
+
+// arithmetic over Fp, and Fp2 = Fp[i]/(i²+1)
+
+func parseFp(b []byte) (*big.Int, bool) {
+	v := new(big.Int).SetBytes(b)
+	return v, v.Cmp(bn256.P) < 0
+}
+
+// parseFlaggedFp parses a field element carrying flags in its most
+// significant bits. Both flags being set is invalid.
+func parseFlaggedFp(b []byte) (*big.Int, byte, bool) {
+	flags := b[0] & (altBn128FlagNegative | altBn128FlagInfinity)
+	if flags == altBn128FlagNegative|altBn128FlagInfinity {
+		return nil, 0, false
+	}
+	masked := append([]byte{}, b...)
+	masked[0] &^= flags
+	v, ok := parseFp(masked)
+	return v, flags, ok
+}
+
+func fpAdd(a, b *big.Int) *big.Int {
+	r := new(big.Int).Add(a, b)
+	return r.Mod(r, bn256.P)
+}
+
+func fpSub(a, b *big.Int) *big.Int {
+	r := new(big.Int).Sub(a, b)
+	return r.Mod(r, bn256.P)
+}
+
+func fpMul(a, b *big.Int) *big.Int {
+	r := new(big.Int).Mul(a, b)
+	return r.Mod(r, bn256.P)
+}
+
+func fpNeg(a *big.Int) *big.Int {
+	return fpSub(new(big.Int), a)
+}
+
+// fpIsNegative returns whether a is greater than -a.
+func fpIsNegative(a *big.Int) bool {
+	return a.Cmp(fpNeg(a)) > 0
+}
+
+// fpSqrt returns a square root of a, using p ≡ 3 (mod 4).
+func fpSqrt(a *big.Int) (*big.Int, bool) {
+	exp := new(big.Int).Add(bn256.P, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	r := new(big.Int).Exp(a, exp, bn256.P)
+	return r, fpMul(r, r).Cmp(a) == 0
+}
+
+// fp2 is c0 + c1*i.
+type fp2 struct {
+	c0, c1 *big.Int
+}
+
+var altBn128TwistB = func() fp2 {
+	// 3/(9+i) = 3(9-i)/82
+	inv82 := new(big.Int).ModInverse(big.NewInt(82), bn256.P)
+	return fp2{fpMul(big.NewInt(27), inv82), fpNeg(fpMul(big.NewInt(3), inv82))}
+}()
+
+// parseFp2 parses an Fp2 element encoded as c1 followed by c0.
+func parseFp2(b []byte) (fp2, bool) {
+	c1, ok1 := parseFp(b[:32])
+	c0, ok0 := parseFp(b[32:64])
+	return fp2{c0, c1}, ok0 && ok1
+}
+
+// parseFlaggedFp2 parses an Fp2 element carrying flags in its c1 component.
+func parseFlaggedFp2(b []byte) (fp2, byte, bool) {
+	c1, flags, ok1 := parseFlaggedFp(b[:32])
+	c0, ok0 := parseFp(b[32:64])
+	return fp2{c0, c1}, flags, ok0 && ok1
+}
+
+func (a fp2) fillBytes(b []byte) {
+	a.c1.FillBytes(b[:32])
+	a.c0.FillBytes(b[32:64])
+}
+
+func (a fp2) add(b fp2) fp2 {
+	return fp2{fpAdd(a.c0, b.c0), fpAdd(a.c1, b.c1)}
+}
+
+func (a fp2) mul(b fp2) fp2 {
+	return fp2{
+		fpSub(fpMul(a.c0, b.c0), fpMul(a.c1, b.c1)),
+		fpAdd(fpMul(a.c0, b.c1), fpMul(a.c1, b.c0)),
+	}
+}
+
+func (a fp2) neg() fp2 {
+	return fp2{fpNeg(a.c0), fpNeg(a.c1)}
+}
+
+func (a fp2) equal(b fp2) bool {
+	return a.c0.Cmp(b.c0) == 0 && a.c1.Cmp(b.c1) == 0
+}
+
+func (a fp2) exp(e *big.Int) fp2 {
+	r := fp2{big.NewInt(1), new(big.Int)}
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = r.mul(r)
+		if e.Bit(i) == 1 {
+			r = r.mul(a)
+		}
+	}
+	return r
+}
+
+// isNegative returns whether a is greater than -a, comparing c1 first.
+func (a fp2) isNegative() bool {
+	n := a.neg()
+	if c := a.c1.Cmp(n.c1); c != 0 {
+		return c > 0
+	}
+	return a.c0.Cmp(n.c0) > 0
+}
+
+// sqrt returns a square root of a, using p ≡ 3 (mod 4).
+//
+// Based on Algorithm 9 of "Square root computation over even extension
+// fields" (Adj, Rodríguez-Henríquez).
+func (a fp2) sqrt() (fp2, bool) {
+	one := fp2{big.NewInt(1), new(big.Int)}
+	minusOne := one.neg()
+
+	e := new(big.Int).Sub(bn256.P, big.NewInt(3))
+	e.Rsh(e, 2)
+	a1 := a.exp(e)
+	alpha := a1.mul(a1).mul(a)
+	conj := fp2{alpha.c0, fpNeg(alpha.c1)} // alpha^p
+	if conj.mul(alpha).equal(minusOne) {
+		return fp2{}, false
+	}
+
+	x0 := a1.mul(a)
+	var x fp2
+	if alpha.equal(minusOne) {
+		x = fp2{fpNeg(x0.c1), x0.c0} // i * x0
+	} else {
+		e := new(big.Int).Sub(bn256.P, big.NewInt(1))
+		e.Rsh(e, 1)
+		x = one.add(alpha).exp(e).mul(x0)
+	}
+	return x, x.mul(x).equal(a)
+}
@@ -0,0 +1,129 @@
+package sealevel
+
+import (
+	"math/big"
+	"testing"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestAltBn128GroupOps(t *testing.T) {
+	g := new(bn256.G1).ScalarBaseMult(big.NewInt(1)).Marshal()
+
+	sum, err := altBn128Addition(append(append([]byte{}, g...), g...))
+	require.NoError(t, err)
+	assert.Equal(t, new(bn256.G1).ScalarBaseMult(big.NewInt(2)).Marshal(), sum)
+
+	// the input is zero-padded, adding the point at infinity
+	sum, err = altBn128Addition(g)
+	require.NoError(t, err)
+	assert.Equal(t, g, sum)
+
+	input := make([]byte, AltBn128MultiplicationInputLen)
+	copy(input, g)
+	input[95] = 3
+	product, err := altBn128Multiplication(input)
+	require.NoError(t, err)
+	assert.Equal(t, new(bn256.G1).ScalarBaseMult(big.NewInt(3)).Marshal(), product)
+
+	// (1, 3) is not on the curve
+	invalid := make([]byte, 128)
+	invalid[31], invalid[63] = 1, 3
+	_, err = altBn128Addition(invalid)
+	assert.Error(t, err)
+}
+
+func TestAltBn128Pairing(t *testing.T) {
+	// e(6 G1, G2) * e(-2 G1, 3 G2) == 1
+	pair := func(a, b int64) []byte {
+		g1 := new(bn256.G1).ScalarBaseMult(big.NewInt(a))
+		g2 := new(bn256.G2).ScalarBaseMult(big.NewInt(b))
+		return append(g1.Marshal(), g2.Marshal()...)
+	}
+	neg2 := new(big.Int).Sub(bn256.Order, big.NewInt(2))
+	negPair := append(new(bn256.G1).ScalarBaseMult(neg2).Marshal(), new(bn256.G2).ScalarBaseMult(big.NewInt(3)).Marshal()...)
+
+	one := make([]byte, 32)
+	one[31] = 1
+
+	result, err := altBn128Pairing(append(pair(6, 1), negPair...))
+	require.NoError(t, err)
+	assert.Equal(t, one, result)
+
+	result, err = altBn128Pairing(append(pair(5, 1), negPair...))
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 32), result)
+
+	result, err = altBn128Pairing(nil)
+	require.NoError(t, err)
+	assert.Equal(t, one, result)
+}
+
+func TestAltBn128Compression(t *testing.T) {
+	for _, k := range []int64{1, 2, 7, -1, -5} {
+		scalar := big.NewInt(k)
+		if k < 0 {
+			scalar.Add(bn256.Order, scalar)
+		}
+
+		g1 := new(bn256.G1).ScalarBaseMult(scalar).Marshal()
+		compressed, err := altBn128G1Compress(g1)
+		require.NoError(t, err)
+		decompressed, err := altBn128G1Decompress(compressed)
+		require.NoError(t, err)
+		assert.Equal(t, g1, decompressed)
+
+		g2 := new(bn256.G2).ScalarBaseMult(scalar).Marshal()
+		compressed, err = altBn128G2Compress(g2)
+		require.NoError(t, err)
+		decompressed, err = altBn128G2Decompress(compressed)
+		require.NoError(t, err)
+		assert.Equal(t, g2, decompressed)
+	}
+
+	// the point at infinity
+	out, err := altBn128G1Decompress(make([]byte, 32))
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 64), out)
+
+	_, err = altBn128G1Compress(make([]byte, 63))
+	assert.Equal(t, errAltBn128InvalidInputSize, err)
+}
+
+func TestSyscallAltBn128(t *testing.T) {
+	execCtx := &ExecutionCtx{ComputeMeter: cu.NewComputeMeter(CUMaxComputeUnitLimit)}
+	execCtx.GlobalCtx.Features = *features.NewFeaturesDefault()
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	const inputAddr, resultAddr = sbpf.VaddrHeap, sbpf.VaddrHeap + 0x100
+	g := new(bn256.G1).ScalarBaseMult(big.NewInt(1)).Marshal()
+	require.NoError(t, vm.Write(inputAddr, g))
+	require.NoError(t, vm.Write(inputAddr+64, g))
+
+	r0, err := SyscallAltBn128Impl(vm, AltBn128Add, inputAddr, 0, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), r0)
+	result, err := vm.Translate(resultAddr, 64, false)
+	require.NoError(t, err)
+	assert.Equal(t, new(bn256.G1).ScalarBaseMult(big.NewInt(2)).Marshal(), result)
+	assert.Equal(t, uint64(CUMaxComputeUnitLimit-CUAltBn128AdditionCost), execCtx.ComputeMeter.Remaining())
+
+	// invalid points are reported in r0
+	require.NoError(t, vm.Write8(inputAddr+63, 3))
+	r0, err = SyscallAltBn128Impl(vm, AltBn128Add, inputAddr, 0, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(AltBn128ErrInvalidInputData), r0)
+
+	_, err = SyscallAltBn128Impl(vm, AltBn128Sub, inputAddr, 0, resultAddr)
+	assert.Equal(t, SyscallErrInvalidAttribute, err)
+
+	r0, err = SyscallAltBn128CompressionImpl(vm, AltBn128G1Decompress, inputAddr, 31, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(AltBn128CompressionErrInvalidInputSize), r0)
+}
@@ -13,6 +13,18 @@ type BorrowedAccount struct {
 	IndexInTransaction uint64
 	IndexInInstruction uint64
 	Account            *accounts.Account
+
+	released bool
+}
+
+// Drop releases the borrow of the account, so that it may be borrowed
+// again. The account must not be used once dropped.
+func (acct *BorrowedAccount) Drop() {
+	if acct == nil || acct.released {
+		return
+	}
+	acct.released = true
+	acct.TxCtx.Accounts.release(acct.IndexInTransaction)
 }
 
 func (acct *BorrowedAccount) Owner() solana.PublicKey {
@@ -71,7 +83,9 @@ func (acct *BorrowedAccount) SetState(f features.Features, data []byte) error {
 		return InstrErrAccountDataTooSmall
 	}
 
-	acct.Account.SetData(data)
+	// the serialized state overwrites the start of the account's data,
+	// leaving the length of the account and any trailing bytes untouched
+	copy(acct.Account.Data, data)
 	return nil
 }
 
@@ -98,7 +112,7 @@ func (acct *BorrowedAccount) SetOwner(f features.Features, owner solana.PublicKe
 		return InstrErrModifiedProgramId
 	}
 
-	if acct.IsExecutable() {
+	if acct.IsExecutable(f) {
 		return InstrErrModifiedProgramId
 	}
 
@@ -141,8 +155,30 @@ func (acct *BorrowedAccount) Key() solana.PublicKey {
 	return key
 }
 
-func (acct *BorrowedAccount) IsExecutable() bool {
-	return acct.Account.IsBuiltin() || acct.Account.IsExecutable()
+// IsExecutable returns whether the account is executable under the given
+// features.
+func (acct *BorrowedAccount) IsExecutable(f features.Features) bool {
+	return isExecutable(acct.Account, f)
+}
+
+// isExecutable returns whether the account is executable. Once
+// deprecate_executable_meta_update_in_bpf_loader is active, the upgradeable
+// loader no longer sets the executable flag of the programs it deploys, so
+// builtins and upgradeable programs are executable by their state alone.
+//
+// Based on solana_sdk::account::is_executable.
+func isExecutable(acct *accounts.Account, f features.Features) bool {
+	if !f.IsActive(features.DeprecateExecutableMetaUpdateInBpfLoader) {
+		return acct.IsExecutable()
+	}
+	if acct.IsExecutable() || acct.IsBuiltin() {
+		return true
+	}
+	if acct.Owner != BpfLoaderUpgradeableAddr {
+		return false
+	}
+	state, err := unmarshalUpgradeableLoaderState(acct.Data)
+	return err == nil && state.Type == UpgradeableLoaderStateTypeProgram
 }
 
 func (acct *BorrowedAccount) IsWritable() bool {
@@ -169,7 +205,7 @@ func (acct *BorrowedAccount) IsOwnedByCurrentProgram() bool {
 }
 
 func (acct *BorrowedAccount) DataCanBeChanged(features features.Features) error {
-	if acct.IsExecutable() {
+	if acct.IsExecutable(features) {
 		return InstrErrExecutableDataModified
 	}
 	if !acct.IsWritable() {
@@ -183,6 +219,16 @@ func (acct *BorrowedAccount) DataCanBeChanged(features features.Features) error
 
 const MaxPermittedDataLength = 10 * 1024 * 1024
 
+// MaxPermittedDataIncrease is the most a program may grow the data of an
+// account by in a single instruction.
+const MaxPermittedDataIncrease = 10 * 1024
+
+// CanDataBeResized returns whether the account data may be resized to
+// newLen. Only the owner may resize an account, up to
+// MaxPermittedDataLength, and the accounts of a transaction may grow by at
+// most MaxAccountsDataAllocsPerTx in total.
+//
+// Based on solana_sdk::transaction_context::BorrowedAccount::can_data_be_resized.
 func (acct *BorrowedAccount) CanDataBeResized(newLen uint64) error {
 	oldLen := len(acct.Data())
 	if newLen != uint64(oldLen) && !acct.IsOwnedByCurrentProgram() {
@@ -193,7 +239,10 @@ func (acct *BorrowedAccount) CanDataBeResized(newLen uint64) error {
 		return InstrErrInvalidRealloc
 	}
 
-	// TODO: support 'per-transaction maximum'
+	lengthDelta := int64(newLen) - int64(oldLen)
+	if acct.TxCtx.AccountsResizeDelta+lengthDelta > MaxAccountsDataAllocsPerTx {
+		return InstrErrMaxAccountsDataAllocsExceeded
+	}
 
 	return nil
 }
@@ -213,7 +262,10 @@ func (acct *BorrowedAccount) SetDataLength(newLength uint64, f features.Features
 		return nil
 	}
 
-	acct.Touch()
+	err = acct.Touch()
+	if err != nil {
+		return err
+	}
 	acct.UpdateAccountsResizeDelta(newLength)
 	acct.Account.Resize(newLength, 0)
 
@@ -233,7 +285,7 @@ func (acct *BorrowedAccount) SetLamports(lamports uint64, f features.Features) e
 		return InstrErrReadonlyLamportChange
 	}
 
-	if acct.IsExecutable() {
+	if acct.IsExecutable(f) {
 		return InstrErrExecutableLamportChange
 	}
 
@@ -250,7 +302,15 @@ func (acct *BorrowedAccount) SetLamports(lamports uint64, f features.Features) e
 	return nil
 }
 
-func (acct *BorrowedAccount) SetExecutable(isExecutable bool) error {
+// SetExecutable sets the executable flag of the account, which only the
+// owner may set, on writable and rent exempt accounts, and never unset.
+// Once deprecate_executable_meta_update_in_bpf_loader is active, the flag
+// is no longer maintained for upgradeable programs, and left untouched.
+func (acct *BorrowedAccount) SetExecutable(f features.Features, isExecutable bool) error {
+	if f.IsActive(features.DeprecateExecutableMetaUpdateInBpfLoader) && acct.Owner() == BpfLoaderUpgradeableAddr {
+		return nil
+	}
+
 	if !acct.TxCtx.Rent.IsExempt(acct.Lamports(), uint64(len(acct.Data()))) {
 		return InstrErrExecutableAccountNotRentExempt
 	}
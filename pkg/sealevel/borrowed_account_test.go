@@ -0,0 +1,140 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func TestBorrowedAccountSetDataLength(t *testing.T) {
+	owner := solana.PublicKey{1}
+	txCtx := &TransactionCtx{
+		AccountKeys: []solana.PublicKey{owner, {2}, {3}},
+		Accounts: TransactionAccounts{
+			Accounts: []*accounts.Account{
+				{Executable: true},
+				{Lamports: 1, Owner: owner},
+				{Lamports: 1, Owner: solana.PublicKey{4}},
+			},
+			Touched: make([]bool, 3),
+		},
+	}
+	instrCtx := &InstructionCtx{
+		ProgramAccounts: []uint64{0},
+		InstructionAccounts: []InstructionAccount{
+			{IndexInTransaction: 1, IndexInCallee: 0, IsWritable: true},
+			{IndexInTransaction: 2, IndexInCallee: 1, IsWritable: true},
+		},
+	}
+	f := features.NewFeaturesDefault()
+
+	acct, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	require.NoError(t, err)
+	defer acct.Drop()
+
+	// the owner may grow the account, up to the max length
+	require.NoError(t, acct.SetDataLength(MaxPermittedDataIncrease, *f))
+	assert.Len(t, acct.Data(), MaxPermittedDataIncrease)
+	assert.Equal(t, int64(MaxPermittedDataIncrease), txCtx.AccountsResizeDelta)
+	assert.True(t, txCtx.Accounts.Touched[1])
+	assert.Equal(t, InstrErrInvalidRealloc, acct.SetDataLength(MaxPermittedDataLength+1, *f))
+
+	// the accounts of a transaction may only grow by so much in total
+	txCtx.AccountsResizeDelta = MaxAccountsDataAllocsPerTx
+	assert.Equal(t, InstrErrMaxAccountsDataAllocsExceeded, acct.SetDataLength(MaxPermittedDataIncrease+1, *f))
+	require.NoError(t, acct.SetDataLength(0, *f))
+	assert.Equal(t, int64(MaxAccountsDataAllocsPerTx-MaxPermittedDataIncrease), txCtx.AccountsResizeDelta)
+
+	// only the owner may resize an account
+	other, err := instrCtx.BorrowInstructionAccount(txCtx, 1)
+	require.NoError(t, err)
+	defer other.Drop()
+	assert.Equal(t, InstrErrAccountDataSizeChanged, other.SetDataLength(1, *f))
+}
+
+func TestIsExecutable(t *testing.T) {
+	programState, err := marshalUpgradeableLoaderState(&UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgram})
+	require.NoError(t, err)
+	bufferState, err := marshalUpgradeableLoaderState(&UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeBuffer})
+	require.NoError(t, err)
+
+	flagged := &accounts.Account{Executable: true}
+	builtin := &accounts.Account{Owner: NativeLoaderAddr, Data: []byte("builtin")}
+	program := &accounts.Account{Owner: BpfLoaderUpgradeableAddr, Data: programState}
+	buffer := &accounts.Account{Owner: BpfLoaderUpgradeableAddr, Data: bufferState}
+
+	f := features.NewFeaturesDefault()
+	assert.True(t, isExecutable(flagged, *f))
+	assert.False(t, isExecutable(builtin, *f))
+	assert.False(t, isExecutable(program, *f))
+	assert.False(t, isExecutable(buffer, *f))
+
+	// builtins and upgradeable programs no longer need the flag
+	f.EnableFeature(features.DeprecateExecutableMetaUpdateInBpfLoader, 0)
+	assert.True(t, isExecutable(flagged, *f))
+	assert.True(t, isExecutable(builtin, *f))
+	assert.True(t, isExecutable(program, *f))
+	assert.False(t, isExecutable(buffer, *f))
+}
+
+func TestBorrowedAccountExecutableRules(t *testing.T) {
+	programState, err := marshalUpgradeableLoaderState(&UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgram})
+	require.NoError(t, err)
+
+	for _, deprecated := range []bool{false, true} {
+		f := features.NewFeaturesDefault()
+		if deprecated {
+			f.EnableFeature(features.DeprecateExecutableMetaUpdateInBpfLoader, 0)
+		}
+		txCtx := &TransactionCtx{
+			AccountKeys: []solana.PublicKey{BpfLoaderUpgradeableAddr, {2}},
+			Accounts: TransactionAccounts{
+				Accounts: []*accounts.Account{
+					{Owner: NativeLoaderAddr, Data: []byte("loader"), Executable: true},
+					{Lamports: 1, Owner: BpfLoaderUpgradeableAddr, Data: append([]byte{}, programState...)},
+				},
+				Touched: make([]bool, 2),
+			},
+		}
+		instrCtx := &InstructionCtx{
+			ProgramAccounts:     []uint64{0},
+			InstructionAccounts: []InstructionAccount{{IndexInTransaction: 1, IndexInCallee: 0, IsWritable: true}},
+		}
+		acct, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+		require.NoError(t, err)
+
+		// the loader only marks its programs executable before the feature
+		require.NoError(t, acct.SetExecutable(*f, true))
+		assert.Equal(t, !deprecated, acct.Account.Executable)
+		assert.True(t, acct.IsExecutable(*f))
+
+		// executable accounts are frozen either way
+		assert.Equal(t, InstrErrExecutableDataModified, acct.DataCanBeChanged(*f))
+		assert.Equal(t, InstrErrExecutableLamportChange, acct.SetLamports(2, *f))
+		assert.Equal(t, InstrErrModifiedProgramId, acct.SetOwner(*f, solana.PublicKey{3}))
+		acct.Drop()
+	}
+
+	// the executable flag may never be unset
+	f := features.NewFeaturesDefault()
+	txCtx := &TransactionCtx{
+		AccountKeys: []solana.PublicKey{{1}, {2}},
+		Accounts: TransactionAccounts{
+			Accounts: []*accounts.Account{{Executable: true}, {Owner: solana.PublicKey{1}, Executable: true}},
+			Touched:  make([]bool, 2),
+		},
+	}
+	instrCtx := &InstructionCtx{
+		ProgramAccounts:     []uint64{0},
+		InstructionAccounts: []InstructionAccount{{IndexInTransaction: 1, IndexInCallee: 0, IsWritable: true}},
+	}
+	acct, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	require.NoError(t, err)
+	defer acct.Drop()
+	assert.Equal(t, InstrErrExecutableModified, acct.SetExecutable(*f, false))
+	assert.NoError(t, acct.SetExecutable(*f, true))
+}
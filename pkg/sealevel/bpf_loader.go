@@ -2,11 +2,15 @@ package sealevel
 
 import (
 	"bytes"
+	"errors"
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
 	"go.firedancer.io/radiance/pkg/features"
 	"go.firedancer.io/radiance/pkg/safemath"
+	"go.firedancer.io/radiance/pkg/sbpf"
 	"go.firedancer.io/radiance/pkg/sbpf/loader"
 	"k8s.io/klog/v2"
 )
@@ -84,7 +88,7 @@ func (write *UpgradeableLoaderInstrWrite) UnmarshalWithDecoder(decoder *bin.Deco
 		return err
 	}
 
-	write.Bytes, err = decoder.ReadByteSlice()
+	write.Bytes, err = readBincodeByteSlice(decoder)
 	return err
 }
 
@@ -120,8 +124,14 @@ func (buffer *UpgradeableLoaderStateBuffer) UnmarshalWithDecoder(decoder *bin.De
 func (buffer *UpgradeableLoaderStateBuffer) MarshalWithEncoder(encoder *bin.Encoder) error {
 	var err error
 	if buffer.AuthorityAddress != nil {
+		err = encoder.WriteBool(true)
+		if err != nil {
+			return err
+		}
 		authAddr := *buffer.AuthorityAddress
 		err = encoder.WriteBytes(authAddr.Bytes(), false)
+	} else {
+		err = encoder.WriteBool(false)
 	}
 	return err
 }
@@ -173,8 +183,14 @@ func (programData *UpgradeableLoaderStateProgramData) MarshalWithEncoder(encoder
 	}
 
 	if programData.UpgradeAuthorityAddress != nil {
+		err = encoder.WriteBool(true)
+		if err != nil {
+			return err
+		}
 		upgradeAuthAddr := *programData.UpgradeAuthorityAddress
 		err = encoder.WriteBytes(upgradeAuthAddr.Bytes(), false)
+	} else {
+		err = encoder.WriteBool(false)
 	}
 
 	return err
@@ -219,7 +235,11 @@ func (state *UpgradeableLoaderState) UnmarshalWithDecoder(decoder *bin.Decoder)
 }
 
 func (state *UpgradeableLoaderState) MarshalWithEncoder(encoder *bin.Encoder) error {
-	var err error
+	err := encoder.WriteUint32(state.Type, bin.LE)
+	if err != nil {
+		return err
+	}
+
 	switch state.Type {
 	case UpgradeableLoaderStateTypeUninitialized:
 		{
@@ -294,6 +314,12 @@ func writeProgramData(execCtx *ExecutionCtx, programDataOffset uint64, bytes []b
 	if err != nil {
 		return err
 	}
+	defer program.Drop()
+
+	err = program.DataCanBeChanged(execCtx.GlobalCtx.Features)
+	if err != nil {
+		return err
+	}
 
 	writeOffset := safemath.SaturatingAddU64(programDataOffset, uint64(len(bytes)))
 	if uint64(len(program.Data())) < writeOffset {
@@ -301,30 +327,45 @@ func writeProgramData(execCtx *ExecutionCtx, programDataOffset uint64, bytes []b
 		return InstrErrAccountDataTooSmall
 	}
 
+	err = program.Touch()
+	if err != nil {
+		return err
+	}
+
 	copy(program.Account.Data[programDataOffset:writeOffset], bytes)
 	return nil
 }
 
-func deployProgram(execCtx *ExecutionCtx, programData []byte) error {
-
-	// 1. register syscalls
-	// 2. load program bytes as ELF
-	// 3. verify program
-
-	syscallRegistry := Syscalls(&execCtx.GlobalCtx.Features)
+// deployProgram parses and verifies the ELF in programData and, if it is
+// valid, records the resulting executable as modified by the current
+// transaction, mirroring Agave's deploy_program! macro.
+func deployProgram(execCtx *ExecutionCtx, programId solana.PublicKey, loaderKey solana.PublicKey, accountSize uint64, slot uint64, programData []byte) error {
+	syscallRegistry := Syscalls(&execCtx.GlobalCtx.Features, true)
 
 	loader, err := loader.NewLoaderWithSyscalls(programData, &syscallRegistry, true)
 	if err != nil {
-		return err
+		klog.Infof("failed to create loader for program %s: %s", programId, err)
+		return InstrErrInvalidAccountData
 	}
 
 	program, err := loader.Load()
 	if err != nil {
-		return err
+		klog.Infof("failed to load program %s: %s", programId, err)
+		return InstrErrInvalidAccountData
 	}
 
 	err = program.Verify()
-	return err
+	if err != nil {
+		klog.Infof("failed to verify program %s: %s", programId, err)
+		return InstrErrInvalidAccountData
+	}
+
+	if execCtx.ModifiedPrograms == nil {
+		execCtx.ModifiedPrograms = make(ProgramsModifiedByTx)
+	}
+	execCtx.ModifiedPrograms.Replenish(programId, newLoadedProgram(program, loaderKey, accountSize, slot))
+
+	return nil
 }
 
 func BpfLoaderProgramExecute(execCtx *ExecutionCtx) error {
@@ -339,6 +380,7 @@ func BpfLoaderProgramExecute(execCtx *ExecutionCtx) error {
 	}
 
 	if programAcct.Owner() == NativeLoaderAddr {
+		programAcct.Drop()
 		programId, err := instrCtx.LastProgramKey(txCtx)
 		if err != nil {
 			return err
@@ -355,25 +397,239 @@ func BpfLoaderProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
-			return InstrErrUnsupportedProgramId
+			return ProcessBpfLoaderInstruction(execCtx)
 		} else if programId == BpfLoaderDeprecatedAddr {
 			err = execCtx.ComputeMeter.Consume(CUDeprecatedLoaderComputeUnits)
 			if err != nil {
 				return err
 			}
+			klog.Infof("Deprecated loader is no longer supported")
 			return InstrErrUnsupportedProgramId
 		} else {
 			return InstrErrUnsupportedProgramId
 		}
 	}
 
-	if !programAcct.IsExecutable() {
+	if !programAcct.IsExecutable(execCtx.GlobalCtx.Features) {
+		programAcct.Drop()
 		return InstrErrUnsupportedProgramId
 	}
 
-	// TODO: program execution
+	program, err := loadProgramForExecution(execCtx, txCtx, programAcct)
+	if err != nil {
+		programAcct.Drop()
+		return err
+	}
+
+	// programs owned by the deprecated loader use the unaligned input format
+	aligned := programAcct.Owner() != BpfLoaderDeprecatedAddr
+	programAcct.Drop()
 
-	return nil
+	return executeProgram(execCtx, txCtx, instrCtx, program, aligned)
+}
+
+// loadProgramForExecution returns the verified executable of the given
+// program account, either from the programs modified by the current
+// transaction or by loading its ELF from the accounts.
+func loadProgramForExecution(execCtx *ExecutionCtx, txCtx *TransactionCtx, programAcct *BorrowedAccount) (*sbpf.Program, error) {
+	programId := programAcct.Key()
+
+	clock, err := execCtx.SysvarCache.Clock()
+	if err != nil {
+		return nil, err
+	}
+
+	if loaded, ok := execCtx.ModifiedPrograms.Find(programId); ok {
+		if loaded.IsTombstone() || !loaded.IsVisibleAt(clock.Slot) {
+			klog.Infof("program %s is not deployed", programId)
+			return nil, InstrErrUnsupportedProgramId
+		}
+		return loaded.Program, nil
+	}
+
+	var programBytes []byte
+	var deploymentSlot uint64
+	var effectiveSlot uint64
+	var accountSize uint64
+
+	switch programAcct.Owner() {
+	case BpfLoaderUpgradeableAddr:
+		programState, err := unmarshalUpgradeableLoaderState(programAcct.Data())
+		if err != nil || programState.Type != UpgradeableLoaderStateTypeProgram {
+			klog.Infof("program %s is not deployed", programId)
+			return nil, InstrErrUnsupportedProgramId
+		}
+
+		programDataAcct, err := accountForProgramLoading(execCtx, txCtx, programState.Program.ProgramDataAddress)
+		if err != nil {
+			klog.Infof("programdata account for %s not found", programId)
+			return nil, InstrErrUnsupportedProgramId
+		}
+
+		programDataState, err := unmarshalUpgradeableLoaderState(programDataAcct.Data)
+		if err != nil || programDataState.Type != UpgradeableLoaderStateTypeProgramData ||
+			len(programDataAcct.Data) < upgradeableLoaderSizeOfProgramDataMetaData {
+			klog.Infof("program %s is not deployed", programId)
+			return nil, InstrErrUnsupportedProgramId
+		}
+		programBytes = programDataAcct.Data[upgradeableLoaderSizeOfProgramDataMetaData:]
+		deploymentSlot = programDataState.ProgramData.Slot
+		effectiveSlot = deploymentSlot + DelayVisibilitySlotOffset
+		accountSize = uint64(len(programAcct.Data()) + len(programDataAcct.Data))
+
+	case BpfLoaderAddr, BpfLoaderDeprecatedAddr:
+		// programs owned by the non-upgradeable loaders can never change,
+		// so they are visible from slot 0
+		programBytes = programAcct.Data()
+		accountSize = uint64(len(programBytes))
+
+	default:
+		return nil, InstrErrUnsupportedProgramId
+	}
+
+	loaded, ok := execCtx.ProgramCache.find(programId, deploymentSlot)
+	if !ok {
+		loaded = loadProgramFromBytes(execCtx, programId, programAcct.Owner(), accountSize, deploymentSlot, programBytes)
+		loaded.EffectiveSlot = effectiveSlot
+		execCtx.ProgramCache.replenish(programId, loaded)
+	}
+
+	if loaded.IsTombstone() || (loaded.EffectiveSlot > 0 && !loaded.IsVisibleAt(clock.Slot)) {
+		klog.Infof("program %s is not deployed", programId)
+		return nil, InstrErrUnsupportedProgramId
+	}
+
+	return loaded.Program, nil
+}
+
+// loadProgramFromBytes parses and verifies a program ELF for execution,
+// returning a FailedVerification tombstone if the ELF is invalid.
+func loadProgramFromBytes(execCtx *ExecutionCtx, programId solana.PublicKey, loaderKey solana.PublicKey, accountSize uint64, deploymentSlot uint64, programBytes []byte) *LoadedProgram {
+	syscallRegistry := Syscalls(&execCtx.GlobalCtx.Features, false)
+	loader, err := loader.NewLoaderWithSyscalls(programBytes, &syscallRegistry, false)
+	if err != nil {
+		klog.Infof("failed to load program %s: %s", programId, err)
+		return newTombstone(deploymentSlot, LoadedProgramTypeFailedVerification)
+	}
+
+	program, err := loader.Load()
+	if err != nil {
+		klog.Infof("failed to load program %s: %s", programId, err)
+		return newTombstone(deploymentSlot, LoadedProgramTypeFailedVerification)
+	}
+
+	err = program.Verify()
+	if err != nil {
+		klog.Infof("failed to verify program %s: %s", programId, err)
+		return newTombstone(deploymentSlot, LoadedProgramTypeFailedVerification)
+	}
+
+	return newLoadedProgram(program, loaderKey, accountSize, deploymentSlot)
+}
+
+// accountForProgramLoading looks up an account needed to load a program,
+// preferring the transaction's view of the account over the account store.
+func accountForProgramLoading(execCtx *ExecutionCtx, txCtx *TransactionCtx, pubkey solana.PublicKey) (*accounts.Account, error) {
+	idx, err := txCtx.IndexOfAccount(pubkey)
+	if err == nil {
+		return txCtx.AccountAtIndex(idx)
+	}
+
+	if execCtx.Accounts == nil {
+		return nil, InstrErrMissingAccount
+	}
+	return execCtx.Accounts.GetAccount((*[32]byte)(&pubkey))
+}
+
+// executeProgram runs an SBF program over the serialized accounts of the
+// current instruction and writes the modified accounts back.
+func executeProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx, program *sbpf.Program, aligned bool) error {
+	params, err := serializeParameters(execCtx, txCtx, instrCtx)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	var inputRegions []sbpf.MemoryRegion
+	switch {
+	case aligned && params.directMapping():
+		inputRegions = params.SerializeDirect(&buf)
+	case aligned:
+		params.Serialize(&buf)
+	case params.directMapping():
+		inputRegions = params.SerializeUnalignedDirect(&buf)
+	default:
+		params.SerializeUnaligned(&buf)
+	}
+	input := buf.Bytes()
+
+	heapSize := execCtx.heapSize()
+	allocator := &heapAllocator{size: heapSize, align: 1}
+	if aligned {
+		allocator.align = 8
+	}
+
+	prevOriginalDataLens, prevAllocator := execCtx.originalDataLens, execCtx.allocator
+	execCtx.originalDataLens, execCtx.allocator = params.originalDataLens(), allocator
+	defer func() { execCtx.originalDataLens, execCtx.allocator = prevOriginalDataLens, prevAllocator }()
+
+	err = execCtx.ComputeMeter.Consume(calculateHeapCost(heapSize))
+	if err != nil {
+		return InstrErrComputationalBudgetExceeded
+	}
+
+	opts := &sbpf.VMOpts{
+		HeapSize:     int(heapSize),
+		Syscalls:     Syscalls(&execCtx.GlobalCtx.Features, false),
+		Context:      execCtx,
+		MaxCU:        int(execCtx.ComputeMeter.Remaining()),
+		Input:        input,
+		InputRegions: inputRegions,
+		ComputeMeter: &execCtx.ComputeMeter,
+	}
+
+	executor := sbpf.NewExecutor(&execCtx.GlobalCtx, program, opts)
+	status, err := executor.Run()
+	if err != nil {
+		klog.Infof("program %s failed: %s", params.ProgramID, err)
+		return instrErrFromVMErr(err)
+	}
+
+	if status != 0 {
+		err = instrErrFromProgramStatus(status)
+		klog.Infof("program %s failed: %s", params.ProgramID, err)
+		return err
+	}
+
+	if aligned {
+		return deserializeParameters(execCtx, txCtx, instrCtx, params, input)
+	}
+	return deserializeParametersUnaligned(execCtx, txCtx, instrCtx, params, input)
+}
+
+// instrErrFromVMErr converts an error raised during program execution into
+// an instruction error. Instruction errors raised by syscalls (e.g. during
+// CPI) are passed through unchanged.
+func instrErrFromVMErr(err error) error {
+	if errors.Is(err, sbpf.ExcOutOfCU) || errors.Is(err, cu.ErrComputeExceeded) {
+		return InstrErrComputationalBudgetExceeded
+	}
+
+	var custom InstrErrCustom
+	if errors.As(err, &custom) {
+		return custom
+	}
+
+	var exc *sbpf.Exception
+	if errors.As(err, &exc) {
+		for _, instrErr := range instrErrVariants {
+			if instrErr != nil && errors.Is(exc.Detail, instrErr) {
+				return instrErr
+			}
+		}
+	}
+
+	return InstrErrProgramFailedToComplete
 }
 
 func UpgradeableLoaderInitializeBuffer(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx) error {
@@ -386,6 +642,7 @@ func UpgradeableLoaderInitializeBuffer(execCtx *ExecutionCtx, txCtx *Transaction
 	if err != nil {
 		return err
 	}
+	defer buffer.Drop()
 
 	state, err := unmarshalUpgradeableLoaderState(buffer.Data())
 	if err != nil {
@@ -424,6 +681,7 @@ func UpgradeableLoaderWrite(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 	if err != nil {
 		return err
 	}
+	defer buffer.Drop()
 
 	state, err := unmarshalUpgradeableLoaderState(buffer.Data())
 	if err != nil {
@@ -462,6 +720,7 @@ func UpgradeableLoaderWrite(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 		klog.Infof("Invalid buffer account")
 		return InstrErrInvalidAccountData
 	}
+	buffer.Drop()
 
 	err = writeProgramData(execCtx, upgradeableLoaderSizeOfBufferMetaData+uint64(write.Offset), write.Bytes)
 	return err
@@ -491,14 +750,12 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 		return err
 	}
 
-	rent := ReadRentSysvar(&execCtx.Accounts)
-	err = checkAcctForRentSysvar(txCtx, instrCtx, 4)
+	rent, err := getRentSysvarWithAccountCheck(execCtx, instrCtx, 4)
 	if err != nil {
 		return err
 	}
 
-	clock := ReadClockSysvar(&execCtx.Accounts)
-	err = checkAcctForClockSysvar(txCtx, instrCtx, 5)
+	clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 5)
 	if err != nil {
 		return err
 	}
@@ -508,14 +765,13 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 		return err
 	}
 
-	var authorityKey *solana.PublicKey
-	authorityIdx, err := instrCtx.IndexOfInstructionAccountInTransaction(1)
-	if err == nil {
-		k, err := txCtx.KeyOfAccountAtIndex(authorityIdx)
-		if err != nil {
-			return err
-		}
-		authorityKey = k.ToPointer()
+	authorityIdx, err := instrCtx.IndexOfInstructionAccountInTransaction(7)
+	if err != nil {
+		return err
+	}
+	authorityKey, err := txCtx.KeyOfAccountAtIndex(authorityIdx)
+	if err != nil {
+		return err
 	}
 
 	// validate program account
@@ -523,6 +779,7 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 	if err != nil {
 		return err
 	}
+	defer program.Drop()
 
 	programAcctState, err := unmarshalUpgradeableLoaderState(program.Data())
 	if err != nil {
@@ -530,36 +787,42 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 	}
 
 	if programAcctState.Type != UpgradeableLoaderStateTypeUninitialized {
+		klog.Infof("program account already initialized")
 		return InstrErrAccountAlreadyInitialized
 	}
 
 	if len(program.Data()) < upgradeableLoaderSizeOfProgram {
+		klog.Infof("program account too small")
 		return InstrErrAccountDataTooSmall
 	}
 
 	if program.Lamports() < rent.MinimumBalance(uint64(len(program.Data()))) {
+		klog.Infof("program account not rent-exempt")
 		return InstrErrExecutableAccountNotRentExempt
 	}
 
 	newProgramId := program.Key()
+	program.Drop()
 
 	// validate buffer account
 	buffer, err := instrCtx.BorrowInstructionAccount(txCtx, 3)
 	if err != nil {
 		return err
 	}
+	defer buffer.Drop()
 
-	bufferAcctState, err := unmarshalUpgradeableLoaderState(program.Data())
+	bufferAcctState, err := unmarshalUpgradeableLoaderState(buffer.Data())
 	if err != nil {
 		return err
 	}
 
 	if bufferAcctState.Type != UpgradeableLoaderStateTypeBuffer {
+		klog.Infof("invalid buffer account")
 		return InstrErrInvalidArgument
 	}
 
-	if bufferAcctState.Buffer.AuthorityAddress != nil && authorityKey != nil &&
-		*bufferAcctState.Buffer.AuthorityAddress != *authorityKey {
+	if bufferAcctState.Buffer.AuthorityAddress == nil || *bufferAcctState.Buffer.AuthorityAddress != authorityKey {
+		klog.Infof("buffer and upgrade authority don't match")
 		return InstrErrIncorrectAuthority
 	}
 
@@ -568,6 +831,7 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 		return err
 	}
 	if !isSigner {
+		klog.Infof("upgrade authority did not sign")
 		return InstrErrMissingRequiredSignature
 	}
 
@@ -577,89 +841,89 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 	programDataDataOffset := uint64(upgradeableLoaderSizeOfProgramDataMetaData)
 	programDataLen := upgradeableLoaderSizeOfProgramData(deploy.MaxDataLen)
 
-	if uint64(len(buffer.Account.Data)) < upgradeableLoaderSizeOfBufferMetaData || bufferDataLen == 0 {
+	if uint64(len(buffer.Data())) < upgradeableLoaderSizeOfBufferMetaData || bufferDataLen == 0 {
+		klog.Infof("buffer account too small")
 		return InstrErrInvalidAccountData
 	}
 
 	if deploy.MaxDataLen < bufferDataLen {
+		klog.Infof("max data length is too small to hold buffer data")
 		return InstrErrAccountDataTooSmall
 	}
 
 	if programDataLen > MaxPermittedDataLength {
+		klog.Infof("max data length is too large")
 		return InstrErrInvalidArgument
 	}
 
-	seed := make([][]byte, 1)
-	seed[0] = make([]byte, solana.PublicKeyLength)
-	copy(seed[0], newProgramId[:])
-
 	programId, err := instrCtx.LastProgramKey(txCtx)
 	if err != nil {
 		return err
 	}
 
-	derivedAddr, bumpSeed, _ := solana.FindProgramAddress(seed, programId)
-	if derivedAddr != programDataKey {
+	derivedAddr, _, err := findProgramAddress([][]byte{newProgramId[:]}, programId[:], nil)
+	if err != nil {
+		return err
+	}
+	if solana.PublicKeyFromBytes(derivedAddr) != programDataKey {
+		klog.Infof("programdata address is not derived from program address")
 		return InstrErrInvalidArgument
 	}
 
+	// drain the buffer account to the payer before paying for the programdata account
 	payer, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
 	if err != nil {
 		return err
 	}
-	payer.CheckedAddLamports(buffer.Lamports(), execCtx.GlobalCtx.Features)
-	buffer.SetLamports(0, execCtx.GlobalCtx.Features)
-
-	//ownerId := programId
-
-	var lamports uint64
-	minBalance := rent.MinimumBalance(programDataLen)
-	if minBalance > 1 {
-		lamports = minBalance
-	} else {
-		lamports = 1
+	defer payer.Drop()
+	err = payer.CheckedAddLamports(buffer.Lamports(), execCtx.GlobalCtx.Features)
+	if err != nil {
+		return err
 	}
-	createAcctInstr := newCreateAccountInstruction(payerKey, programDataKey, lamports, programDataLen, programId)
-	createAcctInstr.Accounts = append(createAcctInstr.Accounts, AccountMeta{Pubkey: bufferKey, IsSigner: false, IsWritable: true})
-
-	callerProgramId, err := instrCtx.LastProgramKey(txCtx)
+	err = buffer.SetLamports(0, execCtx.GlobalCtx.Features)
 	if err != nil {
 		return err
 	}
+	payer.Drop()
 
-	var seeds [][]byte
-	seeds = append(seeds, newProgramId[:])
-	seeds = append(seeds, []byte{bumpSeed})
+	lamports := rent.MinimumBalance(programDataLen)
+	if lamports < 1 {
+		lamports = 1
+	}
+	createAcctInstr := newCreateAccountInstruction(payerKey, programDataKey, lamports, programDataLen, programId)
+	createAcctInstr.Accounts = append(createAcctInstr.Accounts, AccountMeta{Pubkey: bufferKey, IsSigner: false, IsWritable: true})
 
-	signer, err := solana.CreateProgramAddress(seeds, callerProgramId)
+	buffer.Drop()
+	err = execCtx.NativeInvoke(*createAcctInstr, []solana.PublicKey{programDataKey})
 	if err != nil {
 		return err
 	}
 
-	var signers []solana.PublicKey
-	signers = append(signers, signer)
-
-	err = execCtx.NativeInvoke(*createAcctInstr, signers)
+	// the create_account CPI may have modified the accounts, so re-borrow them
+	buffer, err = instrCtx.BorrowInstructionAccount(txCtx, 3)
 	if err != nil {
 		return err
 	}
+	defer buffer.Drop()
 
 	bufferData := buffer.Data()
 	if uint64(len(bufferData)) < bufferDataOffset {
 		return InstrErrAccountDataTooSmall
 	}
-	err = deployProgram(execCtx, bufferData[bufferDataOffset:])
+	err = deployProgram(execCtx, newProgramId, programId, upgradeableLoaderSizeOfProgram+programDataLen, clock.Slot, bufferData[bufferDataOffset:])
 	if err != nil {
-		return InstrErrInvalidAccountData
+		return err
 	}
+	buffer.Drop()
 
 	programData, err := instrCtx.BorrowInstructionAccount(txCtx, 1)
 	if err != nil {
 		return err
 	}
+	defer programData.Drop()
 
 	programDataState := &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgramData,
-		ProgramData: UpgradeableLoaderStateProgramData{Slot: clock.Slot, UpgradeAuthorityAddress: authorityKey}}
+		ProgramData: UpgradeableLoaderStateProgramData{Slot: clock.Slot, UpgradeAuthorityAddress: authorityKey.ToPointer()}}
 
 	err = setUpgradeableLoaderAccountState(programData, programDataState, execCtx.GlobalCtx.Features)
 	if err != nil {
@@ -670,9 +934,12 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 	if uint64(len(programData.Data())) < dstEnd {
 		return InstrErrAccountDataTooSmall
 	}
-	if uint64(len(programData.Data())) < bufferDataOffset {
-		return InstrErrAccountDataTooSmall
+
+	buffer, err = instrCtx.BorrowInstructionAccount(txCtx, 3)
+	if err != nil {
+		return err
 	}
+	defer buffer.Drop()
 
 	dstSlice := programData.Account.Data[programDataDataOffset:dstEnd]
 	srcSlice := buffer.Account.Data[bufferDataOffset:]
@@ -682,6 +949,14 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 	if err != nil {
 		return err
 	}
+	programData.Drop()
+	buffer.Drop()
+
+	program, err = instrCtx.BorrowInstructionAccount(txCtx, 2)
+	if err != nil {
+		return err
+	}
+	defer program.Drop()
 
 	programState := &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgram,
 		Program: UpgradeableLoaderStateProgram{ProgramDataAddress: programDataKey}}
@@ -690,13 +965,12 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 		return err
 	}
 
-	if !execCtx.GlobalCtx.Features.IsActive(features.DeprecateExecutableMetaUpdateInBpfLoader) {
-		err = program.SetExecutable(true)
-		if err != nil {
-			return err
-		}
+	err = program.SetExecutable(execCtx.GlobalCtx.Features, true)
+	if err != nil {
+		return err
 	}
 
+	klog.Infof("deployed program %s", newProgramId)
 	return nil
 }
 
@@ -715,14 +989,12 @@ func UpgradeableLoaderUpgrade(execCtx *ExecutionCtx, txCtx *TransactionCtx, inst
 		return err
 	}
 
-	rent := ReadRentSysvar(&execCtx.Accounts)
-	err = checkAcctForRentSysvar(txCtx, instrCtx, 4)
+	rent, err := getRentSysvarWithAccountCheck(execCtx, instrCtx, 4)
 	if err != nil {
 		return err
 	}
 
-	clock := ReadClockSysvar(&execCtx.Accounts)
-	err = checkAcctForClockSysvar(txCtx, instrCtx, 5)
+	clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 5)
 	if err != nil {
 		return err
 	}
@@ -745,8 +1017,9 @@ func UpgradeableLoaderUpgrade(execCtx *ExecutionCtx, txCtx *TransactionCtx, inst
 	if err != nil {
 		return err
 	}
+	defer program.Drop()
 
-	if !program.IsExecutable() {
+	if !program.IsExecutable(execCtx.GlobalCtx.Features) {
 		return InstrErrAccountNotExecutable
 	}
 
@@ -774,13 +1047,19 @@ func UpgradeableLoaderUpgrade(execCtx *ExecutionCtx, txCtx *TransactionCtx, inst
 	} else {
 		return InstrErrInvalidAccountData
 	}
+	newProgramId := program.Key()
+	program.Drop()
 
 	buffer, err := instrCtx.BorrowInstructionAccount(txCtx, 2)
 	if err != nil {
 		return err
 	}
+	defer buffer.Drop()
 
 	bufferState, err := unmarshalUpgradeableLoaderState(buffer.Data())
+	if err != nil {
+		return err
+	}
 	if bufferState.Type == UpgradeableLoaderStateTypeBuffer {
 		if bufferState.Buffer.AuthorityAddress == nil || *bufferState.Buffer.AuthorityAddress != authorityKey {
 			return InstrErrIncorrectAuthority
@@ -802,11 +1081,13 @@ func UpgradeableLoaderUpgrade(execCtx *ExecutionCtx, txCtx *TransactionCtx, inst
 	if len(buffer.Data()) < upgradeableLoaderSizeOfBufferMetaData || bufferDataLen == 0 {
 		return InstrErrInvalidAccountData
 	}
+	buffer.Drop()
 
 	programData, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
 	if err != nil {
 		return err
 	}
+	defer programData.Drop()
 
 	var programDataBalanceRequired uint64
 	minBalance := rent.MinimumBalance(uint64(len(programData.Data())))
@@ -849,17 +1130,32 @@ func UpgradeableLoaderUpgrade(execCtx *ExecutionCtx, txCtx *TransactionCtx, inst
 	} else {
 		return InstrErrInvalidAccountData
 	}
+	programDataLen := uint64(len(programData.Data()))
+	programData.Drop()
+
+	buffer, err = instrCtx.BorrowInstructionAccount(txCtx, 2)
+	if err != nil {
+		return err
+	}
+	defer buffer.Drop()
 
 	bufferData := buffer.Data()
 	if uint64(len(bufferData)) < bufferDataOffset {
 		return InstrErrAccountDataTooSmall
 	}
-	err = deployProgram(execCtx, bufferData[bufferDataOffset:])
+	err = deployProgram(execCtx, newProgramId, programId, upgradeableLoaderSizeOfProgram+programDataLen, clock.Slot, bufferData[bufferDataOffset:])
 	if err != nil {
-		return InstrErrInvalidAccountData
+		return err
 	}
+	buffer.Drop()
 
-	programDataNewState := &UpgradeableLoaderState{ProgramData: UpgradeableLoaderStateProgramData{Slot: clock.Slot, UpgradeAuthorityAddress: &authorityKey}}
+	programData, err = instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer programData.Drop()
+
+	programDataNewState := &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgramData, ProgramData: UpgradeableLoaderStateProgramData{Slot: clock.Slot, UpgradeAuthorityAddress: &authorityKey}}
 	err = setUpgradeableLoaderAccountState(programData, programDataNewState, execCtx.GlobalCtx.Features)
 	if err != nil {
 		return err
@@ -874,19 +1170,33 @@ func UpgradeableLoaderUpgrade(execCtx *ExecutionCtx, txCtx *TransactionCtx, inst
 		return InstrErrAccountDataTooSmall
 	}
 
+	buffer, err = instrCtx.BorrowInstructionAccount(txCtx, 2)
+	if err != nil {
+		return err
+	}
+	defer buffer.Drop()
+
 	dstSlice := programData.Account.Data[programDataDataOffset:dstEnd]
 	srcSlice := buffer.Account.Data[bufferDataOffset:]
 	copy(dstSlice, srcSlice)
+	buffer.Drop()
 
 	programDataFillSlice := programData.Account.Data[dstEnd:]
 	for i := range programDataFillSlice {
 		programDataFillSlice[i] = 0
 	}
 
+	buffer, err = instrCtx.BorrowInstructionAccount(txCtx, 2)
+	if err != nil {
+		return err
+	}
+	defer buffer.Drop()
+
 	spill, err := instrCtx.BorrowInstructionAccount(txCtx, 3)
 	if err != nil {
 		return err
 	}
+	defer spill.Drop()
 
 	spillLamports := safemath.SaturatingSubU64(safemath.SaturatingAddU64(programData.Lamports(), bufferLamports), programDataBalanceRequired)
 	err = spill.CheckedAddLamports(spillLamports, execCtx.GlobalCtx.Features)
@@ -908,7 +1218,7 @@ func UpgradeableLoaderUpgrade(execCtx *ExecutionCtx, txCtx *TransactionCtx, inst
 		return err
 	}
 
-	klog.Infof("upgraded program %s", program.Key())
+	klog.Infof("upgraded program %s", newProgramId)
 	return nil
 }
 
@@ -922,6 +1232,7 @@ func UpgradeableLoaderSetAuthority(execCtx *ExecutionCtx, txCtx *TransactionCtx,
 	if err != nil {
 		return err
 	}
+	defer account.Drop()
 
 	presentAuthorityKeyIdx, err := instrCtx.IndexOfInstructionAccountInTransaction(1)
 	if err != nil {
@@ -1043,6 +1354,7 @@ func UpgradeableLoaderSetAuthorityChecked(execCtx *ExecutionCtx, txCtx *Transact
 	if err != nil {
 		return err
 	}
+	defer account.Drop()
 
 	presentAuthorityKeyIdx, err := instrCtx.IndexOfInstructionAccountInTransaction(1)
 	if err != nil {
@@ -1127,7 +1439,7 @@ func UpgradeableLoaderSetAuthorityChecked(execCtx *ExecutionCtx, txCtx *Transact
 			}
 
 			if !isSigner {
-				klog.Infof("buffer authority did not sign")
+				klog.Infof("upgrade authority did not sign")
 				return InstrErrMissingRequiredSignature
 			}
 
@@ -1195,11 +1507,13 @@ func closeAcctCommon(authorityAddr *solana.PublicKey, txCtx *TransactionCtx, ins
 	if err != nil {
 		return err
 	}
+	defer closeAcct.Drop()
 
 	recipientAcct, err := instrCtx.BorrowInstructionAccount(txCtx, 1)
 	if err != nil {
 		return err
 	}
+	defer recipientAcct.Drop()
 
 	err = recipientAcct.CheckedAddLamports(closeAcct.Lamports(), f)
 	if err != nil {
@@ -1242,6 +1556,7 @@ func UpgradeableLoaderClose(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 	if err != nil {
 		return err
 	}
+	defer closeAcct.Drop()
 
 	closeKey := closeAcct.Key()
 
@@ -1262,6 +1577,7 @@ func UpgradeableLoaderClose(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 			if err != nil {
 				return err
 			}
+			defer recipientAcct.Drop()
 
 			err = recipientAcct.CheckedAddLamports(closeAcct.Lamports(), execCtx.GlobalCtx.Features)
 			if err != nil {
@@ -1282,6 +1598,7 @@ func UpgradeableLoaderClose(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 			if err != nil {
 				return err
 			}
+			closeAcct.Drop()
 
 			err = closeAcctCommon(closeAcctState.Buffer.AuthorityAddress, txCtx, instrCtx, execCtx.GlobalCtx.Features)
 			if err != nil {
@@ -1297,11 +1614,13 @@ func UpgradeableLoaderClose(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 			if err != nil {
 				return err
 			}
+			closeAcct.Drop()
 
 			programAcct, err := instrCtx.BorrowInstructionAccount(txCtx, 3)
 			if err != nil {
 				return err
 			}
+			defer programAcct.Drop()
 
 			programKey := programAcct.Key()
 
@@ -1320,7 +1639,10 @@ func UpgradeableLoaderClose(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 				return InstrErrIncorrectProgramId
 			}
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
+			clock, err := execCtx.SysvarCache.Clock()
+			if err != nil {
+				return err
+			}
 			if clock.Slot == closeAcctState.ProgramData.Slot {
 				klog.Infof("program was deployed in this block already")
 				return InstrErrInvalidArgument
@@ -1338,22 +1660,17 @@ func UpgradeableLoaderClose(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 						klog.Infof("ProgramData account does not match ProgramData account")
 						return InstrErrInvalidArgument
 					}
+					programAcct.Drop()
 
 					err = closeAcctCommon(closeAcctState.ProgramData.UpgradeAuthorityAddress, txCtx, instrCtx, execCtx.GlobalCtx.Features)
 					if err != nil {
 						return err
 					}
 
-					/* TODO?:
-						let clock = invoke_context.get_sysvar_cache().get_clock()?;
-					    invoke_context.programs_modified_by_tx.replenish(
-					            program_key,
-					            Arc::new(LoadedProgram::new_tombstone(
-					                            clock.slot,
-					                            LoadedProgramType::Closed,
-					            )),
-					    );
-					*/
+					if execCtx.ModifiedPrograms == nil {
+						execCtx.ModifiedPrograms = make(ProgramsModifiedByTx)
+					}
+					execCtx.ModifiedPrograms.Replenish(programKey, newTombstone(clock.Slot, LoadedProgramTypeClosed))
 				}
 
 			default:
@@ -1390,6 +1707,7 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 	if err != nil {
 		return err
 	}
+	defer programDataAcct.Drop()
 	programDataKey := programDataAcct.Key()
 
 	programId, err := instrCtx.LastProgramKey(txCtx)
@@ -1402,7 +1720,7 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 		return InstrErrInvalidAccountOwner
 	}
 
-	if programDataAcct.IsWritable() {
+	if !programDataAcct.IsWritable() {
 		klog.Infof("ProgramData is not writable")
 		return InstrErrInvalidArgument
 	}
@@ -1411,6 +1729,7 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 	if err != nil {
 		return err
 	}
+	defer programAcct.Drop()
 
 	if !programAcct.IsWritable() {
 		klog.Infof("Program account is not writable")
@@ -1422,7 +1741,7 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 		return InstrErrInvalidAccountOwner
 	}
 
-	//programKey := programAcct.Key()
+	programKey := programAcct.Key()
 
 	programAcctState, err := unmarshalUpgradeableLoaderState(programAcct.Data())
 	if err != nil {
@@ -1451,7 +1770,10 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 		return InstrErrInvalidRealloc
 	}
 
-	clock := ReadClockSysvar(&execCtx.Accounts)
+	clock, err := execCtx.SysvarCache.Clock()
+	if err != nil {
+		return err
+	}
 	clockSlot := clock.Slot
 
 	programDataAcctState, err := unmarshalUpgradeableLoaderState(programDataAcct.Data())
@@ -1474,14 +1796,21 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 		return InstrErrInvalidAccountData
 	}
 
-	rent := ReadRentSysvar(&execCtx.Accounts)
+	rent, err := execCtx.SysvarCache.Rent()
+	if err != nil {
+		return err
+	}
 	balance := programDataAcct.Lamports()
 	minBalance := rent.MinimumBalance(newLen)
-	if minBalance > 1 {
+	if minBalance < 1 {
 		minBalance = 1
 	}
 	requiredPayment := safemath.SaturatingSubU64(minBalance, balance)
 
+	// borrowed accounts must be released before invoking the system program
+	programDataAcct.Drop()
+	programAcct.Drop()
+
 	if requiredPayment > 0 {
 		payerKeyIdx, err := instrCtx.IndexOfInstructionAccountInTransaction(optionalPayerAcctIdx)
 		if err != nil {
@@ -1499,6 +1828,12 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 		}
 	}
 
+	programDataAcct, err = instrCtx.BorrowInstructionAccount(txCtx, programDataAcctIdx)
+	if err != nil {
+		return err
+	}
+	defer programDataAcct.Drop()
+
 	err = programDataAcct.SetDataLength(newLen, execCtx.GlobalCtx.Features)
 	if err != nil {
 		return err
@@ -1508,9 +1843,9 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 	if uint64(len(programBytes)) < upgradeableLoaderSizeOfProgramDataMetaData {
 		return InstrErrAccountDataTooSmall
 	}
-	err = deployProgram(execCtx, programBytes[upgradeableLoaderSizeOfProgramDataMetaData:])
+	err = deployProgram(execCtx, programKey, programId, upgradeableLoaderSizeOfProgram+newLen, clockSlot, programBytes[upgradeableLoaderSizeOfProgramDataMetaData:])
 	if err != nil {
-		return InstrErrInvalidAccountData
+		return err
 	}
 
 	programDataAcctState.ProgramData.Slot = clockSlot
@@ -0,0 +1,443 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/global"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+type testInstrAcct struct {
+	key        solana.PublicKey
+	acct       *accounts.Account
+	isSigner   bool
+	isWritable bool
+}
+
+// newTestUpgradeableLoaderExecCtx sets up an execution context in which the
+// upgradeable loader is the currently executing program, invoked with the
+// given instruction accounts.
+func newTestUpgradeableLoaderExecCtx(f features.Features, instrData []byte, instrAccts []testInstrAcct) *ExecutionCtx {
+	return newTestLoaderExecCtx(BpfLoaderUpgradeableAddr, f, instrData, instrAccts)
+}
+
+// newTestLoaderExecCtx sets up an execution context in which the given
+// loader is the currently executing program.
+func newTestLoaderExecCtx(loaderKey solana.PublicKey, f features.Features, instrData []byte, instrAccts []testInstrAcct) *ExecutionCtx {
+	loaderAcct := &accounts.Account{Owner: NativeLoaderAddr, Executable: true, Data: []byte("bpf_loader")}
+
+	keys := []solana.PublicKey{loaderKey}
+	accts := []*accounts.Account{loaderAcct}
+
+	var instructionAccts []InstructionAccount
+	for i, ia := range instrAccts {
+		keys = append(keys, ia.key)
+		accts = append(accts, ia.acct)
+		instructionAccts = append(instructionAccts, InstructionAccount{
+			IndexInTransaction: uint64(i + 1),
+			IndexInCaller:      uint64(i + 1),
+			IndexInCallee:      uint64(i),
+			IsSigner:           ia.isSigner,
+			IsWritable:         ia.isWritable,
+		})
+	}
+
+	txCtx := &TransactionCtx{
+		AccountKeys:              keys,
+		Accounts:                 TransactionAccounts{Accounts: accts, Touched: make([]bool, len(accts))},
+		InstructionTraceCapacity: 64,
+	}
+	txCtx.PushInstructionCtx(InstructionCtx{ProgramAccounts: []uint64{0}, InstructionAccounts: instructionAccts, Data: instrData})
+	_ = txCtx.Push()
+
+	return &ExecutionCtx{
+		TransactionContext: txCtx,
+		GlobalCtx:          global.GlobalCtx{Features: f},
+		ComputeMeter:       cu.NewComputeMeterDefault(),
+	}
+}
+
+func upgradeableLoaderInstrData(instrType uint32) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, instrType)
+	return data
+}
+
+func newTestLoaderOwnedAcct(t *testing.T, state *UpgradeableLoaderState, size int) *accounts.Account {
+	stateBytes, err := marshalUpgradeableLoaderState(state)
+	require.NoError(t, err)
+	data := make([]byte, size)
+	copy(data, stateBytes)
+	return &accounts.Account{Lamports: 1_000_000, Owner: BpfLoaderUpgradeableAddr, Data: data}
+}
+
+func TestMarshal_Unmarshal_UpgradeableLoaderState(t *testing.T) {
+	authority := solana.PublicKeyFromBytes(make([]byte, 32)).ToPointer()
+	authority[0] = 7
+
+	states := []UpgradeableLoaderState{
+		{Type: UpgradeableLoaderStateTypeUninitialized},
+		{Type: UpgradeableLoaderStateTypeBuffer, Buffer: UpgradeableLoaderStateBuffer{AuthorityAddress: authority}},
+		{Type: UpgradeableLoaderStateTypeBuffer},
+		{Type: UpgradeableLoaderStateTypeProgram, Program: UpgradeableLoaderStateProgram{ProgramDataAddress: *authority}},
+		{Type: UpgradeableLoaderStateTypeProgramData, ProgramData: UpgradeableLoaderStateProgramData{Slot: 1337, UpgradeAuthorityAddress: authority}},
+		{Type: UpgradeableLoaderStateTypeProgramData, ProgramData: UpgradeableLoaderStateProgramData{Slot: 1337}},
+	}
+
+	for _, state := range states {
+		stateBytes, err := marshalUpgradeableLoaderState(&state)
+		require.NoError(t, err)
+
+		newState, err := unmarshalUpgradeableLoaderState(stateBytes)
+		require.NoError(t, err)
+		assert.Equal(t, state, *newState)
+	}
+
+	bufferBytes, err := marshalUpgradeableLoaderState(&states[1])
+	require.NoError(t, err)
+	assert.Equal(t, upgradeableLoaderSizeOfBufferMetaData, len(bufferBytes))
+
+	programBytes, err := marshalUpgradeableLoaderState(&states[3])
+	require.NoError(t, err)
+	assert.Equal(t, upgradeableLoaderSizeOfProgram, len(programBytes))
+
+	programDataBytes, err := marshalUpgradeableLoaderState(&states[4])
+	require.NoError(t, err)
+	assert.Equal(t, upgradeableLoaderSizeOfProgramDataMetaData, len(programDataBytes))
+}
+
+func TestUpgradeableLoader_SetAuthority_Buffer(t *testing.T) {
+	presentAuthority := solana.PublicKey{1}
+	newAuthority := solana.PublicKey{2}
+	bufferKey := solana.PublicKey{3}
+
+	newBuffer := func() *accounts.Account {
+		state := &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeBuffer,
+			Buffer: UpgradeableLoaderStateBuffer{AuthorityAddress: presentAuthority.ToPointer()}}
+		return newTestLoaderOwnedAcct(t, state, upgradeableLoaderSizeOfBufferMetaData+16)
+	}
+
+	// success
+	buffer := newBuffer()
+	execCtx := newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: bufferKey, acct: buffer, isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}},
+	})
+	require.NoError(t, ProcessUpgradeableLoaderInstruction(execCtx))
+	assert.Equal(t, upgradeableLoaderSizeOfBufferMetaData+16, len(buffer.Data))
+
+	state, err := unmarshalUpgradeableLoaderState(buffer.Data)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(UpgradeableLoaderStateTypeBuffer), state.Type)
+	assert.Equal(t, newAuthority, *state.Buffer.AuthorityAddress)
+
+	// new authority is not optional for buffers
+	execCtx = newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: bufferKey, acct: newBuffer(), isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+	})
+	assert.Equal(t, InstrErrIncorrectAuthority, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	// present authority did not sign
+	execCtx = newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: bufferKey, acct: newBuffer(), isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}},
+		{key: newAuthority, acct: &accounts.Account{}},
+	})
+	assert.Equal(t, InstrErrMissingRequiredSignature, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	// wrong present authority
+	execCtx = newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: bufferKey, acct: newBuffer(), isWritable: true},
+		{key: newAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}},
+	})
+	assert.Equal(t, InstrErrIncorrectAuthority, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	// immutable buffer
+	immutable := newTestLoaderOwnedAcct(t, &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeBuffer}, upgradeableLoaderSizeOfBufferMetaData)
+	execCtx = newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: bufferKey, acct: immutable, isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}},
+	})
+	assert.Equal(t, InstrErrImmutable, ProcessUpgradeableLoaderInstruction(execCtx))
+}
+
+func TestUpgradeableLoader_SetAuthority_ProgramData(t *testing.T) {
+	presentAuthority := solana.PublicKey{1}
+	newAuthority := solana.PublicKey{2}
+	programDataKey := solana.PublicKey{3}
+
+	newProgramData := func() *accounts.Account {
+		state := &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgramData,
+			ProgramData: UpgradeableLoaderStateProgramData{Slot: 42, UpgradeAuthorityAddress: presentAuthority.ToPointer()}}
+		return newTestLoaderOwnedAcct(t, state, upgradeableLoaderSizeOfProgramDataMetaData+16)
+	}
+
+	// set new authority
+	programData := newProgramData()
+	execCtx := newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: programDataKey, acct: programData, isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}},
+	})
+	require.NoError(t, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	state, err := unmarshalUpgradeableLoaderState(programData.Data)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(UpgradeableLoaderStateTypeProgramData), state.Type)
+	assert.Equal(t, uint64(42), state.ProgramData.Slot)
+	assert.Equal(t, newAuthority, *state.ProgramData.UpgradeAuthorityAddress)
+
+	// omitting the new authority makes the program immutable
+	programData = newProgramData()
+	execCtx = newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: programDataKey, acct: programData, isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+	})
+	require.NoError(t, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	state, err = unmarshalUpgradeableLoaderState(programData.Data)
+	require.NoError(t, err)
+	assert.Nil(t, state.ProgramData.UpgradeAuthorityAddress)
+
+	// ...after which the authority can no longer be changed
+	execCtx = newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: programDataKey, acct: programData, isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}},
+	})
+	assert.Equal(t, InstrErrImmutable, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	// upgrade authority did not sign
+	execCtx = newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: programDataKey, acct: newProgramData(), isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}},
+		{key: newAuthority, acct: &accounts.Account{}},
+	})
+	assert.Equal(t, InstrErrMissingRequiredSignature, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	// accounts other than buffers and programdata do not have authorities
+	program := newTestLoaderOwnedAcct(t, &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgram}, upgradeableLoaderSizeOfProgram)
+	execCtx = newTestUpgradeableLoaderExecCtx(features.Features{}, upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthority), []testInstrAcct{
+		{key: programDataKey, acct: program, isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}},
+	})
+	assert.Equal(t, InstrErrInvalidArgument, ProcessUpgradeableLoaderInstruction(execCtx))
+}
+
+func TestUpgradeableLoader_SetAuthorityChecked(t *testing.T) {
+	presentAuthority := solana.PublicKey{1}
+	newAuthority := solana.PublicKey{2}
+	programDataKey := solana.PublicKey{3}
+
+	newProgramData := func() *accounts.Account {
+		state := &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgramData,
+			ProgramData: UpgradeableLoaderStateProgramData{Slot: 42, UpgradeAuthorityAddress: presentAuthority.ToPointer()}}
+		return newTestLoaderOwnedAcct(t, state, upgradeableLoaderSizeOfProgramDataMetaData+16)
+	}
+
+	instrData := upgradeableLoaderInstrData(UpgradeableLoaderInstrTypeSetAuthorityChecked)
+
+	// instruction is rejected before the feature is activated
+	execCtx := newTestUpgradeableLoaderExecCtx(*features.NewFeaturesDefault(), instrData, []testInstrAcct{
+		{key: programDataKey, acct: newProgramData(), isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}, isSigner: true},
+	})
+	assert.Equal(t, InstrErrInvalidInstructionData, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.EnableBpfLoaderSetAuthorityCheckedIx, 0)
+
+	// new authority must sign
+	execCtx = newTestUpgradeableLoaderExecCtx(*f, instrData, []testInstrAcct{
+		{key: programDataKey, acct: newProgramData(), isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}},
+	})
+	assert.Equal(t, InstrErrMissingRequiredSignature, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	// new authority is not optional
+	execCtx = newTestUpgradeableLoaderExecCtx(*f, instrData, []testInstrAcct{
+		{key: programDataKey, acct: newProgramData(), isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+	})
+	assert.Equal(t, InstrErrNotEnoughAccountKeys, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	// success for programdata
+	programData := newProgramData()
+	execCtx = newTestUpgradeableLoaderExecCtx(*f, instrData, []testInstrAcct{
+		{key: programDataKey, acct: programData, isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}, isSigner: true},
+	})
+	require.NoError(t, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	state, err := unmarshalUpgradeableLoaderState(programData.Data)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), state.ProgramData.Slot)
+	assert.Equal(t, newAuthority, *state.ProgramData.UpgradeAuthorityAddress)
+
+	// success for buffers
+	buffer := newTestLoaderOwnedAcct(t, &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeBuffer,
+		Buffer: UpgradeableLoaderStateBuffer{AuthorityAddress: presentAuthority.ToPointer()}}, upgradeableLoaderSizeOfBufferMetaData)
+	execCtx = newTestUpgradeableLoaderExecCtx(*f, instrData, []testInstrAcct{
+		{key: programDataKey, acct: buffer, isWritable: true},
+		{key: presentAuthority, acct: &accounts.Account{}, isSigner: true},
+		{key: newAuthority, acct: &accounts.Account{}, isSigner: true},
+	})
+	require.NoError(t, ProcessUpgradeableLoaderInstruction(execCtx))
+
+	state, err = unmarshalUpgradeableLoaderState(buffer.Data)
+	require.NoError(t, err)
+	assert.Equal(t, newAuthority, *state.Buffer.AuthorityAddress)
+}
+
+func TestDeployProgram_InsertsIntoModifiedPrograms(t *testing.T) {
+	f := *features.NewFeaturesDefault()
+	execCtx := newTestUpgradeableLoaderExecCtx(f, nil, nil)
+
+	programId := solana.PublicKeyFromBytes(make([]byte, 32))
+	programId[0] = 1
+
+	err := deployProgram(execCtx, programId, BpfLoaderUpgradeableAddr, 1000, 10, fixtures.Load(t, "sbpf", "rodata.so"))
+	require.NoError(t, err)
+
+	loaded, ok := execCtx.ModifiedPrograms.Find(programId)
+	require.True(t, ok)
+	assert.NotNil(t, loaded.Program)
+	assert.Equal(t, solana.PublicKey(BpfLoaderUpgradeableAddr), loaded.Loader)
+	assert.Equal(t, uint64(1000), loaded.AccountSize)
+	assert.Equal(t, uint64(10), loaded.DeploymentSlot)
+	assert.False(t, loaded.IsVisibleAt(10))
+	assert.True(t, loaded.IsVisibleAt(11))
+}
+
+func TestDeployProgram_InvalidElf(t *testing.T) {
+	f := *features.NewFeaturesDefault()
+	execCtx := newTestUpgradeableLoaderExecCtx(f, nil, nil)
+
+	programId := solana.PublicKeyFromBytes(make([]byte, 32))
+	programId[0] = 1
+
+	err := deployProgram(execCtx, programId, BpfLoaderUpgradeableAddr, 1000, 10, []byte("not an elf"))
+	assert.Equal(t, InstrErrInvalidAccountData, err)
+
+	_, ok := execCtx.ModifiedPrograms.Find(programId)
+	assert.False(t, ok)
+}
+
+// newTestProgramExecCtx sets up an execution context in which the program
+// in the given ELF, deployed under the upgradeable loader, is the currently
+// executing program.
+func newTestProgramExecCtx(t *testing.T, elf []byte) *ExecutionCtx {
+	programKey := solana.PublicKey{1}
+	programDataKey := solana.PublicKey{2}
+
+	programAcct := newTestLoaderOwnedAcct(t, &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgram,
+		Program: UpgradeableLoaderStateProgram{ProgramDataAddress: programDataKey}}, upgradeableLoaderSizeOfProgram)
+	programAcct.Executable = true
+
+	programDataAcct := newTestLoaderOwnedAcct(t, &UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgramData,
+		ProgramData: UpgradeableLoaderStateProgramData{Slot: 1}}, upgradeableLoaderSizeOfProgramDataMetaData+len(elf))
+	copy(programDataAcct.Data[upgradeableLoaderSizeOfProgramDataMetaData:], elf)
+
+	accts := []*accounts.Account{programAcct, programDataAcct}
+	txCtx := &TransactionCtx{
+		AccountKeys:              []solana.PublicKey{programKey, programDataKey},
+		Accounts:                 TransactionAccounts{Accounts: accts, Touched: make([]bool, len(accts))},
+		InstructionTraceCapacity: 64,
+	}
+	txCtx.PushInstructionCtx(InstructionCtx{ProgramAccounts: []uint64{0}})
+	require.NoError(t, txCtx.Push())
+
+	execCtx := &ExecutionCtx{
+		Log:                new(LogRecorder),
+		TransactionContext: txCtx,
+		GlobalCtx:          global.GlobalCtx{Features: *features.NewFeaturesDefault()},
+		ComputeMeter:       cu.NewComputeMeterDefault(),
+	}
+	execCtx.SysvarCache.SetClock(SysvarClock{Slot: 10})
+	return execCtx
+}
+
+func TestBpfLoaderProgramExecute_Success(t *testing.T) {
+	execCtx := newTestProgramExecCtx(t, fixtures.Load(t, "sbpf", "multiple_file.so"))
+	assert.NoError(t, BpfLoaderProgramExecute(execCtx))
+}
+
+func TestBpfLoaderProgramExecute_CustomError(t *testing.T) {
+	// the program exits with status 42
+	execCtx := newTestProgramExecCtx(t, fixtures.Load(t, "sbpf", "rodata.so"))
+	assert.Equal(t, InstrErrCustom{Code: 42}, BpfLoaderProgramExecute(execCtx))
+}
+
+func TestBpfLoaderProgramExecute_NotDeployed(t *testing.T) {
+	execCtx := newTestProgramExecCtx(t, fixtures.Load(t, "sbpf", "multiple_file.so"))
+
+	programAcct, err := execCtx.TransactionContext.AccountAtIndex(0)
+	require.NoError(t, err)
+	stateBytes, err := marshalUpgradeableLoaderState(&UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeUninitialized})
+	require.NoError(t, err)
+	copy(programAcct.Data, stateBytes)
+
+	assert.Equal(t, InstrErrUnsupportedProgramId, BpfLoaderProgramExecute(execCtx))
+}
+
+func TestInstrErrFromProgramStatus(t *testing.T) {
+	assert.Equal(t, InstrErrCustom{Code: 0}, instrErrFromProgramStatus(1<<32))
+	assert.Equal(t, InstrErrCustom{Code: 7}, instrErrFromProgramStatus(7))
+	assert.Equal(t, InstrErrInvalidArgument, instrErrFromProgramStatus(2<<32))
+	assert.Equal(t, InstrErrIncorrectAuthority, instrErrFromProgramStatus(26<<32))
+	assert.Equal(t, InstrErrInvalidError, instrErrFromProgramStatus(1000<<32))
+}
+
+func TestInstrErrFromVMErr(t *testing.T) {
+	// instruction errors raised by syscalls are passed through, even wrapped
+	wrapped := fmt.Errorf("cpi: %w", InstrErrPrivilegeEscalation)
+	assert.Equal(t, InstrErrPrivilegeEscalation, instrErrFromVMErr(&sbpf.Exception{Detail: wrapped}))
+	assert.Equal(t, InstrErrCustom{Code: 3}, instrErrFromVMErr(&sbpf.Exception{Detail: InstrErrCustom{Code: 3}}))
+	assert.Equal(t, InstrErrComputationalBudgetExceeded, instrErrFromVMErr(&sbpf.Exception{Detail: sbpf.ExcOutOfCU}))
+	// an error merely named like an instruction error is not one
+	assert.Equal(t, InstrErrProgramFailedToComplete, instrErrFromVMErr(&sbpf.Exception{Detail: errors.New("InstrErrCallDepth")}))
+}
+
+func TestBpfLoaderProgramExecute_UsesProgramCache(t *testing.T) {
+	execCtx := newTestProgramExecCtx(t, fixtures.Load(t, "sbpf", "multiple_file.so"))
+	execCtx.ProgramCache = NewProgramCache(DefaultProgramCacheCapacity)
+
+	require.NoError(t, BpfLoaderProgramExecute(execCtx))
+	loaded, ok := execCtx.ProgramCache.Find(solana.PublicKey{1}, 1)
+	require.True(t, ok)
+	assert.False(t, loaded.IsTombstone())
+
+	// a cached tombstone is honoured without reloading the ELF
+	execCtx.ProgramCache.Replenish(solana.PublicKey{1}, newTombstone(1, LoadedProgramTypeFailedVerification))
+	assert.Equal(t, InstrErrUnsupportedProgramId, BpfLoaderProgramExecute(execCtx))
+}
+
+func TestBpfLoaderProgramExecute_DelayVisibility(t *testing.T) {
+	execCtx := newTestProgramExecCtx(t, fixtures.Load(t, "sbpf", "multiple_file.so"))
+
+	// a program deployed in the current slot cannot be invoked until the next one
+	execCtx.ModifiedPrograms = ProgramsModifiedByTx{}
+	execCtx.ModifiedPrograms.Replenish(solana.PublicKey{1}, newLoadedProgram(nil, BpfLoaderUpgradeableAddr, 0, 10))
+	assert.Equal(t, InstrErrUnsupportedProgramId, BpfLoaderProgramExecute(execCtx))
+
+	execCtx.ModifiedPrograms.Replenish(solana.PublicKey{1}, newTombstone(10, LoadedProgramTypeClosed))
+	assert.Equal(t, InstrErrUnsupportedProgramId, BpfLoaderProgramExecute(execCtx))
+}
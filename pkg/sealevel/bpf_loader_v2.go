@@ -0,0 +1,158 @@
+package sealevel
+
+import (
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/features"
+	"k8s.io/klog/v2"
+)
+
+// instructions of the non-upgradeable BPF loader (BPFLoader2111111111111111111111111111111111)
+const (
+	BpfLoaderInstrTypeWrite = iota
+	BpfLoaderInstrTypeFinalize
+)
+
+type BpfLoaderInstrWrite struct {
+	Offset uint32
+	Bytes  []byte
+}
+
+func (write *BpfLoaderInstrWrite) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	var err error
+	write.Offset, err = decoder.ReadUint32(bin.LE)
+	if err != nil {
+		return err
+	}
+
+	write.Bytes, err = readBincodeByteSlice(decoder)
+	return err
+}
+
+// readBincodeByteSlice reads a bincode-encoded Vec<u8>, i.e. a u64 length
+// prefix followed by the bytes themselves.
+func readBincodeByteSlice(decoder *bin.Decoder) ([]byte, error) {
+	length, err := decoder.ReadUint64(bin.LE)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(decoder.Remaining()) {
+		return nil, InstrErrInvalidInstructionData
+	}
+	return decoder.ReadNBytes(int(length))
+}
+
+func BpfLoaderWrite(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx, write BpfLoaderInstrWrite) error {
+	program, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+
+	isSigner := program.IsSigner()
+	program.Drop()
+
+	if !isSigner {
+		klog.Infof("Program account did not sign")
+		return InstrErrMissingRequiredSignature
+	}
+
+	return writeProgramData(execCtx, uint64(write.Offset), write.Bytes)
+}
+
+func BpfLoaderFinalize(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx) error {
+	program, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer program.Drop()
+
+	if !program.IsSigner() {
+		klog.Infof("Program account did not sign")
+		return InstrErrMissingRequiredSignature
+	}
+
+	programId, err := instrCtx.LastProgramKey(txCtx)
+	if err != nil {
+		return err
+	}
+
+	clock, err := execCtx.SysvarCache.Clock()
+	if err != nil {
+		return err
+	}
+
+	err = deployProgram(execCtx, program.Key(), programId, uint64(len(program.Data())), clock.Slot, program.Data())
+	if err != nil {
+		return err
+	}
+
+	err = program.SetExecutable(execCtx.GlobalCtx.Features, true)
+	if err != nil {
+		return err
+	}
+
+	klog.Infof("Finalized account %s", program.Key())
+	return nil
+}
+
+func ProcessBpfLoaderInstruction(execCtx *ExecutionCtx) error {
+	txCtx := execCtx.TransactionContext
+	instrCtx, err := txCtx.CurrentInstructionCtx()
+	if err != nil {
+		return err
+	}
+
+	if execCtx.GlobalCtx.Features.IsActive(features.DisableBpfLoaderInstructions) {
+		klog.Infof("BPF loader management instructions are no longer supported")
+		return InstrErrUnsupportedProgramId
+	}
+
+	programId, err := instrCtx.LastProgramKey(txCtx)
+	if err != nil {
+		return err
+	}
+
+	program, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+
+	programOwner := program.Owner()
+	program.Drop()
+
+	if programOwner != programId {
+		klog.Infof("Executable account not owned by the BPF loader")
+		return InstrErrIncorrectProgramId
+	}
+
+	decoder := bin.NewBinDecoder(instrCtx.Data)
+
+	instrType, err := decoder.ReadUint32(bin.LE)
+	if err != nil {
+		return InstrErrInvalidInstructionData
+	}
+
+	switch instrType {
+	case BpfLoaderInstrTypeWrite:
+		{
+			var write BpfLoaderInstrWrite
+			err = write.UnmarshalWithDecoder(decoder)
+			if err != nil {
+				return InstrErrInvalidInstructionData
+			}
+
+			err = BpfLoaderWrite(execCtx, txCtx, instrCtx, write)
+		}
+
+	case BpfLoaderInstrTypeFinalize:
+		{
+			err = BpfLoaderFinalize(execCtx, txCtx, instrCtx)
+		}
+
+	default:
+		{
+			err = InstrErrInvalidInstructionData
+		}
+	}
+
+	return err
+}
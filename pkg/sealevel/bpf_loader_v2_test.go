@@ -0,0 +1,123 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/global"
+)
+
+func bpfLoaderWriteInstrData(offset uint32, bytes []byte) []byte {
+	data := make([]byte, 4+4+8, 4+4+8+len(bytes))
+	binary.LittleEndian.PutUint32(data[0:], BpfLoaderInstrTypeWrite)
+	binary.LittleEndian.PutUint32(data[4:], offset)
+	binary.LittleEndian.PutUint64(data[8:], uint64(len(bytes)))
+	return append(data, bytes...)
+}
+
+func newTestClockAccounts(t *testing.T, slot uint64) accounts.Accounts {
+	accts := accounts.NewMemAccounts()
+	clockData := make([]byte, 40)
+	binary.LittleEndian.PutUint64(clockData, slot)
+	require.NoError(t, accts.SetAccount((*[32]byte)(&SysvarClockAddr), &accounts.Account{Data: clockData}))
+	return accts
+}
+
+func TestBpfLoader_Write(t *testing.T) {
+	f := *features.NewFeaturesDefault()
+	programKey := solana.PublicKey{1}
+	programAcct := &accounts.Account{Lamports: 1_000_000, Owner: BpfLoaderAddr, Data: make([]byte, 8)}
+
+	// program account must sign
+	instrData := bpfLoaderWriteInstrData(2, []byte{1, 2, 3})
+	execCtx := newTestLoaderExecCtx(BpfLoaderAddr, f, instrData, []testInstrAcct{{programKey, programAcct, false, true}})
+	err := ProcessBpfLoaderInstruction(execCtx)
+	assert.Equal(t, InstrErrMissingRequiredSignature, err)
+
+	execCtx = newTestLoaderExecCtx(BpfLoaderAddr, f, instrData, []testInstrAcct{{programKey, programAcct, true, true}})
+	err = ProcessBpfLoaderInstruction(execCtx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 1, 2, 3, 0, 0, 0}, programAcct.Data)
+
+	// writes past the end of the account fail
+	instrData = bpfLoaderWriteInstrData(6, []byte{1, 2, 3})
+	execCtx = newTestLoaderExecCtx(BpfLoaderAddr, f, instrData, []testInstrAcct{{programKey, programAcct, true, true}})
+	err = ProcessBpfLoaderInstruction(execCtx)
+	assert.Equal(t, InstrErrAccountDataTooSmall, err)
+}
+
+func TestBpfLoader_WrongOwner(t *testing.T) {
+	f := *features.NewFeaturesDefault()
+	programKey := solana.PublicKey{1}
+	programAcct := &accounts.Account{Lamports: 1_000_000, Owner: SystemProgramAddr, Data: make([]byte, 8)}
+
+	instrData := bpfLoaderWriteInstrData(0, []byte{1})
+	execCtx := newTestLoaderExecCtx(BpfLoaderAddr, f, instrData, []testInstrAcct{{programKey, programAcct, true, true}})
+	err := ProcessBpfLoaderInstruction(execCtx)
+	assert.Equal(t, InstrErrIncorrectProgramId, err)
+}
+
+func TestBpfLoader_InstructionsDisabled(t *testing.T) {
+	f := *features.NewFeaturesDefault()
+	f.EnableFeature(features.DisableBpfLoaderInstructions, 0)
+	programKey := solana.PublicKey{1}
+	programAcct := &accounts.Account{Lamports: 1_000_000, Owner: BpfLoaderAddr, Data: make([]byte, 8)}
+
+	instrData := bpfLoaderWriteInstrData(0, []byte{1})
+	execCtx := newTestLoaderExecCtx(BpfLoaderAddr, f, instrData, []testInstrAcct{{programKey, programAcct, true, true}})
+	err := ProcessBpfLoaderInstruction(execCtx)
+	assert.Equal(t, InstrErrUnsupportedProgramId, err)
+}
+
+func TestBpfLoader_Finalize(t *testing.T) {
+	f := *features.NewFeaturesDefault()
+	programKey := solana.PublicKey{1}
+	elf := fixtures.Load(t, "sbpf", "rodata.so")
+	programAcct := &accounts.Account{Lamports: 1_000_000_000, Owner: BpfLoaderAddr, Data: elf}
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, BpfLoaderInstrTypeFinalize)
+	execCtx := newTestLoaderExecCtx(BpfLoaderAddr, f, instrData, []testInstrAcct{{programKey, programAcct, true, true}})
+	execCtx.SysvarCache.SetClock(SysvarClock{Slot: 5})
+	execCtx.TransactionContext.Rent = SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}
+
+	err := ProcessBpfLoaderInstruction(execCtx)
+	require.NoError(t, err)
+	assert.True(t, programAcct.Executable)
+
+	loaded, ok := execCtx.ModifiedPrograms.Find(programKey)
+	require.True(t, ok)
+	assert.Equal(t, uint64(5), loaded.DeploymentSlot)
+	assert.Equal(t, solana.PublicKey(BpfLoaderAddr), loaded.Loader)
+}
+
+func TestBpfLoaderProgramExecute_V2(t *testing.T) {
+	programAcct := &accounts.Account{Lamports: 1_000_000_000, Owner: BpfLoaderAddr, Executable: true,
+		Data: fixtures.Load(t, "sbpf", "rodata.so")}
+
+	txCtx := &TransactionCtx{
+		AccountKeys:              []solana.PublicKey{{1}},
+		Accounts:                 TransactionAccounts{Accounts: []*accounts.Account{programAcct}, Touched: make([]bool, 1)},
+		InstructionTraceCapacity: 64,
+	}
+	txCtx.PushInstructionCtx(InstructionCtx{ProgramAccounts: []uint64{0}})
+	require.NoError(t, txCtx.Push())
+
+	execCtx := &ExecutionCtx{
+		Log:                new(LogRecorder),
+		TransactionContext: txCtx,
+		GlobalCtx:          global.GlobalCtx{Features: *features.NewFeaturesDefault()},
+		ComputeMeter:       cu.NewComputeMeterDefault(),
+	}
+	execCtx.SysvarCache.SetClock(SysvarClock{})
+
+	// the program exits with status 42
+	assert.Equal(t, InstrErrCustom{Code: 42}, BpfLoaderProgramExecute(execCtx))
+}
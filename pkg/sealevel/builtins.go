@@ -0,0 +1,46 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+// BuiltinRegistry maps the program ids of builtin programs to their
+// processors. Builtin programs are owned by the native loader, and are
+// executed natively rather than in the VM.
+type BuiltinRegistry map[solana.PublicKey]func(execCtx *ExecutionCtx) error
+
+// Register adds a builtin program to the registry.
+func (reg BuiltinRegistry) Register(programId solana.PublicKey, processor func(execCtx *ExecutionCtx) error) {
+	reg[programId] = processor
+}
+
+// Lookup returns the processor of the given builtin program.
+func (reg BuiltinRegistry) Lookup(programId solana.PublicKey) (func(execCtx *ExecutionCtx) error, bool) {
+	processor, ok := reg[programId]
+	return processor, ok
+}
+
+// Builtins creates a registry of the builtin programs active under the given
+// features. Builtins migrated to core BPF programs are removed once their
+// migration feature activates; their program accounts are then owned by the
+// upgradeable loader, and executed by it.
+func Builtins(f *features.Features) BuiltinRegistry {
+	reg := make(BuiltinRegistry)
+	reg.Register(SystemProgramAddr, SystemProgramExecute)
+	reg.Register(VoteProgramAddr, VoteProgramExecute)
+	reg.Register(ComputeBudgetProgramAddr, ComputeBudgetProgramExecute)
+
+	reg.Register(BpfLoaderDeprecatedAddr, BpfLoaderProgramExecute)
+	reg.Register(BpfLoaderAddr, BpfLoaderProgramExecute)
+	reg.Register(BpfLoaderUpgradeableAddr, BpfLoaderProgramExecute)
+
+	if !f.IsActive(features.MigrateConfigProgramToCoreBpf) {
+		reg.Register(ConfigProgramAddr, ConfigProgramExecute)
+	}
+	if !f.IsActive(features.MigrateStakeProgramToCoreBpf) {
+		reg.Register(StakeProgramAddr, StakeProgramExecute)
+	}
+
+	return reg
+}
@@ -0,0 +1,48 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func TestBuiltins(t *testing.T) {
+	f := features.NewFeaturesDefault()
+
+	_, ok := Builtins(f).Lookup(SystemProgramAddr)
+	assert.True(t, ok)
+	_, ok = Builtins(f).Lookup(ConfigProgramAddr)
+	assert.True(t, ok)
+	_, ok = Builtins(f).Lookup(StakeProgramAddr)
+	assert.True(t, ok)
+
+	// migrated builtins are removed
+	f.EnableFeature(features.MigrateConfigProgramToCoreBpf, 0)
+	_, ok = Builtins(f).Lookup(ConfigProgramAddr)
+	assert.False(t, ok)
+	_, ok = Builtins(f).Lookup(StakeProgramAddr)
+	assert.True(t, ok)
+
+	f.EnableFeature(features.MigrateStakeProgramToCoreBpf, 0)
+	_, ok = Builtins(f).Lookup(StakeProgramAddr)
+	assert.False(t, ok)
+	_, err := resolveNativeProgramById(StakeProgramAddr, f)
+	assert.Equal(t, InstrErrUnsupportedProgramId, err)
+}
+
+func TestResolveNativeProgramById_Precompiles(t *testing.T) {
+	f := features.NewFeaturesDefault()
+
+	_, err := resolveNativeProgramById(Ed25519PrecompileAddr, f)
+	assert.Equal(t, IsPrecompile, err)
+	_, err = resolveNativeProgramById(Secp256kPrecompileAddr, f)
+	assert.Equal(t, IsPrecompile, err)
+
+	// secp256r1 is a precompile once its feature activates
+	_, err = resolveNativeProgramById(Secp256r1PrecompileAddr, f)
+	assert.Equal(t, InstrErrUnsupportedProgramId, err)
+	f.EnableFeature(features.EnableSecp256r1Precompile, 0)
+	_, err = resolveNativeProgramById(Secp256r1PrecompileAddr, f)
+	assert.Equal(t, IsPrecompile, err)
+}
@@ -0,0 +1,149 @@
+package sealevel
+
+import (
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/safemath"
+	"k8s.io/klog/v2"
+)
+
+// instructions of the compute budget program (ComputeBudget111111111111111111111111111111)
+const (
+	ComputeBudgetInstrTypeRequestUnitsDeprecated = iota
+	ComputeBudgetInstrTypeRequestHeapFrame
+	ComputeBudgetInstrTypeSetComputeUnitLimit
+	ComputeBudgetInstrTypeSetComputeUnitPrice
+	ComputeBudgetInstrTypeSetLoadedAccountsDataSizeLimit
+)
+
+// ComputeBudgetLimits are the limits requested by the compute budget
+// instructions of a transaction, with defaults applied.
+type ComputeBudgetLimits struct {
+	UpdatedHeapBytes    uint32
+	ComputeUnitLimit    uint32
+	ComputeUnitPrice    uint64
+	LoadedAccountsBytes uint32
+}
+
+// ComputeBudgetProgramExecute is the entrypoint of the compute budget
+// program. Its instructions are processed before the transaction executes,
+// by ProcessComputeBudgetInstructions, so executing them is a no-op.
+func ComputeBudgetProgramExecute(execCtx *ExecutionCtx) error {
+	err := execCtx.ComputeMeter.Consume(CUComputeBudgetDefaultComputeUnits)
+	if err != nil {
+		return InstrErrComputationalBudgetExceeded
+	}
+	return nil
+}
+
+// ProcessComputeBudgetInstructions collects the limits requested by the
+// compute budget instructions among the given transaction instructions.
+// Each limit may be requested at most once.
+//
+// Based on solana_program_runtime::compute_budget_processor::process_compute_budget_instructions.
+func ProcessComputeBudgetInstructions(instrs []Instruction) (ComputeBudgetLimits, error) {
+	var heapBytes, computeUnitLimit, loadedAccountsBytes *uint32
+	var computeUnitPrice *uint64
+	var numNonComputeBudgetInstrs uint64
+
+	for i, instr := range instrs {
+		if instr.ProgramId != ComputeBudgetProgramAddr {
+			numNonComputeBudgetInstrs++
+			continue
+		}
+
+		invalid := func() (ComputeBudgetLimits, error) {
+			klog.Infof("invalid compute budget instruction at index %d", i)
+			return ComputeBudgetLimits{}, InstrErrInvalidInstructionData
+		}
+		duplicate := func() (ComputeBudgetLimits, error) {
+			klog.Infof("duplicate compute budget instruction at index %d", i)
+			return ComputeBudgetLimits{}, TxErrDuplicateInstruction
+		}
+
+		decoder := bin.NewBinDecoder(instr.Data)
+		instrType, err := decoder.ReadUint8()
+		if err != nil {
+			return invalid()
+		}
+
+		switch instrType {
+		case ComputeBudgetInstrTypeRequestHeapFrame:
+			bytes, err := decoder.ReadUint32(bin.LE)
+			if err != nil {
+				return invalid()
+			}
+			if heapBytes != nil {
+				return duplicate()
+			}
+			if !isValidHeapFrameSize(bytes) {
+				return invalid()
+			}
+			heapBytes = &bytes
+
+		case ComputeBudgetInstrTypeSetComputeUnitLimit:
+			limit, err := decoder.ReadUint32(bin.LE)
+			if err != nil {
+				return invalid()
+			}
+			if computeUnitLimit != nil {
+				return duplicate()
+			}
+			computeUnitLimit = &limit
+
+		case ComputeBudgetInstrTypeSetComputeUnitPrice:
+			price, err := decoder.ReadUint64(bin.LE)
+			if err != nil {
+				return invalid()
+			}
+			if computeUnitPrice != nil {
+				return duplicate()
+			}
+			computeUnitPrice = &price
+
+		case ComputeBudgetInstrTypeSetLoadedAccountsDataSizeLimit:
+			bytes, err := decoder.ReadUint32(bin.LE)
+			if err != nil {
+				return invalid()
+			}
+			if loadedAccountsBytes != nil {
+				return duplicate()
+			}
+			loadedAccountsBytes = &bytes
+
+		default:
+			return invalid()
+		}
+	}
+
+	limits := ComputeBudgetLimits{
+		UpdatedHeapBytes:    CUHeapSizeDefault,
+		ComputeUnitLimit:    CUMaxComputeUnitLimit,
+		LoadedAccountsBytes: CUMaxLoadedAccountsDataSizeBytes,
+	}
+	// instructions other than compute budget ones get the default limit each
+	if defaultLimit := safemath.SaturatingMulU64(numNonComputeBudgetInstrs, CUDefaultInstructionComputeUnitLimit); defaultLimit < CUMaxComputeUnitLimit {
+		limits.ComputeUnitLimit = uint32(defaultLimit)
+	}
+	if heapBytes != nil {
+		limits.UpdatedHeapBytes = *heapBytes
+	}
+	if computeUnitLimit != nil {
+		limits.ComputeUnitLimit = CUMaxComputeUnitLimit
+		if *computeUnitLimit < CUMaxComputeUnitLimit {
+			limits.ComputeUnitLimit = *computeUnitLimit
+		}
+	}
+	if computeUnitPrice != nil {
+		limits.ComputeUnitPrice = *computeUnitPrice
+	}
+	if loadedAccountsBytes != nil && *loadedAccountsBytes < CUMaxLoadedAccountsDataSizeBytes {
+		limits.LoadedAccountsBytes = *loadedAccountsBytes
+	}
+	return limits, nil
+}
+
+// isValidHeapFrameSize returns whether a heap of the given size may be
+// requested: between 32 KiB and 256 KiB, in multiples of 1 KiB.
+func isValidHeapFrameSize(bytes uint32) bool {
+	return bytes >= CUHeapSizeDefault && bytes <= CUHeapSizeMax && bytes%1024 == 0
+}
@@ -0,0 +1,54 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func computeBudgetInstr(instrType byte, arg uint64, argSize int) Instruction {
+	data := make([]byte, 1+8)
+	data[0] = instrType
+	binary.LittleEndian.PutUint64(data[1:], arg)
+	return Instruction{ProgramId: ComputeBudgetProgramAddr, Data: data[:1+argSize]}
+}
+
+func TestProcessComputeBudgetInstructions(t *testing.T) {
+	transfer := Instruction{ProgramId: SystemProgramAddr}
+
+	limits, err := ProcessComputeBudgetInstructions([]Instruction{transfer, transfer})
+	require.NoError(t, err)
+	assert.Equal(t, ComputeBudgetLimits{
+		UpdatedHeapBytes:    CUHeapSizeDefault,
+		ComputeUnitLimit:    2 * CUDefaultInstructionComputeUnitLimit,
+		LoadedAccountsBytes: CUMaxLoadedAccountsDataSizeBytes,
+	}, limits)
+
+	limits, err = ProcessComputeBudgetInstructions([]Instruction{
+		computeBudgetInstr(ComputeBudgetInstrTypeRequestHeapFrame, 64*1024, 4),
+		computeBudgetInstr(ComputeBudgetInstrTypeSetComputeUnitLimit, 2_000_000, 4),
+		computeBudgetInstr(ComputeBudgetInstrTypeSetComputeUnitPrice, 5, 8),
+		transfer,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(64*1024), limits.UpdatedHeapBytes)
+	assert.Equal(t, uint32(CUMaxComputeUnitLimit), limits.ComputeUnitLimit)
+	assert.Equal(t, uint64(5), limits.ComputeUnitPrice)
+
+	// heap frames must be 1 KiB aligned and within bounds
+	for _, size := range []uint64{CUHeapSizeDefault + 1, CUHeapSizeDefault - 1024, CUHeapSizeMax + 1024} {
+		_, err = ProcessComputeBudgetInstructions([]Instruction{computeBudgetInstr(ComputeBudgetInstrTypeRequestHeapFrame, size, 4)})
+		assert.Equal(t, InstrErrInvalidInstructionData, err)
+	}
+
+	_, err = ProcessComputeBudgetInstructions([]Instruction{
+		computeBudgetInstr(ComputeBudgetInstrTypeSetComputeUnitPrice, 1, 8),
+		computeBudgetInstr(ComputeBudgetInstrTypeSetComputeUnitPrice, 2, 8),
+	})
+	assert.Equal(t, TxErrDuplicateInstruction, err)
+
+	_, err = ProcessComputeBudgetInstructions([]Instruction{computeBudgetInstr(ComputeBudgetInstrTypeRequestUnitsDeprecated, 0, 8)})
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
@@ -116,6 +116,7 @@ func ConfigProgramExecute(ctx *ExecutionCtx) error {
 	if err != nil {
 		return err
 	}
+	defer configAccount.Drop()
 
 	if configAccount.Owner() != ConfigProgramAddr {
 		return InstrErrInvalidAccountOwner
@@ -141,10 +142,12 @@ func ConfigProgramExecute(ctx *ExecutionCtx) error {
 			if err != nil {
 				return InstrErrMissingRequiredSignature
 			}
-			if !signerAcct.IsSigner() {
+			isSigner, key := signerAcct.IsSigner(), signerAcct.Key()
+			signerAcct.Drop()
+			if !isSigner {
 				return InstrErrMissingRequiredSignature
 			}
-			if signerKey.PubKey != signerAcct.Key() {
+			if signerKey.PubKey != key {
 				return InstrErrMissingRequiredSignature
 			}
 
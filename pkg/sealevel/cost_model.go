@@ -0,0 +1,221 @@
+package sealevel
+
+import (
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/safemath"
+)
+
+// Transaction cost weights, in compute units.
+//
+// Based on solana_cost_model::block_cost_limits.
+const (
+	CostSignature              = 720
+	CostSecp256k1Verify        = 6690
+	CostEd25519Verify          = 2400
+	CostSecp256r1Verify        = 4800
+	CostWriteLock              = 300
+	CostInstructionDataBytes   = 140 // bytes per compute unit
+	CostAccountDataPageSize    = 32 * 1024
+	CostSimpleVoteUsage        = 3428
+	MaxAccountsDataAllocsPerTx = 2 * MaxPermittedDataLength
+)
+
+// builtinInstructionCosts are the execution costs of instructions of builtin
+// programs, which are charged in place of a compute unit limit.
+var builtinInstructionCosts = map[solana.PublicKey]uint64{
+	SystemProgramAddr:             CUSystemProgramDefaultComputeUnits,
+	VoteProgramAddr:               CUVoteProgramDefaultComputeUnits,
+	StakeProgramAddr:              CUStakeProgramDefaultComputeUnits,
+	ConfigProgramAddr:             CUConfigProcessorDefaultComputeUnits,
+	ComputeBudgetProgramAddr:      CUComputeBudgetDefaultComputeUnits,
+	AddressLookupTableProgramAddr: CUAddressLookupTableDefaultComputeUnits,
+	BpfLoaderUpgradeableAddr:      CUUpgradeableLoaderComputeUnits,
+	BpfLoaderDeprecatedAddr:       CUDeprecatedLoaderComputeUnits,
+	BpfLoaderAddr:                 CUDefaultLoaderComputeUnits,
+	Secp256kPrecompileAddr:        0,
+	Ed25519PrecompileAddr:         0,
+}
+
+// TransactionCost is the cost of a transaction, charged against the limits
+// of the block it is included in. Simple vote transactions have a fixed
+// cost, and are also charged against the vote cost limit.
+//
+// Based on solana_cost_model::transaction_cost::TransactionCost.
+type TransactionCost struct {
+	IsSimpleVote               bool
+	WritableAccounts           []solana.PublicKey
+	SignatureCost              uint64
+	WriteLockCost              uint64
+	DataBytesCost              uint64
+	ProgramsExecutionCost      uint64
+	LoadedAccountsDataSizeCost uint64
+	AllocatedAccountsDataSize  uint64
+}
+
+// Sum returns the total cost of the transaction.
+func (txCost *TransactionCost) Sum() uint64 {
+	if txCost.IsSimpleVote {
+		return CostSimpleVoteUsage
+	}
+	sum := safemath.SaturatingAddU64(txCost.SignatureCost, txCost.WriteLockCost)
+	sum = safemath.SaturatingAddU64(sum, txCost.DataBytesCost)
+	sum = safemath.SaturatingAddU64(sum, txCost.ProgramsExecutionCost)
+	return safemath.SaturatingAddU64(sum, txCost.LoadedAccountsDataSizeCost)
+}
+
+// IsSimpleVoteTransaction returns whether a transaction is a simple vote
+// transaction: a legacy transaction with at most two signatures and a
+// single vote program instruction.
+//
+// Based on solana_sdk::transaction::SanitizedTransaction::is_simple_vote_transaction.
+func IsSimpleVoteTransaction(numSignatures uint64, isLegacy bool, instrs []Instruction) bool {
+	return numSignatures < 3 && isLegacy && len(instrs) == 1 && instrs[0].ProgramId == VoteProgramAddr
+}
+
+// CalculateTransactionCost returns the estimated cost of a transaction with
+// the given number of signatures, writable accounts and instructions. The
+// execution cost of a transaction using only builtin programs is the sum
+// of their costs; otherwise it is the compute unit limit requested with the
+// compute budget program, or the default limit of each instruction. The
+// cost charged for an executed transaction is given by
+// CalculateExecutedTransactionCost instead.
+//
+// Based on solana_cost_model::cost_model::CostModel::calculate_cost.
+func CalculateTransactionCost(numSignatures uint64, isSimpleVote bool, writableAccounts []solana.PublicKey, instrs []Instruction, f *features.Features) TransactionCost {
+	txCost := TransactionCost{IsSimpleVote: isSimpleVote, WritableAccounts: writableAccounts}
+	if isSimpleVote {
+		return txCost
+	}
+
+	txCost.SignatureCost = safemath.SaturatingMulU64(numSignatures, CostSignature)
+	for _, instr := range instrs {
+		if len(instr.Data) == 0 {
+			continue
+		}
+		numVerifies := uint64(instr.Data[0])
+		switch {
+		case instr.ProgramId == Secp256kPrecompileAddr:
+			txCost.SignatureCost = safemath.SaturatingAddU64(txCost.SignatureCost, safemath.SaturatingMulU64(numVerifies, CostSecp256k1Verify))
+		case instr.ProgramId == Ed25519PrecompileAddr:
+			txCost.SignatureCost = safemath.SaturatingAddU64(txCost.SignatureCost, safemath.SaturatingMulU64(numVerifies, CostEd25519Verify))
+		case instr.ProgramId == Secp256r1PrecompileAddr && f.IsActive(features.EnableSecp256r1Precompile):
+			txCost.SignatureCost = safemath.SaturatingAddU64(txCost.SignatureCost, safemath.SaturatingMulU64(numVerifies, CostSecp256r1Verify))
+		}
+	}
+
+	txCost.WriteLockCost = safemath.SaturatingMulU64(uint64(len(writableAccounts)), CostWriteLock)
+
+	var executionCost, dataBytesLen uint64
+	var hasUserSpaceInstrs, computeUnitLimitIsSet bool
+	for _, instr := range instrs {
+		cost, isBuiltin := builtinInstructionCosts[instr.ProgramId]
+		if !isBuiltin {
+			hasUserSpaceInstrs = true
+			cost = CUDefaultInstructionComputeUnitLimit
+		}
+		executionCost = safemath.SaturatingAddU64(executionCost, cost)
+		if executionCost > CUMaxComputeUnitLimit {
+			executionCost = CUMaxComputeUnitLimit
+		}
+		dataBytesLen = safemath.SaturatingAddU64(dataBytesLen, uint64(len(instr.Data)))
+
+		if instr.ProgramId == ComputeBudgetProgramAddr && len(instr.Data) != 0 && instr.Data[0] == ComputeBudgetInstrTypeSetComputeUnitLimit {
+			computeUnitLimitIsSet = true
+		}
+	}
+
+	limits, err := ProcessComputeBudgetInstructions(instrs)
+	if err != nil {
+		executionCost = 0
+	} else {
+		if hasUserSpaceInstrs && computeUnitLimitIsSet {
+			executionCost = uint64(limits.ComputeUnitLimit)
+		}
+		txCost.LoadedAccountsDataSizeCost = loadedAccountsDataSizeCost(uint64(limits.LoadedAccountsBytes))
+	}
+	txCost.ProgramsExecutionCost = executionCost
+	txCost.DataBytesCost = dataBytesLen / CostInstructionDataBytes
+	txCost.AllocatedAccountsDataSize = allocatedAccountsDataSize(instrs)
+
+	return txCost
+}
+
+// CalculateExecutedTransactionCost returns the cost of an executed
+// transaction, as charged by replay: that of CalculateTransactionCost, with
+// the estimated execution and loaded accounts data size costs replaced by
+// the compute units consumed and the account data loaded by the execution.
+//
+// Based on solana_cost_model::cost_model::CostModel::calculate_cost_for_executed_transaction.
+func CalculateExecutedTransactionCost(numSignatures uint64, isSimpleVote bool, writableAccounts []solana.PublicKey, instrs []Instruction, computeUnitsConsumed uint64, loadedAccountsDataSize uint32, f *features.Features) TransactionCost {
+	txCost := CalculateTransactionCost(numSignatures, isSimpleVote, writableAccounts, instrs, f)
+	if isSimpleVote {
+		return txCost
+	}
+	txCost.ProgramsExecutionCost = computeUnitsConsumed
+	txCost.LoadedAccountsDataSizeCost = loadedAccountsDataSizeCost(uint64(loadedAccountsDataSize))
+	return txCost
+}
+
+// loadedAccountsDataSizeCost charges the heap cost for every page of the
+// loaded accounts data size limit of a transaction.
+func loadedAccountsDataSizeCost(loadedAccountsBytes uint64) uint64 {
+	pages := (loadedAccountsBytes + CostAccountDataPageSize - 1) / CostAccountDataPageSize
+	return safemath.SaturatingMulU64(pages, CUHeapCost)
+}
+
+// allocatedAccountsDataSize returns the account data space a transaction
+// may allocate with system program instructions.
+//
+// Based on solana_cost_model::cost_model::CostModel::calculate_allocated_accounts_data_size.
+func allocatedAccountsDataSize(instrs []Instruction) uint64 {
+	var total uint64
+	for _, instr := range instrs {
+		if instr.ProgramId != SystemProgramAddr {
+			continue
+		}
+
+		decoder := bin.NewBinDecoder(instr.Data)
+		instrType, err := decoder.ReadUint32(bin.LE)
+		if err != nil {
+			continue
+		}
+
+		var space uint64
+		switch instrType {
+		case SystemProgramInstrTypeCreateAccount:
+			var createAccount SystemInstrCreateAccount
+			err = createAccount.UnmarshalWithDecoder(decoder)
+			space = createAccount.Space
+		case SystemProgramInstrTypeCreateAccountWithSeed:
+			var createAccountWithSeed SystemInstrCreateAccountWithSeed
+			err = createAccountWithSeed.UnmarshalWithDecoder(decoder)
+			space = createAccountWithSeed.Space
+		case SystemProgramInstrTypeAllocate:
+			var allocate SystemInstrAllocate
+			err = allocate.UnmarshalWithDecoder(decoder)
+			space = allocate.Space
+		case SystemProgramInstrTypeAllocateWithSeed:
+			var allocateWithSeed SystemInstrAllocateWithSeed
+			err = allocateWithSeed.UnmarshalWithDecoder(decoder)
+			space = allocateWithSeed.Space
+		default:
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		// such a transaction fails, and allocates nothing
+		if space > MaxPermittedDataLength {
+			return 0
+		}
+		total = safemath.SaturatingAddU64(total, space)
+	}
+
+	if total > MaxAccountsDataAllocsPerTx {
+		return MaxAccountsDataAllocsPerTx
+	}
+	return total
+}
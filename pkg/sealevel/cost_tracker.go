@@ -0,0 +1,116 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/safemath"
+	"k8s.io/klog/v2"
+)
+
+// Block cost limits, in compute units.
+//
+// Based on solana_cost_model::block_cost_limits.
+const (
+	MaxBlockUnits                 = 48_000_000
+	MaxWritableAccountUnits       = 12_000_000
+	MaxVoteUnits                  = 36_000_000
+	MaxBlockAccountsDataSizeDelta = 100_000_000
+)
+
+// CostTracker accumulates the costs of the transactions of a block, and
+// rejects transactions that would exceed the block cost limit, the vote
+// cost limit, the cost limit of any writable account, or the limit on new
+// account data allocated in the block.
+//
+// Based on solana_cost_model::cost_tracker::CostTracker.
+type CostTracker struct {
+	AccountCostLimit uint64
+	BlockCostLimit   uint64
+	VoteCostLimit    uint64
+
+	costByWritableAccounts    map[solana.PublicKey]uint64
+	blockCost                 uint64
+	voteCost                  uint64
+	transactionCount          uint64
+	allocatedAccountsDataSize uint64
+}
+
+// NewCostTracker returns a cost tracker with the default block limits.
+func NewCostTracker() *CostTracker {
+	return &CostTracker{
+		AccountCostLimit:       MaxWritableAccountUnits,
+		BlockCostLimit:         MaxBlockUnits,
+		VoteCostLimit:          MaxVoteUnits,
+		costByWritableAccounts: make(map[solana.PublicKey]uint64),
+	}
+}
+
+// TryAdd adds the cost of a transaction to the block, or returns the
+// transaction error of the first limit it would exceed, adding nothing.
+func (ct *CostTracker) TryAdd(txCost *TransactionCost) error {
+	err := ct.wouldFit(txCost)
+	if err != nil {
+		return err
+	}
+	ct.add(txCost)
+	return nil
+}
+
+func (ct *CostTracker) wouldFit(txCost *TransactionCost) error {
+	cost := txCost.Sum()
+
+	if txCost.IsSimpleVote && safemath.SaturatingAddU64(ct.voteCost, cost) > ct.VoteCostLimit {
+		return TxErrWouldExceedMaxVoteCostLimit
+	}
+	if safemath.SaturatingAddU64(ct.blockCost, cost) > ct.BlockCostLimit {
+		return TxErrWouldExceedMaxBlockCostLimit
+	}
+	if cost > ct.AccountCostLimit {
+		return TxErrWouldExceedMaxAccountCostLimit
+	}
+	if safemath.SaturatingAddU64(ct.allocatedAccountsDataSize, txCost.AllocatedAccountsDataSize) > MaxBlockAccountsDataSizeDelta {
+		return TxErrWouldExceedAccountDataBlockLimit
+	}
+
+	for _, key := range txCost.WritableAccounts {
+		if safemath.SaturatingAddU64(ct.costByWritableAccounts[key], cost) > ct.AccountCostLimit {
+			klog.Infof("transaction would exceed the cost limit of account %s", key)
+			return TxErrWouldExceedMaxAccountCostLimit
+		}
+	}
+	return nil
+}
+
+func (ct *CostTracker) add(txCost *TransactionCost) {
+	cost := txCost.Sum()
+	for _, key := range txCost.WritableAccounts {
+		ct.costByWritableAccounts[key] = safemath.SaturatingAddU64(ct.costByWritableAccounts[key], cost)
+	}
+	ct.blockCost = safemath.SaturatingAddU64(ct.blockCost, cost)
+	if txCost.IsSimpleVote {
+		ct.voteCost = safemath.SaturatingAddU64(ct.voteCost, cost)
+	}
+	ct.allocatedAccountsDataSize = safemath.SaturatingAddU64(ct.allocatedAccountsDataSize, txCost.AllocatedAccountsDataSize)
+	ct.transactionCount++
+}
+
+// BlockCost returns the total cost of the transactions added to the block.
+func (ct *CostTracker) BlockCost() uint64 {
+	return ct.blockCost
+}
+
+// VoteCost returns the total cost of the simple vote transactions added to
+// the block.
+func (ct *CostTracker) VoteCost() uint64 {
+	return ct.voteCost
+}
+
+// TransactionCount returns the number of transactions added to the block.
+func (ct *CostTracker) TransactionCount() uint64 {
+	return ct.transactionCount
+}
+
+// AccountCost returns the total cost of the transactions added to the block
+// that write lock the given account.
+func (ct *CostTracker) AccountCost(key solana.PublicKey) uint64 {
+	return ct.costByWritableAccounts[key]
+}
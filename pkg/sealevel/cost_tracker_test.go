@@ -0,0 +1,105 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func TestCalculateTransactionCost(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	payer, to := solana.PublicKey{1}, solana.PublicKey{2}
+
+	// a transfer only pays for the system program
+	transfer := []Instruction{{ProgramId: SystemProgramAddr, Data: systemInstrData(SystemProgramInstrTypeTransfer, make([]byte, 8))}}
+	txCost := CalculateTransactionCost(1, false, []solana.PublicKey{payer, to}, transfer, f)
+	assert.Equal(t, TransactionCost{
+		WritableAccounts:           []solana.PublicKey{payer, to},
+		SignatureCost:              CostSignature,
+		WriteLockCost:              2 * CostWriteLock,
+		ProgramsExecutionCost:      CUSystemProgramDefaultComputeUnits,
+		LoadedAccountsDataSizeCost: 16 * 1024,
+	}, txCost)
+	assert.Equal(t, uint64(720+600+150+16*1024), txCost.Sum())
+
+	// user programs are charged the requested compute unit limit
+	limitData := make([]byte, 5)
+	limitData[0] = ComputeBudgetInstrTypeSetComputeUnitLimit
+	binary.LittleEndian.PutUint32(limitData[1:], 50_000)
+	instrs := []Instruction{{ProgramId: solana.PublicKey{9}, Data: make([]byte, 280)}, {ProgramId: ComputeBudgetProgramAddr, Data: limitData}}
+	txCost = CalculateTransactionCost(1, false, []solana.PublicKey{payer}, instrs, f)
+	assert.Equal(t, uint64(50_000), txCost.ProgramsExecutionCost)
+	assert.Equal(t, uint64(285/CostInstructionDataBytes), txCost.DataBytesCost)
+
+	// or the default limit of each instruction
+	txCost = CalculateTransactionCost(1, false, []solana.PublicKey{payer}, instrs[:1], f)
+	assert.Equal(t, uint64(CUDefaultInstructionComputeUnitLimit), txCost.ProgramsExecutionCost)
+
+	// precompile signatures are charged per verification
+	txCost = CalculateTransactionCost(1, false, nil, []Instruction{{ProgramId: Ed25519PrecompileAddr, Data: []byte{2}}}, f)
+	assert.Equal(t, uint64(CostSignature+2*CostEd25519Verify), txCost.SignatureCost)
+
+	// account data allocated with the system program
+	space := make([]byte, 8)
+	binary.LittleEndian.PutUint64(space, 1000)
+	createAccount := systemInstrData(SystemProgramInstrTypeCreateAccount, make([]byte, 8), space, make([]byte, 32))
+	txCost = CalculateTransactionCost(1, false, nil, []Instruction{{ProgramId: SystemProgramAddr, Data: createAccount}}, f)
+	assert.Equal(t, uint64(1000), txCost.AllocatedAccountsDataSize)
+
+	txCost = CalculateTransactionCost(1, true, []solana.PublicKey{payer}, transfer, f)
+	assert.Equal(t, uint64(CostSimpleVoteUsage), txCost.Sum())
+}
+
+func TestCalculateExecutedTransactionCost(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	payer := solana.PublicKey{1}
+
+	// executed transactions are charged the compute units they consumed,
+	// rather than those they requested
+	limitData := make([]byte, 5)
+	limitData[0] = ComputeBudgetInstrTypeSetComputeUnitLimit
+	binary.LittleEndian.PutUint32(limitData[1:], 1_400_000)
+	instrs := []Instruction{{ProgramId: solana.PublicKey{9}}, {ProgramId: ComputeBudgetProgramAddr, Data: limitData}}
+	txCost := CalculateExecutedTransactionCost(1, false, []solana.PublicKey{payer}, instrs, 2_000, 40*1024, f)
+	assert.Equal(t, uint64(2_000), txCost.ProgramsExecutionCost)
+	assert.Equal(t, uint64(2*CUHeapCost), txCost.LoadedAccountsDataSizeCost)
+	assert.Equal(t, uint64(CostSignature+CostWriteLock+2_000+2*CUHeapCost), txCost.Sum())
+
+	txCost = CalculateExecutedTransactionCost(1, true, []solana.PublicKey{payer}, instrs, 2_000, 40*1024, f)
+	assert.Equal(t, uint64(CostSimpleVoteUsage), txCost.Sum())
+}
+
+func TestCostTracker(t *testing.T) {
+	ct := NewCostTracker()
+	ct.AccountCostLimit, ct.BlockCostLimit, ct.VoteCostLimit = 100, 250, 3428
+
+	a, b := solana.PublicKey{1}, solana.PublicKey{2}
+	require.NoError(t, ct.TryAdd(&TransactionCost{WritableAccounts: []solana.PublicKey{a}, ProgramsExecutionCost: 60}))
+	assert.Equal(t, TxErrWouldExceedMaxAccountCostLimit, ct.TryAdd(&TransactionCost{WritableAccounts: []solana.PublicKey{a}, ProgramsExecutionCost: 41}))
+	assert.Equal(t, TxErrWouldExceedMaxAccountCostLimit, ct.TryAdd(&TransactionCost{ProgramsExecutionCost: 101}))
+	require.NoError(t, ct.TryAdd(&TransactionCost{WritableAccounts: []solana.PublicKey{b}, ProgramsExecutionCost: 100}))
+	assert.Equal(t, uint64(60), ct.AccountCost(a))
+	assert.Equal(t, uint64(160), ct.BlockCost())
+
+	assert.Equal(t, TxErrWouldExceedMaxBlockCostLimit, ct.TryAdd(&TransactionCost{ProgramsExecutionCost: 91}))
+	assert.Equal(t, TxErrWouldExceedAccountDataBlockLimit, ct.TryAdd(&TransactionCost{AllocatedAccountsDataSize: MaxBlockAccountsDataSizeDelta + 1}))
+	assert.Equal(t, uint64(2), ct.TransactionCount())
+
+	ct = NewCostTracker()
+	ct.VoteCostLimit = 2 * CostSimpleVoteUsage
+	require.NoError(t, ct.TryAdd(&TransactionCost{IsSimpleVote: true}))
+	require.NoError(t, ct.TryAdd(&TransactionCost{IsSimpleVote: true}))
+	assert.Equal(t, TxErrWouldExceedMaxVoteCostLimit, ct.TryAdd(&TransactionCost{IsSimpleVote: true}))
+	assert.Equal(t, uint64(2*CostSimpleVoteUsage), ct.VoteCost())
+}
+
+func TestApplyComputeBudgetLimits(t *testing.T) {
+	execCtx := &ExecutionCtx{}
+	execCtx.ApplyComputeBudgetLimits(ComputeBudgetLimits{ComputeUnitLimit: 12345, UpdatedHeapBytes: 64 * 1024})
+	assert.Equal(t, uint64(12345), execCtx.ComputeMeter.Remaining())
+	assert.Equal(t, uint64(64*1024), execCtx.heapSize())
+}
@@ -1,23 +1,82 @@
 package sealevel
 
+import "go.firedancer.io/radiance/pkg/safemath"
+
+// Compute unit costs and limits.
+//
+// Based on solana_program_runtime::compute_budget::ComputeBudget defaults.
 const (
-	CUSyscallBaseCost                    = 100
-	CULog64Units                         = 100
-	CULogPubkeyUnits                     = 100
-	CUMemOpBaseCost                      = 10
-	CUCpiBytesPerUnit                    = 250
-	CUSha256BaseCost                     = 85
-	CUSha256ByteCost                     = 1
-	CUCreateProgramAddressUnits          = 1500
-	CUSecP256k1RecoverCost               = 25000
-	CUInvokeUnits                        = 1000
-	CUConfigProcessorDefaultComputeUnits = 450
-	CUSystemProgramDefaultComputeUnits   = 150
-	CUVoteProgramDefaultComputeUnits     = 2100
-	CUStakeProgramDefaultComputeUnits    = 750
-	CUSha256MaxSlices                    = 20000
-	CUMaxCpiInstructionSize              = 1280
-	CUUpgradeableLoaderComputeUnits      = 2370
-	CUDeprecatedLoaderComputeUnits       = 1140
-	CUDefaultLoaderComputeUnits          = 570
+	// transaction-wide limits
+	CUDefaultInstructionComputeUnitLimit = 200_000
+	CUMaxComputeUnitLimit                = 1_400_000
+	CUMaxInvokeStackHeight               = 5
+	CUMaxInstructionTraceLength          = 64
+	CUHeapSizeDefault                    = 32 * 1024
+	CUHeapSizeMax                        = 256 * 1024
+	CUHeapCost                           = 8
+	CUMaxLoadedAccountsDataSizeBytes     = 64 * 1024 * 1024
+
+	// syscalls
+	CUSyscallBaseCost             = 100
+	CULog64Units                  = 100
+	CULogPubkeyUnits              = 100
+	CUMemOpBaseCost               = 10
+	CUCpiBytesPerUnit             = 250
+	CUSha256BaseCost              = 85
+	CUSha256ByteCost              = 1
+	CUSha256MaxSlices             = 20000
+	CUCreateProgramAddressUnits   = 1500
+	CUSecP256k1RecoverCost        = 25000
+	CUSysvarBaseCost              = 100
+	CUGetRemainingComputeUnitCost = 100
+
+	// cross-program invocation
+	CUInvokeUnits           = 1000
+	CUMaxCpiInstructionSize = 1280
+
+	// curve25519
+	CUCurve25519EdwardsValidatePointCost    = 159
+	CUCurve25519EdwardsAddCost              = 473
+	CUCurve25519EdwardsSubtractCost         = 475
+	CUCurve25519EdwardsMultiplyCost         = 2177
+	CUCurve25519EdwardsMsmBaseCost          = 2273
+	CUCurve25519EdwardsMsmIncrementalCost   = 758
+	CUCurve25519RistrettoValidatePointCost  = 169
+	CUCurve25519RistrettoAddCost            = 521
+	CUCurve25519RistrettoSubtractCost       = 519
+	CUCurve25519RistrettoMultiplyCost       = 2208
+	CUCurve25519RistrettoMsmBaseCost        = 2303
+	CUCurve25519RistrettoMsmIncrementalCost = 788
+
+	// alt_bn128, big_mod_exp and poseidon
+	CUAltBn128AdditionCost            = 334
+	CUAltBn128MultiplicationCost      = 3840
+	CUAltBn128PairingOnePairCostFirst = 36364
+	CUAltBn128PairingOnePairCostOther = 12121
+	CUAltBn128G1Compress              = 30
+	CUAltBn128G1Decompress            = 398
+	CUAltBn128G2Compress              = 86
+	CUAltBn128G2Decompress            = 13610
+	CUBigModularExponentiationCost    = 33
+	CUPoseidonCostCoefficientA        = 61
+	CUPoseidonCostCoefficientC        = 542
+
+	// builtin programs
+	CUComputeBudgetDefaultComputeUnits      = 150
+	CUConfigProcessorDefaultComputeUnits    = 450
+	CUSystemProgramDefaultComputeUnits      = 150
+	CUVoteProgramDefaultComputeUnits        = 2100
+	CUStakeProgramDefaultComputeUnits       = 750
+	CUUpgradeableLoaderComputeUnits         = 2370
+	CUDeprecatedLoaderComputeUnits          = 1140
+	CUDefaultLoaderComputeUnits             = 570
+	CUAddressLookupTableDefaultComputeUnits = 750
 )
+
+// calculateHeapCost returns the cost of a VM heap of the given size. Heap
+// is charged per 32 KiB page, with the first page free.
+func calculateHeapCost(heapSize uint64) uint64 {
+	const pageSize = 32 * 1024
+	pages := safemath.SaturatingSubU64(safemath.SaturatingAddU64(heapSize, pageSize), 1) / pageSize
+	return safemath.SaturatingMulU64(safemath.SaturatingSubU64(pages, 1), CUHeapCost)
+}
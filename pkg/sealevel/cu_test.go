@@ -0,0 +1,16 @@
+package sealevel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateHeapCost(t *testing.T) {
+	assert.Equal(t, uint64(0), calculateHeapCost(0))
+	assert.Equal(t, uint64(0), calculateHeapCost(CUHeapSizeDefault))
+	assert.Equal(t, uint64(CUHeapCost), calculateHeapCost(CUHeapSizeDefault+1))
+	assert.Equal(t, uint64(7*CUHeapCost), calculateHeapCost(256*1024))
+	assert.Equal(t, uint64((math.MaxUint64/(32*1024)-1)*CUHeapCost), calculateHeapCost(math.MaxUint64))
+}
@@ -0,0 +1,199 @@
+package sealevel
+
+import (
+	"encoding/binary"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+// A durable nonce transaction uses the durable nonce stored in a nonce
+// account as its recent blockhash, rather than a blockhash of a recent slot.
+// Its first instruction must advance the nonce account, so that the
+// transaction cannot be replayed. The nonce account is advanced, and the fee
+// payer is charged, even if the transaction fails.
+
+// NonceInfo is a nonce account advanced by a durable nonce transaction.
+type NonceInfo struct {
+	Address solana.PublicKey
+	Account *accounts.Account
+}
+
+// DurableNonceAccountAddr returns the address of the nonce account used by a
+// durable nonce transaction, or false if the transaction does not use a
+// durable nonce: its first instruction must be a system program
+// AdvanceNonceAccount instruction with a writable nonce account.
+//
+// Based on solana_sdk::transaction::SanitizedTransaction::get_durable_nonce.
+func DurableNonceAccountAddr(instrs []Instruction) (solana.PublicKey, bool) {
+	if len(instrs) == 0 {
+		return solana.PublicKey{}, false
+	}
+	instr := instrs[0]
+	if instr.ProgramId != SystemProgramAddr {
+		return solana.PublicKey{}, false
+	}
+	if len(instr.Data) < 4 || binary.LittleEndian.Uint32(instr.Data) != SystemProgramInstrTypeAdvanceNonceAccount {
+		return solana.PublicKey{}, false
+	}
+	if len(instr.Accounts) == 0 || !instr.Accounts[0].IsWritable {
+		return solana.PublicKey{}, false
+	}
+	return instr.Accounts[0].Pubkey, true
+}
+
+// VerifyNonceAccount returns the nonce data of the given account if it is a
+// current version nonce account, owned by the system program, whose durable
+// nonce is the given recent blockhash.
+//
+// Based on solana_sdk::nonce_account::verify_nonce_account.
+func VerifyNonceAccount(acct *accounts.Account, recentBlockhash [32]byte) (*NonceData, bool) {
+	if acct.Owner != SystemProgramAddr {
+		return nil, false
+	}
+	nonceStateVersions, err := unmarshalNonceStateVersions(acct.Data)
+	if err != nil || nonceStateVersions.Type != NonceVersionCurrent {
+		return nil, false
+	}
+	state := nonceStateVersions.State()
+	if !state.IsInitialized || state.DurableNonce != recentBlockhash {
+		return nil, false
+	}
+	return state, true
+}
+
+// CheckTransactionAge validates the recent blockhash of a transaction. A
+// transaction referencing one of the recent blockhashes is charged the
+// lamports per signature of that blockhash. Otherwise, it must be a valid
+// durable nonce transaction, and its advanced nonce account is returned, to
+// be committed even if the transaction fails.
+//
+// Based on solana_svm::transaction_processor::TransactionBatchProcessor::check_transaction_age.
+func CheckTransactionAge(accts accounts.Accounts, instrs []Instruction, recentBlockhash [32]byte, recentBlockhashes SysvarRecentBlockhashes, blockhash [32]byte, lamportsPerSignature uint64) (*NonceInfo, uint64, error) {
+	for _, entry := range recentBlockhashes {
+		if entry.Blockhash == recentBlockhash {
+			return nil, entry.FeeCalculator.LamportsPerSignature, nil
+		}
+	}
+	return LoadAndAdvanceNonceAccount(accts, instrs, recentBlockhash, blockhash, lamportsPerSignature)
+}
+
+// LoadAndAdvanceNonceAccount validates a durable nonce transaction whose
+// recent blockhash is not a recent blockhash of the bank. It returns the
+// nonce account, advanced to the durable nonce of the given blockhash, and
+// the lamports per signature stored in the nonce account, which the fee is
+// charged at. Returns TxErrBlockhashNotFound if the transaction is not a
+// valid durable nonce transaction.
+//
+// Based on solana_runtime::bank::Bank::check_load_and_advance_message_nonce_account.
+func LoadAndAdvanceNonceAccount(accts accounts.Accounts, instrs []Instruction, recentBlockhash [32]byte, blockhash [32]byte, lamportsPerSignature uint64) (*NonceInfo, uint64, error) {
+	// the nonce cannot be advanced twice in the same slot
+	nextDurableNonce := durableNonce(blockhash)
+	if recentBlockhash == nextDurableNonce {
+		return nil, 0, TxErrBlockhashNotFound
+	}
+
+	nonceAddr, ok := DurableNonceAccountAddr(instrs)
+	if !ok {
+		return nil, 0, TxErrBlockhashNotFound
+	}
+	nonceAcct, err := accts.GetAccount((*[32]byte)(&nonceAddr))
+	if err != nil || nonceAcct == nil {
+		return nil, 0, TxErrBlockhashNotFound
+	}
+	nonceData, ok := VerifyNonceAccount(nonceAcct, recentBlockhash)
+	if !ok {
+		return nil, 0, TxErrBlockhashNotFound
+	}
+
+	// the nonce authority must sign the advance instruction
+	var isAuthorized bool
+	for _, acctMeta := range instrs[0].Accounts {
+		if acctMeta.IsSigner && acctMeta.Pubkey == nonceData.Authority {
+			isAuthorized = true
+			break
+		}
+	}
+	if !isAuthorized {
+		return nil, 0, TxErrBlockhashNotFound
+	}
+
+	nextNonceStateVersions := NonceStateVersions{Type: NonceVersionCurrent, Current: NonceData{
+		IsInitialized: true,
+		Authority:     nonceData.Authority,
+		DurableNonce:  nextDurableNonce,
+		FeeCalculator: FeeCalculator{LamportsPerSignature: lamportsPerSignature},
+	}}
+	nextData, err := nextNonceStateVersions.Marshal()
+	if err != nil || len(nextData) > len(nonceAcct.Data) {
+		return nil, 0, TxErrBlockhashNotFound
+	}
+
+	advancedAcct := *nonceAcct
+	advancedAcct.Data = append([]byte{}, nonceAcct.Data...)
+	copy(advancedAcct.Data, nextData)
+
+	return &NonceInfo{Address: nonceAddr, Account: &advancedAcct}, nonceData.FeeCalculator.LamportsPerSignature, nil
+}
+
+// RollbackAccounts are the accounts committed when a transaction fails:
+// the fee payer, charged the transaction fee, and the advanced nonce
+// account of a durable nonce transaction. If the nonce account is also the
+// fee payer, the fee payer carries the advanced nonce state, and Nonce is nil.
+//
+// Based on solana_svm::rollback_accounts::RollbackAccounts.
+type RollbackAccounts struct {
+	FeePayerAddr solana.PublicKey
+	FeePayer     *accounts.Account
+	Nonce        *NonceInfo
+}
+
+// NewRollbackAccounts returns the rollback accounts of a transaction, given
+// its nonce account, if any, and its fee payer after charging the fee.
+func NewRollbackAccounts(nonce *NonceInfo, feePayerAddr solana.PublicKey, feePayer *accounts.Account) RollbackAccounts {
+	feePayerCopy := *feePayer
+	rollback := RollbackAccounts{FeePayerAddr: feePayerAddr, FeePayer: &feePayerCopy}
+
+	if nonce != nil {
+		if nonce.Address == feePayerAddr {
+			feePayerCopy.Data = append([]byte{}, nonce.Account.Data...)
+		} else {
+			rollback.Nonce = nonce
+		}
+	}
+	return rollback
+}
+
+// Commit writes the rollback accounts to the given accounts.
+func (rollback *RollbackAccounts) Commit(accts accounts.Accounts) error {
+	err := accts.SetAccount((*[32]byte)(&rollback.FeePayerAddr), rollback.FeePayer)
+	if err != nil {
+		return err
+	}
+	if rollback.Nonce != nil {
+		return accts.SetAccount((*[32]byte)(&rollback.Nonce.Address), rollback.Nonce.Account)
+	}
+	return nil
+}
+
+// CommitTransactionAccounts writes the accounts of an executed transaction,
+// in the order of its account keys. If the transaction succeeded, all of its
+// writable accounts are committed. Otherwise, only its rollback accounts
+// are, so that the fee is charged and the nonce advanced regardless.
+//
+// Based on solana_runtime::account_saver::collect_accounts_to_store.
+func CommitTransactionAccounts(accts accounts.Accounts, accountKeys []solana.PublicKey, isWritable []bool, txAccts []*accounts.Account, rollback *RollbackAccounts, txErr error) error {
+	if txErr != nil {
+		return rollback.Commit(accts)
+	}
+	for idx := range accountKeys {
+		if idx >= len(isWritable) || !isWritable[idx] || idx >= len(txAccts) {
+			continue
+		}
+		err := accts.SetAccount((*[32]byte)(&accountKeys[idx]), txAccts[idx])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func newTestNonceAcct(t *testing.T, authority solana.PublicKey, blockhash [32]byte) *accounts.Account {
+	state := NonceStateVersions{Type: NonceVersionCurrent, Current: NonceData{
+		IsInitialized: true,
+		Authority:     authority,
+		DurableNonce:  durableNonce(blockhash),
+		FeeCalculator: FeeCalculator{LamportsPerSignature: 5000},
+	}}
+	stateData, err := state.Marshal()
+	require.NoError(t, err)
+	acct := &accounts.Account{Lamports: 10_000_000, Owner: SystemProgramAddr, Data: make([]byte, NonceStateVersionsSize)}
+	copy(acct.Data, stateData)
+	return acct
+}
+
+func newTestAdvanceNonceInstrs(nonceKey, authority solana.PublicKey) []Instruction {
+	return []Instruction{{
+		ProgramId: SystemProgramAddr,
+		Data:      systemInstrData(SystemProgramInstrTypeAdvanceNonceAccount),
+		Accounts: []AccountMeta{
+			{Pubkey: nonceKey, IsWritable: true},
+			{Pubkey: SysvarRecentBlockHashesAddr},
+			{Pubkey: authority, IsSigner: true},
+		},
+	}}
+}
+
+func TestLoadAndAdvanceNonceAccount(t *testing.T) {
+	nonceKey, authority := solana.PublicKey{1}, solana.PublicKey{2}
+	nonceBlockhash, blockhash := [32]byte{9}, [32]byte{10}
+	recentBlockhash := durableNonce(nonceBlockhash)
+
+	nonceAcct := newTestNonceAcct(t, authority, nonceBlockhash)
+	accts := accounts.NewMemAccounts()
+	require.NoError(t, accts.SetAccount((*[32]byte)(&nonceKey), nonceAcct))
+
+	instrs := newTestAdvanceNonceInstrs(nonceKey, authority)
+	nonce, lamportsPerSignature, err := LoadAndAdvanceNonceAccount(accts, instrs, recentBlockhash, blockhash, 7000)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5000), lamportsPerSignature)
+	assert.Equal(t, nonceKey, nonce.Address)
+
+	state, err := unmarshalNonceStateVersions(nonce.Account.Data)
+	require.NoError(t, err)
+	assert.Equal(t, durableNonce(blockhash), state.Current.DurableNonce)
+	assert.Equal(t, uint64(7000), state.Current.FeeCalculator.LamportsPerSignature)
+	assert.Len(t, nonce.Account.Data, NonceStateVersionsSize)
+
+	// the stored account is left untouched
+	_, ok := VerifyNonceAccount(nonceAcct, recentBlockhash)
+	assert.True(t, ok)
+
+	// the nonce cannot be advanced within the slot it was advanced in
+	_, _, err = LoadAndAdvanceNonceAccount(accts, instrs, recentBlockhash, nonceBlockhash, 7000)
+	assert.Equal(t, TxErrBlockhashNotFound, err)
+
+	// the recent blockhash must be the stored durable nonce
+	_, _, err = LoadAndAdvanceNonceAccount(accts, instrs, [32]byte{11}, blockhash, 7000)
+	assert.Equal(t, TxErrBlockhashNotFound, err)
+
+	// the nonce authority must sign
+	instrs[0].Accounts[2].IsSigner = false
+	_, _, err = LoadAndAdvanceNonceAccount(accts, instrs, recentBlockhash, blockhash, 7000)
+	assert.Equal(t, TxErrBlockhashNotFound, err)
+
+	// the first instruction must advance the nonce
+	instrs = newTestAdvanceNonceInstrs(nonceKey, authority)
+	instrs[0].Data = systemInstrData(SystemProgramInstrTypeTransfer)
+	_, _, err = LoadAndAdvanceNonceAccount(accts, instrs, recentBlockhash, blockhash, 7000)
+	assert.Equal(t, TxErrBlockhashNotFound, err)
+}
+
+func TestRollbackAccounts(t *testing.T) {
+	nonceKey, feePayerKey, authority := solana.PublicKey{1}, solana.PublicKey{2}, solana.PublicKey{3}
+	nonceAcct := newTestNonceAcct(t, authority, [32]byte{9})
+	nonce := &NonceInfo{Address: nonceKey, Account: nonceAcct}
+	feePayer := &accounts.Account{Lamports: 1_000_000, Owner: SystemProgramAddr}
+
+	accts := accounts.NewMemAccounts()
+	rollback := NewRollbackAccounts(nonce, feePayerKey, feePayer)
+	require.NotNil(t, rollback.Nonce)
+	require.NoError(t, rollback.Commit(accts))
+
+	committed, err := accts.GetAccount((*[32]byte)(&nonceKey))
+	require.NoError(t, err)
+	assert.Equal(t, nonceAcct.Data, committed.Data)
+	committed, err = accts.GetAccount((*[32]byte)(&feePayerKey))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1_000_000), committed.Lamports)
+
+	// a nonce account paying the fee carries the advanced nonce state
+	feePayer = &accounts.Account{Lamports: 1_000_000, Owner: SystemProgramAddr, Data: make([]byte, NonceStateVersionsSize)}
+	rollback = NewRollbackAccounts(nonce, nonceKey, feePayer)
+	assert.Nil(t, rollback.Nonce)
+	assert.Equal(t, nonceAcct.Data, rollback.FeePayer.Data)
+	assert.Equal(t, uint64(1_000_000), rollback.FeePayer.Lamports)
+}
+
+func TestCheckTransactionAge(t *testing.T) {
+	nonceKey, authority := solana.PublicKey{1}, solana.PublicKey{2}
+	nonceBlockhash, blockhash := [32]byte{9}, [32]byte{10}
+	recentBlockhashes := SysvarRecentBlockhashes{{Blockhash: [32]byte{12}, FeeCalculator: FeeCalculator{LamportsPerSignature: 6000}}}
+
+	accts := accounts.NewMemAccounts()
+	require.NoError(t, accts.SetAccount((*[32]byte)(&nonceKey), newTestNonceAcct(t, authority, nonceBlockhash)))
+	instrs := newTestAdvanceNonceInstrs(nonceKey, authority)
+
+	// recent blockhashes need no nonce
+	nonce, lamportsPerSignature, err := CheckTransactionAge(accts, instrs, [32]byte{12}, recentBlockhashes, blockhash, 7000)
+	require.NoError(t, err)
+	assert.Nil(t, nonce)
+	assert.Equal(t, uint64(6000), lamportsPerSignature)
+
+	nonce, lamportsPerSignature, err = CheckTransactionAge(accts, instrs, durableNonce(nonceBlockhash), recentBlockhashes, blockhash, 7000)
+	require.NoError(t, err)
+	require.NotNil(t, nonce)
+	assert.Equal(t, uint64(5000), lamportsPerSignature)
+
+	_, _, err = CheckTransactionAge(accts, instrs, [32]byte{11}, recentBlockhashes, blockhash, 7000)
+	assert.Equal(t, TxErrBlockhashNotFound, err)
+}
+
+func TestCommitTransactionAccounts(t *testing.T) {
+	nonceKey, feePayerKey, otherKey := solana.PublicKey{1}, solana.PublicKey{2}, solana.PublicKey{3}
+	keys := []solana.PublicKey{feePayerKey, nonceKey, otherKey}
+	isWritable := []bool{true, true, false}
+	nonce := &NonceInfo{Address: nonceKey, Account: newTestNonceAcct(t, solana.PublicKey{4}, [32]byte{9})}
+	feePayer := &accounts.Account{Lamports: 1_000_000, Owner: SystemProgramAddr}
+	rollback := NewRollbackAccounts(nonce, feePayerKey, feePayer)
+
+	executed := []*accounts.Account{
+		{Lamports: 500_000, Owner: SystemProgramAddr},
+		{Lamports: 2, Owner: SystemProgramAddr},
+		{Lamports: 3},
+	}
+
+	// failed transactions only commit the fee payer and nonce
+	accts := accounts.NewMemAccounts()
+	require.NoError(t, CommitTransactionAccounts(accts, keys, isWritable, executed, &rollback, InstrErrInvalidArgument))
+	committed, err := accts.GetAccount((*[32]byte)(&feePayerKey))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1_000_000), committed.Lamports)
+	committed, err = accts.GetAccount((*[32]byte)(&nonceKey))
+	require.NoError(t, err)
+	assert.Equal(t, nonce.Account.Data, committed.Data)
+
+	// successful transactions commit their writable accounts
+	accts = accounts.NewMemAccounts()
+	require.NoError(t, CommitTransactionAccounts(accts, keys, isWritable, executed, &rollback, nil))
+	committed, err = accts.GetAccount((*[32]byte)(&feePayerKey))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500_000), committed.Lamports)
+	committed, err = accts.GetAccount((*[32]byte)(&nonceKey))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), committed.Lamports)
+	_, err = accts.GetAccount((*[32]byte)(&otherKey))
+	assert.Error(t, err)
+}
@@ -0,0 +1,19 @@
+package sealevel
+
+// EpochStakes holds the active stake of the current epoch, as returned by
+// the sol_get_epoch_stake syscall.
+//
+// Based on solana_runtime::epoch_stakes::EpochStakes.
+type EpochStakes struct {
+	TotalStake uint64
+
+	// VoteAccountStakes maps vote account addresses to the active stake
+	// delegated to them.
+	VoteAccountStakes map[[32]byte]uint64
+}
+
+// VoteAccountStake returns the active stake delegated to the given vote
+// account, or 0 if the address is not a staked vote account.
+func (epochStakes *EpochStakes) VoteAccountStake(voteAddr [32]byte) uint64 {
+	return epochStakes.VoteAccountStakes[voteAddr]
+}
@@ -1,6 +1,9 @@
 package sealevel
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // instruction errors
 var (
@@ -38,8 +41,89 @@ var (
 	InstrErrIncorrectAuthority             = errors.New("InstrErrIncorrectAuthority")
 	InstrErrExecutableAccountNotRentExempt = errors.New("InstrErrExecutableAccountNotRentExempt")
 	InstrErrExecutableModified             = errors.New("InstrErrExecutableModified")
+	InstrErrProgramFailedToComplete        = errors.New("InstrErrProgramFailedToComplete")
+	InstrErrAccountBorrowFailed            = errors.New("InstrErrAccountBorrowFailed")
+	InstrErrMaxSeedLengthExceeded          = errors.New("InstrErrMaxSeedLengthExceeded")
+	InstrErrInvalidSeeds                   = errors.New("InstrErrInvalidSeeds")
+	InstrErrBorshIoError                   = errors.New("InstrErrBorshIoError")
+	InstrErrAccountNotRentExempt           = errors.New("InstrErrAccountNotRentExempt")
+	InstrErrUnsupportedSysvar              = errors.New("InstrErrUnsupportedSysvar")
+	InstrErrIllegalOwner                   = errors.New("InstrErrIllegalOwner")
+	InstrErrMaxAccountsDataAllocsExceeded  = errors.New("InstrErrMaxAccountsDataAllocationsExceeded")
+	InstrErrMaxInstructionTraceLenExceeded = errors.New("InstrErrMaxInstructionTraceLengthExceeded")
+	InstrErrBuiltinProgramsMustConsumeCUs  = errors.New("InstrErrBuiltinProgramsMustConsumeComputeUnits")
+	InstrErrInvalidError                   = errors.New("InstrErrInvalidError")
+	InstrErrMaxAccountsExceeded            = errors.New("InstrErrMaxAccountsExceeded")
+	InstrErrRentEpochModified              = errors.New("InstrErrRentEpochModified")
+	InstrErrGenericError                   = errors.New("InstrErrGenericError")
+	InstrErrDuplicateAccountIndex          = errors.New("InstrErrDuplicateAccountIndex")
+	InstrErrDuplicateAccountOutOfSync      = errors.New("InstrErrDuplicateAccountOutOfSync")
+	InstrErrProgramEnvironmentSetupFailure = errors.New("InstrErrProgramEnvironmentSetupFailure")
+	InstrErrProgramFailedToCompile         = errors.New("InstrErrProgramFailedToCompile")
+)
+
+// InstrErrCustom is a program-defined error, returned when a program exits
+// with a status that does not correspond to a builtin error.
+type InstrErrCustom struct {
+	Code uint32
+}
+
+func (e InstrErrCustom) Error() string {
+	return fmt.Sprintf("InstrErrCustom(%d)", e.Code)
+}
+
+// builtin program error statuses, as returned in r0 by an exiting program.
+// these are the ProgramError variants, shifted into the upper 32 bits.
+const (
+	programStatusCustomZero = uint64(1) << 32
+	programStatusShift      = 32
 )
 
+var builtinProgramStatusErrs = map[uint64]error{
+	2:  InstrErrInvalidArgument,
+	3:  InstrErrInvalidInstructionData,
+	4:  InstrErrInvalidAccountData,
+	5:  InstrErrAccountDataTooSmall,
+	6:  InstrErrInsufficientFunds,
+	7:  InstrErrIncorrectProgramId,
+	8:  InstrErrMissingRequiredSignature,
+	9:  InstrErrAccountAlreadyInitialized,
+	10: InstrErrUninitializedAccount,
+	11: InstrErrNotEnoughAccountKeys,
+	12: InstrErrAccountBorrowFailed,
+	13: InstrErrMaxSeedLengthExceeded,
+	14: InstrErrInvalidSeeds,
+	15: InstrErrBorshIoError,
+	16: InstrErrAccountNotRentExempt,
+	17: InstrErrUnsupportedSysvar,
+	18: InstrErrIllegalOwner,
+	19: InstrErrMaxAccountsDataAllocsExceeded,
+	20: InstrErrInvalidRealloc,
+	21: InstrErrMaxInstructionTraceLenExceeded,
+	22: InstrErrBuiltinProgramsMustConsumeCUs,
+	23: InstrErrInvalidAccountOwner,
+	24: InstrErrArithmeticOverflow,
+	25: InstrErrImmutable,
+	26: InstrErrIncorrectAuthority,
+}
+
+// instrErrFromProgramStatus converts the non-zero exit status of a program
+// into the corresponding instruction error.
+func instrErrFromProgramStatus(status uint64) error {
+	if status == programStatusCustomZero {
+		return InstrErrCustom{Code: 0}
+	}
+	if status>>programStatusShift == 0 {
+		return InstrErrCustom{Code: uint32(status)}
+	}
+	if status&0xffffffff == 0 {
+		if err, ok := builtinProgramStatusErrs[status>>programStatusShift]; ok {
+			return err
+		}
+	}
+	return InstrErrInvalidError
+}
+
 // syscall errors
 var (
 	SyscallErrCopyOverlapping                    = errors.New("SyscallErrCopyOverlapping")
@@ -59,6 +143,9 @@ var (
 	SyscallErrInstructionTooLarge                = errors.New("SyscallErrInstructionTooLarge")
 	SyscallErrMaxInstructionAccountInfosExceeded = errors.New("SyscallErrMaxInstructionAccountInfosExceeded")
 	SyscallErrTooManyAccounts                    = errors.New("SyscallErrTooManyAccounts")
+	SyscallErrInvalidAttribute                   = errors.New("SyscallErrInvalidAttribute")
+	SyscallErrInvalidPoseidonParameters          = errors.New("SyscallErrInvalidPoseidonParameters")
+	SyscallErrInvalidEndianness                  = errors.New("SyscallErrInvalidEndianness")
 )
 
 // precompile errors
@@ -66,6 +153,81 @@ var (
 	PrecompileErrInvalidInstructionDataSize = errors.New("ErrInvalidInstructionDataSize")
 )
 
+// transaction errors
+var (
+	TxErrDuplicateInstruction               = errors.New("TxErrDuplicateInstruction")
+	TxErrBlockhashNotFound                  = errors.New("TxErrBlockhashNotFound")
+	TxErrAccountNotFound                    = errors.New("TxErrAccountNotFound")
+	TxErrProgramAccountNotFound             = errors.New("TxErrProgramAccountNotFound")
+	TxErrInvalidAccountForFee               = errors.New("TxErrInvalidAccountForFee")
+	TxErrInsufficientFundsForFee            = errors.New("TxErrInsufficientFundsForFee")
+	TxErrInvalidProgramForExecution         = errors.New("TxErrInvalidProgramForExecution")
+	TxErrMaxLoadedAccountsDataSizeExceeded  = errors.New("TxErrMaxLoadedAccountsDataSizeExceeded")
+	TxErrAccountInUse                       = errors.New("TxErrAccountInUse")
+	TxErrAccountLoadedTwice                 = errors.New("TxErrAccountLoadedTwice")
+	TxErrTooManyAccountLocks                = errors.New("TxErrTooManyAccountLocks")
+	TxErrAddressLookupTableNotFound         = errors.New("TxErrAddressLookupTableNotFound")
+	TxErrInvalidAddressLookupTableOwner     = errors.New("TxErrInvalidAddressLookupTableOwner")
+	TxErrInvalidAddressLookupTableData      = errors.New("TxErrInvalidAddressLookupTableData")
+	TxErrInvalidAddressLookupTableIndex     = errors.New("TxErrInvalidAddressLookupTableIndex")
+	TxErrSanitizeFailure                    = errors.New("TxErrSanitizeFailure")
+	TxErrSignatureFailure                   = errors.New("TxErrSignatureFailure")
+	TxErrWouldExceedMaxBlockCostLimit       = errors.New("TxErrWouldExceedMaxBlockCostLimit")
+	TxErrWouldExceedMaxAccountCostLimit     = errors.New("TxErrWouldExceedMaxAccountCostLimit")
+	TxErrWouldExceedMaxVoteCostLimit        = errors.New("TxErrWouldExceedMaxVoteCostLimit")
+	TxErrWouldExceedAccountDataBlockLimit   = errors.New("TxErrWouldExceedAccountDataBlockLimit")
+	TxErrAlreadyProcessed                   = errors.New("TxErrAlreadyProcessed")
+	TxErrCallChainTooDeep                   = errors.New("TxErrCallChainTooDeep")
+	TxErrMissingSignatureForFee             = errors.New("TxErrMissingSignatureForFee")
+	TxErrInvalidAccountIndex                = errors.New("TxErrInvalidAccountIndex")
+	TxErrClusterMaintenance                 = errors.New("TxErrClusterMaintenance")
+	TxErrAccountBorrowOutstanding           = errors.New("TxErrAccountBorrowOutstanding")
+	TxErrUnsupportedVersion                 = errors.New("TxErrUnsupportedVersion")
+	TxErrInvalidWritableAccount             = errors.New("TxErrInvalidWritableAccount")
+	TxErrInvalidRentPayingAccount           = errors.New("TxErrInvalidRentPayingAccount")
+	TxErrWouldExceedAccountDataTotalLimit   = errors.New("TxErrWouldExceedAccountDataTotalLimit")
+	TxErrInvalidLoadedAccountsDataSizeLimit = errors.New("TxErrInvalidLoadedAccountsDataSizeLimit")
+	TxErrResanitizationNeeded               = errors.New("TxErrResanitizationNeeded")
+	TxErrUnbalancedTransaction              = errors.New("TxErrUnbalancedTransaction")
+	TxErrProgramCacheHitMaxLimit            = errors.New("TxErrProgramCacheHitMaxLimit")
+	TxErrCommitCancelled                    = errors.New("TxErrCommitCancelled")
+)
+
+// TxErrInsufficientFundsForRent is returned when a transaction would leave
+// the account at the given index with too few lamports to be rent exempt.
+type TxErrInsufficientFundsForRent struct {
+	AccountIndex uint8
+}
+
+func (e TxErrInsufficientFundsForRent) Error() string {
+	return fmt.Sprintf("TxErrInsufficientFundsForRent(%d)", e.AccountIndex)
+}
+
+// TxErrInstructionError is returned when the top-level instruction at the
+// given index of a transaction fails with Err.
+type TxErrInstructionError struct {
+	Index uint8
+	Err   error
+}
+
+func (e TxErrInstructionError) Error() string {
+	return fmt.Sprintf("TxErrInstructionError(%d, %s)", e.Index, e.Err)
+}
+
+func (e TxErrInstructionError) Unwrap() error {
+	return e.Err
+}
+
+// TxErrProgramExecutionTemporarilyRestricted is returned when the program
+// at the given account index may not be executed for the time being.
+type TxErrProgramExecutionTemporarilyRestricted struct {
+	AccountIndex uint8
+}
+
+func (e TxErrProgramExecutionTemporarilyRestricted) Error() string {
+	return fmt.Sprintf("TxErrProgramExecutionTemporarilyRestricted(%d)", e.AccountIndex)
+}
+
 // instruction errors - Solana numerical error codes
 const (
 	InstrErrCodeSuccess                     = 0
@@ -88,6 +250,7 @@ const (
 	PrecompileErrCodeInvalidInstructionDataSize = 101
 	PrecompileErrCodeInvalidSignature           = 102
 	PrecompileErrCodeInvalidRecoveryId          = 103 // TODO: not sure this is correct
+	PrecompileErrCodeInvalidPublicKey           = 104
 )
 
 // TODO: add additional error conversions
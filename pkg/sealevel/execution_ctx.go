@@ -15,8 +15,39 @@ type ExecutionCtx struct {
 	GlobalCtx            global.GlobalCtx
 	ComputeMeter         cu.ComputeMeter
 	SysvarCache          SysvarCache
+	EpochStakes          EpochStakes
 	Blockhash            [32]byte
 	LamportsPerSignature uint64
+	ModifiedPrograms     ProgramsModifiedByTx
+	ProgramCache         *ProgramCache
+
+	// HeapSize is the VM heap size of programs in this transaction, as
+	// requested with the compute budget program. Zero means CUHeapSizeDefault.
+	HeapSize uint64
+
+	// originalDataLens holds the data lengths of the instruction accounts
+	// of the currently executing program, as serialized into its input.
+	originalDataLens []uint64
+
+	// allocator is the heap allocator of the currently executing program.
+	allocator *heapAllocator
+}
+
+// heapSize returns the VM heap size of programs in this transaction.
+func (execCtx *ExecutionCtx) heapSize() uint64 {
+	if execCtx.HeapSize == 0 {
+		return CUHeapSizeDefault
+	}
+	return execCtx.HeapSize
+}
+
+// ApplyComputeBudgetLimits sets up the compute budget of the transaction
+// from the limits requested with the compute budget program. The compute
+// meter is shared by all instructions of the transaction, including
+// cross-program invocations.
+func (execCtx *ExecutionCtx) ApplyComputeBudgetLimits(limits ComputeBudgetLimits) {
+	execCtx.ComputeMeter = cu.NewComputeMeter(uint64(limits.ComputeUnitLimit))
+	execCtx.HeapSize = uint64(limits.UpdatedHeapBytes)
 }
 
 func (execCtx *ExecutionCtx) PrepareInstruction(ix Instruction, signers []solana.PublicKey) ([]InstructionAccount, []uint64, error) {
@@ -29,13 +60,13 @@ func (execCtx *ExecutionCtx) PrepareInstruction(ix Instruction, signers []solana
 	}
 
 	dedupInstructionAccounts := make([]InstructionAccount, 0)
-	duplicateIndices := make([]uint64, len(ix.Accounts))
+	duplicateIndices := make([]uint64, 0, len(ix.Accounts))
 
 	for instructionAcctIndex, accountMeta := range ix.Accounts {
 		indexInTx, err := txCtx.IndexOfAccount(accountMeta.Pubkey)
 		if err != nil {
-			klog.Error("instruction references unknown account %s", accountMeta.Pubkey)
-			return nil, nil, err
+			klog.Errorf("instruction references unknown account %s", accountMeta.Pubkey)
+			return nil, nil, InstrErrMissingAccount
 		}
 
 		duplicateIndex := -1
@@ -48,10 +79,7 @@ func (execCtx *ExecutionCtx) PrepareInstruction(ix Instruction, signers []solana
 
 		if duplicateIndex != -1 {
 			duplicateIndices = append(duplicateIndices, uint64(duplicateIndex))
-			if duplicateIndex > len(dedupInstructionAccounts)-1 {
-				return nil, nil, InstrErrNotEnoughAccountKeys
-			}
-			instructionAcct := dedupInstructionAccounts[duplicateIndex]
+			instructionAcct := &dedupInstructionAccounts[duplicateIndex]
 			instructionAcct.IsSigner = instructionAcct.IsSigner || accountMeta.IsSigner
 			instructionAcct.IsWritable = instructionAcct.IsWritable || accountMeta.IsWritable
 		} else {
@@ -76,9 +104,11 @@ func (execCtx *ExecutionCtx) PrepareInstruction(ix Instruction, signers []solana
 		if err != nil {
 			return nil, nil, err
 		}
+		isWritable, isSigner, key := borrowedAcct.IsWritable(), borrowedAcct.IsSigner(), borrowedAcct.Key()
+		borrowedAcct.Drop()
 
 		// "Read-only in caller cannot become writable in callee"
-		if instructionAcct.IsWritable && !borrowedAcct.IsWritable() {
+		if instructionAcct.IsWritable && !isWritable {
 			return nil, nil, InstrErrPrivilegeEscalation
 		}
 
@@ -86,23 +116,19 @@ func (execCtx *ExecutionCtx) PrepareInstruction(ix Instruction, signers []solana
 		// it must be either signed in the caller or by the program"
 		presentInSigners := false
 		for _, addr := range signers {
-			if addr == borrowedAcct.Key() {
+			if addr == key {
 				presentInSigners = true
 				break
 			}
 		}
-		if instructionAcct.IsSigner && !(borrowedAcct.IsSigner() || presentInSigners) {
+		if instructionAcct.IsSigner && !(isSigner || presentInSigners) {
 			return nil, nil, InstrErrPrivilegeEscalation
 		}
 	}
 
-	var instructionAccounts []InstructionAccount
+	instructionAccounts := make([]InstructionAccount, 0, len(duplicateIndices))
 	for _, duplicateIndex := range duplicateIndices {
-		if duplicateIndex > uint64(len(dedupInstructionAccounts)-1) {
-			return nil, nil, InstrErrNotEnoughAccountKeys
-		}
-		instrAcct := dedupInstructionAccounts[duplicateIndex]
-		instructionAccounts = append(instructionAccounts, instrAcct)
+		instructionAccounts = append(instructionAccounts, dedupInstructionAccounts[duplicateIndex])
 	}
 
 	// "Find and validate executables / program accounts"
@@ -117,8 +143,9 @@ func (execCtx *ExecutionCtx) PrepareInstruction(ix Instruction, signers []solana
 	if err != nil {
 		return nil, nil, err
 	}
+	defer borrowedProgramAcct.Drop()
 
-	if !borrowedProgramAcct.IsExecutable() {
+	if !borrowedProgramAcct.IsExecutable(execCtx.GlobalCtx.Features) {
 		klog.Errorf("account %s is not executable", calleeProgramId)
 		return nil, nil, InstrErrAccountNotExecutable
 	}
@@ -136,12 +163,25 @@ func (execCtx *ExecutionCtx) ProcessInstruction(instrData []byte, instructionAcc
 	nextInstrCtx.InstructionAccounts = instructionAccts
 	nextInstrCtx.Data = instrData
 
+	txCtx := execCtx.TransactionContext
+	programId, err := nextInstrCtx.LastProgramKey(txCtx)
+	if err != nil {
+		return InstrErrUnsupportedProgramId
+	}
+	preAccts, err := preAccountsForInstruction(txCtx, instructionAccts)
+	if err != nil {
+		return err
+	}
+
 	err = execCtx.Push()
 	if err != nil {
 		return err
 	}
 
 	err1 := execCtx.ExecuteInstruction()
+	if err1 == nil {
+		err1 = verifyInstructionAccounts(txCtx, programId, instructionAccts, preAccts)
+	}
 
 	err2 := execCtx.Pop()
 
@@ -174,8 +214,9 @@ func (execCtx *ExecutionCtx) ExecuteInstruction() error {
 	} else {
 		builtinId = ownerId
 	}
+	borrowedRootAccount.Drop()
 
-	nativeProgramFn, err := resolveNativeProgramById(builtinId)
+	nativeProgramFn, err := resolveNativeProgramById(builtinId, &execCtx.GlobalCtx.Features)
 	if err == IsPrecompile {
 		// TODO: handle precompile calls (ed25519, secp256k)
 		return InstrErrUnsupportedProgramId
@@ -183,6 +224,14 @@ func (execCtx *ExecutionCtx) ExecuteInstruction() error {
 		return err
 	}
 
+	// each instruction starts with empty return data, so a caller only
+	// observes return data set by the last program it invoked
+	programId, err := instrCtx.LastProgramKey(txCtx)
+	if err != nil {
+		return err
+	}
+	txCtx.SetReturnData(programId, nil)
+
 	err = nativeProgramFn(execCtx)
 
 	// TODO: other error handling
@@ -211,8 +260,9 @@ func (execCtx *ExecutionCtx) Push() error {
 			if err == nil {
 				programAcct, err := ic.BorrowLastProgramAccount(txCtx)
 				if err == nil {
-					if programAcct.Key() == programId {
-						contains = true
+					contains = programAcct.Key() == programId
+					programAcct.Drop()
+					if contains {
 						break
 					}
 				}
@@ -226,9 +276,8 @@ func (execCtx *ExecutionCtx) Push() error {
 		}
 		programAcct, err := ic.BorrowLastProgramAccount(txCtx)
 		if err == nil {
-			if programAcct.Key() == programId {
-				isLast = true
-			}
+			isLast = programAcct.Key() == programId
+			programAcct.Drop()
 		}
 
 		if contains && !isLast {
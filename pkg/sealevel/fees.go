@@ -0,0 +1,100 @@
+package sealevel
+
+import (
+	"github.com/ryanavella/wide"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/safemath"
+)
+
+// MicroLamportsPerLamport is the unit of the compute unit price set with
+// the compute budget program.
+const MicroLamportsPerLamport = 1_000_000
+
+// DefaultBurnPercent is the percentage of transaction fees burned, rather
+// than paid to the leader.
+const DefaultBurnPercent = 50
+
+// FeeDetails is the fee charged to a transaction: the transaction fee, paid
+// per signature, and the prioritization fee, paid per requested compute unit.
+//
+// Based on solana_sdk::fee::FeeDetails.
+type FeeDetails struct {
+	TransactionFee    uint64
+	PrioritizationFee uint64
+}
+
+// TotalFee returns the fee charged to the fee payer of the transaction.
+func (fd FeeDetails) TotalFee() uint64 {
+	return safemath.SaturatingAddU64(fd.TransactionFee, fd.PrioritizationFee)
+}
+
+// RewardAndBurn splits the fee of a transaction into the reward paid to the
+// leader, and the amount burned. Only the transaction fee is burned once
+// RewardFullPriorityFee is active; before, the total fee was.
+//
+// Based on solana_runtime::bank::fee_distribution::calculate_reward_and_burn_fee_details.
+func (fd FeeDetails) RewardAndBurn(burnPercent uint8, f *features.Features) (uint64, uint64) {
+	if !f.IsActive(features.RewardFullPriorityFee) {
+		total := fd.TotalFee()
+		burned := burnedFee(total, burnPercent)
+		return total - burned, burned
+	}
+	burned := burnedFee(fd.TransactionFee, burnPercent)
+	reward := safemath.SaturatingAddU64(fd.TransactionFee-burned, fd.PrioritizationFee)
+	return reward, burned
+}
+
+// burnedFee returns the portion of the given fee that is burned.
+func burnedFee(fee uint64, burnPercent uint8) uint64 {
+	burned := wide.Uint128FromUint64(fee).Mul(wide.Uint128FromUint64(uint64(burnPercent))).Div(wide.Uint128FromUint64(100))
+	return burned.Uint64()
+}
+
+// precompileSignatureCount returns the number of signatures verified by the
+// precompile instructions of a transaction, which are charged like
+// transaction signatures. The number of signatures is the first byte of the
+// instruction data of each precompile instruction.
+//
+// Based on solana_sdk::precompiles::get_precompile_signature_details.
+func precompileSignatureCount(instrs []Instruction, f *features.Features) uint64 {
+	var count uint64
+	for _, instr := range instrs {
+		if instr.ProgramId != Ed25519PrecompileAddr && instr.ProgramId != Secp256kPrecompileAddr &&
+			!(instr.ProgramId == Secp256r1PrecompileAddr && f.IsActive(features.EnableSecp256r1Precompile)) {
+			continue
+		}
+		if len(instr.Data) != 0 {
+			count += uint64(instr.Data[0])
+		}
+	}
+	return count
+}
+
+// prioritizationFee returns the fee for the requested compute units at the
+// requested compute unit price, in lamports, rounded up.
+//
+// Based on solana_fee::calculate_fee_details.
+func prioritizationFee(limits ComputeBudgetLimits) uint64 {
+	microLamports := wide.Uint128FromUint64(limits.ComputeUnitPrice).Mul(wide.Uint128FromUint64(uint64(limits.ComputeUnitLimit)))
+	lamports := microLamports.Add(wide.Uint128FromUint64(MicroLamportsPerLamport - 1)).Div(wide.Uint128FromUint64(MicroLamportsPerLamport))
+	if !lamports.IsUint64() {
+		return ^uint64(0)
+	}
+	return lamports.Uint64()
+}
+
+// CalculateFeeDetails returns the fee of a transaction with the given number
+// of signatures and instructions, and compute budget limits. A lamports per
+// signature of zero disables fees altogether.
+//
+// Based on solana_sdk::fee::FeeStructure::calculate_fee_details.
+func CalculateFeeDetails(numSignatures uint64, instrs []Instruction, lamportsPerSignature uint64, limits ComputeBudgetLimits, f *features.Features) FeeDetails {
+	if lamportsPerSignature == 0 {
+		return FeeDetails{}
+	}
+	signatures := safemath.SaturatingAddU64(numSignatures, precompileSignatureCount(instrs, f))
+	return FeeDetails{
+		TransactionFee:    safemath.SaturatingMulU64(signatures, lamportsPerSignature),
+		PrioritizationFee: prioritizationFee(limits),
+	}
+}
@@ -0,0 +1,50 @@
+package sealevel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func TestCalculateFeeDetails(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	limits := ComputeBudgetLimits{ComputeUnitLimit: 200_000}
+
+	// one signature, and two verified by the ed25519 precompile
+	instrs := []Instruction{{ProgramId: Ed25519PrecompileAddr, Data: []byte{2, 0}}, {ProgramId: SystemProgramAddr, Data: []byte{9}}}
+	assert.Equal(t, FeeDetails{TransactionFee: 15000}, CalculateFeeDetails(1, instrs, 5000, limits, f))
+
+	// secp256r1 signatures are only charged once the precompile is enabled
+	instrs = []Instruction{{ProgramId: Secp256r1PrecompileAddr, Data: []byte{1}}}
+	assert.Equal(t, FeeDetails{TransactionFee: 5000}, CalculateFeeDetails(1, instrs, 5000, limits, f))
+	f.EnableFeature(features.EnableSecp256r1Precompile, 0)
+	assert.Equal(t, FeeDetails{TransactionFee: 10000}, CalculateFeeDetails(1, instrs, 5000, limits, f))
+
+	// the prioritization fee is rounded up to the next lamport
+	limits.ComputeUnitPrice = 1
+	assert.Equal(t, FeeDetails{TransactionFee: 5000, PrioritizationFee: 1}, CalculateFeeDetails(1, nil, 5000, limits, f))
+	limits.ComputeUnitPrice = 5_000_000
+	assert.Equal(t, FeeDetails{TransactionFee: 5000, PrioritizationFee: 1_000_000}, CalculateFeeDetails(1, nil, 5000, limits, f))
+	limits = ComputeBudgetLimits{ComputeUnitLimit: math.MaxUint32, ComputeUnitPrice: math.MaxUint64}
+	assert.Equal(t, uint64(math.MaxUint64), CalculateFeeDetails(1, nil, 5000, limits, f).PrioritizationFee)
+	assert.Equal(t, uint64(math.MaxUint64), CalculateFeeDetails(1, nil, 5000, limits, f).TotalFee())
+
+	// no fees at all without a lamports per signature
+	assert.Equal(t, FeeDetails{}, CalculateFeeDetails(1, nil, 0, limits, f))
+}
+
+func TestFeeDetailsRewardAndBurn(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	fd := FeeDetails{TransactionFee: 10001, PrioritizationFee: 3000}
+
+	reward, burned := fd.RewardAndBurn(DefaultBurnPercent, f)
+	assert.Equal(t, uint64(6501), reward)
+	assert.Equal(t, uint64(6500), burned)
+
+	f.EnableFeature(features.RewardFullPriorityFee, 0)
+	reward, burned = fd.RewardAndBurn(DefaultBurnPercent, f)
+	assert.Equal(t, uint64(8001), reward)
+	assert.Equal(t, uint64(5000), burned)
+}
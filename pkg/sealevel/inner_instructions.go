@@ -0,0 +1,76 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+)
+
+// TransactionLevelStackHeight is the stack height of the top-level
+// instructions of a transaction.
+const TransactionLevelStackHeight = 1
+
+// InnerInstruction is an instruction invoked by a program, with the
+// indexes of its program and accounts in the transaction, and the stack
+// height it executed at.
+type InnerInstruction struct {
+	Instruction solana.CompiledInstruction
+	StackHeight uint32
+}
+
+// InnerInstructions are the instructions invoked, directly or indirectly,
+// by the top-level instruction at Index, in invocation order. They are
+// recorded in the transaction status meta of the transaction.
+//
+// Based on solana_sdk::inner_instruction::InnerInstructions.
+type InnerInstructions struct {
+	Index        uint8
+	Instructions []InnerInstruction
+}
+
+// InnerInstructionsFromTrace returns the inner instructions of an executed
+// transaction from its instruction trace. Top-level instructions that
+// invoked no other instruction have no entry.
+//
+// Based on solana_svm::transaction_processor::inner_instructions_list_from_instruction_trace.
+func InnerInstructionsFromTrace(txCtx *TransactionCtx) []InnerInstructions {
+	var outer [][]InnerInstruction
+
+	for idxInTrace := uint64(0); idxInTrace < txCtx.InstructionTraceLength(); idxInTrace++ {
+		instrCtx, err := txCtx.InstructionCtxAtIndexInTrace(idxInTrace)
+		if err != nil {
+			break
+		}
+
+		stackHeight := instrCtx.NestingLevel + 1
+		if stackHeight == TransactionLevelStackHeight {
+			outer = append(outer, nil)
+			continue
+		}
+		if len(outer) == 0 {
+			continue
+		}
+
+		programIdx, err := instrCtx.IndexOfProgramAccountInTransaction(instrCtx.NumberOfProgramAccounts() - 1)
+		if err != nil {
+			continue
+		}
+		compiled := solana.CompiledInstruction{
+			ProgramIDIndex: uint16(uint8(programIdx)),
+			Accounts:       make([]uint16, len(instrCtx.InstructionAccounts)),
+			Data:           append([]byte{}, instrCtx.Data...),
+		}
+		for i, instrAcct := range instrCtx.InstructionAccounts {
+			compiled.Accounts[i] = uint16(uint8(instrAcct.IndexInTransaction))
+		}
+
+		last := len(outer) - 1
+		outer[last] = append(outer[last], InnerInstruction{Instruction: compiled, StackHeight: uint32(stackHeight)})
+	}
+
+	var innerInstructions []InnerInstructions
+	for idx, instrs := range outer {
+		if len(instrs) != 0 {
+			innerInstructions = append(innerInstructions, InnerInstructions{Index: uint8(idx), Instructions: instrs})
+		}
+	}
+	return innerInstructions
+}
@@ -0,0 +1,40 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInnerInstructionsFromTrace(t *testing.T) {
+	instr := func(nestingLevel uint64, programIdx uint64, data byte, acctIdxs ...uint64) InstructionCtx {
+		instrCtx := InstructionCtx{ProgramAccounts: []uint64{programIdx}, Data: []byte{data}, NestingLevel: nestingLevel}
+		for _, idx := range acctIdxs {
+			instrCtx.InstructionAccounts = append(instrCtx.InstructionAccounts, InstructionAccount{IndexInTransaction: idx})
+		}
+		return instrCtx
+	}
+
+	txCtx := &TransactionCtx{InstructionTrace: []InstructionCtx{
+		instr(0, 5, 0, 1),
+		instr(1, 6, 1, 1, 2),
+		instr(2, 7, 2),
+		instr(0, 5, 3),
+		instr(0, 6, 4),
+		instr(1, 7, 5, 3),
+		{}, // the slot for the next instruction
+	}}
+
+	assert.Equal(t, []InnerInstructions{
+		{Index: 0, Instructions: []InnerInstruction{
+			{Instruction: solana.CompiledInstruction{ProgramIDIndex: 6, Accounts: []uint16{1, 2}, Data: []byte{1}}, StackHeight: 2},
+			{Instruction: solana.CompiledInstruction{ProgramIDIndex: 7, Accounts: []uint16{}, Data: []byte{2}}, StackHeight: 3},
+		}},
+		{Index: 2, Instructions: []InnerInstruction{
+			{Instruction: solana.CompiledInstruction{ProgramIDIndex: 7, Accounts: []uint16{3}, Data: []byte{5}}, StackHeight: 2},
+		}},
+	}, InnerInstructionsFromTrace(txCtx))
+
+	assert.Nil(t, InnerInstructionsFromTrace(&TransactionCtx{}))
+}
@@ -7,7 +7,6 @@ import (
 )
 
 type InstructionCtx struct {
-	programId                     solana.PublicKey
 	ProgramAccounts               []uint64
 	InstructionAccounts           []InstructionAccount
 	Data                          []byte
@@ -15,10 +14,6 @@ type InstructionCtx struct {
 	NestingLevel                  uint64
 }
 
-func (instrCtx *InstructionCtx) ProgramId() solana.PublicKey {
-	return instrCtx.programId
-}
-
 func (instrCtx *InstructionCtx) IndexOfProgramAccountInTransaction(programAccountIndex uint64) (uint64, error) {
 	if len(instrCtx.ProgramAccounts) == 0 || programAccountIndex > uint64(len(instrCtx.ProgramAccounts)-1) {
 		return 0, SyscallErrNotEnoughAccountKeys
@@ -71,6 +66,10 @@ func (instrCtx *InstructionCtx) BorrowAccount(txCtx *TransactionCtx, idxInTx uin
 	if err != nil {
 		return nil, err
 	}
+	err = txCtx.Accounts.tryBorrow(idxInTx)
+	if err != nil {
+		return nil, err
+	}
 	borrowedAcct := BorrowedAccount{Account: account, TxCtx: txCtx, InstrCtx: instrCtx, IndexInTransaction: idxInTx, IndexInInstruction: idxInInstr}
 	return &borrowedAcct, nil
 }
@@ -98,7 +97,7 @@ func (instrCtx *InstructionCtx) BorrowLastProgramAccount(txCtx *TransactionCtx)
 }
 
 func (instrCtx *InstructionCtx) IsInstructionAccountSigner(instrAcctIdx uint64) (bool, error) {
-	if len(instrCtx.InstructionAccounts) == 0 || instrAcctIdx > uint64(len(instrCtx.InstructionAccounts)) {
+	if len(instrCtx.InstructionAccounts) == 0 || instrAcctIdx > uint64(len(instrCtx.InstructionAccounts)-1) {
 		return false, InstrErrMissingAccount
 	}
 
@@ -106,7 +105,7 @@ func (instrCtx *InstructionCtx) IsInstructionAccountSigner(instrAcctIdx uint64)
 }
 
 func (instrCtx *InstructionCtx) IsInstructionAccountWritable(instrAcctIdx uint64) (bool, error) {
-	if len(instrCtx.InstructionAccounts) == 0 || instrAcctIdx > uint64(len(instrCtx.InstructionAccounts)) {
+	if len(instrCtx.InstructionAccounts) == 0 || instrAcctIdx > uint64(len(instrCtx.InstructionAccounts)-1) {
 		return false, InstrErrMissingAccount
 	}
 
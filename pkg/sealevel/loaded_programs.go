@@ -0,0 +1,301 @@
+package sealevel
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+// DelayVisibilitySlotOffset is the number of slots after deployment before
+// a newly deployed or upgraded program becomes invocable.
+const DelayVisibilitySlotOffset = 1
+
+// DefaultProgramCacheCapacity is the default number of loaded executables
+// kept in a ProgramCache.
+const DefaultProgramCacheCapacity = 256
+
+type LoadedProgramType int
+
+const (
+	// LoadedProgramTypeLoaded is a verified executable, ready for execution.
+	LoadedProgramTypeLoaded LoadedProgramType = iota
+	// LoadedProgramTypeFailedVerification is a tombstone for a program whose
+	// ELF failed to load or verify.
+	LoadedProgramTypeFailedVerification
+	// LoadedProgramTypeClosed is a tombstone for a program that was closed.
+	LoadedProgramTypeClosed
+	// LoadedProgramTypeDelayVisibility is a tombstone for a program that was
+	// deployed too recently to be invoked.
+	LoadedProgramTypeDelayVisibility
+)
+
+// LoadedProgram is a cache entry for a program, either a verified executable
+// or a tombstone recording why the program cannot be executed.
+type LoadedProgram struct {
+	Type           LoadedProgramType
+	Program        *sbpf.Program
+	Loader         solana.PublicKey
+	AccountSize    uint64
+	DeploymentSlot uint64
+	EffectiveSlot  uint64
+
+	lastUsed uint64
+}
+
+func newLoadedProgram(program *sbpf.Program, loader solana.PublicKey, accountSize uint64, deploymentSlot uint64) *LoadedProgram {
+	return &LoadedProgram{
+		Type:           LoadedProgramTypeLoaded,
+		Program:        program,
+		Loader:         loader,
+		AccountSize:    accountSize,
+		DeploymentSlot: deploymentSlot,
+		EffectiveSlot:  deploymentSlot + DelayVisibilitySlotOffset,
+	}
+}
+
+func newTombstone(slot uint64, typ LoadedProgramType) *LoadedProgram {
+	return &LoadedProgram{
+		Type:           typ,
+		DeploymentSlot: slot,
+		EffectiveSlot:  slot,
+	}
+}
+
+// IsTombstone returns whether the entry does not hold an executable.
+func (lp *LoadedProgram) IsTombstone() bool {
+	return lp.Type != LoadedProgramTypeLoaded
+}
+
+// IsVisibleAt returns whether the program may be invoked in the given slot.
+func (lp *LoadedProgram) IsVisibleAt(slot uint64) bool {
+	return slot >= lp.EffectiveSlot
+}
+
+// ProgramsModifiedByTx holds the programs deployed, upgraded, extended or
+// closed by the currently executing transaction.
+type ProgramsModifiedByTx map[solana.PublicKey]*LoadedProgram
+
+// Replenish inserts or replaces the entry for the given program.
+func (p ProgramsModifiedByTx) Replenish(programId solana.PublicKey, program *LoadedProgram) {
+	p[programId] = program
+}
+
+// Find returns the entry for the given program, if any.
+func (p ProgramsModifiedByTx) Find(programId solana.PublicKey) (*LoadedProgram, bool) {
+	program, ok := p[programId]
+	return program, ok
+}
+
+// ProgramCache holds loaded programs across transactions and slots, so that
+// program ELFs do not need to be parsed and verified on every invocation.
+//
+// Entries are keyed by program id and deployment slot. Superseded entries
+// are dropped as slots are rooted, and loaded executables beyond the cache
+// capacity are evicted in least recently used order.
+type ProgramCache struct {
+	mu       sync.Mutex
+	entries  map[solana.PublicKey][]*LoadedProgram // ordered by deployment slot
+	capacity int
+	useCount uint64
+	stats    ProgramCacheStats
+}
+
+// ProgramCacheStats are counters of the lookups and changes of a
+// ProgramCache since it was created.
+type ProgramCacheStats struct {
+	Hits       uint64
+	Misses     uint64
+	Insertions uint64
+	Evictions  uint64
+	Prunes     uint64
+}
+
+func NewProgramCache(capacity int) *ProgramCache {
+	return &ProgramCache{
+		entries:  make(map[solana.PublicKey][]*LoadedProgram),
+		capacity: capacity,
+	}
+}
+
+// Find returns the entry for the given program deployed at deploymentSlot.
+func (pc *ProgramCache) Find(programId solana.PublicKey, deploymentSlot uint64) (*LoadedProgram, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	for _, entry := range pc.entries[programId] {
+		if entry.DeploymentSlot == deploymentSlot {
+			pc.useCount++
+			entry.lastUsed = pc.useCount
+			pc.stats.Hits++
+			return entry, true
+		}
+	}
+	pc.stats.Misses++
+	return nil, false
+}
+
+// FindLatest returns the most recently deployed entry for the given program
+// that was deployed at or before the given slot. If that entry is not yet
+// visible in the slot, a DelayVisibility tombstone is returned instead.
+func (pc *ProgramCache) FindLatest(programId solana.PublicKey, slot uint64) (*LoadedProgram, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	entries := pc.entries[programId]
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].DeploymentSlot <= slot {
+			if !entries[i].IsVisibleAt(slot) {
+				return newTombstone(slot, LoadedProgramTypeDelayVisibility), true
+			}
+			pc.useCount++
+			entries[i].lastUsed = pc.useCount
+			pc.stats.Hits++
+			return entries[i], true
+		}
+	}
+	pc.stats.Misses++
+	return nil, false
+}
+
+// Replenish inserts the entry for the given program, replacing any entry
+// with the same deployment slot, and evicts entries beyond capacity.
+func (pc *ProgramCache) Replenish(programId solana.PublicKey, program *LoadedProgram) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.useCount++
+	program.lastUsed = pc.useCount
+	pc.stats.Insertions++
+
+	entries := pc.entries[programId]
+	idx := sort.Search(len(entries), func(i int) bool {
+		return entries[i].DeploymentSlot >= program.DeploymentSlot
+	})
+	if idx < len(entries) && entries[idx].DeploymentSlot == program.DeploymentSlot {
+		entries[idx] = program
+	} else {
+		entries = append(entries, nil)
+		copy(entries[idx+1:], entries[idx:])
+		entries[idx] = program
+	}
+	pc.entries[programId] = entries
+
+	pc.evict()
+}
+
+// Merge inserts the programs modified by a successfully executed transaction.
+func (pc *ProgramCache) Merge(modified ProgramsModifiedByTx) {
+	for programId, program := range modified {
+		pc.Replenish(programId, program)
+	}
+}
+
+// Prune drops entries that can no longer be observed once newRootSlot is
+// rooted: for each program, only the most recent entry deployed at or
+// before the root, and entries deployed after the root, are kept.
+func (pc *ProgramCache) Prune(newRootSlot uint64) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	for programId, entries := range pc.entries {
+		firstKept := 0
+		for i, entry := range entries {
+			if entry.DeploymentSlot <= newRootSlot {
+				firstKept = i
+			}
+		}
+		pc.stats.Prunes += uint64(firstKept)
+		entries = entries[firstKept:]
+
+		if len(entries) == 1 && entries[0].Type == LoadedProgramTypeClosed && entries[0].DeploymentSlot <= newRootSlot {
+			pc.stats.Prunes++
+			delete(pc.entries, programId)
+			continue
+		}
+		pc.entries[programId] = entries
+	}
+}
+
+// Stats returns the counters of the cache.
+func (pc *ProgramCache) Stats() ProgramCacheStats {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.stats
+}
+
+// Len returns the number of loaded executables in the cache.
+func (pc *ProgramCache) Len() int {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.numLoaded()
+}
+
+func (pc *ProgramCache) numLoaded() int {
+	var n int
+	for _, entries := range pc.entries {
+		for _, entry := range entries {
+			if !entry.IsTombstone() {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// evict removes the least recently used executables until the cache is
+// within capacity. Tombstones hold no executable and are not evicted.
+func (pc *ProgramCache) evict() {
+	excess := pc.numLoaded() - pc.capacity
+	if excess <= 0 {
+		return
+	}
+
+	type candidate struct {
+		programId solana.PublicKey
+		entry     *LoadedProgram
+	}
+	var candidates []candidate
+	for programId, entries := range pc.entries {
+		for _, entry := range entries {
+			if !entry.IsTombstone() {
+				candidates = append(candidates, candidate{programId, entry})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].entry.lastUsed < candidates[j].entry.lastUsed
+	})
+
+	pc.stats.Evictions += uint64(excess)
+	for _, c := range candidates[:excess] {
+		entries := pc.entries[c.programId]
+		for i, entry := range entries {
+			if entry == c.entry {
+				entries = append(entries[:i], entries[i+1:]...)
+				break
+			}
+		}
+		if len(entries) == 0 {
+			delete(pc.entries, c.programId)
+		} else {
+			pc.entries[c.programId] = entries
+		}
+	}
+}
+
+// find is Find for an optional cache: a nil cache never has any entries.
+func (pc *ProgramCache) find(programId solana.PublicKey, deploymentSlot uint64) (*LoadedProgram, bool) {
+	if pc == nil {
+		return nil, false
+	}
+	return pc.Find(programId, deploymentSlot)
+}
+
+// replenish is Replenish for an optional cache.
+func (pc *ProgramCache) replenish(programId solana.PublicKey, program *LoadedProgram) {
+	if pc != nil {
+		pc.Replenish(programId, program)
+	}
+}
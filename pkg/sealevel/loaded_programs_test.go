@@ -0,0 +1,96 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestProgramCache_ReplenishFind(t *testing.T) {
+	pc := NewProgramCache(DefaultProgramCacheCapacity)
+	programId := solana.PublicKey{1}
+
+	pc.Replenish(programId, newLoadedProgram(&sbpf.Program{}, BpfLoaderUpgradeableAddr, 0, 20))
+	pc.Replenish(programId, newLoadedProgram(&sbpf.Program{}, BpfLoaderUpgradeableAddr, 0, 10))
+	pc.Replenish(programId, newTombstone(30, LoadedProgramTypeClosed))
+
+	entry, ok := pc.Find(programId, 10)
+	require.True(t, ok)
+	assert.Equal(t, uint64(11), entry.EffectiveSlot)
+
+	_, ok = pc.Find(programId, 15)
+	assert.False(t, ok)
+
+	entry, ok = pc.FindLatest(programId, 25)
+	require.True(t, ok)
+	assert.Equal(t, uint64(20), entry.DeploymentSlot)
+
+	entry, ok = pc.FindLatest(programId, 30)
+	require.True(t, ok)
+	assert.True(t, entry.IsTombstone())
+
+	_, ok = pc.FindLatest(programId, 5)
+	assert.False(t, ok)
+
+	entry, ok = pc.FindLatest(programId, 20)
+	require.True(t, ok)
+	assert.Equal(t, LoadedProgramTypeDelayVisibility, entry.Type)
+
+	// replacing an entry with the same deployment slot
+	pc.Replenish(programId, newTombstone(20, LoadedProgramTypeFailedVerification))
+	entry, ok = pc.Find(programId, 20)
+	require.True(t, ok)
+	assert.Equal(t, LoadedProgramTypeFailedVerification, entry.Type)
+	assert.Equal(t, 1, pc.Len())
+}
+
+func TestProgramCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	pc := NewProgramCache(2)
+
+	pc.Replenish(solana.PublicKey{1}, newLoadedProgram(&sbpf.Program{}, BpfLoaderAddr, 0, 0))
+	pc.Replenish(solana.PublicKey{2}, newLoadedProgram(&sbpf.Program{}, BpfLoaderAddr, 0, 0))
+
+	_, ok := pc.Find(solana.PublicKey{1}, 0)
+	require.True(t, ok)
+
+	pc.Replenish(solana.PublicKey{3}, newLoadedProgram(&sbpf.Program{}, BpfLoaderAddr, 0, 0))
+	assert.Equal(t, 2, pc.Len())
+
+	_, ok = pc.Find(solana.PublicKey{2}, 0)
+	assert.False(t, ok)
+	_, ok = pc.Find(solana.PublicKey{1}, 0)
+	assert.True(t, ok)
+	_, ok = pc.Find(solana.PublicKey{3}, 0)
+	assert.True(t, ok)
+
+	assert.Equal(t, ProgramCacheStats{Hits: 3, Misses: 1, Insertions: 3, Evictions: 1}, pc.Stats())
+}
+
+func TestProgramCache_Prune(t *testing.T) {
+	pc := NewProgramCache(DefaultProgramCacheCapacity)
+	upgraded := solana.PublicKey{1}
+	closed := solana.PublicKey{2}
+
+	pc.Replenish(upgraded, newLoadedProgram(&sbpf.Program{}, BpfLoaderUpgradeableAddr, 0, 10))
+	pc.Replenish(upgraded, newLoadedProgram(&sbpf.Program{}, BpfLoaderUpgradeableAddr, 0, 20))
+	pc.Replenish(upgraded, newLoadedProgram(&sbpf.Program{}, BpfLoaderUpgradeableAddr, 0, 30))
+	pc.Replenish(closed, newLoadedProgram(&sbpf.Program{}, BpfLoaderUpgradeableAddr, 0, 10))
+	pc.Merge(ProgramsModifiedByTx{closed: newTombstone(20, LoadedProgramTypeClosed)})
+
+	pc.Prune(25)
+
+	_, ok := pc.Find(upgraded, 10)
+	assert.False(t, ok)
+	_, ok = pc.Find(upgraded, 20)
+	assert.True(t, ok)
+	_, ok = pc.Find(upgraded, 30)
+	assert.True(t, ok)
+
+	_, ok = pc.FindLatest(closed, 25)
+	assert.False(t, ok)
+
+	assert.Equal(t, uint64(3), pc.Stats().Prunes)
+}
@@ -11,3 +11,44 @@ type LogRecorder struct {
 func (r *LogRecorder) Log(s string) {
 	r.Logs = append(r.Logs, s)
 }
+
+// LogCollectorDefaultBytesLimit is the max total size of the log messages
+// of a transaction.
+const LogCollectorDefaultBytesLimit = 10 * 1000
+
+// LogCollector collects the log messages of a transaction, as returned by
+// RPC getTransaction. Once the bytes limit would be reached, further
+// messages are dropped, and a single "Log truncated" message is recorded.
+//
+// Based on solana_program_runtime::log_collector::LogCollector.
+type LogCollector struct {
+	Messages []string
+
+	bytesWritten uint64
+	bytesLimit   uint64
+	limitWarning bool
+}
+
+// NewLogCollector creates a log collector with the given bytes limit.
+// A limit of zero means unlimited.
+func NewLogCollector(bytesLimit uint64) *LogCollector {
+	return &LogCollector{bytesLimit: bytesLimit}
+}
+
+func (c *LogCollector) Log(s string) {
+	if c.bytesLimit == 0 {
+		c.Messages = append(c.Messages, s)
+		return
+	}
+
+	bytesWritten := c.bytesWritten + uint64(len(s))
+	if bytesWritten >= c.bytesLimit {
+		if !c.limitWarning {
+			c.limitWarning = true
+			c.Messages = append(c.Messages, "Log truncated")
+		}
+	} else {
+		c.bytesWritten = bytesWritten
+		c.Messages = append(c.Messages, s)
+	}
+}
@@ -0,0 +1,27 @@
+package sealevel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogCollector_Truncate(t *testing.T) {
+	c := NewLogCollector(10)
+	c.Log("12345")
+	c.Log("1234")
+	c.Log("1")
+	c.Log("1234")
+	// messages that still fit are recorded after truncation
+	c.Log("")
+	assert.Equal(t, []string{"12345", "1234", "Log truncated", ""}, c.Messages)
+}
+
+func TestLogCollector_Unlimited(t *testing.T) {
+	c := NewLogCollector(0)
+	msg := strings.Repeat("x", LogCollectorDefaultBytesLimit)
+	c.Log(msg)
+	c.Log(msg)
+	assert.Equal(t, []string{msg, msg}, c.Messages)
+}
@@ -0,0 +1,130 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+// ProcessMessage executes the top-level instructions of a transaction in
+// order, given the transaction indices of the program accounts of each
+// instruction, as loaded by LoadTransactionAccounts. Precompile
+// instructions are verified rather than executed. The first instruction to
+// fail fails the transaction, with a TxErrInstructionError.
+//
+// Based on solana_svm::message_processor::MessageProcessor::process_message.
+func (execCtx *ExecutionCtx) ProcessMessage(instrs []Instruction, programIndices [][]uint64) error {
+	txCtx := execCtx.TransactionContext
+
+	instrDatas := make([][]byte, len(instrs))
+	for idx := range instrs {
+		instrDatas[idx] = instrs[idx].Data
+	}
+
+	for idx := range instrs {
+		instr := &instrs[idx]
+		instrAccts, err := topLevelInstructionAccounts(txCtx, instr)
+		if err == nil {
+			err = StoreInstructionsSysvarCurrentIndex(txCtx, uint16(idx))
+		}
+		if err == nil {
+			if isPrecompile(instr.ProgramId, execCtx.GlobalCtx.Features) {
+				err = execCtx.processPrecompile(instr, instrAccts, programIndices[idx], instrDatas)
+			} else {
+				err = execCtx.ProcessInstruction(instr.Data, instrAccts, programIndices[idx])
+			}
+		}
+		if err != nil {
+			return TxErrInstructionError{Index: uint8(idx), Err: err}
+		}
+	}
+	return nil
+}
+
+// topLevelInstructionAccounts returns the instruction accounts of a
+// top-level instruction. An account referenced more than once is a
+// duplicate of its first occurrence.
+func topLevelInstructionAccounts(txCtx *TransactionCtx, instr *Instruction) ([]InstructionAccount, error) {
+	instrAccts := make([]InstructionAccount, len(instr.Accounts))
+	for instrAcctIdx, acctMeta := range instr.Accounts {
+		indexInTx, err := txCtx.IndexOfAccount(acctMeta.Pubkey)
+		if err != nil {
+			return nil, err
+		}
+
+		indexInCallee := uint64(instrAcctIdx)
+		for prevIdx := 0; prevIdx < instrAcctIdx; prevIdx++ {
+			if instrAccts[prevIdx].IndexInTransaction == indexInTx {
+				indexInCallee = uint64(prevIdx)
+				break
+			}
+		}
+
+		instrAccts[instrAcctIdx] = InstructionAccount{
+			IndexInTransaction: indexInTx,
+			IndexInCaller:      indexInTx,
+			IndexInCallee:      indexInCallee,
+			IsSigner:           acctMeta.IsSigner,
+			IsWritable:         acctMeta.IsWritable,
+		}
+	}
+	return instrAccts, nil
+}
+
+// processPrecompile verifies a precompile instruction against the data of
+// all instructions of the transaction. Like any instruction, it is recorded
+// in the instruction trace.
+//
+// Based on solana_program_runtime::invoke_context::InvokeContext::process_precompile.
+func (execCtx *ExecutionCtx) processPrecompile(instr *Instruction, instrAccts []InstructionAccount, programIndices []uint64, instrDatas [][]byte) error {
+	nextInstrCtx, err := execCtx.TransactionContext.NextInstructionCtx()
+	if err != nil {
+		return err
+	}
+	nextInstrCtx.ProgramAccounts = programIndices
+	nextInstrCtx.InstructionAccounts = instrAccts
+	nextInstrCtx.Data = instr.Data
+
+	err = execCtx.Push()
+	if err != nil {
+		return err
+	}
+	err1 := verifyPrecompile(instr.ProgramId, instr.Data, instrDatas, execCtx.GlobalCtx.Features)
+	err2 := execCtx.Pop()
+
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// verifyPrecompile runs the precompile with the given program id, and
+// returns its error as the custom instruction error of the corresponding
+// solana_precompile_error::PrecompileError.
+func verifyPrecompile(programId solana.PublicKey, data []byte, instrDatas [][]byte, f features.Features) error {
+	var code int
+	switch programId {
+	case Ed25519PrecompileAddr:
+		code = Ed25519ProgramExecute(data, instrDatas)
+	case Secp256kPrecompileAddr:
+		code = Secp256k1ProgramExecute(data, instrDatas, f)
+	case Secp256r1PrecompileAddr:
+		code = Secp256r1ProgramExecute(data, instrDatas)
+	default:
+		return InstrErrUnsupportedProgramId
+	}
+
+	switch code {
+	case InstrErrCodeSuccess:
+		return nil
+	case PrecompileErrCodeInvalidPublicKey:
+		return InstrErrCustom{Code: 0}
+	case PrecompileErrCodeInvalidRecoveryId:
+		return InstrErrCustom{Code: 1}
+	case PrecompileErrCodeInvalidSignature:
+		return InstrErrCustom{Code: 2}
+	case PrecompileErrCodeInvalidDataOffsets:
+		return InstrErrCustom{Code: 3}
+	default:
+		return InstrErrCustom{Code: 4}
+	}
+}
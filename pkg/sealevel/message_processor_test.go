@@ -0,0 +1,55 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/global"
+)
+
+func TestTopLevelInstructionAccounts(t *testing.T) {
+	txCtx := &TransactionCtx{AccountKeys: []solana.PublicKey{{1}, {2}, {3}}}
+	instr := &Instruction{Accounts: []AccountMeta{
+		{Pubkey: solana.PublicKey{2}, IsSigner: true},
+		{Pubkey: solana.PublicKey{3}, IsWritable: true},
+		{Pubkey: solana.PublicKey{2}, IsSigner: true},
+	}}
+
+	instrAccts, err := topLevelInstructionAccounts(txCtx, instr)
+	require.NoError(t, err)
+	assert.Equal(t, []InstructionAccount{
+		{IndexInTransaction: 1, IndexInCaller: 1, IndexInCallee: 0, IsSigner: true},
+		{IndexInTransaction: 2, IndexInCaller: 2, IndexInCallee: 1, IsWritable: true},
+		{IndexInTransaction: 1, IndexInCaller: 1, IndexInCallee: 0, IsSigner: true},
+	}, instrAccts)
+
+	instr.Accounts = append(instr.Accounts, AccountMeta{Pubkey: solana.PublicKey{4}})
+	_, err = topLevelInstructionAccounts(txCtx, instr)
+	assert.Equal(t, InstrErrMissingAccount, err)
+}
+
+func TestProcessMessagePrecompile(t *testing.T) {
+	programAcct := &accounts.Account{Lamports: 1, Owner: NativeLoaderAddr, Executable: true, Data: []byte("ed25519_program")}
+	txCtx := &TransactionCtx{
+		InstructionTrace:         []InstructionCtx{{}},
+		AccountKeys:              []solana.PublicKey{Ed25519PrecompileAddr},
+		Accounts:                 TransactionAccounts{Accounts: []*accounts.Account{programAcct}, Touched: make([]bool, 1)},
+		InstructionTraceCapacity: CUMaxInstructionTraceLength,
+	}
+	execCtx := &ExecutionCtx{TransactionContext: txCtx, GlobalCtx: global.GlobalCtx{Features: *features.NewFeaturesDefault()}}
+
+	// an instruction without signatures verifies
+	instrs := []Instruction{{ProgramId: Ed25519PrecompileAddr, Data: []byte{0, 0}}}
+	require.NoError(t, execCtx.ProcessMessage(instrs, [][]uint64{{0}}))
+	assert.Len(t, InnerInstructionsFromTrace(txCtx), 0)
+	assert.Equal(t, uint64(1), txCtx.InstructionTraceLength())
+
+	// the failing instruction is reported by its index
+	instrs = append(instrs, Instruction{ProgramId: Ed25519PrecompileAddr, Data: []byte{1}})
+	err := execCtx.ProcessMessage(instrs, [][]uint64{{0}, {0}})
+	assert.Equal(t, TxErrInstructionError{Index: 1, Err: InstrErrCustom{Code: 4}}, err)
+}
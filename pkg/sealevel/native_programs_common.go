@@ -5,6 +5,7 @@ import (
 
 	"github.com/gagliardetto/solana-go"
 	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/features"
 )
 
 const BpfLoaderUpgradeableAddrStr = "BPFLoaderUpgradeab1e11111111111111111111111"
@@ -31,10 +32,14 @@ const Secp256kPrecompileAddrStr = "KeccakSecp256k11111111111111111111111111111"
 
 var Secp256kPrecompileAddr = base58.MustDecodeFromString(Secp256kPrecompileAddrStr)
 
-const Ed25519PrecompileAddrStr = "KeccakSecp256k11111111111111111111111111111"
+const Ed25519PrecompileAddrStr = "Ed25519SigVerify111111111111111111111111111"
 
 var Ed25519PrecompileAddr = base58.MustDecodeFromString(Ed25519PrecompileAddrStr)
 
+const Secp256r1PrecompileAddrStr = "Secp256r1SigVerify1111111111111111111111111"
+
+var Secp256r1PrecompileAddr = base58.MustDecodeFromString(Secp256r1PrecompileAddrStr)
+
 var StakeProgramAddrStr = "Stake11111111111111111111111111111111111111"
 
 var StakeProgramAddr = base58.MustDecodeFromString(StakeProgramAddrStr)
@@ -51,28 +56,63 @@ var SystemProgramAddrStr = "11111111111111111111111111111111"
 
 var SystemProgramAddr = base58.MustDecodeFromString(SystemProgramAddrStr)
 
+var ComputeBudgetProgramAddrStr = "ComputeBudget111111111111111111111111111111"
+
+var ComputeBudgetProgramAddr = base58.MustDecodeFromString(ComputeBudgetProgramAddrStr)
+
+var AddressLookupTableProgramAddrStr = "AddressLookupTab1e1111111111111111111111111"
+
+var AddressLookupTableProgramAddr = base58.MustDecodeFromString(AddressLookupTableProgramAddrStr)
+
+var FeatureProgramAddrStr = "Feature111111111111111111111111111111111111"
+
+var FeatureProgramAddr = base58.MustDecodeFromString(FeatureProgramAddrStr)
+
+var LoaderV4AddrStr = "LoaderV411111111111111111111111111111111111"
+
+var LoaderV4Addr = base58.MustDecodeFromString(LoaderV4AddrStr)
+
+var ZkTokenProofProgramAddrStr = "ZkTokenProof1111111111111111111111111111111"
+
+var ZkTokenProofProgramAddr = base58.MustDecodeFromString(ZkTokenProofProgramAddrStr)
+
+var ZkElGamalProofProgramAddrStr = "ZkE1Gama1Proof11111111111111111111111111111"
+
+var ZkElGamalProofProgramAddr = base58.MustDecodeFromString(ZkElGamalProofProgramAddrStr)
+
+var SysvarRewardsAddrStr = "SysvarRewards111111111111111111111111111111"
+
+var SysvarRewardsAddr = base58.MustDecodeFromString(SysvarRewardsAddrStr)
+
+var TokenProgramAddrStr = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+
+var TokenProgramAddr = base58.MustDecodeFromString(TokenProgramAddrStr)
+
+var SysvarOwnerAddrStr = "Sysvar1111111111111111111111111111111111111"
+
+var SysvarOwnerAddr = base58.MustDecodeFromString(SysvarOwnerAddrStr)
+
+var IncineratorAddrStr = "1nc1nerator11111111111111111111111111111111"
+
+var IncineratorAddr = base58.MustDecodeFromString(IncineratorAddrStr)
+
 var IsPrecompile = errors.New("IsPrecompile")
 
 var invalidEnumValue = errors.New("invalid enum value")
 
-func resolveNativeProgramById(programId [32]byte) (func(ctx *ExecutionCtx) error, error) {
-
-	switch programId {
-	case ConfigProgramAddr:
-		return ConfigProgramExecute, nil
-	case SystemProgramAddr:
-		return SystemProgramExecute, nil
-	case StakeProgramAddr:
-		return StakeProgramExecute, nil
-	case VoteProgramAddr:
-		return VoteProgramExecute, nil
-	case Secp256kPrecompileAddr:
-		return nil, IsPrecompile
-	case Ed25519PrecompileAddr:
+// resolveNativeProgramById returns the processor of the given builtin
+// program, or IsPrecompile if the program is a precompile, which has no
+// processor of its own.
+func resolveNativeProgramById(programId solana.PublicKey, f *features.Features) (func(ctx *ExecutionCtx) error, error) {
+	if isPrecompile(programId, *f) {
 		return nil, IsPrecompile
 	}
 
-	return nil, InstrErrUnsupportedProgramId
+	processor, ok := Builtins(f).Lookup(programId)
+	if !ok {
+		return nil, InstrErrUnsupportedProgramId
+	}
+	return processor, nil
 }
 
 func verifySigner(authorized solana.PublicKey, signers []solana.PublicKey) error {
@@ -3,11 +3,12 @@ package sealevel
 import (
 	"bytes"
 	"encoding/binary"
-	"fmt"
 	"io"
 
 	"github.com/gagliardetto/solana-go"
 	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/safemath"
+	"go.firedancer.io/radiance/pkg/sbpf"
 )
 
 // Params is the data passed to programs via the Sealevel VM input segment.
@@ -18,8 +19,13 @@ type Params struct {
 	Features  features.Features
 }
 
-// ReallocSpace is the allowed length by which an account is allowed to grow.
-const ReallocSpace = 1024 * 10
+// ReallocSpace is the size of the realloc region following the data of
+// each account, into which a program may grow the account.
+const ReallocSpace = MaxPermittedDataIncrease
+
+// MaxInstructionAccounts is the max number of accounts that can be
+// serialized into a program's input, as duplicate markers are one byte.
+const MaxInstructionAccounts = 255
 
 // ReallocAlign is the byte amount by which the data following a realloc is aligned.
 const ReallocAlign = 8
@@ -35,12 +41,28 @@ type AccountParam struct {
 	Owner          solana.PublicKey
 	Lamports       uint64
 	Data           []byte
-	Padding        int // ignored, written by serializer
+	DataWritable   bool // whether the program may modify Data
+	Padding        int  // ignored, written by serializer
 	RentEpoch      uint64
 }
 
 // Serialize writes the params to the provided buffer.
 func (p *Params) Serialize(buf *bytes.Buffer) {
+	p.serialize(&inputWriter{buf: buf})
+}
+
+// SerializeDirect writes the params to the provided buffer, except for
+// account data, which is mapped directly into VM memory. Returns the
+// memory regions making up the input, backed by the buffer and the
+// account data.
+func (p *Params) SerializeDirect(buf *bytes.Buffer) []sbpf.MemoryRegion {
+	w := &inputWriter{buf: buf, direct: true}
+	p.serialize(w)
+	return w.memoryRegions()
+}
+
+func (p *Params) serialize(w *inputWriter) {
+	buf := w.buf
 	buf.Reset()
 
 	_ = binary.Write(buf, binary.LittleEndian, uint64(len(p.Accounts)))
@@ -62,14 +84,17 @@ func (p *Params) Serialize(buf *bytes.Buffer) {
 		_ = binary.Write(buf, binary.LittleEndian, acc.Lamports)
 
 		_ = binary.Write(buf, binary.LittleEndian, uint64(len(acc.Data)))
-		// This account copy cannot be avoided without a significant redesign of the VM
-		_, _ = buf.Write(acc.Data[:])
+		w.writeAccountData(acc.Data, acc.DataWritable)
 
 		acc.Padding = ReallocSpace
-		if offset := buf.Len() % ReallocAlign; offset != 0 {
+		if offset := len(acc.Data) % ReallocAlign; offset != 0 {
 			acc.Padding += ReallocAlign - offset
 		}
 		_ = writeZeros(buf, acc.Padding)
+		if w.direct {
+			// the realloc padding gets its own region
+			w.pushRegion(acc.DataWritable)
+		}
 
 		_ = binary.Write(buf, binary.LittleEndian, acc.RentEpoch)
 	}
@@ -83,55 +108,106 @@ func (p *Params) Serialize(buf *bytes.Buffer) {
 	}
 }
 
-// Update writes data modified by a program back to the params struct.
-func (p *Params) Update(buf *bytes.Reader) error {
-	// TODO authorization checks
+// SerializeUnaligned writes the params to the provided buffer in the
+// unaligned format expected by programs owned by the deprecated loader.
+func (p *Params) SerializeUnaligned(buf *bytes.Buffer) {
+	p.serializeUnaligned(&inputWriter{buf: buf})
+}
 
-	for i := 0; true; i++ {
-		if i >= len(p.Accounts) {
-			return fmt.Errorf("number of accounts changed")
-		}
-		acc := &p.Accounts[i]
+// SerializeUnalignedDirect is the counterpart of SerializeDirect for the
+// unaligned format.
+func (p *Params) SerializeUnalignedDirect(buf *bytes.Buffer) []sbpf.MemoryRegion {
+	w := &inputWriter{buf: buf, direct: true}
+	p.serializeUnaligned(w)
+	return w.memoryRegions()
+}
 
-		idx, err := buf.ReadByte()
-		if err != nil {
-			return err
-		}
-		if (!acc.IsDuplicate && idx != 0xFF) || acc.DuplicateIndex != idx {
-			return fmt.Errorf("account order changed")
-		}
+func (p *Params) serializeUnaligned(w *inputWriter) {
+	buf := w.buf
+	buf.Reset()
+
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(p.Accounts)))
+	for i := range p.Accounts {
+		acc := &p.Accounts[i]
 
-		if idx != 0xFF {
+		if acc.IsDuplicate {
+			_, _ = buf.Write([]byte{acc.DuplicateIndex})
 			continue
 		}
+		_ = binary.Write(buf, binary.LittleEndian, uint8(0xFF))
+		_ = binary.Write(buf, binary.LittleEndian, acc.IsSigner)
+		_ = binary.Write(buf, binary.LittleEndian, acc.IsWritable)
+		_, _ = buf.Write(acc.Key[:])
+		_ = binary.Write(buf, binary.LittleEndian, acc.Lamports)
+		_ = binary.Write(buf, binary.LittleEndian, uint64(len(acc.Data)))
+		w.writeAccountData(acc.Data, acc.DataWritable)
+		_, _ = buf.Write(acc.Owner[:])
+		_ = binary.Write(buf, binary.LittleEndian, acc.IsExecutable)
+		_ = binary.Write(buf, binary.LittleEndian, acc.RentEpoch)
+	}
 
-		// TODO is deferring error check okay here?
-		_ = binary.Read(buf, binary.LittleEndian, &acc.IsSigner)
-		_ = binary.Read(buf, binary.LittleEndian, &acc.IsWritable)
-		_ = binary.Read(buf, binary.LittleEndian, &acc.IsExecutable)
-		_, _ = buf.Seek(4, io.SeekCurrent)
-		_, _ = buf.Read(acc.Key[:])
-		_, _ = buf.Read(acc.Owner[:])
-		_ = binary.Read(buf, binary.LittleEndian, &acc.Lamports)
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(p.Data)))
+	_, _ = buf.Write(p.Data)
 
-		oldLen := uint64(len(acc.Data))
-		var newLen uint64
-		_ = binary.Read(buf, binary.LittleEndian, &newLen)
-		if newLen < oldLen {
-			return fmt.Errorf("attempted to shrink account")
-		}
-		if newLen > oldLen+ReallocSpace {
-			return fmt.Errorf("attempted to grow account too much")
-		}
-		acc.Data, _ = io.ReadAll(io.LimitReader(buf, int64(newLen)))
-		_, _ = buf.Seek(int64(acc.Padding-int(newLen-oldLen)), io.SeekCurrent)
+	_, err := buf.Write(p.ProgramID[:])
+	if err != nil {
+		panic("writes to buffer failed: " + err.Error()) // OOM
+	}
+}
 
-		_ = binary.Read(buf, binary.LittleEndian, &acc.RentEpoch)
+// inputWriter tracks the memory regions the serialized input is split
+// into when account data is mapped directly (bpf_account_data_direct_mapping).
+//
+// Based on solana_bpf_loader_program::serialization::Serializer.
+type inputWriter struct {
+	buf         *bytes.Buffer
+	direct      bool
+	regionStart int
+	regions     []inputRegion
+}
+
+type inputRegion struct {
+	start, end int    // range of the buffer, if not account data
+	data       []byte // directly mapped account data
+	writable   bool
+}
+
+// pushRegion ends a region at the current end of the buffer.
+func (w *inputWriter) pushRegion(writable bool) {
+	w.regions = append(w.regions, inputRegion{start: w.regionStart, end: w.buf.Len(), writable: writable})
+	w.regionStart = w.buf.Len()
+}
+
+// writeAccountData copies account data into the buffer, or maps it
+// directly into a region of its own.
+func (w *inputWriter) writeAccountData(data []byte, writable bool) {
+	if !w.direct {
+		_, _ = w.buf.Write(data)
+		return
 	}
+	w.pushRegion(true)
+	if len(data) > 0 {
+		w.regions = append(w.regions, inputRegion{data: data, writable: writable})
+	}
+}
 
-	_, _ = buf.Seek(int64(len(p.Data)), io.SeekCurrent)
-	_, err := buf.Read(p.ProgramID[:])
-	return err
+// memoryRegions returns the regions making up the input, laid out
+// contiguously from VaddrInput.
+func (w *inputWriter) memoryRegions() []sbpf.MemoryRegion {
+	w.pushRegion(true)
+
+	input := w.buf.Bytes()
+	regions := make([]sbpf.MemoryRegion, 0, len(w.regions))
+	vaddr := sbpf.VaddrInput
+	for _, r := range w.regions {
+		data := r.data
+		if data == nil {
+			data = input[r.start:r.end:r.end]
+		}
+		regions = append(regions, sbpf.MemoryRegion{VAddr: vaddr, Data: data, Writable: r.writable})
+		vaddr += uint64(len(data))
+	}
+	return regions
 }
 
 func writeZeros(b *bytes.Buffer, n int) error {
@@ -147,3 +223,281 @@ func (zeroRd) Read(buf []byte) (int, error) {
 	}
 	return len(buf), nil
 }
+
+// serializeParameters collects the accounts and data of the current
+// instruction into Params, in the order the program will see them.
+func serializeParameters(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx) (*Params, error) {
+	programId, err := instrCtx.LastProgramKey(txCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	numAccounts := instrCtx.NumberOfInstructionAccounts()
+	if numAccounts > MaxInstructionAccounts {
+		return nil, InstrErrMaxAccountsExceeded
+	}
+
+	params := &Params{
+		Accounts:  make([]AccountParam, numAccounts),
+		Data:      instrCtx.Data,
+		ProgramID: programId,
+		Features:  execCtx.GlobalCtx.Features,
+	}
+
+	for i := range params.Accounts {
+		instrAcctIdx := uint64(i)
+		isDuplicate, dupIdx, err := instrCtx.IsInstructionAccountDuplicate(instrAcctIdx)
+		if err != nil {
+			return nil, err
+		}
+		if isDuplicate {
+			params.Accounts[i] = AccountParam{IsDuplicate: true, DuplicateIndex: uint8(dupIdx)}
+			continue
+		}
+
+		acct, err := instrCtx.BorrowInstructionAccount(txCtx, instrAcctIdx)
+		if err != nil {
+			return nil, err
+		}
+		dataWritable := acct.DataCanBeChanged(params.Features) == nil
+		if dataWritable && params.Features.IsActive(features.BpfAccountDataDirectMapping) {
+			// the program writes to the account data in place
+			if err = acct.Touch(); err != nil {
+				acct.Drop()
+				return nil, err
+			}
+		}
+		params.Accounts[i] = AccountParam{
+			IsSigner:     acct.IsSigner(),
+			IsWritable:   acct.IsWritable(),
+			IsExecutable: acct.IsExecutable(params.Features),
+			Key:          acct.Key(),
+			Owner:        acct.Owner(),
+			Lamports:     acct.Lamports(),
+			Data:         acct.Data(),
+			DataWritable: dataWritable,
+			RentEpoch:    acct.Account.RentEpoch,
+		}
+		acct.Drop()
+	}
+
+	return params, nil
+}
+
+// originalDataLens returns the data length of each account, resolving
+// duplicates to the account they refer to.
+func (p *Params) originalDataLens() []uint64 {
+	lens := make([]uint64, len(p.Accounts))
+	for i, acc := range p.Accounts {
+		if acc.IsDuplicate {
+			lens[i] = lens[acc.DuplicateIndex]
+		} else {
+			lens[i] = uint64(len(acc.Data))
+		}
+	}
+	return lens
+}
+
+// deserializeParameters applies the account changes made by a program to
+// its serialized input back to the accounts of the current instruction.
+// params must be the value that was serialized into input.
+func deserializeParameters(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx, params *Params, input []byte) error {
+	f := execCtx.GlobalCtx.Features
+
+	offset := uint64(8) // number of accounts
+	for i := range params.Accounts {
+		acc := &params.Accounts[i]
+		if acc.IsDuplicate {
+			offset += 8
+			continue
+		}
+
+		borrowed, err := instrCtx.BorrowInstructionAccount(txCtx, uint64(i))
+		if err != nil {
+			return err
+		}
+		offset, err = deserializeAccount(f, params, acc, borrowed, input, offset)
+		borrowed.Drop()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deserializeAccount applies the changes to an account serialized at offset
+// in the aligned input, and returns the offset of the next account.
+func deserializeAccount(f features.Features, params *Params, acc *AccountParam, borrowed *BorrowedAccount, input []byte, offset uint64) (uint64, error) {
+	var err error
+
+	// skip duplicate marker, is_signer, is_writable, is_executable, padding and key
+	offset += 1 + 1 + 1 + 1 + 4 + solana.PublicKeyLength
+
+	if uint64(len(input)) < offset+solana.PublicKeyLength+8+8 {
+		return 0, InstrErrInvalidArgument
+	}
+
+	owner := solana.PublicKeyFromBytes(input[offset : offset+solana.PublicKeyLength])
+	offset += solana.PublicKeyLength
+
+	lamports := binary.LittleEndian.Uint64(input[offset:])
+	offset += 8
+	if borrowed.Lamports() != lamports {
+		err = borrowed.SetLamports(lamports, f)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	preLen := uint64(len(acc.Data))
+	postLen := binary.LittleEndian.Uint64(input[offset:])
+	offset += 8
+
+	if safemath.SaturatingSubU64(postLen, preLen) > MaxPermittedDataIncrease || postLen > MaxPermittedDataLength {
+		return 0, InstrErrInvalidRealloc
+	}
+
+	if params.directMapping() {
+		// the account data was modified in place, only bytes the
+		// program appended are in the realloc padding
+		err = deserializeAccountDataDirect(f, borrowed, preLen, postLen, input[offset:])
+		offset += uint64(acc.Padding)
+	} else {
+		err = deserializeAccountData(f, borrowed, postLen, input[offset:])
+		offset += preLen + uint64(acc.Padding)
+	}
+	if err != nil {
+		return 0, err
+	}
+	offset += 8 // rent epoch
+
+	if borrowed.Owner() != owner {
+		err = borrowed.SetOwner(f, owner)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// deserializeAccountData applies the data of an account serialized at
+// the start of input.
+func deserializeAccountData(f features.Features, borrowed *BorrowedAccount, postLen uint64, input []byte) error {
+	if uint64(len(input)) < postLen {
+		return InstrErrInvalidArgument
+	}
+	data := input[:postLen]
+
+	err := borrowed.CanDataBeResized(postLen)
+	if err == nil {
+		err = borrowed.DataCanBeChanged(f)
+	}
+	if err == nil {
+		newData := make([]byte, postLen)
+		copy(newData, data)
+		return borrowed.SetData(f, newData)
+	} else if !bytes.Equal(borrowed.Data(), data) {
+		return err
+	}
+	return nil
+}
+
+// deserializeAccountDataDirect resizes a directly mapped account to
+// postLen, appending any bytes beyond preLen from the realloc padding
+// at the start of input.
+func deserializeAccountDataDirect(f features.Features, borrowed *BorrowedAccount, preLen uint64, postLen uint64, input []byte) error {
+	err := borrowed.CanDataBeResized(postLen)
+	if err == nil {
+		err = borrowed.DataCanBeChanged(f)
+	}
+	if err != nil {
+		if uint64(len(borrowed.Data())) != postLen {
+			return err
+		}
+		return nil
+	}
+
+	err = borrowed.SetDataLength(postLen, f)
+	if err != nil {
+		return err
+	}
+	if allocated := safemath.SaturatingSubU64(postLen, preLen); allocated > 0 {
+		if uint64(len(input)) < allocated || uint64(len(borrowed.Data())) < preLen+allocated {
+			return InstrErrInvalidArgument
+		}
+		copy(borrowed.Data()[preLen:postLen], input[:allocated])
+	}
+	return nil
+}
+
+// directMapping returns whether account data is mapped directly into
+// the program's memory rather than copied into its input.
+func (p *Params) directMapping() bool {
+	return p.Features.IsActive(features.BpfAccountDataDirectMapping)
+}
+
+// deserializeParametersUnaligned is the counterpart of deserializeParameters
+// for the unaligned format. Programs using this format cannot resize
+// accounts or change their owner.
+func deserializeParametersUnaligned(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx, params *Params, input []byte) error {
+	f := execCtx.GlobalCtx.Features
+
+	offset := uint64(8) // number of accounts
+	for i := range params.Accounts {
+		acc := &params.Accounts[i]
+		offset++ // duplicate marker
+		if acc.IsDuplicate {
+			continue
+		}
+
+		borrowed, err := instrCtx.BorrowInstructionAccount(txCtx, uint64(i))
+		if err != nil {
+			return err
+		}
+		offset, err = deserializeAccountUnaligned(f, params, acc, borrowed, input, offset)
+		borrowed.Drop()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deserializeAccountUnaligned applies the changes to an account serialized
+// at offset in the unaligned input, and returns the offset of the next
+// account.
+func deserializeAccountUnaligned(f features.Features, params *Params, acc *AccountParam, borrowed *BorrowedAccount, input []byte, offset uint64) (uint64, error) {
+	var err error
+
+	// skip is_signer, is_writable and key
+	offset += 1 + 1 + solana.PublicKeyLength
+
+	preLen := uint64(len(acc.Data))
+	if uint64(len(input)) < offset+8+8 {
+		return 0, InstrErrInvalidArgument
+	}
+
+	lamports := binary.LittleEndian.Uint64(input[offset:])
+	offset += 8
+	if borrowed.Lamports() != lamports {
+		err = borrowed.SetLamports(lamports, f)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	offset += 8 // data length
+
+	// directly mapped account data was modified in place
+	if !params.directMapping() {
+		err = deserializeAccountData(f, borrowed, preLen, input[offset:])
+		if err != nil {
+			return 0, err
+		}
+		offset += preLen
+	}
+	offset += solana.PublicKeyLength + 1 + 8 // owner, is_executable and rent epoch
+	return offset, nil
+}
@@ -0,0 +1,180 @@
+package sealevel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestParams_SerializeUnaligned(t *testing.T) {
+	params := &Params{
+		Accounts: []AccountParam{
+			{IsSigner: true, IsWritable: true, Key: solana.PublicKey{1}, Owner: solana.PublicKey{2}, Lamports: 3, Data: []byte{4, 5}, RentEpoch: 6},
+			{IsDuplicate: true, DuplicateIndex: 0},
+		},
+		Data:      []byte{7},
+		ProgramID: solana.PublicKey{8},
+	}
+
+	var buf bytes.Buffer
+	params.SerializeUnaligned(&buf)
+	out := buf.Bytes()
+
+	assert.Equal(t, 8+(1+1+1+32+8+8+2+32+1+8)+1+8+1+32, len(out))
+	assert.Equal(t, uint64(2), binary.LittleEndian.Uint64(out))
+	assert.Equal(t, []byte{0xFF, 1, 1, 1}, out[8:12])
+	assert.Equal(t, uint64(3), binary.LittleEndian.Uint64(out[43:]))
+	assert.Equal(t, []byte{4, 5}, out[59:61])
+	assert.Equal(t, byte(2), out[61])
+	assert.Equal(t, byte(0), out[102]) // duplicate of account 0
+	assert.Equal(t, byte(7), out[111])
+	assert.Equal(t, byte(8), out[112])
+}
+
+func TestParams_Serialize(t *testing.T) {
+	params := &Params{
+		Accounts: []AccountParam{
+			{IsSigner: true, IsWritable: true, Key: solana.PublicKey{1}, Owner: solana.PublicKey{2}, Lamports: 3, Data: []byte{4, 5}, RentEpoch: 6},
+			{IsDuplicate: true, DuplicateIndex: 0},
+		},
+		Data:      []byte{7},
+		ProgramID: solana.PublicKey{8},
+	}
+
+	var buf bytes.Buffer
+	params.Serialize(&buf)
+	out := buf.Bytes()
+
+	// data is followed by the realloc region, padded to 8 byte alignment
+	accountLen := 8 + 32 + 32 + 8 + 8 + 2 + ReallocSpace + 6 + 8
+	assert.Equal(t, ReallocSpace+6, params.Accounts[0].Padding)
+	assert.Equal(t, 8+accountLen+8+8+1+32, len(out))
+	assert.Equal(t, []byte{0xFF, 1, 1, 0, 0, 0, 0, 0}, out[8:16])
+	assert.Equal(t, uint64(3), binary.LittleEndian.Uint64(out[80:]))
+	assert.Equal(t, uint64(2), binary.LittleEndian.Uint64(out[88:]))
+	assert.Equal(t, uint64(6), binary.LittleEndian.Uint64(out[8+accountLen-8:]))
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0}, out[8+accountLen:8+accountLen+8]) // duplicate of account 0
+	assert.Equal(t, byte(7), out[8+accountLen+16])
+	assert.Equal(t, byte(8), out[8+accountLen+17])
+}
+
+func TestDeserializeParameters_Realloc(t *testing.T) {
+	programKey := solana.PublicKey{9}
+	acct := &accounts.Account{Lamports: 100, Owner: programKey, Data: make([]byte, 3)}
+
+	execCtx := newTestLoaderExecCtx(programKey, *features.NewFeaturesDefault(), nil,
+		[]testInstrAcct{{solana.PublicKey{1}, acct, false, true}})
+	txCtx := execCtx.TransactionContext
+	instrCtx, err := txCtx.CurrentInstructionCtx()
+	require.NoError(t, err)
+
+	params, err := serializeParameters(execCtx, txCtx, instrCtx)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	params.Serialize(&buf)
+	input := buf.Bytes()
+
+	const ownerOffset, dataLenOffset = 8 + 8 + 32, 8 + 8 + 32 + 32 + 8
+
+	// the program grows the account into the realloc region and assigns it,
+	// which requires the data to be zeroed
+	binary.LittleEndian.PutUint64(input[dataLenOffset:], 5)
+	copy(input[ownerOffset:], solana.PublicKey{3}.Bytes())
+
+	require.NoError(t, deserializeParameters(execCtx, txCtx, instrCtx, params, input))
+	assert.Equal(t, make([]byte, 5), acct.Data)
+	assert.Equal(t, solana.PublicKey{3}, solana.PublicKey(acct.Owner))
+
+	// growing beyond the realloc region is rejected
+	binary.LittleEndian.PutUint64(input[dataLenOffset:], 3+ReallocSpace+1)
+	assert.Equal(t, InstrErrInvalidRealloc, deserializeParameters(execCtx, txCtx, instrCtx, params, input))
+}
+
+func TestDeserializeParameters(t *testing.T) {
+	for _, aligned := range []bool{true, false} {
+		programKey := solana.PublicKey{9}
+		acct := &accounts.Account{Lamports: 100, Owner: programKey, Data: []byte{1, 2, 3}}
+
+		execCtx := newTestLoaderExecCtx(programKey, *features.NewFeaturesDefault(), nil,
+			[]testInstrAcct{{solana.PublicKey{1}, acct, false, true}})
+		txCtx := execCtx.TransactionContext
+		instrCtx, err := txCtx.CurrentInstructionCtx()
+		require.NoError(t, err)
+
+		params, err := serializeParameters(execCtx, txCtx, instrCtx)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		var lamportsOffset, dataOffset int
+		if aligned {
+			params.Serialize(&buf)
+			lamportsOffset, dataOffset = 8+8+32+32, 8+8+32+32+8+8
+		} else {
+			params.SerializeUnaligned(&buf)
+			lamportsOffset, dataOffset = 8+3+32, 8+3+32+8+8
+		}
+		input := buf.Bytes()
+
+		// the program moves lamports and modifies the account data
+		binary.LittleEndian.PutUint64(input[lamportsOffset:], 50)
+		input[dataOffset] = 7
+
+		if aligned {
+			err = deserializeParameters(execCtx, txCtx, instrCtx, params, input)
+		} else {
+			err = deserializeParametersUnaligned(execCtx, txCtx, instrCtx, params, input)
+		}
+		require.NoError(t, err)
+
+		assert.Equal(t, uint64(50), acct.Lamports)
+		assert.Equal(t, []byte{7, 2, 3}, acct.Data)
+	}
+}
+
+func TestDeserializeParameters_DirectMapping(t *testing.T) {
+	programKey := solana.PublicKey{9}
+	acct := &accounts.Account{Lamports: 100, Owner: programKey, Data: []byte{1, 2, 3}}
+
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.BpfAccountDataDirectMapping, 0)
+	execCtx := newTestLoaderExecCtx(programKey, *f, nil,
+		[]testInstrAcct{{solana.PublicKey{1}, acct, false, true}})
+	txCtx := execCtx.TransactionContext
+	instrCtx, err := txCtx.CurrentInstructionCtx()
+	require.NoError(t, err)
+
+	params, err := serializeParameters(execCtx, txCtx, instrCtx)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	regions := params.SerializeDirect(&buf)
+	input := buf.Bytes()
+
+	// header, account data, realloc region and trailer
+	require.Len(t, regions, 4)
+	assert.Equal(t, sbpf.VaddrInput, regions[0].VAddr)
+	assert.Equal(t, 8+8+32+32+8+8, len(regions[0].Data))
+	assert.Equal(t, sbpf.VaddrInput+uint64(len(regions[0].Data)), regions[1].VAddr)
+	assert.True(t, regions[1].Writable)
+	assert.Equal(t, ReallocSpace+5, len(regions[2].Data))
+	_, err = sbpf.NewMemoryMapping(regions...)
+	require.NoError(t, err)
+
+	// the program modifies the account data in place
+	// and grows it into the realloc region
+	regions[1].Data[0] = 7
+	regions[2].Data[0], regions[2].Data[1] = 8, 9
+	const dataLenOffset = 8 + 8 + 32 + 32 + 8
+	binary.LittleEndian.PutUint64(input[dataLenOffset:], 5)
+
+	require.NoError(t, deserializeParameters(execCtx, txCtx, instrCtx, params, input))
+	assert.Equal(t, []byte{7, 2, 3, 8, 9}, acct.Data)
+}
@@ -0,0 +1,241 @@
+package sealevel
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// Poseidon hash over the BN254 scalar field with the x^5 S-box, using the
+// parameters of circomlib, for 1 to PoseidonMaxInputs inputs.
+//
+// Based on light_poseidon, whose parameters are generated by the reference
+// generate_parameters_grain.sage script of the Poseidon authors.
+const (
+	PoseidonMaxInputs  = 12
+	poseidonFullRounds = 8
+)
+
+// partial rounds by state width, starting at width 2
+var poseidonPartialRounds = [PoseidonMaxInputs]int{56, 57, 56, 60, 60, 63, 64, 63, 60, 66, 60, 65}
+
+var (
+	errPoseidonInvalidWidth           = errors.New("poseidon: invalid number of inputs")
+	errPoseidonEmptyInput             = errors.New("poseidon: empty input")
+	errPoseidonInputLargerThanModulus = errors.New("poseidon: input larger than modulus")
+)
+
+type poseidonParams struct {
+	width         int
+	partialRounds int
+	ark           []*big.Int   // round constants, width per round
+	mds           [][]*big.Int // width x width
+}
+
+var (
+	poseidonParamsOnce [PoseidonMaxInputs]sync.Once
+	poseidonParamsByN  [PoseidonMaxInputs]*poseidonParams
+)
+
+// poseidonParamsFor returns the parameters for hashing n inputs,
+// generating them on first use.
+func poseidonParamsFor(n int) *poseidonParams {
+	poseidonParamsOnce[n-1].Do(func() {
+		poseidonParamsByN[n-1] = newPoseidonParams(n+1, poseidonPartialRounds[n-1])
+	})
+	return poseidonParamsByN[n-1]
+}
+
+// poseidonHash hashes the given field elements, each at most 32 bytes
+// in the given byte order.
+func poseidonHash(inputs [][]byte, bigEndian bool) ([]byte, error) {
+	if len(inputs) < 1 || len(inputs) > PoseidonMaxInputs {
+		return nil, errPoseidonInvalidWidth
+	}
+	params := poseidonParamsFor(len(inputs))
+
+	state := make([]*big.Int, params.width)
+	state[0] = new(big.Int) // domain tag
+	for i, input := range inputs {
+		if len(input) == 0 {
+			return nil, errPoseidonEmptyInput
+		}
+		if len(input) > 32 {
+			return nil, errPoseidonInputLargerThanModulus
+		}
+		if !bigEndian {
+			input = reverseBytes(input)
+		}
+		v := new(big.Int).SetBytes(input)
+		if v.Cmp(bn256.Order) >= 0 {
+			return nil, errPoseidonInputLargerThanModulus
+		}
+		state[i+1] = v
+	}
+
+	state = params.permute(state)
+
+	out := make([]byte, 32)
+	state[0].FillBytes(out)
+	if !bigEndian {
+		out = reverseBytes(out)
+	}
+	return out, nil
+}
+
+func (p *poseidonParams) permute(state []*big.Int) []*big.Int {
+	rounds := poseidonFullRounds + p.partialRounds
+	for r := 0; r < rounds; r++ {
+		for i := range state {
+			state[i] = frAdd(state[i], p.ark[r*p.width+i])
+		}
+		if r < poseidonFullRounds/2 || r >= poseidonFullRounds/2+p.partialRounds {
+			for i := range state {
+				state[i] = frPow5(state[i])
+			}
+		} else {
+			state[0] = frPow5(state[0])
+		}
+
+		mixed := make([]*big.Int, p.width)
+		for i := range mixed {
+			mixed[i] = new(big.Int)
+			for j := range state {
+				mixed[i] = frAdd(mixed[i], frMul(p.mds[i][j], state[j]))
+			}
+		}
+		state = mixed
+	}
+	return state
+}
+
+// newPoseidonParams generates the round constants and MDS matrix for the
+// given width with a Grain LFSR, as generate_parameters_grain.sage does.
+func newPoseidonParams(width int, partialRounds int) *poseidonParams {
+	const fieldBits = 254
+	g := newGrainLFSR(fieldBits, width, poseidonFullRounds, partialRounds)
+
+	p := &poseidonParams{width: width, partialRounds: partialRounds}
+	for len(p.ark) < (poseidonFullRounds+partialRounds)*width {
+		// rejection sampling
+		if v := g.randomBits(fieldBits); v.Cmp(bn256.Order) < 0 {
+			p.ark = append(p.ark, v)
+		}
+	}
+
+	// Cauchy matrix 1/(x_i + y_j), over distinct sampled x and y
+	for {
+		vals := make([]*big.Int, 2*width)
+		for distinct := false; !distinct; {
+			seen := make(map[string]bool)
+			distinct = true
+			for i := range vals {
+				vals[i] = new(big.Int).Mod(g.randomBits(fieldBits), bn256.Order)
+				if seen[vals[i].String()] {
+					distinct = false
+				}
+				seen[vals[i].String()] = true
+			}
+		}
+		xs, ys := vals[:width], vals[width:]
+
+		mds := make([][]*big.Int, width)
+		ok := true
+		for i := range mds {
+			mds[i] = make([]*big.Int, width)
+			for j := range mds[i] {
+				sum := frAdd(xs[i], ys[j])
+				if sum.Sign() == 0 {
+					ok = false
+					break
+				}
+				mds[i][j] = new(big.Int).ModInverse(sum, bn256.Order)
+			}
+		}
+		if ok {
+			p.mds = mds
+			return p
+		}
+	}
+}
+
+// grainLFSR is the 80 bit Grain LFSR used to generate Poseidon parameters.
+type grainLFSR struct {
+	state [80]byte
+}
+
+func newGrainLFSR(fieldBits, width, fullRounds, partialRounds int) *grainLFSR {
+	g := &grainLFSR{}
+	pos := 0
+	push := func(v, bits int) {
+		for i := bits - 1; i >= 0; i-- {
+			g.state[pos] = byte(v>>i) & 1
+			pos++
+		}
+	}
+	push(1, 2) // prime field
+	push(0, 4) // x^alpha S-box
+	push(fieldBits, 12)
+	push(width, 12)
+	push(fullRounds, 10)
+	push(partialRounds, 10)
+	push(1<<30-1, 30)
+
+	for i := 0; i < 160; i++ {
+		g.next()
+	}
+	return g
+}
+
+func (g *grainLFSR) next() byte {
+	s := &g.state
+	bit := s[62] ^ s[51] ^ s[38] ^ s[23] ^ s[13] ^ s[0]
+	copy(s[:], s[1:])
+	s[79] = bit
+	return bit
+}
+
+// randomBit returns the next output bit, self-shrinking the LFSR output:
+// bits are drawn in pairs, and the second is output if the first is 1.
+func (g *grainLFSR) randomBit() byte {
+	for g.next() == 0 {
+		g.next()
+	}
+	return g.next()
+}
+
+// randomBits returns an integer of the given number of output bits,
+// most significant first.
+func (g *grainLFSR) randomBits(n int) *big.Int {
+	v := new(big.Int)
+	for i := 0; i < n; i++ {
+		v.Lsh(v, 1)
+		v.SetBit(v, 0, uint(g.randomBit()))
+	}
+	return v
+}
+
+func frAdd(a, b *big.Int) *big.Int {
+	r := new(big.Int).Add(a, b)
+	return r.Mod(r, bn256.Order)
+}
+
+func frMul(a, b *big.Int) *big.Int {
+	r := new(big.Int).Mul(a, b)
+	return r.Mod(r, bn256.Order)
+}
+
+func frPow5(a *big.Int) *big.Int {
+	a2 := frMul(a, a)
+	return frMul(frMul(a2, a2), a)
+}
+
+func reverseBytes(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
@@ -0,0 +1,111 @@
+package sealevel
+
+import (
+	"encoding/hex"
+	"testing"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func poseidonInput(v byte) []byte {
+	input := make([]byte, 32)
+	input[31] = v
+	return input
+}
+
+// test vectors from circomlibjs
+func TestPoseidonHash(t *testing.T) {
+	cases := []struct {
+		inputs   []byte
+		expected string
+	}{
+		{[]byte{1}, "29176100eaa962bdc1fe6c654d6a3c130e96a4d1168b33848b897dc502820133"},
+		{[]byte{1, 2}, "115cc0f5e7d690413df64c6b9662e9cf2a3617f2743245519e19607a4417189a"},
+		{[]byte{1, 2, 3, 4}, "299c867db6c1fdd79dcefa40e4510b9837e60ebb1ce0663dbaa525df65250465"},
+	}
+	for _, c := range cases {
+		var inputs, inputsLE [][]byte
+		for _, v := range c.inputs {
+			inputs = append(inputs, poseidonInput(v))
+			inputsLE = append(inputsLE, reverseBytes(poseidonInput(v)))
+		}
+
+		hash, err := poseidonHash(inputs, true)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, hex.EncodeToString(hash))
+
+		hash, err = poseidonHash(inputsLE, false)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, hex.EncodeToString(reverseBytes(hash)))
+	}
+
+	// inputs shorter than 32 bytes are zero extended
+	hash, err := poseidonHash([][]byte{{1}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, cases[0].expected, hex.EncodeToString(hash))
+
+	_, err = poseidonHash(nil, true)
+	assert.Equal(t, errPoseidonInvalidWidth, err)
+	_, err = poseidonHash([][]byte{{}}, true)
+	assert.Equal(t, errPoseidonEmptyInput, err)
+	_, err = poseidonHash([][]byte{make([]byte, 33)}, true)
+	assert.Equal(t, errPoseidonInputLargerThanModulus, err)
+	modulus := bn256.Order.FillBytes(make([]byte, 32))
+	_, err = poseidonHash([][]byte{modulus}, true)
+	assert.Equal(t, errPoseidonInputLargerThanModulus, err)
+}
+
+func TestSyscallPoseidon(t *testing.T) {
+	execCtx := &ExecutionCtx{ComputeMeter: cu.NewComputeMeter(CUMaxComputeUnitLimit)}
+	execCtx.GlobalCtx.Features = *features.NewFeaturesDefault()
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	const (
+		valsAddr   = sbpf.VaddrHeap
+		dataAddr   = sbpf.VaddrHeap + 0x100
+		resultAddr = sbpf.VaddrHeap + 0x200
+	)
+	require.NoError(t, vm.Write(dataAddr, poseidonInput(1)))
+	require.NoError(t, vm.Write(dataAddr+32, poseidonInput(2)))
+	require.NoError(t, vm.Write64(valsAddr, dataAddr))
+	require.NoError(t, vm.Write64(valsAddr+8, 32))
+	require.NoError(t, vm.Write64(valsAddr+16, dataAddr+32))
+	require.NoError(t, vm.Write64(valsAddr+24, 32))
+
+	r0, err := SyscallPoseidonImpl(vm, PoseidonParametersBn254X5, PoseidonBigEndian, valsAddr, 2, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), r0)
+	result, err := vm.Translate(resultAddr, 32, false)
+	require.NoError(t, err)
+	assert.Equal(t, "115cc0f5e7d690413df64c6b9662e9cf2a3617f2743245519e19607a4417189a", hex.EncodeToString(result))
+	assert.Equal(t, uint64(CUMaxComputeUnitLimit-(CUPoseidonCostCoefficientA*4+CUPoseidonCostCoefficientC)), execCtx.ComputeMeter.Remaining())
+
+	// no inputs
+	r0, err = SyscallPoseidonImpl(vm, PoseidonParametersBn254X5, PoseidonBigEndian, valsAddr, 0, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(PoseidonErrInvalidWidthCircom), r0)
+
+	// empty input
+	require.NoError(t, vm.Write64(valsAddr+8, 0))
+	r0, err = SyscallPoseidonImpl(vm, PoseidonParametersBn254X5, PoseidonBigEndian, valsAddr, 2, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(PoseidonErrEmptyInput), r0)
+
+	execCtx.GlobalCtx.Features.EnableFeature(features.SimplifyAltBn128SyscallErrorCodes, 0)
+	r0, err = SyscallPoseidonImpl(vm, PoseidonParametersBn254X5, PoseidonBigEndian, valsAddr, 2, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), r0)
+
+	_, err = SyscallPoseidonImpl(vm, 1, PoseidonBigEndian, valsAddr, 2, resultAddr)
+	assert.Equal(t, SyscallErrInvalidPoseidonParameters, err)
+	_, err = SyscallPoseidonImpl(vm, PoseidonParametersBn254X5, 2, valsAddr, 2, resultAddr)
+	assert.Equal(t, SyscallErrInvalidEndianness, err)
+	_, err = SyscallPoseidonImpl(vm, PoseidonParametersBn254X5, PoseidonBigEndian, valsAddr, PoseidonMaxInputs+1, resultAddr)
+	assert.Equal(t, SyscallErrInvalidLength, err)
+}
@@ -0,0 +1,161 @@
+package sealevel
+
+import (
+	"bytes"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/ryanavella/wide"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+// PreAccount is a snapshot of an instruction account, taken before the
+// instruction executes, against which the changes made by the instruction
+// are verified.
+//
+// Based on solana_program_runtime::pre_account::PreAccount.
+type PreAccount struct {
+	Key     solana.PublicKey
+	Account accounts.Account
+}
+
+// NewPreAccount returns a snapshot of the given account.
+func NewPreAccount(key solana.PublicKey, acct *accounts.Account) PreAccount {
+	pre := PreAccount{Key: key, Account: *acct}
+	pre.Account.Data = append([]byte{}, acct.Data...)
+	return pre
+}
+
+// Verify checks that the changes made to the account by an instruction of
+// the given program were permitted:
+//   - only the owner may assign a writable, non-executable account with
+//     zeroed data to a new owner
+//   - only the owner may debit an account, and only writable,
+//     non-executable accounts may have their balance changed
+//   - only the owner may resize or modify the data of a writable,
+//     non-executable account
+//   - only the owner may mark a writable, rent exempt account executable,
+//     and executable accounts cannot be made non-executable
+//   - the rent epoch is never modified.
+//
+// Based on solana_program_runtime::pre_account::PreAccount::verify.
+func (pre *PreAccount) Verify(programId solana.PublicKey, isWritable bool, rent *SysvarRent, post *accounts.Account) error {
+	preOwner := solana.PublicKeyFromBytes(pre.Account.Owner[:])
+	postOwner := solana.PublicKeyFromBytes(post.Owner[:])
+
+	if preOwner != postOwner && (!isWritable || pre.Account.Executable || programId != preOwner || !isZeroed(post.Data)) {
+		return InstrErrModifiedProgramId
+	}
+
+	if programId != preOwner && pre.Account.Lamports > post.Lamports {
+		return InstrErrExternalAccountLamportSpend
+	}
+
+	if pre.Account.Lamports != post.Lamports {
+		if !isWritable {
+			return InstrErrReadonlyLamportChange
+		}
+		if pre.Account.Executable {
+			return InstrErrExecutableLamportChange
+		}
+	}
+
+	if uint64(len(post.Data)) > MaxPermittedDataLength {
+		return InstrErrInvalidRealloc
+	}
+
+	if len(pre.Account.Data) != len(post.Data) && programId != preOwner {
+		return InstrErrAccountDataSizeChanged
+	}
+
+	if !(programId == preOwner && isWritable && !pre.Account.Executable) && !bytes.Equal(pre.Account.Data, post.Data) {
+		if pre.Account.Executable {
+			return InstrErrExecutableDataModified
+		} else if isWritable {
+			return InstrErrExternalAccountDataModified
+		} else {
+			return InstrErrReadonlyDataModified
+		}
+	}
+
+	if pre.Account.Executable != post.Executable {
+		if !rent.IsExempt(post.Lamports, uint64(len(post.Data))) {
+			return InstrErrExecutableAccountNotRentExempt
+		}
+		if !isWritable || pre.Account.Executable || programId != postOwner {
+			return InstrErrExecutableModified
+		}
+	}
+
+	if pre.Account.RentEpoch != post.RentEpoch {
+		return InstrErrRentEpochModified
+	}
+
+	return nil
+}
+
+func isZeroed(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// preAccountsForInstruction takes snapshots of the distinct accounts of
+// an instruction, before it executes.
+func preAccountsForInstruction(txCtx *TransactionCtx, instrAccts []InstructionAccount) ([]PreAccount, error) {
+	preAccts := make([]PreAccount, 0, len(instrAccts))
+	for idxInInstr, instrAcct := range instrAccts {
+		if instrAcct.IndexInCallee != uint64(idxInInstr) {
+			continue
+		}
+		key, err := txCtx.KeyOfAccountAtIndex(instrAcct.IndexInTransaction)
+		if err != nil {
+			return nil, err
+		}
+		acct, err := txCtx.AccountAtIndex(instrAcct.IndexInTransaction)
+		if err != nil {
+			return nil, err
+		}
+		preAccts = append(preAccts, NewPreAccount(key, acct))
+	}
+	return preAccts, nil
+}
+
+// verifyInstructionAccounts verifies the changes made by an instruction of
+// the given program to its accounts, and that it neither created nor
+// destroyed lamports.
+//
+// Based on solana_program_runtime::invoke_context::InvokeContext::verify.
+func verifyInstructionAccounts(txCtx *TransactionCtx, programId solana.PublicKey, instrAccts []InstructionAccount, preAccts []PreAccount) error {
+	var preSum, postSum wide.Uint128
+	var preIdx int
+	for idxInInstr, instrAcct := range instrAccts {
+		if instrAcct.IndexInCallee != uint64(idxInInstr) {
+			continue
+		}
+		if preIdx >= len(preAccts) {
+			return InstrErrNotEnoughAccountKeys
+		}
+		pre := &preAccts[preIdx]
+		preIdx++
+
+		post, err := txCtx.AccountAtIndex(instrAcct.IndexInTransaction)
+		if err != nil {
+			return err
+		}
+		err = pre.Verify(programId, instrAcct.IsWritable, &txCtx.Rent, post)
+		if err != nil {
+			return err
+		}
+
+		preSum = preSum.Add(wide.Uint128FromUint64(pre.Account.Lamports))
+		postSum = postSum.Add(wide.Uint128FromUint64(post.Lamports))
+	}
+
+	if preSum.Cmp(postSum) != 0 {
+		return InstrErrUnbalancedInstruction
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func TestPreAccountVerify(t *testing.T) {
+	owner, other := solana.PublicKey{1}, solana.PublicKey{2}
+	rent := SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2, BurnPercent: 50}
+
+	verify := func(programId solana.PublicKey, isWritable bool, modify func(post *accounts.Account)) error {
+		pre := NewPreAccount(solana.PublicKey{9}, &accounts.Account{Lamports: 10_000_000, Data: []byte{0, 0}, Owner: owner})
+		post := pre.Account
+		post.Data = append([]byte{}, pre.Account.Data...)
+		modify(&post)
+		return pre.Verify(programId, isWritable, &rent, &post)
+	}
+
+	// the owner may modify, resize, debit and reassign its writable accounts
+	assert.NoError(t, verify(owner, true, func(post *accounts.Account) { post.Data[0] = 1 }))
+	assert.NoError(t, verify(owner, true, func(post *accounts.Account) { post.Data = append(post.Data, 1) }))
+	assert.NoError(t, verify(owner, true, func(post *accounts.Account) { post.Lamports-- }))
+	assert.NoError(t, verify(owner, true, func(post *accounts.Account) { post.Owner = other }))
+	assert.NoError(t, verify(owner, true, func(post *accounts.Account) { post.Executable = true }))
+
+	// other programs may only credit writable accounts
+	assert.NoError(t, verify(other, true, func(post *accounts.Account) { post.Lamports++ }))
+	assert.Equal(t, InstrErrExternalAccountLamportSpend, verify(other, true, func(post *accounts.Account) { post.Lamports-- }))
+	assert.Equal(t, InstrErrExternalAccountDataModified, verify(other, true, func(post *accounts.Account) { post.Data[0] = 1 }))
+	assert.Equal(t, InstrErrAccountDataSizeChanged, verify(other, true, func(post *accounts.Account) { post.Data = nil }))
+	assert.Equal(t, InstrErrModifiedProgramId, verify(other, true, func(post *accounts.Account) { post.Owner = other }))
+
+	// readonly accounts may not change
+	assert.Equal(t, InstrErrReadonlyLamportChange, verify(owner, false, func(post *accounts.Account) { post.Lamports++ }))
+	assert.Equal(t, InstrErrReadonlyDataModified, verify(owner, false, func(post *accounts.Account) { post.Data[0] = 1 }))
+	assert.Equal(t, InstrErrModifiedProgramId, verify(owner, false, func(post *accounts.Account) { post.Owner = other }))
+	assert.Equal(t, InstrErrExecutableModified, verify(owner, false, func(post *accounts.Account) { post.Executable = true }))
+
+	// accounts may only be reassigned with zeroed data
+	assert.Equal(t, InstrErrModifiedProgramId, verify(owner, true, func(post *accounts.Account) {
+		post.Owner = other
+		post.Data[0] = 1
+	}))
+
+	assert.Equal(t, InstrErrExecutableAccountNotRentExempt, verify(owner, true, func(post *accounts.Account) {
+		post.Lamports = 1
+		post.Executable = true
+	}))
+	assert.Equal(t, InstrErrRentEpochModified, verify(owner, true, func(post *accounts.Account) { post.RentEpoch++ }))
+	assert.Equal(t, InstrErrInvalidRealloc, verify(owner, true, func(post *accounts.Account) { post.Data = make([]byte, MaxPermittedDataLength+1) }))
+
+	// snapshots are not affected by later changes to the account
+	acct := &accounts.Account{Data: []byte{1}}
+	pre := NewPreAccount(solana.PublicKey{9}, acct)
+	acct.Data[0] = 2
+	assert.Equal(t, []byte{1}, pre.Account.Data)
+}
+
+func TestVerifyInstructionAccounts(t *testing.T) {
+	owner := solana.PublicKey{1}
+	txCtx := &TransactionCtx{
+		AccountKeys: []solana.PublicKey{{8}, {9}},
+		Accounts: TransactionAccounts{Accounts: []*accounts.Account{
+			{Lamports: 100, Owner: owner},
+			{Lamports: 100, Owner: owner},
+		}},
+	}
+	instrAccts := []InstructionAccount{
+		{IndexInTransaction: 0, IndexInCallee: 0, IsWritable: true},
+		{IndexInTransaction: 1, IndexInCallee: 1, IsWritable: true},
+		{IndexInTransaction: 0, IndexInCallee: 0, IsWritable: true},
+	}
+
+	preAccts, err := preAccountsForInstruction(txCtx, instrAccts)
+	assert.NoError(t, err)
+	assert.Len(t, preAccts, 2)
+
+	txCtx.Accounts.Accounts[0].Lamports -= 40
+	txCtx.Accounts.Accounts[1].Lamports += 40
+	assert.NoError(t, verifyInstructionAccounts(txCtx, owner, instrAccts, preAccts))
+
+	txCtx.Accounts.Accounts[1].Lamports++
+	assert.Equal(t, InstrErrUnbalancedInstruction, verifyInstructionAccounts(txCtx, owner, instrAccts, preAccts))
+}
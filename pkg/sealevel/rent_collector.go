@@ -0,0 +1,111 @@
+package sealevel
+
+import (
+	"math"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/safemath"
+)
+
+// RentExemptRentEpoch is the rent epoch of accounts known to be rent exempt,
+// which are never visited by rent collection again.
+const RentExemptRentEpoch = math.MaxUint64
+
+// RentCollector collects rent from accounts, for the epoch of a bank.
+//
+// Based on solana_sdk::rent_collector::RentCollector.
+type RentCollector struct {
+	Epoch         uint64
+	EpochSchedule SysvarEpochSchedule
+	SlotsPerYear  float64
+	Rent          SysvarRent
+}
+
+// CollectedRent is the rent collected from an account, and the data length
+// of the account if it was emptied by collecting it.
+type CollectedRent struct {
+	RentAmount              uint64
+	AccountDataLenReclaimed uint64
+}
+
+// ShouldCollectRent returns whether rent is collected from the given
+// account. Executable accounts and the incinerator never pay rent.
+func (rc *RentCollector) ShouldCollectRent(key solana.PublicKey, executable bool) bool {
+	return !executable && key != IncineratorAddr
+}
+
+// RentDue returns the rent due by an account with the given lamports and
+// data length, from its rent epoch to the end of the current epoch, or
+// false if the account is rent exempt.
+func (rc *RentCollector) RentDue(lamports uint64, dataLen uint64, rentEpoch uint64) (uint64, bool) {
+	if rc.Rent.IsExempt(lamports, dataLen) {
+		return 0, false
+	}
+
+	var slotsElapsed uint64
+	for epoch := rentEpoch; epoch <= rc.Epoch; epoch++ {
+		slotsElapsed = safemath.SaturatingAddU64(slotsElapsed, rc.EpochSchedule.GetSlotsInEpoch(epoch+1))
+		if epoch == math.MaxUint64 {
+			break
+		}
+	}
+
+	var yearsElapsed float64
+	if rc.SlotsPerYear != 0 {
+		yearsElapsed = float64(slotsElapsed) / rc.SlotsPerYear
+	}
+	return uint64(float64((rentAccountStorageOverhead+dataLen)*rc.Rent.LamportsPerUint8Year) * yearsElapsed), true
+}
+
+// CollectFromExistingAccount collects the rent due by the given account,
+// and moves its rent epoch past the current epoch. Rent exempt accounts
+// are marked as such. An account that cannot pay the rent due is emptied.
+//
+// Based on solana_sdk::rent_collector::RentCollector::collect_from_existing_account.
+func (rc *RentCollector) CollectFromExistingAccount(key solana.PublicKey, acct *accounts.Account) CollectedRent {
+	if acct.RentEpoch == RentExemptRentEpoch || acct.RentEpoch > rc.Epoch {
+		return CollectedRent{}
+	}
+	if !rc.ShouldCollectRent(key, acct.Executable) {
+		acct.RentEpoch = RentExemptRentEpoch
+		return CollectedRent{}
+	}
+
+	rentDue, isRentPaying := rc.RentDue(acct.Lamports, uint64(len(acct.Data)), acct.RentEpoch)
+	if !isRentPaying {
+		acct.RentEpoch = RentExemptRentEpoch
+		return CollectedRent{}
+	}
+	if rentDue == 0 {
+		return CollectedRent{}
+	}
+
+	if acct.Lamports <= rentDue {
+		collected := CollectedRent{RentAmount: acct.Lamports, AccountDataLenReclaimed: uint64(len(acct.Data))}
+		*acct = accounts.Account{}
+		return collected
+	}
+	acct.Lamports -= rentDue
+	acct.RentEpoch = rc.Epoch + 1
+	return CollectedRent{RentAmount: rentDue}
+}
+
+// CollectRentFromAccount collects rent from a writable account loaded by a
+// transaction. Once DisableRentFeesCollection is active, no rent is
+// collected, and rent exempt accounts are only marked as such.
+//
+// Based on solana_svm::account_loader::collect_rent_from_account.
+func CollectRentFromAccount(f *features.Features, rc *RentCollector, key solana.PublicKey, acct *accounts.Account) CollectedRent {
+	if !f.IsActive(features.DisableRentFeesCollection) {
+		return rc.CollectFromExistingAccount(key, acct)
+	}
+
+	if acct.RentEpoch != RentExemptRentEpoch {
+		if _, isRentPaying := rc.RentDue(acct.Lamports, uint64(len(acct.Data)), acct.RentEpoch); !isRentPaying {
+			acct.RentEpoch = RentExemptRentEpoch
+		}
+	}
+	return CollectedRent{}
+}
@@ -0,0 +1,73 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func newTestRentCollector(epoch uint64) *RentCollector {
+	return &RentCollector{
+		Epoch:         epoch,
+		EpochSchedule: SysvarEpochSchedule{SlotsPerEpoch: 432_000},
+		SlotsPerYear:  78_892_314.984,
+		Rent:          SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50},
+	}
+}
+
+func TestGetSlotsInEpoch(t *testing.T) {
+	epochSchedule := SysvarEpochSchedule{SlotsPerEpoch: 8192, Warmup: true, FirstNormalEpoch: 8, FirstNormalSlot: 8160}
+	assert.Equal(t, uint64(32), epochSchedule.GetSlotsInEpoch(0))
+	assert.Equal(t, uint64(64), epochSchedule.GetSlotsInEpoch(1))
+	assert.Equal(t, uint64(4096), epochSchedule.GetSlotsInEpoch(7))
+	assert.Equal(t, uint64(8192), epochSchedule.GetSlotsInEpoch(8))
+}
+
+func TestRentCollectorCollectFromExistingAccount(t *testing.T) {
+	rc := newTestRentCollector(10)
+	key := solana.PublicKey{1}
+
+	// one epoch of rent, for the epoch after the current one
+	acct := &accounts.Account{Lamports: 1_000_000, Data: make([]byte, 100), RentEpoch: 10}
+	rentDue, isRentPaying := rc.RentDue(acct.Lamports, 100, 10)
+	assert.True(t, isRentPaying)
+	assert.Equal(t, uint64(4344), rentDue)
+	assert.Equal(t, CollectedRent{RentAmount: rentDue}, rc.CollectFromExistingAccount(key, acct))
+	assert.Equal(t, 1_000_000-rentDue, acct.Lamports)
+	assert.Equal(t, uint64(11), acct.RentEpoch)
+
+	// rent was already collected for this epoch
+	assert.Equal(t, CollectedRent{}, rc.CollectFromExistingAccount(key, acct))
+
+	// accounts that cannot pay are emptied
+	acct = &accounts.Account{Lamports: 1, Data: make([]byte, 100), RentEpoch: 10}
+	assert.Equal(t, CollectedRent{RentAmount: 1, AccountDataLenReclaimed: 100}, rc.CollectFromExistingAccount(key, acct))
+	assert.Equal(t, &accounts.Account{}, acct)
+
+	// rent exempt and executable accounts are marked exempt
+	acct = &accounts.Account{Lamports: rc.Rent.MinimumBalance(100), Data: make([]byte, 100)}
+	assert.Equal(t, CollectedRent{}, rc.CollectFromExistingAccount(key, acct))
+	assert.Equal(t, uint64(RentExemptRentEpoch), acct.RentEpoch)
+	acct = &accounts.Account{Lamports: 1, Executable: true}
+	assert.Equal(t, CollectedRent{}, rc.CollectFromExistingAccount(key, acct))
+	assert.Equal(t, uint64(RentExemptRentEpoch), acct.RentEpoch)
+}
+
+func TestCollectRentFromAccountDisabled(t *testing.T) {
+	rc := newTestRentCollector(10)
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.DisableRentFeesCollection, 0)
+	key := solana.PublicKey{1}
+
+	acct := &accounts.Account{Lamports: 1_000_000, Data: make([]byte, 100), RentEpoch: 10}
+	assert.Equal(t, CollectedRent{}, CollectRentFromAccount(f, rc, key, acct))
+	assert.Equal(t, uint64(1_000_000), acct.Lamports)
+	assert.Equal(t, uint64(10), acct.RentEpoch)
+
+	acct = &accounts.Account{Lamports: rc.Rent.MinimumBalance(100), Data: make([]byte, 100), RentEpoch: 10}
+	assert.Equal(t, CollectedRent{}, CollectRentFromAccount(f, rc, key, acct))
+	assert.Equal(t, uint64(RentExemptRentEpoch), acct.RentEpoch)
+}
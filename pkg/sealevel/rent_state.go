@@ -0,0 +1,106 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"k8s.io/klog/v2"
+)
+
+// rent states of an account
+const (
+	RentStateUninitialized = iota
+	RentStateRentPaying
+	RentStateRentExempt
+)
+
+// RentState is the state of an account with respect to rent. Lamports and
+// DataSize are only set for rent paying accounts.
+//
+// Based on solana_svm::account_rent_state::RentState.
+type RentState struct {
+	Type     uint32
+	Lamports uint64
+	DataSize uint64
+}
+
+// NewRentState returns the rent state of the given account.
+func NewRentState(acct *accounts.Account, rent *SysvarRent) RentState {
+	if acct.Lamports == 0 {
+		return RentState{Type: RentStateUninitialized}
+	}
+	if !rent.IsExempt(acct.Lamports, uint64(len(acct.Data))) {
+		return RentState{Type: RentStateRentPaying, Lamports: acct.Lamports, DataSize: uint64(len(acct.Data))}
+	}
+	return RentState{Type: RentStateRentExempt}
+}
+
+// TransitionAllowedFrom returns whether an account may go from the pre
+// rent state to this one. New rent paying accounts may not be created: an
+// account may only be rent paying if it already was, and kept its size
+// without gaining lamports.
+func (post RentState) TransitionAllowedFrom(pre RentState) bool {
+	if post.Type != RentStateRentPaying {
+		return true
+	}
+	if pre.Type != RentStateRentPaying {
+		return false
+	}
+	return post.DataSize == pre.DataSize && post.Lamports <= pre.Lamports
+}
+
+// checkRentStateWithAccount returns TxErrInsufficientFundsForRent if the
+// account at the given transaction index went from the pre to the post rent
+// state, other than the incinerator, whose lamports are burned at the end
+// of the slot.
+//
+// Based on solana_svm::account_rent_state::check_rent_state_with_account.
+func checkRentStateWithAccount(pre, post RentState, key solana.PublicKey, idx uint64) error {
+	if key != IncineratorAddr && !post.TransitionAllowedFrom(pre) {
+		klog.Infof("account %s left rent paying", key)
+		return TxErrInsufficientFundsForRent{AccountIndex: uint8(idx)}
+	}
+	return nil
+}
+
+// TransactionRentStates returns the rent states of the writable accounts of
+// a transaction, and nil for its readonly accounts. They are captured before
+// and after the transaction, and before and after each of its instructions,
+// to check that it does not create rent paying accounts.
+//
+// Based on solana_svm::transaction_account_state_info::TransactionAccountStateInfo::new.
+func TransactionRentStates(txCtx *TransactionCtx, isWritable []bool, rent *SysvarRent) []*RentState {
+	states := make([]*RentState, len(txCtx.AccountKeys))
+	for idx := range txCtx.AccountKeys {
+		if idx >= len(isWritable) || !isWritable[idx] {
+			continue
+		}
+		acct, err := txCtx.Accounts.GetAccount(uint64(idx))
+		if err != nil {
+			continue
+		}
+		state := NewRentState(acct, rent)
+		states[idx] = &state
+	}
+	return states
+}
+
+// VerifyRentStateChanges checks the rent state transitions of the writable
+// accounts of a transaction, between the given pre and post states.
+//
+// Based on solana_svm::transaction_account_state_info::TransactionAccountStateInfo::verify_changes.
+func VerifyRentStateChanges(pre, post []*RentState, txCtx *TransactionCtx) error {
+	for idx := range pre {
+		if idx >= len(post) || pre[idx] == nil || post[idx] == nil {
+			continue
+		}
+		key, err := txCtx.KeyOfAccountAtIndex(uint64(idx))
+		if err != nil {
+			return err
+		}
+		err = checkRentStateWithAccount(*pre[idx], *post[idx], key, uint64(idx))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
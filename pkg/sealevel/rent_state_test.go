@@ -0,0 +1,55 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func TestRentStateTransitionAllowedFrom(t *testing.T) {
+	rent := &SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}
+	minBalance := rent.MinimumBalance(10)
+
+	uninitialized := NewRentState(&accounts.Account{}, rent)
+	exempt := NewRentState(&accounts.Account{Lamports: minBalance, Data: make([]byte, 10)}, rent)
+	paying := NewRentState(&accounts.Account{Lamports: minBalance - 1, Data: make([]byte, 10)}, rent)
+	assert.Equal(t, RentState{Type: RentStateUninitialized}, uninitialized)
+	assert.Equal(t, RentState{Type: RentStateRentExempt}, exempt)
+	assert.Equal(t, RentState{Type: RentStateRentPaying, Lamports: minBalance - 1, DataSize: 10}, paying)
+
+	assert.True(t, exempt.TransitionAllowedFrom(paying))
+	assert.True(t, uninitialized.TransitionAllowedFrom(paying))
+	assert.False(t, paying.TransitionAllowedFrom(uninitialized))
+	assert.False(t, paying.TransitionAllowedFrom(exempt))
+
+	// rent paying accounts may lose lamports, but not gain them or resize
+	assert.True(t, RentState{Type: RentStateRentPaying, Lamports: 1, DataSize: 10}.TransitionAllowedFrom(paying))
+	assert.False(t, RentState{Type: RentStateRentPaying, Lamports: minBalance - 1, DataSize: 11}.TransitionAllowedFrom(paying))
+}
+
+func TestVerifyRentStateChanges(t *testing.T) {
+	rent := &SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}
+	keys := []solana.PublicKey{{1}, {2}, IncineratorAddr}
+	txCtx := &TransactionCtx{AccountKeys: keys, Accounts: TransactionAccounts{Accounts: []*accounts.Account{
+		{Lamports: 1_000_000_000},
+		{Lamports: 1_000_000_000},
+		{},
+	}}}
+	isWritable := []bool{true, false, true}
+
+	pre := TransactionRentStates(txCtx, isWritable, rent)
+	require.Len(t, pre, 3)
+	assert.Nil(t, pre[1])
+
+	// readonly accounts and the incinerator are not checked
+	txCtx.Accounts.Accounts[1].Lamports = 1
+	txCtx.Accounts.Accounts[2].Lamports = 1
+	require.NoError(t, VerifyRentStateChanges(pre, TransactionRentStates(txCtx, isWritable, rent), txCtx))
+
+	txCtx.Accounts.Accounts[0].Lamports = 1
+	err := VerifyRentStateChanges(pre, TransactionRentStates(txCtx, isWritable, rent), txCtx)
+	assert.Equal(t, TxErrInsufficientFundsForRent{AccountIndex: 0}, err)
+}
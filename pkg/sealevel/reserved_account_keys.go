@@ -0,0 +1,83 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+// reservedAccount is an account key that transactions may not write lock,
+// once its feature, if any, is active.
+type reservedAccount struct {
+	key     solana.PublicKey
+	feature *features.FeatureGate
+}
+
+// reservedAccounts are the builtin programs, sysvars and other accounts
+// reserved by the runtime.
+//
+// Based on solana_sdk::reserved_account_keys::RESERVED_ACCOUNTS.
+var reservedAccounts = []reservedAccount{
+	// builtin programs
+	{key: AddressLookupTableProgramAddr, feature: &features.AddNewReservedAccountKeys},
+	{key: BpfLoaderAddr},
+	{key: BpfLoaderDeprecatedAddr},
+	{key: BpfLoaderUpgradeableAddr},
+	{key: ComputeBudgetProgramAddr, feature: &features.AddNewReservedAccountKeys},
+	{key: ConfigProgramAddr},
+	{key: Ed25519PrecompileAddr, feature: &features.AddNewReservedAccountKeys},
+	{key: FeatureProgramAddr},
+	{key: LoaderV4Addr, feature: &features.AddNewReservedAccountKeys},
+	{key: Secp256kPrecompileAddr, feature: &features.AddNewReservedAccountKeys},
+	{key: Secp256r1PrecompileAddr, feature: &features.EnableSecp256r1Precompile},
+	{key: StakeProgramConfigAddr},
+	{key: StakeProgramAddr},
+	{key: SystemProgramAddr},
+	{key: VoteProgramAddr},
+	{key: ZkElGamalProofProgramAddr, feature: &features.AddNewReservedAccountKeys},
+	{key: ZkTokenProofProgramAddr, feature: &features.AddNewReservedAccountKeys},
+
+	// sysvars
+	{key: SysvarClockAddr},
+	{key: SysvarEpochRewardsAddr, feature: &features.AddNewReservedAccountKeys},
+	{key: SysvarEpochScheduleAddr},
+	{key: SysvarFeesAddr},
+	{key: SysvarInstructionsAddr},
+	{key: SysvarLastRestartSlotAddr, feature: &features.AddNewReservedAccountKeys},
+	{key: SysvarRecentBlockHashesAddr},
+	{key: SysvarRentAddr},
+	{key: SysvarRewardsAddr},
+	{key: SysvarSlotHashesAddr},
+	{key: SysvarSlotHistoryAddr},
+	{key: SysvarStakeHistoryAddr},
+
+	// other
+	{key: NativeLoaderAddr},
+	{key: SysvarOwnerAddr, feature: &features.AddNewReservedAccountKeys},
+}
+
+// ReservedAccountKeys is the set of account keys reserved by the runtime
+// under a given feature set. Transactions may reference reserved accounts,
+// but never write lock them.
+//
+// Based on solana_sdk::reserved_account_keys::ReservedAccountKeys.
+type ReservedAccountKeys struct {
+	active map[solana.PublicKey]bool
+}
+
+// NewReservedAccountKeys returns the account keys reserved under the given
+// features. A nil feature set reserves only the accounts that are not
+// feature gated.
+func NewReservedAccountKeys(f *features.Features) *ReservedAccountKeys {
+	reserved := &ReservedAccountKeys{active: make(map[solana.PublicKey]bool)}
+	for _, acct := range reservedAccounts {
+		if acct.feature == nil || (f != nil && f.IsActive(*acct.feature)) {
+			reserved.active[acct.key] = true
+		}
+	}
+	return reserved
+}
+
+// IsReserved returns whether the account key is reserved.
+func (reserved *ReservedAccountKeys) IsReserved(key solana.PublicKey) bool {
+	return reserved != nil && reserved.active[key]
+}
@@ -0,0 +1,46 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func TestReservedAccountKeys(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	reserved := NewReservedAccountKeys(f)
+	assert.True(t, reserved.IsReserved(SystemProgramAddr))
+	assert.True(t, reserved.IsReserved(SysvarClockAddr))
+	assert.False(t, reserved.IsReserved(ComputeBudgetProgramAddr))
+	assert.False(t, reserved.IsReserved(Secp256r1PrecompileAddr))
+	assert.False(t, reserved.IsReserved(solana.PublicKey{1}))
+
+	f.EnableFeature(features.AddNewReservedAccountKeys, 0)
+	reserved = NewReservedAccountKeys(f)
+	assert.True(t, reserved.IsReserved(ComputeBudgetProgramAddr))
+	assert.True(t, reserved.IsReserved(SysvarOwnerAddr))
+	assert.False(t, reserved.IsReserved(Secp256r1PrecompileAddr))
+
+	var noReserved *ReservedAccountKeys
+	assert.False(t, noReserved.IsReserved(SystemProgramAddr))
+}
+
+func TestResolveTransactionDemotesReservedAccounts(t *testing.T) {
+	payer := solana.PublicKey{1}
+	tx := &solana.Transaction{Message: solana.Message{
+		Header:      solana.MessageHeader{NumRequiredSignatures: 1},
+		AccountKeys: []solana.PublicKey{payer, SysvarClockAddr, SystemProgramAddr},
+		Instructions: []solana.CompiledInstruction{
+			{ProgramIDIndex: 2, Accounts: []uint16{0, 1}},
+		},
+	}}
+
+	resolved, err := ResolveTransaction(tx, accounts.NewMemAccounts(), 1, nil, NewReservedAccountKeys(features.NewFeaturesDefault()))
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, false}, resolved.IsWritable)
+	assert.Equal(t, []AccountMeta{{Pubkey: payer, IsSigner: true, IsWritable: true}, {Pubkey: SysvarClockAddr}}, resolved.Instructions[0].Accounts)
+}
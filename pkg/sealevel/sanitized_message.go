@@ -0,0 +1,113 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/zeebo/blake3"
+	"k8s.io/klog/v2"
+)
+
+// MaxTxInstructions is the most top-level instructions a transaction may
+// contain.
+const MaxTxInstructions = 64
+
+// maxTxAccountKeys is the most account keys a transaction may reference,
+// static and loaded alike, as they are indexed by a u8.
+const maxTxAccountKeys = 256
+
+// SanitizedMessage is a legacy or v0 message that passed the static checks
+// of NewSanitizedMessage, and may be resolved and executed.
+type SanitizedMessage struct {
+	Message *solana.Message
+}
+
+// NewSanitizedMessage performs the static checks of a legacy or v0 message,
+// those that do not depend on the state of the bank, before it is executed.
+//
+// Based on solana_sdk::message::legacy::Message::sanitize and
+// solana_sdk::message::v0::Message::sanitize.
+func NewSanitizedMessage(msg *solana.Message) (*SanitizedMessage, error) {
+	numStatic := len(msg.AccountKeys)
+	numSigned := int(msg.Header.NumRequiredSignatures)
+
+	// signing area and read-only non-signing area must not overlap
+	if numSigned+int(msg.Header.NumReadonlyUnsignedAccounts) > numStatic {
+		return nil, TxErrSanitizeFailure
+	}
+	// there should be at least 1 RW fee-payer account
+	if int(msg.Header.NumReadonlySignedAccounts) >= numSigned {
+		return nil, TxErrSanitizeFailure
+	}
+
+	numKeys := numStatic
+	if msg.IsVersioned() {
+		for _, lookup := range msg.GetAddressTableLookups() {
+			// each lookup table must be used to load at least one account
+			if len(lookup.WritableIndexes) == 0 && len(lookup.ReadonlyIndexes) == 0 {
+				return nil, TxErrSanitizeFailure
+			}
+			numKeys += len(lookup.WritableIndexes) + len(lookup.ReadonlyIndexes)
+		}
+		if numKeys > maxTxAccountKeys {
+			return nil, TxErrSanitizeFailure
+		}
+	}
+
+	if len(msg.Instructions) > MaxTxInstructions {
+		return nil, TxErrSanitizeFailure
+	}
+	for _, instr := range msg.Instructions {
+		// the program id must be a static key, and may not be the fee payer
+		if instr.ProgramIDIndex == 0 || int(instr.ProgramIDIndex) >= numStatic {
+			return nil, TxErrSanitizeFailure
+		}
+		for _, acctIdx := range instr.Accounts {
+			if int(acctIdx) >= numKeys {
+				return nil, TxErrSanitizeFailure
+			}
+		}
+	}
+
+	seen := make(map[solana.PublicKey]struct{}, numStatic)
+	for _, key := range msg.AccountKeys {
+		if _, ok := seen[key]; ok {
+			klog.Infof("account %s is loaded twice", key)
+			return nil, TxErrAccountLoadedTwice
+		}
+		seen[key] = struct{}{}
+	}
+
+	return &SanitizedMessage{Message: msg}, nil
+}
+
+// SanitizeTransaction checks that a transaction carries one signature for
+// each signer of its message, and sanitizes the message.
+//
+// Based on solana_sdk::transaction::versioned::VersionedTransaction::sanitize.
+func SanitizeTransaction(tx *solana.Transaction) (*SanitizedMessage, error) {
+	msg, err := NewSanitizedMessage(&tx.Message)
+	if err != nil {
+		return nil, err
+	}
+	numSigned := int(tx.Message.Header.NumRequiredSignatures)
+	if len(tx.Signatures) != numSigned || len(tx.Signatures) > len(tx.Message.AccountKeys) {
+		return nil, TxErrSanitizeFailure
+	}
+	return msg, nil
+}
+
+// MessageHash returns the hash of a serialized message, which identifies a
+// transaction independently of its signatures.
+//
+// Based on solana_sdk::message::VersionedMessage::hash_raw_message.
+func MessageHash(msg *solana.Message) ([32]byte, error) {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	hasher := blake3.New()
+	hasher.Write([]byte("solana-tx-message-v1"))
+	hasher.Write(data)
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	return hash, nil
+}
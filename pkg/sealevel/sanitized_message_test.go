@@ -0,0 +1,86 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSanitizedMessage(t *testing.T) {
+	newMsg := func() *solana.Message {
+		return &solana.Message{
+			Header:      solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+			AccountKeys: []solana.PublicKey{{1}, {2}, {3}},
+			Instructions: []solana.CompiledInstruction{
+				{ProgramIDIndex: 2, Accounts: []uint16{0, 1}},
+			},
+		}
+	}
+
+	sanitized, err := NewSanitizedMessage(newMsg())
+	require.NoError(t, err)
+	assert.Len(t, sanitized.Message.AccountKeys, 3)
+
+	// there must be a writable fee payer
+	msg := newMsg()
+	msg.Header.NumReadonlySignedAccounts = 1
+	_, err = NewSanitizedMessage(msg)
+	assert.Equal(t, TxErrSanitizeFailure, err)
+
+	// signers and readonly unsigned accounts may not overlap
+	msg = newMsg()
+	msg.Header.NumReadonlyUnsignedAccounts = 3
+	_, err = NewSanitizedMessage(msg)
+	assert.Equal(t, TxErrSanitizeFailure, err)
+
+	// the fee payer may not be invoked
+	msg = newMsg()
+	msg.Instructions[0].ProgramIDIndex = 0
+	_, err = NewSanitizedMessage(msg)
+	assert.Equal(t, TxErrSanitizeFailure, err)
+
+	msg = newMsg()
+	msg.Instructions[0].Accounts = []uint16{3}
+	_, err = NewSanitizedMessage(msg)
+	assert.Equal(t, TxErrSanitizeFailure, err)
+
+	msg = newMsg()
+	msg.AccountKeys[1] = msg.AccountKeys[0]
+	_, err = NewSanitizedMessage(msg)
+	assert.Equal(t, TxErrAccountLoadedTwice, err)
+
+	msg = newMsg()
+	msg.Instructions = make([]solana.CompiledInstruction, MaxTxInstructions+1)
+	for i := range msg.Instructions {
+		msg.Instructions[i].ProgramIDIndex = 2
+	}
+	_, err = NewSanitizedMessage(msg)
+	assert.Equal(t, TxErrSanitizeFailure, err)
+
+	// v0 messages may reference accounts loaded from lookup tables, but
+	// each lookup must load at least one
+	msg = newMsg()
+	msg.Instructions[0].Accounts = []uint16{0, 3}
+	msg.SetAddressTableLookups([]solana.MessageAddressTableLookup{{AccountKey: solana.PublicKey{4}, ReadonlyIndexes: []uint8{0}}})
+	_, err = NewSanitizedMessage(msg)
+	require.NoError(t, err)
+	msg.SetAddressTableLookups([]solana.MessageAddressTableLookup{{AccountKey: solana.PublicKey{4}}})
+	_, err = NewSanitizedMessage(msg)
+	assert.Equal(t, TxErrSanitizeFailure, err)
+}
+
+func TestSanitizeTransaction(t *testing.T) {
+	tx := &solana.Transaction{Message: solana.Message{
+		Header:       solana.MessageHeader{NumRequiredSignatures: 1},
+		AccountKeys:  []solana.PublicKey{{1}, {2}},
+		Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 1}},
+	}}
+	_, err := SanitizeTransaction(tx)
+	assert.Equal(t, TxErrSanitizeFailure, err)
+
+	tx.Signatures = []solana.Signature{{1}}
+	_, err = SanitizeTransaction(tx)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+package sealevel
+
+import (
+	"sync"
+)
+
+// Scheduler executes the transactions of a batch in parallel, as far as
+// their account locks allow, while committing them in batch order.
+//
+// Transactions are dispatched in batch order, each once it can take its
+// account locks, and hold them until they are committed. A transaction
+// therefore executes only after every earlier transaction it conflicts with
+// has been committed, and observes the same account state as under
+// sequential execution. Transactions are committed sequentially, in batch
+// order, so commits are deterministic regardless of execution order.
+type Scheduler struct {
+	numWorkers int
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	locks *AccountLocks
+}
+
+// NewScheduler returns a scheduler executing up to numWorkers transactions
+// at a time.
+func NewScheduler(numWorkers int) *Scheduler {
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	s := &Scheduler{numWorkers: numWorkers, locks: NewAccountLocks()}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Execute runs the transactions of a batch, given their account locks.
+// execute is called concurrently for transactions that do not conflict, and
+// commit is called from the calling goroutine, once per transaction, in
+// batch order, with the error returned by execute. Transactions with
+// invalid account locks are not executed, and committed with their
+// validation error. Execute returns once every transaction is committed.
+func (s *Scheduler) Execute(txLocks []TransactionAccountLocks, execute func(idx int) error, commit func(idx int, err error)) {
+	validationErrs := make([]error, len(txLocks))
+	results := make([]chan error, len(txLocks))
+	for idx := range txLocks {
+		validationErrs[idx] = txLocks[idx].Validate()
+		results[idx] = make(chan error, 1)
+	}
+
+	go func() {
+		workers := make(chan struct{}, s.numWorkers)
+		for idx := range txLocks {
+			if validationErrs[idx] != nil {
+				results[idx] <- validationErrs[idx]
+				continue
+			}
+
+			s.mu.Lock()
+			for s.locks.TryLock(&txLocks[idx]) != nil {
+				s.cond.Wait()
+			}
+			s.mu.Unlock()
+
+			workers <- struct{}{}
+			go func(idx int) {
+				results[idx] <- execute(idx)
+				<-workers
+			}(idx)
+		}
+	}()
+
+	for idx := range txLocks {
+		err := <-results[idx]
+		commit(idx, err)
+
+		if validationErrs[idx] == nil {
+			s.mu.Lock()
+			s.locks.Unlock(&txLocks[idx])
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		}
+	}
+}
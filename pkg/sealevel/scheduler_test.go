@@ -0,0 +1,110 @@
+package sealevel
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountLocks(t *testing.T) {
+	a, b, c := solana.PublicKey{1}, solana.PublicKey{2}, solana.PublicKey{3}
+	locks := NewAccountLocks()
+
+	tx1 := &TransactionAccountLocks{Writable: []solana.PublicKey{a}, Readonly: []solana.PublicKey{b}}
+	require.NoError(t, locks.TryLock(tx1))
+
+	// readers share locks, writers do not
+	tx2 := &TransactionAccountLocks{Writable: []solana.PublicKey{c}, Readonly: []solana.PublicKey{b}}
+	require.NoError(t, locks.TryLock(tx2))
+	assert.Equal(t, TxErrAccountInUse, locks.TryLock(&TransactionAccountLocks{Readonly: []solana.PublicKey{a}}))
+	assert.Equal(t, TxErrAccountInUse, locks.TryLock(&TransactionAccountLocks{Writable: []solana.PublicKey{b}}))
+
+	// a failed lock takes none of the locks
+	assert.Equal(t, TxErrAccountInUse, locks.TryLock(&TransactionAccountLocks{Writable: []solana.PublicKey{{4}, a}}))
+	require.NoError(t, locks.TryLock(&TransactionAccountLocks{Writable: []solana.PublicKey{{4}}}))
+
+	locks.Unlock(tx1)
+	assert.Equal(t, TxErrAccountInUse, locks.TryLock(&TransactionAccountLocks{Writable: []solana.PublicKey{b}}))
+	locks.Unlock(tx2)
+	require.NoError(t, locks.TryLock(&TransactionAccountLocks{Writable: []solana.PublicKey{a, b, c}}))
+}
+
+func TestTransactionAccountLocksValidate(t *testing.T) {
+	a, b := solana.PublicKey{1}, solana.PublicKey{2}
+	locks := NewTransactionAccountLocks([]solana.PublicKey{a, b}, []bool{true, false})
+	assert.Equal(t, TransactionAccountLocks{Writable: []solana.PublicKey{a}, Readonly: []solana.PublicKey{b}}, locks)
+	assert.NoError(t, locks.Validate())
+
+	locks = NewTransactionAccountLocks([]solana.PublicKey{a, b, a}, []bool{true, false, false})
+	assert.Equal(t, TxErrAccountLoadedTwice, locks.Validate())
+
+	locks = TransactionAccountLocks{Readonly: make([]solana.PublicKey, MaxTxAccountLocks+1)}
+	assert.Equal(t, TxErrTooManyAccountLocks, locks.Validate())
+}
+
+func TestSchedulerExecute(t *testing.T) {
+	shared, other := solana.PublicKey{1}, solana.PublicKey{2}
+
+	// every other transaction increments a shared counter, the rest only
+	// read an unrelated account
+	var txLocks []TransactionAccountLocks
+	for i := 0; i < 64; i++ {
+		if i%2 == 0 {
+			txLocks = append(txLocks, TransactionAccountLocks{Writable: []solana.PublicKey{shared}})
+		} else {
+			txLocks = append(txLocks, TransactionAccountLocks{Readonly: []solana.PublicKey{other}})
+		}
+	}
+	txLocks = append(txLocks, TransactionAccountLocks{Writable: []solana.PublicKey{shared, shared}})
+
+	var mu sync.Mutex
+	var counter uint64
+	observed := make([]uint64, len(txLocks))
+	var running, maxRunning int64
+
+	execute := func(idx int) error {
+		n := atomic.AddInt64(&running, 1)
+		for {
+			highest := atomic.LoadInt64(&maxRunning)
+			if n <= highest || atomic.CompareAndSwapInt64(&maxRunning, highest, n) {
+				break
+			}
+		}
+		defer atomic.AddInt64(&running, -1)
+
+		mu.Lock()
+		defer mu.Unlock()
+		observed[idx] = counter
+		return nil
+	}
+
+	var commitOrder []int
+	var commitErrs []error
+	commit := func(idx int, err error) {
+		commitOrder = append(commitOrder, idx)
+		commitErrs = append(commitErrs, err)
+		if err == nil && idx%2 == 0 {
+			mu.Lock()
+			counter++
+			mu.Unlock()
+		}
+	}
+
+	NewScheduler(8).Execute(txLocks, execute, commit)
+
+	require.Len(t, commitOrder, len(txLocks))
+	for i, idx := range commitOrder {
+		assert.Equal(t, i, idx)
+	}
+	assert.Equal(t, TxErrAccountLoadedTwice, commitErrs[len(txLocks)-1])
+
+	// writers of the shared account observe the commits of all earlier writers
+	for i := 0; i < 64; i += 2 {
+		assert.Equal(t, uint64(i/2), observed[i])
+	}
+	assert.LessOrEqual(t, atomic.LoadInt64(&maxRunning), int64(8))
+}
@@ -3,7 +3,7 @@ package sealevel
 import (
 	"bytes"
 
-	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
 	"go.firedancer.io/radiance/pkg/sbpf"
 )
 
@@ -15,21 +15,19 @@ func transactionCtx(vm sbpf.VM) *TransactionCtx {
 	return vm.VMContext().(*ExecutionCtx).TransactionContext
 }
 
-func getAccounts(vm sbpf.VM) *accounts.Accounts {
-	return vm.VMContext().(*ExecutionCtx).GlobalCtx.Accounts
-}
-
 func (t *TransactionCtx) newVMOpts(params *Params) *sbpf.VMOpts {
 	execution := &ExecutionCtx{
-		Log: new(LogRecorder),
+		Log:          NewLogCollector(LogCollectorDefaultBytesLimit),
+		ComputeMeter: cu.NewComputeMeter(CUMaxComputeUnitLimit),
 	}
 	var buf bytes.Buffer
 	params.Serialize(&buf)
 	return &sbpf.VMOpts{
-		HeapSize: 32 * 1024,
-		Syscalls: Syscalls(&params.Features),
-		Context:  execution,
-		MaxCU:    1_400_000,
-		Input:    buf.Bytes(),
+		HeapSize:     CUHeapSizeDefault,
+		Syscalls:     Syscalls(&params.Features, false),
+		Context:      execution,
+		MaxCU:        CUMaxComputeUnitLimit,
+		Input:        buf.Bytes(),
+		ComputeMeter: &execution.ComputeMeter,
 	}
 }
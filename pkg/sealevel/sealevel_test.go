@@ -39,10 +39,10 @@ func TestExecute_Memo(t *testing.T) {
 	interpreter := sbpf.NewInterpreter(nil, program, opts)
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	assert.NoError(t, err)
 
-	logs := opts.Context.(*ExecutionCtx).Log.(*LogRecorder).Logs
+	logs := opts.Context.(*ExecutionCtx).Log.(*LogCollector).Messages
 	assert.Equal(t, logs, []string{
 		`Program log: Memo (len 3): "Bla"`,
 	})
@@ -75,15 +75,52 @@ func TestInterpreter_Noop(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.NoError(t, err)
 
 	assert.Equal(t, log.Logs, []string{
 		"Program log: entrypoint\x00",
-		"Program log: 0x1, 0x2, 0x3, 0x4, 0x5\n",
+		"Program log: 0x1, 0x2, 0x3, 0x4, 0x5",
 	})
 }
 
+func TestInterpreter_ComputeMeter(t *testing.T) {
+	loader, err := loader.NewLoaderFromBytes(fixtures.Load(t, "sbpf", "noop.so"))
+	require.NoError(t, err)
+
+	program, err := loader.Load()
+	require.NoError(t, err)
+	require.NoError(t, program.Verify())
+
+	syscalls := sbpf.NewSyscallRegistry()
+	syscalls.Register("log", SyscallLog)
+	syscalls.Register("log_64", SyscallLog64)
+
+	// every instruction and syscall is charged to the shared meter
+	execCtx := &ExecutionCtx{Log: new(LogRecorder), ComputeMeter: cu.NewComputeMeter(10000)}
+	interpreter := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{
+		HeapSize:     32 * 1024,
+		Syscalls:     syscalls,
+		Context:      execCtx,
+		ComputeMeter: &execCtx.ComputeMeter,
+	})
+	_, err = interpreter.Run()
+	require.NoError(t, err)
+	used := 10000 - execCtx.ComputeMeter.Remaining()
+	assert.Greater(t, used, uint64(2*CUSyscallBaseCost))
+
+	// running out of compute units aborts the program
+	execCtx = &ExecutionCtx{Log: new(LogRecorder), ComputeMeter: cu.NewComputeMeter(used - 1)}
+	interpreter = sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{
+		HeapSize:     32 * 1024,
+		Syscalls:     syscalls,
+		Context:      execCtx,
+		ComputeMeter: &execCtx.ComputeMeter,
+	})
+	_, err = interpreter.Run()
+	assert.ErrorIs(t, err, sbpf.ExcOutOfCU)
+}
+
 // The TestInterpreter_Memcpy_Strings_Match tests that memcpy works as expected
 // by running an SBPF program that uses the memcpy syscall to copy a string
 // literal to a stack buffer, before testing for equality using memcmp.
@@ -109,12 +146,13 @@ func TestInterpreter_Memcpy_Strings_Match(t *testing.T) {
 	interpreter := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{
 		HeapSize: 32 * 1024,
 		Input:    nil,
+		MaxCU:    10000,
 		Syscalls: syscalls,
 		Context:  &ExecutionCtx{Log: &log, ComputeMeter: cu.NewComputeMeterDefault()},
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	assert.Equal(t, log.Logs, []string{
 		"Program log: Strings matched after copy.",
 	})
@@ -153,7 +191,7 @@ func TestInterpreter_Memcpy_Do_Not_Match(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	assert.Equal(t, log.Logs, []string{
 		"Program log: Strings did not match after copy.",
 	})
@@ -191,7 +229,7 @@ func TestInterpreter_Memmove_Strings_Match(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	assert.Equal(t, log.Logs, []string{
 		"Program log: Strings matched after copy.",
 	})
@@ -230,7 +268,7 @@ func TestInterpreter_Memmove_Do_Not_Match(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	assert.Equal(t, log.Logs, []string{
 		"Program log: Strings did not match after copy.",
 	})
@@ -267,7 +305,7 @@ func TestInterpreter_Memcpy_Overlapping(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 
 	// expecting an error here because the src and dst are overlapping in the
 	// program being run.
@@ -305,7 +343,7 @@ func TestInterpreter_Memcmp_Matches(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.NoError(t, err)
 
 	assert.Equal(t, log.Logs, []string{
@@ -346,7 +384,7 @@ func TestInterpreter_Memcmp_Does_Not_Match(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.NoError(t, err)
 
 	assert.Equal(t, log.Logs, []string{
@@ -387,7 +425,7 @@ func TestInterpreter_Memset_Check_Correct(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.NoError(t, err)
 
 	assert.Equal(t, log.Logs, []string{
@@ -427,7 +465,7 @@ func TestInterpreter_Sha256(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.NoError(t, err)
 
 	assert.Equal(t, log.Logs, []string{
@@ -468,7 +506,7 @@ func TestInterpreter_Blake3(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.NoError(t, err)
 
 	assert.Equal(t, log.Logs, []string{
@@ -509,7 +547,7 @@ func TestInterpreter_Keccak256(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.NoError(t, err)
 
 	assert.Equal(t, log.Logs, []string{
@@ -551,7 +589,7 @@ func TestInterpreter_CreateProgramAddress(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.NoError(t, err)
 
 	assert.Equal(t, log.Logs, []string{
@@ -596,7 +634,7 @@ func TestInterpreter_TryFindProgramAddress(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.NoError(t, err)
 
 	assert.Equal(t, log.Logs, []string{
@@ -638,7 +676,7 @@ func TestInterpreter_TestPanic(t *testing.T) {
 	})
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	require.Error(t, err)
 	assert.Equal(t, err.Error(), "exception at 16: SBF program Panicked in some_file_1234.c at 1337:10")
 }
@@ -669,10 +707,10 @@ func (e *executeCase) run(t *testing.T) {
 	interpreter := sbpf.NewInterpreter(nil, program, opts)
 	require.NotNil(t, interpreter)
 
-	err = interpreter.Run()
+	_, err = interpreter.Run()
 	assert.NoError(t, err)
 
-	logs := opts.Context.(*ExecutionCtx).Log.(*LogRecorder).Logs
+	logs := opts.Context.(*ExecutionCtx).Log.(*LogCollector).Messages
 	assert.Equal(t, logs, e.Logs)
 }
 
@@ -0,0 +1,86 @@
+package sealevel
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"math/big"
+)
+
+const Secp256r1CompressedPubkeySerializedSize = 33
+const Secp256r1FieldSize = 32
+const Secp256r1MaxSignatures = 8
+
+var (
+	secp256r1Order     = elliptic.P256().Params().N
+	secp256r1HalfOrder = new(big.Int).Rsh(secp256r1Order, 1)
+)
+
+// Secp256r1ProgramExecute verifies the P-256 ECDSA signatures of a secp256r1
+// precompile instruction, as specified by SIMD-0075. The signature offsets
+// have the same layout as those of the ed25519 precompile. Public keys are
+// SEC1 compressed, signatures are r || s over the SHA-256 digest of the
+// message, and s must be in the lower half of the curve order, so that
+// signatures are not malleable.
+func Secp256r1ProgramExecute(data []byte, instructionDatas [][]byte) int {
+	dataLen := uint64(len(data))
+
+	if dataLen < SignatureOffsetStarts {
+		return PrecompileErrCodeInvalidInstructionDataSize
+	}
+
+	numSignatures := uint64(data[0])
+	if numSignatures == 0 || numSignatures > Secp256r1MaxSignatures {
+		return PrecompileErrCodeInvalidInstructionDataSize
+	}
+
+	expectedDataSize := (numSignatures * SignatureOffsetsSerializedSize) + SignatureOffsetStarts
+	if dataLen < expectedDataSize {
+		return PrecompileErrCodeInvalidInstructionDataSize
+	}
+
+	for count := uint64(0); count < numSignatures; count++ {
+		start := (count * SignatureOffsetsSerializedSize) + SignatureOffsetStarts
+		end := start + SignatureOffsetsSerializedSize
+
+		var offsets Ed25519SignatureOffsets
+		err := offsets.UnmarshalWithDecoder(bytes.NewReader(data[start:end]))
+		if err != nil {
+			return PrecompileErrCodeInvalidDataOffsets
+		}
+
+		signature, errCode := ed25519GetDataSlice(data, instructionDatas, offsets.SignatureInstructionIndex, offsets.SignatureOffset, SignatureSerializedSize)
+		if errCode != InstrErrCodeSuccess {
+			return errCode
+		}
+
+		pubkey, errCode := ed25519GetDataSlice(data, instructionDatas, offsets.PublicKeyInstructionIndex, offsets.PublicKeyOffset, Secp256r1CompressedPubkeySerializedSize)
+		if errCode != InstrErrCodeSuccess {
+			return errCode
+		}
+
+		msg, errCode := ed25519GetDataSlice(data, instructionDatas, offsets.MessageInstructionIndex, offsets.MessageDataOffset, uint64(offsets.MessageDataSize))
+		if errCode != InstrErrCodeSuccess {
+			return errCode
+		}
+
+		r := new(big.Int).SetBytes(signature[:Secp256r1FieldSize])
+		s := new(big.Int).SetBytes(signature[Secp256r1FieldSize:])
+		if r.Sign() == 0 || r.Cmp(secp256r1Order) >= 0 || s.Sign() == 0 || s.Cmp(secp256r1HalfOrder) > 0 {
+			return PrecompileErrCodeInvalidSignature
+		}
+
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), pubkey)
+		if x == nil {
+			return PrecompileErrCodeInvalidPublicKey
+		}
+
+		digest := sha256.Sum256(msg)
+		if !ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, digest[:], r, s) {
+			return PrecompileErrCodeInvalidSignature
+		}
+	}
+
+	return InstrErrCodeSuccess
+}
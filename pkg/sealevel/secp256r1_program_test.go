@@ -0,0 +1,84 @@
+package sealevel
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secp256r1InstrData builds a single signature secp256r1 instruction, with
+// the signature, public key and message stored in the instruction itself.
+func secp256r1InstrData(signature, pubkey, msg []byte) []byte {
+	data := make([]byte, SignatureOffsetStarts+SignatureOffsetsSerializedSize)
+	data[0] = 1
+
+	sigOffset := len(data)
+	pubkeyOffset := sigOffset + len(signature)
+	msgOffset := pubkeyOffset + len(pubkey)
+
+	offsets := data[SignatureOffsetStarts:]
+	binary.LittleEndian.PutUint16(offsets[0:], uint16(sigOffset))
+	binary.LittleEndian.PutUint16(offsets[2:], math.MaxUint16)
+	binary.LittleEndian.PutUint16(offsets[4:], uint16(pubkeyOffset))
+	binary.LittleEndian.PutUint16(offsets[6:], math.MaxUint16)
+	binary.LittleEndian.PutUint16(offsets[8:], uint16(msgOffset))
+	binary.LittleEndian.PutUint16(offsets[10:], uint16(len(msg)))
+	binary.LittleEndian.PutUint16(offsets[12:], math.MaxUint16)
+
+	data = append(data, signature...)
+	data = append(data, pubkey...)
+	return append(data, msg...)
+}
+
+func TestSecp256r1ProgramExecute(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	pubkey := elliptic.MarshalCompressed(elliptic.P256(), key.X, key.Y)
+
+	msg := []byte("hello passkeys")
+	digest := sha256.Sum256(msg)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	if s.Cmp(secp256r1HalfOrder) > 0 {
+		s.Sub(secp256r1Order, s)
+	}
+	signature := make([]byte, SignatureSerializedSize)
+	r.FillBytes(signature[:Secp256r1FieldSize])
+	s.FillBytes(signature[Secp256r1FieldSize:])
+
+	assert.Equal(t, InstrErrCodeSuccess, Secp256r1ProgramExecute(secp256r1InstrData(signature, pubkey, msg), nil))
+
+	// wrong message
+	assert.Equal(t, PrecompileErrCodeInvalidSignature, Secp256r1ProgramExecute(secp256r1InstrData(signature, pubkey, []byte("hello world")), nil))
+
+	// high s signatures are malleable, and rejected
+	highS := append([]byte{}, signature...)
+	new(big.Int).Sub(secp256r1Order, s).FillBytes(highS[Secp256r1FieldSize:])
+	assert.Equal(t, PrecompileErrCodeInvalidSignature, Secp256r1ProgramExecute(secp256r1InstrData(highS, pubkey, msg), nil))
+
+	// invalid public key
+	badPubkey := append([]byte{}, pubkey...)
+	badPubkey[0] = 0x05
+	assert.Equal(t, PrecompileErrCodeInvalidPublicKey, Secp256r1ProgramExecute(secp256r1InstrData(signature, badPubkey, msg), nil))
+
+	// no signatures
+	assert.Equal(t, PrecompileErrCodeInvalidInstructionDataSize, Secp256r1ProgramExecute([]byte{0, 0}, nil))
+
+	// offsets past the end of the instruction
+	data := secp256r1InstrData(signature, pubkey, msg)
+	assert.Equal(t, PrecompileErrCodeInvalidDataOffsets, Secp256r1ProgramExecute(data[:len(data)-1], nil))
+
+	// signature in another instruction
+	data = secp256r1InstrData(signature, pubkey, msg)
+	binary.LittleEndian.PutUint16(data[SignatureOffsetStarts+2:], 1)
+	assert.Equal(t, PrecompileErrCodeInvalidDataOffsets, Secp256r1ProgramExecute(data, [][]byte{{}}))
+	assert.Equal(t, InstrErrCodeSuccess, Secp256r1ProgramExecute(data, [][]byte{{}, data}))
+}
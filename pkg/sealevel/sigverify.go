@@ -0,0 +1,68 @@
+package sealevel
+
+import (
+	"crypto/ed25519"
+	"runtime"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"k8s.io/klog/v2"
+)
+
+// VerifyTransactionSignatures verifies the ed25519 signatures of a
+// transaction against its serialized message. The transaction must carry
+// exactly one signature for each signer of its message, in the order of the
+// signer account keys.
+//
+// Based on solana_sdk::transaction::versioned::VersionedTransaction::verify_and_hash_message.
+func VerifyTransactionSignatures(tx *solana.Transaction) error {
+	numSigned := int(tx.Message.Header.NumRequiredSignatures)
+	if len(tx.Signatures) != numSigned || numSigned > len(tx.Message.AccountKeys) {
+		return TxErrSanitizeFailure
+	}
+
+	msg, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return TxErrSanitizeFailure
+	}
+
+	for i, sig := range tx.Signatures {
+		signer := tx.Message.AccountKeys[i]
+		if !ed25519.Verify(signer[:], msg, sig[:]) {
+			klog.Infof("invalid signature by %s", signer)
+			return TxErrSignatureFailure
+		}
+	}
+	return nil
+}
+
+// VerifyTransactionsSignatures verifies the signatures of a batch of
+// transactions, spread over all CPUs. The result holds the error of each
+// transaction, nil if all of its signatures are valid.
+func VerifyTransactionsSignatures(txs []*solana.Transaction) []error {
+	errs := make([]error, len(txs))
+
+	numWorkers := runtime.NumCPU()
+	if numWorkers > len(txs) {
+		numWorkers = len(txs)
+	}
+
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range next {
+				errs[idx] = VerifyTransactionSignatures(txs[idx])
+			}
+		}()
+	}
+	for idx := range txs {
+		next <- idx
+	}
+	close(next)
+	wg.Wait()
+
+	return errs
+}
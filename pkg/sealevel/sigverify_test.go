@@ -0,0 +1,41 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyTransactionSignatures(t *testing.T) {
+	signer := solana.NewWallet().PrivateKey
+	newTx := func() *solana.Transaction {
+		tx := &solana.Transaction{Message: solana.Message{
+			Header:       solana.MessageHeader{NumRequiredSignatures: 1},
+			AccountKeys:  []solana.PublicKey{signer.PublicKey(), {2}},
+			Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 1, Data: []byte{1}}},
+		}}
+		msg, err := tx.Message.MarshalBinary()
+		require.NoError(t, err)
+		sig, err := signer.Sign(msg)
+		require.NoError(t, err)
+		tx.Signatures = []solana.Signature{sig}
+		return tx
+	}
+
+	valid := newTx()
+	assert.NoError(t, VerifyTransactionSignatures(valid))
+
+	tampered := newTx()
+	tampered.Message.Instructions[0].Data = []byte{2}
+	assert.Equal(t, TxErrSignatureFailure, VerifyTransactionSignatures(tampered))
+
+	unsigned := newTx()
+	unsigned.Signatures = nil
+	assert.Equal(t, TxErrSanitizeFailure, VerifyTransactionSignatures(unsigned))
+
+	errs := VerifyTransactionsSignatures([]*solana.Transaction{valid, tampered, unsigned})
+	assert.Equal(t, []error{nil, TxErrSignatureFailure, TxErrSanitizeFailure}, errs)
+	assert.Empty(t, VerifyTransactionsSignatures(nil))
+}
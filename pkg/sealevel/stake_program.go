@@ -323,6 +323,7 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			return nil, err
 		}
 		if acct.Owner() != StakeProgramAddr {
+			acct.Drop()
 			return nil, InstrErrInvalidAccountOwner
 		}
 		return acct, nil
@@ -352,14 +353,14 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
-			rent := ReadRentSysvar(&execCtx.Accounts)
-			err = checkAcctForRentSysvar(txCtx, instrCtx, 1)
+			rent, err := getRentSysvarWithAccountCheck(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
 			}
 
-			err = StakeProgramInitialize(me, initialize.Authorized, initialize.Lockup, rent, execCtx.GlobalCtx.Features)
+			return StakeProgramInitialize(me, initialize.Authorized, initialize.Lockup, *rent, execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeAuthorize:
@@ -374,9 +375,9 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 1)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
 			}
@@ -391,7 +392,7 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			err = StakeProgramAuthorize(me, signers, authorize.Pubkey, authorize.StakeAuthorize, clock, custodianPubkey, execCtx.GlobalCtx.Features)
+			return StakeProgramAuthorize(me, signers, authorize.Pubkey, authorize.StakeAuthorize, *clock, custodianPubkey, execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeAuthorizeWithSeed:
@@ -406,14 +407,14 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
 			err = instrCtx.CheckNumOfInstructionAccounts(2)
 			if err != nil {
 				return err
 			}
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 1)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
 			}
@@ -423,29 +424,28 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			err = StakeProgramAuthorizeWithSeed(txCtx, instrCtx, me, 1, authorizeWithSeed.AuthoritySeed, authorizeWithSeed.AuthorityOwner, authorizeWithSeed.NewAuthorizedPubkey, authorizeWithSeed.StakeAuthorize, clock, custodianPubkey, execCtx.GlobalCtx.Features)
+			return StakeProgramAuthorizeWithSeed(txCtx, instrCtx, me, 1, authorizeWithSeed.AuthoritySeed, authorizeWithSeed.AuthorityOwner, authorizeWithSeed.NewAuthorizedPubkey, authorizeWithSeed.StakeAuthorize, *clock, custodianPubkey, execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeDelegateStake:
 		{
-			_, err := getStakeAccount()
+			me, err := getStakeAccount()
 			if err != nil {
 				return err
 			}
+			me.Drop()
 
 			err = instrCtx.CheckNumOfInstructionAccounts(2)
 			if err != nil {
 				return err
 			}
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 2)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 2)
 			if err != nil {
 				return err
 			}
 
-			stakeHistory := ReadStakeHistorySysvar(&execCtx.Accounts)
-			err = checkAcctForStakeHistorySysvar(txCtx, instrCtx, 3)
+			stakeHistory, err := getStakeHistorySysvarWithAccountCheck(execCtx, instrCtx, 3)
 			if err != nil {
 				return err
 			}
@@ -460,17 +460,19 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 				if err != nil {
 					return err
 				}
-				if configAcct.Key() != StakeProgramConfigAddr {
+				configKey, configData := configAcct.Key(), configAcct.Data()
+				configAcct.Drop()
+				if configKey != StakeProgramConfigAddr {
 					return InstrErrInvalidArgument
 				}
 
-				_, err = unmarshalStakeConfig(configAcct.Data())
+				_, err = unmarshalStakeConfig(configData)
 				if err != nil {
 					return InstrErrInvalidArgument
 				}
 			}
 
-			err = StakeProgramDelegate(execCtx, txCtx, instrCtx, 0, 1, clock, stakeHistory, signers, execCtx.GlobalCtx.Features)
+			return StakeProgramDelegate(execCtx, txCtx, instrCtx, 0, 1, *clock, *stakeHistory, signers, execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeSplit:
@@ -481,44 +483,44 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 				return InstrErrInvalidInstructionData
 			}
 
-			_, err := getStakeAccount()
+			me, err := getStakeAccount()
 			if err != nil {
 				return err
 			}
+			me.Drop()
 
 			err = instrCtx.CheckNumOfInstructionAccounts(2)
 			if err != nil {
 				return err
 			}
 
-			err = StakeProgramSplit(execCtx, txCtx, instrCtx, 0, split.Lamports, 1, signers)
+			return StakeProgramSplit(execCtx, txCtx, instrCtx, 0, split.Lamports, 1, signers)
 		}
 
 	case StakeProgramInstrTypeMerge:
 		{
-			_, err = getStakeAccount()
+			me, err := getStakeAccount()
 			if err != nil {
 				return err
 			}
+			me.Drop()
 
 			err = instrCtx.CheckNumOfInstructionAccounts(2)
 			if err != nil {
 				return err
 			}
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 2)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 2)
 			if err != nil {
 				return err
 			}
 
-			stakeHistory := ReadStakeHistorySysvar(&execCtx.Accounts)
-			err = checkAcctForStakeHistorySysvar(txCtx, instrCtx, 3)
+			stakeHistory, err := getStakeHistorySysvarWithAccountCheck(execCtx, instrCtx, 3)
 			if err != nil {
 				return err
 			}
 
-			err = StakeProgramMerge(execCtx, txCtx, instrCtx, 0, 1, clock, stakeHistory, signers)
+			return StakeProgramMerge(execCtx, txCtx, instrCtx, 0, 1, *clock, *stakeHistory, signers)
 		}
 
 	case StakeProgramInstrTypeWithdraw:
@@ -529,24 +531,23 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 				return InstrErrInvalidInstructionData
 			}
 
-			_, err := getStakeAccount()
+			me, err := getStakeAccount()
 			if err != nil {
 				return err
 			}
+			me.Drop()
 
 			err = instrCtx.CheckNumOfInstructionAccounts(2)
 			if err != nil {
 				return err
 			}
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 2)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 2)
 			if err != nil {
 				return err
 			}
 
-			stakeHistory := ReadStakeHistorySysvar(&execCtx.Accounts)
-			err = checkAcctForStakeHistorySysvar(txCtx, instrCtx, 3)
+			stakeHistory, err := getStakeHistorySysvarWithAccountCheck(execCtx, instrCtx, 3)
 			if err != nil {
 				return err
 			}
@@ -562,7 +563,7 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 				custodianIndex = &i
 			}
 
-			err = StakeProgramWithdraw(txCtx, instrCtx, 0, withdraw.Lamports, 1, clock, stakeHistory, 4, custodianIndex, newWarmupCooldownRateEpoch(execCtx), execCtx.GlobalCtx.Features)
+			return StakeProgramWithdraw(txCtx, instrCtx, 0, withdraw.Lamports, 1, *clock, *stakeHistory, 4, custodianIndex, newWarmupCooldownRateEpoch(execCtx), execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeDeactivate:
@@ -571,14 +572,14 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 1)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
 			}
 
-			err = StakeProgramDeactivate(execCtx, me, clock, signers)
+			return StakeProgramDeactivate(execCtx, me, *clock, signers)
 		}
 
 	case StakeProgramInstrTypeSetLockup:
@@ -593,10 +594,14 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
+			clock, err := execCtx.SysvarCache.Clock()
+			if err != nil {
+				return err
+			}
 
-			err = StakeProgramSetLockup(me, lockup, signers, clock, execCtx.GlobalCtx.Features)
+			return StakeProgramSetLockup(me, lockup, signers, *clock, execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeInitializeChecked:
@@ -605,6 +610,7 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
 			err = instrCtx.CheckNumOfInstructionAccounts(4)
 			if err != nil {
@@ -641,13 +647,12 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 
 			authorized := Authorized{Staker: stakerPubkey, Withdrawer: withdrawerPubkey}
 
-			rent := ReadRentSysvar(&execCtx.Accounts)
-			err = checkAcctForRentSysvar(txCtx, instrCtx, 1)
+			rent, err := getRentSysvarWithAccountCheck(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
 			}
 
-			err = StakeProgramInitialize(me, authorized, StakeLockup{}, rent, execCtx.GlobalCtx.Features)
+			return StakeProgramInitialize(me, authorized, StakeLockup{}, *rent, execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeAuthorizeChecked:
@@ -662,9 +667,9 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 1)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
 			}
@@ -697,7 +702,7 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			err = StakeProgramAuthorize(me, signers, authorizedPubkey, authorizeChecked.StakeAuthorize, clock, custodianPubkey, execCtx.GlobalCtx.Features)
+			return StakeProgramAuthorize(me, signers, authorizedPubkey, authorizeChecked.StakeAuthorize, *clock, custodianPubkey, execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeAuthorizeCheckedWithSeed:
@@ -712,14 +717,14 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
 			err = instrCtx.CheckNumOfInstructionAccounts(2)
 			if err != nil {
 				return err
 			}
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 2)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 2)
 			if err != nil {
 				return err
 			}
@@ -752,7 +757,7 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			err = StakeProgramAuthorizeWithSeed(txCtx, instrCtx, me, 1, authorizeCheckedWithSeed.AuthoritySeed, authorizeCheckedWithSeed.AuthorityOwner, authorizedPubkey, authorizeCheckedWithSeed.StakeAuthorize, clock, custodianPubkey, execCtx.GlobalCtx.Features)
+			return StakeProgramAuthorizeWithSeed(txCtx, instrCtx, me, 1, authorizeCheckedWithSeed.AuthoritySeed, authorizeCheckedWithSeed.AuthorityOwner, authorizedPubkey, authorizeCheckedWithSeed.StakeAuthorize, *clock, custodianPubkey, execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeSetLockupChecked:
@@ -767,16 +772,20 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
 			custodianPubkey, err := getOptionalPubkey(txCtx, instrCtx, 2, true)
 			if err != nil {
 				return err
 			}
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
+			clock, err := execCtx.SysvarCache.Clock()
+			if err != nil {
+				return err
+			}
 			lockup := StakeInstrSetLockup{UnixTimestamp: setLockupChecked.UnixTimestamp, Epoch: setLockupChecked.Epoch, Custodian: custodianPubkey}
 
-			err = StakeProgramSetLockup(me, lockup, signers, clock, execCtx.GlobalCtx.Features)
+			return StakeProgramSetLockup(me, lockup, signers, *clock, execCtx.GlobalCtx.Features)
 		}
 
 	case StakeProgramInstrTypeGetMinimumDelegation:
@@ -793,15 +802,19 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
 			err = instrCtx.CheckNumOfInstructionAccounts(3)
 			if err != nil {
 				return err
 			}
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
+			clock, err := execCtx.SysvarCache.Clock()
+			if err != nil {
+				return err
+			}
 
-			err = StakeProgramDeactivateDelinquent(execCtx, txCtx, instrCtx, me, 1, 2, clock.Epoch)
+			return StakeProgramDeactivateDelinquent(execCtx, txCtx, instrCtx, me, 1, 2, clock.Epoch)
 		}
 
 	case StakeProgramInstrTypeRedelegate:
@@ -810,6 +823,7 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer me.Drop()
 
 			if execCtx.GlobalCtx.Features.IsActive(features.StakeRedelegateInstruction) {
 				err = instrCtx.CheckNumOfInstructionAccounts(3)
@@ -822,17 +836,19 @@ func StakeProgramExecute(execCtx *ExecutionCtx) error {
 					if err != nil {
 						return err
 					}
-					if configAcct.Key() != StakeProgramConfigAddr {
+					configKey, configData := configAcct.Key(), configAcct.Data()
+					configAcct.Drop()
+					if configKey != StakeProgramConfigAddr {
 						return InstrErrInvalidArgument
 					}
 
-					_, err = unmarshalStakeConfig(configAcct.Data())
+					_, err = unmarshalStakeConfig(configData)
 					if err != nil {
 						return InstrErrInvalidArgument
 					}
 				}
 
-				err = StakeProgramRedelegate(execCtx, txCtx, instrCtx, me, 1, 2, signers)
+				return StakeProgramRedelegate(execCtx, txCtx, instrCtx, me, 1, 2, signers)
 
 			} else {
 				return InstrErrInvalidInstructionData
@@ -978,6 +994,7 @@ func StakeProgramDelegate(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx
 	if err != nil {
 		return err
 	}
+	defer voteAcct.Drop()
 
 	if voteAcct.Owner() != VoteProgramAddr {
 		return InstrErrIncorrectProgramId
@@ -985,11 +1002,13 @@ func StakeProgramDelegate(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx
 
 	votePubkey := voteAcct.Key()
 	versionedVoteState, voteUnmarshalErr := unmarshalVersionedVoteState(voteAcct.Data())
+	voteAcct.Drop()
 
 	stakeAcct, err := instrCtx.BorrowInstructionAccount(txCtx, stakeAcctIdx)
 	if err != nil {
 		return err
 	}
+	defer stakeAcct.Drop()
 
 	stakeState, err := unmarshalStakeState(stakeAcct.Data())
 	if err != nil {
@@ -1068,14 +1087,18 @@ func validateSplitAmount(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx
 	if err != nil {
 		return validatedSplitInfo{}, err
 	}
+	defer srcAcct.Drop()
 	srcLamports := srcAcct.Lamports()
+	srcAcct.Drop()
 
 	dstAcct, err := instrCtx.BorrowInstructionAccount(txCtx, destAcctIdx)
 	if err != nil {
 		return validatedSplitInfo{}, err
 	}
+	defer dstAcct.Drop()
 	dstLamports := dstAcct.Lamports()
 	dstDataLen := uint64(len(dstAcct.Data()))
+	dstAcct.Drop()
 
 	if lamports == 0 {
 		return validatedSplitInfo{}, InstrErrInsufficientFunds
@@ -1091,7 +1114,10 @@ func validateSplitAmount(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx
 		return validatedSplitInfo{}, InstrErrInsufficientFunds
 	}
 
-	rent := ReadRentSysvar(&execCtx.Accounts)
+	rent, err := execCtx.SysvarCache.Rent()
+	if err != nil {
+		return validatedSplitInfo{}, err
+	}
 	dstRentExemptReserve := rent.MinimumBalance(dstDataLen)
 
 	if execCtx.GlobalCtx.Features.IsActive(features.RequireRentExemptSplitDestination) &&
@@ -1113,6 +1139,7 @@ func StakeProgramSplit(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *I
 	if err != nil {
 		return err
 	}
+	defer split.Drop()
 
 	if split.Owner() != StakeProgramAddr {
 		return InstrErrIncorrectProgramId
@@ -1132,11 +1159,13 @@ func StakeProgramSplit(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *I
 	}
 
 	splitLamportBalance := split.Lamports()
+	split.Drop()
 
 	stakeAcct, err := instrCtx.BorrowInstructionAccount(txCtx, stakeAcctIdx)
 	if err != nil {
 		return err
 	}
+	defer stakeAcct.Drop()
 
 	if lamports > stakeAcct.Lamports() {
 		return InstrErrInsufficientFunds
@@ -1146,6 +1175,7 @@ func StakeProgramSplit(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *I
 	if err != nil {
 		return err
 	}
+	stakeAcct.Drop()
 
 	switch stakeState.Status {
 	case StakeStateV2StatusStake:
@@ -1159,9 +1189,15 @@ func StakeProgramSplit(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *I
 
 			var isActive bool
 			if execCtx.GlobalCtx.Features.IsActive(features.RequireRentExemptSplitDestination) {
-				clock := ReadClockSysvar(&execCtx.Accounts)
-				stakeHistory := ReadStakeHistorySysvar(&execCtx.Accounts)
-				stakeHistoryEntry := stakeState.Stake.Stake.Delegation.StakeActivatingAndDeactivating(clock.Epoch, stakeHistory, newWarmupCooldownRateEpoch(execCtx))
+				clock, err := execCtx.SysvarCache.Clock()
+				if err != nil {
+					return err
+				}
+				stakeHistory, err := execCtx.SysvarCache.StakeHistory()
+				if err != nil {
+					return err
+				}
+				stakeHistoryEntry := stakeState.Stake.Stake.Delegation.StakeActivatingAndDeactivating(clock.Epoch, *stakeHistory, newWarmupCooldownRateEpoch(execCtx))
 				if stakeHistoryEntry.Effective > 0 {
 					isActive = true
 				}
@@ -1202,16 +1238,19 @@ func StakeProgramSplit(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *I
 			if err != nil {
 				return err
 			}
+			defer stakeAcct.Drop()
 
 			err = setStakeAccountState(stakeAcct, stakeState, execCtx.GlobalCtx.Features)
 			if err != nil {
 				return err
 			}
+			stakeAcct.Drop()
 
 			split, err := instrCtx.BorrowInstructionAccount(txCtx, splitIdx)
 			if err != nil {
 				return err
 			}
+			defer split.Drop()
 
 			newSplitStakeState := StakeStateV2{Status: StakeStateV2StatusStake, Stake: StakeStateV2Stake{Meta: splitMeta, Stake: splitStake, StakeFlags: stakeState.Stake.StakeFlags}}
 			err = setStakeAccountState(split, &newSplitStakeState, execCtx.GlobalCtx.Features)
@@ -1239,6 +1278,7 @@ func StakeProgramSplit(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *I
 			if err != nil {
 				return err
 			}
+			defer split.Drop()
 
 			newStakeState := StakeStateV2{Status: StakeStateV2StatusInitialized, Initialized: StakeStateV2Initialized{Meta: splitMeta}}
 			err = setStakeAccountState(split, &newStakeState, execCtx.GlobalCtx.Features)
@@ -1278,6 +1318,7 @@ func StakeProgramMerge(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *I
 	if err != nil {
 		return err
 	}
+	defer srcAcct.Drop()
 
 	if srcAcct.Owner() != StakeProgramAddr {
 		return InstrErrIncorrectProgramId
@@ -1301,6 +1342,7 @@ func StakeProgramMerge(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *I
 	if err != nil {
 		return err
 	}
+	defer stakeAcct.Drop()
 
 	stakeAcctState, err := unmarshalStakeState(stakeAcct.Data())
 	if err != nil {
@@ -1382,6 +1424,7 @@ func StakeProgramWithdraw(txCtx *TransactionCtx, instrCtx *InstructionCtx, stake
 	if err != nil {
 		return err
 	}
+	defer stakeAcct.Drop()
 
 	stakeState, err := unmarshalStakeState(stakeAcct.Data())
 	if err != nil {
@@ -1491,11 +1534,13 @@ func StakeProgramWithdraw(txCtx *TransactionCtx, instrCtx *InstructionCtx, stake
 	if err != nil {
 		return err
 	}
+	stakeAcct.Drop()
 
 	to, err := instrCtx.BorrowInstructionAccount(txCtx, toIndex)
 	if err != nil {
 		return err
 	}
+	defer to.Drop()
 
 	err = to.CheckedAddLamports(lamports, f)
 	return err
@@ -1615,6 +1660,7 @@ func StakeProgramDeactivateDelinquent(execCtx *ExecutionCtx, txCtx *TransactionC
 	if err != nil {
 		return err
 	}
+	defer delinquentVoteAcct.Drop()
 
 	if delinquentVoteAcct.Owner() != VoteProgramAddr {
 		return InstrErrIncorrectProgramId
@@ -1630,6 +1676,7 @@ func StakeProgramDeactivateDelinquent(execCtx *ExecutionCtx, txCtx *TransactionC
 	if err != nil {
 		return err
 	}
+	defer referenceVoteAcct.Drop()
 
 	if referenceVoteAcct.Owner() != VoteProgramAddr {
 		return InstrErrIncorrectProgramId
@@ -1672,12 +1719,16 @@ func StakeProgramDeactivateDelinquent(execCtx *ExecutionCtx, txCtx *TransactionC
 }
 
 func StakeProgramRedelegate(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx, stakeAcct *BorrowedAccount, uninitializedStakeAcctIdx uint64, voteAcctIdx uint64, signers []solana.PublicKey) error {
-	clock := ReadClockSysvar(&execCtx.Accounts)
+	clock, err := execCtx.SysvarCache.Clock()
+	if err != nil {
+		return err
+	}
 
 	uninitializedStakeAcct, err := instrCtx.BorrowInstructionAccount(txCtx, uninitializedStakeAcctIdx)
 	if err != nil {
 		return err
 	}
+	defer uninitializedStakeAcct.Drop()
 
 	if uninitializedStakeAcct.Owner() != StakeProgramAddr {
 		return InstrErrIncorrectProgramId
@@ -1700,6 +1751,7 @@ func StakeProgramRedelegate(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 	if err != nil {
 		return err
 	}
+	defer voteAcct.Drop()
 
 	if voteAcct.Owner() != VoteProgramAddr {
 		return InstrErrIncorrectProgramId
@@ -1719,7 +1771,10 @@ func StakeProgramRedelegate(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 	var stakeMeta *Meta
 	var effectiveStake uint64
 	if stakeState.Status == StakeStateV2StatusStake {
-		status := getStakeStatus(execCtx, &stakeState.Stake.Stake, clock)
+		status, err := getStakeStatus(execCtx, &stakeState.Stake.Stake, *clock)
+		if err != nil {
+			return err
+		}
 		if status.Effective == 0 || status.Activating != 0 || status.Deactivating != 0 {
 			return StakeErrRedelegateTransientOrInactiveStake
 		}
@@ -1732,7 +1787,7 @@ func StakeProgramRedelegate(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 		return InstrErrInvalidAccountData
 	}
 
-	err = StakeProgramDeactivate(execCtx, stakeAcct, clock, signers)
+	err = StakeProgramDeactivate(execCtx, stakeAcct, *clock, signers)
 	if err != nil {
 		return err
 	}
@@ -1746,7 +1801,10 @@ func StakeProgramRedelegate(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 		return err
 	}
 
-	rent := ReadRentSysvar(&execCtx.Accounts)
+	rent, err := execCtx.SysvarCache.Rent()
+	if err != nil {
+		return err
+	}
 
 	uninitializedStakeMeta := *stakeMeta
 	uninitializedStakeMeta.RentExemptReserve = rent.MinimumBalance(uint64(len(uninitializedStakeAcct.Data())))
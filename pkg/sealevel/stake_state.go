@@ -450,7 +450,8 @@ func (delegation *Delegation) StakeActivatingAndDeactivating(targetEpoch uint64,
 			return StakeHistoryEntry{Effective: effectiveStake, Activating: activatingStake}
 		}
 	} else if targetEpoch == delegation.DeactivationEpoch {
-		return StakeHistoryEntry{Deactivating: effectiveStake}
+		// deactivating stake remains effective until it cools down
+		return StakeHistoryEntry{Effective: effectiveStake, Deactivating: effectiveStake}
 	} else if prevClusterStake := stakeHistory.Get(delegation.DeactivationEpoch); prevClusterStake != nil {
 		prevEpoch := delegation.DeactivationEpoch
 		currentEpoch := uint64(0)
@@ -469,7 +470,7 @@ func (delegation *Delegation) StakeActivatingAndDeactivating(targetEpoch uint64,
 			newlyNotEffectiveClusterStake := float64(prevClusterStake.Effective) * warmupCooldownRate
 
 			var newlyNotEffectiveStake uint64
-			if (weight * newlyNotEffectiveClusterStake) > 1 {
+			if uint64(weight*newlyNotEffectiveClusterStake) < 1 {
 				newlyNotEffectiveStake = 1
 			} else {
 				newlyNotEffectiveStake = uint64(weight * newlyNotEffectiveClusterStake)
@@ -491,7 +492,7 @@ func (delegation *Delegation) StakeActivatingAndDeactivating(targetEpoch uint64,
 				break
 			}
 		}
-		return StakeHistoryEntry{Deactivating: currentEffectiveStake}
+		return StakeHistoryEntry{Effective: currentEffectiveStake, Deactivating: currentEffectiveStake}
 	} else {
 		return StakeHistoryEntry{}
 	}
@@ -803,7 +804,11 @@ func newWarmupCooldownRateEpoch(execCtx *ExecutionCtx) *uint64 {
 		return nil
 	}
 
-	epochSchedule := ReadEpochScheduleSysvar(&execCtx.Accounts)
+	// without an epoch schedule, the new rate is treated as never activated
+	epochSchedule, err := execCtx.SysvarCache.EpochSchedule()
+	if err != nil {
+		return nil
+	}
 
 	epoch := epochSchedule.GetEpoch(slot)
 	return &epoch
@@ -983,12 +988,14 @@ func (mergeKind *MergeKind) Merge(execCtx *ExecutionCtx, src *MergeKind, clock S
 }
 
 func deactivateStake(execCtx *ExecutionCtx, stake *Stake, stakeFlags *StakeFlags, epoch uint64) error {
-	var err error
 	if execCtx.GlobalCtx.Features.IsActive(features.StakeRedelegateInstruction) {
 		if stakeFlags.Contains(StakeFlagsMustFullyActivateBeforeDeactivationIsPermitted) {
-			stakeHistory := ReadStakeHistorySysvar(&execCtx.Accounts)
+			stakeHistory, err := execCtx.SysvarCache.StakeHistory()
+			if err != nil {
+				return err
+			}
 
-			status := stake.Delegation.StakeActivatingAndDeactivating(epoch, stakeHistory, newWarmupCooldownRateEpoch(execCtx))
+			status := stake.Delegation.StakeActivatingAndDeactivating(epoch, *stakeHistory, newWarmupCooldownRateEpoch(execCtx))
 			if status.Activating != 0 {
 				return StakeErrRedelegatedStakeMustFullyActivateBeforeDeactivationIsPermitted
 			} else {
@@ -1009,7 +1016,10 @@ func deactivateStake(execCtx *ExecutionCtx, stake *Stake, stakeFlags *StakeFlags
 	}
 }
 
-func getStakeStatus(execCtx *ExecutionCtx, stake *Stake, clock SysvarClock) StakeHistoryEntry {
-	stakeHistory := ReadStakeHistorySysvar(&execCtx.Accounts)
-	return stake.Delegation.StakeActivatingAndDeactivating(clock.Epoch, stakeHistory, newWarmupCooldownRateEpoch(execCtx))
+func getStakeStatus(execCtx *ExecutionCtx, stake *Stake, clock SysvarClock) (StakeHistoryEntry, error) {
+	stakeHistory, err := execCtx.SysvarCache.StakeHistory()
+	if err != nil {
+		return StakeHistoryEntry{}, err
+	}
+	return stake.Delegation.StakeActivatingAndDeactivating(clock.Epoch, *stakeHistory, newWarmupCooldownRateEpoch(execCtx)), nil
 }
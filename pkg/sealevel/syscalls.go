@@ -5,8 +5,9 @@ import (
 	"go.firedancer.io/radiance/pkg/sbpf"
 )
 
-// Syscalls creates a registry of all Sealevel syscalls.
-func Syscalls(f *features.Features) sbpf.SyscallRegistry {
+// Syscalls creates a registry of all Sealevel syscalls. isDeploy restricts
+// the registry to the syscalls that newly deployed programs may use.
+func Syscalls(f *features.Features, isDeploy bool) sbpf.SyscallRegistry {
 	reg := sbpf.NewSyscallRegistry()
 	reg.Register("abort", SyscallAbort)
 	reg.Register("sol_panic_", SyscallPanic)
@@ -19,18 +20,43 @@ func Syscalls(f *features.Features) sbpf.SyscallRegistry {
 
 	reg.Register("sol_sha256", SyscallSha256)
 	reg.Register("sol_keccak256", SyscallKeccak256)
-	reg.Register("sol_blake3", SyscallBlake3)
+	if f.IsActive(features.Blake3SyscallEnabled) {
+		reg.Register("sol_blake3", SyscallBlake3)
+	}
 	reg.Register("sol_secp256k1_recover", SyscallSecp256k1Recover)
 
+	if f.IsActive(features.EnableAltBn128Syscall) {
+		reg.Register("sol_alt_bn128_group_op", SyscallAltBn128)
+	}
+	if f.IsActive(features.EnableAltBn128CompressionSyscall) {
+		reg.Register("sol_alt_bn128_compression", SyscallAltBn128Compression)
+	}
+	if f.IsActive(features.EnablePoseidonSyscall) {
+		reg.Register("sol_poseidon", SyscallPoseidon)
+	}
+	if f.IsActive(features.EnableBigModExpSyscall) {
+		reg.Register("sol_big_mod_exp", SyscallBigModExp)
+	}
+
 	reg.Register("sol_memcpy_", SyscallMemcpy)
 	reg.Register("sol_memcmp_", SyscallMemcmp)
 	reg.Register("sol_memset_", SyscallMemset)
 	reg.Register("sol_memmove_", SyscallMemmove)
 
+	if !(isDeploy && f.IsActive(features.DisableDeployOfAllocFreeSyscall)) {
+		reg.Register("sol_alloc_free_", SyscallAllocFree)
+	}
+
 	reg.Register("sol_create_program_address", SyscallCreateProgramAddress)
 	reg.Register("sol_try_find_program_address", SyscallTryFindProgramAddress)
 
 	reg.Register("sol_get_stack_height", SyscallGetStackHeight)
+	if f.IsActive(features.RemainingComputeUnitsSyscallEnabled) {
+		reg.Register("sol_remaining_compute_units", SyscallRemainingComputeUnits)
+	}
+	if f.IsActive(features.EnableGetEpochStakeSyscall) {
+		reg.Register("sol_get_epoch_stake", SyscallGetEpochStake)
+	}
 	reg.Register("sol_get_return_data", SyscallGetReturnData)
 	reg.Register("sol_set_return_data", SyscallSetReturnData)
 	reg.Register("sol_get_processed_sibling_instruction", SyscallGetProcessedSiblingInstruction)
@@ -38,6 +64,9 @@ func Syscalls(f *features.Features) sbpf.SyscallRegistry {
 	reg.Register("sol_get_clock_sysvar", SyscallGetClockSysvar)
 	reg.Register("sol_get_rent_sysvar", SyscallGetRentSysvar)
 	reg.Register("sol_get_epoch_schedule_sysvar", SyscallGetEpochScheduleSysvar)
+	if !f.IsActive(features.DisableFeesSysvar) {
+		reg.Register("sol_get_fees_sysvar", SyscallGetFeesSysvar)
+	}
 
 	if f.IsActive(features.EnablePartitionedEpochReward) {
 		reg.Register("sol_get_epoch_rewards_sysvar", SyscallGetEpochRewardsSysvar)
@@ -47,8 +76,15 @@ func Syscalls(f *features.Features) sbpf.SyscallRegistry {
 		reg.Register("sol_get_last_restart_slot_sysvar", SyscallGetLastRestartSlotSysvar)
 	}
 
-	reg.Register("sol_invoke_signed_c", SyscallInvokeSignedC)
-	reg.Register("sol_invoke_signed_rust", SyscallInvokeSignedRust)
+	if f.IsActive(features.GetSysvarSyscallEnabled) {
+		reg.Register("sol_get_sysvar", SyscallGetSysvar)
+	}
+
+	// the CPI syscalls are constructed here rather than referenced through
+	// their package-level vars, as CPI can reach this function again via
+	// program execution, which would otherwise form an initialization cycle.
+	reg.Register("sol_invoke_signed_c", sbpf.SyscallFunc5(SyscallInvokeSignedCImpl))
+	reg.Register("sol_invoke_signed_rust", sbpf.SyscallFunc5(SyscallInvokeSignedRustImpl))
 
 	// non-"feature gated" syscalls still yet to implement:
 	// 		sol_get_processed_sibling_instruction
@@ -57,13 +93,6 @@ func Syscalls(f *features.Features) sbpf.SyscallRegistry {
 	//		sol_curve_validate_point (disabled)
 	//		sol_curve_group_op (disabled)
 	//		sol_curve_multiscalar_mul (disabled)
-	//		sol_alt_bn128_group_op (disabled)
-	//		sol_big_mod_exp (disabled)
-	//		sol_poseidon (disabled)
-	//		sol_remaining_compute_units (disabled)
-	//		sol_alt_bn128_compression (disabled)
-	//		sol_get_fees_sysvar (deprecated & now disabled via feature gate JAN1trEUEtZjgXYzNBYHU9DYd7GnThhXfFP7SzPXkPsG)
-	//		sol_alloc_free_ (deprecated & now disabled via feature gate 79HWsX9rpnnJBPcdNURVqygpMAfxdrAirzAGAVmf92im)
 
 	return reg
 }
@@ -0,0 +1,152 @@
+package sealevel
+
+import (
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/safemath"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+// sol_alt_bn128_group_op operations
+const (
+	AltBn128Add     = 0
+	AltBn128Sub     = 1 // not supported
+	AltBn128Mul     = 2
+	AltBn128Pairing = 3
+)
+
+// sol_alt_bn128_compression operations
+const (
+	AltBn128G1Compress   = 0
+	AltBn128G1Decompress = 1
+	AltBn128G2Compress   = 2
+	AltBn128G2Decompress = 3
+)
+
+// alt_bn128 error codes, returned in r0. Since
+// simplify_alt_bn128_syscall_error_codes, all failures return 1.
+//
+// Based on solana_program::alt_bn128::AltBn128Error and
+// solana_program::alt_bn128::compression::AltBn128CompressionError.
+const (
+	AltBn128ErrInvalidInputData = 1
+
+	AltBn128CompressionErrG1DecompressionFailed = 2
+	AltBn128CompressionErrG2DecompressionFailed = 3
+	AltBn128CompressionErrInvalidInputSize      = 6
+)
+
+// SyscallAltBn128Impl is the implementation of the sol_alt_bn128_group_op syscall.
+func SyscallAltBn128Impl(vm sbpf.VM, groupOp, inputAddr, inputSize, resultAddr uint64) (r0 uint64, err error) {
+	var cost, outputSize uint64
+	switch groupOp {
+	case AltBn128Add:
+		cost, inputSize, outputSize = CUAltBn128AdditionCost, AltBn128AdditionInputLen, AltBn128AdditionOutputLen
+	case AltBn128Mul:
+		cost, inputSize, outputSize = CUAltBn128MultiplicationCost, AltBn128MultiplicationInputLen, AltBn128MultiplicationOutputLen
+	case AltBn128Pairing:
+		pairs := inputSize / AltBn128PairingElementLen
+		cost = safemath.SaturatingAddU64(CUAltBn128PairingOnePairCostFirst,
+			safemath.SaturatingMulU64(CUAltBn128PairingOnePairCostOther, safemath.SaturatingSubU64(pairs, 1)))
+		cost = safemath.SaturatingAddU64(cost, CUSha256BaseCost)
+		cost = safemath.SaturatingAddU64(cost, inputSize)
+		cost = safemath.SaturatingAddU64(cost, AltBn128PairingOutputLen)
+		outputSize = AltBn128PairingOutputLen
+	default:
+		err = SyscallErrInvalidAttribute
+		return
+	}
+
+	execCtx := executionCtx(vm)
+	err = execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return
+	}
+
+	input, err := sbpf.TranslateSlice(vm, inputAddr, inputSize, 1, false)
+	if err != nil {
+		return
+	}
+	result, err := vm.Translate(resultAddr, outputSize, true)
+	if err != nil {
+		return
+	}
+
+	var output []byte
+	var opErr error
+	switch groupOp {
+	case AltBn128Add:
+		output, opErr = altBn128Addition(input)
+	case AltBn128Mul:
+		output, opErr = altBn128Multiplication(input)
+	case AltBn128Pairing:
+		output, opErr = altBn128Pairing(input)
+	}
+	if opErr != nil {
+		r0 = AltBn128ErrInvalidInputData
+		return
+	}
+
+	copy(result, output)
+	return
+}
+
+var SyscallAltBn128 = sbpf.SyscallFunc4(SyscallAltBn128Impl)
+
+// SyscallAltBn128CompressionImpl is the implementation of the sol_alt_bn128_compression syscall.
+func SyscallAltBn128CompressionImpl(vm sbpf.VM, op, inputAddr, inputSize, resultAddr uint64) (r0 uint64, err error) {
+	var cost, outputSize uint64
+	var compress func([]byte) ([]byte, error)
+	switch op {
+	case AltBn128G1Compress:
+		cost, outputSize, compress = CUAltBn128G1Compress, AltBn128G1CompressedSize, altBn128G1Compress
+	case AltBn128G1Decompress:
+		cost, outputSize, compress = CUAltBn128G1Decompress, AltBn128G1Size, altBn128G1Decompress
+	case AltBn128G2Compress:
+		cost, outputSize, compress = CUAltBn128G2Compress, AltBn128G2CompressedSize, altBn128G2Compress
+	case AltBn128G2Decompress:
+		cost, outputSize, compress = CUAltBn128G2Decompress, AltBn128G2Size, altBn128G2Decompress
+	default:
+		err = SyscallErrInvalidAttribute
+		return
+	}
+
+	execCtx := executionCtx(vm)
+	err = execCtx.ComputeMeter.Consume(safemath.SaturatingAddU64(CUSyscallBaseCost, cost))
+	if err != nil {
+		return
+	}
+
+	input, err := sbpf.TranslateSlice(vm, inputAddr, inputSize, 1, false)
+	if err != nil {
+		return
+	}
+	result, err := vm.Translate(resultAddr, outputSize, true)
+	if err != nil {
+		return
+	}
+
+	output, opErr := compress(input)
+	if opErr != nil {
+		r0 = altBn128CompressionErrCode(execCtx, opErr)
+		return
+	}
+
+	copy(result, output)
+	return
+}
+
+var SyscallAltBn128Compression = sbpf.SyscallFunc4(SyscallAltBn128CompressionImpl)
+
+func altBn128CompressionErrCode(execCtx *ExecutionCtx, err error) uint64 {
+	if execCtx.GlobalCtx.Features.IsActive(features.SimplifyAltBn128SyscallErrorCodes) {
+		return 1
+	}
+	switch err {
+	case errAltBn128InvalidInputSize:
+		return AltBn128CompressionErrInvalidInputSize
+	case errAltBn128G2DecompressionFailed:
+		return AltBn128CompressionErrG2DecompressionFailed
+	default:
+		return AltBn128CompressionErrG1DecompressionFailed
+	}
+}
@@ -0,0 +1,96 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"go.firedancer.io/radiance/pkg/safemath"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+// BigModExpMaxInputLen is the max length in bytes of each of the base,
+// exponent and modulus of sol_big_mod_exp.
+const BigModExpMaxInputLen = 512
+
+// bigModExpParamsSize is the size of the BigModExpParams struct,
+// six u64 fields: base, base_len, exponent, exponent_len, modulus, modulus_len
+const bigModExpParamsSize = 48
+
+// SyscallBigModExpImpl is the implementation for the sol_big_mod_exp syscall.
+// It computes base^exponent mod modulus over big-endian unsigned integers
+// described by the BigModExpParams at paramsAddr, and writes the result,
+// left-padded to the length of the modulus, to returnAddr.
+func SyscallBigModExpImpl(vm sbpf.VM, paramsAddr, returnAddr uint64) (r0 uint64, err error) {
+	paramsData, err := vm.Translate(paramsAddr, bigModExpParamsSize, false)
+	if err != nil {
+		return
+	}
+	baseAddr := binary.LittleEndian.Uint64(paramsData[0:8])
+	baseLen := binary.LittleEndian.Uint64(paramsData[8:16])
+	exponentAddr := binary.LittleEndian.Uint64(paramsData[16:24])
+	exponentLen := binary.LittleEndian.Uint64(paramsData[24:32])
+	modulusAddr := binary.LittleEndian.Uint64(paramsData[32:40])
+	modulusLen := binary.LittleEndian.Uint64(paramsData[40:48])
+
+	if baseLen > BigModExpMaxInputLen || exponentLen > BigModExpMaxInputLen || modulusLen > BigModExpMaxInputLen {
+		err = SyscallErrInvalidLength
+		return
+	}
+
+	inputLen := baseLen
+	if exponentLen > inputLen {
+		inputLen = exponentLen
+	}
+	if modulusLen > inputLen {
+		inputLen = modulusLen
+	}
+
+	execCtx := executionCtx(vm)
+	cost := safemath.SaturatingAddU64(CUSyscallBaseCost, safemath.SaturatingMulU64(inputLen, inputLen)/CUBigModularExponentiationCost)
+	err = execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return
+	}
+
+	base, err := vm.Translate(baseAddr, baseLen, false)
+	if err != nil {
+		return
+	}
+	exponent, err := vm.Translate(exponentAddr, exponentLen, false)
+	if err != nil {
+		return
+	}
+	modulus, err := vm.Translate(modulusAddr, modulusLen, false)
+	if err != nil {
+		return
+	}
+
+	result := bigModExp(base, exponent, modulus)
+
+	returnValue, err := vm.Translate(returnAddr, modulusLen, true)
+	if err != nil {
+		return
+	}
+	copy(returnValue, result)
+	return
+}
+
+var SyscallBigModExp = sbpf.SyscallFunc2(SyscallBigModExpImpl)
+
+// bigModExp returns base^exponent mod modulus, big-endian and left-padded
+// to the length of the modulus. A modulus of 0 or 1 yields all zeroes.
+//
+// Based on solana_program::big_mod_exp::big_mod_exp.
+func bigModExp(base, exponent, modulus []byte) []byte {
+	result := make([]byte, len(modulus))
+
+	m := new(big.Int).SetBytes(modulus)
+	if m.Cmp(big.NewInt(1)) <= 0 {
+		return result
+	}
+
+	b := new(big.Int).SetBytes(base)
+	e := new(big.Int).SetBytes(exponent)
+	new(big.Int).Exp(b, e, m).FillBytes(result)
+	return result
+}
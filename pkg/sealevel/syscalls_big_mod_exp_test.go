@@ -0,0 +1,64 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestBigModExp(t *testing.T) {
+	// 3^5 mod 7 = 5, padded to the modulus length
+	assert.Equal(t, []byte{0, 5}, bigModExp([]byte{3}, []byte{5}, []byte{0, 7}))
+	// zero exponent
+	assert.Equal(t, []byte{1}, bigModExp([]byte{3}, nil, []byte{7}))
+	// modulus of 0 or 1
+	assert.Equal(t, []byte{0, 0}, bigModExp([]byte{3}, []byte{5}, []byte{0, 1}))
+	assert.Equal(t, []byte{0}, bigModExp([]byte{3}, []byte{5}, []byte{0}))
+	assert.Equal(t, []byte{}, bigModExp([]byte{3}, []byte{5}, nil))
+}
+
+func TestSyscallBigModExp(t *testing.T) {
+	execCtx := &ExecutionCtx{ComputeMeter: cu.NewComputeMeter(CUMaxComputeUnitLimit)}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x2000, Context: execCtx})
+
+	const (
+		paramsAddr   = sbpf.VaddrHeap
+		baseAddr     = sbpf.VaddrHeap + 0x100
+		exponentAddr = sbpf.VaddrHeap + 0x400
+		modulusAddr  = sbpf.VaddrHeap + 0x700
+		returnAddr   = sbpf.VaddrHeap + 0xa00
+	)
+	modulus := make([]byte, 256)
+	modulus[0] = 0x80
+	modulus[255] = 0x01
+	require.NoError(t, vm.Write(baseAddr, []byte{2}))
+	require.NoError(t, vm.Write(exponentAddr, []byte{0x01, 0x00}))
+	require.NoError(t, vm.Write(modulusAddr, modulus))
+
+	writeParams := func(modulusLen uint64) {
+		require.NoError(t, vm.Write64(paramsAddr, baseAddr))
+		require.NoError(t, vm.Write64(paramsAddr+8, 1))
+		require.NoError(t, vm.Write64(paramsAddr+16, exponentAddr))
+		require.NoError(t, vm.Write64(paramsAddr+24, 2))
+		require.NoError(t, vm.Write64(paramsAddr+32, modulusAddr))
+		require.NoError(t, vm.Write64(paramsAddr+40, modulusLen))
+	}
+	writeParams(256)
+
+	r0, err := SyscallBigModExpImpl(vm, paramsAddr, returnAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), r0)
+
+	result, err := vm.Translate(returnAddr, 256, false)
+	require.NoError(t, err)
+	assert.Equal(t, bigModExp([]byte{2}, []byte{0x01, 0x00}, modulus), result)
+	assert.Equal(t, uint64(CUMaxComputeUnitLimit-(CUSyscallBaseCost+256*256/CUBigModularExponentiationCost)), execCtx.ComputeMeter.Remaining())
+
+	writeParams(BigModExpMaxInputLen + 1)
+	_, err = SyscallBigModExpImpl(vm, paramsAddr, returnAddr)
+	assert.Equal(t, SyscallErrInvalidLength, err)
+}
@@ -23,6 +23,53 @@ func SyscallGetStackHeightImpl(vm sbpf.VM) (r0 uint64, err error) {
 
 var SyscallGetStackHeight = sbpf.SyscallFunc0(SyscallGetStackHeightImpl)
 
+// SyscallRemainingComputeUnitsImpl is an implementation of the sol_remaining_compute_units syscall.
+// Returns the compute units remaining after charging for the syscall itself.
+func SyscallRemainingComputeUnitsImpl(vm sbpf.VM) (r0 uint64, err error) {
+	execCtx := executionCtx(vm)
+	err = execCtx.ComputeMeter.Consume(CUGetRemainingComputeUnitCost)
+	if err != nil {
+		return
+	}
+
+	r0 = execCtx.ComputeMeter.Remaining()
+	return
+}
+
+var SyscallRemainingComputeUnits = sbpf.SyscallFunc0(SyscallRemainingComputeUnitsImpl)
+
+// SyscallGetEpochStakeImpl is an implementation of the sol_get_epoch_stake syscall (SIMD-0133).
+// With a null varAddr, returns the total active stake of the current epoch.
+// Otherwise, returns the active stake delegated to the vote account at varAddr,
+// or 0 if it is not a staked vote account.
+func SyscallGetEpochStakeImpl(vm sbpf.VM, varAddr uint64) (r0 uint64, err error) {
+	execCtx := executionCtx(vm)
+
+	if varAddr == 0 {
+		err = execCtx.ComputeMeter.Consume(CUSyscallBaseCost)
+		if err != nil {
+			return
+		}
+		r0 = execCtx.EpochStakes.TotalStake
+		return
+	}
+
+	err = execCtx.ComputeMeter.Consume(CUSyscallBaseCost + solana.PublicKeyLength/CUCpiBytesPerUnit + CUMemOpBaseCost)
+	if err != nil {
+		return
+	}
+
+	voteAddr, err := vm.Translate(varAddr, solana.PublicKeyLength, false)
+	if err != nil {
+		return
+	}
+
+	r0 = execCtx.EpochStakes.VoteAccountStake(*(*[32]byte)(voteAddr))
+	return
+}
+
+var SyscallGetEpochStake = sbpf.SyscallFunc1(SyscallGetEpochStakeImpl)
+
 // SyscallGetReturnDataImpl is an implementation of the sol_get_return_data syscall
 func SyscallGetReturnDataImpl(vm sbpf.VM, returnDataAddr, length, programIdAddr uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
@@ -50,12 +97,7 @@ func SyscallGetReturnDataImpl(vm sbpf.VM, returnDataAddr, length, programIdAddr
 			return
 		}
 
-		if len(returnData) != len(returnDataResult) {
-			err = SyscallErrInvalidLength
-			return
-		}
-
-		copy(returnDataResult, returnData)
+		copy(returnDataResult, returnData[:length])
 
 		var programIdResult []byte
 		programIdResult, err = vm.Translate(programIdAddr, solana.PublicKeyLength, true)
@@ -77,6 +119,7 @@ func SyscallGetReturnDataImpl(vm sbpf.VM, returnDataAddr, length, programIdAddr
 
 var SyscallGetReturnData = sbpf.SyscallFunc3(SyscallGetReturnDataImpl)
 
+// MaxReturnData is the maximum size of the data set by sol_set_return_data.
 const MaxReturnData = 1024
 
 // SyscallSetReturnDataImpl is an implementation of the sol_set_return_data syscall
@@ -93,14 +136,9 @@ func SyscallSetReturnDataImpl(vm sbpf.VM, addr, length uint64) (r0 uint64, err e
 		return
 	}
 
-	var returnData []byte
-	if length == 0 {
-		returnData = make([]byte, 0)
-	} else {
-		returnData, err = vm.Translate(addr, length, false)
-		if err != nil {
-			return
-		}
+	returnData, err := sbpf.TranslateSlice(vm, addr, length, 1, false)
+	if err != nil {
+		return
 	}
 
 	txCtx := transactionCtx(vm)
@@ -108,9 +146,13 @@ func SyscallSetReturnDataImpl(vm sbpf.VM, addr, length uint64) (r0 uint64, err e
 	if err != nil {
 		return
 	}
-	programId := ixCtx.ProgramId()
+	programId, err := ixCtx.LastProgramKey(txCtx)
+	if err != nil {
+		return
+	}
 
-	txCtx.SetReturnData(programId, returnData)
+	// copy out of VM memory, which is freed when the program exits
+	txCtx.SetReturnData(programId, append([]byte{}, returnData...))
 
 	r0 = 0
 	return
@@ -178,13 +220,13 @@ func SyscallGetProcessedSiblingInstructionImpl(vm sbpf.VM, index, metaAddr, prog
 			}
 			programId := solana.PublicKeyFromBytes(programIdBytes)
 
-			data, err := vm.Translate(dataAddr, resultHeader.DataLen, true)
+			data, err := sbpf.TranslateSlice(vm, dataAddr, resultHeader.DataLen, 1, true)
 			if err != nil {
 				return r0, err
 			}
 
 			accountMetaDataSize := safemath.SaturatingMulU64(resultHeader.AccountsLen, AccountMetaSize)
-			accountMetaSliceBytes, err := vm.Translate(accountsAddr, accountMetaDataSize, true)
+			accountMetaSliceBytes, err := sbpf.TranslateSlice(vm, accountsAddr, resultHeader.AccountsLen, AccountMetaSize, true)
 			if err != nil {
 				return r0, err
 			}
@@ -0,0 +1,115 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestSyscallReturnData(t *testing.T) {
+	programId := solana.PublicKey{9}
+	from, to := solana.PublicKey{1}, solana.PublicKey{2}
+	execCtx := newTestLoaderExecCtx(programId, *features.NewFeaturesDefault(), nil, []testInstrAcct{
+		{from, &accounts.Account{Lamports: 1000, Owner: SystemProgramAddr}, true, true},
+		{to, &accounts.Account{Lamports: 10, Owner: SystemProgramAddr}, false, true},
+		{SystemProgramAddr, &accounts.Account{Owner: NativeLoaderAddr, Executable: true}, false, false},
+	})
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(&execCtx.GlobalCtx, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	const (
+		dataAddr      = sbpf.VaddrHeap
+		resultAddr    = sbpf.VaddrHeap + 0x100
+		programIdAddr = sbpf.VaddrHeap + 0x200
+	)
+	require.NoError(t, vm.Write(dataAddr, []byte{1, 2, 3, 4}))
+
+	r0, err := SyscallSetReturnDataImpl(vm, dataAddr, 4)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), r0)
+
+	// the return data does not alias VM memory
+	require.NoError(t, vm.Write(dataAddr, []byte{5}))
+
+	// reading fewer bytes than available copies a prefix and returns the full length
+	r0, err = SyscallGetReturnDataImpl(vm, resultAddr, 2, programIdAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), r0)
+	result, err := vm.Translate(resultAddr, 4, false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 0, 0}, result)
+	key, err := vm.Translate(programIdAddr, solana.PublicKeyLength, false)
+	require.NoError(t, err)
+	assert.Equal(t, programId[:], key)
+
+	_, err = SyscallSetReturnDataImpl(vm, dataAddr, MaxReturnData+1)
+	assert.Equal(t, SyscallErrReturnDataTooLarge, err)
+
+	// invoking another program clears the return data
+	transfer := make([]byte, 12)
+	binary.LittleEndian.PutUint32(transfer, SystemProgramInstrTypeTransfer)
+	binary.LittleEndian.PutUint64(transfer[4:], 300)
+	require.NoError(t, execCtx.NativeInvoke(Instruction{
+		ProgramId: SystemProgramAddr,
+		Accounts:  []AccountMeta{{Pubkey: from, IsSigner: true, IsWritable: true}, {Pubkey: to, IsWritable: true}},
+		Data:      transfer,
+	}, nil))
+	setter, data := execCtx.TransactionContext.ReturnData()
+	assert.Equal(t, solana.PublicKey(SystemProgramAddr), setter)
+	assert.Empty(t, data)
+}
+
+func TestSyscallIntrospection(t *testing.T) {
+	execCtx := newTestLoaderExecCtx(solana.PublicKey{9}, *features.NewFeaturesDefault(), nil, nil)
+	execCtx.ComputeMeter = cu.NewComputeMeter(1000)
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(&execCtx.GlobalCtx, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	height, err := SyscallGetStackHeightImpl(vm)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), height)
+
+	remaining, err := SyscallRemainingComputeUnitsImpl(vm)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000-CUSyscallBaseCost-CUGetRemainingComputeUnitCost), remaining)
+
+	execCtx.ComputeMeter = cu.NewComputeMeter(CUGetRemainingComputeUnitCost - 1)
+	_, err = SyscallRemainingComputeUnitsImpl(vm)
+	assert.Error(t, err)
+}
+
+func TestSyscallGetEpochStake(t *testing.T) {
+	execCtx := newTestLoaderExecCtx(solana.PublicKey{9}, *features.NewFeaturesDefault(), nil, nil)
+	execCtx.ComputeMeter = cu.NewComputeMeter(1000)
+	voteAddr := solana.PublicKey{7}
+	execCtx.EpochStakes = EpochStakes{
+		TotalStake:        1_000_000,
+		VoteAccountStakes: map[[32]byte]uint64{voteAddr: 4000},
+	}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(&execCtx.GlobalCtx, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	total, err := SyscallGetEpochStakeImpl(vm, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1_000_000), total)
+	assert.Equal(t, uint64(1000-CUSyscallBaseCost), execCtx.ComputeMeter.Remaining())
+
+	require.NoError(t, vm.Write(sbpf.VaddrHeap, voteAddr[:]))
+	stake, err := SyscallGetEpochStakeImpl(vm, sbpf.VaddrHeap)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4000), stake)
+	assert.Equal(t, uint64(1000-2*CUSyscallBaseCost-CUMemOpBaseCost), execCtx.ComputeMeter.Remaining())
+
+	// unknown vote accounts have no stake
+	require.NoError(t, vm.Write(sbpf.VaddrHeap, []byte{8}))
+	stake, err = SyscallGetEpochStakeImpl(vm, sbpf.VaddrHeap)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), stake)
+}
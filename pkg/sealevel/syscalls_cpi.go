@@ -2,14 +2,13 @@ package sealevel
 
 import (
 	"bytes"
-	"encoding/binary"
-	"fmt"
 
 	"github.com/gagliardetto/solana-go"
 	"go.firedancer.io/radiance/pkg/base58"
 	"go.firedancer.io/radiance/pkg/features"
 	"go.firedancer.io/radiance/pkg/safemath"
 	"go.firedancer.io/radiance/pkg/sbpf"
+	"k8s.io/klog/v2"
 )
 
 const (
@@ -37,6 +36,16 @@ func checkInstructionSize(execCtx *ExecutionCtx, numAccounts uint64, dataLen uin
 	return nil
 }
 
+// consumeInstructionDataCost charges for copying CPI instruction data,
+// which is only bounded by a size limit once loosen_cpi_size_restriction
+// is active.
+func consumeInstructionDataCost(execCtx *ExecutionCtx, dataLen uint64) error {
+	if !execCtx.GlobalCtx.Features.IsActive(features.LoosenCpiSizeRestriction) {
+		return nil
+	}
+	return execCtx.ComputeMeter.Consume(dataLen / CUCpiBytesPerUnit)
+}
+
 func translateInstructionC(vm sbpf.VM, addr uint64) (Instruction, error) {
 	ixData, err := vm.Translate(addr, SolInstructionCStructSize, false)
 	if err != nil {
@@ -62,7 +71,7 @@ func translateInstructionC(vm sbpf.VM, addr uint64) (Instruction, error) {
 	}
 	programId := solana.PublicKeyFromBytes(pkData)
 
-	accountMetasData, err := vm.Translate(ix.AccountsAddr, SolAccountMetaCSize*ix.AccountsLen, false)
+	accountMetasData, err := sbpf.TranslateSlice(vm, ix.AccountsAddr, ix.AccountsLen, SolAccountMetaCSize, false)
 	if err != nil {
 		return Instruction{}, err
 	}
@@ -79,14 +88,17 @@ func translateInstructionC(vm sbpf.VM, addr uint64) (Instruction, error) {
 		accountMetas = append(accountMetas, am)
 	}
 
-	// TODO: do CU accounting for `loosen_cpi_size_restriction` feature gate
+	data, err := sbpf.TranslateSlice(vm, ix.DataAddr, ix.DataLen, 1, false)
+	if err != nil {
+		return Instruction{}, err
+	}
 
-	data, err := vm.Translate(ix.DataAddr, ix.DataLen, false)
+	err = consumeInstructionDataCost(executionCtx(vm), ix.DataLen)
 	if err != nil {
 		return Instruction{}, err
 	}
 
-	accounts := make([]AccountMeta, ix.AccountsLen)
+	accounts := make([]AccountMeta, 0, ix.AccountsLen)
 	for count := uint64(0); count < ix.AccountsLen; count++ {
 		accountMeta := accountMetas[count]
 		if accountMeta.IsSigner > 1 || accountMeta.IsWritable > 1 {
@@ -134,7 +146,7 @@ func translateInstructionRust(vm sbpf.VM, addr uint64) (Instruction, error) {
 		return Instruction{}, err
 	}
 
-	accountMetasData, err := vm.Translate(ix.Accounts.Addr, AccountMetaSize*ix.Accounts.Len, false)
+	accountMetasData, err := sbpf.TranslateSlice(vm, ix.Accounts.Addr, ix.Accounts.Len, AccountMetaSize, false)
 	if err != nil {
 		return Instruction{}, err
 	}
@@ -151,7 +163,12 @@ func translateInstructionRust(vm sbpf.VM, addr uint64) (Instruction, error) {
 		accountMetas = append(accountMetas, accountMeta)
 	}
 
-	data, err := vm.Translate(ix.Data.Addr, ix.Data.Len, false)
+	data, err := sbpf.TranslateSlice(vm, ix.Data.Addr, ix.Data.Len, 1, false)
+	if err != nil {
+		return Instruction{}, err
+	}
+
+	err = consumeInstructionDataCost(executionCtx(vm), ix.Data.Len)
 	if err != nil {
 		return Instruction{}, err
 	}
@@ -169,8 +186,7 @@ func translateSigners(vm sbpf.VM, programId solana.PublicKey, signersSeedsAddr,
 		return nil, SyscallErrTooManySigners
 	}
 
-	ssLen := safemath.SaturatingMulU64(signersSeedsLen, SolSignerSeedsCSize)
-	signerSeedsMem, err := vm.Translate(signersSeedsAddr, ssLen, false)
+	signerSeedsMem, err := sbpf.TranslateSlice(vm, signersSeedsAddr, signersSeedsLen, SolSignerSeedsCSize, false)
 	if err != nil {
 		return nil, err
 	}
@@ -194,8 +210,7 @@ func translateSigners(vm sbpf.VM, programId solana.PublicKey, signersSeedsAddr,
 			return nil, SyscallErrMaxSeedLengthExceeded
 		}
 
-		sz := safemath.SaturatingMulU64(signerSeed.Len, SolSignerSeedsCSize)
-		mem, err := vm.Translate(signerSeed.Addr, sz, false)
+		mem, err := sbpf.TranslateSlice(vm, signerSeed.Addr, signerSeed.Len, SolSignerSeedsCSize, false)
 		if err != nil {
 			return nil, err
 		}
@@ -215,7 +230,7 @@ func translateSigners(vm sbpf.VM, programId solana.PublicKey, signersSeedsAddr,
 		var seedBytes [][]byte
 
 		for _, seed := range seeds {
-			seedFragmentMem, err := vm.Translate(seed.Addr, seed.Len, false)
+			seedFragmentMem, err := sbpf.TranslateSlice(vm, seed.Addr, seed.Len, 1, false)
 			if err != nil {
 				return nil, err
 			}
@@ -249,9 +264,11 @@ func isBpfLoaderUpgradebleCloseInstr(data []byte) bool {
 	return len(data) != 0 && data[0] == 5
 }
 
-func isPrecompile(programId solana.PublicKey) bool {
+func isPrecompile(programId solana.PublicKey, f features.Features) bool {
 	if programId == Secp256kPrecompileAddr || programId == Ed25519PrecompileAddr {
 		return true
+	} else if programId == Secp256r1PrecompileAddr {
+		return f.IsActive(features.EnableSecp256r1Precompile)
 	} else {
 		return false
 	}
@@ -267,7 +284,7 @@ func checkAuthorizedProgram(execCtx *ExecutionCtx, programId solana.PublicKey, i
 				isBpfLoaderUpgradebleSetAuthorityInstr(instructionData) ||
 				(execCtx.GlobalCtx.Features.IsActive(features.EnableBpfLoaderSetAuthorityCheckedIx) && isBpfLoaderUpgradebleSetAuthorityCheckedInstr(instructionData)) ||
 				isBpfLoaderUpgradebleCloseInstr(instructionData))) ||
-		isPrecompile(programId) {
+		isPrecompile(programId, execCtx.GlobalCtx.Features) {
 		return SyscallErrProgramNotSupported
 	}
 
@@ -295,8 +312,7 @@ func checkAccountInfos(execCtx *ExecutionCtx, numAccountInfos uint64) error {
 }
 
 func translateAccountInfosC(vm sbpf.VM, accountInfosAddr, accountInfosLen uint64) ([]SolAccountInfoC, []solana.PublicKey, error) {
-	size := safemath.SaturatingMulU64(accountInfosLen, SolAccountInfoCSize)
-	accountInfosData, err := vm.Translate(accountInfosAddr, size, false)
+	accountInfosData, err := sbpf.TranslateSlice(vm, accountInfosAddr, accountInfosLen, SolAccountInfoCSize, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -332,8 +348,7 @@ func translateAccountInfosC(vm sbpf.VM, accountInfosAddr, accountInfosLen uint64
 }
 
 func translateAccountInfosRust(vm sbpf.VM, accountInfosAddr, accountInfosLen uint64) ([]SolAccountInfoRust, []solana.PublicKey, error) {
-	size := safemath.SaturatingMulU64(accountInfosLen, SolAccountInfoRustSize)
-	accountInfosData, err := vm.Translate(accountInfosAddr, size, false)
+	accountInfosData, err := sbpf.TranslateSlice(vm, accountInfosAddr, accountInfosLen, SolAccountInfoRustSize, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -368,12 +383,7 @@ func translateAccountInfosRust(vm sbpf.VM, accountInfosAddr, accountInfosLen uin
 	return accountInfos, accountInfoKeys, nil
 }
 
-type callerAccountsAndIndex struct {
-	index   uint64
-	account CallerAccount
-}
-
-func callerAccountFromAccountInfoC(vm sbpf.VM, execCtx *ExecutionCtx, accountInfo SolAccountInfoC) (CallerAccount, error) {
+func callerAccountFromAccountInfoC(vm sbpf.VM, execCtx *ExecutionCtx, accountInfoAddr uint64, accountInfo SolAccountInfoC, originalDataLen uint64) (CallerAccount, error) {
 
 	var callerAcct CallerAccount
 
@@ -382,12 +392,14 @@ func callerAccountFromAccountInfoC(vm sbpf.VM, execCtx *ExecutionCtx, accountInf
 		return callerAcct, err
 	}
 	callerAcct.Lamports = lamports
+	callerAcct.VmLamportsAddr = accountInfo.LamportsAddr
 
-	accOwner, err := vm.Translate(accountInfo.OwnerAddr, solana.PublicKeyLength, false)
+	accOwner, err := vm.Translate(accountInfo.OwnerAddr, solana.PublicKeyLength, true)
 	if err != nil {
 		return callerAcct, err
 	}
 	callerAcct.Owner = solana.PublicKeyFromBytes(accOwner)
+	callerAcct.VmOwnerAddr = accountInfo.OwnerAddr
 
 	cost := accountInfo.DataLen / CUCpiBytesPerUnit
 	err = execCtx.ComputeMeter.Consume(cost)
@@ -395,20 +407,27 @@ func callerAccountFromAccountInfoC(vm sbpf.VM, execCtx *ExecutionCtx, accountInf
 		return callerAcct, err
 	}
 
-	acctData, err := vm.Translate(accountInfo.DataAddr, accountInfo.DataLen, false)
-	if err != nil {
-		return callerAcct, err
+	// directly mapped account data is not cached, see updateCallerAccount
+	acctData := []byte{}
+	if !execCtx.GlobalCtx.Features.IsActive(features.BpfAccountDataDirectMapping) {
+		acctData, err = sbpf.TranslateSlice(vm, accountInfo.DataAddr, accountInfo.DataLen, 1, true)
+		if err != nil {
+			return callerAcct, err
+		}
 	}
 
+	callerAcct.OriginalDataLen = originalDataLen
 	callerAcct.SerializedData = &acctData
 	callerAcct.SerializedDataLen = accountInfo.DataLen
+	callerAcct.VmDataAddr = accountInfo.DataAddr
+	callerAcct.RefToLenInVm = accountInfoAddr + SolAccountInfoCDataLenOffset
 	callerAcct.Executable = accountInfo.Executable
 	callerAcct.RentEpoch = accountInfo.RentEpoch
 
 	return callerAcct, nil
 }
 
-func callerAccountFromAccountInfoRust(vm sbpf.VM, execCtx *ExecutionCtx, accountInfo SolAccountInfoRust) (CallerAccount, error) {
+func callerAccountFromAccountInfoRust(vm sbpf.VM, execCtx *ExecutionCtx, accountInfoAddr uint64, accountInfo SolAccountInfoRust, originalDataLen uint64) (CallerAccount, error) {
 
 	var callerAcct CallerAccount
 
@@ -430,14 +449,16 @@ func callerAccountFromAccountInfoRust(vm sbpf.VM, execCtx *ExecutionCtx, account
 		return callerAcct, err
 	}
 	callerAcct.Lamports = lamports
+	callerAcct.VmLamportsAddr = lamportsBox.Addr
 
-	ownerAddrBytes, err := vm.Translate(accountInfo.OwnerAddr, solana.PublicKeyLength, false)
+	ownerAddrBytes, err := vm.Translate(accountInfo.OwnerAddr, solana.PublicKeyLength, true)
 	if err != nil {
 		return callerAcct, err
 	}
 	callerAcct.Owner = solana.PublicKeyFromBytes(ownerAddrBytes)
+	callerAcct.VmOwnerAddr = accountInfo.OwnerAddr
 
-	dataBoxBytes, err := vm.Translate(accountInfo.DataBoxAddr, RefCellRustSize, false)
+	dataBoxBytes, err := vm.Translate(accountInfo.DataBoxAddr, RefCellVecRustSize, false)
 	if err != nil {
 		return callerAcct, err
 	}
@@ -456,97 +477,208 @@ func callerAccountFromAccountInfoRust(vm sbpf.VM, execCtx *ExecutionCtx, account
 		return callerAcct, err
 	}
 
-	data, err := vm.Translate(dataBox.Addr, dataBox.Len, false)
-	if err != nil {
-		return callerAcct, err
+	// directly mapped account data is not cached, see updateCallerAccount
+	data := []byte{}
+	if !execCtx.GlobalCtx.Features.IsActive(features.BpfAccountDataDirectMapping) {
+		data, err = sbpf.TranslateSlice(vm, dataBox.Addr, dataBox.Len, 1, true)
+		if err != nil {
+			return callerAcct, err
+		}
 	}
 
+	callerAcct.OriginalDataLen = originalDataLen
 	callerAcct.SerializedData = &data
 	callerAcct.SerializedDataLen = dataBox.Len
+	callerAcct.VmDataAddr = dataBox.Addr
+	callerAcct.RefToLenInVm = accountInfo.DataBoxAddr + RefCellVecRustLenOffset
 	callerAcct.Executable = accountInfo.Executable == 1
 	callerAcct.RentEpoch = accountInfo.RentEpoch
 
 	return callerAcct, nil
 }
 
-func updateCalleeAccount(execCtx *ExecutionCtx, callerAccount CallerAccount, calleeAccount *BorrowedAccount) error {
-	if calleeAccount.Account.Lamports != callerAccount.Lamports {
-		calleeAccount.Account.Lamports = callerAccount.Lamports
-	}
-
-	err1 := calleeAccount.CanDataBeResized(callerAccount.SerializedDataLen)
-	err2 := calleeAccount.DataCanBeChanged(execCtx.GlobalCtx.Features)
+// updateCalleeAccount applies changes the caller made to an account before
+// the CPI, so that the callee observes them.
+func updateCalleeAccount(vm sbpf.VM, execCtx *ExecutionCtx, callerAccount CallerAccount, calleeAccount *BorrowedAccount, isLoaderDeprecated bool) error {
+	f := execCtx.GlobalCtx.Features
 
-	var err error
+	if calleeAccount.Lamports() != callerAccount.Lamports {
+		err := calleeAccount.SetLamports(callerAccount.Lamports, f)
+		if err != nil {
+			return err
+		}
+	}
 
-	if err1 != nil {
-		err = err1
-	} else if err2 != nil {
-		err = err2
+	err := calleeAccount.CanDataBeResized(callerAccount.SerializedDataLen)
+	if err == nil {
+		err = calleeAccount.DataCanBeChanged(f)
+	}
+	if f.IsActive(features.BpfAccountDataDirectMapping) {
+		// the caller modified the account data in place,
+		// only the length and the realloc region need to be applied
+		postLen := callerAccount.SerializedDataLen
+		if err == nil {
+			err = updateCalleeAccountDirect(vm, execCtx, callerAccount, calleeAccount, postLen, isLoaderDeprecated)
+			if err != nil {
+				return err
+			}
+		} else if uint64(len(calleeAccount.Data())) != postLen {
+			return err
+		}
+	} else if err == nil {
+		data := make([]byte, len(*callerAccount.SerializedData))
+		copy(data, *callerAccount.SerializedData)
+		err = calleeAccount.SetData(f, data)
+		if err != nil {
+			return err
+		}
+	} else if !bytes.Equal(*callerAccount.SerializedData, calleeAccount.Data()) {
+		return err
 	}
 
-	// can't change data
-	if err != nil {
-		if !bytes.Equal(*callerAccount.SerializedData, calleeAccount.Data()) {
+	// change the owner last, so that lamports and data could be modified first
+	if calleeAccount.Owner() != callerAccount.Owner {
+		err = calleeAccount.SetOwner(f, callerAccount.Owner)
+		if err != nil {
 			return err
 		}
+	}
 
-		// can't change data, but data didn't actually change anyway
-		return nil
+	return nil
+}
+
+// updateCalleeAccountDirect resizes a directly mapped account to postLen,
+// copying bytes beyond the original length from the caller's realloc region.
+func updateCalleeAccountDirect(vm sbpf.VM, execCtx *ExecutionCtx, callerAccount CallerAccount, calleeAccount *BorrowedAccount, postLen uint64, isLoaderDeprecated bool) error {
+	reallocBytesUsed := safemath.SaturatingSubU64(postLen, callerAccount.OriginalDataLen)
+	// programs of the deprecated loader have no realloc region
+	if isLoaderDeprecated && reallocBytesUsed > 0 {
+		return InstrErrInvalidRealloc
 	}
 
-	err = calleeAccount.SetData(execCtx.GlobalCtx.Features, *callerAccount.SerializedData)
+	err := calleeAccount.SetDataLength(postLen, execCtx.GlobalCtx.Features)
 	if err != nil {
 		return err
 	}
-
-	if calleeAccount.Owner() != callerAccount.Owner {
-		err = calleeAccount.SetOwner(execCtx.GlobalCtx.Features, callerAccount.Owner)
+	if reallocBytesUsed > 0 {
+		reallocData, err := vm.Translate(callerAccount.VmDataAddr+callerAccount.OriginalDataLen, reallocBytesUsed, false)
+		if err != nil {
+			return err
+		}
+		copy(calleeAccount.Data()[callerAccount.OriginalDataLen:postLen], reallocData)
 	}
-
-	return err
+	return nil
 }
 
-func updateCallerAccount(vm sbpf.VM, callerAcct *CallerAccount, calleeAcct *BorrowedAccount) error {
-
+// updateCallerAccount writes changes the callee made to an account back
+// into the caller's memory, after the CPI.
+func updateCallerAccount(vm sbpf.VM, callerAcct *CallerAccount, calleeAcct *BorrowedAccount, isLoaderDeprecated bool) error {
+	directMapping := executionCtx(vm).GlobalCtx.Features.IsActive(features.BpfAccountDataDirectMapping)
 	callerAcct.Lamports = calleeAcct.Lamports()
+	err := vm.Write64(callerAcct.VmLamportsAddr, callerAcct.Lamports)
+	if err != nil {
+		return err
+	}
+
 	callerAcct.Owner = calleeAcct.Owner()
+	err = vm.Write(callerAcct.VmOwnerAddr, callerAcct.Owner[:])
+	if err != nil {
+		return err
+	}
+
+	// the callee may have reallocated the account data, point the
+	// caller's data region at the new backing array
+	zeroAllSpareCapacity := false
+	if directMapping && callerAcct.OriginalDataLen > 0 {
+		data := calleeAcct.Data()
+		if uint64(cap(data)) < callerAcct.OriginalDataLen {
+			newData := make([]byte, len(data), callerAcct.OriginalDataLen)
+			copy(newData, data)
+			calleeAcct.Account.Data = newData
+			data = newData
+		}
+		region, err := vm.Region(callerAcct.VmDataAddr)
+		if err != nil {
+			return err
+		}
+		if cap(region.Data) == 0 || &region.Data[:1][0] != &data[:1][0] {
+			region.Data = data[:callerAcct.OriginalDataLen]
+			zeroAllSpareCapacity = true
+		}
+	}
 
-	prevLen := callerAcct.RefToLenInVm
+	prevLen, err := vm.Read64(callerAcct.RefToLenInVm)
+	if err != nil {
+		return err
+	}
 	postLen := uint64(len(calleeAcct.Data()))
 
 	if prevLen != postLen {
-		// TODO: use constant
-		maxPermittedIncrease := uint64(10240)
+		// programs of the deprecated loader have no realloc region
+		// when account data is mapped directly
+		maxIncrease := uint64(MaxPermittedDataIncrease)
+		if directMapping && isLoaderDeprecated {
+			maxIncrease = 0
+		}
 
 		// account data size increased by too much
-		if postLen > safemath.SaturatingAddU64(callerAcct.SerializedDataLen, maxPermittedIncrease) {
+		if postLen > safemath.SaturatingAddU64(callerAcct.OriginalDataLen, maxIncrease) {
 			return InstrErrInvalidRealloc
 		}
 
+		// zero the memory that was in use before the account shrunk
 		if postLen < prevLen {
-			serializedData := *callerAcct.SerializedData
-			if uint64(len(serializedData)) < postLen {
-				return InstrErrAccountDataTooSmall
+			if directMapping {
+				// only the realloc region is zeroed here,
+				// the spare capacity of the account data is zeroed below
+				if prevLen > callerAcct.OriginalDataLen {
+					dirtyStart := callerAcct.OriginalDataLen
+					if postLen > dirtyStart {
+						dirtyStart = postLen
+					}
+					dirty, err := vm.Translate(callerAcct.VmDataAddr+dirtyStart, prevLen-dirtyStart, true)
+					if err != nil {
+						return err
+					}
+					for i := range dirty {
+						dirty[i] = 0
+					}
+				}
+			} else {
+				serializedData := *callerAcct.SerializedData
+				if uint64(len(serializedData)) < postLen {
+					return InstrErrAccountDataTooSmall
+				}
+				for i := range serializedData[postLen:] {
+					serializedData[postLen+uint64(i)] = 0
+				}
 			}
-			for i := range serializedData[postLen:] {
-				serializedData[i] = 0
+		}
+
+		if !directMapping {
+			sd, err := sbpf.TranslateSlice(vm, callerAcct.VmDataAddr, postLen, 1, true)
+			if err != nil {
+				return err
 			}
+			callerAcct.SerializedData = &sd
 		}
+		callerAcct.SerializedDataLen = postLen
 
-		sd, err := vm.Translate(callerAcct.VmDataAddr, postLen, false)
+		// the length in the AccountInfo data slice
+		err = vm.Write64(callerAcct.RefToLenInVm, postLen)
 		if err != nil {
 			return err
 		}
-		callerAcct.SerializedData = &sd
-		callerAcct.RefToLenInVm = postLen
 
-		ptrAddr := safemath.SaturatingSubU64(callerAcct.VmDataAddr, 8)
-		serializedLenSlice, err := vm.Translate(ptrAddr, 8, false)
+		// the length in the serialized parameters
+		err = vm.Write64(safemath.SaturatingSubU64(callerAcct.VmDataAddr, 8), postLen)
 		if err != nil {
 			return err
 		}
-		binary.LittleEndian.PutUint64(serializedLenSlice, postLen)
+	}
+
+	if directMapping {
+		return updateCallerAccountDirect(vm, callerAcct, calleeAcct, prevLen, postLen, zeroAllSpareCapacity)
 	}
 
 	toSlice := *callerAcct.SerializedData
@@ -567,78 +699,61 @@ func updateCallerAccount(vm sbpf.VM, callerAcct *CallerAccount, calleeAcct *Borr
 	return nil
 }
 
-func translateAndUpdateAccountsC(vm sbpf.VM, instructionAccts []InstructionAccount, programIndices []uint64, accountInfoKeys []solana.PublicKey, accountInfos []SolAccountInfoC, accountInfosAddr uint64, isLoaderDeprecated bool) (TranslatedAccounts, error) {
-	execCtx := executionCtx(vm)
-	txCtx := execCtx.TransactionContext
+// updateCallerAccountDirect zeroes the spare capacity of a directly mapped
+// account that shrunk, and copies data beyond the original length into the
+// caller's realloc region.
+func updateCallerAccountDirect(vm sbpf.VM, callerAcct *CallerAccount, calleeAcct *BorrowedAccount, prevLen uint64, postLen uint64, zeroAllSpareCapacity bool) error {
+	data := calleeAcct.Data()
 
-	ixCtx, err := txCtx.CurrentInstructionCtx()
-	if err != nil {
-		return nil, err
+	dirtyLen := prevLen
+	if zeroAllSpareCapacity {
+		dirtyLen = callerAcct.OriginalDataLen
 	}
-
-	accounts := make(TranslatedAccounts, len(instructionAccts)+1)
-
-	idx := len(programIndices) - 1
-	if idx < 0 {
-		return nil, InstrErrMissingAccount
-	}
-	programAcctIdx := programIndices[idx]
-	accounts = append(accounts, TranslatedAccount{IndexOfAccount: programAcctIdx, CallerAccount: nil})
-
-	for instructionAcctIdx, instructionAcct := range instructionAccts {
-		if uint64(instructionAcctIdx) != instructionAcct.IndexInCallee {
-			continue
+	spareLen := safemath.SaturatingSubU64(dirtyLen, postLen)
+	if spareLen > 0 {
+		spare := data[postLen:cap(data)]
+		if uint64(len(spare)) < spareLen {
+			return InstrErrInvalidRealloc
 		}
-		calleeAcct, err := ixCtx.BorrowInstructionAccount(txCtx, instructionAcct.IndexInCaller)
-		if err != nil {
-			return nil, err
+		for i := range spare[:spareLen] {
+			spare[i] = 0
 		}
+	}
 
-		accountKey, err := txCtx.KeyOfAccountAtIndex(instructionAcct.IndexInTransaction)
+	reallocBytesUsed := safemath.SaturatingSubU64(postLen, callerAcct.OriginalDataLen)
+	if reallocBytesUsed > 0 {
+		toSlice, err := vm.Translate(callerAcct.VmDataAddr+callerAcct.OriginalDataLen, reallocBytesUsed, true)
 		if err != nil {
-			return nil, err
+			return err
 		}
-
-		if calleeAcct.IsExecutable() {
-			cost := uint64(len(calleeAcct.Data()) / CUCpiBytesPerUnit)
-			err = execCtx.ComputeMeter.Consume(cost)
-			if err != nil {
-				return nil, InstrErrComputationalBudgetExceeded
-			}
-		} else {
-			var found bool
-			for index, accountInfoKey := range accountInfoKeys {
-				if accountKey == accountInfoKey {
-					accountInfo := accountInfos[index]
-					callerAcct, err := callerAccountFromAccountInfoC(vm, execCtx, accountInfo)
-					if err != nil {
-						return nil, err
-					}
-					err = updateCalleeAccount(execCtx, callerAcct, calleeAcct)
-					if err != nil {
-						return nil, err
-					}
-
-					var c *CallerAccount
-					if instructionAcct.IsWritable {
-						c = &callerAcct
-					} else {
-						c = nil
-					}
-					accounts = append(accounts, TranslatedAccount{IndexOfAccount: instructionAcct.IndexInCaller, CallerAccount: c})
-					found = true
-				}
-			}
-			if !found {
-				return nil, InstrErrMissingAccount
-			}
+		if uint64(len(data)) < postLen {
+			return InstrErrAccountDataTooSmall
 		}
+		copy(toSlice, data[callerAcct.OriginalDataLen:postLen])
 	}
+	return nil
+}
 
-	return accounts, nil
+// isCallerLoaderDeprecated returns whether the calling program is owned by
+// the deprecated BPF loader, whose programs run without a realloc region.
+func isCallerLoaderDeprecated(txCtx *TransactionCtx, ixCtx *InstructionCtx) (bool, error) {
+	programAcct, err := ixCtx.BorrowLastProgramAccount(txCtx)
+	if err != nil {
+		return false, err
+	}
+	defer programAcct.Drop()
+	return programAcct.Owner() == BpfLoaderDeprecatedAddr, nil
 }
 
-func translateAndUpdateAccountsRust(vm sbpf.VM, instructionAccts []InstructionAccount, programIndices []uint64, accountInfoKeys []solana.PublicKey, accountInfos []SolAccountInfoRust, accountInfosAddr uint64, isLoaderDeprecated bool) (TranslatedAccounts, error) {
+// callerAccountFunc builds the CallerAccount for the account info at the
+// given index, in either the C or the Rust ABI.
+type callerAccountFunc func(vm sbpf.VM, execCtx *ExecutionCtx, index int, originalDataLen uint64) (CallerAccount, error)
+
+// translateAndUpdateAccounts matches the accounts of a CPI with the
+// caller's account infos, applying any changes the caller made to them.
+// Writable accounts are returned with their CallerAccount, for
+// updateCallerAccount to write back the callee's changes.
+func translateAndUpdateAccounts(vm sbpf.VM, instructionAccts []InstructionAccount, programIndices []uint64, accountInfoKeys []solana.PublicKey, callerAccount callerAccountFunc) (TranslatedAccounts, error) {
 	execCtx := executionCtx(vm)
 	txCtx := execCtx.TransactionContext
 
@@ -647,177 +762,130 @@ func translateAndUpdateAccountsRust(vm sbpf.VM, instructionAccts []InstructionAc
 		return nil, err
 	}
 
-	accounts := make(TranslatedAccounts, len(instructionAccts)+1)
-
-	idx := len(programIndices) - 1
-	if idx < 0 {
+	if len(programIndices) == 0 {
 		return nil, InstrErrMissingAccount
 	}
-	programAcctIdx := programIndices[idx]
-	accounts = append(accounts, TranslatedAccount{IndexOfAccount: programAcctIdx, CallerAccount: nil})
+
+	isLoaderDeprecated, err := isCallerLoaderDeprecated(txCtx, ixCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make(TranslatedAccounts, 0, len(instructionAccts)+1)
+	accounts = append(accounts, TranslatedAccount{IndexOfAccount: programIndices[len(programIndices)-1], CallerAccount: nil})
 
 	for instructionAcctIdx, instructionAcct := range instructionAccts {
+		// skip duplicate accounts
 		if uint64(instructionAcctIdx) != instructionAcct.IndexInCallee {
 			continue
 		}
-		calleeAcct, err := ixCtx.BorrowInstructionAccount(txCtx, instructionAcct.IndexInCaller)
-		if err != nil {
-			return nil, err
-		}
-
-		accountKey, err := txCtx.KeyOfAccountAtIndex(instructionAcct.IndexInTransaction)
+		account, err := translateAndUpdateAccount(vm, execCtx, ixCtx, instructionAcct, accountInfoKeys, callerAccount, isLoaderDeprecated)
 		if err != nil {
 			return nil, err
 		}
-
-		if calleeAcct.IsExecutable() {
-			cost := uint64(len(calleeAcct.Data()) / CUCpiBytesPerUnit)
-			err = execCtx.ComputeMeter.Consume(cost)
-			if err != nil {
-				return nil, InstrErrComputationalBudgetExceeded
-			}
-		} else {
-			var found bool
-			for index, accountInfoKey := range accountInfoKeys {
-				if accountKey == accountInfoKey {
-					accountInfo := accountInfos[index]
-					callerAcct, err := callerAccountFromAccountInfoRust(vm, execCtx, accountInfo)
-					if err != nil {
-						return nil, err
-					}
-					err = updateCalleeAccount(execCtx, callerAcct, calleeAcct)
-					if err != nil {
-						return nil, err
-					}
-
-					var c *CallerAccount
-					if instructionAcct.IsWritable {
-						c = &callerAcct
-					} else {
-						c = nil
-					}
-					accounts = append(accounts, TranslatedAccount{IndexOfAccount: instructionAcct.IndexInCaller, CallerAccount: c})
-					found = true
-				}
-			}
-			if !found {
-				return nil, InstrErrMissingAccount
-			}
-		}
+		accounts = append(accounts, account)
 	}
 
 	return accounts, nil
 }
 
-func translateAccountsC(vm sbpf.VM, instructionAccts []InstructionAccount, programIndices []uint64, accountInfosAddr uint64, accountInfosLen uint64, isLoaderDeprecated bool) (TranslatedAccounts, error) {
+// translateAndUpdateAccount matches an account of a CPI with the caller's
+// account info, applying any changes the caller made to it.
+func translateAndUpdateAccount(vm sbpf.VM, execCtx *ExecutionCtx, ixCtx *InstructionCtx, instructionAcct InstructionAccount, accountInfoKeys []solana.PublicKey, callerAccount callerAccountFunc, isLoaderDeprecated bool) (TranslatedAccount, error) {
+	txCtx := execCtx.TransactionContext
 
-	accountInfos, accountInfoKeys, err := translateAccountInfosC(vm, accountInfosAddr, accountInfosLen)
+	calleeAcct, err := ixCtx.BorrowInstructionAccount(txCtx, instructionAcct.IndexInCaller)
 	if err != nil {
-		return nil, err
+		return TranslatedAccount{}, err
 	}
+	defer calleeAcct.Drop()
 
-	return translateAndUpdateAccountsC(vm, instructionAccts, programIndices, accountInfoKeys, accountInfos, accountInfosAddr, isLoaderDeprecated)
-}
-
-func translateAccountsRust(vm sbpf.VM, instructionAccts []InstructionAccount, programIndices []uint64, accountInfosAddr uint64, accountInfosLen uint64, isLoaderDeprecated bool) (TranslatedAccounts, error) {
-
-	accountInfos, accountInfoKeys, err := translateAccountInfosRust(vm, accountInfosAddr, accountInfosLen)
+	accountKey, err := txCtx.KeyOfAccountAtIndex(instructionAcct.IndexInTransaction)
 	if err != nil {
-		return nil, err
+		return TranslatedAccount{}, err
 	}
 
-	return translateAndUpdateAccountsRust(vm, instructionAccts, programIndices, accountInfoKeys, accountInfos, accountInfosAddr, isLoaderDeprecated)
-}
-
-// SyscallInvokeSignedCImpl is an implementation of the sol_invoke_signed_c syscall
-func SyscallInvokeSignedCImpl(vm sbpf.VM, instructionAddr, accountInfosAddr, accountInfosLen, signerSeedsAddr, signerSeedsLen uint64) (r0 uint64, err error) {
-	execCtx := executionCtx(vm)
-	err = execCtx.ComputeMeter.Consume(CUInvokeUnits)
-	if err != nil {
-		return
+	if calleeAcct.IsExecutable(execCtx.GlobalCtx.Features) {
+		cost := uint64(len(calleeAcct.Data()) / CUCpiBytesPerUnit)
+		err = execCtx.ComputeMeter.Consume(cost)
+		if err != nil {
+			return TranslatedAccount{}, InstrErrComputationalBudgetExceeded
+		}
+		return TranslatedAccount{IndexOfAccount: instructionAcct.IndexInCaller, CallerAccount: nil}, nil
 	}
 
-	// translate instruction
-	ix, err := translateInstructionC(vm, instructionAddr)
-	if err != nil {
-		return
+	index := -1
+	for i, accountInfoKey := range accountInfoKeys {
+		if accountKey == accountInfoKey {
+			index = i
+			break
+		}
 	}
-
-	txCtx := transactionCtx(vm)
-	instructionCtx, err := txCtx.CurrentInstructionCtx()
-	if err != nil {
-		return
+	if index == -1 {
+		klog.Infof("instruction references an unknown account %s", accountKey)
+		return TranslatedAccount{}, InstrErrMissingAccount
 	}
 
-	callerProgramId, err := instructionCtx.LastProgramKey(txCtx)
-
-	// translate signers
-	signers, err := translateSigners(vm, callerProgramId, signerSeedsAddr, signerSeedsAddr)
-
-	fmt.Printf("got C ABI CPI call from programId: %s -----> %s, %d signers\n", callerProgramId, ix.ProgramId, len(signers))
+	if instructionAcct.IndexInCaller >= uint64(len(execCtx.originalDataLens)) {
+		return TranslatedAccount{}, InstrErrMissingAccount
+	}
+	originalDataLen := execCtx.originalDataLens[instructionAcct.IndexInCaller]
 
-	var isLoaderDeprecated bool
-	lastProgramAcct, err := instructionCtx.BorrowLastProgramAccount(txCtx)
+	callerAcct, err := callerAccount(vm, execCtx, index, originalDataLen)
 	if err != nil {
-		r0 = 1
-		return
+		return TranslatedAccount{}, err
 	}
-	if lastProgramAcct.Owner() == BpfLoaderDeprecatedAddr {
-		isLoaderDeprecated = true
+	err = updateCalleeAccount(vm, execCtx, callerAcct, calleeAcct, isLoaderDeprecated)
+	if err != nil {
+		return TranslatedAccount{}, err
 	}
 
-	instructionAccts, programIndices, err := execCtx.PrepareInstruction(ix, signers)
-	if err != nil {
-		r0 = 1
-		return
+	var c *CallerAccount
+	if instructionAcct.IsWritable {
+		c = &callerAcct
 	}
+	return TranslatedAccount{IndexOfAccount: instructionAcct.IndexInCaller, CallerAccount: c}, nil
+}
 
-	err = checkAuthorizedProgram(execCtx, ix.ProgramId, ix.Data)
+func translateAccountsC(vm sbpf.VM, instructionAccts []InstructionAccount, programIndices []uint64, accountInfosAddr uint64, accountInfosLen uint64) (TranslatedAccounts, error) {
+	accountInfos, accountInfoKeys, err := translateAccountInfosC(vm, accountInfosAddr, accountInfosLen)
 	if err != nil {
-		r0 = 1
-		return
+		return nil, err
 	}
 
-	accounts, err := translateAccountsC(vm, instructionAccts, programIndices, accountInfosAddr, accountInfosLen, isLoaderDeprecated)
-	if err != nil {
-		r0 = 1
-		return
+	callerAccount := func(vm sbpf.VM, execCtx *ExecutionCtx, index int, originalDataLen uint64) (CallerAccount, error) {
+		accountInfoAddr := accountInfosAddr + uint64(index)*SolAccountInfoCSize
+		return callerAccountFromAccountInfoC(vm, execCtx, accountInfoAddr, accountInfos[index], originalDataLen)
 	}
+	return translateAndUpdateAccounts(vm, instructionAccts, programIndices, accountInfoKeys, callerAccount)
+}
 
-	err = execCtx.ProcessInstruction(ix.Data, instructionAccts, programIndices)
+func translateAccountsRust(vm sbpf.VM, instructionAccts []InstructionAccount, programIndices []uint64, accountInfosAddr uint64, accountInfosLen uint64) (TranslatedAccounts, error) {
+	accountInfos, accountInfoKeys, err := translateAccountInfosRust(vm, accountInfosAddr, accountInfosLen)
 	if err != nil {
-		r0 = uint64(translateErrToInstrErrCode(err))
-		return
+		return nil, err
 	}
 
-	for _, acct := range accounts {
-		var calleeAcct *BorrowedAccount
-		calleeAcct, err = instructionCtx.BorrowInstructionAccount(txCtx, acct.IndexOfAccount)
-		if err != nil {
-			return
-		}
-		err = updateCallerAccount(vm, acct.CallerAccount, calleeAcct)
-		if err != nil {
-			return
-		}
+	callerAccount := func(vm sbpf.VM, execCtx *ExecutionCtx, index int, originalDataLen uint64) (CallerAccount, error) {
+		accountInfoAddr := accountInfosAddr + uint64(index)*SolAccountInfoRustSize
+		return callerAccountFromAccountInfoRust(vm, execCtx, accountInfoAddr, accountInfos[index], originalDataLen)
 	}
-
-	r0 = 0
-	return
+	return translateAndUpdateAccounts(vm, instructionAccts, programIndices, accountInfoKeys, callerAccount)
 }
 
-var SyscallInvokeSignedC = sbpf.SyscallFunc5(SyscallInvokeSignedCImpl)
-
-// SyscallInvokeSignedRustImpl is an implementation of the sol_invoke_signed_rust syscall
-func SyscallInvokeSignedRustImpl(vm sbpf.VM, instructionAddr, accountInfosAddr, accountInfosLen, signerSeedsAddr, signerSeedsLen uint64) (r0 uint64, err error) {
+// invokeSigned implements the CPI syscalls, given the functions that
+// translate the instruction and the account infos for the caller's ABI.
+func invokeSigned(vm sbpf.VM, instructionAddr, accountInfosAddr, accountInfosLen, signerSeedsAddr, signerSeedsLen uint64,
+	translateInstruction func(vm sbpf.VM, addr uint64) (Instruction, error),
+	translateAccounts func(vm sbpf.VM, instructionAccts []InstructionAccount, programIndices []uint64, accountInfosAddr uint64, accountInfosLen uint64) (TranslatedAccounts, error),
+) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 	err = execCtx.ComputeMeter.Consume(CUInvokeUnits)
 	if err != nil {
 		return
 	}
 
-	// translate instruction
-	ix, err := translateInstructionRust(vm, instructionAddr)
+	ix, err := translateInstruction(vm, instructionAddr)
 	if err != nil {
 		return
 	}
@@ -829,53 +897,51 @@ func SyscallInvokeSignedRustImpl(vm sbpf.VM, instructionAddr, accountInfosAddr,
 	}
 
 	callerProgramId, err := instructionCtx.LastProgramKey(txCtx)
-
-	// translate signers
-	signers, err := translateSigners(vm, callerProgramId, signerSeedsAddr, signerSeedsAddr)
-
-	fmt.Printf("got Rust ABI CPI call from programId: %s -----> %s, %d signers\n", callerProgramId, ix.ProgramId, len(signers))
-
-	var isLoaderDeprecated bool
-	lastProgramAcct, err := instructionCtx.BorrowLastProgramAccount(txCtx)
 	if err != nil {
-		r0 = 1
 		return
 	}
-	if lastProgramAcct.Owner() == BpfLoaderDeprecatedAddr {
-		isLoaderDeprecated = true
+
+	signers, err := translateSigners(vm, callerProgramId, signerSeedsAddr, signerSeedsLen)
+	if err != nil {
+		return
 	}
 
 	instructionAccts, programIndices, err := execCtx.PrepareInstruction(ix, signers)
 	if err != nil {
-		r0 = 1
 		return
 	}
 
 	err = checkAuthorizedProgram(execCtx, ix.ProgramId, ix.Data)
 	if err != nil {
-		r0 = 1
 		return
 	}
 
-	accounts, err := translateAccountsRust(vm, instructionAccts, programIndices, accountInfosAddr, accountInfosLen, isLoaderDeprecated)
+	accounts, err := translateAccounts(vm, instructionAccts, programIndices, accountInfosAddr, accountInfosLen)
+	if err != nil {
+		return
+	}
+
+	isLoaderDeprecated, err := isCallerLoaderDeprecated(txCtx, instructionCtx)
 	if err != nil {
-		r0 = 1
 		return
 	}
 
 	err = execCtx.ProcessInstruction(ix.Data, instructionAccts, programIndices)
 	if err != nil {
-		r0 = uint64(translateErrToInstrErrCode(err))
 		return
 	}
 
 	for _, acct := range accounts {
+		if acct.CallerAccount == nil {
+			continue
+		}
 		var calleeAcct *BorrowedAccount
 		calleeAcct, err = instructionCtx.BorrowInstructionAccount(txCtx, acct.IndexOfAccount)
 		if err != nil {
 			return
 		}
-		err = updateCallerAccount(vm, acct.CallerAccount, calleeAcct)
+		err = updateCallerAccount(vm, acct.CallerAccount, calleeAcct, isLoaderDeprecated)
+		calleeAcct.Drop()
 		if err != nil {
 			return
 		}
@@ -885,4 +951,16 @@ func SyscallInvokeSignedRustImpl(vm sbpf.VM, instructionAddr, accountInfosAddr,
 	return
 }
 
+// SyscallInvokeSignedCImpl is an implementation of the sol_invoke_signed_c syscall
+func SyscallInvokeSignedCImpl(vm sbpf.VM, instructionAddr, accountInfosAddr, accountInfosLen, signerSeedsAddr, signerSeedsLen uint64) (r0 uint64, err error) {
+	return invokeSigned(vm, instructionAddr, accountInfosAddr, accountInfosLen, signerSeedsAddr, signerSeedsLen, translateInstructionC, translateAccountsC)
+}
+
+var SyscallInvokeSignedC = sbpf.SyscallFunc5(SyscallInvokeSignedCImpl)
+
+// SyscallInvokeSignedRustImpl is an implementation of the sol_invoke_signed_rust syscall
+func SyscallInvokeSignedRustImpl(vm sbpf.VM, instructionAddr, accountInfosAddr, accountInfosLen, signerSeedsAddr, signerSeedsLen uint64) (r0 uint64, err error) {
+	return invokeSigned(vm, instructionAddr, accountInfosAddr, accountInfosLen, signerSeedsAddr, signerSeedsLen, translateInstructionRust, translateAccountsRust)
+}
+
 var SyscallInvokeSignedRust = sbpf.SyscallFunc5(SyscallInvokeSignedRustImpl)
@@ -0,0 +1,114 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestSyscallInvokeSignedC_Transfer(t *testing.T) {
+	from, to := solana.PublicKey{1}, solana.PublicKey{2}
+	fromAcct := &accounts.Account{Lamports: 1000, Owner: SystemProgramAddr}
+	toAcct := &accounts.Account{Lamports: 10, Owner: SystemProgramAddr}
+	systemAcct := &accounts.Account{Owner: NativeLoaderAddr, Executable: true}
+
+	execCtx := newTestLoaderExecCtx(solana.PublicKey{9}, *features.NewFeaturesDefault(), nil, []testInstrAcct{
+		{from, fromAcct, true, true},
+		{to, toAcct, false, true},
+		{SystemProgramAddr, systemAcct, false, false},
+	})
+	execCtx.originalDataLens = []uint64{0, 0, 0}
+
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(&execCtx.GlobalCtx, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	const (
+		ixAddr           = sbpf.VaddrHeap
+		programIdAddr    = sbpf.VaddrHeap + 0x100
+		accountMetasAddr = sbpf.VaddrHeap + 0x200
+		dataAddr         = sbpf.VaddrHeap + 0x300
+		keysAddr         = sbpf.VaddrHeap + 0x400
+		accountInfosAddr = sbpf.VaddrHeap + 0x500
+		lamportsAddr     = sbpf.VaddrHeap + 0x600
+		ownersAddr       = sbpf.VaddrHeap + 0x700
+		accountDataAddr  = sbpf.VaddrHeap + 0x808
+	)
+	write64 := func(addr uint64, x uint64) { require.NoError(t, vm.Write64(addr, x)) }
+
+	// SolInstruction
+	write64(ixAddr, programIdAddr)
+	write64(ixAddr+8, accountMetasAddr)
+	write64(ixAddr+16, 2)
+	write64(ixAddr+24, dataAddr)
+	write64(ixAddr+32, 12)
+	require.NoError(t, vm.Write(programIdAddr, SystemProgramAddr[:]))
+
+	// SolAccountMeta: pubkey, is_writable, is_signer
+	write64(accountMetasAddr, keysAddr)
+	require.NoError(t, vm.Write(accountMetasAddr+8, []byte{1, 1}))
+	write64(accountMetasAddr+SolAccountMetaCSize, keysAddr+32)
+	require.NoError(t, vm.Write(accountMetasAddr+SolAccountMetaCSize+8, []byte{1, 0}))
+
+	// SystemInstruction::Transfer
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint32(data, SystemProgramInstrTypeTransfer)
+	binary.LittleEndian.PutUint64(data[4:], 300)
+	require.NoError(t, vm.Write(dataAddr, data))
+
+	// SolAccountInfo: key, lamports, data_len, data, owner, rent_epoch, flags
+	for i, acct := range []struct {
+		key      solana.PublicKey
+		lamports uint64
+	}{{from, 1000}, {to, 10}} {
+		off := uint64(i)
+		require.NoError(t, vm.Write(keysAddr+32*off, acct.key[:]))
+		write64(lamportsAddr+8*off, acct.lamports)
+		require.NoError(t, vm.Write(ownersAddr+32*off, SystemProgramAddr[:]))
+
+		info := accountInfosAddr + SolAccountInfoCSize*off
+		write64(info, keysAddr+32*off)
+		write64(info+8, lamportsAddr+8*off)
+		write64(info+16, 0)
+		write64(info+24, accountDataAddr)
+		write64(info+32, ownersAddr+32*off)
+		require.NoError(t, vm.Write(info+48, []byte{1 - byte(i), 1, 0}))
+	}
+
+	r0, err := SyscallInvokeSignedCImpl(vm, ixAddr, accountInfosAddr, 2, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), r0)
+
+	assert.Equal(t, uint64(700), fromAcct.Lamports)
+	assert.Equal(t, uint64(310), toAcct.Lamports)
+
+	// the callee's changes are written back into the caller's account infos
+	fromLamports, err := vm.Read64(lamportsAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(700), fromLamports)
+	toLamports, err := vm.Read64(lamportsAddr + 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(310), toLamports)
+}
+
+func TestSyscallInvokeSignedC_PrivilegeEscalation(t *testing.T) {
+	from, to := solana.PublicKey{1}, solana.PublicKey{2}
+	fromAcct := &accounts.Account{Lamports: 1000, Owner: SystemProgramAddr}
+	toAcct := &accounts.Account{Lamports: 10, Owner: SystemProgramAddr}
+	systemAcct := &accounts.Account{Owner: NativeLoaderAddr, Executable: true}
+
+	// the caller did not sign for the source account
+	execCtx := newTestLoaderExecCtx(solana.PublicKey{9}, *features.NewFeaturesDefault(), nil, []testInstrAcct{
+		{from, fromAcct, false, true},
+		{to, toAcct, false, true},
+		{SystemProgramAddr, systemAcct, false, false},
+	})
+
+	_, _, err := execCtx.PrepareInstruction(*newTransferInstruction(from, to, 300), nil)
+	assert.Equal(t, InstrErrPrivilegeEscalation, err)
+}
@@ -2,17 +2,23 @@ package sealevel
 
 import (
 	"crypto/sha256"
+	"hash"
 	"unsafe"
 
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
 	"github.com/zeebo/blake3"
+	"go.firedancer.io/radiance/pkg/features"
 	"go.firedancer.io/radiance/pkg/safemath"
 	"go.firedancer.io/radiance/pkg/sbpf"
 	"golang.org/x/crypto/sha3"
 )
 
-// SyscallSha256Impl is the implementation for the sol_sha256 syscall
-func SyscallSha256Impl(vm sbpf.VM, valsAddr, valsLen, resultsAddr uint64) (r0 uint64, err error) {
+// syscallHash implements the hashing syscalls. It hashes the concatenation
+// of valsLen byte slices, described at valsAddr as [ptr (u64)] [size (u64)]
+// pairs, and writes the 32 byte digest to resultsAddr.
+//
+// Based on solana_bpf_loader_program::syscalls::SyscallHash.
+func syscallHash(vm sbpf.VM, valsAddr, valsLen, resultsAddr uint64, hasher hash.Hash) (r0 uint64, err error) {
 	if valsLen > CUSha256MaxSlices {
 		err = SyscallErrTooManySlices
 		return
@@ -29,176 +35,160 @@ func SyscallSha256Impl(vm sbpf.VM, valsAddr, valsLen, resultsAddr uint64) (r0 ui
 		return
 	}
 
-	hasher := sha256.New()
-	if valsLen > 0 {
-		var vals []byte
+	// Safety: valsLen*16 cannot overflow because of the check versus CUSha256MaxSlices above
+	vals, err := sbpf.TranslateSlice(vm, valsAddr, valsLen, 16, false)
+	if err != nil {
+		return
+	}
+
+	for idx := uint64(0); idx < uint64(len(vals)); idx += 16 {
+		dataPtr := *(*uint64)(unsafe.Pointer(&vals[idx]))
+		dataSize := *(*uint64)(unsafe.Pointer(&vals[idx+8]))
 
-		// The data at 'valsAddr' consists of an array of 'slice references', which consists
-		// of: [ptr (u64)] [size (u64)], hence 16 bytes for each of the slice references that
-		// refers to an input value to hash.
-		// Safety: valsLen*16 cannot overflow because of the check versus CUSha256MaxSlices above
-		vals, err = vm.Translate(valsAddr, valsLen*16, false)
+		var data []byte
+		data, err = sbpf.TranslateSlice(vm, dataPtr, dataSize, 1, false)
 		if err != nil {
 			return
 		}
 
-		var data []byte
-		var idx uint64
-
-		for count := uint64(0); count < valsLen; count++ {
-			dataPtr := *(*uint64)(unsafe.Pointer(&vals[idx]))
-			dataSize := *(*uint64)(unsafe.Pointer(&vals[idx+8]))
-			idx += 16
-
-			data, err = vm.Translate(dataPtr, dataSize, false)
-			if err != nil {
-				return
-			}
-
-			cost := safemath.SaturatingMulU64(CUSha256ByteCost, dataSize) / 2
-			if CUMemOpBaseCost > cost {
-				cost = CUMemOpBaseCost
-			}
-			err = execCtx.ComputeMeter.Consume(cost)
-			if err != nil {
-				return
-			}
-			hasher.Write(data)
+		cost := safemath.SaturatingMulU64(CUSha256ByteCost, dataSize/2)
+		if CUMemOpBaseCost > cost {
+			cost = CUMemOpBaseCost
+		}
+		err = execCtx.ComputeMeter.Consume(cost)
+		if err != nil {
+			return
 		}
+
+		hasher.Write(data)
 	}
-	copy(hashResult[:], hasher.Sum(nil))
+	copy(hashResult, hasher.Sum(nil))
 	return
 }
 
+// SyscallSha256Impl is the implementation for the sol_sha256 syscall
+func SyscallSha256Impl(vm sbpf.VM, valsAddr, valsLen, resultsAddr uint64) (r0 uint64, err error) {
+	return syscallHash(vm, valsAddr, valsLen, resultsAddr, sha256.New())
+}
+
 var SyscallSha256 = sbpf.SyscallFunc3(SyscallSha256Impl)
 
 // SyscallKeccak256Impl is the implementation for the sol_keccak256 syscall
 func SyscallKeccak256Impl(vm sbpf.VM, valsAddr, valsLen, resultsAddr uint64) (r0 uint64, err error) {
-	if valsLen > CUSha256MaxSlices {
-		err = SyscallErrTooManySlices
-		return
-	}
-
-	execCtx := executionCtx(vm)
-	err = execCtx.ComputeMeter.Consume(CUSha256BaseCost)
-	if err != nil {
-		return
-	}
-
-	hashResult, err := vm.Translate(resultsAddr, 32, true)
-	if err != nil {
-		return
-	}
-
-	hasher := sha3.NewLegacyKeccak256()
-	if valsLen > 0 {
-		var vals []byte
+	return syscallHash(vm, valsAddr, valsLen, resultsAddr, sha3.NewLegacyKeccak256())
+}
 
-		// The data at 'valsAddr' consists of an array of 'slice references', which consists
-		// of: [ptr (u64)] [size (u64)], hence 16 bytes for each of the slice references that
-		// refers to an input value to hash.
-		// Safety: valsLen*16 cannot overflow because of the check versus CUSha256MaxSlices above
-		vals, err = vm.Translate(valsAddr, valsLen*16, false)
-		if err != nil {
-			return
-		}
+var SyscallKeccak256 = sbpf.SyscallFunc3(SyscallKeccak256Impl)
 
-		var data []byte
-		var idx uint64
+// SyscallBlake3Impl is the implementation for the sol_blake3 syscall
+func SyscallBlake3Impl(vm sbpf.VM, valsAddr, valsLen, resultsAddr uint64) (r0 uint64, err error) {
+	return syscallHash(vm, valsAddr, valsLen, resultsAddr, blake3.New())
+}
 
-		for count := uint64(0); count < valsLen; count++ {
-			dataPtr := *(*uint64)(unsafe.Pointer(&vals[idx]))
-			dataSize := *(*uint64)(unsafe.Pointer(&vals[idx+8]))
-			idx += 16
+var SyscallBlake3 = sbpf.SyscallFunc3(SyscallBlake3Impl)
 
-			data, err = vm.Translate(dataPtr, dataSize, false)
-			if err != nil {
-				return
-			}
+// sol_poseidon parameters and endianness
+const (
+	PoseidonParametersBn254X5 = 0
 
-			cost := safemath.SaturatingMulU64(CUSha256ByteCost, dataSize) / 2
-			if CUMemOpBaseCost > cost {
-				cost = CUMemOpBaseCost
-			}
+	PoseidonBigEndian    = 0
+	PoseidonLittleEndian = 1
+)
 
-			err = execCtx.ComputeMeter.Consume(cost)
-			if err != nil {
-				return
-			}
+// sol_poseidon error codes, returned in r0. Since
+// simplify_alt_bn128_syscall_error_codes, all failures return 1.
+//
+// Based on solana_program::poseidon::PoseidonSyscallError.
+const (
+	PoseidonErrEmptyInput             = 4
+	PoseidonErrInputLargerThanModulus = 7
+	PoseidonErrInvalidWidthCircom     = 11
+)
 
-			hasher.Write(data)
-		}
+// SyscallPoseidonImpl is the implementation for the sol_poseidon syscall.
+// It hashes valsLen field elements, described at valsAddr as
+// [ptr (u64)] [size (u64)] pairs, and writes the 32 byte hash to resultAddr.
+func SyscallPoseidonImpl(vm sbpf.VM, parameters, endianness, valsAddr, valsLen, resultAddr uint64) (r0 uint64, err error) {
+	if parameters != PoseidonParametersBn254X5 {
+		err = SyscallErrInvalidPoseidonParameters
+		return
 	}
-	copy(hashResult[:], hasher.Sum(nil))
-	return
-}
-
-var SyscallKeccak256 = sbpf.SyscallFunc3(SyscallKeccak256Impl)
-
-// SyscallBlake3Impl is the implementation for the sol_blake3 syscall
-func SyscallBlake3Impl(vm sbpf.VM, valsAddr, valsLen, resultsAddr uint64) (r0 uint64, err error) {
-	if valsLen > CUSha256MaxSlices {
-		err = SyscallErrTooManySlices
+	if endianness != PoseidonBigEndian && endianness != PoseidonLittleEndian {
+		err = SyscallErrInvalidEndianness
+		return
+	}
+	if valsLen > PoseidonMaxInputs {
+		err = SyscallErrInvalidLength
 		return
 	}
 
 	execCtx := executionCtx(vm)
-	err = execCtx.ComputeMeter.Consume(CUSha256BaseCost)
+	cost := CUPoseidonCostCoefficientA*valsLen*valsLen + CUPoseidonCostCoefficientC
+	err = execCtx.ComputeMeter.Consume(cost)
 	if err != nil {
 		return
 	}
 
-	hashResult, err := vm.Translate(resultsAddr, 32, true)
+	hashResult, err := vm.Translate(resultAddr, 32, true)
 	if err != nil {
 		return
 	}
 
-	hasher := blake3.New()
-	if valsLen > 0 {
-		var vals []byte
+	vals, err := sbpf.TranslateSlice(vm, valsAddr, valsLen, 16, false)
+	if err != nil {
+		return
+	}
 
-		// The data at 'valsAddr' consists of an array of 'slice references', which consists
-		// of: [ptr (u64)] [size (u64)], hence 16 bytes for each of the slice references that
-		// refers to an input value to hash.
-		// Safety: valsLen*16 cannot overflow because of the check versus CUSha256MaxSlices above
-		vals, err = vm.Translate(valsAddr, valsLen*16, false)
+	inputs := make([][]byte, 0, valsLen)
+	for idx := uint64(0); idx < uint64(len(vals)); idx += 16 {
+		dataPtr := *(*uint64)(unsafe.Pointer(&vals[idx]))
+		dataSize := *(*uint64)(unsafe.Pointer(&vals[idx+8]))
+
+		var data []byte
+		data, err = sbpf.TranslateSlice(vm, dataPtr, dataSize, 1, false)
 		if err != nil {
 			return
 		}
+		inputs = append(inputs, data)
+	}
 
-		var data []byte
-		var idx uint64
-
-		for count := uint64(0); count < valsLen; count++ {
-			dataPtr := *(*uint64)(unsafe.Pointer(&vals[idx]))
-			dataSize := *(*uint64)(unsafe.Pointer(&vals[idx+8]))
-			idx += 16
-
-			data, err = vm.Translate(dataPtr, dataSize, false)
-			if err != nil {
-				return
-			}
-
-			cost := safemath.SaturatingMulU64(CUSha256ByteCost, dataSize) / 2
-			if CUMemOpBaseCost > cost {
-				cost = CUMemOpBaseCost
-			}
-
-			err = execCtx.ComputeMeter.Consume(cost)
-			if err != nil {
-				return
-			}
-
-			hasher.Write(data)
+	hash, hashErr := poseidonHash(inputs, endianness == PoseidonBigEndian)
+	if hashErr != nil {
+		if execCtx.GlobalCtx.Features.IsActive(features.SimplifyAltBn128SyscallErrorCodes) {
+			r0 = 1
+			return
 		}
+		switch hashErr {
+		case errPoseidonEmptyInput:
+			r0 = PoseidonErrEmptyInput
+		case errPoseidonInputLargerThanModulus:
+			r0 = PoseidonErrInputLargerThanModulus
+		default:
+			r0 = PoseidonErrInvalidWidthCircom
+		}
+		return
 	}
-	copy(hashResult[:], hasher.Sum(nil))
+
+	copy(hashResult, hash)
 	return
 }
 
-var SyscallBlake3 = sbpf.SyscallFunc3(SyscallBlake3Impl)
+var SyscallPoseidon = sbpf.SyscallFunc5(SyscallPoseidonImpl)
+
+// sol_secp256k1_recover error codes, returned in r0.
+//
+// Based on solana_program::secp256k1_recover::Secp256k1RecoverError.
+const (
+	Secp256k1RecoverErrInvalidHash = iota + 1
+	Secp256k1RecoverErrInvalidRecoveryId
+	Secp256k1RecoverErrInvalidSignature
+)
 
-// SyscallSecp256k1Recover is an implementation of the sol_secp256k1_recover syscall
+// SyscallSecp256k1Recover is an implementation of the sol_secp256k1_recover syscall.
+// It recovers the public key that produced the signature over the 32 byte
+// hash, and writes it to resultAddr in its 64 byte uncompressed form,
+// without the 0x04 prefix. Recovery failures are returned as error codes
+// in r0 rather than aborting the program.
 func SyscallSecp256k1RecoverImpl(vm sbpf.VM, hashAddr, recoveryIdVal, signatureAddr, resultAddr uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 	err = execCtx.ComputeMeter.Consume(CUSecP256k1RecoverCost)
@@ -226,15 +216,15 @@ func SyscallSecp256k1RecoverImpl(vm sbpf.VM, hashAddr, recoveryIdVal, signatureA
 	// because all the `parse_slice` function checks for is len(hash) == 32, which is always
 	// the case.
 
-	// check for invalid recovery ID
 	if recoveryIdVal >= 4 {
-		r0 = 2 // Secp256k1RecoverError::InvalidHash
+		r0 = Secp256k1RecoverErrInvalidRecoveryId
 		return
 	}
 
 	err = parseAndValidateSignature(signature)
 	if err != nil {
-		r0 = 3 // Secp256k1RecoverError::InvalidSignature
+		err = nil
+		r0 = Secp256k1RecoverErrInvalidSignature
 		return
 	}
 
@@ -244,11 +234,13 @@ func SyscallSecp256k1RecoverImpl(vm sbpf.VM, hashAddr, recoveryIdVal, signatureA
 
 	recoveredPubKey, err := secp256k1.RecoverPubkey(hash, sigAndRecoveryId)
 	if err != nil {
-		r0 = 3 // Secp256k1RecoverError::InvalidSignature
+		err = nil
+		r0 = Secp256k1RecoverErrInvalidSignature
 		return
 	}
 
-	copy(recoverResult, recoveredPubKey)
+	// skip the 0x04 tag of the uncompressed encoding
+	copy(recoverResult, recoveredPubKey[1:])
 	r0 = 0
 	return
 }
@@ -0,0 +1,86 @@
+package sealevel
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestSyscallSha256(t *testing.T) {
+	execCtx := &ExecutionCtx{ComputeMeter: cu.NewComputeMeter(1000)}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	const (
+		valsAddr   = sbpf.VaddrHeap
+		dataAddr   = sbpf.VaddrHeap + 0x100
+		resultAddr = sbpf.VaddrHeap + 0x200
+	)
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	require.NoError(t, vm.Write(dataAddr, data))
+	require.NoError(t, vm.Write64(valsAddr, dataAddr))
+	require.NoError(t, vm.Write64(valsAddr+8, 100))
+	require.NoError(t, vm.Write64(valsAddr+16, dataAddr))
+	require.NoError(t, vm.Write64(valsAddr+24, 4))
+
+	_, err := SyscallSha256Impl(vm, valsAddr, 2, resultAddr)
+	require.NoError(t, err)
+
+	expected := sha256.Sum256(append(append([]byte{}, data...), data[:4]...))
+	result, err := vm.Translate(resultAddr, 32, false)
+	require.NoError(t, err)
+	assert.Equal(t, expected[:], result)
+
+	// base cost, then half a unit per byte with a minimum per slice
+	assert.Equal(t, uint64(1000-CUSha256BaseCost-50-CUMemOpBaseCost), execCtx.ComputeMeter.Remaining())
+
+	_, err = SyscallSha256Impl(vm, valsAddr, CUSha256MaxSlices+1, resultAddr)
+	assert.Equal(t, SyscallErrTooManySlices, err)
+}
+
+func TestSyscallSecp256k1Recover(t *testing.T) {
+	execCtx := &ExecutionCtx{ComputeMeter: cu.NewComputeMeter(CUMaxComputeUnitLimit)}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	const (
+		hashAddr   = sbpf.VaddrHeap
+		sigAddr    = sbpf.VaddrHeap + 0x100
+		resultAddr = sbpf.VaddrHeap + 0x200
+	)
+	hash := sha256.Sum256([]byte("hello"))
+	seckey := bytes.Repeat([]byte{0x11}, 32)
+	sig, err := secp256k1.Sign(hash[:], seckey)
+	require.NoError(t, err)
+	pubkey, err := secp256k1.RecoverPubkey(hash[:], sig)
+	require.NoError(t, err)
+
+	require.NoError(t, vm.Write(hashAddr, hash[:]))
+	require.NoError(t, vm.Write(sigAddr, sig[:64]))
+
+	r0, err := SyscallSecp256k1RecoverImpl(vm, hashAddr, uint64(sig[64]), sigAddr, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), r0)
+	result, err := vm.Translate(resultAddr, 64, false)
+	require.NoError(t, err)
+	assert.Equal(t, pubkey[1:], result)
+
+	// recovery failures are reported in r0
+	r0, err = SyscallSecp256k1RecoverImpl(vm, hashAddr, 4, sigAddr, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(Secp256k1RecoverErrInvalidRecoveryId), r0)
+
+	require.NoError(t, vm.Write(sigAddr, bytes.Repeat([]byte{0xFF}, 32)))
+	r0, err = SyscallSecp256k1RecoverImpl(vm, hashAddr, uint64(sig[64]), sigAddr, resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(Secp256k1RecoverErrInvalidSignature), r0)
+}
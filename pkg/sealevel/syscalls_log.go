@@ -3,6 +3,8 @@ package sealevel
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 	"unsafe"
 
 	"github.com/gagliardetto/solana-go"
@@ -25,8 +27,12 @@ func SyscallLogImpl(vm sbpf.VM, ptr, strlen uint64) (r0 uint64, err error) {
 		return
 	}
 
-	buf := make([]byte, strlen)
-	if err = vm.Read(ptr, buf); err != nil {
+	buf, err := vm.Translate(ptr, strlen, false)
+	if err != nil {
+		return
+	}
+	if !utf8.Valid(buf) {
+		err = SyscallErrInvalidString
 		return
 	}
 	execCtx.Log.Log("Program log: " + string(buf))
@@ -42,7 +48,7 @@ func SyscallLog64Impl(vm sbpf.VM, r1, r2, r3, r4, r5 uint64) (r0 uint64, err err
 		return
 	}
 
-	msg := fmt.Sprintf("Program log: %#x, %#x, %#x, %#x, %#x\n", r1, r2, r3, r4, r5)
+	msg := fmt.Sprintf("Program log: %#x, %#x, %#x, %#x, %#x", r1, r2, r3, r4, r5)
 	execCtx.Log.Log(msg)
 	return
 }
@@ -89,39 +95,22 @@ func SyscallLogDataImpl(vm sbpf.VM, addr uint64, len uint64) (r0 uint64, err err
 		return
 	}
 
-	size, err := safemath.CheckedMulU64(len, 16)
+	mem, err := sbpf.TranslateSlice(vm, addr, len, 16, false)
 	if err != nil {
 		return
 	}
 
-	mem, err := vm.Translate(addr, size, false)
+	// every field costs the syscall base cost plus one unit per byte, all
+	// charged before any field is translated
+	err = execCtx.ComputeMeter.Consume(safemath.SaturatingMulU64(CUSyscallBaseCost, len))
 	if err != nil {
 		return
 	}
 
-	msg := ""
 	totalSize := uint64(0)
-
-	var data []byte
-	var idx uint64
-
-	for count := uint64(0); count < len; count++ {
-		dataPtr := *(*uint64)(unsafe.Pointer(&mem[idx]))
+	for idx := uint64(0); idx < len*16; idx += 16 {
 		dataSize := *(*uint64)(unsafe.Pointer(&mem[idx+8]))
-		idx += 16
-
 		totalSize = safemath.SaturatingAddU64(totalSize, dataSize)
-
-		data, err = vm.Translate(dataPtr, dataSize, false)
-		if err != nil {
-			return
-		}
-		encodedStr := base64.StdEncoding.EncodeToString(data)
-		if count != len-1 {
-			msg += fmt.Sprintf(" ")
-		}
-
-		msg += fmt.Sprintf("%s", encodedStr)
 	}
 
 	err = execCtx.ComputeMeter.Consume(totalSize)
@@ -129,9 +118,20 @@ func SyscallLogDataImpl(vm sbpf.VM, addr uint64, len uint64) (r0 uint64, err err
 		return
 	}
 
-	execCtx.Log.Log("Program log: " + msg)
+	fields := make([]string, 0, len)
+	for idx := uint64(0); idx < len*16; idx += 16 {
+		dataPtr := *(*uint64)(unsafe.Pointer(&mem[idx]))
+		dataSize := *(*uint64)(unsafe.Pointer(&mem[idx+8]))
+
+		var data []byte
+		data, err = sbpf.TranslateSlice(vm, dataPtr, dataSize, 1, false)
+		if err != nil {
+			return
+		}
+		fields = append(fields, base64.StdEncoding.EncodeToString(data))
+	}
 
-	r0 = 0
+	execCtx.Log.Log("Program data: " + strings.Join(fields, " "))
 	return
 }
 
@@ -0,0 +1,42 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestSyscallLogData(t *testing.T) {
+	log := NewLogCollector(LogCollectorDefaultBytesLimit)
+	execCtx := &ExecutionCtx{Log: log, ComputeMeter: cu.NewComputeMeter(10000)}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	const (
+		valsAddr = sbpf.VaddrHeap
+		dataAddr = sbpf.VaddrHeap + 0x100
+	)
+	require.NoError(t, vm.Write(dataAddr, []byte("hello world")))
+	require.NoError(t, vm.Write64(valsAddr, dataAddr))
+	require.NoError(t, vm.Write64(valsAddr+8, 5))
+	require.NoError(t, vm.Write64(valsAddr+16, dataAddr+6))
+	require.NoError(t, vm.Write64(valsAddr+24, 5))
+
+	_, err := SyscallLogDataImpl(vm, valsAddr, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Program data: aGVsbG8= d29ybGQ="}, log.Messages)
+	assert.Equal(t, uint64(10000-3*CUSyscallBaseCost-10), execCtx.ComputeMeter.Remaining())
+}
+
+func TestSyscallLog_InvalidString(t *testing.T) {
+	execCtx := &ExecutionCtx{Log: NewLogCollector(LogCollectorDefaultBytesLimit), ComputeMeter: cu.NewComputeMeter(10000)}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	require.NoError(t, vm.Write(sbpf.VaddrHeap, []byte{0xff, 0xfe}))
+	_, err := SyscallLogImpl(vm, sbpf.VaddrHeap, 2)
+	assert.Equal(t, SyscallErrInvalidString, err)
+}
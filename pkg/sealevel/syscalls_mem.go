@@ -1,9 +1,16 @@
 package sealevel
 
 import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/safemath"
 	"go.firedancer.io/radiance/pkg/sbpf"
 )
 
+// MemOpConsume charges the cost of a memory operation over n bytes.
 func MemOpConsume(execCtx *ExecutionCtx, n uint64) error {
 	perBytesCost := n / CUCpiBytesPerUnit
 	var cost uint64
@@ -15,16 +22,6 @@ func MemOpConsume(execCtx *ExecutionCtx, n uint64) error {
 	return execCtx.ComputeMeter.Consume(cost)
 }
 
-func memmoveImplInternal(vm sbpf.VM, dst, src, n uint64) (err error) {
-	srcBuf := make([]byte, n)
-	err = vm.Read(src, srcBuf)
-	if err != nil {
-		return
-	}
-	err = vm.Write(dst, srcBuf)
-	return
-}
-
 // SyscallMemcpyImpl is the implementation of the memcpy (sol_memcpy_) syscall.
 // Overlapping src and dst for a given n bytes to be copied results in an error being returned.
 func SyscallMemcpyImpl(vm sbpf.VM, dst, src, n uint64) (r0 uint64, err error) {
@@ -40,13 +37,32 @@ func SyscallMemcpyImpl(vm sbpf.VM, dst, src, n uint64) (r0 uint64, err error) {
 		return r0, SyscallErrCopyOverlapping
 	}
 
-	err = memmoveImplInternal(vm, dst, src, n)
+	dstBuf, err := vm.Translate(dst, n, true)
+	if err != nil {
+		return
+	}
+	srcBuf, err := vm.Translate(src, n, false)
+	if err != nil {
+		return
+	}
+
+	// distinct virtual ranges may still be backed by the same host memory
+	if execCtx.GlobalCtx.Features.IsActive(features.CheckPhysicalOverlapping) && n != 0 {
+		dstPtr := uint64(uintptr(unsafe.Pointer(&dstBuf[0])))
+		srcPtr := uint64(uintptr(unsafe.Pointer(&srcBuf[0])))
+		if !isNonOverlapping(srcPtr, n, dstPtr, n) {
+			return r0, SyscallErrCopyOverlapping
+		}
+	}
+
+	copy(dstBuf, srcBuf)
 	return
 }
 
 var SyscallMemcpy = sbpf.SyscallFunc3(SyscallMemcpyImpl)
 
 // SyscallMemmoveImpl is the implementation for the memmove (sol_memmove_) syscall.
+// Unlike memcpy, src and dst may overlap.
 func SyscallMemmoveImpl(vm sbpf.VM, dst, src, n uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 	err = MemOpConsume(execCtx, n)
@@ -54,13 +70,24 @@ func SyscallMemmoveImpl(vm sbpf.VM, dst, src, n uint64) (r0 uint64, err error) {
 		return
 	}
 
-	err = memmoveImplInternal(vm, dst, src, n)
+	dstBuf, err := vm.Translate(dst, n, true)
+	if err != nil {
+		return
+	}
+	srcBuf, err := vm.Translate(src, n, false)
+	if err != nil {
+		return
+	}
+
+	copy(dstBuf, srcBuf)
 	return
 }
 
 var SyscallMemmove = sbpf.SyscallFunc3(SyscallMemmoveImpl)
 
 // SyscallMemcmpImpl is the implementation for the memcmp (sol_memcmp_) syscall.
+// The i32 result, written to resultAddr, is the difference of the first
+// pair of differing bytes, or 0 if the memory areas are equal.
 func SyscallMemcmpImpl(vm sbpf.VM, addr1, addr2, n, resultAddr uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 	err = MemOpConsume(execCtx, n)
@@ -78,6 +105,11 @@ func SyscallMemcmpImpl(vm sbpf.VM, addr1, addr2, n, resultAddr uint64) (r0 uint6
 		return
 	}
 
+	result, err := vm.Translate(resultAddr, 4, true)
+	if err != nil {
+		return
+	}
+
 	cmpResult := int32(0)
 	for count := uint64(0); count < n; count++ {
 		b1 := slice1[count]
@@ -87,13 +119,13 @@ func SyscallMemcmpImpl(vm sbpf.VM, addr1, addr2, n, resultAddr uint64) (r0 uint6
 			break
 		}
 	}
-	err = vm.Write32(resultAddr, uint32(cmpResult))
+	binary.LittleEndian.PutUint32(result, uint32(cmpResult))
 	return
 }
 
 var SyscallMemcmp = sbpf.SyscallFunc4(SyscallMemcmpImpl)
 
-// SyscallMemcmpImpl is the implementation for the memset (sol_memset_) syscall.
+// SyscallMemsetImpl is the implementation for the memset (sol_memset_) syscall.
 func SyscallMemsetImpl(vm sbpf.VM, dst, c, n uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 	err = MemOpConsume(execCtx, n)
@@ -114,3 +146,47 @@ func SyscallMemsetImpl(vm sbpf.VM, dst, c, n uint64) (r0 uint64, err error) {
 }
 
 var SyscallMemset = sbpf.SyscallFunc3(SyscallMemsetImpl)
+
+// heapAllocator is the bump allocator backing sol_alloc_free_, for old
+// programs that allocate heap memory through the runtime. Memory is never
+// freed.
+//
+// Based on solana_bpf_loader_program::allocator_bump::BpfAllocator.
+type heapAllocator struct {
+	size  uint64
+	pos   uint64
+	align uint64
+}
+
+// alloc returns the address of a fresh allocation of the given size, or
+// false if the heap is exhausted.
+func (a *heapAllocator) alloc(size uint64) (uint64, bool) {
+	bytesToAlign := (a.align - a.pos%a.align) % a.align
+	end := safemath.SaturatingAddU64(safemath.SaturatingAddU64(a.pos, bytesToAlign), size)
+	if end > a.size {
+		return 0, false
+	}
+	addr := sbpf.VaddrHeap + a.pos + bytesToAlign
+	a.pos = end
+	return addr, true
+}
+
+// SyscallAllocFreeImpl is the implementation of the sol_alloc_free_ syscall.
+// Returns the address of the new allocation, or 0 if the allocation failed.
+// Freeing memory is a no-op.
+func SyscallAllocFreeImpl(vm sbpf.VM, size, freeAddr uint64) (r0 uint64, err error) {
+	allocator := executionCtx(vm).allocator
+	if allocator == nil || freeAddr != 0 {
+		return
+	}
+	// layouts larger than isize::MAX are rejected
+	if size > math.MaxInt64-(allocator.align-1) {
+		return
+	}
+	if addr, ok := allocator.alloc(size); ok {
+		r0 = addr
+	}
+	return
+}
+
+var SyscallAllocFree = sbpf.SyscallFunc2(SyscallAllocFreeImpl)
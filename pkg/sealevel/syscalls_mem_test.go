@@ -0,0 +1,88 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestSyscallAllocFree(t *testing.T) {
+	execCtx := newTestLoaderExecCtx(solana.PublicKey{9}, *features.NewFeaturesDefault(), nil, nil)
+	execCtx.allocator = &heapAllocator{size: 32, align: 8}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(&execCtx.GlobalCtx, program, &sbpf.VMOpts{HeapSize: 32, Context: execCtx})
+
+	addr, err := SyscallAllocFreeImpl(vm, 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, sbpf.VaddrHeap, addr)
+
+	// allocations are aligned
+	addr, err = SyscallAllocFreeImpl(vm, 8, 0)
+	require.NoError(t, err)
+	assert.Equal(t, sbpf.VaddrHeap+8, addr)
+
+	// freeing is a no-op
+	addr, err = SyscallAllocFreeImpl(vm, 8, sbpf.VaddrHeap)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), addr)
+
+	// the heap is exhausted
+	addr, err = SyscallAllocFreeImpl(vm, 17, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), addr)
+
+	addr, err = SyscallAllocFreeImpl(vm, 16, 0)
+	require.NoError(t, err)
+	assert.Equal(t, sbpf.VaddrHeap+16, addr)
+}
+
+func TestSyscallMemOps(t *testing.T) {
+	execCtx := &ExecutionCtx{ComputeMeter: cu.NewComputeMeter(10000)}
+	execCtx.GlobalCtx.Features = *features.NewFeaturesDefault()
+	execCtx.GlobalCtx.Features.EnableFeature(features.CheckPhysicalOverlapping, 0)
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	require.NoError(t, vm.Write(sbpf.VaddrHeap, []byte("abcdefgh")))
+
+	// memcpy rejects overlapping ranges, memmove does not
+	_, err := SyscallMemcpyImpl(vm, sbpf.VaddrHeap+2, sbpf.VaddrHeap, 4)
+	assert.Equal(t, SyscallErrCopyOverlapping, err)
+	_, err = SyscallMemmoveImpl(vm, sbpf.VaddrHeap+2, sbpf.VaddrHeap, 4)
+	require.NoError(t, err)
+	mem, err := vm.Translate(sbpf.VaddrHeap, 8, false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ababcdgh"), mem)
+
+	_, err = SyscallMemcpyImpl(vm, sbpf.VaddrHeap+0x100, sbpf.VaddrHeap, 8)
+	require.NoError(t, err)
+	_, err = SyscallMemsetImpl(vm, sbpf.VaddrHeap+0x104, 'z', 2)
+	require.NoError(t, err)
+	mem, err = vm.Translate(sbpf.VaddrHeap+0x100, 8, false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ababzzgh"), mem)
+
+	const resultAddr = sbpf.VaddrHeap + 0x200
+	_, err = SyscallMemcmpImpl(vm, sbpf.VaddrHeap, sbpf.VaddrHeap+0x100, 8, resultAddr)
+	require.NoError(t, err)
+	result, err := vm.Read32(resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, int32('c'-'z'), int32(result))
+
+	_, err = SyscallMemcmpImpl(vm, sbpf.VaddrHeap, sbpf.VaddrHeap+0x100, 4, resultAddr)
+	require.NoError(t, err)
+	result, err = vm.Read32(resultAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), result)
+
+	// every op costs the base cost, or one unit per CUCpiBytesPerUnit bytes
+	assert.Equal(t, uint64(10000-6*CUMemOpBaseCost), execCtx.ComputeMeter.Remaining())
+	_, err = SyscallMemsetImpl(vm, sbpf.VaddrHeap, 0, 0x1000)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10000-6*CUMemOpBaseCost-0x1000/CUCpiBytesPerUnit), execCtx.ComputeMeter.Remaining())
+}
@@ -1,9 +1,10 @@
 package sealevel
 
 import (
+	"errors"
+	"math"
 	"unsafe"
 
-	"github.com/ethereum/go-ethereum/common/math"
 	"go.firedancer.io/radiance/pkg/sbpf"
 	"go.firedancer.io/radiance/pkg/solana"
 )
@@ -11,16 +12,44 @@ import (
 const MaxSeeds = 16
 const MaxSeedLen = 32
 
-func translateAndValidateSeeds(vm sbpf.VM, seedsAddr, seedsLen uint64) ([][]byte, error) {
-	if seedsLen > MaxSeeds {
-		return nil, SyscallErrMaxSeedLengthExceeded
+var errNoViableBumpSeed = errors.New("unable to find a viable program address bump seed")
+
+// findProgramAddress returns the first valid program address for the given
+// seeds with a bump seed appended, trying bump seeds from 255 down to 1.
+// If onMiss is non-nil, it is called for each bump seed that yields no valid
+// address, and its error aborts the search.
+//
+// Based on solana_program::pubkey::Pubkey::try_find_program_address.
+func findProgramAddress(seeds [][]byte, programId []byte, onMiss func() error) (address []byte, bumpSeed uint8, err error) {
+	seedsWithBump := make([][]byte, len(seeds), len(seeds)+1)
+	copy(seedsWithBump, seeds)
+	seedsWithBump = append(seedsWithBump, []byte{0})
+
+	for bumpSeed = math.MaxUint8; bumpSeed > 0; bumpSeed-- {
+		seedsWithBump[len(seeds)][0] = bumpSeed
+		address, err = solana.CreateProgramAddressBytes(seedsWithBump, programId)
+		if err == nil {
+			return
+		}
+		if onMiss != nil {
+			if err = onMiss(); err != nil {
+				return nil, 0, err
+			}
+		}
 	}
+	return nil, 0, errNoViableBumpSeed
+}
 
-	seedsData, err := vm.Translate(seedsAddr, seedsLen*16, false)
+func translateAndValidateSeeds(vm sbpf.VM, seedsAddr, seedsLen uint64) ([][]byte, error) {
+	seedsData, err := sbpf.TranslateSlice(vm, seedsAddr, seedsLen, 16, false)
 	if err != nil {
 		return nil, err
 	}
 
+	if seedsLen > MaxSeeds {
+		return nil, SyscallErrMaxSeedLengthExceeded
+	}
+
 	var data []byte
 	var idx uint64
 	seedsRet := make([][]byte, 0)
@@ -34,7 +63,7 @@ func translateAndValidateSeeds(vm sbpf.VM, seedsAddr, seedsLen uint64) ([][]byte
 			return nil, SyscallErrMaxSeedLengthExceeded
 		}
 
-		data, err = vm.Translate(dataPtr, dataSize, false)
+		data, err = sbpf.TranslateSlice(vm, dataPtr, dataSize, 1, false)
 		if err != nil {
 			return nil, err
 		}
@@ -94,42 +123,30 @@ func SyscallTryFindProgramAddressImpl(vm sbpf.VM, seedsAddr, seedsLen, programId
 		return
 	}
 
-	for bumpSeed := uint8(math.MaxUint8); bumpSeed > 0; bumpSeed-- {
-		seedsWithBump := make([][]byte, len(seeds))
-		for i := range seeds {
-			seedsWithBump[i] = make([]byte, len(seeds[i]))
-			copy(seedsWithBump[i], seeds[i])
-		}
-		seedsWithBump = append(seedsWithBump, []byte{bumpSeed})
-
-		var newAddress []byte
-		newAddress, err = solana.CreateProgramAddressBytes(seedsWithBump, programId)
-		if err == nil {
-			var bumpSeedOut []byte
-			bumpSeedOut, err = vm.Translate(bumpSeedAddr, 1, true)
-			if err != nil {
-				return
-			}
-			var addressOut []byte
-			addressOut, err = vm.Translate(addressAddr, 32, true)
-			if err != nil {
-				return
-			}
-			if !isNonOverlapping(bumpSeedAddr, 1, addressAddr, 32) {
-				err = SyscallErrCopyOverlapping
-				return
-			}
-			bumpSeedOut[0] = bumpSeed
-			copy(addressOut, newAddress)
-			return 0, nil
-		}
-		err = execCtx.ComputeMeter.Consume(CUCreateProgramAddressUnits)
-		if err != nil {
-			return
-		}
+	newAddress, bumpSeed, err := findProgramAddress(seeds, programId, func() error {
+		return execCtx.ComputeMeter.Consume(CUCreateProgramAddressUnits)
+	})
+	if err == errNoViableBumpSeed {
+		return 1, nil
+	} else if err != nil {
+		return
 	}
 
-	return 1, nil
+	bumpSeedOut, err := vm.Translate(bumpSeedAddr, 1, true)
+	if err != nil {
+		return
+	}
+	addressOut, err := vm.Translate(addressAddr, 32, true)
+	if err != nil {
+		return
+	}
+	if !isNonOverlapping(bumpSeedAddr, 1, addressAddr, 32) {
+		err = SyscallErrCopyOverlapping
+		return
+	}
+	bumpSeedOut[0] = bumpSeed
+	copy(addressOut, newAddress)
+	return 0, nil
 }
 
 var SyscallTryFindProgramAddress = sbpf.SyscallFunc5(SyscallTryFindProgramAddressImpl)
@@ -0,0 +1,66 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestFindProgramAddress(t *testing.T) {
+	programId := solana.MustPublicKeyFromBase58("BPFLoaderUpgradeab1e11111111111111111111111")
+	seeds := [][]byte{[]byte("seed"), {1, 2, 3}}
+
+	expectedAddr, expectedBump, err := solana.FindProgramAddress(seeds, programId)
+	require.NoError(t, err)
+
+	var misses int
+	addr, bump, err := findProgramAddress(seeds, programId[:], func() error {
+		misses++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, expectedAddr[:], addr)
+	assert.Equal(t, expectedBump, bump)
+	assert.Equal(t, 255-int(bump), misses)
+
+	// with the bump seed, there are too many seeds for any address to be valid
+	tooManySeeds := make([][]byte, MaxSeeds)
+	_, _, err = findProgramAddress(tooManySeeds, programId[:], nil)
+	assert.Equal(t, errNoViableBumpSeed, err)
+}
+
+func TestSyscallCreateProgramAddress_SeedLimits(t *testing.T) {
+	execCtx := &ExecutionCtx{ComputeMeter: cu.NewComputeMeter(CUMaxComputeUnitLimit)}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	const (
+		seedsAddr     = sbpf.VaddrHeap
+		seedAddr      = sbpf.VaddrHeap + 0x200
+		programIdAddr = sbpf.VaddrHeap + 0x300
+		addressAddr   = sbpf.VaddrHeap + 0x400
+	)
+	for i := uint64(0); i <= MaxSeeds; i++ {
+		require.NoError(t, vm.Write64(seedsAddr+16*i, seedAddr))
+		require.NoError(t, vm.Write64(seedsAddr+16*i+8, 1))
+	}
+
+	_, err := SyscallCreateProgramAddressImpl(vm, seedsAddr, MaxSeeds+1, programIdAddr, addressAddr)
+	assert.Equal(t, SyscallErrMaxSeedLengthExceeded, err)
+
+	require.NoError(t, vm.Write64(seedsAddr+8, MaxSeedLen+1))
+	_, err = SyscallCreateProgramAddressImpl(vm, seedsAddr, 1, programIdAddr, addressAddr)
+	assert.Equal(t, SyscallErrMaxSeedLengthExceeded, err)
+
+	// 16 seeds leave no room for the bump seed; every attempt is charged
+	require.NoError(t, vm.Write64(seedsAddr+8, 1))
+	remaining := execCtx.ComputeMeter.Remaining()
+	r0, err := SyscallTryFindProgramAddressImpl(vm, seedsAddr, MaxSeeds, programIdAddr, addressAddr, addressAddr+32)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), r0)
+	assert.Equal(t, remaining-256*CUCreateProgramAddressUnits, execCtx.ComputeMeter.Remaining())
+}
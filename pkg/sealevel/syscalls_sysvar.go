@@ -2,15 +2,24 @@ package sealevel
 
 import (
 	"encoding/binary"
+	"math"
 
+	"go.firedancer.io/radiance/pkg/safemath"
 	"go.firedancer.io/radiance/pkg/sbpf"
 )
 
+// The sol_get_*_sysvar syscalls copy a sysvar from the sysvar cache into
+// program memory, laid out as the corresponding repr(C) Rust struct.
+// Each charges the sysvar base cost plus the size of the struct, and fails
+// with InstrErrUnsupportedSysvar if the sysvar is not in the cache.
+//
+// Based on solana_bpf_loader_program::syscalls::sysvar::get_sysvar.
+
 // SyscallGetClockSysvarImpl is an implementation of the sol_get_clock_sysvar syscall
 func SyscallGetClockSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 
-	cost := uint64(CUSyscallBaseCost + SysvarClockStructLen)
+	cost := uint64(CUSysvarBaseCost + SysvarClockStructLen)
 	err = execCtx.ComputeMeter.Consume(cost)
 	if err != nil {
 		return
@@ -21,7 +30,10 @@ func SyscallGetClockSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err error) {
 		return
 	}
 
-	clock := ReadClockSysvar(getAccounts(vm))
+	clock, err := execCtx.SysvarCache.Clock()
+	if err != nil {
+		return
+	}
 
 	binary.LittleEndian.PutUint64(clockDst[:8], clock.Slot)
 	binary.LittleEndian.PutUint64(clockDst[8:16], uint64(clock.EpochStartTimestamp))
@@ -39,7 +51,7 @@ var SyscallGetClockSysvar = sbpf.SyscallFunc1(SyscallGetClockSysvarImpl)
 func SyscallGetRentSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 
-	cost := uint64(CUSyscallBaseCost + SysvarRentStructLen)
+	cost := uint64(CUSysvarBaseCost + SysvarRentStructLen)
 	err = execCtx.ComputeMeter.Consume(cost)
 	if err != nil {
 		return
@@ -50,10 +62,13 @@ func SyscallGetRentSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err error) {
 		return
 	}
 
-	rent := ReadRentSysvar(getAccounts(vm))
+	rent, err := execCtx.SysvarCache.Rent()
+	if err != nil {
+		return
+	}
 
 	binary.LittleEndian.PutUint64(rentDst[:8], rent.LamportsPerUint8Year)
-	binary.LittleEndian.PutUint64(rentDst[8:16], uint64(rent.ExemptionThreshold))
+	binary.LittleEndian.PutUint64(rentDst[8:16], math.Float64bits(rent.ExemptionThreshold))
 	rentDst[16] = rent.BurnPercent
 
 	r0 = 0
@@ -66,7 +81,7 @@ var SyscallGetRentSysvar = sbpf.SyscallFunc1(SyscallGetRentSysvarImpl)
 func SyscallGetEpochScheduleSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 
-	cost := uint64(CUSyscallBaseCost + SysvarEpochScheduleStructLen)
+	cost := uint64(CUSysvarBaseCost + SysvarEpochScheduleStructLen)
 	err = execCtx.ComputeMeter.Consume(cost)
 	if err != nil {
 		return
@@ -77,10 +92,13 @@ func SyscallGetEpochScheduleSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err
 		return
 	}
 
-	epochSchedule := ReadEpochScheduleSysvar(getAccounts(vm))
+	epochSchedule, err := execCtx.SysvarCache.EpochSchedule()
+	if err != nil {
+		return
+	}
 
 	binary.LittleEndian.PutUint64(epochScheduleDst[:8], epochSchedule.SlotsPerEpoch)
-	binary.LittleEndian.PutUint64(epochScheduleDst[8:16], uint64(epochSchedule.LeaderScheduleSlotOffset))
+	binary.LittleEndian.PutUint64(epochScheduleDst[8:16], epochSchedule.LeaderScheduleSlotOffset)
 
 	if epochSchedule.Warmup {
 		epochScheduleDst[16] = 1
@@ -88,8 +106,8 @@ func SyscallGetEpochScheduleSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err
 		epochScheduleDst[16] = 0
 	}
 
-	binary.LittleEndian.PutUint64(epochScheduleDst[17:25], epochSchedule.FirstNormalEpoch)
-	binary.LittleEndian.PutUint64(epochScheduleDst[25:33], epochSchedule.FirstNormalSlot)
+	binary.LittleEndian.PutUint64(epochScheduleDst[24:32], epochSchedule.FirstNormalEpoch)
+	binary.LittleEndian.PutUint64(epochScheduleDst[32:40], epochSchedule.FirstNormalSlot)
 
 	r0 = 0
 	return
@@ -97,11 +115,39 @@ func SyscallGetEpochScheduleSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err
 
 var SyscallGetEpochScheduleSysvar = sbpf.SyscallFunc1(SyscallGetEpochScheduleSysvarImpl)
 
+// SyscallGetFeesSysvarImpl is an implementation of the deprecated sol_get_fees_sysvar syscall
+func SyscallGetFeesSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err error) {
+	execCtx := executionCtx(vm)
+
+	cost := uint64(CUSysvarBaseCost + SysvarFeesStructLen)
+	err = execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return
+	}
+
+	feesDst, err := vm.Translate(addr, SysvarFeesStructLen, true)
+	if err != nil {
+		return
+	}
+
+	fees, err := execCtx.SysvarCache.Fees()
+	if err != nil {
+		return
+	}
+
+	binary.LittleEndian.PutUint64(feesDst[:8], fees.FeeCalculator.LamportsPerSignature)
+
+	r0 = 0
+	return
+}
+
+var SyscallGetFeesSysvar = sbpf.SyscallFunc1(SyscallGetFeesSysvarImpl)
+
 // SyscallGetEpochRewardsSysvarImpl is an implementation of the sol_get_epoch_rewards_sysvar syscall
 func SyscallGetEpochRewardsSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 
-	cost := uint64(CUSyscallBaseCost + SysvarEpochRewardsStructLen)
+	cost := uint64(CUSysvarBaseCost + SysvarEpochRewardsStructLen)
 	err = execCtx.ComputeMeter.Consume(cost)
 	if err != nil {
 		return
@@ -112,11 +158,14 @@ func SyscallGetEpochRewardsSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err e
 		return
 	}
 
-	epochRewards := ReadEpochRewardsSysvar(getAccounts(vm))
+	epochRewards, err := execCtx.SysvarCache.EpochRewards()
+	if err != nil {
+		return
+	}
 
 	binary.LittleEndian.PutUint64(epochRewardsDst[:8], epochRewards.TotalRewards)
 	binary.LittleEndian.PutUint64(epochRewardsDst[8:16], epochRewards.DistributedRewards)
-	binary.LittleEndian.PutUint64(epochRewardsDst[8:16], epochRewards.DistributionCompleteBlockHeight)
+	binary.LittleEndian.PutUint64(epochRewardsDst[16:24], epochRewards.DistributionCompleteBlockHeight)
 
 	r0 = 0
 	return
@@ -128,7 +177,7 @@ var SyscallGetEpochRewardsSysvar = sbpf.SyscallFunc1(SyscallGetEpochRewardsSysva
 func SyscallGetLastRestartSlotSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, err error) {
 	execCtx := executionCtx(vm)
 
-	cost := uint64(CUSyscallBaseCost + SysvarLastRestartSlotStructLen)
+	cost := uint64(CUSysvarBaseCost + SysvarLastRestartSlotStructLen)
 	err = execCtx.ComputeMeter.Consume(cost)
 	if err != nil {
 		return
@@ -139,7 +188,10 @@ func SyscallGetLastRestartSlotSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, er
 		return
 	}
 
-	lrs := ReadLastRestartSlotSysvar(getAccounts(vm))
+	lrs, err := execCtx.SysvarCache.LastRestartSlot()
+	if err != nil {
+		return
+	}
 
 	binary.LittleEndian.PutUint64(lastRestartSlotDst[:8], lrs.LastRestartSlot)
 
@@ -148,3 +200,65 @@ func SyscallGetLastRestartSlotSysvarImpl(vm sbpf.VM, addr uint64) (r0 uint64, er
 }
 
 var SyscallGetLastRestartSlotSysvar = sbpf.SyscallFunc1(SyscallGetLastRestartSlotSysvarImpl)
+
+// sol_get_sysvar return codes
+const (
+	GetSysvarOffsetLengthExceedsSysvar = 1
+	GetSysvarSysvarNotFound            = 2
+)
+
+// SyscallGetSysvarImpl is an implementation of the generic sol_get_sysvar syscall
+// (SIMD-0127). It copies length bytes at offset of the account data of the
+// sysvar with the given address. Returns GetSysvarSysvarNotFound if the sysvar
+// is not in the sysvar cache, and GetSysvarOffsetLengthExceedsSysvar if the
+// range exceeds the sysvar data.
+func SyscallGetSysvarImpl(vm sbpf.VM, sysvarIdAddr, varAddr, offset, length uint64) (r0 uint64, err error) {
+	execCtx := executionCtx(vm)
+
+	sysvarBufCost := length / CUCpiBytesPerUnit
+	if sysvarBufCost < CUMemOpBaseCost {
+		sysvarBufCost = CUMemOpBaseCost
+	}
+	cost := safemath.SaturatingAddU64(CUSysvarBaseCost+32/CUCpiBytesPerUnit, sysvarBufCost)
+	err = execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return
+	}
+
+	sysvarId, err := vm.Translate(sysvarIdAddr, 32, false)
+	if err != nil {
+		return
+	}
+
+	varDst, err := vm.Translate(varAddr, length, true)
+	if err != nil {
+		return
+	}
+
+	offsetLength, err := safemath.CheckedAddU64(offset, length)
+	if err != nil {
+		err = InstrErrArithmeticOverflow
+		return
+	}
+	if _, err = safemath.CheckedAddU64(varAddr, length); err != nil {
+		err = InstrErrArithmeticOverflow
+		return
+	}
+
+	sysvarBuf, ok := execCtx.SysvarCache.SysvarData(*(*[32]byte)(sysvarId))
+	if !ok {
+		r0 = GetSysvarSysvarNotFound
+		return
+	}
+
+	if offsetLength > uint64(len(sysvarBuf)) {
+		r0 = GetSysvarOffsetLengthExceedsSysvar
+		return
+	}
+	copy(varDst, sysvarBuf[offset:offsetLength])
+
+	r0 = 0
+	return
+}
+
+var SyscallGetSysvar = sbpf.SyscallFunc4(SyscallGetSysvarImpl)
@@ -0,0 +1,133 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestSysvarCache_FillFromAccounts(t *testing.T) {
+	var sysvarCache SysvarCache
+	sysvarCache.FillFromAccounts(newTestClockAccounts(t, 42))
+
+	clock, err := sysvarCache.Clock()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), clock.Slot)
+
+	clockData, ok := sysvarCache.SysvarData(SysvarClockAddr)
+	require.True(t, ok)
+	assert.Equal(t, uint64(42), binary.LittleEndian.Uint64(clockData))
+
+	_, err = sysvarCache.Rent()
+	assert.Equal(t, InstrErrUnsupportedSysvar, err)
+}
+
+func TestSyscallGetSysvar(t *testing.T) {
+	execCtx := &ExecutionCtx{ComputeMeter: cu.NewComputeMeter(10000)}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	// sysvars missing from the cache are unsupported
+	_, err := SyscallGetRentSysvarImpl(vm, sbpf.VaddrHeap)
+	assert.Equal(t, InstrErrUnsupportedSysvar, err)
+	assert.Equal(t, uint64(10000-CUSysvarBaseCost-SysvarRentStructLen), execCtx.ComputeMeter.Remaining())
+
+	execCtx.SysvarCache.SetRent(SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50})
+	_, err = SyscallGetRentSysvarImpl(vm, sbpf.VaddrHeap)
+	require.NoError(t, err)
+	rent, err := vm.Translate(sbpf.VaddrHeap, SysvarRentStructLen, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3480), binary.LittleEndian.Uint64(rent[0:8]))
+	assert.Equal(t, 2.0, math.Float64frombits(binary.LittleEndian.Uint64(rent[8:16])))
+	assert.Equal(t, byte(50), rent[16])
+
+	execCtx.SysvarCache.SetEpochSchedule(SysvarEpochSchedule{
+		SlotsPerEpoch:            432000,
+		LeaderScheduleSlotOffset: 432000,
+		Warmup:                   true,
+		FirstNormalEpoch:         14,
+		FirstNormalSlot:          524256,
+	})
+	_, err = SyscallGetEpochScheduleSysvarImpl(vm, sbpf.VaddrHeap+0x100)
+	require.NoError(t, err)
+	epochSchedule, err := vm.Translate(sbpf.VaddrHeap+0x100, SysvarEpochScheduleStructLen, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(432000), binary.LittleEndian.Uint64(epochSchedule[0:8]))
+	assert.Equal(t, uint64(432000), binary.LittleEndian.Uint64(epochSchedule[8:16]))
+	assert.Equal(t, byte(1), epochSchedule[16])
+	assert.Equal(t, uint64(14), binary.LittleEndian.Uint64(epochSchedule[24:32]))
+	assert.Equal(t, uint64(524256), binary.LittleEndian.Uint64(epochSchedule[32:40]))
+
+	execCtx.SysvarCache.SetEpochRewards(SysvarEpochRewards{TotalRewards: 1, DistributedRewards: 2, DistributionCompleteBlockHeight: 3})
+	_, err = SyscallGetEpochRewardsSysvarImpl(vm, sbpf.VaddrHeap+0x200)
+	require.NoError(t, err)
+	epochRewards, err := vm.Translate(sbpf.VaddrHeap+0x200, SysvarEpochRewardsStructLen, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), binary.LittleEndian.Uint64(epochRewards[8:16]))
+	assert.Equal(t, uint64(3), binary.LittleEndian.Uint64(epochRewards[16:24]))
+}
+
+func TestSyscallGetSysvarGeneric(t *testing.T) {
+	execCtx := &ExecutionCtx{ComputeMeter: cu.NewComputeMeter(10000)}
+	program := &sbpf.Program{Text: make([]byte, sbpf.SlotSize)}
+	vm := sbpf.NewInterpreter(nil, program, &sbpf.VMOpts{HeapSize: 0x1000, Context: execCtx})
+
+	const (
+		sysvarIdAddr = sbpf.VaddrHeap
+		varAddr      = sbpf.VaddrHeap + 0x100
+	)
+	require.NoError(t, vm.Write(sysvarIdAddr, SysvarSlotHashesAddr[:]))
+
+	r0, err := SyscallGetSysvarImpl(vm, sysvarIdAddr, varAddr, 0, 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(GetSysvarSysvarNotFound), r0)
+	assert.Equal(t, uint64(10000-CUSysvarBaseCost-CUMemOpBaseCost), execCtx.ComputeMeter.Remaining())
+
+	slotHashes := make([]byte, 8+40)
+	binary.LittleEndian.PutUint64(slotHashes[0:8], 1)
+	binary.LittleEndian.PutUint64(slotHashes[8:16], 99)
+	execCtx.SysvarCache.SetSysvarData(SysvarSlotHashesAddr, slotHashes)
+
+	r0, err = SyscallGetSysvarImpl(vm, sysvarIdAddr, varAddr, 8, 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), r0)
+	slot, err := vm.Read64(varAddr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(99), slot)
+
+	r0, err = SyscallGetSysvarImpl(vm, sysvarIdAddr, varAddr, 41, 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(GetSysvarOffsetLengthExceedsSysvar), r0)
+
+	_, err = SyscallGetSysvarImpl(vm, sysvarIdAddr, varAddr, math.MaxUint64, 1)
+	assert.Equal(t, InstrErrArithmeticOverflow, err)
+}
+
+func TestGetSysvarWithAccountCheck(t *testing.T) {
+	execCtx := newTestLoaderExecCtx(SystemProgramAddr, *features.NewFeaturesDefault(), nil, []testInstrAcct{
+		{SysvarClockAddr, &accounts.Account{}, false, false},
+		{SysvarRentAddr, &accounts.Account{}, false, false},
+	})
+	instrCtx, err := execCtx.TransactionContext.CurrentInstructionCtx()
+	require.NoError(t, err)
+
+	// the sysvar must be passed at the expected instruction account index
+	_, err = getClockSysvarWithAccountCheck(execCtx, instrCtx, 1)
+	assert.Equal(t, InstrErrInvalidArgument, err)
+
+	// and present in the sysvar cache
+	_, err = getClockSysvarWithAccountCheck(execCtx, instrCtx, 0)
+	assert.Equal(t, InstrErrUnsupportedSysvar, err)
+
+	execCtx.SysvarCache.SetClock(SysvarClock{Slot: 7})
+	clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), clock.Slot)
+}
@@ -100,6 +100,10 @@ const (
 	NonceVersionCurrent = 1
 )
 
+// NonceStateVersionsSize is the size of a serialized NonceStateVersions,
+// and so the data length of a nonce account.
+const NonceStateVersionsSize = 80
+
 type NonceStateVersions struct {
 	Type    uint32
 	Legacy  NonceData
@@ -446,7 +450,10 @@ func (nonceStateVersions *NonceStateVersions) Upgrade() {
 	if !nonceStateVersions.IsUpgradeable() {
 		panic("attempting to mutate non-upgradeable state - programming error")
 	}
+	// legacy nonce accounts store the blockhash itself, rather than a
+	// durable nonce derived from it
 	nonceStateVersions.Current = nonceStateVersions.Legacy
+	nonceStateVersions.Current.DurableNonce = durableNonce(nonceStateVersions.Legacy.DurableNonce)
 	nonceStateVersions.Legacy = NonceData{}
 	nonceStateVersions.Type = NonceVersionCurrent
 }
@@ -483,6 +490,8 @@ func (nonceData *NonceData) UnmarshalWithDecoder(decoder *bin.Decoder) error {
 		}
 		nonceData.FeeCalculator.LamportsPerSignature = lamportsPerSig
 		nonceData.IsInitialized = true
+	} else if isInitialized != 0 {
+		return InstrErrInvalidAccountData
 	}
 	return nil
 }
@@ -608,7 +617,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
-			err = SystemProgramCreateAccount(execCtx, toAddr, createAccount.Lamports, createAccount.Space, createAccount.Owner, signers)
+			return SystemProgramCreateAccount(execCtx, toAddr, createAccount.Lamports, createAccount.Space, createAccount.Owner, signers)
 		}
 
 	case SystemProgramInstrTypeAssign:
@@ -626,11 +635,12 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer acct.Drop()
 			addr, err := extractAddress(txCtx, instrCtx, 0)
 			if err != nil {
 				return err
 			}
-			err = SystemProgramAssign(execCtx, acct, addr, assign.Owner, signers)
+			return SystemProgramAssign(execCtx, acct, addr, assign.Owner, signers)
 		}
 
 	case SystemProgramInstrTypeTransfer:
@@ -644,7 +654,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
-			err = SystemProgramTransfer(execCtx, 0, 1, transfer.Lamports)
+			return SystemProgramTransfer(execCtx, 0, 1, transfer.Lamports)
 		}
 
 	case SystemProgramInstrTypeCreateAccountWithSeed:
@@ -662,7 +672,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
-			err = SystemProgramCreateAccount(execCtx, toAddr, createAcctWithSeed.Lamports, createAcctWithSeed.Space, createAcctWithSeed.Owner, signers)
+			return SystemProgramCreateAccount(execCtx, toAddr, createAcctWithSeed.Lamports, createAcctWithSeed.Space, createAcctWithSeed.Owner, signers)
 		}
 
 	case SystemProgramInstrTypeAdvanceNonceAccount:
@@ -675,6 +685,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer acct.Drop()
 			recentBlockHashes, err := ReadRecentBlockHashesSysvar(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
@@ -682,7 +693,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if len(*recentBlockHashes) == 0 {
 				return SystemProgErrNonceNoRecentBlockhashes
 			}
-			err = SystemProgramAdvanceNonceAccount(execCtx, acct, signers)
+			return SystemProgramAdvanceNonceAccount(execCtx, acct, signers)
 		}
 
 	case SystemProgramInstrTypeWithdrawNonceAccount:
@@ -703,14 +714,12 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			// TODO: replace with reading rent sysvar from sysvar cache
-			err = checkAcctForRentSysvar(txCtx, instrCtx, 3)
+			rent, err := getRentSysvarWithAccountCheck(execCtx, instrCtx, 3)
 			if err != nil {
 				return err
 			}
-			rent := ReadRentSysvar(&execCtx.Accounts)
 
-			err = SystemProgramWithdrawNonceAccount(execCtx, instrCtx, 0, withdrawNonceAcct.Lamports, 1, &rent, signers)
+			return SystemProgramWithdrawNonceAccount(execCtx, instrCtx, 0, withdrawNonceAcct.Lamports, 1, rent, signers)
 		}
 	case SystemProgramInstrTypeInitializeNonceAccount:
 		{
@@ -727,6 +736,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer acct.Drop()
 			recentBlockHashes, err := ReadRecentBlockHashesSysvar(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
@@ -735,14 +745,12 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 				return SystemProgErrNonceNoRecentBlockhashes
 			}
 
-			// TODO: replace with reading rent sysvar from sysvar cache
-			err = checkAcctForRentSysvar(txCtx, instrCtx, 2)
+			rent, err := getRentSysvarWithAccountCheck(execCtx, instrCtx, 2)
 			if err != nil {
 				return err
 			}
-			rent := ReadRentSysvar(&execCtx.Accounts)
 
-			err = SystemProgramInitializeNonceAccount(execCtx, acct, initNonceAcct.Pubkey, &rent)
+			return SystemProgramInitializeNonceAccount(execCtx, acct, initNonceAcct.Pubkey, rent)
 		}
 
 	case SystemProgramInstrTypeAuthorizeNonceAccount:
@@ -760,7 +768,8 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
-			err = SystemProgramAuthorizeNonceAccount(execCtx, acct, authNonceAcct.Pubkey, signers)
+			defer acct.Drop()
+			return SystemProgramAuthorizeNonceAccount(execCtx, acct, authNonceAcct.Pubkey, signers)
 		}
 
 	case SystemProgramInstrTypeAllocate:
@@ -778,11 +787,12 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer acct.Drop()
 			addr, err := extractAddress(txCtx, instrCtx, 0)
 			if err != nil {
 				return err
 			}
-			err = SystemProgramAllocate(execCtx, acct, addr, allocate.Space, signers)
+			return SystemProgramAllocate(execCtx, acct, addr, allocate.Space, signers)
 		}
 
 	case SystemProgramInstrTypeAllocateWithSeed:
@@ -800,11 +810,12 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer acct.Drop()
 			addr, err := extractAddressWithSeed(txCtx, instrCtx, 0, allocateWithSeed.Base, allocateWithSeed.Seed, allocateWithSeed.Owner)
 			if err != nil {
 				return err
 			}
-			err = SystemProgramAllocateAndAssign(execCtx, acct, addr, allocateWithSeed.Space, allocateWithSeed.Owner, signers)
+			return SystemProgramAllocateAndAssign(execCtx, acct, addr, allocateWithSeed.Space, allocateWithSeed.Owner, signers)
 		}
 
 	case SystemProgramInstrTypeAssignWithSeed:
@@ -822,8 +833,9 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
+			defer acct.Drop()
 			addr, err := extractAddressWithSeed(txCtx, instrCtx, 0, assignWithSeed.Base, assignWithSeed.Seed, assignWithSeed.Owner)
-			err = SystemProgramAssign(execCtx, acct, addr, assignWithSeed.Owner, signers)
+			return SystemProgramAssign(execCtx, acct, addr, assignWithSeed.Owner, signers)
 		}
 
 	case SystemProgramInstrTypeTransferWithSeed:
@@ -837,7 +849,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
-			err = SystemProgramTransferWithSeed(execCtx, 0, 1, transferWithSeed.FromSeed, transferWithSeed.FromOwner, 2, transferWithSeed.Lamports)
+			return SystemProgramTransferWithSeed(execCtx, 0, 1, transferWithSeed.FromSeed, transferWithSeed.FromOwner, 2, transferWithSeed.Lamports)
 
 		}
 
@@ -851,14 +863,13 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 			if err != nil {
 				return err
 			}
-			err = SystemProgramUpgradeNonceAccount(execCtx, acct)
+			defer acct.Drop()
+			return SystemProgramUpgradeNonceAccount(execCtx, acct)
 		}
 
 	default:
 		return InstrErrInvalidInstructionData
 	}
-
-	return err
 }
 
 func SystemProgramCreateAccount(execCtx *ExecutionCtx, toAddr solana.PublicKey, lamports uint64, space uint64, owner solana.PublicKey, signers []solana.PublicKey) error {
@@ -872,6 +883,7 @@ func SystemProgramCreateAccount(execCtx *ExecutionCtx, toAddr solana.PublicKey,
 	if err != nil {
 		return err
 	}
+	defer toAcct.Drop()
 
 	if toAcct.Lamports() > 0 {
 		klog.Errorf("CreateAccount: account %s already in use (non-zero lamports)", toAddr)
@@ -882,6 +894,7 @@ func SystemProgramCreateAccount(execCtx *ExecutionCtx, toAddr solana.PublicKey,
 	if err != nil {
 		return err
 	}
+	toAcct.Drop()
 
 	return SystemProgramTransfer(execCtx, 0, 1, lamports)
 }
@@ -1011,6 +1024,7 @@ func transferInternal(execCtx *ExecutionCtx, fromAcctIdx uint64, toAcctIdx uint6
 	if err != nil {
 		return err
 	}
+	defer from.Drop()
 
 	if len(from.Data()) != 0 {
 		klog.Errorf("Transfer: 'from' must not carry data")
@@ -1027,23 +1041,27 @@ func transferInternal(execCtx *ExecutionCtx, fromAcctIdx uint64, toAcctIdx uint6
 	if err != nil {
 		return err
 	}
+	from.Drop()
 
 	to, err := instrCtx.BorrowInstructionAccount(txCtx, toAcctIdx)
 	if err != nil {
 		return err
 	}
+	defer to.Drop()
 
 	return to.CheckedAddLamports(lamports, f)
 }
 
+// durableNonce returns the durable nonce derived from the given blockhash.
+// Durable nonces are distinct from blockhashes, so that a nonce
+// transaction cannot also be valid as a regular transaction.
 func durableNonce(hash [32]byte) [32]byte {
-	prefix := "DURABLE_NONCE"
 	hasher := sha256.New()
-	hasher.Write([]byte(prefix))
-	sum := hasher.Sum(hash[:])
+	hasher.Write([]byte("DURABLE_NONCE"))
+	hasher.Write(hash[:])
 
 	var result [32]byte
-	copy(result[:], sum)
+	copy(result[:], hasher.Sum(nil))
 	return result
 }
 
@@ -1072,6 +1090,7 @@ func SystemProgramInitializeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAc
 	durableNonce := durableNonce(execCtx.Blockhash)
 
 	newNonceStateVersions := NonceStateVersions{Type: NonceVersionCurrent, Current: NonceData{
+		IsInitialized: true,
 		Authority:     nonceAuthority,
 		DurableNonce:  durableNonce,
 		FeeCalculator: FeeCalculator{LamportsPerSignature: execCtx.LamportsPerSignature},
@@ -1082,7 +1101,7 @@ func SystemProgramInitializeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAc
 		return err
 	}
 
-	return acct.SetData(execCtx.GlobalCtx.Features, newStateBytes)
+	return acct.SetState(execCtx.GlobalCtx.Features, newStateBytes)
 }
 
 func SystemProgramAuthorizeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccount, nonceAuthority solana.PublicKey, signers []solana.PublicKey) error {
@@ -1112,7 +1131,7 @@ func SystemProgramAuthorizeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAcc
 	if err != nil {
 		return err
 	}
-	return acct.SetData(execCtx.GlobalCtx.Features, newStateData)
+	return acct.SetState(execCtx.GlobalCtx.Features, newStateData)
 }
 
 func SystemProgramUpgradeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccount) error {
@@ -1139,7 +1158,7 @@ func SystemProgramUpgradeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccou
 	if err != nil {
 		return err
 	}
-	return acct.SetData(execCtx.GlobalCtx.Features, newStateData)
+	return acct.SetState(execCtx.GlobalCtx.Features, newStateData)
 }
 
 func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *InstructionCtx, fromAcctIdx uint64, lamports uint64, toAcctIdx uint64, rent *SysvarRent, signers []solana.PublicKey) error {
@@ -1147,6 +1166,7 @@ func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *Instruct
 	if err != nil {
 		return err
 	}
+	defer from.Drop()
 
 	if !from.IsWritable() {
 		klog.Errorf("withdraw nonce account: account %s must be writeable", from.Key())
@@ -1162,6 +1182,8 @@ func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *Instruct
 	state := nonceStateVersions.State()
 
 	if state.IsInitialized {
+		// deinitializing clears the state, so capture the authority first
+		signer = state.Authority
 		if lamports == from.Lamports() {
 			durableNonce := durableNonce(execCtx.Blockhash)
 			if durableNonce == state.DurableNonce {
@@ -1173,7 +1195,10 @@ func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *Instruct
 			if err != nil {
 				return err
 			}
-			from.SetData(execCtx.GlobalCtx.Features, deinitNonceStateVersionsData)
+			err = from.SetState(execCtx.GlobalCtx.Features, deinitNonceStateVersionsData)
+			if err != nil {
+				return err
+			}
 		} else {
 			minBalance := rent.MinimumBalance(uint64(len(from.Data())))
 			amount, err := safemath.CheckedAddU64(lamports, minBalance)
@@ -1185,7 +1210,6 @@ func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *Instruct
 				return InstrErrInsufficientFunds
 			}
 		}
-		signer = state.Authority
 	} else {
 		if lamports > from.Lamports() {
 			klog.Errorf("Withdraw nonce account: insufficient lamports %d, need %d", from.Lamports(), lamports)
@@ -1211,11 +1235,13 @@ func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *Instruct
 	if err != nil {
 		return err
 	}
+	from.Drop()
 
 	to, err := instrCtx.BorrowInstructionAccount(execCtx.TransactionContext, toAcctIdx)
 	if err != nil {
 		return err
 	}
+	defer to.Drop()
 
 	err = to.CheckedAddLamports(lamports, execCtx.GlobalCtx.Features)
 	if err != nil {
@@ -1254,14 +1280,18 @@ func SystemProgramAdvanceNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccou
 		return SystemProgErrNonceBlockhashNotExpired
 	}
 
-	state.DurableNonce = nextDurableNonce
-	state.FeeCalculator.LamportsPerSignature = execCtx.LamportsPerSignature
+	// advancing also upgrades legacy nonce accounts
+	newNonceStateVersions := NonceStateVersions{Type: NonceVersionCurrent, Current: NonceData{
+		IsInitialized: true,
+		Authority:     state.Authority,
+		DurableNonce:  nextDurableNonce,
+		FeeCalculator: FeeCalculator{LamportsPerSignature: execCtx.LamportsPerSignature},
+	}}
 
-	newData, err := nonceStateVersions.Marshal()
+	newData, err := newNonceStateVersions.Marshal()
 	if err != nil {
 		return err
 	}
 
-	acct.SetData(execCtx.GlobalCtx.Features, newData)
-	return nil
+	return acct.SetState(execCtx.GlobalCtx.Features, newData)
 }
@@ -0,0 +1,137 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func systemInstrData(instrType uint32, args ...[]byte) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, instrType)
+	for _, arg := range args {
+		data = append(data, arg...)
+	}
+	return data
+}
+
+func newTestNonceExecCtx(instrData []byte, instrAccts []testInstrAcct, blockhash [32]byte) *ExecutionCtx {
+	execCtx := newTestLoaderExecCtx(SystemProgramAddr, *features.NewFeaturesDefault(), instrData, instrAccts)
+	execCtx.Blockhash = blockhash
+	execCtx.LamportsPerSignature = 5000
+	execCtx.SysvarCache.SetRecentBlockHashes(SysvarRecentBlockhashes{{Blockhash: blockhash}})
+	execCtx.SysvarCache.SetRent(SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50})
+	return execCtx
+}
+
+func TestSystemProgram_NonceLifecycle(t *testing.T) {
+	nonceKey, authority, to := solana.PublicKey{1}, solana.PublicKey{2}, solana.PublicKey{3}
+	nonceAcct := &accounts.Account{Lamports: 10_000_000, Owner: SystemProgramAddr, Data: make([]byte, NonceStateVersionsSize)}
+	recentBlockhashesAcct := &accounts.Account{}
+	rentAcct := &accounts.Account{}
+	blockhash := [32]byte{9}
+
+	// initialize
+	execCtx := newTestNonceExecCtx(systemInstrData(SystemProgramInstrTypeInitializeNonceAccount, authority[:]), []testInstrAcct{
+		{nonceKey, nonceAcct, false, true},
+		{SysvarRecentBlockHashesAddr, recentBlockhashesAcct, false, false},
+		{SysvarRentAddr, rentAcct, false, false},
+	}, blockhash)
+	require.NoError(t, SystemProgramExecute(execCtx))
+
+	require.Len(t, nonceAcct.Data, NonceStateVersionsSize)
+	state, err := unmarshalNonceStateVersions(nonceAcct.Data)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(NonceVersionCurrent), state.Type)
+	assert.True(t, state.Current.IsInitialized)
+	assert.Equal(t, authority, state.Current.Authority)
+	assert.Equal(t, durableNonce(blockhash), state.Current.DurableNonce)
+	assert.NotEqual(t, blockhash, state.Current.DurableNonce)
+	assert.Equal(t, uint64(5000), state.Current.FeeCalculator.LamportsPerSignature)
+
+	// initializing twice fails
+	execCtx = newTestNonceExecCtx(systemInstrData(SystemProgramInstrTypeInitializeNonceAccount, authority[:]), []testInstrAcct{
+		{nonceKey, nonceAcct, false, true},
+		{SysvarRecentBlockHashesAddr, recentBlockhashesAcct, false, false},
+		{SysvarRentAddr, rentAcct, false, false},
+	}, blockhash)
+	assert.Equal(t, InstrErrInvalidAccountData, SystemProgramExecute(execCtx))
+
+	// advancing needs the authority's signature, and a new blockhash
+	advance := func(blockhash [32]byte, authoritySigns bool) error {
+		execCtx := newTestNonceExecCtx(systemInstrData(SystemProgramInstrTypeAdvanceNonceAccount), []testInstrAcct{
+			{nonceKey, nonceAcct, false, true},
+			{SysvarRecentBlockHashesAddr, recentBlockhashesAcct, false, false},
+			{authority, &accounts.Account{}, authoritySigns, false},
+		}, blockhash)
+		return SystemProgramExecute(execCtx)
+	}
+	assert.Equal(t, InstrErrMissingRequiredSignature, advance([32]byte{10}, false))
+	assert.Equal(t, SystemProgErrNonceBlockhashNotExpired, advance(blockhash, true))
+	require.NoError(t, advance([32]byte{10}, true))
+
+	state, err = unmarshalNonceStateVersions(nonceAcct.Data)
+	require.NoError(t, err)
+	assert.Equal(t, durableNonce([32]byte{10}), state.Current.DurableNonce)
+
+	// withdrawing must leave the nonce account rent exempt
+	withdraw := func(lamports uint64) error {
+		amount := make([]byte, 8)
+		binary.LittleEndian.PutUint64(amount, lamports)
+		execCtx := newTestNonceExecCtx(systemInstrData(SystemProgramInstrTypeWithdrawNonceAccount, amount), []testInstrAcct{
+			{nonceKey, nonceAcct, false, true},
+			{to, &accounts.Account{Owner: SystemProgramAddr}, false, true},
+			{SysvarRecentBlockHashesAddr, recentBlockhashesAcct, false, false},
+			{SysvarRentAddr, rentAcct, false, false},
+			{authority, &accounts.Account{}, true, false},
+		}, [32]byte{11})
+		return SystemProgramExecute(execCtx)
+	}
+	assert.Equal(t, InstrErrInsufficientFunds, withdraw(10_000_000-1))
+	require.NoError(t, withdraw(1_000_000))
+	assert.Equal(t, uint64(9_000_000), nonceAcct.Lamports)
+
+	// withdrawing everything deinitializes the account
+	require.NoError(t, withdraw(9_000_000))
+	assert.Equal(t, uint64(0), nonceAcct.Lamports)
+	require.Len(t, nonceAcct.Data, NonceStateVersionsSize)
+	state, err = unmarshalNonceStateVersions(nonceAcct.Data)
+	require.NoError(t, err)
+	assert.False(t, state.State().IsInitialized)
+}
+
+func TestSystemProgram_UpgradeNonceAccount(t *testing.T) {
+	nonceKey, authority := solana.PublicKey{1}, solana.PublicKey{2}
+	blockhash := [32]byte{9}
+
+	legacy := NonceStateVersions{Type: NonceVersionLegacy, Legacy: NonceData{
+		IsInitialized: true,
+		Authority:     authority,
+		DurableNonce:  blockhash,
+	}}
+	legacyData, err := legacy.Marshal()
+	require.NoError(t, err)
+	nonceAcct := &accounts.Account{Lamports: 10_000_000, Owner: SystemProgramAddr, Data: make([]byte, NonceStateVersionsSize)}
+	copy(nonceAcct.Data, legacyData)
+
+	execCtx := newTestNonceExecCtx(systemInstrData(SystemProgramInstrTypeUpgradeNonceAccount), []testInstrAcct{
+		{nonceKey, nonceAcct, false, true},
+	}, [32]byte{10})
+	require.NoError(t, SystemProgramExecute(execCtx))
+
+	state, err := unmarshalNonceStateVersions(nonceAcct.Data)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(NonceVersionCurrent), state.Type)
+	assert.Equal(t, durableNonce(blockhash), state.Current.DurableNonce)
+
+	// current version nonce accounts cannot be upgraded
+	execCtx = newTestNonceExecCtx(systemInstrData(SystemProgramInstrTypeUpgradeNonceAccount), []testInstrAcct{
+		{nonceKey, nonceAcct, false, true},
+	}, [32]byte{10})
+	assert.Equal(t, InstrErrInvalidArgument, SystemProgramExecute(execCtx))
+}
@@ -1,9 +1,228 @@
 package sealevel
 
+import (
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+// SysvarCache holds the sysvars of the current slot, as read by builtin
+// programs and returned by the sol_get_*_sysvar syscalls. It is filled once
+// per slot, and shared by the transactions of the slot, so sysvar accounts
+// are not deserialized for every instruction. Sysvars that are not present
+// are unsupported, and reading them returns InstrErrUnsupportedSysvar.
+//
+// Based on solana_program_runtime::sysvar_cache::SysvarCache.
 type SysvarCache struct {
+	clock             *SysvarClock
+	epochSchedule     *SysvarEpochSchedule
+	epochRewards      *SysvarEpochRewards
+	fees              *SysvarFees
+	rent              *SysvarRent
+	lastRestartSlot   *SysvarLastRestartSlot
 	recentBlockHashes *SysvarRecentBlockhashes
+	slotHashes        *SysvarSlotHashes
+	stakeHistory      *SysvarStakeHistory
+
+	// data holds the raw account data of the sysvars readable through
+	// sol_get_sysvar, by address.
+	data map[[32]byte][]byte
+}
+
+// sysvarsWithData are the sysvars whose account data is cached for
+// sol_get_sysvar. SlotHashes and StakeHistory have no dedicated getter
+// syscall and are only readable this way.
+var sysvarsWithData = [][32]byte{
+	SysvarClockAddr,
+	SysvarEpochScheduleAddr,
+	SysvarEpochRewardsAddr,
+	SysvarRentAddr,
+	SysvarSlotHashesAddr,
+	SysvarStakeHistoryAddr,
+	SysvarLastRestartSlotAddr,
+}
+
+// FillFromAccounts populates the cache with the sysvar accounts present in
+// the given accounts. Missing or undecodable sysvar accounts are skipped.
+func (sysvarCache *SysvarCache) FillFromAccounts(accts accounts.Accounts) {
+	for _, addr := range sysvarsWithData {
+		addr := addr
+		acct, err := accts.GetAccount(&addr)
+		if err != nil || acct == nil {
+			continue
+		}
+		sysvarCache.SetSysvarData(addr, acct.Data)
+	}
+
+	decode := func(addr [32]byte, v interface {
+		UnmarshalWithDecoder(decoder *bin.Decoder) error
+	}) bool {
+		acct, err := accts.GetAccount(&addr)
+		if err != nil || acct == nil {
+			return false
+		}
+		return v.UnmarshalWithDecoder(bin.NewBinDecoder(acct.Data)) == nil
+	}
+
+	var clock SysvarClock
+	if decode(SysvarClockAddr, &clock) {
+		sysvarCache.SetClock(clock)
+	}
+	var epochSchedule SysvarEpochSchedule
+	if decode(SysvarEpochScheduleAddr, &epochSchedule) {
+		sysvarCache.SetEpochSchedule(epochSchedule)
+	}
+	var epochRewards SysvarEpochRewards
+	if decode(SysvarEpochRewardsAddr, &epochRewards) {
+		sysvarCache.SetEpochRewards(epochRewards)
+	}
+	var fees SysvarFees
+	if decode(SysvarFeesAddr, &fees) {
+		sysvarCache.SetFees(fees)
+	}
+	var rent SysvarRent
+	if decode(SysvarRentAddr, &rent) {
+		sysvarCache.SetRent(rent)
+	}
+	var lastRestartSlot SysvarLastRestartSlot
+	if decode(SysvarLastRestartSlotAddr, &lastRestartSlot) {
+		sysvarCache.SetLastRestartSlot(lastRestartSlot)
+	}
+	var recentBlockHashes SysvarRecentBlockhashes
+	if decode(SysvarRecentBlockHashesAddr, &recentBlockHashes) {
+		sysvarCache.SetRecentBlockHashes(recentBlockHashes)
+	}
+	var slotHashes SysvarSlotHashes
+	if decode(SysvarSlotHashesAddr, &slotHashes) {
+		sysvarCache.SetSlotHashes(slotHashes)
+	}
+	var stakeHistory SysvarStakeHistory
+	if decode(SysvarStakeHistoryAddr, &stakeHistory) {
+		sysvarCache.SetStakeHistory(stakeHistory)
+	}
+}
+
+// SysvarData returns the raw account data of the sysvar at the given
+// address, or false if it is not in the cache.
+func (sysvarCache *SysvarCache) SysvarData(addr [32]byte) ([]byte, bool) {
+	data, ok := sysvarCache.data[addr]
+	return data, ok
+}
+
+// SetSysvarData caches a copy of the raw account data of the sysvar at the
+// given address. It does not update the decoded sysvar.
+func (sysvarCache *SysvarCache) SetSysvarData(addr [32]byte, data []byte) {
+	if sysvarCache.data == nil {
+		sysvarCache.data = make(map[[32]byte][]byte)
+	}
+	sysvarCache.data[addr] = append([]byte{}, data...)
+}
+
+// Clone returns a copy of the cache, which may be updated without affecting
+// the original.
+func (sysvarCache *SysvarCache) Clone() SysvarCache {
+	clone := *sysvarCache
+	clone.data = make(map[[32]byte][]byte, len(sysvarCache.data))
+	for addr, data := range sysvarCache.data {
+		clone.data[addr] = data
+	}
+	return clone
+}
+
+func (sysvarCache *SysvarCache) Clock() (*SysvarClock, error) {
+	if sysvarCache.clock == nil {
+		return nil, InstrErrUnsupportedSysvar
+	}
+	return sysvarCache.clock, nil
+}
+
+func (sysvarCache *SysvarCache) SetClock(clock SysvarClock) {
+	sysvarCache.clock = &clock
+}
+
+func (sysvarCache *SysvarCache) EpochSchedule() (*SysvarEpochSchedule, error) {
+	if sysvarCache.epochSchedule == nil {
+		return nil, InstrErrUnsupportedSysvar
+	}
+	return sysvarCache.epochSchedule, nil
+}
+
+func (sysvarCache *SysvarCache) SetEpochSchedule(epochSchedule SysvarEpochSchedule) {
+	sysvarCache.epochSchedule = &epochSchedule
+}
+
+func (sysvarCache *SysvarCache) EpochRewards() (*SysvarEpochRewards, error) {
+	if sysvarCache.epochRewards == nil {
+		return nil, InstrErrUnsupportedSysvar
+	}
+	return sysvarCache.epochRewards, nil
+}
+
+func (sysvarCache *SysvarCache) SetEpochRewards(epochRewards SysvarEpochRewards) {
+	sysvarCache.epochRewards = &epochRewards
+}
+
+func (sysvarCache *SysvarCache) Fees() (*SysvarFees, error) {
+	if sysvarCache.fees == nil {
+		return nil, InstrErrUnsupportedSysvar
+	}
+	return sysvarCache.fees, nil
+}
+
+func (sysvarCache *SysvarCache) SetFees(fees SysvarFees) {
+	sysvarCache.fees = &fees
+}
+
+func (sysvarCache *SysvarCache) Rent() (*SysvarRent, error) {
+	if sysvarCache.rent == nil {
+		return nil, InstrErrUnsupportedSysvar
+	}
+	return sysvarCache.rent, nil
+}
+
+func (sysvarCache *SysvarCache) SetRent(rent SysvarRent) {
+	sysvarCache.rent = &rent
+}
+
+func (sysvarCache *SysvarCache) LastRestartSlot() (*SysvarLastRestartSlot, error) {
+	if sysvarCache.lastRestartSlot == nil {
+		return nil, InstrErrUnsupportedSysvar
+	}
+	return sysvarCache.lastRestartSlot, nil
+}
+
+func (sysvarCache *SysvarCache) SetLastRestartSlot(lastRestartSlot SysvarLastRestartSlot) {
+	sysvarCache.lastRestartSlot = &lastRestartSlot
+}
+
+func (sysvarCache *SysvarCache) RecentBlockHashes() (*SysvarRecentBlockhashes, error) {
+	if sysvarCache.recentBlockHashes == nil {
+		return nil, InstrErrUnsupportedSysvar
+	}
+	return sysvarCache.recentBlockHashes, nil
+}
+
+func (sysvarCache *SysvarCache) SetRecentBlockHashes(recentBlockHashes SysvarRecentBlockhashes) {
+	sysvarCache.recentBlockHashes = &recentBlockHashes
+}
+
+func (sysvarCache *SysvarCache) SlotHashes() (*SysvarSlotHashes, error) {
+	if sysvarCache.slotHashes == nil {
+		return nil, InstrErrUnsupportedSysvar
+	}
+	return sysvarCache.slotHashes, nil
+}
+
+func (sysvarCache *SysvarCache) SetSlotHashes(slotHashes SysvarSlotHashes) {
+	sysvarCache.slotHashes = &slotHashes
+}
+
+func (sysvarCache *SysvarCache) StakeHistory() (*SysvarStakeHistory, error) {
+	if sysvarCache.stakeHistory == nil {
+		return nil, InstrErrUnsupportedSysvar
+	}
+	return sysvarCache.stakeHistory, nil
 }
 
-func (sysvarCache *SysvarCache) RecentBlockHashes() *SysvarRecentBlockhashes {
-	return sysvarCache.recentBlockHashes
+func (sysvarCache *SysvarCache) SetStakeHistory(stakeHistory SysvarStakeHistory) {
+	sysvarCache.stakeHistory = &stakeHistory
 }
@@ -140,3 +140,14 @@ func checkAcctForClockSysvar(txCtx *TransactionCtx, instrCtx *InstructionCtx, in
 		return InstrErrInvalidArgument
 	}
 }
+
+// getClockSysvarWithAccountCheck returns the clock sysvar from the sysvar
+// cache, after checking that the given instruction account is the clock
+// sysvar account.
+func getClockSysvarWithAccountCheck(execCtx *ExecutionCtx, instrCtx *InstructionCtx, instrAcctIdx uint64) (*SysvarClock, error) {
+	err := checkAcctForClockSysvar(execCtx.TransactionContext, instrCtx, instrAcctIdx)
+	if err != nil {
+		return nil, err
+	}
+	return execCtx.SysvarCache.Clock()
+}
@@ -15,7 +15,9 @@ const SysvarEpochScheduleAddrStr = "SysvarEpochSchedu1e111111111111111111111111"
 
 var SysvarEpochScheduleAddr = base58.MustDecodeFromString(SysvarEpochScheduleAddrStr)
 
-const SysvarEpochScheduleStructLen = 33
+// SysvarEpochScheduleStructLen is the size of the EpochSchedule struct in
+// program memory, including padding after Warmup.
+const SysvarEpochScheduleStructLen = 40
 
 const MinimumSlotsPerEpoch = 32
 
@@ -92,6 +94,41 @@ func (sr *SysvarEpochSchedule) GetEpochAndSlotIndex(slot uint64) (uint64, uint64
 	}
 }
 
+// GetSlotsInEpoch returns the number of slots in the given epoch. Warmup
+// epochs double in length, starting from MinimumSlotsPerEpoch.
+func (sr *SysvarEpochSchedule) GetSlotsInEpoch(epoch uint64) uint64 {
+	if epoch < sr.FirstNormalEpoch {
+		shift := epoch + uint64(bits.TrailingZeros64(MinimumSlotsPerEpoch))
+		if shift >= 64 {
+			return math.MaxUint64
+		}
+		return 1 << shift
+	}
+	return sr.SlotsPerEpoch
+}
+
+// GetFirstSlotInEpoch returns the first slot of the given epoch.
+func (sr *SysvarEpochSchedule) GetFirstSlotInEpoch(epoch uint64) uint64 {
+	if epoch <= sr.FirstNormalEpoch {
+		return ((uint64(1) << epoch) - 1) * MinimumSlotsPerEpoch
+	}
+	return (epoch-sr.FirstNormalEpoch)*sr.SlotsPerEpoch + sr.FirstNormalSlot
+}
+
+// GetLeaderScheduleEpoch returns the epoch of which the leader schedule is
+// calculated at the given slot.
+//
+// Based on solana_sdk::epoch_schedule::EpochSchedule::get_leader_schedule_epoch.
+func (sr *SysvarEpochSchedule) GetLeaderScheduleEpoch(slot uint64) uint64 {
+	if slot < sr.FirstNormalSlot {
+		return sr.GetEpoch(slot) + 1
+	}
+	newSlotsSinceFirstNormalSlot := slot - sr.FirstNormalSlot
+	newFirstNormalLeaderScheduleSlot := newSlotsSinceFirstNormalSlot + sr.LeaderScheduleSlotOffset
+	newEpochsSinceFirstNormalLeaderSchedule := newFirstNormalLeaderScheduleSlot / sr.SlotsPerEpoch
+	return sr.FirstNormalEpoch + newEpochsSinceFirstNormalLeaderSchedule
+}
+
 func ReadEpochScheduleSysvar(accts *accounts.Accounts) SysvarEpochSchedule {
 	epochScheduleSysvarAcct, err := (*accts).GetAccount(&SysvarEpochScheduleAddr)
 	if err != nil {
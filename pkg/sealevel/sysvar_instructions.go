@@ -0,0 +1,98 @@
+package sealevel
+
+import (
+	"encoding/binary"
+
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
+)
+
+const SysvarInstructionsAddrStr = "Sysvar1nstructions1111111111111111111111111"
+
+var SysvarInstructionsAddr = base58.MustDecodeFromString(SysvarInstructionsAddrStr)
+
+// flags of a serialized account meta in the Instructions sysvar
+const (
+	instructionsSysvarAcctIsSigner   = 1 << 0
+	instructionsSysvarAcctIsWritable = 1 << 1
+)
+
+// The Instructions sysvar is not stored in accounts db. Its account is
+// constructed for each transaction that references it, and holds the
+// serialized instructions of the transaction, followed by the index of the
+// currently executing instruction:
+//
+//	u16 number of instructions
+//	u16 offset of each instruction
+//	for each instruction:
+//	    u16 number of accounts
+//	    for each account: u8 flags, 32 byte pubkey
+//	    32 byte program id
+//	    u16 data length, data
+//	u16 current instruction index
+//
+// Programs use it to introspect the other instructions of their transaction,
+// e.g. to check that a signature was verified by a precompile.
+
+// ConstructInstructionsSysvarData serializes the given instructions into the
+// account data of the Instructions sysvar, with a current index of zero.
+//
+// Based on solana_program::sysvar::instructions::construct_instructions_data.
+func ConstructInstructionsSysvarData(instrs []Instruction) []byte {
+	data := make([]byte, 2+(2*len(instrs)))
+	binary.LittleEndian.PutUint16(data, uint16(len(instrs)))
+
+	for i, instr := range instrs {
+		binary.LittleEndian.PutUint16(data[2+(2*i):], uint16(len(data)))
+
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(instr.Accounts)))
+		for _, acctMeta := range instr.Accounts {
+			var flags byte
+			if acctMeta.IsSigner {
+				flags |= instructionsSysvarAcctIsSigner
+			}
+			if acctMeta.IsWritable {
+				flags |= instructionsSysvarAcctIsWritable
+			}
+			data = append(data, flags)
+			data = append(data, acctMeta.Pubkey[:]...)
+		}
+
+		data = append(data, instr.ProgramId[:]...)
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(instr.Data)))
+		data = append(data, instr.Data...)
+	}
+
+	// current instruction index
+	return append(data, 0, 0)
+}
+
+// NewInstructionsSysvarAccount creates the Instructions sysvar account of a
+// transaction with the given instructions.
+func NewInstructionsSysvarAccount(instrs []Instruction) *accounts.Account {
+	return &accounts.Account{
+		Owner: SysvarOwnerAddr,
+		Data:  ConstructInstructionsSysvarData(instrs),
+	}
+}
+
+// StoreInstructionsSysvarCurrentIndex sets the index of the currently
+// executing top-level instruction in the Instructions sysvar account of the
+// transaction, if the transaction references it. It is called before each
+// top-level instruction is executed.
+//
+// Based on solana_program::sysvar::instructions::store_current_index.
+func StoreInstructionsSysvarCurrentIndex(txCtx *TransactionCtx, instrIdx uint16) error {
+	idx, err := txCtx.IndexOfAccount(SysvarInstructionsAddr)
+	if err != nil {
+		return nil
+	}
+	acct, err := txCtx.Accounts.GetAccount(idx)
+	if err != nil {
+		return err
+	}
+	if len(acct.Data) >= 2 {
+		binary.LittleEndian.PutUint16(acct.Data[len(acct.Data)-2:], instrIdx)
+	}
+	return nil
+}
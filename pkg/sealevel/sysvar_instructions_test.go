@@ -0,0 +1,74 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func TestConstructInstructionsSysvarData(t *testing.T) {
+	instrs := []Instruction{
+		{ProgramId: Ed25519PrecompileAddr, Data: []byte{1, 2, 3}},
+		{
+			ProgramId: solana.PublicKey{9},
+			Accounts: []AccountMeta{
+				{Pubkey: solana.PublicKey{1}, IsSigner: true, IsWritable: true},
+				{Pubkey: SysvarInstructionsAddr},
+			},
+			Data: []byte{4},
+		},
+	}
+	data := ConstructInstructionsSysvarData(instrs)
+
+	require.Equal(t, uint16(2), binary.LittleEndian.Uint16(data))
+
+	// first instruction: no accounts
+	offset := binary.LittleEndian.Uint16(data[2:])
+	assert.Equal(t, uint16(6), offset)
+	instr := data[offset:]
+	assert.Equal(t, uint16(0), binary.LittleEndian.Uint16(instr))
+	assert.Equal(t, Ed25519PrecompileAddr[:], instr[2:34])
+	assert.Equal(t, uint16(3), binary.LittleEndian.Uint16(instr[34:]))
+	assert.Equal(t, []byte{1, 2, 3}, instr[36:39])
+
+	// second instruction
+	offset = binary.LittleEndian.Uint16(data[4:])
+	assert.Equal(t, uint16(6+39), offset)
+	instr = data[offset:]
+	assert.Equal(t, uint16(2), binary.LittleEndian.Uint16(instr))
+	assert.Equal(t, byte(instructionsSysvarAcctIsSigner|instructionsSysvarAcctIsWritable), instr[2])
+	assert.Equal(t, solana.PublicKey{1}.Bytes(), instr[3:35])
+	assert.Equal(t, byte(0), instr[35])
+	assert.Equal(t, SysvarInstructionsAddr[:], instr[36:68])
+	assert.Equal(t, solana.PublicKey{9}.Bytes(), instr[68:100])
+	assert.Equal(t, uint16(1), binary.LittleEndian.Uint16(instr[100:]))
+	assert.Equal(t, byte(4), instr[102])
+
+	// current index
+	assert.Len(t, data, int(offset)+103+2)
+	assert.Equal(t, uint16(0), binary.LittleEndian.Uint16(data[len(data)-2:]))
+}
+
+func TestStoreInstructionsSysvarCurrentIndex(t *testing.T) {
+	instrs := []Instruction{{ProgramId: SystemProgramAddr}, {ProgramId: SystemProgramAddr}}
+	instrsAcct := NewInstructionsSysvarAccount(instrs)
+	assert.Equal(t, SysvarOwnerAddr, instrsAcct.Owner)
+
+	txCtx := &TransactionCtx{
+		AccountKeys: []solana.PublicKey{SystemProgramAddr, SysvarInstructionsAddr},
+		Accounts:    TransactionAccounts{Accounts: []*accounts.Account{{}, instrsAcct}, Touched: make([]bool, 2)},
+	}
+	require.NoError(t, StoreInstructionsSysvarCurrentIndex(txCtx, 1))
+	assert.Equal(t, uint16(1), binary.LittleEndian.Uint16(instrsAcct.Data[len(instrsAcct.Data)-2:]))
+
+	// transactions that do not reference the sysvar are left untouched
+	txCtx = &TransactionCtx{
+		AccountKeys: []solana.PublicKey{SystemProgramAddr},
+		Accounts:    TransactionAccounts{Accounts: []*accounts.Account{{}}, Touched: make([]bool, 1)},
+	}
+	assert.NoError(t, StoreInstructionsSysvarCurrentIndex(txCtx, 1))
+}
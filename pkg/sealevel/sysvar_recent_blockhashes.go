@@ -1,6 +1,9 @@
 package sealevel
 
 import (
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
 	"go.firedancer.io/radiance/pkg/base58"
 )
 
@@ -8,6 +11,14 @@ const SysvarRecentBlockHashesAddrStr = "SysvarRecentB1ockHashes11111111111111111
 
 var SysvarRecentBlockHashesAddr = base58.MustDecodeFromString(SysvarRecentBlockHashesAddrStr)
 
+// RecentBlockhashesMaxEntries is the number of blockhashes retained in the
+// RecentBlockhashes sysvar.
+const RecentBlockhashesMaxEntries = 150
+
+// SysvarRecentBlockhashesStructLen is the size of the RecentBlockhashes
+// sysvar account, holding RecentBlockhashesMaxEntries entries.
+const SysvarRecentBlockhashesStructLen = 8 + (RecentBlockhashesMaxEntries * 40)
+
 type RecentBlockHashesEntry struct {
 	Blockhash     [32]byte
 	FeeCalculator FeeCalculator
@@ -15,6 +26,33 @@ type RecentBlockHashesEntry struct {
 
 type SysvarRecentBlockhashes []RecentBlockHashesEntry
 
+func (recentBlockhashes *SysvarRecentBlockhashes) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
+	numEntries, err := decoder.ReadUint64(bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to read number of entries when decoding SysvarRecentBlockhashes: %w", err)
+	}
+	// each entry is 40 bytes
+	if numEntries > uint64(decoder.Remaining())/40 {
+		return fmt.Errorf("too many entries when decoding SysvarRecentBlockhashes: %d", numEntries)
+	}
+
+	entries := make(SysvarRecentBlockhashes, numEntries)
+	for i := range entries {
+		blockhash, err := decoder.ReadBytes(32)
+		if err != nil {
+			return fmt.Errorf("failed to read Blockhash when decoding SysvarRecentBlockhashes: %w", err)
+		}
+		copy(entries[i].Blockhash[:], blockhash)
+
+		entries[i].FeeCalculator.LamportsPerSignature, err = decoder.ReadUint64(bin.LE)
+		if err != nil {
+			return fmt.Errorf("failed to read LamportsPerSignature when decoding SysvarRecentBlockhashes: %w", err)
+		}
+	}
+	*recentBlockhashes = entries
+	return
+}
+
 func checkAcctForRecentBlockHashesSysvar(txCtx *TransactionCtx, instrCtx *InstructionCtx, instrAcctIdx uint64) error {
 	idxInTx, err := instrCtx.IndexOfInstructionAccountInTransaction(instrAcctIdx)
 	if err != nil {
@@ -36,5 +74,5 @@ func ReadRecentBlockHashesSysvar(execCtx *ExecutionCtx, instrCtx *InstructionCtx
 	if err != nil {
 		return nil, err
 	}
-	return execCtx.SysvarCache.RecentBlockHashes(), nil
+	return execCtx.SysvarCache.RecentBlockHashes()
 }
@@ -13,7 +13,9 @@ const SysvarRentAddrStr = "SysvarRent111111111111111111111111111111111"
 
 var SysvarRentAddr = base58.MustDecodeFromString(SysvarRentAddrStr)
 
-const SysvarRentStructLen = 17
+// SysvarRentStructLen is the size of the Rent struct in program memory,
+// including padding after BurnPercent.
+const SysvarRentStructLen = 24
 
 const rentAccountStorageOverhead = 128
 
@@ -127,3 +129,14 @@ func checkAcctForRentSysvar(txCtx *TransactionCtx, instrCtx *InstructionCtx, ins
 		return InstrErrInvalidArgument
 	}
 }
+
+// getRentSysvarWithAccountCheck returns the rent sysvar from the sysvar
+// cache, after checking that the given instruction account is the rent
+// sysvar account.
+func getRentSysvarWithAccountCheck(execCtx *ExecutionCtx, instrCtx *InstructionCtx, instrAcctIdx uint64) (*SysvarRent, error) {
+	err := checkAcctForRentSysvar(execCtx.TransactionContext, instrCtx, instrAcctIdx)
+	if err != nil {
+		return nil, err
+	}
+	return execCtx.SysvarCache.Rent()
+}
@@ -3,6 +3,7 @@ package sealevel
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
 	bin "github.com/gagliardetto/binary"
 	"go.firedancer.io/radiance/pkg/accounts"
@@ -13,6 +14,14 @@ const SysvarSlotHashesAddrStr = "SysvarS1otHashes111111111111111111111111111"
 
 var SysvarSlotHashesAddr = base58.MustDecodeFromString(SysvarSlotHashesAddrStr)
 
+// SlotHashesMaxEntries is the number of slots retained in the SlotHashes
+// sysvar.
+const SlotHashesMaxEntries = 512
+
+// SysvarSlotHashesStructLen is the size of the SlotHashes sysvar account,
+// holding SlotHashesMaxEntries entries.
+const SysvarSlotHashesStructLen = 8 + (SlotHashesMaxEntries * 40)
+
 type SlotHash struct {
 	Slot uint64
 	Hash [32]byte
@@ -44,6 +53,7 @@ func (sh *SysvarSlotHashes) UnmarshalWithDecoder(decoder *bin.Decoder) (err erro
 		slotHashes = append(slotHashes, slotHash)
 	}
 
+	*sh = slotHashes
 	return
 }
 
@@ -54,6 +64,22 @@ func (sh *SysvarSlotHashes) MustUnmarshalWithDecoder(decoder *bin.Decoder) {
 	}
 }
 
+// Add records the hash of the given slot. Entries are kept ordered by
+// descending slot, and only the SlotHashesMaxEntries most recent slots are
+// retained.
+//
+// Based on solana_slot_hashes::SlotHashes::add.
+func (sh *SysvarSlotHashes) Add(slot uint64, hash [32]byte) {
+	slotHashes := append(*sh, SlotHash{Slot: slot, Hash: hash})
+	sort.SliceStable(slotHashes, func(i, j int) bool {
+		return slotHashes[i].Slot > slotHashes[j].Slot
+	})
+	if len(slotHashes) > SlotHashesMaxEntries {
+		slotHashes = slotHashes[:SlotHashesMaxEntries]
+	}
+	*sh = slotHashes
+}
+
 func ReadSlotHashesSysvar(accts *accounts.Accounts) SysvarSlotHashes {
 	slotHashesSysvarAcct, err := (*accts).GetAccount(&SysvarSlotHashesAddr)
 	if err != nil {
@@ -120,3 +146,14 @@ func checkAcctForSlotHashesSysvar(txCtx *TransactionCtx, instrCtx *InstructionCt
 		return InstrErrInvalidArgument
 	}
 }
+
+// getSlotHashesSysvarWithAccountCheck returns the SlotHashes sysvar from the
+// sysvar cache, after checking that the given instruction account is the
+// SlotHashes sysvar account.
+func getSlotHashesSysvarWithAccountCheck(execCtx *ExecutionCtx, instrCtx *InstructionCtx, instrAcctIdx uint64) (*SysvarSlotHashes, error) {
+	err := checkAcctForSlotHashesSysvar(execCtx.TransactionContext, instrCtx, instrAcctIdx)
+	if err != nil {
+		return nil, err
+	}
+	return execCtx.SysvarCache.SlotHashes()
+}
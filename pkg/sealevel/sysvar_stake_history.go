@@ -3,16 +3,26 @@ package sealevel
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
 	bin "github.com/gagliardetto/binary"
 	"go.firedancer.io/radiance/pkg/accounts"
 	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/safemath"
 )
 
 const SysvarStakeHistoryAddrStr = "SysvarStakeHistory1111111111111111111111111"
 
 var SysvarStakeHistoryAddr = base58.MustDecodeFromString(SysvarStakeHistoryAddrStr)
 
+// StakeHistoryMaxEntries is the number of epochs retained in the
+// StakeHistory sysvar.
+const StakeHistoryMaxEntries = 512
+
+// SysvarStakeHistoryStructLen is the size of the StakeHistory sysvar
+// account, holding StakeHistoryMaxEntries entries.
+const SysvarStakeHistoryStructLen = 8 + (StakeHistoryMaxEntries * 32)
+
 type StakeHistoryEntry struct {
 	Effective    uint64
 	Activating   uint64
@@ -60,6 +70,7 @@ func (sh *SysvarStakeHistory) UnmarshalWithDecoder(decoder *bin.Decoder) (err er
 		stakeHistory = append(stakeHistory, stakeHistoryPair)
 	}
 
+	*sh = stakeHistory
 	return
 }
 
@@ -79,6 +90,54 @@ func (sh *SysvarStakeHistory) Get(epoch uint64) *StakeHistoryEntry {
 	return nil
 }
 
+// Add records the stake history entry of the given epoch. Entries are kept
+// ordered by descending epoch, and only the StakeHistoryMaxEntries most
+// recent epochs are retained.
+func (sh *SysvarStakeHistory) Add(epoch uint64, entry StakeHistoryEntry) {
+	stakeHistory := *sh
+
+	idx := sort.Search(len(stakeHistory), func(i int) bool {
+		return stakeHistory[i].Epoch <= epoch
+	})
+	if idx < len(stakeHistory) && stakeHistory[idx].Epoch == epoch {
+		stakeHistory[idx].Entry = entry
+	} else {
+		stakeHistory = append(stakeHistory, StakeHistoryPair{})
+		copy(stakeHistory[idx+1:], stakeHistory[idx:])
+		stakeHistory[idx] = StakeHistoryPair{Epoch: epoch, Entry: entry}
+	}
+
+	if len(stakeHistory) > StakeHistoryMaxEntries {
+		stakeHistory = stakeHistory[:StakeHistoryMaxEntries]
+	}
+	*sh = stakeHistory
+}
+
+// StakeHistoryEntryForEpoch sums the effective, activating and deactivating
+// stake of the given delegations in the given epoch.
+func StakeHistoryEntryForEpoch(epoch uint64, delegations []Delegation, stakeHistory SysvarStakeHistory, newRateActivationEpoch *uint64) StakeHistoryEntry {
+	var entry StakeHistoryEntry
+	for _, delegation := range delegations {
+		status := delegation.StakeActivatingAndDeactivating(epoch, stakeHistory, newRateActivationEpoch)
+		entry.Effective = safemath.SaturatingAddU64(entry.Effective, status.Effective)
+		entry.Activating = safemath.SaturatingAddU64(entry.Activating, status.Activating)
+		entry.Deactivating = safemath.SaturatingAddU64(entry.Deactivating, status.Deactivating)
+	}
+	return entry
+}
+
+// UpdateStakeHistorySysvar records the stake activation of the given
+// delegations in the epoch that has just ended, at the first slot of the
+// following epoch.
+//
+// Based on solana_runtime::stakes::Stakes::activate_epoch.
+func UpdateStakeHistorySysvar(accts *accounts.Accounts, endedEpoch uint64, delegations []Delegation, newRateActivationEpoch *uint64) {
+	stakeHistory := ReadStakeHistorySysvar(accts)
+	entry := StakeHistoryEntryForEpoch(endedEpoch, delegations, stakeHistory, newRateActivationEpoch)
+	stakeHistory.Add(endedEpoch, entry)
+	WriteStakeHistorySysvar(accts, stakeHistory)
+}
+
 func ReadStakeHistorySysvar(accts *accounts.Accounts) SysvarStakeHistory {
 	stakeHistorySysvarAcct, err := (*accts).GetAccount(&SysvarStakeHistoryAddr)
 	if err != nil {
@@ -161,3 +220,14 @@ func checkAcctForStakeHistorySysvar(txCtx *TransactionCtx, instrCtx *Instruction
 		return InstrErrInvalidArgument
 	}
 }
+
+// getStakeHistorySysvarWithAccountCheck returns the StakeHistory sysvar from
+// the sysvar cache, after checking that the given instruction account is the
+// StakeHistory sysvar account.
+func getStakeHistorySysvarWithAccountCheck(execCtx *ExecutionCtx, instrCtx *InstructionCtx, instrAcctIdx uint64) (*SysvarStakeHistory, error) {
+	err := checkAcctForStakeHistorySysvar(execCtx.TransactionContext, instrCtx, instrAcctIdx)
+	if err != nil {
+		return nil, err
+	}
+	return execCtx.SysvarCache.StakeHistory()
+}
@@ -0,0 +1,65 @@
+package sealevel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func TestSysvarStakeHistory_Add(t *testing.T) {
+	var stakeHistory SysvarStakeHistory
+	stakeHistory.Add(1, StakeHistoryEntry{Effective: 1})
+	stakeHistory.Add(3, StakeHistoryEntry{Effective: 3})
+	stakeHistory.Add(2, StakeHistoryEntry{Effective: 2})
+
+	// newest entries first
+	require.Len(t, stakeHistory, 3)
+	assert.Equal(t, []uint64{3, 2, 1}, []uint64{stakeHistory[0].Epoch, stakeHistory[1].Epoch, stakeHistory[2].Epoch})
+
+	// entries are replaced
+	stakeHistory.Add(2, StakeHistoryEntry{Effective: 20})
+	require.Len(t, stakeHistory, 3)
+	assert.Equal(t, uint64(20), stakeHistory.Get(2).Effective)
+
+	// only the most recent epochs are retained
+	for epoch := uint64(4); epoch < StakeHistoryMaxEntries+10; epoch++ {
+		stakeHistory.Add(epoch, StakeHistoryEntry{Effective: epoch})
+	}
+	require.Len(t, stakeHistory, StakeHistoryMaxEntries)
+	assert.Equal(t, uint64(StakeHistoryMaxEntries+9), stakeHistory[0].Epoch)
+	assert.Nil(t, stakeHistory.Get(1))
+}
+
+func TestUpdateStakeHistorySysvar(t *testing.T) {
+	var accts accounts.Accounts = accounts.NewMemAccounts()
+	require.NoError(t, accts.SetAccount(&SysvarStakeHistoryAddr, &accounts.Account{Data: make([]byte, SysvarStakeHistoryStructLen)}))
+
+	bootstrap := Delegation{StakeLamports: 1000, ActivationEpoch: math.MaxUint64, DeactivationEpoch: math.MaxUint64}
+	delegation := Delegation{StakeLamports: 100, ActivationEpoch: 1, DeactivationEpoch: math.MaxUint64}
+
+	UpdateStakeHistorySysvar(&accts, 1, []Delegation{bootstrap, delegation}, nil)
+	stakeHistory := ReadStakeHistorySysvar(&accts)
+	require.Len(t, stakeHistory, 1)
+	assert.Equal(t, StakeHistoryEntry{Effective: 1000, Activating: 100}, *stakeHistory.Get(1))
+
+	// the delegation warms up within an epoch
+	UpdateStakeHistorySysvar(&accts, 2, []Delegation{bootstrap, delegation}, nil)
+	stakeHistory = ReadStakeHistorySysvar(&accts)
+	require.Len(t, stakeHistory, 2)
+	assert.Equal(t, StakeHistoryEntry{Effective: 1100}, *stakeHistory.Get(2))
+
+	// deactivating stake remains effective until it has cooled down
+	delegation.DeactivationEpoch = 3
+	UpdateStakeHistorySysvar(&accts, 3, []Delegation{bootstrap, delegation}, nil)
+	stakeHistory = ReadStakeHistorySysvar(&accts)
+	assert.Equal(t, StakeHistoryEntry{Effective: 1100, Deactivating: 100}, *stakeHistory.Get(3))
+
+	UpdateStakeHistorySysvar(&accts, 4, []Delegation{bootstrap, delegation}, nil)
+	stakeHistory = ReadStakeHistorySysvar(&accts)
+	require.Len(t, stakeHistory, 4)
+	assert.Equal(t, StakeHistoryEntry{Effective: 1000}, *stakeHistory.Get(4))
+	assert.Equal(t, uint64(4), stakeHistory[0].Epoch)
+}
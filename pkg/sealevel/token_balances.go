@@ -0,0 +1,149 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+// TokenProgramAddrs are the token programs whose accounts have their token
+// balances recorded in the transaction status meta.
+var TokenProgramAddrs = []solana.PublicKey{TokenProgramAddr}
+
+// serialized layout of SPL token accounts and mints
+const (
+	TokenAccountSize         = 165
+	tokenAccountOwnerOffset  = 32
+	tokenAccountAmountOffset = 64
+	tokenAccountStateOffset  = 108
+	TokenMintSize            = 82
+	tokenMintDecimalsOffset  = 44
+	tokenMintInitOffset      = 45
+)
+
+const tokenAccountStateUninitialized = 0
+
+// UiTokenAmount is a token amount in base units, together with its value
+// in whole tokens.
+//
+// Based on solana_account_decoder::parse_token::UiTokenAmount.
+type UiTokenAmount struct {
+	Amount         string
+	Decimals       uint8
+	UiAmount       float64
+	UiAmountString string
+}
+
+// TokenBalance is the balance of a token account referenced by a
+// transaction.
+//
+// Based on solana_transaction_status::TransactionTokenBalance.
+type TokenBalance struct {
+	AccountIndex  uint8
+	Mint          solana.PublicKey
+	UiTokenAmount UiTokenAmount
+	Owner         solana.PublicKey
+	ProgramId     solana.PublicKey
+}
+
+func isTokenProgram(programId solana.PublicKey) bool {
+	for _, addr := range TokenProgramAddrs {
+		if programId == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// NewUiTokenAmount returns the token amount of the given number of base
+// units of a mint with the given number of decimals.
+//
+// Based on solana_account_decoder::parse_token::token_amount_to_ui_amount.
+func NewUiTokenAmount(amount uint64, decimals uint8) UiTokenAmount {
+	amountStr := strconv.FormatUint(amount, 10)
+
+	uiAmountString := amountStr
+	if decimals > 0 {
+		if pad := int(decimals) + 1 - len(amountStr); pad > 0 {
+			uiAmountString = strings.Repeat("0", pad) + amountStr
+		}
+		point := len(uiAmountString) - int(decimals)
+		uiAmountString = uiAmountString[:point] + "." + uiAmountString[point:]
+		uiAmountString = strings.TrimRight(strings.TrimRight(uiAmountString, "0"), ".")
+	}
+
+	return UiTokenAmount{
+		Amount:         amountStr,
+		Decimals:       decimals,
+		UiAmount:       float64(amount) / math.Pow10(int(decimals)),
+		UiAmountString: uiAmountString,
+	}
+}
+
+// mintDecimals returns the decimals of an initialized mint account.
+func mintDecimals(accts accounts.Accounts, mint solana.PublicKey) (uint8, bool) {
+	mintAcct, err := accts.GetAccount((*[32]byte)(&mint))
+	if err != nil || mintAcct == nil || !isTokenProgram(mintAcct.Owner) {
+		return 0, false
+	}
+	if len(mintAcct.Data) != TokenMintSize || mintAcct.Data[tokenMintInitOffset] == 0 {
+		return 0, false
+	}
+	return mintAcct.Data[tokenMintDecimalsOffset], true
+}
+
+// CollectTokenBalances returns the balances of the initialized token
+// accounts referenced by a transaction, as currently stored in accts. It
+// is called before and after the transaction executes, for the pre and
+// post token balances of its status meta. Accounts invoked as programs by
+// the transaction, and token accounts whose mint cannot be loaded, are
+// skipped.
+//
+// Based on solana_ledger::token_balances::collect_token_balances.
+func CollectTokenBalances(accts accounts.Accounts, accountKeys []solana.PublicKey, instrs []Instruction) []TokenBalance {
+	var balances []TokenBalance
+
+	for idx, key := range accountKeys {
+		if isInvokedProgram(key, instrs) {
+			continue
+		}
+
+		acct, err := accts.GetAccount((*[32]byte)(&key))
+		if err != nil || acct == nil || !isTokenProgram(acct.Owner) {
+			continue
+		}
+		if len(acct.Data) != TokenAccountSize || acct.Data[tokenAccountStateOffset] == tokenAccountStateUninitialized {
+			continue
+		}
+
+		mint := solana.PublicKeyFromBytes(acct.Data[:tokenAccountOwnerOffset])
+		decimals, ok := mintDecimals(accts, mint)
+		if !ok {
+			continue
+		}
+
+		amount := binary.LittleEndian.Uint64(acct.Data[tokenAccountAmountOffset:])
+		balances = append(balances, TokenBalance{
+			AccountIndex:  uint8(idx),
+			Mint:          mint,
+			UiTokenAmount: NewUiTokenAmount(amount, decimals),
+			Owner:         solana.PublicKeyFromBytes(acct.Data[tokenAccountOwnerOffset:tokenAccountAmountOffset]),
+			ProgramId:     acct.Owner,
+		})
+	}
+
+	return balances
+}
+
+func isInvokedProgram(key solana.PublicKey, instrs []Instruction) bool {
+	for _, instr := range instrs {
+		if instr.ProgramId == key {
+			return true
+		}
+	}
+	return false
+}
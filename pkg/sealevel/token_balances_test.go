@@ -0,0 +1,64 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func TestNewUiTokenAmount(t *testing.T) {
+	assert.Equal(t, UiTokenAmount{Amount: "1234500", Decimals: 6, UiAmount: 1.2345, UiAmountString: "1.2345"}, NewUiTokenAmount(1234500, 6))
+	assert.Equal(t, UiTokenAmount{Amount: "5", Decimals: 3, UiAmount: 0.005, UiAmountString: "0.005"}, NewUiTokenAmount(5, 3))
+	assert.Equal(t, UiTokenAmount{Amount: "2000", Decimals: 3, UiAmount: 2, UiAmountString: "2"}, NewUiTokenAmount(2000, 3))
+	assert.Equal(t, UiTokenAmount{Amount: "0", Decimals: 2, UiAmount: 0, UiAmountString: "0"}, NewUiTokenAmount(0, 2))
+	assert.Equal(t, UiTokenAmount{Amount: "42", Decimals: 0, UiAmount: 42, UiAmountString: "42"}, NewUiTokenAmount(42, 0))
+}
+
+func TestCollectTransactionBalances(t *testing.T) {
+	accts := accounts.NewMemAccounts()
+
+	mint := solana.NewWallet().PublicKey()
+	mintData := make([]byte, TokenMintSize)
+	mintData[tokenMintDecimalsOffset] = 2
+	mintData[tokenMintInitOffset] = 1
+	assert.NoError(t, accts.SetAccount((*[32]byte)(&mint), &accounts.Account{Lamports: 1, Data: mintData, Owner: TokenProgramAddr}))
+
+	newTokenAcct := func(amount uint64, owner solana.PublicKey, state byte) solana.PublicKey {
+		data := make([]byte, TokenAccountSize)
+		copy(data, mint[:])
+		copy(data[tokenAccountOwnerOffset:], owner[:])
+		binary.LittleEndian.PutUint64(data[tokenAccountAmountOffset:], amount)
+		data[tokenAccountStateOffset] = state
+		key := solana.NewWallet().PublicKey()
+		assert.NoError(t, accts.SetAccount((*[32]byte)(&key), &accounts.Account{Lamports: 2, Data: data, Owner: TokenProgramAddr}))
+		return key
+	}
+
+	owner := solana.NewWallet().PublicKey()
+	payer := solana.NewWallet().PublicKey()
+	assert.NoError(t, accts.SetAccount((*[32]byte)(&payer), &accounts.Account{Lamports: 1000, Owner: SystemProgramAddr}))
+	tokenAcct := newTokenAcct(150, owner, 1)
+	uninitializedTokenAcct := newTokenAcct(0, owner, tokenAccountStateUninitialized)
+	missing := solana.NewWallet().PublicKey()
+
+	accountKeys := []solana.PublicKey{payer, tokenAcct, uninitializedTokenAcct, mint, missing, TokenProgramAddr}
+	instrs := []Instruction{{ProgramId: TokenProgramAddr}}
+
+	balances := CollectTransactionBalances(accts, accountKeys, instrs)
+	assert.Equal(t, []uint64{1000, 2, 2, 1, 0, 0}, balances.Balances)
+	assert.Equal(t, []TokenBalance{{
+		AccountIndex:  1,
+		Mint:          mint,
+		UiTokenAmount: UiTokenAmount{Amount: "150", Decimals: 2, UiAmount: 1.5, UiAmountString: "1.5"},
+		Owner:         owner,
+		ProgramId:     TokenProgramAddr,
+	}}, balances.TokenBalances)
+
+	meta := NewTransactionStatusMeta(nil, 5000, balances, balances, nil, LoadedAddresses{})
+	assert.Equal(t, balances.Balances, meta.PreBalances)
+	assert.Equal(t, balances.TokenBalances, meta.PostTokenBalances)
+	assert.Nil(t, meta.InnerInstructions)
+}
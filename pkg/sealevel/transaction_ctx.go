@@ -15,6 +15,9 @@ type TxReturnData struct {
 type TransactionAccounts struct {
 	Accounts []*accounts.Account
 	Touched  []bool
+
+	// borrowed marks the accounts currently borrowed by a BorrowedAccount.
+	borrowed []bool
 }
 
 type TransactionCtx struct {
@@ -26,6 +29,19 @@ type TransactionCtx struct {
 	InstructionTraceCapacity uint64
 	AccountsResizeDelta      int64
 	Rent                     SysvarRent
+
+	// InstructionStackCapacity is the max invoke stack height of the
+	// transaction. Zero means CUMaxInvokeStackHeight.
+	InstructionStackCapacity uint64
+}
+
+// instructionStackCapacity returns the max invoke stack height of the
+// transaction.
+func (txCtx *TransactionCtx) instructionStackCapacity() uint64 {
+	if txCtx.InstructionStackCapacity == 0 {
+		return CUMaxInvokeStackHeight
+	}
+	return txCtx.InstructionStackCapacity
 }
 
 func (txCtx *TransactionCtx) PushInstructionCtx(ixCtx InstructionCtx) {
@@ -41,9 +57,11 @@ func (txCtx *TransactionCtx) CurrentInstructionCtx() (*InstructionCtx, error) {
 	if err != nil {
 		return nil, InstrErrCallDepth
 	}
-	return &txCtx.InstructionTrace[level], nil
+	return txCtx.InstructionCtxAtNestingLevel(level)
 }
 
+// ReturnData returns the data most recently set by sol_set_return_data
+// and the program that set it.
 func (txCtx *TransactionCtx) ReturnData() (solana.PublicKey, []byte) {
 	return txCtx.RetData.programId, txCtx.RetData.data
 }
@@ -56,6 +74,7 @@ func (txCtx *TransactionCtx) KeyOfAccountAtIndex(index uint64) (solana.PublicKey
 	return txCtx.AccountKeys[index], nil
 }
 
+// SetReturnData replaces the transaction's return data.
 func (txCtx *TransactionCtx) SetReturnData(programId solana.PublicKey, data []byte) {
 	txCtx.RetData.programId = programId
 	txCtx.RetData.data = data
@@ -128,6 +147,12 @@ func (txCtx *TransactionCtx) InstructionAccountsLamportSum(instrCtx *Instruction
 		}
 
 		acct, err := txCtx.AccountAtIndex(idxInTx)
+		if err != nil {
+			return wide.NewUint128(0, 0), err
+		}
+		if txCtx.Accounts.IsBorrowed(idxInTx) {
+			return wide.NewUint128(0, 0), InstrErrAccountBorrowOutstanding
+		}
 		lamportsUint128 := wide.Uint128FromUint64(acct.Lamports)
 		instructionAcctsLamportSum, err = safemath.CheckedAddU128(instructionAcctsLamportSum, lamportsUint128)
 		if err != nil {
@@ -177,9 +202,15 @@ func (txCtx *TransactionCtx) Push() error {
 
 	idxInTrace := txCtx.InstructionTraceLength()
 	if idxInTrace >= txCtx.InstructionTraceCapacity {
-		return InstrErrCallDepth
+		return InstrErrMaxInstructionTraceLenExceeded
 	}
 
+	// the last trace entry is always the slot for the next instruction
+	txCtx.InstructionTrace = append(txCtx.InstructionTrace, InstructionCtx{})
+
+	if nestingLevel >= txCtx.instructionStackCapacity() {
+		return InstrErrCallDepth
+	}
 	txCtx.InstructionStack = append(txCtx.InstructionStack, idxInTrace)
 
 	return nil
@@ -195,23 +226,39 @@ func (txCtx *TransactionCtx) Pop() error {
 		return err
 	}
 
-	lamportsSum, err := txCtx.InstructionAccountsLamportSum(currentInstrCtx)
-	if err != nil {
-		return InstrErrUnbalancedInstruction
+	// the program accounts and instruction accounts must all have been
+	// released by the instruction
+	var unbalanced int
+	err = txCtx.checkProgramAccountsReleased(currentInstrCtx)
+	if err == nil {
+		var lamportsSum wide.Uint128
+		lamportsSum, err = txCtx.InstructionAccountsLamportSum(currentInstrCtx)
+		if err == nil {
+			unbalanced = currentInstrCtx.InstructionAccountsLamportSum.Cmp(lamportsSum)
+		}
 	}
 
-	unbalanced := currentInstrCtx.InstructionAccountsLamportSum.Cmp(lamportsSum)
-
-	// pop
+	// pop, even if the instruction is unbalanced
 	txCtx.InstructionStack = txCtx.InstructionStack[:len(txCtx.InstructionStack)-1]
 
-	if unbalanced != 0 {
+	if err == InstrErrAccountBorrowOutstanding {
+		return err
+	} else if err != nil || unbalanced != 0 {
 		return InstrErrUnbalancedInstruction
 	}
 
 	return nil
 }
 
+func (txCtx *TransactionCtx) checkProgramAccountsReleased(instrCtx *InstructionCtx) error {
+	for _, idxInTx := range instrCtx.ProgramAccounts {
+		if txCtx.Accounts.IsBorrowed(idxInTx) {
+			return InstrErrAccountBorrowOutstanding
+		}
+	}
+	return nil
+}
+
 func (txAccounts *TransactionAccounts) GetAccount(idx uint64) (*accounts.Account, error) {
 	if len(txAccounts.Accounts) == 0 || idx > (uint64(len(txAccounts.Accounts)-1)) {
 		return nil, InstrErrMissingAccount
@@ -226,3 +273,29 @@ func (txAccounts *TransactionAccounts) Touch(idx uint64) error {
 	txAccounts.Touched[idx] = true
 	return nil
 }
+
+// tryBorrow marks the account at idx as borrowed. An account may only be
+// borrowed once at a time, even through different instruction accounts.
+func (txAccounts *TransactionAccounts) tryBorrow(idx uint64) error {
+	if txAccounts.IsBorrowed(idx) {
+		return InstrErrAccountBorrowFailed
+	}
+	if len(txAccounts.borrowed) != len(txAccounts.Accounts) {
+		borrowed := make([]bool, len(txAccounts.Accounts))
+		copy(borrowed, txAccounts.borrowed)
+		txAccounts.borrowed = borrowed
+	}
+	txAccounts.borrowed[idx] = true
+	return nil
+}
+
+func (txAccounts *TransactionAccounts) release(idx uint64) {
+	if idx < uint64(len(txAccounts.borrowed)) {
+		txAccounts.borrowed[idx] = false
+	}
+}
+
+// IsBorrowed returns whether the account at idx is currently borrowed.
+func (txAccounts *TransactionAccounts) IsBorrowed(idx uint64) bool {
+	return idx < uint64(len(txAccounts.borrowed)) && txAccounts.borrowed[idx]
+}
@@ -0,0 +1,64 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func TestTransactionCtxPushLimits(t *testing.T) {
+	txCtx := &TransactionCtx{InstructionTrace: []InstructionCtx{{}}, InstructionTraceCapacity: CUMaxInstructionTraceLength}
+
+	for i := 0; i < CUMaxInvokeStackHeight; i++ {
+		require.NoError(t, txCtx.Push())
+	}
+	assert.Equal(t, InstrErrCallDepth, txCtx.Push())
+	assert.Equal(t, uint64(CUMaxInvokeStackHeight), txCtx.InstructionCtxStackHeight())
+
+	txCtx = &TransactionCtx{InstructionTrace: []InstructionCtx{{}}, InstructionTraceCapacity: 3}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, txCtx.Push())
+		require.NoError(t, txCtx.Pop())
+	}
+	assert.Equal(t, InstrErrMaxInstructionTraceLenExceeded, txCtx.Push())
+	assert.Equal(t, uint64(3), txCtx.InstructionTraceLength())
+}
+
+func TestTransactionCtxAccountBorrows(t *testing.T) {
+	instrCtx := InstructionCtx{InstructionAccounts: []InstructionAccount{
+		{IndexInTransaction: 0, IndexInCallee: 0, IsWritable: true},
+		{IndexInTransaction: 0, IndexInCallee: 0, IsWritable: true},
+	}}
+	txCtx := &TransactionCtx{
+		InstructionTrace:         []InstructionCtx{instrCtx},
+		InstructionTraceCapacity: CUMaxInstructionTraceLength,
+		Accounts:                 TransactionAccounts{Accounts: []*accounts.Account{{Lamports: 1}}},
+	}
+	require.NoError(t, txCtx.Push())
+	current, err := txCtx.CurrentInstructionCtx()
+	require.NoError(t, err)
+
+	acct, err := current.BorrowInstructionAccount(txCtx, 0)
+	require.NoError(t, err)
+	assert.True(t, txCtx.Accounts.IsBorrowed(0))
+
+	// duplicate instruction accounts share the same borrow
+	_, err = current.BorrowInstructionAccount(txCtx, 1)
+	assert.Equal(t, InstrErrAccountBorrowFailed, err)
+
+	acct.Drop()
+	acct.Drop()
+	assert.False(t, txCtx.Accounts.IsBorrowed(0))
+	dupe, err := current.BorrowInstructionAccount(txCtx, 1)
+	require.NoError(t, err)
+
+	// instructions must release their accounts before returning
+	assert.Equal(t, InstrErrAccountBorrowOutstanding, txCtx.Pop())
+	dupe.Drop()
+
+	txCtx.InstructionTrace[len(txCtx.InstructionTrace)-1] = instrCtx
+	require.NoError(t, txCtx.Push())
+	assert.NoError(t, txCtx.Pop())
+}
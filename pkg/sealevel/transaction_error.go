@@ -0,0 +1,237 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// transaction error variants, in the order of
+// solana_sdk::transaction::TransactionError
+var txErrVariants = []error{
+	TxErrAccountInUse,
+	TxErrAccountLoadedTwice,
+	TxErrAccountNotFound,
+	TxErrProgramAccountNotFound,
+	TxErrInsufficientFundsForFee,
+	TxErrInvalidAccountForFee,
+	TxErrAlreadyProcessed,
+	TxErrBlockhashNotFound,
+	nil, // InstructionError
+	TxErrCallChainTooDeep,
+	TxErrMissingSignatureForFee,
+	TxErrInvalidAccountIndex,
+	TxErrSignatureFailure,
+	TxErrInvalidProgramForExecution,
+	TxErrSanitizeFailure,
+	TxErrClusterMaintenance,
+	TxErrAccountBorrowOutstanding,
+	TxErrWouldExceedMaxBlockCostLimit,
+	TxErrUnsupportedVersion,
+	TxErrInvalidWritableAccount,
+	TxErrWouldExceedMaxAccountCostLimit,
+	TxErrWouldExceedAccountDataBlockLimit,
+	TxErrTooManyAccountLocks,
+	TxErrAddressLookupTableNotFound,
+	TxErrInvalidAddressLookupTableOwner,
+	TxErrInvalidAddressLookupTableData,
+	TxErrInvalidAddressLookupTableIndex,
+	TxErrInvalidRentPayingAccount,
+	TxErrWouldExceedMaxVoteCostLimit,
+	TxErrWouldExceedAccountDataTotalLimit,
+	TxErrDuplicateInstruction,
+	nil, // InsufficientFundsForRent
+	TxErrMaxLoadedAccountsDataSizeExceeded,
+	TxErrInvalidLoadedAccountsDataSizeLimit,
+	TxErrResanitizationNeeded,
+	nil, // ProgramExecutionTemporarilyRestricted
+	TxErrUnbalancedTransaction,
+	TxErrProgramCacheHitMaxLimit,
+	TxErrCommitCancelled,
+}
+
+const (
+	txErrVariantInstructionError                      = 8
+	txErrVariantDuplicateInstruction                  = 30
+	txErrVariantInsufficientFundsForRent              = 31
+	txErrVariantProgramExecutionTemporarilyRestricted = 35
+)
+
+// instruction error variants, in the order of
+// solana_program::instruction::InstructionError
+var instrErrVariants = []error{
+	InstrErrGenericError,
+	InstrErrInvalidArgument,
+	InstrErrInvalidInstructionData,
+	InstrErrInvalidAccountData,
+	InstrErrAccountDataTooSmall,
+	InstrErrInsufficientFunds,
+	InstrErrIncorrectProgramId,
+	InstrErrMissingRequiredSignature,
+	InstrErrAccountAlreadyInitialized,
+	InstrErrUninitializedAccount,
+	InstrErrUnbalancedInstruction,
+	InstrErrModifiedProgramId,
+	InstrErrExternalAccountLamportSpend,
+	InstrErrExternalAccountDataModified,
+	InstrErrReadonlyLamportChange,
+	InstrErrReadonlyDataModified,
+	InstrErrDuplicateAccountIndex,
+	InstrErrExecutableModified,
+	InstrErrRentEpochModified,
+	InstrErrNotEnoughAccountKeys,
+	InstrErrAccountDataSizeChanged,
+	InstrErrAccountNotExecutable,
+	InstrErrAccountBorrowFailed,
+	InstrErrAccountBorrowOutstanding,
+	InstrErrDuplicateAccountOutOfSync,
+	nil, // Custom
+	InstrErrInvalidError,
+	InstrErrExecutableDataModified,
+	InstrErrExecutableLamportChange,
+	InstrErrExecutableAccountNotRentExempt,
+	InstrErrUnsupportedProgramId,
+	InstrErrCallDepth,
+	InstrErrMissingAccount,
+	InstrErrReentrancyNotAllowed,
+	InstrErrMaxSeedLengthExceeded,
+	InstrErrInvalidSeeds,
+	InstrErrInvalidRealloc,
+	InstrErrComputationalBudgetExceeded,
+	InstrErrPrivilegeEscalation,
+	InstrErrProgramEnvironmentSetupFailure,
+	InstrErrProgramFailedToComplete,
+	InstrErrProgramFailedToCompile,
+	InstrErrImmutable,
+	InstrErrIncorrectAuthority,
+	InstrErrBorshIoError,
+	InstrErrAccountNotRentExempt,
+	InstrErrInvalidAccountOwner,
+	InstrErrArithmeticOverflow,
+	InstrErrUnsupportedSysvar,
+	InstrErrIllegalOwner,
+	InstrErrMaxAccountsDataAllocsExceeded,
+	InstrErrMaxAccountsExceeded,
+	InstrErrMaxInstructionTraceLenExceeded,
+	InstrErrBuiltinProgramsMustConsumeCUs,
+}
+
+const (
+	instrErrVariantCustom       = 25
+	instrErrVariantBorshIoError = 44
+)
+
+// AppendTransactionError appends the bincode encoding of a transaction
+// error, as a solana_sdk::transaction::TransactionError, to buf.
+func AppendTransactionError(buf []byte, err error) ([]byte, error) {
+	var instrErr TxErrInstructionError
+	var rentErr TxErrInsufficientFundsForRent
+	var restrictedErr TxErrProgramExecutionTemporarilyRestricted
+	switch {
+	case errors.As(err, &instrErr):
+		buf = binary.LittleEndian.AppendUint32(buf, txErrVariantInstructionError)
+		buf = append(buf, instrErr.Index)
+		return appendInstructionError(buf, instrErr.Err)
+	case errors.As(err, &rentErr):
+		buf = binary.LittleEndian.AppendUint32(buf, txErrVariantInsufficientFundsForRent)
+		return append(buf, rentErr.AccountIndex), nil
+	case errors.As(err, &restrictedErr):
+		buf = binary.LittleEndian.AppendUint32(buf, txErrVariantProgramExecutionTemporarilyRestricted)
+		return append(buf, restrictedErr.AccountIndex), nil
+	case err == TxErrDuplicateInstruction:
+		// the index of the duplicate instruction is not tracked
+		buf = binary.LittleEndian.AppendUint32(buf, txErrVariantDuplicateInstruction)
+		return append(buf, 0), nil
+	}
+	for variant, variantErr := range txErrVariants {
+		if variantErr != nil && err == variantErr {
+			return binary.LittleEndian.AppendUint32(buf, uint32(variant)), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown transaction error: %w", err)
+}
+
+func appendInstructionError(buf []byte, err error) ([]byte, error) {
+	var custom InstrErrCustom
+	switch {
+	case errors.As(err, &custom):
+		buf = binary.LittleEndian.AppendUint32(buf, instrErrVariantCustom)
+		return binary.LittleEndian.AppendUint32(buf, custom.Code), nil
+	case err == InstrErrBorshIoError:
+		// the message of the error is not tracked
+		buf = binary.LittleEndian.AppendUint32(buf, instrErrVariantBorshIoError)
+		return binary.LittleEndian.AppendUint64(buf, 0), nil
+	}
+	for variant, variantErr := range instrErrVariants {
+		if variantErr != nil && err == variantErr {
+			return binary.LittleEndian.AppendUint32(buf, uint32(variant)), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown instruction error: %w", err)
+}
+
+// DecodeTransactionError decodes a bincode-encoded
+// solana_sdk::transaction::TransactionError from the start of data, and
+// returns it with the number of bytes read.
+func DecodeTransactionError(data []byte) (txErr error, n int, err error) {
+	if len(data) < 4 {
+		return nil, 0, fmt.Errorf("transaction error truncated")
+	}
+	variant := binary.LittleEndian.Uint32(data)
+	n = 4
+	switch variant {
+	case txErrVariantInstructionError:
+		if len(data) < n+1 {
+			return nil, 0, fmt.Errorf("instruction error truncated")
+		}
+		index := data[n]
+		instrErr, instrLen, err := decodeInstructionError(data[n+1:])
+		if err != nil {
+			return nil, 0, err
+		}
+		return TxErrInstructionError{Index: index, Err: instrErr}, n + 1 + instrLen, nil
+	case txErrVariantDuplicateInstruction, txErrVariantInsufficientFundsForRent, txErrVariantProgramExecutionTemporarilyRestricted:
+		if len(data) < n+1 {
+			return nil, 0, fmt.Errorf("transaction error truncated")
+		}
+		switch variant {
+		case txErrVariantInsufficientFundsForRent:
+			return TxErrInsufficientFundsForRent{AccountIndex: data[n]}, n + 1, nil
+		case txErrVariantProgramExecutionTemporarilyRestricted:
+			return TxErrProgramExecutionTemporarilyRestricted{AccountIndex: data[n]}, n + 1, nil
+		}
+		return TxErrDuplicateInstruction, n + 1, nil
+	}
+	if variant >= uint32(len(txErrVariants)) {
+		return nil, 0, fmt.Errorf("unknown transaction error variant %d", variant)
+	}
+	return txErrVariants[variant], n, nil
+}
+
+func decodeInstructionError(data []byte) (instrErr error, n int, err error) {
+	if len(data) < 4 {
+		return nil, 0, fmt.Errorf("instruction error truncated")
+	}
+	variant := binary.LittleEndian.Uint32(data)
+	n = 4
+	switch variant {
+	case instrErrVariantCustom:
+		if len(data) < n+4 {
+			return nil, 0, fmt.Errorf("instruction error truncated")
+		}
+		return InstrErrCustom{Code: binary.LittleEndian.Uint32(data[n:])}, n + 4, nil
+	case instrErrVariantBorshIoError:
+		if len(data) < n+8 {
+			return nil, 0, fmt.Errorf("instruction error truncated")
+		}
+		msgLen := binary.LittleEndian.Uint64(data[n:])
+		if msgLen > uint64(len(data)-n-8) {
+			return nil, 0, fmt.Errorf("instruction error truncated")
+		}
+		return InstrErrBorshIoError, n + 8 + int(msgLen), nil
+	}
+	if variant >= uint32(len(instrErrVariants)) {
+		return nil, 0, fmt.Errorf("unknown instruction error variant %d", variant)
+	}
+	return instrErrVariants[variant], n, nil
+}
@@ -0,0 +1,39 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionErrorEncoding(t *testing.T) {
+	cases := []struct {
+		err  error
+		data []byte
+	}{
+		{TxErrAccountInUse, []byte{0, 0, 0, 0}},
+		{TxErrBlockhashNotFound, []byte{7, 0, 0, 0}},
+		{TxErrInstructionError{Index: 2, Err: InstrErrInvalidArgument}, []byte{8, 0, 0, 0, 2, 1, 0, 0, 0}},
+		{TxErrInstructionError{Index: 0, Err: InstrErrCustom{Code: 6}}, []byte{8, 0, 0, 0, 0, 25, 0, 0, 0, 6, 0, 0, 0}},
+		{TxErrInsufficientFundsForRent{AccountIndex: 3}, []byte{31, 0, 0, 0, 3}},
+		{TxErrCommitCancelled, []byte{38, 0, 0, 0}},
+	}
+	for _, c := range cases {
+		data, err := AppendTransactionError(nil, c.err)
+		require.NoError(t, err)
+		assert.Equal(t, c.data, data, c.err.Error())
+
+		decoded, n, err := DecodeTransactionError(append(data, 0xff))
+		require.NoError(t, err)
+		assert.Equal(t, len(data), n)
+		assert.Equal(t, c.err, decoded)
+	}
+
+	_, err := AppendTransactionError(nil, InstrErrInvalidArgument)
+	assert.Error(t, err)
+	_, _, err = DecodeTransactionError([]byte{39, 0, 0, 0})
+	assert.Error(t, err)
+	_, _, err = DecodeTransactionError([]byte{8, 0, 0, 0, 2})
+	assert.Error(t, err)
+}
@@ -0,0 +1,219 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/safemath"
+	"k8s.io/klog/v2"
+)
+
+// A transaction is loaded before it executes: its fee payer is validated
+// and charged the transaction fee, and the accounts it references are read,
+// up to its loaded accounts data size limit. Failing to load a transaction
+// is a transaction error; the transaction is not executed, and, other than
+// for a fee payer that cannot pay, the fee is still charged.
+
+// LoadedTransaction is the accounts of a transaction, loaded for execution.
+type LoadedTransaction struct {
+	// Accounts are the accounts of the transaction, in the order of its
+	// account keys. The fee payer has been charged the transaction fee.
+	Accounts []*accounts.Account
+
+	// ProgramIndices are, for each instruction, the transaction indices of
+	// the accounts of its program. Builtin programs owned by the native
+	// loader have none.
+	ProgramIndices [][]uint64
+
+	// LoadedAccountsDataSize is the total data size of the loaded accounts.
+	LoadedAccountsDataSize uint32
+
+	// Rent is the rent collected from the writable accounts.
+	Rent uint64
+}
+
+// systemAccountKind is the kind of a system program owned account that may
+// pay transaction fees.
+type systemAccountKind int
+
+const (
+	systemAccountKindSystem systemAccountKind = iota
+	systemAccountKindNonce
+)
+
+// getSystemAccountKind returns the kind of the given account, or false if
+// it is neither a system account nor an initialized nonce account.
+//
+// Based on solana_sdk::system_instruction::get_system_account_kind.
+func getSystemAccountKind(acct *accounts.Account) (systemAccountKind, bool) {
+	if acct.Owner != SystemProgramAddr {
+		return 0, false
+	}
+	if len(acct.Data) == 0 {
+		return systemAccountKindSystem, true
+	}
+	if len(acct.Data) != NonceStateVersionsSize {
+		return 0, false
+	}
+	nonceStateVersions, err := unmarshalNonceStateVersions(acct.Data)
+	if err != nil || !nonceStateVersions.State().IsInitialized {
+		return 0, false
+	}
+	return systemAccountKindNonce, true
+}
+
+// ValidateFeePayer checks that the given fee payer can pay the fee, and
+// charges it. The fee payer must be a system account or an initialized nonce
+// account, left rent exempt and, for a nonce account, with at least its rent
+// exempt minimum balance.
+//
+// Based on solana_svm::account_loader::validate_fee_payer.
+func ValidateFeePayer(payerKey solana.PublicKey, payer *accounts.Account, payerIdx uint64, fee uint64, rent *SysvarRent) error {
+	if payer.Lamports == 0 {
+		klog.Infof("fee payer has no lamports")
+		return TxErrAccountNotFound
+	}
+
+	kind, ok := getSystemAccountKind(payer)
+	if !ok {
+		klog.Infof("fee payer is not a system account or nonce account")
+		return TxErrInvalidAccountForFee
+	}
+
+	var minBalance uint64
+	if kind == systemAccountKindNonce {
+		minBalance = rent.MinimumBalance(NonceStateVersionsSize)
+	}
+
+	balance, err := safemath.CheckedSubU64(payer.Lamports, minBalance)
+	if err == nil {
+		_, err = safemath.CheckedSubU64(balance, fee)
+	}
+	if err != nil {
+		klog.Infof("fee payer has insufficient funds for fee of %d lamports", fee)
+		return TxErrInsufficientFundsForFee
+	}
+
+	pre := NewRentState(payer, rent)
+	payer.Lamports -= fee
+	post := NewRentState(payer, rent)
+
+	return checkRentStateWithAccount(pre, post, payerKey, payerIdx)
+}
+
+// accumulateLoadedAccountsDataSize adds the data size of an account to the
+// loaded accounts data size of a transaction, failing if it exceeds the
+// requested limit.
+func accumulateLoadedAccountsDataSize(total *uint32, dataLen int, limit uint32) error {
+	size := safemath.SaturatingAddU64(uint64(*total), uint64(dataLen))
+	if size > uint64(limit) {
+		klog.Infof("loaded accounts data size %d exceeds limit of %d bytes", size, limit)
+		return TxErrMaxLoadedAccountsDataSizeExceeded
+	}
+	*total = uint32(size)
+	return nil
+}
+
+// LoadTransactionAccounts loads the accounts referenced by a transaction,
+// and collects rent from its writable accounts. The first account key is
+// the fee payer, which is validated and charged the given fee. Accounts that
+// do not exist are loaded as empty accounts, except for the fee payer and
+// programs. The Instructions sysvar is constructed from the instructions of
+// the transaction. The loaded accounts, together
+// with the accounts of the programs that own the invoked programs, may not
+// exceed the LoadedAccountsBytes limit.
+//
+// Based on solana_svm::account_loader::load_transaction_accounts.
+func LoadTransactionAccounts(accts accounts.Accounts, accountKeys []solana.PublicKey, isWritable []bool, instrs []Instruction, fee uint64, rentCollector *RentCollector, limits ComputeBudgetLimits, f *features.Features) (*LoadedTransaction, error) {
+	if len(accountKeys) == 0 {
+		return nil, TxErrAccountNotFound
+	}
+
+	loaded := &LoadedTransaction{Accounts: make([]*accounts.Account, len(accountKeys))}
+
+	for idx, key := range accountKeys {
+		var acct *accounts.Account
+		if key == SysvarInstructionsAddr {
+			acct = NewInstructionsSysvarAccount(instrs)
+		} else {
+			existing, err := accts.GetAccount((*[32]byte)(&key))
+			if err == nil && existing != nil {
+				acctCopy := *existing
+				acctCopy.Data = append([]byte{}, existing.Data...)
+				acct = &acctCopy
+			} else if idx == 0 {
+				klog.Infof("fee payer %s not found", key)
+				return nil, TxErrAccountNotFound
+			} else {
+				acct = &accounts.Account{}
+			}
+
+			err = accumulateLoadedAccountsDataSize(&loaded.LoadedAccountsDataSize, len(acct.Data), limits.LoadedAccountsBytes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if idx < len(isWritable) && isWritable[idx] && key != SysvarInstructionsAddr && acct.Lamports != 0 {
+			collected := CollectRentFromAccount(f, rentCollector, key, acct)
+			loaded.Rent = safemath.SaturatingAddU64(loaded.Rent, collected.RentAmount)
+		}
+
+		if idx == 0 {
+			err := ValidateFeePayer(key, acct, 0, fee, &rentCollector.Rent)
+			if err != nil {
+				return nil, err
+			}
+		}
+		loaded.Accounts[idx] = acct
+	}
+
+	// owners of invoked programs are loaded once each, and count towards
+	// the loaded accounts data size
+	validatedOwners := make(map[solana.PublicKey]bool)
+
+	loaded.ProgramIndices = make([][]uint64, len(instrs))
+	for i, instr := range instrs {
+		if instr.ProgramId == NativeLoaderAddr {
+			loaded.ProgramIndices[i] = []uint64{}
+			continue
+		}
+
+		programIdx := -1
+		for idx, key := range accountKeys {
+			if key == instr.ProgramId {
+				programIdx = idx
+				break
+			}
+		}
+		if programIdx == -1 || loaded.Accounts[programIdx].Lamports == 0 {
+			klog.Infof("program %s not found", instr.ProgramId)
+			return nil, TxErrProgramAccountNotFound
+		}
+
+		programAcct := loaded.Accounts[programIdx]
+		if !programAcct.IsBuiltin() && !isExecutable(programAcct, *f) {
+			klog.Infof("program %s is not executable", instr.ProgramId)
+			return nil, TxErrInvalidProgramForExecution
+		}
+		loaded.ProgramIndices[i] = []uint64{uint64(programIdx)}
+
+		owner := solana.PublicKeyFromBytes(programAcct.Owner[:])
+		if owner == NativeLoaderAddr || validatedOwners[owner] {
+			continue
+		}
+
+		ownerAcct, err := accts.GetAccount((*[32]byte)(&owner))
+		if err != nil || ownerAcct == nil || (!ownerAcct.IsBuiltin() && !isExecutable(ownerAcct, *f)) || ownerAcct.Owner != NativeLoaderAddr {
+			klog.Infof("owner %s of program %s is not a builtin loader", owner, instr.ProgramId)
+			return nil, TxErrInvalidProgramForExecution
+		}
+		err = accumulateLoadedAccountsDataSize(&loaded.LoadedAccountsDataSize, len(ownerAcct.Data), limits.LoadedAccountsBytes)
+		if err != nil {
+			return nil, err
+		}
+		validatedOwners[owner] = true
+	}
+
+	return loaded, nil
+}
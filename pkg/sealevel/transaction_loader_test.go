@@ -0,0 +1,102 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func TestValidateFeePayer(t *testing.T) {
+	rent := &SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}
+	minBalance := rent.MinimumBalance(0)
+	payerKey := solana.PublicKey{9}
+
+	payer := &accounts.Account{Lamports: minBalance + 5000, Owner: SystemProgramAddr}
+	require.NoError(t, ValidateFeePayer(payerKey, payer, 0, 5000, rent))
+	assert.Equal(t, minBalance, payer.Lamports)
+
+	// the fee payer may be emptied, but not left rent paying
+	payer = &accounts.Account{Lamports: 5000, Owner: SystemProgramAddr}
+	require.NoError(t, ValidateFeePayer(payerKey, payer, 0, 5000, rent))
+	assert.Equal(t, uint64(0), payer.Lamports)
+
+	payer = &accounts.Account{Lamports: minBalance, Owner: SystemProgramAddr}
+	assert.Equal(t, TxErrInsufficientFundsForRent{AccountIndex: 0}, ValidateFeePayer(payerKey, payer, 0, 5000, rent))
+
+	payer = &accounts.Account{Lamports: 4999, Owner: SystemProgramAddr}
+	assert.Equal(t, TxErrInsufficientFundsForFee, ValidateFeePayer(payerKey, payer, 0, 5000, rent))
+
+	payer = &accounts.Account{Owner: SystemProgramAddr}
+	assert.Equal(t, TxErrAccountNotFound, ValidateFeePayer(payerKey, payer, 0, 5000, rent))
+
+	payer = &accounts.Account{Lamports: minBalance + 5000, Owner: VoteProgramAddr}
+	assert.Equal(t, TxErrInvalidAccountForFee, ValidateFeePayer(payerKey, payer, 0, 5000, rent))
+
+	// nonce accounts must keep their rent exempt minimum balance
+	nonceMinBalance := rent.MinimumBalance(NonceStateVersionsSize)
+	payer = newTestNonceAcct(t, solana.PublicKey{1}, [32]byte{2})
+	payer.Lamports = nonceMinBalance + 4999
+	assert.Equal(t, TxErrInsufficientFundsForFee, ValidateFeePayer(payerKey, payer, 0, 5000, rent))
+	payer.Lamports = nonceMinBalance + 5000
+	require.NoError(t, ValidateFeePayer(payerKey, payer, 0, 5000, rent))
+	assert.Equal(t, nonceMinBalance, payer.Lamports)
+
+	// uninitialized nonce accounts cannot pay fees
+	payer = &accounts.Account{Lamports: nonceMinBalance + 5000, Owner: SystemProgramAddr, Data: make([]byte, NonceStateVersionsSize)}
+	assert.Equal(t, TxErrInvalidAccountForFee, ValidateFeePayer(payerKey, payer, 0, 5000, rent))
+}
+
+func TestLoadTransactionAccounts(t *testing.T) {
+	rentCollector := &RentCollector{Rent: SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50}}
+	f := features.NewFeaturesDefault()
+	limits := ComputeBudgetLimits{LoadedAccountsBytes: CUMaxLoadedAccountsDataSizeBytes}
+
+	payerKey, recipientKey, programKey := solana.PublicKey{1}, solana.PublicKey{2}, solana.PublicKey{3}
+	accts := accounts.NewMemAccounts()
+	require.NoError(t, accts.SetAccount((*[32]byte)(&payerKey), &accounts.Account{Lamports: 1_000_000_000, Owner: SystemProgramAddr}))
+	require.NoError(t, accts.SetAccount((*[32]byte)(&programKey), &accounts.Account{Lamports: 1, Owner: BpfLoaderUpgradeableAddr, Executable: true, Data: make([]byte, 36)}))
+	require.NoError(t, accts.SetAccount((*[32]byte)(&BpfLoaderUpgradeableAddr), &accounts.Account{Lamports: 1, Owner: NativeLoaderAddr, Executable: true, Data: []byte("loader")}))
+
+	instrs := []Instruction{{ProgramId: programKey, Accounts: []AccountMeta{{Pubkey: recipientKey, IsWritable: true}}}}
+	keys := []solana.PublicKey{payerKey, recipientKey, programKey, SysvarInstructionsAddr}
+	isWritable := []bool{true, true, false, false}
+
+	loaded, err := LoadTransactionAccounts(accts, keys, isWritable, instrs, 5000, rentCollector, limits, f)
+	require.NoError(t, err)
+	require.Len(t, loaded.Accounts, 4)
+	assert.Equal(t, uint64(1_000_000_000-5000), loaded.Accounts[0].Lamports)
+	assert.Equal(t, uint64(RentExemptRentEpoch), loaded.Accounts[0].RentEpoch)
+	assert.Equal(t, &accounts.Account{}, loaded.Accounts[1])
+	assert.Equal(t, ConstructInstructionsSysvarData(instrs), loaded.Accounts[3].Data)
+	assert.Equal(t, [][]uint64{{2}}, loaded.ProgramIndices)
+	assert.Equal(t, uint32(36+len("loader")), loaded.LoadedAccountsDataSize)
+
+	// the loaded accounts, including the program owner, must fit the limit
+	limits.LoadedAccountsBytes = 36 + 5
+	_, err = LoadTransactionAccounts(accts, keys, isWritable, instrs, 5000, rentCollector, limits, f)
+	assert.Equal(t, TxErrMaxLoadedAccountsDataSizeExceeded, err)
+	limits.LoadedAccountsBytes = CUMaxLoadedAccountsDataSizeBytes
+
+	// missing fee payer
+	_, err = LoadTransactionAccounts(accts, []solana.PublicKey{recipientKey, programKey}, isWritable, instrs, 5000, rentCollector, limits, f)
+	assert.Equal(t, TxErrAccountNotFound, err)
+
+	// missing program
+	_, err = LoadTransactionAccounts(accts, keys, isWritable, []Instruction{{ProgramId: recipientKey}}, 5000, rentCollector, limits, f)
+	assert.Equal(t, TxErrProgramAccountNotFound, err)
+
+	// program owned by an account that is not a builtin loader
+	ownerKey := solana.PublicKey{4}
+	require.NoError(t, accts.SetAccount((*[32]byte)(&programKey), &accounts.Account{Lamports: 1, Owner: ownerKey, Executable: true}))
+	_, err = LoadTransactionAccounts(accts, keys, isWritable, instrs, 5000, rentCollector, limits, f)
+	assert.Equal(t, TxErrInvalidProgramForExecution, err)
+
+	// program that is not executable
+	require.NoError(t, accts.SetAccount((*[32]byte)(&programKey), &accounts.Account{Lamports: 1, Owner: BpfLoaderUpgradeableAddr}))
+	_, err = LoadTransactionAccounts(accts, keys, isWritable, instrs, 5000, rentCollector, limits, f)
+	assert.Equal(t, TxErrInvalidProgramForExecution, err)
+}
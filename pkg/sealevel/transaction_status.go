@@ -0,0 +1,78 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+// TransactionStatusMeta is the execution status of a transaction, as
+// recorded in the ledger and returned by RPC getTransaction.
+//
+// Based on solana_transaction_status::TransactionStatusMeta.
+type TransactionStatusMeta struct {
+	Err                  error
+	Fee                  uint64
+	PreBalances          []uint64
+	PostBalances         []uint64
+	InnerInstructions    []InnerInstructions
+	LogMessages          []string
+	PreTokenBalances     []TokenBalance
+	PostTokenBalances    []TokenBalance
+	LoadedAddresses      LoadedAddresses
+	ComputeUnitsConsumed uint64
+}
+
+// TransactionBalances are the lamport and token balances of the accounts of
+// a transaction, captured before or after it executes.
+type TransactionBalances struct {
+	Balances      []uint64
+	TokenBalances []TokenBalance
+}
+
+// CollectBalances returns the lamport balance of each of the given
+// accounts, as currently stored in accts. Accounts that do not exist have
+// a zero balance.
+//
+// Based on solana_runtime::bank::Bank::collect_balances.
+func CollectBalances(accts accounts.Accounts, accountKeys []solana.PublicKey) []uint64 {
+	balances := make([]uint64, len(accountKeys))
+	for idx, key := range accountKeys {
+		acct, err := accts.GetAccount((*[32]byte)(&key))
+		if err == nil && acct != nil {
+			balances[idx] = acct.Lamports
+		}
+	}
+	return balances
+}
+
+// CollectTransactionBalances returns the lamport and token balances of the
+// accounts of a transaction, as currently stored in accts.
+func CollectTransactionBalances(accts accounts.Accounts, accountKeys []solana.PublicKey, instrs []Instruction) TransactionBalances {
+	return TransactionBalances{
+		Balances:      CollectBalances(accts, accountKeys),
+		TokenBalances: CollectTokenBalances(accts, accountKeys, instrs),
+	}
+}
+
+// NewTransactionStatusMeta returns the status meta of a committed
+// transaction, from the balances captured before and after it, and the
+// instruction trace of its transaction context. txCtx is nil if the
+// transaction failed to load, and was not executed. The caller records the
+// log messages and compute units consumed.
+func NewTransactionStatusMeta(txErr error, fee uint64, pre TransactionBalances, post TransactionBalances, txCtx *TransactionCtx, loadedAddresses LoadedAddresses) *TransactionStatusMeta {
+	meta := &TransactionStatusMeta{
+		Err:               txErr,
+		Fee:               fee,
+		PreBalances:       pre.Balances,
+		PostBalances:      post.Balances,
+		PreTokenBalances:  pre.TokenBalances,
+		PostTokenBalances: post.TokenBalances,
+		LoadedAddresses:   loadedAddresses,
+	}
+
+	if txCtx != nil {
+		meta.InnerInstructions = InnerInstructionsFromTrace(txCtx)
+	}
+
+	return meta
+}
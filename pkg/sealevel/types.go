@@ -40,12 +40,13 @@ type AccountMeta struct {
 	IsWritable bool
 }
 
-const SolAccountMetaCSize = 10
+// SolAccountMetaCSize is the size of SolAccountMeta, including trailing padding.
+const SolAccountMetaCSize = 16
 
 type SolAccountMetaC struct {
 	PubkeyAddr uint64
-	IsSigner   byte
 	IsWritable byte
+	IsSigner   byte
 }
 
 const SolAccountMetaRustSize = 34
@@ -77,7 +78,11 @@ type InstructionAccount struct {
 	IsWritable         bool
 }
 
-const SolAccountInfoCSize = 51
+// SolAccountInfoCSize is the size of SolAccountInfo, including trailing padding.
+const SolAccountInfoCSize = 56
+
+// SolAccountInfoCDataLenOffset is the offset of the data_len field in SolAccountInfo.
+const SolAccountInfoCDataLenOffset = 16
 
 type SolAccountInfoC struct {
 	KeyAddr      uint64
@@ -91,7 +96,8 @@ type SolAccountInfoC struct {
 	Executable   bool
 }
 
-const SolAccountInfoRustSize = 43
+// SolAccountInfoRustSize is the size of AccountInfo, including trailing padding.
+const SolAccountInfoRustSize = 48
 
 type SolAccountInfoRust struct {
 	PubkeyAddr      uint64 // points to uchar[32]
@@ -106,6 +112,13 @@ type SolAccountInfoRust struct {
 
 const RefCellRustSize = 32
 
+// RefCellVecRustSize is the size of an Rc<RefCell<&mut [u8]>> allocation.
+const RefCellVecRustSize = 40
+
+// RefCellVecRustLenOffset is the offset of the slice length in an
+// Rc<RefCell<&mut [u8]>> allocation.
+const RefCellVecRustLenOffset = 32
+
 type RefCellRust struct {
 	Strong uint64
 	Weak   uint64
@@ -128,13 +141,18 @@ type TranslatedAccount struct {
 	CallerAccount  *CallerAccount
 }
 
+// CallerAccount is the caller's view of an account passed to a CPI,
+// as described by an AccountInfo in the caller's memory.
 type CallerAccount struct {
 	Lamports          uint64
 	Owner             solana.PublicKey
+	OriginalDataLen   uint64 // data length when the caller's input was serialized
 	SerializedData    *[]byte
 	SerializedDataLen uint64
 	VmDataAddr        uint64
-	RefToLenInVm      uint64
+	RefToLenInVm      uint64 // address of the AccountInfo's data length
+	VmLamportsAddr    uint64
+	VmOwnerAddr       uint64
 	Executable        bool
 	RentEpoch         uint64
 }
@@ -191,16 +209,19 @@ func (accountMeta *SolAccountMetaC) Unmarshal(buf io.Reader) error {
 		return err
 	}
 
-	err = binary.Read(buf, binary.LittleEndian, &accountMeta.IsSigner)
+	err = binary.Read(buf, binary.LittleEndian, &accountMeta.IsWritable)
 	if err != nil {
 		return err
 	}
 
-	err = binary.Read(buf, binary.LittleEndian, &accountMeta.IsWritable)
+	err = binary.Read(buf, binary.LittleEndian, &accountMeta.IsSigner)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	var padding [SolAccountMetaCSize - 10]byte
+	_, err = io.ReadFull(buf, padding[:])
+	return err
 }
 
 func (accountMeta *SolAccountMetaC) Marshal() ([]byte, error) {
@@ -212,12 +233,18 @@ func (accountMeta *SolAccountMetaC) Marshal() ([]byte, error) {
 		return nil, err
 	}
 
+	err = binary.Write(buf, binary.LittleEndian, accountMeta.IsWritable)
+	if err != nil {
+		return nil, err
+	}
+
 	err = binary.Write(buf, binary.LittleEndian, accountMeta.IsSigner)
 	if err != nil {
 		return nil, err
 	}
 
-	err = binary.Write(buf, binary.LittleEndian, accountMeta.IsWritable)
+	var padding [SolAccountMetaCSize - 10]byte
+	_, err = buf.Write(padding[:])
 	if err != nil {
 		return nil, err
 	}
@@ -458,7 +485,9 @@ func (accountInfo *SolAccountInfoC) Unmarshal(buf io.Reader) error {
 		return err
 	}
 
-	return nil
+	var padding [SolAccountInfoCSize - 51]byte
+	_, err = io.ReadFull(buf, padding[:])
+	return err
 }
 
 func (accountInfo *SolAccountInfoRust) Unmarshal(buf io.Reader) error {
@@ -502,7 +531,9 @@ func (accountInfo *SolAccountInfoRust) Unmarshal(buf io.Reader) error {
 		return err
 	}
 
-	return nil
+	var padding [SolAccountInfoRustSize - 43]byte
+	_, err = io.ReadFull(buf, padding[:])
+	return err
 }
 
 func (refCell *RefCellRust) Unmarshal(buf io.Reader) error {
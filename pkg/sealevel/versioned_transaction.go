@@ -0,0 +1,146 @@
+package sealevel
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"k8s.io/klog/v2"
+)
+
+// LoadedAddresses are the account keys loaded from address lookup tables by
+// a v0 transaction. They follow the static account keys of the transaction,
+// writable addresses first.
+//
+// Based on solana_sdk::message::v0::LoadedAddresses.
+type LoadedAddresses struct {
+	Writable []solana.PublicKey
+	Readonly []solana.PublicKey
+}
+
+// LoadLookupTableAddresses resolves the address table lookups of a v0
+// transaction against the lookup table accounts as of the current slot.
+//
+// Based on solana_runtime::bank::address_lookup_table::load_lookup_table_addresses.
+func LoadLookupTableAddresses(accts accounts.Accounts, lookups []solana.MessageAddressTableLookup, currentSlot uint64, slotHashes SysvarSlotHashes) (LoadedAddresses, error) {
+	var loaded LoadedAddresses
+
+	for _, lookup := range lookups {
+		tableAcct, err := accts.GetAccount((*[32]byte)(&lookup.AccountKey))
+		if err != nil || tableAcct == nil || tableAcct.Lamports == 0 {
+			klog.Infof("address lookup table %s not found", lookup.AccountKey)
+			return LoadedAddresses{}, TxErrAddressLookupTableNotFound
+		}
+		if tableAcct.Owner != AddressLookupTableProgramAddr {
+			return LoadedAddresses{}, TxErrInvalidAddressLookupTableOwner
+		}
+
+		table, err := UnmarshalAddressLookupTable(tableAcct.Data)
+		if err != nil {
+			klog.Infof("address lookup table %s: %s", lookup.AccountKey, err)
+			return LoadedAddresses{}, TxErrInvalidAddressLookupTableData
+		}
+
+		writable, err := table.Lookup(currentSlot, lookup.WritableIndexes, slotHashes)
+		if err == nil {
+			var readonly []solana.PublicKey
+			readonly, err = table.Lookup(currentSlot, lookup.ReadonlyIndexes, slotHashes)
+			loaded.Readonly = append(loaded.Readonly, readonly...)
+		}
+		if err == errLookupTableNotActive {
+			klog.Infof("address lookup table %s is deactivated", lookup.AccountKey)
+			return LoadedAddresses{}, TxErrAddressLookupTableNotFound
+		} else if err != nil {
+			return LoadedAddresses{}, TxErrInvalidAddressLookupTableIndex
+		}
+		loaded.Writable = append(loaded.Writable, writable...)
+	}
+
+	return loaded, nil
+}
+
+// ResolvedTransaction is a legacy or v0 transaction with its account keys
+// resolved, ready to be loaded and executed.
+type ResolvedTransaction struct {
+	// AccountKeys are the static account keys of the transaction, followed
+	// by its loaded addresses.
+	AccountKeys     []solana.PublicKey
+	IsSigner        []bool
+	IsWritable      []bool
+	LoadedAddresses LoadedAddresses
+	Instructions    []Instruction
+}
+
+// ResolveTransaction resolves the account keys of a decoded transaction,
+// loading the addresses of a v0 transaction from its address lookup tables,
+// and the instructions of the transaction against them. The static account
+// keys are signers and writable as described by the message header; loaded
+// addresses are never signers. Reserved accounts are demoted to readonly,
+// so that transactions never write lock them. The message is sanitized
+// before anything is loaded.
+func ResolveTransaction(tx *solana.Transaction, accts accounts.Accounts, currentSlot uint64, slotHashes SysvarSlotHashes, reservedKeys *ReservedAccountKeys) (*ResolvedTransaction, error) {
+	sanitized, err := NewSanitizedMessage(&tx.Message)
+	if err != nil {
+		return nil, err
+	}
+	msg := sanitized.Message
+	resolved := &ResolvedTransaction{}
+
+	if msg.IsVersioned() {
+		loaded, err := LoadLookupTableAddresses(accts, msg.GetAddressTableLookups(), currentSlot, slotHashes)
+		if err != nil {
+			return nil, err
+		}
+		resolved.LoadedAddresses = loaded
+	}
+
+	numStatic := len(msg.AccountKeys)
+	numSigned := int(msg.Header.NumRequiredSignatures)
+	numWritableSigned := numSigned - int(msg.Header.NumReadonlySignedAccounts)
+	numWritableUnsigned := numStatic - numSigned - int(msg.Header.NumReadonlyUnsignedAccounts)
+
+	resolved.AccountKeys = make([]solana.PublicKey, 0, numStatic+len(resolved.LoadedAddresses.Writable)+len(resolved.LoadedAddresses.Readonly))
+	resolved.AccountKeys = append(resolved.AccountKeys, msg.AccountKeys...)
+	resolved.AccountKeys = append(resolved.AccountKeys, resolved.LoadedAddresses.Writable...)
+	resolved.AccountKeys = append(resolved.AccountKeys, resolved.LoadedAddresses.Readonly...)
+
+	resolved.IsSigner = make([]bool, len(resolved.AccountKeys))
+	resolved.IsWritable = make([]bool, len(resolved.AccountKeys))
+	for idx := range resolved.AccountKeys {
+		switch {
+		case idx < numSigned:
+			resolved.IsSigner[idx] = true
+			resolved.IsWritable[idx] = idx < numWritableSigned
+		case idx < numStatic:
+			resolved.IsWritable[idx] = idx-numSigned < numWritableUnsigned
+		default:
+			resolved.IsWritable[idx] = idx-numStatic < len(resolved.LoadedAddresses.Writable)
+		}
+		if reservedKeys.IsReserved(resolved.AccountKeys[idx]) {
+			resolved.IsWritable[idx] = false
+		}
+	}
+
+	resolved.Instructions = make([]Instruction, len(msg.Instructions))
+	for i, compiled := range msg.Instructions {
+		if int(compiled.ProgramIDIndex) >= len(resolved.AccountKeys) {
+			return nil, TxErrSanitizeFailure
+		}
+		instr := Instruction{
+			ProgramId: resolved.AccountKeys[compiled.ProgramIDIndex],
+			Data:      compiled.Data,
+			Accounts:  make([]AccountMeta, len(compiled.Accounts)),
+		}
+		for j, acctIdx := range compiled.Accounts {
+			if int(acctIdx) >= len(resolved.AccountKeys) {
+				return nil, TxErrSanitizeFailure
+			}
+			instr.Accounts[j] = AccountMeta{
+				Pubkey:     resolved.AccountKeys[acctIdx],
+				IsSigner:   resolved.IsSigner[acctIdx],
+				IsWritable: resolved.IsWritable[acctIdx],
+			}
+		}
+		resolved.Instructions[i] = instr
+	}
+
+	return resolved, nil
+}
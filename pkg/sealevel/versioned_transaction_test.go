@@ -0,0 +1,99 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func newTestLookupTableAcct(deactivationSlot, lastExtendedSlot uint64, lastExtendedSlotStartIndex uint8, addresses []solana.PublicKey) *accounts.Account {
+	data := make([]byte, LookupTableMetaSize, LookupTableMetaSize+len(addresses)*solana.PublicKeyLength)
+	binary.LittleEndian.PutUint32(data[0:], LookupTableProgramStateLookupTable)
+	binary.LittleEndian.PutUint64(data[4:], deactivationSlot)
+	binary.LittleEndian.PutUint64(data[12:], lastExtendedSlot)
+	data[20] = lastExtendedSlotStartIndex
+	for _, addr := range addresses {
+		data = append(data, addr[:]...)
+	}
+	return &accounts.Account{Lamports: 1_000_000, Owner: AddressLookupTableProgramAddr, Data: data}
+}
+
+func TestLoadLookupTableAddresses(t *testing.T) {
+	tableKey := solana.PublicKey{0xaa}
+	addresses := []solana.PublicKey{{1}, {2}, {3}, {4}}
+	accts := accounts.NewMemAccounts()
+	require.NoError(t, accts.SetAccount((*[32]byte)(&tableKey), newTestLookupTableAcct(math.MaxUint64, 10, 3, addresses)))
+
+	lookups := []solana.MessageAddressTableLookup{{AccountKey: tableKey, WritableIndexes: []uint8{2}, ReadonlyIndexes: []uint8{0, 3}}}
+	loaded, err := LoadLookupTableAddresses(accts, lookups, 11, nil)
+	require.NoError(t, err)
+	assert.Equal(t, LoadedAddresses{Writable: []solana.PublicKey{{3}}, Readonly: []solana.PublicKey{{1}, {4}}}, loaded)
+
+	// addresses appended in the current slot are not visible yet
+	_, err = LoadLookupTableAddresses(accts, lookups, 10, nil)
+	assert.Equal(t, TxErrInvalidAddressLookupTableIndex, err)
+
+	// deactivated tables are usable while the deactivation slot is recent
+	require.NoError(t, accts.SetAccount((*[32]byte)(&tableKey), newTestLookupTableAcct(12, 10, 3, addresses)))
+	_, err = LoadLookupTableAddresses(accts, lookups, 13, SysvarSlotHashes{{Slot: 12}})
+	assert.NoError(t, err)
+	_, err = LoadLookupTableAddresses(accts, lookups, 13, SysvarSlotHashes{{Slot: 11}})
+	assert.Equal(t, TxErrAddressLookupTableNotFound, err)
+
+	tableAcct := newTestLookupTableAcct(math.MaxUint64, 10, 3, addresses)
+	tableAcct.Owner = SystemProgramAddr
+	require.NoError(t, accts.SetAccount((*[32]byte)(&tableKey), tableAcct))
+	_, err = LoadLookupTableAddresses(accts, lookups, 11, nil)
+	assert.Equal(t, TxErrInvalidAddressLookupTableOwner, err)
+
+	tableAcct = newTestLookupTableAcct(math.MaxUint64, 10, 3, addresses)
+	tableAcct.Data = tableAcct.Data[:len(tableAcct.Data)-1]
+	require.NoError(t, accts.SetAccount((*[32]byte)(&tableKey), tableAcct))
+	_, err = LoadLookupTableAddresses(accts, lookups, 11, nil)
+	assert.Equal(t, TxErrInvalidAddressLookupTableData, err)
+
+	_, err = LoadLookupTableAddresses(accts, []solana.MessageAddressTableLookup{{AccountKey: solana.PublicKey{0xbb}}}, 11, nil)
+	assert.Equal(t, TxErrAddressLookupTableNotFound, err)
+}
+
+func TestResolveTransaction(t *testing.T) {
+	tableKey := solana.PublicKey{0xaa}
+	accts := accounts.NewMemAccounts()
+	require.NoError(t, accts.SetAccount((*[32]byte)(&tableKey), newTestLookupTableAcct(math.MaxUint64, 0, 0, []solana.PublicKey{{5}, {6}})))
+
+	payer, readonlySigner, programId := solana.PublicKey{1}, solana.PublicKey{2}, solana.PublicKey{3}
+	tx := &solana.Transaction{Message: solana.Message{
+		Header:      solana.MessageHeader{NumRequiredSignatures: 2, NumReadonlySignedAccounts: 1, NumReadonlyUnsignedAccounts: 1},
+		AccountKeys: []solana.PublicKey{payer, readonlySigner, programId},
+		Instructions: []solana.CompiledInstruction{
+			{ProgramIDIndex: 2, Accounts: []uint16{0, 1, 3, 4}, Data: []byte{1, 2}},
+		},
+	}}
+	tx.Message.SetAddressTableLookups([]solana.MessageAddressTableLookup{{AccountKey: tableKey, WritableIndexes: []uint8{1}, ReadonlyIndexes: []uint8{0}}})
+
+	resolved, err := ResolveTransaction(tx, accts, 1, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []solana.PublicKey{payer, readonlySigner, programId, {6}, {5}}, resolved.AccountKeys)
+	assert.Equal(t, []bool{true, true, false, false, false}, resolved.IsSigner)
+	assert.Equal(t, []bool{true, false, false, true, false}, resolved.IsWritable)
+	assert.Equal(t, []Instruction{{
+		ProgramId: programId,
+		Data:      []byte{1, 2},
+		Accounts: []AccountMeta{
+			{Pubkey: payer, IsSigner: true, IsWritable: true},
+			{Pubkey: readonlySigner, IsSigner: true},
+			{Pubkey: solana.PublicKey{6}, IsWritable: true},
+			{Pubkey: solana.PublicKey{5}},
+		},
+	}}, resolved.Instructions)
+
+	// instructions may only reference resolved account keys
+	tx.Message.Instructions[0].Accounts = []uint16{5}
+	_, err = ResolveTransaction(tx, accts, 1, nil, nil)
+	assert.Equal(t, TxErrSanitizeFailure, err)
+}
@@ -481,6 +481,56 @@ func (cuvs *CompactUpdateVoteState) UnmarshalWithDecoder(decoder *bin.Decoder) e
 	return nil
 }
 
+// VotedBankHash returns the last slot voted on by a vote instruction, with
+// the bank hash the voter computed for it, or false if the instruction is
+// not a vote.
+func VotedBankHash(data []byte) (uint64, [32]byte, bool) {
+	decoder := bin.NewBinDecoder(data)
+	instructionType, err := decoder.ReadUint32(bin.LE)
+	if err != nil {
+		return 0, [32]byte{}, false
+	}
+
+	var vote VoteInstrVote
+	updateVoteState := &VoteInstrUpdateVoteState{}
+	switch instructionType {
+	case VoteProgramInstrTypeVote:
+		err = vote.UnmarshalWithDecoder(decoder)
+	case VoteProgramInstrTypeVoteSwitch:
+		var voteSwitch VoteInstrVoteSwitch
+		err = voteSwitch.UnmarshalWithDecoder(decoder)
+		vote = voteSwitch.Vote
+	case VoteProgramInstrTypeUpdateVoteState:
+		err = updateVoteState.UnmarshalWithDecoder(decoder)
+	case VoteProgramInstrTypeUpdateVoteStateSwitch:
+		var uvss VoteInstrUpdateVoteStateSwitch
+		err = uvss.UnmarshalWithDecoder(decoder)
+		updateVoteState = &uvss.UpdateVoteState
+	case VoteProgramInstrTypeCompactUpdateVoteState:
+		var cuvs VoteInstrCompactUpdateVoteState
+		err = cuvs.UnmarshalWithDecoder(decoder)
+		updateVoteState = &cuvs.UpdateVoteState
+	case VoteProgramInstrTypeCompactUpdateVoteStateSwitch:
+		var cuvss VoteInstrCompactUpdateVoteStateSwitch
+		err = cuvss.UnmarshalWithDecoder(decoder)
+		updateVoteState = &cuvss.UpdateVoteState
+	default:
+		return 0, [32]byte{}, false
+	}
+	if err != nil {
+		return 0, [32]byte{}, false
+	}
+
+	if len(vote.Slots) != 0 {
+		return vote.Slots[len(vote.Slots)-1], vote.Hash, true
+	}
+	lastLockout, ok := updateVoteState.Lockouts.Back()
+	if !ok {
+		return 0, [32]byte{}, false
+	}
+	return lastLockout.Slot, updateVoteState.Hash, true
+}
+
 func VoteProgramExecute(execCtx *ExecutionCtx) error {
 	err := execCtx.ComputeMeter.Consume(CUVoteProgramDefaultComputeUnits)
 	if err != nil {
@@ -497,6 +547,7 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 	if err != nil {
 		return err
 	}
+	defer me.Drop()
 
 	if me.Owner() != VoteProgramAddr {
 		return InstrErrInvalidAccountOwner
@@ -526,9 +577,7 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				return InstrErrInvalidInstructionData
 			}
 
-			// TODO: switch to using a sysvar cache
-			rent := ReadRentSysvar(&execCtx.Accounts)
-			err = checkAcctForRentSysvar(txCtx, instrCtx, 1)
+			rent, err := getRentSysvarWithAccountCheck(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
 			}
@@ -537,14 +586,12 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				return InstrErrInsufficientFunds
 			}
 
-			// TODO: switch to using a sysvar cache
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 2)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 2)
 			if err != nil {
 				return err
 			}
 
-			err = VoteProgramInitializeAccount(me, voteInit, signers, clock, execCtx.GlobalCtx.Features)
+			return VoteProgramInitializeAccount(me, voteInit, signers, *clock, execCtx.GlobalCtx.Features)
 		}
 
 	case VoteProgramInstrTypeAuthorize:
@@ -555,14 +602,12 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				return InstrErrInvalidInstructionData
 			}
 
-			// TODO: switch to using a sysvar cache
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 1)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
 			}
 
-			err = VoteProgramAuthorize(me, voteAuthorize.Pubkey, voteAuthorize.VoteAuthorize, signers, clock, execCtx.GlobalCtx.Features)
+			return VoteProgramAuthorize(me, voteAuthorize.Pubkey, voteAuthorize.VoteAuthorize, signers, *clock, execCtx.GlobalCtx.Features)
 		}
 
 	case VoteProgramInstrTypeAuthorizeWithSeed:
@@ -578,7 +623,7 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			err = VoteProgramAuthorizeWithSeed(execCtx, instrCtx, me, voteAuthWithSeed.NewAuthority, voteAuthWithSeed.AuthorizationType, voteAuthWithSeed.CurrentAuthorityDerivedKeyOwner, voteAuthWithSeed.CurrentAuthorityDerivedKeySeed)
+			return VoteProgramAuthorizeWithSeed(execCtx, instrCtx, me, voteAuthWithSeed.NewAuthority, voteAuthWithSeed.AuthorizationType, voteAuthWithSeed.CurrentAuthorityDerivedKeyOwner, voteAuthWithSeed.CurrentAuthorityDerivedKeySeed)
 		}
 
 	case VoteProgramInstrTypeAuthorizeCheckedWithSeed:
@@ -613,7 +658,7 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				return InstrErrMissingRequiredSignature
 			}
 
-			err = VoteProgramAuthorizeWithSeed(execCtx, instrCtx, me, newAuthority, voteAuthCheckedWithSeed.AuthorizationType, voteAuthCheckedWithSeed.CurrentAuthorityDerivedKeyOwner, voteAuthCheckedWithSeed.CurrentAuthorityDerivedKeySeed)
+			return VoteProgramAuthorizeWithSeed(execCtx, instrCtx, me, newAuthority, voteAuthCheckedWithSeed.AuthorizationType, voteAuthCheckedWithSeed.CurrentAuthorityDerivedKeyOwner, voteAuthCheckedWithSeed.CurrentAuthorityDerivedKeySeed)
 		}
 
 	case VoteProgramInstrTypeUpdateValidatorIdentity:
@@ -633,7 +678,7 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			err = VoteProgramUpdateValidatorIdentity(me, nodePubkey, signers, execCtx.GlobalCtx.Features)
+			return VoteProgramUpdateValidatorIdentity(me, nodePubkey, signers, execCtx.GlobalCtx.Features)
 		}
 
 	case VoteProgramInstrTypeUpdateCommission:
@@ -644,11 +689,16 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				return InstrErrInvalidInstructionData
 			}
 
-			// TODO: switch to using a sysvar cache
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			epochSchedule := ReadEpochScheduleSysvar(&execCtx.Accounts)
+			clock, err := execCtx.SysvarCache.Clock()
+			if err != nil {
+				return err
+			}
+			epochSchedule, err := execCtx.SysvarCache.EpochSchedule()
+			if err != nil {
+				return err
+			}
 
-			err = VoteProgramUpdateCommission(me, updateCommission.Commission, signers, epochSchedule, clock, execCtx.GlobalCtx.Features)
+			return VoteProgramUpdateCommission(me, updateCommission.Commission, signers, *epochSchedule, *clock, execCtx.GlobalCtx.Features)
 		}
 
 	case VoteProgramInstrTypeVoteSwitch:
@@ -665,24 +715,24 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				}
 				vote = &voteSwitch.Vote
 			} else {
+				vote = new(VoteInstrVote)
 				err = vote.UnmarshalWithDecoder(decoder)
 				if err != nil {
 					return InstrErrInvalidInstructionData
 				}
 			}
 
-			// TODO: switch to using a sysvar cache
-
-			slotHashes := ReadSlotHashesSysvar(&execCtx.Accounts)
-			checkAcctForSlotHashesSysvar(txCtx, instrCtx, 1)
+			slotHashes, err := getSlotHashesSysvarWithAccountCheck(execCtx, instrCtx, 1)
+			if err != nil {
+				return err
+			}
 
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err := checkAcctForClockSysvar(txCtx, instrCtx, 2)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 2)
 			if err != nil {
 				return err
 			}
 
-			err = VoteProgramProcessVote(me, slotHashes, clock, vote, signers, execCtx.GlobalCtx.Features)
+			return VoteProgramProcessVote(me, *slotHashes, *clock, vote, signers, execCtx.GlobalCtx.Features)
 		}
 	case VoteProgramInstrTypeUpdateVoteStateSwitch:
 		isUpdateVoteStateSwitch = true
@@ -699,18 +749,23 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				}
 				updateVoteState = &updateVoteStateSwitch.UpdateVoteState
 			} else {
+				updateVoteState = new(VoteInstrUpdateVoteState)
 				err = updateVoteState.UnmarshalWithDecoder(decoder)
 				if err != nil {
 					return err
 				}
 			}
 
-			// TODO: switch to using a sysvar cache
-
-			slotHashes := ReadSlotHashesSysvar(&execCtx.Accounts)
-			clock := ReadClockSysvar(&execCtx.Accounts)
+			slotHashes, err := execCtx.SysvarCache.SlotHashes()
+			if err != nil {
+				return err
+			}
+			clock, err := execCtx.SysvarCache.Clock()
+			if err != nil {
+				return err
+			}
 
-			err = VoteProgramProcessVoteStateUpdate(me, slotHashes, clock, updateVoteState, signers, execCtx.GlobalCtx.Features)
+			return VoteProgramProcessVoteStateUpdate(me, *slotHashes, *clock, updateVoteState, signers, execCtx.GlobalCtx.Features)
 		}
 
 	case VoteProgramInstrTypeCompactUpdateVoteStateSwitch:
@@ -736,12 +791,16 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				updateVoteState = &compactUpdateVoteState.UpdateVoteState
 			}
 
-			// TODO: switch to using a sysvar cache
-
-			slotHashes := ReadSlotHashesSysvar(&execCtx.Accounts)
-			clock := ReadClockSysvar(&execCtx.Accounts)
+			slotHashes, err := execCtx.SysvarCache.SlotHashes()
+			if err != nil {
+				return err
+			}
+			clock, err := execCtx.SysvarCache.Clock()
+			if err != nil {
+				return err
+			}
 
-			err = VoteProgramProcessVoteStateUpdate(me, slotHashes, clock, updateVoteState, signers, execCtx.GlobalCtx.Features)
+			return VoteProgramProcessVoteStateUpdate(me, *slotHashes, *clock, updateVoteState, signers, execCtx.GlobalCtx.Features)
 		}
 
 	case VoteProgramInstrTypeWithdraw:
@@ -757,10 +816,17 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			rent := ReadRentSysvar(&execCtx.Accounts)
-			clock := ReadClockSysvar(&execCtx.Accounts)
+			rent, err := execCtx.SysvarCache.Rent()
+			if err != nil {
+				return err
+			}
+			clock, err := execCtx.SysvarCache.Clock()
+			if err != nil {
+				return err
+			}
 
-			err = VoteProgramWithdraw(txCtx, instrCtx, 0, withdraw.Lamports, 1, signers, rent, clock, execCtx.GlobalCtx.Features)
+			me.Drop()
+			return VoteProgramWithdraw(txCtx, instrCtx, 0, withdraw.Lamports, 1, signers, *rent, *clock, execCtx.GlobalCtx.Features)
 		}
 
 	case VoteProgramInstrTypeAuthorizeChecked:
@@ -795,14 +861,12 @@ func VoteProgramExecute(execCtx *ExecutionCtx) error {
 				return InstrErrMissingRequiredSignature
 			}
 
-			// TODO: switch to using a sysvar cache
-			clock := ReadClockSysvar(&execCtx.Accounts)
-			err = checkAcctForClockSysvar(txCtx, instrCtx, 1)
+			clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 1)
 			if err != nil {
 				return err
 			}
 
-			err = VoteProgramAuthorize(me, voterPubkey, voteAuthorize.VoteAuthorize, signers, clock, execCtx.GlobalCtx.Features)
+			return VoteProgramAuthorize(me, voterPubkey, voteAuthorize.VoteAuthorize, signers, *clock, execCtx.GlobalCtx.Features)
 		}
 	default: // invalid instruction
 		{
@@ -887,9 +951,7 @@ func VoteProgramAuthorize(voteAcct *BorrowedAccount, authorized solana.PublicKey
 func VoteProgramAuthorizeWithSeed(execCtx *ExecutionCtx, instrCtx *InstructionCtx, voteAcct *BorrowedAccount, newAuthority solana.PublicKey, authorizationType uint32, currentAuthorityDerivedKeyOwner solana.PublicKey, currentAuthorityDerivedKeySeed string) error {
 	txCtx := execCtx.TransactionContext
 
-	// TODO: switch to using a sysvar cache
-	clock := ReadClockSysvar(&execCtx.Accounts)
-	err := checkAcctForClockSysvar(txCtx, instrCtx, 1)
+	clock, err := getClockSysvarWithAccountCheck(execCtx, instrCtx, 1)
 	if err != nil {
 		return err
 	}
@@ -918,7 +980,7 @@ func VoteProgramAuthorizeWithSeed(execCtx *ExecutionCtx, instrCtx *InstructionCt
 		expectedAuthorityKeys = append(expectedAuthorityKeys, authKey)
 	}
 
-	err = VoteProgramAuthorize(voteAcct, newAuthority, authorizationType, expectedAuthorityKeys, clock, execCtx.GlobalCtx.Features)
+	err = VoteProgramAuthorize(voteAcct, newAuthority, authorizationType, expectedAuthorityKeys, *clock, execCtx.GlobalCtx.Features)
 	return err
 }
 
@@ -1524,6 +1586,7 @@ func VoteProgramWithdraw(txCtx *TransactionCtx, instrCtx *InstructionCtx, voteAc
 	if err != nil {
 		return err
 	}
+	defer voteAcct.Drop()
 
 	versionedVoteState, err := unmarshalVersionedVoteState(voteAcct.Data())
 	if err != nil {
@@ -1571,11 +1634,13 @@ func VoteProgramWithdraw(txCtx *TransactionCtx, instrCtx *InstructionCtx, voteAc
 	if err != nil {
 		return err
 	}
+	voteAcct.Drop()
 
 	toAcct, err := instrCtx.BorrowInstructionAccount(txCtx, toAcctIdx)
 	if err != nil {
 		return err
 	}
+	defer toAcct.Drop()
 
 	err = toAcct.CheckedAddLamports(lamports, f)
 
@@ -0,0 +1,126 @@
+package shred
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// MerkleRoot returns the merkle root of the erasure set of a serialized
+// merkle shred, as proven by the shred.
+//
+// Based on solana_ledger::shred::merkle::Shred::merkle_root.
+func MerkleRoot(raw []byte) (root [32]byte, err error) {
+	h, ok := parseCommonHeader(raw)
+	if !ok {
+		return root, fmt.Errorf("invalid shred")
+	}
+	layout, ok := parseMerkleVariant(h.Variant)
+	if !ok {
+		return root, fmt.Errorf("not a merkle shred: variant %#x", h.Variant)
+	}
+	if len(raw) != layout.payloadSize() {
+		return root, fmt.Errorf("invalid merkle shred size %d", len(raw))
+	}
+	var idx int
+	if layout.code {
+		_, code, ok := ParseCodeHeader(raw)
+		if !ok {
+			return root, fmt.Errorf("invalid coding shred")
+		}
+		idx = int(code.NumDataShreds) + int(code.Position)
+	} else {
+		if h.Index < h.FECSetIndex {
+			return root, fmt.Errorf("data shred %d precedes its erasure set", h.Index)
+		}
+		idx = int(h.Index - h.FECSetIndex)
+	}
+	layout.storedRoot = hasStoredRoot(raw, layout, idx)
+	return merkleRootFromProof(layout, idx, merkleLeaf(raw, layout), layout.proof(raw))
+}
+
+// ChainedMerkleRoot returns the chained merkle root of a serialized merkle
+// shred, which is the merkle root of the previous erasure set, unless the
+// shred does not chain.
+func ChainedMerkleRoot(raw []byte) (root [32]byte, ok bool) {
+	h, ok := parseCommonHeader(raw)
+	if !ok {
+		return root, false
+	}
+	layout, ok := parseMerkleVariant(h.Variant)
+	if !ok || !layout.chained || len(raw) != layout.payloadSize() {
+		return root, false
+	}
+	offset := layout.chainedRootOffset()
+	copy(root[:], raw[offset:offset+MerkleRootSize])
+	return root, true
+}
+
+// ChainedMerkleRootError reports an erasure set of which the chained merkle
+// root is not the merkle root of the previous erasure set, which is the
+// last erasure set of the parent slot for the first erasure set of a slot.
+type ChainedMerkleRootError struct {
+	Slot            uint64
+	FECSetIndex     uint32
+	PrevSlot        uint64
+	PrevFECSetIndex uint32
+	Chained         [32]byte
+	Expected        [32]byte
+}
+
+func (e *ChainedMerkleRootError) Error() string {
+	return fmt.Sprintf("erasure set %d/%d chains to merkle root %s instead of %s of erasure set %d/%d",
+		e.Slot, e.FECSetIndex, hex.EncodeToString(e.Chained[:]),
+		hex.EncodeToString(e.Expected[:]), e.PrevSlot, e.PrevFECSetIndex)
+}
+
+// VerifyChainedMerkleRoots checks that each erasure set chains to the
+// merkle root of the previous erasure set. sets holds a serialized shred of
+// each erasure set, ordered by erasure set. prev is a serialized shred of
+// the erasure set preceding the first one, or nil if unknown. Erasure sets
+// that do not chain, or follow legacy shreds, are not checked.
+//
+// Based on solana_ledger::blockstore::Blockstore::check_chained_merkle_root_consistency.
+func VerifyChainedMerkleRoots(prev []byte, sets [][]byte) ([]*ChainedMerkleRootError, error) {
+	var violations []*ChainedMerkleRootError
+	for _, raw := range sets {
+		if prev != nil {
+			if chained, ok := ChainedMerkleRoot(raw); ok {
+				violation, err := verifyChainedMerkleRoot(prev, raw, chained)
+				if err != nil {
+					return violations, err
+				}
+				if violation != nil {
+					violations = append(violations, violation)
+				}
+			}
+		}
+		prev = raw
+	}
+	return violations, nil
+}
+
+func verifyChainedMerkleRoot(prev, raw []byte, chained [32]byte) (*ChainedMerkleRootError, error) {
+	prevHeader, ok := parseCommonHeader(prev)
+	if !ok {
+		return nil, fmt.Errorf("invalid shred")
+	}
+	if prevHeader.Variant == LegacyDataID || prevHeader.Variant == LegacyCodeID {
+		return nil, nil
+	}
+	expected, err := MerkleRoot(prev)
+	if err != nil {
+		return nil, fmt.Errorf("shred %d/%d: %w", prevHeader.Slot, prevHeader.Index, err)
+	}
+	if chained == expected {
+		return nil, nil
+	}
+	h, _ := parseCommonHeader(raw)
+	return &ChainedMerkleRootError{
+		Slot:            h.Slot,
+		FECSetIndex:     h.FECSetIndex,
+		PrevSlot:        prevHeader.Slot,
+		PrevFECSetIndex: prevHeader.FECSetIndex,
+		Chained:         chained,
+		Expected:        expected,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package shred
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/reedsolomon"
+)
+
+// makeChainedSet returns the serialized data and coding shreds of a chained
+// merkle erasure set.
+func makeChainedSet(t *testing.T, rng *rand.Rand, slot uint64, fecSetIndex uint32, chained [32]byte, numData, numCode int) (data, code [][]byte) {
+	layout := merkleLayout{
+		proofSize: bits.Len(uint(numData + numCode - 1)),
+		chained:   true,
+	}
+	dataLayout, codeLayout := layout, layout
+	codeLayout.code = true
+
+	payloads := make([][]byte, numData+numCode)
+	shards := make([][]byte, numData+numCode)
+	for i := range payloads {
+		l := dataLayout
+		if i >= numData {
+			l = codeLayout
+		}
+		payload := make([]byte, l.payloadSize())
+		payload[0x40] = l.variant(l.code)
+		binary.LittleEndian.PutUint64(payload[0x41:0x49], slot)
+		binary.LittleEndian.PutUint32(payload[0x49:0x4d], fecSetIndex+uint32(i%numData))
+		binary.LittleEndian.PutUint32(payload[0x4f:0x53], fecSetIndex)
+		if i < numData {
+			binary.LittleEndian.PutUint16(payload[0x56:0x58], DataHeaderSize+100)
+			rng.Read(payload[DataHeaderSize : DataHeaderSize+100])
+		} else {
+			binary.LittleEndian.PutUint32(payload[0x49:0x4d], fecSetIndex+uint32(i-numData))
+			binary.LittleEndian.PutUint16(payload[0x53:0x55], uint16(numData))
+			binary.LittleEndian.PutUint16(payload[0x55:0x57], uint16(numCode))
+			binary.LittleEndian.PutUint16(payload[0x57:0x59], uint16(i-numData))
+		}
+		copy(payload[l.chainedRootOffset():], chained[:])
+		payloads[i] = payload
+		shards[i] = l.erasureShard(payload)
+	}
+	enc, err := reedsolomon.New(numData, numCode)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(shards))
+
+	leaves := make([][32]byte, len(payloads))
+	for i, payload := range payloads {
+		if i < numData {
+			leaves[i] = merkleLeaf(payload, dataLayout)
+		} else {
+			leaves[i] = merkleLeaf(payload, codeLayout)
+		}
+	}
+	tree := makeMerkleTree(layout, leaves)
+	for i, payload := range payloads {
+		l := dataLayout
+		if i >= numData {
+			l = codeLayout
+		}
+		offset := l.proofOffset()
+		for _, entry := range merkleProof(tree, i, len(leaves)) {
+			offset += copy(payload[offset:], entry[:])
+		}
+	}
+	return payloads[:numData], payloads[numData:]
+}
+
+func TestVerifyChainedMerkleRoots(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var genesis [32]byte
+	data0, code0 := makeChainedSet(t, rng, 10, 0, genesis, 4, 4)
+	root0, err := MerkleRoot(data0[0])
+	require.NoError(t, err)
+	for _, raw := range append(data0[1:], code0...) {
+		root, err := MerkleRoot(raw)
+		require.NoError(t, err)
+		assert.Equal(t, root0, root)
+	}
+	data1, _ := makeChainedSet(t, rng, 10, 4, root0, 3, 5)
+	root1, err := MerkleRoot(data1[0])
+	require.NoError(t, err)
+	chained, ok := ChainedMerkleRoot(data1[2])
+	require.True(t, ok)
+	assert.Equal(t, root0, chained)
+
+	violations, err := VerifyChainedMerkleRoots(nil, [][]byte{data0[0], data1[1]})
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	// the next slot chains to the wrong erasure set
+	data2, _ := makeChainedSet(t, rng, 11, 0, root0, 2, 2)
+	violations, err = VerifyChainedMerkleRoots(data1[2], [][]byte{data2[0]})
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, &ChainedMerkleRootError{
+		Slot:            11,
+		FECSetIndex:     0,
+		PrevSlot:        10,
+		PrevFECSetIndex: 4,
+		Chained:         root0,
+		Expected:        root1,
+	}, violations[0])
+}
+
+func TestRecoverDataShreds_Chained(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	data, code := makeChainedSet(t, rng, 10, 0, [32]byte{1}, 4, 4)
+	recovered, err := RecoverDataShreds(data[:1], code[1:])
+	require.NoError(t, err)
+	assert.Equal(t, data[1:], recovered)
+}
@@ -0,0 +1,147 @@
+package shred
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DuplicateSlotProof is a pair of conflicting shreds of a slot, which
+// proves that the leader of the slot produced more than one block.
+//
+// Based on solana_ledger::blockstore_meta::DuplicateSlotProof.
+type DuplicateSlotProof struct {
+	Shred1 []byte
+	Shred2 []byte
+}
+
+// MarshalBincode returns the bincode encoding of the proof, as stored in
+// the duplicate slots column family and chunked into gossip.
+func (p *DuplicateSlotProof) MarshalBincode() []byte {
+	b := make([]byte, 0, 16+len(p.Shred1)+len(p.Shred2))
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(p.Shred1)))
+	b = append(b, p.Shred1...)
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(p.Shred2)))
+	b = append(b, p.Shred2...)
+	return b
+}
+
+// ParseDuplicateSlotProof decodes a bincode encoded proof.
+func ParseDuplicateSlotProof(b []byte) (*DuplicateSlotProof, error) {
+	var shreds [2][]byte
+	for i := range shreds {
+		if len(b) < 8 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		n := binary.LittleEndian.Uint64(b)
+		b = b[8:]
+		if n > uint64(len(b)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		shreds[i] = append([]byte(nil), b[:n]...)
+		b = b[n:]
+	}
+	if len(b) != 0 {
+		return nil, fmt.Errorf("%d trailing bytes", len(b))
+	}
+	return &DuplicateSlotProof{Shred1: shreds[0], Shred2: shreds[1]}, nil
+}
+
+// ErrNotDuplicate reports a pair of shreds that do not conflict.
+var ErrNotDuplicate = errors.New("shreds do not conflict")
+
+// CheckDuplicate returns a proof that two serialized shreds of a slot
+// conflict, which they do if they have the same index and type but
+// different payloads, if they are in the same erasure set but have
+// different merkle roots, or if one is the last data shred of the slot and
+// the other a data shred beyond it.
+//
+// Based on solana_gossip::duplicate_shred::check_shreds.
+func CheckDuplicate(shred1, shred2 []byte) (*DuplicateSlotProof, error) {
+	h1, ok1 := parseCommonHeader(shred1)
+	h2, ok2 := parseCommonHeader(shred2)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("invalid shred")
+	}
+	if h1.Slot != h2.Slot {
+		return nil, fmt.Errorf("shreds of different slots %d and %d", h1.Slot, h2.Slot)
+	}
+	proof := &DuplicateSlotProof{Shred1: shred1, Shred2: shred2}
+	if h1.Index == h2.Index && h1.IsData() == h2.IsData() {
+		if !samePayload(shred1, shred2) {
+			return proof, nil
+		}
+		return nil, ErrNotDuplicate
+	}
+	if h1.FECSetIndex == h2.FECSetIndex {
+		root1, err1 := MerkleRoot(shred1)
+		root2, err2 := MerkleRoot(shred2)
+		if err1 == nil && err2 == nil && root1 != root2 {
+			return proof, nil
+		}
+	}
+	if h1.IsData() && h2.IsData() {
+		if (isLastDataShred(shred1) && h2.Index > h1.Index) ||
+			(isLastDataShred(shred2) && h1.Index > h2.Index) {
+			return proof, nil
+		}
+	}
+	return nil, ErrNotDuplicate
+}
+
+// FindDuplicates returns proofs of the conflicts among the serialized data
+// and coding shreds of a slot: a proof for each erasure set of which the
+// shreds have different merkle roots, and a proof if data shreds follow the
+// last data shred of the slot.
+func FindDuplicates(shreds [][]byte) []*DuplicateSlotProof {
+	var proofs []*DuplicateSlotProof
+	type setRoot struct {
+		raw  []byte
+		root [32]byte
+	}
+	roots := make(map[uint32]setRoot)
+	conflicting := make(map[uint32]bool)
+	var last, highest []byte
+	var lastIndex, highestIndex uint32
+	for _, raw := range shreds {
+		h, ok := parseCommonHeader(raw)
+		if !ok {
+			continue
+		}
+		if root, err := MerkleRoot(raw); err == nil && !conflicting[h.FECSetIndex] {
+			if first, ok := roots[h.FECSetIndex]; !ok {
+				roots[h.FECSetIndex] = setRoot{raw: raw, root: root}
+			} else if first.root != root {
+				conflicting[h.FECSetIndex] = true
+				proofs = append(proofs, &DuplicateSlotProof{Shred1: first.raw, Shred2: raw})
+			}
+		}
+		if !h.IsData() {
+			continue
+		}
+		if isLastDataShred(raw) && (last == nil || h.Index < lastIndex) {
+			last, lastIndex = raw, h.Index
+		}
+		if highest == nil || h.Index > highestIndex {
+			highest, highestIndex = raw, h.Index
+		}
+	}
+	if last != nil && highestIndex > lastIndex {
+		proofs = append(proofs, &DuplicateSlotProof{Shred1: last, Shred2: highest})
+	}
+	return proofs
+}
+
+// isLastDataShred reports whether a serialized data shred is the last of
+// its slot.
+func isLastDataShred(raw []byte) bool {
+	return len(raw) > 0x55 && raw[0x55]&FlagDataEndOfBlock == FlagDataEndOfBlock
+}
+
+// samePayload reports whether two serialized shreds are equal, ignoring
+// the zero padding of legacy data shreds.
+func samePayload(shred1, shred2 []byte) bool {
+	return bytes.Equal(bytes.TrimRight(shred1, "\x00"), bytes.TrimRight(shred2, "\x00"))
+}
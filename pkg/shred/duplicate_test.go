@@ -0,0 +1,66 @@
+package shred
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateSlotProof(t *testing.T) {
+	proof := &DuplicateSlotProof{Shred1: []byte{1, 2, 3}, Shred2: []byte{4}}
+	parsed, err := ParseDuplicateSlotProof(proof.MarshalBincode())
+	require.NoError(t, err)
+	assert.Equal(t, proof, parsed)
+
+	_, err = ParseDuplicateSlotProof(proof.MarshalBincode()[:10])
+	assert.Error(t, err)
+}
+
+func TestCheckDuplicate(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var genesis [32]byte
+	data, code := makeChainedSet(t, rng, 10, 0, genesis, 4, 4)
+	other, _ := makeChainedSet(t, rng, 10, 0, genesis, 4, 4)
+
+	_, err := CheckDuplicate(data[0], data[0])
+	assert.ErrorIs(t, err, ErrNotDuplicate)
+	_, err = CheckDuplicate(data[0], code[1])
+	assert.ErrorIs(t, err, ErrNotDuplicate)
+
+	// same index, different payload
+	proof, err := CheckDuplicate(data[0], other[0])
+	require.NoError(t, err)
+	assert.Equal(t, &DuplicateSlotProof{Shred1: data[0], Shred2: other[0]}, proof)
+
+	// same erasure set, different merkle roots
+	_, err = CheckDuplicate(code[0], other[1])
+	assert.NoError(t, err)
+
+	// different slots
+	_, slot11 := makeChainedSet(t, rng, 11, 0, genesis, 4, 4)
+	_, err = CheckDuplicate(code[0], slot11[0])
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotDuplicate)
+}
+
+func TestFindDuplicates(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	var genesis [32]byte
+	data, code := makeChainedSet(t, rng, 10, 0, genesis, 4, 4)
+	other, _ := makeChainedSet(t, rng, 10, 0, genesis, 4, 4)
+
+	shreds := append(append([][]byte{}, data...), code...)
+	assert.Empty(t, FindDuplicates(shreds))
+
+	shreds = append(shreds, other[3])
+	assert.Equal(t, []*DuplicateSlotProof{{Shred1: data[0], Shred2: other[3]}}, FindDuplicates(shreds))
+
+	// a data shred following the last one of the slot
+	last := append([]byte(nil), data[1]...)
+	last[0x55] |= FlagDataEndOfBlock
+	proofs := FindDuplicates([][]byte{data[0], last, data[2]})
+	require.Len(t, proofs, 2)
+	assert.Equal(t, &DuplicateSlotProof{Shred1: last, Shred2: data[2]}, proofs[1])
+}
@@ -0,0 +1,274 @@
+package shred
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Sizes of the parts of shreds.
+const (
+	SignatureSize        = 64
+	CommonHeaderSize     = 83
+	DataHeaderSize       = 88 // common and data shred headers
+	CodeHeaderSize       = 89 // common and coding shred headers
+	CodePayloadSize      = 1228
+	LegacyDataSize       = CodePayloadSize - CodeHeaderSize                 // 1139
+	MerkleDataSize       = CodePayloadSize - CodeHeaderSize + SignatureSize // 1203
+	MerkleRootSize       = 32
+	StoredRootSize       = 20 // merkle root of the first merkle shreds
+	MerkleProofEntrySize = 20
+)
+
+// Variants of merkle shreds, in the high nibble of the variant, of which
+// the low nibble is the number of entries of the merkle proof.
+const (
+	MerkleCodeChainedID         = uint8(0x60)
+	MerkleCodeChainedResignedID = uint8(0x70)
+	MerkleDataChainedID         = uint8(0x90)
+	MerkleDataChainedResignedID = uint8(0xB0)
+)
+
+var (
+	merkleLeafPrefix = []byte("\x00SOLANA_MERKLE_SHREDS_LEAF")
+	merkleNodePrefix = []byte("\x01SOLANA_MERKLE_SHREDS_NODE")
+	// the first merkle shreds hashed with one byte prefixes
+	storedRootLeafPrefix = []byte{0x00}
+	storedRootNodePrefix = []byte{0x01}
+)
+
+// merkleLayout describes the trailer of a merkle shred: an optional
+// chained merkle root, the merkle proof, and an optional retransmitter
+// signature.
+//
+// The first merkle shreds, which share their variants with the unchained
+// merkle shreds of today, instead carry the truncated merkle root of their
+// erasure set ahead of the proof.
+type merkleLayout struct {
+	code       bool
+	proofSize  int
+	chained    bool
+	resigned   bool
+	storedRoot bool
+}
+
+// parseMerkleVariant returns the layout of a merkle shred variant.
+//
+// Based on solana_ledger::shred::ShredVariant.
+func parseMerkleVariant(variant uint8) (layout merkleLayout, ok bool) {
+	layout.proofSize = int(variant & MerkleDepthMask)
+	switch variant & MerkleTypeMask {
+	case MerkleCodeID:
+		layout.code = true
+	case MerkleCodeChainedID:
+		layout.code, layout.chained = true, true
+	case MerkleCodeChainedResignedID:
+		layout.code, layout.chained, layout.resigned = true, true, true
+	case MerkleDataID:
+	case MerkleDataChainedID:
+		layout.chained = true
+	case MerkleDataChainedResignedID:
+		layout.chained, layout.resigned = true, true
+	default:
+		return layout, false
+	}
+	return layout, true
+}
+
+// variant returns the variant of a shred of the layout, with the code flag
+// replaced.
+func (l merkleLayout) variant(code bool) uint8 {
+	var typ uint8
+	switch {
+	case code && l.resigned:
+		typ = MerkleCodeChainedResignedID
+	case code && l.chained:
+		typ = MerkleCodeChainedID
+	case code:
+		typ = MerkleCodeID
+	case l.resigned:
+		typ = MerkleDataChainedResignedID
+	case l.chained:
+		typ = MerkleDataChainedID
+	default:
+		typ = MerkleDataID
+	}
+	return typ | uint8(l.proofSize)
+}
+
+func (l merkleLayout) payloadSize() int {
+	if l.code {
+		return CodePayloadSize
+	}
+	return MerkleDataSize
+}
+
+// proofOffset returns the offset of the merkle proof in the payload.
+func (l merkleLayout) proofOffset() int {
+	offset := l.payloadSize() - l.proofSize*MerkleProofEntrySize
+	if l.resigned {
+		offset -= SignatureSize
+	}
+	return offset
+}
+
+// chainedRootOffset returns the offset of the chained merkle root in the
+// payload, which immediately precedes the proof.
+func (l merkleLayout) chainedRootOffset() int {
+	return l.proofOffset() - MerkleRootSize
+}
+
+// storedRootOffset returns the offset of the merkle root in the payload
+// of the first merkle shreds.
+func (l merkleLayout) storedRootOffset() int {
+	return l.proofOffset() - StoredRootSize
+}
+
+// capacityEnd returns the end of the data buffer, or the erasure coded
+// shard, in the payload.
+func (l merkleLayout) capacityEnd() int {
+	switch {
+	case l.chained:
+		return l.chainedRootOffset()
+	case l.storedRoot:
+		return l.storedRootOffset()
+	}
+	return l.proofOffset()
+}
+
+// proof returns the merkle proof in the payload.
+func (l merkleLayout) proof(payload []byte) []byte {
+	offset := l.proofOffset()
+	return payload[offset : offset+l.proofSize*MerkleProofEntrySize]
+}
+
+// erasureShard returns the part of the payload that is erasure coded.
+//
+// Based on solana_ledger::shred::merkle::ShredData::erasure_shard.
+func (l merkleLayout) erasureShard(payload []byte) []byte {
+	start := SignatureSize
+	if l.code {
+		start = CodeHeaderSize
+	}
+	return payload[start:l.capacityEnd()]
+}
+
+// merkleLeaf returns the hash of a merkle shred as a leaf of the merkle
+// tree of its erasure set, which covers the payload up to the proof, or up
+// to the stored root.
+//
+// Based on solana_ledger::shred::merkle::ShredData::merkle_node.
+func merkleLeaf(payload []byte, l merkleLayout) [32]byte {
+	h := sha256.New()
+	if l.storedRoot {
+		h.Write(storedRootLeafPrefix)
+		h.Write(payload[SignatureSize:l.storedRootOffset()])
+	} else {
+		h.Write(merkleLeafPrefix)
+		h.Write(payload[SignatureSize:l.proofOffset()])
+	}
+	var out [32]byte
+	h.Sum(out[:0])
+	return out
+}
+
+// joinMerkleNodes returns the parent of two nodes of the merkle tree, of
+// which only the first 20 bytes count.
+//
+// Based on solana_ledger::shred::merkle::join_nodes.
+func joinMerkleNodes(l merkleLayout, left, right *[32]byte) [32]byte {
+	h := sha256.New()
+	if l.storedRoot {
+		h.Write(storedRootNodePrefix)
+	} else {
+		h.Write(merkleNodePrefix)
+	}
+	h.Write(left[:MerkleProofEntrySize])
+	h.Write(right[:MerkleProofEntrySize])
+	var out [32]byte
+	h.Sum(out[:0])
+	return out
+}
+
+// makeMerkleTree returns all nodes of the merkle tree over the leaves, level
+// by level, ending with the root. The last node of a level with an odd
+// number of nodes is joined with itself.
+//
+// Based on solana_ledger::shred::merkle::make_merkle_tree.
+func makeMerkleTree(l merkleLayout, leaves [][32]byte) [][32]byte {
+	tree := append([][32]byte(nil), leaves...)
+	size := len(tree)
+	for size > 1 {
+		offset := len(tree) - size
+		for i := offset; i < offset+size; i += 2 {
+			j := i + 1
+			if j > offset+size-1 {
+				j = offset + size - 1
+			}
+			tree = append(tree, joinMerkleNodes(l, &tree[i], &tree[j]))
+		}
+		size = len(tree) - offset - size
+	}
+	return tree
+}
+
+// merkleProof returns the proof of the leaf at the given index of a tree
+// of numLeaves leaves.
+//
+// Based on solana_ledger::shred::merkle::make_merkle_proof.
+func merkleProof(tree [][32]byte, index, numLeaves int) [][MerkleProofEntrySize]byte {
+	var proof [][MerkleProofEntrySize]byte
+	offset := 0
+	for size := numLeaves; size > 1; size = (size + 1) / 2 {
+		sibling := index ^ 1
+		if sibling > size-1 {
+			sibling = size - 1
+		}
+		var entry [MerkleProofEntrySize]byte
+		copy(entry[:], tree[offset+sibling][:])
+		proof = append(proof, entry)
+		offset += size
+		index >>= 1
+	}
+	return proof
+}
+
+// merkleRootFromProof returns the root of the merkle tree of which the
+// node is the leaf at the given index, as per its proof.
+//
+// Based on solana_ledger::shred::merkle::get_merkle_root.
+func merkleRootFromProof(l merkleLayout, index int, node [32]byte, proof []byte) ([32]byte, error) {
+	if len(proof)%MerkleProofEntrySize != 0 {
+		return node, fmt.Errorf("invalid merkle proof size %d", len(proof))
+	}
+	for ; len(proof) != 0; proof = proof[MerkleProofEntrySize:] {
+		var other [32]byte
+		copy(other[:], proof[:MerkleProofEntrySize])
+		if index%2 == 0 {
+			node = joinMerkleNodes(l, &node, &other)
+		} else {
+			node = joinMerkleNodes(l, &other, &node)
+		}
+		index >>= 1
+	}
+	if index != 0 {
+		return node, fmt.Errorf("merkle proof too short for index")
+	}
+	return node, nil
+}
+
+// hasStoredRoot returns whether a shred of an unchained merkle variant is
+// one of the first merkle shreds, of which the proof yields the root
+// stored ahead of it. idx is the index of the shred in its erasure set.
+func hasStoredRoot(payload []byte, l merkleLayout, idx int) bool {
+	if l.chained || len(payload) != l.payloadSize() {
+		return false
+	}
+	l.storedRoot = true
+	root, err := merkleRootFromProof(l, idx, merkleLeaf(payload, l), l.proof(payload))
+	if err != nil {
+		return false
+	}
+	offset := l.storedRootOffset()
+	return bytes.Equal(root[:StoredRootSize], payload[offset:offset+StoredRootSize])
+}
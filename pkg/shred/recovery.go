@@ -0,0 +1,364 @@
+package shred
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go.firedancer.io/radiance/pkg/reedsolomon"
+)
+
+// CodeHeader is the header of coding shreds, which follows the common
+// header: the shape of the erasure set, and the position of the shred in
+// its coding shreds.
+type CodeHeader struct {
+	NumDataShreds uint16
+	NumCodeShreds uint16
+	Position      uint16
+}
+
+// parseCommonHeader parses the common header of a serialized shred.
+func parseCommonHeader(raw []byte) (h CommonHeader, ok bool) {
+	if len(raw) < CommonHeaderSize {
+		return h, false
+	}
+	copy(h.Signature[:], raw[0x00:0x40])
+	h.Variant = raw[0x40]
+	h.Slot = binary.LittleEndian.Uint64(raw[0x41:0x49])
+	h.Index = binary.LittleEndian.Uint32(raw[0x49:0x4d])
+	h.Version = binary.LittleEndian.Uint16(raw[0x4d:0x4f])
+	h.FECSetIndex = binary.LittleEndian.Uint32(raw[0x4f:0x53])
+	return h, h.Ok()
+}
+
+// ParseCodeHeader parses the headers of a serialized coding shred.
+func ParseCodeHeader(raw []byte) (CommonHeader, CodeHeader, bool) {
+	h, ok := parseCommonHeader(raw)
+	if !ok || !h.IsCode() || len(raw) < CodeHeaderSize {
+		return h, CodeHeader{}, false
+	}
+	return h, CodeHeader{
+		NumDataShreds: binary.LittleEndian.Uint16(raw[0x53:0x55]),
+		NumCodeShreds: binary.LittleEndian.Uint16(raw[0x55:0x57]),
+		Position:      binary.LittleEndian.Uint16(raw[0x57:0x59]),
+	}, true
+}
+
+// erasureSet holds the shreds of an erasure set at hand, by shard index:
+// the data shreds, followed by the coding shreds.
+type erasureSet struct {
+	common CommonHeader
+	code   CodeHeader
+	// layout of the shreds, unless they are legacy shreds
+	merkle *merkleLayout
+	// payloads of the shreds at hand, and their erasure coded shards
+	payloads [][]byte
+	shards   [][]byte
+}
+
+// RecoverDataShreds recovers the data shreds missing from an erasure set
+// from the data and coding shreds of the set at hand, with Reed-Solomon
+// erasure coding. The shreds are serialized, as stored in the blockstore,
+// and so are the recovered data shreds, which are ordered by index. At
+// least one coding shred must be given.
+//
+// Based on solana_ledger::shred::recover.
+func RecoverDataShreds(data, code [][]byte) ([][]byte, error) {
+	set, err := newErasureSet(data, code)
+	if err != nil {
+		return nil, err
+	}
+	numData := int(set.code.NumDataShreds)
+	var missing []int
+	for i := 0; i < numData; i++ {
+		if set.shards[i] == nil {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	enc, err := reedsolomon.New(numData, int(set.code.NumCodeShreds))
+	if err != nil {
+		return nil, err
+	}
+	if err := enc.Reconstruct(set.shards); err != nil {
+		return nil, fmt.Errorf("cannot recover erasure set %d of slot %d: %w",
+			set.common.FECSetIndex, set.common.Slot, err)
+	}
+
+	if set.merkle == nil {
+		return set.recoverLegacy(missing)
+	}
+	return set.recoverMerkle(missing)
+}
+
+func newErasureSet(data, code [][]byte) (*erasureSet, error) {
+	if len(code) == 0 {
+		return nil, fmt.Errorf("no coding shreds")
+	}
+	common, codeHeader, ok := ParseCodeHeader(code[0])
+	if !ok {
+		return nil, fmt.Errorf("invalid coding shred")
+	}
+	numData, numCode := int(codeHeader.NumDataShreds), int(codeHeader.NumCodeShreds)
+	if numData == 0 || numCode == 0 || numData+numCode > 256 {
+		return nil, fmt.Errorf("invalid erasure set shape %d/%d", numData, numCode)
+	}
+	set := &erasureSet{
+		common:   common,
+		code:     codeHeader,
+		payloads: make([][]byte, numData+numCode),
+		shards:   make([][]byte, numData+numCode),
+	}
+	if common.Variant != LegacyCodeID {
+		layout, ok := parseMerkleVariant(common.Variant)
+		if !ok {
+			return nil, fmt.Errorf("invalid shred variant %#x", common.Variant)
+		}
+		set.merkle = &layout
+	}
+
+	// the legacy shreds of old ledgers leave the position of coding shreds
+	// unset, and index them from the index of the erasure set instead
+	derivePosition := false
+	if set.merkle == nil && len(code) > 1 {
+		derivePosition = true
+		for _, raw := range code {
+			if _, ch, ok := ParseCodeHeader(raw); !ok || ch.Position != 0 {
+				derivePosition = false
+				break
+			}
+		}
+	}
+
+	for i, raw := range code {
+		h, ch, ok := ParseCodeHeader(raw)
+		if err := set.check(h, ok); err != nil {
+			return nil, err
+		}
+		if derivePosition {
+			if h.Index < h.FECSetIndex || h.Index-h.FECSetIndex >= uint32(numCode) {
+				return nil, fmt.Errorf("coding shred %d has no position", h.Index)
+			}
+			ch.Position = uint16(h.Index - h.FECSetIndex)
+		}
+		if ch.NumDataShreds != codeHeader.NumDataShreds || ch.NumCodeShreds != codeHeader.NumCodeShreds ||
+			int(ch.Position) >= numCode {
+			return nil, fmt.Errorf("coding shred %d does not match erasure set", h.Index)
+		}
+		if len(raw) != CodePayloadSize {
+			return nil, fmt.Errorf("invalid coding shred size %d", len(raw))
+		}
+		idx := numData + int(ch.Position)
+		if set.payloads[idx] != nil {
+			return nil, fmt.Errorf("duplicate coding shred %d", h.Index)
+		}
+		set.payloads[idx] = raw
+		if set.merkle != nil && i == 0 {
+			set.merkle.storedRoot = hasStoredRoot(raw, *set.merkle, idx)
+		}
+		if set.merkle != nil {
+			set.shards[idx] = set.merkle.erasureShard(raw)
+		} else {
+			set.shards[idx] = raw[CodeHeaderSize:]
+		}
+	}
+	for _, raw := range data {
+		h, ok := parseCommonHeader(raw)
+		if err := set.check(h, ok && h.IsData()); err != nil {
+			return nil, err
+		}
+		if h.Index < common.FECSetIndex || int(h.Index-common.FECSetIndex) >= numData {
+			return nil, fmt.Errorf("data shred %d is not in erasure set %d", h.Index, common.FECSetIndex)
+		}
+		idx := int(h.Index - common.FECSetIndex)
+		if set.payloads[idx] != nil {
+			return nil, fmt.Errorf("duplicate data shred %d", h.Index)
+		}
+		set.payloads[idx] = raw
+		if set.merkle != nil {
+			if len(raw) != MerkleDataSize {
+				return nil, fmt.Errorf("invalid data shred size %d", len(raw))
+			}
+			layout := *set.merkle
+			layout.code = false
+			set.shards[idx] = layout.erasureShard(raw)
+		} else {
+			// legacy data shreds are stored without their zero padding
+			if len(raw) > LegacyDataSize {
+				return nil, fmt.Errorf("invalid data shred size %d", len(raw))
+			}
+			shard := make([]byte, LegacyDataSize)
+			copy(shard, raw)
+			set.shards[idx] = shard
+		}
+	}
+	return set, nil
+}
+
+// check returns an error unless the shred belongs to the erasure set.
+func (s *erasureSet) check(h CommonHeader, ok bool) error {
+	if !ok {
+		return fmt.Errorf("invalid shred")
+	}
+	if h.Slot != s.common.Slot || h.FECSetIndex != s.common.FECSetIndex {
+		return fmt.Errorf("shred %d/%d is not in erasure set %d/%d",
+			h.Slot, h.Index, s.common.Slot, s.common.FECSetIndex)
+	}
+	if (h.Variant == LegacyCodeID || h.Variant == LegacyDataID) != (s.merkle == nil) {
+		return fmt.Errorf("shred %d mixes legacy and merkle shreds", h.Index)
+	}
+	if s.merkle != nil {
+		layout, ok := parseMerkleVariant(h.Variant)
+		layout.code, layout.storedRoot = s.merkle.code, s.merkle.storedRoot
+		if !ok || layout != *s.merkle {
+			return fmt.Errorf("shred %d has mismatched variant %#x", h.Index, h.Variant)
+		}
+	}
+	return nil
+}
+
+// checkRecovered returns an error unless a recovered data shred is the
+// expected data shred of the erasure set.
+func (s *erasureSet) checkRecovered(raw []byte, idx int, variant uint8) error {
+	h, ok := parseCommonHeader(raw)
+	if !ok || h.Variant != variant || h.Slot != s.common.Slot ||
+		h.Index != s.common.FECSetIndex+uint32(idx) || h.FECSetIndex != s.common.FECSetIndex {
+		return fmt.Errorf("recovered invalid data shred %d of slot %d",
+			s.common.FECSetIndex+uint32(idx), s.common.Slot)
+	}
+	return nil
+}
+
+// recoverLegacy returns the recovered legacy data shreds, of which the
+// shards are the whole shreds, trimmed to their size.
+func (s *erasureSet) recoverLegacy(missing []int) ([][]byte, error) {
+	recovered := make([][]byte, 0, len(missing))
+	for _, idx := range missing {
+		raw := s.shards[idx]
+		if err := s.checkRecovered(raw, idx, LegacyDataID); err != nil {
+			return nil, err
+		}
+		size := int(binary.LittleEndian.Uint16(raw[0x56:0x58]))
+		if size < DataHeaderSize || size > LegacyDataSize {
+			return nil, fmt.Errorf("recovered data shred of invalid size %d", size)
+		}
+		recovered = append(recovered, raw[:size])
+	}
+	return recovered, nil
+}
+
+// recoverMerkle returns the recovered merkle data shreds. Their merkle
+// proofs are rebuilt from the tree of the whole erasure set, which must
+// have the root that the shreds at hand prove, and their signature,
+// chained merkle root and retransmitter signature are those of the shreds
+// at hand, which all share them. The first merkle shreds also get the
+// root of the tree.
+//
+// Based on solana_ledger::shred::merkle::recover.
+func (s *erasureSet) recoverMerkle(missing []int) ([][]byte, error) {
+	numData := int(s.code.NumDataShreds)
+	dataLayout, codeLayout := *s.merkle, *s.merkle
+	dataLayout.code, codeLayout.code = false, true
+
+	// any shred at hand provides the parts shared by all shreds
+	var received []byte
+	var receivedIdx int
+	for i, payload := range s.payloads {
+		if payload != nil {
+			received, receivedIdx = payload, i
+			break
+		}
+	}
+	receivedLayout := dataLayout
+	if receivedIdx >= numData {
+		receivedLayout = codeLayout
+	}
+	var chainedRoot, retransmitterSig []byte
+	if s.merkle.chained {
+		offset := receivedLayout.chainedRootOffset()
+		chainedRoot = received[offset : offset+MerkleRootSize]
+	}
+	if s.merkle.resigned {
+		retransmitterSig = received[len(received)-SignatureSize:]
+	}
+
+	// rebuild the payloads of the missing shreds, up to their proofs
+	firstCodeIndex := s.common.Index - uint32(s.code.Position)
+	for i, shard := range s.shards {
+		if s.payloads[i] != nil {
+			continue
+		}
+		layout := dataLayout
+		if i >= numData {
+			layout = codeLayout
+		}
+		payload := make([]byte, layout.payloadSize())
+		copy(payload, received[:SignatureSize])
+		if i >= numData {
+			position := uint16(i - numData)
+			payload[0x40] = codeLayout.variant(true)
+			binary.LittleEndian.PutUint64(payload[0x41:0x49], s.common.Slot)
+			binary.LittleEndian.PutUint32(payload[0x49:0x4d], firstCodeIndex+uint32(position))
+			binary.LittleEndian.PutUint16(payload[0x4d:0x4f], s.common.Version)
+			binary.LittleEndian.PutUint32(payload[0x4f:0x53], s.common.FECSetIndex)
+			binary.LittleEndian.PutUint16(payload[0x53:0x55], s.code.NumDataShreds)
+			binary.LittleEndian.PutUint16(payload[0x55:0x57], s.code.NumCodeShreds)
+			binary.LittleEndian.PutUint16(payload[0x57:0x59], position)
+			copy(payload[CodeHeaderSize:], shard)
+		} else {
+			copy(payload[SignatureSize:], shard)
+		}
+		if chainedRoot != nil {
+			copy(payload[layout.chainedRootOffset():], chainedRoot)
+		}
+		if retransmitterSig != nil {
+			copy(payload[len(payload)-SignatureSize:], retransmitterSig)
+		}
+		s.payloads[i] = payload
+	}
+	for _, idx := range missing {
+		if err := s.checkRecovered(s.payloads[idx], idx, dataLayout.variant(false)); err != nil {
+			return nil, err
+		}
+	}
+
+	leaves := make([][32]byte, len(s.payloads))
+	for i, payload := range s.payloads {
+		if i < numData {
+			leaves[i] = merkleLeaf(payload, dataLayout)
+		} else {
+			leaves[i] = merkleLeaf(payload, codeLayout)
+		}
+	}
+	tree := makeMerkleTree(*s.merkle, leaves)
+	root := tree[len(tree)-1]
+	provenRoot, err := merkleRootFromProof(*s.merkle, receivedIdx, leaves[receivedIdx],
+		receivedLayout.proof(received))
+	if err != nil {
+		return nil, err
+	}
+	if provenRoot != root {
+		return nil, fmt.Errorf("recovered erasure set %d of slot %d does not match its merkle root",
+			s.common.FECSetIndex, s.common.Slot)
+	}
+
+	recovered := make([][]byte, 0, len(missing))
+	for _, idx := range missing {
+		payload := s.payloads[idx]
+		proof := merkleProof(tree, idx, len(leaves))
+		if len(proof) != s.merkle.proofSize {
+			return nil, fmt.Errorf("merkle proof of %d entries does not fit variant", len(proof))
+		}
+		if s.merkle.storedRoot {
+			copy(payload[dataLayout.storedRootOffset():], root[:StoredRootSize])
+		}
+		offset := dataLayout.proofOffset()
+		for _, entry := range proof {
+			offset += copy(payload[offset:], entry[:])
+		}
+		recovered = append(recovered, payload)
+	}
+	return recovered, nil
+}
@@ -0,0 +1,86 @@
+package shred
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+)
+
+// erasureSets groups shreds by erasure set.
+func erasureSets(data, code [][]byte) (dataSets, codeSets map[uint32][][]byte) {
+	dataSets = make(map[uint32][][]byte)
+	codeSets = make(map[uint32][][]byte)
+	for _, raw := range data {
+		h, _ := parseCommonHeader(raw)
+		dataSets[h.FECSetIndex] = append(dataSets[h.FECSetIndex], raw)
+	}
+	for _, raw := range code {
+		h, _ := parseCommonHeader(raw)
+		codeSets[h.FECSetIndex] = append(codeSets[h.FECSetIndex], raw)
+	}
+	return
+}
+
+func TestRecoverDataShreds_Legacy(t *testing.T) {
+	dataSets, codeSets := erasureSets(
+		fixtures.DataShreds(t, "mainnet", 102815960),
+		fixtures.CodeShreds(t, "mainnet", 102815960))
+
+	var numRecovered int
+	for fecSetIndex, data := range dataSets {
+		code := codeSets[fecSetIndex]
+		if len(code) == 0 {
+			continue
+		}
+		_, header, ok := ParseCodeHeader(code[0])
+		require.True(t, ok)
+		if len(data) != int(header.NumDataShreds) || len(code) < len(data)/2 {
+			continue
+		}
+		// drop every other data shred
+		var kept, dropped [][]byte
+		for i, raw := range data {
+			if i%2 == 0 {
+				dropped = append(dropped, raw)
+			} else {
+				kept = append(kept, raw)
+			}
+		}
+		recovered, err := RecoverDataShreds(kept, code)
+		require.NoError(t, err, "erasure set %d", fecSetIndex)
+		assert.Equal(t, dropped, recovered)
+		numRecovered += len(recovered)
+	}
+	assert.NotZero(t, numRecovered)
+}
+
+func TestRecoverDataShreds_Merkle(t *testing.T) {
+	dataSets, codeSets := erasureSets(
+		fixtures.MerkleDataShreds(t),
+		fixtures.MerkleCodeShreds(t))
+
+	require.NotEmpty(t, dataSets)
+	for fecSetIndex, data := range dataSets {
+		code := codeSets[fecSetIndex]
+		recovered, err := RecoverDataShreds(nil, code[len(code)/2:])
+		require.NoError(t, err, "erasure set %d", fecSetIndex)
+		assert.Equal(t, data, recovered)
+
+		recovered, err = RecoverDataShreds(data, code[:1])
+		require.NoError(t, err)
+		assert.Empty(t, recovered)
+	}
+
+	// the recovered shreds must match the merkle root of the set
+	code := append([][]byte(nil), codeSets[0]...)
+	corrupted := append([]byte(nil), code[0]...)
+	corrupted[CodeHeaderSize] ^= 1
+	code[0] = corrupted
+	_, err := RecoverDataShreds(nil, code)
+	assert.Error(t, err)
+
+	_, err = RecoverDataShreds(dataSets[0], nil)
+	assert.Error(t, err)
+}
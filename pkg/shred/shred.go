@@ -81,10 +81,11 @@ func NewShredFromSerialized(shred []byte, revision int) (s Shred) {
 		}
 		s.Payload = make([]byte, payloadSize)
 		copy(s.Payload, shred[payloadOff:payloadOff+payloadSize])
-	case variant&MerkleTypeMask == MerkleCodeID:
+	case isMerkleCode(variant):
 		panic("todo merkle code shred")
 		//return MerkleCodeFromPayload(shred)
-	case variant&MerkleTypeMask == MerkleDataID:
+	case isMerkleData(variant):
+		layout, _ := parseMerkleVariant(variant)
 		s.DataHeader.ParentOffset = binary.LittleEndian.Uint16(shred[0x53:0x55])
 		s.DataHeader.Flags = shred[0x55]
 		s.DataHeader.Size = binary.LittleEndian.Uint16(shred[0x56:0x58])
@@ -100,9 +101,13 @@ func NewShredFromSerialized(shred []byte, revision int) (s Shred) {
 		}
 		s.Payload = make([]byte, payloadSize)
 		copy(s.Payload, shred[payloadOff:payloadOff+payloadSize])
+		proofEnd := len(shred)
+		if layout.resigned {
+			proofEnd -= SignatureSize
+		}
 		s.MerklePath = make([][20]byte, merkleDepth)
 		for i := range s.MerklePath {
-			copy(s.MerklePath[i][:], shred[len(shred)-(merkleDepth-i)*20:len(shred)-(merkleDepth-i-1)*20])
+			copy(s.MerklePath[i][:], shred[proofEnd-(merkleDepth-i)*20:proofEnd-(merkleDepth-i-1)*20])
 		}
 	default:
 		return
@@ -148,11 +153,27 @@ func (c *CommonHeader) Ok() bool {
 }
 
 func (c *CommonHeader) IsData() bool {
-	return c.Variant == LegacyDataID || (c.Variant&MerkleTypeMask) == MerkleDataID
+	return c.Variant == LegacyDataID || isMerkleData(c.Variant)
 }
 
 func (c *CommonHeader) IsCode() bool {
-	return c.Variant == LegacyCodeID || (c.Variant&MerkleTypeMask) == MerkleCodeID
+	return c.Variant == LegacyCodeID || isMerkleCode(c.Variant)
+}
+
+func isMerkleData(variant uint8) bool {
+	switch variant & MerkleTypeMask {
+	case MerkleDataID, MerkleDataChainedID, MerkleDataChainedResignedID:
+		return true
+	}
+	return false
+}
+
+func isMerkleCode(variant uint8) bool {
+	switch variant & MerkleTypeMask {
+	case MerkleCodeID, MerkleCodeChainedID, MerkleCodeChainedResignedID:
+		return true
+	}
+	return false
 }
 
 type DataHeader struct {
@@ -172,3 +193,20 @@ func (s *DataHeader) EndOfBatch() bool {
 func (s *DataHeader) Tick() uint8 {
 	return s.Flags & FlagDataTickMask
 }
+
+// VersionFromHash returns the shred version of a cluster, given the hash
+// of its genesis and hard forks. The version is never zero.
+//
+// Based on solana_sdk::shred_version::version_from_hash.
+func VersionFromHash(hash [32]byte) uint16 {
+	var accum [2]byte
+	for i := 0; i < len(hash); i += 2 {
+		accum[0] ^= hash[i]
+		accum[1] ^= hash[i+1]
+	}
+	version := binary.BigEndian.Uint16(accum[:])
+	if version < 0xffff {
+		version++
+	}
+	return version
+}
@@ -0,0 +1,261 @@
+// Package snapshot reads and writes snapshot archives, which hold the state
+// of a bank and of all accounts as of a slot.
+package snapshot
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/appendvec"
+	"go.firedancer.io/radiance/pkg/archiveutil"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/features"
+	"k8s.io/klog/v2"
+)
+
+// maxManifestSize bounds the size of the manifest of a snapshot.
+const maxManifestSize = 1 << 32
+
+// LoadArchiveFromFile is a convenience wrapper for LoadArchive.
+func LoadArchiveFromFile(fpath string, accts accounts.Accounts) (*Manifest, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadArchive(f, accts)
+}
+
+// LoadArchive reads a full snapshot archive, such as a
+// `snapshot-<slot>-<hash>.tar.zst` file, storing its accounts into accts,
+// and returns its manifest, with the status cache of the archive.
+//
+// The archive is streamed: the manifest must precede the account storages,
+// as it does in archives created by the validator. Of the versions of an
+// account stored in several slots, the newest one is kept.
+func LoadArchive(archive io.Reader, accts accounts.Accounts) (*Manifest, error) {
+	files, err := archiveutil.OpenTar(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest *Manifest
+	var storageLens map[storageId]uint64
+	var statusCache []bank.SlotDelta
+	var numStorages, numAccounts int
+	slots := make(map[[32]byte]uint64)
+	for {
+		hdr, err := files.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+
+		if isManifestPath(name) {
+			if manifest != nil {
+				return nil, fmt.Errorf("more than one manifest in snapshot")
+			}
+			if hdr.Size > maxManifestSize {
+				return nil, fmt.Errorf("manifest too large (%d bytes)", hdr.Size)
+			}
+			data, err := io.ReadAll(files)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			manifest, err = UnmarshalManifest(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid manifest %s: %w", name, err)
+			}
+			storageLens = manifest.storageLens()
+			continue
+		}
+
+		if name == statusCachePath {
+			data, err := io.ReadAll(files)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			statusCache, err = UnmarshalStatusCache(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status cache: %w", err)
+			}
+			continue
+		}
+
+		id, ok := parseStoragePath(name)
+		if !ok {
+			// version file
+			klog.V(4).Infof("Skipping %s", name)
+			continue
+		}
+		if manifest == nil {
+			return nil, fmt.Errorf("account storage %s precedes the manifest", name)
+		}
+		currentLen, ok := storageLens[id]
+		if !ok {
+			return nil, fmt.Errorf("account storage %s is not in the manifest", name)
+		}
+		data, err := io.ReadAll(files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		vec, err := appendvec.FromBytes(data, currentLen)
+		if err != nil {
+			return nil, fmt.Errorf("invalid account storage %s: %w", name, err)
+		}
+		stored := 0
+		err = vec.ForEach(func(meta *appendvec.StoredAccountMeta) error {
+			stored++
+			prevSlot, exists := slots[meta.Pubkey]
+			if exists && prevSlot > id.slot {
+				return nil
+			}
+			slots[meta.Pubkey] = id.slot
+			acct := meta.Account()
+			if acct.Lamports == 0 {
+				// zero-lamport accounts are deleted, which only matters if
+				// an older version was stored
+				if !exists {
+					return nil
+				}
+				acct = &accounts.Account{}
+			}
+			return accts.SetAccount(&meta.Pubkey, acct)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load account storage %s: %w", name, err)
+		}
+		numStorages++
+		numAccounts += stored
+		klog.V(3).Infof("Loaded %d accounts from %s", stored, name)
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("no manifest in snapshot")
+	}
+	if numStorages != len(storageLens) {
+		return nil, fmt.Errorf("snapshot has %d of %d account storages", numStorages, len(storageLens))
+	}
+	manifest.StatusCache = statusCache
+	klog.V(2).Infof("Loaded snapshot of slot %d: %d storages, %d stored accounts, %d pubkeys",
+		manifest.Bank.Slot, numStorages, numAccounts, len(slots))
+	return manifest, nil
+}
+
+// storageId identifies an account storage.
+type storageId struct {
+	slot uint64
+	id   uint64
+}
+
+// storageLens returns the valid length of each account storage.
+func (m *Manifest) storageLens() map[storageId]uint64 {
+	lens := make(map[storageId]uint64)
+	for _, slot := range m.AccountsDb.Storages {
+		for _, entry := range slot.Storages {
+			lens[storageId{slot: slot.Slot, id: entry.Id}] = entry.AccountsCurrentLen
+		}
+	}
+	return lens
+}
+
+// isManifestPath returns whether the path is snapshots/<slot>/<slot>.
+func isManifestPath(name string) bool {
+	parts := strings.Split(name, "/")
+	if len(parts) != 3 || parts[0] != "snapshots" || parts[1] != parts[2] {
+		return false
+	}
+	_, err := strconv.ParseUint(parts[1], 10, 64)
+	return err == nil
+}
+
+// parseStoragePath parses an account storage path, accounts/<slot>.<id>.
+func parseStoragePath(name string) (id storageId, ok bool) {
+	file := strings.TrimPrefix(name, "accounts/")
+	if file == name {
+		return
+	}
+	slotStr, idStr, found := strings.Cut(file, ".")
+	if !found {
+		return
+	}
+	var err error
+	if id.slot, err = strconv.ParseUint(slotStr, 10, 64); err != nil {
+		return
+	}
+	if id.id, err = strconv.ParseUint(idStr, 10, 64); err != nil {
+		return
+	}
+	return id, true
+}
+
+// NewBank returns the frozen bank of the snapshot slot, of which the
+// accounts have been loaded into accts. Replay may continue from it with
+// bank.NewBankFromParent.
+//
+// Based on solana_runtime::bank::Bank::new_from_fields.
+func (m *Manifest) NewBank(accts accounts.Accounts, f *features.Features) *bank.Bank {
+	fields := &m.Bank
+	b := bank.NewBank(fields.Slot, accts, f, fields.EpochSchedule)
+	b.ParentSlot = fields.ParentSlot
+	b.ParentHash = fields.ParentHash
+	b.TicksPerSlot = fields.TicksPerSlot
+	b.SlotsPerYear = fields.SlotsPerYear
+	b.CollectorId = fields.CollectorId
+	b.FeeBurnPercent = fields.FeeRateGovernor.BurnPercent
+	b.SignatureCount = fields.SignatureCount
+	b.BlockHeight = fields.BlockHeight
+	b.TransactionCount = fields.TransactionCount
+	b.LamportsPerSignature = m.LamportsPerSignature
+	if b.LamportsPerSignature == 0 {
+		b.LamportsPerSignature = fields.FeeCalculator
+	}
+	b.EpochAccountsHash = m.EpochAccountsHash
+	hardForks := make([]bank.HardFork, len(fields.HardForks))
+	for i, fork := range fields.HardForks {
+		hardForks[i] = bank.HardFork{Slot: fork.Slot, Count: fork.Index}
+	}
+	b.HardForks = bank.NewHardForks(hardForks...)
+	if m.AccountsLtHash != nil {
+		b.AccountsLtHash = *m.AccountsLtHash
+	}
+
+	queue := &fields.BlockhashQueue
+	hashes := make(map[[32]byte]bank.HashInfo, len(queue.Hashes))
+	for _, entry := range queue.Hashes {
+		hashes[entry.Hash] = bank.HashInfo{LamportsPerSignature: entry.LamportsPerSignature, HashIndex: entry.HashIndex}
+	}
+	b.BlockhashQueue = bank.RestoreBlockhashQueue(queue.LastHash, queue.LastHashIndex, hashes, queue.MaxAge)
+	// the stakes cache holds the vote and stake accounts of the snapshot
+	for _, voteAccount := range fields.Stakes.VoteAccounts {
+		storeStakesAccount(b, accts, voteAccount.Pubkey)
+	}
+	for _, delegation := range fields.Stakes.StakeDelegations {
+		storeStakesAccount(b, accts, delegation.Pubkey)
+	}
+	b.StatusCache.AppendSlotDeltas(m.StatusCache)
+	b.StatusCache.AddRoot(fields.Slot)
+
+	b.FreezeWithHash(fields.Hash, m.AccountsDb.BankHashInfo.AccountsDeltaHash)
+	return b
+}
+
+// storeStakesAccount adds a vote or stake account of the snapshot to the
+// stakes cache of the bank.
+func storeStakesAccount(b *bank.Bank, accts accounts.Accounts, pubkey [32]byte) {
+	if acct, err := accts.GetAccount(&pubkey); err == nil && acct != nil {
+		b.Stakes.CheckAndStore(pubkey, acct)
+	}
+}
@@ -0,0 +1,185 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/appendvec"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func newTestManifest() *Manifest {
+	lastHash := [32]byte{9}
+	hashesPerTick := uint64(12500)
+	eah := [32]byte{7}
+	return &Manifest{
+		Bank: BankFields{
+			BlockhashQueue: BlockhashQueue{
+				LastHashIndex: 2,
+				LastHash:      &lastHash,
+				Hashes: []BlockhashQueueEntry{
+					{Hash: [32]byte{8}, LamportsPerSignature: 5000, HashIndex: 1, Timestamp: 100},
+					{Hash: lastHash, LamportsPerSignature: 5000, HashIndex: 2, Timestamp: 101},
+				},
+				MaxAge: 300,
+			},
+			Ancestors:      []SlotAndIndex{{Slot: 10}},
+			Hash:           [32]byte{1},
+			ParentHash:     [32]byte{2},
+			ParentSlot:     5,
+			HashesPerTick:  &hashesPerTick,
+			TicksPerSlot:   64,
+			SlotsPerYear:   78892314.984,
+			Slot:           10,
+			CollectorId:    [32]byte{3},
+			FeeCalculator:  5000,
+			SignatureCount: 4,
+			FeeRateGovernor: FeeRateGovernor{
+				TargetLamportsPerSignature: 10000,
+				MaxLamportsPerSignature:    100000,
+				BurnPercent:                50,
+			},
+			RentCollector: RentCollector{
+				EpochSchedule: sealevel.SysvarEpochSchedule{SlotsPerEpoch: 432000},
+				Rent:          sealevel.SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2.0, BurnPercent: 50},
+			},
+			EpochSchedule: sealevel.SysvarEpochSchedule{SlotsPerEpoch: 432000, LeaderScheduleSlotOffset: 432000},
+			Stakes: Stakes{
+				VoteAccounts: []VoteAccount{{Pubkey: [32]byte{4}, Stake: 42, Account: accounts.Account{Lamports: 1, Data: []byte{1, 2, 3}}}},
+				StakeDelegations: []StakeDelegation{
+					{Pubkey: [32]byte{5}, VoterPubkey: [32]byte{4}, Stake: 42, DeactivationEpoch: ^uint64(0), WarmupCooldownRate: 0.25},
+				},
+			},
+			EpochStakes: []EpochStakes{{Epoch: 1, TotalStake: 42}},
+		},
+		AccountsDb: AccountsDbFields{
+			Storages: []SlotStorages{
+				{Slot: 5, Storages: []StorageEntry{{Id: 0}}},
+				{Slot: 10, Storages: []StorageEntry{{Id: 1}}},
+			},
+			Slot:         10,
+			BankHashInfo: BankHashInfo{AccountsDeltaHash: [32]byte{6}},
+		},
+		LamportsPerSignature: 5000,
+		EpochAccountsHash:    &eah,
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	manifest := newTestManifest()
+	data, err := manifest.Marshal()
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalManifest(data)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Bank.BlockhashQueue, decoded.Bank.BlockhashQueue)
+	assert.Equal(t, manifest.Bank.Stakes.VoteAccounts, decoded.Bank.Stakes.VoteAccounts)
+	assert.Equal(t, manifest.Bank.Stakes.StakeDelegations, decoded.Bank.Stakes.StakeDelegations)
+	assert.Equal(t, uint64(12500), *decoded.Bank.HashesPerTick)
+	assert.Equal(t, manifest.Bank.RentCollector, decoded.Bank.RentCollector)
+	assert.Equal(t, manifest.AccountsDb.Storages, decoded.AccountsDb.Storages)
+	assert.Equal(t, manifest.EpochAccountsHash, decoded.EpochAccountsHash)
+	assert.Nil(t, decoded.AccountsLtHash)
+
+	reencoded, err := decoded.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, data, reencoded)
+
+	// trailing fields are optional
+	decoded, err = UnmarshalManifest(data[:len(data)-33-1-8])
+	require.NoError(t, err)
+	assert.Zero(t, decoded.LamportsPerSignature)
+	assert.Nil(t, decoded.EpochAccountsHash)
+
+	_, err = UnmarshalManifest(data[:100])
+	assert.Error(t, err)
+}
+
+// appendStoredAccount appends an account to an append vec.
+func appendStoredAccount(vec []byte, pubkey [32]byte, acct *accounts.Account) []byte {
+	meta := &appendvec.StoredAccountMeta{
+		Pubkey:     pubkey,
+		Lamports:   acct.Lamports,
+		RentEpoch:  acct.RentEpoch,
+		Owner:      acct.Owner,
+		Executable: acct.Executable,
+		Data:       acct.Data,
+	}
+	stored := appendvec.New(meta.StoredSize())
+	if _, err := stored.Append(meta); err != nil {
+		panic(err)
+	}
+	return append(vec, stored.Bytes()...)
+}
+
+func TestLoadArchive(t *testing.T) {
+	older := appendStoredAccount(nil, [32]byte{1}, &accounts.Account{Lamports: 1, Data: []byte{1}})
+	older = appendStoredAccount(older, [32]byte{2}, &accounts.Account{Lamports: 2})
+	older = appendStoredAccount(older, [32]byte{3}, &accounts.Account{Lamports: 3, Owner: [32]byte{9}, Executable: true, RentEpoch: 5})
+	newer := appendStoredAccount(nil, [32]byte{1}, &accounts.Account{Lamports: 10, Data: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}})
+	newer = appendStoredAccount(newer, [32]byte{2}, &accounts.Account{})
+	newer = appendStoredAccount(newer, [32]byte{4}, &accounts.Account{})
+
+	manifest := newTestManifest()
+	manifest.AccountsDb.Storages[0].Storages[0].AccountsCurrentLen = uint64(len(older))
+	manifest.AccountsDb.Storages[1].Storages[0].AccountsCurrentLen = uint64(len(newer))
+	manifestData, err := manifest.Marshal()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{"version", []byte("1.2.0")},
+		{"snapshots/10/10", manifestData},
+		{"accounts/5.0", older},
+		{"accounts/10.1", append(newer, make([]byte, 64)...)},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.data)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(file.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	accts := accounts.NewMemAccounts()
+	loaded, err := LoadArchive(bytes.NewReader(buf.Bytes()), accts)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), loaded.Bank.Slot)
+
+	assert.Len(t, accts.Map, 3)
+	assert.Equal(t, &accounts.Account{Lamports: 10, Data: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}}, accts.Map[[32]byte{1}])
+	assert.Equal(t, &accounts.Account{Lamports: 3, Data: []byte{}, Owner: [32]byte{9}, Executable: true, RentEpoch: 5}, accts.Map[[32]byte{3}])
+	assert.Zero(t, accts.Map[[32]byte{2}].Lamports)
+	assert.NotContains(t, accts.Map, [32]byte{4})
+
+	b := loaded.NewBank(accts, features.NewFeaturesDefault())
+	hash, frozen := b.Hash()
+	assert.True(t, frozen)
+	assert.Equal(t, [32]byte{1}, hash)
+	assert.Equal(t, [32]byte{9}, b.LastBlockhash())
+	assert.Equal(t, uint64(5), b.ParentSlot)
+	assert.Equal(t, uint8(50), b.FeeBurnPercent)
+
+	child, err := bank.NewBankFromParent(b, 11)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte{1}, child.ParentHash)
+	assert.Equal(t, uint64(5000), child.LamportsPerSignature)
+
+	// the manifest must precede the account storages
+	buf.Reset()
+	tw = tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "accounts/5.0", Mode: 0644, Size: int64(len(older)), Typeflag: tar.TypeReg}))
+	_, err = tw.Write(older)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	_, err = LoadArchive(bytes.NewReader(buf.Bytes()), accounts.NewMemAccounts())
+	assert.Error(t, err)
+}
@@ -0,0 +1,254 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// maxSeqLen bounds the length of sequences read from a manifest, so that a
+// corrupt length fails the read instead of exhausting memory.
+const maxSeqLen = 1 << 28
+
+// reader reads the bincode encoding of a snapshot manifest. The first
+// error is sticky: once a read fails, later reads return zero values, and
+// the error is reported by err.
+type reader struct {
+	dec *bin.Decoder
+	err error
+}
+
+func newReader(data []byte) *reader {
+	return &reader{dec: bin.NewBinDecoder(data)}
+}
+
+func (r *reader) setErr(err error, what string) {
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("failed to read %s: %w", what, err)
+	}
+}
+
+func (r *reader) u8(what string) uint8 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := r.dec.ReadUint8()
+	r.setErr(err, what)
+	return v
+}
+
+func (r *reader) u32(what string) uint32 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := r.dec.ReadUint32(bin.LE)
+	r.setErr(err, what)
+	return v
+}
+
+func (r *reader) u64(what string) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := r.dec.ReadUint64(bin.LE)
+	r.setErr(err, what)
+	return v
+}
+
+func (r *reader) i64(what string) int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := r.dec.ReadInt64(bin.LE)
+	r.setErr(err, what)
+	return v
+}
+
+func (r *reader) u128(what string) bin.Uint128 {
+	if r.err != nil {
+		return bin.Uint128{}
+	}
+	v, err := r.dec.ReadUint128(bin.LE)
+	r.setErr(err, what)
+	return v
+}
+
+func (r *reader) f64(what string) float64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := r.dec.ReadFloat64(bin.LE)
+	r.setErr(err, what)
+	return v
+}
+
+func (r *reader) boolean(what string) bool {
+	if r.err != nil {
+		return false
+	}
+	v, err := r.dec.ReadBool()
+	r.setErr(err, what)
+	return v
+}
+
+func (r *reader) hash(what string) (h [32]byte) {
+	if r.err != nil {
+		return
+	}
+	b, err := r.dec.ReadNBytes(32)
+	r.setErr(err, what)
+	copy(h[:], b)
+	return
+}
+
+func (r *reader) bytes(what string) []byte {
+	n := r.length(what)
+	if r.err != nil {
+		return nil
+	}
+	b, err := r.dec.ReadNBytes(n)
+	r.setErr(err, what)
+	return append([]byte{}, b...)
+}
+
+// length reads the length of a sequence or map.
+func (r *reader) length(what string) int {
+	n := r.u64(what)
+	if r.err == nil && (n > maxSeqLen || n > uint64(r.dec.Remaining())) {
+		r.setErr(fmt.Errorf("invalid length %d", n), what)
+		return 0
+	}
+	return int(n)
+}
+
+// option reads the tag of an Option, returning whether it is Some.
+func (r *reader) option(what string) bool {
+	tag := r.u8(what)
+	if r.err == nil && tag > 1 {
+		r.setErr(fmt.Errorf("invalid option tag %d", tag), what)
+	}
+	return tag == 1
+}
+
+// txResult reads a Result<(), TransactionError>, returning the error of a
+// failed transaction.
+func (r *reader) txResult(what string) error {
+	tag := r.u32(what)
+	if r.err != nil || tag == 0 {
+		return nil
+	}
+	if tag != 1 {
+		r.setErr(fmt.Errorf("invalid result tag %d", tag), what)
+		return nil
+	}
+	rest, err := r.dec.Peek(r.dec.Remaining())
+	if err != nil {
+		r.setErr(err, what)
+		return nil
+	}
+	txErr, n, err := sealevel.DecodeTransactionError(rest)
+	if err != nil {
+		r.setErr(err, what)
+		return nil
+	}
+	r.setErr(r.dec.SkipBytes(uint(n)), what)
+	return txErr
+}
+
+// atEOF returns whether all input has been read. Fields appended to the
+// manifest by later versions are absent from older snapshots.
+func (r *reader) atEOF() bool {
+	return r.err == nil && !r.dec.HasRemaining()
+}
+
+// writer writes the bincode encoding of a snapshot manifest, with a sticky
+// first error like reader.
+type writer struct {
+	enc *bin.Encoder
+	err error
+}
+
+func newWriter(w io.Writer) *writer {
+	return &writer{enc: bin.NewBinEncoder(w)}
+}
+
+func (w *writer) u8(v uint8) {
+	if w.err == nil {
+		w.err = w.enc.WriteUint8(v)
+	}
+}
+
+func (w *writer) u32(v uint32) {
+	if w.err == nil {
+		w.err = w.enc.WriteUint32(v, bin.LE)
+	}
+}
+
+func (w *writer) u64(v uint64) {
+	if w.err == nil {
+		w.err = w.enc.WriteUint64(v, bin.LE)
+	}
+}
+
+func (w *writer) i64(v int64) {
+	if w.err == nil {
+		w.err = w.enc.WriteInt64(v, bin.LE)
+	}
+}
+
+func (w *writer) u128(v bin.Uint128) {
+	if w.err == nil {
+		w.err = w.enc.WriteUint128(v, bin.LE)
+	}
+}
+
+func (w *writer) f64(v float64) {
+	if w.err == nil {
+		w.err = w.enc.WriteFloat64(v, bin.LE)
+	}
+}
+
+func (w *writer) boolean(v bool) {
+	if w.err == nil {
+		w.err = w.enc.WriteBool(v)
+	}
+}
+
+func (w *writer) hash(h [32]byte) {
+	if w.err == nil {
+		w.err = w.enc.WriteBytes(h[:], false)
+	}
+}
+
+func (w *writer) bytes(b []byte) {
+	w.u64(uint64(len(b)))
+	if w.err == nil {
+		w.err = w.enc.WriteBytes(b, false)
+	}
+}
+
+func (w *writer) option(some bool) {
+	if some {
+		w.u8(1)
+	} else {
+		w.u8(0)
+	}
+}
+
+func (w *writer) txResult(txErr error) {
+	if txErr == nil {
+		w.u32(0)
+		return
+	}
+	w.u32(1)
+	if w.err != nil {
+		return
+	}
+	var buf []byte
+	buf, w.err = sealevel.AppendTransactionError(nil, txErr)
+	if w.err == nil {
+		w.err = w.enc.WriteBytes(buf, false)
+	}
+}
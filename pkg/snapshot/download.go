@@ -0,0 +1,119 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PartialSuffix is appended to the path of a file being downloaded.
+const PartialSuffix = ".partial"
+
+// Download downloads a file over HTTP. The file is written to the path with
+// PartialSuffix, and renamed to the path once complete, such that an
+// interrupted download is resumed by the next call, with a range request.
+//
+// Progress, if not nil, is called with the number of bytes written so far,
+// and the size of the file, or -1 if unknown.
+//
+// Based on solana_file_download::download_file.
+func Download(ctx context.Context, client *http.Client, url, fpath string, progress func(written, total int64)) error {
+	partial := fpath + PartialSuffix
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// the server ignored the range, or none was requested
+		if offset > 0 {
+			if err := f.Truncate(0); err != nil {
+				return err
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			offset = 0
+		}
+	case http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			return fmt.Errorf("unexpected content range %q", resp.Header.Get("Content-Range"))
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial download is not a prefix of the file, start over
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		return fmt.Errorf("cannot resume download of %s at offset %d", url, offset)
+	default:
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	w := &progressWriter{w: f, written: offset, total: total, progress: progress}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return err
+	}
+	if total >= 0 && w.written != total {
+		return io.ErrUnexpectedEOF
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partial, fpath)
+}
+
+// contentRangeStart returns the first byte of a Content-Range header.
+func contentRangeStart(header string) (int64, bool) {
+	if !strings.HasPrefix(header, "bytes ") {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(strings.TrimPrefix(header, "bytes "), "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
+
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress func(written, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if p.progress != nil {
+		p.progress(p.written, p.total)
+	}
+	return n, err
+}
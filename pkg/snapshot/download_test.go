@@ -0,0 +1,55 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("radiance"), 1<<12)
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "snapshot", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "snapshot-1-x.tar.zst")
+	var written, total int64
+	progress := func(w, t int64) { written, total = w, t }
+
+	// an interrupted download is resumed
+	require.NoError(t, os.WriteFile(fpath+PartialSuffix, content[:1000], 0644))
+	require.NoError(t, Download(context.Background(), server.Client(), server.URL, fpath, progress))
+	got, err := os.ReadFile(fpath)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	assert.Equal(t, []string{"bytes=1000-"}, ranges)
+	assert.Equal(t, int64(len(content)), written)
+	assert.Equal(t, int64(len(content)), total)
+	assert.NoFileExists(t, fpath+PartialSuffix)
+
+	// a partial download longer than the file starts over
+	require.NoError(t, os.WriteFile(fpath+PartialSuffix, append(content, 'x'), 0644))
+	assert.Error(t, Download(context.Background(), server.Client(), server.URL, fpath, nil))
+	require.NoError(t, Download(context.Background(), server.Client(), server.URL, fpath, nil))
+	got, err = os.ReadFile(fpath)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	assert.Error(t, Download(context.Background(), server.Client(), server.URL+"/missing", filepath.Join(dir, "missing"), nil))
+}
@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+// FeatureProgramAddr is the owner of feature accounts.
+var FeatureProgramAddr = base58.MustDecodeFromString("Feature111111111111111111111111111111111111")
+
+// Hash returns the snapshot hash of a full snapshot, given the hash of its
+// accounts: the accounts hash, or the checksum of the accounts lattice hash
+// once snapshots_lt_hash is active. The epoch accounts hash is mixed in if
+// the snapshot holds one.
+//
+// Based on solana_runtime::snapshot_hash::SnapshotHash::new.
+func Hash(accountsHash [32]byte, epochAccountsHash *[32]byte) [32]byte {
+	if epochAccountsHash == nil {
+		return accountsHash
+	}
+	hasher := sha256.New()
+	hasher.Write(accountsHash[:])
+	hasher.Write(epochAccountsHash[:])
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	return hash
+}
+
+// ComputeHash computes the snapshot hash of the snapshot of the manifest,
+// of which the accounts are given.
+//
+// Based on solana_runtime::bank::Bank::get_snapshot_hash.
+func (m *Manifest) ComputeHash(accts map[[32]byte]*accounts.Account) [32]byte {
+	var accountsHash [32]byte
+	if isFeatureActive(accts, features.SnapshotsLtHash, m.Bank.Slot) {
+		ltHash := accounts.AccountsLtHash(accts)
+		accountsHash = ltHash.Checksum()
+	} else {
+		accountsHash = accounts.AccountsHash(accts)
+	}
+	return Hash(accountsHash, m.EpochAccountsHash)
+}
+
+// isFeatureActive returns whether the feature account of a gate records its
+// activation at or before the given slot.
+func isFeatureActive(accts map[[32]byte]*accounts.Account, gate features.FeatureGate, slot uint64) bool {
+	acct, ok := accts[gate.Address]
+	if !ok || acct == nil || acct.Owner != FeatureProgramAddr {
+		return false
+	}
+	activatedAt, activated, _ := features.ActivatedAt(acct.Data)
+	return activated && activatedAt <= slot
+}
+
+// ArchiveName returns the file name of a full snapshot archive, of the
+// form snapshot-<slot>-<hash>.tar.zst.
+func ArchiveName(slot uint64, hash [32]byte) string {
+	return fmt.Sprintf("snapshot-%d-%s.tar.zst", slot, base58.Encode(hash[:]))
+}
+
+// IncrementalArchiveName returns the file name of an incremental snapshot
+// archive on top of the full snapshot of the base slot, of the form
+// incremental-snapshot-<base slot>-<slot>-<hash>.tar.zst.
+func IncrementalArchiveName(baseSlot, slot uint64, hash [32]byte) string {
+	return fmt.Sprintf("incremental-snapshot-%d-%d-%s.tar.zst", baseSlot, slot, base58.Encode(hash[:]))
+}
+
+// ParseArchiveName parses the slot and snapshot hash from the path of a
+// full snapshot archive.
+func ParseArchiveName(fpath string) (slot uint64, hash [32]byte, err error) {
+	name := filepath.Base(fpath)
+	if i := strings.Index(name, ".tar"); i >= 0 {
+		name = name[:i]
+	}
+	parts := strings.Split(name, "-")
+	if len(parts) != 3 || parts[0] != "snapshot" {
+		return 0, hash, fmt.Errorf("%s is not named snapshot-<slot>-<hash>", filepath.Base(fpath))
+	}
+	if slot, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return 0, hash, fmt.Errorf("invalid slot in snapshot name: %w", err)
+	}
+	if hash, err = base58.DecodeFromString(parts[2]); err != nil {
+		return 0, hash, fmt.Errorf("invalid hash in snapshot name: %w", err)
+	}
+	return slot, hash, nil
+}
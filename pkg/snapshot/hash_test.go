@@ -0,0 +1,61 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func TestHash(t *testing.T) {
+	accountsHash := [32]byte{1, 2, 3}
+	assert.Equal(t, accountsHash, Hash(accountsHash, nil))
+
+	eah := [32]byte{4, 5, 6}
+	expected := sha256.Sum256(append(accountsHash[:], eah[:]...))
+	assert.Equal(t, expected, Hash(accountsHash, &eah))
+}
+
+func TestArchiveName(t *testing.T) {
+	hash := [32]byte{7, 8, 9}
+	name := ArchiveName(1234, hash)
+	assert.Regexp(t, `^snapshot-1234-\w+\.tar\.zst$`, name)
+
+	slot, parsed, err := ParseArchiveName("/tmp/snapshots/" + name)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1234), slot)
+	assert.Equal(t, hash, parsed)
+
+	incremental := IncrementalArchiveName(1234, 1300, hash)
+	assert.Regexp(t, `^incremental-snapshot-1234-1300-\w+\.tar\.zst$`, incremental)
+	_, _, err = ParseArchiveName(incremental)
+	assert.Error(t, err)
+	_, _, err = ParseArchiveName("snapshot-abc-11111111111111111111111111111111.tar.zst")
+	assert.Error(t, err)
+}
+
+func TestComputeHash(t *testing.T) {
+	m := newTestManifest()
+	accts := map[[32]byte]*accounts.Account{
+		{1}: {Lamports: 10, Owner: [32]byte{9}},
+		{2}: {Lamports: 20, Data: []byte{1, 2}},
+		{3}: {},
+	}
+	accountsHash := accounts.AccountsHash(accts)
+	assert.Equal(t, Hash(accountsHash, m.EpochAccountsHash), m.ComputeHash(accts))
+
+	// feature activated after the snapshot slot
+	featureData := make([]byte, 9)
+	featureData[0] = 1
+	binary.LittleEndian.PutUint64(featureData[1:], m.Bank.Slot+1)
+	accts[features.SnapshotsLtHash.Address] = &accounts.Account{Lamports: 1, Owner: FeatureProgramAddr, Data: featureData}
+	assert.Equal(t, Hash(accounts.AccountsHash(accts), m.EpochAccountsHash), m.ComputeHash(accts))
+
+	binary.LittleEndian.PutUint64(featureData[1:], m.Bank.Slot)
+	ltHash := accounts.AccountsLtHash(accts)
+	assert.Equal(t, Hash(ltHash.Checksum(), m.EpochAccountsHash), m.ComputeHash(accts))
+}
@@ -0,0 +1,751 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// Manifest is the bank and accounts database state serialized in a
+// snapshot, as found at snapshots/<slot>/<slot> in a snapshot archive.
+// Fields appended to the manifest by later versions of the validator are
+// optional, and left at their zero value when absent.
+//
+// Based on solana_runtime::serde_snapshot::SnapshotBankFields and
+// solana_runtime::serde_snapshot::SnapshotAccountsDbFields.
+type Manifest struct {
+	Bank       BankFields
+	AccountsDb AccountsDbFields
+
+	LamportsPerSignature           uint64
+	IncrementalSnapshotPersistence *IncrementalSnapshotPersistence
+	EpochAccountsHash              *[32]byte
+	VersionedEpochStakes           []EpochStakes
+	AccountsLtHash                 *accounts.LtHash
+
+	// StatusCache holds the statuses of the recent transactions, which are
+	// stored next to the manifest in snapshot archives, and not serialized
+	// with it.
+	StatusCache []bank.SlotDelta
+}
+
+// BankFields is the state of the bank of the snapshot slot.
+//
+// Based on solana_runtime::serde_snapshot::newer::DeserializableVersionedBank.
+type BankFields struct {
+	BlockhashQueue      BlockhashQueue
+	Ancestors           []SlotAndIndex
+	Hash                [32]byte
+	ParentHash          [32]byte
+	ParentSlot          uint64
+	HardForks           []SlotAndIndex
+	TransactionCount    uint64
+	TickHeight          uint64
+	SignatureCount      uint64
+	Capitalization      uint64
+	MaxTickHeight       uint64
+	HashesPerTick       *uint64
+	TicksPerSlot        uint64
+	NsPerSlot           bin.Uint128
+	GenesisCreationTime int64
+	SlotsPerYear        float64
+	AccountsDataLen     uint64
+	Slot                uint64
+	Epoch               uint64
+	BlockHeight         uint64
+	CollectorId         [32]byte
+	CollectorFees       uint64
+	FeeCalculator       uint64
+	FeeRateGovernor     FeeRateGovernor
+	CollectedRent       uint64
+	RentCollector       RentCollector
+	EpochSchedule       sealevel.SysvarEpochSchedule
+	Inflation           Inflation
+	Stakes              Stakes
+	UnusedAccounts      UnusedAccounts
+	EpochStakes         []EpochStakes
+	IsDelta             bool
+}
+
+// BlockhashQueue is the serialized blockhash queue of a bank.
+//
+// Based on solana_accounts_db::blockhash_queue::BlockhashQueue.
+type BlockhashQueue struct {
+	LastHashIndex uint64
+	LastHash      *[32]byte
+	Hashes        []BlockhashQueueEntry
+	MaxAge        uint64
+}
+
+// BlockhashQueueEntry is a blockhash of the blockhash queue.
+type BlockhashQueueEntry struct {
+	Hash                 [32]byte
+	LamportsPerSignature uint64
+	HashIndex            uint64
+	Timestamp            uint64
+}
+
+// SlotAndIndex is an entry of the ancestors or hard forks of a bank.
+type SlotAndIndex struct {
+	Slot  uint64
+	Index uint64
+}
+
+// FeeRateGovernor is the serialized fee rate governor of a bank.
+//
+// Based on solana_sdk::fee_calculator::FeeRateGovernor.
+type FeeRateGovernor struct {
+	TargetLamportsPerSignature uint64
+	TargetSignaturesPerSlot    uint64
+	MinLamportsPerSignature    uint64
+	MaxLamportsPerSignature    uint64
+	BurnPercent                uint8
+}
+
+// RentCollector is the serialized rent collector of a bank.
+//
+// Based on solana_sdk::rent_collector::RentCollector.
+type RentCollector struct {
+	Epoch         uint64
+	EpochSchedule sealevel.SysvarEpochSchedule
+	SlotsPerYear  float64
+	Rent          sealevel.SysvarRent
+}
+
+// Inflation is the serialized inflation schedule of a bank.
+//
+// Based on solana_sdk::inflation::Inflation.
+type Inflation struct {
+	Initial        float64
+	Terminal       float64
+	Taper          float64
+	Foundation     float64
+	FoundationTerm float64
+	Unused         float64
+}
+
+// Stakes is the serialized stake and vote accounts cache of a bank.
+//
+// Based on solana_runtime::stakes::Stakes.
+type Stakes struct {
+	VoteAccounts     []VoteAccount
+	StakeDelegations []StakeDelegation
+	Unused           uint64
+	Epoch            uint64
+	StakeHistory     []StakeHistoryEntry
+}
+
+// VoteAccount is a vote account, with the stake delegated to it.
+type VoteAccount struct {
+	Pubkey  [32]byte
+	Stake   uint64
+	Account accounts.Account
+}
+
+// StakeDelegation is the delegation of a stake account. CreditsObserved is
+// only serialized in versioned epoch stakes.
+//
+// Based on solana_sdk::stake::state::Delegation.
+type StakeDelegation struct {
+	Pubkey             [32]byte
+	VoterPubkey        [32]byte
+	Stake              uint64
+	ActivationEpoch    uint64
+	DeactivationEpoch  uint64
+	WarmupCooldownRate float64
+	CreditsObserved    uint64
+}
+
+// StakeHistoryEntry is the stake of the cluster at the end of an epoch.
+type StakeHistoryEntry struct {
+	Epoch        uint64
+	Effective    uint64
+	Activating   uint64
+	Deactivating uint64
+}
+
+// UnusedAccounts is a deprecated field of the bank, kept for compatibility.
+type UnusedAccounts struct {
+	Unused1 [][32]byte
+	Unused2 [][32]byte
+	Unused3 []PubkeyAndU64
+}
+
+// PubkeyAndU64 is an entry of a map from pubkey to u64.
+type PubkeyAndU64 struct {
+	Pubkey [32]byte
+	Value  uint64
+}
+
+// EpochStakes is the stake of the cluster for an epoch, which is used for
+// the leader schedule and vote tallies of the epoch. The versioned epoch
+// stakes serialized after the fields of the accounts database also hold the
+// credits observed by the stake delegations.
+//
+// Based on solana_runtime::epoch_stakes::EpochStakes and
+// solana_runtime::epoch_stakes::VersionedEpochStakes.
+type EpochStakes struct {
+	Epoch                 uint64
+	Stakes                Stakes
+	TotalStake            uint64
+	NodeIdToVoteAccounts  []NodeVoteAccounts
+	EpochAuthorizedVoters []PubkeyAndPubkey
+}
+
+// NodeVoteAccounts are the vote accounts of a validator node.
+type NodeVoteAccounts struct {
+	NodeId       [32]byte
+	VoteAccounts [][32]byte
+	TotalStake   uint64
+}
+
+// PubkeyAndPubkey is an entry of a map from pubkey to pubkey.
+type PubkeyAndPubkey struct {
+	Key   [32]byte
+	Value [32]byte
+}
+
+// AccountsDbFields lists the account storages of the snapshot.
+//
+// Based on solana_runtime::serde_snapshot::AccountsDbFields.
+type AccountsDbFields struct {
+	Storages                []SlotStorages
+	WriteVersion            uint64
+	Slot                    uint64
+	BankHashInfo            BankHashInfo
+	HistoricalRoots         []uint64
+	HistoricalRootsWithHash []SlotAndHash
+}
+
+// SlotStorages are the account storages of a slot.
+type SlotStorages struct {
+	Slot     uint64
+	Storages []StorageEntry
+}
+
+// StorageEntry is an account storage, at accounts/<slot>.<id> in the
+// snapshot archive, of which the first AccountsCurrentLen bytes are valid.
+//
+// Based on solana_runtime::serde_snapshot::SerializableAccountStorageEntry.
+type StorageEntry struct {
+	Id                 uint64
+	AccountsCurrentLen uint64
+}
+
+// BankHashInfo holds the hashes of the accounts of the snapshot slot.
+type BankHashInfo struct {
+	AccountsDeltaHash [32]byte
+	AccountsHash      [32]byte
+	Stats             BankHashStats
+}
+
+// BankHashStats are statistics about the accounts of the snapshot slot.
+type BankHashStats struct {
+	NumUpdatedAccounts    uint64
+	NumRemovedAccounts    uint64
+	NumLamportsStored     uint64
+	TotalDataLen          uint64
+	NumExecutableAccounts uint64
+}
+
+// SlotAndHash is an entry of the historical roots with hash.
+type SlotAndHash struct {
+	Slot uint64
+	Hash [32]byte
+}
+
+// IncrementalSnapshotPersistence links an incremental snapshot to its full
+// snapshot.
+//
+// Based on solana_accounts_db::accounts_hash::IncrementalSnapshotPersistence.
+type IncrementalSnapshotPersistence struct {
+	FullSlot                  uint64
+	FullHash                  [32]byte
+	FullCapitalization        uint64
+	IncrementalHash           [32]byte
+	IncrementalCapitalization uint64
+}
+
+// UnmarshalManifest decodes the bincode encoding of a snapshot manifest.
+func UnmarshalManifest(data []byte) (*Manifest, error) {
+	r := newReader(data)
+	m := &Manifest{}
+	m.Bank.read(r)
+	m.AccountsDb.read(r)
+	if !r.atEOF() {
+		m.LamportsPerSignature = r.u64("lamports_per_signature")
+	}
+	if !r.atEOF() && r.option("incremental_snapshot_persistence") {
+		p := &IncrementalSnapshotPersistence{}
+		p.FullSlot = r.u64("full_slot")
+		p.FullHash = r.hash("full_hash")
+		p.FullCapitalization = r.u64("full_capitalization")
+		p.IncrementalHash = r.hash("incremental_hash")
+		p.IncrementalCapitalization = r.u64("incremental_capitalization")
+		m.IncrementalSnapshotPersistence = p
+	}
+	if !r.atEOF() && r.option("epoch_accounts_hash") {
+		hash := r.hash("epoch_accounts_hash")
+		m.EpochAccountsHash = &hash
+	}
+	if !r.atEOF() {
+		m.VersionedEpochStakes = make([]EpochStakes, r.length("versioned_epoch_stakes"))
+		for i := range m.VersionedEpochStakes {
+			epoch := r.u64("versioned_epoch_stakes")
+			if variant := r.u32("versioned_epoch_stakes"); r.err == nil && variant != 0 {
+				return nil, fmt.Errorf("unknown versioned epoch stakes variant %d", variant)
+			}
+			m.VersionedEpochStakes[i].read(r, epoch, true)
+		}
+	}
+	if !r.atEOF() && r.option("accounts_lt_hash") {
+		var ltHash accounts.LtHash
+		for i := range ltHash {
+			ltHash[i] = uint16(r.u8("accounts_lt_hash")) | uint16(r.u8("accounts_lt_hash"))<<8
+		}
+		m.AccountsLtHash = &ltHash
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return m, nil
+}
+
+// Marshal returns the bincode encoding of the manifest. Optional trailing
+// fields are written up to the last one that is set.
+func (m *Manifest) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	m.Bank.write(w)
+	m.AccountsDb.write(w)
+
+	last := 0
+	switch {
+	case m.AccountsLtHash != nil:
+		last = 5
+	case m.VersionedEpochStakes != nil:
+		last = 4
+	case m.EpochAccountsHash != nil:
+		last = 3
+	case m.IncrementalSnapshotPersistence != nil:
+		last = 2
+	case m.LamportsPerSignature != 0:
+		last = 1
+	}
+	if last >= 1 {
+		w.u64(m.LamportsPerSignature)
+	}
+	if last >= 2 {
+		w.option(m.IncrementalSnapshotPersistence != nil)
+		if p := m.IncrementalSnapshotPersistence; p != nil {
+			w.u64(p.FullSlot)
+			w.hash(p.FullHash)
+			w.u64(p.FullCapitalization)
+			w.hash(p.IncrementalHash)
+			w.u64(p.IncrementalCapitalization)
+		}
+	}
+	if last >= 3 {
+		w.option(m.EpochAccountsHash != nil)
+		if m.EpochAccountsHash != nil {
+			w.hash(*m.EpochAccountsHash)
+		}
+	}
+	if last >= 4 {
+		w.u64(uint64(len(m.VersionedEpochStakes)))
+		for i := range m.VersionedEpochStakes {
+			w.u64(m.VersionedEpochStakes[i].Epoch)
+			w.u32(0)
+			m.VersionedEpochStakes[i].write(w, true)
+		}
+	}
+	if last >= 5 {
+		w.option(true)
+		for _, v := range m.AccountsLtHash {
+			w.u8(uint8(v))
+			w.u8(uint8(v >> 8))
+		}
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	return buf.Bytes(), nil
+}
+
+func (b *BankFields) read(r *reader) {
+	b.BlockhashQueue.read(r)
+	b.Ancestors = readSlotAndIndexes(r, "ancestors")
+	b.Hash = r.hash("hash")
+	b.ParentHash = r.hash("parent_hash")
+	b.ParentSlot = r.u64("parent_slot")
+	b.HardForks = readSlotAndIndexes(r, "hard_forks")
+	b.TransactionCount = r.u64("transaction_count")
+	b.TickHeight = r.u64("tick_height")
+	b.SignatureCount = r.u64("signature_count")
+	b.Capitalization = r.u64("capitalization")
+	b.MaxTickHeight = r.u64("max_tick_height")
+	if r.option("hashes_per_tick") {
+		hashesPerTick := r.u64("hashes_per_tick")
+		b.HashesPerTick = &hashesPerTick
+	}
+	b.TicksPerSlot = r.u64("ticks_per_slot")
+	b.NsPerSlot = r.u128("ns_per_slot")
+	b.GenesisCreationTime = r.i64("genesis_creation_time")
+	b.SlotsPerYear = r.f64("slots_per_year")
+	b.AccountsDataLen = r.u64("accounts_data_len")
+	b.Slot = r.u64("slot")
+	b.Epoch = r.u64("epoch")
+	b.BlockHeight = r.u64("block_height")
+	b.CollectorId = r.hash("collector_id")
+	b.CollectorFees = r.u64("collector_fees")
+	b.FeeCalculator = r.u64("fee_calculator")
+	b.FeeRateGovernor.TargetLamportsPerSignature = r.u64("fee_rate_governor")
+	b.FeeRateGovernor.TargetSignaturesPerSlot = r.u64("fee_rate_governor")
+	b.FeeRateGovernor.MinLamportsPerSignature = r.u64("fee_rate_governor")
+	b.FeeRateGovernor.MaxLamportsPerSignature = r.u64("fee_rate_governor")
+	b.FeeRateGovernor.BurnPercent = r.u8("fee_rate_governor")
+	b.CollectedRent = r.u64("collected_rent")
+	b.RentCollector.Epoch = r.u64("rent_collector")
+	b.RentCollector.EpochSchedule = readEpochSchedule(r)
+	b.RentCollector.SlotsPerYear = r.f64("rent_collector")
+	b.RentCollector.Rent.LamportsPerUint8Year = r.u64("rent")
+	b.RentCollector.Rent.ExemptionThreshold = r.f64("rent")
+	b.RentCollector.Rent.BurnPercent = r.u8("rent")
+	b.EpochSchedule = readEpochSchedule(r)
+	b.Inflation.Initial = r.f64("inflation")
+	b.Inflation.Terminal = r.f64("inflation")
+	b.Inflation.Taper = r.f64("inflation")
+	b.Inflation.Foundation = r.f64("inflation")
+	b.Inflation.FoundationTerm = r.f64("inflation")
+	b.Inflation.Unused = r.f64("inflation")
+	b.Stakes.read(r, false)
+	b.UnusedAccounts.Unused1 = readPubkeys(r, "unused_accounts")
+	b.UnusedAccounts.Unused2 = readPubkeys(r, "unused_accounts")
+	b.UnusedAccounts.Unused3 = make([]PubkeyAndU64, r.length("unused_accounts"))
+	for i := range b.UnusedAccounts.Unused3 {
+		b.UnusedAccounts.Unused3[i].Pubkey = r.hash("unused_accounts")
+		b.UnusedAccounts.Unused3[i].Value = r.u64("unused_accounts")
+	}
+	b.EpochStakes = make([]EpochStakes, r.length("epoch_stakes"))
+	for i := range b.EpochStakes {
+		b.EpochStakes[i].read(r, r.u64("epoch_stakes"), false)
+	}
+	b.IsDelta = r.boolean("is_delta")
+}
+
+func (b *BankFields) write(w *writer) {
+	b.BlockhashQueue.write(w)
+	writeSlotAndIndexes(w, b.Ancestors)
+	w.hash(b.Hash)
+	w.hash(b.ParentHash)
+	w.u64(b.ParentSlot)
+	writeSlotAndIndexes(w, b.HardForks)
+	w.u64(b.TransactionCount)
+	w.u64(b.TickHeight)
+	w.u64(b.SignatureCount)
+	w.u64(b.Capitalization)
+	w.u64(b.MaxTickHeight)
+	w.option(b.HashesPerTick != nil)
+	if b.HashesPerTick != nil {
+		w.u64(*b.HashesPerTick)
+	}
+	w.u64(b.TicksPerSlot)
+	w.u128(b.NsPerSlot)
+	w.i64(b.GenesisCreationTime)
+	w.f64(b.SlotsPerYear)
+	w.u64(b.AccountsDataLen)
+	w.u64(b.Slot)
+	w.u64(b.Epoch)
+	w.u64(b.BlockHeight)
+	w.hash(b.CollectorId)
+	w.u64(b.CollectorFees)
+	w.u64(b.FeeCalculator)
+	w.u64(b.FeeRateGovernor.TargetLamportsPerSignature)
+	w.u64(b.FeeRateGovernor.TargetSignaturesPerSlot)
+	w.u64(b.FeeRateGovernor.MinLamportsPerSignature)
+	w.u64(b.FeeRateGovernor.MaxLamportsPerSignature)
+	w.u8(b.FeeRateGovernor.BurnPercent)
+	w.u64(b.CollectedRent)
+	w.u64(b.RentCollector.Epoch)
+	writeEpochSchedule(w, &b.RentCollector.EpochSchedule)
+	w.f64(b.RentCollector.SlotsPerYear)
+	w.u64(b.RentCollector.Rent.LamportsPerUint8Year)
+	w.f64(b.RentCollector.Rent.ExemptionThreshold)
+	w.u8(b.RentCollector.Rent.BurnPercent)
+	writeEpochSchedule(w, &b.EpochSchedule)
+	w.f64(b.Inflation.Initial)
+	w.f64(b.Inflation.Terminal)
+	w.f64(b.Inflation.Taper)
+	w.f64(b.Inflation.Foundation)
+	w.f64(b.Inflation.FoundationTerm)
+	w.f64(b.Inflation.Unused)
+	b.Stakes.write(w, false)
+	writePubkeys(w, b.UnusedAccounts.Unused1)
+	writePubkeys(w, b.UnusedAccounts.Unused2)
+	w.u64(uint64(len(b.UnusedAccounts.Unused3)))
+	for _, entry := range b.UnusedAccounts.Unused3 {
+		w.hash(entry.Pubkey)
+		w.u64(entry.Value)
+	}
+	w.u64(uint64(len(b.EpochStakes)))
+	for i := range b.EpochStakes {
+		w.u64(b.EpochStakes[i].Epoch)
+		b.EpochStakes[i].write(w, false)
+	}
+	w.boolean(b.IsDelta)
+}
+
+func (q *BlockhashQueue) read(r *reader) {
+	q.LastHashIndex = r.u64("blockhash_queue")
+	if r.option("blockhash_queue") {
+		hash := r.hash("blockhash_queue")
+		q.LastHash = &hash
+	}
+	q.Hashes = make([]BlockhashQueueEntry, r.length("blockhash_queue"))
+	for i := range q.Hashes {
+		q.Hashes[i].Hash = r.hash("blockhash_queue")
+		q.Hashes[i].LamportsPerSignature = r.u64("blockhash_queue")
+		q.Hashes[i].HashIndex = r.u64("blockhash_queue")
+		q.Hashes[i].Timestamp = r.u64("blockhash_queue")
+	}
+	q.MaxAge = r.u64("blockhash_queue")
+}
+
+func (q *BlockhashQueue) write(w *writer) {
+	w.u64(q.LastHashIndex)
+	w.option(q.LastHash != nil)
+	if q.LastHash != nil {
+		w.hash(*q.LastHash)
+	}
+	w.u64(uint64(len(q.Hashes)))
+	for _, entry := range q.Hashes {
+		w.hash(entry.Hash)
+		w.u64(entry.LamportsPerSignature)
+		w.u64(entry.HashIndex)
+		w.u64(entry.Timestamp)
+	}
+	w.u64(q.MaxAge)
+}
+
+func (s *Stakes) read(r *reader, withCredits bool) {
+	s.VoteAccounts = make([]VoteAccount, r.length("vote_accounts"))
+	for i := range s.VoteAccounts {
+		v := &s.VoteAccounts[i]
+		v.Pubkey = r.hash("vote_accounts")
+		v.Stake = r.u64("vote_accounts")
+		v.Account.Lamports = r.u64("vote_accounts")
+		v.Account.Data = r.bytes("vote_accounts")
+		v.Account.Owner = r.hash("vote_accounts")
+		v.Account.Executable = r.boolean("vote_accounts")
+		v.Account.RentEpoch = r.u64("vote_accounts")
+	}
+	s.StakeDelegations = make([]StakeDelegation, r.length("stake_delegations"))
+	for i := range s.StakeDelegations {
+		d := &s.StakeDelegations[i]
+		d.Pubkey = r.hash("stake_delegations")
+		d.VoterPubkey = r.hash("stake_delegations")
+		d.Stake = r.u64("stake_delegations")
+		d.ActivationEpoch = r.u64("stake_delegations")
+		d.DeactivationEpoch = r.u64("stake_delegations")
+		d.WarmupCooldownRate = r.f64("stake_delegations")
+		if withCredits {
+			d.CreditsObserved = r.u64("stake_delegations")
+		}
+	}
+	s.Unused = r.u64("stakes")
+	s.Epoch = r.u64("stakes")
+	s.StakeHistory = make([]StakeHistoryEntry, r.length("stake_history"))
+	for i := range s.StakeHistory {
+		s.StakeHistory[i].Epoch = r.u64("stake_history")
+		s.StakeHistory[i].Effective = r.u64("stake_history")
+		s.StakeHistory[i].Activating = r.u64("stake_history")
+		s.StakeHistory[i].Deactivating = r.u64("stake_history")
+	}
+}
+
+func (s *Stakes) write(w *writer, withCredits bool) {
+	w.u64(uint64(len(s.VoteAccounts)))
+	for _, v := range s.VoteAccounts {
+		w.hash(v.Pubkey)
+		w.u64(v.Stake)
+		w.u64(v.Account.Lamports)
+		w.bytes(v.Account.Data)
+		w.hash(v.Account.Owner)
+		w.boolean(v.Account.Executable)
+		w.u64(v.Account.RentEpoch)
+	}
+	w.u64(uint64(len(s.StakeDelegations)))
+	for _, d := range s.StakeDelegations {
+		w.hash(d.Pubkey)
+		w.hash(d.VoterPubkey)
+		w.u64(d.Stake)
+		w.u64(d.ActivationEpoch)
+		w.u64(d.DeactivationEpoch)
+		w.f64(d.WarmupCooldownRate)
+		if withCredits {
+			w.u64(d.CreditsObserved)
+		}
+	}
+	w.u64(s.Unused)
+	w.u64(s.Epoch)
+	w.u64(uint64(len(s.StakeHistory)))
+	for _, entry := range s.StakeHistory {
+		w.u64(entry.Epoch)
+		w.u64(entry.Effective)
+		w.u64(entry.Activating)
+		w.u64(entry.Deactivating)
+	}
+}
+
+func (e *EpochStakes) read(r *reader, epoch uint64, withCredits bool) {
+	e.Epoch = epoch
+	e.Stakes.read(r, withCredits)
+	e.TotalStake = r.u64("epoch_stakes")
+	e.NodeIdToVoteAccounts = make([]NodeVoteAccounts, r.length("node_id_to_vote_accounts"))
+	for i := range e.NodeIdToVoteAccounts {
+		n := &e.NodeIdToVoteAccounts[i]
+		n.NodeId = r.hash("node_id_to_vote_accounts")
+		n.VoteAccounts = readPubkeys(r, "node_id_to_vote_accounts")
+		n.TotalStake = r.u64("node_id_to_vote_accounts")
+	}
+	e.EpochAuthorizedVoters = make([]PubkeyAndPubkey, r.length("epoch_authorized_voters"))
+	for i := range e.EpochAuthorizedVoters {
+		e.EpochAuthorizedVoters[i].Key = r.hash("epoch_authorized_voters")
+		e.EpochAuthorizedVoters[i].Value = r.hash("epoch_authorized_voters")
+	}
+}
+
+func (e *EpochStakes) write(w *writer, withCredits bool) {
+	e.Stakes.write(w, withCredits)
+	w.u64(e.TotalStake)
+	w.u64(uint64(len(e.NodeIdToVoteAccounts)))
+	for _, n := range e.NodeIdToVoteAccounts {
+		w.hash(n.NodeId)
+		writePubkeys(w, n.VoteAccounts)
+		w.u64(n.TotalStake)
+	}
+	w.u64(uint64(len(e.EpochAuthorizedVoters)))
+	for _, entry := range e.EpochAuthorizedVoters {
+		w.hash(entry.Key)
+		w.hash(entry.Value)
+	}
+}
+
+func (a *AccountsDbFields) read(r *reader) {
+	a.Storages = make([]SlotStorages, r.length("storages"))
+	for i := range a.Storages {
+		a.Storages[i].Slot = r.u64("storages")
+		a.Storages[i].Storages = make([]StorageEntry, r.length("storages"))
+		for j := range a.Storages[i].Storages {
+			a.Storages[i].Storages[j].Id = r.u64("storages")
+			a.Storages[i].Storages[j].AccountsCurrentLen = r.u64("storages")
+		}
+	}
+	a.WriteVersion = r.u64("write_version")
+	a.Slot = r.u64("accounts_db_slot")
+	a.BankHashInfo.AccountsDeltaHash = r.hash("bank_hash_info")
+	a.BankHashInfo.AccountsHash = r.hash("bank_hash_info")
+	a.BankHashInfo.Stats.NumUpdatedAccounts = r.u64("bank_hash_info")
+	a.BankHashInfo.Stats.NumRemovedAccounts = r.u64("bank_hash_info")
+	a.BankHashInfo.Stats.NumLamportsStored = r.u64("bank_hash_info")
+	a.BankHashInfo.Stats.TotalDataLen = r.u64("bank_hash_info")
+	a.BankHashInfo.Stats.NumExecutableAccounts = r.u64("bank_hash_info")
+	if r.atEOF() {
+		return
+	}
+	a.HistoricalRoots = make([]uint64, r.length("historical_roots"))
+	for i := range a.HistoricalRoots {
+		a.HistoricalRoots[i] = r.u64("historical_roots")
+	}
+	if r.atEOF() {
+		return
+	}
+	a.HistoricalRootsWithHash = make([]SlotAndHash, r.length("historical_roots_with_hash"))
+	for i := range a.HistoricalRootsWithHash {
+		a.HistoricalRootsWithHash[i].Slot = r.u64("historical_roots_with_hash")
+		a.HistoricalRootsWithHash[i].Hash = r.hash("historical_roots_with_hash")
+	}
+}
+
+func (a *AccountsDbFields) write(w *writer) {
+	w.u64(uint64(len(a.Storages)))
+	for _, slot := range a.Storages {
+		w.u64(slot.Slot)
+		w.u64(uint64(len(slot.Storages)))
+		for _, entry := range slot.Storages {
+			w.u64(entry.Id)
+			w.u64(entry.AccountsCurrentLen)
+		}
+	}
+	w.u64(a.WriteVersion)
+	w.u64(a.Slot)
+	w.hash(a.BankHashInfo.AccountsDeltaHash)
+	w.hash(a.BankHashInfo.AccountsHash)
+	w.u64(a.BankHashInfo.Stats.NumUpdatedAccounts)
+	w.u64(a.BankHashInfo.Stats.NumRemovedAccounts)
+	w.u64(a.BankHashInfo.Stats.NumLamportsStored)
+	w.u64(a.BankHashInfo.Stats.TotalDataLen)
+	w.u64(a.BankHashInfo.Stats.NumExecutableAccounts)
+	w.u64(uint64(len(a.HistoricalRoots)))
+	for _, slot := range a.HistoricalRoots {
+		w.u64(slot)
+	}
+	w.u64(uint64(len(a.HistoricalRootsWithHash)))
+	for _, entry := range a.HistoricalRootsWithHash {
+		w.u64(entry.Slot)
+		w.hash(entry.Hash)
+	}
+}
+
+func readEpochSchedule(r *reader) (s sealevel.SysvarEpochSchedule) {
+	s.SlotsPerEpoch = r.u64("epoch_schedule")
+	s.LeaderScheduleSlotOffset = r.u64("epoch_schedule")
+	s.Warmup = r.boolean("epoch_schedule")
+	s.FirstNormalEpoch = r.u64("epoch_schedule")
+	s.FirstNormalSlot = r.u64("epoch_schedule")
+	return
+}
+
+func writeEpochSchedule(w *writer, s *sealevel.SysvarEpochSchedule) {
+	w.u64(s.SlotsPerEpoch)
+	w.u64(s.LeaderScheduleSlotOffset)
+	w.boolean(s.Warmup)
+	w.u64(s.FirstNormalEpoch)
+	w.u64(s.FirstNormalSlot)
+}
+
+func readSlotAndIndexes(r *reader, what string) []SlotAndIndex {
+	entries := make([]SlotAndIndex, r.length(what))
+	for i := range entries {
+		entries[i].Slot = r.u64(what)
+		entries[i].Index = r.u64(what)
+	}
+	return entries
+}
+
+func writeSlotAndIndexes(w *writer, entries []SlotAndIndex) {
+	w.u64(uint64(len(entries)))
+	for _, entry := range entries {
+		w.u64(entry.Slot)
+		w.u64(entry.Index)
+	}
+}
+
+func readPubkeys(r *reader, what string) [][32]byte {
+	pubkeys := make([][32]byte, r.length(what))
+	for i := range pubkeys {
+		pubkeys[i] = r.hash(what)
+	}
+	return pubkeys
+}
+
+func writePubkeys(w *writer, pubkeys [][32]byte) {
+	w.u64(uint64(len(pubkeys)))
+	for _, pubkey := range pubkeys {
+		w.hash(pubkey)
+	}
+}
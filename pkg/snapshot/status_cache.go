@@ -0,0 +1,95 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"go.firedancer.io/radiance/pkg/bank"
+)
+
+// statusCachePath is the path of the status cache in snapshot archives.
+const statusCachePath = "snapshots/status_cache"
+
+// UnmarshalStatusCache decodes the bincode-encoded status cache of a
+// snapshot, a Vec<BankSlotDelta>.
+//
+// Based on solana_runtime::snapshot_bank_utils::deserialize_status_cache.
+func UnmarshalStatusCache(data []byte) ([]bank.SlotDelta, error) {
+	r := newReader(data)
+	deltas := make([]bank.SlotDelta, r.length("slot deltas"))
+	for i := range deltas {
+		delta := &deltas[i]
+		delta.Slot = r.u64("slot")
+		delta.IsRoot = r.boolean("is root")
+		numHashes := r.length("statuses")
+		delta.Statuses = make(map[[32]byte]*bank.BlockhashStatuses, numHashes)
+		for j := 0; j < numHashes && r.err == nil; j++ {
+			blockhash := r.hash("blockhash")
+			statuses := &bank.BlockhashStatuses{KeyIndex: int(r.u64("key index"))}
+			if r.err == nil && statuses.KeyIndex > 64-bank.CachedKeySize {
+				r.setErr(fmt.Errorf("invalid key index %d", statuses.KeyIndex), "key index")
+			}
+			statuses.Statuses = make([]bank.KeyStatus, r.length("key statuses"))
+			for k := range statuses.Statuses {
+				if r.err != nil {
+					break
+				}
+				key, err := r.dec.ReadNBytes(bank.CachedKeySize)
+				r.setErr(err, "key")
+				copy(statuses.Statuses[k].Key[:], key)
+				statuses.Statuses[k].Err = r.txResult("status")
+			}
+			delta.Statuses[blockhash] = statuses
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if !r.atEOF() {
+		return nil, fmt.Errorf("%d trailing bytes after status cache", r.dec.Remaining())
+	}
+	return deltas, nil
+}
+
+// MarshalStatusCache encodes the slot deltas of a status cache, as written
+// to snapshots.
+//
+// Based on solana_runtime::snapshot_bank_utils::serialize_status_cache.
+func MarshalStatusCache(deltas []bank.SlotDelta) ([]byte, error) {
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	w.u64(uint64(len(deltas)))
+	for _, delta := range deltas {
+		w.u64(delta.Slot)
+		w.boolean(delta.IsRoot)
+		w.u64(uint64(len(delta.Statuses)))
+		// sorted, so that the encoding is deterministic
+		blockhashes := make([][32]byte, 0, len(delta.Statuses))
+		for blockhash := range delta.Statuses {
+			blockhashes = append(blockhashes, blockhash)
+		}
+		sort.Slice(blockhashes, func(i, j int) bool {
+			return bytes.Compare(blockhashes[i][:], blockhashes[j][:]) < 0
+		})
+		for _, blockhash := range blockhashes {
+			statuses := delta.Statuses[blockhash]
+			w.hash(blockhash)
+			w.u64(uint64(statuses.KeyIndex))
+			w.u64(uint64(len(statuses.Statuses)))
+			for _, status := range statuses.Statuses {
+				if w.err == nil {
+					w.err = w.enc.WriteBytes(status.Key[:], false)
+				}
+				w.txResult(status.Err)
+			}
+		}
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	return buf.Bytes(), nil
+}
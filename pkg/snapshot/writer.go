@@ -0,0 +1,389 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/klauspost/compress/zstd"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/appendvec"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// Version is the snapshot version written to the version file of snapshot
+// archives.
+const Version = "1.2.0"
+
+// DefaultStorageSize is the capacity of the append vecs that accounts are
+// packed into by PackAccounts.
+const DefaultStorageSize = 4 << 20
+
+// Storage is an account storage written to a snapshot archive, at
+// accounts/<slot>.<id>.
+type Storage struct {
+	Slot uint64
+	Id   uint64
+	Vec  *appendvec.AppendVec
+}
+
+// PackAccounts packs the given accounts into append vecs of the given
+// slot, with ids starting at 0, in pubkey order. Zero-lamport accounts are
+// deleted, and left out. It returns the storages and the total lamports of
+// the accounts, which is the capitalization of the bank.
+func PackAccounts(slot uint64, accts map[[32]byte]*accounts.Account, storageSize uint64) ([]Storage, uint64, error) {
+	pubkeys := make([][32]byte, 0, len(accts))
+	for pubkey, acct := range accts {
+		if acct != nil && acct.Lamports != 0 {
+			pubkeys = append(pubkeys, pubkey)
+		}
+	}
+	sort.Slice(pubkeys, func(i, j int) bool {
+		return bytes.Compare(pubkeys[i][:], pubkeys[j][:]) < 0
+	})
+
+	var storages []Storage
+	var capitalization uint64
+	for _, pubkey := range pubkeys {
+		acct := accts[pubkey]
+		meta := &appendvec.StoredAccountMeta{
+			Pubkey:     pubkey,
+			Lamports:   acct.Lamports,
+			RentEpoch:  acct.RentEpoch,
+			Owner:      acct.Owner,
+			Executable: acct.Executable,
+			Hash:       acct.Hash(pubkey),
+			Data:       acct.Data,
+		}
+		var err error
+		if len(storages) != 0 {
+			_, err = storages[len(storages)-1].Vec.Append(meta)
+		}
+		if len(storages) == 0 || err == appendvec.ErrFull {
+			size := storageSize
+			if meta.StoredSize() > size {
+				size = meta.StoredSize()
+			}
+			storages = append(storages, Storage{Slot: slot, Id: uint64(len(storages)), Vec: appendvec.New(size)})
+			_, err = storages[len(storages)-1].Vec.Append(meta)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		capitalization += acct.Lamports
+	}
+	return storages, capitalization, nil
+}
+
+// NewManifest returns the manifest of a snapshot of a frozen bank, which
+// descends from the bank of the base manifest, with the given
+// capitalization. The stakes are those of the stakes cache of the bank, and
+// the epoch stakes it captured are added to those of the base manifest. The
+// fields the bank does not track, such as inflation, are carried over from
+// the base manifest. The status cache holds the statuses of the rooted
+// slots and of the ancestors of the bank.
+//
+// Based on solana_runtime::bank::Bank::get_fields_to_serialize.
+func NewManifest(b *bank.Bank, base *Manifest, capitalization uint64) (*Manifest, error) {
+	hash, ok := b.Hash()
+	if !ok {
+		return nil, fmt.Errorf("bank of slot %d is not frozen", b.Slot)
+	}
+	accountsDeltaHash, _ := b.AccountsDeltaHash()
+
+	m := *base
+	fields := &m.Bank
+	lastHash, ok := b.BlockhashQueue.LastHash()
+	fields.BlockhashQueue = BlockhashQueue{
+		LastHashIndex: b.BlockhashQueue.LastHashIndex(),
+		MaxAge:        b.BlockhashQueue.MaxAge(),
+	}
+	if ok {
+		fields.BlockhashQueue.LastHash = &lastHash
+	}
+	for blockhash, info := range b.BlockhashQueue.Hashes() {
+		fields.BlockhashQueue.Hashes = append(fields.BlockhashQueue.Hashes, BlockhashQueueEntry{
+			Hash:                 blockhash,
+			LamportsPerSignature: info.LamportsPerSignature,
+			HashIndex:            info.HashIndex,
+		})
+	}
+	sort.Slice(fields.BlockhashQueue.Hashes, func(i, j int) bool {
+		return fields.BlockhashQueue.Hashes[i].HashIndex < fields.BlockhashQueue.Hashes[j].HashIndex
+	})
+
+	fields.Ancestors = []SlotAndIndex{{Slot: b.Slot}}
+	fields.Hash = hash
+	fields.ParentHash = b.ParentHash
+	fields.ParentSlot = b.ParentSlot
+	fields.HardForks = nil
+	for _, fork := range b.HardForks.Forks() {
+		fields.HardForks = append(fields.HardForks, SlotAndIndex{Slot: fork.Slot, Index: fork.Count})
+	}
+	fields.TransactionCount = b.TransactionCount
+	fields.TickHeight = (b.Slot + 1) * b.TicksPerSlot
+	fields.MaxTickHeight = (b.Slot + 1) * b.TicksPerSlot
+	fields.SignatureCount = b.SignatureCount
+	fields.Capitalization = capitalization
+	fields.TicksPerSlot = b.TicksPerSlot
+	fields.SlotsPerYear = b.SlotsPerYear
+	fields.Slot = b.Slot
+	fields.Epoch = b.Epoch
+	fields.BlockHeight = b.BlockHeight
+	fields.CollectorId = b.CollectorId
+	fields.CollectorFees = b.CollectorFeeDetails.TransactionFee + b.CollectorFeeDetails.PrioritizationFee
+	fields.FeeCalculator = b.LamportsPerSignature
+	fields.FeeRateGovernor.BurnPercent = b.FeeBurnPercent
+	fields.EpochSchedule = b.EpochSchedule
+	fields.IsDelta = false
+	newRateActivationEpoch := b.NewWarmupCooldownRateEpoch()
+	fields.Stakes = newStakes(b.Stakes.Epoch(), b.Stakes.VoteAccounts(), b.Stakes.VoteAccountStakes(newRateActivationEpoch),
+		b.Stakes.StakeDelegations(), b.Stakes.History())
+	leaderScheduleEpoch := b.EpochSchedule.GetLeaderScheduleEpoch(b.Slot)
+	m.VersionedEpochStakes = addEpochStakes(base.Bank.EpochStakes, base.VersionedEpochStakes, b.Stakes.EpochStakes(), leaderScheduleEpoch)
+	fields.EpochStakes = retainEpochStakes(base.Bank.EpochStakes, leaderScheduleEpoch)
+
+	m.AccountsDb = AccountsDbFields{
+		Slot: b.Slot,
+		// the accounts hash stored here is obsolete, as the snapshot hash
+		// names the archive instead
+		BankHashInfo:            BankHashInfo{AccountsDeltaHash: accountsDeltaHash},
+		HistoricalRoots:         base.AccountsDb.HistoricalRoots,
+		HistoricalRootsWithHash: base.AccountsDb.HistoricalRootsWithHash,
+	}
+	m.LamportsPerSignature = b.LamportsPerSignature
+	m.IncrementalSnapshotPersistence = nil
+	m.EpochAccountsHash = b.EpochAccountsHashToSerialize()
+	m.StatusCache = b.StatusCache.SlotDeltas(b.Ancestors())
+	m.AccountsLtHash = nil
+	if b.Features.IsActive(features.AccountsLtHash) {
+		ltHash := b.AccountsLtHash
+		m.AccountsLtHash = &ltHash
+	}
+	return &m, nil
+}
+
+// newStakes returns the serialized form of the stakes of the cluster,
+// ordered by pubkey.
+func newStakes(epoch uint64, voteAccounts map[[32]byte]*accounts.Account, voteAccountStakes map[[32]byte]uint64,
+	delegations map[[32]byte]sealevel.Stake, history sealevel.SysvarStakeHistory) Stakes {
+	stakes := Stakes{Epoch: epoch}
+	for pubkey, acct := range voteAccounts {
+		stakes.VoteAccounts = append(stakes.VoteAccounts, VoteAccount{
+			Pubkey:  pubkey,
+			Stake:   voteAccountStakes[pubkey],
+			Account: *acct,
+		})
+	}
+	sort.Slice(stakes.VoteAccounts, func(i, j int) bool {
+		return bytes.Compare(stakes.VoteAccounts[i].Pubkey[:], stakes.VoteAccounts[j].Pubkey[:]) < 0
+	})
+	for pubkey, stake := range delegations {
+		stakes.StakeDelegations = append(stakes.StakeDelegations, StakeDelegation{
+			Pubkey:             pubkey,
+			VoterPubkey:        stake.Delegation.VoterPubkey,
+			Stake:              stake.Delegation.StakeLamports,
+			ActivationEpoch:    stake.Delegation.ActivationEpoch,
+			DeactivationEpoch:  stake.Delegation.DeactivationEpoch,
+			WarmupCooldownRate: stake.Delegation.WarmupCooldownRate,
+			CreditsObserved:    stake.CreditsObserved,
+		})
+	}
+	sort.Slice(stakes.StakeDelegations, func(i, j int) bool {
+		return bytes.Compare(stakes.StakeDelegations[i].Pubkey[:], stakes.StakeDelegations[j].Pubkey[:]) < 0
+	})
+	for _, pair := range history {
+		stakes.StakeHistory = append(stakes.StakeHistory, StakeHistoryEntry{
+			Epoch:        pair.Epoch,
+			Effective:    pair.Entry.Effective,
+			Activating:   pair.Entry.Activating,
+			Deactivating: pair.Entry.Deactivating,
+		})
+	}
+	return stakes
+}
+
+// addEpochStakes returns the versioned epoch stakes of the base manifest,
+// with the epoch stakes captured by a bank for the leader schedule epochs
+// that the base manifest has none for, as per retainEpochStakes.
+//
+// Based on solana_runtime::bank::Bank::update_epoch_stakes.
+func addEpochStakes(epochStakes, versioned []EpochStakes, captured map[uint64]*bank.EpochStakes, leaderScheduleEpoch uint64) []EpochStakes {
+	known := make(map[uint64]bool)
+	for _, es := range epochStakes {
+		known[es.Epoch] = true
+	}
+	for _, es := range versioned {
+		known[es.Epoch] = true
+	}
+	result := append([]EpochStakes{}, versioned...)
+	for epoch, stakes := range captured {
+		if !known[epoch] {
+			result = append(result, newEpochStakes(epoch, stakes))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Epoch < result[j].Epoch
+	})
+	return retainEpochStakes(result, leaderScheduleEpoch)
+}
+
+// retainEpochStakes returns the epoch stakes of the epochs at most
+// bank.MaxLeaderScheduleStakes before the leader schedule epoch.
+func retainEpochStakes(epochStakes []EpochStakes, leaderScheduleEpoch uint64) []EpochStakes {
+	retained := make([]EpochStakes, 0, len(epochStakes))
+	for _, es := range epochStakes {
+		if es.Epoch+bank.MaxLeaderScheduleStakes >= leaderScheduleEpoch {
+			retained = append(retained, es)
+		}
+	}
+	return retained
+}
+
+// newEpochStakes returns the serialized form of the epoch stakes of a
+// leader schedule epoch: the stakes, and the stake and the authorized voter
+// of each vote account with stake, by node.
+//
+// Based on solana_runtime::epoch_stakes::EpochStakes::new.
+func newEpochStakes(leaderScheduleEpoch uint64, stakes *bank.EpochStakes) EpochStakes {
+	es := EpochStakes{
+		Epoch:  leaderScheduleEpoch,
+		Stakes: newStakes(stakes.Epoch, stakes.VoteAccounts, stakes.VoteAccountStakes, stakes.StakeDelegations, stakes.History),
+	}
+	nodes := make(map[[32]byte]int)
+	for _, voteAccount := range es.Stakes.VoteAccounts {
+		es.TotalStake += voteAccount.Stake
+		if voteAccount.Stake == 0 {
+			continue
+		}
+		var versions sealevel.VoteStateVersions
+		if err := versions.UnmarshalWithDecoder(bin.NewBinDecoder(voteAccount.Account.Data)); err != nil {
+			continue
+		}
+		voteState := versions.ConvertToCurrent()
+		if voter, ok := authorizedVoter(voteState, leaderScheduleEpoch); ok {
+			es.EpochAuthorizedVoters = append(es.EpochAuthorizedVoters, PubkeyAndPubkey{Key: voteAccount.Pubkey, Value: voter})
+		}
+		i, ok := nodes[voteState.NodePubkey]
+		if !ok {
+			i = len(es.NodeIdToVoteAccounts)
+			nodes[voteState.NodePubkey] = i
+			es.NodeIdToVoteAccounts = append(es.NodeIdToVoteAccounts, NodeVoteAccounts{NodeId: voteState.NodePubkey})
+		}
+		es.NodeIdToVoteAccounts[i].VoteAccounts = append(es.NodeIdToVoteAccounts[i].VoteAccounts, voteAccount.Pubkey)
+		es.NodeIdToVoteAccounts[i].TotalStake += voteAccount.Stake
+	}
+	return es
+}
+
+// authorizedVoter returns the voter authorized by a vote account in an
+// epoch: that of the latest authorization at or before the epoch.
+//
+// Based on solana_vote_interface::authorized_voters::AuthorizedVoters::get_authorized_voter.
+func authorizedVoter(voteState *sealevel.VoteState, epoch uint64) (voter [32]byte, ok bool) {
+	voteState.AuthorizedVoters.AuthorizedVoters.Ascend(0, func(key uint64, value solana.PublicKey) bool {
+		if key > epoch {
+			return false
+		}
+		voter, ok = value, true
+		return true
+	})
+	return voter, ok
+}
+
+// WriteArchiveToFile is a convenience wrapper for WriteArchive.
+func WriteArchiveToFile(fpath string, m *Manifest, storages []Storage) error {
+	f, err := os.Create(fpath)
+	if err != nil {
+		return err
+	}
+	if err := WriteArchive(f, m, storages); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteArchive writes a full snapshot archive, compressed with zstd, of the
+// given manifest, its status cache and account storages. The storages
+// listed in the manifest are replaced by the given ones.
+//
+// Based on solana_runtime::snapshot_utils::archive_snapshot.
+func WriteArchive(w io.Writer, m *Manifest, storages []Storage) error {
+	m.AccountsDb.Storages = nil
+	for _, storage := range storages {
+		n := len(m.AccountsDb.Storages)
+		if n == 0 || m.AccountsDb.Storages[n-1].Slot != storage.Slot {
+			m.AccountsDb.Storages = append(m.AccountsDb.Storages, SlotStorages{Slot: storage.Slot})
+			n++
+		}
+		m.AccountsDb.Storages[n-1].Storages = append(m.AccountsDb.Storages[n-1].Storages, StorageEntry{
+			Id:                 storage.Id,
+			AccountsCurrentLen: storage.Vec.Len(),
+		})
+	}
+	manifestData, err := m.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to serialize manifest: %w", err)
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+	modTime := time.Now()
+	writeFile := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  modTime,
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	statusCache, err := MarshalStatusCache(m.StatusCache)
+	if err != nil {
+		return fmt.Errorf("failed to serialize status cache: %w", err)
+	}
+
+	slot := m.Bank.Slot
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"version", []byte(Version)},
+		{statusCachePath, statusCache},
+		{fmt.Sprintf("snapshots/%d/%d", slot, slot), manifestData},
+	}
+	for _, file := range files {
+		if err := writeFile(file.name, file.data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	for _, storage := range storages {
+		name := fmt.Sprintf("accounts/%d.%d", storage.Slot, storage.Id)
+		if err := writeFile(name, storage.Vec.Bytes()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,195 @@
+package snapshot
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/bank"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func TestPackAccounts(t *testing.T) {
+	accts := map[[32]byte]*accounts.Account{
+		{2}: {Lamports: 2, Data: make([]byte, 100)},
+		{1}: {Lamports: 1},
+		{3}: {},
+		{4}: {Lamports: 4, Data: make([]byte, 1000)},
+	}
+	storages, capitalization, err := PackAccounts(7, accts, 512)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), capitalization)
+
+	// the account too large for a storage gets one of its own
+	require.Len(t, storages, 2)
+	assert.Equal(t, Storage{Slot: 7, Id: 1, Vec: storages[1].Vec}, storages[1])
+	stored, err := storages[0].Vec.Accounts()
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, [32]byte{1}, stored[0].Pubkey)
+	assert.Equal(t, [32]byte{2}, stored[1].Pubkey)
+	assert.Equal(t, accts[[32]byte{2}].Hash([32]byte{2}), stored[1].Hash)
+}
+
+func TestWriteArchive(t *testing.T) {
+	accts := accounts.NewMemAccounts()
+	require.NoError(t, accts.SetAccount(&[32]byte{1}, &accounts.Account{Lamports: 10, Data: []byte{1, 2, 3}}))
+	require.NoError(t, accts.SetAccount(&[32]byte{2}, &accounts.Account{Lamports: 20, Owner: [32]byte{9}}))
+
+	base := newTestManifest()
+	root := base.NewBank(accts, features.NewFeaturesDefault())
+	require.True(t, root.RegisterHardFork(12))
+	child, err := bank.NewBankFromParent(root, 12)
+	require.NoError(t, err)
+	require.NoError(t, child.StoreAccount([32]byte{2}, &accounts.Account{}))
+	require.NoError(t, child.RegisterBlockhash([32]byte{10}))
+	child.StatusCache.Insert([32]byte{10}, make([]byte, 32), child.Slot, sealevel.TxErrAccountInUse)
+	child.Freeze()
+
+	storages, capitalization, err := PackAccounts(child.Slot, accts.Map, DefaultStorageSize)
+	require.NoError(t, err)
+	manifest, err := NewManifest(child, base, capitalization)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, WriteArchive(&buf, manifest, storages))
+
+	loadedAccts := accounts.NewMemAccounts()
+	loaded, err := LoadArchive(&buf, loadedAccts)
+	require.NoError(t, err)
+	// along with the SlotHashes and RecentBlockhashes sysvars stored in the
+	// slot, of a lamport each
+	assert.Len(t, loadedAccts.Map, 3)
+	assert.Equal(t, &accounts.Account{Lamports: 10, Data: []byte{1, 2, 3}}, loadedAccts.Map[[32]byte{1}])
+	assert.Equal(t, accts.Map[sealevel.SysvarSlotHashesAddr], loadedAccts.Map[sealevel.SysvarSlotHashesAddr])
+	assert.Equal(t, accts.Map[sealevel.SysvarRecentBlockHashesAddr], loadedAccts.Map[sealevel.SysvarRecentBlockHashesAddr])
+	assert.Equal(t, uint64(12), loaded.Bank.Slot)
+	assert.Equal(t, uint64(10), loaded.Bank.ParentSlot)
+	assert.Equal(t, uint64(12), loaded.Bank.Capitalization)
+	// the stakes are rebuilt from the accounts, which hold no vote account
+	assert.Empty(t, loaded.Bank.Stakes.VoteAccounts)
+
+	loadedBank := loaded.NewBank(loadedAccts, features.NewFeaturesDefault())
+	childHash, _ := child.Hash()
+	loadedHash, _ := loadedBank.Hash()
+	assert.Equal(t, childHash, loadedHash)
+	assert.Equal(t, child.BlockhashQueue, loadedBank.BlockhashQueue)
+	assert.Equal(t, child.BlockHeight, loadedBank.BlockHeight)
+	assert.Equal(t, []SlotAndIndex{{Slot: 12, Index: 1}}, loaded.Bank.HardForks)
+	assert.Equal(t, child.HardForks.Forks(), loadedBank.HardForks.Forks())
+
+	// the transactions of the snapshot slot are not processed again
+	slot, txErr, ok := loadedBank.StatusCache.GetStatus(make([]byte, 32), [32]byte{10}, nil)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(12), slot)
+	assert.Equal(t, sealevel.TxErrAccountInUse, txErr)
+}
+
+func TestStatusCacheRoundTrip(t *testing.T) {
+	deltas := []bank.SlotDelta{
+		{Slot: 5, IsRoot: true, Statuses: map[[32]byte]*bank.BlockhashStatuses{
+			{1}: {KeyIndex: 3, Statuses: []bank.KeyStatus{
+				{Key: [20]byte{1}},
+				{Key: [20]byte{2}, Err: sealevel.TxErrInstructionError{Index: 1, Err: sealevel.InstrErrCustom{Code: 7}}},
+			}},
+			{2}: {KeyIndex: 0, Statuses: []bank.KeyStatus{{Key: [20]byte{3}, Err: sealevel.TxErrBlockhashNotFound}}},
+		}},
+		{Slot: 6, IsRoot: true, Statuses: map[[32]byte]*bank.BlockhashStatuses{}},
+	}
+	data, err := MarshalStatusCache(deltas)
+	require.NoError(t, err)
+	decoded, err := UnmarshalStatusCache(data)
+	require.NoError(t, err)
+	assert.Equal(t, deltas, decoded)
+
+	_, err = UnmarshalStatusCache(data[:len(data)-1])
+	assert.Error(t, err)
+}
+
+// stakeAccount returns a stake account delegating stake to the vote
+// account {4} since genesis.
+func stakeAccount(t *testing.T, stake uint64) *accounts.Account {
+	state := sealevel.StakeStateV2{
+		Status: sealevel.StakeStateV2StatusStake,
+		Stake: sealevel.StakeStateV2Stake{Stake: sealevel.Stake{
+			Delegation: sealevel.Delegation{
+				VoterPubkey:        [32]byte{4},
+				StakeLamports:      stake,
+				ActivationEpoch:    math.MaxUint64,
+				DeactivationEpoch:  math.MaxUint64,
+				WarmupCooldownRate: 0.25,
+			},
+			CreditsObserved: 7,
+		}},
+	}
+	var data bytes.Buffer
+	require.NoError(t, state.MarshalWithEncoder(bin.NewBinEncoder(&data)))
+	return &accounts.Account{Lamports: stake, Owner: sealevel.StakeProgramAddr, Data: data.Bytes()}
+}
+
+func TestNewManifestStakes(t *testing.T) {
+	// a current vote state of node {8}, with no votes, voting as {9}
+	var voteState sealevel.VoteState
+	voteState.NodePubkey = [32]byte{8}
+	voteState.AuthorizedVoters.AuthorizedVoters.Set(0, [32]byte{9})
+	var voteData bytes.Buffer
+	enc := bin.NewBinEncoder(&voteData)
+	require.NoError(t, enc.WriteUint32(sealevel.VoteStateVersionCurrent, bin.LE))
+	require.NoError(t, enc.WriteBytes(voteState.NodePubkey[:], false))
+	require.NoError(t, enc.WriteBytes(voteState.AuthorizedWithdrawer[:], false))
+	require.NoError(t, enc.WriteByte(voteState.Commission))
+	require.NoError(t, enc.WriteUint64(0, bin.LE))
+	require.NoError(t, enc.WriteBool(false))
+	require.NoError(t, voteState.AuthorizedVoters.MarshalWithEncoder(enc))
+	require.NoError(t, voteState.PriorVoters.MarshalWithEncoder(enc))
+	require.NoError(t, enc.WriteUint64(0, bin.LE))
+	require.NoError(t, voteState.LastTimestamp.MarshalWithEncoder(enc))
+	voteAcct := &accounts.Account{
+		Lamports: 1,
+		Owner:    sealevel.VoteProgramAddr,
+		Data:     append(voteData.Bytes(), make([]byte, sealevel.VoteStateV3Size-voteData.Len())...),
+	}
+	accts := accounts.NewMemAccounts()
+	require.NoError(t, accts.SetAccount(&[32]byte{4}, voteAcct))
+	require.NoError(t, accts.SetAccount(&[32]byte{5}, stakeAccount(t, 42)))
+
+	// the bank of the next epoch captures the epoch stakes of the leader
+	// schedule epoch after it, before stake is delegated in its slot
+	base := newTestManifest()
+	root := base.NewBank(accts, features.NewFeaturesDefault())
+	child, err := bank.NewBankFromParent(root, 432000)
+	require.NoError(t, err)
+	require.NoError(t, child.StoreAccount([32]byte{6}, stakeAccount(t, 100)))
+	child.Freeze()
+	m, err := NewManifest(child, base, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1), m.Bank.Stakes.Epoch)
+	assert.Equal(t, []VoteAccount{{Pubkey: [32]byte{4}, Stake: 142, Account: *voteAcct}}, m.Bank.Stakes.VoteAccounts)
+	assert.Equal(t, []StakeDelegation{
+		{Pubkey: [32]byte{5}, VoterPubkey: [32]byte{4}, Stake: 42, ActivationEpoch: math.MaxUint64, DeactivationEpoch: math.MaxUint64, WarmupCooldownRate: 0.25, CreditsObserved: 7},
+		{Pubkey: [32]byte{6}, VoterPubkey: [32]byte{4}, Stake: 100, ActivationEpoch: math.MaxUint64, DeactivationEpoch: math.MaxUint64, WarmupCooldownRate: 0.25, CreditsObserved: 7},
+	}, m.Bank.Stakes.StakeDelegations)
+	assert.Equal(t, []StakeHistoryEntry{{Epoch: 0, Effective: 42}}, m.Bank.Stakes.StakeHistory)
+
+	assert.Equal(t, base.Bank.EpochStakes, m.Bank.EpochStakes)
+	require.Len(t, m.VersionedEpochStakes, 1)
+	epochStakes := m.VersionedEpochStakes[0]
+	assert.Equal(t, uint64(2), epochStakes.Epoch)
+	assert.Equal(t, uint64(1), epochStakes.Stakes.Epoch)
+	assert.Len(t, epochStakes.Stakes.StakeDelegations, 1)
+	assert.Equal(t, uint64(42), epochStakes.TotalStake)
+	assert.Equal(t, []NodeVoteAccounts{{NodeId: [32]byte{8}, VoteAccounts: [][32]byte{{4}}, TotalStake: 42}}, epochStakes.NodeIdToVoteAccounts)
+	assert.Equal(t, []PubkeyAndPubkey{{Key: [32]byte{4}, Value: [32]byte{9}}}, epochStakes.EpochAuthorizedVoters)
+
+	// the epoch stakes survive the round trip
+	data, err := m.Marshal()
+	require.NoError(t, err)
+	decoded, err := UnmarshalManifest(data)
+	require.NoError(t, err)
+	assert.Equal(t, m.VersionedEpochStakes, decoded.VersionedEpochStakes)
+}
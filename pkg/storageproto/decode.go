@@ -0,0 +1,312 @@
+package storageproto
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ForEachField calls fn with each field of an encoded message, and the
+// value of the field: a uint64 for varint and 64-bit fields, a []byte for
+// length delimited ones. Other fields are skipped.
+func ForEachField(data []byte, fn func(num protowire.Number, v interface{}) error) error {
+	for len(data) != 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var v interface{}
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if v == nil {
+			continue
+		}
+		if err := fn(num, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FieldUint64 returns the value of a varint field.
+func FieldUint64(num protowire.Number, v interface{}) (uint64, error) {
+	u, ok := v.(uint64)
+	if !ok {
+		return 0, fmt.Errorf("field %d is not a number", num)
+	}
+	return u, nil
+}
+
+// FieldBytes returns the value of a length-delimited field.
+func FieldBytes(num protowire.Number, v interface{}) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("field %d is not length-delimited", num)
+	}
+	return b, nil
+}
+
+// ParseTransactionStatusMeta decodes a
+// solana.storage.ConfirmedBlock.TransactionStatusMeta. Rewards and return
+// data are skipped.
+func ParseTransactionStatusMeta(data []byte) (*sealevel.TransactionStatusMeta, error) {
+	meta := new(sealevel.TransactionStatusMeta)
+	logMessagesNone := false
+	err := ForEachField(data, func(num protowire.Number, v interface{}) error {
+		switch num {
+		case 1:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			meta.Err, err = parseTransactionError(b)
+			return err
+		case 2:
+			fee, err := FieldUint64(num, v)
+			meta.Fee = fee
+			return err
+		case 3:
+			return appendUint64s(&meta.PreBalances, num, v)
+		case 4:
+			return appendUint64s(&meta.PostBalances, num, v)
+		case 5:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			inner, err := parseInnerInstructions(b)
+			if err != nil {
+				return err
+			}
+			meta.InnerInstructions = append(meta.InnerInstructions, *inner)
+		case 6:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			meta.LogMessages = append(meta.LogMessages, string(b))
+		case 7, 8:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			balance, err := parseTokenBalance(b)
+			if err != nil {
+				return err
+			}
+			if num == 7 {
+				meta.PreTokenBalances = append(meta.PreTokenBalances, *balance)
+			} else {
+				meta.PostTokenBalances = append(meta.PostTokenBalances, *balance)
+			}
+		case 11:
+			none, err := FieldUint64(num, v)
+			logMessagesNone = none != 0
+			return err
+		case 12, 13:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			if len(b) != 32 {
+				return fmt.Errorf("invalid loaded address of %d bytes", len(b))
+			}
+			addr := solana.PublicKeyFromBytes(b)
+			if num == 12 {
+				meta.LoadedAddresses.Writable = append(meta.LoadedAddresses.Writable, addr)
+			} else {
+				meta.LoadedAddresses.Readonly = append(meta.LoadedAddresses.Readonly, addr)
+			}
+		case 16:
+			units, err := FieldUint64(num, v)
+			meta.ComputeUnitsConsumed = units
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if logMessagesNone {
+		meta.LogMessages = nil
+	} else if meta.LogMessages == nil {
+		meta.LogMessages = []string{}
+	}
+	return meta, nil
+}
+
+// parseTransactionError decodes a
+// solana.storage.ConfirmedBlock.TransactionError, which holds the bincode
+// encoding of the error.
+func parseTransactionError(data []byte) (txErr error, err error) {
+	err = ForEachField(data, func(num protowire.Number, v interface{}) error {
+		if num != 1 {
+			return nil
+		}
+		b, err := FieldBytes(num, v)
+		if err != nil {
+			return err
+		}
+		txErr, _, err = sealevel.DecodeTransactionError(b)
+		return err
+	})
+	return txErr, err
+}
+
+// appendUint64s appends the values of a repeated uint64 field, packed or
+// not.
+func appendUint64s(vs *[]uint64, num protowire.Number, v interface{}) error {
+	if u, ok := v.(uint64); ok {
+		*vs = append(*vs, u)
+		return nil
+	}
+	packed, err := FieldBytes(num, v)
+	if err != nil {
+		return err
+	}
+	if *vs == nil {
+		*vs = make([]uint64, 0, len(packed))
+	}
+	for len(packed) != 0 {
+		u, n := protowire.ConsumeVarint(packed)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		*vs = append(*vs, u)
+		packed = packed[n:]
+	}
+	return nil
+}
+
+func parseInnerInstructions(data []byte) (*sealevel.InnerInstructions, error) {
+	inner := new(sealevel.InnerInstructions)
+	err := ForEachField(data, func(num protowire.Number, v interface{}) error {
+		switch num {
+		case 1:
+			index, err := FieldUint64(num, v)
+			inner.Index = uint8(index)
+			return err
+		case 2:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			var instr sealevel.InnerInstruction
+			err = ForEachField(b, func(num protowire.Number, v interface{}) error {
+				if num == 4 {
+					height, err := FieldUint64(num, v)
+					instr.StackHeight = uint32(height)
+					return err
+				}
+				return parseCompiledInstructionField(&instr.Instruction, num, v)
+			})
+			if err != nil {
+				return err
+			}
+			inner.Instructions = append(inner.Instructions, instr)
+		}
+		return nil
+	})
+	return inner, err
+}
+
+// parseCompiledInstructionField decodes a field of a
+// solana.storage.ConfirmedBlock.CompiledInstruction.
+func parseCompiledInstructionField(instr *solana.CompiledInstruction, num protowire.Number, v interface{}) error {
+	switch num {
+	case 1:
+		index, err := FieldUint64(num, v)
+		instr.ProgramIDIndex = uint16(index)
+		return err
+	case 2:
+		b, err := FieldBytes(num, v)
+		if err != nil {
+			return err
+		}
+		instr.Accounts = make([]uint16, len(b))
+		for i, idx := range b {
+			instr.Accounts[i] = uint16(idx)
+		}
+	case 3:
+		b, err := FieldBytes(num, v)
+		if err != nil {
+			return err
+		}
+		instr.Data = append([]byte{}, b...)
+	}
+	return nil
+}
+
+func parseTokenBalance(data []byte) (*sealevel.TokenBalance, error) {
+	balance := new(sealevel.TokenBalance)
+	err := ForEachField(data, func(num protowire.Number, v interface{}) error {
+		switch num {
+		case 1:
+			index, err := FieldUint64(num, v)
+			balance.AccountIndex = uint8(index)
+			return err
+		case 2, 4, 5:
+			b, err := FieldBytes(num, v)
+			if err != nil || len(b) == 0 {
+				return err
+			}
+			key, err := solana.PublicKeyFromBase58(string(b))
+			if err != nil {
+				return err
+			}
+			switch num {
+			case 2:
+				balance.Mint = key
+			case 4:
+				balance.Owner = key
+			case 5:
+				balance.ProgramId = key
+			}
+		case 3:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			return ForEachField(b, func(num protowire.Number, v interface{}) error {
+				amount := &balance.UiTokenAmount
+				switch num {
+				case 1:
+					bits, err := FieldUint64(num, v)
+					amount.UiAmount = math.Float64frombits(bits)
+					return err
+				case 2:
+					decimals, err := FieldUint64(num, v)
+					amount.Decimals = uint8(decimals)
+					return err
+				case 3, 4:
+					b, err := FieldBytes(num, v)
+					if num == 3 {
+						amount.Amount = string(b)
+					} else {
+						amount.UiAmountString = string(b)
+					}
+					return err
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return balance, err
+}
@@ -0,0 +1,167 @@
+package storageproto
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// RewardType is the kind of a reward.
+//
+// Based on solana.storage.ConfirmedBlock.RewardType.
+type RewardType int32
+
+const (
+	RewardTypeUnspecified RewardType = iota
+	RewardTypeFee
+	RewardTypeRent
+	RewardTypeStaking
+	RewardTypeVoting
+)
+
+func (t RewardType) String() string {
+	switch t {
+	case RewardTypeFee:
+		return "fee"
+	case RewardTypeRent:
+		return "rent"
+	case RewardTypeStaking:
+		return "staking"
+	case RewardTypeVoting:
+		return "voting"
+	default:
+		return "unspecified"
+	}
+}
+
+// Reward is a credit or debit of an account at the end of a block. The
+// commission of staking and voting rewards is nil for other rewards.
+//
+// Based on solana.storage.ConfirmedBlock.Reward.
+type Reward struct {
+	Pubkey      solana.PublicKey
+	Lamports    int64
+	PostBalance uint64
+	RewardType  RewardType
+	Commission  *uint8
+}
+
+// Rewards are the rewards of a block, and the number of partitions of the
+// epoch rewards, if they are partitioned.
+//
+// Based on solana.storage.ConfirmedBlock.Rewards.
+type Rewards struct {
+	Rewards       []Reward
+	NumPartitions *uint64
+}
+
+// AppendRewards appends a solana.storage.ConfirmedBlock.Rewards.
+func AppendRewards(b []byte, rewards *Rewards) []byte {
+	for i := range rewards.Rewards {
+		b = AppendMessage(b, 1, func(b []byte) []byte {
+			return appendReward(b, &rewards.Rewards[i])
+		})
+	}
+	if rewards.NumPartitions != nil {
+		b = AppendMessage(b, 2, func(b []byte) []byte {
+			return AppendUint64(b, 1, *rewards.NumPartitions)
+		})
+	}
+	return b
+}
+
+func appendReward(b []byte, reward *Reward) []byte {
+	b = AppendString(b, 1, reward.Pubkey.String())
+	b = AppendUint64(b, 2, uint64(reward.Lamports))
+	b = AppendUint64(b, 3, reward.PostBalance)
+	b = AppendUint64(b, 4, uint64(reward.RewardType))
+	if reward.Commission != nil {
+		b = AppendString(b, 5, strconv.Itoa(int(*reward.Commission)))
+	}
+	return b
+}
+
+// ParseRewards decodes a solana.storage.ConfirmedBlock.Rewards.
+func ParseRewards(data []byte) (*Rewards, error) {
+	rewards := new(Rewards)
+	err := ForEachField(data, func(num protowire.Number, v interface{}) error {
+		switch num {
+		case 1:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			reward, err := parseReward(b)
+			if err != nil {
+				return err
+			}
+			rewards.Rewards = append(rewards.Rewards, *reward)
+		case 2:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			var numPartitions uint64
+			err = ForEachField(b, func(num protowire.Number, v interface{}) error {
+				if num != 1 {
+					return nil
+				}
+				var err error
+				numPartitions, err = FieldUint64(num, v)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			rewards.NumPartitions = &numPartitions
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rewards, nil
+}
+
+func parseReward(data []byte) (*Reward, error) {
+	reward := new(Reward)
+	err := ForEachField(data, func(num protowire.Number, v interface{}) error {
+		switch num {
+		case 1, 5:
+			b, err := FieldBytes(num, v)
+			if err != nil {
+				return err
+			}
+			if num == 1 {
+				reward.Pubkey, err = solana.PublicKeyFromBase58(string(b))
+				return err
+			}
+			if len(b) == 0 {
+				return nil
+			}
+			commission, err := strconv.ParseUint(string(b), 10, 8)
+			if err != nil {
+				return fmt.Errorf("invalid commission %q", b)
+			}
+			c := uint8(commission)
+			reward.Commission = &c
+		case 2, 3, 4:
+			u, err := FieldUint64(num, v)
+			if err != nil {
+				return err
+			}
+			switch num {
+			case 2:
+				reward.Lamports = int64(u)
+			case 3:
+				reward.PostBalance = u
+			case 4:
+				reward.RewardType = RewardType(u)
+			}
+		}
+		return nil
+	})
+	return reward, err
+}
@@ -0,0 +1,196 @@
+// Package storageproto encodes the messages of solana-storage.proto, the
+// protobuf schema of confirmed blocks shared by Bigtable and geyser, with
+// protowire, field by field, as no generated code is vendored.
+//
+// Fields holding their zero value are omitted, unless appended with the
+// Optional variants.
+package storageproto
+
+import (
+	"math"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func AppendUint64(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return AppendOptionalUint64(b, num, v)
+}
+
+func AppendOptionalUint64(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func AppendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	return AppendOptionalUint64(b, num, 1)
+}
+
+func AppendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	return AppendOptionalBytes(b, num, v)
+}
+
+func AppendOptionalBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func AppendString(b []byte, num protowire.Number, v string) []byte {
+	return AppendBytes(b, num, []byte(v))
+}
+
+func AppendDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+// AppendMessage appends an embedded message, of which the fields are
+// appended by fn. The message is appended even if it is empty.
+func AppendMessage(b []byte, num protowire.Number, fn func(b []byte) []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, fn(nil))
+}
+
+// AppendTransaction appends a solana.storage.ConfirmedBlock.Transaction.
+func AppendTransaction(b []byte, tx *solana.Transaction) []byte {
+	for _, sig := range tx.Signatures {
+		b = AppendOptionalBytes(b, 1, sig[:])
+	}
+	msg := &tx.Message
+	return AppendMessage(b, 2, func(b []byte) []byte {
+		b = AppendMessage(b, 1, func(b []byte) []byte {
+			b = AppendUint64(b, 1, uint64(msg.Header.NumRequiredSignatures))
+			b = AppendUint64(b, 2, uint64(msg.Header.NumReadonlySignedAccounts))
+			return AppendUint64(b, 3, uint64(msg.Header.NumReadonlyUnsignedAccounts))
+		})
+		for _, key := range msg.AccountKeys {
+			b = AppendOptionalBytes(b, 2, key[:])
+		}
+		b = AppendBytes(b, 3, msg.RecentBlockhash[:])
+		for i := range msg.Instructions {
+			b = AppendMessage(b, 4, func(b []byte) []byte {
+				return appendCompiledInstruction(b, &msg.Instructions[i])
+			})
+		}
+		b = AppendBool(b, 5, msg.IsVersioned())
+		for _, lookup := range msg.GetAddressTableLookups() {
+			lookup := lookup
+			b = AppendMessage(b, 6, func(b []byte) []byte {
+				b = AppendBytes(b, 1, lookup.AccountKey[:])
+				b = AppendBytes(b, 2, lookup.WritableIndexes)
+				return AppendBytes(b, 3, lookup.ReadonlyIndexes)
+			})
+		}
+		return b
+	})
+}
+
+// appendCompiledInstruction appends the fields of a
+// solana.storage.ConfirmedBlock.CompiledInstruction, which InnerInstruction
+// shares.
+func appendCompiledInstruction(b []byte, instr *solana.CompiledInstruction) []byte {
+	b = AppendUint64(b, 1, uint64(instr.ProgramIDIndex))
+	accts := make([]byte, len(instr.Accounts))
+	for i, idx := range instr.Accounts {
+		accts[i] = uint8(idx)
+	}
+	b = AppendBytes(b, 2, accts)
+	return AppendBytes(b, 3, instr.Data)
+}
+
+// AppendTransactionError appends a
+// solana.storage.ConfirmedBlock.TransactionError, which holds the bincode
+// encoding of the error.
+func AppendTransactionError(b []byte, num protowire.Number, txErr error) ([]byte, error) {
+	encoded, err := sealevel.AppendTransactionError(nil, txErr)
+	if err != nil {
+		return nil, err
+	}
+	return AppendMessage(b, num, func(b []byte) []byte {
+		return AppendOptionalBytes(b, 1, encoded)
+	}), nil
+}
+
+// AppendTransactionStatusMeta appends a
+// solana.storage.ConfirmedBlock.TransactionStatusMeta.
+func AppendTransactionStatusMeta(b []byte, meta *sealevel.TransactionStatusMeta) ([]byte, error) {
+	if meta.Err != nil {
+		var err error
+		if b, err = AppendTransactionError(b, 1, meta.Err); err != nil {
+			return nil, err
+		}
+	}
+	b = AppendUint64(b, 2, meta.Fee)
+	b = appendPackedUint64s(b, 3, meta.PreBalances)
+	b = appendPackedUint64s(b, 4, meta.PostBalances)
+	for i := range meta.InnerInstructions {
+		inner := &meta.InnerInstructions[i]
+		b = AppendMessage(b, 5, func(b []byte) []byte {
+			b = AppendUint64(b, 1, uint64(inner.Index))
+			for j := range inner.Instructions {
+				instr := &inner.Instructions[j]
+				b = AppendMessage(b, 2, func(b []byte) []byte {
+					b = appendCompiledInstruction(b, &instr.Instruction)
+					return AppendOptionalUint64(b, 4, uint64(instr.StackHeight))
+				})
+			}
+			return b
+		})
+	}
+	for _, msg := range meta.LogMessages {
+		b = AppendOptionalBytes(b, 6, []byte(msg))
+	}
+	b = AppendBool(b, 11, meta.LogMessages == nil)
+	for i := range meta.PreTokenBalances {
+		b = AppendMessage(b, 7, func(b []byte) []byte { return appendTokenBalance(b, &meta.PreTokenBalances[i]) })
+	}
+	for i := range meta.PostTokenBalances {
+		b = AppendMessage(b, 8, func(b []byte) []byte { return appendTokenBalance(b, &meta.PostTokenBalances[i]) })
+	}
+	for _, addr := range meta.LoadedAddresses.Writable {
+		b = AppendOptionalBytes(b, 12, addr[:])
+	}
+	for _, addr := range meta.LoadedAddresses.Readonly {
+		b = AppendOptionalBytes(b, 13, addr[:])
+	}
+	// return data is not recorded
+	b = AppendBool(b, 15, true)
+	return AppendOptionalUint64(b, 16, meta.ComputeUnitsConsumed), nil
+}
+
+func appendPackedUint64s(b []byte, num protowire.Number, vs []uint64) []byte {
+	if len(vs) == 0 {
+		return b
+	}
+	var packed []byte
+	for _, v := range vs {
+		packed = protowire.AppendVarint(packed, v)
+	}
+	return AppendOptionalBytes(b, num, packed)
+}
+
+func appendTokenBalance(b []byte, balance *sealevel.TokenBalance) []byte {
+	b = AppendUint64(b, 1, uint64(balance.AccountIndex))
+	b = AppendString(b, 2, balance.Mint.String())
+	b = AppendMessage(b, 3, func(b []byte) []byte {
+		b = AppendDouble(b, 1, balance.UiTokenAmount.UiAmount)
+		b = AppendUint64(b, 2, uint64(balance.UiTokenAmount.Decimals))
+		b = AppendString(b, 3, balance.UiTokenAmount.Amount)
+		return AppendString(b, 4, balance.UiTokenAmount.UiAmountString)
+	})
+	b = AppendString(b, 4, balance.Owner.String())
+	return AppendString(b, 5, balance.ProgramId.String())
+}
@@ -0,0 +1,85 @@
+package storageproto
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func TestParseTransactionStatusMeta(t *testing.T) {
+	meta := &sealevel.TransactionStatusMeta{
+		Err:          sealevel.TxErrInstructionError{Index: 1, Err: sealevel.InstrErrCustom{Code: 42}},
+		Fee:          5000,
+		PreBalances:  []uint64{100, 0, 1},
+		PostBalances: []uint64{95, 0, 1},
+		InnerInstructions: []sealevel.InnerInstructions{{
+			Index: 1,
+			Instructions: []sealevel.InnerInstruction{{
+				Instruction: solana.CompiledInstruction{ProgramIDIndex: 2, Accounts: []uint16{0, 1}, Data: []byte{3}},
+				StackHeight: 2,
+			}},
+		}},
+		LogMessages: []string{"Program log: hi"},
+		PostTokenBalances: []sealevel.TokenBalance{{
+			AccountIndex: 1,
+			Mint:         solana.SystemProgramID,
+			UiTokenAmount: sealevel.UiTokenAmount{
+				Amount:         "1500",
+				Decimals:       3,
+				UiAmount:       1.5,
+				UiAmountString: "1.5",
+			},
+			Owner:     solana.SysVarClockPubkey,
+			ProgramId: solana.TokenProgramID,
+		}},
+		LoadedAddresses: sealevel.LoadedAddresses{
+			Writable: []solana.PublicKey{solana.SysVarRentPubkey},
+		},
+		ComputeUnitsConsumed: 1234,
+	}
+	data, err := AppendTransactionStatusMeta(nil, meta)
+	require.NoError(t, err)
+	parsed, err := ParseTransactionStatusMeta(data)
+	require.NoError(t, err)
+	assert.Equal(t, meta, parsed)
+
+	// log messages that were not recorded stay nil
+	meta = &sealevel.TransactionStatusMeta{Fee: 5000}
+	data, err = AppendTransactionStatusMeta(nil, meta)
+	require.NoError(t, err)
+	parsed, err = ParseTransactionStatusMeta(data)
+	require.NoError(t, err)
+	assert.Equal(t, meta, parsed)
+
+	_, err = ParseTransactionStatusMeta([]byte{0x0a, 0x05})
+	assert.Error(t, err)
+}
+
+func TestParseRewards(t *testing.T) {
+	commission := uint8(10)
+	numPartitions := uint64(4)
+	rewards := &Rewards{
+		Rewards: []Reward{
+			{
+				Pubkey:      solana.SysVarRentPubkey,
+				Lamports:    -5,
+				PostBalance: 95,
+				RewardType:  RewardTypeRent,
+			},
+			{
+				Pubkey:      solana.SysVarClockPubkey,
+				Lamports:    1000,
+				PostBalance: 2000,
+				RewardType:  RewardTypeVoting,
+				Commission:  &commission,
+			},
+		},
+		NumPartitions: &numPartitions,
+	}
+	parsed, err := ParseRewards(AppendRewards(nil, rewards))
+	require.NoError(t, err)
+	assert.Equal(t, rewards, parsed)
+}
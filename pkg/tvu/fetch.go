@@ -0,0 +1,146 @@
+package tvu
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.firedancer.io/radiance/pkg/blockstore"
+)
+
+// PacketSize is the maximum size of a shred packet.
+const PacketSize = 1232
+
+// Defaults of the batching of shreds.
+const (
+	DefaultBatchSize  = 1024
+	DefaultBatchDelay = 10 * time.Millisecond
+)
+
+// ShredFetcher receives shreds from Turbine on the TVU socket, and sends
+// the shreds passing its filter downstream in batches.
+//
+// As a leaf of the Turbine tree, the fetcher does not retransmit shreds.
+//
+// Based on solana_core::shred_fetch_stage::ShredFetchStage.
+type ShredFetcher struct {
+	so     *net.UDPConn
+	filter *ShredFilter
+
+	// BatchSize is the maximum number of shreds of a batch.
+	BatchSize int
+	// BatchDelay is how long a batch waits for more shreds.
+	BatchDelay time.Duration
+
+	NumPackets atomic.Uint64 // packets received
+	NumBatches atomic.Uint64 // batches sent
+}
+
+func NewShredFetcher(so *net.UDPConn, filter *ShredFilter) *ShredFetcher {
+	return &ShredFetcher{
+		so:         so,
+		filter:     filter,
+		BatchSize:  DefaultBatchSize,
+		BatchDelay: DefaultBatchDelay,
+	}
+}
+
+// Run receives shreds until the context is cancelled, and sends their
+// batches to out, which is closed after returning.
+//
+// Closes the socket after returning. Returns any network error or nil if
+// the context closed.
+func (f *ShredFetcher) Run(ctx context.Context, out chan<- [][]byte) error {
+	defer close(out)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var graceful atomic.Bool
+	go func() {
+		defer f.so.Close()
+		defer graceful.Store(true)
+		<-ctx.Done()
+	}()
+
+	var batch [][]byte
+	var batchStart time.Time
+	send := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		select {
+		case out <- batch:
+			f.NumBatches.Add(1)
+			batch = nil
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	buf := make([]byte, PacketSize)
+	for {
+		deadline := time.Now().Add(f.BatchDelay)
+		if len(batch) > 0 {
+			deadline = batchStart.Add(f.BatchDelay)
+		}
+		if err := f.so.SetReadDeadline(deadline); err != nil && !graceful.Load() {
+			return err
+		}
+		n, _, err := f.so.ReadFromUDPAddrPort(buf)
+		if n > 0 {
+			f.NumPackets.Add(1)
+			if raw := f.filter.Check(buf[:n]); raw != nil {
+				if len(batch) == 0 {
+					batchStart = time.Now()
+				}
+				batch = append(batch, append([]byte(nil), raw...))
+			}
+		}
+		if err != nil {
+			if graceful.Load() {
+				return nil
+			}
+			if !errors.Is(err, os.ErrDeadlineExceeded) {
+				return err
+			}
+		}
+		if len(batch) >= f.BatchSize || (len(batch) > 0 && time.Since(batchStart) >= f.BatchDelay) {
+			if !send() {
+				return nil
+			}
+		}
+	}
+}
+
+// ShredInserter inserts shreds into the blockstore.
+type ShredInserter interface {
+	InsertShreds(shreds [][]byte) (*blockstore.InsertStats, error)
+}
+
+// InsertShreds inserts the batches of shreds received from in, until in
+// is closed or the context is cancelled, and calls onInsert with the
+// stats of each insertion, of which the completed slots are ready to be
+// replayed.
+func InsertShreds(ctx context.Context, db ShredInserter, in <-chan [][]byte, onInsert func(*blockstore.InsertStats)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case batch, ok := <-in:
+			if !ok {
+				return nil
+			}
+			stats, err := db.InsertShreds(batch)
+			if err != nil {
+				return err
+			}
+			if onInsert != nil {
+				onInsert(stats)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package tvu
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/blockstore"
+	"go.firedancer.io/radiance/pkg/shred"
+	"golang.org/x/sync/errgroup"
+)
+
+type recordingInserter struct {
+	shreds [][]byte
+}
+
+func (r *recordingInserter) InsertShreds(shreds [][]byte) (*blockstore.InsertStats, error) {
+	r.shreds = append(r.shreds, shreds...)
+	return &blockstore.InsertStats{NumData: len(shreds)}, nil
+}
+
+func TestShredFetcher(t *testing.T) {
+	conn, err := net.ListenUDP("udp", net.UDPAddrFromAddrPort(netip.MustParseAddrPort("127.0.0.1:0")))
+	require.NoError(t, err)
+	sender, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	fetcher := NewShredFetcher(conn, NewShredFilter(7, 0))
+	fetcher.BatchSize = 3
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	group, ctx := errgroup.WithContext(ctx)
+	batches := make(chan [][]byte)
+	group.Go(func() error {
+		return fetcher.Run(ctx, batches)
+	})
+
+	inserter := new(recordingInserter)
+	var numInserted int
+	group.Go(func() error {
+		defer cancel()
+		return InsertShreds(ctx, inserter, batches, func(stats *blockstore.InsertStats) {
+			numInserted += stats.NumData
+			if numInserted == 4 {
+				cancel()
+			}
+		})
+	})
+
+	for i := uint32(0); i < 4; i++ {
+		// dropped
+		_, err := sender.Write(makePacket(shred.MerkleDataID|6, 10, i, 8))
+		require.NoError(t, err)
+	}
+	for i := uint32(0); i < 4; i++ {
+		_, err := sender.Write(makePacket(shred.MerkleDataID|6, 10, i, 7))
+		require.NoError(t, err)
+	}
+	require.NoError(t, group.Wait())
+
+	require.Len(t, inserter.shreds, 4)
+	for _, raw := range inserter.shreds {
+		assert.Len(t, raw, shred.MerkleDataSize)
+	}
+	assert.Equal(t, uint64(8), fetcher.NumPackets.Load())
+	assert.Equal(t, uint64(4), fetcher.filter.NumVersion.Load())
+}
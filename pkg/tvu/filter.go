@@ -0,0 +1,167 @@
+// Package tvu implements the Transaction Validation Unit of a node, which
+// receives the shreds of blocks from Turbine, and stores them.
+package tvu
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// Limits of the shred indexes of a slot.
+//
+// Based on solana_ledger::shred::{MAX_DATA_SHREDS_PER_SLOT, MAX_CODE_SHREDS_PER_SLOT}.
+const (
+	MaxDataShredsPerSlot = 32768
+	MaxCodeShredsPerSlot = MaxDataShredsPerSlot
+)
+
+// DefaultMaxSlotDistance is how far ahead of the root shreds are accepted,
+// two epochs of mainnet.
+//
+// Based on solana_core::shred_fetch_stage::ShredFetchStage::modify_packets.
+const DefaultMaxSlotDistance = 2 * 432000
+
+// dedupCapacity is the number of payloads remembered by a generation of
+// the deduplicator.
+const dedupCapacity = 1 << 18
+
+// ShredFilter checks the shreds received from Turbine before they are
+// inserted: it drops malformed shreds, shreds of another cluster or out
+// of the slot window, and payloads received before.
+//
+// Based on solana_ledger::shred::should_discard_shred.
+type ShredFilter struct {
+	shredVersion    uint16
+	maxSlotDistance uint64
+	root            atomic.Uint64
+
+	dedupLock sync.Mutex
+	dedupSeed maphash.Seed
+	// payload hashes of the current and previous generation
+	seen, prevSeen map[uint64]struct{}
+
+	NumOK          atomic.Uint64 // shreds passed
+	NumInvalid     atomic.Uint64 // malformed shreds
+	NumVersion     atomic.Uint64 // shreds of another shred version
+	NumSlotWindow  atomic.Uint64 // shreds out of the slot window
+	NumDuplicate   atomic.Uint64 // payloads received before
+	NumIndexBounds atomic.Uint64 // shreds with an index beyond the limit
+}
+
+// NewShredFilter returns a filter for the shreds of the given shred
+// version. A maxSlotDistance of zero defaults to DefaultMaxSlotDistance.
+func NewShredFilter(shredVersion uint16, maxSlotDistance uint64) *ShredFilter {
+	if maxSlotDistance == 0 {
+		maxSlotDistance = DefaultMaxSlotDistance
+	}
+	return &ShredFilter{
+		shredVersion:    shredVersion,
+		maxSlotDistance: maxSlotDistance,
+		dedupSeed:       maphash.MakeSeed(),
+		seen:            make(map[uint64]struct{}),
+	}
+}
+
+// SetRoot sets the root slot, shreds of which and of preceding slots are
+// dropped. Until a root is set, shreds of any slot pass.
+func (f *ShredFilter) SetRoot(slot uint64) {
+	f.root.Store(slot)
+}
+
+// Root returns the root slot set.
+func (f *ShredFilter) Root() uint64 {
+	return f.root.Load()
+}
+
+// Check returns the shred held by a packet, with the padding of legacy
+// data shreds removed, or nil if the shred is dropped.
+//
+// The returned slice aliases the packet.
+func (f *ShredFilter) Check(packet []byte) []byte {
+	raw, ok := trimShred(packet)
+	if !ok {
+		f.NumInvalid.Add(1)
+		return nil
+	}
+	variant := raw[0x40]
+	slot := binary.LittleEndian.Uint64(raw[0x41:0x49])
+	index := binary.LittleEndian.Uint32(raw[0x49:0x4d])
+	version := binary.LittleEndian.Uint16(raw[0x4d:0x4f])
+
+	if version != f.shredVersion {
+		f.NumVersion.Add(1)
+		return nil
+	}
+	if root := f.root.Load(); root != 0 && (slot <= root || slot > root+f.maxSlotDistance) {
+		f.NumSlotWindow.Add(1)
+		return nil
+	}
+	common := shred.CommonHeader{Variant: variant}
+	if (common.IsData() && index >= MaxDataShredsPerSlot) ||
+		(common.IsCode() && index >= MaxCodeShredsPerSlot) {
+		f.NumIndexBounds.Add(1)
+		return nil
+	}
+	if f.dedup(raw) {
+		f.NumDuplicate.Add(1)
+		return nil
+	}
+	f.NumOK.Add(1)
+	return raw
+}
+
+// dedup reports whether a payload was seen before, and remembers it
+// otherwise. Payloads are remembered for at least a generation.
+func (f *ShredFilter) dedup(raw []byte) bool {
+	h := maphash.Bytes(f.dedupSeed, raw)
+	f.dedupLock.Lock()
+	defer f.dedupLock.Unlock()
+	if _, ok := f.seen[h]; ok {
+		return true
+	}
+	if _, ok := f.prevSeen[h]; ok {
+		return true
+	}
+	if len(f.seen) >= dedupCapacity {
+		f.prevSeen = f.seen
+		f.seen = make(map[uint64]struct{}, dedupCapacity)
+	}
+	f.seen[h] = struct{}{}
+	return false
+}
+
+// trimShred returns the shred held by a packet, the size of which depends
+// on the variant of the shred, and which is followed by padding.
+//
+// Based on solana_ledger::shred::layout::get_shred.
+func trimShred(packet []byte) ([]byte, bool) {
+	if len(packet) < shred.CommonHeaderSize {
+		return nil, false
+	}
+	common := shred.CommonHeader{Variant: packet[0x40]}
+	var size int
+	switch {
+	case common.Variant == shred.LegacyDataID:
+		if len(packet) < shred.DataHeaderSize {
+			return nil, false
+		}
+		size = int(binary.LittleEndian.Uint16(packet[0x56:0x58]))
+		if size < shred.DataHeaderSize {
+			return nil, false
+		}
+	case common.IsData():
+		size = shred.MerkleDataSize
+	case common.IsCode():
+		size = shred.CodePayloadSize
+	default:
+		return nil, false
+	}
+	if len(packet) < size {
+		return nil, false
+	}
+	return packet[:size], true
+}
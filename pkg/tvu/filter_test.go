@@ -0,0 +1,72 @@
+package tvu
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// makePacket returns a shred packet of the given variant, padded to the
+// size of coding shreds.
+func makePacket(variant uint8, slot uint64, index uint32, version uint16) []byte {
+	packet := make([]byte, shred.CodePayloadSize)
+	packet[0] = byte(index) // distinct signatures
+	packet[0x40] = variant
+	binary.LittleEndian.PutUint64(packet[0x41:0x49], slot)
+	binary.LittleEndian.PutUint32(packet[0x49:0x4d], index)
+	binary.LittleEndian.PutUint16(packet[0x4d:0x4f], version)
+	if variant == shred.LegacyDataID {
+		binary.LittleEndian.PutUint16(packet[0x56:0x58], shred.DataHeaderSize+10)
+	}
+	return packet
+}
+
+func TestShredFilter(t *testing.T) {
+	f := NewShredFilter(7, 100)
+	merkleData := shred.MerkleDataID | 6
+	merkleCode := shred.MerkleCodeID | 6
+
+	assert.Len(t, f.Check(makePacket(shred.LegacyDataID, 10, 0, 7)), shred.DataHeaderSize+10)
+	assert.Len(t, f.Check(makePacket(merkleData, 10, 0, 7)), shred.MerkleDataSize)
+	assert.Len(t, f.Check(makePacket(merkleCode, 10, 0, 7)), shred.CodePayloadSize)
+	assert.Equal(t, uint64(3), f.NumOK.Load())
+
+	// payloads received before
+	assert.Nil(t, f.Check(makePacket(merkleCode, 10, 0, 7)))
+	assert.Equal(t, uint64(1), f.NumDuplicate.Load())
+
+	assert.Nil(t, f.Check(makePacket(merkleCode, 10, 1, 8)))
+	assert.Equal(t, uint64(1), f.NumVersion.Load())
+
+	assert.Nil(t, f.Check(makePacket(merkleData, 10, MaxDataShredsPerSlot, 7)))
+	assert.Equal(t, uint64(1), f.NumIndexBounds.Load())
+
+	assert.Nil(t, f.Check(makePacket(0xff, 10, 1, 7)))
+	assert.Nil(t, f.Check(makePacket(merkleData, 10, 1, 7)[:shred.MerkleDataSize-1]))
+	short := makePacket(shred.LegacyDataID, 10, 1, 7)
+	binary.LittleEndian.PutUint16(short[0x56:0x58], 1)
+	assert.Nil(t, f.Check(short))
+	assert.Equal(t, uint64(3), f.NumInvalid.Load())
+
+	// slot window after the root
+	f.SetRoot(10)
+	assert.Nil(t, f.Check(makePacket(merkleData, 10, 2, 7)))
+	assert.Nil(t, f.Check(makePacket(merkleData, 111, 2, 7)))
+	assert.Equal(t, uint64(2), f.NumSlotWindow.Load())
+	assert.NotNil(t, f.Check(makePacket(merkleData, 110, 2, 7)))
+}
+
+func TestShredFilter_DedupGenerations(t *testing.T) {
+	f := NewShredFilter(0, 0)
+	first := []byte("first")
+	assert.False(t, f.dedup(first))
+	for i := 0; i < dedupCapacity; i++ {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], uint64(i))
+		f.dedup(buf[:])
+	}
+	// remembered by the previous generation
+	assert.True(t, f.dedup(first))
+}
@@ -0,0 +1,106 @@
+package tvu
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"sync/atomic"
+
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+// VerifyShredSignature checks the signature of a serialized shred by the
+// leader of its slot. Merkle shreds sign the merkle root of their erasure
+// set, and legacy shreds their padded payload.
+//
+// Based on solana_ledger::sigverify_shreds::verify_shred_cpu.
+func VerifyShredSignature(raw []byte, leader [32]byte) bool {
+	if len(raw) < shred.CommonHeaderSize {
+		return false
+	}
+	sig := raw[:shred.SignatureSize]
+	variant := raw[0x40]
+	switch variant {
+	case shred.LegacyDataID, shred.LegacyCodeID:
+		msg := raw[shred.SignatureSize:]
+		if len(raw) < shred.CodePayloadSize {
+			// legacy data shreds are stored without their padding
+			msg = make([]byte, shred.CodePayloadSize-shred.SignatureSize)
+			copy(msg, raw[shred.SignatureSize:])
+		}
+		return ed25519.Verify(leader[:], msg, sig)
+	default:
+		root, err := shred.MerkleRoot(raw)
+		if err != nil {
+			return false
+		}
+		return ed25519.Verify(leader[:], root[:], sig)
+	}
+}
+
+// LeaderFunc returns the leader of a slot, if known.
+type LeaderFunc func(slot uint64) ([32]byte, bool)
+
+// SigVerifier drops the shreds not signed by the leader of their slot.
+//
+// Based on solana_turbine::sigverify_shreds.
+type SigVerifier struct {
+	leaders LeaderFunc
+
+	NumOK       atomic.Uint64 // shreds signed by their leader
+	NumInvalid  atomic.Uint64 // shreds with an invalid signature
+	NumNoLeader atomic.Uint64 // shreds of slots without a known leader
+}
+
+func NewSigVerifier(leaders LeaderFunc) *SigVerifier {
+	return &SigVerifier{leaders: leaders}
+}
+
+// Verify returns the shreds of a batch signed by their leader.
+func (v *SigVerifier) Verify(batch [][]byte) [][]byte {
+	verified := batch[:0]
+	for _, raw := range batch {
+		if len(raw) < shred.CommonHeaderSize {
+			v.NumInvalid.Add(1)
+			continue
+		}
+		leader, ok := v.leaders(binary.LittleEndian.Uint64(raw[0x41:0x49]))
+		if !ok {
+			v.NumNoLeader.Add(1)
+			continue
+		}
+		if !VerifyShredSignature(raw, leader) {
+			v.NumInvalid.Add(1)
+			continue
+		}
+		v.NumOK.Add(1)
+		verified = append(verified, raw)
+	}
+	return verified
+}
+
+// Run verifies the batches of shreds received from in, and sends the
+// verified shreds to out, which is closed after returning.
+func (v *SigVerifier) Run(ctx context.Context, in <-chan [][]byte, out chan<- [][]byte) {
+	defer close(out)
+	for {
+		var batch [][]byte
+		select {
+		case <-ctx.Done():
+			return
+		case b, ok := <-in:
+			if !ok {
+				return
+			}
+			batch = v.Verify(b)
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case out <- batch:
+		}
+	}
+}
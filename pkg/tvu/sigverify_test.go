@@ -0,0 +1,64 @@
+package tvu
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/shred"
+)
+
+func TestVerifyShredSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	var leader [32]byte
+	copy(leader[:], pub)
+
+	// legacy shreds sign their payload, padded for data shreds
+	packet := makePacket(shred.LegacyDataID, 10, 0, 7)
+	copy(packet, ed25519.Sign(priv, packet[shred.SignatureSize:]))
+	raw, ok := trimShred(packet)
+	require.True(t, ok)
+	assert.True(t, VerifyShredSignature(raw, leader))
+	code := makePacket(shred.LegacyCodeID, 10, 0, 7)
+	copy(code, ed25519.Sign(priv, code[shred.SignatureSize:]))
+	assert.True(t, VerifyShredSignature(code, leader))
+	code[shred.CodePayloadSize-1] ^= 1
+	assert.False(t, VerifyShredSignature(code, leader))
+
+	// merkle shreds sign the merkle root of their erasure set
+	merkle := append([]byte(nil), fixtures.MerkleDataShreds(t)[0]...)
+	root, err := shred.MerkleRoot(merkle)
+	require.NoError(t, err)
+	assert.False(t, VerifyShredSignature(merkle, leader))
+	copy(merkle, ed25519.Sign(priv, root[:]))
+	assert.True(t, VerifyShredSignature(merkle, leader))
+
+	assert.False(t, VerifyShredSignature(makePacket(shred.MerkleDataID|6, 10, 0, 7)[:100], leader))
+	assert.False(t, VerifyShredSignature(packet[:10], leader))
+}
+
+func TestSigVerifier(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	var leader [32]byte
+	copy(leader[:], pub)
+	v := NewSigVerifier(func(slot uint64) ([32]byte, bool) {
+		return leader, slot == 10
+	})
+
+	signed := makePacket(shred.LegacyCodeID, 10, 0, 7)
+	copy(signed, ed25519.Sign(priv, signed[shred.SignatureSize:]))
+	batch := [][]byte{
+		makePacket(shred.LegacyCodeID, 10, 1, 7),
+		signed,
+		makePacket(shred.LegacyCodeID, 11, 0, 7),
+	}
+	assert.Equal(t, [][]byte{signed}, v.Verify(batch))
+	assert.Equal(t, uint64(1), v.NumOK.Load())
+	assert.Equal(t, uint64(1), v.NumInvalid.Load())
+	assert.Equal(t, uint64(1), v.NumNoLeader.Load())
+}
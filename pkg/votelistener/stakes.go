@@ -0,0 +1,75 @@
+package votelistener
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+// Stakes are the stakes and authorized voters of the vote accounts in the
+// epochs of the epoch stakes of a bank.
+type Stakes struct {
+	schedule sealevel.SysvarEpochSchedule
+	epochs   map[uint64]*epochStakes
+}
+
+type epochStakes struct {
+	totalStake       uint64
+	stakes           map[solana.PublicKey]uint64
+	authorizedVoters map[solana.PublicKey]solana.PublicKey
+}
+
+// NewStakes indexes epoch stakes, of epochs laid out by the schedule.
+func NewStakes(schedule sealevel.SysvarEpochSchedule, epochs []snapshot.EpochStakes) *Stakes {
+	s := &Stakes{schedule: schedule, epochs: make(map[uint64]*epochStakes)}
+	for i := range epochs {
+		es := &epochs[i]
+		e := &epochStakes{
+			totalStake:       es.TotalStake,
+			stakes:           make(map[solana.PublicKey]uint64, len(es.Stakes.VoteAccounts)),
+			authorizedVoters: make(map[solana.PublicKey]solana.PublicKey, len(es.EpochAuthorizedVoters)),
+		}
+		for _, account := range es.Stakes.VoteAccounts {
+			e.stakes[account.Pubkey] = account.Stake
+		}
+		for _, voter := range es.EpochAuthorizedVoters {
+			e.authorizedVoters[voter.Key] = voter.Value
+		}
+		s.epochs[es.Epoch] = e
+	}
+	return s
+}
+
+// StakesFromManifest indexes the epoch stakes of the bank of a snapshot.
+func StakesFromManifest(manifest *snapshot.Manifest) *Stakes {
+	var epochs []snapshot.EpochStakes
+	epochs = append(epochs, manifest.Bank.EpochStakes...)
+	epochs = append(epochs, manifest.VersionedEpochStakes...)
+	return NewStakes(manifest.Bank.EpochSchedule, epochs)
+}
+
+func (s *Stakes) epoch(slot uint64) (*epochStakes, bool) {
+	e, ok := s.epochs[s.schedule.GetEpoch(slot)]
+	return e, ok
+}
+
+// VoteAccountStake returns the stake of a vote account in the epoch of a
+// slot, and the total stake of the epoch, or false if the epoch is unknown.
+func (s *Stakes) VoteAccountStake(slot uint64, voteAccount solana.PublicKey) (stake, totalStake uint64, ok bool) {
+	e, ok := s.epoch(slot)
+	if !ok {
+		return 0, 0, false
+	}
+	return e.stakes[voteAccount], e.totalStake, true
+}
+
+// AuthorizedVoter returns the voter authorized to vote with a vote account
+// in the epoch of a slot.
+func (s *Stakes) AuthorizedVoter(slot uint64, voteAccount solana.PublicKey) (solana.PublicKey, bool) {
+	e, ok := s.epoch(slot)
+	if !ok {
+		return solana.PublicKey{}, false
+	}
+	voter, ok := e.authorizedVoters[voteAccount]
+	return voter, ok
+}
@@ -0,0 +1,131 @@
+package votelistener
+
+import (
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// VoteThreshold is the fraction of the stake of an epoch which must vote on
+// a slot and bank hash for them to be optimistically confirmed.
+//
+// Based on solana_core::consensus::VOTE_THRESHOLD_SIZE.
+const VoteThreshold = 2.0 / 3.0
+
+// Tally is the stake voting on a slot and bank hash.
+type Tally struct {
+	Slot       uint64
+	Hash       [32]byte
+	Stake      uint64
+	TotalStake uint64 // of the epoch of the slot
+	NumVotes   int
+}
+
+// Confirmed returns whether the stake voting reached the threshold of
+// optimistic confirmation.
+func (t *Tally) Confirmed() bool {
+	return t.TotalStake != 0 && float64(t.Stake)/float64(t.TotalStake) > VoteThreshold
+}
+
+// Tracker tallies the stake voting on each slot and bank hash above the
+// root, to find the slots optimistically confirmed. Only the last slot of
+// each vote is counted, as the voter only attests the bank hash of that
+// one. A Tracker must not be used concurrently.
+//
+// Based on solana_core::cluster_info_vote_listener::ClusterInfoVoteListener::track_optimistic_confirmation_vote
+// and solana_core::vote_stake_tracker::VoteStakeTracker.
+type Tracker struct {
+	stakes *Stakes
+	root   uint64
+	slots  map[uint64]map[[32]byte]*trackedHash
+}
+
+type trackedHash struct {
+	Tally
+	voted map[solana.PublicKey]struct{}
+}
+
+// NewTracker returns a tracker weighting votes by the given stakes.
+func NewTracker(stakes *Stakes) *Tracker {
+	return &Tracker{stakes: stakes, slots: make(map[uint64]map[[32]byte]*trackedHash)}
+}
+
+// Add tallies a vote, and returns the tally of its slot and bank hash if the
+// vote made it reach the threshold of optimistic confirmation. Votes of a
+// vote account are only counted once for each slot and bank hash, and
+// votes of slots of unknown epochs are ignored.
+func (t *Tracker) Add(vote Vote) (Tally, bool) {
+	if vote.Slot <= t.root {
+		return Tally{}, false
+	}
+	stake, totalStake, ok := t.stakes.VoteAccountStake(vote.Slot, vote.VoteAccount)
+	if !ok {
+		return Tally{}, false
+	}
+	hashes, ok := t.slots[vote.Slot]
+	if !ok {
+		hashes = make(map[[32]byte]*trackedHash)
+		t.slots[vote.Slot] = hashes
+	}
+	tracked, ok := hashes[vote.Hash]
+	if !ok {
+		tracked = &trackedHash{
+			Tally: Tally{Slot: vote.Slot, Hash: vote.Hash, TotalStake: totalStake},
+			voted: make(map[solana.PublicKey]struct{}),
+		}
+		hashes[vote.Hash] = tracked
+	}
+	if _, ok := tracked.voted[vote.VoteAccount]; ok {
+		return Tally{}, false
+	}
+	tracked.voted[vote.VoteAccount] = struct{}{}
+	wasConfirmed := tracked.Confirmed()
+	tracked.Stake += stake
+	tracked.NumVotes++
+	if !wasConfirmed && tracked.Confirmed() {
+		return tracked.Tally, true
+	}
+	return Tally{}, false
+}
+
+// Tallies returns the tallies of the bank hashes voted on a slot, by
+// descending stake.
+func (t *Tracker) Tallies(slot uint64) []Tally {
+	var tallies []Tally
+	for _, tracked := range t.slots[slot] {
+		tallies = append(tallies, tracked.Tally)
+	}
+	sort.Slice(tallies, func(i, j int) bool {
+		return tallies[i].Stake > tallies[j].Stake
+	})
+	return tallies
+}
+
+// HighestConfirmed returns the tally of the highest slot optimistically
+// confirmed, if any.
+func (t *Tracker) HighestConfirmed() (Tally, bool) {
+	var highest Tally
+	var found bool
+	for slot, hashes := range t.slots {
+		if found && slot <= highest.Slot {
+			continue
+		}
+		for _, tracked := range hashes {
+			if tracked.Confirmed() {
+				highest, found = tracked.Tally, true
+			}
+		}
+	}
+	return highest, found
+}
+
+// SetRoot drops the tallies of the slots up to the root, votes on which
+// are ignored from now on.
+func (t *Tracker) SetRoot(root uint64) {
+	for slot := range t.slots {
+		if slot <= root {
+			delete(t.slots, slot)
+		}
+	}
+	t.root = root
+}
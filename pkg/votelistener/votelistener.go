@@ -0,0 +1,143 @@
+// Package votelistener follows the votes of the cluster propagated through
+// gossip, and tracks the slots optimistically confirmed by them.
+package votelistener
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/gossip"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// Vote is a vote transaction propagated through gossip.
+type Vote struct {
+	VoteAccount solana.PublicKey
+	Slot        uint64   // last slot voted on
+	Hash        [32]byte // bank hash of the slot
+	Signature   solana.Signature
+	From        gossip.Pubkey // node which propagated the vote
+	Wallclock   uint64
+}
+
+// ParseVote parses a vote transaction, of which the first instruction
+// must be a vote on a slot.
+//
+// Based on solana_vote::vote_parser::parse_vote_transaction.
+func ParseVote(tx *gossip.Transaction) (Vote, bool) {
+	msg := &tx.Message
+	if len(tx.Signatures) == 0 || len(msg.Instructions) == 0 {
+		return Vote{}, false
+	}
+	instr := &msg.Instructions[0]
+	if int(instr.ProgramIDIndex) >= len(msg.AccountKeys) ||
+		msg.AccountKeys[instr.ProgramIDIndex] != solana.PublicKey(sealevel.VoteProgramAddr) {
+		return Vote{}, false
+	}
+	if len(instr.Accounts) == 0 || int(instr.Accounts[0]) >= len(msg.AccountKeys) {
+		return Vote{}, false
+	}
+	slot, hash, ok := sealevel.VotedBankHash(instr.Data)
+	if !ok {
+		return Vote{}, false
+	}
+	return Vote{
+		VoteAccount: msg.AccountKeys[instr.Accounts[0]],
+		Slot:        slot,
+		Hash:        hash,
+		Signature:   tx.Signatures[0],
+	}, true
+}
+
+// Listener streams the votes inserted into a CRDS table.
+//
+// Votes are only passed on if their transaction is validly signed and,
+// with stakes, signed by the authorized voter of the vote account in the
+// epoch of the slot voted on.
+//
+// Based on solana_core::cluster_info_vote_listener::ClusterInfoVoteListener.
+type Listener struct {
+	table   *gossip.CrdsTable
+	stakes  *Stakes
+	ordinal uint64
+
+	NumVotes   atomic.Uint64
+	NumInvalid atomic.Uint64 // not votes, or not signed by the voter
+}
+
+// NewListener returns a listener of the votes of the table, starting with
+// those it holds. Without stakes, the voters are not checked.
+func NewListener(table *gossip.CrdsTable, stakes *Stakes) *Listener {
+	return &Listener{table: table, stakes: stakes}
+}
+
+// Poll returns the votes inserted since the last poll, in order of
+// insertion.
+func (l *Listener) Poll() []Vote {
+	entries, ordinal := l.table.EntriesSince(l.ordinal)
+	l.ordinal = ordinal
+	var votes []Vote
+	for _, entry := range entries {
+		data, ok := entry.Value.Data.(*gossip.CrdsData__Vote)
+		if !ok {
+			continue
+		}
+		tx := &data.Field1.Transaction
+		vote, ok := ParseVote(tx)
+		if !ok || !l.verify(tx, &vote) {
+			l.NumInvalid.Add(1)
+			continue
+		}
+		vote.From = data.Field1.From
+		vote.Wallclock = data.Field1.Wallclock
+		votes = append(votes, vote)
+	}
+	l.NumVotes.Add(uint64(len(votes)))
+	return votes
+}
+
+// verify checks the signatures of a vote transaction, and the signer of
+// the vote.
+//
+// Based on solana_core::cluster_info_vote_listener::ClusterInfoVoteListener::verify_votes.
+func (l *Listener) verify(tx *gossip.Transaction, vote *Vote) bool {
+	if sealevel.VerifyTransactionSignatures((*solana.Transaction)(tx)) != nil {
+		return false
+	}
+	if l.stakes == nil {
+		return true
+	}
+	voter, ok := l.stakes.AuthorizedVoter(vote.Slot, vote.VoteAccount)
+	if !ok {
+		return false
+	}
+	for _, signer := range tx.Message.AccountKeys[:tx.Message.Header.NumRequiredSignatures] {
+		if signer == voter {
+			return true
+		}
+	}
+	return false
+}
+
+// Run polls the table at the given interval, sending the votes to out,
+// until the context is done.
+func (l *Listener) Run(ctx context.Context, interval time.Duration, out chan<- Vote) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, vote := range l.Poll() {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- vote:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package votelistener
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/gossip"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+// voteTx returns a vote transaction on a slot signed by the voter.
+func voteTx(t *testing.T, voter ed25519.PrivateKey, voteAccount solana.PublicKey, slot uint64, hash [32]byte) gossip.Transaction {
+	data := binary.LittleEndian.AppendUint32(nil, sealevel.VoteProgramInstrTypeVote)
+	data = binary.LittleEndian.AppendUint64(data, 1)
+	data = binary.LittleEndian.AppendUint64(data, slot)
+	data = append(data, hash[:]...)
+	data = append(data, 0) // no timestamp
+
+	tx := gossip.Transaction{Message: solana.Message{
+		Header: solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+		AccountKeys: []solana.PublicKey{
+			solana.PublicKeyFromBytes(voter.Public().(ed25519.PublicKey)),
+			voteAccount,
+			sealevel.VoteProgramAddr,
+		},
+		Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 2, Accounts: []uint16{1, 0}, Data: data}},
+	}}
+	msg, err := tx.Message.MarshalBinary()
+	require.NoError(t, err)
+	tx.Signatures = []solana.Signature{solana.SignatureFromBytes(ed25519.Sign(voter, msg))}
+	return tx
+}
+
+func newVoter(t *testing.T) (ed25519.PrivateKey, solana.PublicKey) {
+	pub, voter, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return voter, solana.PublicKeyFromBytes(pub)
+}
+
+func TestParseVote(t *testing.T) {
+	voter, _ := newVoter(t)
+	tx := voteTx(t, voter, solana.PublicKey{1}, 100, [32]byte{2})
+	vote, ok := ParseVote(&tx)
+	require.True(t, ok)
+	assert.Equal(t, Vote{VoteAccount: solana.PublicKey{1}, Slot: 100, Hash: [32]byte{2}, Signature: tx.Signatures[0]}, vote)
+
+	tx.Message.AccountKeys[2] = solana.PublicKey{3}
+	_, ok = ParseVote(&tx)
+	assert.False(t, ok, "not the vote program")
+}
+
+func TestListener(t *testing.T) {
+	schedule := sealevel.SysvarEpochSchedule{SlotsPerEpoch: 1000}
+	voter, voterPubkey := newVoter(t)
+	other, _ := newVoter(t)
+	stakes := NewStakes(schedule, []snapshot.EpochStakes{{
+		Epoch:                 0,
+		EpochAuthorizedVoters: []snapshot.PubkeyAndPubkey{{Key: [32]byte{1}, Value: voterPubkey}},
+	}})
+
+	table := gossip.NewCrdsTable()
+	listener := NewListener(table, stakes)
+	insert := func(index uint8, tx gossip.Transaction) {
+		_, err := table.Insert(gossip.CrdsValue{Data: &gossip.CrdsData__Vote{Field0: index, Field1: gossip.Vote{
+			From:        gossip.Pubkey{9},
+			Transaction: tx,
+			Wallclock:   uint64(index),
+		}}}, time.Now())
+		require.NoError(t, err)
+	}
+	insert(0, voteTx(t, voter, solana.PublicKey{1}, 100, [32]byte{2}))
+	insert(1, voteTx(t, other, solana.PublicKey{1}, 101, [32]byte{3}))  // not the authorized voter
+	insert(2, voteTx(t, voter, solana.PublicKey{1}, 2000, [32]byte{4})) // unknown epoch
+	tampered := voteTx(t, voter, solana.PublicKey{1}, 102, [32]byte{5})
+	tampered.Message.Instructions[0].Data[12] = 103
+	insert(3, tampered)
+
+	votes := listener.Poll()
+	require.Len(t, votes, 1)
+	assert.Equal(t, uint64(100), votes[0].Slot)
+	assert.Equal(t, gossip.Pubkey{9}, votes[0].From)
+	assert.Equal(t, uint64(0), votes[0].Wallclock)
+	assert.Equal(t, uint64(3), listener.NumInvalid.Load())
+	assert.Empty(t, listener.Poll())
+
+	// without stakes, any validly signed vote passes
+	listener = NewListener(table, nil)
+	assert.Len(t, listener.Poll(), 3)
+}
+
+func TestTracker(t *testing.T) {
+	schedule := sealevel.SysvarEpochSchedule{SlotsPerEpoch: 1000}
+	stakes := NewStakes(schedule, []snapshot.EpochStakes{{
+		Epoch:      0,
+		TotalStake: 100,
+		Stakes: snapshot.Stakes{VoteAccounts: []snapshot.VoteAccount{
+			{Pubkey: [32]byte{1}, Stake: 40},
+			{Pubkey: [32]byte{2}, Stake: 30},
+			{Pubkey: [32]byte{3}, Stake: 30},
+		}},
+	}})
+	tracker := NewTracker(stakes)
+	vote := func(account byte, slot uint64, hash byte) (Tally, bool) {
+		return tracker.Add(Vote{VoteAccount: solana.PublicKey{account}, Slot: slot, Hash: [32]byte{hash}})
+	}
+
+	_, ok := vote(1, 10, 1)
+	assert.False(t, ok)
+	_, ok = vote(1, 10, 1)
+	assert.False(t, ok, "counted once")
+	_, ok = vote(3, 10, 2)
+	assert.False(t, ok, "other hash")
+	_, ok = vote(2, 10, 2)
+	assert.False(t, ok, "100% split on two hashes")
+	_, ok = vote(1, 1000, 1)
+	assert.False(t, ok, "unknown epoch")
+	_, ok = vote(3, 11, 1)
+	assert.False(t, ok)
+	_, ok = vote(2, 11, 1)
+	assert.False(t, ok, "60% is below the threshold")
+	tally, ok := vote(1, 11, 1)
+	require.True(t, ok)
+	assert.Equal(t, Tally{Slot: 11, Hash: [32]byte{1}, Stake: 100, TotalStake: 100, NumVotes: 3}, tally)
+
+	assert.Equal(t, []Tally{
+		{Slot: 10, Hash: [32]byte{2}, Stake: 60, TotalStake: 100, NumVotes: 2},
+		{Slot: 10, Hash: [32]byte{1}, Stake: 40, TotalStake: 100, NumVotes: 1},
+	}, tracker.Tallies(10))
+	highest, ok := tracker.HighestConfirmed()
+	require.True(t, ok)
+	assert.Equal(t, uint64(11), highest.Slot)
+
+	tracker.SetRoot(11)
+	assert.Empty(t, tracker.Tallies(10))
+	_, ok = tracker.HighestConfirmed()
+	assert.False(t, ok)
+	_, ok = vote(3, 11, 3)
+	assert.False(t, ok, "rooted")
+}